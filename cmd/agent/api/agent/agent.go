@@ -12,8 +12,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"io"
 	"net/http"
 	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -40,21 +42,28 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/version", common.GetVersion).Methods("GET")
 	r.HandleFunc("/hostname", getHostname).Methods("GET")
 	r.HandleFunc("/flare", makeFlare).Methods("POST")
+	r.HandleFunc("/profile", startProfile).Methods("POST")
+	r.HandleFunc("/profile", getProfile).Methods("GET")
 	r.HandleFunc("/stop", stopAgent).Methods("POST")
 	r.HandleFunc("/status", getStatus).Methods("GET")
 	r.HandleFunc("/dogstatsd-stats", getDogstatsdStats).Methods("GET")
 	r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
+	r.HandleFunc("/status/structured", getStructuredStatus).Methods("GET")
 	r.HandleFunc("/status/health", getHealth).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusGetterHandler).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusHandler).Methods("POST")
 	r.HandleFunc("/{component}/configs", componentConfigHandler).Methods("GET")
 	r.HandleFunc("/gui/csrf-token", getCSRFToken).Methods("GET")
 	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
+	r.HandleFunc("/config-check/resolution", getConfigResolution).Methods("GET")
 	r.HandleFunc("/config", getFullRuntimeConfig).Methods("GET")
 	r.HandleFunc("/config/list-runtime", getRuntimeConfigurableSettings).Methods("GET")
+	r.HandleFunc("/config/reload", getReloadReport).Methods("GET")
+	r.HandleFunc("/config/reload", reloadConfig).Methods("POST")
 	r.HandleFunc("/config/{setting}", getRuntimeConfig).Methods("GET")
 	r.HandleFunc("/config/{setting}", setRuntimeConfig).Methods("POST")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
+	r.HandleFunc("/tagger-state", getTaggerState).Methods("GET")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
 }
 
@@ -82,8 +91,35 @@ func makeFlare(w http.ResponseWriter, r *http.Request) {
 		logFile = common.DefaultLogFile
 	}
 
-	log.Infof("Making a flare")
-	filePath, err := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, logFile)
+	// the profile is optional, the standard one is used by default
+	var options struct {
+		Profile                    string `json:"profile"`
+		IncludePerformanceProfiles bool   `json:"include_performance_profiles"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil && err != io.EOF {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+	profile, err := flare.ParseProfile(options.Profile)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	// the performance profiles are the ones of the last `agent profile`
+	var perfProfiles flare.PerformanceProfiles
+	if options.IncludePerformanceProfiles {
+		perfProfiles = lastPerformanceProfiles()
+		if perfProfiles == nil {
+			http.Error(w, "no performance profiles were collected", 400)
+			return
+		}
+	}
+
+	log.Infof("Making a flare with the %s profile", profile)
+	filePath, err := flare.CreateArchiveWithPerformanceProfiles(false, profile, common.GetDistPath(), common.PyChecksPath, logFile, perfProfiles)
 	if err != nil || filePath == "" {
 		if err != nil {
 			log.Errorf("The flare failed to be created: %s", err)
@@ -156,6 +192,20 @@ func getStatus(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonStats)
 }
 
+func getStructuredStatus(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for the structured status. Making structured status.")
+	w.Header().Set("Content-Type", "application/json")
+	report, err := json.Marshal(status.GetReport())
+	if err != nil {
+		log.Errorf("Error marshalling structured status. Error: %v", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Write(report)
+}
+
 func getDogstatsdStats(w http.ResponseWriter, r *http.Request) {
 	log.Info("Got a request for the Dogstatsd stats.")
 
@@ -170,17 +220,6 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !config.Datadog.GetBool("dogstatsd_metrics_stats_enable") {
-		w.Header().Set("Content-Type", "application/json")
-		body, _ := json.Marshal(map[string]string{
-			"error":      "Dogstatsd metrics stats not enabled in the Agent configuration",
-			"error_type": "not enabled",
-		})
-		w.WriteHeader(400)
-		w.Write(body)
-		return
-	}
-
 	// Weird state that should not happen: dogstatsd is enabled
 	// but the server has not been successfully initialized.
 	// Return no data.
@@ -190,6 +229,17 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !common.DSD.IsMetricsStatsEnabled() {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd metrics stats not enabled, run `agent config set dogstatsd_stats true` to enable them",
+			"error_type": "not enabled",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
 	jsonStats, err := common.DSD.GetJSONDebugStats()
 	if err != nil {
 		log.Errorf("Error getting marshalled Dogstatsd stats: %s", err)
@@ -271,6 +321,28 @@ func getConfigCheck(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonConfig)
 }
 
+func getConfigResolution(w http.ResponseWriter, r *http.Request) {
+	if common.AC == nil {
+		log.Errorf("Trying to use /config-check/resolution before the agent has been initialized.")
+		body, _ := json.Marshal(map[string]string{"error": "agent not initialized"})
+		http.Error(w, string(body), 503)
+		return
+	}
+
+	response := response.ConfigResolutionResponse{
+		Templates: common.AC.ExplainResolution(),
+	}
+	jsonResolution, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Unable to marshal config resolution response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Write(jsonResolution)
+}
+
 func getFullRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	runtimeConfig, err := yaml.Marshal(config.Datadog.AllSettings())
 	if err != nil {
@@ -297,6 +369,11 @@ func getRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	log.Infof("Got a request to read a setting value: %s", setting)
 
 	val, err := config.GetRuntimeSetting(setting)
+	if _, notFound := err.(*config.SettingNotFoundError); notFound && config.Datadog.IsSet(setting) {
+		// the other configuration keys are reported with the layers setting them
+		getConfigKey(w, setting)
+		return
+	}
 	if err != nil {
 		body, _ := json.Marshal(map[string]string{"error": err.Error()})
 		switch err.(type) {
@@ -307,7 +384,7 @@ func getRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	body, err := json.Marshal(map[string]interface{}{"value": val})
+	body, err := json.Marshal(map[string]interface{}{"value": val, "source": config.Datadog.GetSource(setting)})
 	if err != nil {
 		log.Errorf("Unable to marshal runtime setting value response: %s", err)
 		body, _ := json.Marshal(map[string]string{"error": err.Error()})
@@ -317,6 +394,48 @@ func getRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 }
 
+// getConfigKey writes the effective value of a configuration key, and its value in each
+// layer setting it, scrubbed
+func getConfigKey(w http.ResponseWriter, key string) {
+	sources := config.Datadog.GetSources(key)
+	for i := range sources {
+		sources[i].Value = scrubbedValue(key, sources[i].Value)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"value":   scrubbedValue(key, config.Datadog.Get(key)),
+		"source":  config.Datadog.GetSource(key),
+		"sources": sources,
+	})
+	if err != nil {
+		log.Errorf("Unable to marshal the configuration value response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(body)
+}
+
+// scrubbedValue renders the value of a configuration key in YAML, with its credentials scrubbed
+func scrubbedValue(key string, value interface{}) string {
+	raw, err := yaml.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return fmt.Sprintf("unable to marshal the value: %v", err)
+	}
+	scrubbed, err := log.CredentialsCleanerBytes(raw)
+	if err != nil {
+		return fmt.Sprintf("unable to scrub the value: %v", err)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(scrubbed, &values); err != nil {
+		return fmt.Sprintf("unable to unmarshal the scrubbed value: %v", err)
+	}
+	rendered, err := yaml.Marshal(values[key])
+	if err != nil {
+		return fmt.Sprintf("unable to marshal the value: %v", err)
+	}
+	return strings.TrimSpace(string(rendered))
+}
+
 func setRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	setting := vars["setting"]
@@ -351,11 +470,47 @@ func getRuntimeConfigurableSettings(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 }
 
+// getReloadReport writes the report of the last reload of the configuration
+func getReloadReport(w http.ResponseWriter, r *http.Request) {
+	report := config.LastReloadReport()
+	if report == nil {
+		body, _ := json.Marshal(map[string]string{"error": "the configuration wasn't reloaded since the agent started"})
+		http.Error(w, string(body), 404)
+		return
+	}
+	writeReloadReport(w, *report)
+}
+
+func reloadConfig(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request to reload the configuration")
+	writeReloadReport(w, config.Reload())
+}
+
+func writeReloadReport(w http.ResponseWriter, report config.ReloadReport) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		log.Errorf("Unable to marshal the reload report: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(body)
+}
+
 func getTaggerList(w http.ResponseWriter, r *http.Request) {
 	// query at the highest cardinality between checks and dogstatsd cardinalities
 	cardinality := collectors.TagCardinality(max(int(tagger.ChecksCardinality), int(tagger.DogstatsdCardinality)))
 	response := tagger.List(cardinality)
 
+	// only list the deleted entities kept until their deletion grace period expires
+	if r.URL.Query().Get("stale") == "true" {
+		for entity, e := range response.Entities {
+			if e.ExpiresAt == nil {
+				delete(response.Entities, entity)
+			}
+		}
+	}
+
 	jsonTags, err := json.Marshal(response)
 	if err != nil {
 		log.Errorf("Unable to marshal tagger list response: %s", err)
@@ -366,6 +521,25 @@ func getTaggerList(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonTags)
 }
 
+func getTaggerState(w http.ResponseWriter, r *http.Request) {
+	state, err := tagger.ExportState()
+	if err != nil {
+		log.Errorf("Unable to export the tagger state: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	jsonState, err := json.Marshal(state)
+	if err != nil {
+		log.Errorf("Unable to marshal tagger state response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(jsonState)
+}
+
 func secretInfo(w http.ResponseWriter, r *http.Request) {
 	info, err := secrets.GetDebugInfo()
 	if err != nil {
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultProfileDuration = 30 * time.Second
+	maxProfileDuration     = 5 * time.Minute
+	// profileMutexFraction is the rate of the mutex contention events sampled
+	// while the agent is profiled
+	profileMutexFraction = 5
+)
+
+// ProfileStatus is the state of the collection of the performance profiles
+type ProfileStatus struct {
+	Running bool   `json:"running"`
+	Archive string `json:"archive,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// profileState holds the status and the profiles of the last collection, the
+// profiles are kept to be attached to a flare
+var profileState struct {
+	sync.Mutex
+	status   ProfileStatus
+	profiles flare.PerformanceProfiles
+}
+
+// startProfile collects the profiles in the background: the CPU profile lasts
+// longer than the timeout of the IPC server
+func startProfile(w http.ResponseWriter, r *http.Request) {
+	var options struct {
+		Duration string `json:"duration"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil && err != io.EOF {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+	duration, err := parseProfileDuration(options.Duration)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	profileState.Lock()
+	if profileState.status.Running {
+		profileState.Unlock()
+		http.Error(w, "the agent is already being profiled", 409)
+		return
+	}
+	profileState.status = ProfileStatus{Running: true}
+	profileState.profiles = nil
+	profileState.Unlock()
+
+	log.Infof("Profiling the agent processes for %s", duration)
+	go collectProfiles(duration)
+
+	w.WriteHeader(http.StatusAccepted)
+	writeProfileStatus(w)
+}
+
+func getProfile(w http.ResponseWriter, r *http.Request) {
+	writeProfileStatus(w)
+}
+
+func writeProfileStatus(w http.ResponseWriter) {
+	profileState.Lock()
+	status := profileState.status
+	profileState.Unlock()
+
+	body, err := json.Marshal(status)
+	if err != nil {
+		log.Errorf("Unable to marshal the profile status: %s", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Write(body)
+}
+
+func collectProfiles(duration time.Duration) {
+	// the mutex profile is empty unless the contention events are sampled
+	previousFraction := runtime.SetMutexProfileFraction(profileMutexFraction)
+	profiles := flare.CollectPerformanceProfiles(duration)
+	runtime.SetMutexProfileFraction(previousFraction)
+
+	status := ProfileStatus{}
+	archive, err := flare.CreatePerformanceProfileArchive(profiles)
+	if err != nil {
+		log.Errorf("The profile archive failed to be created: %s", err)
+		status.Error = err.Error()
+	} else {
+		log.Infof("The profiles of the agent processes are in %s", archive)
+		status.Archive = archive
+	}
+
+	profileState.Lock()
+	profileState.status = status
+	profileState.profiles = profiles
+	profileState.Unlock()
+}
+
+// lastPerformanceProfiles returns the profiles of the last collection, nil
+// while they're being collected
+func lastPerformanceProfiles() flare.PerformanceProfiles {
+	profileState.Lock()
+	defer profileState.Unlock()
+	return profileState.profiles
+}
+
+func parseProfileDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultProfileDuration, nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid profile duration %q: %s", s, err)
+	}
+	if duration < time.Second || duration > maxProfileDuration {
+		return 0, fmt.Errorf("the profile duration must be between 1s and %s", maxProfileDuration)
+	}
+	return duration, nil
+}
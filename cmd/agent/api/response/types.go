@@ -6,6 +6,8 @@
 package response
 
 import (
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 )
 
@@ -17,6 +19,11 @@ type ConfigCheckResponse struct {
 	Unresolved      map[string][]integration.Config `json:"unresolved"`
 }
 
+// ConfigResolutionResponse holds the explanation of the resolution of the templates
+type ConfigResolutionResponse struct {
+	Templates []integration.TemplateResolution `json:"templates"`
+}
+
 // TaggerListResponse holds the tagger list response
 type TaggerListResponse struct {
 	Entities map[string]TaggerListEntity `json:"entities"`
@@ -26,4 +33,7 @@ type TaggerListResponse struct {
 type TaggerListEntity struct {
 	Sources []string `json:"sources"`
 	Tags    []string `json:"tags"`
+	// set for the deleted entities kept until their deletion grace period expires
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
@@ -25,11 +25,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger/remote"
 	"github.com/gorilla/mux"
 )
 
 var (
-	listener net.Listener
+	listener     net.Listener
+	taggerServer *remote.Server
 )
 
 // StartServer creates the router and starts the HTTP server
@@ -40,6 +42,8 @@ func StartServer() error {
 	// IPC REST API server
 	agent.SetupHandlers(r.PathPrefix("/agent").Subrouter())
 	check.SetupHandlers(r.PathPrefix("/check").Subrouter())
+	// the liveness and readiness probes are served on health_port, not here:
+	// the probes can't present the auth token of the IPC server
 
 	// Validate token for every request
 	r.Use(validateToken)
@@ -90,6 +94,18 @@ func StartServer() error {
 	tlsListener := tls.NewListener(listener, &tlsConfig)
 
 	go srv.Serve(tlsListener)
+
+	if config.Datadog.GetBool("remote_tagger.enabled") {
+		// the remote tagger clients verify the server with the saved certificate
+		if err := security.SaveIPCCert(rootCertPEM); err != nil {
+			return fmt.Errorf("Unable to save the IPC certificate: %v", err)
+		}
+		taggerServer, err = remote.NewServer(rootTLSCert)
+		if err != nil {
+			return fmt.Errorf("Unable to create the remote tagger server: %v", err)
+		}
+		taggerServer.Start()
+	}
 	return nil
 }
 
@@ -99,6 +115,9 @@ func StopServer() {
 	if listener != nil {
 		listener.Close()
 	}
+	if taggerServer != nil {
+		taggerServer.Stop()
+	}
 }
 
 // ServerAddress retruns the server address.
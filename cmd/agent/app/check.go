@@ -37,7 +37,8 @@ import (
 var (
 	checkRate            bool
 	checkTimes           int
-	checkPause           int
+	checkRepeat          int
+	checkPause           pauseValue
 	checkName            string
 	checkDelay           int
 	logLevel             string
@@ -62,12 +63,13 @@ func init() {
 
 	checkCmd.Flags().BoolVarP(&checkRate, "check-rate", "r", false, "check rates by running the check twice with a 1sec-pause between the 2 runs")
 	checkCmd.Flags().IntVarP(&checkTimes, "check-times", "t", 1, "number of times to run the check")
-	checkCmd.Flags().IntVar(&checkPause, "pause", 0, "pause between multiple runs of the check, in milliseconds")
+	checkCmd.Flags().IntVar(&checkRepeat, "repeat", 1, "number of times to repeat the check, with the results of each repetition and a summary of their differences")
+	checkCmd.Flags().Var(&checkPause, "pause", "pause between multiple runs of the check, in milliseconds or as a duration, e.g. 2s")
 	checkCmd.Flags().StringVarP(&logLevel, "log-level", "l", "", "set the log level (default 'off') (deprecated, use the env var DD_LOG_LEVEL instead)")
 	checkCmd.Flags().IntVarP(&checkDelay, "delay", "d", 100, "delay between running the check and grabbing the metrics in milliseconds")
 	checkCmd.Flags().BoolVarP(&formatJSON, "json", "", false, "format aggregator and check runner output as json")
 	checkCmd.Flags().StringVarP(&breakPoint, "breakpoint", "b", "", "set a breakpoint at a particular line number (Python checks only)")
-	checkCmd.Flags().BoolVarP(&profileMemory, "profile-memory", "m", false, "run the memory profiler (Python checks only)")
+	checkCmd.Flags().BoolVarP(&profileMemory, "profile-memory", "m", false, "run the memory profiler, with the diffs of the allocations between the runs")
 	checkCmd.Flags().BoolVar(&fullSketches, "full-sketches", false, "output sketches with bins information")
 	config.Datadog.BindPFlag("cmd.check.fullsketches", checkCmd.Flags().Lookup("full-sketches"))
 
@@ -275,95 +277,93 @@ var checkCmd = &cobra.Command{
 			fmt.Println("Multiple check instances found, running each of them")
 		}
 
+		if checkRepeat < 1 {
+			return fmt.Errorf("--repeat must be at least 1")
+		}
+
 		var instancesData []interface{}
 		for _, c := range cs {
-			s := runCheck(c, agg)
-
-			// Sleep for a while to allow the aggregator to finish ingesting all the metrics/events/sc
-			time.Sleep(time.Duration(checkDelay) * time.Millisecond)
-
-			if formatJSON {
-				aggregatorData := getMetricsData(agg)
-				var collectorData map[string]interface{}
+			var runs []repeatRun
+			for i := 0; i < checkRepeat; i++ {
+				if checkRepeat > 1 && !formatJSON {
+					fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Run %d/%d of %s", i+1, checkRepeat, c.ID())))
+				}
 
-				collectorJSON, _ := status.GetCheckStatusJSON(c, s)
-				err = json.Unmarshal(collectorJSON, &collectorData)
-				if err != nil {
-					return err
+				// The allocations of the Go checks are tracked by the Go runtime,
+				// the Python checks track theirs with the profile_memory option
+				var memoryDiff *goMemoryDiff
+				var before runtime.MemStats
+				profileGo := profileMemory && isGoCheck(c)
+				if profileGo {
+					before = readMemStats()
+				}
+				s := runCheck(c, agg)
+				if profileGo {
+					after := readMemStats()
+					diff := diffMemStats(&before, &after)
+					memoryDiff = &diff
 				}
 
-				checkRuns := collectorData["runnerStats"].(map[string]interface{})["Checks"].(map[string]interface{})[checkName].(map[string]interface{})
+				// Sleep for a while to allow the aggregator to finish ingesting all the metrics/events/sc
+				time.Sleep(time.Duration(checkDelay) * time.Millisecond)
 
-				// There is only one checkID per run so we'll just access that
-				var runnerData map[string]interface{}
-				for _, checkIDData := range checkRuns {
-					runnerData = checkIDData.(map[string]interface{})
-					break
-				}
+				run := repeatRun{errors: s.TotalErrors, warnings: s.TotalWarnings, memory: memoryDiff}
+				if formatJSON {
+					aggregatorData, metricNames := getMetricsData(agg)
+					run.metrics = metricNames
+					var collectorData map[string]interface{}
 
-				instanceData := map[string]interface{}{
-					"aggregator":  aggregatorData,
-					"runner":      runnerData,
-					"inventories": collectorData["inventories"],
-				}
-				instancesData = append(instancesData, instanceData)
-			} else if profileMemory {
-				// Every instance will create its own directory
-				instanceID := strings.SplitN(string(c.ID()), ":", 2)[1]
-				// Colons can't be part of Windows file paths
-				instanceID = strings.Replace(instanceID, ":", "_", -1)
-				profileDataDir := filepath.Join(profileMemoryDir, checkName, instanceID)
-
-				snapshotDir := filepath.Join(profileDataDir, "snapshots")
-				if _, err := os.Stat(snapshotDir); !os.IsNotExist(err) {
-					snapshots, err := ioutil.ReadDir(snapshotDir)
+					collectorJSON, _ := status.GetCheckStatusJSON(c, s)
+					err = json.Unmarshal(collectorJSON, &collectorData)
 					if err != nil {
 						return err
 					}
 
-					numSnapshots := len(snapshots)
-					if numSnapshots > 0 {
-						lastSnapshot := snapshots[numSnapshots-1]
-						snapshotContents, err := ioutil.ReadFile(filepath.Join(snapshotDir, lastSnapshot.Name()))
-						if err != nil {
-							return err
-						}
-
-						color.HiWhite(string(snapshotContents))
-					} else {
-						return fmt.Errorf("no snapshots found in %s", snapshotDir)
+					checkRuns := collectorData["runnerStats"].(map[string]interface{})["Checks"].(map[string]interface{})[checkName].(map[string]interface{})
+
+					// There is only one checkID per run so we'll just access that
+					var runnerData map[string]interface{}
+					for _, checkIDData := range checkRuns {
+						runnerData = checkIDData.(map[string]interface{})
+						break
 					}
-				} else {
-					return fmt.Errorf("no snapshot data found in %s", profileDataDir)
-				}
 
-				diffDir := filepath.Join(profileDataDir, "diffs")
-				if _, err := os.Stat(diffDir); !os.IsNotExist(err) {
-					diffs, err := ioutil.ReadDir(diffDir)
-					if err != nil {
+					instanceData := map[string]interface{}{
+						"aggregator":  aggregatorData,
+						"runner":      runnerData,
+						"inventories": collectorData["inventories"],
+					}
+					if checkRepeat > 1 {
+						instanceData["run"] = i + 1
+					}
+					if memoryDiff != nil {
+						instanceData["go_memory"] = memoryDiff
+					}
+					instancesData = append(instancesData, instanceData)
+				} else if profileGo {
+					fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Go allocations")))
+					fmt.Println(memoryDiff.String())
+					fmt.Println("")
+				} else if profileMemory {
+					// The diffs are made from the second run of the instance
+					expectDiff := i > 0 || checkTimes > 1 || checkRate
+					if err := printPythonMemoryProfile(c, expectDiff); err != nil {
 						return err
 					}
+				} else {
+					run.metrics = printMetrics(agg)
+					checkStatus, _ := status.GetCheckStatus(c, s)
+					fmt.Println(string(checkStatus))
+				}
+				runs = append(runs, run)
 
-					numDiffs := len(diffs)
-					if numDiffs > 0 {
-						lastDiff := diffs[numDiffs-1]
-						diffContents, err := ioutil.ReadFile(filepath.Join(diffDir, lastDiff.Name()))
-						if err != nil {
-							return err
-						}
-
-						color.HiCyan(fmt.Sprintf("\n%s\n\n", strings.Repeat("=", 50)))
-						color.HiWhite(string(diffContents))
-					} else {
-						return fmt.Errorf("no diffs found in %s", diffDir)
-					}
-				} else if !singleCheckRun() {
-					return fmt.Errorf("no diff data found in %s", profileDataDir)
+				if checkPause > 0 && i < checkRepeat-1 {
+					time.Sleep(time.Duration(checkPause))
 				}
-			} else {
-				printMetrics(agg)
-				checkStatus, _ := status.GetCheckStatus(c, s)
-				fmt.Println(string(checkStatus))
+			}
+
+			if checkRepeat > 1 && !formatJSON {
+				printRepeatSummary(color.Output, c.ID(), runs)
 			}
 		}
 
@@ -377,7 +377,7 @@ var checkCmd = &cobra.Command{
 			fmt.Println(string(instancesJSON))
 		} else if singleCheckRun() {
 			if profileMemory {
-				color.Yellow("Check has run only once, to collect diff data run the check multiple times with the -t/--check-times flag.")
+				color.Yellow("Check has run only once, to collect diff data run the check multiple times with the -t/--check-times or --repeat flag.")
 			} else {
 				color.Yellow("Check has run only once, if some metrics are missing you can try again with --check-rate to see any other metric if available.")
 			}
@@ -390,7 +390,7 @@ var checkCmd = &cobra.Command{
 func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
 	s := check.NewStats(c)
 	times := checkTimes
-	pause := checkPause
+	pause := time.Duration(checkPause)
 	if checkRate {
 		if checkTimes > 2 {
 			color.Yellow("The check-rate option is overriding check-times to 2")
@@ -399,7 +399,7 @@ func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
 			color.Yellow("The check-rate option is overriding pause to 1000ms")
 		}
 		times = 2
-		pause = 1000
+		pause = time.Second
 	}
 	for i := 0; i < times; i++ {
 		t0 := time.Now()
@@ -408,14 +408,15 @@ func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
 		mStats, _ := c.GetMetricStats()
 		s.Add(time.Since(t0), err, warnings, mStats)
 		if pause > 0 && i < times-1 {
-			time.Sleep(time.Duration(pause) * time.Millisecond)
+			time.Sleep(pause)
 		}
 	}
 
 	return s
 }
 
-func printMetrics(agg *aggregator.BufferedAggregator) {
+// printMetrics outputs the data of the aggregator, and returns the names of the metrics
+func printMetrics(agg *aggregator.BufferedAggregator) []string {
 	series, sketches := agg.GetSeriesAndSketches()
 	if len(series) != 0 {
 		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Series")))
@@ -441,9 +442,12 @@ func printMetrics(agg *aggregator.BufferedAggregator) {
 		j, _ := json.MarshalIndent(events, "", "  ")
 		fmt.Println(string(j))
 	}
+
+	return seriesNames(series, sketches)
 }
 
-func getMetricsData(agg *aggregator.BufferedAggregator) map[string]interface{} {
+// getMetricsData returns the data of the aggregator, and the names of the metrics
+func getMetricsData(agg *aggregator.BufferedAggregator) (map[string]interface{}, []string) {
 	aggData := make(map[string]interface{})
 
 	series, sketches := agg.GetSeriesAndSketches()
@@ -470,11 +474,69 @@ func getMetricsData(agg *aggregator.BufferedAggregator) map[string]interface{} {
 		aggData["events"] = events
 	}
 
-	return aggData
+	return aggData, seriesNames(series, sketches)
+}
+
+// printPythonMemoryProfile outputs the last snapshot and the last diff of the
+// allocations tracked by a Python check
+func printPythonMemoryProfile(c check.Check, expectDiff bool) error {
+	// Every instance will create its own directory
+	instanceID := strings.SplitN(string(c.ID()), ":", 2)[1]
+	// Colons can't be part of Windows file paths
+	instanceID = strings.Replace(instanceID, ":", "_", -1)
+	profileDataDir := filepath.Join(profileMemoryDir, checkName, instanceID)
+
+	snapshotDir := filepath.Join(profileDataDir, "snapshots")
+	if _, err := os.Stat(snapshotDir); !os.IsNotExist(err) {
+		snapshots, err := ioutil.ReadDir(snapshotDir)
+		if err != nil {
+			return err
+		}
+
+		numSnapshots := len(snapshots)
+		if numSnapshots > 0 {
+			lastSnapshot := snapshots[numSnapshots-1]
+			snapshotContents, err := ioutil.ReadFile(filepath.Join(snapshotDir, lastSnapshot.Name()))
+			if err != nil {
+				return err
+			}
+
+			color.HiWhite(string(snapshotContents))
+		} else {
+			return fmt.Errorf("no snapshots found in %s", snapshotDir)
+		}
+	} else {
+		return fmt.Errorf("no snapshot data found in %s", profileDataDir)
+	}
+
+	diffDir := filepath.Join(profileDataDir, "diffs")
+	if _, err := os.Stat(diffDir); !os.IsNotExist(err) {
+		diffs, err := ioutil.ReadDir(diffDir)
+		if err != nil {
+			return err
+		}
+
+		numDiffs := len(diffs)
+		if numDiffs > 0 {
+			lastDiff := diffs[numDiffs-1]
+			diffContents, err := ioutil.ReadFile(filepath.Join(diffDir, lastDiff.Name()))
+			if err != nil {
+				return err
+			}
+
+			color.HiCyan(fmt.Sprintf("\n%s\n\n", strings.Repeat("=", 50)))
+			color.HiWhite(string(diffContents))
+		} else {
+			return fmt.Errorf("no diffs found in %s", diffDir)
+		}
+	} else if expectDiff {
+		return fmt.Errorf("no diff data found in %s", profileDataDir)
+	}
+	return nil
 }
 
 func singleCheckRun() bool {
-	return checkRate == false && checkTimes < 2
+	return checkRate == false && checkTimes < 2 && checkRepeat < 2
 }
 
 func createHiddenStringFlag(p *string, name string, value string, usage string) {
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// pauseValue is the pause between the runs of the check, as a number of
+// milliseconds like before, or as a duration, e.g. 2s
+type pauseValue time.Duration
+
+func (p *pauseValue) String() string {
+	return time.Duration(*p).String()
+}
+
+func (p *pauseValue) Set(s string) error {
+	if ms, err := strconv.Atoi(s); err == nil {
+		*p = pauseValue(time.Duration(ms) * time.Millisecond)
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("the pause must be a number of milliseconds or a duration, e.g. 2s")
+	}
+	*p = pauseValue(d)
+	return nil
+}
+
+func (p *pauseValue) Type() string {
+	return "duration"
+}
+
+// goCheck is implemented by the Go checks, through their CheckBase
+type goCheck interface {
+	CommonConfigure(instance integration.Data, source string) error
+}
+
+func isGoCheck(c check.Check) bool {
+	_, ok := c.(goCheck)
+	return ok
+}
+
+// goMemoryDiff is the difference of the Go allocations of the agent between
+// before and after a run of a check, the heap is measured after a GC so that
+// it holds the memory retained by the check
+type goMemoryDiff struct {
+	Mallocs     uint64 `json:"mallocs"`
+	AllocBytes  uint64 `json:"alloc_bytes"`
+	HeapInuse   int64  `json:"heap_inuse_delta"`
+	HeapObjects int64  `json:"heap_objects_delta"`
+}
+
+func readMemStats() runtime.MemStats {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m
+}
+
+func diffMemStats(before, after *runtime.MemStats) goMemoryDiff {
+	return goMemoryDiff{
+		Mallocs:     after.Mallocs - before.Mallocs,
+		AllocBytes:  after.TotalAlloc - before.TotalAlloc,
+		HeapInuse:   int64(after.HeapInuse) - int64(before.HeapInuse),
+		HeapObjects: int64(after.HeapObjects) - int64(before.HeapObjects),
+	}
+}
+
+func (d goMemoryDiff) String() string {
+	return fmt.Sprintf("Allocations: %d (%d bytes), heap in use: %+d bytes, heap objects: %+d", d.Mallocs, d.AllocBytes, d.HeapInuse, d.HeapObjects)
+}
+
+// repeatRun is the outcome of a repetition of the check, compared to the
+// others in the repeat summary
+type repeatRun struct {
+	errors   uint64
+	warnings uint64
+	metrics  []string
+	memory   *goMemoryDiff
+}
+
+// seriesNames returns the names of the metrics of the series and sketches
+func seriesNames(series metrics.Series, sketches metrics.SketchSeriesList) []string {
+	var names []string
+	for _, serie := range series {
+		names = append(names, serie.Name)
+	}
+	for _, sketch := range sketches {
+		names = append(names, sketch.Name)
+	}
+	return names
+}
+
+// printRepeatSummary outputs the runs that failed, the metrics that weren't
+// collected on every run and the growth of the Go heap
+func printRepeatSummary(w io.Writer, checkID check.ID, runs []repeatRun) {
+	fmt.Fprintln(w, fmt.Sprintf("=== %s ===", color.BlueString("Repeat summary of %s", checkID)))
+
+	var failed, warned []string
+	for i, run := range runs {
+		if run.errors > 0 {
+			failed = append(failed, strconv.Itoa(i+1))
+		}
+		if run.warnings > 0 {
+			warned = append(warned, strconv.Itoa(i+1))
+		}
+	}
+	fmt.Fprintln(w, fmt.Sprintf("Runs: %d", len(runs)))
+	if len(failed) > 0 {
+		fmt.Fprintln(w, fmt.Sprintf("%s: %d (runs %s)", color.RedString("Failed runs"), len(failed), strings.Join(failed, ", ")))
+	}
+	if len(warned) > 0 {
+		fmt.Fprintln(w, fmt.Sprintf("%s: %d (runs %s)", color.YellowString("Runs with warnings"), len(warned), strings.Join(warned, ", ")))
+	}
+
+	if flaky := flakyMetrics(runs); len(flaky) > 0 {
+		fmt.Fprintln(w, color.YellowString("Metrics not collected on every run:"))
+		for _, name := range flaky {
+			fmt.Fprintln(w, fmt.Sprintf("  %s", name))
+		}
+	}
+
+	var heapGrowth int64
+	profiled := false
+	for _, run := range runs {
+		if run.memory != nil {
+			heapGrowth += run.memory.HeapInuse
+			profiled = true
+		}
+	}
+	if profiled {
+		fmt.Fprintln(w, fmt.Sprintf("Go heap in use over the runs: %+d bytes", heapGrowth))
+	}
+	fmt.Fprintln(w, "")
+}
+
+// flakyMetrics returns the metrics collected on some runs only, with the
+// number of runs they were collected on
+func flakyMetrics(runs []repeatRun) []string {
+	counts := make(map[string]int)
+	for _, run := range runs {
+		seen := make(map[string]bool)
+		for _, name := range run.metrics {
+			if !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+
+	var flaky []string
+	for name, count := range counts {
+		if count < len(runs) {
+			flaky = append(flaky, fmt.Sprintf("%s: %d/%d runs", name, count, len(runs)))
+		}
+	}
+	sort.Strings(flaky)
+	return flaky
+}
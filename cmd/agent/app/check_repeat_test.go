@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestPauseValue(t *testing.T) {
+	var p pauseValue
+	require.NoError(t, p.Set("500"))
+	assert.Equal(t, 500*time.Millisecond, time.Duration(p))
+	require.NoError(t, p.Set("2s"))
+	assert.Equal(t, 2*time.Second, time.Duration(p))
+	assert.Equal(t, "2s", p.String())
+	assert.Error(t, p.Set("soon"))
+}
+
+func TestSeriesNames(t *testing.T) {
+	names := seriesNames(
+		metrics.Series{{Name: "foo.gauge"}, {Name: "foo.count"}},
+		metrics.SketchSeriesList{{Name: "foo.distribution"}},
+	)
+	assert.Equal(t, []string{"foo.gauge", "foo.count", "foo.distribution"}, names)
+}
+
+func TestDiffMemStats(t *testing.T) {
+	before := runtime.MemStats{Mallocs: 10, TotalAlloc: 1000, HeapInuse: 4096, HeapObjects: 8}
+	after := runtime.MemStats{Mallocs: 15, TotalAlloc: 1500, HeapInuse: 2048, HeapObjects: 10}
+	assert.Equal(t, goMemoryDiff{Mallocs: 5, AllocBytes: 500, HeapInuse: -2048, HeapObjects: 2}, diffMemStats(&before, &after))
+}
+
+func TestPrintRepeatSummary(t *testing.T) {
+	color.NoColor = true
+	runs := []repeatRun{
+		{metrics: []string{"foo.gauge", "foo.count", "foo.count"}, memory: &goMemoryDiff{HeapInuse: 1024}},
+		{errors: 1, metrics: []string{"foo.gauge"}, memory: &goMemoryDiff{HeapInuse: 512}},
+		{warnings: 2, metrics: []string{"foo.gauge", "foo.count", "foo.rate"}, memory: &goMemoryDiff{HeapInuse: -256}},
+	}
+	assert.Equal(t, []string{"foo.count: 2/3 runs", "foo.rate: 1/3 runs"}, flakyMetrics(runs))
+
+	w := &bytes.Buffer{}
+	printRepeatSummary(w, "foo:1234", runs)
+	assert.Equal(t, `=== Repeat summary of foo:1234 ===
+Runs: 3
+Failed runs: 1 (runs 2)
+Runs with warnings: 1 (runs 3)
+Metrics not collected on every run:
+  foo.count: 2/3 runs
+  foo.rate: 1/3 runs
+Go heap in use over the runs: +1280 bytes
+
+`, w.String())
+}
@@ -23,6 +23,8 @@ func init() {
 	configCommand.AddCommand(listRuntimeCommand)
 	configCommand.AddCommand(setCommand)
 	configCommand.AddCommand(getCommand)
+	configCommand.AddCommand(reloadCommand)
+	reloadCommand.Flags().BoolVarP(&reloadLast, "last", "", false, "print the report of the last reload instead of reloading")
 }
 
 var (
@@ -46,12 +48,21 @@ var (
 	}
 	getCommand = &cobra.Command{
 		Use:   "get [setting]",
-		Short: "Get, for the current runtime, the value of a given configuration setting",
+		Short: "Get, for the current runtime, the value of a given configuration setting, and the layer setting it",
 		Long:  ``,
 		RunE:  getConfigValue,
 	}
+	reloadCommand = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload datadog.yaml, and print which changed settings were applied and which require a restart",
+		Long:  ``,
+		RunE:  reloadConfig,
+	}
 	agentConfigURLPath = "/agent/config"
 	listRuntimeURLPath = agentConfigURLPath + "/list-runtime"
+	reloadURLPath      = agentConfigURLPath + "/reload"
+
+	reloadLast bool
 )
 
 func setupConfig() error {
@@ -198,14 +209,66 @@ func getConfigValue(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var setting = make(map[string]interface{})
+	var setting struct {
+		Value   interface{}              `json:"value"`
+		Source  string                   `json:"source"`
+		Sources []config.ValueWithSource `json:"sources"`
+	}
 	err = json.Unmarshal(r, &setting)
 	if err != nil {
 		return err
 	}
-	if value, found := setting["value"]; found {
-		fmt.Printf("%s is set to: %s\n", args[0], value)
-		return nil
+	if setting.Value == nil {
+		return fmt.Errorf("unable to get value for this setting: %v", args[0])
+	}
+	fmt.Printf("%s is set to: %v\n", args[0], setting.Value)
+	if setting.Source != "" && setting.Source != string(config.SourceUnknown) {
+		fmt.Printf("Set by: %s\n", setting.Source)
+	}
+	if len(setting.Sources) > 1 {
+		fmt.Println("Value in each layer, from the lowest priority to the highest:")
+		for _, layer := range setting.Sources {
+			fmt.Printf("  %s:\t%v\n", layer.Source, layer.Value)
+		}
+	}
+	return nil
+}
+
+func reloadConfig(cmd *cobra.Command, args []string) error {
+	err := setupConfig()
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("unable to get value for this setting: %v", args[0])
+	c := util.GetClient(false)
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%v:%v"+reloadURLPath, ipcAddress, config.Datadog.GetInt("cmd_port"))
+	var r []byte
+	if reloadLast {
+		r, err = util.DoGet(c, url)
+	} else {
+		r, err = util.DoPost(c, url, "application/json", bytes.NewBuffer([]byte{}))
+	}
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			return fmt.Errorf(e)
+		}
+		return err
+	}
+
+	var report config.ReloadReport
+	err = json.Unmarshal(r, &report)
+	if err != nil {
+		return err
+	}
+	if report.Error != "" {
+		return fmt.Errorf("%s", report)
+	}
+	fmt.Println(report)
+	return nil
 }
@@ -18,12 +18,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var withDebug bool
+var (
+	withDebug             bool
+	withVerboseResolution bool
+)
 
 func init() {
 	AgentCmd.AddCommand(configCheckCommand)
 
 	configCheckCommand.Flags().BoolVarP(&withDebug, "verbose", "v", false, "print additional debug info")
+	configCheckCommand.Flags().BoolVarP(&withVerboseResolution, "verbose-resolution", "", false, "print how each template resolved against the discovered services")
 }
 
 var configCheckCommand = &cobra.Command{
@@ -48,7 +52,11 @@ var configCheckCommand = &cobra.Command{
 		}
 		var b bytes.Buffer
 		color.Output = &b
-		err = flare.GetConfigCheck(color.Output, withDebug)
+		if withVerboseResolution {
+			err = flare.GetConfigResolution(color.Output)
+		} else {
+			err = flare.GetConfigCheck(color.Output, withDebug)
+		}
 		if err != nil {
 			return fmt.Errorf("unable to get config: %v", err)
 		}
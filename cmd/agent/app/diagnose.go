@@ -16,7 +16,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var jsonDiagnose bool
+
 func init() {
+	diagnoseCommand.Flags().BoolVarP(&jsonDiagnose, "json", "j", false, "print out the results of the diagnosis as json")
+	diagnoseCommand.AddCommand(diagnoseProxyCommand)
 	AgentCmd.AddCommand(diagnoseCommand)
 }
 
@@ -27,7 +31,35 @@ var diagnoseCommand = &cobra.Command{
 	RunE:  doDiagnose,
 }
 
+var diagnoseProxyCommand = &cobra.Command{
+	Use:   "proxy",
+	Short: "Check that the Datadog endpoints are reachable through the configured proxies",
+	Long:  ``,
+	RunE:  doDiagnoseProxy,
+}
+
 func doDiagnose(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	if jsonDiagnose {
+		return diagnose.RunAllJSON(color.Output)
+	}
+	return diagnose.RunAll(color.Output)
+}
+
+func doDiagnoseProxy(cmd *cobra.Command, args []string) error {
+	if err := setupDiagnose(); err != nil {
+		return err
+	}
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return fmt.Errorf("misconfiguration of agent endpoints: %s", err)
+	}
+	return diagnose.RunProxy(color.Output, keysPerDomain)
+}
+
+func setupDiagnose() error {
 	// Global config setup
 	err := common.SetupConfig(confFilePath)
 	if err != nil {
@@ -38,18 +70,21 @@ func doDiagnose(cmd *cobra.Command, args []string) error {
 		color.NoColor = true
 	}
 
+	diagnose.DefaultLogFile = common.DefaultLogFile
+
+	// the logs would be mixed with the JSON output
+	logToConsole := config.Datadog.GetBool("log_to_console") && !jsonDiagnose
 	err = config.SetupLogger(
 		loggerName,
 		config.Datadog.GetString("log_level"),
 		common.DefaultLogFile,
 		config.GetSyslogURI(),
 		config.Datadog.GetBool("syslog_rfc"),
-		config.Datadog.GetBool("log_to_console"),
+		logToConsole,
 		config.Datadog.GetBool("log_format_json"),
 	)
 	if err != nil {
 		return fmt.Errorf("Error while setting up logging, exiting: %v", err)
 	}
-
-	return diagnose.RunAll(color.Output)
+	return nil
 }
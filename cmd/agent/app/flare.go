@@ -7,14 +7,18 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/util/input"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -24,6 +28,9 @@ var (
 	customerEmail string
 	autoconfirm   bool
 	forceLocal    bool
+	flareProfile  string
+	reviewFlare   bool
+	flarePerf     time.Duration
 )
 
 func init() {
@@ -32,6 +39,9 @@ func init() {
 	flareCmd.Flags().StringVarP(&customerEmail, "email", "e", "", "Your email")
 	flareCmd.Flags().BoolVarP(&autoconfirm, "send", "s", false, "Automatically send flare (don't prompt for confirmation)")
 	flareCmd.Flags().BoolVarP(&forceLocal, "local", "l", false, "Force the creation of the flare by the command line instead of the agent process (useful when running in a containerized env)")
+	flareCmd.Flags().StringVarP(&flareProfile, "profile", "p", string(flare.ProfileStandard), "Which content to include in the flare: minimal, standard or full")
+	flareCmd.Flags().BoolVarP(&reviewFlare, "review", "r", false, "Print the files of the flare and the number of values scrubbed from them, and always ask for confirmation before sending it")
+	flareCmd.Flags().DurationVarP(&flarePerf, "performance-profile", "", 0, "Profile the agent processes for this duration, e.g. 30s, and attach the CPU, heap, goroutine and mutex profiles to the flare")
 	flareCmd.SetArgs([]string{"caseID"})
 }
 
@@ -57,6 +67,11 @@ var flareCmd = &cobra.Command{
 			return err
 		}
 
+		profile, err := flare.ParseProfile(flareProfile)
+		if err != nil {
+			return err
+		}
+
 		caseID := ""
 		if len(args) > 0 {
 			caseID = args[0]
@@ -71,11 +86,11 @@ var flareCmd = &cobra.Command{
 			}
 		}
 
-		return makeFlare(caseID)
+		return makeFlare(caseID, profile)
 	},
 }
 
-func makeFlare(caseID string) error {
+func makeFlare(caseID string, profile flare.Profile) error {
 	logFile := config.Datadog.GetString("log_file")
 	if logFile == "" {
 		logFile = common.DefaultLogFile
@@ -84,9 +99,9 @@ func makeFlare(caseID string) error {
 	var filePath string
 	var err error
 	if forceLocal {
-		filePath, err = createArchive(logFile)
+		filePath, err = createArchive(logFile, profile)
 	} else {
-		filePath, err = requestArchive(logFile)
+		filePath, err = requestArchive(logFile, profile)
 	}
 
 	if err != nil {
@@ -99,8 +114,19 @@ func makeFlare(caseID string) error {
 		return err
 	}
 
+	if reviewFlare {
+		manifest, err := flare.ReadManifest(filePath)
+		if err != nil {
+			fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Unable to read the manifest of the flare: %s", err)))
+			return err
+		}
+		manifest.Print(color.Output)
+		fmt.Fprintln(color.Output, "")
+	}
+
 	fmt.Fprintln(color.Output, fmt.Sprintf("%s is going to be uploaded to Datadog", color.YellowString(filePath)))
-	if !autoconfirm {
+	// the review always asks for a confirmation, even with --send
+	if !autoconfirm || reviewFlare {
 		confirmation := input.AskForConfirmation("Are you sure you want to upload a flare? [y/N]")
 		if !confirmation {
 			fmt.Fprintln(color.Output, fmt.Sprintf("Aborting. (You can still use %s)", color.YellowString(filePath)))
@@ -116,14 +142,14 @@ func makeFlare(caseID string) error {
 	return nil
 }
 
-func requestArchive(logFile string) (string, error) {
+func requestArchive(logFile string, profile flare.Profile) (string, error) {
 	fmt.Fprintln(color.Output, color.BlueString("Asking the agent to build the flare archive."))
 	var e error
 	c := util.GetClient(false) // FIX: get certificates right then make this true
 	ipcAddress, err := config.GetIPCAddress()
 	if err != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error getting IPC address for the agent: %s", err)))
-		return createArchive(logFile)
+		return createArchive(logFile, profile)
 	}
 	urlstr := fmt.Sprintf("https://%v:%v/agent/flare", ipcAddress, config.Datadog.GetInt("cmd_port"))
 
@@ -131,27 +157,74 @@ func requestArchive(logFile string) (string, error) {
 	e = util.SetAuthToken()
 	if e != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error: %s", e)))
-		return createArchive(logFile)
+		return createArchive(logFile, profile)
+	}
+
+	flareOptions := map[string]interface{}{"profile": string(profile)}
+	if flarePerf > 0 {
+		if _, e = requestProfile(flarePerf); e != nil {
+			fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("The agent was unable to profile its processes: %s", e)))
+			return createArchive(logFile, profile)
+		}
+		flareOptions["include_performance_profiles"] = true
 	}
 
-	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
+	options, e := json.Marshal(flareOptions)
+	if e != nil {
+		return createArchive(logFile, profile)
+	}
+
+	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer(options))
 	if e != nil {
 		if r != nil && string(r) != "" {
 			fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error while making the flare: %s", color.RedString(string(r))))
 		} else {
 			fmt.Fprintln(color.Output, color.RedString("The agent was unable to make the flare. (is it running?)"))
 		}
-		return createArchive(logFile)
+		return createArchive(logFile, profile)
 	}
 	return string(r), nil
 }
 
-func createArchive(logFile string) (string, error) {
+func createArchive(logFile string, profile flare.Profile) (string, error) {
 	fmt.Fprintln(color.Output, color.YellowString("Initiating flare locally."))
-	filePath, e := flare.CreateArchive(true, common.GetDistPath(), common.PyChecksPath, logFile)
+	var perfProfiles flare.PerformanceProfiles
+	if flarePerf > 0 {
+		fmt.Fprintln(color.Output, color.BlueString("Profiling the agent processes for %s.", flarePerf))
+		perfProfiles = flare.CollectPerformanceProfiles(flarePerf)
+	}
+	filePath, e := flare.CreateArchiveWithPerformanceProfiles(true, profile, common.GetDistPath(), common.PyChecksPath, logFile, perfProfiles)
 	if e != nil {
 		fmt.Printf("The flare zipfile failed to be created: %s\n", e)
 		return "", e
 	}
 	return filePath, nil
 }
+
+// makeRequestedFlare builds the flare the cluster-agent asked for and sends it
+func makeRequestedFlare(request types.FlareRequest) {
+	profile, err := flare.ParseProfile(request.Profile)
+	if err != nil {
+		log.Errorf("Could not make the flare requested by the cluster-agent: %s", err)
+		return
+	}
+
+	logFile := config.Datadog.GetString("log_file")
+	if logFile == "" {
+		logFile = common.DefaultLogFile
+	}
+
+	filePath, err := flare.CreateArchive(false, profile, common.GetDistPath(), common.PyChecksPath, logFile)
+	if err != nil {
+		log.Errorf("The flare requested by the cluster-agent failed to be created: %s", err)
+		return
+	}
+	defer os.Remove(filePath)
+
+	response, err := flare.SendFlare(filePath, request.CaseID, request.Email)
+	if err != nil {
+		log.Errorf("The flare requested by the cluster-agent failed to be sent: %s", err)
+		return
+	}
+	log.Infof("The flare requested by the cluster-agent was sent: %s", response)
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build python
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/app/standalone"
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector"
+	"github.com/DataDog/datadog-agent/pkg/collector/python"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+func init() {
+	AgentCmd.AddCommand(isolatedCheckCmd)
+}
+
+// isolatedCheckCmd runs a Python check instance configured with
+// `isolation: process`, it's started by the agent and not meant to be run by hand
+var isolatedCheckCmd = &cobra.Command{
+	Use:    "isolated-check",
+	Short:  "Run a Python check instance for the agent, in a process of its own",
+	Long:   ``,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the logs go to the standard output, relayed by the agent to its own logs
+		if _, err := standalone.SetupCLI(loggerName, confFilePath, "", "info"); err != nil {
+			fmt.Printf("Cannot initialize command: %v\n", err)
+			return err
+		}
+
+		hostname, err := util.GetHostname()
+		if err != nil {
+			fmt.Printf("Cannot get hostname, exiting: %v\n", err)
+			return err
+		}
+
+		// the data is sent by the agent, the aggregator only holds the sender of the check
+		aggregator.InitAggregatorWithFlushInterval(nil, hostname, "agent", 0)
+		common.Coll = collector.NewCollector(common.GetPythonPaths()...)
+
+		return python.ServeIsolatedCheck(os.Stdin, os.NewFile(python.IsolatedResultsFd, "results"))
+	},
+}
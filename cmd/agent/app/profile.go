@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// profilePollInterval is the interval between the checks of the end of the profiling
+const profilePollInterval = time.Second
+
+var profileDuration time.Duration
+
+func init() {
+	AgentCmd.AddCommand(profileCmd)
+
+	profileCmd.Flags().DurationVarP(&profileDuration, "duration", "d", 30*time.Second, "Duration of the CPU profile, between 1s and 5m")
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Collect the CPU, heap, goroutine and mutex profiles of the agent processes",
+	Long: `Ask the running agent to collect the pprof profiles of the core, trace and process agents,
+and bundle them in an archive. The profiles can be attached to a flare with its --performance-profile option.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		archive, err := requestProfile(profileDuration)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(color.Output, fmt.Sprintf("The profiles of the agent processes are in %s", color.YellowString(archive)))
+		return nil
+	},
+}
+
+// profileStatus is the state of the profiling returned by the agent
+type profileStatus struct {
+	Running bool   `json:"running"`
+	Archive string `json:"archive"`
+	Error   string `json:"error"`
+}
+
+// requestProfile asks the agent to profile its processes, waits for the end of
+// the profiling and returns the path of the archive of the profiles
+func requestProfile(duration time.Duration) (string, error) {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return "", err
+	}
+	urlstr := fmt.Sprintf("https://%v:%v/agent/profile", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+	// Set session token
+	err = util.SetAuthToken()
+	if err != nil {
+		return "", err
+	}
+
+	options, err := json.Marshal(map[string]string{"duration": duration.String()})
+	if err != nil {
+		return "", err
+	}
+	r, err := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer(options))
+	if err != nil {
+		if r != nil && string(r) != "" {
+			return "", fmt.Errorf("the agent could not start the profiling: %s", bytes.TrimSpace(r))
+		}
+		return "", fmt.Errorf("the agent could not start the profiling (is it running?): %v", err)
+	}
+
+	fmt.Fprintln(color.Output, color.BlueString("Profiling the agent processes for %s.", duration))
+	for {
+		time.Sleep(profilePollInterval)
+
+		r, err = util.DoGet(c, urlstr)
+		if err != nil {
+			return "", fmt.Errorf("could not get the status of the profiling: %v", err)
+		}
+		var status profileStatus
+		if err := json.Unmarshal(r, &status); err != nil {
+			return "", err
+		}
+		if status.Running {
+			continue
+		}
+		if status.Error != "" {
+			return "", fmt.Errorf("the profiling failed: %s", status.Error)
+		}
+		return status.Archive, nil
+	}
+}
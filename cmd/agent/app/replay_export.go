@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+)
+
+func init() {
+	AgentCmd.AddCommand(replayExportCommand)
+}
+
+var replayExportCommand = &cobra.Command{
+	Use:   "replay-export <export directory>",
+	Short: "Send the payloads exported by an Agent running with forwarder_export_path",
+	Long: `Send the payloads of the complete export files of the directory to the endpoints
+configured on this host, with its API keys. The files are removed once sent.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "info"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		keysPerDomain, err := config.GetMultipleEndpoints()
+		if err != nil {
+			return fmt.Errorf("misconfiguration of the agent endpoints: %v", err)
+		}
+
+		sent, err := forwarder.ReplayExport(args[0], keysPerDomain)
+		fmt.Printf("%d payload(s) sent\n", sent)
+		return err
+	},
+}
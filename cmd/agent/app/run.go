@@ -25,7 +25,9 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
@@ -33,6 +35,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -47,6 +50,7 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/ebpf"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/net"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/openmetrics"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/systemd"
 
@@ -117,6 +121,16 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
+	// SIGHUP reloads the settings of datadog.yaml that can change while the agent runs
+	sighupCh := make(chan os.Signal, 1)
+	signal.Notify(sighupCh, syscall.SIGHUP)
+	go func() {
+		for range sighupCh {
+			log.Info("Received signal 'hangup', reloading the configuration...")
+			config.Reload()
+		}
+	}()
+
 	if err := StartAgent(); err != nil {
 		return err
 	}
@@ -244,10 +258,27 @@ func StartAgent() error {
 	if err != nil {
 		log.Error("Misconfiguration of agent endpoints: ", err)
 	}
-	common.Forwarder = forwarder.NewDefaultForwarder(forwarder.NewOptions(keysPerDomain))
+	if exportPath := config.Datadog.GetString("forwarder_export_path"); exportPath != "" {
+		common.Forwarder = forwarder.NewExportForwarder(exportPath)
+	} else {
+		common.Forwarder = forwarder.NewDefaultForwarder(forwarder.NewOptions(keysPerDomain))
+	}
 	log.Debugf("Starting forwarder")
-	common.Forwarder.Start()
+	if err := common.Forwarder.Start(); err != nil {
+		return log.Errorf("Error while starting the forwarder, exiting: %v", err)
+	}
 	log.Debugf("Forwarder started")
+	if f, ok := common.Forwarder.(*forwarder.DefaultForwarder); ok {
+		reloadAPIKeys := func() error {
+			keysPerDomain, err := config.GetMultipleEndpoints()
+			if err != nil {
+				return err
+			}
+			return f.UpdateAPIKeys(keysPerDomain)
+		}
+		config.RegisterReloadHandler("api_key", reloadAPIKeys)
+		config.RegisterReloadHandler("additional_endpoints", reloadAPIKeys)
+	}
 
 	// setup the aggregator
 	s := serializer.NewSerializer(common.Forwarder)
@@ -260,6 +291,14 @@ func StartAgent() error {
 		common.DSD, err = dogstatsd.NewServer(agg)
 		if err != nil {
 			log.Errorf("Could not start dogstatsd: %s", err)
+		} else {
+			if err = config.RegisterRuntimeSetting(common.DsdStatsRuntimeSetting("dogstatsd_stats")); err != nil {
+				log.Warnf("Could not register the dogstatsd_stats runtime setting: %s", err)
+			}
+			config.RegisterReloadHandler("statsd_metric_namespace_blacklist", func() error {
+				common.DSD.SetMetricPrefixBlacklist(config.Datadog.GetStringSlice("statsd_metric_namespace_blacklist"))
+				return nil
+			})
 		}
 	}
 	log.Debugf("statsd started")
@@ -280,6 +319,9 @@ func StartAgent() error {
 	// Detect Cloud Provider
 	go util.DetectCloudProvider()
 
+	// build the flares requested by the cluster-agent
+	providers.FlareRequestHandler = makeRequestedFlare
+
 	// create and setup the Autoconfig instance
 	common.SetupAutoConfig(config.Datadog.GetString("confd_path"))
 	// start the autoconfig, this will immediately run any configured check
@@ -297,6 +339,19 @@ func StartAgent() error {
 		}
 	}
 
+	// poll the remote configuration, once the checks are scheduled
+	if config.Datadog.GetBool("remote_configuration.enabled") {
+		client, err := remoteconfig.NewClient(hostname, func(conf *remoteconfig.Configuration) {
+			runner.SetDisabledChecks(conf.DisabledChecks)
+		})
+		if err != nil {
+			log.Errorf("Could not start the remote configuration client: %v", err)
+		} else {
+			common.RemoteConfig = client
+			common.RemoteConfig.Start()
+		}
+	}
+
 	// start dependent services
 	startDependentServices()
 	return nil
@@ -319,6 +374,9 @@ func StopAgent() {
 	if common.DSD != nil {
 		common.DSD.Stop()
 	}
+	if common.RemoteConfig != nil {
+		common.RemoteConfig.Stop()
+	}
 	if common.AC != nil {
 		common.AC.Stop()
 	}
@@ -22,13 +22,15 @@ import (
 
 var (
 	jsonStatus      bool
+	rawJSONStatus   bool
 	prettyPrintJSON bool
 	statusFilePath  string
 )
 
 func init() {
 	AgentCmd.AddCommand(statusCmd)
-	statusCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
+	statusCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out the status as json, with a versioned schema")
+	statusCmd.Flags().BoolVarP(&rawJSONStatus, "raw-json", "", false, "print out the raw status payload of the agent as json")
 	statusCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
 	statusCmd.Flags().StringVarP(&statusFilePath, "file", "o", "", "Output the status command to a file")
 	statusCmd.AddCommand(componentCmd)
@@ -84,7 +86,7 @@ var componentCmd = &cobra.Command{
 func requestStatus() error {
 	var s string
 
-	if !prettyPrintJSON && !jsonStatus {
+	if !prettyPrintJSON && !jsonStatus && !rawJSONStatus {
 		fmt.Printf("Getting the status from the agent.\n\n")
 	}
 	ipcAddress, err := config.GetIPCAddress()
@@ -92,6 +94,9 @@ func requestStatus() error {
 		return err
 	}
 	urlstr := fmt.Sprintf("https://%v:%v/agent/status", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	if jsonStatus {
+		urlstr = fmt.Sprintf("https://%v:%v/agent/status/structured", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	}
 	r, err := makeRequest(urlstr)
 	if err != nil {
 		return err
@@ -102,7 +107,7 @@ func requestStatus() error {
 		var prettyJSON bytes.Buffer
 		json.Indent(&prettyJSON, r, "", "  ")
 		s = prettyJSON.String()
-	} else if jsonStatus {
+	} else if jsonStatus || rawJSONStatus {
 		s = string(r)
 	} else {
 		formattedStatus, err := status.FormatStatus(r)
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 
@@ -21,8 +22,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var staleEntities bool
+
 func init() {
 	AgentCmd.AddCommand(taggerListCommand)
+	taggerListCommand.Flags().BoolVarP(&staleEntities, "stale", "", false, "only list the deleted entities whose tags are kept until their deletion grace period expires")
 }
 
 var taggerListCommand = &cobra.Command{
@@ -57,7 +61,11 @@ var taggerListCommand = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		r, err := util.DoGet(c, fmt.Sprintf("https://%v:%v/agent/tagger-list", ipcAddress, config.Datadog.GetInt("cmd_port")))
+		urlstr := fmt.Sprintf("https://%v:%v/agent/tagger-list", ipcAddress, config.Datadog.GetInt("cmd_port"))
+		if staleEntities {
+			urlstr += "?stale=true"
+		}
+		r, err := util.DoGet(c, urlstr)
 		if err != nil {
 			if r != nil && string(r) != "" {
 				fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error while getting tags list: %s", string(r)))
@@ -72,36 +80,46 @@ var taggerListCommand = &cobra.Command{
 			return err
 		}
 
-		for entity, tagItem := range tr.Entities {
-			fmt.Fprintln(color.Output, fmt.Sprintf("\n=== Entity %s ===", color.GreenString(entity)))
-
-			fmt.Fprint(color.Output, "Tags: [")
-			// sort tags for easy comparison
-			sort.Slice(tagItem.Tags, func(i, j int) bool {
-				return tagItem.Tags[i] < tagItem.Tags[j]
-			})
-			for i, tag := range tagItem.Tags {
-				tagInfo := strings.Split(tag, ":")
-				fmt.Fprintf(color.Output, fmt.Sprintf("%s:%s", color.BlueString(tagInfo[0]), color.CyanString(strings.Join(tagInfo[1:], ":"))))
-				if i != len(tagItem.Tags)-1 {
-					fmt.Fprintf(color.Output, " ")
-				}
-			}
-			fmt.Fprintln(color.Output, "]")
-			fmt.Fprint(color.Output, "Sources: [")
-			sort.Slice(tagItem.Sources, func(i, j int) bool {
-				return tagItem.Sources[i] < tagItem.Sources[j]
-			})
-			for i, source := range tagItem.Sources {
-				fmt.Fprintf(color.Output, fmt.Sprintf("%s", color.BlueString(source)))
-				if i != len(tagItem.Sources)-1 {
-					fmt.Fprintf(color.Output, " ")
-				}
-			}
-			fmt.Fprintln(color.Output, "]")
-			fmt.Fprintln(color.Output, "===")
-		}
+		printTaggerEntities(tr.Entities)
 
 		return nil
 	},
 }
+
+// printTaggerEntities prints the tags and sources of the entities
+func printTaggerEntities(entities map[string]response.TaggerListEntity) {
+	for entity, tagItem := range entities {
+		fmt.Fprintln(color.Output, fmt.Sprintf("\n=== Entity %s ===", color.GreenString(entity)))
+
+		fmt.Fprint(color.Output, "Tags: [")
+		// sort tags for easy comparison
+		sort.Slice(tagItem.Tags, func(i, j int) bool {
+			return tagItem.Tags[i] < tagItem.Tags[j]
+		})
+		for i, tag := range tagItem.Tags {
+			tagInfo := strings.Split(tag, ":")
+			fmt.Fprintf(color.Output, fmt.Sprintf("%s:%s", color.BlueString(tagInfo[0]), color.CyanString(strings.Join(tagInfo[1:], ":"))))
+			if i != len(tagItem.Tags)-1 {
+				fmt.Fprintf(color.Output, " ")
+			}
+		}
+		fmt.Fprintln(color.Output, "]")
+		fmt.Fprint(color.Output, "Sources: [")
+		sort.Slice(tagItem.Sources, func(i, j int) bool {
+			return tagItem.Sources[i] < tagItem.Sources[j]
+		})
+		for i, source := range tagItem.Sources {
+			fmt.Fprintf(color.Output, fmt.Sprintf("%s", color.BlueString(source)))
+			if i != len(tagItem.Sources)-1 {
+				fmt.Fprintf(color.Output, " ")
+			}
+		}
+		fmt.Fprintln(color.Output, "]")
+		if tagItem.DeletedAt != nil && tagItem.ExpiresAt != nil {
+			fmt.Fprintln(color.Output, fmt.Sprintf("Deleted at %s, tags kept until %s",
+				color.YellowString(tagItem.DeletedAt.Format(time.RFC3339)),
+				color.YellowString(tagItem.ExpiresAt.Format(time.RFC3339))))
+		}
+		fmt.Fprintln(color.Output, "===")
+	}
+}
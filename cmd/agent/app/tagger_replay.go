@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+var (
+	replayCardinality string
+	replayEntities    []string
+)
+
+func init() {
+	AgentCmd.AddCommand(taggerReplayCommand)
+	taggerReplayCommand.Flags().StringVarP(&replayCardinality, "cardinality", "c", "high", "cardinality of the resolved tags: low, orchestrator or high")
+	taggerReplayCommand.Flags().StringSliceVarP(&replayEntities, "entity", "e", nil, "only resolve the tags of these entities")
+}
+
+var taggerReplayCommand = &cobra.Command{
+	Use:   "tagger-replay <state file>",
+	Short: "Resolve the tags of a tagger state, as dumped in the flares, offline",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		cardinality, err := tagger.ParseCardinality(replayCardinality)
+		if err != nil {
+			return err
+		}
+
+		raw, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("unable to read the tagger state: %v", err)
+		}
+		var state tagger.State
+		if err = json.Unmarshal(raw, &state); err != nil {
+			return fmt.Errorf("unable to parse the tagger state: %v", err)
+		}
+
+		t, err := tagger.NewReplayTagger(state, collectors.DefaultCatalog)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(color.Output, fmt.Sprintf("Tagger state version %d, created at %s",
+			state.Version, state.CreatedAt.Format("2006-01-02 15:04:05 MST")))
+
+		if state.Workloadmeta != nil {
+			printWorkloadmetaSummary(*state.Workloadmeta)
+			fmt.Fprintln(color.Output, fmt.Sprintf("Tags computed again from the workloadmeta entities for the sources: %s",
+				color.BlueString(strings.Join(tagger.ReplayedSources(collectors.DefaultCatalog), " "))))
+		}
+
+		entities := t.List(cardinality).Entities
+		if len(replayEntities) > 0 {
+			selected := make(map[string]response.TaggerListEntity, len(replayEntities))
+			for _, entity := range replayEntities {
+				tags, err := t.Tag(entity, cardinality)
+				if err != nil {
+					return fmt.Errorf("unable to resolve the tags of %s: %v", entity, err)
+				}
+				e := entities[entity]
+				e.Tags = tags
+				selected[entity] = e
+			}
+			entities = selected
+		}
+
+		printTaggerEntities(entities)
+
+		return nil
+	},
+}
+
+// printWorkloadmetaSummary prints the number of workloadmeta entities by kind and source
+func printWorkloadmetaSummary(dump workloadmeta.Dump) {
+	counts := make(map[string]int)
+	for _, e := range dump.Entities {
+		counts[fmt.Sprintf("%s from %s", e.Kind, e.Source)]++
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(color.Output, "Workloadmeta entities:")
+	for _, key := range keys {
+		fmt.Fprintln(color.Output, fmt.Sprintf("  %s: %d", color.BlueString(key), counts[key]))
+	}
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	validateJSON   bool
+	validateStrict bool
+	validateSchema bool
+)
+
+func init() {
+	AgentCmd.AddCommand(validateConfigCommand)
+	validateConfigCommand.Flags().BoolVarP(&validateJSON, "json", "", false, "print the report as json")
+	validateConfigCommand.Flags().BoolVarP(&validateStrict, "strict", "", false, "fail on the warnings too")
+	validateConfigCommand.Flags().BoolVarP(&validateSchema, "schema", "", false, "print the schema of datadog.yaml generated from the defaults, and exit")
+}
+
+var validateConfigCommand = &cobra.Command{
+	Use:   "validate-config",
+	Short: "Validate datadog.yaml and the configuration files of conf.d",
+	Long: `Check the types of the settings of datadog.yaml against the schema generated from their
+defaults, the constraints between the settings, and the checks and logs configurations of
+the conf.d files. Exits with an error when the configuration is invalid.`,
+	RunE: doValidateConfig,
+}
+
+type validationReport struct {
+	Valid      bool                     `json:"valid"`
+	ConfigFile string                   `json:"config_file"`
+	Errors     int                      `json:"errors"`
+	Warnings   int                      `json:"warnings"`
+	Issues     []config.ValidationIssue `json:"issues"`
+}
+
+func doValidateConfig(cmd *cobra.Command, args []string) error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	report := validationReport{Issues: []config.ValidationIssue{}}
+
+	// the secrets aren't resolved, the ENC[] handles are valid values
+	err := common.SetupConfigWithoutSecrets(confFilePath, "")
+	report.ConfigFile = config.Datadog.ConfigFileUsed()
+	if err != nil {
+		report.Issues = append(report.Issues, config.ValidationIssue{
+			Severity: config.ValidationError,
+			File:     report.ConfigFile,
+			Message:  err.Error(),
+		})
+	}
+
+	// the logs would mix with the report
+	err = config.SetupLogger(loggerName, "off", "", "", false, true, false)
+	if err != nil {
+		fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+		return err
+	}
+
+	if validateSchema {
+		schema, err := json.MarshalIndent(config.GenerateSchema(config.Datadog), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(schema))
+		return nil
+	}
+
+	report.Issues = append(report.Issues, config.Validate(config.Datadog)...)
+
+	confSearchPaths := []string{
+		config.Datadog.GetString("confd_path"),
+		filepath.Join(common.GetDistPath(), "conf.d"),
+	}
+	configs, issues := providers.ValidateFileConfigs(confSearchPaths)
+	report.Issues = append(report.Issues, issues...)
+
+	logsEnabled := config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled")
+	if logsEnabled && !config.Datadog.GetBool("logs_config.container_collect_all") && !hasLogsConfig(configs) {
+		report.Issues = append(report.Issues, config.ValidationIssue{
+			Severity: config.ValidationWarning,
+			File:     report.ConfigFile,
+			Key:      "logs_enabled",
+			Message:  "the logs are enabled, but no logs configuration is found and logs_config.container_collect_all is false",
+		})
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == config.ValidationError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0 && (!validateStrict || report.Warnings == 0)
+
+	if validateJSON {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+	} else {
+		printValidationReport(report)
+	}
+
+	if !report.Valid {
+		return fmt.Errorf("the configuration is invalid: %d error(s), %d warning(s)", report.Errors, report.Warnings)
+	}
+	return nil
+}
+
+func hasLogsConfig(configs []integration.Config) bool {
+	for _, c := range configs {
+		if c.LogsConfig != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func printValidationReport(report validationReport) {
+	for _, issue := range report.Issues {
+		severity := color.YellowString(string(issue.Severity))
+		if issue.Severity == config.ValidationError {
+			severity = color.RedString(string(issue.Severity))
+		}
+		location := issue.File
+		if issue.Key != "" {
+			location = fmt.Sprintf("%s: %s", location, issue.Key)
+		}
+		fmt.Printf("%s\t%s: %s\n", severity, location, issue.Message)
+	}
+	if report.Valid {
+		fmt.Println(color.GreenString("Configuration is valid"), fmt.Sprintf("(%d warning(s))", report.Warnings))
+	}
+}
@@ -7,6 +7,7 @@ package common
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
@@ -50,7 +51,16 @@ func SetupAutoConfig(confdPath string) {
 		filepath.Join(GetDistPath(), "conf.d"),
 		"",
 	}
-	AC.AddConfigProvider(providers.NewFileConfigProvider(confSearchPaths), false, 0)
+	fileProvider := providers.NewFileConfigProvider(confSearchPaths)
+	watchConfigFiles := false
+	if config.Datadog.GetBool("autoconf_config_files_watch") {
+		if err := fileProvider.Watch(); err != nil {
+			log.Warnf("Unable to watch the configuration files, the changes will need an Agent restart: %s", err)
+		} else {
+			watchConfigFiles = true
+		}
+	}
+	AC.AddConfigProvider(fileProvider, watchConfigFiles, config.Datadog.GetDuration("ad_config_poll_interval")*time.Second)
 
 	// Register additional configuration providers
 	var CP []config.ConfigurationProviders
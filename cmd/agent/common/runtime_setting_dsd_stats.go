@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// DsdStatsRuntimeSetting wraps operations to enable/disable the collection of
+// the dogstatsd metrics statistics at runtime.
+type DsdStatsRuntimeSetting string
+
+// Description returns the runtime setting's description
+func (s DsdStatsRuntimeSetting) Description() string {
+	return "Enable/disable the dogstatsd debug stats. Possible values: true, false"
+}
+
+// Name returns the name of the runtime setting
+func (s DsdStatsRuntimeSetting) Name() string {
+	return string(s)
+}
+
+// Get returns the current value of the runtime setting
+func (s DsdStatsRuntimeSetting) Get() (interface{}, error) {
+	if DSD == nil {
+		return false, nil
+	}
+	return DSD.IsMetricsStatsEnabled(), nil
+}
+
+// Set changes the value of the runtime setting
+func (s DsdStatsRuntimeSetting) Set(v interface{}) error {
+	var enabled bool
+	var err error
+
+	switch v := v.(type) {
+	case bool:
+		enabled = v
+	case string:
+		enabled, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", s.Name(), err)
+		}
+	default:
+		return fmt.Errorf("invalid value type for %s: %T", s.Name(), v)
+	}
+
+	if DSD == nil {
+		return fmt.Errorf("dogstatsd is not running")
+	}
+
+	if enabled {
+		DSD.EnableMetricsStats()
+	} else {
+		DSD.DisableMetricsStats()
+	}
+	config.Datadog.Set("dogstatsd_metrics_stats_enable", enabled)
+	return nil
+}
@@ -134,7 +134,7 @@ func makeFlare(w http.ResponseWriter, r *http.Request) {
 		logFile = common.DefaultLogFile
 	}
 
-	filePath, e := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, logFile)
+	filePath, e := flare.CreateArchive(false, flare.ProfileStandard, common.GetDistPath(), common.PyChecksPath, logFile)
 	if e != nil {
 		w.Write([]byte("Error creating flare zipfile: " + e.Error()))
 		log.Errorf("Error creating flare zipfile: " + e.Error())
@@ -158,6 +158,7 @@ func makeFlare(w http.ResponseWriter, r *http.Request) {
 func restartAgent(w http.ResponseWriter, r *http.Request) {
 	log.Infof("got restart function")
 	e := restart()
+	auditModification(r, "restart agent", "agent", e)
 	if e != nil {
 		log.Warnf("restart failed %v", e)
 		w.Write([]byte(e.Error()))
@@ -185,6 +186,7 @@ func setConfigFile(w http.ResponseWriter, r *http.Request) {
 	payload, e := parseBody(r)
 	if e != nil {
 		w.Write([]byte(e.Error()))
+		return
 	}
 	data := []byte(payload.Config)
 
@@ -198,6 +200,7 @@ func setConfigFile(w http.ResponseWriter, r *http.Request) {
 
 	path := config.Datadog.ConfigFileUsed()
 	e = ioutil.WriteFile(path, data, 0644)
+	auditModification(r, "write agent config", path, e)
 	if e != nil {
 		w.Write([]byte("Error: " + e.Error()))
 		return
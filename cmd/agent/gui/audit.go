@@ -0,0 +1,75 @@
+package gui
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// auditMutex serializes the writes to the audit log
+var auditMutex sync.Mutex
+
+// auditEntry is a line of the audit log, written for each modification made from the GUI
+type auditEntry struct {
+	Time       string `json:"time"`
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	RemoteAddr string `json:"remote_addr"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+}
+
+// auditLogPath returns the path of the audit log, by default next to the agent log file
+func auditLogPath() string {
+	if path := config.Datadog.GetString("GUI_audit_log_file"); path != "" {
+		return path
+	}
+	logFile := config.Datadog.GetString("log_file")
+	if logFile == "" {
+		logFile = common.DefaultLogFile
+	}
+	return filepath.Join(filepath.Dir(logFile), "gui-audit.log")
+}
+
+// auditModification appends a modification made from the GUI to the audit log,
+// err is the outcome of the modification
+func auditModification(r *http.Request, action, target string, err error) {
+	entry := auditEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Action:     action,
+		Target:     target,
+		RemoteAddr: r.RemoteAddr,
+		Success:    err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if e := writeAuditEntry(auditLogPath(), entry); e != nil {
+		log.Warnf("Unable to write the GUI audit log: %v", e)
+	}
+}
+
+// writeAuditEntry appends an entry to the audit log, as a JSON line
+func writeAuditEntry(path string, entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
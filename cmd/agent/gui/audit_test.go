@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAuditEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gui-audit.log")
+
+	require.NoError(t, writeAuditEntry(path, auditEntry{Action: "enable check config", Target: "foo.d/conf.yaml", Success: true}))
+	require.NoError(t, writeAuditEntry(path, auditEntry{Action: "restart agent", Target: "agent", Error: "not implemented"}))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, []auditEntry{
+		{Action: "enable check config", Target: "foo.d/conf.yaml", Success: true},
+		{Action: "restart agent", Target: "agent", Error: "not implemented"},
+	}, entries)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -48,6 +48,12 @@ func checkHandler(r *mux.Router) {
 	r.HandleFunc("/setConfig/{checkFolder}/{fileName}", http.HandlerFunc(setCheckConfigFile)).Methods("POST")
 	r.HandleFunc("/setConfig/{fileName}", http.HandlerFunc(setCheckConfigFile)).Methods("DELETE")
 	r.HandleFunc("/setConfig/{checkFolder}/{fileName}", http.HandlerFunc(setCheckConfigFile)).Methods("DELETE")
+	r.HandleFunc("/validateConfig/{fileName}", http.HandlerFunc(validateCheckConfigFile)).Methods("POST")
+	r.HandleFunc("/validateConfig/{checkFolder}/{fileName}", http.HandlerFunc(validateCheckConfigFile)).Methods("POST")
+	r.HandleFunc("/enable/{fileName}", http.HandlerFunc(enableCheckConfigFile)).Methods("POST")
+	r.HandleFunc("/enable/{checkFolder}/{fileName}", http.HandlerFunc(enableCheckConfigFile)).Methods("POST")
+	r.HandleFunc("/disable/{fileName}", http.HandlerFunc(disableCheckConfigFile)).Methods("POST")
+	r.HandleFunc("/disable/{checkFolder}/{fileName}", http.HandlerFunc(disableCheckConfigFile)).Methods("POST")
 	r.HandleFunc("/listChecks", http.HandlerFunc(listChecks)).Methods("POST")
 	r.HandleFunc("/listConfigs", http.HandlerFunc(listConfigs)).Methods("POST")
 }
@@ -70,9 +76,13 @@ func runCheck(w http.ResponseWriter, r *http.Request) {
 	name := mux.Vars(r)["name"]
 	instances := collector.GetChecksByNameForConfigs(name, common.AC.GetAllConfigs())
 
+	var err error
 	for _, ch := range instances {
-		common.Coll.RunCheck(ch)
+		if _, e := common.Coll.RunCheck(ch); e != nil {
+			err = e
+		}
 	}
+	auditModification(r, "schedule check", name, err)
 	log.Infof("Scheduled new check: " + name)
 	w.Write([]byte("Scheduled new check:" + name))
 }
@@ -143,6 +153,7 @@ func reloadCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	killed, e := common.Coll.ReloadAllCheckInstances(name, instances)
+	auditModification(r, "reload check", name, e)
 	if e != nil {
 		log.Errorf("Error reloading check: " + e.Error())
 		w.Write([]byte("Error reloading check: " + e.Error()))
@@ -191,76 +202,197 @@ type configFormat struct {
 func setCheckConfigFile(w http.ResponseWriter, r *http.Request) {
 	fileName := mux.Vars(r)["fileName"]
 	checkFolder := mux.Vars(r)["checkFolder"]
+	target := filepath.Join(checkFolder, fileName)
 
-	var checkConfFolderPath, defaultCheckConfFolderPath string
-
-	if checkFolder != "" {
-		checkConfFolderPath = filepath.Join(config.Datadog.GetString("confd_path"), checkFolder)
-		defaultCheckConfFolderPath = filepath.Join(common.GetDistPath(), "conf.d", checkFolder)
-	} else {
-		checkConfFolderPath = config.Datadog.GetString("confd_path")
-		defaultCheckConfFolderPath = filepath.Join(common.GetDistPath(), "conf.d")
+	if e := validateConfigPath(checkFolder, fileName); e != nil {
+		w.Write([]byte("Error: " + e.Error()))
+		return
 	}
 
-	if r.Method == "POST" {
-		payload, e := parseBody(r)
-		if e != nil {
-			w.Write([]byte(e.Error()))
-		}
-		data := []byte(payload.Config)
-
-		// Check that the data is actually a valid yaml file
-		cf := configFormat{}
-		e = yaml.Unmarshal(data, &cf)
+	if r.Method == "DELETE" {
+		e := disableCheckConfig(checkConfFolders(checkFolder), fileName)
+		auditModification(r, "disable check config", target, e)
 		if e != nil {
-			w.Write([]byte("Error: " + e.Error()))
-			return
-		}
-		if cf.MetricConfig == nil && cf.LogsConfig == nil && len(cf.Instances) < 1 {
-			w.Write([]byte("Configuration file contains no valid instances or log configuration"))
+			w.Write([]byte("Error disabling config file: " + e.Error()))
+			log.Errorf("Error disabling config file (%v): %v ", target, e)
 			return
 		}
 
-		// Attempt to write new configs to custom checks directory
-		path := filepath.Join(checkConfFolderPath, fileName)
-		os.MkdirAll(checkConfFolderPath, os.FileMode(0755))
+		log.Infof("Successfully disabled integration " + fileName + " config file.")
+		w.Write([]byte("Success"))
+		return
+	}
+
+	payload, e := parseBody(r)
+	if e != nil {
+		w.Write([]byte(e.Error()))
+		return
+	}
+	data := []byte(payload.Config)
+
+	// Check that the data is actually a valid configuration file
+	if e = validateCheckConfig(data); e != nil {
+		w.Write([]byte("Error: " + e.Error()))
+		return
+	}
+
+	// Attempt to write new configs to custom checks directory
+	folders := checkConfFolders(checkFolder)
+	path := filepath.Join(folders[0], fileName)
+	os.MkdirAll(folders[0], os.FileMode(0755))
+	e = ioutil.WriteFile(path, data, 0600)
+
+	// If the write didn't work, try writing to the default checks directory
+	if e != nil && strings.Contains(e.Error(), "no such file or directory") {
+		path = filepath.Join(folders[1], fileName)
+		os.MkdirAll(folders[1], os.FileMode(0755))
 		e = ioutil.WriteFile(path, data, 0600)
+	}
+	auditModification(r, "write check config", target, e)
 
-		// If the write didn't work, try writing to the default checks directory
-		if e != nil && strings.Contains(e.Error(), "no such file or directory") {
-			path = filepath.Join(defaultCheckConfFolderPath, fileName)
-			os.MkdirAll(defaultCheckConfFolderPath, os.FileMode(0755))
-			e = ioutil.WriteFile(path, data, 0600)
-		}
+	if e != nil {
+		w.Write([]byte("Error saving config file: " + e.Error()))
+		log.Debug("Error saving config file: " + e.Error())
+		return
+	}
 
-		if e != nil {
-			w.Write([]byte("Error saving config file: " + e.Error()))
-			log.Debug("Error saving config file: " + e.Error())
-			return
+	log.Infof("Successfully wrote new " + fileName + " config file.")
+	w.Write([]byte("Success"))
+}
+
+// Checks a configuration file without writing it, so that the errors are shown
+// while editing it
+func validateCheckConfigFile(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]string)
+	e := validateConfigPath(mux.Vars(r)["checkFolder"], mux.Vars(r)["fileName"])
+	if e == nil {
+		var payload Payload
+		if payload, e = parseBody(r); e == nil {
+			e = validateCheckConfig([]byte(payload.Config))
 		}
+	}
 
-		log.Infof("Successfully wrote new " + fileName + " config file.")
-		w.Write([]byte("Success"))
-	} else if r.Method == "DELETE" {
-		// Attempt to write new configs to custom checks directory
-		path := filepath.Join(checkConfFolderPath, fileName)
-		e := os.Rename(path, path+".disabled")
+	if e != nil {
+		response["success"] = "" // empty string evaluates to false in JS
+		response["error"] = e.Error()
+	} else {
+		response["success"] = "true"
+	}
+	res, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res)
+}
 
-		// If the move didn't work, try writing to the dev checks directory
-		if e != nil {
-			path = filepath.Join(defaultCheckConfFolderPath, fileName)
-			e = os.Rename(path, path+".disabled")
-		}
+// Enables a check configuration file disabled from the GUI
+func enableCheckConfigFile(w http.ResponseWriter, r *http.Request) {
+	fileName := mux.Vars(r)["fileName"]
+	checkFolder := mux.Vars(r)["checkFolder"]
+	target := filepath.Join(checkFolder, fileName)
 
-		if e != nil {
-			w.Write([]byte("Error disabling config file: " + e.Error()))
-			log.Errorf("Error disabling config file (%v): %v ", path, e)
-			return
+	e := validateConfigPath(checkFolder, fileName)
+	if e == nil {
+		e = enableCheckConfig(checkConfFolders(checkFolder), fileName)
+	}
+	auditModification(r, "enable check config", target, e)
+	if e != nil {
+		w.Write([]byte("Error enabling config file: " + e.Error()))
+		log.Errorf("Error enabling config file (%v): %v ", target, e)
+		return
+	}
+
+	log.Infof("Successfully enabled integration " + fileName + " config file.")
+	w.Write([]byte("Success"))
+}
+
+// Disables a check configuration file, it can be enabled back
+func disableCheckConfigFile(w http.ResponseWriter, r *http.Request) {
+	fileName := mux.Vars(r)["fileName"]
+	checkFolder := mux.Vars(r)["checkFolder"]
+	target := filepath.Join(checkFolder, fileName)
+
+	e := validateConfigPath(checkFolder, fileName)
+	if e == nil {
+		e = disableCheckConfig(checkConfFolders(checkFolder), fileName)
+	}
+	auditModification(r, "disable check config", target, e)
+	if e != nil {
+		w.Write([]byte("Error disabling config file: " + e.Error()))
+		log.Errorf("Error disabling config file (%v): %v ", target, e)
+		return
+	}
+
+	log.Infof("Successfully disabled integration " + fileName + " config file.")
+	w.Write([]byte("Success"))
+}
+
+// Returns the folders of the check configuration files: the custom checks
+// directory first, then the default checks directory
+func checkConfFolders(checkFolder string) []string {
+	return []string{
+		filepath.Join(config.Datadog.GetString("confd_path"), checkFolder),
+		filepath.Join(common.GetDistPath(), "conf.d", checkFolder),
+	}
+}
+
+// Helper function which refuses the paths outside of the configuration directories
+func validateConfigPath(checkFolder, fileName string) error {
+	if checkFolder != "" && (filepath.Ext(checkFolder) != ".d" || filepath.Base(checkFolder) != checkFolder || checkFolder == ".d") {
+		return fmt.Errorf("invalid check folder %q", checkFolder)
+	}
+	if fileName == "" || filepath.Base(fileName) != fileName || strings.HasPrefix(fileName, ".") || !hasRightEnding(fileName) {
+		return fmt.Errorf("invalid config file name %q", fileName)
+	}
+	return nil
+}
+
+// Helper function which checks that the data is a check configuration file
+func validateCheckConfig(data []byte) error {
+	cf := configFormat{}
+	if e := yaml.Unmarshal(data, &cf); e != nil {
+		return e
+	}
+	if cf.MetricConfig == nil && cf.LogsConfig == nil && len(cf.Instances) < 1 {
+		return fmt.Errorf("Configuration file contains no valid instances or log configuration")
+	}
+	if cf.InitConfig != nil {
+		if _, ok := cf.InitConfig.(map[interface{}]interface{}); !ok {
+			return fmt.Errorf("init_config should be a map")
 		}
+	}
+	for i, instance := range cf.Instances {
+		if instance == nil {
+			return fmt.Errorf("instance %d is empty", i+1)
+		}
+	}
+	return nil
+}
 
-		log.Infof("Successfully disabled integration " + fileName + " config file.")
-		w.Write([]byte("Success"))
+// Helper function which renames the enabled config file to .disabled, in the
+// first folder it's found in
+func disableCheckConfig(folders []string, fileName string) error {
+	return renameCheckConfig(folders, fileName, fileName+".disabled")
+}
+
+// Helper function which renames a .disabled config file back to its name, in
+// the first folder it's found in
+func enableCheckConfig(folders []string, fileName string) error {
+	return renameCheckConfig(folders, fileName+".disabled", fileName)
+}
+
+func renameCheckConfig(folders []string, from, to string) error {
+	var e error
+	for _, folder := range folders {
+		source := filepath.Join(folder, from)
+		if _, e = os.Stat(source); e != nil {
+			continue
+		}
+		destination := filepath.Join(folder, to)
+		if _, err := os.Stat(destination); err == nil {
+			return fmt.Errorf("%s already exists", destination)
+		}
+		return os.Rename(source, destination)
 	}
+	return e
 }
 
 func getWheelsChecks() ([]string, error) {
@@ -1,10 +1,14 @@
 package gui
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadConfDir(t *testing.T) {
@@ -39,3 +43,46 @@ func TestConfigsInPath(t *testing.T) {
 
 	assert.Equal(t, expected, files)
 }
+
+func TestValidateConfigPath(t *testing.T) {
+	assert.NoError(t, validateConfigPath("", "check.yaml"))
+	assert.NoError(t, validateConfigPath("foo.d", "conf.yaml.default"))
+	assert.Error(t, validateConfigPath("foo", "conf.yaml"))
+	assert.Error(t, validateConfigPath("..", "conf.yaml"))
+	assert.Error(t, validateConfigPath("foo.d", ".."))
+	assert.Error(t, validateConfigPath("foo.d", "conf.json"))
+	assert.Error(t, validateConfigPath("", ""))
+}
+
+func TestValidateCheckConfig(t *testing.T) {
+	assert.NoError(t, validateCheckConfig([]byte("init_config:\ninstances:\n  - host: localhost\n")))
+	assert.NoError(t, validateCheckConfig([]byte("logs:\n  - type: file\n    path: /var/log/foo.log\n")))
+	assert.Error(t, validateCheckConfig([]byte("instances: [")))
+	assert.Error(t, validateCheckConfig([]byte("init_config:\n")))
+	assert.Error(t, validateCheckConfig([]byte("init_config: foo\ninstances:\n  - host: localhost\n")))
+	assert.Error(t, validateCheckConfig([]byte("instances:\n  -\n")))
+}
+
+func TestEnableDisableCheckConfig(t *testing.T) {
+	custom, err := ioutil.TempDir("", "confd")
+	require.NoError(t, err)
+	defer os.RemoveAll(custom)
+	dist, err := ioutil.TempDir("", "dist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dist)
+	folders := []string{custom, dist}
+
+	// the config is in the default checks directory
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dist, "conf.yaml"), []byte("instances: [{}]"), 0600))
+	require.NoError(t, disableCheckConfig(folders, "conf.yaml"))
+	assert.FileExists(t, filepath.Join(dist, "conf.yaml.disabled"))
+	assert.Error(t, disableCheckConfig(folders, "conf.yaml"))
+
+	require.NoError(t, enableCheckConfig(folders, "conf.yaml"))
+	assert.FileExists(t, filepath.Join(dist, "conf.yaml"))
+	assert.Error(t, enableCheckConfig(folders, "conf.yaml"))
+
+	// an enabled file isn't overwritten
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dist, "conf.yaml.disabled"), []byte("instances: [{}]"), 0600))
+	assert.Error(t, enableCheckConfig(folders, "conf.yaml"))
+}
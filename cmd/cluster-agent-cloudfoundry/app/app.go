@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
@@ -181,6 +182,23 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// the CC API cache resolves the names and the metadata of the apps, it's optional
+	if config.Datadog.GetString("cloud_foundry_cc.client_id") != "" {
+		if err := initializeCCCache(mainCtx); err != nil {
+			log.Errorf("Failed to initialize the CC Cache, the tags of the apps won't be resolved: %v", err)
+		}
+	}
+
+	// start the logs agent before autoconfig so that it schedules the logs configs,
+	// e.g. the cloud_foundry sources reading the loggregator gateway
+	if config.Datadog.GetBool("logs_enabled") {
+		if err := logs.Start(); err != nil {
+			log.Errorf("Could not start logs-agent: %v", err)
+		}
+	} else {
+		log.Info("logs-agent disabled")
+	}
+
 	// create and setup the Autoconfig instance
 	common.SetupAutoConfig(config.Datadog.GetString("confd_path"))
 	// start the autoconfig, this will immediately run any configured check
@@ -216,6 +234,7 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Cancel the main context to stop components
 	mainCtxCancel()
+	logs.Stop()
 
 	log.Info("See ya!")
 	log.Flush()
@@ -253,6 +272,20 @@ func initializeBBSCache(ctx context.Context) error {
 	}
 }
 
+func initializeCCCache(ctx context.Context) error {
+	pollInterval := time.Second * time.Duration(config.Datadog.GetInt("cloud_foundry_cc.poll_interval"))
+	_, err := cloudfoundry.ConfigureGlobalCCCache(
+		ctx,
+		config.Datadog.GetString("cloud_foundry_cc.url"),
+		config.Datadog.GetString("cloud_foundry_cc.client_id"),
+		config.Datadog.GetString("cloud_foundry_cc.client_secret"),
+		config.Datadog.GetBool("cloud_foundry_cc.skip_ssl_validation"),
+		pollInterval,
+		nil,
+	)
+	return err
+}
+
 func setupClusterCheck(ctx context.Context) (*clusterchecks.Handler, error) {
 	handler, err := clusterchecks.NewHandler(common.AC)
 	if err != nil {
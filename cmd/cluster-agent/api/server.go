@@ -58,22 +58,9 @@ func StartServer(sc clusteragent.ServerContext) error {
 	// DCA client token
 	util.InitDCAAuthToken()
 
-	// create cert
-	hosts := []string{"127.0.0.1", "localhost"}
-	_, rootCertPEM, rootKey, err := security.GenerateRootCert(hosts, 2048)
+	rootTLSCert, err := getTLSCertificate()
 	if err != nil {
-		return fmt.Errorf("unable to start TLS server")
-	}
-
-	// PEM encode the private key
-	rootKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rootKey),
-	})
-
-	// Create a TLS cert using the private key and certificate
-	rootTLSCert, err := tls.X509KeyPair(rootCertPEM, rootKeyPEM)
-	if err != nil {
-		return fmt.Errorf("invalid key pair: %v", err)
+		return err
 	}
 
 	tlsConfig := tls.Config{
@@ -94,6 +81,37 @@ func StartServer(sc clusteragent.ServerContext) error {
 	return nil
 }
 
+// getTLSCertificate returns the certificate set with cluster_agent.tls_cert_file, that the
+// followers can verify, or a self-signed one
+func getTLSCertificate() (tls.Certificate, error) {
+	if certFile := config.Datadog.GetString("cluster_agent.tls_cert_file"); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, config.Datadog.GetString("cluster_agent.tls_key_file"))
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("invalid key pair: %v", err)
+		}
+		return cert, nil
+	}
+
+	// create cert
+	hosts := []string{"127.0.0.1", "localhost"}
+	_, rootCertPEM, rootKey, err := security.GenerateRootCert(hosts, 2048)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("unable to start TLS server")
+	}
+
+	// PEM encode the private key
+	rootKeyPEM := pem.EncodeToMemory(&pem.Block{
+		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rootKey),
+	})
+
+	// Create a TLS cert using the private key and certificate
+	rootTLSCert, err := tls.X509KeyPair(rootCertPEM, rootKeyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid key pair: %v", err)
+	}
+	return rootTLSCert, nil
+}
+
 // StopServer closes the connection and the server
 // stops listening to new commands.
 func StopServer() {
@@ -129,5 +147,7 @@ func isExternalPath(path string) bool {
 		strings.HasPrefix(path, "/api/v1/tags/pod/") && (len(strings.Split(path, "/")) == 6 || len(strings.Split(path, "/")) == 8) ||
 		strings.HasPrefix(path, "/api/v1/tags/node/") && len(strings.Split(path, "/")) == 6 ||
 		strings.HasPrefix(path, "/api/v1/clusterchecks/") && len(strings.Split(path, "/")) == 6 ||
-		strings.HasPrefix(path, "/api/v1/endpointschecks/") && len(strings.Split(path, "/")) == 6
+		strings.HasPrefix(path, "/api/v1/endpointschecks/") && len(strings.Split(path, "/")) == 6 ||
+		path == "/api/v1/tags/cf/apps" ||
+		strings.HasPrefix(path, "/api/v1/tags/cf/containers/") && len(strings.Split(path, "/")) == 7
 }
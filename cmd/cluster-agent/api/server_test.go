@@ -51,6 +51,16 @@ func TestValidateTokenMiddleware(t *testing.T) {
 			"bandit!",
 			http.StatusForbidden,
 		},
+		{
+			"/api/v1/tags/cf/apps",
+			"abc123",
+			http.StatusOK,
+		},
+		{
+			"/api/v1/tags/cf/containers/handle",
+			"abc123",
+			http.StatusOK,
+		},
 	}
 
 	for i, tt := range tests {
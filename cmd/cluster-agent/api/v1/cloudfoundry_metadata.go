@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installCloudFoundryMetadataEndpoints registers v1 API endpoints for the Cloud Foundry metadata
+func installCloudFoundryMetadataEndpoints(r *mux.Router) {
+	r.HandleFunc("/tags/cf/apps", getCFAppsMetadata).Methods("GET")
+	r.HandleFunc("/tags/cf/containers/{handle}", getCFContainerMetadata).Methods("GET")
+}
+
+// getCFAppsMetadata is used by the node agents to tag the containers of the
+// Cloud Foundry applications
+func getCFAppsMetadata(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/tags/cf/apps
+		Outputs
+			Status: 200
+			Returns: map[string][]string
+			Example: {"<app_guid>": ["app_guid:<app_guid>", "app_name:my-app", "org_name:my-org", "space_name:my-space"]}
+
+			Status: 412
+			Returns: string
+			Example: "BBS cache is not configured"
+	*/
+	bbsCache, err := cloudfoundry.GetGlobalBBSCache()
+	if err != nil {
+		http.Error(w, "BBS cache is not configured", http.StatusPreconditionFailed)
+		incrementRequestMetric("getCFAppsMetadata", http.StatusPreconditionFailed)
+		return
+	}
+
+	// the CC API is optional, the tags from BBS are served without it
+	var ccCache cloudfoundry.CCCacheI
+	if cc, err := cloudfoundry.GetGlobalCCCache(); err == nil {
+		ccCache = cc
+	}
+
+	tagsBytes, err := json.Marshal(cloudfoundry.GetAppTags(bbsCache, ccCache))
+	if err != nil {
+		log.Errorf("Could not process the tags of the Cloud Foundry applications: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getCFAppsMetadata", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(tagsBytes)
+	incrementRequestMetric("getCFAppsMetadata", http.StatusOK)
+}
+
+// getCFContainerMetadata is used by the node agents to tag a single container, so
+// that they don't have to download the tags of all the applications
+func getCFContainerMetadata(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/tags/cf/containers/<handle>
+		Outputs
+			Status: 200
+			Returns: []string
+			Example: ["app_guid:<app_guid>", "app_instance_guid:<handle>", "app_instance_index:0", "app_name:my-app"]
+
+			Status: 404
+			Returns: string
+			Example: "no ActualLRP found for the container <handle>"
+
+			Status: 412
+			Returns: string
+			Example: "BBS cache is not configured"
+	*/
+	bbsCache, err := cloudfoundry.GetGlobalBBSCache()
+	if err != nil {
+		http.Error(w, "BBS cache is not configured", http.StatusPreconditionFailed)
+		incrementRequestMetric("getCFContainerMetadata", http.StatusPreconditionFailed)
+		return
+	}
+
+	var ccCache cloudfoundry.CCCacheI
+	if cc, err := cloudfoundry.GetGlobalCCCache(); err == nil {
+		ccCache = cc
+	}
+
+	// the handle of the Garden container of an ActualLRP is its instance GUID
+	handle := mux.Vars(r)["handle"]
+	tags, found := cloudfoundry.GetInstanceTags(bbsCache, ccCache, handle)
+	if !found {
+		http.Error(w, fmt.Sprintf("no ActualLRP found for the container %s", handle), http.StatusNotFound)
+		incrementRequestMetric("getCFContainerMetadata", http.StatusNotFound)
+		return
+	}
+
+	tagsBytes, err := json.Marshal(tags)
+	if err != nil {
+		log.Errorf("Could not process the tags of the Cloud Foundry container %s: %v", handle, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("getCFContainerMetadata", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(tagsBytes)
+	incrementRequestMetric("getCFContainerMetadata", http.StatusOK)
+}
@@ -27,6 +27,7 @@ import (
 func installClusterCheckEndpoints(r *mux.Router, sc clusteragent.ServerContext) {
 	r.HandleFunc("/clusterchecks/status/{nodeName}", postCheckStatus(sc)).Methods("POST")
 	r.HandleFunc("/clusterchecks/configs/{nodeName}", getCheckConfigs(sc)).Methods("GET")
+	r.HandleFunc("/clusterchecks/flare/{nodeName}", postNodeFlare(sc)).Methods("POST")
 	r.HandleFunc("/clusterchecks", getState(sc)).Methods("GET")
 }
 
@@ -95,6 +96,38 @@ func getCheckConfigs(sc clusteragent.ServerContext) func(w http.ResponseWriter,
 	}
 }
 
+// postNodeFlare is used by the flare command to ask a node-agent for a flare
+func postNodeFlare(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
+	if sc.ClusterCheckHandler == nil {
+		return clusterChecksDisabledHandler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !shouldHandle(w, r, sc.ClusterCheckHandler, "postNodeFlare") {
+			return
+		}
+
+		vars := mux.Vars(r)
+		nodeName := vars["nodeName"]
+
+		var request cctypes.FlareRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			incrementRequestMetric("postNodeFlare", http.StatusBadRequest)
+			return
+		}
+
+		if !sc.ClusterCheckHandler.RequestNodeFlare(nodeName, request) {
+			http.Error(w, fmt.Sprintf("node %s is unknown", nodeName), http.StatusNotFound)
+			incrementRequestMetric("postNodeFlare", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		incrementRequestMetric("postNodeFlare", http.StatusAccepted)
+	}
+}
+
 // getState is used by the clustercheck config
 func getState(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
 	if sc.ClusterCheckHandler == nil {
@@ -133,8 +166,8 @@ func writeJSONResponse(w http.ResponseWriter, data interface{}, handler string)
 	incrementRequestMetric(handler, http.StatusNotFound)
 }
 
-// shouldHandle is common code to handle redirection and errors
-// due to the handler state
+// shouldHandle is common code to handle the forwarding to the leader and
+// errors due to the handler state
 func shouldHandle(w http.ResponseWriter, r *http.Request, h *clusterchecks.Handler, handler string) bool {
 	code, reason := h.ShouldHandle()
 
@@ -142,11 +175,12 @@ func shouldHandle(w http.ResponseWriter, r *http.Request, h *clusterchecks.Handl
 	case http.StatusOK:
 		return true
 	case http.StatusFound:
-		// Redirection to leader
-		url := r.URL
-		url.Host = reason
-		http.Redirect(w, r, url.String(), http.StatusFound)
-		incrementRequestMetric(handler, http.StatusFound)
+		// Forwarding to leader, or redirection when its certificate can't be verified
+		if transport := getLeaderTransport(); transport != nil {
+			forwardToLeader(w, r, reason, handler, transport)
+		} else {
+			redirectToLeader(w, r, reason, handler)
+		}
 		return false
 	default:
 		// Unexpected error
@@ -14,6 +14,8 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -35,8 +37,12 @@ func Install(r *mux.Router, sc clusteragent.ServerContext) {
 	r.HandleFunc("/tags/pod/{nodeName}", getPodMetadataForNode).Methods("GET")
 	r.HandleFunc("/tags/pod", getAllMetadata).Methods("GET")
 	r.HandleFunc("/tags/node/{nodeName}", getNodeMetadata).Methods("GET")
+	r.HandleFunc("/tags/namespace/{ns}", getNamespaceMetadata).Methods("GET")
 	installClusterCheckEndpoints(r, sc)
 	installEndpointsCheckEndpoints(r, sc)
+	installNodeConfigsEndpoints(r, sc)
+	installRecommendationsEndpoints(r, sc)
+	installCloudFoundryMetadataEndpoints(r)
 }
 
 // getNodeMetadata is only used when the node agent hits the DCA for the list of labels
@@ -98,6 +104,66 @@ func getNodeMetadata(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(fmt.Sprintf("Could not find labels on the node: %s", nodeName)))
 }
 
+// getNamespaceMetadata is only used when the node agent hits the DCA for the tags of a namespace.
+// The tags are extracted from the namespace annotations with kubernetes_namespace_annotations_as_tags.
+func getNamespaceMetadata(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/tags/namespace/default
+		Outputs
+			Status: 200
+			Returns: []string
+			Example: ["team:backend"]
+
+			Status: 404
+			Returns: string
+			Example: 404 page not found
+
+			Status: 500
+			Returns: string
+			Example: "Metadata collection is disabled on the Cluster Agent"
+	*/
+
+	vars := mux.Vars(r)
+	ns := vars["ns"]
+	annotations, err := as.GetNamespaceAnnotations(ns)
+	if err != nil {
+		log.Errorf("Could not retrieve the annotations of the namespace %s: %v", ns, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getNamespaceMetadata",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+
+	templates, errs := utils.ParseTagTemplates(config.Datadog.GetStringMapString("kubernetes_namespace_annotations_as_tags"))
+	for _, err := range errs {
+		log.Errorf("Invalid kubernetes_namespace_annotations_as_tags: %s", err)
+	}
+	tags := utils.ExtractTags(templates, annotations)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	tagBytes, err := json.Marshal(tags)
+	if err != nil {
+		log.Errorf("Could not process the tags of the namespace %s: %v", ns, err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getNamespaceMetadata",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(tagBytes)
+	apiRequests.Inc(
+		"getNamespaceMetadata",
+		strconv.Itoa(http.StatusOK),
+	)
+}
+
 // getPodMetadata is only used when the node agent hits the DCA for the tags list.
 // It returns a list of all the tags that can be directly used in the tagger of the agent.
 func getPodMetadata(w http.ResponseWriter, r *http.Request) {
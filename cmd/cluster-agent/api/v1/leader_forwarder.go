@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package v1
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// forwardedHeader marks the requests forwarded by a follower, so that they're not
+	// forwarded again when the followers disagree on the leader
+	forwardedHeader = "X-DCA-Follower-Forwarded"
+
+	leaderDialTimeout           = 5 * time.Second
+	leaderTLSHandshakeTimeout   = 5 * time.Second
+	leaderResponseHeaderTimeout = 10 * time.Second
+)
+
+var (
+	forwardedRequests = telemetry.NewCounterWithOpts("", "api_requests_forwarded",
+		[]string{"handler", "status"}, "Counter of requests forwarded by the cluster agent followers to the leader.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+
+	leaderTransportOnce sync.Once
+	leaderTransport     http.RoundTripper
+)
+
+// getLeaderTransport returns the transport of the requests forwarded to the leader, nil
+// when the certificate of the leader can't be verified and the requests are redirected
+func getLeaderTransport() http.RoundTripper {
+	leaderTransportOnce.Do(func() {
+		transport, err := newLeaderTransport()
+		if err != nil {
+			log.Warnf("The requests the leader must handle are redirected to it instead of forwarded: %v", err)
+			return
+		}
+		leaderTransport = transport
+	})
+	return leaderTransport
+}
+
+// newLeaderTransport creates a transport verifying the certificate the leader serves, set
+// with cluster_agent.tls_cert_file, against the cluster CA
+func newLeaderTransport() (*http.Transport, error) {
+	if config.Datadog.GetString("cluster_agent.tls_cert_file") == "" {
+		return nil, fmt.Errorf("cluster_agent.tls_cert_file is not set, the cluster agents serve self-signed certificates")
+	}
+	caFile := config.Datadog.GetString("cluster_agent.tls_ca_file")
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the cluster CA: %v", err)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in the cluster CA %s", caFile)
+	}
+
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   leaderDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig: &tls.Config{
+			RootCAs: rootCAs,
+			// the leader is reached by its pod IP, its certificate is the one of the service
+			ServerName: config.Datadog.GetString("cluster_agent.kubernetes_service_name"),
+		},
+		TLSHandshakeTimeout:   leaderTLSHandshakeTimeout,
+		ResponseHeaderTimeout: leaderResponseHeaderTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	}, nil
+}
+
+// redirectToLeader redirects a request the leader must handle to the leader, at leaderAddr
+func redirectToLeader(w http.ResponseWriter, r *http.Request, leaderAddr string, handler string) {
+	url := r.URL
+	url.Host = leaderAddr
+	http.Redirect(w, r, url.String(), http.StatusFound)
+	incrementRequestMetric(handler, http.StatusFound)
+}
+
+// forwardToLeader forwards a request the leader must handle to the leader, at
+// leaderAddr, with the transport, and writes its response. The node agents don't have
+// to follow a redirect.
+func forwardToLeader(w http.ResponseWriter, r *http.Request, leaderAddr string, handler string, transport http.RoundTripper) {
+	if r.Header.Get(forwardedHeader) != "" {
+		// the cluster agent this request was forwarded to isn't the leader either,
+		// the node agent retries later
+		http.Error(w, "the leader is changing", http.StatusServiceUnavailable)
+		incrementRequestMetric(handler, http.StatusServiceUnavailable)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "https"
+			req.URL.Host = leaderAddr
+			req.Header.Set(forwardedHeader, "true")
+		},
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			forwardedRequests.Inc(handler, strconv.Itoa(resp.StatusCode))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Warnf("Could not forward the request %s to the leader at %s: %v", r.URL.Path, leaderAddr, err)
+			http.Error(w, "could not reach the leader", http.StatusServiceUnavailable)
+			forwardedRequests.Inc(handler, strconv.Itoa(http.StatusServiceUnavailable))
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package v1
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestForwardToLeader(t *testing.T) {
+	leader := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/clusterchecks/status/node1", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "10.0.0.1", r.Header.Get("X-Real-Ip"))
+		assert.Equal(t, "true", r.Header.Get(forwardedHeader))
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"LastChange":0}`, string(body))
+		w.Write([]byte(`{"isuptodate":true}`))
+	}))
+	defer leader.Close()
+	leaderURL, err := url.Parse(leader.URL)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "https://follower:5005/api/v1/clusterchecks/status/node1", strings.NewReader(`{"LastChange":0}`))
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Real-Ip", "10.0.0.1")
+	w := httptest.NewRecorder()
+	forwardToLeader(w, req, leaderURL.Host, "postCheckStatus", leader.Client().Transport)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"isuptodate":true}`, w.Body.String())
+
+	// the requests are forwarded once
+	req = httptest.NewRequest("GET", "https://follower:5005/api/v1/clusterchecks/configs/node1", nil)
+	req.Header.Set(forwardedHeader, "true")
+	w = httptest.NewRecorder()
+	forwardToLeader(w, req, leaderURL.Host, "getCheckConfigs", leader.Client().Transport)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// the node agent retries when the leader can't be reached
+	leader.Close()
+	req = httptest.NewRequest("GET", "https://follower:5005/api/v1/clusterchecks/configs/node1", nil)
+	w = httptest.NewRecorder()
+	forwardToLeader(w, req, leaderURL.Host, "getCheckConfigs", leader.Client().Transport)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestNewLeaderTransport(t *testing.T) {
+	leader := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"isuptodate":true}`))
+	}))
+	defer leader.Close()
+	leaderURL, err := url.Parse(leader.URL)
+	require.NoError(t, err)
+
+	caFile, err := ioutil.TempFile("", "ca.crt")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	require.NoError(t, pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: leader.Certificate().Raw}))
+	caFile.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("cluster_agent.tls_ca_file", caFile.Name())
+	// the certificate of the test server is valid for example.com
+	mockConfig.Set("cluster_agent.kubernetes_service_name", "example.com")
+
+	// the leader serves a self-signed certificate
+	_, err = newLeaderTransport()
+	assert.Error(t, err)
+
+	mockConfig.Set("cluster_agent.tls_cert_file", "/etc/datadog-agent/certificates/tls.crt")
+	transport, err := newLeaderTransport()
+	require.NoError(t, err)
+	req := httptest.NewRequest("GET", "https://follower:5005/api/v1/clusterchecks/configs/node1", nil)
+	w := httptest.NewRecorder()
+	forwardToLeader(w, req, leaderURL.Host, "getCheckConfigs", transport)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the certificate isn't valid for the service
+	mockConfig.Set("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
+	transport, err = newLeaderTransport()
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	forwardToLeader(w, req, leaderURL.Host, "getCheckConfigs", transport)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// installNodeConfigsEndpoints registers v1 API endpoints for node configs
+func installNodeConfigsEndpoints(r *mux.Router, sc clusteragent.ServerContext) {
+	r.HandleFunc("/configs/node/{nodeName}", getNodeConfigs(sc)).Methods("GET")
+}
+
+// getNodeConfigs is used by the node-agent's node_configs config provider.
+// The node agent passes the version of its templates in the `version` query
+// parameter, the templates are only sent when it changed.
+func getNodeConfigs(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
+	if sc.NodeConfigsStore == nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte("Node configs are not enabled"))
+			incrementRequestMetric("GetNodeConfigs", http.StatusPreconditionFailed)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Tracef("Node %s polled the node configs", mux.Vars(r)["nodeName"])
+		if version, found := r.URL.Query()["version"]; found && sc.NodeConfigsStore.IsUpToDate(version[0]) {
+			w.WriteHeader(http.StatusNotModified)
+			incrementRequestMetric("GetNodeConfigs", http.StatusNotModified)
+			return
+		}
+
+		body, err := json.Marshal(sc.NodeConfigsStore.GetConfigs())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			incrementRequestMetric("GetNodeConfigs", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		incrementRequestMetric("GetNodeConfigs", http.StatusOK)
+	}
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+)
+
+// installRecommendationsEndpoints registers v1 API endpoints for the resource recommendations
+func installRecommendationsEndpoints(r *mux.Router, sc clusteragent.ServerContext) {
+	r.HandleFunc("/recommendations", getRecommendations(sc)).Methods("GET")
+}
+
+// getRecommendations returns the right-sizing recommendations of the
+// containers of the workloads. They're only computed by the leader, the
+// followers answer with a 503.
+func getRecommendations(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
+	if sc.RecommendationsStore == nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte("Resource recommendations are not enabled"))
+			incrementRequestMetric("GetRecommendations", http.StatusPreconditionFailed)
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, computed := sc.RecommendationsStore.Get()
+		if !computed {
+			http.Error(w, "the recommendations are not computed yet, or this cluster agent isn't the leader", http.StatusServiceUnavailable)
+			incrementRequestMetric("GetRecommendations", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			incrementRequestMetric("GetRecommendations", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		incrementRequestMetric("GetRecommendations", http.StatusOK)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -29,8 +30,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/orchestrator"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/recommendations"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
@@ -174,9 +178,13 @@ func start(cmd *cobra.Command, args []string) error {
 
 	log.Infof("Datadog Cluster Agent is now running.")
 
+	var recommendationsStore *recommendations.Store
 	apiCl, err := apiserver.GetAPIClient() // make sure we can connect to the apiserver
 	if err != nil {
 		log.Errorf("Could not connect to the apiserver: %v", err)
+		apiErr := err
+		healthprobe.RegisterReadinessGate("read", func() error { return apiErr })
+		healthprobe.RegisterReadinessGate("leader", func() error { return apiErr })
 	} else {
 		le, err := leaderelection.GetLeaderEngine()
 		if err != nil {
@@ -204,6 +212,17 @@ func start(cmd *cobra.Command, args []string) error {
 			log.Errorf("Could not start controllers: %v", err)
 		}
 
+		// Every replica serves the tags from its own informers, only the leader
+		// handles the cluster checks dispatching and the autoscalers: the Services
+		// select the replicas with the readiness gate matching the role they need.
+		healthprobe.RegisterReadinessGate("read", apiserver.ReadPathReady)
+		healthprobe.RegisterReadinessGate("leader", func() error {
+			if !config.Datadog.GetBool("leader_election") || le.IsLeader() {
+				return nil
+			}
+			return fmt.Errorf("not the leader, the leader is %q", le.GetLeader())
+		})
+
 		// Generate and persist a cluster ID
 		// this must be a UUID, and ideally be stable for the lifetime of a cluster
 		// so we store it in a configmap that we try and read before generating a new one.
@@ -217,6 +236,7 @@ func start(cmd *cobra.Command, args []string) error {
 		orchestratorCtx := orchestrator.ControllerContext{
 			IsLeaderFunc:                 le.IsLeader,
 			UnassignedPodInformerFactory: apiCl.UnassignedPodInformerFactory,
+			InformerFactory:              apiCl.InformerFactory,
 			Client:                       apiCl.Cl,
 			StopCh:                       stopCh,
 			Hostname:                     hostname,
@@ -227,6 +247,23 @@ func start(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			log.Errorf("Could not start orchestrator controller: %v", err)
 		}
+
+		if config.Datadog.GetBool("resource_recommendations.enabled") {
+			// Compute the right-sizing recommendations of the workloads
+			recommender, err := recommendations.NewRecommender(apiCl.InformerFactory, eventRecorder, le.IsLeader)
+			if err != nil {
+				log.Errorf("Could not start the resource recommender: %v", err)
+			} else {
+				recommendationsStore = recommender.Store()
+				go recommender.Run(stopCh)
+			}
+		}
+
+		if config.Datadog.GetBool("admission_controller.enabled") {
+			if err := admission.Start(mainCtx, apiCl.Cl); err != nil {
+				log.Errorf("Could not start the admission controller: %v", err)
+			}
+		}
 	}
 
 	// Setup a channel to catch OS signals
@@ -248,11 +285,21 @@ func start(cmd *cobra.Command, args []string) error {
 		log.Debug("Cluster check Autodiscovery disabled")
 	}
 
+	var nodeConfigsStore *nodeconfigs.Store
+	if config.Datadog.GetBool("node_configs.enabled") {
+		// Serve the node check templates to the node agents
+		pollPeriod := time.Duration(config.Datadog.GetInt("ad_config_poll_interval")) * time.Second
+		nodeConfigsStore = nodeconfigs.NewStore(config.Datadog.GetString("node_configs.confd_path"), pollPeriod)
+		go nodeConfigsStore.Run(mainCtx)
+	}
+
 	// Start the cmd HTTPS server
 	// We always need to start it, even with nil clusterCheckHandler
 	// as it's also used to perform the agent commands (e.g. agent status)
 	sc := clusteragent.ServerContext{
-		ClusterCheckHandler: clusterCheckHandler,
+		ClusterCheckHandler:  clusterCheckHandler,
+		NodeConfigsStore:     nodeConfigsStore,
+		RecommendationsStore: recommendationsStore,
 	}
 	if err = api.StartServer(sc); err != nil {
 		return log.Errorf("Error while starting agent API, exiting: %v", err)
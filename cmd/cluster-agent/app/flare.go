@@ -9,13 +9,18 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/util/input"
@@ -24,6 +29,8 @@ import (
 var (
 	customerEmail string
 	autoconfirm   bool
+	nodeName      string
+	nodeProfile   string
 )
 
 func init() {
@@ -31,6 +38,8 @@ func init() {
 
 	flareCmd.Flags().StringVarP(&customerEmail, "email", "e", "", "Your email")
 	flareCmd.Flags().BoolVarP(&autoconfirm, "send", "s", false, "Automatically send flare (don't prompt for confirmation)")
+	flareCmd.Flags().StringVarP(&nodeName, "node", "", "", "Ask the node agent with this name, as listed by the clusterchecks command, to build and send a flare")
+	flareCmd.Flags().StringVarP(&nodeProfile, "profile", "p", string(flare.ProfileStandard), "Which content to include in the flare of the node agent: minimal, standard or full")
 	flareCmd.SetArgs([]string{"caseID"})
 }
 
@@ -72,6 +81,14 @@ var flareCmd = &cobra.Command{
 			}
 		}
 
+		if nodeName != "" {
+			profile, err := flare.ParseProfile(nodeProfile)
+			if err != nil {
+				return err
+			}
+			return requestNodeFlare(nodeName, caseID, profile)
+		}
+
 		return requestFlare(caseID)
 	},
 }
@@ -127,3 +144,58 @@ func requestFlare(caseID string) error {
 	}
 	return nil
 }
+
+// requestNodeFlare asks the cluster agent to pass a flare request to a node
+// agent, the node agent builds and sends the flare on its next status report
+func requestNodeFlare(nodeName, caseID string, profile flare.Profile) error {
+	if !config.Datadog.GetBool("cluster_checks.enabled") {
+		return fmt.Errorf("the flares of the node agents are requested through the cluster checks, they must be enabled")
+	}
+
+	fmt.Fprintln(color.Output, fmt.Sprintf("The node agent %s is going to build a flare with the %s profile and upload it to Datadog", color.YellowString(nodeName), profile))
+	if !autoconfirm {
+		confirmation := input.AskForConfirmation("Are you sure you want the node agent to upload a flare? [Y/N]")
+		if !confirmation {
+			fmt.Fprintln(color.Output, "Aborting.")
+			return nil
+		}
+	}
+
+	// The request is authenticated with the token shared with the node agents,
+	// so that the followers can forward it to the leader
+	if err := util.InitDCAAuthToken(); err != nil {
+		return err
+	}
+	body, err := json.Marshal(types.FlareRequest{
+		CaseID:  caseID,
+		Email:   customerEmail,
+		Profile: string(profile),
+	})
+	if err != nil {
+		return err
+	}
+
+	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/clusterchecks/flare/%s", config.Datadog.GetInt("cluster_agent.cmd_port"), url.PathEscape(nodeName))
+	req, err := http.NewRequest("POST", urlstr, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+util.GetDCAAuthToken())
+
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	resp, err := c.Do(req)
+	if err != nil {
+		fmt.Fprintln(color.Output, color.RedString("The Cluster Agent was unable to request the flare (is it running?)"))
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		fmt.Fprintln(color.Output, fmt.Sprintf("The Cluster Agent could not request the flare: %s", color.RedString(string(bytes.TrimSpace(msg)))))
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	fmt.Fprintln(color.Output, color.GreenString("The flare was requested, the node agent builds and sends it after its next status report. Its logs show the result."))
+	return nil
+}
@@ -231,6 +231,9 @@ func initInfo(conf *config.AgentConfig) error {
 		expvar.Publish("container_count", expvar.Func(publishContainerCount))
 		expvar.Publish("queue_size", expvar.Func(publishQueueSize))
 		expvar.Publish("container_id", expvar.Func(publishContainerID))
+		expvar.Publish("scrubbed_args", expvar.Func(func() interface{} {
+			return conf.Scrubber.ScrubbedArgsCount()
+		}))
 
 		infoTmpl, err = template.New("info").Funcs(funcMap).Parse(infoTmplSrc)
 		if err != nil {
@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/statsd"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/remote"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -109,7 +110,16 @@ func runAgent(exit chan bool) {
 	log.Infof("running version: %s", versionString(", "))
 
 	// Tagger must be initialized after agent config has been setup
-	tagger.Init()
+	if ddconfig.Datadog.GetBool("remote_tagger.enabled") {
+		remoteTagger := remote.NewTagger()
+		if err := remoteTagger.Start(); err != nil {
+			log.Criticalf("Error starting the remote tagger: %s", err)
+			cleanupAndExit(1)
+		}
+		tagger.InitRemote(remoteTagger)
+	} else {
+		tagger.Init()
+	}
 	defer tagger.Stop()
 
 	err = initInfo(cfg)
@@ -29,23 +29,18 @@ var inactivityLogDuration = 10 * time.Minute
 type SystemProbe struct {
 	cfg *config.AgentConfig
 
-	tracer *ebpf.Tracer
+	tracer ebpf.ConnectionTracer
 	conn   net.Conn
 
 	tcpQueueLengthTracer *ebpf.TCPQueueLengthTracer
+	oomKillTracer        *ebpf.OOMKillTracer
+	tcpStatsTracer       *ebpf.TCPStatsTracer
 }
 
 // CreateSystemProbe creates a SystemProbe as well as it's UDS socket after confirming that the OS supports BPF-based
 // system probe
 func CreateSystemProbe(cfg *config.AgentConfig) (*SystemProbe, error) {
-	// Checking whether the current OS + kernel version is supported by the tracer
-	if supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions); !supported {
-		return nil, fmt.Errorf("%s: %s", ErrSysprobeUnsupported, msg)
-	}
-
-	log.Infof("Creating tracer for: %s", filepath.Base(os.Args[0]))
-
-	t, err := ebpf.NewTracer(config.SysProbeConfigFromConfig(cfg))
+	t, err := createTracer(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +56,28 @@ func CreateSystemProbe(cfg *config.AgentConfig) (*SystemProbe, error) {
 		log.Infof("TCP queue length tracer disabled")
 	}
 
+	var oomkt *ebpf.OOMKillTracer
+	if cfg.CheckIsEnabled("OOM Kill") {
+		log.Infof("Starting the OOM Kill tracer")
+		oomkt, err = ebpf.NewOOMKillTracer()
+		if err != nil {
+			log.Errorf("unable to start the OOM Kill tracer: %v", err)
+		}
+	} else {
+		log.Infof("OOM Kill tracer disabled")
+	}
+
+	var tcpst *ebpf.TCPStatsTracer
+	if cfg.CheckIsEnabled("TCP stats") {
+		log.Infof("Starting the TCP stats tracer")
+		tcpst, err = ebpf.NewTCPStatsTracer()
+		if err != nil {
+			log.Errorf("unable to start the TCP stats tracer: %v", err)
+		}
+	} else {
+		log.Infof("TCP stats tracer disabled")
+	}
+
 	// Setting up the unix socket
 	conn, err := net.NewListener(cfg)
 	if err != nil {
@@ -70,11 +87,46 @@ func CreateSystemProbe(cfg *config.AgentConfig) (*SystemProbe, error) {
 	return &SystemProbe{
 		tracer:               t,
 		tcpQueueLengthTracer: tqlt,
+		oomKillTracer:        oomkt,
+		tcpStatsTracer:       tcpst,
 		cfg:                  cfg,
 		conn:                 conn,
 	}, nil
 }
 
+// createTracer creates the eBPF tracer, or the fallback tracer reading the sockets of the host if it's
+// enabled and the eBPF tracer isn't supported or can't be loaded
+func createTracer(cfg *config.AgentConfig) (ebpf.ConnectionTracer, error) {
+	tracerConfig := config.SysProbeConfigFromConfig(cfg)
+
+	// Checking whether the current OS + kernel version is supported by the tracer
+	supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions)
+	if !supported && !tracerConfig.EnableFallback {
+		return nil, fmt.Errorf("%s: %s", ErrSysprobeUnsupported, msg)
+	}
+
+	if supported {
+		log.Infof("Creating tracer for: %s", filepath.Base(os.Args[0]))
+
+		t, err := ebpf.NewTracer(tracerConfig)
+		if err == nil {
+			return t, nil
+		} else if !tracerConfig.EnableFallback {
+			return nil, err
+		}
+		msg = err.Error()
+	}
+
+	log.Warnf("could not create the eBPF tracer, falling back to reading the sockets of the host: %s", msg)
+	t, err := ebpf.NewFallbackTracer(tracerConfig)
+	if err != nil && !supported {
+		return nil, fmt.Errorf("%s: %s, could not create the fallback tracer: %s", ErrSysprobeUnsupported, msg, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not create the fallback tracer: %s", err)
+	}
+	return t, nil
+}
+
 // Run makes available the HTTP endpoint for network collection
 func (nt *SystemProbe) Run() {
 	// if a debug port is specified, we expose the default handler to that port
@@ -153,11 +205,39 @@ func (nt *SystemProbe) Run() {
 		writeAsJSON(w, stats)
 	})
 
+	httpMux.HandleFunc("/check/oom_kill", func(w http.ResponseWriter, req *http.Request) {
+		if nt.oomKillTracer == nil {
+			log.Errorf("OOM Kill tracer was not properly initialized")
+			w.WriteHeader(500)
+			return
+		}
+		stats := nt.oomKillTracer.GetAndFlush()
+
+		writeAsJSON(w, stats)
+	})
+
+	httpMux.HandleFunc("/check/tcp_stats", func(w http.ResponseWriter, req *http.Request) {
+		if nt.tcpStatsTracer == nil {
+			log.Errorf("TCP stats tracer was not properly initialized")
+			w.WriteHeader(500)
+			return
+		}
+		stats := nt.tcpStatsTracer.GetAndFlush()
+
+		writeAsJSON(w, stats)
+	})
+
 	go func() {
 		tags := []string{
 			fmt.Sprintf("version:%s", Version),
 			fmt.Sprintf("revision:%s", GitCommit),
 		}
+		// the mode tells whether the connections are tracked with eBPF or with the fallback tracer
+		if stats, err := nt.tracer.GetStats(); err == nil {
+			if mode, ok := stats["mode"].(string); ok {
+				tags = append(tags, fmt.Sprintf("tracer_mode:%s", mode))
+			}
+		}
 		heartbeat := time.NewTicker(15 * time.Second)
 		for range heartbeat.C {
 			statsd.Client.Gauge("datadog.system_probe.agent", 1, tags, 1)
@@ -221,4 +301,13 @@ func writeAsJSON(w http.ResponseWriter, data interface{}) {
 func (nt *SystemProbe) Close() {
 	nt.conn.Stop()
 	nt.tracer.Stop()
+	if nt.tcpQueueLengthTracer != nil {
+		nt.tcpQueueLengthTracer.Close()
+	}
+	if nt.oomKillTracer != nil {
+		nt.oomKillTracer.Close()
+	}
+	if nt.tcpStatsTracer != nil {
+		nt.tcpStatsTracer.Close()
+	}
 }
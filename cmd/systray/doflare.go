@@ -206,7 +206,7 @@ func requestFlare(caseID, customerEmail string) (response string, e error) {
 		}
 		log.Debug("Initiating flare locally.")
 
-		filePath, e = flare.CreateArchive(true, common.GetDistPath(), common.PyChecksPath, logFile)
+		filePath, e = flare.CreateArchive(true, flare.ProfileStandard, common.GetDistPath(), common.PyChecksPath, logFile)
 		if e != nil {
 			log.Errorf("The flare zipfile failed to be created: %s\n", e)
 			return
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util"
@@ -102,6 +103,8 @@ var (
 	aggregatorServiceCheck                     = expvar.Int{}
 	aggregatorEvent                            = expvar.Int{}
 	aggregatorHostnameUpdate                   = expvar.Int{}
+	aggregatorSlowFlushes                      = expvar.Int{}
+	aggregatorFlushIntervalSeconds             = expvar.Int{}
 
 	tlmFlush = telemetry.NewCounter("aggregator", "flush",
 		[]string{"data_type", "state"}, "Count of flush")
@@ -109,6 +112,12 @@ var (
 		[]string{"data_type"}, "Amount of metrics/services_checks/events processed by the aggregator")
 	tlmHostnameUpdate = telemetry.NewCounter("aggregator", "hostname_update",
 		nil, "Count of hostname update")
+	tlmFlushDuration = telemetry.NewGauge("aggregator", "flush_duration_seconds",
+		[]string{"data_type"}, "Duration of the last flush, from its start to the end of the serialization")
+	tlmSerializationDuration = telemetry.NewGauge("aggregator", "serialization_duration_seconds",
+		[]string{"data_type"}, "Time spent serializing the payloads of the last flush")
+	tlmSlowFlushes = telemetry.NewCounter("aggregator", "slow_flushes",
+		nil, "Count of flushes that took longer than the flush interval")
 
 	// Hold series to be added to aggregated series on each flush
 	recurrentSeries     metrics.Series
@@ -121,6 +130,8 @@ func init() {
 	newFlushTimeStats("EventFlushTime")
 	newFlushTimeStats("MainFlushTime")
 	newFlushTimeStats("MetricSketchFlushTime")
+	newFlushTimeStats("SeriesSerializationTime")
+	newFlushTimeStats("SketchSerializationTime")
 	aggregatorExpvars.Set("Flush", expvar.Func(expStatsMap(flushTimeStats)))
 
 	newFlushCountStats("ServiceChecks")
@@ -144,6 +155,9 @@ func init() {
 	aggregatorExpvars.Set("ServiceCheck", &aggregatorServiceCheck)
 	aggregatorExpvars.Set("Event", &aggregatorEvent)
 	aggregatorExpvars.Set("HostnameUpdate", &aggregatorHostnameUpdate)
+	aggregatorExpvars.Set("SlowFlushes", &aggregatorSlowFlushes)
+	aggregatorExpvars.Set("FlushIntervalSeconds", &aggregatorFlushIntervalSeconds)
+	aggregatorExpvars.Set("Pipelines", expvar.Func(getPipelinesStats))
 }
 
 // InitAggregator returns the Singleton instance
@@ -194,9 +208,13 @@ type BufferedAggregator struct {
 	// Used by the Dogstatsd Batcher.
 	MetricSamplePool *metrics.MetricSamplePool
 
-	statsdSampler      TimeSampler
+	// statsdPipelines aggregate the dogstatsd samples, each of them
+	// handles a shard of the contexts, see PipelineIndex.
+	statsdPipelines    []*timeSamplerWorker
+	keyGenerator       *ckey.KeyGenerator // used to shard the samples received on metricIn and bufferedMetricIn
 	checkSamplers      map[check.ID]*CheckSampler
 	serviceChecks      metrics.ServiceChecks
+	serviceCheckEvents *serviceCheckEvents // nil unless `service_check_events.service_checks` is set
 	events             metrics.Events
 	flushInterval      time.Duration
 	mu                 sync.Mutex // to protect the checkSamplers field
@@ -213,6 +231,18 @@ type BufferedAggregator struct {
 // NewBufferedAggregator instantiates a BufferedAggregator
 func NewBufferedAggregator(s serializer.MetricSerializer, hostname, agentName string, flushInterval time.Duration) *BufferedAggregator {
 	bufferSize := config.Datadog.GetInt("aggregator_buffer_size")
+	metricSamplePool := metrics.NewMetricSamplePool(MetricSamplePoolBatchSize)
+
+	pipelineCount := getPipelineCount()
+	statsdPipelines := make([]*timeSamplerWorker, 0, pipelineCount)
+	for i := 0; i < pipelineCount; i++ {
+		statsdPipelines = append(statsdPipelines, newTimeSamplerWorker(i, bufferSize, metricSamplePool))
+	}
+	if pipelineCount > 1 {
+		log.Infof("Aggregating dogstatsd metrics with %d pipelines", pipelineCount)
+	}
+	resetPipelinesStats(pipelineCount)
+	aggregatorFlushIntervalSeconds.Set(int64(flushInterval / time.Second))
 
 	aggregator := &BufferedAggregator{
 		bufferedMetricIn:       make(chan []metrics.MetricSample, bufferSize),
@@ -226,10 +256,12 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname, agentName st
 		checkMetricIn:          make(chan senderMetricSample, bufferSize),
 		checkHistogramBucketIn: make(chan senderHistogramBucket, bufferSize),
 
-		MetricSamplePool: metrics.NewMetricSamplePool(MetricSamplePoolBatchSize),
+		MetricSamplePool: metricSamplePool,
 
-		statsdSampler:      *NewTimeSampler(bucketSize),
+		statsdPipelines:    statsdPipelines,
+		keyGenerator:       ckey.NewKeyGenerator(),
 		checkSamplers:      make(map[check.ID]*CheckSampler),
+		serviceCheckEvents: newServiceCheckEvents(),
 		flushInterval:      flushInterval,
 		serializer:         s,
 		hostname:           hostname,
@@ -269,6 +301,18 @@ func (agg *BufferedAggregator) GetBufferedChannels() (chan []metrics.MetricSampl
 	return agg.bufferedMetricIn, agg.bufferedEventIn, agg.bufferedServiceCheckIn
 }
 
+// GetBufferedMetricsPipelines returns one channel per dogstatsd pipeline. The samples
+// sent on these channels must be sharded by the caller with PipelineIndex, this saves
+// the aggregator goroutine from doing it. The batches are put back in MetricSamplePool
+// once processed.
+func (agg *BufferedAggregator) GetBufferedMetricsPipelines() []chan []metrics.MetricSample {
+	pipelines := make([]chan []metrics.MetricSample, 0, len(agg.statsdPipelines))
+	for _, worker := range agg.statsdPipelines {
+		pipelines = append(pipelines, worker.samplesChan)
+	}
+	return pipelines
+}
+
 // SetHostname sets the hostname that the aggregator uses by default on all the data it sends
 // Blocks until the main aggregator goroutine has finished handling the update
 func (agg *BufferedAggregator) SetHostname(hostname string) {
@@ -351,6 +395,12 @@ func (agg *BufferedAggregator) addServiceCheck(sc metrics.ServiceCheck) {
 	sc.Tags = util.SortUniqInPlace(sc.Tags)
 
 	agg.serviceChecks = append(agg.serviceChecks, &sc)
+
+	if agg.serviceCheckEvents != nil {
+		if e := agg.serviceCheckEvents.process(&sc, time.Now()); e != nil {
+			agg.addEvent(*e)
+		}
+	}
 }
 
 // addEvent adds the event to the slice of current events
@@ -363,16 +413,42 @@ func (agg *BufferedAggregator) addEvent(e metrics.Event) {
 	agg.events = append(agg.events, &e)
 }
 
-// addSample adds the metric sample
-func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, timestamp float64) {
-	metricSample.Tags = util.SortUniqInPlace(metricSample.Tags)
-	agg.statsdSampler.addSample(metricSample, timestamp)
+// addSamples dispatches the metric samples to the pipelines in charge of their contexts.
+// The batch is put back in the MetricSamplePool once processed.
+func (agg *BufferedAggregator) addSamples(metricSamples []metrics.MetricSample) {
+	if len(agg.statsdPipelines) == 1 {
+		agg.statsdPipelines[0].samplesChan <- metricSamples
+		return
+	}
+
+	batches := make([][]metrics.MetricSample, len(agg.statsdPipelines))
+	for i := range metricSamples {
+		idx := PipelineIndex(agg.keyGenerator, &metricSamples[i], len(agg.statsdPipelines))
+		if batches[idx] == nil {
+			batches[idx] = agg.MetricSamplePool.GetBatch()[:0]
+		}
+		batches[idx] = append(batches[idx], metricSamples[i])
+	}
+	agg.MetricSamplePool.PutBatch(metricSamples)
+
+	for idx, batch := range batches {
+		if batch != nil {
+			agg.statsdPipelines[idx].samplesChan <- batch
+		}
+	}
 }
 
 // GetSeriesAndSketches grabs all the series & sketches from the queue and clears the queue
 func (agg *BufferedAggregator) GetSeriesAndSketches() (metrics.Series, metrics.SketchSeriesList) {
 	agg.mu.Lock()
-	series, sketches := agg.statsdSampler.flush(timeNowNano())
+	var series metrics.Series
+	var sketches metrics.SketchSeriesList
+	timestamp := timeNowNano()
+	for _, worker := range agg.statsdPipelines {
+		s, sk := worker.flush(timestamp)
+		series = append(series, s...)
+		sketches = append(sketches, sk...)
+	}
 
 	for _, checkSampler := range agg.checkSamplers {
 		s, sk := checkSampler.flush()
@@ -383,9 +459,24 @@ func (agg *BufferedAggregator) GetSeriesAndSketches() (metrics.Series, metrics.S
 	return series, sketches
 }
 
+// checkFlushDuration warns when a flush, including its serialization, took longer
+// than the flush interval: the next flush has started before the end of this one.
+func (agg *BufferedAggregator) checkFlushDuration(dataType string, duration time.Duration) {
+	tlmFlushDuration.Set(duration.Seconds(), dataType)
+	if agg.flushInterval > 0 && duration > agg.flushInterval {
+		log.Warnf("Flushing %s took %s, longer than the flush interval (%s): datapoints may be delayed or dropped", dataType, duration, agg.flushInterval)
+		aggregatorSlowFlushes.Add(1)
+		tlmSlowFlushes.Inc()
+	}
+}
+
 func (agg *BufferedAggregator) pushSketches(start time.Time, sketches metrics.SketchSeriesList) {
 	log.Debugf("Flushing %d sketches to the forwarder", len(sketches))
+	serializationStart := time.Now()
 	err := agg.serializer.SendSketch(sketches)
+	serializationDuration := time.Since(serializationStart)
+	addFlushTime("SketchSerializationTime", int64(serializationDuration))
+	tlmSerializationDuration.Set(serializationDuration.Seconds(), "sketches")
 	state := stateOk
 	if err != nil {
 		log.Warnf("Error flushing sketch: %v", err)
@@ -393,13 +484,18 @@ func (agg *BufferedAggregator) pushSketches(start time.Time, sketches metrics.Sk
 		state = stateError
 	}
 	addFlushTime("MetricSketchFlushTime", int64(time.Since(start)))
+	agg.checkFlushDuration("sketches", time.Since(start))
 	aggregatorSketchesFlushed.Add(int64(len(sketches)))
 	tlmFlush.Add(float64(len(sketches)), "sketches", state)
 }
 
 func (agg *BufferedAggregator) pushSeries(start time.Time, series metrics.Series) {
 	log.Debugf("Flushing %d series to the forwarder", len(series))
+	serializationStart := time.Now()
 	err := agg.serializer.SendSeries(series)
+	serializationDuration := time.Since(serializationStart)
+	addFlushTime("SeriesSerializationTime", int64(serializationDuration))
+	tlmSerializationDuration.Set(serializationDuration.Seconds(), "series")
 	state := stateOk
 	if err != nil {
 		log.Warnf("Error flushing series: %v", err)
@@ -407,6 +503,7 @@ func (agg *BufferedAggregator) pushSeries(start time.Time, series metrics.Series
 		state = stateError
 	}
 	addFlushTime("ChecksMetricSampleFlushTime", int64(time.Since(start)))
+	agg.checkFlushDuration("series", time.Since(start))
 	aggregatorSeriesFlushed.Add(int64(len(series)))
 	tlmFlush.Add(float64(len(series)), "series", state)
 }
@@ -530,6 +627,9 @@ func (agg *BufferedAggregator) flushServiceChecks(start time.Time, waitForSerial
 		Status:    metrics.ServiceCheckOK,
 		Host:      agg.hostname,
 	})
+	if agg.serviceCheckEvents != nil {
+		agg.serviceCheckEvents.expire(start)
+	}
 
 	serviceChecks := agg.GetServiceChecks()
 	addFlushCount("ServiceChecks", int64(len(serviceChecks)))
@@ -622,9 +722,16 @@ func (agg *BufferedAggregator) Stop() {
 		}
 	}
 
+	for _, worker := range agg.statsdPipelines {
+		worker.stop()
+	}
 }
 
 func (agg *BufferedAggregator) run() {
+	for _, worker := range agg.statsdPipelines {
+		worker.start()
+	}
+
 	if agg.TickerChan == nil {
 		if agg.flushInterval != 0 {
 			agg.TickerChan = time.NewTicker(agg.flushInterval).C
@@ -653,9 +760,10 @@ func (agg *BufferedAggregator) run() {
 			tlmProcessed.Inc("histogram_bucket")
 			agg.handleSenderBucket(checkHistogramBucket)
 		case metric := <-agg.metricIn:
-			aggregatorDogstatsdMetricSample.Add(1)
-			tlmProcessed.Inc("dogstatsd_metrics")
-			agg.addSample(metric, timeNowNano())
+			// the dogstatsd samples are counted by the pipelines
+			batch := agg.MetricSamplePool.GetBatch()
+			batch[0] = *metric
+			agg.addSamples(batch[:1])
 		case event := <-agg.eventIn:
 			aggregatorEvent.Add(1)
 			tlmProcessed.Inc("events")
@@ -665,12 +773,7 @@ func (agg *BufferedAggregator) run() {
 			tlmProcessed.Inc("service_checks")
 			agg.addServiceCheck(serviceCheck)
 		case ms := <-agg.bufferedMetricIn:
-			aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
-			tlmProcessed.Add(float64(len(ms)), "dogstatsd_metrics")
-			for i := 0; i < len(ms); i++ {
-				agg.addSample(&ms[i], timeNowNano())
-			}
-			agg.MetricSamplePool.PutBatch(ms)
+			agg.addSamples(ms)
 		case serviceChecks := <-agg.bufferedServiceCheckIn:
 			aggregatorServiceCheck.Add(int64(len(serviceChecks)))
 			tlmProcessed.Add(float64(len(serviceChecks)), "service_checks")
@@ -26,6 +26,8 @@ type CheckSampler struct {
 	lastBucketValue map[ckey.ContextKey]int64
 	lastSeenBucket  map[ckey.ContextKey]time.Time
 	bucketExpiry    time.Duration
+	// histogramNamespaces configures the histograms of the check metrics
+	histogramNamespaces metrics.HistogramNamespaces
 }
 
 // newCheckSampler returns a newly initialized CheckSampler
@@ -39,13 +41,15 @@ func newCheckSampler() *CheckSampler {
 		lastBucketValue: make(map[ckey.ContextKey]int64),
 		lastSeenBucket:  make(map[ckey.ContextKey]time.Time),
 		bucketExpiry:    1 * time.Minute,
+
+		histogramNamespaces: metrics.LoadHistogramNamespaces(),
 	}
 }
 
 func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
 	contextKey := cs.contextResolver.trackContext(metricSample, metricSample.Timestamp)
 
-	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1); err != nil {
+	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1, cs.histogramNamespaces); err != nil {
 		log.Debug("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
 	}
 }
@@ -84,6 +84,11 @@ func (m *MockSender) FinalizeCheckServiceTag() {
 	m.Called()
 }
 
+//SetContextLimit enables the setting of the context limit mock call.
+func (m *MockSender) SetContextLimit(limit int) {
+	m.Called(limit)
+}
+
 //GetMetricStats enables the get metric stats mock call.
 func (m *MockSender) GetMetricStats() map[string]int64 {
 	m.Called()
@@ -68,6 +68,7 @@ func (m *MockSender) SetupAcceptAll() {
 	m.On("SetCheckCustomTags", mock.AnythingOfType("[]string")).Return()
 	m.On("SetCheckService", mock.AnythingOfType("string")).Return()
 	m.On("FinalizeCheckServiceTag").Return()
+	m.On("SetContextLimit", mock.AnythingOfType("int")).Return()
 	m.On("Commit").Return()
 }
 
@@ -13,10 +13,19 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
+const (
+	// contextLimitServiceCheck is OK while a check with a context limit stays under it
+	contextLimitServiceCheck = "datadog.agent.check.metric_contexts"
+	// contextLimitMetric is the number of samples dropped by a check over its context limit
+	contextLimitMetric = "datadog.agent.check.metric_contexts.dropped"
+)
+
 var senderInstance *checkSender
 var senderInit sync.Once
 var senderPool *checkSenderPool
@@ -39,6 +48,7 @@ type Sender interface {
 	SetCheckCustomTags(tags []string)
 	SetCheckService(service string)
 	FinalizeCheckServiceTag()
+	SetContextLimit(limit int)
 }
 
 type metricStats struct {
@@ -69,6 +79,17 @@ type checkSender struct {
 	histogramBucketOut      chan<- senderHistogramBucket
 	checkTags               []string
 	service                 string
+	contextLimiter          contextLimiter
+}
+
+// contextLimiter drops the metric samples of the contexts above the limit of a check run
+type contextLimiter struct {
+	limit        int
+	contexts     map[ckey.ContextKey]struct{}
+	dropped      int64
+	keyGenerator *ckey.KeyGenerator
+	tags         []string // sorted by the key generator, the tags of the sample are left as is
+	m            sync.Mutex
 }
 
 type senderMetricSample struct {
@@ -185,9 +206,75 @@ func (s *checkSender) FinalizeCheckServiceTag() {
 	}
 }
 
+// SetContextLimit sets the number of metric contexts a check run can send, the
+// samples of the other contexts are dropped. 0 doesn't limit the contexts.
+func (s *checkSender) SetContextLimit(limit int) {
+	s.contextLimiter.m.Lock()
+	defer s.contextLimiter.m.Unlock()
+	s.contextLimiter.limit = limit
+}
+
+// accept returns whether a sample of the context can be sent during this run
+func (l *contextLimiter) accept(metric string, hostname string, tags []string) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.limit <= 0 {
+		return true
+	}
+	if l.contexts == nil {
+		l.contexts = make(map[ckey.ContextKey]struct{})
+		l.keyGenerator = ckey.NewKeyGenerator()
+	}
+
+	l.tags = append(l.tags[:0], tags...)
+	key := l.keyGenerator.Generate(metric, hostname, l.tags)
+	if _, found := l.contexts[key]; found {
+		return true
+	}
+	if len(l.contexts) >= l.limit {
+		l.dropped++
+		return false
+	}
+	l.contexts[key] = struct{}{}
+	return true
+}
+
+// reset starts a new run, it returns the limit and the samples dropped during the previous one
+func (l *contextLimiter) reset() (int, int64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	limit, dropped := l.limit, l.dropped
+	l.contexts = nil
+	l.dropped = 0
+	return limit, dropped
+}
+
+// reportContextLimit sends the service check of the context limit of the check,
+// and the number of dropped samples when the limit was reached during the run
+func (s *checkSender) reportContextLimit() {
+	limit, dropped := s.contextLimiter.reset()
+	if limit <= 0 {
+		return
+	}
+
+	tags := []string{fmt.Sprintf("check:%s", check.IDToCheckName(s.id))}
+	if dropped == 0 {
+		s.ServiceCheck(contextLimitServiceCheck, metrics.ServiceCheckOK, "", tags, "")
+		return
+	}
+
+	message := fmt.Sprintf("The check sent more than %d metric contexts, %d samples were dropped: raise `max_metric_contexts` in the configuration of the instance to collect them", limit, dropped)
+	log.Warnf("Check %s: %s", s.id, message)
+	s.ServiceCheck(contextLimitServiceCheck, metrics.ServiceCheckWarning, "", tags, message)
+	s.sendSample(s.newMetricSample(contextLimitMetric, float64(dropped), "", tags, metrics.GaugeType))
+}
+
 // Commit commits the metric samples & histogram buckets that were added during a check run
 // Should be called at the end of every check run
 func (s *checkSender) Commit() {
+	s.reportContextLimit()
 	// we use a metric sample to commit both for metrics & sketches
 	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
 	s.cyclemetricStats()
@@ -224,10 +311,21 @@ func (s *checkSender) cyclemetricStats() {
 // SendRawMetricSample sends the raw sample
 // Useful for testing - submitting precomputed samples.
 func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
+	if !s.contextLimiter.accept(sample.Name, sample.Host, sample.Tags) {
+		return
+	}
 	s.smsOut <- senderMetricSample{s.id, sample, false}
 }
 
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) {
+	metricSample := s.newMetricSample(metric, value, hostname, tags, mType)
+	if !s.contextLimiter.accept(metricSample.Name, metricSample.Host, metricSample.Tags) {
+		return
+	}
+	s.sendSample(metricSample)
+}
+
+func (s *checkSender) newMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) *metrics.MetricSample {
 	tags = append(tags, s.checkTags...)
 
 	log.Trace(mType.String(), " sample: ", metric, ": ", value, " for hostname: ", hostname, " tags: ", tags)
@@ -245,7 +343,10 @@ func (s *checkSender) sendMetricSample(metric string, value float64, hostname st
 	if hostname == "" && !s.defaultHostnameDisabled {
 		metricSample.Host = s.defaultHostname
 	}
+	return metricSample
+}
 
+func (s *checkSender) sendSample(metricSample *metrics.MetricSample) {
 	s.smsOut <- senderMetricSample{s.id, metricSample, false}
 
 	s.metricStats.Lock.Lock()
@@ -316,6 +417,10 @@ func (s *checkSender) HistogramBucket(metric string, value int64, lowerBound, up
 		histogramBucket.Host = s.defaultHostname
 	}
 
+	if !s.contextLimiter.accept(histogramBucket.Name, histogramBucket.Host, histogramBucket.Tags) {
+		return
+	}
+
 	s.histogramBucketOut <- senderHistogramBucket{s.id, histogramBucket}
 
 	s.metricStats.Lock.Lock()
@@ -404,6 +509,8 @@ func (sp *checkSenderPool) mkSender(id check.ID) (Sender, error) {
 
 	err := aggregatorInstance.registerSender(id)
 	sender := newCheckSender(id, aggregatorInstance.hostname, aggregatorInstance.checkMetricIn, aggregatorInstance.serviceCheckIn, aggregatorInstance.eventIn, aggregatorInstance.checkHistogramBucketIn)
+	// the limit of the instance, if any, is set when the check is configured
+	sender.SetContextLimit(config.Datadog.GetInt("check_max_metric_contexts"))
 	sp.senders[id] = sender
 	return sender, err
 }
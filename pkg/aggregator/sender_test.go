@@ -567,3 +567,74 @@ func TestChangeAllSendersDefaultHostname(t *testing.T) {
 	gaugeSenderSample = <-senderMetricSampleChan
 	assert.Equal(t, "hostname1", gaugeSenderSample.metricSample.Host)
 }
+
+func TestCheckSenderContextLimit(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+	checkSender.SetContextLimit(2)
+
+	tags := []string{"b", "a"}
+	checkSender.Gauge("my.metric", 1.0, "", tags)
+	checkSender.Gauge("my.metric", 2.0, "", []string{"a", "b"}) // same context
+	checkSender.Gauge("my.other_metric", 3.0, "", nil)
+	checkSender.Gauge("my.metric", 4.0, "other-hostname", tags)                     // dropped
+	checkSender.HistogramBucket("my.histogram_bucket", 42, 1.0, 2.0, true, "", nil) // dropped
+	checkSender.Commit()
+
+	for _, value := range []float64{1.0, 2.0, 3.0} {
+		sms := <-senderMetricSampleChan
+		assert.Equal(t, value, sms.metricSample.Value)
+	}
+	// the tags of the samples are left as is
+	assert.Equal(t, []string{"b", "a"}, tags)
+
+	dropped := <-senderMetricSampleChan
+	assert.Equal(t, "datadog.agent.check.metric_contexts.dropped", dropped.metricSample.Name)
+	assert.Equal(t, 2.0, dropped.metricSample.Value)
+	assert.Equal(t, []string{"check:1"}, dropped.metricSample.Tags)
+	assert.True(t, (<-senderMetricSampleChan).commit)
+	assert.Len(t, bucketChan, 0)
+
+	serviceCheck := <-serviceCheckChan
+	assert.Equal(t, "datadog.agent.check.metric_contexts", serviceCheck.CheckName)
+	assert.Equal(t, metrics.ServiceCheckWarning, serviceCheck.Status)
+	assert.Equal(t, "The check sent more than 2 metric contexts, 2 samples were dropped: raise `max_metric_contexts` in the configuration of the instance to collect them", serviceCheck.Message)
+
+	// the contexts are counted per run
+	checkSender.Gauge("my.metric", 4.0, "other-hostname", tags)
+	checkSender.Commit()
+	sms := <-senderMetricSampleChan
+	assert.Equal(t, 4.0, sms.metricSample.Value)
+	assert.True(t, (<-senderMetricSampleChan).commit)
+	serviceCheck = <-serviceCheckChan
+	assert.Equal(t, metrics.ServiceCheckOK, serviceCheck.Status)
+
+	// no limit, no service check
+	checkSender.SetContextLimit(0)
+	checkSender.Commit()
+	assert.True(t, (<-senderMetricSampleChan).commit)
+	assert.Len(t, serviceCheckChan, 0)
+}
+
+func TestCheckSenderRawSampleContextLimit(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	bucketChan := make(chan senderHistogramBucket, 10)
+	checkSender := newCheckSender(checkID1, "default-hostname", senderMetricSampleChan, serviceCheckChan, eventChan, bucketChan)
+	checkSender.SetContextLimit(1)
+
+	checkSender.SendRawMetricSample(&metrics.MetricSample{Name: "my.metric", Value: 1.0, Mtype: metrics.GaugeType})
+	checkSender.SendRawMetricSample(&metrics.MetricSample{Name: "my.other_metric", Value: 2.0, Mtype: metrics.GaugeType}) // dropped
+	checkSender.Commit()
+
+	sms := <-senderMetricSampleChan
+	assert.Equal(t, "my.metric", sms.metricSample.Name)
+	dropped := <-senderMetricSampleChan
+	assert.Equal(t, "datadog.agent.check.metric_contexts.dropped", dropped.metricSample.Name)
+	assert.Equal(t, 1.0, dropped.metricSample.Value)
+	assert.True(t, (<-senderMetricSampleChan).commit)
+}
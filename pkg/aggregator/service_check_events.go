@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// serviceCheckEventSource is the source type of the events sent on the status
+// changes of the service checks
+const serviceCheckEventSource = "service_check"
+
+// serviceCheckStateTTL is the time after which the state of a service check no
+// longer submitted is forgotten
+const serviceCheckStateTTL = time.Hour
+
+// serviceCheckEvents sends an event when a service check matching
+// `service_check_events.service_checks` changes status. The first status of a
+// service check doesn't send an event, and the changes of a flapping service
+// check are replaced by an event when it starts flapping and another one when
+// it settles. It's only used by the aggregator goroutine.
+type serviceCheckEvents struct {
+	patterns            []string
+	flappingTransitions int
+	flappingWindow      time.Duration
+	keyGenerator        *ckey.KeyGenerator
+	states              map[ckey.ContextKey]*serviceCheckState
+}
+
+// serviceCheckState is the state of a service check, for a host and tags
+type serviceCheckState struct {
+	status      metrics.ServiceCheckStatus // last submitted status
+	sentStatus  metrics.ServiceCheckStatus // status of the last event
+	transitions []time.Time                // status changes in the flapping window
+	flapping    bool
+	lastSeen    time.Time
+}
+
+// newServiceCheckEvents returns the bridge configured by the `service_check_events`
+// section, nil if no service checks are configured
+func newServiceCheckEvents() *serviceCheckEvents {
+	patterns := config.Datadog.GetStringSlice("service_check_events.service_checks")
+	if len(patterns) == 0 {
+		return nil
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Warnf("Invalid pattern '%s' in service_check_events.service_checks: %s", pattern, err)
+		}
+	}
+
+	return &serviceCheckEvents{
+		patterns:            patterns,
+		flappingTransitions: config.Datadog.GetInt("service_check_events.flapping_transitions"),
+		flappingWindow:      config.Datadog.GetDuration("service_check_events.flapping_window") * time.Second,
+		keyGenerator:        ckey.NewKeyGenerator(),
+		states:              make(map[ckey.ContextKey]*serviceCheckState),
+	}
+}
+
+func (b *serviceCheckEvents) matches(checkName string) bool {
+	for _, pattern := range b.patterns {
+		if matched, _ := path.Match(pattern, checkName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// process returns the event to send for the service check, if any. The tags of
+// the service check must be sorted and unique.
+func (b *serviceCheckEvents) process(sc *metrics.ServiceCheck, now time.Time) *metrics.Event {
+	if !b.matches(sc.CheckName) {
+		return nil
+	}
+
+	key := b.keyGenerator.Generate(sc.CheckName, sc.Host, sc.Tags)
+	state, found := b.states[key]
+	if !found {
+		b.states[key] = &serviceCheckState{status: sc.Status, sentStatus: sc.Status, lastSeen: now}
+		return nil
+	}
+	state.lastSeen = now
+
+	// forget the transitions out of the flapping window
+	i := 0
+	for i < len(state.transitions) && now.Sub(state.transitions[i]) > b.flappingWindow {
+		i++
+	}
+	state.transitions = state.transitions[i:]
+
+	if sc.Status != state.status {
+		state.status = sc.Status
+		state.transitions = append(state.transitions, now)
+	}
+
+	if b.flappingTransitions > 0 && len(state.transitions) >= b.flappingTransitions {
+		if state.flapping {
+			return nil
+		}
+		state.flapping = true
+		return b.event(sc, fmt.Sprintf("%s is flapping", sc.CheckName),
+			fmt.Sprintf("The status changed %d times in the last %s, the next changes are not reported until it settles.", len(state.transitions), b.flappingWindow), metrics.EventAlertTypeWarning)
+	}
+	if state.flapping {
+		// settled: the status is reported if it changed since the last event
+		state.flapping = false
+		if sc.Status == state.sentStatus {
+			return b.event(sc, fmt.Sprintf("%s stopped flapping", sc.CheckName),
+				fmt.Sprintf("The status settled to %s.", sc.Status), metrics.EventAlertTypeInfo)
+		}
+	}
+	if sc.Status == state.sentStatus {
+		return nil
+	}
+
+	previous := state.sentStatus
+	state.sentStatus = sc.Status
+	text := fmt.Sprintf("The status changed from %s to %s.", previous, sc.Status)
+	if sc.Message != "" {
+		text = fmt.Sprintf("%s\n%s", text, sc.Message)
+	}
+	return b.event(sc, fmt.Sprintf("%s is %s", sc.CheckName, sc.Status), text, serviceCheckAlertType(sc.Status))
+}
+
+func (b *serviceCheckEvents) event(sc *metrics.ServiceCheck, title string, text string, alertType metrics.EventAlertType) *metrics.Event {
+	if sc.Host != "" {
+		title = fmt.Sprintf("%s on %s", title, sc.Host)
+	}
+	return &metrics.Event{
+		Title:          title,
+		Text:           text,
+		Ts:             sc.Ts,
+		Priority:       metrics.EventPriorityNormal,
+		Host:           sc.Host,
+		Tags:           append([]string{}, sc.Tags...),
+		AlertType:      alertType,
+		AggregationKey: strings.Join([]string{sc.CheckName, sc.Host}, ":"),
+		SourceTypeName: serviceCheckEventSource,
+	}
+}
+
+// expire forgets the service checks no longer submitted
+func (b *serviceCheckEvents) expire(now time.Time) {
+	for key, state := range b.states {
+		if now.Sub(state.lastSeen) > serviceCheckStateTTL {
+			delete(b.states, key)
+		}
+	}
+}
+
+func serviceCheckAlertType(status metrics.ServiceCheckStatus) metrics.EventAlertType {
+	switch status {
+	case metrics.ServiceCheckOK:
+		return metrics.EventAlertTypeSuccess
+	case metrics.ServiceCheckWarning:
+		return metrics.EventAlertTypeWarning
+	case metrics.ServiceCheckCritical:
+		return metrics.EventAlertTypeError
+	default:
+		return metrics.EventAlertTypeInfo
+	}
+}
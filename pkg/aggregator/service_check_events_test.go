@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestServiceCheckEvents() *serviceCheckEvents {
+	return &serviceCheckEvents{
+		patterns:            []string{"my_service.*"},
+		flappingTransitions: 3,
+		flappingWindow:      10 * time.Minute,
+		keyGenerator:        ckey.NewKeyGenerator(),
+		states:              make(map[ckey.ContextKey]*serviceCheckState),
+	}
+}
+
+func serviceCheckWithStatus(status metrics.ServiceCheckStatus) *metrics.ServiceCheck {
+	return &metrics.ServiceCheck{
+		CheckName: "my_service.can_connect",
+		Status:    status,
+		Host:      "my-hostname",
+		Tags:      []string{"bar", "foo"},
+		Ts:        12345,
+		Message:   "connection refused",
+	}
+}
+
+func TestServiceCheckEventsTransitions(t *testing.T) {
+	b := newTestServiceCheckEvents()
+	now := time.Now()
+
+	// the first status and the same status don't send events
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now))
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now))
+
+	e := b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now)
+	require.NotNil(t, e)
+	assert.Equal(t, metrics.Event{
+		Title:          "my_service.can_connect is CRITICAL on my-hostname",
+		Text:           "The status changed from OK to CRITICAL.\nconnection refused",
+		Ts:             12345,
+		Priority:       metrics.EventPriorityNormal,
+		Host:           "my-hostname",
+		Tags:           []string{"bar", "foo"},
+		AlertType:      metrics.EventAlertTypeError,
+		AggregationKey: "my_service.can_connect:my-hostname",
+		SourceTypeName: "service_check",
+	}, *e)
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now))
+
+	// the states are per host and tags
+	other := serviceCheckWithStatus(metrics.ServiceCheckOK)
+	other.Tags = []string{"baz"}
+	assert.Nil(t, b.process(other, now))
+
+	// not configured
+	other = serviceCheckWithStatus(metrics.ServiceCheckOK)
+	other.CheckName = "other_service.can_connect"
+	assert.Nil(t, b.process(other, now))
+	assert.Len(t, b.states, 2)
+
+	// recovery, after the flapping window
+	e = b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now.Add(time.Hour))
+	require.NotNil(t, e)
+	assert.Equal(t, "my_service.can_connect is OK on my-hostname", e.Title)
+	assert.Equal(t, metrics.EventAlertTypeSuccess, e.AlertType)
+
+	// the states not updated for an hour are forgotten
+	b.expire(now.Add(90 * time.Minute))
+	assert.Len(t, b.states, 1)
+}
+
+func TestServiceCheckEventsFlapping(t *testing.T) {
+	b := newTestServiceCheckEvents()
+	now := time.Now()
+
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now))
+	assert.NotNil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(time.Minute)))
+	assert.NotNil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now.Add(2*time.Minute)))
+
+	e := b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(3*time.Minute))
+	require.NotNil(t, e)
+	assert.Equal(t, "my_service.can_connect is flapping on my-hostname", e.Title)
+	assert.Equal(t, "The status changed 3 times in the last 10m0s, the next changes are not reported until it settles.", e.Text)
+	assert.Equal(t, metrics.EventAlertTypeWarning, e.AlertType)
+
+	// the changes are suppressed while flapping
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckOK), now.Add(4*time.Minute)))
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(5*time.Minute)))
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(10*time.Minute)))
+
+	// settled to a status different from the one of the last event
+	e = b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(16*time.Minute))
+	require.NotNil(t, e)
+	assert.Equal(t, "my_service.can_connect is CRITICAL on my-hostname", e.Title)
+	assert.Equal(t, "The status changed from OK to CRITICAL.\nconnection refused", e.Text)
+	assert.Nil(t, b.process(serviceCheckWithStatus(metrics.ServiceCheckCritical), now.Add(17*time.Minute)))
+}
@@ -30,6 +30,10 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	histogramNamespaces         metrics.HistogramNamespaces
+	// lateSamples counts the samples whose timestamp falls in a bucket that
+	// has already been flushed
+	lateSamples uint64
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
@@ -43,6 +47,7 @@ func NewTimeSampler(interval int64) *TimeSampler {
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		histogramNamespaces:         metrics.LoadHistogramNamespaces(),
 	}
 }
 
@@ -59,6 +64,9 @@ func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp fl
 	// Keep track of the context
 	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
 	bucketStart := s.calculateBucketStart(timestamp)
+	if bucketStart < s.lastCutOffTime {
+		s.lateSamples++
+	}
 
 	switch metricSample.Mtype {
 	case metrics.DistributionType:
@@ -76,7 +84,7 @@ func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp fl
 		}
 
 		// Add sample to bucket
-		if err := bucketMetrics.AddSample(contextKey, metricSample, timestamp, s.interval); err != nil {
+		if err := bucketMetrics.AddSample(contextKey, metricSample, timestamp, s.interval, s.histogramNamespaces); err != nil {
 			log.Debug("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
 		}
 	}
@@ -221,7 +229,7 @@ func (s *TimeSampler) countersSampleZeroValue(timestamp int64, contextMetrics me
 			}
 			// Add a zero value sample to the counter
 			// It is ok to add a 0 sample to a counter that was already sampled in the bucket, it won't change its value
-			contextMetrics.AddSample(counterContext, sample, float64(timestamp), s.interval)
+			contextMetrics.AddSample(counterContext, sample, float64(timestamp), s.interval, s.histogramNamespaces)
 
 			// Update the tracked context so that the contextResolver doesn't expire counter contexts too early
 			// i.e. while we are still sending zeros for them
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+var (
+	tlmPipelineFlushDuration = telemetry.NewGauge("aggregator", "pipeline_flush_duration_seconds",
+		[]string{"pipeline"}, "Time spent by a dogstatsd pipeline to flush its sampler")
+	tlmPipelineContexts = telemetry.NewGauge("aggregator", "pipeline_contexts",
+		[]string{"pipeline"}, "Number of contexts tracked by a dogstatsd pipeline")
+	tlmPipelineSeries = telemetry.NewGauge("aggregator", "pipeline_series_flushed",
+		[]string{"pipeline"}, "Number of series flushed by a dogstatsd pipeline during the last flush")
+	tlmPipelineLateSamples = telemetry.NewGauge("aggregator", "pipeline_late_samples",
+		[]string{"pipeline"}, "Number of samples received by a dogstatsd pipeline after their bucket was flushed")
+
+	pipelinesStats     []PipelineStats
+	pipelinesStatsLock sync.Mutex
+)
+
+// PipelineStats holds the statistics of a dogstatsd pipeline, as of its last flush
+type PipelineStats struct {
+	FlushDuration int64  // in nanoseconds
+	Contexts      int    // number of contexts tracked
+	SeriesFlushed int    // number of series flushed
+	LateSamples   uint64 // number of samples received after their bucket was flushed
+}
+
+func resetPipelinesStats(count int) {
+	pipelinesStatsLock.Lock()
+	defer pipelinesStatsLock.Unlock()
+	pipelinesStats = make([]PipelineStats, count)
+}
+
+func setPipelineStats(id int, stats PipelineStats) {
+	pipelinesStatsLock.Lock()
+	defer pipelinesStatsLock.Unlock()
+	if id < len(pipelinesStats) {
+		pipelinesStats[id] = stats
+	}
+}
+
+func getPipelinesStats() interface{} {
+	pipelinesStatsLock.Lock()
+	defer pipelinesStatsLock.Unlock()
+	stats := make([]PipelineStats, len(pipelinesStats))
+	copy(stats, pipelinesStats)
+	return stats
+}
+
+// timeSamplerWorker runs a TimeSampler in its own goroutine. The dogstatsd
+// samples are sharded by context between several of these workers (the
+// "pipelines") so that the sample ingestion is not bound to a single core.
+type timeSamplerWorker struct {
+	id      int
+	sampler *TimeSampler
+
+	// samplesChan receives the samples to aggregate, they are put back in
+	// the metricSamplePool once processed.
+	samplesChan      chan []metrics.MetricSample
+	metricSamplePool *metrics.MetricSamplePool
+
+	flushChan chan flushTrigger
+	stopChan  chan struct{}
+	doneChan  chan struct{}
+	// started is set atomically while the worker goroutine is running. Before
+	// it is started and once it is stopped, the sampler can be flushed directly
+	// by the caller.
+	started uint32
+}
+
+// flushTrigger asks a timeSamplerWorker to flush its sampler, the result is
+// sent on the responseChan.
+type flushTrigger struct {
+	timestamp    float64
+	responseChan chan flushResponse
+}
+
+type flushResponse struct {
+	series   metrics.Series
+	sketches metrics.SketchSeriesList
+}
+
+func newTimeSamplerWorker(id int, bufferSize int, metricSamplePool *metrics.MetricSamplePool) *timeSamplerWorker {
+	return &timeSamplerWorker{
+		id:               id,
+		sampler:          NewTimeSampler(bucketSize),
+		samplesChan:      make(chan []metrics.MetricSample, bufferSize),
+		metricSamplePool: metricSamplePool,
+		flushChan:        make(chan flushTrigger),
+		stopChan:         make(chan struct{}),
+		doneChan:         make(chan struct{}),
+	}
+}
+
+// start runs the worker in its own goroutine
+func (w *timeSamplerWorker) start() {
+	atomic.StoreUint32(&w.started, 1)
+	go w.run()
+}
+
+func (w *timeSamplerWorker) run() {
+	defer close(w.doneChan)
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case ms := <-w.samplesChan:
+			w.processSamples(ms)
+		case trigger := <-w.flushChan:
+			// process the samples queued before the flush was triggered
+			for i := len(w.samplesChan); i > 0; i-- {
+				w.processSamples(<-w.samplesChan)
+			}
+			series, sketches := w.flushSampler(trigger.timestamp)
+			trigger.responseChan <- flushResponse{series: series, sketches: sketches}
+		}
+	}
+}
+
+func (w *timeSamplerWorker) processSamples(ms []metrics.MetricSample) {
+	aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
+	tlmProcessed.Add(float64(len(ms)), "dogstatsd_metrics")
+	now := timeNowNano()
+	for i := 0; i < len(ms); i++ {
+		// the samples are stamped by the dogstatsd server when they are
+		// received, a sample stamped before the last flush of the pipeline
+		// is counted as late by the sampler
+		timestamp := ms[i].Timestamp
+		if timestamp == 0 {
+			timestamp = now
+		}
+		w.addSample(&ms[i], timestamp)
+	}
+	w.metricSamplePool.PutBatch(ms)
+}
+
+func (w *timeSamplerWorker) addSample(metricSample *metrics.MetricSample, timestamp float64) {
+	metricSample.Tags = util.SortUniqInPlace(metricSample.Tags)
+	w.sampler.addSample(metricSample, timestamp)
+}
+
+// flush blocks until the worker has flushed its sampler.
+func (w *timeSamplerWorker) flush(timestamp float64) (metrics.Series, metrics.SketchSeriesList) {
+	if atomic.LoadUint32(&w.started) == 0 {
+		return w.flushSampler(timestamp)
+	}
+	responseChan := make(chan flushResponse)
+	w.flushChan <- flushTrigger{timestamp: timestamp, responseChan: responseChan}
+	response := <-responseChan
+	return response.series, response.sketches
+}
+
+// flushSampler flushes the sampler and reports the pipeline statistics
+func (w *timeSamplerWorker) flushSampler(timestamp float64) (metrics.Series, metrics.SketchSeriesList) {
+	start := time.Now()
+	series, sketches := w.sampler.flush(timestamp)
+	duration := time.Since(start)
+
+	stats := PipelineStats{
+		FlushDuration: int64(duration),
+		Contexts:      len(w.sampler.contextResolver.contextsByKey),
+		SeriesFlushed: len(series),
+		LateSamples:   w.sampler.lateSamples,
+	}
+	setPipelineStats(w.id, stats)
+
+	pipeline := strconv.Itoa(w.id)
+	tlmPipelineFlushDuration.Set(duration.Seconds(), pipeline)
+	tlmPipelineContexts.Set(float64(stats.Contexts), pipeline)
+	tlmPipelineSeries.Set(float64(stats.SeriesFlushed), pipeline)
+	tlmPipelineLateSamples.Set(float64(stats.LateSamples), pipeline)
+
+	return series, sketches
+}
+
+// stop waits for the worker goroutine to exit, the sampler can then still be
+// flushed directly by the caller.
+func (w *timeSamplerWorker) stop() {
+	if atomic.LoadUint32(&w.started) == 1 {
+		close(w.stopChan)
+		<-w.doneChan
+		atomic.StoreUint32(&w.started, 0)
+	}
+}
+
+// PipelineIndex returns the index of the pipeline in charge of the context of
+// the given sample. All the samples of a given context must be sent to the same
+// pipeline. The sample tags are sorted and deduplicated in place.
+// keyGenerator is not safe for concurrent use, each caller must use its own.
+func PipelineIndex(keyGenerator *ckey.KeyGenerator, sample *metrics.MetricSample, pipelineCount int) int {
+	if pipelineCount <= 1 {
+		return 0
+	}
+	sample.Tags = util.SortUniqInPlace(sample.Tags)
+	key := keyGenerator.Generate(sample.Name, sample.Host, sample.Tags)
+	return int(key[0] % uint64(pipelineCount))
+}
+
+// getPipelineCount returns the number of dogstatsd pipelines to run, either
+// from the configuration or based on the number of cores available.
+func getPipelineCount() int {
+	count := config.Datadog.GetInt("dogstatsd_pipeline_count")
+	if config.Datadog.GetBool("dogstatsd_pipeline_autoadjust") {
+		// leave half of the cores to the dogstatsd listeners/parsers and the checks
+		count = runtime.GOMAXPROCS(-1) / 2
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestPipelineIndex(t *testing.T) {
+	keyGenerator := ckey.NewKeyGenerator()
+
+	sample := &metrics.MetricSample{Name: "my.metric", Tags: []string{"b:1", "a:1"}}
+	assert.Equal(t, 0, PipelineIndex(keyGenerator, sample, 1))
+
+	idx := PipelineIndex(keyGenerator, sample, 8)
+	assert.True(t, idx >= 0 && idx < 8)
+
+	// same context with tags in another order or duplicated goes to the same pipeline
+	other := &metrics.MetricSample{Name: "my.metric", Tags: []string{"a:1", "b:1", "a:1"}}
+	assert.Equal(t, idx, PipelineIndex(keyGenerator, other, 8))
+	assert.Equal(t, []string{"a:1", "b:1"}, other.Tags)
+}
+
+func TestGetPipelineCount(t *testing.T) {
+	defer config.Datadog.Set("dogstatsd_pipeline_count", 1)
+
+	config.Datadog.Set("dogstatsd_pipeline_count", 4)
+	assert.Equal(t, 4, getPipelineCount())
+
+	config.Datadog.Set("dogstatsd_pipeline_count", 0)
+	assert.Equal(t, 1, getPipelineCount())
+}
+
+func TestTimeSamplerWorker(t *testing.T) {
+	pool := metrics.NewMetricSamplePool(MetricSamplePoolBatchSize)
+	worker := newTimeSamplerWorker(0, 10, pool)
+	worker.start()
+
+	processed := aggregatorDogstatsdMetricSample.Value()
+	batch := pool.GetBatch()
+	batch[0] = metrics.MetricSample{Name: "my.metric", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1}
+	batch[1] = metrics.MetricSample{Name: "my.metric", Value: 2, Mtype: metrics.GaugeType, SampleRate: 1, Tags: []string{"a:1"}}
+	worker.samplesChan <- batch[:2]
+
+	// flush far enough in the future for the bucket to be closed
+	series, sketches := worker.flush(timeNowNano() + 2*bucketSize)
+	require.Len(t, series, 2)
+	assert.Len(t, sketches, 0)
+	assert.Equal(t, processed+2, aggregatorDogstatsdMetricSample.Value())
+
+	// the sampler can still be flushed once the worker is stopped
+	worker.stop()
+	done := make(chan struct{})
+	go func() {
+		worker.flush(timeNowNano() + 4*bucketSize)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "flush blocked after stop")
+	}
+}
+
+func TestMultiplePipelinesFlush(t *testing.T) {
+	config.Datadog.Set("dogstatsd_pipeline_count", 3)
+	defer config.Datadog.Set("dogstatsd_pipeline_count", 1)
+
+	agg := NewBufferedAggregator(nil, "hostname", "agent", DefaultFlushInterval)
+	require.Len(t, agg.statsdPipelines, 3)
+	for _, worker := range agg.statsdPipelines {
+		worker.start()
+		defer worker.stop()
+	}
+
+	batch := agg.MetricSamplePool.GetBatch()
+	for i, name := range []string{"metric.a", "metric.b", "metric.c", "metric.d"} {
+		batch[i] = metrics.MetricSample{Name: name, Value: 1, Mtype: metrics.GaugeType, SampleRate: 1}
+	}
+	agg.addSamples(batch[:4])
+
+	var series metrics.Series
+	for _, worker := range agg.statsdPipelines {
+		s, _ := worker.flush(timeNowNano() + 2*bucketSize)
+		series = append(series, s...)
+	}
+	assert.Len(t, series, 4)
+}
+
+func TestTimeSamplerWorkerLateSamples(t *testing.T) {
+	resetPipelinesStats(1)
+	pool := metrics.NewMetricSamplePool(MetricSamplePoolBatchSize)
+	worker := newTimeSamplerWorker(0, 10, pool)
+	worker.start()
+	defer worker.stop()
+
+	batch := pool.GetBatch()
+	batch[0] = metrics.MetricSample{Name: "my.metric", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1, Timestamp: 12345.0}
+	worker.samplesChan <- batch[:1]
+	worker.flush(12360.0)
+	assert.Equal(t, uint64(0), getPipelinesStats().([]PipelineStats)[0].LateSamples)
+
+	// the bucket [12340, 12350[ has already been flushed, only the first sample is late
+	batch = pool.GetBatch()
+	batch[0] = metrics.MetricSample{Name: "my.metric", Value: 2, Mtype: metrics.GaugeType, SampleRate: 1, Timestamp: 12346.0}
+	batch[1] = metrics.MetricSample{Name: "my.metric", Value: 3, Mtype: metrics.GaugeType, SampleRate: 1, Timestamp: 12361.0}
+	worker.samplesChan <- batch[:2]
+	worker.flush(12370.0)
+	assert.Equal(t, uint64(1), getPipelinesStats().([]PipelineStats)[0].LateSamples)
+}
@@ -12,13 +12,42 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-const defaultTimeout = time.Second
+const (
+	defaultTimeout = time.Second
+	// readinessGatePath prefixes the paths of the readiness gates
+	readinessGatePath = "/ready/"
+)
+
+var (
+	readinessGatesMutex sync.RWMutex
+	readinessGates      = make(map[string]func() error)
+)
+
+// RegisterReadinessGate registers a readiness gate served on /ready/<name>: the
+// requests succeed when the components are healthy and the gate returns no error.
+// The replicas of a deployment can be ready for different roles, the gates let the
+// services select the replicas ready for theirs.
+func RegisterReadinessGate(name string, gate func() error) {
+	readinessGatesMutex.Lock()
+	defer readinessGatesMutex.Unlock()
+	readinessGates[name] = gate
+}
+
+func getReadinessGate(name string) (func() error, bool) {
+	readinessGatesMutex.RLock()
+	defer readinessGatesMutex.RUnlock()
+	gate, found := readinessGates[name]
+	return gate, found
+}
 
 // Serve configures and starts the http server for the health check.
 // It returns an error if the setup failed, or runs the server in a goroutine.
@@ -33,7 +62,7 @@ func Serve(ctx context.Context, port int) error {
 	}
 
 	srv := &http.Server{
-		Handler:           healthHandler{},
+		Handler:           healthHandler{failureThreshold: config.Datadog.GetInt("health_failure_threshold")},
 		ReadTimeout:       defaultTimeout,
 		ReadHeaderTimeout: defaultTimeout,
 		WriteTimeout:      defaultTimeout,
@@ -54,9 +83,39 @@ func closeOnContext(ctx context.Context, srv *http.Server) {
 	srv.Shutdown(timeout)
 }
 
-type healthHandler struct{}
+// healthHandler serves the liveness probe on /health, the readiness probe on
+// /ready and the health of the components on the other paths. The probes must
+// use /health and /ready: the other paths fail as soon as a component is
+// unhealthy, which would restart the agent on a single missed health ping.
+type healthHandler struct {
+	failureThreshold int
+}
+
+func (h healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/health":
+		LivenessHandler(h.failureThreshold)(w, r)
+		return
+	case "/ready":
+		ReadinessHandler(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, readinessGatePath) {
+		name := strings.TrimPrefix(r.URL.Path, readinessGatePath)
+		gate, found := getReadinessGate(name)
+		if !found {
+			http.Error(w, fmt.Sprintf("unknown readiness gate %q", name), http.StatusNotFound)
+			return
+		}
+		if err := gate(); err != nil {
+			log.Debugf("Readiness gate %q failed: %v", name, err)
+			body, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(body), http.StatusServiceUnavailable)
+			return
+		}
+	}
 
-func (h healthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 	health, err := health.GetStatusNonBlocking()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -77,3 +136,48 @@ func (h healthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 
 	w.Write(jsonHealth)
 }
+
+// LivenessHandler serves the liveness of the agent: the requests fail when a
+// component missed failureThreshold health pings in a row, the agent should
+// then be restarted. The threshold is at least 1, a component can't miss less
+// than a ping.
+func LivenessHandler(failureThreshold int) http.HandlerFunc {
+	if failureThreshold < 1 {
+		log.Warnf("Invalid health_failure_threshold %d, using 1", failureThreshold)
+		failureThreshold = 1
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, func(status health.Status) bool { return status.IsAlive(failureThreshold) })
+	}
+}
+
+// ReadinessHandler serves the readiness of the agent: the requests fail until
+// all the components are healthy
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, health.Status.IsReady)
+}
+
+// writeProbe writes the status of the components, with a 503 if the probe fails
+func writeProbe(w http.ResponseWriter, probe func(health.Status) bool) {
+	w.Header().Set("Content-Type", "application/json")
+	status, err := health.GetStatusNonBlocking()
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), http.StatusServiceUnavailable)
+		return
+	}
+
+	jsonHealth, err := json.Marshal(status)
+	if err != nil {
+		log.Errorf("Error marshalling status. Error: %v, Status: %v", err, status)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), http.StatusInternalServerError)
+		return
+	}
+
+	if !probe(status) {
+		log.Debugf("Health probe failed on: %v", status.Unhealthy)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(jsonHealth)
+}
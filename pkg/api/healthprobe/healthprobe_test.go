@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package healthprobe
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessGates(t *testing.T) {
+	RegisterReadinessGate("read", func() error { return nil })
+	RegisterReadinessGate("leader", func() error { return errors.New("follower") })
+	defer func() { readinessGates = make(map[string]func() error) }()
+
+	for path, code := range map[string]int{
+		"/live":          http.StatusOK,
+		"/ready":         http.StatusOK,
+		"/health":        http.StatusOK,
+		"/ready/read":    http.StatusOK,
+		"/ready/leader":  http.StatusServiceUnavailable,
+		"/ready/unknown": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		healthHandler{}.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, code, w.Code, path)
+	}
+}
+
+func TestLivenessAndReadinessHandlers(t *testing.T) {
+	handle := health.Register("probe-test")
+	defer handle.Deregister()
+
+	// the component just started, it's alive but not ready
+	w := httptest.NewRecorder()
+	LivenessHandler(1)(w, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Name":"probe-test"`)
+
+	// an invalid threshold is clamped to 1 instead of failing the liveness probe forever
+	w = httptest.NewRecorder()
+	LivenessHandler(0)(w, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the health port serves the probes
+	w = httptest.NewRecorder()
+	healthHandler{failureThreshold: 1}.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	ReadinessHandler(w, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"Unhealthy":["probe-test"]`)
+}
@@ -29,6 +29,7 @@ const (
 	authTokenName                 = "auth_token"
 	authTokenMinimalLen           = 32
 	clusterAgentAuthTokenFilename = "cluster_agent.auth_token"
+	ipcCertName                   = "ipc_cert.pem"
 )
 
 // GenerateKeyPair create a public/private keypair
@@ -159,6 +160,31 @@ func fetchAuthToken(tokenCreationAllowed bool) (string, error) {
 	return authToken, nil
 }
 
+// GetIPCCertFilepath returns the path to the IPC certificate file, next to the auth_token file.
+func GetIPCCertFilepath() string {
+	return filepath.Join(filepath.Dir(GetAuthTokenFilepath()), ipcCertName)
+}
+
+// SaveIPCCert writes the PEM encoded certificate of the IPC servers of the
+// Agent, so that the other agent processes can verify them
+func SaveIPCCert(certPEM []byte) error {
+	return saveAuthToken(string(certPEM), GetIPCCertFilepath())
+}
+
+// FetchIPCCertPool returns a certificate pool holding the IPC certificate saved by the Agent
+func FetchIPCCertPool() (*x509.CertPool, error) {
+	certFile := GetIPCCertFilepath()
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the IPC certificate file: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(certPEM) {
+		return nil, fmt.Errorf("no valid certificate found in %s", certFile)
+	}
+	return pool, nil
+}
+
 // DeleteAuthToken removes auth_token file (test clean up)
 func DeleteAuthToken() error {
 	authTokenFile := filepath.Join(filepath.Dir(config.Datadog.ConfigFileUsed()), authTokenName)
@@ -8,6 +8,7 @@
 package security
 
 import (
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -70,3 +71,25 @@ func TestFetchAuthToken(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("%v", err))
 	require.Equal(t, newToken, token)
 }
+
+func TestSaveAndFetchIPCCert(t *testing.T) {
+	expectTokenPath := initMockConf(t)
+	defer cleanMockConf(expectTokenPath)
+
+	_, err := FetchIPCCertPool()
+	require.NotNil(t, err)
+
+	cert, certPEM, _, err := GenerateRootCert([]string{"127.0.0.1", "localhost"}, 2048)
+	require.Nil(t, err, fmt.Sprintf("%v", err))
+	require.Nil(t, SaveIPCCert(certPEM))
+	assert.Equal(t, filepath.Join(filepath.Dir(expectTokenPath), ipcCertName), GetIPCCertFilepath())
+
+	pool, err := FetchIPCCertPool()
+	require.Nil(t, err, fmt.Sprintf("%v", err))
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost"})
+	assert.Nil(t, err)
+
+	require.Nil(t, ioutil.WriteFile(GetIPCCertFilepath(), []byte("invalid"), 0600))
+	_, err = FetchIPCCertPool()
+	assert.NotNil(t, err)
+}
@@ -40,6 +40,9 @@ func init() {
 	acErrors.Set("ResolveWarnings", expvar.Func(func() interface{} {
 		return errorStats.getResolveWarnings()
 	}))
+	acErrors.Set("SecretRotations", expvar.Func(func() interface{} {
+		return GetSecretRotations()
+	}))
 }
 
 // AutoConfig is responsible to collect integrations configurations from
@@ -57,6 +60,8 @@ type AutoConfig struct {
 	providers          []*configPoller
 	listeners          []listeners.ServiceListener
 	listenerCandidates map[string]listeners.ServiceListenerFactory
+	listenerConfigs    map[string]config.Listeners
+	debouncers         []*serviceDebouncer
 	listenerRetryStop  chan struct{}
 	scheduler          *scheduler.MetaScheduler
 	listenerStop       chan struct{}
@@ -64,6 +69,9 @@ type AutoConfig struct {
 	newService         chan listeners.Service
 	delService         chan listeners.Service
 	store              *store
+	secretConfigs      map[string]secretConfig // configs holding secrets, by digest of the collected config
+	secretConfigsLock  sync.Mutex
+	secretRefreshStop  chan struct{}
 	m                  sync.RWMutex
 }
 
@@ -72,16 +80,21 @@ func NewAutoConfig(scheduler *scheduler.MetaScheduler) *AutoConfig {
 	ac := &AutoConfig{
 		providers:          make([]*configPoller, 0, 9),
 		listenerCandidates: make(map[string]listeners.ServiceListenerFactory),
+		listenerConfigs:    make(map[string]config.Listeners),
 		listenerRetryStop:  nil, // We'll open it if needed
 		listenerStop:       make(chan struct{}),
 		healthListening:    health.Register("ad-servicelistening"),
 		newService:         make(chan listeners.Service),
 		delService:         make(chan listeners.Service),
 		store:              newStore(),
+		secretConfigs:      make(map[string]secretConfig),
 		scheduler:          scheduler,
 	}
 	// We need to listen to the service channels before anything is sent to them
 	go ac.serviceListening()
+	if interval := config.Datadog.GetInt("secret_refresh_interval"); interval > 0 {
+		ac.startSecretRefresh(time.Duration(interval) * time.Second)
+	}
 	return ac
 }
 
@@ -144,6 +157,16 @@ func (ac *AutoConfig) Stop() {
 	// stop the service listener
 	ac.listenerStop <- struct{}{}
 
+	// stop debouncing the services, their events can't be processed anymore
+	for _, d := range ac.debouncers {
+		d.stop()
+	}
+
+	// stop refreshing the secrets
+	if ac.secretRefreshStop != nil {
+		close(ac.secretRefreshStop)
+	}
+
 	// stop the meta scheduler
 	ac.scheduler.Stop()
 
@@ -192,6 +215,31 @@ func (ac *AutoConfig) LoadAndRun() {
 	ac.schedule(resolvedConfigs)
 }
 
+// processFileConfigs stores the JMX metric files collected by the file
+// provider, filtering them out of the check configs, and records the errors
+// of the invalid files.
+func (ac *AutoConfig) processFileConfigs(fileConfPd *providers.FileConfigProvider, cfgs []integration.Config) []integration.Config {
+	var goodConfs []integration.Config
+	for _, cfg := range cfgs {
+		// JMX checks can have 2 YAML files: one containing the metrics to collect, one containing the
+		// instance configuration
+		// If the file provider finds any of these metric YAMLs, we store them in a map for future access
+		if cfg.MetricConfig != nil {
+			// We don't want to save metric files, it's enough to store them in the map
+			ac.store.setJMXMetricsForConfigName(cfg.Name, cfg.MetricConfig)
+			continue
+		}
+
+		goodConfs = append(goodConfs, cfg)
+	}
+
+	// Grab the errors that occurred when reading the YAML files, this
+	// clears the errors of the files fixed or removed since the last collection
+	errorStats.setConfigErrors(fileConfPd.Errors)
+
+	return goodConfs
+}
+
 // GetAllConfigs queries all the providers and returns all the integration
 // configurations found, resolving the ones it can
 func (ac *AutoConfig) GetAllConfigs() []integration.Config {
@@ -204,29 +252,7 @@ func (ac *AutoConfig) GetAllConfigs() []integration.Config {
 		}
 
 		if fileConfPd, ok := pd.provider.(*providers.FileConfigProvider); ok {
-			var goodConfs []integration.Config
-			for _, cfg := range cfgs {
-				// JMX checks can have 2 YAML files: one containing the metrics to collect, one containing the
-				// instance configuration
-				// If the file provider finds any of these metric YAMLs, we store them in a map for future access
-				if cfg.MetricConfig != nil {
-					// We don't want to save metric files, it's enough to store them in the map
-					ac.store.setJMXMetricsForConfigName(cfg.Name, cfg.MetricConfig)
-					continue
-				}
-
-				goodConfs = append(goodConfs, cfg)
-
-				// Clear any old errors if a valid config file is found
-				errorStats.removeConfigError(cfg.Name)
-			}
-
-			// Grab any errors that occurred when reading the YAML file
-			for name, e := range fileConfPd.Errors {
-				errorStats.setConfigError(name, e)
-			}
-
-			cfgs = goodConfs
+			cfgs = ac.processFileConfigs(fileConfPd, cfgs)
 		}
 		// Store all raw configs in the provider
 		pd.configs = cfgs
@@ -282,21 +308,32 @@ func (ac *AutoConfig) processNewConfig(config integration.Config) []integration.
 		}
 
 		// each template can resolve to multiple configs
-		for _, config := range resolvedConfigs {
-			config, err := decryptConfig(config)
+		for _, resolvedConfig := range resolvedConfigs {
+			config, err := decryptConfig(resolvedConfig)
 			if err != nil {
 				log.Errorf("Dropping conf for %q: %s", config.Name, err.Error())
 				continue
 			}
+			ac.trackSecretConfig(resolvedConfig, config, false)
 			configs = append(configs, config)
 		}
 		return configs
 	}
+	// tag_cardinality selects the tags of the autodiscovered service, a config that
+	// isn't resolved against a service has no tags to select
+	if config.Entity == "" && config.HasTagCardinality() {
+		e := fmt.Sprintf("tag_cardinality is only supported by the Autodiscovery templates, dropping the %s configuration", config.Name)
+		errorStats.setConfigError(config.Name, e)
+		log.Error(e)
+		return configs
+	}
+	rawConfig := config
 	config, err := decryptConfig(config)
 	if err != nil {
 		log.Errorf("Dropping conf for '%s': %s", config.Name, err.Error())
 		return configs
 	}
+	ac.trackSecretConfig(rawConfig, config, true)
 	configs = append(configs, config)
 
 	// store non template configs in the AC
@@ -336,6 +373,7 @@ func (ac *AutoConfig) addListenerCandidates(listenerConfigs []config.Listeners)
 		}
 		log.Debugf("Listener %s was registered", c.Name)
 		ac.listenerCandidates[c.Name] = factory
+		ac.listenerConfigs[c.Name] = c
 	}
 }
 
@@ -350,7 +388,8 @@ func (ac *AutoConfig) initListenerCandidates() bool {
 			// Init successful, let's start listening
 			log.Infof("%s listener successfully started", name)
 			ac.listeners = append(ac.listeners, listener)
-			listener.Listen(ac.newService, ac.delService)
+			newService, delService := ac.serviceChannels(ac.listenerConfigs[name])
+			listener.Listen(newService, delService)
 			delete(ac.listenerCandidates, name)
 		case retry.IsErrWillRetry(err):
 			// Log an info and keep in candidates
@@ -365,6 +404,51 @@ func (ac *AutoConfig) initListenerCandidates() bool {
 	return len(ac.listenerCandidates) > 0
 }
 
+// serviceChannels returns the channels a listener sends its services to,
+// debouncing its events if it's configured with a stability window or an
+// unschedule delay.
+func (ac *AutoConfig) serviceChannels(listenerConfig config.Listeners) (chan<- listeners.Service, chan<- listeners.Service) {
+	newService, delService := ac.listenerChannels(listenerConfig.Name)
+	if listenerConfig.StabilityWindowSeconds <= 0 && listenerConfig.UnscheduleDelaySeconds <= 0 {
+		return newService, delService
+	}
+
+	stabilityWindow := time.Duration(listenerConfig.StabilityWindowSeconds) * time.Second
+	unscheduleDelay := time.Duration(listenerConfig.UnscheduleDelaySeconds) * time.Second
+	if stabilityWindow < 0 {
+		stabilityWindow = 0
+	}
+	if unscheduleDelay < 0 {
+		unscheduleDelay = 0
+	}
+	log.Infof("%s listener services are scheduled after %s and unscheduled after %s", listenerConfig.Name, stabilityWindow, unscheduleDelay)
+
+	debouncer := newServiceDebouncer(listenerConfig.Name, stabilityWindow, unscheduleDelay, newService, delService)
+	debouncer.start()
+	ac.debouncers = append(ac.debouncers, debouncer)
+	return debouncer.newIn, debouncer.delIn
+}
+
+// listenerChannels returns channels forwarding the services of a listener to
+// AutoConfig, recording the listener that created each service. A single
+// goroutine forwards both channels to keep the events in order.
+func (ac *AutoConfig) listenerChannels(listener string) (chan<- listeners.Service, chan<- listeners.Service) {
+	newService := make(chan listeners.Service)
+	delService := make(chan listeners.Service)
+	go func() {
+		for {
+			select {
+			case svc := <-newService:
+				ac.store.setListenerForEntity(svc.GetEntity(), listener)
+				ac.newService <- svc
+			case svc := <-delService:
+				ac.delService <- svc
+			}
+		}
+	}()
+	return newService, delService
+}
+
 func (ac *AutoConfig) retryListenerCandidates() {
 	retryTicker := time.NewTicker(listenerCandidateIntl)
 	defer func() {
@@ -413,31 +497,36 @@ func (ac *AutoConfig) RemoveScheduler(name string) {
 	ac.scheduler.Deregister(name)
 }
 
+// testing purpose
+var secretDecrypt = secrets.Decrypt
+
 func decryptConfig(conf integration.Config) (integration.Config, error) {
 	var err error
 
 	// init_config
-	conf.InitConfig, err = secrets.Decrypt(conf.InitConfig, conf.Name)
+	conf.InitConfig, err = secretDecrypt(conf.InitConfig, conf.Name)
 	if err != nil {
 		return conf, fmt.Errorf("error while decrypting secrets in 'init_config': %s", err)
 	}
 
-	// instances
+	// instances, copied to keep the collected config as it was, it's decrypted again
+	// when its secrets are rotated
+	conf.Instances = append([]integration.Data(nil), conf.Instances...)
 	for idx := range conf.Instances {
-		conf.Instances[idx], err = secrets.Decrypt(conf.Instances[idx], conf.Name)
+		conf.Instances[idx], err = secretDecrypt(conf.Instances[idx], conf.Name)
 		if err != nil {
 			return conf, fmt.Errorf("error while decrypting secrets in an instance: %s", err)
 		}
 	}
 
 	// metrics
-	conf.MetricConfig, err = secrets.Decrypt(conf.MetricConfig, conf.Name)
+	conf.MetricConfig, err = secretDecrypt(conf.MetricConfig, conf.Name)
 	if err != nil {
 		return conf, fmt.Errorf("error while decrypting secrets in 'metrics': %s", err)
 	}
 
 	// logs
-	conf.LogsConfig, err = secrets.Decrypt(conf.LogsConfig, conf.Name)
+	conf.LogsConfig, err = secretDecrypt(conf.LogsConfig, conf.Name)
 	if err != nil {
 		return conf, fmt.Errorf("error while decrypting secrets 'logs': %s", err)
 	}
@@ -446,9 +535,12 @@ func decryptConfig(conf integration.Config) (integration.Config, error) {
 }
 
 func (ac *AutoConfig) processRemovedConfigs(configs []integration.Config) {
-	ac.unschedule(configs)
-	for _, c := range configs {
+	// the configs holding secrets were scheduled decrypted
+	scheduled := ac.untrackSecretConfigs(configs)
+	ac.unschedule(scheduled)
+	for idx, c := range configs {
 		ac.store.removeLoadedConfig(c)
+		ac.store.removeLoadedConfig(scheduled[idx])
 	}
 }
 
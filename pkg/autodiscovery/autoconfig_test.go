@@ -346,6 +346,24 @@ func TestRemoveTemplate(t *testing.T) {
 	assert.Len(t, ac.GetLoadedConfigs(), 1)
 }
 
+func TestTagCardinalityOutsideTemplate(t *testing.T) {
+	ac := NewAutoConfig(scheduler.NewMetaScheduler())
+	defer errorStats.removeConfigError("disk")
+
+	// the static configs can't select the tags of a service
+	c := integration.Config{
+		Name:      "disk",
+		Instances: []integration.Data{integration.Data("tag_cardinality: high")},
+	}
+	assert.Len(t, ac.processNewConfig(c), 0)
+	assert.Len(t, ac.GetLoadedConfigs(), 0)
+	assert.Contains(t, errorStats.getConfigErrors(), "disk")
+
+	// the configs resolved against a service, like the dispatched cluster checks, keep it
+	c.Entity = "kube_service://default/redis"
+	assert.Len(t, ac.processNewConfig(c), 1)
+}
+
 func TestGetLoadedConfigNotInitialized(t *testing.T) {
 	ac := AutoConfig{}
 	cfgs := ac.GetLoadedConfigs()
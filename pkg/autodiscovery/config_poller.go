@@ -69,6 +69,14 @@ func (pd *configPoller) start(ac *AutoConfig) {
 // poll polls config of the corresponding config provider
 func (pd *configPoller) poll(ac *AutoConfig) {
 	ticker := time.NewTicker(pd.pollInterval)
+
+	// changes is left nil, and thus never selected, for the providers
+	// that can't watch their backend
+	var changes <-chan struct{}
+	if watchable, ok := pd.provider.(providers.WatchableConfigProvider); ok {
+		changes = watchable.Changes()
+	}
+
 	for {
 		select {
 		case <-pd.healthHandle.C:
@@ -76,6 +84,9 @@ func (pd *configPoller) poll(ac *AutoConfig) {
 			pd.healthHandle.Deregister()
 			ticker.Stop()
 			return
+		case <-changes:
+			log.Debugf("%v provider notified a template change", pd.provider)
+			pd.refresh(ac)
 		case <-ticker.C:
 			log.Tracef("Polling %s config provider", pd.provider.String())
 			// Check if the CPupdate cache is up to date. Fill it and trigger a Collect() if outdated.
@@ -87,33 +98,38 @@ func (pd *configPoller) poll(ac *AutoConfig) {
 				log.Debugf("No modifications in the templates stored in %v configuration provider", pd.provider)
 				break
 			}
-
-			// retrieve the list of newly added configurations as well
-			// as removed configurations
-			newConfigs, removedConfigs := pd.collect()
-			if len(newConfigs) > 0 || len(removedConfigs) > 0 {
-				log.Infof("%v provider: collected %d new configurations, removed %d", pd.provider, len(newConfigs), len(removedConfigs))
-			} else {
-				log.Debugf("%v provider: no configuration change", pd.provider)
-			}
-			// Process removed configs first to handle the case where a
-			// container churn would result in the same configuration hash.
-			ac.processRemovedConfigs(removedConfigs)
-			// We can also remove any cached template
-			ac.removeConfigTemplates(removedConfigs)
-
-			for _, config := range newConfigs {
-				config.Provider = pd.provider.String()
-				resolvedConfigs := ac.processNewConfig(config)
-				ac.schedule(resolvedConfigs)
-			}
+			pd.refresh(ac)
 		}
 	}
 }
 
+// refresh collects the configurations of the provider and schedules
+// or unschedules the ones that changed.
+func (pd *configPoller) refresh(ac *AutoConfig) {
+	// retrieve the list of newly added configurations as well
+	// as removed configurations
+	newConfigs, removedConfigs := pd.collect(ac)
+	if len(newConfigs) > 0 || len(removedConfigs) > 0 {
+		log.Infof("%v provider: collected %d new configurations, removed %d", pd.provider, len(newConfigs), len(removedConfigs))
+	} else {
+		log.Debugf("%v provider: no configuration change", pd.provider)
+	}
+	// Process removed configs first to handle the case where a
+	// container churn would result in the same configuration hash.
+	ac.processRemovedConfigs(removedConfigs)
+	// We can also remove any cached template
+	ac.removeConfigTemplates(removedConfigs)
+
+	for _, config := range newConfigs {
+		config.Provider = pd.provider.String()
+		resolvedConfigs := ac.processNewConfig(config)
+		ac.schedule(resolvedConfigs)
+	}
+}
+
 // collect is just a convenient wrapper to fetch configurations from a provider and
 // see what changed from the last time we called Collect().
-func (pd *configPoller) collect() ([]integration.Config, []integration.Config) {
+func (pd *configPoller) collect(ac *AutoConfig) ([]integration.Config, []integration.Config) {
 	var newConf []integration.Config
 	var removedConf []integration.Config
 	old := pd.configs
@@ -123,6 +139,9 @@ func (pd *configPoller) collect() ([]integration.Config, []integration.Config) {
 		log.Errorf("Unable to collect configurations from provider %s: %s", pd.provider, err)
 		return nil, nil
 	}
+	if fileConfPd, ok := pd.provider.(*providers.FileConfigProvider); ok {
+		fetched = ac.processFileConfigs(fileConfPd, fetched)
+	}
 
 	for _, c := range fetched {
 		if !pd.contains(&c) {
@@ -7,13 +7,20 @@ package configresolver
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
@@ -23,25 +30,68 @@ import (
 type variableGetter func(key []byte, svc listeners.Service) ([]byte, error)
 
 var templateVariables = map[string]variableGetter{
-	"host":     getHost,
-	"pid":      getPid,
-	"port":     getPort,
-	"hostname": getHostname,
+	"host":      getHost,
+	"hosts":     getHosts,
+	"pid":       getPid,
+	"port":      getPort,
+	"hostname":  getHostname,
+	"kube":      getKubeVar,
+	"container": getContainerVar,
+	"extra":     getExtraVar,
+}
+
+// getWorkloadmetaStore returns the store used to resolve the variables
+// related to the container of the service, overridden in tests
+var getWorkloadmetaStore = workloadmeta.GetGlobalStore
+
+// scrubbedVariableValue replaces the values of the environment variables in the
+// resolution traces, as they often hold credentials
+const scrubbedVariableValue = "********"
+
+// resolutionTrace collects the variable resolutions, a nil trace records nothing
+type resolutionTrace struct {
+	variables []integration.VariableResolution
+}
+
+func (t *resolutionTrace) record(instance int, v tmplvar.TemplateVar, value []byte, err error) {
+	if t == nil {
+		return
+	}
+	resolution := integration.VariableResolution{Instance: instance, Variable: string(v.Raw)}
+	switch {
+	case err != nil:
+		resolution.Error = err.Error()
+	case string(v.Name) == "env" || string(v.Name) == "container":
+		// %%env_*%% and %%container_env_*%% resolve to environment variables
+		resolution.Value = scrubbedVariableValue
+	default:
+		resolution.Value = string(value)
+	}
+	t.variables = append(t.variables, resolution)
 }
 
 // SubstituteTemplateVariables replaces %%VARIABLES%% using the variableGetters passed in
 func SubstituteTemplateVariables(config *integration.Config, getters map[string]variableGetter, svc listeners.Service) error {
+	return substituteTemplateVariables(config, getters, svc, nil)
+}
+
+func substituteTemplateVariables(config *integration.Config, getters map[string]variableGetter, svc listeners.Service, trace *resolutionTrace) error {
 	for i := 0; i < len(config.Instances); i++ {
 		vars := config.GetTemplateVariablesForInstance(i)
 		for _, v := range vars {
 			if f, found := getters[string(v.Name)]; found {
 				resolvedVar, err := f(v.Key, svc)
+				trace.record(i, v, resolvedVar, err)
 				if err != nil {
 					return err
 				}
 				// init config vars are replaced by the first found
 				config.InitConfig = bytes.Replace(config.InitConfig, v.Raw, resolvedVar, -1)
 				config.Instances[i] = bytes.Replace(config.Instances[i], v.Raw, resolvedVar, -1)
+			} else if string(v.Name) != "env" {
+				// env vars are substituted afterwards by SubstituteTemplateEnvVars
+				log.Warnf("Unknown template variable %s in the %s config for service %s, leaving it unresolved", v.Raw, config.Name, svc.GetEntity())
+				trace.record(i, v, nil, errors.New("unknown template variable, left unresolved"))
 			}
 		}
 	}
@@ -50,12 +100,17 @@ func SubstituteTemplateVariables(config *integration.Config, getters map[string]
 
 // SubstituteTemplateEnvVars replaces %%ENV_VARIABLE%% from environment variables
 func SubstituteTemplateEnvVars(config *integration.Config) error {
+	return substituteTemplateEnvVars(config, nil)
+}
+
+func substituteTemplateEnvVars(config *integration.Config, trace *resolutionTrace) error {
 	var retErr error
 	for i := 0; i < len(config.Instances); i++ {
 		vars := config.GetTemplateVariablesForInstance(i)
 		for _, v := range vars {
 			if "env" == string(v.Name) {
 				resolvedVar, err := getEnvvar(v.Key)
+				trace.record(i, v, resolvedVar, err)
 				if err != nil {
 					log.Warnf("variable not replaced: %s", err)
 					if retErr == nil {
@@ -75,6 +130,18 @@ func SubstituteTemplateEnvVars(config *integration.Config) error {
 // Resolve takes a template and a service and generates a config with
 // valid connection info and relevant tags.
 func Resolve(tpl integration.Config, svc listeners.Service) (integration.Config, error) {
+	return resolve(tpl, svc, nil)
+}
+
+// ResolveWithTrace resolves a template like Resolve, also returning how each
+// template variable was resolved, up to the first error.
+func ResolveWithTrace(tpl integration.Config, svc listeners.Service) (integration.Config, []integration.VariableResolution, error) {
+	trace := &resolutionTrace{}
+	resolvedConfig, err := resolve(tpl, svc, trace)
+	return resolvedConfig, trace.variables, err
+}
+
+func resolve(tpl integration.Config, svc listeners.Service, trace *resolutionTrace) (integration.Config, error) {
 	// Copy original template
 	resolvedConfig := integration.Config{
 		Name:            tpl.Name,
@@ -97,7 +164,7 @@ func Resolve(tpl integration.Config, svc listeners.Service) (integration.Config,
 
 	// Ignore the config from file if it's overridden by an empty config
 	// or by a different config for the same check
-	if tpl.Provider == names.File && svc.GetCheckNames() != nil {
+	if (tpl.Provider == names.File || tpl.Provider == names.NodeConfigs) && svc.GetCheckNames() != nil {
 		checkNames := svc.GetCheckNames()
 		lenCheckNames := len(checkNames)
 		if lenCheckNames == 0 || (lenCheckNames == 1 && checkNames[0] == "") {
@@ -119,11 +186,11 @@ func Resolve(tpl integration.Config, svc listeners.Service) (integration.Config,
 		return resolvedConfig, errors.New("unable to resolve, service not ready")
 	}
 
-	if err := SubstituteTemplateVariables(&resolvedConfig, templateVariables, svc); err != nil {
+	if err := substituteTemplateVariables(&resolvedConfig, templateVariables, svc, trace); err != nil {
 		return resolvedConfig, err
 	}
 
-	if err := SubstituteTemplateEnvVars(&resolvedConfig); err != nil {
+	if err := substituteTemplateEnvVars(&resolvedConfig, trace); err != nil {
 		// We add the service name to the error here, since SubstituteTemplateEnvVars doesn't know about that
 		return resolvedConfig, fmt.Errorf("%s, skipping service %s", err, svc.GetEntity())
 	}
@@ -138,11 +205,23 @@ func Resolve(tpl integration.Config, svc listeners.Service) (integration.Config,
 }
 
 func addServiceTags(resolvedConfig *integration.Config, svc listeners.Service) error {
-	tags, err := svc.GetTags()
-	if err != nil {
-		return err
-	}
+	var serviceTags []string
+	var serviceTagsFetched bool
 	for i := 0; i < len(resolvedConfig.Instances); i++ {
+		var tags []string
+		var err error
+		if card := resolvedConfig.Instances[i].GetTagCardinality(); card != "" {
+			tags, err = getTagsWithCardinality(svc, card)
+		} else {
+			if !serviceTagsFetched {
+				serviceTags, err = svc.GetTags()
+				serviceTagsFetched = true
+			}
+			tags = serviceTags
+		}
+		if err != nil {
+			return err
+		}
 		err = resolvedConfig.Instances[i].MergeAdditionalTags(tags)
 		if err != nil {
 			return err
@@ -151,6 +230,59 @@ func addServiceTags(resolvedConfig *integration.Config, svc listeners.Service) e
 	return nil
 }
 
+// getTagsWithCardinality returns the tags of the service at the cardinality
+// requested by the instance with the tag_cardinality option. The tags of the
+// service that don't come from the tagger (SNMP devices, kubernetes services
+// and endpoints...) are kept.
+func getTagsWithCardinality(svc listeners.Service, card string) ([]string, error) {
+	cardinality, err := tagger.ParseCardinality(card)
+	if err != nil {
+		return nil, err
+	}
+	taggerTags, err := tagger.Tag(svc.GetTaggerEntity(), cardinality)
+	if err != nil {
+		return nil, err
+	}
+	serviceTags, err := svc.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	// The container services return the tagger tags at the checks cardinality,
+	// they are replaced by the ones at the requested cardinality
+	defaultTaggerTags, err := tagger.Tag(svc.GetTaggerEntity(), tagger.ChecksCardinality)
+	if err != nil {
+		return nil, err
+	}
+	return mergeServiceTags(serviceTags, defaultTaggerTags, taggerTags), nil
+}
+
+// mergeServiceTags returns the deduplicated taggerTags and serviceTags, minus
+// the serviceTags that are in defaultTaggerTags.
+func mergeServiceTags(serviceTags, defaultTaggerTags, taggerTags []string) []string {
+	excluded := make(map[string]struct{}, len(defaultTaggerTags))
+	for _, tag := range defaultTaggerTags {
+		excluded[tag] = struct{}{}
+	}
+
+	seen := make(map[string]struct{}, len(taggerTags)+len(serviceTags))
+	tags := make([]string, 0, len(taggerTags)+len(serviceTags))
+	for _, tag := range taggerTags {
+		if _, found := seen[tag]; !found {
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range serviceTags {
+		_, found := seen[tag]
+		_, isTaggerTag := excluded[tag]
+		if !found && !isTaggerTag {
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func getHost(tplVar []byte, svc listeners.Service) ([]byte, error) {
 	hosts, err := svc.GetHosts()
 	if err != nil {
@@ -176,6 +308,32 @@ func getHost(tplVar []byte, svc listeners.Service) ([]byte, error) {
 	return []byte(ip), nil
 }
 
+// getHosts returns every IP address of the service, as a list sorted
+// and deduplicated, e.g. ["10.0.0.1","172.17.0.2"]
+func getHosts(_ []byte, svc listeners.Service) ([]byte, error) {
+	hosts, err := svc.GetHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract IP addresses for container %s, ignoring it. Source error: %s", svc.GetEntity(), err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no network found for container %s, ignoring it", svc.GetEntity())
+	}
+
+	unique := make(map[string]struct{}, len(hosts))
+	ips := make([]string, 0, len(hosts))
+	for _, ip := range hosts {
+		if _, found := unique[ip]; found {
+			continue
+		}
+		unique[ip] = struct{}{}
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	// a JSON list is also a valid YAML flow sequence
+	return json.Marshal(ips)
+}
+
 // getFallbackHost implements the fallback strategy to get a service's IP address
 // the current strategy is:
 // 		- if there's only one network we use its IP
@@ -243,6 +401,74 @@ func getHostname(tplVar []byte, svc listeners.Service) ([]byte, error) {
 	return []byte(name), nil
 }
 
+// getKubeVar resolves the %%kube_annotation_<name>%% variables with the
+// annotations of the pod of the service
+func getKubeVar(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	annotation := strings.TrimPrefix(string(tplVar), "annotation_")
+	if annotation == string(tplVar) || annotation == "" {
+		return nil, fmt.Errorf("invalid template variable %%%%kube_%s%%%%, only %%%%kube_annotation_<name>%%%% is supported, skipping service %s", tplVar, svc.GetEntity())
+	}
+
+	store := getWorkloadmetaStore()
+	var pod workloadmeta.KubernetesPod
+	var err error
+	switch prefix, id := containers.SplitEntityName(svc.GetTaggerEntity()); prefix {
+	case kubelet.KubePodTaggerEntityName:
+		pod, err = store.GetKubernetesPod(id)
+	case containers.ContainerEntityName:
+		pod, err = store.GetKubernetesPodForContainer(id)
+	default:
+		return nil, fmt.Errorf("service %s is not a Kubernetes pod or container, can't resolve the annotation %s", svc.GetEntity(), annotation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pod of service %s to resolve the annotation %s: %s", svc.GetEntity(), annotation, err)
+	}
+
+	value, found := pod.Annotations[annotation]
+	if !found {
+		return nil, fmt.Errorf("annotation %s not found on pod %s/%s, skipping service %s", annotation, pod.Namespace, pod.Name, svc.GetEntity())
+	}
+	return []byte(value), nil
+}
+
+// getContainerVar resolves the %%container_env_<name>%% variables with the
+// environment variables of the container of the service
+func getContainerVar(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	envVar := strings.TrimPrefix(string(tplVar), "env_")
+	if envVar == string(tplVar) || envVar == "" {
+		return nil, fmt.Errorf("invalid template variable %%%%container_%s%%%%, only %%%%container_env_<name>%%%% is supported, skipping service %s", tplVar, svc.GetEntity())
+	}
+
+	prefix, id := containers.SplitEntityName(svc.GetTaggerEntity())
+	if prefix != containers.ContainerEntityName {
+		return nil, fmt.Errorf("service %s is not a container, can't resolve the environment variable %s", svc.GetEntity(), envVar)
+	}
+	container, err := getWorkloadmetaStore().GetContainer(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container %s to resolve the environment variable %s: %s", id, envVar, err)
+	}
+
+	value, found := container.EnvVars[envVar]
+	if !found {
+		return nil, fmt.Errorf("environment variable %s not found in container %s, skipping service %s", envVar, id, svc.GetEntity())
+	}
+	return []byte(value), nil
+}
+
+// getExtraVar resolves the %%extra_<key>%% variables with the settings
+// exposed by the listener of the service
+func getExtraVar(tplVar []byte, svc listeners.Service) ([]byte, error) {
+	extraSvc, ok := svc.(listeners.ExtraConfigService)
+	if !ok {
+		return nil, fmt.Errorf("service %s doesn't support the template variable %%%%extra_%s%%%%", svc.GetEntity(), tplVar)
+	}
+	value, err := extraSvc.GetExtraConfig(tplVar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the template variable %%%%extra_%s%%%% for service %s: %s", tplVar, svc.GetEntity(), err)
+	}
+	return value, nil
+}
+
 // getEnvvar returns a system environment variable if found
 func getEnvvar(envVar []byte) ([]byte, error) {
 	if len(envVar) == 0 {
@@ -6,6 +6,7 @@
 package configresolver
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -16,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	// we need some valid check in the catalog to run tests
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
@@ -561,3 +563,149 @@ func newFakeContainerPorts() []listeners.ContainerPort {
 		{Port: 3, Name: "baz"},
 	}
 }
+
+func TestGetHosts(t *testing.T) {
+	svc := &dummyService{
+		ID:    "a5901276aed1",
+		Hosts: map[string]string{"bridge": "172.17.0.2", "foo": "10.0.0.1", "bar": "172.17.0.2"},
+	}
+	hosts, err := getHosts(nil, svc)
+	require.NoError(t, err)
+	assert.Equal(t, `["10.0.0.1","172.17.0.2"]`, string(hosts))
+
+	_, err = getHosts(nil, &dummyService{ID: "a5901276aed1"})
+	assert.EqualError(t, err, "no network found for container a5901276aed1, ignoring it")
+}
+
+func newTestWorkloadmetaStore(t *testing.T, entities ...workloadmeta.Entity) *workloadmeta.Store {
+	dump := workloadmeta.Dump{}
+	for _, entity := range entities {
+		raw, err := json.Marshal(entity)
+		require.NoError(t, err)
+		dump.Entities = append(dump.Entities, workloadmeta.DumpEntity{
+			Kind:   entity.GetID().Kind,
+			Source: workloadmeta.SourceKubelet,
+			Entity: raw,
+		})
+	}
+
+	store := workloadmeta.NewStore(nil)
+	require.NoError(t, store.Load(dump))
+	return store
+}
+
+func TestContainerVariables(t *testing.T) {
+	store := newTestWorkloadmetaStore(t,
+		workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "a5901276aed1"},
+			EnvVars:  map[string]string{"REDIS_PORT": "6380"},
+		},
+		workloadmeta.KubernetesPod{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod-uid"},
+			EntityMeta: workloadmeta.EntityMeta{
+				Name:        "redis",
+				Namespace:   "default",
+				Annotations: map[string]string{"example.com/password-secret": "redis-password"},
+			},
+			Containers: []string{"a5901276aed1"},
+		},
+	)
+	getWorkloadmetaStore = func() *workloadmeta.Store { return store }
+	defer func() { getWorkloadmetaStore = workloadmeta.GetGlobalStore }()
+
+	container := &dummyService{ID: "container_id://a5901276aed1"}
+	pod := &dummyService{ID: "kubernetes_pod_uid://pod-uid"}
+
+	tpl := integration.Config{
+		Name:          "redisdb",
+		ADIdentifiers: []string{"redis"},
+		Instances:     []integration.Data{integration.Data("port: %%container_env_REDIS_PORT%%\npassword: ENC[%%kube_annotation_example.com/password-secret%%]")},
+	}
+	config, err := Resolve(tpl, container)
+	require.NoError(t, err)
+	assert.Equal(t, "port: 6380\npassword: ENC[redis-password]", string(config.Instances[0]))
+
+	// the values of the environment variables are scrubbed in the traces
+	os.Setenv("REDIS_USER", "datadog")
+	defer os.Unsetenv("REDIS_USER")
+	tpl.Instances = append(tpl.Instances, integration.Data("username: %%env_REDIS_USER%%"))
+	_, variables, err := ResolveWithTrace(tpl, container)
+	require.NoError(t, err)
+	assert.Equal(t, []integration.VariableResolution{
+		{Instance: 0, Variable: "%%container_env_REDIS_PORT%%", Value: "********"},
+		{Instance: 0, Variable: "%%kube_annotation_example.com/password-secret%%", Value: "redis-password"},
+		{Instance: 1, Variable: "%%env_REDIS_USER%%", Value: "********"},
+	}, variables)
+
+	value, err := getKubeVar([]byte("annotation_example.com/password-secret"), pod)
+	require.NoError(t, err)
+	assert.Equal(t, "redis-password", string(value))
+
+	_, err = getKubeVar([]byte("annotation_missing"), pod)
+	assert.EqualError(t, err, "annotation missing not found on pod default/redis, skipping service kubernetes_pod_uid://pod-uid")
+
+	_, err = getKubeVar([]byte("label_app"), pod)
+	assert.EqualError(t, err, "invalid template variable %%kube_label_app%%, only %%kube_annotation_<name>%% is supported, skipping service kubernetes_pod_uid://pod-uid")
+
+	_, err = getContainerVar([]byte("env_REDIS_PORT"), pod)
+	assert.EqualError(t, err, "service kubernetes_pod_uid://pod-uid is not a container, can't resolve the environment variable REDIS_PORT")
+
+	_, err = getContainerVar([]byte("env_MISSING"), container)
+	assert.EqualError(t, err, "environment variable MISSING not found in container a5901276aed1, skipping service container_id://a5901276aed1")
+}
+
+type dummyExtraService struct {
+	dummyService
+	Extra map[string]string
+}
+
+// GetExtraConfig returns the dummy extra settings
+func (s *dummyExtraService) GetExtraConfig(key []byte) ([]byte, error) {
+	value, found := s.Extra[string(key)]
+	if !found {
+		return nil, listeners.ErrNotSupported
+	}
+	return []byte(value), nil
+}
+
+func TestExtraVariables(t *testing.T) {
+	svc := &dummyExtraService{
+		dummyService: dummyService{
+			ID:    "snmp://abc:10.0.0.2",
+			Hosts: map[string]string{"": "10.0.0.2"},
+			Ports: []listeners.ContainerPort{{Port: 161, Name: "snmp"}},
+		},
+		Extra: map[string]string{"community": "public", "profile": "cisco-nexus"},
+	}
+
+	tpl := integration.Config{
+		Name:          "snmp",
+		ADIdentifiers: []string{"snmp"},
+		Instances:     []integration.Data{integration.Data("ip_address: %%host%%\nport: %%port%%\ncommunity_string: %%extra_community%%\nprofile: %%extra_profile%%")},
+	}
+	config, err := Resolve(tpl, svc)
+	require.NoError(t, err)
+	assert.Equal(t, "ip_address: 10.0.0.2\nport: 161\ncommunity_string: public\nprofile: cisco-nexus", string(config.Instances[0]))
+
+	_, err = getExtraVar([]byte("missing"), svc)
+	assert.EqualError(t, err, "failed to resolve the template variable %%extra_missing%% for service snmp://abc:10.0.0.2: AD: variable not supported by listener")
+
+	_, err = getExtraVar([]byte("community"), &svc.dummyService)
+	assert.EqualError(t, err, "service snmp://abc:10.0.0.2 doesn't support the template variable %%extra_community%%")
+}
+
+func TestMergeServiceTags(t *testing.T) {
+	// the tags of a listener are kept
+	assert.Equal(t,
+		[]string{"kube_namespace:default", "kube_service:redis"},
+		mergeServiceTags([]string{"kube_service:redis"}, nil, []string{"kube_namespace:default"}))
+
+	// the tagger tags of a container are replaced by the ones at the requested cardinality
+	assert.Equal(t,
+		[]string{"image_name:redis", "snmp_device:10.0.0.2"},
+		mergeServiceTags(
+			[]string{"image_name:redis", "container_id:abc", "snmp_device:10.0.0.2"},
+			[]string{"image_name:redis", "container_id:abc"},
+			[]string{"image_name:redis", "image_name:redis"},
+		))
+}
@@ -59,12 +59,17 @@ type Config struct {
 
 // CommonInstanceConfig holds the reserved fields for the yaml instance data
 type CommonInstanceConfig struct {
-	MinCollectionInterval int      `yaml:"min_collection_interval"`
-	EmptyDefaultHostname  bool     `yaml:"empty_default_hostname"`
-	Tags                  []string `yaml:"tags"`
-	Service               string   `yaml:"service"`
-	Name                  string   `yaml:"name"`
-	Namespace             string   `yaml:"namespace"`
+	MinCollectionInterval       int      `yaml:"min_collection_interval"`
+	EmptyDefaultHostname        bool     `yaml:"empty_default_hostname"`
+	Tags                        []string `yaml:"tags"`
+	Service                     string   `yaml:"service"`
+	Name                        string   `yaml:"name"`
+	Namespace                   string   `yaml:"namespace"`
+	TagCardinality              string   `yaml:"tag_cardinality"`
+	MinCollectionIntervalJitter int      `yaml:"min_collection_interval_jitter"`
+	RunTimeout                  int      `yaml:"run_timeout"`
+	ConcurrencyClass            string   `yaml:"concurrency_class"`
+	MaxMetricContexts           int      `yaml:"max_metric_contexts"`
 }
 
 // CommonGlobalConfig holds the reserved fields for the yaml init_config data
@@ -213,6 +218,29 @@ func (c *Data) GetNameForInstance() string {
 	return commonOptions.Namespace
 }
 
+// GetTagCardinality returns the tag cardinality requested by the instance,
+// empty if it uses the checks_tag_cardinality default
+func (c *Data) GetTagCardinality() string {
+	commonOptions := CommonInstanceConfig{}
+	err := yaml.Unmarshal(*c, &commonOptions)
+	if err != nil {
+		log.Errorf("invalid instance section: %s", err)
+		return ""
+	}
+	return commonOptions.TagCardinality
+}
+
+// HasTagCardinality returns true if one of the instances of the config
+// requests a tag cardinality
+func (c *Config) HasTagCardinality() bool {
+	for _, instance := range c.Instances {
+		if instance.GetTagCardinality() != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // MergeAdditionalTags merges additional tags to possible existing config tags
 func (c *Data) MergeAdditionalTags(tags []string) error {
 	rawConfig := RawMap{}
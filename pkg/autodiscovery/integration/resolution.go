@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package integration
+
+import (
+	"time"
+)
+
+// TemplateResolution explains how a template resolves against the services
+// matching its AD identifiers
+type TemplateResolution struct {
+	Name          string   `json:"name"`
+	Provider      string   `json:"provider"`
+	Source        string   `json:"source"`
+	ADIdentifiers []string `json:"ad_identifiers"`
+	// UnmatchedADIdentifiers are the identifiers no service has
+	UnmatchedADIdentifiers []string `json:"unmatched_ad_identifiers,omitempty"`
+	// FilteredContainers are the containers excluded by the container
+	// filters whose image matches an unmatched identifier
+	FilteredContainers []FilteredContainer `json:"filtered_containers,omitempty"`
+	Services           []ServiceResolution `json:"services"`
+}
+
+// ServiceResolution explains how a template resolves for a service
+type ServiceResolution struct {
+	Entity          string               `json:"entity"`
+	Listener        string               `json:"listener,omitempty"`
+	ADIdentifier    string               `json:"ad_identifier"`
+	Variables       []VariableResolution `json:"variables,omitempty"`
+	Resolved        bool                 `json:"resolved"`
+	Error           string               `json:"error,omitempty"`
+	MetricsExcluded bool                 `json:"metrics_excluded,omitempty"`
+	LogsExcluded    bool                 `json:"logs_excluded,omitempty"`
+}
+
+// VariableResolution records how a template variable was resolved for a service
+type VariableResolution struct {
+	Instance int    `json:"instance"`
+	Variable string `json:"variable"`
+	Value    string `json:"value,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FilteredContainer is a container excluded by the container filters, the
+// listeners don't create any service for it.
+type FilteredContainer struct {
+	Name      string    `json:"name"`
+	Image     string    `json:"image"`
+	Namespace string    `json:"namespace,omitempty"`
+	LastSeen  time.Time `json:"last_seen"`
+}
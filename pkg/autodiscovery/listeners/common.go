@@ -146,7 +146,11 @@ func newContainerFilters() (*containerFilters, error) {
 func (f *containerFilters) IsExcluded(filter containers.FilterType, name, image, ns string) bool {
 	switch filter {
 	case containers.GlobalFilter:
-		return f.global.IsExcluded(name, image, ns)
+		if f.global.IsExcluded(name, image, ns) {
+			recordFilteredContainer(name, image, ns)
+			return true
+		}
+		return false
 	case containers.MetricsFilter:
 		return f.metrics.IsExcluded(name, image, ns)
 	case containers.LogsFilter:
@@ -154,3 +158,21 @@ func (f *containerFilters) IsExcluded(filter containers.FilterType, name, image,
 	}
 	return false
 }
+
+// IsPodContainerExcluded is IsExcluded for the containers of a pod, also
+// applying the pod label filters
+func (f *containerFilters) IsPodContainerExcluded(filter containers.FilterType, name, image, ns string, podLabels map[string]string) bool {
+	switch filter {
+	case containers.GlobalFilter:
+		if f.global.IsPodContainerExcluded(name, image, ns, podLabels) {
+			recordFilteredContainer(name, image, ns)
+			return true
+		}
+		return false
+	case containers.MetricsFilter:
+		return f.metrics.IsPodContainerExcluded(name, image, ns, podLabels)
+	case containers.LogsFilter:
+		return f.logs.IsPodContainerExcluded(name, image, ns, podLabels)
+	}
+	return false
+}
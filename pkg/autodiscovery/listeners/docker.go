@@ -9,7 +9,6 @@ package listeners
 
 import (
 	"fmt"
-	"io"
 	"sort"
 	"strings"
 	"sync"
@@ -18,23 +17,30 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/go-connections/nat"
 
-	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+
+	// register the workloadmeta collectors feeding the store
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors"
 )
 
 // DockerListener implements the ServiceListener interface.
-// It listens for Docker events and reports container updates to Auto Discovery
+// It listens to the docker containers of the workloadmeta store and reports
+// container updates to Auto Discovery
 // It also holds a cache of services that the AutoConfig can query to
 // match templates against.
 type DockerListener struct {
 	dockerUtil *docker.DockerUtil
+	store      *workloadmeta.Store
 	filters    *containerFilters
 	services   map[string]Service
+	// removing holds the containers whose service removal is delayed
+	removing   map[string]struct{}
 	newService chan<- Service
 	delService chan<- Service
 	stop       chan bool
@@ -76,14 +82,16 @@ func NewDockerListener() (ServiceListener, error) {
 	}
 	return &DockerListener{
 		dockerUtil: d,
+		store:      workloadmeta.GetGlobalStore(),
 		filters:    filters,
 		services:   make(map[string]Service),
+		removing:   make(map[string]struct{}),
 		stop:       make(chan bool),
 		health:     health.Register("ad-dockerlistener"),
 	}, nil
 }
 
-// Listen streams container-related events from Docker and report said containers as Services.
+// Listen streams the docker containers of the workloadmeta store and report said containers as Services.
 func (l *DockerListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 	// setup the I/O channels
 	l.newService = newSvc
@@ -92,29 +100,29 @@ func (l *DockerListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
 	// process containers that might be already running
 	l.init()
 
-	messages, errs, err := l.dockerUtil.SubscribeToContainerEvents("DockerListener")
-	if err != nil {
-		log.Errorf("can't listen to docker events: %v", err)
-		signals.ErrorStopper <- true
-		return
-	}
+	// the first events hold the containers already in the store, the ones
+	// found by init are skipped
+	events := l.store.Subscribe("ad-dockerlistener", &workloadmeta.Filter{
+		Kinds:   []workloadmeta.Kind{workloadmeta.KindContainer},
+		Sources: []workloadmeta.Source{workloadmeta.SourceDocker},
+	})
 
 	go func() {
 		for {
 			select {
 			case <-l.stop:
-				l.dockerUtil.UnsubscribeFromContainerEvents("DockerListener")
+				l.store.Unsubscribe(events)
 				l.health.Deregister()
 				return
 			case <-l.health.C:
-			case msg := <-messages:
-				l.processEvent(msg)
-			case err := <-errs:
-				if err != nil && err != io.EOF {
-					log.Errorf("docker listener error: %v", err)
-					signals.ErrorStopper <- true
+			case evs, ok := <-events:
+				if !ok {
+					l.health.Deregister()
+					return
+				}
+				for _, e := range evs {
+					l.processEvent(e)
 				}
-				return
 			}
 		}
 	}()
@@ -172,33 +180,34 @@ func (l *DockerListener) init() {
 	}
 }
 
-// processEvent takes a ContainerEvent, tries to find a service linked to it, and
+// processEvent takes a workloadmeta event, tries to find a service linked to it, and
 // figure out if the AutoConfig could be interested to inspect it.
-func (l *DockerListener) processEvent(e *docker.ContainerEvent) {
-	cID := e.ContainerID
+func (l *DockerListener) processEvent(e workloadmeta.Event) {
+	cID := e.Entity.GetID().ID
 
 	l.m.RLock()
 	_, found := l.services[cID]
+	_, removing := l.removing[cID]
 	l.m.RUnlock()
 
 	if found {
-		switch e.Action {
-		case "die":
+		switch {
+		case e.Type == workloadmeta.EventTypeUnset:
 			l.removeService(cID)
-		case "start":
+		case removing:
 			// Container restarted with the same ID within 5 seconds.
 			time.AfterFunc(5*time.Second, func() {
 				l.createService(cID)
 			})
 		default:
-			// FIXME sometimes the agent's container's events are picked up twice at startup
-			log.Debugf("Expected die for container %s got %s: skipping event", cID[:12], e.Action)
+			// the container is already known, ex: renamed or listed by init
+			log.Debugf("Container %s already has a service: skipping event", cID[:12])
 			return
 		}
 	} else {
-		// we might receive a `die` event for an unrelated container we don't
+		// we might receive an unset event for an unrelated container we don't
 		// care about, let's ignore it.
-		if e.Action == "start" {
+		if e.Type == workloadmeta.EventTypeSet {
 			l.createService(cID)
 		}
 	}
@@ -210,6 +219,8 @@ func (l *DockerListener) createService(cID string) {
 	var svc Service
 	var containerName string
 	var containerImage string
+	var podNamespace string
+	var podLabels map[string]string
 
 	// Detect whether that container is managed by Kubernetes
 	var isKube bool
@@ -224,7 +235,8 @@ func (l *DockerListener) createService(cID string) {
 		}
 		// Detect AD exclusion
 		containerName = cInspect.Name
-		if l.filters.IsExcluded(containers.GlobalFilter, containerName, containerImage, "") {
+		podNamespace, podLabels = l.store.GetPodMetaForContainer(cID, cInspect.Config.Labels)
+		if l.filters.IsPodContainerExcluded(containers.GlobalFilter, containerName, containerImage, podNamespace, podLabels) {
 			log.Debugf("container %s filtered out: name %q image %q", cID[:12], containerName, containerImage)
 			return
 		}
@@ -250,8 +262,8 @@ func (l *DockerListener) createService(cID string) {
 			cID:             cID,
 			creationTime:    integration.After,
 			checkNames:      checkNames,
-			metricsExcluded: l.filters.IsExcluded(containers.MetricsFilter, containerName, containerImage, ""),
-			logsExcluded:    l.filters.IsExcluded(containers.LogsFilter, containerName, containerImage, ""),
+			metricsExcluded: l.filters.IsPodContainerExcluded(containers.MetricsFilter, containerName, containerImage, podNamespace, podLabels),
+			logsExcluded:    l.filters.IsPodContainerExcluded(containers.LogsFilter, containerName, containerImage, podNamespace, podLabels),
 		}
 	}
 
@@ -291,10 +303,15 @@ func (l *DockerListener) removeService(cID string) {
 	l.m.RUnlock()
 
 	if ok {
+		l.m.Lock()
+		l.removing[cID] = struct{}{}
+		l.m.Unlock()
+
 		// delay service removal for short lived service detection
 		time.AfterFunc(5*time.Second, func() {
 			l.m.Lock()
 			delete(l.services, cID)
+			delete(l.removing, cID)
 			l.m.Unlock()
 			l.delService <- svc
 		})
@@ -377,8 +394,9 @@ func (l *DockerListener) isExcluded(co types.Container) bool {
 		log.Warnf("error while resolving image name: %s", err)
 		image = ""
 	}
+	podNamespace, podLabels := l.store.GetPodMetaForContainer(co.ID, co.Labels)
 	for _, name := range co.Names {
-		if l.filters.IsExcluded(containers.GlobalFilter, name, image, "") {
+		if l.filters.IsPodContainerExcluded(containers.GlobalFilter, name, image, podNamespace, podLabels) {
 			log.Debugf("container %s filtered out: name %q image %q", co.ID[:12], name, image)
 			return true
 		}
@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	ecsmeta "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata"
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
@@ -27,6 +28,7 @@ import (
 type ECSListener struct {
 	task       *v2.Task
 	filters    *containerFilters
+	store      *workloadmeta.Store
 	services   map[string]Service // maps container IDs to services
 	newService chan<- Service
 	delService chan<- Service
@@ -69,6 +71,7 @@ func NewECSListener() (ServiceListener, error) {
 		services: make(map[string]Service),
 		stop:     make(chan bool),
 		filters:  filters,
+		store:    workloadmeta.GetGlobalStore(),
 		t:        time.NewTicker(2 * time.Second),
 		health:   health.Register("ad-ecslistener"),
 	}, nil
@@ -131,7 +134,8 @@ func (l *ECSListener) refreshServices(firstRun bool) {
 			continue
 		}
 		// Detect AD exclusion
-		if l.filters.IsExcluded(containers.GlobalFilter, c.DockerName, c.Image, "") {
+		podNamespace, podLabels := l.store.GetPodMetaForContainer(c.DockerID, c.Labels)
+		if l.filters.IsPodContainerExcluded(containers.GlobalFilter, c.DockerName, c.Image, podNamespace, podLabels) {
 			log.Debugf("container %s filtered out: name %q image %q", c.DockerID[:12], c.DockerName, c.Image)
 			continue
 		}
@@ -201,8 +205,9 @@ func (l *ECSListener) createService(c v2.Container, firstRun bool) (ECSService,
 	svc.tags = tags
 
 	// Detect metrics or logs exclusion
-	svc.metricsExcluded = l.filters.IsExcluded(containers.MetricsFilter, c.DockerName, c.Image, "")
-	svc.logsExcluded = l.filters.IsExcluded(containers.LogsFilter, c.DockerName, c.Image, "")
+	podNamespace, podLabels := l.store.GetPodMetaForContainer(c.DockerID, labels)
+	svc.metricsExcluded = l.filters.IsPodContainerExcluded(containers.MetricsFilter, c.DockerName, c.Image, podNamespace, podLabels)
+	svc.logsExcluded = l.filters.IsPodContainerExcluded(containers.LogsFilter, c.DockerName, c.Image, podNamespace, podLabels)
 
 	return svc, err
 }
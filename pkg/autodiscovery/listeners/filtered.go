@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+const (
+	// filteredContainersTTL is the duration a filtered container is reported
+	// after it was last seen by a listener
+	filteredContainersTTL = 10 * time.Minute
+	// filteredContainersMax bounds the number of filtered containers kept
+	filteredContainersMax = 1000
+)
+
+var filteredContainers = struct {
+	sync.Mutex
+	containers map[string]integration.FilteredContainer
+}{containers: make(map[string]integration.FilteredContainer)}
+
+// recordFilteredContainer keeps track of a container excluded by the global
+// filter, to explain why it isn't matched by any template
+func recordFilteredContainer(name, image, namespace string) {
+	filteredContainers.Lock()
+	defer filteredContainers.Unlock()
+
+	key := namespace + "/" + name + "/" + image
+	if _, found := filteredContainers.containers[key]; !found && len(filteredContainers.containers) >= filteredContainersMax {
+		return
+	}
+	filteredContainers.containers[key] = integration.FilteredContainer{
+		Name:      name,
+		Image:     image,
+		Namespace: namespace,
+		LastSeen:  time.Now(),
+	}
+}
+
+// GetFilteredContainers returns the containers excluded by the global
+// filter that were seen recently, sorted by name.
+func GetFilteredContainers() []integration.FilteredContainer {
+	filteredContainers.Lock()
+	defer filteredContainers.Unlock()
+
+	var containers []integration.FilteredContainer
+	for key, c := range filteredContainers.containers {
+		if time.Since(c.LastSeen) > filteredContainersTTL {
+			delete(filteredContainers.containers, key)
+			continue
+		}
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	return containers
+}
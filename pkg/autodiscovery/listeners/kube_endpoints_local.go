@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+// +build kubelet
+
+package listeners
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kubeEndpointsLocalRefreshInterval is the interval between two queries of the
+// annotated services of the namespaces of the local pods
+const kubeEndpointsLocalRefreshInterval = 30 * time.Second
+
+// KubeEndpointsLocalListener is the node agent flavor of the kube_endpoints
+// listener, it only creates the services of the endpoints backed by pods
+// running on the local node. Instead of watching the services and endpoints of
+// the whole cluster, it regularly lists the services of the namespaces of the
+// local pods, read from the kubelet, and only gets the endpoints of the
+// annotated ones, keeping the addresses of the local pods.
+type KubeEndpointsLocalListener struct {
+	apiClient  *apiserver.APIClient
+	kubeUtil   kubelet.KubeUtilInterface
+	endpoints  map[string]*KubeEndpointService // by entity
+	newService chan<- Service
+	delService chan<- Service
+	stop       chan bool
+	t          *time.Ticker
+	health     *health.Handle
+}
+
+func init() {
+	Register("kube_endpoints_local", NewKubeEndpointsLocalListener)
+}
+
+// NewKubeEndpointsLocalListener returns a new KubeEndpointsLocalListener
+func NewKubeEndpointsLocalListener() (ServiceListener, error) {
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the kubelet: %s", err)
+	}
+	ac, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	return &KubeEndpointsLocalListener{
+		apiClient: ac,
+		kubeUtil:  ku,
+		endpoints: make(map[string]*KubeEndpointService),
+		stop:      make(chan bool),
+		t:         time.NewTicker(kubeEndpointsLocalRefreshInterval),
+		health:    health.Register("ad-kubeendpointslocallistener"),
+	}, nil
+}
+
+// Listen regularly queries the annotated services of the local pods and
+// reports their endpoints as Services
+func (l *KubeEndpointsLocalListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	// setup the I/O channels
+	l.newService = newSvc
+	l.delService = delSvc
+
+	go func() {
+		l.refreshServices(true)
+		for {
+			select {
+			case <-l.stop:
+				l.t.Stop()
+				l.health.Deregister()
+				return
+			case <-l.health.C:
+			case <-l.t.C:
+				l.refreshServices(false)
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of KubeEndpointsLocalListener
+func (l *KubeEndpointsLocalListener) Stop() {
+	l.stop <- true
+}
+
+// refreshServices queries the endpoints of the annotated services backed by
+// the local pods and sends the new and deleted services
+func (l *KubeEndpointsLocalListener) refreshServices(firstRun bool) {
+	pods, err := l.kubeUtil.GetLocalPodList()
+	if err != nil {
+		log.Errorf("Cannot get the local pods, not refreshing the endpoints: %s", err)
+		return
+	}
+	serviceEndpoints, err := l.apiClient.GetServiceEndpointsForPods(kubelet.PodNamespacesByIP(pods), func(ksvc *v1.Service) bool {
+		return isServiceAnnotated(ksvc, kubeEndpointsAnnotationFormat)
+	})
+	if err != nil {
+		log.Errorf("Cannot get the endpoints of the local pods, not refreshing the endpoints: %s", err)
+		return
+	}
+
+	var eps []*KubeEndpointService
+	for _, se := range serviceEndpoints {
+		eps = append(eps, processEndpoints(se.Endpoints, firstRun, getStandardTags(se.Service.GetLabels()))...)
+	}
+	l.updateServices(eps)
+}
+
+// updateServices sends the services that are new or changed since the
+// previous refresh, and deletes the ones that are gone or changed
+func (l *KubeEndpointsLocalListener) updateServices(eps []*KubeEndpointService) {
+	notSeen := make(map[string]*KubeEndpointService, len(l.endpoints))
+	for entity, ep := range l.endpoints {
+		notSeen[entity] = ep
+	}
+
+	for _, ep := range eps {
+		old, found := l.endpoints[ep.entity]
+		delete(notSeen, ep.entity)
+		if found {
+			if reflect.DeepEqual(old.ports, ep.ports) && reflect.DeepEqual(old.tags, ep.tags) {
+				continue
+			}
+			log.Debugf("Deleting AD service: %s", old.entity)
+			l.delService <- old
+		}
+		l.endpoints[ep.entity] = ep
+		log.Debugf("Creating a new AD service: %s", ep.entity)
+		l.newService <- ep
+	}
+
+	for entity, ep := range notSeen {
+		delete(l.endpoints, entity)
+		log.Debugf("Deleting AD service: %s", entity)
+		l.delService <- ep
+	}
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+// +build kubelet
+
+package listeners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeEndpointsLocalUpdateServices(t *testing.T) {
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	l := &KubeEndpointsLocalListener{
+		endpoints:  make(map[string]*KubeEndpointService),
+		newService: newSvc,
+		delService: delSvc,
+	}
+
+	ep1 := &KubeEndpointService{entity: "kube_endpoint_uid://default/web/10.0.0.1", ports: []ContainerPort{{80, "http"}}}
+	ep2 := &KubeEndpointService{entity: "kube_endpoint_uid://default/web/10.0.0.2", ports: []ContainerPort{{80, "http"}}}
+	l.updateServices([]*KubeEndpointService{ep1, ep2})
+	assert.Len(t, newSvc, 2)
+	assert.Len(t, delSvc, 0)
+	<-newSvc
+	<-newSvc
+
+	// unchanged services are not sent again
+	l.updateServices([]*KubeEndpointService{
+		{entity: ep1.entity, ports: []ContainerPort{{80, "http"}}},
+		{entity: ep2.entity, ports: []ContainerPort{{80, "http"}}},
+	})
+	assert.Len(t, newSvc, 0)
+	assert.Len(t, delSvc, 0)
+
+	// a changed service is created again, a gone service is deleted
+	changed := &KubeEndpointService{entity: ep1.entity, ports: []ContainerPort{{8080, "http"}}}
+	l.updateServices([]*KubeEndpointService{changed})
+	assert.Len(t, newSvc, 1)
+	assert.Equal(t, changed, <-newSvc)
+	assert.Len(t, delSvc, 2)
+	deleted := []Service{<-delSvc, <-delSvc}
+	assert.ElementsMatch(t, []Service{ep1, ep2}, deleted)
+	assert.Equal(t, map[string]*KubeEndpointService{ep1.entity: changed}, l.endpoints)
+}
@@ -220,14 +220,14 @@ func (l *KubeletListener) createService(entity string, pod *kubelet.Pod, firstRu
 	for _, container := range pod.Status.GetAllContainers() {
 		if container.ID == svc.entity {
 			// Detect AD exclusion
-			if l.filters.IsExcluded(containers.GlobalFilter, container.Name, container.Image, pod.Metadata.Namespace) {
+			if l.filters.IsPodContainerExcluded(containers.GlobalFilter, container.Name, container.Image, pod.Metadata.Namespace, pod.Metadata.Labels) {
 				log.Debugf("container %s filtered out: name %q image %q namespace %q", container.ID, container.Name, container.Image, pod.Metadata.Namespace)
 				return
 			}
 
 			// Detect metrics or logs exclusion
-			svc.metricsExcluded = l.filters.IsExcluded(containers.MetricsFilter, container.Name, container.Image, pod.Metadata.Namespace)
-			svc.logsExcluded = l.filters.IsExcluded(containers.LogsFilter, container.Name, container.Image, pod.Metadata.Namespace)
+			svc.metricsExcluded = l.filters.IsPodContainerExcluded(containers.MetricsFilter, container.Name, container.Image, pod.Metadata.Namespace, pod.Metadata.Labels)
+			svc.logsExcluded = l.filters.IsPodContainerExcluded(containers.LogsFilter, container.Name, container.Image, pod.Metadata.Namespace, pod.Metadata.Labels)
 
 			containerName = container.Name
 
@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/nomad"
+)
+
+const (
+	nomadServiceEntityPrefix = "nomad_service://"
+	nomadRefreshInterval     = 10 * time.Second
+)
+
+// NomadListener implements the ServiceListener interface for the services
+// registered in the Nomad service catalog by the allocations of the node.
+// It polls the local Nomad agent for the services of the running allocations
+// of the node and reports the registrations as Services.
+type NomadListener struct {
+	client     *nomad.Client
+	services   map[string]Service // maps registration IDs to services
+	newService chan<- Service
+	delService chan<- Service
+	stop       chan bool
+	t          *time.Ticker
+	health     *health.Handle
+	m          sync.RWMutex
+}
+
+// NomadService implements the Service interface for a Nomad service registration
+type NomadService struct {
+	entity        string
+	adIdentifiers []string
+	hosts         map[string]string
+	ports         []ContainerPort
+	tags          []string
+	creationTime  integration.CreationTime
+}
+
+// Make sure NomadService implements the Service interface
+var _ Service = &NomadService{}
+
+func init() {
+	Register("nomad", NewNomadListener)
+}
+
+// NewNomadListener creates a NomadListener
+func NewNomadListener() (ServiceListener, error) {
+	client, err := nomad.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return &NomadListener{
+		client:   client,
+		services: make(map[string]Service),
+		stop:     make(chan bool),
+		t:        time.NewTicker(nomadRefreshInterval),
+		health:   health.Register("ad-nomadlistener"),
+	}, nil
+}
+
+// Listen polls regularly the service registrations of the node
+func (l *NomadListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	l.newService = newSvc
+	l.delService = delSvc
+
+	go func() {
+		l.refreshServices(true)
+		for {
+			select {
+			case <-l.stop:
+				l.health.Deregister()
+				return
+			case <-l.health.C:
+			case <-l.t.C:
+				l.refreshServices(false)
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of NomadListener
+func (l *NomadListener) Stop() {
+	l.stop <- true
+}
+
+// refreshServices compares the registrations of the node to the local cache
+// and sends new/removed services over newService and delService accordingly.
+// Registrations are immutable: Nomad registers a new one when the address or
+// port of an allocation changes.
+func (l *NomadListener) refreshServices(firstRun bool) {
+	registrations, err := l.client.GetNodeServices()
+	if err != nil {
+		log.Errorf("failed to list Nomad services, not refreshing services - %s", err)
+		return
+	}
+
+	notSeen := make(map[string]struct{})
+	l.m.RLock()
+	for id := range l.services {
+		notSeen[id] = struct{}{}
+	}
+	l.m.RUnlock()
+
+	for _, reg := range registrations {
+		delete(notSeen, reg.ID)
+		l.m.RLock()
+		_, found := l.services[reg.ID]
+		l.m.RUnlock()
+		if found {
+			continue
+		}
+
+		svc := newNomadService(reg, firstRun)
+		l.m.Lock()
+		l.services[reg.ID] = svc
+		l.m.Unlock()
+		l.newService <- svc
+	}
+
+	for id := range notSeen {
+		l.m.Lock()
+		svc := l.services[id]
+		delete(l.services, id)
+		l.m.Unlock()
+		l.delService <- svc
+	}
+}
+
+func newNomadService(reg nomad.ServiceRegistration, firstRun bool) *NomadService {
+	svc := &NomadService{
+		entity:        nomadServiceEntityPrefix + reg.ID,
+		adIdentifiers: []string{nomadServiceEntityPrefix + reg.ServiceName},
+		hosts:         map[string]string{"nomad": reg.Address},
+		tags: []string{
+			fmt.Sprintf("nomad_service:%s", reg.ServiceName),
+			fmt.Sprintf("nomad_job:%s", reg.JobID),
+			fmt.Sprintf("nomad_namespace:%s", reg.Namespace),
+		},
+		creationTime: integration.After,
+	}
+	if firstRun {
+		svc.creationTime = integration.Before
+	}
+	if reg.Port > 0 {
+		svc.ports = []ContainerPort{{Port: reg.Port, Name: reg.ServiceName}}
+	}
+	return svc
+}
+
+// GetEntity returns the unique entity name linked to that service
+func (s *NomadService) GetEntity() string {
+	return s.entity
+}
+
+// GetTaggerEntity returns the tagger entity name linked to that service
+func (s *NomadService) GetTaggerEntity() string {
+	return s.entity
+}
+
+// GetADIdentifiers returns the service AD identifiers, built from the name
+// of the Nomad service so that templates apply to all its registrations
+func (s *NomadService) GetADIdentifiers() ([]string, error) {
+	return s.adIdentifiers, nil
+}
+
+// GetHosts returns the address of the registration
+func (s *NomadService) GetHosts() (map[string]string, error) {
+	return s.hosts, nil
+}
+
+// GetPorts returns the port of the registration
+func (s *NomadService) GetPorts() ([]ContainerPort, error) {
+	return s.ports, nil
+}
+
+// GetTags returns the tags of the registration
+func (s *NomadService) GetTags() ([]string, error) {
+	return s.tags, nil
+}
+
+// GetPid is not supported for NomadService
+func (s *NomadService) GetPid() (int, error) {
+	return -1, ErrNotSupported
+}
+
+// GetHostname is not supported for NomadService
+func (s *NomadService) GetHostname() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetCreationTime returns the creation time of the service compare to the agent start.
+func (s *NomadService) GetCreationTime() integration.CreationTime {
+	return s.creationTime
+}
+
+// IsReady returns if the service is ready
+func (s *NomadService) IsReady() bool {
+	return true
+}
+
+// GetCheckNames is not supported for NomadService
+func (s *NomadService) GetCheckNames() []string {
+	return nil
+}
+
+// HasFilter always returns false
+// NomadService doesn't implement this method
+func (s *NomadService) HasFilter(filter containers.FilterType) bool {
+	return false
+}
@@ -0,0 +1,596 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/snmp"
+)
+
+const (
+	snmpADIdentifier        = "snmp"
+	snmpEntityPrefix        = "snmp://"
+	snmpDefaultPort         = 161
+	snmpDefaultTimeout      = 5
+	snmpMaxSubnetAddresses  = 1 << 16
+	snmpDiscoveredCacheFile = "snmp"
+)
+
+// SNMPListener implements the ServiceListener interface for the SNMP devices
+// of the configured subnets. It regularly probes every address of the subnets
+// for their sysObjectID and reports the responsive devices as Services.
+type SNMPListener struct {
+	subnets           []*snmpSubnet
+	workers           int
+	discoveryInterval time.Duration
+	allowedFailures   int
+	profiles          []snmpProfile
+	cacheDir          string
+	services          map[string]*SNMPService // maps entities to services
+	newService        chan<- Service
+	delService        chan<- Service
+	stop              chan struct{}
+	m                 sync.RWMutex
+}
+
+// SNMPService implements the Service interface for a discovered SNMP device
+type SNMPService struct {
+	entity       string
+	deviceIP     string
+	sysObjectID  string
+	profile      string
+	subnet       *snmpSubnet
+	creationTime integration.CreationTime
+}
+
+// Make sure SNMPService implements the Service interface
+var _ Service = &SNMPService{}
+
+// snmpSubnetConfig is an entry of the `snmp_listener.configs` option
+type snmpSubnetConfig struct {
+	Network            string   `mapstructure:"network"`
+	Port               int      `mapstructure:"port"`
+	Community          string   `mapstructure:"community"`
+	Version            string   `mapstructure:"snmp_version"`
+	Timeout            int      `mapstructure:"timeout"`
+	Retries            int      `mapstructure:"retries"`
+	IgnoredIPAddresses []string `mapstructure:"ignored_ip_addresses"`
+}
+
+// snmpSubnet is a scanned subnet and the state of its devices
+type snmpSubnet struct {
+	config   snmpSubnetConfig
+	network  *net.IPNet
+	version  int
+	ignored  map[string]struct{}
+	digest   string
+	failures map[string]int // maps the known device IPs to their consecutive failed probes
+}
+
+// snmpProfile associates the devices whose sysObjectID matches one of the
+// patterns to a profile of the snmp check
+type snmpProfile struct {
+	name     string
+	patterns []*regexp.Regexp
+	// specificity holds the number of literal characters of each pattern,
+	// the most specific profile wins when several of them match
+	specificity []int
+}
+
+// snmpDevice is a discovered device, as persisted in the cache
+type snmpDevice struct {
+	IP          string `json:"ip"`
+	SysObjectID string `json:"sys_object_id"`
+}
+
+func init() {
+	Register("snmp", NewSNMPListener)
+}
+
+// NewSNMPListener creates a SNMPListener from the `snmp_listener` options
+func NewSNMPListener() (ServiceListener, error) {
+	var configs []snmpSubnetConfig
+	if err := config.Datadog.UnmarshalKey("snmp_listener.configs", &configs); err != nil {
+		return nil, fmt.Errorf("invalid snmp_listener.configs: %s", err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no subnet configured in snmp_listener.configs")
+	}
+
+	subnets := make([]*snmpSubnet, 0, len(configs))
+	for _, c := range configs {
+		subnet, err := newSNMPSubnet(c)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, subnet)
+	}
+
+	profiles, err := parseSNMPProfiles(config.Datadog.GetStringMapStringSlice("snmp_listener.profiles"))
+	if err != nil {
+		return nil, err
+	}
+
+	workers := config.Datadog.GetInt("snmp_listener.workers")
+	if workers <= 0 {
+		workers = 1
+	}
+
+	discoveryInterval := time.Duration(config.Datadog.GetInt("snmp_listener.discovery_interval")) * time.Second
+	for _, subnet := range subnets {
+		if scanDuration := subnet.maxScanDuration(workers); discoveryInterval > 0 && scanDuration > discoveryInterval {
+			return nil, fmt.Errorf("scanning network %s can take up to %s with %d workers, more than the discovery interval of %s, increase snmp_listener.workers or split the network", subnet.config.Network, scanDuration, workers, discoveryInterval)
+		}
+	}
+
+	return &SNMPListener{
+		subnets:           subnets,
+		workers:           workers,
+		discoveryInterval: discoveryInterval,
+		allowedFailures:   config.Datadog.GetInt("snmp_listener.discovery_allowed_failures"),
+		profiles:          profiles,
+		cacheDir:          filepath.Join(config.Datadog.GetString("run_path"), snmpDiscoveredCacheFile),
+		services:          make(map[string]*SNMPService),
+		stop:              make(chan struct{}),
+	}, nil
+}
+
+func newSNMPSubnet(c snmpSubnetConfig) (*snmpSubnet, error) {
+	_, network, err := net.ParseCIDR(c.Network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %q in snmp_listener.configs: %s", c.Network, err)
+	}
+	if ones, bits := network.Mask.Size(); bits-ones > 16 {
+		return nil, fmt.Errorf("network %s in snmp_listener.configs is too large, at most %d addresses can be scanned", c.Network, snmpMaxSubnetAddresses)
+	}
+	version, err := snmp.ParseVersion(c.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snmp_version of network %s in snmp_listener.configs: %s", c.Network, err)
+	}
+
+	if c.Port == 0 {
+		c.Port = snmpDefaultPort
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = snmpDefaultTimeout
+	}
+	if c.Retries < 0 {
+		c.Retries = 0
+	}
+
+	ignored := make(map[string]struct{}, len(c.IgnoredIPAddresses))
+	for _, ip := range c.IgnoredIPAddresses {
+		ignored[ip] = struct{}{}
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%d|%s|%d", network.String(), c.Port, c.Community, version)
+
+	return &snmpSubnet{
+		config:   c,
+		network:  network,
+		version:  version,
+		ignored:  ignored,
+		digest:   strconv.FormatUint(h.Sum64(), 16),
+		failures: make(map[string]int),
+	}, nil
+}
+
+// maxScanDuration returns how long a scan of the subnet takes when no device
+// answers, every probe waiting for all its retries to time out
+func (s *snmpSubnet) maxScanDuration(workers int) time.Duration {
+	ones, bits := s.network.Mask.Size()
+	probesPerWorker := (1<<uint(bits-ones) + workers - 1) / workers
+	return time.Duration(probesPerWorker*(s.config.Retries+1)*s.config.Timeout) * time.Second
+}
+
+// parseSNMPProfiles compiles the sysObjectID patterns of the profiles, `*`
+// matches any sequence of characters
+func parseSNMPProfiles(definitions map[string][]string) ([]snmpProfile, error) {
+	profiles := make([]snmpProfile, 0, len(definitions))
+	for name, patterns := range definitions {
+		profile := snmpProfile{name: name}
+		for _, pattern := range patterns {
+			pattern = strings.TrimPrefix(pattern, ".")
+			quoted := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+			r, err := regexp.Compile("^" + quoted + "$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid sysObjectID pattern %q of SNMP profile %s: %s", pattern, name, err)
+			}
+			profile.patterns = append(profile.patterns, r)
+			profile.specificity = append(profile.specificity, len(strings.Replace(pattern, "*", "", -1)))
+		}
+		profiles = append(profiles, profile)
+	}
+	// iterate in a stable order, the map order is random
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].name < profiles[j].name })
+	return profiles, nil
+}
+
+// detectSNMPProfile returns the profile whose most specific pattern matches
+// the sysObjectID, or an empty string if none matches
+func detectSNMPProfile(profiles []snmpProfile, sysObjectID string) string {
+	best, bestSpecificity := "", -1
+	for _, profile := range profiles {
+		for i, pattern := range profile.patterns {
+			if pattern.MatchString(sysObjectID) && profile.specificity[i] > bestSpecificity {
+				best, bestSpecificity = profile.name, profile.specificity[i]
+			}
+		}
+	}
+	return best
+}
+
+// Listen loads the devices discovered before the restart, then scans the
+// subnets regularly
+func (l *SNMPListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	l.newService = newSvc
+	l.delService = delSvc
+
+	go func() {
+		for _, subnet := range l.subnets {
+			l.loadCache(subnet)
+		}
+
+		l.discover()
+		if l.discoveryInterval <= 0 {
+			<-l.stop
+			return
+		}
+
+		t := time.NewTicker(l.discoveryInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-t.C:
+				l.discover()
+			}
+		}
+	}()
+}
+
+// Stop interrupts the current scan and stops the SNMPListener
+func (l *SNMPListener) Stop() {
+	close(l.stop)
+}
+
+// discover scans all the subnets once
+func (l *SNMPListener) discover() {
+	for _, subnet := range l.subnets {
+		log.Debugf("Scanning the SNMP devices of network %s", subnet.config.Network)
+		devices, complete := l.scanSubnet(subnet)
+		if !complete {
+			// the listener is stopping
+			return
+		}
+		l.refreshServices(subnet, devices)
+		l.saveCache(subnet)
+	}
+}
+
+// scanSubnet probes the sysObjectID of every address of the subnet, and
+// returns the responsive devices, or false if the scan was interrupted
+func (l *SNMPListener) scanSubnet(subnet *snmpSubnet) (map[string]string, bool) {
+	ips := make(chan string)
+	devices := make(map[string]string)
+	var m sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < l.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range ips {
+				client := &snmp.Client{
+					Host:      ip,
+					Port:      subnet.config.Port,
+					Community: subnet.config.Community,
+					Version:   subnet.version,
+					Timeout:   time.Duration(subnet.config.Timeout) * time.Second,
+					Retries:   subnet.config.Retries,
+				}
+				sysObjectID, err := client.GetOID(snmp.SysObjectIDOID)
+				if err != nil {
+					log.Tracef("No SNMP device on %s: %s", ip, err)
+					continue
+				}
+				m.Lock()
+				devices[ip] = sysObjectID
+				m.Unlock()
+			}
+		}()
+	}
+
+	for _, ip := range subnetAddresses(subnet) {
+		select {
+		case ips <- ip:
+		case <-l.stop:
+			close(ips)
+			wg.Wait()
+			return nil, false
+		}
+	}
+	close(ips)
+	wg.Wait()
+	return devices, true
+}
+
+// subnetAddresses returns the addresses of the subnet to probe, without the
+// ignored ones, nor the network and broadcast addresses of IPv4 networks
+func subnetAddresses(subnet *snmpSubnet) []string {
+	var ips []string
+	ip := make(net.IP, len(subnet.network.IP))
+	copy(ip, subnet.network.IP)
+
+	ones, bits := subnet.network.Mask.Size()
+	skipEdges := bits == 32 && bits-ones > 1
+
+	for ; subnet.network.Contains(ip); incrementIP(ip) {
+		if skipEdges && (ip.Equal(subnet.network.IP) || isBroadcast(ip, subnet.network)) {
+			continue
+		}
+		s := ip.String()
+		if _, found := subnet.ignored[s]; found {
+			continue
+		}
+		ips = append(ips, s)
+	}
+	return ips
+}
+
+func incrementIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+func isBroadcast(ip net.IP, network *net.IPNet) bool {
+	for i := range ip {
+		if ip[i]|network.Mask[i] != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshServices creates the services of the new devices and deletes the
+// ones of the devices that didn't respond to `allowedFailures` scans in a row
+func (l *SNMPListener) refreshServices(subnet *snmpSubnet, devices map[string]string) {
+	for ip := range subnet.failures {
+		if _, found := devices[ip]; found {
+			continue
+		}
+		subnet.failures[ip]++
+		if subnet.failures[ip] <= l.allowedFailures {
+			continue
+		}
+
+		delete(subnet.failures, ip)
+		entity := snmpEntity(subnet, ip)
+		l.m.Lock()
+		svc, found := l.services[entity]
+		delete(l.services, entity)
+		l.m.Unlock()
+		if found {
+			log.Infof("SNMP device %s of network %s stopped responding, removing it", ip, subnet.config.Network)
+			l.delService <- svc
+		}
+	}
+
+	for ip, sysObjectID := range devices {
+		subnet.failures[ip] = 0
+		l.createService(subnet, ip, sysObjectID, integration.After)
+	}
+}
+
+// createService reports the service of a device, or recreates it if its
+// sysObjectID changed
+func (l *SNMPListener) createService(subnet *snmpSubnet, ip, sysObjectID string, creationTime integration.CreationTime) {
+	entity := snmpEntity(subnet, ip)
+
+	l.m.RLock()
+	old, found := l.services[entity]
+	l.m.RUnlock()
+	if found {
+		if old.sysObjectID == sysObjectID {
+			return
+		}
+		l.delService <- old
+	}
+
+	svc := &SNMPService{
+		entity:       entity,
+		deviceIP:     ip,
+		sysObjectID:  sysObjectID,
+		profile:      detectSNMPProfile(l.profiles, sysObjectID),
+		subnet:       subnet,
+		creationTime: creationTime,
+	}
+	log.Infof("Discovered SNMP device %s of network %s with sysObjectID %s and profile %q", ip, subnet.config.Network, sysObjectID, svc.profile)
+
+	l.m.Lock()
+	l.services[entity] = svc
+	l.m.Unlock()
+	l.newService <- svc
+}
+
+func snmpEntity(subnet *snmpSubnet, ip string) string {
+	return fmt.Sprintf("%s%s:%s", snmpEntityPrefix, subnet.digest, ip)
+}
+
+func (l *SNMPListener) cachePath(subnet *snmpSubnet) string {
+	return filepath.Join(l.cacheDir, subnet.digest+".json")
+}
+
+// loadCache creates the services of the devices of the subnet discovered
+// before the restart, without waiting for the first scan
+func (l *SNMPListener) loadCache(subnet *snmpSubnet) {
+	content, err := ioutil.ReadFile(l.cachePath(subnet))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Couldn't read the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+		}
+		return
+	}
+
+	var devices []snmpDevice
+	if err := json.Unmarshal(content, &devices); err != nil {
+		log.Warnf("Couldn't parse the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+		return
+	}
+	for _, device := range devices {
+		if ip := net.ParseIP(device.IP); ip == nil || !subnet.network.Contains(ip) {
+			continue
+		}
+		if _, found := subnet.ignored[device.IP]; found {
+			continue
+		}
+		subnet.failures[device.IP] = 0
+		l.createService(subnet, device.IP, device.SysObjectID, integration.Before)
+	}
+}
+
+// saveCache persists the devices of the subnet, the file is replaced
+// atomically so that a crash never leaves a truncated cache
+func (l *SNMPListener) saveCache(subnet *snmpSubnet) {
+	devices := []snmpDevice{}
+	l.m.RLock()
+	for _, svc := range l.services {
+		if svc.subnet == subnet {
+			devices = append(devices, snmpDevice{IP: svc.deviceIP, SysObjectID: svc.sysObjectID})
+		}
+	}
+	l.m.RUnlock()
+	sort.Slice(devices, func(i, j int) bool { return devices[i].IP < devices[j].IP })
+
+	content, err := json.Marshal(devices)
+	if err != nil {
+		log.Warnf("Couldn't persist the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+		return
+	}
+	if err := os.MkdirAll(l.cacheDir, 0755); err != nil {
+		log.Warnf("Couldn't persist the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+		return
+	}
+	tmpPath := l.cachePath(subnet) + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0640); err != nil {
+		log.Warnf("Couldn't persist the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+		return
+	}
+	if err := os.Rename(tmpPath, l.cachePath(subnet)); err != nil {
+		log.Warnf("Couldn't persist the SNMP devices discovered in network %s: %s", subnet.config.Network, err)
+	}
+}
+
+// GetEntity returns the unique entity name linked to that service
+func (s *SNMPService) GetEntity() string {
+	return s.entity
+}
+
+// GetTaggerEntity returns the unique entity name linked to that service
+func (s *SNMPService) GetTaggerEntity() string {
+	return s.entity
+}
+
+// GetADIdentifiers returns the `snmp` identifier shared by all the devices
+func (s *SNMPService) GetADIdentifiers() ([]string, error) {
+	return []string{snmpADIdentifier}, nil
+}
+
+// GetHosts returns the IP address of the device
+func (s *SNMPService) GetHosts() (map[string]string, error) {
+	return map[string]string{"": s.deviceIP}, nil
+}
+
+// GetPorts returns the SNMP port of the subnet
+func (s *SNMPService) GetPorts() ([]ContainerPort, error) {
+	return []ContainerPort{{Port: s.subnet.config.Port, Name: "snmp"}}, nil
+}
+
+// GetTags returns the tags identifying the device and its subnet
+func (s *SNMPService) GetTags() ([]string, error) {
+	return []string{
+		"snmp_device:" + s.deviceIP,
+		"autodiscovery_subnet:" + s.subnet.config.Network,
+	}, nil
+}
+
+// GetPid is not supported for SNMP devices
+func (s *SNMPService) GetPid() (int, error) {
+	return -1, ErrNotSupported
+}
+
+// GetHostname is not supported for SNMP devices
+func (s *SNMPService) GetHostname() (string, error) {
+	return "", ErrNotSupported
+}
+
+// GetCreationTime returns whether the device was discovered before the agent start
+func (s *SNMPService) GetCreationTime() integration.CreationTime {
+	return s.creationTime
+}
+
+// IsReady returns true, the device answered the discovery probe
+func (s *SNMPService) IsReady() bool {
+	return true
+}
+
+// GetCheckNames returns nil, the device doesn't configure its checks
+func (s *SNMPService) GetCheckNames() []string {
+	return nil
+}
+
+// HasFilter returns false, the container filters don't apply to SNMP devices
+func (s *SNMPService) HasFilter(filter containers.FilterType) bool {
+	return false
+}
+
+// GetExtraConfig resolves the %%extra_<key>%% template variables with the
+// discovery settings of the device
+func (s *SNMPService) GetExtraConfig(key []byte) ([]byte, error) {
+	switch string(key) {
+	case "community":
+		return []byte(s.subnet.config.Community), nil
+	case "version":
+		if s.subnet.version == snmp.Version1 {
+			return []byte("1"), nil
+		}
+		return []byte("2"), nil
+	case "timeout":
+		return []byte(strconv.Itoa(s.subnet.config.Timeout)), nil
+	case "retries":
+		return []byte(strconv.Itoa(s.subnet.config.Retries)), nil
+	case "network":
+		return []byte(s.subnet.config.Network), nil
+	case "profile":
+		return []byte(s.profile), nil
+	case "sysobjectid":
+		return []byte(s.sysObjectID), nil
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func TestDetectSNMPProfile(t *testing.T) {
+	profiles, err := parseSNMPProfiles(map[string][]string{
+		"generic-router": {"1.3.6.1.4.1.*"},
+		"cisco":          {"1.3.6.1.4.1.9.*"},
+		"cisco-nexus":    {"1.3.6.1.4.1.9.12.3.1.3.*", ".1.3.6.1.4.1.9.1.1208"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "cisco-nexus", detectSNMPProfile(profiles, "1.3.6.1.4.1.9.1.1208"))
+	assert.Equal(t, "cisco-nexus", detectSNMPProfile(profiles, "1.3.6.1.4.1.9.12.3.1.3.1812"))
+	assert.Equal(t, "cisco", detectSNMPProfile(profiles, "1.3.6.1.4.1.9.1.1"))
+	assert.Equal(t, "generic-router", detectSNMPProfile(profiles, "1.3.6.1.4.1.2636.1.1.1.2.29"))
+	assert.Equal(t, "", detectSNMPProfile(profiles, "1.3.6.1.2.1.1"))
+}
+
+func TestSubnetAddresses(t *testing.T) {
+	subnet, err := newSNMPSubnet(snmpSubnetConfig{
+		Network:            "10.0.0.0/29",
+		IgnoredIPAddresses: []string{"10.0.0.3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.4", "10.0.0.5", "10.0.0.6"}, subnetAddresses(subnet))
+	assert.Equal(t, snmpDefaultPort, subnet.config.Port)
+
+	subnet, err = newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.8/31"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.8", "10.0.0.9"}, subnetAddresses(subnet))
+
+	_, err = newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.0/8"})
+	assert.Error(t, err)
+	_, err = newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.0/24", Version: "3"})
+	assert.Error(t, err)
+}
+
+func TestSubnetMaxScanDuration(t *testing.T) {
+	subnet, err := newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.0/24", Timeout: 2, Retries: 1})
+	require.NoError(t, err)
+	// 256 addresses, 3 probes per worker, each of them timing out twice
+	assert.Equal(t, 12*time.Second, subnet.maxScanDuration(100))
+	assert.Equal(t, 1024*time.Second, subnet.maxScanDuration(1))
+
+	subnet, err = newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.0/16"})
+	require.NoError(t, err)
+	assert.Equal(t, 3280*time.Second, subnet.maxScanDuration(100))
+}
+
+func TestSNMPListenerRefresh(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "snmp-listener")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	subnet, err := newSNMPSubnet(snmpSubnetConfig{Network: "10.0.0.0/24", Community: "public"})
+	require.NoError(t, err)
+	profiles, err := parseSNMPProfiles(map[string][]string{"cisco": {"1.3.6.1.4.1.9.*"}})
+	require.NoError(t, err)
+
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	newListener := func() *SNMPListener {
+		return &SNMPListener{
+			subnets:         []*snmpSubnet{subnet},
+			allowedFailures: 1,
+			profiles:        profiles,
+			cacheDir:        cacheDir,
+			services:        make(map[string]*SNMPService),
+			newService:      newSvc,
+			delService:      delSvc,
+		}
+	}
+	l := newListener()
+
+	l.refreshServices(subnet, map[string]string{"10.0.0.2": "1.3.6.1.4.1.9.1.1208"})
+	require.Len(t, newSvc, 1)
+	svc := (<-newSvc).(*SNMPService)
+	assert.Equal(t, integration.After, svc.GetCreationTime())
+	hosts, _ := svc.GetHosts()
+	assert.Equal(t, map[string]string{"": "10.0.0.2"}, hosts)
+	ports, _ := svc.GetPorts()
+	assert.Equal(t, []ContainerPort{{Port: 161, Name: "snmp"}}, ports)
+	profile, _ := svc.GetExtraConfig([]byte("profile"))
+	assert.Equal(t, "cisco", string(profile))
+	community, _ := svc.GetExtraConfig([]byte("community"))
+	assert.Equal(t, "public", string(community))
+
+	// the devices discovered before a restart are scheduled right away
+	l.saveCache(subnet)
+	subnet.failures = make(map[string]int)
+	restarted := newListener()
+	restarted.loadCache(subnet)
+	require.Len(t, newSvc, 1)
+	cached := (<-newSvc).(*SNMPService)
+	assert.Equal(t, svc.GetEntity(), cached.GetEntity())
+	assert.Equal(t, integration.Before, cached.GetCreationTime())
+	assert.Equal(t, "cisco", cached.profile)
+
+	// the device is kept until it misses more than allowedFailures scans
+	restarted.refreshServices(subnet, map[string]string{})
+	assert.Len(t, delSvc, 0)
+	restarted.refreshServices(subnet, map[string]string{})
+	require.Len(t, delSvc, 1)
+	assert.Equal(t, svc.GetEntity(), (<-delSvc).GetEntity())
+	assert.Len(t, newSvc, 0)
+}
@@ -69,3 +69,9 @@ func Register(name string, factory ServiceListenerFactory) {
 
 // ErrNotSupported is thrown if listener doesn't support the asked variable
 var ErrNotSupported = errors.New("AD: variable not supported by listener")
+
+// ExtraConfigService is implemented by the services exposing additional
+// settings to their templates, with the %%extra_<key>%% template variables
+type ExtraConfigService interface {
+	GetExtraConfig(key []byte) ([]byte, error)
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// adAnnotationPrefix is the prefix of the autodiscovery annotations of the pods,
+// services and endpoints
+const adAnnotationPrefix = "ad.datadoghq.com/"
+
+// ValidateADAnnotations checks the autodiscovery annotations of a Kubernetes object
+// like the providers parse them, and against the schema of the checks and logs
+// configurations. identifiers are the names the annotations can be scoped to: the
+// containers of a pod, or service and endpoints for a service. It's used by the
+// admission controller of the cluster agent to reject the invalid annotations
+// of the pods, their templates and the services.
+func ValidateADAnnotations(source string, annotations map[string]string, identifiers []string) []config.ValidationIssue {
+	var issues []config.ValidationIssue
+	known := make(map[string]bool, len(identifiers))
+	for _, id := range identifiers {
+		known[id] = true
+	}
+
+	// the templates scoped to unknown identifiers are ignored by the providers, they
+	// are only reported as warnings since they used to be accepted
+	var unknown []string
+	for name := range annotations {
+		if !strings.HasPrefix(name, adAnnotationPrefix) {
+			continue
+		}
+		scoped := strings.TrimPrefix(name, adAnnotationPrefix)
+		for _, path := range []string{checkNamePath, initConfigPath, instancePath, logsConfigPath} {
+			if id := strings.TrimSuffix(scoped, "."+path); id != scoped && !known[id] {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		issues = append(issues, config.ValidationIssue{
+			Severity: config.ValidationWarning,
+			File:     source,
+			Key:      name,
+			Message:  fmt.Sprintf("the annotation doesn't match any of %s, it is ignored", strings.Join(identifiers, ", ")),
+		})
+	}
+
+	for _, id := range identifiers {
+		prefix := adAnnotationPrefix + id + "."
+		configs, errors := extractTemplatesFromMap(id, annotations, prefix)
+		for _, err := range errors {
+			issues = append(issues, config.ValidationIssue{
+				Severity: config.ValidationError,
+				File:     source,
+				Key:      prefix + "*",
+				Message:  err.Error(),
+			})
+		}
+		if _, found := annotations[prefix+checkNamePath]; found && len(errors) == 0 && !hasCheckConfig(configs) {
+			issues = append(issues, config.ValidationIssue{
+				Severity: config.ValidationError,
+				File:     source,
+				Key:      prefix + "*",
+				Message:  fmt.Sprintf("%s, %s and %s must hold one entry per check", checkNamePath, initConfigPath, instancePath),
+			})
+		}
+		for _, c := range configs {
+			c.Source = source
+			for _, issue := range validateIntegrationConfig(c) {
+				issue.Key = prefix + issue.Key
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
+
+func hasCheckConfig(configs []integration.Config) bool {
+	for _, c := range configs {
+		if c.Name != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestValidateADAnnotations(t *testing.T) {
+	valid := map[string]string{
+		"ad.datadoghq.com/redis.check_names":  `["redisdb"]`,
+		"ad.datadoghq.com/redis.init_configs": `[{}]`,
+		"ad.datadoghq.com/redis.instances":    `[{"host": "%%host%%", "port": "6379"}]`,
+		"ad.datadoghq.com/redis.logs":         `[{"source": "redis", "service": "cache"}]`,
+		"ad.datadoghq.com/tolerate-unready":   "true",
+	}
+	assert.Empty(t, ValidateADAnnotations("pod:default/redis", valid, []string{"redis", "sidecar"}))
+
+	issues := ValidateADAnnotations("pod:default/redis", map[string]string{
+		"ad.datadoghq.com/redis.check_names":  `["redisdb"]`,
+		"ad.datadoghq.com/redis.init_configs": `[{}]`,
+		"ad.datadoghq.com/redis.instances":    `[{"host": "%%host%%", "tags": "env:prod"}]`,
+		"ad.datadoghq.com/redis.logs":         `{"source": "redis"}`,
+		"ad.datadoghq.com/cache.instances":    `[{}]`,
+		"ad.datadoghq.com/proxy.check_names":  `["envoy", "nginx"]`,
+		"ad.datadoghq.com/proxy.init_configs": `[{}]`,
+		"ad.datadoghq.com/proxy.instances":    `[{}]`,
+	}, []string{"redis", "proxy"})
+	var keys []string
+	for _, issue := range issues {
+		assert.Equal(t, "pod:default/redis", issue.File)
+		keys = append(keys, issue.Key)
+	}
+	// the annotations scoped to unknown identifiers are only warned about
+	assert.Equal(t, config.ValidationWarning, issues[0].Severity)
+	assert.Equal(t, config.ValidationError, issues[1].Severity)
+	assert.Equal(t, []string{
+		"ad.datadoghq.com/cache.instances",
+		"ad.datadoghq.com/redis.*",
+		"ad.datadoghq.com/redis.instances[0].tags",
+		"ad.datadoghq.com/proxy.*",
+	}, keys)
+}
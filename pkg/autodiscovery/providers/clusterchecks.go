@@ -6,6 +6,8 @@
 package providers
 
 import (
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -13,12 +15,20 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const defaultGraceDuration = 60 * time.Second
 
+// FlareRequestHandler builds and sends the flares the cluster-agent asks for.
+// It's set by the agent, as the flare package depends on this one.
+var FlareRequestHandler func(request types.FlareRequest)
+
+// flareInProgress is 1 while a requested flare is built, only one is built at a time
+var flareInProgress int32
+
 // ClusterChecksConfigProvider implements the ConfigProvider interface
 // for the cluster check feature.
 type ClusterChecksConfigProvider struct {
@@ -27,6 +37,7 @@ type ClusterChecksConfigProvider struct {
 	heartbeat      time.Time
 	lastChange     int64
 	nodeName       string
+	identity       *types.NodeIdentity
 	flushedConfigs bool
 }
 
@@ -39,6 +50,22 @@ func NewClusterChecksConfigProvider(cfg config.ConfigurationProviders) (ConfigPr
 	}
 
 	c.nodeName, _ = util.GetHostname()
+	if specPath := config.Datadog.GetString("clc_runner_bosh_spec_file"); specPath != "" {
+		// Register with the BOSH instance identity, stable across the
+		// recreations of the VM, and its availability zone
+		instance, err := cloudfoundry.GetBOSHInstance(specPath)
+		if err != nil {
+			return nil, err
+		}
+		c.nodeName = fmt.Sprintf("%s.%s.%s", instance.Deployment, instance.InstanceGroup, instance.ID)
+		c.identity = &types.NodeIdentity{
+			Type:       types.NodeIdentityBOSH,
+			Deployment: instance.Deployment,
+			Job:        instance.InstanceGroup,
+			InstanceID: instance.ID,
+			Zone:       instance.AZ,
+		}
+	}
 	if cfg.GraceTimeSeconds > 0 {
 		c.graceDuration = time.Duration(cfg.GraceTimeSeconds) * time.Second
 	}
@@ -78,6 +105,7 @@ func (c *ClusterChecksConfigProvider) IsUpToDate() (bool, error) {
 
 	status := types.NodeStatus{
 		LastChange: c.lastChange,
+		Identity:   c.identity,
 	}
 
 	reply, err := c.dcaClient.PostClusterCheckStatus(c.nodeName, status)
@@ -92,6 +120,16 @@ func (c *ClusterChecksConfigProvider) IsUpToDate() (bool, error) {
 	}
 
 	c.heartbeat = time.Now()
+	if reply.Flare != nil {
+		if FlareRequestHandler == nil {
+			log.Warnf("The cluster-agent requested a flare but this agent can't build one")
+		} else if atomic.CompareAndSwapInt32(&flareInProgress, 0, 1) {
+			log.Infof("The cluster-agent requested a flare for case %q", reply.Flare.CaseID)
+			go handleFlareRequest(*reply.Flare)
+		} else {
+			log.Warnf("The cluster-agent requested a flare for case %q but one is already in progress, ignoring it", reply.Flare.CaseID)
+		}
+	}
 	if reply.IsUpToDate {
 		log.Tracef("Up to date with change %d", c.lastChange)
 	} else {
@@ -100,6 +138,18 @@ func (c *ClusterChecksConfigProvider) IsUpToDate() (bool, error) {
 	return reply.IsUpToDate, nil
 }
 
+// handleFlareRequest calls FlareRequestHandler, a panic while the flare is built
+// mustn't crash the agent
+func handleFlareRequest(request types.FlareRequest) {
+	defer atomic.StoreInt32(&flareInProgress, 0)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("The flare requested by the cluster-agent for case %q panicked: %v", request.CaseID, r)
+		}
+	}()
+	FlareRequestHandler(request)
+}
+
 // Collect retrieves configurations the cluster-agent dispatched to this agent
 func (c *ClusterChecksConfigProvider) Collect() ([]integration.Config, error) {
 	if c.dcaClient == nil {
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	consul "github.com/hashicorp/consul/api"
@@ -47,6 +48,7 @@ type ConsulConfigProvider struct {
 	Client      consulBackend
 	TemplateDir string
 	cache       *ProviderCache
+	watch       *templateWatch
 }
 
 // NewConsulConfigProvider creates a client connection to consul and create a new ConsulConfigProvider
@@ -90,12 +92,17 @@ func NewConsulConfigProvider(config config.ConfigurationProviders) (ConfigProvid
 		client: cli,
 	}
 
-	return &ConsulConfigProvider{
+	p := &ConsulConfigProvider{
 		Client:      c,
 		TemplateDir: config.TemplateDir,
 		cache:       cache,
-	}, nil
+	}
+	if config.Watch {
+		p.watch = newTemplateWatch(names.Consul)
+		go p.watchTemplates()
+	}
 
+	return p, nil
 }
 
 // String returns a string representation of the ConsulConfigProvider
@@ -151,6 +158,56 @@ func (p *ConsulConfigProvider) IsUpToDate() (bool, error) {
 	return true, nil
 }
 
+// Changes returns the channel notified when the templates stored under
+// TemplateDir are modified, it is nil when the watch is disabled.
+func (p *ConsulConfigProvider) Changes() <-chan struct{} {
+	if p.watch == nil {
+		return nil
+	}
+	return p.watch.changes
+}
+
+// watchTemplates runs blocking queries on the TemplateDir prefix and
+// notifies every modification of the keys it contains.
+func (p *ConsulConfigProvider) watchTemplates() {
+	kv := p.Client.KV()
+	var lastIndex uint64
+
+	for {
+		_, meta, err := kv.List(p.TemplateDir, &consul.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  watchWaitTime,
+		})
+		if err != nil {
+			log.Warnf("Can't watch templates in consul, retrying in %s: %s", watchRetryDelay, err)
+			p.watch.fail()
+			time.Sleep(watchRetryDelay)
+			continue
+		}
+
+		if !p.processWatchIndex(&lastIndex, meta.LastIndex) {
+			continue
+		}
+		log.Debugf("Templates modified in consul at index %d", meta.LastIndex)
+		p.watch.notify()
+	}
+}
+
+// processWatchIndex updates the index of the blocking queries and returns
+// whether the templates changed since the previous query. As recommended
+// by Consul, the index is reset when it goes backwards, which can happen
+// when the KV store is restored, so that case is reported as a change.
+func (p *ConsulConfigProvider) processWatchIndex(lastIndex *uint64, index uint64) bool {
+	previous := *lastIndex
+	if index < previous {
+		*lastIndex = 0
+		return true
+	}
+	*lastIndex = index
+	// the first query returns immediately and only initializes the index
+	return previous != 0 && index > previous
+}
+
 // getIdentifiers gets folders at the root of the TemplateDir
 // verifies they have the right content to be a valid template
 // and return their names.
@@ -351,3 +351,34 @@ func TestIsUpToDate(t *testing.T) {
 	provider.AssertExpectations(t)
 	kv.AssertExpectations(t)
 }
+
+func TestConsulProcessWatchIndex(t *testing.T) {
+	provider := &ConsulConfigProvider{}
+	var lastIndex uint64
+
+	// first query only initializes the index
+	assert.False(t, provider.processWatchIndex(&lastIndex, 10))
+	assert.Equal(t, uint64(10), lastIndex)
+
+	// blocking query timed out without modification
+	assert.False(t, provider.processWatchIndex(&lastIndex, 10))
+
+	assert.True(t, provider.processWatchIndex(&lastIndex, 12))
+	assert.Equal(t, uint64(12), lastIndex)
+
+	// index went backwards, it is reset
+	assert.True(t, provider.processWatchIndex(&lastIndex, 3))
+	assert.Equal(t, uint64(0), lastIndex)
+}
+
+func TestConsulChanges(t *testing.T) {
+	provider := &ConsulConfigProvider{}
+	assert.Nil(t, provider.Changes())
+
+	provider.watch = newTemplateWatch("consul")
+	provider.watch.notify()
+	provider.watch.notify()
+
+	// notifications are coalesced
+	assert.Len(t, provider.Changes(), 1)
+}
@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// datadogCheckGVR identifies the DatadogCheck custom resources
+var datadogCheckGVR = schema.GroupVersionResource{
+	Group:    "datadoghq.com",
+	Version:  "v1alpha1",
+	Resource: "datadogchecks",
+}
+
+// DatadogCheckConfigProvider implements the ConfigProvider interface for the
+// DatadogCheck custom resources. In the cluster agent, it provides the cluster
+// checks to dispatch; in the node agents, the templates resolved against the
+// local containers and the checks to run on every node.
+type DatadogCheckConfigProvider struct {
+	lister   cache.GenericLister
+	upToDate bool
+}
+
+// NewDatadogCheckConfigProvider returns a new ConfigProvider watching the
+// DatadogCheck resources of every namespace
+func NewDatadogCheckConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	factory, err := apiserver.GetDynamicInformerFactory()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	informer := factory.ForResource(datadogCheckGVR)
+	p := &DatadogCheckConfigProvider{
+		lister: informer.Lister(),
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    p.invalidate,
+		UpdateFunc: p.invalidateIfChanged,
+		DeleteFunc: p.invalidate,
+	})
+
+	// the provider lives as long as the agent
+	factory.Start(make(chan struct{}))
+
+	return p, nil
+}
+
+// String returns a string representation of the DatadogCheckConfigProvider
+func (p *DatadogCheckConfigProvider) String() string {
+	return names.DatadogChecks
+}
+
+// Collect builds the Config objects of the DatadogCheck resources
+func (p *DatadogCheckConfigProvider) Collect() ([]integration.Config, error) {
+	objects, err := p.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	p.upToDate = true
+
+	return parseDatadogChecks(objects), nil
+}
+
+// IsUpToDate allows to cache configs as long as no changes are detected in the apiserver
+func (p *DatadogCheckConfigProvider) IsUpToDate() (bool, error) {
+	return p.upToDate, nil
+}
+
+func (p *DatadogCheckConfigProvider) invalidate(obj interface{}) {
+	if obj != nil {
+		log.Trace("Invalidating configs on new/deleted DatadogCheck")
+		p.upToDate = false
+	}
+}
+
+func (p *DatadogCheckConfigProvider) invalidateIfChanged(old, obj interface{}) {
+	castedObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %v", obj)
+		return
+	}
+	castedOld, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %v", old)
+		p.upToDate = false
+		return
+	}
+	// the generation only changes with the spec
+	if castedObj.GetGeneration() == castedOld.GetGeneration() {
+		return
+	}
+	log.Trace("Invalidating configs on DatadogCheck change")
+	p.upToDate = false
+}
+
+// parseDatadogChecks converts the DatadogCheck resources to configs, the
+// invalid resources are skipped. As for the annotations, the configurations
+// are serialized in JSON, which is valid YAML.
+func parseDatadogChecks(objects []runtime.Object) []integration.Config {
+	var configs []integration.Config
+	for _, obj := range objects {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			log.Errorf("Expected an Unstructured type, got: %v", obj)
+			continue
+		}
+		conf, err := parseDatadogCheck(u)
+		if err != nil {
+			log.Errorf("Cannot parse DatadogCheck %s/%s: %s", u.GetNamespace(), u.GetName(), err)
+			continue
+		}
+		configs = append(configs, conf)
+	}
+	return configs
+}
+
+func parseDatadogCheck(u *unstructured.Unstructured) (integration.Config, error) {
+	conf := integration.Config{
+		Source: fmt.Sprintf("datadogcheck:%s/%s", u.GetNamespace(), u.GetName()),
+	}
+
+	name, _, err := unstructured.NestedString(u.Object, "spec", "name")
+	if err != nil {
+		return conf, err
+	}
+	if name == "" {
+		return conf, fmt.Errorf("spec.name is required")
+	}
+	conf.Name = name
+
+	conf.ClusterCheck, _, err = unstructured.NestedBool(u.Object, "spec", "clusterCheck")
+	if err != nil {
+		return conf, err
+	}
+
+	conf.ADIdentifiers, _, err = unstructured.NestedStringSlice(u.Object, "spec", "adIdentifiers")
+	if err != nil {
+		return conf, err
+	}
+	if conf.ClusterCheck && len(conf.ADIdentifiers) > 0 {
+		return conf, fmt.Errorf("spec.adIdentifiers cannot be set for cluster checks")
+	}
+
+	initConfig, found, err := unstructured.NestedMap(u.Object, "spec", "initConfig")
+	if err != nil {
+		return conf, err
+	}
+	if !found {
+		initConfig = map[string]interface{}{}
+	}
+	if conf.InitConfig, err = json.Marshal(initConfig); err != nil {
+		return conf, err
+	}
+
+	instances, _, err := unstructured.NestedSlice(u.Object, "spec", "instances")
+	if err != nil {
+		return conf, err
+	}
+	if len(instances) == 0 {
+		return conf, fmt.Errorf("spec.instances must hold at least one instance")
+	}
+	for _, instance := range instances {
+		data, err := json.Marshal(instance)
+		if err != nil {
+			return conf, err
+		}
+		conf.Instances = append(conf.Instances, data)
+	}
+
+	logs, found, err := unstructured.NestedSlice(u.Object, "spec", "logs")
+	if err != nil {
+		return conf, err
+	}
+	if found && len(logs) > 0 {
+		if conf.LogsConfig, err = json.Marshal(logs); err != nil {
+			return conf, err
+		}
+	}
+
+	return conf, nil
+}
+
+// ValidateDatadogCheck checks a DatadogCheck like the datadogchecks provider parses
+// it, and against the schema of the checks and logs configurations. It's used by the
+// admission controller of the cluster agent to reject the invalid DatadogChecks.
+func ValidateDatadogCheck(u *unstructured.Unstructured) []config.ValidationIssue {
+	conf, err := parseDatadogCheck(u)
+	if err != nil {
+		return []config.ValidationIssue{{
+			Severity: config.ValidationError,
+			File:     conf.Source,
+			Message:  err.Error(),
+		}}
+	}
+	return validateIntegrationConfig(conf)
+}
+
+func init() {
+	RegisterProvider("datadogchecks", NewDatadogCheckConfigProvider)
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func datadogCheck(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "datadoghq.com/v1alpha1",
+		"kind":       "DatadogCheck",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"spec": spec,
+	}}
+}
+
+func TestParseDatadogChecks(t *testing.T) {
+	objects := []runtime.Object{
+		datadogCheck("http", map[string]interface{}{
+			"name":         "http_check",
+			"clusterCheck": true,
+			"initConfig":   map[string]interface{}{"ca_certs": "/etc/ssl/certs"},
+			"instances": []interface{}{
+				map[string]interface{}{"name": "frontend", "url": "http://frontend.default", "timeout": int64(1)},
+			},
+		}),
+		datadogCheck("redis", map[string]interface{}{
+			"name":          "redisdb",
+			"adIdentifiers": []interface{}{"redis"},
+			"instances": []interface{}{
+				map[string]interface{}{"host": "%%host%%", "port": "6379"},
+			},
+			"logs": []interface{}{
+				map[string]interface{}{"source": "redis", "service": "cache"},
+			},
+		}),
+		// invalid resources are skipped
+		datadogCheck("no-instances", map[string]interface{}{
+			"name": "http_check",
+		}),
+		datadogCheck("no-name", map[string]interface{}{
+			"instances": []interface{}{map[string]interface{}{}},
+		}),
+		datadogCheck("cluster-template", map[string]interface{}{
+			"name":          "redisdb",
+			"clusterCheck":  true,
+			"adIdentifiers": []interface{}{"redis"},
+			"instances":     []interface{}{map[string]interface{}{}},
+		}),
+	}
+
+	expected := []integration.Config{
+		{
+			Name:         "http_check",
+			InitConfig:   integration.Data(`{"ca_certs":"/etc/ssl/certs"}`),
+			Instances:    []integration.Data{integration.Data(`{"name":"frontend","timeout":1,"url":"http://frontend.default"}`)},
+			ClusterCheck: true,
+			Source:       "datadogcheck:default/http",
+		},
+		{
+			Name:          "redisdb",
+			ADIdentifiers: []string{"redis"},
+			InitConfig:    integration.Data(`{}`),
+			Instances:     []integration.Data{integration.Data(`{"host":"%%host%%","port":"6379"}`)},
+			LogsConfig:    integration.Data(`[{"service":"cache","source":"redis"}]`),
+			Source:        "datadogcheck:default/redis",
+		},
+	}
+
+	assert.EqualValues(t, expected, parseDatadogChecks(objects))
+}
+
+func TestValidateDatadogCheck(t *testing.T) {
+	assert.Empty(t, ValidateDatadogCheck(datadogCheck("http", map[string]interface{}{
+		"name":      "http_check",
+		"instances": []interface{}{map[string]interface{}{"url": "http://frontend.default"}},
+	})))
+
+	issues := ValidateDatadogCheck(datadogCheck("http", map[string]interface{}{"name": "http_check"}))
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "spec.instances must hold at least one instance", issues[0].Message)
+	}
+
+	issues = ValidateDatadogCheck(datadogCheck("http", map[string]interface{}{
+		"name":      "http_check",
+		"instances": []interface{}{map[string]interface{}{"min_collection_interval": "often"}},
+	}))
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "instances[0].min_collection_interval", issues[0].Key)
+	}
+}
@@ -15,6 +15,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/coreos/etcd/client"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/transport"
 	"golang.org/x/net/context"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -22,6 +24,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
+const etcdDialTimeout = 5 * time.Second
+
 type etcdBackend interface {
 	Get(ctx context.Context, key string, opts *client.GetOptions) (*client.Response, error)
 }
@@ -32,28 +36,128 @@ type EtcdConfigProvider struct {
 	Client      etcdBackend
 	templateDir string
 	cache       *ProviderCache
+	watch       *templateWatch
 }
 
 // NewEtcdConfigProvider creates a client connection to etcd and create a new EtcdConfigProvider
 func NewEtcdConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
-	clientCfg := client.Config{
-		Endpoints:               []string{config.TemplateURL},
-		Transport:               client.DefaultTransport,
-		HeaderTimeoutPerRequest: time.Second,
+	tlsInfo := transport.TLSInfo{
+		CertFile:      config.CertFile,
+		KeyFile:       config.KeyFile,
+		TrustedCAFile: config.CAFile,
 	}
 	if len(config.Username) > 0 && len(config.Password) > 0 {
 		log.Info("Using provided etcd credentials: username ", config.Username)
-		clientCfg.Username = config.Username
-		clientCfg.Password = config.Password
 	}
 
-	cl, err := client.New(clientCfg)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to instantiate the etcd client: %s", err)
+	p := &EtcdConfigProvider{templateDir: config.TemplateDir, cache: NewCPCache()}
+	if config.Watch {
+		p.watch = newTemplateWatch(names.Etcd)
+	}
+
+	switch config.APIVersion {
+	case 0, 2:
+		clientCfg := client.Config{
+			Endpoints:               []string{config.TemplateURL},
+			Transport:               client.DefaultTransport,
+			HeaderTimeoutPerRequest: time.Second,
+			Username:                config.Username,
+			Password:                config.Password,
+		}
+		if !tlsInfo.Empty() {
+			tr, err := transport.NewTransport(tlsInfo, etcdDialTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to configure TLS for etcd: %s", err)
+			}
+			clientCfg.Transport = tr
+		}
+
+		cl, err := client.New(clientCfg)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to instantiate the etcd client: %s", err)
+		}
+		keysAPI := client.NewKeysAPI(cl)
+		p.Client = keysAPI
+		if p.watch != nil {
+			go p.watchTemplatesV2(keysAPI)
+		}
+	case 3:
+		clientCfg := clientv3.Config{
+			Endpoints:   []string{config.TemplateURL},
+			DialTimeout: etcdDialTimeout,
+			Username:    config.Username,
+			Password:    config.Password,
+		}
+		if !tlsInfo.Empty() {
+			tlsCfg, err := tlsInfo.ClientConfig()
+			if err != nil {
+				return nil, fmt.Errorf("Unable to configure TLS for etcd: %s", err)
+			}
+			clientCfg.TLS = tlsCfg
+		}
+
+		cl, err := clientv3.New(clientCfg)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to instantiate the etcd client: %s", err)
+		}
+		p.Client = &etcdV3Backend{kv: cl.KV}
+		if p.watch != nil {
+			go p.watchTemplatesV3(cl.Watcher)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported etcd API version %d, supported versions are 2 and 3", config.APIVersion)
+	}
+
+	return p, nil
+}
+
+// Changes returns the channel notified when the templates stored under
+// the template dir are modified, it is nil when the watch is disabled.
+func (p *EtcdConfigProvider) Changes() <-chan struct{} {
+	if p.watch == nil {
+		return nil
+	}
+	return p.watch.changes
+}
+
+// watchTemplatesV2 watches the template dir recursively with the v2 API
+// and notifies every modification of the keys it contains.
+func (p *EtcdConfigProvider) watchTemplatesV2(keysAPI client.KeysAPI) {
+	watcher := keysAPI.Watcher(p.templateDir, &client.WatcherOptions{Recursive: true})
+	for {
+		resp, err := watcher.Next(context.Background())
+		if err != nil {
+			log.Warnf("Can't watch templates in etcd, retrying in %s: %s", watchRetryDelay, err)
+			p.watch.fail()
+			time.Sleep(watchRetryDelay)
+			// the index of the previous watcher may have been compacted
+			watcher = keysAPI.Watcher(p.templateDir, &client.WatcherOptions{Recursive: true})
+			continue
+		}
+		log.Debugf("Template %s %s in etcd", resp.Node.Key, resp.Action)
+		p.watch.notify()
+	}
+}
+
+// watchTemplatesV3 watches the template dir prefix with the v3 API and
+// notifies every modification of the keys it contains.
+func (p *EtcdConfigProvider) watchTemplatesV3(watcher clientv3.Watcher) {
+	for {
+		for resp := range watcher.Watch(context.Background(), p.templateDir, clientv3.WithPrefix()) {
+			if err := resp.Err(); err != nil {
+				log.Warnf("Error while watching templates in etcd: %s", err)
+				p.watch.fail()
+				continue
+			}
+			if len(resp.Events) > 0 {
+				log.Debugf("%d templates keys modified in etcd at revision %d", len(resp.Events), resp.Header.Revision)
+				p.watch.notify()
+			}
+		}
+		log.Warnf("Watch of the templates in etcd was closed, retrying in %s", watchRetryDelay)
+		p.watch.fail()
+		time.Sleep(watchRetryDelay)
 	}
-	cache := NewCPCache()
-	c := client.NewKeysAPI(cl)
-	return &EtcdConfigProvider{Client: c, templateDir: config.TemplateDir, cache: cache}, nil
 }
 
 // Collect retrieves templates from etcd, builds Config objects and returns them
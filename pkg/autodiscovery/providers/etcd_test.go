@@ -11,8 +11,10 @@ import (
 	"testing"
 
 	"github.com/coreos/etcd/client"
+	"github.com/coreos/etcd/mvcc/mvccpb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -173,3 +175,29 @@ func TestETCDIsUpToDate(t *testing.T) {
 	assert.Equal(t, 2, etcd.cache.NumAdTemplates)
 	backend.AssertExpectations(t)
 }
+
+func TestBuildEtcdV3Tree(t *testing.T) {
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("/datadog/check_configs/nginx/check_names"), Value: []byte(`["nginx"]`), ModRevision: 12},
+		{Key: []byte("/datadog/check_configs/nginx/init_configs"), Value: []byte(`[{}]`), ModRevision: 10},
+		{Key: []byte("/datadog/check_configs/nginx/instances"), Value: []byte(`[{}]`), ModRevision: 11},
+		{Key: []byte("/datadog/check_configs/orphan"), Value: []byte("foo")},
+		{Key: []byte("/datadog/check_configs/redis/nested/instances"), Value: []byte("bar")},
+	}
+
+	root := buildEtcdV3Tree("/datadog/check_configs/", kvs)
+	assert.True(t, root.Dir)
+	assert.Equal(t, "/datadog/check_configs", root.Key)
+	require.Len(t, root.Nodes, 1)
+
+	nginx := root.Nodes[0]
+	assert.True(t, nginx.Dir)
+	assert.Equal(t, "/datadog/check_configs/nginx", nginx.Key)
+	require.Len(t, nginx.Nodes, 3)
+	assert.True(t, hasTemplateFields(nginx.Nodes))
+	assert.Equal(t, `["nginx"]`, nginx.Nodes[0].Value)
+	assert.Equal(t, uint64(12), nginx.Nodes[0].ModifiedIndex)
+
+	provider := &EtcdConfigProvider{}
+	assert.Nil(t, provider.Changes())
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build etcd
+
+package providers
+
+import (
+	"strings"
+
+	"github.com/coreos/etcd/client"
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"golang.org/x/net/context"
+)
+
+// etcdV3Backend exposes the flat keyspace of the etcd v3 API as the
+// directory tree returned by the v2 API, so that templates are parsed the
+// same way whatever the API version in use.
+type etcdV3Backend struct {
+	kv clientv3.KV
+}
+
+// Get implements etcdBackend
+func (b *etcdV3Backend) Get(ctx context.Context, key string, opts *client.GetOptions) (*client.Response, error) {
+	if opts != nil && opts.Recursive {
+		resp, err := b.kv.Get(ctx, key, clientv3.WithPrefix())
+		if err != nil {
+			return nil, err
+		}
+		return &client.Response{Action: "get", Node: buildEtcdV3Tree(key, resp.Kvs)}, nil
+	}
+
+	resp, err := b.kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, client.Error{Code: client.ErrorCodeKeyNotFound, Message: "Key not found", Cause: key}
+	}
+	return &client.Response{Action: "get", Node: etcdV3Node(resp.Kvs[0])}, nil
+}
+
+// buildEtcdV3Tree groups the `<prefix>/<identifier>/<field>` keys by
+// identifier, ignoring the keys that don't match this layout.
+func buildEtcdV3Tree(prefix string, kvs []*mvccpb.KeyValue) *client.Node {
+	prefix = strings.TrimRight(prefix, "/")
+	root := &client.Node{Key: prefix, Dir: true}
+	dirs := make(map[string]*client.Node)
+
+	for _, kv := range kvs {
+		path := strings.TrimLeft(strings.TrimPrefix(string(kv.Key), prefix), "/")
+		split := strings.Split(path, "/")
+		if len(split) != 2 || split[0] == "" || split[1] == "" {
+			continue
+		}
+
+		dir, found := dirs[split[0]]
+		if !found {
+			dir = &client.Node{Key: prefix + "/" + split[0], Dir: true}
+			dirs[split[0]] = dir
+			root.Nodes = append(root.Nodes, dir)
+		}
+		dir.Nodes = append(dir.Nodes, etcdV3Node(kv))
+	}
+
+	return root
+}
+
+func etcdV3Node(kv *mvccpb.KeyValue) *client.Node {
+	return &client.Node{
+		Key:           string(kv.Key),
+		Value:         string(kv.Value),
+		CreatedIndex:  uint64(kv.CreateRevision),
+		ModifiedIndex: uint64(kv.ModRevision),
+	}
+}
@@ -7,9 +7,11 @@ package providers
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/configresolver"
@@ -49,23 +51,33 @@ type configEntry struct {
 
 // FileConfigProvider collect configuration files from disk
 type FileConfigProvider struct {
-	paths  []string
-	Errors map[string]string
+	paths []string
+	// Errors holds the errors of the invalid files of the last collection,
+	// by check name
+	Errors     map[string]string
+	fileErrors map[string]map[string]string // check name -> file path -> error
+	watch      *templateWatch
 }
 
 // NewFileConfigProvider creates a new FileConfigProvider searching for
 // configuration files on the given paths
 func NewFileConfigProvider(paths []string) *FileConfigProvider {
 	return &FileConfigProvider{
-		paths:  paths,
-		Errors: make(map[string]string),
+		paths:      paths,
+		Errors:     make(map[string]string),
+		fileErrors: make(map[string]map[string]string),
 	}
 }
 
 // Collect scans provided paths searching for configuration files. When found,
 // it parses the files and try to unmarshall Yaml contents into a CheckConfig
-// instance
+// instance. The invalid files are skipped and reported in Errors.
 func (c *FileConfigProvider) Collect() ([]integration.Config, error) {
+	// errors are collected again with the files, so that the fixed and
+	// removed files aren't reported anymore
+	c.fileErrors = make(map[string]map[string]string)
+	defer c.buildErrors()
+
 	configs := []integration.Config{}
 	configNames := make(map[string]struct{}) // use this map as a python set
 	defaultConfigs := []integration.Config{}
@@ -131,9 +143,19 @@ func (c *FileConfigProvider) Collect() ([]integration.Config, error) {
 	return configs, nil
 }
 
-// IsUpToDate is not implemented for the file Providers as the files are not meant to change very often.
+// IsUpToDate returns true when the configuration directories are watched,
+// the changes being notified through Changes.
 func (c *FileConfigProvider) IsUpToDate() (bool, error) {
-	return false, nil
+	return c.watch != nil, nil
+}
+
+// Changes returns the channel notified when a configuration file changes,
+// it's nil until Watch is called.
+func (c *FileConfigProvider) Changes() <-chan struct{} {
+	if c.watch == nil {
+		return nil
+	}
+	return c.watch.changes
 }
 
 // String returns a string representation of the FileConfigProvider
@@ -182,7 +204,7 @@ func (c *FileConfigProvider) collectEntry(file os.FileInfo, path string, integra
 	entry.conf, err = GetIntegrationConfigFromFile(integrationName, absPath)
 	if err != nil {
 		log.Warnf("%s is not a valid config file: %s", absPath, err)
-		c.Errors[integrationName] = err.Error()
+		c.setFileError(integrationName, absPath, err)
 		entry.err = errors.New("Invalid config file format")
 		return entry
 	}
@@ -192,11 +214,37 @@ func (c *FileConfigProvider) collectEntry(file os.FileInfo, path string, integra
 		entry.isLogsOnly = true
 	}
 
-	delete(c.Errors, integrationName) // noop if entry is nonexistant
 	log.Debug("Found valid configuration in file:", absPath)
 	return entry
 }
 
+// setFileError records the error of an invalid configuration file
+func (c *FileConfigProvider) setFileError(integrationName, path string, err error) {
+	if _, found := c.fileErrors[integrationName]; !found {
+		c.fileErrors[integrationName] = make(map[string]string)
+	}
+	c.fileErrors[integrationName][path] = err.Error()
+}
+
+// buildErrors reports the errors of the invalid files of each check in
+// Errors, prefixed with the file path.
+func (c *FileConfigProvider) buildErrors() {
+	c.Errors = make(map[string]string, len(c.fileErrors))
+	for name, files := range c.fileErrors {
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		errs := make([]string, 0, len(paths))
+		for _, path := range paths {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, files[path]))
+		}
+		c.Errors[name] = strings.Join(errs, "\n")
+	}
+}
+
 // collectDir collects entries in subdirectories of the main conf folder
 func (c *FileConfigProvider) collectDir(parentPath string, folder os.FileInfo) configPkg {
 	configs := []integration.Config{}
@@ -247,15 +295,27 @@ func (c *FileConfigProvider) collectDir(parentPath string, folder os.FileInfo) c
 
 // GetIntegrationConfigFromFile returns an instance of integration.Config if `fpath` points to a valid config file
 func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error) {
-	cf := configFormat{}
-	config := integration.Config{Name: name}
-
 	// Read file contents
 	// FIXME: ReadFile reads the entire file, possible security implications
 	yamlFile, err := readFilePtr(fpath)
+	if err != nil {
+		return integration.Config{Name: name}, err
+	}
+
+	config, err := parseIntegrationConfig(name, fpath, yamlFile)
 	if err != nil {
 		return config, err
 	}
+	config.Source = "file:" + fpath
+
+	return config, nil
+}
+
+// parseIntegrationConfig builds an integration.Config out of the content of a
+// configuration file, `origin` is the location of the file used in logs.
+func parseIntegrationConfig(name, origin string, yamlFile []byte) (integration.Config, error) {
+	cf := configFormat{}
+	config := integration.Config{Name: name}
 
 	// Parse configuration
 	// Try UnmarshalStrict first, so we can warn about duplicated keys
@@ -263,7 +323,7 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 		if err := yaml.Unmarshal(yamlFile, &cf); err != nil {
 			return config, err
 		}
-		log.Warnf("reading config file %v: %v\n", fpath, strictErr)
+		log.Warnf("reading config file %v: %v\n", origin, strictErr)
 	}
 
 	// If no valid instances were found & this is neither a metrics file, nor a logs file
@@ -315,7 +375,5 @@ func GetIntegrationConfigFromFile(name, fpath string) (integration.Config, error
 	// Interpolate env vars. Returns an error a variable wasn't subsituted, ignore it.
 	_ = configresolver.SubstituteTemplateEnvVars(&config)
 
-	config.Source = "file:" + fpath
-
-	return config, err
+	return config, nil
 }
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	logsConfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// instanceSchema is the type of the instance settings handled by the agent itself,
+// the other settings are only read by the checks
+var instanceSchema = map[string]string{
+	"min_collection_interval": "integer", // in seconds, not negative
+	"empty_default_hostname":  "boolean",
+	"tags":                    "list",
+	"service":                 "string",
+	"name":                    "string",
+}
+
+// ValidateFileConfigs collects the configuration files of the paths like the file
+// provider does, and checks them against the schema of the checks and logs
+// configurations. It returns the configurations collected and the issues found.
+func ValidateFileConfigs(paths []string) ([]integration.Config, []config.ValidationIssue) {
+	provider := NewFileConfigProvider(paths)
+	configs, _ := provider.Collect()
+
+	issues := []config.ValidationIssue{}
+	for _, files := range provider.fileErrors {
+		for path, err := range files {
+			issues = append(issues, config.ValidationIssue{
+				Severity: config.ValidationError,
+				File:     path,
+				Message:  err,
+			})
+		}
+	}
+	for _, c := range configs {
+		issues = append(issues, validateIntegrationConfig(c)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].File < issues[j].File
+	})
+	return configs, issues
+}
+
+// validateIntegrationConfig checks the init config, the instances and the logs
+// configurations of a configuration file
+func validateIntegrationConfig(c integration.Config) []config.ValidationIssue {
+	var issues []config.ValidationIssue
+	addIssue := func(key string, format string, args ...interface{}) {
+		issues = append(issues, config.ValidationIssue{
+			Severity: config.ValidationError,
+			File:     strings.TrimPrefix(c.Source, "file:"),
+			Key:      key,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(c.InitConfig) > 0 {
+		// the Yaml was already parsed by the provider, no need to check the error
+		var initConfig interface{}
+		_ = yaml.Unmarshal(c.InitConfig, &initConfig)
+		if _, isMap := initConfig.(map[interface{}]interface{}); initConfig != nil && !isMap {
+			addIssue("init_config", "expected a mapping, got %v", initConfig)
+		}
+	}
+
+	instanceKeys := make([]string, 0, len(instanceSchema))
+	for key := range instanceSchema {
+		instanceKeys = append(instanceKeys, key)
+	}
+	sort.Strings(instanceKeys)
+	for i, data := range c.Instances {
+		instance := integration.RawMap{}
+		_ = yaml.Unmarshal(data, &instance)
+		for _, key := range instanceKeys {
+			value, found := instance[key]
+			if found && value != nil && !matchesInstanceType(instanceSchema[key], value) {
+				addIssue(fmt.Sprintf("instances[%d].%s", i, key), "expected type %s, got %v", instanceSchema[key], value)
+			}
+		}
+	}
+
+	if c.LogsConfig != nil {
+		sources, err := logsConfig.ParseYAML(c.LogsConfig)
+		if err != nil {
+			addIssue("logs", "%v", err)
+		}
+		for i, source := range sources {
+			// the type of the templates can be set when they're resolved
+			if source.Type == "" && len(c.ADIdentifiers) > 0 {
+				continue
+			}
+			if err := source.Validate(); err != nil {
+				addIssue(fmt.Sprintf("logs[%d]", i), "%v", err)
+			}
+		}
+	}
+	return issues
+}
+
+func matchesInstanceType(expected string, value interface{}) bool {
+	switch expected {
+	case "integer":
+		switch v := value.(type) {
+		case int:
+			return v >= 0
+		case float64:
+			return v >= 0
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "list":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return false
+		}
+		return true
+	}
+	return true
+}
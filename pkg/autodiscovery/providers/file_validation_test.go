@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestValidateFileConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"valid.yaml":      "init_config:\ninstances:\n- min_collection_interval: 30\n  tags: [foo:bar]\n",
+		"noinstance.yaml": "init_config:\n",
+		"types.yaml":      "init_config: [foo]\ninstances:\n- min_collection_interval: -1\n  tags: foo:bar\n",
+		"logs.yaml":       "logs:\n- type: file\n  service: foo\n- type: tcp\n  port: 10514\n",
+		"ad.yaml":         "ad_identifiers: [redis]\ninstances:\n- host: '%%host%%'\nlogs:\n- service: redis\n",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	configs, issues := ValidateFileConfigs([]string{dir})
+	assert.Len(t, configs, 4)
+
+	byFile := make(map[string][]config.ValidationIssue)
+	for _, issue := range issues {
+		assert.Equal(t, config.ValidationError, issue.Severity)
+		byFile[filepath.Base(issue.File)] = append(byFile[filepath.Base(issue.File)], issue)
+	}
+	assert.Len(t, byFile, 3)
+	assert.Len(t, byFile["noinstance.yaml"], 1)
+	if assert.Len(t, byFile["types.yaml"], 3) {
+		assert.Equal(t, "init_config", byFile["types.yaml"][0].Key)
+		assert.Equal(t, "instances[0].min_collection_interval", byFile["types.yaml"][1].Key)
+		assert.Equal(t, "instances[0].tags", byFile["types.yaml"][2].Key)
+	}
+	if assert.Len(t, byFile["logs.yaml"], 1) {
+		assert.Equal(t, "logs[0]", byFile["logs.yaml"][0].Key)
+	}
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// fileWatchDebounce is the delay without any event after which the
+// changes are notified, editors and deployment tools usually write
+// several files or the same file several times.
+var fileWatchDebounce = time.Second
+
+// Watch starts watching the configuration directories and their `.d`
+// subdirectories, the changes are notified through Changes.
+func (c *FileConfigProvider) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range c.paths {
+		if path == "" {
+			continue
+		}
+		if err := addConfigDirWatch(watcher, path); err != nil {
+			log.Debugf("Not watching the config directory %s: %s", path, err)
+		}
+	}
+
+	c.watch = newTemplateWatch(names.File)
+	go c.watchFiles(watcher)
+	return nil
+}
+
+// addConfigDirWatch watches a configuration directory and its `.d`
+// subdirectories
+func addConfigDirWatch(watcher *fsnotify.Watcher, path string) error {
+	if err := watcher.Add(path); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && filepath.Ext(entry.Name()) == ".d" {
+			subPath := filepath.Join(path, entry.Name())
+			if err := watcher.Add(subPath); err != nil {
+				log.Debugf("Not watching the config directory %s: %s", subPath, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *FileConfigProvider) watchFiles(watcher *fsnotify.Watcher) {
+	debounce := time.NewTimer(fileWatchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			log.Debugf("Config file event: %s", event)
+
+			// watch the `.d` directories created after the start
+			if event.Op&fsnotify.Create != 0 && filepath.Ext(event.Name) == ".d" {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						log.Warnf("Can't watch the config directory %s: %s", event.Name, err)
+					}
+				}
+			}
+			debounce.Reset(fileWatchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Error watching the config files: %s", err)
+			c.watch.fail()
+			// events may have been lost, collect the files again
+			debounce.Reset(fileWatchDebounce)
+		case <-debounce.C:
+			c.watch.notify()
+		}
+	}
+}
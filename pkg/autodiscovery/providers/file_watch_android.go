@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build android
+
+package providers
+
+import (
+	"errors"
+)
+
+// Watch is not supported on Android, the configuration files are assets
+func (c *FileConfigProvider) Watch() error {
+	return errors.New("config files can't be watched on Android")
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !android
+
+package providers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForChange(t *testing.T, provider *FileConfigProvider) {
+	select {
+	case <-provider.Changes():
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no change notified")
+	}
+}
+
+func TestWatchConfigFiles(t *testing.T) {
+	fileWatchDebounce = 10 * time.Millisecond
+	defer func() { fileWatchDebounce = time.Second }()
+
+	dir, err := ioutil.TempDir("", "confd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	provider := NewFileConfigProvider([]string{dir})
+	assert.Nil(t, provider.Changes())
+	require.NoError(t, provider.Watch())
+	upToDate, err := provider.IsUpToDate()
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	// a new check config
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.yaml"), []byte("instances:\n- {}\n"), 0600))
+	waitForChange(t, provider)
+	configs, err := provider.Collect()
+	require.NoError(t, err)
+	assert.Len(t, configs, 1)
+
+	// the configs of a .d directory created after the start are watched
+	subDir := filepath.Join(dir, "bar.d")
+	require.NoError(t, os.Mkdir(subDir, 0700))
+	waitForChange(t, provider)
+	time.Sleep(50 * time.Millisecond)
+	invalidPath := filepath.Join(subDir, "conf.yaml")
+	require.NoError(t, ioutil.WriteFile(invalidPath, []byte("init_config:\n"), 0600))
+	waitForChange(t, provider)
+
+	// the invalid file is reported, without failing the collection
+	configs, err = provider.Collect()
+	require.NoError(t, err)
+	assert.Len(t, configs, 1)
+	require.Contains(t, provider.Errors, "bar")
+	assert.Contains(t, provider.Errors["bar"], invalidPath+": ")
+
+	// fixing the file clears the error
+	require.NoError(t, ioutil.WriteFile(invalidPath, []byte("instances:\n- {}\n"), 0600))
+	waitForChange(t, provider)
+	configs, err = provider.Collect()
+	require.NoError(t, err)
+	assert.Len(t, configs, 2)
+	assert.Empty(t, provider.Errors)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "foo.yaml")))
+	waitForChange(t, provider)
+	configs, err = provider.Collect()
+	require.NoError(t, err)
+	assert.Len(t, configs, 1)
+}
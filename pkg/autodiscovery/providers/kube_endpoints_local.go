@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+// +build kubelet
+
+package providers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kubeEndpointsLocalRefreshInterval is the maximum age of the configs when the
+// local pods do not change, to catch the changes of the service annotations
+const kubeEndpointsLocalRefreshInterval = time.Minute
+
+// kubeEndpointsLocalConfigProvider is the node agent flavor of the
+// kube_endpoints provider: it only generates the configs of the endpoints
+// backed by pods running on the local node, and the node agent schedules them
+// itself, without requiring the cluster checks. Instead of watching the
+// services and endpoints of the whole cluster, it lists the services of the
+// namespaces of the local pods, read from the kubelet, and only gets the
+// endpoints of the annotated ones, keeping the addresses of the local pods.
+type kubeEndpointsLocalConfigProvider struct {
+	apiClient *apiserver.APIClient
+	kubeUtil  kubelet.KubeUtilInterface
+	// podNamespaces holds the namespaces of the local pods by IP, as of the last Collect
+	podNamespaces map[string]string
+	lastCollect   time.Time
+}
+
+// NewKubeEndpointsLocalConfigProvider returns a new kubeEndpointsLocalConfigProvider
+func NewKubeEndpointsLocalConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the kubelet: %s", err)
+	}
+	ac, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	return &kubeEndpointsLocalConfigProvider{
+		apiClient: ac,
+		kubeUtil:  ku,
+	}, nil
+}
+
+// String returns a string representation of the kubeEndpointsLocalConfigProvider
+func (k *kubeEndpointsLocalConfigProvider) String() string {
+	return names.KubeEndpointsLocal
+}
+
+// Collect retrieves the annotated services of the local pods from the
+// apiserver, builds the Config objects of their local endpoints and returns them
+func (k *kubeEndpointsLocalConfigProvider) Collect() ([]integration.Config, error) {
+	pods, err := k.kubeUtil.GetLocalPodList()
+	if err != nil {
+		return nil, err
+	}
+	podNamespaces := kubelet.PodNamespacesByIP(pods)
+
+	serviceEndpoints, err := k.apiClient.GetServiceEndpointsForPods(podNamespaces, hasEndpointsAnnotations)
+	if err != nil {
+		return nil, err
+	}
+	k.podNamespaces = podNamespaces
+	k.lastCollect = time.Now()
+
+	var generatedConfigs []integration.Config
+	for _, se := range serviceEndpoints {
+		for _, config := range parseServiceAnnotationsForEndpoints([]*v1.Service{se.Service}) {
+			generatedConfigs = append(generatedConfigs, toLocalConfigs(generateConfigs(config.tpl, se.Endpoints))...)
+		}
+	}
+	return generatedConfigs, nil
+}
+
+// IsUpToDate returns false when the local pods changed since the last Collect,
+// or when the configs are older than kubeEndpointsLocalRefreshInterval
+func (k *kubeEndpointsLocalConfigProvider) IsUpToDate() (bool, error) {
+	if time.Since(k.lastCollect) > kubeEndpointsLocalRefreshInterval {
+		return false, nil
+	}
+	pods, err := k.kubeUtil.GetLocalPodList()
+	if err != nil {
+		log.Debugf("Cannot get the local pods: %s", err)
+		return false, nil
+	}
+	return reflect.DeepEqual(kubelet.PodNamespacesByIP(pods), k.podNamespaces), nil
+}
+
+// hasEndpointsAnnotations returns true if the service has endpoints check annotations
+func hasEndpointsAnnotations(ksvc *v1.Service) bool {
+	for annotation := range ksvc.GetAnnotations() {
+		if strings.HasPrefix(annotation, kubeEndpointAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// toLocalConfigs returns the configs scheduled by the local node agent
+// instead of being dispatched by the cluster agent
+func toLocalConfigs(configs []integration.Config) []integration.Config {
+	localConfigs := make([]integration.Config, 0, len(configs))
+	for _, config := range configs {
+		config.ClusterCheck = false
+		config.NodeName = ""
+		localConfigs = append(localConfigs, config)
+	}
+	return localConfigs
+}
+
+func init() {
+	RegisterProvider(KubeEndpointsLocalProviderName, NewKubeEndpointsLocalConfigProvider)
+}
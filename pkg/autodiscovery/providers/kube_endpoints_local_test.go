@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+// +build kubelet
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+func TestToLocalConfigs(t *testing.T) {
+	configs := []integration.Config{
+		{
+			Entity:        "kube_endpoint_uid://default/myservice/10.0.0.1",
+			Name:          "http_check",
+			ADIdentifiers: []string{"kube_endpoint_uid://default/myservice/10.0.0.1", "kubernetes_pod://pod-uid-1"},
+			ClusterCheck:  true,
+			NodeName:      "node1",
+		},
+	}
+
+	assert.EqualValues(t, []integration.Config{
+		{
+			Entity:        "kube_endpoint_uid://default/myservice/10.0.0.1",
+			Name:          "http_check",
+			ADIdentifiers: []string{"kube_endpoint_uid://default/myservice/10.0.0.1", "kubernetes_pod://pod-uid-1"},
+			ClusterCheck:  false,
+		},
+	}, toLocalConfigs(configs))
+
+	// the input configs are left untouched
+	assert.True(t, configs[0].ClusterCheck)
+	assert.Equal(t, "node1", configs[0].NodeName)
+}
+
+func TestHasEndpointsAnnotations(t *testing.T) {
+	assert.True(t, hasEndpointsAnnotations(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			"ad.datadoghq.com/endpoints.check_names": "[\"http_check\"]",
+		}},
+	}))
+	assert.False(t, hasEndpointsAnnotations(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			"ad.datadoghq.com/service.check_names": "[\"http_check\"]",
+		}},
+	}))
+	assert.False(t, hasEndpointsAnnotations(&v1.Service{}))
+}
@@ -7,16 +7,22 @@ package names
 
 // User-facing names for the config providers
 const (
-	Consul          = "consul"
-	CloudFoundryBBS = "cloudfoundry-bbs"
-	ClusterChecks   = "cluster-checks"
-	Docker          = "docker"
-	ECS             = "ecs"
-	EndpointsChecks = "endpoints-checks"
-	Etcd            = "etcd"
-	File            = "file"
-	Kubernetes      = "kubernetes"
-	KubeServices    = "kubernetes-services"
-	KubeEndpoints   = "kubernetes-endpoints"
-	Zookeeper       = "zookeeper"
+	Consul             = "consul"
+	CloudFoundryBBS    = "cloudfoundry-bbs"
+	ClusterChecks      = "cluster-checks"
+	DatadogChecks      = "datadog-checks"
+	Docker             = "docker"
+	ECS                = "ecs"
+	EndpointsChecks    = "endpoints-checks"
+	Etcd               = "etcd"
+	File               = "file"
+	Kubernetes         = "kubernetes"
+	KubeServices       = "kubernetes-services"
+	KubeEndpoints      = "kubernetes-endpoints"
+	KubeEndpointsLocal = "kubernetes-endpoints-local"
+	NodeConfigs        = "node-configs"
+	ObjectStore        = "object-store"
+	PrometheusPods     = "prometheus-pods"
+	PrometheusServices = "prometheus-services"
+	Zookeeper          = "zookeeper"
 )
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs/types"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// NodeConfigsConfigProvider implements the ConfigProvider interface for the
+// check templates the cluster-agent serves to all the node agents.
+// The templates are only downloaded when their version changes, and the last
+// known ones are kept while the cluster-agent is unreachable.
+type NodeConfigsConfigProvider struct {
+	dcaClient clusteragent.DCAClientInterface
+	nodeName  string
+	version   string
+	// pending holds the templates downloaded by IsUpToDate, returned by the
+	// next Collect
+	pending *types.ConfigResponse
+}
+
+// NewNodeConfigsConfigProvider returns a new ConfigProvider collecting
+// the node check templates from the cluster-agent.
+// Connectivity is not checked at this stage to allow for retries, Collect will do it.
+func NewNodeConfigsConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	c := &NodeConfigsConfigProvider{}
+	c.nodeName, _ = util.GetHostname()
+	return c, nil
+}
+
+func (c *NodeConfigsConfigProvider) initClient() error {
+	dcaClient, err := clusteragent.GetClusterAgentClient()
+	if err == nil {
+		c.dcaClient = dcaClient
+	}
+	return err
+}
+
+// String returns a string representation of the NodeConfigsConfigProvider
+func (c *NodeConfigsConfigProvider) String() string {
+	return names.NodeConfigs
+}
+
+// IsUpToDate queries the cluster-agent for templates newer than the
+// current ones
+func (c *NodeConfigsConfigProvider) IsUpToDate() (bool, error) {
+	if c.dcaClient == nil {
+		if err := c.initClient(); err != nil {
+			return false, err
+		}
+	}
+
+	reply, upToDate, err := c.dcaClient.GetNodeConfigs(c.nodeName, c.version)
+	if err != nil {
+		// Collect will fail too and the current configs will be kept
+		return false, err
+	}
+	if upToDate {
+		log.Tracef("Up to date with node configs version %s", c.version)
+		return true, nil
+	}
+	c.pending = &reply
+	return false, nil
+}
+
+// Collect retrieves the node check templates from the cluster-agent
+func (c *NodeConfigsConfigProvider) Collect() ([]integration.Config, error) {
+	reply := c.pending
+	c.pending = nil
+	if reply == nil {
+		if c.dcaClient == nil {
+			if err := c.initClient(); err != nil {
+				return nil, err
+			}
+		}
+		// the version is not passed to always get the templates
+		r, _, err := c.dcaClient.GetNodeConfigs(c.nodeName, "")
+		if err != nil {
+			return nil, err
+		}
+		reply = &r
+	}
+
+	c.version = reply.Version
+	log.Tracef("Storing node configs version %s", c.version)
+	return reply.Configs, nil
+}
+
+func init() {
+	RegisterProvider("node_configs", NewNodeConfigsConfigProvider)
+}
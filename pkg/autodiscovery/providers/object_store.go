@@ -0,0 +1,373 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/gce"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	objectStoreTimeout       = 10 * time.Second
+	objectStoreMaxObjectSize = 1024 * 1024
+	objectStoreCacheFile     = "templates.json"
+	gcsEndpoint              = "https://storage.googleapis.com"
+)
+
+// storedObject is a template file fetched from the bucket
+type storedObject struct {
+	ETag    string `json:"etag"`
+	Content []byte `json:"content"`
+}
+
+type objectInfo struct {
+	Key  string
+	ETag string
+}
+
+// listBucketResult is the response of the ListObjectsV2 call, GCS serves the
+// same format through its XML API.
+type listBucketResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+	} `xml:"Contents"`
+}
+
+// objectStoreClient reads the objects of a bucket through the S3 compatible
+// HTTP API, `authorize` adds the credentials to the requests.
+type objectStoreClient struct {
+	bucketURL  string
+	authorize  func(req *http.Request) error
+	httpClient *http.Client
+}
+
+func (c *objectStoreClient) do(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.authorize != nil {
+		if err := c.authorize(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// list returns the objects stored under prefix
+func (c *objectStoreClient) list(prefix string) ([]objectInfo, error) {
+	var objects []objectInfo
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(c.bucketURL + "/?" + query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the bucket listing: %s", err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, objectInfo{Key: content.Key, ETag: content.ETag})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// get returns the content of an object
+func (c *objectStoreClient) get(key string) ([]byte, error) {
+	resp, err := c.do(c.bucketURL + "/" + (&url.URL{Path: key}).EscapedPath())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, objectStoreMaxObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > objectStoreMaxObjectSize {
+		return nil, fmt.Errorf("object %s is larger than %d bytes", key, objectStoreMaxObjectSize)
+	}
+	return content, nil
+}
+
+// gcsAuthorizer authenticates the GCS requests with the token of the service
+// account of the instance, the bucket is read anonymously without it.
+type gcsAuthorizer struct {
+	m      sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (a *gcsAuthorizer) authorize(req *http.Request) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.token == "" || time.Now().Add(time.Minute).After(a.expiry) {
+		token, expiry, err := gce.GetServiceAccountToken()
+		if err != nil {
+			log.Debugf("Reading the GCS bucket anonymously: %s", err)
+			return nil
+		}
+		a.token, a.expiry = token, expiry
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// ObjectStoreConfigProvider collects the check configurations stored in an
+// S3 or GCS bucket. The objects under the prefix follow the layout of the
+// conf.d directory: `<check>.yaml` or `<check>.d/<file>.yaml`.
+type ObjectStoreConfigProvider struct {
+	client    *objectStoreClient
+	bucket    string
+	prefix    string
+	cachePath string
+	objects   map[string]storedObject
+}
+
+// NewObjectStoreConfigProvider returns a new ObjectStoreConfigProvider
+// reading the bucket set in the `s3://<bucket>/<prefix>` or
+// `gs://<bucket>/<prefix>` template URL.
+func NewObjectStoreConfigProvider(cfg config.ConfigurationProviders) (ConfigProvider, error) {
+	bucketURL, err := url.Parse(cfg.TemplateURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template_url %q: %s", cfg.TemplateURL, err)
+	}
+	if bucketURL.Host == "" {
+		return nil, fmt.Errorf("invalid template_url %q: missing bucket name", cfg.TemplateURL)
+	}
+
+	client := &objectStoreClient{httpClient: &http.Client{Timeout: objectStoreTimeout}}
+	switch bucketURL.Scheme {
+	case "s3":
+		client.bucketURL, client.authorize, err = newS3Authorizer(bucketURL.Host)
+		if err != nil {
+			return nil, err
+		}
+	case "gs":
+		client.bucketURL = gcsEndpoint + "/" + bucketURL.Host
+		client.authorize = (&gcsAuthorizer{}).authorize
+	default:
+		return nil, fmt.Errorf("unsupported template_url scheme %q, supported schemes are s3 and gs", bucketURL.Scheme)
+	}
+
+	prefix := strings.TrimLeft(bucketURL.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &ObjectStoreConfigProvider{
+		client:    client,
+		bucket:    bucketURL.Scheme + "://" + bucketURL.Host,
+		prefix:    prefix,
+		cachePath: filepath.Join(config.Datadog.GetString("run_path"), "object_store", bucketURL.Scheme, bucketURL.Host, objectStoreCacheFile),
+		objects:   make(map[string]storedObject),
+	}, nil
+}
+
+// String returns a string representation of the ObjectStoreConfigProvider
+func (p *ObjectStoreConfigProvider) String() string {
+	return names.ObjectStore
+}
+
+// IsUpToDate lists the bucket and compares the ETags of the templates with
+// the ones of the objects collected last.
+func (p *ObjectStoreConfigProvider) IsUpToDate() (bool, error) {
+	listed, err := p.client.list(p.prefix)
+	if err != nil {
+		return false, err
+	}
+
+	templates := 0
+	for _, object := range listed {
+		if p.checkName(object.Key) == "" {
+			continue
+		}
+		templates++
+		if stored, found := p.objects[object.Key]; !found || stored.ETag != object.ETag {
+			return false, nil
+		}
+	}
+	return templates == len(p.objects), nil
+}
+
+// Collect fetches the templates modified since the last collection and
+// returns the configurations of all the templates of the bucket. If the
+// bucket can't be listed, the templates cached on disk are used.
+func (p *ObjectStoreConfigProvider) Collect() ([]integration.Config, error) {
+	listed, err := p.client.list(p.prefix)
+	if err != nil {
+		if len(p.objects) == 0 {
+			p.objects = p.loadCache()
+		}
+		log.Warnf("Can't list the templates of %s, using the %d last known ones: %s", p.bucket, len(p.objects), err)
+		return p.buildConfigs(), nil
+	}
+
+	objects := make(map[string]storedObject)
+	for _, object := range listed {
+		if p.checkName(object.Key) == "" {
+			continue
+		}
+
+		stored, found := p.objects[object.Key]
+		if found && stored.ETag == object.ETag {
+			objects[object.Key] = stored
+			continue
+		}
+
+		content, err := p.client.get(object.Key)
+		if err != nil {
+			log.Warnf("Can't fetch the template %s/%s: %s", p.bucket, object.Key, err)
+			if found {
+				objects[object.Key] = stored
+			}
+			continue
+		}
+		objects[object.Key] = storedObject{ETag: object.ETag, Content: content}
+	}
+
+	p.objects = objects
+	if err := p.saveCache(); err != nil {
+		log.Warnf("Can't cache the templates of %s: %s", p.bucket, err)
+	}
+	return p.buildConfigs(), nil
+}
+
+// checkName returns the name of the check configured by an object, or an
+// empty string if the object isn't a template.
+func (p *ObjectStoreConfigProvider) checkName(key string) string {
+	ext := path.Ext(key)
+	if ext != ".yaml" && ext != ".yml" {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimPrefix(key, p.prefix), "/")
+	switch len(parts) {
+	case 1:
+		return strings.TrimSuffix(parts[0], ext)
+	case 2:
+		if strings.HasSuffix(parts[0], ".d") {
+			return strings.TrimSuffix(parts[0], ".d")
+		}
+	}
+	return ""
+}
+
+func (p *ObjectStoreConfigProvider) buildConfigs() []integration.Config {
+	keys := make([]string, 0, len(p.objects))
+	for key := range p.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	configs := make([]integration.Config, 0, len(keys))
+	for _, key := range keys {
+		origin := p.bucket + "/" + key
+		config, err := parseIntegrationConfig(p.checkName(key), origin, p.objects[key].Content)
+		if err != nil {
+			log.Warnf("%s is not a valid config: %s", origin, err)
+			continue
+		}
+		config.Source = "object_store:" + origin
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// loadCache returns the templates cached on disk by the last collection
+func (p *ObjectStoreConfigProvider) loadCache() map[string]storedObject {
+	objects := make(map[string]storedObject)
+
+	content, err := ioutil.ReadFile(p.cachePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Can't read the templates cache %s: %s", p.cachePath, err)
+		}
+		return objects
+	}
+	if err := json.Unmarshal(content, &objects); err != nil {
+		log.Warnf("Can't parse the templates cache %s: %s", p.cachePath, err)
+		return make(map[string]storedObject)
+	}
+
+	// don't trust cached objects outside of the prefix
+	for key := range objects {
+		if p.checkName(key) == "" {
+			delete(objects, key)
+		}
+	}
+	return objects
+}
+
+// saveCache writes the collected templates on disk, atomically
+func (p *ObjectStoreConfigProvider) saveCache() error {
+	content, err := json.Marshal(p.objects)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.cachePath), 0700); err != nil {
+		return err
+	}
+
+	tmpPath := p.cachePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p.cachePath)
+}
+
+func init() {
+	RegisterProvider("object_store", NewObjectStoreConfigProvider)
+}
@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !ec2
+
+package providers
+
+import (
+	"errors"
+	"net/http"
+)
+
+func newS3Authorizer(bucket string) (string, func(req *http.Request) error, error) {
+	return "", nil, errors.New("S3 buckets are not supported: the agent was built without the ec2 tag")
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build ec2
+
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// newS3Authorizer returns the URL of an S3 bucket and a function signing the
+// requests with the credentials of the default AWS credentials chain.
+func newS3Authorizer(bucket string) (string, func(req *http.Request) error, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to get an aws session: %s", err)
+	}
+
+	region := aws.StringValue(sess.Config.Region)
+	if region == "" {
+		region, err = ec2metadata.New(sess).Region()
+		if err != nil {
+			log.Debugf("Unable to get the region of the instance, using us-east-1: %s", err)
+			region = "us-east-1"
+		}
+	}
+
+	signer := v4.NewSigner(sess.Config.Credentials)
+	authorize := func(req *http.Request) error {
+		_, err := signer.Sign(req, nil, "s3", region, time.Now())
+		return err
+	}
+
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region), authorize, nil
+}
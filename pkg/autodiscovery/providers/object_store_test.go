@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBucket struct {
+	sync.Mutex
+	objects map[string]storedObject
+	gets    []string
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.Lock()
+	defer b.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if key != "" {
+		object, found := b.objects[key]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b.gets = append(b.gets, key)
+		w.Write(object.Content)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	for key, object := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><ETag>%s</ETag></Contents>", key, object.ETag)
+		}
+	}
+	fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+}
+
+func newTestObjectStoreProvider(t *testing.T, url string) *ObjectStoreConfigProvider {
+	dir, err := ioutil.TempDir("", "object-store")
+	require.NoError(t, err)
+
+	return &ObjectStoreConfigProvider{
+		client:    &objectStoreClient{bucketURL: url, httpClient: http.DefaultClient},
+		bucket:    "s3://bucket",
+		prefix:    "checks/",
+		cachePath: filepath.Join(dir, "cache", objectStoreCacheFile),
+		objects:   make(map[string]storedObject),
+	}
+}
+
+func TestObjectStoreCheckName(t *testing.T) {
+	p := &ObjectStoreConfigProvider{prefix: "checks/"}
+
+	assert.Equal(t, "redisdb", p.checkName("checks/redisdb.yaml"))
+	assert.Equal(t, "nginx", p.checkName("checks/nginx.d/conf.yml"))
+	assert.Equal(t, "", p.checkName("checks/nginx.d/README.md"))
+	assert.Equal(t, "", p.checkName("checks/nginx/conf.yaml"))
+	assert.Equal(t, "", p.checkName("checks/nginx.d/nested/conf.yaml"))
+}
+
+func TestObjectStoreCollect(t *testing.T) {
+	bucket := &fakeBucket{objects: map[string]storedObject{
+		"checks/redisdb.yaml":     {ETag: `"1"`, Content: []byte("instances:\n- host: localhost\n")},
+		"checks/nginx.d/conf.yml": {ETag: `"2"`, Content: []byte("ad_identifiers:\n- nginx\ninstances:\n- nginx_status_url: http://%%host%%/status\n")},
+		"checks/invalid.yaml":     {ETag: `"3"`, Content: []byte("init_config:\n")},
+		"other/ignored.yaml":      {ETag: `"4"`, Content: []byte("instances:\n- {}\n")},
+	}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	p := newTestObjectStoreProvider(t, server.URL)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(p.cachePath)))
+
+	upToDate, err := p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	configs, err := p.Collect()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "nginx", configs[0].Name)
+	assert.Equal(t, []string{"nginx"}, configs[0].ADIdentifiers)
+	assert.Equal(t, "object_store:s3://bucket/checks/nginx.d/conf.yml", configs[0].Source)
+	assert.Equal(t, "redisdb", configs[1].Name)
+	assert.Len(t, bucket.gets, 3)
+
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.True(t, upToDate)
+
+	// only the modified template is fetched again
+	bucket.Lock()
+	bucket.objects["checks/redisdb.yaml"] = storedObject{ETag: `"5"`, Content: []byte("instances:\n- host: redis\n")}
+	bucket.gets = nil
+	bucket.Unlock()
+
+	upToDate, err = p.IsUpToDate()
+	require.NoError(t, err)
+	assert.False(t, upToDate)
+
+	configs, err = p.Collect()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, []string{"checks/redisdb.yaml"}, bucket.gets)
+	assert.Contains(t, string(configs[1].Instances[0]), "redis")
+}
+
+func TestObjectStoreCollectFromCache(t *testing.T) {
+	bucket := &fakeBucket{objects: map[string]storedObject{
+		"checks/redisdb.yaml": {ETag: `"1"`, Content: []byte("instances:\n- host: localhost\n")},
+	}}
+	server := httptest.NewServer(bucket)
+	p := newTestObjectStoreProvider(t, server.URL)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(p.cachePath)))
+
+	configs, err := p.Collect()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+
+	// a restarted agent uses the cached templates while the bucket is unreachable
+	server.Close()
+	restarted := newTestObjectStoreProvider(t, server.URL)
+	os.RemoveAll(filepath.Dir(filepath.Dir(restarted.cachePath)))
+	restarted.cachePath = p.cachePath
+
+	configs, err = restarted.Collect()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "redisdb", configs[0].Name)
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	prometheusAnnotationPrefix = "prometheus.io/"
+	prometheusScrapeAnnotation = prometheusAnnotationPrefix + "scrape"
+	prometheusPathAnnotation   = prometheusAnnotationPrefix + "path"
+	prometheusPortAnnotation   = prometheusAnnotationPrefix + "port"
+	prometheusSchemeAnnotation = prometheusAnnotationPrefix + "scheme"
+
+	openmetricsCheckName  = "openmetrics"
+	prometheusDefaultPath = "/metrics"
+)
+
+// prometheusScrapeRule is a rule of the `prometheus_scrape.rules` option,
+// the first rule matching the namespace of a pod or a service configures
+// its openmetrics check.
+type prometheusScrapeRule struct {
+	// Namespaces and ExcludeNamespaces are regexes matching the whole
+	// Kubernetes namespace, an empty Namespaces list matches them all
+	Namespaces        []string          `mapstructure:"namespaces"`
+	ExcludeNamespaces []string          `mapstructure:"exclude_namespaces"`
+	MetricNamespace   string            `mapstructure:"metric_namespace"`
+	Metrics           []string          `mapstructure:"metrics"`
+	LabelsMapper      map[string]string `mapstructure:"labels_mapper"`
+	ExcludeLabels     []string          `mapstructure:"exclude_labels"`
+
+	namespaces        []*regexp.Regexp
+	excludeNamespaces []*regexp.Regexp
+}
+
+// openmetricsInstance is the instance of the openmetrics check built for a
+// scraped pod or service
+type openmetricsInstance struct {
+	PrometheusURL string            `yaml:"prometheus_url"`
+	Namespace     string            `yaml:"namespace"`
+	Metrics       []string          `yaml:"metrics"`
+	LabelsMapper  map[string]string `yaml:"labels_mapper,omitempty"`
+	ExcludeLabels []string          `yaml:"exclude_labels,omitempty"`
+}
+
+// getPrometheusScrapeRules returns the rules of the `prometheus_scrape.rules`
+// option, or a rule scraping every namespace if none is configured.
+func getPrometheusScrapeRules() ([]*prometheusScrapeRule, error) {
+	var rules []*prometheusScrapeRule
+	if err := config.Datadog.UnmarshalKey("prometheus_scrape.rules", &rules); err != nil {
+		return nil, fmt.Errorf("invalid prometheus_scrape.rules: %s", err)
+	}
+	if len(rules) == 0 {
+		rules = []*prometheusScrapeRule{{}}
+	}
+
+	for _, rule := range rules {
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("invalid prometheus_scrape.rules: %s", err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *prometheusScrapeRule) compile() error {
+	var err error
+	if r.namespaces, err = compileAnchoredRegexes(r.Namespaces); err != nil {
+		return err
+	}
+	if r.excludeNamespaces, err = compileAnchoredRegexes(r.ExcludeNamespaces); err != nil {
+		return err
+	}
+	if len(r.Metrics) == 0 {
+		r.Metrics = []string{"*"}
+	}
+	return nil
+}
+
+func compileAnchoredRegexes(patterns []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		r, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace regex '%s': %s", pattern, err)
+		}
+		regexes = append(regexes, r)
+	}
+	return regexes, nil
+}
+
+// matches returns whether the rule applies to a Kubernetes namespace
+func (r *prometheusScrapeRule) matches(namespace string) bool {
+	for _, exclude := range r.excludeNamespaces {
+		if exclude.MatchString(namespace) {
+			return false
+		}
+	}
+	if len(r.namespaces) == 0 {
+		return true
+	}
+	for _, include := range r.namespaces {
+		if include.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPrometheusScrapeRule returns the first rule applying to a namespace,
+// or nil if the objects of the namespace must not be scraped.
+func matchPrometheusScrapeRule(rules []*prometheusScrapeRule, namespace string) *prometheusScrapeRule {
+	for _, rule := range rules {
+		if rule.matches(namespace) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// isPrometheusScraped returns whether the annotations request the scraping
+func isPrometheusScraped(annotations map[string]string) bool {
+	return annotations[prometheusScrapeAnnotation] == "true"
+}
+
+// buildPrometheusConfig builds the openmetrics check template scraping the
+// endpoint described by the annotations of a pod or a service, on `port`.
+func buildPrometheusConfig(rule *prometheusScrapeRule, adIdentifier, port string, annotations map[string]string) (integration.Config, error) {
+	scheme := annotations[prometheusSchemeAnnotation]
+	if scheme == "" {
+		scheme = "http"
+	}
+	if scheme != "http" && scheme != "https" {
+		return integration.Config{}, fmt.Errorf("invalid %s annotation %q", prometheusSchemeAnnotation, scheme)
+	}
+
+	path := annotations[prometheusPathAnnotation]
+	if path == "" {
+		path = prometheusDefaultPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	instance, err := yaml.Marshal(openmetricsInstance{
+		PrometheusURL: fmt.Sprintf("%s://%%%%host%%%%:%s%s", scheme, port, path),
+		Namespace:     rule.MetricNamespace,
+		Metrics:       rule.Metrics,
+		LabelsMapper:  rule.LabelsMapper,
+		ExcludeLabels: rule.ExcludeLabels,
+	})
+	if err != nil {
+		return integration.Config{}, err
+	}
+
+	return integration.Config{
+		Name:          openmetricsCheckName,
+		InitConfig:    integration.Data("{}"),
+		Instances:     []integration.Data{instance},
+		ADIdentifiers: []string{adIdentifier},
+	}, nil
+}
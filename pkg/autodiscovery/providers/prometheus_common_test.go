@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestGetPrometheusScrapeRules(t *testing.T) {
+	mockConfig := config.Mock()
+
+	// every namespace is scraped by default
+	rules, err := getPrometheusScrapeRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, []string{"*"}, rules[0].Metrics)
+	assert.NotNil(t, matchPrometheusScrapeRule(rules, "default"))
+
+	mockConfig.Set("prometheus_scrape.rules", []map[string]interface{}{
+		{
+			"namespaces":         []string{"team-.*"},
+			"exclude_namespaces": []string{"team-sandbox"},
+			"metric_namespace":   "team",
+			"metrics":            []string{"http_.*"},
+		},
+		{
+			"namespaces":    []string{"default"},
+			"labels_mapper": map[string]string{"pod": "pod_name"},
+		},
+	})
+	defer mockConfig.Set("prometheus_scrape.rules", nil)
+
+	rules, err = getPrometheusScrapeRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	rule := matchPrometheusScrapeRule(rules, "team-a")
+	require.NotNil(t, rule)
+	assert.Equal(t, "team", rule.MetricNamespace)
+	assert.Nil(t, matchPrometheusScrapeRule(rules, "team-sandbox"))
+	assert.Nil(t, matchPrometheusScrapeRule(rules, "my-team-a"))
+	assert.Nil(t, matchPrometheusScrapeRule(rules, "kube-system"))
+
+	rule = matchPrometheusScrapeRule(rules, "default")
+	require.NotNil(t, rule)
+	assert.Equal(t, map[string]string{"pod": "pod_name"}, rule.LabelsMapper)
+	assert.Equal(t, []string{"*"}, rule.Metrics)
+
+	mockConfig.Set("prometheus_scrape.rules", []map[string]interface{}{{"namespaces": []string{"[invalid"}}})
+	_, err = getPrometheusScrapeRules()
+	assert.Error(t, err)
+}
+
+func TestBuildPrometheusConfig(t *testing.T) {
+	rule := &prometheusScrapeRule{ExcludeLabels: []string{"timestamp"}}
+	require.NoError(t, rule.compile())
+
+	c, err := buildPrometheusConfig(rule, "docker://abc", "8080", map[string]string{
+		prometheusPathAnnotation: "custom/metrics",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "openmetrics", c.Name)
+	assert.Equal(t, []string{"docker://abc"}, c.ADIdentifiers)
+	assert.Equal(t, integration.Data("{}"), c.InitConfig)
+	require.Len(t, c.Instances, 1)
+	assert.Equal(t, "prometheus_url: http://%%host%%:8080/custom/metrics\nnamespace: \"\"\nmetrics:\n- '*'\nexclude_labels:\n- timestamp\n", string(c.Instances[0]))
+
+	c, err = buildPrometheusConfig(rule, "docker://abc", "%%port%%", map[string]string{prometheusSchemeAnnotation: "https"})
+	require.NoError(t, err)
+	assert.Contains(t, string(c.Instances[0]), "prometheus_url: https://%%host%%:%%port%%/metrics\n")
+
+	_, err = buildPrometheusConfig(rule, "docker://abc", "8080", map[string]string{prometheusSchemeAnnotation: "ftp"})
+	assert.Error(t, err)
+}
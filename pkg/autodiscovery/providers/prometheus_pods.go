@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package providers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// PrometheusPodsConfigProvider implements the ConfigProvider interface for
+// the pods of the node annotated with `prometheus.io/scrape: "true"`.
+type PrometheusPodsConfigProvider struct {
+	kubelet kubelet.KubeUtilInterface
+	rules   []*prometheusScrapeRule
+	// version identifies the templates of the last collection
+	version string
+}
+
+// NewPrometheusPodsConfigProvider returns a new ConfigProvider scraping the
+// annotated pods with the openmetrics check
+func NewPrometheusPodsConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	rules, err := getPrometheusScrapeRules()
+	if err != nil {
+		return nil, err
+	}
+	return &PrometheusPodsConfigProvider{rules: rules}, nil
+}
+
+// String returns a string representation of the PrometheusPodsConfigProvider
+func (p *PrometheusPodsConfigProvider) String() string {
+	return names.PrometheusPods
+}
+
+// Collect retrieves the pods from the kubelet and builds the openmetrics
+// check templates of the annotated ones
+func (p *PrometheusPodsConfigProvider) Collect() ([]integration.Config, error) {
+	var err error
+	if p.kubelet == nil {
+		p.kubelet, err = kubelet.GetKubeUtil()
+		if err != nil {
+			return []integration.Config{}, err
+		}
+	}
+
+	pods, err := p.kubelet.GetLocalPodList()
+	if err != nil {
+		return []integration.Config{}, err
+	}
+
+	configs := parsePrometheusPods(pods, p.rules)
+	p.version = prometheusConfigsVersion(configs)
+	return configs, nil
+}
+
+// IsUpToDate builds the templates of the current pods and compares their
+// version with the one of the last collection
+func (p *PrometheusPodsConfigProvider) IsUpToDate() (bool, error) {
+	if p.kubelet == nil {
+		return false, nil
+	}
+
+	pods, err := p.kubelet.GetLocalPodList()
+	if err != nil {
+		return false, err
+	}
+
+	return prometheusConfigsVersion(parsePrometheusPods(pods, p.rules)) == p.version, nil
+}
+
+// prometheusConfigsVersion returns a version of the templates, changing when
+// a container is scraped or stops being scraped, or when its template changes
+func prometheusConfigsVersion(configs []integration.Config) string {
+	digests := make([]string, 0, len(configs))
+	for _, c := range configs {
+		digests = append(digests, c.Source+"="+c.Digest())
+	}
+	// the containers of a pod are not returned in a stable order
+	sort.Strings(digests)
+	return strings.Join(digests, ",")
+}
+
+func parsePrometheusPods(pods []*kubelet.Pod, rules []*prometheusScrapeRule) []integration.Config {
+	var configs []integration.Config
+	for _, pod := range pods {
+		if !isPrometheusScraped(pod.Metadata.Annotations) {
+			continue
+		}
+		rule := matchPrometheusScrapeRule(rules, pod.Metadata.Namespace)
+		if rule == nil {
+			log.Debugf("Pod %s/%s is not scraped, no prometheus_scrape rule matches its namespace", pod.Metadata.Namespace, pod.Metadata.Name)
+			continue
+		}
+
+		for name, port := range prometheusPodContainerPorts(pod) {
+			containerID := ""
+			for _, container := range pod.Status.Containers {
+				if container.Name == name {
+					containerID = container.ID
+				}
+			}
+			if containerID == "" {
+				// the container isn't created yet
+				continue
+			}
+
+			c, err := buildPrometheusConfig(rule, containerID, port, pod.Metadata.Annotations)
+			if err != nil {
+				log.Errorf("Can't build the openmetrics config of pod %s/%s: %s", pod.Metadata.Namespace, pod.Metadata.Name, err)
+				break
+			}
+			c.Source = "prometheus_pods:" + containerID
+			configs = append(configs, c)
+		}
+	}
+	return configs
+}
+
+// prometheusPodContainerPorts returns the containers to scrape, by name, with
+// the port to scrape. The `prometheus.io/port` annotation selects the
+// containers declaring that port, or the first container if none does.
+// Without it, the containers declaring ports are scraped on their last port.
+func prometheusPodContainerPorts(pod *kubelet.Pod) map[string]string {
+	ports := make(map[string]string)
+
+	annotatedPort := pod.Metadata.Annotations[prometheusPortAnnotation]
+	if annotatedPort == "" {
+		for _, container := range pod.Spec.Containers {
+			if len(container.Ports) > 0 {
+				ports[container.Name] = "%%port%%"
+			}
+		}
+		return ports
+	}
+
+	if port, err := strconv.Atoi(annotatedPort); err != nil || port <= 0 {
+		log.Errorf("Invalid %s annotation %q on pod %s/%s", prometheusPortAnnotation, annotatedPort, pod.Metadata.Namespace, pod.Metadata.Name)
+		return ports
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if strconv.Itoa(port.ContainerPort) == annotatedPort {
+				ports[container.Name] = annotatedPort
+			}
+		}
+	}
+	if len(ports) == 0 && len(pod.Spec.Containers) > 0 {
+		ports[pod.Spec.Containers[0].Name] = annotatedPort
+	}
+	return ports
+}
+
+func init() {
+	RegisterProvider("prometheus_pods", NewPrometheusPodsConfigProvider)
+}
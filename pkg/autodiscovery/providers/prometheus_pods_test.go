@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+func newPrometheusTestPod(name, namespace string, annotations map[string]string) *kubelet.Pod {
+	return &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: kubelet.Spec{
+			Containers: []kubelet.ContainerSpec{
+				{Name: "app", Ports: []kubelet.ContainerPortSpec{{ContainerPort: 8080}}},
+				{Name: "sidecar", Ports: []kubelet.ContainerPortSpec{{ContainerPort: 9090}}},
+				{Name: "no-port"},
+			},
+		},
+		Status: kubelet.Status{
+			Containers: []kubelet.ContainerStatus{
+				{Name: "app", ID: "docker://app-" + name},
+				{Name: "sidecar", ID: "docker://sidecar-" + name},
+				{Name: "no-port", ID: "docker://no-port-" + name},
+			},
+		},
+	}
+}
+
+func TestParsePrometheusPods(t *testing.T) {
+	rule := &prometheusScrapeRule{ExcludeNamespaces: []string{"kube-system"}}
+	require.NoError(t, rule.compile())
+	rules := []*prometheusScrapeRule{rule}
+
+	pods := []*kubelet.Pod{
+		newPrometheusTestPod("not-annotated", "default", nil),
+		newPrometheusTestPod("excluded", "kube-system", map[string]string{prometheusScrapeAnnotation: "true"}),
+		newPrometheusTestPod("disabled", "default", map[string]string{prometheusScrapeAnnotation: "false"}),
+		newPrometheusTestPod("port", "default", map[string]string{
+			prometheusScrapeAnnotation: "true",
+			prometheusPortAnnotation:   "9090",
+		}),
+	}
+
+	configs := parsePrometheusPods(pods, rules)
+	require.Len(t, configs, 1)
+	assert.Equal(t, []string{"docker://sidecar-port"}, configs[0].ADIdentifiers)
+	assert.Equal(t, "prometheus_pods:docker://sidecar-port", configs[0].Source)
+	assert.Contains(t, string(configs[0].Instances[0]), "prometheus_url: http://%%host%%:9090/metrics")
+
+	// without port annotation, the containers declaring ports are scraped
+	configs = parsePrometheusPods([]*kubelet.Pod{
+		newPrometheusTestPod("all", "default", map[string]string{prometheusScrapeAnnotation: "true"}),
+	}, rules)
+	require.Len(t, configs, 2)
+	for _, c := range configs {
+		assert.Contains(t, string(c.Instances[0]), "prometheus_url: http://%%host%%:%%port%%/metrics")
+	}
+}
+
+func TestPrometheusPodContainerPorts(t *testing.T) {
+	pod := newPrometheusTestPod("pod", "default", map[string]string{prometheusPortAnnotation: "8080"})
+	assert.Equal(t, map[string]string{"app": "8080"}, prometheusPodContainerPorts(pod))
+
+	// the first container is scraped when no container declares the port
+	pod.Metadata.Annotations[prometheusPortAnnotation] = "1234"
+	assert.Equal(t, map[string]string{"app": "1234"}, prometheusPodContainerPorts(pod))
+
+	pod.Metadata.Annotations[prometheusPortAnnotation] = "http"
+	assert.Empty(t, prometheusPodContainerPorts(pod))
+}
+
+func TestPrometheusConfigsVersion(t *testing.T) {
+	rule := &prometheusScrapeRule{}
+	require.NoError(t, rule.compile())
+	rules := []*prometheusScrapeRule{rule}
+
+	annotations := map[string]string{prometheusScrapeAnnotation: "true"}
+	pods := []*kubelet.Pod{newPrometheusTestPod("pod", "default", annotations)}
+	version := prometheusConfigsVersion(parsePrometheusPods(pods, rules))
+	assert.NotEmpty(t, version)
+	assert.Equal(t, version, prometheusConfigsVersion(parsePrometheusPods(pods, rules)))
+
+	// a new pod changes the version
+	pods = append(pods, newPrometheusTestPod("other", "default", annotations))
+	newVersion := prometheusConfigsVersion(parsePrometheusPods(pods, rules))
+	assert.NotEqual(t, version, newVersion)
+	version = newVersion
+
+	// so does a change of the annotations
+	pods[1].Metadata.Annotations = map[string]string{
+		prometheusScrapeAnnotation: "true",
+		prometheusPortAnnotation:   "9090",
+	}
+	assert.NotEqual(t, version, prometheusConfigsVersion(parsePrometheusPods(pods, rules)))
+
+	assert.Empty(t, prometheusConfigsVersion(nil))
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package providers
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// PrometheusServicesConfigProvider implements the ConfigProvider interface
+// for the services annotated with `prometheus.io/scrape: "true"`, their
+// openmetrics checks are cluster checks dispatched by the cluster agent.
+type PrometheusServicesConfigProvider struct {
+	lister   listersv1.ServiceLister
+	rules    []*prometheusScrapeRule
+	upToDate bool
+}
+
+// NewPrometheusServicesConfigProvider returns a new ConfigProvider scraping
+// the annotated services with the openmetrics check
+func NewPrometheusServicesConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	rules, err := getPrometheusScrapeRules()
+	if err != nil {
+		return nil, err
+	}
+
+	ac, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	servicesInformer := ac.InformerFactory.Core().V1().Services()
+	if servicesInformer == nil {
+		return nil, fmt.Errorf("cannot get service informer: %s", err)
+	}
+
+	p := &PrometheusServicesConfigProvider{
+		lister: servicesInformer.Lister(),
+		rules:  rules,
+	}
+
+	servicesInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    p.invalidate,
+		UpdateFunc: p.invalidateIfChanged,
+		DeleteFunc: p.invalidate,
+	})
+
+	return p, nil
+}
+
+// String returns a string representation of the PrometheusServicesConfigProvider
+func (p *PrometheusServicesConfigProvider) String() string {
+	return names.PrometheusServices
+}
+
+// Collect retrieves the services from the apiserver and builds the
+// openmetrics check templates of the annotated ones
+func (p *PrometheusServicesConfigProvider) Collect() ([]integration.Config, error) {
+	services, err := p.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	p.upToDate = true
+
+	return parsePrometheusServices(services, p.rules), nil
+}
+
+// IsUpToDate allows to cache configs as long as no changes are detected in the apiserver
+func (p *PrometheusServicesConfigProvider) IsUpToDate() (bool, error) {
+	return p.upToDate, nil
+}
+
+func (p *PrometheusServicesConfigProvider) invalidate(obj interface{}) {
+	if obj != nil {
+		log.Trace("Invalidating prometheus configs on new/deleted service")
+		p.upToDate = false
+	}
+}
+
+func (p *PrometheusServicesConfigProvider) invalidateIfChanged(old, obj interface{}) {
+	castedObj, ok := obj.(*v1.Service)
+	if !ok {
+		log.Errorf("Expected a Service type, got: %v", obj)
+		return
+	}
+	castedOld, ok := old.(*v1.Service)
+	if !ok {
+		log.Errorf("Expected a Service type, got: %v", old)
+		p.upToDate = false
+		return
+	}
+	if castedObj.ResourceVersion == castedOld.ResourceVersion {
+		return
+	}
+	if valuesDiffer(castedObj.Annotations, castedOld.Annotations, prometheusAnnotationPrefix) {
+		log.Trace("Invalidating prometheus configs on service change")
+		p.upToDate = false
+	}
+}
+
+func parsePrometheusServices(services []*v1.Service, rules []*prometheusScrapeRule) []integration.Config {
+	var configs []integration.Config
+	for _, svc := range services {
+		if svc == nil || svc.ObjectMeta.UID == "" || !isPrometheusScraped(svc.Annotations) {
+			continue
+		}
+		rule := matchPrometheusScrapeRule(rules, svc.Namespace)
+		if rule == nil {
+			log.Debugf("Service %s/%s is not scraped, no prometheus_scrape rule matches its namespace", svc.Namespace, svc.Name)
+			continue
+		}
+
+		port := svc.Annotations[prometheusPortAnnotation]
+		if port == "" {
+			port = "%%port%%"
+		} else if p, err := strconv.Atoi(port); err != nil || p <= 0 {
+			log.Errorf("Invalid %s annotation %q on service %s/%s", prometheusPortAnnotation, port, svc.Namespace, svc.Name)
+			continue
+		}
+
+		serviceID := apiserver.EntityForService(svc)
+		c, err := buildPrometheusConfig(rule, serviceID, port, svc.Annotations)
+		if err != nil {
+			log.Errorf("Can't build the openmetrics config of service %s/%s: %s", svc.Namespace, svc.Name, err)
+			continue
+		}
+		c.ClusterCheck = true
+		c.Source = "prometheus_services:" + serviceID
+		configs = append(configs, c)
+	}
+	return configs
+}
+
+func init() {
+	RegisterProvider("prometheus_services", NewPrometheusServicesConfigProvider)
+}
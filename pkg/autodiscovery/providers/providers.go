@@ -13,6 +13,9 @@ import (
 // KubeEndpointsProviderName defines the kube endpoints provider name
 const KubeEndpointsProviderName = "kube_endpoints"
 
+// KubeEndpointsLocalProviderName defines the node agent kube endpoints provider name
+const KubeEndpointsLocalProviderName = "kube_endpoints_local"
+
 // ProviderCatalog keeps track of config providers by name
 var ProviderCatalog = make(map[string]ConfigProviderFactory)
 
@@ -52,3 +55,12 @@ type ConfigProvider interface {
 	String() string
 	IsUpToDate() (bool, error)
 }
+
+// WatchableConfigProvider is implemented by the config providers able to
+// watch their backend for changes. Changes() is signaled every time the
+// templates they store are modified, so that AutoConfig collects them right
+// away instead of waiting for the next poll.
+type WatchableConfigProvider interface {
+	ConfigProvider
+	Changes() <-chan struct{}
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const (
+	// watchWaitTime is the maximum duration of a single blocking query
+	watchWaitTime = 5 * time.Minute
+	// watchRetryDelay is the delay before watching again after an error
+	watchRetryDelay = 10 * time.Second
+)
+
+var (
+	tlmWatchEvents = telemetry.NewCounter("autodiscovery", "provider_watch_events",
+		[]string{"provider"}, "Number of template changes notified by the config provider watches")
+	tlmWatchErrors = telemetry.NewCounter("autodiscovery", "provider_watch_errors",
+		[]string{"provider"}, "Number of errors returned by the config provider watches")
+)
+
+// templateWatch notifies AutoConfig of the changes detected by the watch
+// of a config provider.
+type templateWatch struct {
+	provider string
+	changes  chan struct{}
+}
+
+func newTemplateWatch(provider string) *templateWatch {
+	return &templateWatch{
+		provider: provider,
+		changes:  make(chan struct{}, 1),
+	}
+}
+
+// notify signals a change without blocking: pending notifications are
+// coalesced as a single collection picks up all of them.
+func (w *templateWatch) notify() {
+	tlmWatchEvents.Inc(w.provider)
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+// fail records a watch error
+func (w *templateWatch) fail() {
+	tlmWatchErrors.Inc(w.provider)
+}
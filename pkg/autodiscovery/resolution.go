@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/configresolver"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+)
+
+// ExplainResolution resolves the templates against the services known at
+// the time of the call, without scheduling anything, and reports how each
+// template variable resolved and why the configs were rejected.
+func (ac *AutoConfig) ExplainResolution() []integration.TemplateResolution {
+	templates := ac.store.templateCache.GetTemplates()
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Source < templates[j].Source
+	})
+
+	var filtered []integration.FilteredContainer
+	resolutions := make([]integration.TemplateResolution, 0, len(templates))
+	for _, tpl := range templates {
+		resolution := integration.TemplateResolution{
+			Name:          tpl.Name,
+			Provider:      tpl.Provider,
+			Source:        tpl.Source,
+			ADIdentifiers: tpl.ADIdentifiers,
+			Services:      []integration.ServiceResolution{},
+		}
+
+		for _, id := range tpl.ADIdentifiers {
+			matched := false
+			for _, entity := range ac.store.getSortedServiceEntitiesForADID(id) {
+				svc := ac.store.getServiceForEntity(entity)
+				if svc == nil {
+					// the service was deleted
+					continue
+				}
+				matched = true
+				resolution.Services = append(resolution.Services, ac.explainServiceResolution(tpl, svc, id))
+			}
+			if matched {
+				continue
+			}
+
+			resolution.UnmatchedADIdentifiers = append(resolution.UnmatchedADIdentifiers, id)
+			if filtered == nil {
+				filtered = listeners.GetFilteredContainers()
+			}
+			for _, c := range filtered {
+				if imageMatchesADIdentifier(c.Image, id) {
+					resolution.FilteredContainers = append(resolution.FilteredContainers, c)
+				}
+			}
+		}
+
+		resolutions = append(resolutions, resolution)
+	}
+	return resolutions
+}
+
+func (ac *AutoConfig) explainServiceResolution(tpl integration.Config, svc listeners.Service, adID string) integration.ServiceResolution {
+	_, variables, err := configresolver.ResolveWithTrace(tpl, svc)
+	resolution := integration.ServiceResolution{
+		Entity:          svc.GetEntity(),
+		Listener:        ac.store.getListenerForEntity(svc.GetEntity()),
+		ADIdentifier:    adID,
+		Variables:       variables,
+		Resolved:        err == nil,
+		MetricsExcluded: svc.HasFilter(containers.MetricsFilter),
+		LogsExcluded:    svc.HasFilter(containers.LogsFilter),
+	}
+	if err != nil {
+		resolution.Error = err.Error()
+	}
+	return resolution
+}
+
+// imageMatchesADIdentifier returns whether a container image would be
+// identified by an AD identifier: its short name or its full name.
+func imageMatchesADIdentifier(image, adID string) bool {
+	if image == adID {
+		return true
+	}
+	long, short, _, err := containers.SplitImageName(image)
+	if err != nil {
+		return false
+	}
+	return adID == short || adID == long
+}
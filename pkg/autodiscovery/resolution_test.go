@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
+)
+
+func TestExplainResolution(t *testing.T) {
+	ac := NewAutoConfig(scheduler.NewMetaScheduler())
+
+	ac.store.setListenerForEntity("docker://redis-1", "docker")
+	ac.processNewService(&dummyService{
+		ID:            "docker://redis-1",
+		ADIdentifiers: []string{"redis"},
+		Hosts:         map[string]string{"bridge": "172.17.0.2"},
+	})
+	ac.processNewService(&dummyService{
+		ID:            "docker://redis-2",
+		ADIdentifiers: []string{"redis"},
+	})
+	ac.processNewConfig(integration.Config{
+		Name:          "redisdb",
+		ADIdentifiers: []string{"redis", "valkey"},
+		Instances:     []integration.Data{integration.Data("host: %%host%%")},
+	})
+
+	resolutions := ac.ExplainResolution()
+	require.Len(t, resolutions, 1)
+	assert.Equal(t, "redisdb", resolutions[0].Name)
+	assert.Equal(t, []string{"valkey"}, resolutions[0].UnmatchedADIdentifiers)
+	require.Len(t, resolutions[0].Services, 2)
+
+	resolved := resolutions[0].Services[0]
+	assert.Equal(t, "docker://redis-1", resolved.Entity)
+	assert.Equal(t, "docker", resolved.Listener)
+	assert.Equal(t, "redis", resolved.ADIdentifier)
+	assert.True(t, resolved.Resolved)
+	assert.Equal(t, []integration.VariableResolution{{Instance: 0, Variable: "%%host%%", Value: "172.17.0.2"}}, resolved.Variables)
+
+	rejected := resolutions[0].Services[1]
+	assert.Equal(t, "docker://redis-2", rejected.Entity)
+	assert.False(t, rejected.Resolved)
+	assert.Contains(t, rejected.Error, "no network found")
+	require.Len(t, rejected.Variables, 1)
+	assert.Equal(t, rejected.Error, rejected.Variables[0].Error)
+}
+
+func TestImageMatchesADIdentifier(t *testing.T) {
+	assert.True(t, imageMatchesADIdentifier("redis", "redis"))
+	assert.True(t, imageMatchesADIdentifier("docker.io/library/redis:6.0", "redis"))
+	assert.True(t, imageMatchesADIdentifier("gcr.io/project/redis:6.0", "gcr.io/project/redis"))
+	assert.False(t, imageMatchesADIdentifier("redis:6.0", "nginx"))
+}
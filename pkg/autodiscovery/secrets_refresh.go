@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxSecretRotations is the number of rotations kept in the audit trail
+const maxSecretRotations = 100
+
+var (
+	secretRotations     []SecretRotation
+	secretRotationsLock sync.Mutex
+)
+
+// SecretRotation records a config rescheduled because some of its secrets were rotated.
+// The values of the secrets are never recorded.
+type SecretRotation struct {
+	Time     time.Time `json:"time"`
+	Config   string    `json:"config"`
+	Provider string    `json:"provider"`
+	Source   string    `json:"source"`
+	Handles  []string  `json:"handles"`
+}
+
+// secretConfig is a config holding secrets, as it was collected and as it was scheduled
+type secretConfig struct {
+	raw       integration.Config
+	scheduled integration.Config
+	// loaded is true when the scheduled config is the one stored in the loaded configs,
+	// the configs resolved from a template are stored as they were resolved
+	loaded bool
+}
+
+// GetSecretRotations returns the configs rescheduled after the rotation of their secrets,
+// the most recent last.
+func GetSecretRotations() []SecretRotation {
+	secretRotationsLock.Lock()
+	defer secretRotationsLock.Unlock()
+	return append([]SecretRotation(nil), secretRotations...)
+}
+
+func recordSecretRotation(rotation SecretRotation) {
+	secretRotationsLock.Lock()
+	defer secretRotationsLock.Unlock()
+	secretRotations = append(secretRotations, rotation)
+	if len(secretRotations) > maxSecretRotations {
+		secretRotations = secretRotations[len(secretRotations)-maxSecretRotations:]
+	}
+}
+
+// startSecretRefresh fetches the secrets again periodically, and reschedules the configs
+// whose secrets were rotated. They are also rescheduled when the secrets are refreshed
+// by the other components, e.g. the forwarder reloading its API keys.
+func (ac *AutoConfig) startSecretRefresh(interval time.Duration) {
+	secrets.RegisterRefreshHandler(ac.rescheduleSecretConfigs)
+	ac.secretRefreshStop = make(chan struct{})
+	go ac.secretRefreshLoop(interval)
+}
+
+func (ac *AutoConfig) secretRefreshLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ac.secretRefreshStop:
+			return
+		case <-ticker.C:
+			if _, err := secrets.Refresh(); err != nil {
+				log.Warnf("Could not refresh the secrets, the checks keep their current secrets: %v", err)
+			}
+		}
+	}
+}
+
+// trackSecretConfig keeps the collected config when its secrets were decrypted, so that it
+// can be decrypted again when they are rotated.
+func (ac *AutoConfig) trackSecretConfig(raw integration.Config, scheduled integration.Config, loaded bool) {
+	if !secretConfigChanged(raw, scheduled) {
+		// the config doesn't hold any secret
+		return
+	}
+	ac.secretConfigsLock.Lock()
+	defer ac.secretConfigsLock.Unlock()
+	ac.secretConfigs[raw.Digest()] = secretConfig{raw: raw, scheduled: scheduled, loaded: loaded}
+}
+
+// untrackSecretConfigs forgets the collected configs, and returns them as they were scheduled.
+func (ac *AutoConfig) untrackSecretConfigs(configs []integration.Config) []integration.Config {
+	ac.secretConfigsLock.Lock()
+	defer ac.secretConfigsLock.Unlock()
+
+	scheduled := make([]integration.Config, 0, len(configs))
+	for _, c := range configs {
+		digest := c.Digest()
+		if sc, found := ac.secretConfigs[digest]; found {
+			delete(ac.secretConfigs, digest)
+			scheduled = append(scheduled, sc.scheduled)
+			continue
+		}
+		scheduled = append(scheduled, c)
+	}
+	return scheduled
+}
+
+// rescheduleSecretConfigs decrypts again the configs referencing the rotated secrets, and
+// reschedules the ones that changed. The logs sources are rescheduled with the checks, as
+// both are scheduled from the same configs.
+func (ac *AutoConfig) rescheduleSecretConfigs(handles []string, _ map[string]string) {
+	ac.secretConfigsLock.Lock()
+	defer ac.secretConfigsLock.Unlock()
+
+	for digest, sc := range ac.secretConfigs {
+		rotated := referencedHandles(sc.raw, handles)
+		if len(rotated) == 0 {
+			continue
+		}
+
+		config, err := decryptConfig(sc.raw)
+		if err != nil {
+			log.Errorf("Could not decrypt %s again after the rotation of its secrets, keeping it scheduled: %s", sc.raw.Name, err)
+			continue
+		}
+		if !secretConfigChanged(sc.scheduled, config) {
+			continue
+		}
+
+		log.Infof("Secrets %s of %s were rotated, rescheduling it", strings.Join(rotated, ", "), config.Name)
+		ac.unschedule([]integration.Config{sc.scheduled})
+		if sc.loaded {
+			ac.store.removeLoadedConfig(sc.scheduled)
+			ac.store.setLoadedConfig(config)
+		}
+		ac.schedule([]integration.Config{config})
+		ac.secretConfigs[digest] = secretConfig{raw: sc.raw, scheduled: config, loaded: sc.loaded}
+
+		recordSecretRotation(SecretRotation{
+			Time:     time.Now(),
+			Config:   config.Name,
+			Provider: config.Provider,
+			Source:   config.Source,
+			Handles:  rotated,
+		})
+	}
+}
+
+// referencedHandles returns the handles referenced by the config
+func referencedHandles(config integration.Config, handles []string) []string {
+	var referenced []string
+	for _, handle := range handles {
+		enc := []byte("ENC[" + handle + "]")
+		found := bytes.Contains(config.InitConfig, enc) ||
+			bytes.Contains(config.MetricConfig, enc) ||
+			bytes.Contains(config.LogsConfig, enc)
+		for _, instance := range config.Instances {
+			found = found || bytes.Contains(instance, enc)
+		}
+		if found {
+			referenced = append(referenced, handle)
+		}
+	}
+	return referenced
+}
+
+// secretConfigChanged returns true when the decrypted configs differ, the digest doesn't
+// cover the metrics of the JMX checks.
+func secretConfigChanged(old integration.Config, updated integration.Config) bool {
+	return old.Digest() != updated.Digest() || !bytes.Equal(old.MetricConfig, updated.MetricConfig)
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
+)
+
+type recordingScheduler struct {
+	scheduled   []integration.Config
+	unscheduled []integration.Config
+}
+
+func (s *recordingScheduler) Schedule(configs []integration.Config) {
+	s.scheduled = append(s.scheduled, configs...)
+}
+
+func (s *recordingScheduler) Unschedule(configs []integration.Config) {
+	s.unscheduled = append(s.unscheduled, configs...)
+}
+
+func (s *recordingScheduler) Stop() {}
+
+func TestRescheduleSecretConfigs(t *testing.T) {
+	secretValues := map[string]string{"db_pass": "password1", "api_token": "token1"}
+	secretDecrypt = func(data []byte, origin string) ([]byte, error) {
+		decrypted := string(data)
+		for handle, value := range secretValues {
+			decrypted = strings.Replace(decrypted, "ENC["+handle+"]", value, -1)
+		}
+		return []byte(decrypted), nil
+	}
+	defer func() { secretDecrypt = secrets.Decrypt }()
+
+	s := &recordingScheduler{}
+	ms := scheduler.NewMetaScheduler()
+	ms.Register("recording", s)
+	ac := NewAutoConfig(ms)
+
+	raw := integration.Config{
+		Name:      "postgres",
+		Instances: []integration.Data{integration.Data("password: ENC[db_pass]")},
+	}
+	configs := ac.processNewConfig(raw)
+	require.Len(t, configs, 1)
+	assert.Equal(t, integration.Data("password: password1"), configs[0].Instances[0])
+	// the collected config is kept encrypted
+	assert.Equal(t, integration.Data("password: ENC[db_pass]"), raw.Instances[0])
+	ac.schedule(configs)
+
+	// the other secrets don't reschedule the config
+	secretValues["api_token"] = "token2"
+	ac.rescheduleSecretConfigs([]string{"api_token"}, nil)
+	assert.Len(t, s.scheduled, 1)
+	assert.Empty(t, s.unscheduled)
+
+	secretValues["db_pass"] = "password2"
+	ac.rescheduleSecretConfigs([]string{"api_token", "db_pass"}, nil)
+	require.Len(t, s.unscheduled, 1)
+	assert.Equal(t, integration.Data("password: password1"), s.unscheduled[0].Instances[0])
+	require.Len(t, s.scheduled, 2)
+	assert.Equal(t, integration.Data("password: password2"), s.scheduled[1].Instances[0])
+	assert.Contains(t, ac.GetLoadedConfigs(), s.scheduled[1].Digest())
+	assert.NotContains(t, ac.GetLoadedConfigs(), configs[0].Digest())
+
+	rotations := GetSecretRotations()
+	require.NotEmpty(t, rotations)
+	assert.Equal(t, "postgres", rotations[len(rotations)-1].Config)
+	assert.Equal(t, []string{"db_pass"}, rotations[len(rotations)-1].Handles)
+
+	// the config is unscheduled as it was rescheduled
+	ac.processRemovedConfigs([]integration.Config{raw})
+	require.Len(t, s.unscheduled, 2)
+	assert.Equal(t, integration.Data("password: password2"), s.unscheduled[1].Instances[0])
+	assert.Empty(t, ac.GetLoadedConfigs())
+	assert.Empty(t, ac.secretConfigs)
+}
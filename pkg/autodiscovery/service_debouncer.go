@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	tlmServiceEvents = telemetry.NewCounter("autodiscovery", "service_events",
+		[]string{"listener", "event"}, "Number of services created and deleted by the listeners")
+	tlmServicesDropped = telemetry.NewCounter("autodiscovery", "services_dropped",
+		[]string{"listener"}, "Number of services deleted within their stability window, that were never scheduled")
+	tlmServicesDebounced = telemetry.NewCounter("autodiscovery", "services_debounced",
+		[]string{"listener"}, "Number of services created again within their unschedule delay, that were never unscheduled")
+)
+
+// pendingService is a service event waiting to be forwarded
+type pendingService struct {
+	svc     listeners.Service
+	timer   *time.Timer
+	pending map[string]*pendingService
+	out     chan<- listeners.Service
+}
+
+// serviceDebouncer sits between a listener and AutoConfig to absorb the
+// churn of the services that restart in a loop:
+//  - new services are only forwarded once they have existed for the
+//    stability window, services deleted before are never scheduled
+//  - deleted services are only forwarded after the unschedule delay, services
+//    created again in the meantime are never unscheduled
+// A single goroutine handles the events of the listener and the expired
+// timers, and forwards the services, so AutoConfig receives them in order.
+type serviceDebouncer struct {
+	listener        string
+	stabilityWindow time.Duration
+	unscheduleDelay time.Duration
+	newIn           chan listeners.Service
+	delIn           chan listeners.Service
+	expired         chan *pendingService
+	newOut          chan<- listeners.Service
+	delOut          chan<- listeners.Service
+	pendingNew      map[string]*pendingService
+	pendingDel      map[string]*pendingService
+	stopChan        chan struct{}
+}
+
+func newServiceDebouncer(listener string, stabilityWindow, unscheduleDelay time.Duration, newOut, delOut chan<- listeners.Service) *serviceDebouncer {
+	return &serviceDebouncer{
+		listener:        listener,
+		stabilityWindow: stabilityWindow,
+		unscheduleDelay: unscheduleDelay,
+		newIn:           make(chan listeners.Service),
+		delIn:           make(chan listeners.Service),
+		expired:         make(chan *pendingService),
+		newOut:          newOut,
+		delOut:          delOut,
+		pendingNew:      make(map[string]*pendingService),
+		pendingDel:      make(map[string]*pendingService),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// start processes the events sent by the listener until stop is called
+func (d *serviceDebouncer) start() {
+	go func() {
+		for {
+			select {
+			case svc := <-d.newIn:
+				d.handleNew(svc)
+			case svc := <-d.delIn:
+				d.handleDel(svc)
+			case p := <-d.expired:
+				d.forward(p)
+			case <-d.stopChan:
+				for entity, p := range d.pendingNew {
+					p.timer.Stop()
+					delete(d.pendingNew, entity)
+				}
+				for entity, p := range d.pendingDel {
+					p.timer.Stop()
+					delete(d.pendingDel, entity)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// stop drops the pending events and stops processing the events of the
+// listener
+func (d *serviceDebouncer) stop() {
+	close(d.stopChan)
+}
+
+// send forwards an event, unless the debouncer is stopped
+func (d *serviceDebouncer) send(out chan<- listeners.Service, svc listeners.Service) {
+	select {
+	case out <- svc:
+	case <-d.stopChan:
+	}
+}
+
+// delay queues an event until its timer expires
+func (d *serviceDebouncer) delay(pending map[string]*pendingService, out chan<- listeners.Service, svc listeners.Service, delay time.Duration) {
+	p := &pendingService{svc: svc, pending: pending, out: out}
+	p.timer = time.AfterFunc(delay, func() {
+		select {
+		case d.expired <- p:
+		case <-d.stopChan:
+		}
+	})
+	pending[svc.GetEntity()] = p
+}
+
+func (d *serviceDebouncer) handleNew(svc listeners.Service) {
+	tlmServiceEvents.Inc(d.listener, "new")
+	entity := svc.GetEntity()
+
+	if p, found := d.pendingDel[entity]; found {
+		delete(d.pendingDel, entity)
+		p.timer.Stop()
+		tlmServicesDebounced.Inc(d.listener)
+
+		if servicesEqual(p.svc, svc) {
+			log.Debugf("Service %s was created again within %s, keeping its checks scheduled", entity, d.unscheduleDelay)
+			return
+		}
+		// the service changed while restarting, its checks must be resolved again
+		d.send(d.delOut, p.svc)
+		d.send(d.newOut, svc)
+		return
+	}
+
+	if p, found := d.pendingNew[entity]; found {
+		p.timer.Stop()
+		delete(d.pendingNew, entity)
+	}
+
+	// services existing before the agent started are considered stable
+	if d.stabilityWindow == 0 || svc.GetCreationTime() == integration.Before {
+		d.send(d.newOut, svc)
+		return
+	}
+
+	d.delay(d.pendingNew, d.newOut, svc, d.stabilityWindow)
+}
+
+func (d *serviceDebouncer) handleDel(svc listeners.Service) {
+	tlmServiceEvents.Inc(d.listener, "del")
+	entity := svc.GetEntity()
+
+	if p, found := d.pendingNew[entity]; found {
+		delete(d.pendingNew, entity)
+		p.timer.Stop()
+		tlmServicesDropped.Inc(d.listener)
+		log.Debugf("Service %s was deleted within its stability window of %s, it is not scheduled", entity, d.stabilityWindow)
+		return
+	}
+
+	if p, found := d.pendingDel[entity]; found {
+		p.timer.Stop()
+		delete(d.pendingDel, entity)
+	}
+
+	if d.unscheduleDelay == 0 {
+		d.send(d.delOut, svc)
+		return
+	}
+
+	d.delay(d.pendingDel, d.delOut, svc, d.unscheduleDelay)
+}
+
+// forward sends a pending event once its timer expires, unless it was
+// cancelled or replaced while the expiry was queued
+func (d *serviceDebouncer) forward(p *pendingService) {
+	entity := p.svc.GetEntity()
+	if p.pending[entity] != p {
+		return
+	}
+	delete(p.pending, entity)
+
+	d.send(p.out, p.svc)
+}
+
+// servicesEqual returns whether two instances of a service resolve the
+// templates the same way
+func servicesEqual(first, second listeners.Service) bool {
+	firstHosts, err1 := first.GetHosts()
+	secondHosts, err2 := second.GetHosts()
+	if err1 != nil || err2 != nil || !reflect.DeepEqual(firstHosts, secondHosts) {
+		return false
+	}
+
+	firstPorts, err1 := first.GetPorts()
+	secondPorts, err2 := second.GetPorts()
+	if err1 != nil || err2 != nil || !reflect.DeepEqual(firstPorts, secondPorts) {
+		return false
+	}
+
+	return true
+}
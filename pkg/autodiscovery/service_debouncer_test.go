@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package autodiscovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/listeners"
+)
+
+func newTestDebouncer(stabilityWindow, unscheduleDelay time.Duration) (*serviceDebouncer, chan listeners.Service, chan listeners.Service) {
+	newOut := make(chan listeners.Service, 10)
+	delOut := make(chan listeners.Service, 10)
+	d := newServiceDebouncer("test", stabilityWindow, unscheduleDelay, newOut, delOut)
+	d.start()
+	return d, newOut, delOut
+}
+
+func receive(t *testing.T, ch chan listeners.Service) listeners.Service {
+	select {
+	case svc := <-ch:
+		return svc
+	case <-time.After(time.Second):
+		assert.FailNow(t, "no service received")
+		return nil
+	}
+}
+
+func TestDebouncerStabilityWindow(t *testing.T) {
+	d, newOut, delOut := newTestDebouncer(50*time.Millisecond, 0)
+
+	// a service deleted within its stability window is never scheduled
+	crashing := &dummyService{ID: "crashing", CreationTime: integration.After}
+	d.newIn <- crashing
+	d.delIn <- crashing
+
+	stable := &dummyService{ID: "stable", CreationTime: integration.After}
+	d.newIn <- stable
+	assert.Len(t, newOut, 0)
+	assert.Equal(t, stable, receive(t, newOut))
+
+	// services created before the agent started are scheduled right away
+	existing := &dummyService{ID: "existing", CreationTime: integration.Before}
+	d.newIn <- existing
+	assert.Equal(t, existing, receive(t, newOut))
+
+	d.delIn <- stable
+	assert.Equal(t, stable, receive(t, delOut))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, newOut, 0)
+	assert.Len(t, delOut, 0)
+}
+
+func TestDebouncerUnscheduleDelay(t *testing.T) {
+	d, newOut, delOut := newTestDebouncer(0, 50*time.Millisecond)
+
+	svc := &dummyService{ID: "svc", Hosts: map[string]string{"bridge": "172.17.0.2"}}
+	d.newIn <- svc
+	assert.Equal(t, svc, receive(t, newOut))
+
+	// a service restarting with the same network setup is never unscheduled
+	d.delIn <- svc
+	d.newIn <- &dummyService{ID: "svc", Hosts: map[string]string{"bridge": "172.17.0.2"}}
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, newOut, 0)
+	assert.Len(t, delOut, 0)
+
+	// a service restarting with a new IP is replaced right away
+	restarted := &dummyService{ID: "svc", Hosts: map[string]string{"bridge": "172.17.0.3"}}
+	d.delIn <- svc
+	d.newIn <- restarted
+	assert.Equal(t, svc, receive(t, delOut))
+	assert.Equal(t, restarted, receive(t, newOut))
+
+	// a deleted service is unscheduled after the delay
+	d.delIn <- restarted
+	assert.Len(t, delOut, 0)
+	assert.Equal(t, restarted, receive(t, delOut))
+}
+
+func TestDebouncerStop(t *testing.T) {
+	d, newOut, delOut := newTestDebouncer(50*time.Millisecond, 50*time.Millisecond)
+
+	scheduled := &dummyService{ID: "scheduled", CreationTime: integration.Before}
+	d.newIn <- scheduled
+	assert.Equal(t, scheduled, receive(t, newOut))
+
+	// the pending events are dropped once stopped
+	d.newIn <- &dummyService{ID: "pending", CreationTime: integration.After}
+	d.delIn <- scheduled
+	d.stop()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, newOut, 0)
+	assert.Len(t, delOut, 0)
+}
+
+func TestDebouncerDeleteRacingStabilityWindow(t *testing.T) {
+	newOut := make(chan listeners.Service)
+	delOut := make(chan listeners.Service, 10)
+	d := newServiceDebouncer("test", 10*time.Millisecond, 0, newOut, delOut)
+	d.start()
+	defer d.stop()
+
+	// the stability window expires while AutoConfig doesn't read the new services
+	svc := &dummyService{ID: "svc", CreationTime: integration.After}
+	d.newIn <- svc
+	time.Sleep(50 * time.Millisecond)
+
+	deleted := make(chan struct{})
+	go func() {
+		d.delIn <- svc
+		close(deleted)
+	}()
+
+	// the delete is only forwarded after the creation
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, delOut, 0)
+	assert.Equal(t, svc, receive(t, newOut))
+	<-deleted
+	assert.Equal(t, svc, receive(t, delOut))
+}
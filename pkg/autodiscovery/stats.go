@@ -32,6 +32,17 @@ func (es *acErrorStats) setConfigError(checkName string, err string) {
 	es.config[checkName] = err
 }
 
+// setConfigErrors will safely replace the errors of all the check configuration files
+func (es *acErrorStats) setConfigErrors(errors map[string]string) {
+	es.m.Lock()
+	defer es.m.Unlock()
+
+	es.config = make(map[string]string, len(errors))
+	for checkName, err := range errors {
+		es.config[checkName] = err
+	}
+}
+
 // removeConfigErrors removes the errors for a check config file
 func (es *acErrorStats) removeConfigError(checkName string) {
 	es.m.Lock()
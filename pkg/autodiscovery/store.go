@@ -6,6 +6,7 @@
 package autodiscovery
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -21,6 +22,7 @@ type store struct {
 	nameToJMXMetrics  map[string]integration.Data
 	adIDToServices    map[string]map[string]bool
 	entityToService   map[string]listeners.Service
+	entityToListener  map[string]string
 	templateCache     *TemplateCache
 	m                 sync.RWMutex
 }
@@ -35,6 +37,7 @@ func newStore() *store {
 		nameToJMXMetrics:  make(map[string]integration.Data),
 		adIDToServices:    make(map[string]map[string]bool),
 		entityToService:   make(map[string]listeners.Service),
+		entityToListener:  make(map[string]string),
 		templateCache:     NewTemplateCache(),
 	}
 
@@ -170,6 +173,20 @@ func (s *store) removeServiceForEntity(entity string) {
 	s.m.Lock()
 	defer s.m.Unlock()
 	delete(s.entityToService, entity)
+	delete(s.entityToListener, entity)
+}
+
+// setListenerForEntity records the name of the listener that created a service
+func (s *store) setListenerForEntity(entity string, listener string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.entityToListener[entity] = listener
+}
+
+func (s *store) getListenerForEntity(entity string) string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.entityToListener[entity]
 }
 
 func (s *store) setADIDForServices(adID string, serviceEntity string) {
@@ -187,3 +204,16 @@ func (s *store) getServiceEntitiesForADID(adID string) (map[string]bool, bool) {
 	services, found := s.adIDToServices[adID]
 	return services, found
 }
+
+// getSortedServiceEntitiesForADID returns a copy of the entities of the
+// services having an AD identifier, sorted
+func (s *store) getSortedServiceEntitiesForADID(adID string) []string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	entities := make([]string, 0, len(s.adIDToServices[adID]))
+	for entity := range s.adIDToServices[adID] {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+	return entities
+}
@@ -85,6 +85,18 @@ func (cache *TemplateCache) GetUnresolvedTemplates() map[string][]integration.Co
 	return tpls
 }
 
+// GetTemplates returns all the templates of the cache
+func (cache *TemplateCache) GetTemplates() []integration.Config {
+	cache.m.RLock()
+	defer cache.m.RUnlock()
+
+	tpls := make([]integration.Config, 0, len(cache.digestToTemplate))
+	for _, config := range cache.digestToTemplate {
+		tpls = append(tpls, config)
+	}
+	return tpls
+}
+
 // Del removes a template from the cache
 func (cache *TemplateCache) Del(tpl integration.Config) error {
 	// compute the digest once
@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	stdLog "log"
+	"net"
+	"net/http"
+	"strconv"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// injectLibPath is the path of the webhook injecting the tracing libraries
+	injectLibPath = "/injectlib"
+	// injectConfigPath is the path of the webhook injecting the agent configuration
+	injectConfigPath = "/injectconfig"
+)
+
+var mutatedPods = telemetry.NewCounterWithOpts("", "admission_pods_mutated",
+	[]string{"webhook", "mutated", "error"}, "Counter of pods reviewed by the admission controller.",
+	telemetry.Options{NoDoubleUnderscoreSep: true})
+
+// patchOperation is a JSON patch operation, see https://tools.ietf.org/html/rfc6902
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Start registers the mutating webhook of the cluster agent in the apiserver and
+// serves it until the context is cancelled. The certificates of the webhook are
+// persisted in a secret, so that all the replicas share them, and rotated before
+// they expire. The /ready/admission readiness gate of the health port reports
+// whether the replica serves a valid certificate.
+func Start(ctx context.Context, client kubernetes.Interface) error {
+	certificates := newCertificateController(client, common.GetMyNamespace())
+	if err := certificates.check(); err != nil {
+		return fmt.Errorf("could not set up the certificate of the webhook: %v", err)
+	}
+	go certificates.run(ctx)
+	healthprobe.RegisterReadinessGate("admission", certificates.ready)
+	tlsConfig := &tls.Config{GetCertificate: certificates.getCertificate}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Datadog.GetInt("admission_controller.port")))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(injectLibPath, mutationHandler("inject_lib", func(pod *corev1.Pod) bool {
+		return injectLibs(pod, config.Datadog.GetString("admission_controller.inject_lib.container_registry"))
+	}))
+	mux.HandleFunc(injectConfigPath, mutationHandler("inject_config", injectConfig))
+	mux.HandleFunc(validatePath, handleValidation)
+	srv := &http.Server{
+		Handler: mux,
+		ErrorLog: stdLog.New(&config.ErrorLogWriter{
+			AdditionalDepth: 4, // Use a stack depth of 4 on top of the default one to get a relevant filename in the stdlib
+		}, "Error from the admission controller http server: ", 0), // log errors to seelog,
+		TLSConfig: tlsConfig,
+	}
+	go srv.Serve(tls.NewListener(listener, tlsConfig)) //nolint:errcheck
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background()) //nolint:errcheck
+	}()
+
+	log.Infof("Admission controller listening on %s", listener.Addr())
+	return nil
+}
+
+// mutationHandler returns the handler of a webhook reviewing the creation of the
+// pods, and mutating them with mutate
+func mutationHandler(webhook string, mutate func(*corev1.Pod) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleMutation(w, r, webhook, mutate)
+	}
+}
+
+func handleMutation(w http.ResponseWriter, r *http.Request, webhook string, mutate func(*corev1.Pod) bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		log.Warnf("Invalid admission review: %s", body)
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	response, err := mutatePod(review.Request, mutate)
+	if err != nil {
+		log.Warnf("Could not mutate the pod %s/%s: %v", review.Request.Namespace, review.Request.Name, err)
+		mutatedPods.Inc(webhook, "false", "true")
+		// the failure policy of the webhook decides whether the pod is created
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	mutatedPods.Inc(webhook, strconv.FormatBool(response.Patch != nil), "false")
+	response.UID = review.Request.UID
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review) //nolint:errcheck
+}
+
+// mutatePod returns the response allowing the pod of the request, with the
+// JSON patch of the mutation if mutate changed it
+func mutatePod(req *admissionv1beta1.AdmissionRequest, mutate func(*corev1.Pod) bool) (*admissionv1beta1.AdmissionResponse, error) {
+	pod := corev1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return nil, fmt.Errorf("could not decode the pod: %v", err)
+	}
+	if pod.Namespace == "" {
+		// the namespace isn't set yet when the pods are created by a controller
+		pod.Namespace = req.Namespace
+	}
+
+	response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if !mutate(&pod) {
+		return response, nil
+	}
+
+	// the mutated fields are replaced as a whole, the "add" operation replaces
+	// the existing values. The mutations only add items, the empty fields are unchanged.
+	operations := []patchOperation{{Op: "add", Path: "/spec/containers", Value: pod.Spec.Containers}}
+	if len(pod.Spec.InitContainers) > 0 {
+		operations = append(operations, patchOperation{Op: "add", Path: "/spec/initContainers", Value: pod.Spec.InitContainers})
+	}
+	if len(pod.Spec.Volumes) > 0 {
+		operations = append(operations, patchOperation{Op: "add", Path: "/spec/volumes", Value: pod.Spec.Volumes})
+	}
+	patch, err := json.Marshal(operations)
+	if err != nil {
+		return nil, err
+	}
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	response.Patch = patch
+	response.PatchType = &patchType
+	return response, nil
+}
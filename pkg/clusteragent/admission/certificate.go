@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	caCertSecretKey         = "ca.pem"
+	caKeySecretKey          = "ca-key.pem"
+	certSecretKey           = "cert.pem"
+	keySecretKey            = "key.pem"
+	previousCACertSecretKey = "ca-previous.pem"
+)
+
+// ensureCertificates returns the secret data holding the CA of the webhook and the
+// serving certificate it signed. They're generated and persisted in the secret when
+// it doesn't exist yet, and rotated when they expire within the expiration threshold:
+// the serving certificate is signed again by the same CA, so that the apiserver keeps
+// trusting the replicas that didn't reload it yet. The CA is only rotated when it expires,
+// the previous one is kept in the secret so that the CA bundle trusts both of them.
+// It requires get, create and update perms on secrets in the cluster-agent's namespace.
+func ensureCertificates(client corev1client.SecretsGetter, namespace string) (map[string][]byte, error) {
+	if err := validateCertificateSettings(); err != nil {
+		return nil, err
+	}
+	data, err := syncCertificates(client, namespace)
+	if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+		// another replica created or rotated them first, read them again
+		data, err = syncCertificates(client, namespace)
+	}
+	return data, err
+}
+
+// validateCertificateSettings checks that the certificates are valid longer than
+// the expiration threshold, otherwise they would be rotated at every check
+func validateCertificateSettings() error {
+	validityBound := config.Datadog.GetInt("admission_controller.certificate.validity_bound")
+	threshold := config.Datadog.GetInt("admission_controller.certificate.expiration_threshold")
+	if validityBound <= threshold {
+		return fmt.Errorf("admission_controller.certificate.validity_bound (%d) must be greater than admission_controller.certificate.expiration_threshold (%d)", validityBound, threshold)
+	}
+	return nil
+}
+
+// caBundle returns the CA bundle of the webhook: the CA of the secret data, and the
+// previous one until it expires so that the apiserver keeps trusting the serving
+// certificates it signed while the replicas reload the new one
+func caBundle(data map[string][]byte) []byte {
+	bundle := append([]byte{}, data[caCertSecretKey]...)
+	if previous, err := parseCertificate(data[previousCACertSecretKey]); err == nil && time.Now().Before(previous.NotAfter) {
+		bundle = append(bundle, data[previousCACertSecretKey]...)
+	}
+	return bundle
+}
+
+func syncCertificates(client corev1client.SecretsGetter, namespace string) (map[string][]byte, error) {
+	name := config.Datadog.GetString("admission_controller.certificate.secret_name")
+	secret, err := client.Secrets(namespace).Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{},
+		}
+		if err := rotateCertificates(secret.Data, namespace); err != nil {
+			return nil, err
+		}
+		if _, err := client.Secrets(namespace).Create(secret); err != nil {
+			return nil, err
+		}
+		log.Infof("Created the certificate of the webhook in the secret %s/%s", namespace, name)
+		return secret.Data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	rotate, err := needsRotation(secret.Data)
+	if err != nil {
+		log.Warnf("Invalid certificate in the secret %s/%s, generating a new one: %v", namespace, name, err)
+	}
+	if !rotate {
+		return secret.Data, nil
+	}
+
+	if err := rotateCertificates(secret.Data, namespace); err != nil {
+		return nil, err
+	}
+	if _, err := client.Secrets(namespace).Update(secret); err != nil {
+		return nil, err
+	}
+	log.Infof("Rotated the certificate of the webhook in the secret %s/%s", namespace, name)
+	return secret.Data, nil
+}
+
+// needsRotation returns whether the CA or the serving certificate of the secret
+// data expire within the expiration threshold
+func needsRotation(data map[string][]byte) (bool, error) {
+	threshold := time.Duration(config.Datadog.GetInt("admission_controller.certificate.expiration_threshold")) * time.Hour
+	for _, key := range []string{caCertSecretKey, certSecretKey} {
+		cert, err := parseCertificate(data[key])
+		if err != nil {
+			return true, fmt.Errorf("%s: %v", key, err)
+		}
+		if time.Until(cert.NotAfter) < threshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rotateCertificates generates a new serving certificate in the secret data, and a
+// new CA signing it when the current one is missing or expires within the expiration
+// threshold. The replaced CA is kept as the previous one while it's still valid.
+func rotateCertificates(data map[string][]byte, namespace string) error {
+	threshold := time.Duration(config.Datadog.GetInt("admission_controller.certificate.expiration_threshold")) * time.Hour
+
+	ca, caErr := parseCertificate(data[caCertSecretKey])
+	caKey, keyErr := parsePrivateKey(data[caKeySecretKey])
+	if caErr != nil || keyErr != nil || time.Until(ca.NotAfter) < threshold {
+		if caErr == nil && time.Now().Before(ca.NotAfter) {
+			data[previousCACertSecretKey] = data[caCertSecretKey]
+		} else {
+			delete(data, previousCACertSecretKey)
+		}
+		var caPEM []byte
+		var err error
+		ca, caPEM, caKey, err = security.GenerateRootCert(nil, 2048)
+		if err != nil {
+			return fmt.Errorf("could not generate the CA: %v", err)
+		}
+		data[caCertSecretKey] = caPEM
+		data[caKeySecretKey] = encodePrivateKey(caKey)
+	}
+
+	template, err := security.CertTemplate()
+	if err != nil {
+		return err
+	}
+	template.NotAfter = template.NotBefore.Add(time.Duration(config.Datadog.GetInt("admission_controller.certificate.validity_bound")) * time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	service := config.Datadog.GetString("admission_controller.service_name")
+	template.Subject.CommonName = service + "." + namespace + ".svc"
+	template.DNSNames = []string{service, service + "." + namespace, template.Subject.CommonName}
+
+	key, err := security.GenerateKeyPair(2048)
+	if err != nil {
+		return err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("could not sign the serving certificate: %v", err)
+	}
+	data[certSecretKey] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data[keySecretKey] = encodePrivateKey(key)
+	return nil
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(keyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func encodePrivateKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestEnsureCertificates(t *testing.T) {
+	mockConfig := config.Mock()
+	client := fake.NewSimpleClientset().CoreV1()
+
+	data, err := ensureCertificates(client, "default")
+	require.NoError(t, err)
+	ca, err := parseCertificate(data[caCertSecretKey])
+	require.NoError(t, err)
+	cert, err := parseCertificate(data[certSecretKey])
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "datadog-admission-controller.default.svc"})
+	assert.NoError(t, err)
+
+	// the certificates are read from the secret afterwards
+	secret, err := client.Secrets("default").Get("webhook-certificate", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, data, secret.Data)
+	read, err := ensureCertificates(client, "default")
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+
+	// the serving certificate is signed again by the same CA before it expires
+	mockConfig.Set("admission_controller.certificate.validity_bound", 2*365*24)
+	mockConfig.Set("admission_controller.certificate.expiration_threshold", 400*24)
+	rotated, err := ensureCertificates(client, "default")
+	require.NoError(t, err)
+	assert.NotEqual(t, data[certSecretKey], rotated[certSecretKey])
+	assert.Equal(t, data[caCertSecretKey], rotated[caCertSecretKey])
+	cert, err = parseCertificate(rotated[certSecretKey])
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "datadog-admission-controller.default.svc"})
+	assert.NoError(t, err)
+	secret, err = client.Secrets("default").Get("webhook-certificate", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, rotated, secret.Data)
+
+	assert.Equal(t, data[caCertSecretKey], caBundle(rotated))
+
+	// the CA is rotated when it expires, the bundle trusts both CAs meanwhile
+	mockConfig.Set("admission_controller.certificate.validity_bound", 21*365*24)
+	mockConfig.Set("admission_controller.certificate.expiration_threshold", 20*365*24)
+	rotated, err = ensureCertificates(client, "default")
+	require.NoError(t, err)
+	assert.NotEqual(t, data[caCertSecretKey], rotated[caCertSecretKey])
+	assert.Equal(t, data[caCertSecretKey], rotated[previousCACertSecretKey])
+	assert.Equal(t, append(append([]byte{}, rotated[caCertSecretKey]...), data[caCertSecretKey]...), caBundle(rotated))
+	roots = x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caBundle(rotated)))
+	cert, err = parseCertificate(rotated[certSecretKey])
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "datadog-admission-controller.default.svc"})
+	assert.NoError(t, err)
+}
+
+func TestEnsureCertificatesInvalidSettings(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.certificate.validity_bound", 24)
+	mockConfig.Set("admission_controller.certificate.expiration_threshold", 48)
+	client := fake.NewSimpleClientset().CoreV1()
+
+	_, err := ensureCertificates(client, "default")
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// certificateCheckPeriod is the period the certificates are checked, rotated and
+// reloaded at
+const certificateCheckPeriod = 10 * time.Minute
+
+// certificateController keeps the certificates of the webhook valid: it rotates
+// them before they expire, patches the CA bundle of the webhook configuration, and
+// reloads the serving certificate the other replicas rotated.
+type certificateController struct {
+	client    kubernetes.Interface
+	namespace string
+
+	m        sync.RWMutex
+	cert     *tls.Certificate
+	notAfter time.Time
+	// err is the error of the last check
+	err error
+}
+
+func newCertificateController(client kubernetes.Interface, namespace string) *certificateController {
+	return &certificateController{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// run checks the certificates periodically until the context is cancelled
+func (c *certificateController) run(ctx context.Context) {
+	healthHandle := health.Register("admission-certificate")
+	ticker := time.NewTicker(certificateCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			healthHandle.Deregister() //nolint:errcheck
+			return
+		case <-healthHandle.C:
+		case <-ticker.C:
+			if err := c.check(); err != nil {
+				log.Warnf("Could not check the certificate of the webhook, retrying in %s: %v", certificateCheckPeriod, err)
+			}
+		}
+	}
+}
+
+// check rotates the certificates if needed, updates the webhook configuration, and
+// reloads the serving certificate
+func (c *certificateController) check() error {
+	data, err := ensureCertificates(c.client.CoreV1(), c.namespace)
+	if err == nil {
+		err = reconcileWebhook(c.client.AdmissionregistrationV1beta1(), c.namespace, caBundle(data))
+	}
+	var cert tls.Certificate
+	if err == nil {
+		cert, err = tls.X509KeyPair(data[certSecretKey], data[keySecretKey])
+	}
+	var parsed *x509.Certificate
+	if err == nil {
+		parsed, err = x509.ParseCertificate(cert.Certificate[0])
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.err = err
+	if err == nil {
+		c.cert = &cert
+		c.notAfter = parsed.NotAfter
+	}
+	return err
+}
+
+// getCertificate returns the serving certificate, it's the GetCertificate
+// callback of the TLS configuration of the server
+func (c *certificateController) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if c.cert == nil {
+		return nil, errors.New("no certificate loaded yet")
+	}
+	return c.cert, nil
+}
+
+// ready returns an error when the last check failed, or when the served
+// certificate expired
+func (c *certificateController) ready() error {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if c.err != nil {
+		return fmt.Errorf("the last certificate check failed: %v", c.err)
+	}
+	if c.cert == nil {
+		return errors.New("no certificate loaded yet")
+	}
+	if time.Now().After(c.notAfter) {
+		return fmt.Errorf("the served certificate expired on %s", c.notAfter)
+	}
+	return nil
+}
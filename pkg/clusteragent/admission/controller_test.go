@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestCertificateController(t *testing.T) {
+	config.Mock()
+	client := fake.NewSimpleClientset()
+	c := newCertificateController(client, "default")
+	assert.Error(t, c.ready())
+
+	require.NoError(t, c.check())
+	assert.NoError(t, c.ready())
+	cert, err := c.getCertificate(nil)
+	require.NoError(t, err)
+	assert.NotNil(t, cert)
+
+	// the CA bundle of the webhook is patched
+	secret, err := client.CoreV1().Secrets("default").Get("webhook-certificate", metav1.GetOptions{})
+	require.NoError(t, err)
+	webhook, err := client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	for _, w := range webhook.Webhooks {
+		assert.Equal(t, secret.Data[caCertSecretKey], w.ClientConfig.CABundle)
+	}
+
+	// the certificate is still served when a check fails
+	client.PrependReactor("get", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	assert.Error(t, c.check())
+	assert.Error(t, c.ready())
+	served, err := c.getCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cert, served)
+}
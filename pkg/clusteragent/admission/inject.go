@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// libVersionAnnotationFormat is the pod annotation requesting the injection of
+	// the tracing library of a language, set to the version of the library
+	libVersionAnnotationFormat = "admission.datadoghq.com/%s-lib.version"
+
+	libVolumeName = "datadog-auto-instrumentation"
+	libMountPath  = "/datadog-lib"
+)
+
+// standardLabelsEnv maps the standard tagging labels of the pods to the
+// environment variables the tracing libraries read
+var standardLabelsEnv = map[string]string{
+	"tags.datadoghq.com/env":     "DD_ENV",
+	"tags.datadoghq.com/service": "DD_SERVICE",
+	"tags.datadoghq.com/version": "DD_VERSION",
+}
+
+// language describes how the tracing library of a language is loaded by the
+// application containers, once the init container copied it in the shared volume
+type language struct {
+	envName  string
+	envValue string
+	// envSeparator separates envValue from the value the user set
+	envSeparator string
+}
+
+var languages = map[string]language{
+	"java":   {"JAVA_TOOL_OPTIONS", "-javaagent:" + libMountPath + "/dd-java-agent.jar", " "},
+	"js":     {"NODE_OPTIONS", "--require=" + libMountPath + "/node_modules/dd-trace/init", " "},
+	"python": {"PYTHONPATH", libMountPath + "/", ":"},
+}
+
+// injectLibs adds to the pod the init containers copying the tracing libraries its
+// annotations request, and the environment variables loading them and setting the
+// standard tags. It returns whether the pod was mutated.
+func injectLibs(pod *corev1.Pod, registry string) bool {
+	var langs []string
+	for name := range languages {
+		if _, found := pod.Annotations[fmt.Sprintf(libVersionAnnotationFormat, name)]; found {
+			langs = append(langs, name)
+		}
+	}
+	if len(langs) == 0 {
+		return false
+	}
+	sort.Strings(langs)
+
+	for _, c := range pod.Spec.InitContainers {
+		if strings.HasPrefix(c.Name, "datadog-lib-") {
+			// the pod was already mutated, e.g. by a previous invocation of the webhook
+			return false
+		}
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name:         libVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	mount := corev1.VolumeMount{Name: libVolumeName, MountPath: libMountPath}
+
+	for _, name := range langs {
+		version := pod.Annotations[fmt.Sprintf(libVersionAnnotationFormat, name)]
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+			Name:         "datadog-lib-" + name + "-init",
+			Image:        fmt.Sprintf("%s/dd-lib-%s-init:%s", registry, name, version),
+			Command:      []string{"sh", "copy-lib.sh", libMountPath},
+			VolumeMounts: []corev1.VolumeMount{mount},
+		})
+	}
+
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		c.VolumeMounts = append(c.VolumeMounts, mount)
+		for _, name := range langs {
+			lang := languages[name]
+			injectEnv(c, lang.envName, lang.envValue, lang.envSeparator)
+		}
+		for _, label := range sortedKeys(standardLabelsEnv) {
+			if value, found := pod.Labels[label]; found {
+				injectEnv(c, standardLabelsEnv[label], value, "")
+			}
+		}
+	}
+
+	return true
+}
+
+// injectEnv sets an environment variable of the container. The variables the user
+// set take precedence, unless a separator is given: value is appended to the user
+// value then.
+func injectEnv(c *corev1.Container, name, value, separator string) {
+	for i, env := range c.Env {
+		if env.Name != name {
+			continue
+		}
+		if separator != "" && env.ValueFrom == nil {
+			c.Env[i].Value = env.Value + separator + value
+		}
+		return
+	}
+	c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: value})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// configOptInLabel is the label of the pods the agent configuration is injected into
+	configOptInLabel = "admission.datadoghq.com/config.enabled"
+	// configModeAnnotation overrides admission_controller.inject_config.mode for a pod
+	configModeAnnotation = "admission.datadoghq.com/config.mode"
+
+	hostIPMode  = "hostip"
+	serviceMode = "service"
+	socketMode  = "socket"
+
+	socketVolumePrefix = "datadog-socket-"
+)
+
+// injectConfig sets the environment variables the DogStatsD clients and the tracing
+// libraries read to reach the agent of the node, per the configuration mode of the
+// pod, and mounts the sockets of the agent in socket mode. It returns whether the pod
+// was mutated.
+func injectConfig(pod *corev1.Pod) bool {
+	// the apiservers before 1.15 ignore the object selector of the webhook, and send all the pods
+	if pod.Labels[configOptInLabel] != "true" {
+		return false
+	}
+
+	mode := config.Datadog.GetString("admission_controller.inject_config.mode")
+	if override, found := pod.Annotations[configModeAnnotation]; found {
+		mode = override
+	}
+
+	var env []corev1.EnvVar
+	var socketDirs []string
+	switch mode {
+	case hostIPMode:
+		env = []corev1.EnvVar{{
+			Name:      "DD_AGENT_HOST",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.hostIP"}},
+		}}
+	case serviceMode:
+		env = []corev1.EnvVar{{
+			Name:  "DD_AGENT_HOST",
+			Value: config.Datadog.GetString("admission_controller.inject_config.local_service_name") + "." + common.GetMyNamespace() + ".svc",
+		}}
+	case socketMode:
+		dsdSocket := config.Datadog.GetString("admission_controller.inject_config.dogstatsd_socket")
+		apmSocket := config.Datadog.GetString("admission_controller.inject_config.trace_agent_socket")
+		env = []corev1.EnvVar{
+			{Name: "DD_DOGSTATSD_URL", Value: "unix://" + dsdSocket},
+			{Name: "DD_TRACE_AGENT_URL", Value: "unix://" + apmSocket},
+		}
+		socketDirs = append(socketDirs, path.Dir(dsdSocket))
+		if dir := path.Dir(apmSocket); dir != socketDirs[0] {
+			socketDirs = append(socketDirs, dir)
+		}
+	default:
+		log.Warnf("Unknown configuration mode %q for the pod %s/%s, must be %s, %s or %s", mode, pod.Namespace, pod.Name, hostIPMode, serviceMode, socketMode)
+		return false
+	}
+	// the entity ID lets the agent tag the metrics and traces with the tags of the pod
+	env = append(env, corev1.EnvVar{
+		Name:      "DD_ENTITY_ID",
+		ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.uid"}},
+	})
+
+	for _, volume := range pod.Spec.Volumes {
+		if strings.HasPrefix(volume.Name, socketVolumePrefix) {
+			// the pod was already mutated, e.g. by a previous invocation of the webhook
+			return false
+		}
+	}
+
+	var mounts []corev1.VolumeMount
+	for i, dir := range socketDirs {
+		name := fmt.Sprintf("%s%d", socketVolumePrefix, i)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: dir}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: dir})
+	}
+
+	mutated := len(mounts) > 0
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		c.VolumeMounts = append(c.VolumeMounts, mounts...)
+		for _, e := range env {
+			if !hasEnv(c, e.Name) {
+				c.Env = append(c.Env, e)
+				mutated = true
+			}
+		}
+	}
+	return mutated
+}
+
+// hasEnv returns whether the container sets an environment variable, the
+// variables the user set take precedence over the injected ones
+func hasEnv(c *corev1.Container, name string) bool {
+	for _, env := range c.Env {
+		if env.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestInjectConfig(t *testing.T) {
+	mockConfig := config.Mock()
+	newPod := func(annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{configOptInLabel: "true"},
+				Annotations: annotations,
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Env:  []corev1.EnvVar{{Name: "DD_ENTITY_ID", Value: "custom"}},
+			}}},
+		}
+	}
+
+	// hostip is the default mode
+	pod := newPod(nil)
+	assert.True(t, injectConfig(pod))
+	require.Len(t, pod.Spec.Containers[0].Env, 2)
+	assert.Equal(t, "DD_AGENT_HOST", pod.Spec.Containers[0].Env[1].Name)
+	assert.Equal(t, "status.hostIP", pod.Spec.Containers[0].Env[1].ValueFrom.FieldRef.FieldPath)
+	assert.Empty(t, pod.Spec.Volumes)
+
+	// the pods are mutated once
+	assert.False(t, injectConfig(pod))
+
+	// the mode can be overridden per pod
+	pod = newPod(map[string]string{configModeAnnotation: socketMode})
+	mockConfig.Set("admission_controller.inject_config.trace_agent_socket", "/var/run/datadog-apm/apm.socket")
+	assert.True(t, injectConfig(pod))
+	assert.Equal(t, []corev1.Volume{
+		{Name: "datadog-socket-0", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/datadog"}}},
+		{Name: "datadog-socket-1", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/datadog-apm"}}},
+	}, pod.Spec.Volumes)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "datadog-socket-0", MountPath: "/var/run/datadog"},
+		{Name: "datadog-socket-1", MountPath: "/var/run/datadog-apm"},
+	}, pod.Spec.Containers[0].VolumeMounts)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "DD_ENTITY_ID", Value: "custom"},
+		{Name: "DD_DOGSTATSD_URL", Value: "unix:///var/run/datadog/dsd.socket"},
+		{Name: "DD_TRACE_AGENT_URL", Value: "unix:///var/run/datadog-apm/apm.socket"},
+	}, pod.Spec.Containers[0].Env)
+	assert.False(t, injectConfig(pod))
+
+	mockConfig.Set("admission_controller.inject_config.mode", serviceMode)
+	pod = newPod(nil)
+	assert.True(t, injectConfig(pod))
+	assert.Equal(t, corev1.EnvVar{Name: "DD_AGENT_HOST", Value: "datadog.default.svc"}, pod.Spec.Containers[0].Env[1])
+
+	// the unknown modes are ignored
+	assert.False(t, injectConfig(newPod(map[string]string{configModeAnnotation: "udp"})))
+
+	// the pods not opting in are ignored
+	pod = newPod(nil)
+	delete(pod.Labels, configOptInLabel)
+	assert.False(t, injectConfig(pod))
+	assert.Len(t, pod.Spec.Containers[0].Env, 1)
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInjectLibs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"admission.datadoghq.com/java-lib.version": "v0.60.0"},
+			Labels: map[string]string{
+				"tags.datadoghq.com/env":     "prod",
+				"tags.datadoghq.com/service": "billing",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Env: []corev1.EnvVar{
+					{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx1g"},
+					{Name: "DD_SERVICE", Value: "billing-api"},
+				},
+			}},
+		},
+	}
+
+	assert.True(t, injectLibs(pod, "gcr.io/datadoghq"))
+	require.Len(t, pod.Spec.InitContainers, 1)
+	assert.Equal(t, "gcr.io/datadoghq/dd-lib-java-init:v0.60.0", pod.Spec.InitContainers[0].Image)
+	require.Len(t, pod.Spec.Volumes, 1)
+	assert.NotNil(t, pod.Spec.Volumes[0].EmptyDir)
+	assert.Equal(t, []corev1.VolumeMount{{Name: libVolumeName, MountPath: libMountPath}}, pod.Spec.Containers[0].VolumeMounts)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx1g -javaagent:/datadog-lib/dd-java-agent.jar"},
+		{Name: "DD_SERVICE", Value: "billing-api"},
+		{Name: "DD_ENV", Value: "prod"},
+	}, pod.Spec.Containers[0].Env)
+
+	// the pods are mutated once
+	assert.False(t, injectLibs(pod, "gcr.io/datadoghq"))
+	// the pods without annotation are not mutated
+	assert.False(t, injectLibs(&corev1.Pod{}, "gcr.io/datadoghq"))
+}
+
+func TestMutationHandler(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"admission.datadoghq.com/python-lib.version": "v0.40.0"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+	}
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{UID: "123", Object: runtime.RawExtension{Raw: raw}},
+	})
+	require.NoError(t, err)
+
+	handler := mutationHandler("inject_lib", func(pod *corev1.Pod) bool {
+		return injectLibs(pod, "gcr.io/datadoghq")
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", injectLibPath, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	review := admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+	require.NotNil(t, review.Response)
+	assert.Equal(t, "123", string(review.Response.UID))
+	assert.True(t, review.Response.Allowed)
+	require.NotNil(t, review.Response.PatchType)
+	assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *review.Response.PatchType)
+
+	var patch []patchOperation
+	require.NoError(t, json.Unmarshal(review.Response.Patch, &patch))
+	require.Len(t, patch, 3)
+	assert.Equal(t, "/spec/initContainers", patch[1].Path)
+	assert.Contains(t, string(review.Response.Patch), "gcr.io/datadoghq/dd-lib-python-init:v0.40.0")
+	assert.Contains(t, string(review.Response.Patch), `"PYTHONPATH","value":"/datadog-lib/"`)
+
+	// invalid reviews are rejected
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", injectLibPath, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// validatePath is the path of the webhook validating the autodiscovery
+// annotations and the DatadogChecks
+const validatePath = "/validate"
+
+var validatedObjects = telemetry.NewCounterWithOpts("", "admission_objects_validated",
+	[]string{"kind", "allowed", "error"}, "Counter of objects reviewed by the validation webhook.",
+	telemetry.Options{NoDoubleUnderscoreSep: true})
+
+// handleValidation reviews the creation and the update of the pods, the services,
+// the workloads and the DatadogChecks, and rejects them when their checks or logs
+// configurations are invalid, instead of letting the agents ignore them
+func handleValidation(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		log.Warnf("Invalid admission review: %s", body)
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	issues, err := validateObject(review.Request)
+	if err != nil {
+		log.Warnf("Could not validate the %s %s/%s: %v", review.Request.Kind.Kind, review.Request.Namespace, review.Request.Name, err)
+		validatedObjects.Inc(review.Request.Kind.Kind, "false", "true")
+		// the failure policy of the webhook decides whether the object is admitted
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := &admissionv1beta1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	if len(issues) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: "invalid Datadog configuration: " + strings.Join(issues, "; "),
+		}
+	}
+	validatedObjects.Inc(review.Request.Kind.Kind, strconv.FormatBool(response.Allowed), "false")
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review) //nolint:errcheck
+}
+
+// validateObject returns the issues of the checks and logs configurations of
+// the object of the request
+func validateObject(req *admissionv1beta1.AdmissionRequest) ([]string, error) {
+	var issues []config.ValidationIssue
+	switch req.Kind.Kind {
+	case "Pod":
+		pod := corev1.Pod{}
+		if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+			return nil, fmt.Errorf("could not decode the pod: %v", err)
+		}
+		if annotationsUnchanged(req, pod.Annotations) {
+			return nil, nil
+		}
+		issues = providers.ValidateADAnnotations("pod:"+req.Namespace+"/"+req.Name, pod.Annotations, containerNames(pod.Spec))
+	case "Deployment", "DaemonSet", "StatefulSet":
+		// the pod templates are validated so that the issues are returned when the
+		// workload is applied, and not when its controller creates the pods
+		obj := workload{}
+		if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+			return nil, fmt.Errorf("could not decode the %s: %v", req.Kind.Kind, err)
+		}
+		if templateAnnotationsUnchanged(req, obj.Spec.Template.Annotations) {
+			return nil, nil
+		}
+		source := strings.ToLower(req.Kind.Kind) + ":" + req.Namespace + "/" + req.Name
+		issues = providers.ValidateADAnnotations(source, obj.Spec.Template.Annotations, containerNames(obj.Spec.Template.Spec))
+	case "Service":
+		svc := corev1.Service{}
+		if err := json.Unmarshal(req.Object.Raw, &svc); err != nil {
+			return nil, fmt.Errorf("could not decode the service: %v", err)
+		}
+		if annotationsUnchanged(req, svc.Annotations) {
+			return nil, nil
+		}
+		issues = providers.ValidateADAnnotations("service:"+req.Namespace+"/"+req.Name, svc.Annotations, []string{"service", "endpoints"})
+	case "DatadogCheck":
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+			return nil, fmt.Errorf("could not decode the DatadogCheck: %v", err)
+		}
+		issues = providers.ValidateDatadogCheck(&unstructured.Unstructured{Object: obj})
+	default:
+		return nil, nil
+	}
+
+	var messages []string
+	for _, issue := range issues {
+		message := issue.Message
+		if issue.Key != "" {
+			message = fmt.Sprintf("%s: %s", issue.Key, issue.Message)
+		}
+		if issue.Severity != config.ValidationError {
+			log.Infof("The %s %s/%s is admitted with a warning: %s", req.Kind.Kind, req.Namespace, req.Name, message)
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// workload holds the pod template of the Deployments, the DaemonSets and the StatefulSets
+type workload struct {
+	Spec struct {
+		Template corev1.PodTemplateSpec `json:"template"`
+	} `json:"spec"`
+}
+
+// containerNames returns the names of the containers of the pod spec, the
+// autodiscovery annotations can be scoped to
+func containerNames(spec corev1.PodSpec) []string {
+	var names []string
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// templateAnnotationsUnchanged returns whether the request updates a workload
+// without changing the annotations of its pod template
+func templateAnnotationsUnchanged(req *admissionv1beta1.AdmissionRequest, annotations map[string]string) bool {
+	if req.Operation != admissionv1beta1.Update {
+		return false
+	}
+	old := workload{}
+	if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(old.Spec.Template.Annotations, annotations)
+}
+
+// annotationsUnchanged returns whether the request updates an object without
+// changing its annotations, so that the objects created before the webhook was
+// enabled can still be updated, e.g. by their controllers
+func annotationsUnchanged(req *admissionv1beta1.AdmissionRequest, annotations map[string]string) bool {
+	if req.Operation != admissionv1beta1.Update {
+		return false
+	}
+	old := struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(old.Metadata.Annotations, annotations)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandleValidation(t *testing.T) {
+	review := func(kind string, operation admissionv1beta1.Operation, object, oldObject interface{}) *admissionv1beta1.AdmissionResponse {
+		raw, err := json.Marshal(object)
+		require.NoError(t, err)
+		oldRaw, err := json.Marshal(oldObject)
+		require.NoError(t, err)
+		body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+			Request: &admissionv1beta1.AdmissionRequest{
+				UID:       "123",
+				Kind:      metav1.GroupVersionKind{Kind: kind},
+				Namespace: "default",
+				Name:      "redis",
+				Operation: operation,
+				Object:    runtime.RawExtension{Raw: raw},
+				OldObject: runtime.RawExtension{Raw: oldRaw},
+			},
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		handleValidation(w, httptest.NewRequest("POST", validatePath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		response := admissionv1beta1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Response)
+		assert.Equal(t, "123", string(response.Response.UID))
+		return response.Response
+	}
+
+	invalidPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			"ad.datadoghq.com/redis.check_names":  `["redisdb"]`,
+			"ad.datadoghq.com/redis.init_configs": `[{}]`,
+			"ad.datadoghq.com/redis.instances":    `[{"host": "%%host%%", "tags": "env:prod"}]`,
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "redis"}}},
+	}
+	response := review("Pod", admissionv1beta1.Create, invalidPod, nil)
+	assert.False(t, response.Allowed)
+	require.NotNil(t, response.Result)
+	assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+	assert.Contains(t, response.Result.Message, "ad.datadoghq.com/redis.instances[0].tags: expected type list")
+
+	// the updates not changing the annotations are allowed
+	assert.True(t, review("Pod", admissionv1beta1.Update, invalidPod, invalidPod).Allowed)
+
+	validPod := *invalidPod.DeepCopy()
+	validPod.Annotations["ad.datadoghq.com/redis.instances"] = `[{"host": "%%host%%", "tags": ["env:prod"]}]`
+	assert.True(t, review("Pod", admissionv1beta1.Create, validPod, nil).Allowed)
+
+	// the annotations scoped to unknown containers are only warned about
+	unknownContainer := *validPod.DeepCopy()
+	unknownContainer.Annotations["ad.datadoghq.com/cache.instances"] = `[{}]`
+	assert.True(t, review("Pod", admissionv1beta1.Create, unknownContainer, nil).Allowed)
+
+	// the pod templates of the workloads are validated
+	deployment := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]interface{}{
+			"template": corev1.PodTemplateSpec{ObjectMeta: invalidPod.ObjectMeta, Spec: invalidPod.Spec},
+		},
+	}
+	response = review("Deployment", admissionv1beta1.Create, deployment, nil)
+	assert.False(t, response.Allowed)
+	assert.Contains(t, response.Result.Message, "ad.datadoghq.com/redis.instances[0].tags: expected type list")
+	assert.True(t, review("Deployment", admissionv1beta1.Update, deployment, deployment).Allowed)
+	assert.True(t, review("StatefulSet", admissionv1beta1.Create, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": corev1.PodTemplateSpec{ObjectMeta: validPod.ObjectMeta, Spec: validPod.Spec},
+		},
+	}, nil).Allowed)
+
+	// the annotations of the services are scoped to service and endpoints
+	response = review("Service", admissionv1beta1.Create, corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			"ad.datadoghq.com/redis.instances": `[{}]`,
+		}},
+	}, nil)
+	assert.False(t, response.Allowed)
+
+	response = review("DatadogCheck", admissionv1beta1.Create, map[string]interface{}{
+		"apiVersion": "datadoghq.com/v1alpha1",
+		"kind":       "DatadogCheck",
+		"spec":       map[string]interface{}{"name": "redisdb"},
+	}, nil)
+	assert.False(t, response.Allowed)
+	assert.Contains(t, response.Result.Message, "spec.instances must hold at least one instance")
+
+	// the other kinds are allowed
+	assert.True(t, review("ConfigMap", admissionv1beta1.Create, map[string]interface{}{}, nil).Allowed)
+}
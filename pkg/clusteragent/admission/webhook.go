@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"fmt"
+
+	admiregv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	admiregclient "k8s.io/client-go/kubernetes/typed/admissionregistration/v1beta1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// namespaceOptInLabel is the label of the namespaces whose pods are reviewed by the webhook
+	namespaceOptInLabel = "admission.datadoghq.com/enabled"
+	// namespaceNameLabel is the label holding the name of the namespaces
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// reconcileWebhook creates the mutating webhook configuration of the cluster agent,
+// or updates it when the CA bundle or the settings changed, and does the same for
+// the validating webhook configuration when the validation is enabled, or deletes it.
+// It requires get, create and update perms on mutatingwebhookconfigurations, and
+// get, create, update and delete perms on validatingwebhookconfigurations.
+func reconcileWebhook(client admiregclient.AdmissionregistrationV1beta1Interface, namespace string, caBundle []byte) error {
+	webhook, err := buildWebhook(namespace, caBundle)
+	if err != nil {
+		return err
+	}
+
+	current, err := client.MutatingWebhookConfigurations().Get(webhook.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.MutatingWebhookConfigurations().Create(webhook)
+		if err == nil {
+			log.Infof("Created the mutating webhook configuration %s", webhook.Name)
+		}
+	} else if err == nil && !equality.Semantic.DeepDerivative(webhook.Webhooks, current.Webhooks) {
+		current.Webhooks = webhook.Webhooks
+		_, err = client.MutatingWebhookConfigurations().Update(current)
+		if err == nil {
+			log.Infof("Updated the mutating webhook configuration %s", webhook.Name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return reconcileValidatingWebhook(client, namespace, caBundle)
+}
+
+func reconcileValidatingWebhook(client admiregclient.ValidatingWebhookConfigurationsGetter, namespace string, caBundle []byte) error {
+	webhook, err := buildValidatingWebhook(namespace, caBundle)
+	if err != nil {
+		return err
+	}
+
+	if !config.Datadog.GetBool("admission_controller.validation.enabled") {
+		err := client.ValidatingWebhookConfigurations().Delete(webhook.Name, &metav1.DeleteOptions{})
+		if err == nil {
+			log.Infof("Deleted the validating webhook configuration %s", webhook.Name)
+		}
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	current, err := client.ValidatingWebhookConfigurations().Get(webhook.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.ValidatingWebhookConfigurations().Create(webhook)
+		if err == nil {
+			log.Infof("Created the validating webhook configuration %s", webhook.Name)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// the fields defaulted by the apiserver are ignored in the comparison
+	if equality.Semantic.DeepDerivative(webhook.Webhooks, current.Webhooks) {
+		return nil
+	}
+	current.Webhooks = webhook.Webhooks
+	_, err = client.ValidatingWebhookConfigurations().Update(current)
+	if err == nil {
+		log.Infof("Updated the validating webhook configuration %s", webhook.Name)
+	}
+	return err
+}
+
+func buildWebhook(namespace string, caBundle []byte) (*admiregv1beta1.MutatingWebhookConfiguration, error) {
+	failurePolicy, err := getFailurePolicy()
+	if err != nil {
+		return nil, err
+	}
+	sideEffects := admiregv1beta1.SideEffectClassNone
+	webhook := func(name, path string) admiregv1beta1.MutatingWebhook {
+		return admiregv1beta1.MutatingWebhook{
+			Name:          name,
+			ClientConfig:  clientConfig(namespace, path, caBundle),
+			Rules:         rules([]admiregv1beta1.OperationType{admiregv1beta1.Create}, "", "v1", "pods"),
+			FailurePolicy: &failurePolicy,
+			SideEffects:   &sideEffects,
+		}
+	}
+
+	// the tracing libraries are only injected in the namespaces opting in
+	libWebhook := webhook("lib-injection.admission.datadoghq.com", injectLibPath)
+	libWebhook.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{namespaceOptInLabel: "true"},
+	}
+	// the agent configuration is injected in the pods opting in
+	configWebhook := webhook("config-injection.admission.datadoghq.com", injectConfigPath)
+	configWebhook.ObjectSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{configOptInLabel: "true"},
+	}
+
+	return &admiregv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: config.Datadog.GetString("admission_controller.webhook_name")},
+		Webhooks:   []admiregv1beta1.MutatingWebhook{libWebhook, configWebhook},
+	}, nil
+}
+
+func buildValidatingWebhook(namespace string, caBundle []byte) (*admiregv1beta1.ValidatingWebhookConfiguration, error) {
+	failurePolicy, err := getFailurePolicy()
+	if err != nil {
+		return nil, err
+	}
+	sideEffects := admiregv1beta1.SideEffectClassNone
+	operations := []admiregv1beta1.OperationType{admiregv1beta1.Create, admiregv1beta1.Update}
+	objectRules := append(rules(operations, "", "v1", "pods", "services"), rules(operations, "apps", "v1", "deployments", "daemonsets", "statefulsets")...)
+	objectRules = append(objectRules, rules(operations, "datadoghq.com", "v1alpha1", "datadogchecks")...)
+
+	return &admiregv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: config.Datadog.GetString("admission_controller.webhook_name")},
+		Webhooks: []admiregv1beta1.ValidatingWebhook{
+			{
+				Name:          "validation.admission.datadoghq.com",
+				ClientConfig:  clientConfig(namespace, validatePath, caBundle),
+				Rules:         objectRules,
+				FailurePolicy: &failurePolicy,
+				SideEffects:   &sideEffects,
+				// an outage of the webhook mustn't block the recovery of the cluster or of the
+				// cluster agent itself. The label is set by the apiserver since Kubernetes 1.21.
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      namespaceNameLabel,
+							Operator: metav1.LabelSelectorOpNotIn,
+							Values:   []string{"kube-system", namespace},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func getFailurePolicy() (admiregv1beta1.FailurePolicyType, error) {
+	switch policy := config.Datadog.GetString("admission_controller.failure_policy"); policy {
+	case string(admiregv1beta1.Ignore), string(admiregv1beta1.Fail):
+		return admiregv1beta1.FailurePolicyType(policy), nil
+	default:
+		return "", fmt.Errorf("invalid admission_controller.failure_policy %q, must be Ignore or Fail", policy)
+	}
+}
+
+func clientConfig(namespace, path string, caBundle []byte) admiregv1beta1.WebhookClientConfig {
+	return admiregv1beta1.WebhookClientConfig{
+		Service: &admiregv1beta1.ServiceReference{
+			Namespace: namespace,
+			Name:      config.Datadog.GetString("admission_controller.service_name"),
+			Path:      &path,
+		},
+		CABundle: caBundle,
+	}
+}
+
+func rules(operations []admiregv1beta1.OperationType, group, version string, resources ...string) []admiregv1beta1.RuleWithOperations {
+	return []admiregv1beta1.RuleWithOperations{
+		{
+			Operations: operations,
+			Rule: admiregv1beta1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{version},
+				Resources:   resources,
+			},
+		},
+	}
+}
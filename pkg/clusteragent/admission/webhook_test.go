@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admiregv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	fakeadmiregv1beta1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1beta1/fake"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestReconcileWebhook(t *testing.T) {
+	mockConfig := config.Mock()
+	client := fake.NewSimpleClientset().AdmissionregistrationV1beta1()
+
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	webhook, err := client.MutatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, webhook.Webhooks, 2)
+	assert.Equal(t, "datadog", webhook.Webhooks[0].ClientConfig.Service.Namespace)
+	assert.Equal(t, "datadog-admission-controller", webhook.Webhooks[0].ClientConfig.Service.Name)
+	assert.Equal(t, []byte("ca"), webhook.Webhooks[0].ClientConfig.CABundle)
+	assert.Equal(t, admiregv1beta1.Ignore, *webhook.Webhooks[0].FailurePolicy)
+	assert.Equal(t, map[string]string{namespaceOptInLabel: "true"}, webhook.Webhooks[0].NamespaceSelector.MatchLabels)
+	assert.Equal(t, injectConfigPath, *webhook.Webhooks[1].ClientConfig.Service.Path)
+	assert.Equal(t, map[string]string{configOptInLabel: "true"}, webhook.Webhooks[1].ObjectSelector.MatchLabels)
+
+	// the configuration isn't updated when nothing changed
+	fakeClient := client.(*fakeadmiregv1beta1.FakeAdmissionregistrationV1beta1)
+	fakeClient.ClearActions()
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	for _, action := range fakeClient.Actions() {
+		assert.NotEqual(t, "update", action.GetVerb())
+	}
+
+	// the existing configuration is updated
+	mockConfig.Set("admission_controller.failure_policy", "Fail")
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	webhook, err = client.MutatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, admiregv1beta1.Fail, *webhook.Webhooks[0].FailurePolicy)
+	assert.Equal(t, admiregv1beta1.Fail, *webhook.Webhooks[1].FailurePolicy)
+
+	// the validating webhook is registered when the validation is enabled, and
+	// deleted when it's disabled
+	_, err = client.ValidatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	mockConfig.Set("admission_controller.validation.enabled", true)
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	validating, err := client.ValidatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, validating.Webhooks, 1)
+	assert.Equal(t, validatePath, *validating.Webhooks[0].ClientConfig.Service.Path)
+	assert.Equal(t, []byte("ca"), validating.Webhooks[0].ClientConfig.CABundle)
+	require.Len(t, validating.Webhooks[0].NamespaceSelector.MatchExpressions, 1)
+	assert.Equal(t, []string{"kube-system", "datadog"}, validating.Webhooks[0].NamespaceSelector.MatchExpressions[0].Values)
+	assert.Equal(t, metav1.LabelSelectorOpNotIn, validating.Webhooks[0].NamespaceSelector.MatchExpressions[0].Operator)
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	mockConfig.Set("admission_controller.validation.enabled", false)
+	require.NoError(t, reconcileWebhook(client, "datadog", []byte("ca")))
+	_, err = client.ValidatingWebhookConfigurations().Get("datadog-webhook", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	mockConfig.Set("admission_controller.failure_policy", "Retry")
+	assert.Error(t, reconcileWebhook(client, "datadog", []byte("ca")))
+}
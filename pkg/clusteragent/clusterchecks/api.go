@@ -66,9 +66,19 @@ func (h *Handler) PostStatus(nodeName, clientIP string, status types.NodeStatus)
 	response := types.StatusResponse{
 		IsUpToDate: upToDate,
 	}
+	if err == nil {
+		response.Flare = h.dispatcher.popNodeFlare(nodeName)
+	}
 	return response, err
 }
 
+// RequestNodeFlare queues a flare request for a given node, it's sent to the
+// node agent with the response to its next status report. It returns false
+// if the node is unknown.
+func (h *Handler) RequestNodeFlare(nodeName string, request types.FlareRequest) bool {
+	return h.dispatcher.requestNodeFlare(nodeName, request)
+}
+
 // GetEndpointsConfigs returns endpoints configurations dispatched to a given node
 func (h *Handler) GetEndpointsConfigs(nodeName string) (types.ConfigResponse, error) {
 	configs, err := h.dispatcher.getEndpointsConfigs(nodeName)
@@ -31,8 +31,9 @@ func (d *dispatcher) getState() (types.StateResponse, error) {
 	}
 	for _, node := range d.store.nodes {
 		n := types.StateNodeResponse{
-			Name:    node.name,
-			Configs: makeConfigArray(node.digestToConfig),
+			Name:     node.name,
+			Identity: node.identity,
+			Configs:  makeConfigArray(node.digestToConfig),
 		}
 		response.Nodes = append(response.Nodes, n)
 	}
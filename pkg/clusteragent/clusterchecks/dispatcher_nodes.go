@@ -39,6 +39,10 @@ func (d *dispatcher) getNodeConfigs(nodeName string) ([]integration.Config, int6
 func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.NodeStatus) (bool, error) {
 	var warmingUp bool
 
+	if status.Identity != nil && status.Identity.Type != types.NodeIdentityHostname && status.Identity.Type != types.NodeIdentityBOSH {
+		return false, fmt.Errorf("unknown identity type %q for node %s", status.Identity.Type, nodeName)
+	}
+
 	d.store.Lock()
 	if !d.store.active {
 		warmingUp = true
@@ -50,6 +54,9 @@ func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.N
 	defer node.Unlock()
 	node.lastStatus = status
 	node.heartbeat = timestampNow()
+	if status.Identity != nil {
+		node.identity = status.Identity
+	}
 
 	if node.lastConfigChange == status.LastChange {
 		// Node-agent is up to date
@@ -67,9 +74,48 @@ func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.N
 	return false, nil
 }
 
+// requestNodeFlare keeps a flare request for the node until its next status
+// report, a newer request replaces the pending one
+func (d *dispatcher) requestNodeFlare(nodeName string, request types.FlareRequest) bool {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	node, found := d.store.getNodeStore(nodeName)
+	if !found {
+		return false
+	}
+
+	node.Lock()
+	defer node.Unlock()
+	node.pendingFlare = &request
+	log.Infof("Flare requested for node %s, it will be sent with its next status report", nodeName)
+	return true
+}
+
+// popNodeFlare returns the flare request pending for the node, if any, and
+// removes it so that it's only sent once
+func (d *dispatcher) popNodeFlare(nodeName string) *types.FlareRequest {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	node, found := d.store.getNodeStore(nodeName)
+	if !found {
+		return nil
+	}
+
+	node.Lock()
+	defer node.Unlock()
+	request := node.pendingFlare
+	node.pendingFlare = nil
+	return request
+}
+
 // getLeastBusyNode returns the name of the node that is assigned
 // the lowest number of checks. In case of equality, one is chosen
 // randomly, based on map iterations being randomized.
+// When the nodes span several availability zones, the node is chosen
+// in the zone running the fewest checks per node, so that the checks
+// keep running if a zone goes down.
 func (d *dispatcher) getLeastBusyNode() string {
 	var leastBusyNode string
 	minCheckCount := int(-1)
@@ -78,10 +124,15 @@ func (d *dispatcher) getLeastBusyNode() string {
 	d.store.RLock()
 	defer d.store.RUnlock()
 
+	zone, zoneAware := d.getLeastBusyZone()
+
 	for name, store := range d.store.nodes {
 		if name == "" {
 			continue
 		}
+		if zoneAware && store.zone() != zone {
+			continue
+		}
 		if d.advancedDispatching && store.busyness > defaultBusynessValue {
 			// dispatching based on clc runners stats
 			// only when advancedDispatching is true and
@@ -101,6 +152,48 @@ func (d *dispatcher) getLeastBusyNode() string {
 	return leastBusyNode
 }
 
+// getLeastBusyZone returns the availability zone running the fewest checks
+// per node, and false if the nodes don't span several zones. The nodes
+// without a zone are counted in the "" zone.
+// The store must be locked by the caller.
+func (d *dispatcher) getLeastBusyZone() (string, bool) {
+	nodeCounts := make(map[string]int)
+	checkCounts := make(map[string]int)
+	for name, node := range d.store.nodes {
+		if name == "" {
+			continue
+		}
+		nodeCounts[node.zone()]++
+		checkCounts[node.zone()] += len(node.digestToConfig)
+	}
+	if len(nodeCounts) < 2 {
+		return "", false
+	}
+
+	var leastBusyZone string
+	for i, zone := range orderedKeys(nodeCounts) {
+		// compare checkCounts[zone]/nodeCounts[zone] without rounding
+		if i == 0 || checkCounts[zone]*nodeCounts[leastBusyZone] < checkCounts[leastBusyZone]*nodeCounts[zone] {
+			leastBusyZone = zone
+		}
+	}
+	return leastBusyZone, true
+}
+
+// getNodeZones returns the availability zone of each node
+func (d *dispatcher) getNodeZones() map[string]string {
+	d.store.RLock()
+	defer d.store.RUnlock()
+
+	zones := make(map[string]string, len(d.store.nodes))
+	for name, node := range d.store.nodes {
+		node.RLock()
+		zones[name] = node.zone()
+		node.RUnlock()
+	}
+	return zones
+}
+
 // expireNodes iterates over nodes and removes the ones that have not
 // reported for more than the expiration duration. The configurations
 // dispatched to these nodes will be moved to the danglingConfigs map.
@@ -126,6 +126,17 @@ func pickNode(diffMap map[string]int, sourceNode string) string {
 	return pickedNode
 }
 
+// sameZone filters a diff map on the nodes in the same availability zone as a node
+func sameZone(diffMap map[string]int, zones map[string]string, nodeName string) map[string]int {
+	filtered := make(map[string]int, len(diffMap))
+	for node, diff := range diffMap {
+		if zones[node] == zones[nodeName] {
+			filtered[node] = diff
+		}
+	}
+	return filtered
+}
+
 // moveCheck moves a check by its ID from a node to another
 func (d *dispatcher) moveCheck(src, dest, checkID string) error {
 	log.Debugf("Moving %s from %s to %s", checkID, src, dest)
@@ -175,6 +186,7 @@ func (d *dispatcher) rebalance() {
 		return
 	}
 	diffMap, weights := d.getDiffAndWeights(totalAvg)
+	zones := d.getNodeZones()
 	sort.Sort(weights)
 	for _, nodeWeight := range weights {
 		for diffMap[nodeWeight.nodeName] > 0 {
@@ -186,7 +198,12 @@ func (d *dispatcher) rebalance() {
 				break
 			}
 
-			pickedNodeName := pickNode(diffMap, sourceNodeName)
+			// checks are only moved within the zone of their node to keep
+			// the spread across availability zones made when dispatching
+			pickedNodeName := pickNode(sameZone(diffMap, zones, sourceNodeName), sourceNodeName)
+			if pickedNodeName == "" {
+				break
+			}
 			if diffMap[pickedNodeName]+checkWeight < int(float64(diffMap[sourceNodeName])*tolerationMargin) {
 				// move a check to a new node only if it keeps the busyness of the new node
 				// lower than the original node's busyness multiplied by the tolerationMargin value
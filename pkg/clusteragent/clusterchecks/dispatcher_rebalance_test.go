@@ -1404,3 +1404,13 @@ func TestMoveCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestSameZone(t *testing.T) {
+	diffMap := map[string]int{"a1": 10, "a2": -5, "b1": -10, "c1": 0}
+	zones := map[string]string{"a1": "z1", "a2": "z1", "b1": "z2", "c1": ""}
+
+	assert.Equal(t, map[string]int{"a1": 10, "a2": -5}, sameZone(diffMap, zones, "a1"))
+	assert.Equal(t, "a2", pickNode(sameZone(diffMap, zones, "a1"), "a1"))
+	assert.Equal(t, map[string]int{"c1": 0}, sameZone(diffMap, zones, "c1"))
+	assert.Equal(t, "", pickNode(sameZone(diffMap, zones, "c1"), "c1"))
+}
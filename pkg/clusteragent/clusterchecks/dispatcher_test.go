@@ -261,6 +261,83 @@ func TestGetLeastBusyNode(t *testing.T) {
 	requireNotLocked(t, dispatcher.store)
 }
 
+func TestProcessNodeStatusIdentity(t *testing.T) {
+	dispatcher := newDispatcher()
+	identity := &types.NodeIdentity{
+		Type:       types.NodeIdentityBOSH,
+		Deployment: "datadog",
+		Job:        "clc-runner",
+		InstanceID: "6d0b6e5c",
+		Zone:       "z1",
+	}
+
+	_, err := dispatcher.processNodeStatus("datadog.clc-runner.6d0b6e5c", "10.0.0.1", types.NodeStatus{Identity: identity})
+	assert.NoError(t, err)
+	node, found := dispatcher.store.getNodeStore("datadog.clc-runner.6d0b6e5c")
+	require.True(t, found)
+	assert.Equal(t, "z1", node.zone())
+
+	// The identity is kept when the node doesn't send it
+	_, err = dispatcher.processNodeStatus("datadog.clc-runner.6d0b6e5c", "10.0.0.1", types.NodeStatus{})
+	assert.NoError(t, err)
+	assert.Equal(t, identity, node.identity)
+
+	state, err := dispatcher.getState()
+	assert.NoError(t, err)
+	require.Len(t, state.Nodes, 1)
+	assert.Equal(t, identity, state.Nodes[0].Identity)
+
+	_, err = dispatcher.processNodeStatus("node2", "10.0.0.2", types.NodeStatus{Identity: &types.NodeIdentity{Type: "unknown"}})
+	assert.Error(t, err)
+	_, found = dispatcher.store.getNodeStore("node2")
+	assert.False(t, found)
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestNodeFlare(t *testing.T) {
+	dispatcher := newDispatcher()
+	request := types.FlareRequest{CaseID: "1234", Email: "support@example.com", Profile: "minimal"}
+
+	// Unknown nodes can't be asked for a flare
+	assert.False(t, dispatcher.requestNodeFlare("node1", request))
+
+	dispatcher.processNodeStatus("node1", "10.0.0.1", types.NodeStatus{})
+	assert.Nil(t, dispatcher.popNodeFlare("node1"))
+	assert.True(t, dispatcher.requestNodeFlare("node1", request))
+
+	// The request is only handed once
+	assert.Equal(t, &request, dispatcher.popNodeFlare("node1"))
+	assert.Nil(t, dispatcher.popNodeFlare("node1"))
+	assert.Nil(t, dispatcher.popNodeFlare("unknown"))
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestGetLeastBusyNodeZones(t *testing.T) {
+	dispatcher := newDispatcher()
+	for name, zone := range map[string]string{"a1": "z1", "a2": "z1", "b1": "z2"} {
+		dispatcher.processNodeStatus(name, "", types.NodeStatus{Identity: &types.NodeIdentity{Type: types.NodeIdentityBOSH, Zone: zone}})
+	}
+
+	// 2 configs on each zone: z1 runs 1 per node and z2 runs 2 per node
+	dispatcher.addConfig(generateIntegration("A"), "a1")
+	dispatcher.addConfig(generateIntegration("B"), "a2")
+	dispatcher.addConfig(generateIntegration("C"), "b1")
+	dispatcher.addConfig(generateIntegration("D"), "b1")
+	assert.Contains(t, []string{"a1", "a2"}, dispatcher.getLeastBusyNode())
+
+	// An empty node makes z2 the least busy zone
+	dispatcher.addConfig(generateIntegration("E"), "a1")
+	dispatcher.processNodeStatus("b2", "", types.NodeStatus{Identity: &types.NodeIdentity{Type: types.NodeIdentityBOSH, Zone: "z2"}})
+	assert.Equal(t, "b2", dispatcher.getLeastBusyNode())
+	dispatcher.addConfig(generateIntegration("F"), "b2")
+	dispatcher.addConfig(generateIntegration("G"), "b2")
+	assert.Equal(t, "a2", dispatcher.getLeastBusyNode())
+
+	requireNotLocked(t, dispatcher.store)
+}
+
 func TestExpireNodes(t *testing.T) {
 	dispatcher := newDispatcher()
 
@@ -86,6 +86,8 @@ type nodeStore struct {
 	lastConfigChange int64
 	digestToConfig   map[string]integration.Config
 	clientIP         string
+	identity         *types.NodeIdentity
+	pendingFlare     *types.FlareRequest
 	clcRunnerStats   types.CLCRunnersStats
 	busyness         int
 }
@@ -100,6 +102,14 @@ func newNodeStore(name, clientIP string) *nodeStore {
 	}
 }
 
+// zone returns the availability zone of the node, empty if it doesn't report any
+func (s *nodeStore) zone() string {
+	if s.identity == nil {
+		return ""
+	}
+	return s.identity.Zone
+}
+
 func (s *nodeStore) addConfig(config integration.Config) {
 	s.lastConfigChange = timestampNow()
 	s.digestToConfig[config.Digest()] = config
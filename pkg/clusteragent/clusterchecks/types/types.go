@@ -9,14 +9,45 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 )
 
+// NodeIdentityType is the kind of identity a node-agent registers with
+type NodeIdentityType string
+
+const (
+	// NodeIdentityHostname identifies the node-agents by their hostname, it's
+	// the default when no identity is sent
+	NodeIdentityHostname NodeIdentityType = "hostname"
+	// NodeIdentityBOSH identifies the runners deployed as BOSH instances
+	NodeIdentityBOSH NodeIdentityType = "bosh"
+)
+
+// NodeIdentity describes the node-agent that registers with the DCA, the
+// zone is used to spread the cluster checks across availability zones
+type NodeIdentity struct {
+	Type       NodeIdentityType `json:"type"`
+	Deployment string           `json:"deployment,omitempty"`
+	Job        string           `json:"job,omitempty"` // BOSH instance group
+	InstanceID string           `json:"instance_id,omitempty"`
+	Zone       string           `json:"zone,omitempty"`
+}
+
 // NodeStatus holds the status report from the node-agent
 type NodeStatus struct {
-	LastChange int64 `json:"last_change"`
+	LastChange int64         `json:"last_change"`
+	Identity   *NodeIdentity `json:"identity,omitempty"`
 }
 
 // StatusResponse holds the DCA response for a status report
 type StatusResponse struct {
-	IsUpToDate bool `json:"isuptodate"`
+	IsUpToDate bool          `json:"isuptodate"`
+	Flare      *FlareRequest `json:"flare,omitempty"`
+}
+
+// FlareRequest asks a node-agent to build a flare and send it to Datadog,
+// it's passed in the response to the next status report of the node
+type FlareRequest struct {
+	CaseID  string `json:"case_id"`
+	Email   string `json:"email"`
+	Profile string `json:"profile,omitempty"`
 }
 
 // ConfigResponse holds the DCA response for a config query
@@ -35,8 +66,9 @@ type StateResponse struct {
 
 // StateNodeResponse is a chunk of StateResponse
 type StateNodeResponse struct {
-	Name    string               `json:"name"`
-	Configs []integration.Config `json:"configs"`
+	Name     string               `json:"name"`
+	Identity *NodeIdentity        `json:"identity,omitempty"`
+	Configs  []integration.Config `json:"configs"`
 }
 
 // Stats holds statistics for the agent status command
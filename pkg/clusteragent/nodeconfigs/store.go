@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nodeconfigs
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// configsCollector collects the templates served to the node agents,
+// implemented by the file config provider
+type configsCollector interface {
+	Collect() ([]integration.Config, error)
+}
+
+// Store holds the check templates read from the `node_configs.confd_path`
+// directory, and serves them to the node agents. The files are read again
+// whenever they change, the node agents poll the store and only download the
+// templates when their version changed.
+type Store struct {
+	m          sync.RWMutex
+	collector  configsCollector
+	changes    <-chan struct{}
+	configs    []integration.Config
+	version    string
+	pollPeriod time.Duration
+}
+
+// NewStore returns a Store serving the templates of the confd directory
+func NewStore(confdPath string, pollPeriod time.Duration) *Store {
+	fileProvider := providers.NewFileConfigProvider([]string{confdPath})
+	s := &Store{
+		collector:  fileProvider,
+		pollPeriod: pollPeriod,
+	}
+	if err := fileProvider.Watch(); err != nil {
+		log.Warnf("Can't watch the node configs in %s, polling them every %s: %s", confdPath, pollPeriod, err)
+	} else {
+		s.changes = fileProvider.Changes()
+	}
+	return s
+}
+
+// Run reads the templates, then reads them again when they change until the
+// context is cancelled
+func (s *Store) Run(ctx context.Context) {
+	s.refresh()
+
+	ticker := time.NewTicker(s.pollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changes:
+			s.refresh()
+		case <-ticker.C:
+			s.refresh()
+		}
+	}
+}
+
+// refresh collects the templates and updates the version if they changed
+func (s *Store) refresh() {
+	collected, err := s.collector.Collect()
+	if err != nil {
+		log.Warnf("Can't read the node configs, keeping the previous ones: %s", err)
+		return
+	}
+
+	configs := make([]integration.Config, 0, len(collected))
+	for _, c := range collected {
+		if c.ClusterCheck {
+			log.Warnf("Ignoring the node config %s from %s: cluster checks can't be distributed to the node agents", c.Name, c.Source)
+			continue
+		}
+		configs = append(configs, c)
+	}
+	version := configsVersion(configs)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	if version == s.version {
+		return
+	}
+	log.Infof("Serving %d node configs with version %s", len(configs), version)
+	s.configs = configs
+	s.version = version
+}
+
+// configsVersion hashes the digests of the configs, independently of their
+// order, so that all the replicas serving the same files agree on it
+func configsVersion(configs []integration.Config) string {
+	digests := make([]string, 0, len(configs))
+	for _, c := range configs {
+		digests = append(digests, c.Digest())
+	}
+	sort.Strings(digests)
+
+	h := fnv.New64a()
+	for _, d := range digests {
+		h.Write([]byte(d))
+		h.Write([]byte{0})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// GetConfigs returns the templates served to the node agents
+func (s *Store) GetConfigs() types.ConfigResponse {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return types.ConfigResponse{
+		Version: s.version,
+		Configs: s.configs,
+	}
+}
+
+// IsUpToDate returns whether a node agent already has the current templates
+func (s *Store) IsUpToDate(version string) bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return version == s.version
+}
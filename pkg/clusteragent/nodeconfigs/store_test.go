@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nodeconfigs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+type fakeCollector struct {
+	configs []integration.Config
+	err     error
+}
+
+func (f *fakeCollector) Collect() ([]integration.Config, error) {
+	return f.configs, f.err
+}
+
+func TestStoreRefresh(t *testing.T) {
+	redis := integration.Config{Name: "redisdb", ADIdentifiers: []string{"redis"}, Instances: []integration.Data{integration.Data("port: 6379")}}
+	ntp := integration.Config{Name: "ntp", Instances: []integration.Data{integration.Data("{}")}}
+	clusterCheck := integration.Config{Name: "http_check", ClusterCheck: true, Instances: []integration.Data{integration.Data("url: http://foo")}}
+
+	collector := &fakeCollector{configs: []integration.Config{redis, ntp, clusterCheck}}
+	s := &Store{collector: collector}
+	s.refresh()
+
+	response := s.GetConfigs()
+	assert.Equal(t, []integration.Config{redis, ntp}, response.Configs)
+	assert.NotEmpty(t, response.Version)
+	assert.True(t, s.IsUpToDate(response.Version))
+	assert.False(t, s.IsUpToDate(""))
+
+	// the version doesn't depend on the order of the files
+	collector.configs = []integration.Config{ntp, redis}
+	s.refresh()
+	assert.Equal(t, response.Version, s.GetConfigs().Version)
+
+	// the previous configs are kept when the files can't be read
+	collector.err = errors.New("permission denied")
+	collector.configs = nil
+	s.refresh()
+	assert.Equal(t, response.Version, s.GetConfigs().Version)
+
+	collector.err = nil
+	collector.configs = []integration.Config{ntp}
+	s.refresh()
+	assert.False(t, s.IsUpToDate(response.Version))
+	assert.Equal(t, []integration.Config{ntp}, s.GetConfigs().Configs)
+}
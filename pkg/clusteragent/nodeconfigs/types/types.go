@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package types
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+// ConfigResponse holds the DCA response for a node configs query
+type ConfigResponse struct {
+	// Version identifies the content of the configs, it is the same
+	// on all the cluster agent replicas serving the same files
+	Version string               `json:"version"`
+	Configs []integration.Config `json:"configs"`
+}
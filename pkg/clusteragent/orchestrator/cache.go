@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver,orchestrator
+
+package orchestrator
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type cacheEntry struct {
+	resourceVersion string
+	sentAt          time.Time
+	round           uint64
+	// sent is the last object sent, it's reported as deleted when it isn't listed anymore
+	sent runtime.Object
+}
+
+// resourceCache keeps track of the resource versions of the objects sent to the
+// orchestrator intake, so that the unchanged objects are only sent again once
+// their entry expires, and the deleted objects are reported. It isn't thread safe,
+// each collection loop owns its cache.
+type resourceCache struct {
+	entries         map[types.UID]cacheEntry
+	expiration      time.Duration
+	round           uint64
+	reportDeletions bool
+}
+
+// newResourceCache returns a cache whose entries expire after expiration. When
+// reportDeletions is false, the objects not listed anymore are just forgotten.
+func newResourceCache(expiration time.Duration, reportDeletions bool) *resourceCache {
+	return &resourceCache{
+		entries:         make(map[types.UID]cacheEntry),
+		expiration:      expiration,
+		reportDeletions: reportDeletions,
+	}
+}
+
+// startRound must be called before checking the objects of a new listing
+func (c *resourceCache) startRound() {
+	c.round++
+}
+
+// changed returns whether the object must be sent, because it's new, its resource
+// version changed, or it was last sent before the expiration of its entry. The
+// entry is only updated once the object is sent, see markSent.
+func (c *resourceCache) changed(obj metav1.Object, now time.Time) bool {
+	entry, found := c.entries[obj.GetUID()]
+	entry.round = c.round
+	c.entries[obj.GetUID()] = entry
+	return !found || entry.sent == nil || entry.resourceVersion != obj.GetResourceVersion() || now.Sub(entry.sentAt) >= c.expiration
+}
+
+// endRound returns a copy of the objects that weren't listed in the current round,
+// they were deleted, with their deletion timestamp set to now. Their entries are
+// kept until the deletion is sent, the entries of the objects never sent are forgotten.
+func (c *resourceCache) endRound(now time.Time) []runtime.Object {
+	var deleted []runtime.Object
+	for uid, entry := range c.entries {
+		if entry.round == c.round {
+			continue
+		}
+		if !c.reportDeletions || entry.sent == nil {
+			delete(c.entries, uid)
+			continue
+		}
+		obj := entry.sent.DeepCopyObject()
+		if m, err := meta.Accessor(obj); err == nil {
+			if m.GetDeletionTimestamp() == nil {
+				deletionTime := metav1.NewTime(now)
+				m.SetDeletionTimestamp(&deletionTime)
+			}
+			deleted = append(deleted, obj)
+		}
+	}
+	return deleted
+}
+
+// markSent updates the entries of the objects sent successfully, and forgets the
+// deleted ones
+func (c *resourceCache) markSent(objects []runtime.Object, now time.Time) {
+	for _, obj := range objects {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		entry, found := c.entries[m.GetUID()]
+		if !found {
+			continue
+		}
+		if entry.round != c.round {
+			delete(c.entries, m.GetUID())
+			continue
+		}
+		entry.resourceVersion = m.GetResourceVersion()
+		entry.sentAt = now
+		entry.sent = obj
+		c.entries[m.GetUID()] = entry
+	}
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver,orchestrator
+
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestResourceCache(t *testing.T) {
+	c := newResourceCache(5*time.Minute, false)
+	now := time.Now()
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid", ResourceVersion: "1"}}
+	round := func(now time.Time, sent bool, objects ...*corev1.Pod) (changed []bool, deleted []runtime.Object) {
+		c.startRound()
+		var toSend []runtime.Object
+		for _, o := range objects {
+			changed = append(changed, c.changed(o, now))
+			if changed[len(changed)-1] {
+				toSend = append(toSend, o)
+			}
+		}
+		deleted = c.endRound(now)
+		if sent {
+			c.markSent(append(toSend, deleted...), now)
+		}
+		return changed, deleted
+	}
+
+	changed, _ := round(now, true, obj)
+	assert.Equal(t, []bool{true}, changed)
+
+	// unchanged objects are skipped until their entry expires
+	changed, _ = round(now.Add(time.Minute), true, obj)
+	assert.Equal(t, []bool{false}, changed)
+	changed, _ = round(now.Add(5*time.Minute), true, obj)
+	assert.Equal(t, []bool{true}, changed)
+
+	// the objects are sent again until the send succeeds
+	obj.ResourceVersion = "2"
+	changed, _ = round(now.Add(6*time.Minute), false, obj)
+	assert.Equal(t, []bool{true}, changed)
+	changed, _ = round(now.Add(6*time.Minute), true, obj)
+	assert.Equal(t, []bool{true}, changed)
+	changed, _ = round(now.Add(6*time.Minute), true, obj)
+	assert.Equal(t, []bool{false}, changed)
+
+	// the objects not listed anymore are forgotten
+	_, deleted := round(now.Add(7*time.Minute), true)
+	assert.Empty(t, deleted)
+	assert.Empty(t, c.entries)
+	changed, _ = round(now.Add(7*time.Minute), true, obj)
+	assert.Equal(t, []bool{true}, changed)
+}
+
+func TestResourceCacheDeletions(t *testing.T) {
+	c := newResourceCache(5*time.Minute, true)
+	now := time.Now()
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid", ResourceVersion: "1"}}
+
+	c.startRound()
+	assert.True(t, c.changed(obj, now))
+	assert.Empty(t, c.endRound(now))
+	c.markSent([]runtime.Object{obj}, now)
+
+	// the deleted objects are reported until the deletion is sent
+	deletedAt := now.Add(time.Minute)
+	for _, sent := range []bool{false, true} {
+		c.startRound()
+		deleted := c.endRound(deletedAt)
+		require.Len(t, deleted, 1)
+		pod := deleted[0].(*corev1.Pod)
+		assert.Equal(t, obj.UID, pod.UID)
+		require.NotNil(t, pod.DeletionTimestamp)
+		assert.Equal(t, deletedAt.Unix(), pod.DeletionTimestamp.Unix())
+		assert.Nil(t, obj.DeletionTimestamp)
+		if sent {
+			c.markSent(deleted, deletedAt)
+		}
+	}
+	assert.Empty(t, c.entries)
+
+	// the objects never sent aren't reported
+	c.startRound()
+	assert.True(t, c.changed(obj, now))
+	c.endRound(now)
+	c.startRound()
+	assert.Empty(t, c.endRound(now))
+	assert.Empty(t, c.entries)
+}
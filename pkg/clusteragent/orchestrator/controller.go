@@ -24,18 +24,24 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	model "github.com/DataDog/agent-payload/process"
-	"k8s.io/apimachinery/pkg/labels"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
+// cacheExpiration is the delay after which the unchanged objects are sent again
+const cacheExpiration = 5 * time.Minute
+
 // ControllerContext holds necessary context for the controller
 type ControllerContext struct {
 	IsLeaderFunc                 func() bool
 	UnassignedPodInformerFactory informers.SharedInformerFactory
+	InformerFactory              informers.SharedInformerFactory
 	Client                       kubernetes.Interface
 	StopCh                       chan struct{}
 	Hostname                     string
@@ -43,17 +49,27 @@ type ControllerContext struct {
 	ConfigPath                   string
 }
 
+// resourceCollector collects a kind of resources from the cache of its informer
+type resourceCollector struct {
+	name    string
+	store   cache.Store
+	synced  cache.InformerSynced
+	cache   *resourceCache
+	process func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error)
+}
+
 // Controller is responsible of collecting & sending orchestrator info
 type Controller struct {
-	unassignedPodLister     corelisters.PodLister
-	unassignedPodListerSync cache.InformerSynced
-	groupID                 int32
-	hostName                string
-	clusterName             string
-	clusterID               string
-	forwarder               forwarder.Forwarder
-	processConfig           *processcfg.AgentConfig
-	IsLeaderFunc            func() bool
+	unassignedPods  *resourceCollector
+	collectors      []*resourceCollector
+	customResources []*customResourceCollector
+	groupID         int32
+	hostName        string
+	clusterName     string
+	clusterID       string
+	forwarder       forwarder.Forwarder
+	processConfig   *processcfg.AgentConfig
+	IsLeaderFunc    func() bool
 }
 
 // StartController starts the orchestrator controller
@@ -75,14 +91,24 @@ func StartController(ctx ControllerContext) error {
 	go orchestratorController.Run(ctx.StopCh)
 
 	ctx.UnassignedPodInformerFactory.Start(ctx.StopCh)
+	// the factory may have been started already, only the new informers are started
+	ctx.InformerFactory.Start(ctx.StopCh)
+	// the custom resources aren't waited for, their definition may not exist
+	for _, c := range orchestratorController.customResources {
+		c.factory.Start(ctx.StopCh)
+	}
 
 	return apiserver.SyncInformers(map[string]cache.SharedInformer{
-		"pods": ctx.UnassignedPodInformerFactory.Core().V1().Pods().Informer(),
+		"pods":         ctx.UnassignedPodInformerFactory.Core().V1().Pods().Informer(),
+		"deployments":  ctx.InformerFactory.Apps().V1().Deployments().Informer(),
+		"replicasets":  ctx.InformerFactory.Apps().V1().ReplicaSets().Informer(),
+		"statefulsets": ctx.InformerFactory.Apps().V1().StatefulSets().Informer(),
+		"daemonsets":   ctx.InformerFactory.Apps().V1().DaemonSets().Informer(),
+		"nodes":        ctx.InformerFactory.Core().V1().Nodes().Informer(),
 	})
 }
 
 func newController(ctx ControllerContext) (*Controller, error) {
-	podInformer := ctx.UnassignedPodInformerFactory.Core().V1().Pods()
 	clusterID, err := clustername.GetClusterID()
 	if err != nil {
 		return nil, err
@@ -98,25 +124,92 @@ func newController(ctx ControllerContext) (*Controller, error) {
 		keysPerDomain[ep.Endpoint.String()] = []string{ep.APIKey}
 	}
 
+	customResources, err := newCustomResourceCollectors()
+	if err != nil {
+		log.Errorf("Not collecting the custom resources: %v", err)
+	}
+
 	podForwarderOpts := forwarder.NewOptions(keysPerDomain)
 	podForwarderOpts.EnableHealthChecking = false
 
 	oc := &Controller{
-		unassignedPodLister:     podInformer.Lister(),
-		unassignedPodListerSync: podInformer.Informer().HasSynced,
-		groupID:                 rand.Int31(),
-		hostName:                ctx.Hostname,
-		clusterName:             ctx.ClusterName,
-		clusterID:               clusterID,
-		processConfig:           cfg,
-		forwarder:               forwarder.NewDefaultForwarder(podForwarderOpts),
-		IsLeaderFunc:            ctx.IsLeaderFunc,
-	}
-
-	oc.processConfig = cfg
+		groupID:         rand.Int31(),
+		hostName:        ctx.Hostname,
+		clusterName:     ctx.ClusterName,
+		clusterID:       clusterID,
+		processConfig:   cfg,
+		forwarder:       forwarder.NewDefaultForwarder(podForwarderOpts),
+		IsLeaderFunc:    ctx.IsLeaderFunc,
+		customResources: customResources,
+	}
+	oc.unassignedPods, oc.collectors = oc.newResourceCollectors(ctx)
 	return oc, nil
 }
 
+// newResourceCollectors returns the collector of the unassigned pods, and the
+// collectors of the other resources
+func (o *Controller) newResourceCollectors(ctx ControllerContext) (*resourceCollector, []*resourceCollector) {
+	collector := func(name string, informer cache.SharedIndexInformer, reportDeletions bool, process func([]runtime.Object, int32) ([]model.MessageBody, error)) *resourceCollector {
+		return &resourceCollector{
+			name:    name,
+			store:   informer.GetStore(),
+			synced:  informer.HasSynced,
+			cache:   newResourceCache(cacheExpiration, reportDeletions),
+			process: process,
+		}
+	}
+
+	// the pods leave the listing of the unassigned pods once they're scheduled, they're
+	// then collected by the agent of their node: their deletion isn't reported here
+	pods := collector("pods", ctx.UnassignedPodInformerFactory.Core().V1().Pods().Informer(), false, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+		pods := make([]*corev1.Pod, 0, len(objects))
+		for _, obj := range objects {
+			// the pods are scrubbed in place, they're shared with the informer cache
+			pods = append(pods, obj.(*corev1.Pod).DeepCopy())
+		}
+		// we send an empty hostname for unassigned pods
+		return orchestrator.ProcessPodlist(pods, groupID, o.processConfig, "", o.clusterName, o.clusterID)
+	})
+
+	return pods, []*resourceCollector{
+		collector("deployments", ctx.InformerFactory.Apps().V1().Deployments().Informer(), true, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			deploys := make([]*appsv1.Deployment, 0, len(objects))
+			for _, obj := range objects {
+				deploys = append(deploys, obj.(*appsv1.Deployment))
+			}
+			return orchestrator.ProcessDeploymentList(deploys, groupID, o.processConfig, o.clusterName, o.clusterID)
+		}),
+		collector("replica sets", ctx.InformerFactory.Apps().V1().ReplicaSets().Informer(), true, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			rss := make([]*appsv1.ReplicaSet, 0, len(objects))
+			for _, obj := range objects {
+				rss = append(rss, obj.(*appsv1.ReplicaSet))
+			}
+			return orchestrator.ProcessReplicaSetList(rss, groupID, o.processConfig, o.clusterName, o.clusterID)
+		}),
+		collector("stateful sets", ctx.InformerFactory.Apps().V1().StatefulSets().Informer(), true, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			stss := make([]*appsv1.StatefulSet, 0, len(objects))
+			for _, obj := range objects {
+				stss = append(stss, obj.(*appsv1.StatefulSet))
+			}
+			return orchestrator.ProcessStatefulSetList(stss, groupID, o.processConfig, o.clusterName, o.clusterID)
+		}),
+		collector("daemon sets", ctx.InformerFactory.Apps().V1().DaemonSets().Informer(), true, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			dss := make([]*appsv1.DaemonSet, 0, len(objects))
+			for _, obj := range objects {
+				dss = append(dss, obj.(*appsv1.DaemonSet))
+			}
+			return orchestrator.ProcessDaemonSetList(dss, groupID, o.processConfig, o.clusterName, o.clusterID)
+		}),
+		collector("nodes", ctx.InformerFactory.Core().V1().Nodes().Informer(), true, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			nodes := make([]*corev1.Node, 0, len(objects))
+			for _, obj := range objects {
+				nodes = append(nodes, obj.(*corev1.Node))
+			}
+			return orchestrator.ProcessNodeList(nodes, groupID, o.processConfig, o.clusterName, o.clusterID)
+		}),
+	}
+}
+
 // Run starts the orchestrator controller
 func (o *Controller) Run(stopCh <-chan struct{}) {
 	log.Infof("Starting orchestrator controller")
@@ -127,11 +220,16 @@ func (o *Controller) Run(stopCh <-chan struct{}) {
 		return
 	}
 
-	if !cache.WaitForCacheSync(stopCh, o.unassignedPodListerSync) {
+	synced := []cache.InformerSynced{o.unassignedPods.synced}
+	for _, c := range o.collectors {
+		synced = append(synced, c.synced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		return
 	}
 
 	go wait.Until(o.processPods, 10*time.Second, stopCh)
+	go wait.Until(o.processResources, 10*time.Second, stopCh)
 
 	<-stopCh
 
@@ -142,20 +240,61 @@ func (o *Controller) processPods() {
 	if !o.IsLeaderFunc() {
 		return
 	}
-	podList, err := o.unassignedPodLister.List(labels.Everything())
+	o.collect(o.unassignedPods)
+}
 
-	if err != nil {
-		log.Errorf("Unable to list pods: %s", err)
+// processResources sends the deployments, replica sets, stateful sets, daemon sets,
+// nodes and custom resources that changed or were deleted since they were last sent
+func (o *Controller) processResources() {
+	if !o.IsLeaderFunc() {
 		return
 	}
+	for _, c := range o.collectors {
+		o.collect(c)
+	}
+	o.processCustomResources()
+}
 
-	// we send an empty hostname for unassigned pods
-	msg, err := orchestrator.ProcessPodlist(podList, atomic.AddInt32(&o.groupID, 1), o.processConfig, "", o.clusterName, o.clusterID)
+// collect sends the objects of the collector that changed or were deleted since
+// they were last sent
+func (o *Controller) collect(c *resourceCollector) {
+	now := time.Now()
+	var changed []runtime.Object
+	c.cache.startRound()
+	for _, item := range c.store.List() {
+		obj, ok := item.(runtime.Object)
+		if !ok {
+			continue
+		}
+		if m, err := meta.Accessor(obj); err == nil && c.cache.changed(m, now) {
+			changed = append(changed, obj)
+		}
+	}
+	changed = append(changed, c.cache.endRound(now)...)
+	o.send(c.name, c.cache, changed, now, c.process)
+}
+
+// send processes and sends the objects, they're only marked as sent in the cache
+// once every message was accepted, so that they're sent again on failure
+func (o *Controller) send(kind string, rc *resourceCache, objects []runtime.Object, now time.Time, process func([]runtime.Object, int32) ([]model.MessageBody, error)) {
+	if len(objects) == 0 {
+		return
+	}
+	msg, err := process(objects, atomic.AddInt32(&o.groupID, 1))
 	if err != nil {
-		log.Errorf("Unable to process pod list: %v", err)
+		log.Errorf("Unable to process %s list: %v", kind, err)
 		return
 	}
+	if o.sendMessages(msg) {
+		rc.markSent(objects, now)
+	}
+}
 
+// sendMessages sends the messages to the orchestrator intake, the pod endpoint
+// accepts all the orchestrator message types. It returns whether every message
+// was accepted.
+func (o *Controller) sendMessages(msg []model.MessageBody) bool {
+	ok := true
 	for _, m := range msg {
 		extraHeaders := make(http.Header)
 		extraHeaders.Set(api.HostHeader, o.hostName)
@@ -164,6 +303,7 @@ func (o *Controller) processPods() {
 		body, err := encodePayload(m)
 		if err != nil {
 			log.Errorf("Unable to encode message: %s", err)
+			ok = false
 			continue
 		}
 
@@ -171,15 +311,25 @@ func (o *Controller) processPods() {
 		responses, err := o.forwarder.SubmitPodChecks(payloads, extraHeaders)
 		if err != nil {
 			log.Errorf("Unable to submit payload: %s", err)
+			ok = false
 			continue
 		}
 
-		// Consume the responses so that writers to the channel do not become blocked
-		// we don't need the bodies here though
-		for range responses {
-
+		// Consume the responses so that writers to the channel do not become blocked,
+		// the channel is closed without a response when they time out
+		received := 0
+		for response := range responses {
+			received++
+			if response.Err != nil || response.StatusCode >= 300 {
+				log.Errorf("Unable to send payload to %s: %d %v", response.Domain, response.StatusCode, response.Err)
+				ok = false
+			}
+		}
+		if received == 0 {
+			ok = false
 		}
 	}
+	return ok
 }
 
 func encodePayload(m model.MessageBody) ([]byte, error) {
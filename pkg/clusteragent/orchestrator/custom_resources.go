@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver,orchestrator
+
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util/orchestrator"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	model "github.com/DataDog/agent-payload/process"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	customResourcesListed = telemetry.NewGaugeWithOpts("", "orchestrator_custom_resources_listed",
+		[]string{"resource"}, "Number of custom resources listed by the orchestrator explorer.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+	customResourcesSent = telemetry.NewCounterWithOpts("", "orchestrator_custom_resources_sent",
+		[]string{"resource"}, "Counter of custom resources sent to the orchestrator explorer.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+	customResourcesSkipped = telemetry.NewCounterWithOpts("", "orchestrator_custom_resources_skipped",
+		[]string{"resource", "reason"}, "Counter of custom resources not sent to the orchestrator explorer because of the size guards.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+)
+
+// deniedResources are the core resources that can't be collected, their manifests
+// hold credentials the scrubber can't recognize
+var deniedResources = map[string]struct{}{
+	"secrets":    {},
+	"configmaps": {},
+}
+
+// customResourceConfig is an item of the `orchestrator_explorer.custom_resources`
+// option
+type customResourceConfig struct {
+	Group         string `mapstructure:"group"`
+	Version       string `mapstructure:"version"`
+	Resource      string `mapstructure:"resource"`
+	Namespace     string `mapstructure:"namespace"`
+	FieldSelector string `mapstructure:"field_selector"`
+	LabelSelector string `mapstructure:"label_selector"`
+}
+
+// customResourceCollector collects the objects of a configured resource with a
+// dynamic informer
+type customResourceCollector struct {
+	name    string
+	factory dynamicinformer.DynamicSharedInformerFactory
+	lister  cache.GenericLister
+	synced  cache.InformerSynced
+	cache   *resourceCache
+}
+
+// getCustomResourceConfigs returns the resources of the
+// `orchestrator_explorer.custom_resources` option
+func getCustomResourceConfigs() ([]customResourceConfig, error) {
+	var configs []customResourceConfig
+	if err := config.Datadog.UnmarshalKey("orchestrator_explorer.custom_resources", &configs); err != nil {
+		return nil, fmt.Errorf("invalid orchestrator_explorer.custom_resources: %s", err)
+	}
+
+	seen := make(map[string]struct{}, len(configs))
+	for _, c := range configs {
+		if c.Version == "" || c.Resource == "" {
+			return nil, fmt.Errorf("invalid orchestrator_explorer.custom_resources: the version and the resource of %q must be set", c.gvr().String())
+		}
+		if _, denied := deniedResources[c.Resource]; denied && c.Group == "" {
+			return nil, fmt.Errorf("invalid orchestrator_explorer.custom_resources: %s can't be collected", resourceName(c.gvr()))
+		}
+		if _, err := fields.ParseSelector(c.FieldSelector); err != nil {
+			return nil, fmt.Errorf("invalid field selector for %s: %s", resourceName(c.gvr()), err)
+		}
+		if _, err := labels.Parse(c.LabelSelector); err != nil {
+			return nil, fmt.Errorf("invalid label selector for %s: %s", resourceName(c.gvr()), err)
+		}
+		// the informers are shared per resource, they can't be configured twice
+		if _, found := seen[resourceName(c.gvr())]; found {
+			return nil, fmt.Errorf("invalid orchestrator_explorer.custom_resources: %s is configured twice", resourceName(c.gvr()))
+		}
+		seen[resourceName(c.gvr())] = struct{}{}
+	}
+	return configs, nil
+}
+
+func (c customResourceConfig) gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: c.Group, Version: c.Version, Resource: c.Resource}
+}
+
+// resourceName returns the name of the resource used in the logs and the telemetry,
+// e.g. widgets.v1.example.com
+func resourceName(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Resource + "." + gvr.Version
+	}
+	return gvr.Resource + "." + gvr.Version + "." + gvr.Group
+}
+
+// newCustomResourceCollectors creates the informers of the configured custom
+// resources, each resource has its own factory so that its objects can be
+// filtered server-side
+func newCustomResourceCollectors() ([]*customResourceCollector, error) {
+	configs, err := getCustomResourceConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	collectors := make([]*customResourceCollector, 0, len(configs))
+	for _, c := range configs {
+		fieldSelector, labelSelector := c.FieldSelector, c.LabelSelector
+		factory, err := apiserver.GetFilteredDynamicInformerFactory(c.Namespace, func(options *metav1.ListOptions) {
+			options.FieldSelector = fieldSelector
+			options.LabelSelector = labelSelector
+		})
+		if err != nil {
+			return nil, err
+		}
+		informer := factory.ForResource(c.gvr())
+		collectors = append(collectors, &customResourceCollector{
+			name:    resourceName(c.gvr()),
+			factory: factory,
+			lister:  informer.Lister(),
+			synced:  informer.Informer().HasSynced,
+			cache:   newResourceCache(cacheExpiration, true),
+		})
+	}
+	return collectors, nil
+}
+
+// processCustomResources sends the custom resources that changed or were deleted
+// since they were last sent. The resources whose informer didn't sync, e.g. because their
+// definition doesn't exist, are skipped.
+func (o *Controller) processCustomResources() {
+	maxCount := config.Datadog.GetInt("orchestrator_explorer.custom_resources_max_count")
+	maxSize := config.Datadog.GetInt("orchestrator_explorer.custom_resources_max_size")
+	now := time.Now()
+
+	for _, c := range o.customResources {
+		if !c.synced() {
+			log.Debugf("The informer of %s isn't synced yet, skipping it", c.name)
+			continue
+		}
+		objects, err := c.lister.List(labels.Everything())
+		if err != nil {
+			log.Errorf("Unable to list %s: %s", c.name, err)
+			continue
+		}
+		customResourcesListed.Set(float64(len(objects)), c.name)
+		// the objects are sorted so that the same ones are dropped at every run
+		sortObjects(objects)
+		if maxCount > 0 && len(objects) > maxCount {
+			log.Warnf("Only collecting %d %s out of %d, see orchestrator_explorer.custom_resources_max_count", maxCount, c.name, len(objects))
+			customResourcesSkipped.Add(float64(len(objects)-maxCount), c.name, "max_count")
+		}
+
+		var changed []runtime.Object
+		c.cache.startRound()
+		for i, obj := range objects {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				log.Debugf("Unexpected object type %T for %s", obj, c.name)
+				continue
+			}
+			// the objects over the max count are still checked in, so that they
+			// aren't reported as deleted
+			if c.cache.changed(u, now) && (maxCount <= 0 || i < maxCount) {
+				changed = append(changed, u)
+			}
+		}
+		changed = append(changed, c.cache.endRound(now)...)
+
+		name := c.name
+		o.send(name, c.cache, changed, now, func(objects []runtime.Object, groupID int32) ([]model.MessageBody, error) {
+			crs := make([]*unstructured.Unstructured, 0, len(objects))
+			for _, obj := range objects {
+				crs = append(crs, obj.(*unstructured.Unstructured))
+			}
+			msg, tooLarge, err := orchestrator.ProcessCustomResourceList(crs, groupID, o.processConfig, o.clusterName, o.clusterID, maxSize)
+			if err != nil {
+				return nil, err
+			}
+			if tooLarge > 0 {
+				customResourcesSkipped.Add(float64(tooLarge), name, "max_size")
+			}
+			customResourcesSent.Add(float64(len(crs)-tooLarge), name)
+			return msg, nil
+		})
+	}
+}
+
+// sortObjects sorts the objects by namespace and name
+func sortObjects(objects []runtime.Object) {
+	key := func(obj runtime.Object) string {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return ""
+		}
+		return m.GetNamespace() + "/" + m.GetName()
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return key(objects[i]) < key(objects[j])
+	})
+}
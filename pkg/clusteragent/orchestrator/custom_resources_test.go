@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver,orchestrator
+
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestGetCustomResourceConfigs(t *testing.T) {
+	mockConfig := config.Mock()
+
+	configs, err := getCustomResourceConfigs()
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	mockConfig.Set("orchestrator_explorer.custom_resources", []map[string]interface{}{
+		{"group": "example.com", "version": "v1", "resource": "widgets", "field_selector": "metadata.name=foo"},
+		{"version": "v1", "resource": "events", "namespace": "default"},
+	})
+	configs, err = getCustomResourceConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "widgets.v1.example.com", resourceName(configs[0].gvr()))
+	assert.Equal(t, "metadata.name=foo", configs[0].FieldSelector)
+	assert.Equal(t, "events.v1", resourceName(configs[1].gvr()))
+	assert.Equal(t, "default", configs[1].Namespace)
+
+	for name, resources := range map[string][]map[string]interface{}{
+		"missing version":        {{"group": "example.com", "resource": "widgets"}},
+		"invalid field selector": {{"version": "v1", "resource": "widgets", "field_selector": "metadata.name=="}},
+		"invalid label selector": {{"version": "v1", "resource": "widgets", "label_selector": "team in (a"}},
+		"secrets":                {{"version": "v1", "resource": "secrets"}},
+		"configmaps":             {{"version": "v1", "resource": "configmaps", "namespace": "default"}},
+		"duplicated": {
+			{"version": "v1", "resource": "widgets"},
+			{"version": "v1", "resource": "widgets", "namespace": "default"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockConfig.Set("orchestrator_explorer.custom_resources", resources)
+			_, err := getCustomResourceConfigs()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSortObjects(t *testing.T) {
+	object := func(namespace, name string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+	objects := []runtime.Object{object("b", "a"), object("a", "b"), object("a", "a")}
+	sortObjects(objects)
+	assert.Equal(t, []runtime.Object{object("a", "a"), object("a", "b"), object("b", "a")}, objects)
+}
@@ -0,0 +1,407 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package recommendations
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/zorkian/go-datadog-api.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// eventReason is the reason of the events sent on the workloads
+	eventReason = "ResourceRecommendation"
+	// rollup is the resolution, in seconds, of the usage series
+	rollup = 300
+	// leaderCheckPeriod is the period at which a new leader is detected, it
+	// computes the recommendations right away
+	leaderCheckPeriod = time.Minute
+)
+
+var (
+	recommendationsCount = telemetry.NewGaugeWithOpts("", "resource_recommendations",
+		[]string{"resource", "reason"}, "Number of right-sizing recommendations computed by the cluster agent.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+	usageQueries = telemetry.NewCounterWithOpts("", "resource_recommendations_queries",
+		[]string{"status"}, "Counter of usage queries made to Datadog to compute the right-sizing recommendations.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+)
+
+// workloadKind is a kind of workload, and the tag of its containers' usage
+type workloadKind struct {
+	name string
+	tag  string
+}
+
+var workloadKinds = []workloadKind{
+	{name: "Deployment", tag: "kube_deployment"},
+	{name: "StatefulSet", tag: "kube_stateful_set"},
+}
+
+// usageMetric is a usage metric reported by the node agents, and how its
+// values convert to a quantity of the resource
+type usageMetric struct {
+	resource v1.ResourceName
+	metric   string
+	quantity func(float64) *resource.Quantity
+}
+
+var usageMetrics = []usageMetric{
+	{
+		resource: v1.ResourceCPU,
+		metric:   "kubernetes.cpu.usage.total",
+		// nanocores, rounded up to the millicore
+		quantity: func(v float64) *resource.Quantity {
+			return resource.NewMilliQuantity(int64(math.Ceil(v/1e6)), resource.DecimalSI)
+		},
+	},
+	{
+		resource: v1.ResourceMemory,
+		metric:   "kubernetes.memory.usage",
+		// bytes, rounded up to the mebibyte
+		quantity: func(v float64) *resource.Quantity {
+			return resource.NewQuantity(int64(math.Ceil(v/(1<<20)))<<20, resource.BinarySI)
+		},
+	},
+}
+
+// containerKey identifies a container of a workload
+type containerKey struct {
+	kind      string
+	namespace string
+	name      string
+	container string
+}
+
+// usage holds the usage percentile of the resources of the containers
+type usage map[containerKey]map[v1.ResourceName]float64
+
+// Recommender periodically correlates the requests of the containers of the
+// deployments and statefulsets with their usage, reported by the node agents,
+// and computes right-sizing recommendations. Only the leader queries Datadog,
+// stores the recommendations and sends them as events on the workloads.
+type Recommender struct {
+	client          autoscalers.DatadogClient
+	informerFactory informers.SharedInformerFactory
+	deployLister    appslisters.DeploymentLister
+	stsLister       appslisters.StatefulSetLister
+	listersSynced   []cache.InformerSynced
+	recorder        record.EventRecorder
+	isLeader        func() bool
+	store           *Store
+	lastRefresh     time.Time
+	clusterName     string
+	period          time.Duration
+	window          time.Duration
+	percentile      float64
+	margin          float64
+	threshold       float64
+	sendEvents      bool
+}
+
+// NewRecommender returns a Recommender configured with the
+// `resource_recommendations` options
+func NewRecommender(informerFactory informers.SharedInformerFactory, recorder record.EventRecorder, isLeader func() bool) (*Recommender, error) {
+	percentile := config.Datadog.GetFloat64("resource_recommendations.percentile")
+	if percentile <= 0 || percentile > 100 {
+		return nil, fmt.Errorf("invalid resource_recommendations.percentile %v, it must be greater than 0 and at most 100", percentile)
+	}
+	client, err := autoscalers.NewDatadogClient()
+	if err != nil {
+		return nil, err
+	}
+
+	r := newRecommender(client, informerFactory, recorder, isLeader)
+	r.clusterName = config.Datadog.GetString("cluster_name")
+	r.period = time.Duration(config.Datadog.GetInt64("resource_recommendations.refresh_period")) * time.Second
+	r.window = time.Duration(config.Datadog.GetInt64("resource_recommendations.window")) * time.Second
+	r.percentile = percentile
+	r.margin = config.Datadog.GetFloat64("resource_recommendations.margin")
+	r.threshold = config.Datadog.GetFloat64("resource_recommendations.threshold")
+	r.sendEvents = config.Datadog.GetBool("resource_recommendations.send_events")
+	return r, nil
+}
+
+func newRecommender(client autoscalers.DatadogClient, informerFactory informers.SharedInformerFactory, recorder record.EventRecorder, isLeader func() bool) *Recommender {
+	deployInformer := informerFactory.Apps().V1().Deployments()
+	stsInformer := informerFactory.Apps().V1().StatefulSets()
+	return &Recommender{
+		client:          client,
+		informerFactory: informerFactory,
+		deployLister:    deployInformer.Lister(),
+		stsLister:       stsInformer.Lister(),
+		listersSynced:   []cache.InformerSynced{deployInformer.Informer().HasSynced, stsInformer.Informer().HasSynced},
+		recorder:        recorder,
+		isLeader:        isLeader,
+		store:           NewStore(),
+	}
+}
+
+// Store returns the store of the recommendations served by the API
+func (r *Recommender) Store() *Store {
+	return r.store
+}
+
+// Run computes the recommendations every refresh period while the cluster
+// agent is the leader, until stopCh is closed
+func (r *Recommender) Run(stopCh <-chan struct{}) {
+	// starts the informers of the workloads, if they weren't already
+	r.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, r.listersSynced...) {
+		log.Error("Couldn't sync the informers of the resource recommender")
+		return
+	}
+
+	log.Infof("Computing the resource recommendations every %s", r.period)
+	wait.Until(func() {
+		if !r.isLeader() {
+			// a follower doesn't serve stale recommendations, and refreshes
+			// them as soon as it becomes the leader
+			r.lastRefresh = time.Time{}
+			r.store.Set(nil, 0, time.Time{})
+			return
+		}
+		if time.Since(r.lastRefresh) < r.period {
+			return
+		}
+		r.refresh(time.Now())
+	}, leaderCheckPeriod, stopCh)
+}
+
+// refresh computes the recommendations from the usage of the last window
+func (r *Recommender) refresh(now time.Time) {
+	containersUsage, err := r.queryUsage(now)
+	if err != nil {
+		log.Errorf("Couldn't compute the resource recommendations: %v", err)
+		return
+	}
+	r.lastRefresh = now
+
+	// served as an empty list rather than null
+	recommendations := []Recommendation{}
+	deploys, err := r.deployLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("Unable to list deployments: %v", err)
+	}
+	for _, d := range deploys {
+		recommendations = append(recommendations, r.recommend("Deployment", d, d.ObjectMeta, d.Spec.Template.Spec, containersUsage)...)
+	}
+	statefulSets, err := r.stsLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("Unable to list statefulsets: %v", err)
+	}
+	for _, s := range statefulSets {
+		recommendations = append(recommendations, r.recommend("StatefulSet", s, s.ObjectMeta, s.Spec.Template.Spec, containersUsage)...)
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.Resource < b.Resource
+	})
+	r.store.Set(recommendations, r.window, now)
+
+	counts := make(map[[2]string]int)
+	for _, m := range usageMetrics {
+		for _, reason := range []string{ReasonOverProvisioned, ReasonUnderProvisioned, ReasonRequestNotSet} {
+			counts[[2]string{string(m.resource), reason}] = 0
+		}
+	}
+	for _, rec := range recommendations {
+		counts[[2]string{rec.Resource, rec.Reason}]++
+	}
+	for tags, count := range counts {
+		recommendationsCount.Set(float64(count), tags[0], tags[1])
+	}
+	log.Debugf("Computed %d resource recommendations", len(recommendations))
+}
+
+// recommend returns the recommendations for the containers of a workload, and
+// sends them as an event on the workload
+func (r *Recommender) recommend(kind string, obj runtime.Object, meta metav1.ObjectMeta, spec v1.PodSpec, containersUsage usage) []Recommendation {
+	var recommendations []Recommendation
+	for _, c := range spec.Containers {
+		key := containerKey{kind: kind, namespace: meta.Namespace, name: meta.Name, container: c.Name}
+		for _, m := range usageMetrics {
+			used, found := containersUsage[key][m.resource]
+			if !found {
+				continue
+			}
+			if rec, ok := r.recommendResource(key, c.Resources, m, used); ok {
+				recommendations = append(recommendations, rec)
+			}
+		}
+	}
+
+	if r.sendEvents && len(recommendations) > 0 {
+		messages := make([]string, 0, len(recommendations))
+		for _, rec := range recommendations {
+			current := rec.Request
+			if current == "" {
+				current = "not set"
+			}
+			messages = append(messages, fmt.Sprintf("container %s %s request %s, recommended %s (%s)", rec.Container, rec.Resource, current, rec.RecommendedRequest, rec.Reason))
+		}
+		r.recorder.Eventf(obj, v1.EventTypeNormal, eventReason, "Based on the p%v usage over %s: %s", r.percentile, r.window, strings.Join(messages, "; "))
+	}
+	return recommendations
+}
+
+// recommendResource returns the recommended request of a resource of a
+// container, if it drifts from the current one by more than the threshold
+func (r *Recommender) recommendResource(key containerKey, requirements v1.ResourceRequirements, m usageMetric, used float64) (Recommendation, bool) {
+	recommended := m.quantity(used * (1 + r.margin))
+	rec := Recommendation{
+		Kind:               key.kind,
+		Namespace:          key.namespace,
+		Name:               key.name,
+		Container:          key.container,
+		Resource:           string(m.resource),
+		Usage:              m.quantity(used).String(),
+		RecommendedRequest: recommended.String(),
+	}
+	limit, hasLimit := requirements.Limits[m.resource]
+	if hasLimit {
+		rec.Limit = limit.String()
+	}
+	request, hasRequest := requirements.Requests[m.resource]
+	if !hasRequest && hasLimit {
+		// the request defaults to the limit
+		request, hasRequest = limit, true
+	}
+	if !hasRequest || request.IsZero() {
+		rec.Reason = ReasonRequestNotSet
+		return rec, true
+	}
+
+	rec.Request = request.String()
+	drift := float64(recommended.MilliValue()-request.MilliValue()) / float64(request.MilliValue())
+	switch {
+	case math.Abs(drift) < r.threshold:
+		return rec, false
+	case drift < 0:
+		rec.Reason = ReasonOverProvisioned
+	default:
+		rec.Reason = ReasonUnderProvisioned
+	}
+	return rec, true
+}
+
+// queryUsage returns the usage percentile of the containers of the workloads
+// over the window. The usage of a container is the one of its busiest pod.
+func (r *Recommender) queryUsage(now time.Time) (usage, error) {
+	scope := "*"
+	if r.clusterName != "" {
+		scope = "kube_cluster_name:" + r.clusterName
+	}
+
+	containersUsage := make(usage)
+	for _, kind := range workloadKinds {
+		for _, m := range usageMetrics {
+			query := fmt.Sprintf("max:%s{%s} by {kube_namespace,%s,kube_container_name}.rollup(max, %d)", m.metric, scope, kind.tag, rollup)
+			series, err := r.client.QueryMetrics(now.Add(-r.window).Unix(), now.Unix(), query)
+			if err != nil {
+				usageQueries.Inc("error")
+				return nil, fmt.Errorf("error while executing the query %s: %v", query, err)
+			}
+			usageQueries.Inc("success")
+
+			for _, serie := range series {
+				tags := parseScope(serie.Scope)
+				key := containerKey{
+					kind:      kind.name,
+					namespace: tags["kube_namespace"],
+					name:      tags[kind.tag],
+					container: tags["kube_container_name"],
+				}
+				if key.namespace == "" || key.name == "" || key.container == "" {
+					continue
+				}
+				values := pointValues(serie.Points)
+				if len(values) == 0 {
+					continue
+				}
+				if containersUsage[key] == nil {
+					containersUsage[key] = make(map[v1.ResourceName]float64, len(usageMetrics))
+				}
+				containersUsage[key][m.resource] = percentile(values, r.percentile)
+			}
+		}
+	}
+	return containersUsage, nil
+}
+
+// parseScope returns the tags of the scope of a series, e.g.
+// `kube_namespace:default,kube_deployment:web`
+func parseScope(scope *string) map[string]string {
+	tags := make(map[string]string)
+	if scope == nil {
+		return tags
+	}
+	for _, tag := range strings.Split(*scope, ",") {
+		if parts := strings.SplitN(tag, ":", 2); len(parts) == 2 {
+			tags[parts[0]] = parts[1]
+		}
+	}
+	return tags
+}
+
+// pointValues returns the values of the points of a series, without the empty ones
+func pointValues(points []datadog.DataPoint) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		if p[1] != nil {
+			values = append(values, *p[1])
+		}
+	}
+	return values
+}
+
+// percentile returns the nearest-rank percentile of the values
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
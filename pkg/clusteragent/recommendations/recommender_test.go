@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package recommendations
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/zorkian/go-datadog-api.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+type fakeDatadogClient struct {
+	queryMetricsFunc func(from, to int64, query string) ([]datadog.Series, error)
+}
+
+func (d *fakeDatadogClient) QueryMetrics(from, to int64, query string) ([]datadog.Series, error) {
+	return d.queryMetricsFunc(from, to, query)
+}
+
+func (d *fakeDatadogClient) GetRateLimitStats() map[string]datadog.RateLimit {
+	return nil
+}
+
+func makeSeries(scope string, values ...float64) datadog.Series {
+	points := make([]datadog.DataPoint, 0, len(values))
+	for i := range values {
+		ts := float64(i * rollup * 1000)
+		points = append(points, datadog.DataPoint{&ts, &values[i]})
+	}
+	return datadog.Series{Scope: &scope, Points: points}
+}
+
+func TestRefresh(t *testing.T) {
+	client := &fakeDatadogClient{
+		queryMetricsFunc: func(from, to int64, query string) ([]datadog.Series, error) {
+			assert.Equal(t, int64(24*3600), to-from)
+			assert.Contains(t, query, "{kube_cluster_name:mycluster}")
+			switch {
+			case strings.HasPrefix(query, "max:kubernetes.cpu.usage.total") && strings.Contains(query, "kube_deployment"):
+				return []datadog.Series{
+					// 1 core at p95, for a request of 500m
+					makeSeries("kube_namespace:default,kube_deployment:web,kube_container_name:app", 50e6, 80e6, 100e6, 1000e6),
+					// 500m, for a request defaulting to its limit of 500m
+					makeSeries("kube_namespace:default,kube_deployment:web,kube_container_name:sidecar", 500e6),
+					makeSeries("kube_namespace:default,kube_deployment:gone,kube_container_name:app", 500e6),
+				}, nil
+			case strings.HasPrefix(query, "max:kubernetes.memory.usage") && strings.Contains(query, "kube_stateful_set"):
+				return []datadog.Series{
+					makeSeries("kube_namespace:db,kube_stateful_set:postgres,kube_container_name:postgres", 900*(1<<20)),
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	recorder := record.NewFakeRecorder(10)
+	r := newRecommender(client, factory, recorder, func() bool { return true })
+	r.clusterName = "mycluster"
+	r.window = 24 * time.Hour
+	r.percentile = 95
+	r.margin = 0.25
+	r.threshold = 0.3
+	r.sendEvents = true
+
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{
+			{
+				Name: "app",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+					Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+				},
+			},
+			{
+				Name: "sidecar",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+				},
+			},
+		}}}},
+	}
+	postgres := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "db"},
+		Spec: appsv1.StatefulSetSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{
+			{Name: "postgres"},
+		}}}},
+	}
+	require.NoError(t, factory.Apps().V1().Deployments().Informer().GetIndexer().Add(web))
+	require.NoError(t, factory.Apps().V1().StatefulSets().Informer().GetIndexer().Add(postgres))
+
+	_, computed := r.Store().Get()
+	assert.False(t, computed)
+
+	now := time.Now()
+	r.refresh(now)
+
+	response, computed := r.Store().Get()
+	require.True(t, computed)
+	assert.Equal(t, now, response.GeneratedAt)
+	assert.Equal(t, "24h0m0s", response.Window)
+	assert.Equal(t, []Recommendation{
+		{
+			Kind:               "Deployment",
+			Namespace:          "default",
+			Name:               "web",
+			Container:          "app",
+			Resource:           "cpu",
+			Request:            "500m",
+			Limit:              "2",
+			Usage:              "1",
+			RecommendedRequest: "1250m",
+			Reason:             ReasonUnderProvisioned,
+		},
+		{
+			Kind:               "StatefulSet",
+			Namespace:          "db",
+			Name:               "postgres",
+			Container:          "postgres",
+			Resource:           "memory",
+			Usage:              "900Mi",
+			RecommendedRequest: "1125Mi",
+			Reason:             ReasonRequestNotSet,
+		},
+	}, response.Recommendations)
+
+	require.Len(t, recorder.Events, 2)
+	assert.Equal(t, "Normal ResourceRecommendation Based on the p95 usage over 24h0m0s: container app cpu request 500m, recommended 1250m (under_provisioned)", <-recorder.Events)
+	assert.Equal(t, "Normal ResourceRecommendation Based on the p95 usage over 24h0m0s: container postgres memory request not set, recommended 1125Mi (request_not_set)", <-recorder.Events)
+}
+
+func TestRecommendResource(t *testing.T) {
+	r := &Recommender{margin: 0.25, threshold: 0.3}
+	key := containerKey{kind: "Deployment", namespace: "default", name: "web", container: "app"}
+	cpu := usageMetrics[0]
+	requests := func(quantity string) v1.ResourceRequirements {
+		return v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(quantity)}}
+	}
+
+	rec, ok := r.recommendResource(key, requests("1"), cpu, 200e6)
+	require.True(t, ok)
+	assert.Equal(t, ReasonOverProvisioned, rec.Reason)
+	assert.Equal(t, "250m", rec.RecommendedRequest)
+	assert.Equal(t, "200m", rec.Usage)
+
+	// within the threshold
+	_, ok = r.recommendResource(key, requests("250m"), cpu, 200e6)
+	assert.False(t, ok)
+
+	rec, ok = r.recommendResource(key, requests("100m"), cpu, 200e6)
+	require.True(t, ok)
+	assert.Equal(t, ReasonUnderProvisioned, rec.Reason)
+
+	rec, ok = r.recommendResource(key, v1.ResourceRequirements{}, cpu, 200e6)
+	require.True(t, ok)
+	assert.Equal(t, ReasonRequestNotSet, rec.Reason)
+	assert.Empty(t, rec.Request)
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3, 6, 7, 8, 9, 10}
+	assert.Equal(t, 10.0, percentile(values, 100))
+	assert.Equal(t, 10.0, percentile(values, 95))
+	assert.Equal(t, 5.0, percentile(values, 50))
+	assert.Equal(t, 1.0, percentile(values, 1))
+	assert.Equal(t, 3.0, percentile([]float64{3}, 95))
+	// the values are left untouched
+	assert.Equal(t, 5.0, values[0])
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package recommendations
+
+import (
+	"sync"
+	"time"
+)
+
+// Reasons of the recommendations
+const (
+	ReasonOverProvisioned  = "over_provisioned"
+	ReasonUnderProvisioned = "under_provisioned"
+	ReasonRequestNotSet    = "request_not_set"
+)
+
+// Recommendation is a right-sizing recommendation for a resource of a container
+// of a workload. The quantities use the Kubernetes format.
+type Recommendation struct {
+	Kind               string `json:"kind"`
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	Container          string `json:"container"`
+	Resource           string `json:"resource"`
+	Request            string `json:"request,omitempty"`
+	Limit              string `json:"limit,omitempty"`
+	Usage              string `json:"usage"`
+	RecommendedRequest string `json:"recommended_request"`
+	Reason             string `json:"reason"`
+}
+
+// Response is served by the `/recommendations` endpoint
+type Response struct {
+	GeneratedAt     time.Time        `json:"generated_at"`
+	Window          string           `json:"window"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// Store holds the last recommendations computed by the leader
+type Store struct {
+	m        sync.RWMutex
+	response Response
+}
+
+// NewStore returns an empty Store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Set replaces the recommendations
+func (s *Store) Set(recommendations []Recommendation, window time.Duration, generatedAt time.Time) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.response = Response{
+		GeneratedAt:     generatedAt,
+		Window:          window.String(),
+		Recommendations: recommendations,
+	}
+}
+
+// Get returns the last recommendations, and whether they were computed yet
+func (s *Store) Get() (Response, bool) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.response, !s.response.GeneratedAt.IsZero()
+}
@@ -5,9 +5,15 @@
 
 package clusteragent
 
-import "github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
+import (
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/recommendations"
+)
 
 // ServerContext holds business logic classes required to setup API endpoints
 type ServerContext struct {
-	ClusterCheckHandler *clusterchecks.Handler
+	ClusterCheckHandler  *clusterchecks.Handler
+	NodeConfigsStore     *nodeconfigs.Store
+	RecommendationsStore *recommendations.Store
 }
@@ -15,6 +15,7 @@ import (
 type Check interface {
 	Run() error                                                         // run the check
 	Stop()                                                              // stop the check if it's running
+	Cancel()                                                            // cleanup the check resources once it's unscheduled
 	String() string                                                     // provide a printable version of the check name
 	Configure(config, initConfig integration.Data, source string) error // configure the check from the outside
 	Interval() time.Duration                                            // return the interval time for the check
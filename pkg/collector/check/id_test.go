@@ -21,6 +21,7 @@ func (c *TestCheck) String() string
 func (c *TestCheck) Version() string                                            { return "" }
 func (c *TestCheck) ConfigSource() string                                       { return "" }
 func (c *TestCheck) Stop()                                                      {}
+func (c *TestCheck) Cancel()                                                    {}
 func (c *TestCheck) Configure(integration.Data, integration.Data, string) error { return nil }
 func (c *TestCheck) Interval() time.Duration                                    { return 1 }
 func (c *TestCheck) Run() error                                                 { return nil }
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package check
+
+import (
+	"context"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+// RunOptions are the options of a check instance driving how the scheduler and
+// the runner run it
+type RunOptions struct {
+	// Jitter is the upper bound of the random delay added to each scheduling of the check
+	Jitter time.Duration
+	// Timeout is the duration after which a run is cancelled, 0 for no timeout
+	Timeout time.Duration
+	// ConcurrencyClass is the class limiting the runs of the check running at the same time
+	ConcurrencyClass string
+}
+
+// RunOptionsProvider is implemented by the checks configured with run options
+type RunOptionsProvider interface {
+	RunOptions() RunOptions
+}
+
+// ContextCheck is implemented by the checks propagating a context to their runs,
+// the context of a run is cancelled when it times out
+type ContextCheck interface {
+	SetRunContext(ctx context.Context)
+}
+
+// Interruptible is implemented by the checks whose stuck runs can be interrupted
+type Interruptible interface {
+	Interrupt() error
+}
+
+// NewRunOptions returns the run options set in the common options of an instance
+func NewRunOptions(options integration.CommonInstanceConfig) RunOptions {
+	runOptions := RunOptions{ConcurrencyClass: options.ConcurrencyClass}
+	if options.MinCollectionIntervalJitter > 0 {
+		runOptions.Jitter = time.Duration(options.MinCollectionIntervalJitter) * time.Second
+	}
+	if options.RunTimeout > 0 {
+		runOptions.Timeout = time.Duration(options.RunTimeout) * time.Second
+	}
+	return runOptions
+}
+
+// GetRunOptions returns the run options of a check, the zero options if it
+// doesn't support them
+func GetRunOptions(c Check) RunOptions {
+	if p, ok := c.(RunOptionsProvider); ok {
+		return p.RunOptions()
+	}
+	return RunOptions{}
+}
@@ -25,6 +25,8 @@ var (
 		[]string{"check_name"}, "Service checks count")
 	tlmExecutionTime = telemetry.NewGauge("checks", "execution_time",
 		[]string{"check_name"}, "Check execution time")
+	tlmMemory = telemetry.NewGauge("checks", "memory_bytes",
+		[]string{"check_name"}, "Memory in use by the check")
 )
 
 // Stats holds basic runtime statistics about check instances
@@ -49,6 +51,7 @@ type Stats struct {
 	LastError            string    // error that occurred in the last run, if any
 	LastWarnings         []string  // warnings that occurred in the last run, if any
 	UpdateTimestamp      int64     // latest update to this instance, unix timestamp in seconds
+	MemoryBytes          int64     // memory in use by the instance after the last run, if accounted
 	m                    sync.Mutex
 	telemetry            bool // do we want telemetry on this Check
 }
@@ -132,4 +135,10 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats m
 			tlmServices.Add(float64(sc), cs.CheckName)
 		}
 	}
+	if mem, ok := metricStats["MemoryBytes"]; ok {
+		cs.MemoryBytes = mem
+		if cs.telemetry {
+			tlmMemory.Set(float64(mem), cs.CheckName)
+		}
+	}
 }
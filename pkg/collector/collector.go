@@ -24,8 +24,7 @@ const (
 
 // Collector abstract common operations about running a Check
 type Collector struct {
-	checkInstances int64
-	state          uint32
+	state uint32
 
 	scheduler *scheduler.Scheduler
 	runner    *runner.Runner
@@ -44,11 +43,10 @@ func NewCollector(paths ...string) *Collector {
 	sched.Run()
 
 	c := &Collector{
-		scheduler:      sched,
-		runner:         run,
-		checks:         make(map[check.ID]check.Check),
-		state:          started,
-		checkInstances: int64(0),
+		scheduler: sched,
+		runner:    run,
+		checks:    make(map[check.ID]check.Check),
+		state:     started,
 	}
 	pyVer, pyHome, pyPath := pySetup(paths...)
 
@@ -106,16 +104,13 @@ func (c *Collector) RunCheck(ch check.Check) (check.ID, error) {
 		return emptyID, fmt.Errorf("unable to schedule the check: %s", err)
 	}
 
-	// Track the total number of checks running in order to have an appropriate number of workers
-	c.checkInstances++
+	// the number of workers is sized by the runner to the delay of the checks
 	if ch.Interval() == 0 {
 		// Adding a temporary runner for long running check in case the
 		// number of runners is lower than the number of long running
 		// checks.
 		log.Infof("Adding an extra runner for the '%s' long running check", ch)
 		c.runner.AddWorker()
-	} else {
-		c.runner.UpdateNumWorkers(c.checkInstances)
 	}
 
 	c.checks[ch.ID()] = ch
@@ -187,7 +182,8 @@ func (c *Collector) StopCheck(id check.ID) error {
 	// remove the check from the stats map
 	runner.RemoveCheckStats(id)
 
-	// vaporize the check
+	// vaporize the check, once it's released its resources
+	c.cancel(id)
 	c.delete(id)
 
 	return nil
@@ -202,6 +198,17 @@ func (c *Collector) find(id check.ID) bool {
 	return found
 }
 
+// release the resources of a check
+func (c *Collector) cancel(id check.ID) {
+	c.m.RLock()
+	ch := c.checks[id]
+	c.m.RUnlock()
+
+	if ch != nil {
+		ch.Cancel()
+	}
+}
+
 // remove the check from the list
 func (c *Collector) delete(id check.ID) {
 	c.m.Lock()
@@ -230,6 +237,22 @@ func (c *Collector) GetAllInstanceIDs(checkName string) []check.ID {
 	return instances
 }
 
+// GetChecksVersions returns the version of the integration of every running
+// check, by check name. The checks without version are skipped.
+func (c *Collector) GetChecksVersions() map[string]string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	versions := map[string]string{}
+	for _, check := range c.checks {
+		if version := check.Version(); version != "" {
+			versions[check.String()] = version
+		}
+	}
+
+	return versions
+}
+
 // ReloadAllCheckInstances completely restarts a check with a new configuration
 func (c *Collector) ReloadAllCheckInstances(name string, newInstances []check.Check) ([]check.ID, error) {
 	if !c.started() {
@@ -20,10 +20,13 @@ import (
 type TestCheck struct {
 	uniqueID check.ID
 	name     string
+	version  string
 	stop     chan bool
+	canceled bool
 }
 
 func (c *TestCheck) Stop()                                                { c.stop <- true }
+func (c *TestCheck) Cancel()                                              { c.canceled = true }
 func (c *TestCheck) Configure(a, b integration.Data, source string) error { return nil }
 func (c *TestCheck) Interval() time.Duration                              { return 1 * time.Minute }
 func (c *TestCheck) Run() error                                           { <-c.stop; return nil }
@@ -44,7 +47,7 @@ func (c *TestCheck) String() string {
 }
 
 func (c *TestCheck) Version() string {
-	return ""
+	return c.version
 }
 
 func (c *TestCheck) ConfigSource() string {
@@ -134,6 +137,7 @@ func (suite *CollectorTestSuite) TestStopCheck() {
 	err = suite.c.StopCheck("TestCheck")
 	assert.Nil(suite.T(), err)
 	assert.Zero(suite.T(), len(suite.c.checks))
+	assert.True(suite.T(), ch.canceled)
 }
 
 func (suite *CollectorTestSuite) TestFind() {
@@ -186,6 +190,21 @@ func (suite *CollectorTestSuite) TestGetAllInstanceIDs() {
 	}
 }
 
+func (suite *CollectorTestSuite) TestGetChecksVersions() {
+	ch1 := NewCheckUnique("foo", "TestCheck1")
+	ch1.version = "1.2.0"
+	ch2 := NewCheckUnique("bar", "TestCheck1")
+	ch2.version = "1.2.0"
+	ch3 := NewCheckUnique("baz", "TestCheck2")
+	for _, ch := range []*TestCheck{ch1, ch2, ch3} {
+		_, err := suite.c.RunCheck(ch)
+		assert.Nil(suite.T(), err)
+	}
+
+	// TestCheck2 has no version
+	assert.Equal(suite.T(), map[string]string{"TestCheck1": "1.2.0"}, suite.c.GetChecksVersions())
+}
+
 func (suite *CollectorTestSuite) TestReloadAllCheckInstances() {
 	// Schedule 2 check instances
 	ch1 := NewCheckUnique("foo", "TestCheck")
@@ -6,6 +6,7 @@
 package corechecks
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 // To use it, you need to embed it in your check struct, by calling
 // NewCheckBase() in your factory, plus:
 // - long-running checks must override Stop() and Interval()
+// - checks holding resources across runs must release them in Cancel()
 // - checks supporting multiple instances must call BuildID() from
 // their Config() method
 // - after optionally building a unique ID, CommonConfigure() must
@@ -44,6 +46,8 @@ type CheckBase struct {
 	checkInterval  time.Duration
 	source         string
 	telemetry      bool
+	runOptions     check.RunOptions
+	runContext     context.Context
 }
 
 // NewCheckBase returns a check base struct with a given check name
@@ -117,6 +121,7 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 	if commonOptions.MinCollectionInterval > 0 {
 		c.checkInterval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
+	c.runOptions = check.NewRunOptions(commonOptions)
 
 	// Disable default hostname if specified
 	if commonOptions.EmptyDefaultHostname {
@@ -148,6 +153,16 @@ func (c *CheckBase) CommonConfigure(instance integration.Data, source string) er
 		s.SetCheckService(commonOptions.Service)
 	}
 
+	// Override the context limit of `check_max_metric_contexts` for this check
+	if commonOptions.MaxMetricContexts > 0 {
+		s, err := aggregator.GetSender(c.checkID)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.ID()), err)
+			return err
+		}
+		s.SetContextLimit(commonOptions.MaxMetricContexts)
+	}
+
 	c.source = source
 	return nil
 }
@@ -172,12 +187,35 @@ func (c *CheckBase) Warnf(format string, params ...interface{}) error {
 // long-running checks (persisting after Run() exits)
 func (c *CheckBase) Stop() {}
 
+// Cancel does nothing by default, you need to implement it in checks holding
+// resources across runs, it's called once the check is unscheduled
+func (c *CheckBase) Cancel() {}
+
 // Interval returns the scheduling time for the check.
 // Long-running checks should override to return 0.
 func (c *CheckBase) Interval() time.Duration {
 	return c.checkInterval
 }
 
+// RunOptions returns the jitter, timeout and concurrency class of the instance
+func (c *CheckBase) RunOptions() check.RunOptions {
+	return c.runOptions
+}
+
+// SetRunContext is called by the runner before each run of the check
+func (c *CheckBase) SetRunContext(ctx context.Context) {
+	c.runContext = ctx
+}
+
+// RunContext returns the context of the current run, cancelled when the run
+// times out. Checks with a `run_timeout` should pass it to their blocking calls.
+func (c *CheckBase) RunContext() context.Context {
+	if c.runContext == nil {
+		return context.Background()
+	}
+	return c.runContext
+}
+
 // String returns the name of the check, the same for every instance
 func (c *CheckBase) String() string {
 	return c.checkName
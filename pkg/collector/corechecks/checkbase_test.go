@@ -6,12 +6,14 @@
 package corechecks
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
 )
 
@@ -68,3 +70,33 @@ func TestCommonConfigureCustomID(t *testing.T) {
 	assert.Equal(t, string(mycheck.ID()), "test:foobar:bd63a7031add5db9")
 	mockSender.AssertExpectations(t)
 }
+
+func TestCommonConfigureRunOptions(t *testing.T) {
+	mycheck := &dummyCheck{
+		CheckBase: NewCheckBase("test"),
+	}
+	mocksender.NewMockSender(mycheck.ID())
+
+	err := mycheck.CommonConfigure([]byte(defaultsInstance), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, check.RunOptions{}, mycheck.RunOptions())
+	assert.Equal(t, context.Background(), mycheck.RunContext())
+
+	instance := `
+min_collection_interval_jitter: 5
+run_timeout: 30
+concurrency_class: heavy
+`
+	err = mycheck.CommonConfigure([]byte(instance), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, check.RunOptions{
+		Jitter:           5 * time.Second,
+		Timeout:          30 * time.Second,
+		ConcurrencyClass: "heavy",
+	}, mycheck.RunOptions())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mycheck.SetRunContext(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, mycheck.RunContext().Err())
+}
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package containers
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	containerCheckName = "container"
+)
+
+// metricsProvider is the part of the container implementation used by the check
+type metricsProvider interface {
+	Prefetch() error
+	cmetrics.ContainerMetricsProvider
+}
+
+// ContainerCheck reports the same metrics for the containers of every runtime:
+// the containers are listed from workloadmeta and their metrics are read from
+// their cgroups, v1 or v2.
+type ContainerCheck struct {
+	core.CheckBase
+	filter   *containers.Filter
+	store    *workloadmeta.Store
+	provider metricsProvider
+}
+
+func init() {
+	core.RegisterCheck(containerCheckName, ContainerFactory)
+}
+
+// ContainerFactory is exported for integration testing
+func ContainerFactory() check.Check {
+	return &ContainerCheck{
+		CheckBase: core.NewCheckBase(containerCheckName),
+	}
+}
+
+// Configure parses the check configuration and init the check
+func (c *ContainerCheck) Configure(config, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(config, source); err != nil {
+		return err
+	}
+
+	// GetSharedFilter should not return a nil instance of *Filter if there is an error during its setup.
+	filter, err := containers.GetSharedFilter()
+	if err != nil {
+		return err
+	}
+	c.filter = filter
+	c.store = workloadmeta.GetGlobalStore()
+	c.provider = providers.ContainerImpl()
+	return nil
+}
+
+// Run executes the check
+func (c *ContainerCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	if err := c.provider.Prefetch(); err != nil {
+		c.Warnf("Cannot read the cgroups of the containers: %s", err)
+		return err
+	}
+
+	now := time.Now()
+	for _, ctr := range c.store.ListContainers() {
+		if !ctr.State.Running {
+			continue
+		}
+		if c.isExcluded(ctr) {
+			log.Tracef("Container excluded: %s", ctr.ID)
+			continue
+		}
+		c.reportContainer(sender, ctr, now)
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// isExcluded applies the container filters with the namespace and labels of
+// the pod of the container, if any. The namespace of the container itself is
+// the one of its runtime, e.g. k8s.io for containerd, not the Kubernetes one.
+func (c *ContainerCheck) isExcluded(ctr workloadmeta.Container) bool {
+	if c.filter == nil {
+		return false
+	}
+	pod, err := c.store.GetKubernetesPodForContainer(ctr.ID)
+	if err != nil {
+		return c.filter.IsExcluded(ctr.Name, ctr.Image, "")
+	}
+	return c.filter.IsPodContainerExcluded(ctr.Name, ctr.Image, pod.Namespace, pod.Labels)
+}
+
+// reportContainer sends the metrics of a container, they are tagged with the
+// tags of the tagger and the runtime of the container
+func (c *ContainerCheck) reportContainer(sender aggregator.Sender, ctr workloadmeta.Container, now time.Time) {
+	tags, err := tagger.Tag(containers.BuildTaggerEntityName(ctr.ID), collectors.HighCardinality)
+	if err != nil {
+		log.Errorf("Could not collect tags for container %s: %s", ctr.ID, err)
+	}
+	tags = append(tags, "runtime:"+string(ctr.Runtime))
+	// appending the device and interface tags must copy the slice
+	tags = tags[:len(tags):len(tags)]
+
+	if !ctr.State.StartedAt.IsZero() && now.After(ctr.State.StartedAt) {
+		sender.Gauge("container.uptime", now.Sub(ctr.State.StartedAt).Seconds(), "", tags)
+	}
+
+	metrics, err := c.provider.GetContainerMetrics(ctr.ID)
+	if err != nil {
+		log.Debugf("Cannot get the metrics of container %s: %s", ctr.ID, err)
+		return
+	}
+	if metrics.CPU != nil {
+		sender.Rate("container.cpu.system", float64(metrics.CPU.System), "", tags)
+		sender.Rate("container.cpu.user", float64(metrics.CPU.User), "", tags)
+		sender.Rate("container.cpu.usage", metrics.CPU.UsageTotal, "", tags)
+		sender.Gauge("container.cpu.shares", float64(metrics.CPU.Shares), "", tags)
+		sender.Rate("container.cpu.throttled", float64(metrics.CPU.NrThrottled), "", tags)
+		if metrics.CPU.ThreadCount != 0 {
+			sender.Gauge("container.pid.thread_count", float64(metrics.CPU.ThreadCount), "", tags)
+		}
+	}
+	if metrics.Memory != nil {
+		sender.Gauge("container.memory.rss", float64(metrics.Memory.RSS), "", tags)
+		sender.Gauge("container.memory.cache", float64(metrics.Memory.Cache), "", tags)
+		if metrics.Memory.MemUsageInBytes != 0 {
+			sender.Gauge("container.memory.usage", float64(metrics.Memory.MemUsageInBytes), "", tags)
+		}
+		if metrics.Memory.SwapPresent {
+			sender.Gauge("container.memory.swap", float64(metrics.Memory.Swap), "", tags)
+		}
+		sender.Gauge("container.memory.kernel", float64(metrics.Memory.KernMemUsage), "", tags)
+		sender.Gauge("container.memory.failed_count", float64(metrics.Memory.MemFailCnt), "", tags)
+		if metrics.Memory.SoftMemLimit > 0 {
+			sender.Gauge("container.memory.soft_limit", float64(metrics.Memory.SoftMemLimit), "", tags)
+		}
+	}
+	if metrics.IO != nil {
+		reportContainerIO(sender, metrics.IO, tags)
+		sender.Gauge("container.pid.open_files", float64(metrics.IO.OpenFiles), "", tags)
+	}
+
+	limits, err := c.provider.GetContainerLimits(ctr.ID)
+	if err != nil {
+		log.Debugf("Cannot get the limits of container %s: %s", ctr.ID, err)
+	} else {
+		sender.Gauge("container.cpu.limit", limits.CPULimit, "", tags)
+		if limits.MemLimit > 0 {
+			sender.Gauge("container.memory.limit", float64(limits.MemLimit), "", tags)
+		}
+		if limits.ThreadLimit > 0 {
+			sender.Gauge("container.pid.thread_limit", float64(limits.ThreadLimit), "", tags)
+		}
+	}
+
+	network, err := c.provider.GetNetworkMetrics(ctr.ID, nil)
+	if err != nil {
+		log.Debugf("Cannot get the network metrics of container %s: %s", ctr.ID, err)
+		return
+	}
+	for _, iface := range network {
+		ifaceTags := append(tags, "interface:"+iface.NetworkName)
+		sender.Rate("container.net.sent", float64(iface.BytesSent), "", ifaceTags)
+		sender.Rate("container.net.rcvd", float64(iface.BytesRcvd), "", ifaceTags)
+	}
+}
+
+// reportContainerIO sends the I/O per device, or the sum without device mapping
+func reportContainerIO(sender aggregator.Sender, io *cmetrics.ContainerIOStats, tags []string) {
+	if len(io.DeviceReadBytes) > 0 {
+		for dev, value := range io.DeviceReadBytes {
+			sender.Rate("container.io.read", float64(value), "", append(tags, "device:"+dev))
+		}
+	} else {
+		sender.Rate("container.io.read", float64(io.ReadBytes), "", tags)
+	}
+
+	if len(io.DeviceWriteBytes) > 0 {
+		for dev, value := range io.DeviceWriteBytes {
+			sender.Rate("container.io.write", float64(value), "", append(tags, "device:"+dev))
+		}
+	} else {
+		sender.Rate("container.io.write", float64(io.WriteBytes), "", tags)
+	}
+}
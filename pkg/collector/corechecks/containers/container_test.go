@@ -0,0 +1,216 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package containers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+type fakeMetricsProvider struct {
+	metrics map[string]*cmetrics.ContainerMetrics
+	limits  map[string]*cmetrics.ContainerLimits
+	network map[string]cmetrics.ContainerNetStats
+}
+
+func (p *fakeMetricsProvider) Prefetch() error {
+	return nil
+}
+
+func (p *fakeMetricsProvider) GetContainerMetrics(containerID string) (*cmetrics.ContainerMetrics, error) {
+	if m, found := p.metrics[containerID]; found {
+		return m, nil
+	}
+	return nil, fmt.Errorf("no cgroup for %s", containerID)
+}
+
+func (p *fakeMetricsProvider) GetContainerLimits(containerID string) (*cmetrics.ContainerLimits, error) {
+	if l, found := p.limits[containerID]; found {
+		return l, nil
+	}
+	return nil, fmt.Errorf("no cgroup for %s", containerID)
+}
+
+func (p *fakeMetricsProvider) GetNetworkMetrics(containerID string, networks map[string]string) (cmetrics.ContainerNetStats, error) {
+	return p.network[containerID], nil
+}
+
+func newTestStore(t *testing.T, entities ...workloadmeta.Entity) *workloadmeta.Store {
+	var dump workloadmeta.Dump
+	for _, entity := range entities {
+		raw, err := json.Marshal(entity)
+		require.NoError(t, err)
+		source := workloadmeta.SourceKubelet
+		if ctr, ok := entity.(workloadmeta.Container); ok {
+			source = workloadmeta.Source(ctr.Runtime)
+		}
+		dump.Entities = append(dump.Entities, workloadmeta.DumpEntity{
+			Kind:   entity.GetID().Kind,
+			Source: source,
+			Entity: raw,
+		})
+	}
+	store := workloadmeta.NewStore(nil)
+	require.NoError(t, store.Load(dump))
+	return store
+}
+
+func TestContainerCheckRun(t *testing.T) {
+	running := workloadmeta.ContainerState{Running: true, StartedAt: time.Now().Add(-42 * time.Second)}
+	store := newTestStore(t,
+		workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "docker1"},
+			Runtime:  workloadmeta.ContainerRuntimeDocker,
+			State:    running,
+		},
+		workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "containerd1"},
+			Runtime:  workloadmeta.ContainerRuntimeContainerd,
+			State:    running,
+		},
+		workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "stopped1"},
+			Runtime:  workloadmeta.ContainerRuntimeDocker,
+		},
+	)
+	provider := &fakeMetricsProvider{
+		metrics: map[string]*cmetrics.ContainerMetrics{
+			"docker1": {
+				CPU:    &cmetrics.ContainerCPUStats{User: 10, System: 20, UsageTotal: 30, Shares: 1024, NrThrottled: 1, ThreadCount: 5},
+				Memory: &cmetrics.ContainerMemStats{RSS: 100, Cache: 200, MemUsageInBytes: 300},
+				IO: &cmetrics.ContainerIOStats{
+					ReadBytes:        1000,
+					WriteBytes:       2000,
+					DeviceReadBytes:  map[string]uint64{"sda": 1000},
+					DeviceWriteBytes: map[string]uint64{},
+					OpenFiles:        7,
+				},
+			},
+			"containerd1": {
+				CPU:    &cmetrics.ContainerCPUStats{User: 11, System: 21},
+				Memory: &cmetrics.ContainerMemStats{RSS: 101, Swap: 5, SwapPresent: true},
+			},
+		},
+		limits: map[string]*cmetrics.ContainerLimits{
+			"docker1": {CPULimit: 50, MemLimit: 4096, ThreadLimit: 100},
+		},
+		network: map[string]cmetrics.ContainerNetStats{
+			"docker1": {{NetworkName: "eth0", BytesSent: 12, BytesRcvd: 34}},
+		},
+	}
+	c := &ContainerCheck{
+		CheckBase: core.NewCheckBase(containerCheckName),
+		store:     store,
+		provider:  provider,
+	}
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+
+	docker := []string{"runtime:docker"}
+	sender.AssertMetric(t, "Rate", "container.cpu.user", 10, "", docker)
+	sender.AssertMetric(t, "Rate", "container.cpu.system", 20, "", docker)
+	sender.AssertMetric(t, "Rate", "container.cpu.usage", 30, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.cpu.shares", 1024, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.cpu.limit", 50, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.pid.thread_count", 5, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.pid.thread_limit", 100, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.pid.open_files", 7, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.memory.rss", 100, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.memory.usage", 300, "", docker)
+	sender.AssertMetric(t, "Gauge", "container.memory.limit", 4096, "", docker)
+	sender.AssertMetric(t, "Rate", "container.io.read", 1000, "", []string{"runtime:docker", "device:sda"})
+	sender.AssertMetric(t, "Rate", "container.io.write", 2000, "", docker)
+	sender.AssertMetric(t, "Rate", "container.net.sent", 12, "", []string{"runtime:docker", "interface:eth0"})
+	sender.AssertMetric(t, "Rate", "container.net.rcvd", 34, "", []string{"runtime:docker", "interface:eth0"})
+	sender.AssertMetricInRange(t, "Gauge", "container.uptime", 42, 60, "", docker)
+
+	// same metrics for the containers of the other runtimes
+	containerd := []string{"runtime:containerd"}
+	sender.AssertMetric(t, "Rate", "container.cpu.user", 11, "", containerd)
+	sender.AssertMetric(t, "Gauge", "container.memory.rss", 101, "", containerd)
+	sender.AssertMetric(t, "Gauge", "container.memory.swap", 5, "", containerd)
+	sender.AssertNotCalled(t, "Gauge", "container.memory.swap", 0.0, "", docker)
+	sender.AssertNotCalled(t, "Gauge", "container.cpu.limit", mock.Anything, "", containerd)
+
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestContainerCheckFilter(t *testing.T) {
+	running := workloadmeta.ContainerState{Running: true}
+	store := newTestStore(t,
+		workloadmeta.Container{
+			EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "coredns"},
+			EntityMeta: workloadmeta.EntityMeta{Name: "coredns", Namespace: "k8s.io"},
+			Image:      "k8s.gcr.io/coredns:1.6.7",
+			Runtime:    workloadmeta.ContainerRuntimeContainerd,
+			State:      running,
+		},
+		workloadmeta.Container{
+			EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "app"},
+			EntityMeta: workloadmeta.EntityMeta{Name: "app", Namespace: "k8s.io"},
+			Image:      "app:latest",
+			Runtime:    workloadmeta.ContainerRuntimeContainerd,
+			State:      running,
+		},
+		workloadmeta.Container{
+			EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "standalone"},
+			EntityMeta: workloadmeta.EntityMeta{Name: "standalone"},
+			Image:      "standalone:latest",
+			Runtime:    workloadmeta.ContainerRuntimeDocker,
+			State:      running,
+		},
+		workloadmeta.KubernetesPod{
+			EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod1"},
+			EntityMeta: workloadmeta.EntityMeta{Name: "coredns-abcde", Namespace: "kube-system"},
+			Containers: []string{"coredns"},
+		},
+		workloadmeta.KubernetesPod{
+			EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod2"},
+			EntityMeta: workloadmeta.EntityMeta{Name: "app-abcde", Namespace: "default"},
+			Containers: []string{"app"},
+		},
+	)
+	filter, err := containers.NewFilter(nil, []string{"kube_namespace:kube-system"})
+	require.NoError(t, err)
+	provider := &fakeMetricsProvider{
+		metrics: map[string]*cmetrics.ContainerMetrics{
+			"coredns":    {Memory: &cmetrics.ContainerMemStats{RSS: 1}},
+			"app":        {Memory: &cmetrics.ContainerMemStats{RSS: 2}},
+			"standalone": {Memory: &cmetrics.ContainerMemStats{RSS: 3}},
+		},
+	}
+	c := &ContainerCheck{
+		CheckBase: core.NewCheckBase(containerCheckName),
+		filter:    filter,
+		store:     store,
+		provider:  provider,
+	}
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+
+	// the containerd container of the kube-system pod is excluded by the
+	// namespace of its pod, not by its containerd namespace
+	sender.AssertNotCalled(t, "Gauge", "container.memory.rss", 1.0, "", []string{"runtime:containerd"})
+	sender.AssertMetric(t, "Gauge", "container.memory.rss", 2, "", []string{"runtime:containerd"})
+	sender.AssertMetric(t, "Gauge", "container.memory.rss", 3, "", []string{"runtime:docker"})
+}
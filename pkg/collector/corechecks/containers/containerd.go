@@ -31,6 +31,7 @@ import (
 	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
 	cgroup "github.com/DataDog/datadog-agent/pkg/util/containers/providers/cgroup"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 const (
@@ -83,6 +84,7 @@ func (c *ContainerdCheck) Configure(config, initConfig integration.Data, source
 		return err
 	}
 	c.sub.Filters = c.instance.ContainerdFilters
+	log.Warnf("The container metrics of the containerd check are deprecated, please use the container check, the containerd check will only report the events in a future version")
 	// GetSharedFilter should not return a nil instance of *Filter if there is an error during its setup.
 	fil, err := ddContainers.GetSharedFilter()
 	if err != nil {
@@ -255,8 +257,10 @@ func computeMetrics(sender aggregator.Sender, cu cutil.ContainerdItf, fil *ddCon
 }
 
 func isExcluded(ctn containers.Container, fil *ddContainers.Filter) bool {
-	// The container name is not available in Containerd, we only rely on image name based exclusion
-	return fil.IsExcluded("", ctn.Image, "")
+	// The container name is not available in Containerd, we rely on the image
+	// and on the namespace and labels of the pod of the container
+	podNamespace, podLabels := workloadmeta.GetGlobalStore().GetPodMetaForContainer(ctn.ID, ctn.Labels)
+	return fil.IsPodContainerExcluded("", ctn.Image, podNamespace, podLabels)
 }
 
 func convertTasktoMetrics(metricTask *containerdTypes.Metric) (*cgroups.Metrics, error) {
@@ -28,12 +28,14 @@ import (
 type mockItf struct {
 	mockEvents      func() containerd.EventService
 	mockContainer   func() ([]containerd.Container, error)
+	mockLoad        func(id string) (containerd.Container, error)
 	mockMetadata    func() (containerd.Version, error)
 	mockImageSize   func(ctn containerd.Container) (int64, error)
 	mockTaskMetrics func(ctn containerd.Container) (*types.Metric, error)
 	mockTaskPids    func(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 	mockInfo        func(ctn containerd.Container) (containers.Container, error)
 	mockNamespace   func() string
+	mockNamespaces  func() []string
 }
 
 func (m *mockItf) ImageSize(ctn containerd.Container) (int64, error) {
@@ -60,6 +62,18 @@ func (m *mockItf) Namespace() string {
 	return m.mockNamespace()
 }
 
+func (m *mockItf) Namespaces() []string {
+	return m.mockNamespaces()
+}
+
+func (m *mockItf) WithNamespace(namespace string) containerdutil.ContainerdItf {
+	return m
+}
+
+func (m *mockItf) Container(id string) (containerd.Container, error) {
+	return m.mockLoad(id)
+}
+
 func (m *mockItf) Containers() ([]containerd.Container, error) {
 	return m.mockContainer()
 }
@@ -410,6 +410,7 @@ func (d *DockerCheck) Configure(config, initConfig integration.Data, source stri
 	}
 
 	d.instance.Parse(config)
+	log.Warnf("The container metrics of the docker check are deprecated, please use the container check, the docker check will only report the events and service checks in a future version")
 
 	if len(d.instance.FilteredEventType) == 0 {
 		d.instance.FilteredEventType = []string{"top", "exec_create", "exec_start", "exec_die"}
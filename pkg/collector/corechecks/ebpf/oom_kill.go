@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// FIXME: we require the `cgo` build tag because of this dep relationship:
+// github.com/DataDog/datadog-agent/pkg/process/net depends on `github.com/DataDog/agent-payload/process`,
+// which has a hard dependency on `github.com/DataDog/zstd`, which requires CGO.
+// Should be removed once `github.com/DataDog/agent-payload/process` can be imported with CGO disabled.
+// +build cgo
+// +build linux
+
+package ebpf
+
+import (
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	oomKillCheckName = "oom_kill"
+)
+
+// OOMKillConfig is the config of the OOM Kill check
+type OOMKillConfig struct {
+	CollectOOMKill bool `yaml:"collect_oom_kill"`
+}
+
+// OOMKillCheck grabs the processes killed by the OOM killer
+type OOMKillCheck struct {
+	core.CheckBase
+	instance *OOMKillConfig
+}
+
+func init() {
+	core.RegisterCheck(oomKillCheckName, OOMKillFactory)
+}
+
+// OOMKillFactory is exported for integration testing
+func OOMKillFactory() check.Check {
+	return &OOMKillCheck{
+		CheckBase: core.NewCheckBase(oomKillCheckName),
+		instance:  &OOMKillConfig{},
+	}
+}
+
+// Parse parses the check configuration and init the check
+func (c *OOMKillConfig) Parse(data []byte) error {
+	// default values
+	c.CollectOOMKill = true
+
+	return yaml.Unmarshal(data, c)
+}
+
+// Configure parses the check configuration and init the check
+func (m *OOMKillCheck) Configure(config, initConfig integration.Data, source string) error {
+	// TODO: Remove that hard-code and put it somewhere else
+	process_net.SetSystemProbePath(dd_config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+
+	err := m.CommonConfigure(config, source)
+	if err != nil {
+		return err
+	}
+
+	return m.instance.Parse(config)
+}
+
+// Run executes the check
+func (m *OOMKillCheck) Run() error {
+	if !m.instance.CollectOOMKill {
+		return nil
+	}
+
+	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return err
+	}
+
+	stats, err := sysProbeUtil.GetCheck(oomKillCheckName)
+	if err != nil {
+		return err
+	}
+
+	sender, err := aggregator.GetSender(m.ID())
+	if err != nil {
+		return err
+	}
+
+	for _, line := range stats.([]oomkill.Stats) {
+		tags := oomKillTags(line)
+		sender.Count("oom_kill.oom_process.count", 1, "", tags)
+		sender.Event(oomKillEvent(line, tags))
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// oomKillTags returns the tags of the container of the killed process with the names of the processes
+func oomKillTags(line oomkill.Stats) []string {
+	var tags []string
+	if line.ContainerID != "" {
+		entityID := containers.BuildTaggerEntityName(line.ContainerID)
+		var err error
+		tags, err = tagger.Tag(entityID, collectors.HighCardinality)
+		if err != nil {
+			log.Errorf("Could not collect tags for container %s: %s", line.ContainerID, err)
+		}
+	}
+
+	triggerType := "system"
+	if line.MemCgOOM {
+		triggerType = "cgroup"
+	}
+	return append(tags,
+		"process_name:"+line.ProcessName,
+		"trigger_process_name:"+line.TriggerProcessName,
+		"trigger_type:"+triggerType,
+	)
+}
+
+func oomKillEvent(line oomkill.Stats, tags []string) metrics.Event {
+	scope := "the system"
+	if line.MemCgOOM {
+		scope = "its cgroup"
+	}
+	return metrics.Event{
+		Title:          fmt.Sprintf("Process OOM killed: %s (pid %d)", line.ProcessName, line.Pid),
+		Text:           fmt.Sprintf("The OOM killer was invoked because %s was out of memory, when %s (pid %d) allocated memory. It killed %s (pid %d), which had %d pages of memory available.", scope, line.TriggerProcessName, line.TriggerPid, line.ProcessName, line.Pid, line.Pages),
+		Ts:             time.Now().Unix(),
+		Priority:       metrics.EventPriorityNormal,
+		AlertType:      metrics.EventAlertTypeError,
+		SourceTypeName: oomKillCheckName,
+		EventType:      oomKillCheckName,
+		AggregationKey: fmt.Sprintf("oom_kill:%d", line.Pid),
+		Tags:           tags,
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
 	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -97,10 +98,11 @@ func (t *TCPQueueLengthCheck) Run() error {
 		return err
 	}
 
-	data, err := sysProbeUtil.GetCheck("tcp_queue_length")
+	stats, err := sysProbeUtil.GetCheck("tcp_queue_length")
 	if err != nil {
 		return err
 	}
+	data := stats.([]tcpqueuelength.Stats)
 
 	sender, err := aggregator.GetSender(t.ID())
 	if err != nil {
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// FIXME: we require the `cgo` build tag because of this dep relationship:
+// github.com/DataDog/datadog-agent/pkg/process/net depends on `github.com/DataDog/agent-payload/process`,
+// which has a hard dependency on `github.com/DataDog/zstd`, which requires CGO.
+// Should be removed once `github.com/DataDog/agent-payload/process` can be imported with CGO disabled.
+// +build cgo
+// +build linux
+
+package ebpf
+
+import (
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpstats"
+	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	tcpStatsCheckName = "tcp_stats"
+)
+
+// TCPStatsConfig is the config of the TCP stats check
+type TCPStatsConfig struct {
+	CollectTCPStats bool `yaml:"collect_tcp_stats"`
+}
+
+// TCPStatsCheck grabs the TCP retransmits and resets per container
+type TCPStatsCheck struct {
+	core.CheckBase
+	instance *TCPStatsConfig
+}
+
+func init() {
+	core.RegisterCheck(tcpStatsCheckName, TCPStatsFactory)
+}
+
+// TCPStatsFactory is exported for integration testing
+func TCPStatsFactory() check.Check {
+	return &TCPStatsCheck{
+		CheckBase: core.NewCheckBase(tcpStatsCheckName),
+		instance:  &TCPStatsConfig{},
+	}
+}
+
+// Parse parses the check configuration and init the check
+func (c *TCPStatsConfig) Parse(data []byte) error {
+	// default values
+	c.CollectTCPStats = true
+
+	return yaml.Unmarshal(data, c)
+}
+
+// Configure parses the check configuration and init the check
+func (t *TCPStatsCheck) Configure(config, initConfig integration.Data, source string) error {
+	// TODO: Remove that hard-code and put it somewhere else
+	process_net.SetSystemProbePath(dd_config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+
+	err := t.CommonConfigure(config, source)
+	if err != nil {
+		return err
+	}
+
+	return t.instance.Parse(config)
+}
+
+// Run executes the check
+func (t *TCPStatsCheck) Run() error {
+	if !t.instance.CollectTCPStats {
+		return nil
+	}
+
+	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return err
+	}
+
+	stats, err := sysProbeUtil.GetCheck(tcpStatsCheckName)
+	if err != nil {
+		return err
+	}
+
+	sender, err := aggregator.GetSender(t.ID())
+	if err != nil {
+		return err
+	}
+
+	// the system-probe flushes its counters, so the values are the ones since the previous run
+	for _, line := range stats.([]tcpstats.Stats) {
+		var tags []string
+		if line.ContainerID != "" {
+			entityID := containers.BuildTaggerEntityName(line.ContainerID)
+			tags, err = tagger.Tag(entityID, collectors.OrchestratorCardinality)
+			if err != nil {
+				log.Errorf("Could not collect tags for container %s: %s", line.ContainerID, err)
+			}
+		}
+
+		sender.Count("tcp.retransmits", float64(line.Retransmits), "", tags)
+		sender.Count("tcp.resets.sent", float64(line.SentResets), "", tags)
+		sender.Count("tcp.resets.received", float64(line.ReceivedResets), "", tags)
+	}
+
+	sender.Commit()
+	return nil
+}
@@ -191,6 +191,9 @@ func (c *APMCheck) IsTelemetryEnabled() bool {
 	return c.telemetry
 }
 
+// Cancel does nothing
+func (c *APMCheck) Cancel() {}
+
 // Stop sends a termination signal to the APM process
 func (c *APMCheck) Stop() {
 	if atomic.LoadUint32(&c.running) == 0 {
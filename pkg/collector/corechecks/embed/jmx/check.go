@@ -56,6 +56,9 @@ func (c *JMXCheck) Run() error {
 	return nil
 }
 
+// Cancel does nothing
+func (c *JMXCheck) Cancel() {}
+
 func (c *JMXCheck) Stop() {
 	close(c.stop)
 	state.unscheduleCheck(c)
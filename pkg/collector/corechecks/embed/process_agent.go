@@ -188,6 +188,9 @@ func (c *ProcessAgentCheck) IsTelemetryEnabled() bool {
 	return c.telemetry
 }
 
+// Cancel does nothing
+func (c *ProcessAgentCheck) Cancel() {}
+
 // Stop sends a termination signal to the process-agent process
 func (c *ProcessAgentCheck) Stop() {
 	if atomic.LoadUint32(&c.running) == 0 {
@@ -22,6 +22,7 @@ func (c *TestCheck) Version() string                           { return "" }
 func (c *TestCheck) ConfigSource() string                      { return "" }
 func (c *TestCheck) Run() error                                { return nil }
 func (c *TestCheck) Stop()                                     {}
+func (c *TestCheck) Cancel()                                   {}
 func (c *TestCheck) Interval() time.Duration                   { return 1 }
 func (c *TestCheck) ID() check.ID                              { return check.ID(c.String()) }
 func (c *TestCheck) GetWarnings() []error                      { return []error{} }
@@ -77,10 +77,16 @@ type NetworkCheck struct {
 }
 
 type networkInstanceConfig struct {
-	CollectConnectionState   bool     `yaml:"collect_connection_state"`
-	ExcludedInterfaces       []string `yaml:"excluded_interfaces"`
-	ExcludedInterfaceRe      string   `yaml:"excluded_interface_re"`
+	CollectConnectionState   bool              `yaml:"collect_connection_state"`
+	ExcludedInterfaces       []string          `yaml:"excluded_interfaces"`
+	ExcludedInterfaceRe      string            `yaml:"excluded_interface_re"`
+	InterfaceInclude         []string          `yaml:"interface_include"`
+	InterfaceExclude         []string          `yaml:"interface_exclude"`
+	DeviceTagRe              map[string]string `yaml:"device_tag_re"`
 	ExcludedInterfacePattern *regexp.Regexp
+	InterfaceIncludePatterns []*regexp.Regexp
+	InterfaceExcludePatterns []*regexp.Regexp
+	DeviceTagPatterns        map[*regexp.Regexp][]string
 }
 
 type networkInitConfig struct{}
@@ -100,6 +106,14 @@ type networkStats interface {
 type defaultNetworkStats struct{}
 
 func (n defaultNetworkStats) IOCounters(pernic bool) ([]net.IOCountersStat, error) {
+	// netlink avoids reading and parsing /proc/net/dev, which is slow with many interfaces
+	if pernic {
+		counters, err := netlinkIOCounters()
+		if err == nil {
+			return counters, nil
+		}
+		log.Debugf("Unable to get the interface counters from netlink, falling back to /proc/net/dev: %s", err)
+	}
 	return net.IOCounters(pernic)
 }
 
@@ -128,7 +142,7 @@ func (c *NetworkCheck) Run() error {
 	}
 	for _, interfaceIO := range ioByInterface {
 		if !c.isDeviceExcluded(interfaceIO.Name) {
-			submitInterfaceMetrics(sender, interfaceIO)
+			submitInterfaceMetrics(sender, interfaceIO, c.deviceTags(interfaceIO.Name))
 		}
 	}
 
@@ -188,14 +202,38 @@ func (c *NetworkCheck) isDeviceExcluded(deviceName string) bool {
 			return true
 		}
 	}
-	if c.config.instance.ExcludedInterfacePattern != nil {
-		return c.config.instance.ExcludedInterfacePattern.MatchString(deviceName)
+	if c.config.instance.ExcludedInterfacePattern != nil && c.config.instance.ExcludedInterfacePattern.MatchString(deviceName) {
+		return true
+	}
+	// `interface_exclude` takes precedence over `interface_include`
+	for _, re := range c.config.instance.InterfaceExcludePatterns {
+		if re.MatchString(deviceName) {
+			return true
+		}
+	}
+	if len(c.config.instance.InterfaceIncludePatterns) == 0 {
+		return false
+	}
+	for _, re := range c.config.instance.InterfaceIncludePatterns {
+		if re.MatchString(deviceName) {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceTags returns the tags of an interface, with the ones of `device_tag_re`
+func (c *NetworkCheck) deviceTags(deviceName string) []string {
+	tags := []string{fmt.Sprintf("device:%s", deviceName)}
+	for re, deviceTags := range c.config.instance.DeviceTagPatterns {
+		if re.MatchString(deviceName) {
+			tags = append(tags, deviceTags...)
+		}
 	}
-	return false
+	return tags
 }
 
-func submitInterfaceMetrics(sender aggregator.Sender, interfaceIO net.IOCountersStat) {
-	tags := []string{fmt.Sprintf("device:%s", interfaceIO.Name)}
+func submitInterfaceMetrics(sender aggregator.Sender, interfaceIO net.IOCountersStat, tags []string) {
 	sender.Rate("system.net.bytes_rcvd", float64(interfaceIO.BytesRecv), "", tags)
 	sender.Rate("system.net.bytes_sent", float64(interfaceIO.BytesSent), "", tags)
 	sender.Rate("system.net.packets_in.count", float64(interfaceIO.PacketsRecv), "", tags)
@@ -299,6 +337,30 @@ func (c *NetworkCheck) Configure(rawInstance integration.Data, rawInitConfig int
 		}
 	}
 
+	for _, reString := range c.config.instance.InterfaceInclude {
+		pattern, err := regexp.Compile(reString)
+		if err != nil {
+			return fmt.Errorf("failed to parse network check option interface_include: %s", err)
+		}
+		c.config.instance.InterfaceIncludePatterns = append(c.config.instance.InterfaceIncludePatterns, pattern)
+	}
+	for _, reString := range c.config.instance.InterfaceExclude {
+		pattern, err := regexp.Compile(reString)
+		if err != nil {
+			return fmt.Errorf("failed to parse network check option interface_exclude: %s", err)
+		}
+		c.config.instance.InterfaceExcludePatterns = append(c.config.instance.InterfaceExcludePatterns, pattern)
+	}
+
+	c.config.instance.DeviceTagPatterns = make(map[*regexp.Regexp][]string)
+	for reString, tags := range c.config.instance.DeviceTagRe {
+		pattern, err := regexp.Compile(reString)
+		if err != nil {
+			return fmt.Errorf("failed to parse network check option device_tag_re: %s", err)
+		}
+		c.config.instance.DeviceTagPatterns[pattern] = strings.Split(tags, ",")
+	}
+
 	return nil
 }
 
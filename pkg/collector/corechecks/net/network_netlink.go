@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/shirou/gopsutil/net"
+)
+
+// iflaStats64 is IFLA_STATS64, the 64-bit counters of an interface, which
+// the syscall package doesn't define
+const iflaStats64 = 0x17
+
+// sizeofLinkStats64 is the size of the fields of rtnl_link_stats64 we read
+const sizeofLinkStats64 = 8 * 8
+
+var nativeEndian binary.ByteOrder
+
+// In lack of binary.NativeEndian ...
+func init() {
+	var i int32 = 0x01020304
+	if *(*byte)(unsafe.Pointer(&i)) == 0x04 {
+		nativeEndian = binary.LittleEndian
+	} else {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// netlinkIOCounters returns the counters of the interfaces with a single
+// RTM_GETLINK dump
+func netlinkIOCounters() ([]net.IOCountersStat, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	return parseNetlinkLinks(data)
+}
+
+// parseNetlinkLinks parses the RTM_NEWLINK messages of a RTM_GETLINK dump
+func parseNetlinkLinks(data []byte) ([]net.IOCountersStat, error) {
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var counters []net.IOCountersStat
+	for i := range msgs {
+		if msgs[i].Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+		if msgs[i].Header.Type != syscall.RTM_NEWLINK {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(&msgs[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var stat net.IOCountersStat
+		hasStats := false
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFLA_IFNAME:
+				stat.Name = string(bytes.TrimRight(attr.Value, "\x00"))
+			case iflaStats64:
+				if len(attr.Value) < sizeofLinkStats64 {
+					return nil, fmt.Errorf("IFLA_STATS64 attribute too short: %d bytes", len(attr.Value))
+				}
+				stat.PacketsRecv = nativeEndian.Uint64(attr.Value[0:])
+				stat.PacketsSent = nativeEndian.Uint64(attr.Value[8:])
+				stat.BytesRecv = nativeEndian.Uint64(attr.Value[16:])
+				stat.BytesSent = nativeEndian.Uint64(attr.Value[24:])
+				stat.Errin = nativeEndian.Uint64(attr.Value[32:])
+				stat.Errout = nativeEndian.Uint64(attr.Value[40:])
+				stat.Dropin = nativeEndian.Uint64(attr.Value[48:])
+				stat.Dropout = nativeEndian.Uint64(attr.Value[56:])
+				hasStats = true
+			}
+		}
+		// kernels before 2.6.35 don't send the 64-bit counters
+		if !hasStats {
+			return nil, fmt.Errorf("no IFLA_STATS64 attribute for interface %s", stat.Name)
+		}
+		counters = append(counters, stat)
+	}
+
+	if len(counters) == 0 {
+		return nil, errors.New("no interface in the RTM_GETLINK dump")
+	}
+	return counters, nil
+}
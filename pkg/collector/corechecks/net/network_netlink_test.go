@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package net
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rtAttr encodes a route attribute, padded to 4 bytes
+func rtAttr(typ uint16, value []byte) []byte {
+	length := syscall.SizeofRtAttr + len(value)
+	b := make([]byte, (length+syscall.RTA_ALIGNTO-1) & ^(syscall.RTA_ALIGNTO-1))
+	nativeEndian.PutUint16(b[0:], uint16(length))
+	nativeEndian.PutUint16(b[2:], typ)
+	copy(b[syscall.SizeofRtAttr:], value)
+	return b
+}
+
+// netlinkMessage encodes a netlink message with an ifinfomsg header
+func netlinkMessage(typ uint16, attrs ...[]byte) []byte {
+	b := make([]byte, syscall.SizeofNlMsghdr+syscall.SizeofIfInfomsg)
+	for _, attr := range attrs {
+		b = append(b, attr...)
+	}
+	nativeEndian.PutUint32(b[0:], uint32(len(b)))
+	nativeEndian.PutUint16(b[4:], typ)
+	return b
+}
+
+func linkStats64(values ...uint64) []byte {
+	b := make([]byte, 23*8)
+	for i, v := range values {
+		nativeEndian.PutUint64(b[i*8:], v)
+	}
+	return b
+}
+
+func TestParseNetlinkLinks(t *testing.T) {
+	var data []byte
+	data = append(data, netlinkMessage(syscall.RTM_NEWLINK,
+		rtAttr(syscall.IFLA_IFNAME, []byte("lo\x00")),
+		rtAttr(iflaStats64, linkStats64(1, 2, 3, 4, 5, 6, 7, 8)),
+	)...)
+	data = append(data, netlinkMessage(syscall.RTM_NEWLINK,
+		rtAttr(syscall.IFLA_IFNAME, []byte("eth0\x00")),
+		rtAttr(iflaStats64, linkStats64(10, 20, 30, 40)),
+	)...)
+	data = append(data, netlinkMessage(syscall.NLMSG_DONE)...)
+
+	counters, err := parseNetlinkLinks(data)
+	require.NoError(t, err)
+	assert.Equal(t, []net.IOCountersStat{
+		{Name: "lo", PacketsRecv: 1, PacketsSent: 2, BytesRecv: 3, BytesSent: 4, Errin: 5, Errout: 6, Dropin: 7, Dropout: 8},
+		{Name: "eth0", PacketsRecv: 10, PacketsSent: 20, BytesRecv: 30, BytesSent: 40},
+	}, counters)
+}
+
+func TestParseNetlinkLinksErrors(t *testing.T) {
+	_, err := parseNetlinkLinks(netlinkMessage(syscall.RTM_NEWLINK, rtAttr(syscall.IFLA_IFNAME, []byte("lo\x00"))))
+	assert.EqualError(t, err, "no IFLA_STATS64 attribute for interface lo")
+
+	_, err = parseNetlinkLinks(netlinkMessage(syscall.NLMSG_DONE))
+	assert.Error(t, err)
+}
@@ -485,3 +485,90 @@ excluded_interface_re: "eth[0-9]"
 	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.count", float64(26), "", lo0Tags)
 	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.error", float64(27), "", lo0Tags)
 }
+
+func TestInterfaceIncludeExclude(t *testing.T) {
+	net := &fakeNetworkStats{
+		counterStats: []net.IOCountersStat{
+			{
+				Name:        "eth0",
+				BytesRecv:   10,
+				BytesSent:   11,
+				PacketsRecv: 12,
+				Errin:       13,
+				PacketsSent: 14,
+				Errout:      15,
+			},
+			{
+				Name:        "eth1",
+				BytesRecv:   16,
+				BytesSent:   17,
+				PacketsRecv: 18,
+				Errin:       19,
+				PacketsSent: 20,
+				Errout:      21,
+			},
+			{
+				Name:        "lo0",
+				BytesRecv:   22,
+				BytesSent:   23,
+				PacketsRecv: 24,
+				Errin:       25,
+				PacketsSent: 26,
+				Errout:      27,
+			},
+		},
+	}
+
+	networkCheck := NetworkCheck{
+		net: net,
+	}
+
+	rawInstanceConfig := []byte(`
+interface_include:
+    - "eth[0-9]"
+    - "wlan.*"
+interface_exclude:
+    - "eth1"
+device_tag_re:
+    "eth.*": interface_type:ethernet,role:public
+`)
+
+	err := networkCheck.Configure(rawInstanceConfig, []byte(``), "test")
+	assert.Nil(t, err)
+
+	mockSender := mocksender.NewMockSender(networkCheck.ID())
+
+	mockSender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Rate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("MonotonicCount", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Commit").Return()
+
+	err = networkCheck.Run()
+	assert.Nil(t, err)
+
+	eth0Tags := []string{"device:eth0", "interface_type:ethernet", "role:public"}
+	mockSender.AssertCalled(t, "Rate", "system.net.bytes_rcvd", float64(10), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.bytes_sent", float64(11), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.packets_in.count", float64(12), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.packets_in.error", float64(13), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.count", float64(14), "", eth0Tags)
+	mockSender.AssertCalled(t, "Rate", "system.net.packets_out.error", float64(15), "", eth0Tags)
+
+	eth1Tags := []string{"device:eth1", "interface_type:ethernet", "role:public"}
+	mockSender.AssertNotCalled(t, "Rate", "system.net.bytes_rcvd", float64(16), "", eth1Tags)
+	mockSender.AssertNotCalled(t, "Rate", "system.net.bytes_sent", float64(17), "", eth1Tags)
+
+	lo0Tags := []string{"device:lo0"}
+	mockSender.AssertNotCalled(t, "Rate", "system.net.bytes_rcvd", float64(22), "", lo0Tags)
+	mockSender.AssertNotCalled(t, "Rate", "system.net.bytes_sent", float64(23), "", lo0Tags)
+}
+
+func TestInvalidInterfaceIncludeExclude(t *testing.T) {
+	check := NetworkCheck{}
+	err := check.Configure([]byte("interface_include:\n  - \"eth[\""), []byte(``), "test")
+	assert.Error(t, err)
+
+	check = NetworkCheck{}
+	err = check.Configure([]byte("interface_exclude:\n  - \"eth[\""), []byte(``), "test")
+	assert.Error(t, err)
+}
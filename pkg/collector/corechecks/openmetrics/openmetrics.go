@@ -0,0 +1,359 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openmetrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	checkName = "openmetrics_core"
+
+	defaultTimeout         = 10                // in seconds
+	defaultMaxExposition   = 100 * 1024 * 1024 // in bytes
+	healthServiceCheckName = "openmetrics.health"
+
+	acceptText     = "text/plain;version=0.0.4;q=0.5,*/*;q=0.1"
+	acceptProtobuf = protobufContentType + ";proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7," + acceptText
+)
+
+var validTypes = map[metricType]bool{
+	typeCounter:   true,
+	typeGauge:     true,
+	typeHistogram: true,
+	typeSummary:   true,
+	typeUntyped:   true,
+}
+
+// instanceConfig is the configuration of an instance, see the conf.yaml.example of the check
+type instanceConfig struct {
+	Endpoint               string              `yaml:"openmetrics_endpoint"`
+	Namespace              string              `yaml:"namespace"`
+	RawMetricPrefix        string              `yaml:"raw_metric_prefix"`
+	Metrics                []interface{}       `yaml:"metrics"`
+	TypeOverrides          map[string]string   `yaml:"type_overrides"`
+	ExcludeLabels          []string            `yaml:"exclude_labels"`
+	RenameLabels           map[string]string   `yaml:"rename_labels"`
+	ExcludeMetricsByLabels map[string][]string `yaml:"exclude_metrics_by_labels"`
+	Headers                map[string]string   `yaml:"headers"`
+	Timeout                int                 `yaml:"timeout"`
+	TLSVerify              bool                `yaml:"tls_verify"`
+	TLSCACert              string              `yaml:"tls_ca_cert"`
+	UseProtobuf            bool                `yaml:"use_protobuf"`
+	MaxExpositionSize      int64               `yaml:"max_exposition_size"`
+}
+
+// metricRule selects the metric families matching its pattern
+type metricRule struct {
+	pattern *regexp.Regexp
+	name    string     // the name of the metric, the name of the family if empty
+	typ     metricType // overrides the type of the family if set
+}
+
+// metricTransform is how the samples of a family are submitted
+type metricTransform struct {
+	name string
+	typ  metricType
+}
+
+// Check scrapes an OpenMetrics or Prometheus endpoint. Unlike the Python
+// implementation, the exposition is parsed as it's read, so that the memory
+// used by the check doesn't depend on its size.
+type Check struct {
+	core.CheckBase
+	config        instanceConfig
+	rules         []metricRule
+	excludeLabels map[string]bool
+	transforms    map[string]*metricTransform // by family, nil for the excluded families
+	client        *http.Client
+	tags          []string
+}
+
+// Configure parses the configuration of the instance
+func (c *Check) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(data, initConfig)
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	c.config = instanceConfig{
+		Timeout:           defaultTimeout,
+		TLSVerify:         true,
+		MaxExpositionSize: defaultMaxExposition,
+	}
+	if err := yaml.Unmarshal(data, &c.config); err != nil {
+		return err
+	}
+	if c.config.Endpoint == "" {
+		return fmt.Errorf("`openmetrics_endpoint` is required")
+	}
+	if len(c.config.Metrics) == 0 {
+		return fmt.Errorf("`metrics` must list the metrics to collect, `.+` collects all of them")
+	}
+
+	rules, err := parseMetricRules(c.config.Metrics)
+	if err != nil {
+		return err
+	}
+	for family, typ := range c.config.TypeOverrides {
+		if !validTypes[metricType(typ)] {
+			return fmt.Errorf("invalid type '%s' for '%s' in `type_overrides`", typ, family)
+		}
+	}
+	c.rules = rules
+	c.excludeLabels = make(map[string]bool)
+	for _, l := range c.config.ExcludeLabels {
+		c.excludeLabels[l] = true
+	}
+	c.transforms = make(map[string]*metricTransform)
+	c.tags = []string{fmt.Sprintf("endpoint:%s", c.config.Endpoint)}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: !c.config.TLSVerify}
+	if c.config.TLSCACert != "" {
+		cert, err := ioutil.ReadFile(c.config.TLSCACert)
+		if err != nil {
+			return fmt.Errorf("could not read `tls_ca_cert`: %s", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		tlsConfig.RootCAs.AppendCertsFromPEM(cert)
+	}
+	c.client = &http.Client{
+		Timeout: time.Duration(c.config.Timeout) * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return nil
+}
+
+// parseMetricRules parses the `metrics` option: regular expressions matching the
+// families, or maps of a family to its new name, or to its new name and type
+func parseMetricRules(items []interface{}) ([]metricRule, error) {
+	var rules []metricRule
+	add := func(pattern string, name string, typ string) error {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s' in `metrics`: %s", pattern, err)
+		}
+		if typ != "" && !validTypes[metricType(typ)] {
+			return fmt.Errorf("invalid type '%s' for '%s' in `metrics`", typ, pattern)
+		}
+		rules = append(rules, metricRule{pattern: re, name: name, typ: metricType(typ)})
+		return nil
+	}
+
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			if err := add(item, "", ""); err != nil {
+				return nil, err
+			}
+		case map[interface{}]interface{}:
+			for pattern, value := range item {
+				var err error
+				switch value := value.(type) {
+				case string:
+					err = add(fmt.Sprint(pattern), value, "")
+				case map[interface{}]interface{}:
+					name, _ := value["name"].(string)
+					typ, _ := value["type"].(string)
+					err = add(fmt.Sprint(pattern), name, typ)
+				default:
+					err = fmt.Errorf("invalid value for '%v' in `metrics`", pattern)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid item '%v' in `metrics`", item)
+		}
+	}
+	return rules, nil
+}
+
+// Run scrapes the endpoint and submits its samples
+func (c *Check) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	serviceCheckName := healthServiceCheckName
+	if c.config.Namespace != "" {
+		serviceCheckName = c.config.Namespace + "." + serviceCheckName
+	}
+
+	if err := c.scrape(sender); err != nil {
+		sender.ServiceCheck(serviceCheckName, metrics.ServiceCheckCritical, "", c.tags, err.Error())
+		return err
+	}
+	sender.ServiceCheck(serviceCheckName, metrics.ServiceCheckOK, "", c.tags, "")
+	return nil
+}
+
+func (c *Check) scrape(sender aggregator.Sender) error {
+	req, err := http.NewRequest("GET", c.config.Endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(c.RunContext())
+	if c.config.UseProtobuf {
+		req.Header.Set("Accept", acceptProtobuf)
+	} else {
+		req.Header.Set("Accept", acceptText)
+	}
+	for name, value := range c.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, c.config.Endpoint)
+	}
+	if resp.ContentLength > c.config.MaxExpositionSize {
+		return fmt.Errorf("the exposition is %d bytes, above the %d bytes of `max_exposition_size`", resp.ContentLength, c.config.MaxExpositionSize)
+	}
+
+	body := &limitedReader{r: resp.Body, n: c.config.MaxExpositionSize}
+	handle := func(s *sample) error {
+		c.submit(sender, s)
+		return nil
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), protobufContentType) {
+		err = parseProtobuf(body, c.config.MaxExpositionSize, handle)
+	} else {
+		err = parseText(body, handle)
+	}
+	if err == errExpositionTooLarge {
+		return fmt.Errorf("the exposition is above the %d bytes of `max_exposition_size`, the samples after the limit were dropped", c.config.MaxExpositionSize)
+	}
+	return err
+}
+
+// transform returns how to submit the samples of a family, nil if they're not collected
+func (c *Check) transform(family string, familyType metricType) *metricTransform {
+	if t, found := c.transforms[family]; found {
+		return t
+	}
+
+	var t *metricTransform
+	name := strings.TrimPrefix(family, c.config.RawMetricPrefix)
+	if familyType == typeCounter {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	for _, rule := range c.rules {
+		if !rule.pattern.MatchString(name) {
+			continue
+		}
+		t = &metricTransform{name: name, typ: familyType}
+		if rule.name != "" {
+			t.name = rule.name
+		}
+		if rule.typ != "" {
+			t.typ = rule.typ
+		}
+		if typ, found := c.config.TypeOverrides[name]; found {
+			t.typ = metricType(typ)
+		}
+		if c.config.Namespace != "" {
+			t.name = c.config.Namespace + "." + t.name
+		}
+		break
+	}
+	c.transforms[family] = t
+	return t
+}
+
+// submit sends a sample of the exposition
+func (c *Check) submit(sender aggregator.Sender, s *sample) {
+	t := c.transform(s.family, s.familyType)
+	if t == nil || math.IsNaN(s.value) {
+		return
+	}
+
+	tags := make([]string, 0, len(s.labels))
+	for _, l := range s.labels {
+		if values, found := c.config.ExcludeMetricsByLabels[l.name]; found {
+			if len(values) == 0 {
+				return
+			}
+			for _, v := range values {
+				if v == l.value {
+					return
+				}
+			}
+		}
+		if c.excludeLabels[l.name] {
+			continue
+		}
+		name := l.name
+		if renamed, found := c.config.RenameLabels[name]; found {
+			name = renamed
+		} else if name == "le" && t.typ == typeHistogram {
+			name = "upper_bound"
+		}
+		tags = append(tags, name+":"+l.value)
+	}
+	tags = append(tags, c.tags...)
+
+	switch t.typ {
+	case typeCounter:
+		sender.MonotonicCount(t.name+".count", s.value, "", tags)
+	case typeHistogram:
+		switch s.suffix() {
+		case "_bucket":
+			sender.MonotonicCount(t.name+".bucket", s.value, "", tags)
+		case "_sum":
+			sender.MonotonicCount(t.name+".sum", s.value, "", tags)
+		case "_count":
+			sender.MonotonicCount(t.name+".count", s.value, "", tags)
+		}
+	case typeSummary:
+		switch s.suffix() {
+		case "":
+			sender.Gauge(t.name+".quantile", s.value, "", tags)
+		case "_sum":
+			sender.MonotonicCount(t.name+".sum", s.value, "", tags)
+		case "_count":
+			sender.MonotonicCount(t.name+".count", s.value, "", tags)
+		}
+	default:
+		sender.Gauge(t.name, s.value, "", tags)
+	}
+}
+
+func openmetricsFactory() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(checkName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(checkName, openmetricsFactory)
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestCheck(t *testing.T, instance string) (*Check, *mocksender.MockSender) {
+	c := openmetricsFactory().(*Check)
+	require.NoError(t, c.Configure(integration.Data(instance), nil, "test"))
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	return c, sender
+}
+
+func TestConfigure(t *testing.T) {
+	c := openmetricsFactory().(*Check)
+	assert.EqualError(t, c.Configure(integration.Data("metrics: [.+]"), nil, "test"), "`openmetrics_endpoint` is required")
+	assert.EqualError(t, c.Configure(integration.Data("openmetrics_endpoint: http://localhost"), nil, "test"), "`metrics` must list the metrics to collect, `.+` collects all of them")
+	assert.Error(t, c.Configure(integration.Data("openmetrics_endpoint: http://localhost\nmetrics: ['(']"), nil, "test"))
+	assert.Error(t, c.Configure(integration.Data("openmetrics_endpoint: http://localhost\nmetrics: [{a: {type: set}}]"), nil, "test"))
+	assert.Error(t, c.Configure(integration.Data("openmetrics_endpoint: http://localhost\nmetrics: [.+]\ntype_overrides: {a: set}"), nil, "test"))
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		fmt.Fprint(w, testExposition)
+	}))
+	defer server.Close()
+
+	c, sender := newTestCheck(t, fmt.Sprintf(`
+openmetrics_endpoint: %s
+namespace: test
+metrics:
+  - http_requests
+  - go_goroutines: goroutines
+  - request_duration_seconds
+  - rpc_duration_seconds
+  - untyped_metric:
+      name: untyped
+      type: counter
+exclude_labels: [path]
+rename_labels:
+  text: message
+exclude_metrics_by_labels:
+  code: ["400"]
+headers:
+  Authorization: secret
+`, server.URL))
+	endpoint := "endpoint:" + server.URL
+
+	require.NoError(t, c.Run())
+
+	sender.AssertMetric(t, "MonotonicCount", "test.http_requests.count", 1027, "", []string{"method:post", "code:200", endpoint})
+	sender.AssertNotCalled(t, "MonotonicCount", "test.http_requests.count", 3.0, "", mock.Anything)
+	sender.AssertMetric(t, "Gauge", "test.goroutines", 42, "", []string{endpoint})
+	sender.AssertMetric(t, "MonotonicCount", "test.request_duration_seconds.bucket", 24054, "", []string{"upper_bound:0.5", endpoint})
+	sender.AssertMetric(t, "MonotonicCount", "test.request_duration_seconds.sum", 53423, "", []string{endpoint})
+	sender.AssertMetric(t, "MonotonicCount", "test.request_duration_seconds.count", 144320, "", []string{endpoint})
+	sender.AssertMetric(t, "Gauge", "test.rpc_duration_seconds.quantile", 76656, "", []string{"quantile:0.99", endpoint})
+	sender.AssertMetric(t, "MonotonicCount", "test.rpc_duration_seconds.count", 2693, "", []string{endpoint})
+	sender.AssertCalled(t, "MonotonicCount", "test.untyped.count", 7.0, "", []string{"message:say \"hi\"\n", endpoint})
+	// not listed in `metrics`
+	sender.AssertNotCalled(t, "MonotonicCount", "test.jobs.count", 5.0, "", mock.Anything)
+	sender.AssertServiceCheck(t, "test.openmetrics.health", metrics.ServiceCheckOK, "", []string{endpoint}, "")
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestRunErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, testExposition)
+	}))
+	defer server.Close()
+
+	c, sender := newTestCheck(t, fmt.Sprintf("openmetrics_endpoint: %s/error\nmetrics: [.+]", server.URL))
+	assert.EqualError(t, c.Run(), "unexpected status code 500 from "+server.URL+"/error")
+	sender.AssertServiceCheck(t, "openmetrics.health", metrics.ServiceCheckCritical, "", []string{"endpoint:" + server.URL + "/error"}, "unexpected status code 500 from "+server.URL+"/error")
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+
+	c, sender = newTestCheck(t, fmt.Sprintf("openmetrics_endpoint: %s\nmetrics: [.+]\nmax_exposition_size: 100", server.URL))
+	assert.Error(t, c.Run())
+	sender.AssertServiceCheck(t, "openmetrics.health", metrics.ServiceCheckCritical, "", []string{"endpoint:" + server.URL}, fmt.Sprintf("the exposition is %d bytes, above the 100 bytes of `max_exposition_size`", len(testExposition)))
+}
@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// metricType is the type of a metric family
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+	typeSummary   metricType = "summary"
+	typeUntyped   metricType = "untyped"
+)
+
+// maxLineSize is the size of the longest line of a text exposition
+const maxLineSize = 1024 * 1024
+
+var errExpositionTooLarge = errors.New("exposition too large")
+
+// label is a label of a sample, in the order of the exposition
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a sample of an exposition. Its name is the one of the exposition,
+// with the suffix of its family: `_bucket`, `_sum`, `_count` or `_total`.
+type sample struct {
+	family     string
+	familyType metricType
+	name       string
+	labels     []label
+	value      float64
+}
+
+// suffix returns the suffix of the name of the sample, after its family
+func (s *sample) suffix() string {
+	return strings.TrimPrefix(s.name, s.family)
+}
+
+// sampleHandler handles the samples of an exposition as they're parsed. The
+// sample and its labels are reused for the next samples.
+type sampleHandler func(s *sample) error
+
+// limitedReader fails once more than n bytes are read
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// the exposition ends right at the limit if nothing remains
+		if n, _ := l.r.Read(make([]byte, 1)); n == 0 {
+			return 0, io.EOF
+		}
+		return 0, errExpositionTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// parseText parses an exposition in the Prometheus or OpenMetrics text
+// format, one line at a time, so that the memory used doesn't depend on the
+// size of the exposition
+func parseText(r io.Reader, handle sampleHandler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	types := make(map[string]metricType)
+	s := &sample{}
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[1] == "EOF" {
+				return nil
+			}
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = metricType(strings.ToLower(fields[3]))
+			}
+			continue
+		}
+
+		if err := parseSample(line, s); err != nil {
+			return fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		s.family, s.familyType = family(s.name, types)
+		if s.suffix() == "_created" {
+			continue
+		}
+		if err := handle(s); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return fmt.Errorf("line %d is longer than %d bytes", lineNumber+1, maxLineSize)
+		}
+		return err
+	}
+	return nil
+}
+
+// family returns the metric family of a sample, from the `# TYPE` lines
+func family(name string, types map[string]metricType) (string, metricType) {
+	if t, found := types[name]; found {
+		return name, t
+	}
+	for _, suffix := range []string{"_total", "_bucket", "_sum", "_count", "_created"} {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		if t, found := types[strings.TrimSuffix(name, suffix)]; found {
+			return strings.TrimSuffix(name, suffix), t
+		}
+	}
+	return name, typeUntyped
+}
+
+// parseSample parses `name{label="value",...} value [timestamp]` into s
+func parseSample(line string, s *sample) error {
+	s.labels = s.labels[:0]
+
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return fmt.Errorf("invalid sample '%s'", line)
+	}
+	s.name = line[:end]
+	line = line[end:]
+
+	if line[0] == '{' {
+		rest, err := parseLabels(line[1:], s)
+		if err != nil {
+			return err
+		}
+		line = rest
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return fmt.Errorf("no value for '%s'", s.name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for '%s': %s", s.name, err)
+	}
+	s.value = value
+	return nil
+}
+
+// parseLabels parses the labels up to the closing brace, it returns the rest of the line
+func parseLabels(line string, s *sample) (string, error) {
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return "", fmt.Errorf("unterminated labels for '%s'", s.name)
+		}
+		if line[0] == '}' {
+			return line[1:], nil
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || len(line) < eq+2 || line[eq+1] != '"' {
+			return "", fmt.Errorf("invalid labels for '%s'", s.name)
+		}
+		name := strings.TrimSpace(line[:eq])
+		line = line[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			if c == '\\' && i+1 < len(line) {
+				i++
+				switch line[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(line[i])
+				}
+				continue
+			}
+			if c == '"' {
+				line = line[i+1:]
+				closed = true
+				break
+			}
+			value.WriteByte(c)
+		}
+		if !closed {
+			return "", fmt.Errorf("unterminated label value for '%s'", s.name)
+		}
+		s.labels = append(s.labels, label{name: name, value: value.String()})
+
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, ",") {
+			line = line[1:]
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// protobufContentType is the content type of the delimited protobuf exposition format
+const protobufContentType = "application/vnd.google.protobuf"
+
+// The fields of a MetricFamily message and the wire types of protobuf
+const (
+	familyNameField   = 1
+	familyTypeField   = 3
+	familyMetricField = 4
+
+	wireVarint          = 0
+	wireFixed64         = 1
+	wireLengthDelimited = 2
+	wireFixed32         = 5
+)
+
+var errInvalidProtobuf = errors.New("invalid protobuf exposition")
+
+// protobufReader reads the messages of an exposition, it counts the bytes read
+// so that a length is checked against the rest of the exposition before the
+// message is allocated
+type protobufReader struct {
+	r         *bufio.Reader
+	remaining int64
+}
+
+// readVarint reads a varint of at most 10 bytes, io.EOF is only returned if
+// the exposition ends before it
+func (p *protobufReader) readVarint() (uint64, error) {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		if p.remaining <= 0 {
+			if _, err := p.r.ReadByte(); err == io.EOF && i == 0 {
+				return 0, io.EOF
+			}
+			return 0, errExpositionTooLarge
+		}
+		b, err := p.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		p.remaining--
+		v |= uint64(b&0x7f) << (7 * uint(i))
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, errInvalidProtobuf
+}
+
+// readBytes reads length bytes, it fails before allocating them if they're
+// after the end of the enclosing message or above the rest of the exposition
+func (p *protobufReader) readBytes(length uint64, end int64) ([]byte, error) {
+	if err := p.checkLength(length, end); err != nil {
+		return nil, err
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(p.r, b); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	p.remaining -= int64(length)
+	return b, nil
+}
+
+// skip discards length bytes, e.g. the help of a family
+func (p *protobufReader) skip(length uint64, end int64) error {
+	if err := p.checkLength(length, end); err != nil {
+		return err
+	}
+	if _, err := p.r.Discard(int(length)); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	p.remaining -= int64(length)
+	return nil
+}
+
+// checkLength checks that length bytes end before end, the remaining bytes
+// of the exposition once the enclosing message is read
+func (p *protobufReader) checkLength(length uint64, end int64) error {
+	if length > uint64(p.remaining) {
+		return errExpositionTooLarge
+	}
+	if p.remaining-int64(length) < end {
+		return errInvalidProtobuf
+	}
+	return nil
+}
+
+// parseProtobuf parses an exposition in the delimited protobuf format into
+// the samples of the text format. It's read one metric at a time, the lengths
+// declared by the exposition are checked against maxSize before allocating the
+// messages, so that the memory used doesn't depend on the size of the exposition.
+func parseProtobuf(r io.Reader, maxSize int64, handle sampleHandler) error {
+	p := &protobufReader{r: bufio.NewReader(r), remaining: maxSize}
+	s := &sample{}
+	for {
+		length, err := p.readVarint()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if length > uint64(p.remaining) {
+			return errExpositionTooLarge
+		}
+		if err := parseProtobufFamily(p, p.remaining-int64(length), s, handle); err != nil {
+			return err
+		}
+	}
+}
+
+// parseProtobufFamily parses a MetricFamily message, which ends when end bytes
+// of the exposition remain. The name and the type of the family are written
+// before its metrics by the Prometheus clients; the metrics read before them,
+// if any, are only handled at the end of the family.
+func parseProtobufFamily(p *protobufReader, end int64, s *sample, handle sampleHandler) error {
+	s.family = ""
+	s.familyType = protobufType(dto.MetricType_COUNTER)
+	var nameSeen, typeSeen bool
+	var pending []*dto.Metric
+
+	for p.remaining > end {
+		key, err := p.readVarint()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		field, wireType := key>>3, key&7
+		switch {
+		case field == familyNameField && wireType == wireLengthDelimited:
+			length, err := p.readVarint()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			name, err := p.readBytes(length, end)
+			if err != nil {
+				return err
+			}
+			s.family = string(name)
+			nameSeen = true
+		case field == familyTypeField && wireType == wireVarint:
+			t, err := p.readVarint()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			s.familyType = protobufType(dto.MetricType(t))
+			typeSeen = true
+		case field == familyMetricField && wireType == wireLengthDelimited:
+			length, err := p.readVarint()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			data, err := p.readBytes(length, end)
+			if err != nil {
+				return err
+			}
+			m := &dto.Metric{}
+			if err := proto.Unmarshal(data, m); err != nil {
+				return err
+			}
+			if !nameSeen || !typeSeen {
+				pending = append(pending, m)
+				continue
+			}
+			if err := handleProtobufMetric(s, m, handle); err != nil {
+				return err
+			}
+		default:
+			if err := p.skipField(wireType, end); err != nil {
+				return err
+			}
+		}
+	}
+	if p.remaining != end {
+		return errInvalidProtobuf
+	}
+
+	for _, m := range pending {
+		if err := handleProtobufMetric(s, m, handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipField skips the value of a field that isn't used
+func (p *protobufReader) skipField(wireType uint64, end int64) error {
+	switch wireType {
+	case wireVarint:
+		_, err := p.readVarint()
+		return unexpectedEOF(err)
+	case wireFixed64:
+		return p.skip(8, end)
+	case wireLengthDelimited:
+		length, err := p.readVarint()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		return p.skip(length, end)
+	case wireFixed32:
+		return p.skip(4, end)
+	default:
+		return errInvalidProtobuf
+	}
+}
+
+// unexpectedEOF converts io.EOF, the exposition can only end between families
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func protobufType(t dto.MetricType) metricType {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return typeCounter
+	case dto.MetricType_GAUGE:
+		return typeGauge
+	case dto.MetricType_HISTOGRAM:
+		return typeHistogram
+	case dto.MetricType_SUMMARY:
+		return typeSummary
+	default:
+		return typeUntyped
+	}
+}
+
+// handleProtobufMetric handles the samples of a metric of the family of s
+func handleProtobufMetric(s *sample, m *dto.Metric, handle sampleHandler) error {
+	// emit sets the sample, the extra label, if any, is the last one
+	emit := func(suffix string, value float64, extra ...label) error {
+		s.name = s.family + suffix
+		s.value = value
+		s.labels = s.labels[:0]
+		for _, l := range m.GetLabel() {
+			s.labels = append(s.labels, label{name: l.GetName(), value: l.GetValue()})
+		}
+		s.labels = append(s.labels, extra...)
+		return handle(s)
+	}
+
+	switch s.familyType {
+	case typeCounter:
+		return emit("", m.GetCounter().GetValue())
+	case typeGauge:
+		return emit("", m.GetGauge().GetValue())
+	case typeHistogram:
+		h := m.GetHistogram()
+		hasInf := false
+		for _, b := range h.GetBucket() {
+			if math.IsInf(b.GetUpperBound(), +1) {
+				hasInf = true
+			}
+			if err := emit("_bucket", float64(b.GetCumulativeCount()), label{name: "le", value: formatFloat(b.GetUpperBound())}); err != nil {
+				return err
+			}
+		}
+		// the +Inf bucket is implicit in the protobuf format
+		if !hasInf {
+			if err := emit("_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"}); err != nil {
+				return err
+			}
+		}
+		if err := emit("_sum", h.GetSampleSum()); err != nil {
+			return err
+		}
+		return emit("_count", float64(h.GetSampleCount()))
+	case typeSummary:
+		summary := m.GetSummary()
+		for _, q := range summary.GetQuantile() {
+			if err := emit("", q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())}); err != nil {
+				return err
+			}
+		}
+		if err := emit("_sum", summary.GetSampleSum()); err != nil {
+			return err
+		}
+		return emit("_count", float64(summary.GetSampleCount()))
+	default:
+		return emit("", m.GetUntyped().GetValue())
+	}
+}
+
+// formatFloat formats the bounds and quantiles like the text format
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package openmetrics
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testExposition = `# HELP http_requests_total The total number of requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post", code="400",} 3
+
+# TYPE go_goroutines gauge
+go_goroutines 42
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.5"} 24054
+request_duration_seconds_bucket{le="+Inf"} 144320
+request_duration_seconds_sum 53423
+request_duration_seconds_count 144320
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.99"} 76656
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+# TYPE jobs counter
+jobs_total 5
+jobs_created 1.5e9
+untyped_metric{path="C:\\dir\\",text="say \"hi\"\n"} 7
+# EOF
+ignored 1
+`
+
+// parsed is a copy of a sample, the samples are reused by the parsers
+type parsed struct {
+	family     string
+	familyType metricType
+	name       string
+	labels     []label
+	value      float64
+}
+
+func parseAll(t *testing.T, parse func(handle sampleHandler) error) []parsed {
+	var samples []parsed
+	err := parse(func(s *sample) error {
+		samples = append(samples, parsed{s.family, s.familyType, s.name, append([]label{}, s.labels...), s.value})
+		return nil
+	})
+	require.NoError(t, err)
+	return samples
+}
+
+func TestParseText(t *testing.T) {
+	samples := parseAll(t, func(handle sampleHandler) error {
+		return parseText(strings.NewReader(testExposition), handle)
+	})
+
+	require.Len(t, samples, 12)
+	assert.Equal(t, parsed{"http_requests_total", typeCounter, "http_requests_total", []label{{"method", "post"}, {"code", "200"}}, 1027}, samples[0])
+	assert.Equal(t, []label{{"method", "post"}, {"code", "400"}}, samples[1].labels)
+	assert.Equal(t, parsed{"go_goroutines", typeGauge, "go_goroutines", []label{}, 42}, samples[2])
+	assert.Equal(t, parsed{"request_duration_seconds", typeHistogram, "request_duration_seconds_bucket", []label{{"le", "+Inf"}}, 144320}, samples[4])
+	assert.Equal(t, "request_duration_seconds_count", samples[6].name)
+	assert.Equal(t, parsed{"rpc_duration_seconds", typeSummary, "rpc_duration_seconds", []label{{"quantile", "0.99"}}, 76656}, samples[7])
+	assert.Equal(t, 1.7560473e+07, samples[8].value)
+	// OpenMetrics counter, without its _created sample
+	assert.Equal(t, parsed{"jobs", typeCounter, "jobs_total", []label{}, 5}, samples[10])
+	assert.Equal(t, typeUntyped, samples[11].familyType)
+	assert.Equal(t, []label{{"path", `C:\dir\`}, {"text", "say \"hi\"\n"}}, samples[11].labels)
+}
+
+func TestParseTextErrors(t *testing.T) {
+	for _, exposition := range []string{
+		"metric",
+		"metric{",
+		`metric{label="value} 1`,
+		`metric{label=value} 1`,
+		"metric abc",
+	} {
+		err := parseText(strings.NewReader(exposition), func(*sample) error { return nil })
+		assert.Error(t, err, exposition)
+	}
+
+	err := parseText(strings.NewReader("metric 1\n"+strings.Repeat("a", maxLineSize+1)), func(*sample) error { return nil })
+	assert.EqualError(t, err, "line 2 is longer than 1048576 bytes")
+}
+
+func TestLimitedReader(t *testing.T) {
+	data, err := ioutil.ReadAll(&limitedReader{r: strings.NewReader("0123456789"), n: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	_, err = ioutil.ReadAll(&limitedReader{r: strings.NewReader("0123456789"), n: 9})
+	assert.Equal(t, errExpositionTooLarge, err)
+}
+
+func TestParseProtobuf(t *testing.T) {
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("http_requests_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("200")}},
+				Counter: &dto.Counter{Value: proto.Float64(1027)},
+			}},
+		},
+		{
+			Name: proto.String("request_duration_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount: proto.Uint64(144320),
+					SampleSum:   proto.Float64(53423),
+					Bucket:      []*dto.Bucket{{CumulativeCount: proto.Uint64(24054), UpperBound: proto.Float64(0.5)}},
+				},
+			}},
+		},
+		{
+			Name: proto.String("rpc_duration_seconds"),
+			Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{
+					SampleCount: proto.Uint64(2693),
+					SampleSum:   proto.Float64(1.7560473e+07),
+					Quantile:    []*dto.Quantile{{Quantile: proto.Float64(0.99), Value: proto.Float64(76656)}},
+				},
+			}},
+		},
+	}
+	buf := &bytes.Buffer{}
+	for _, mf := range families {
+		_, err := pbutil.WriteDelimited(buf, mf)
+		require.NoError(t, err)
+	}
+
+	samples := parseAll(t, func(handle sampleHandler) error {
+		return parseProtobuf(buf, int64(buf.Len()), handle)
+	})
+
+	require.Len(t, samples, 8)
+	assert.Equal(t, parsed{"http_requests_total", typeCounter, "http_requests_total", []label{{"code", "200"}}, 1027}, samples[0])
+	assert.Equal(t, parsed{"request_duration_seconds", typeHistogram, "request_duration_seconds_bucket", []label{{"le", "0.5"}}, 24054}, samples[1])
+	assert.Equal(t, parsed{"request_duration_seconds", typeHistogram, "request_duration_seconds_bucket", []label{{"le", "+Inf"}}, 144320}, samples[2])
+	assert.Equal(t, parsed{"request_duration_seconds", typeHistogram, "request_duration_seconds_sum", []label{}, 53423}, samples[3])
+	assert.Equal(t, parsed{"request_duration_seconds", typeHistogram, "request_duration_seconds_count", []label{}, 144320}, samples[4])
+	assert.Equal(t, parsed{"rpc_duration_seconds", typeSummary, "rpc_duration_seconds", []label{{"quantile", "0.99"}}, 76656}, samples[5])
+	assert.Equal(t, "rpc_duration_seconds_count", samples[7].name)
+}
+
+func TestParseProtobufTypeAfterMetrics(t *testing.T) {
+	metric, err := proto.Marshal(&dto.Metric{Gauge: &dto.Gauge{Value: proto.Float64(42)}})
+	require.NoError(t, err)
+
+	// metric (4), name (1) and type (3) fields, in this order
+	family := append([]byte{0x22, byte(len(metric))}, metric...)
+	family = append(family, 0x0a, 0x03, 'f', 'o', 'o')
+	family = append(family, 0x18, byte(dto.MetricType_GAUGE))
+	data := append([]byte{byte(len(family))}, family...)
+
+	samples := parseAll(t, func(handle sampleHandler) error {
+		return parseProtobuf(bytes.NewReader(data), int64(len(data)), handle)
+	})
+	assert.Equal(t, []parsed{{"foo", typeGauge, "foo", []label{}, 42}}, samples)
+}
+
+func TestParseProtobufInvalid(t *testing.T) {
+	noop := func(s *sample) error { return nil }
+	for name, tc := range map[string]struct {
+		data    []byte
+		maxSize int64
+		err     error
+	}{
+		// a family of about 32GB, rejected before it's allocated
+		"family above the limit":  {[]byte{0xff, 0xff, 0xff, 0xff, 0x7f, 0x0a}, 1024, errExpositionTooLarge},
+		"metric above the limit":  {[]byte{0x04, 0x22, 0xff, 0xff, 0x7f}, 1024, errExpositionTooLarge},
+		"metric after its family": {[]byte{0x03, 0x22, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, 1024, errInvalidProtobuf},
+		"varint too long":         {[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1024, errInvalidProtobuf},
+		"truncated family":        {[]byte{0x05, 0x0a, 0x03, 'f'}, 1024, io.ErrUnexpectedEOF},
+	} {
+		err := parseProtobuf(bytes.NewReader(tc.data), tc.maxSize, noop)
+		assert.Equal(t, tc.err, err, name)
+	}
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package corechecks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// This file is the API to build Go checks living outside of this repository
+// and compiled into a custom agent. Such a check implements Implementation,
+// and optionally Configurer and Stopper, and is registered from the init
+// function of its package with Register; the custom agent imports the package
+// for its side effects. See docs/dev/checks/go_checks.md.
+
+// Sender is the interface used by the checks registered with Register to
+// submit their data. The SDK commits the data at the end of each run.
+type Sender interface {
+	Gauge(metric string, value float64, hostname string, tags []string)
+	Rate(metric string, value float64, hostname string, tags []string)
+	Count(metric string, value float64, hostname string, tags []string)
+	MonotonicCount(metric string, value float64, hostname string, tags []string)
+	Histogram(metric string, value float64, hostname string, tags []string)
+	Historate(metric string, value float64, hostname string, tags []string)
+	ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string)
+	Event(e metrics.Event)
+}
+
+// Implementation is implemented by the checks registered with Register. Run
+// is called at each run of an instance; ctx is cancelled when the run exceeds
+// the `run_timeout` of the instance.
+type Implementation interface {
+	Run(ctx context.Context, sender Sender) error
+}
+
+// Configurer is implemented by the checks reading their configuration, it's
+// called once, before the first run of the instance
+type Configurer interface {
+	Configure(config *Config) error
+}
+
+// Stopper is implemented by the checks whose runs can be stopped, it's called
+// when the instance is unscheduled, or the agent stops, during a run
+type Stopper interface {
+	Stop()
+}
+
+// Factory returns a new implementation for each instance of a check
+type Factory func() Implementation
+
+// Validator is implemented by the configuration structs needing more
+// validation than the `validate:"required"` fields
+type Validator interface {
+	Validate() error
+}
+
+// Config is the configuration of an instance of a check
+type Config struct {
+	Instance   integration.Data
+	InitConfig integration.Data
+	Source     string
+}
+
+// UnmarshalInstance reads the instance configuration into out, a pointer to a
+// struct with `yaml` tags whose fields hold the defaults. The fields tagged
+// `validate:"required"` must be set, and out is validated if it implements
+// Validator. The common options, like `min_collection_interval` or `tags`, are
+// handled by the agent.
+func (c *Config) UnmarshalInstance(out interface{}) error {
+	return unmarshalConfig("instance", c.Instance, out)
+}
+
+// UnmarshalInitConfig reads the init_config section, shared by the instances,
+// like UnmarshalInstance
+func (c *Config) UnmarshalInitConfig(out interface{}) error {
+	return unmarshalConfig("init_config", c.InitConfig, out)
+}
+
+func unmarshalConfig(section string, data integration.Data, out interface{}) error {
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid %s section: %s", section, err)
+	}
+	if err := checkRequired(out); err != nil {
+		return fmt.Errorf("invalid %s section: %s", section, err)
+	}
+	if v, ok := out.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid %s section: %s", section, err)
+		}
+	}
+	return nil
+}
+
+// checkRequired returns an error listing the fields tagged `validate:"required"`
+// left to their zero value. Unexported fields are skipped, they can't be set
+// from the configuration.
+func checkRequired(out interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(out))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var missing []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" || field.Tag.Get("validate") != "required" {
+			continue
+		}
+		if reflect.DeepEqual(v.Field(i).Interface(), reflect.Zero(field.Type).Interface()) {
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required options: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Register adds a check built with the SDK to the catalog of the Go checks.
+// Unlike RegisterCheck, it fails if the name is already taken, so that an
+// external check can't silently replace a check of the agent.
+func Register(name string, factory Factory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("a check needs a name and a factory to be registered")
+	}
+	if _, found := catalog[name]; found {
+		return fmt.Errorf("a check named '%s' is already registered", name)
+	}
+
+	RegisterCheck(name, func() check.Check {
+		return &sdkCheck{
+			CheckBase: NewCheckBase(name),
+			impl:      factory(),
+		}
+	})
+	return nil
+}
+
+// sdkCheck runs an Implementation as a check.Check
+type sdkCheck struct {
+	CheckBase
+	impl Implementation
+}
+
+// Configure handles the common options of the instance and calls the
+// Configurer of the check
+func (c *sdkCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(data, initConfig)
+	if err := c.CheckBase.Configure(data, initConfig, source); err != nil {
+		return err
+	}
+
+	if configurer, ok := c.impl.(Configurer); ok {
+		return configurer.Configure(&Config{
+			Instance:   data,
+			InitConfig: initConfig,
+			Source:     source,
+		})
+	}
+	return nil
+}
+
+// Run runs the check and commits the data it sent, even if it failed
+func (c *sdkCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	return c.impl.Run(c.RunContext(), sender)
+}
+
+// Stop calls the Stopper of the check
+func (c *sdkCheck) Stop() {
+	if stopper, ok := c.impl.(Stopper); ok {
+		stopper.Stop()
+	}
+}
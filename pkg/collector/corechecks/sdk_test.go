@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package corechecks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type sdkTestConfig struct {
+	URL     string `yaml:"url" validate:"required"`
+	Timeout int    `yaml:"timeout"`
+}
+
+func (c *sdkTestConfig) Validate() error {
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	return nil
+}
+
+type sdkTestCheck struct {
+	config  sdkTestConfig
+	stopped bool
+}
+
+func (c *sdkTestCheck) Configure(config *Config) error {
+	c.config = sdkTestConfig{Timeout: 5}
+	return config.UnmarshalInstance(&c.config)
+}
+
+func (c *sdkTestCheck) Run(ctx context.Context, sender Sender) error {
+	sender.Gauge("sdk.timeout", float64(c.config.Timeout), "", []string{"url:" + c.config.URL})
+	sender.ServiceCheck("sdk.can_connect", metrics.ServiceCheckOK, "", nil, "")
+	return ctx.Err()
+}
+
+func (c *sdkTestCheck) Stop() {
+	c.stopped = true
+}
+
+func TestRegister(t *testing.T) {
+	factory := func() Implementation { return &sdkTestCheck{} }
+
+	require.NoError(t, Register("sdk_register", factory))
+	assert.NotNil(t, GetCheckFactory("sdk_register"))
+	assert.EqualError(t, Register("sdk_register", factory), "a check named 'sdk_register' is already registered")
+	assert.Error(t, Register("", factory))
+	assert.Error(t, Register("sdk_nil", nil))
+}
+
+func TestSDKCheckLifecycle(t *testing.T) {
+	require.NoError(t, Register("sdk_lifecycle", func() Implementation { return &sdkTestCheck{} }))
+
+	c := GetCheckFactory("sdk_lifecycle")()
+	sdk := c.(*sdkCheck)
+	mockSender := mocksender.NewMockSender(c.ID())
+	mockSender.SetupAcceptAll()
+
+	err := c.Configure(integration.Data("url: http://localhost\nmin_collection_interval: 60"), integration.Data(""), "test")
+	require.NoError(t, err)
+	assert.NotEqual(t, "sdk_lifecycle", string(c.ID()))
+	assert.Equal(t, "test", c.ConfigSource())
+	assert.Equal(t, "http://localhost", sdk.impl.(*sdkTestCheck).config.URL)
+	assert.Equal(t, 5, sdk.impl.(*sdkTestCheck).config.Timeout)
+
+	// the sender registered for the ID of the instance is used
+	mockSender = mocksender.NewMockSender(c.ID())
+	mockSender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	mockSender.AssertMetric(t, "Gauge", "sdk.timeout", 5, "", []string{"url:http://localhost"})
+	mockSender.AssertServiceCheck(t, "sdk.can_connect", metrics.ServiceCheckOK, "", nil, "")
+	mockSender.AssertNumberOfCalls(t, "Commit", 1)
+
+	// the context of the run is passed to the check
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sdk.SetRunContext(ctx)
+	assert.Equal(t, context.Canceled, c.Run())
+	mockSender.AssertNumberOfCalls(t, "Commit", 2)
+
+	c.Stop()
+	assert.True(t, sdk.impl.(*sdkTestCheck).stopped)
+}
+
+func TestConfigUnmarshal(t *testing.T) {
+	config := &Config{
+		Instance:   integration.Data("timeout: 0"),
+		InitConfig: integration.Data("url: http://localhost\ntimeout: 10"),
+	}
+
+	c := sdkTestConfig{}
+	assert.EqualError(t, config.UnmarshalInstance(&c), "invalid instance section: missing required options: url")
+
+	c = sdkTestConfig{}
+	require.NoError(t, config.UnmarshalInitConfig(&c))
+	assert.Equal(t, sdkTestConfig{URL: "http://localhost", Timeout: 10}, c)
+
+	config.Instance = integration.Data("url: http://localhost\ntimeout: 0")
+	assert.EqualError(t, config.UnmarshalInstance(&c), "invalid instance section: timeout must be positive")
+
+	config.Instance = integration.Data("url: [")
+	assert.Error(t, config.UnmarshalInstance(&c))
+
+	// unexported fields can't be set, they are never required
+	unexported := struct {
+		URL   string `yaml:"url" validate:"required"`
+		token string `validate:"required"`
+	}{}
+	config.Instance = integration.Data("url: http://localhost")
+	require.NoError(t, config.UnmarshalInstance(&unexported))
+	assert.Equal(t, "http://localhost", unexported.URL)
+}
@@ -7,6 +7,7 @@
 package system
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -32,6 +33,12 @@ type diskConfig struct {
 	excludedMountpointRe *regexp.Regexp
 	allPartitions        bool
 	deviceTagRe          map[*regexp.Regexp][]string
+	deviceInclude        []*regexp.Regexp
+	deviceExclude        []*regexp.Regexp
+	fileSystemInclude    []*regexp.Regexp
+	fileSystemExclude    []*regexp.Regexp
+	mountPointInclude    []*regexp.Regexp
+	mountPointExclude    []*regexp.Regexp
 }
 
 func (c *DiskCheck) excludeDisk(mountpoint, device, fstype string) bool {
@@ -74,6 +81,17 @@ func (c *DiskCheck) excludeDisk(mountpoint, device, fstype string) bool {
 		return true
 	}
 
+	// `*_exclude` take precedence over `*_include`
+	if !nameEmpty && !matchFilters(device, c.cfg.deviceInclude, c.cfg.deviceExclude) {
+		return true
+	}
+	if !matchFilters(fstype, c.cfg.fileSystemInclude, c.cfg.fileSystemExclude) {
+		return true
+	}
+	if !matchFilters(mountpoint, c.cfg.mountPointInclude, c.cfg.mountPointExclude) {
+		return true
+	}
+
 	// all good, don't exclude the disk
 	return false
 }
@@ -91,18 +109,12 @@ func (c *DiskCheck) instanceConfigure(data integration.Data) error {
 		c.cfg.useMount = useMount
 	}
 
-	excludedFilesystems, found := conf["excluded_filesystems"]
-	if excludedFilesystems, ok := excludedFilesystems.([]string); found && ok {
-		c.cfg.excludedFilesystems = excludedFilesystems
-	}
+	c.cfg.excludedFilesystems = stringList(conf["excluded_filesystems"])
 
 	// Force exclusion of CDROM (iso9660) from disk check
 	c.cfg.excludedFilesystems = append(c.cfg.excludedFilesystems, "iso9660")
 
-	excludedDisks, found := conf["excluded_disks"]
-	if excludedDisks, ok := excludedDisks.([]string); found && ok {
-		c.cfg.excludedDisks = excludedDisks
-	}
+	c.cfg.excludedDisks = stringList(conf["excluded_disks"])
 
 	excludedDiskRe, found := conf["excluded_disk_re"]
 	if excludedDiskRe, ok := excludedDiskRe.(string); found && ok {
@@ -146,9 +158,59 @@ func (c *DiskCheck) instanceConfigure(data integration.Data) error {
 		}
 	}
 
+	filters := map[string]*[]*regexp.Regexp{
+		"device_include":      &c.cfg.deviceInclude,
+		"device_exclude":      &c.cfg.deviceExclude,
+		"file_system_include": &c.cfg.fileSystemInclude,
+		"file_system_exclude": &c.cfg.fileSystemExclude,
+		"mount_point_include": &c.cfg.mountPointInclude,
+		"mount_point_exclude": &c.cfg.mountPointExclude,
+	}
+	for option, filter := range filters {
+		for _, reString := range stringList(conf[option]) {
+			re, err := regexp.Compile(reString)
+			if err != nil {
+				return fmt.Errorf("invalid regex in %s: %s", option, err)
+			}
+			*filter = append(*filter, re)
+		}
+	}
+
 	return nil
 }
 
+// stringList returns the strings of a list option, yaml decodes them as []interface{}
+func stringList(option interface{}) []string {
+	var list []string
+	if items, ok := option.([]interface{}); ok {
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+// matchFilters returns whether a value matches one of the include regexes, if
+// any, and none of the exclude regexes
+func matchFilters(value string, include, exclude []*regexp.Regexp) bool {
+	for _, re := range exclude {
+		if re.MatchString(value) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, re := range include {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
 func stringSliceContain(slice []string, x string) bool {
 	for _, e := range slice {
 		if e == x {
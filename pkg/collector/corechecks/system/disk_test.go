@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -184,6 +185,35 @@ func TestDiskCheckExcludedRe(t *testing.T) {
 	mock.AssertNumberOfCalls(t, "Commit", 1)
 }
 
+func TestDiskCheckFilters(t *testing.T) {
+	diskPartitions = diskSampler
+	diskUsage = diskUsageSampler
+	ioCounters = diskIoSampler
+
+	for _, config := range []string{
+		"excluded_filesystems:\n  - vfat",
+		"file_system_include:\n  - ext.*",
+		"device_exclude:\n  - .*sda1",
+		"mount_point_include:\n  - /\nmount_point_exclude:\n  - /boot.*",
+	} {
+		diskCheck := new(DiskCheck)
+		err := diskCheck.Configure(integration.Data(config), nil, "test")
+		assert.NoError(t, err)
+
+		mock := mocksender.NewMockSender(diskCheck.ID())
+		mock.SetupAcceptAll()
+
+		diskCheck.Run()
+		mock.AssertMetric(t, "Gauge", "system.disk.total", 50825728.0, "", []string{"device:/dev/sda2", "device_name:sda2"})
+		mock.AssertNotCalled(t, "Gauge", "system.disk.total", 523248.0, "", []string{"device:/dev/sda1", "device_name:sda1"})
+		mock.AssertNumberOfCalls(t, "Gauge", 8)
+	}
+
+	diskCheck := new(DiskCheck)
+	err := diskCheck.Configure(integration.Data("device_include:\n  - ("), nil, "test")
+	assert.Error(t, err)
+}
+
 func TestDiskCheckTags(t *testing.T) {
 	diskPartitions = diskSampler
 	diskUsage = diskUsageSampler
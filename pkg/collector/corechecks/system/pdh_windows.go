@@ -0,0 +1,213 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"fmt"
+	"path"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+const pdhCheckName = "windows_pdh"
+
+// pdhInstanceConfig is the configuration of an instance of the check, the
+// metrics are `[<counter>, <metric>, <type>]`, like for the pdh_check integration
+type pdhInstanceConfig struct {
+	CounterSetName   string     `yaml:"countersetname"`
+	Metrics          [][]string `yaml:"metrics"`
+	Instances        []string   `yaml:"instances"`
+	ExcludeInstances []string   `yaml:"exclude_instances"`
+	TagName          string     `yaml:"tag_name"`
+}
+
+// pdhMetric is a counter of the counter set and the metric it's submitted as
+type pdhMetric struct {
+	name           string
+	metricType     string
+	singleInstance *pdhutil.PdhSingleInstanceCounterSet
+	multiInstance  *pdhutil.PdhMultiInstanceCounterSet
+}
+
+// PdhCheck collects the counters of a counter set, it only needs a configuration
+// to collect the counters of a new class
+type PdhCheck struct {
+	core.CheckBase
+	config pdhInstanceConfig
+	// m protects the query from being closed during a run
+	m       sync.Mutex
+	query   *pdhutil.PdhQuery
+	metrics []pdhMetric
+}
+
+var pdhMetricTypes = map[string]bool{
+	"gauge":           true,
+	"rate":            true,
+	"count":           true,
+	"monotonic_count": true,
+	"histogram":       true,
+}
+
+// Configure the pdh check
+func (c *PdhCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(data, initConfig)
+	err := c.CommonConfigure(data, source)
+	if err != nil {
+		return err
+	}
+
+	c.config = pdhInstanceConfig{TagName: "instance"}
+	if err := yaml.Unmarshal(data, &c.config); err != nil {
+		return err
+	}
+	if c.config.CounterSetName == "" {
+		return fmt.Errorf("countersetname is required")
+	}
+	for _, pattern := range c.config.ExcludeInstances {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s in exclude_instances: %s", pattern, err)
+		}
+	}
+
+	singleInstance, err := pdhutil.IsSingleInstance(c.config.CounterSetName)
+	if err != nil {
+		return err
+	}
+
+	c.Cancel()
+	c.m.Lock()
+	defer c.m.Unlock()
+	if err := c.addMetrics(singleInstance); err != nil {
+		c.closeQuery()
+		return err
+	}
+	return nil
+}
+
+// addMetrics adds the counters of the metrics to a query shared by all of them
+func (c *PdhCheck) addMetrics(singleInstance bool) error {
+	var err error
+	c.query, err = pdhutil.NewPdhQuery()
+	if err != nil {
+		return err
+	}
+	for _, m := range c.config.Metrics {
+		if len(m) != 3 {
+			return fmt.Errorf("invalid metric %v, expected [<counter>, <metric>, <type>]", m)
+		}
+		if !pdhMetricTypes[m[2]] {
+			return fmt.Errorf("invalid type %s for metric %s", m[2], m[1])
+		}
+		metric := pdhMetric{name: m[1], metricType: m[2]}
+		if singleInstance {
+			metric.singleInstance, err = c.query.AddSingleInstanceCounter(c.config.CounterSetName, m[0])
+		} else {
+			metric.multiInstance, err = c.query.AddMultiInstanceCounter(c.config.CounterSetName, m[0], &c.config.Instances, c.isIncluded)
+		}
+		if err != nil {
+			return err
+		}
+		c.metrics = append(c.metrics, metric)
+	}
+	return nil
+}
+
+// isIncluded returns false for the instances matching exclude_instances
+func (c *PdhCheck) isIncluded(instance string) bool {
+	for _, pattern := range c.config.ExcludeInstances {
+		if matched, _ := path.Match(pattern, instance); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the check
+func (c *PdhCheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.query == nil {
+		return fmt.Errorf("the check has been canceled")
+	}
+	c.query.CollectData()
+	for _, metric := range c.metrics {
+		if metric.singleInstance != nil {
+			val, err := metric.singleInstance.GetValue()
+			if err != nil {
+				log.Debugf("Error getting the value of %s: %v", metric.name, err)
+				continue
+			}
+			submitPdhMetric(sender, metric, val, nil)
+			continue
+		}
+
+		vals, err := metric.multiInstance.GetAllValues()
+		if err != nil {
+			log.Debugf("Error getting the values of %s: %v", metric.name, err)
+			continue
+		}
+		for inst, val := range vals {
+			submitPdhMetric(sender, metric, val, []string{c.config.TagName + ":" + inst})
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+func submitPdhMetric(sender aggregator.Sender, metric pdhMetric, val float64, tags []string) {
+	switch metric.metricType {
+	case "rate":
+		sender.Rate(metric.name, val, "", tags)
+	case "count":
+		sender.Count(metric.name, val, "", tags)
+	case "monotonic_count":
+		sender.MonotonicCount(metric.name, val, "", tags)
+	case "histogram":
+		sender.Histogram(metric.name, val, "", tags)
+	default:
+		sender.Gauge(metric.name, val, "", tags)
+	}
+}
+
+// Cancel closes the pdh query of the check
+func (c *PdhCheck) Cancel() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.closeQuery()
+}
+
+func (c *PdhCheck) closeQuery() {
+	if c.query != nil {
+		c.query.Close()
+	}
+	c.query = nil
+	c.metrics = nil
+}
+
+func pdhCheckFactory() check.Check {
+	return &PdhCheck{
+		CheckBase: core.NewCheckBase(pdhCheckName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(pdhCheckName, pdhCheckFactory)
+}
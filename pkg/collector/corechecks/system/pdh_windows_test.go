@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build windows
+
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	pdhtest "github.com/DataDog/datadog-agent/pkg/util/winutil/pdhutil"
+)
+
+func TestPdhCheckMultiInstance(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(0)\\% Processor Time", 10.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(1)\\% Processor Time", 20.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(_Total)\\% Processor Time", 15.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(0)\\Interrupts/sec", 100.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(1)\\Interrupts/sec", 200.0)
+
+	pdhCheck := pdhCheckFactory().(*PdhCheck)
+	err := pdhCheck.Configure(integration.Data(`
+countersetname: Processor
+tag_name: cpu
+instances: ["[0-9]*"]
+metrics:
+  - ["% Processor Time", windows.cpu.time, gauge]
+  - ["Interrupts/sec", windows.cpu.interrupts, gauge]
+`), nil, "test")
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(pdhCheck.ID())
+	mock.SetupAcceptAll()
+	pdhCheck.Run()
+
+	mock.AssertMetric(t, "Gauge", "windows.cpu.time", 10.0, "", []string{"cpu:0"})
+	mock.AssertMetric(t, "Gauge", "windows.cpu.time", 20.0, "", []string{"cpu:1"})
+	mock.AssertMetric(t, "Gauge", "windows.cpu.interrupts", 100.0, "", []string{"cpu:0"})
+	mock.AssertMetric(t, "Gauge", "windows.cpu.interrupts", 200.0, "", []string{"cpu:1"})
+	mock.AssertNumberOfCalls(t, "Gauge", 4)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestPdhCheckExcludeInstances(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(0)\\% User Time", 1.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(1)\\% User Time", 2.0)
+	pdhtest.SetQueryReturnValue("\\\\.\\Processor(_Total)\\% User Time", 1.5)
+
+	pdhCheck := pdhCheckFactory().(*PdhCheck)
+	err := pdhCheck.Configure(integration.Data(`
+countersetname: Processor
+exclude_instances: ["_*"]
+metrics:
+  - ["% User Time", windows.cpu.user, rate]
+`), nil, "test")
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(pdhCheck.ID())
+	mock.SetupAcceptAll()
+	pdhCheck.Run()
+
+	mock.AssertMetric(t, "Rate", "windows.cpu.user", 1.0, "", []string{"instance:0"})
+	mock.AssertMetric(t, "Rate", "windows.cpu.user", 2.0, "", []string{"instance:1"})
+	mock.AssertNumberOfCalls(t, "Rate", 2)
+}
+
+func TestPdhCheckSingleInstance(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.SetQueryReturnValue("\\\\.\\System\\Processes", 32.0)
+
+	pdhCheck := pdhCheckFactory().(*PdhCheck)
+	err := pdhCheck.Configure(integration.Data(`
+countersetname: System
+metrics:
+  - ["Processes", windows.system.processes, gauge]
+`), nil, "test")
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(pdhCheck.ID())
+	mock.SetupAcceptAll()
+	pdhCheck.Run()
+
+	mock.AssertMetric(t, "Gauge", "windows.system.processes", 32.0, "", nil)
+	mock.AssertNumberOfCalls(t, "Gauge", 1)
+}
+
+func TestPdhCheckConfigErrors(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+
+	for _, config := range []string{
+		"metrics: [[Processes, windows.system.processes, gauge]]",
+		"countersetname: System\nmetrics: [[Processes, windows.system.processes]]",
+		"countersetname: System\nmetrics: [[Processes, windows.system.processes, set]]",
+	} {
+		pdhCheck := pdhCheckFactory().(*PdhCheck)
+		assert.Error(t, pdhCheck.Configure(integration.Data(config), nil, "test"), config)
+	}
+}
+
+func TestPdhCheckMultiInstanceWithoutInstances(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.AddMultiInstanceClassWithoutInstances("Event Tracing for Windows")
+
+	pdhCheck := pdhCheckFactory().(*PdhCheck)
+	err := pdhCheck.Configure(integration.Data(`
+countersetname: Event Tracing for Windows
+metrics:
+  - ["Total Memory Usage --- Paged Pool", windows.etw.paged_pool, gauge]
+`), nil, "test")
+	require.NoError(t, err)
+
+	mock := mocksender.NewMockSender(pdhCheck.ID())
+	mock.SetupAcceptAll()
+	require.NoError(t, pdhCheck.Run())
+
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+	mock.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestPdhCheckCancel(t *testing.T) {
+	pdhtest.SetupTesting("testfiles\\counter_indexes_en-us.txt", "testfiles\\allcounters_en-us.txt")
+	pdhtest.SetQueryReturnValue("\\\\.\\System\\Processes", 32.0)
+
+	pdhCheck := pdhCheckFactory().(*PdhCheck)
+	err := pdhCheck.Configure(integration.Data(`
+countersetname: System
+metrics:
+  - ["Processes", windows.system.processes, gauge]
+`), nil, "test")
+	require.NoError(t, err)
+
+	mocksender.NewMockSender(pdhCheck.ID()).SetupAcceptAll()
+	pdhCheck.Cancel()
+	assert.Nil(t, pdhCheck.query)
+	assert.Error(t, pdhCheck.Run())
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -37,6 +38,9 @@ import "C"
 
 // PythonCheck represents a Python check, implements `Check` interface
 type PythonCheck struct {
+	// keep members that are used in atomic functions at the top of the structure
+	// important for 32 bit compiles.
+	runThreadID  uint64 // the Python identifier of the thread running the check, 0 when not running
 	id           check.ID
 	version      string
 	instance     *C.rtloader_pyobject_t
@@ -46,6 +50,8 @@ type PythonCheck struct {
 	lastWarnings []error
 	source       string
 	telemetry    bool // whether or not the telemetry is enabled for this check
+	runOptions   check.RunOptions
+	memoryOwner  C.int // the owner of the memory allocated by the instance, see python_memory_accounting
 }
 
 // NewPythonCheck conveniently creates a PythonCheck instance
@@ -69,6 +75,15 @@ func (c *PythonCheck) runCheck(commitMetrics bool) error {
 	gstate := newStickyLock()
 	defer gstate.unlock()
 
+	// the thread is known while the GIL is held, so that Interrupt can't target
+	// another run on the same thread
+	atomic.StoreUint64(&c.runThreadID, uint64(C.get_current_thread_id(rtloader)))
+	defer atomic.StoreUint64(&c.runThreadID, 0)
+
+	// the memory allocated during the run is accounted to the instance
+	previousOwner := C.set_memory_owner(rtloader, c.memoryOwner)
+	defer C.set_memory_owner(rtloader, previousOwner)
+
 	log.Debugf("Running python check %s %s", c.ModuleName, c.id)
 
 	cResult := C.run_check(rtloader, c.instance)
@@ -111,6 +126,30 @@ func (c *PythonCheck) RunSimple() error {
 // Stop does nothing
 func (c *PythonCheck) Stop() {}
 
+// Cancel does nothing
+func (c *PythonCheck) Cancel() {}
+
+// Interrupt raises a KeyboardInterrupt exception in the thread running the
+// check, the run is interrupted the next time it executes Python code
+func (c *PythonCheck) Interrupt() error {
+	glock := newStickyLock()
+	defer glock.unlock()
+
+	threadID := atomic.LoadUint64(&c.runThreadID)
+	if threadID == 0 {
+		return fmt.Errorf("the check %s is not running", c.id)
+	}
+	if C.interrupt_thread(rtloader, C.ulong(threadID)) == 0 {
+		return fmt.Errorf("could not find the thread running the check %s", c.id)
+	}
+	return nil
+}
+
+// RunOptions returns the jitter, timeout and concurrency class of the instance
+func (c *PythonCheck) RunOptions() check.RunOptions {
+	return c.runOptions
+}
+
 // String representation (for debug and logging)
 func (c *PythonCheck) String() string {
 	return c.ModuleName
@@ -172,26 +211,10 @@ func (c *PythonCheck) getPythonWarnings(gstate *stickyLock) []error {
 func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
 	// Generate check ID
 	c.id = check.Identify(c, data, initConfig)
+	c.memoryOwner = memoryOwner(c.id)
 
-	commonGlobalOptions := integration.CommonGlobalConfig{}
-	if err := yaml.Unmarshal(initConfig, &commonGlobalOptions); err != nil {
-		log.Errorf("invalid init_config section for check %s: %s", string(c.id), err)
-		return err
-	}
-
-	// Set service for this check
-	if len(commonGlobalOptions.Service) > 0 {
-		s, err := aggregator.GetSender(c.id)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
-		} else {
-			s.SetCheckService(commonGlobalOptions.Service)
-		}
-	}
-
-	commonOptions := integration.CommonInstanceConfig{}
-	if err := yaml.Unmarshal(data, &commonOptions); err != nil {
-		log.Errorf("invalid instance section for check %s: %s", string(c.id), err)
+	commonOptions, err := configureCommonOptions(c.id, data, initConfig)
+	if err != nil {
 		return err
 	}
 
@@ -199,26 +222,7 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 	if commonOptions.MinCollectionInterval > 0 {
 		c.interval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
 	}
-
-	// Disable default hostname if specified
-	if commonOptions.EmptyDefaultHostname {
-		s, err := aggregator.GetSender(c.id)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
-		} else {
-			s.DisableDefaultHostname(true)
-		}
-	}
-
-	// Set configured service for this check, overriding the one possibly defined globally
-	if len(commonOptions.Service) > 0 {
-		s, err := aggregator.GetSender(c.id)
-		if err != nil {
-			log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
-		} else {
-			s.SetCheckService(commonOptions.Service)
-		}
-	}
+	c.runOptions = check.NewRunOptions(commonOptions)
 
 	cInitConfig := TrackedCString(string(initConfig))
 	cInstance := TrackedCString(string(data))
@@ -229,6 +233,13 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 	defer C._free(unsafe.Pointer(cCheckID))
 	defer C._free(unsafe.Pointer(cCheckName))
 
+	// the memory allocated by the instantiation is accounted to the instance, the
+	// owner is set on the thread making the calls
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	previousOwner := C.set_memory_owner(rtloader, c.memoryOwner)
+	defer C.set_memory_owner(rtloader, previousOwner)
+
 	var check *C.rtloader_pyobject_t
 	res := C.get_check(rtloader, c.class, cInitConfig, cInstance, cCheckID, cCheckName, &check)
 	var rtLoaderError error
@@ -270,13 +281,75 @@ func (c *PythonCheck) Configure(data integration.Data, initConfig integration.Da
 	return nil
 }
 
+// configureCommonOptions applies the options common to the instances of all the
+// checks to the sender of an instance, and returns them
+func configureCommonOptions(id check.ID, data integration.Data, initConfig integration.Data) (integration.CommonInstanceConfig, error) {
+	commonGlobalOptions := integration.CommonGlobalConfig{}
+	if err := yaml.Unmarshal(initConfig, &commonGlobalOptions); err != nil {
+		log.Errorf("invalid init_config section for check %s: %s", string(id), err)
+		return integration.CommonInstanceConfig{}, err
+	}
+
+	// Set service for this check
+	if len(commonGlobalOptions.Service) > 0 {
+		s, err := aggregator.GetSender(id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(id), err)
+		} else {
+			s.SetCheckService(commonGlobalOptions.Service)
+		}
+	}
+
+	commonOptions := integration.CommonInstanceConfig{}
+	if err := yaml.Unmarshal(data, &commonOptions); err != nil {
+		log.Errorf("invalid instance section for check %s: %s", string(id), err)
+		return commonOptions, err
+	}
+
+	// Disable default hostname if specified
+	if commonOptions.EmptyDefaultHostname {
+		s, err := aggregator.GetSender(id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(id), err)
+		} else {
+			s.DisableDefaultHostname(true)
+		}
+	}
+
+	// Set configured service for this check, overriding the one possibly defined globally
+	if len(commonOptions.Service) > 0 {
+		s, err := aggregator.GetSender(id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(id), err)
+		} else {
+			s.SetCheckService(commonOptions.Service)
+		}
+	}
+
+	// Override the context limit of `check_max_metric_contexts` for this check
+	if commonOptions.MaxMetricContexts > 0 {
+		s, err := aggregator.GetSender(id)
+		if err != nil {
+			log.Errorf("failed to retrieve a sender for check %s: %s", string(id), err)
+		} else {
+			s.SetContextLimit(commonOptions.MaxMetricContexts)
+		}
+	}
+
+	return commonOptions, nil
+}
+
 // GetMetricStats returns the stats from the last run of the check
 func (c *PythonCheck) GetMetricStats() (map[string]int64, error) {
 	sender, err := aggregator.GetSender(c.ID())
 	if err != nil {
 		return nil, fmt.Errorf("Failed to retrieve a Sender instance: %v", err)
 	}
-	return sender.GetMetricStats(), nil
+	stats := sender.GetMetricStats()
+	if bytes, ok := memoryOwnerBytes(c.memoryOwner); ok {
+		stats["MemoryBytes"] = bytes
+	}
+	return stats, nil
 }
 
 // Interval returns the scheduling time for the check
@@ -15,6 +15,14 @@ func TestRunCheck(t *testing.T) {
 	testRunCheck(t)
 }
 
+func TestInterrupt(t *testing.T) {
+	testInterrupt(t)
+}
+
+func TestRunMemoryOwner(t *testing.T) {
+	testRunMemoryOwner(t)
+}
+
 func TestRunErrorNil(t *testing.T) {
 	testRunErrorNil(t)
 }
@@ -336,6 +336,15 @@ func Initialize(paths ...string) error {
 	C.initContainersModule(rtloader)
 	C.initkubeutilModule(rtloader)
 
+	// The allocators of Python can only be replaced before its initialization
+	if config.Datadog.GetBool("python_memory_accounting") {
+		if C.enable_memory_accounting(rtloader) == 0 {
+			log.Warnf("The memory of the Python checks can't be accounted with python %s", pythonVersion)
+		} else {
+			log.Infof("Accounting the memory allocated by the Python checks")
+		}
+	}
+
 	// Init RtLoader machinery
 	if C.init(rtloader) == 0 {
 		err := fmt.Sprintf("could not initialize rtloader: %s", C.GoString(C.get_error(rtloader)))
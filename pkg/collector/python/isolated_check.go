@@ -0,0 +1,346 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build python
+
+package python
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// IsolatedCheck runs a Python check instance in a process of its own, see
+// isolation.go. The process is started when the instance is configured, and
+// restarted at the next run when it exits or uses too much memory.
+type IsolatedCheck struct {
+	id           check.ID
+	name         string
+	source       string
+	instance     integration.Data
+	initConfig   integration.Data
+	interval     time.Duration
+	runOptions   check.RunOptions
+	lastWarnings []error
+	memoryBytes  int64
+	telemetry    bool
+	process      *isolatedProcess
+	m            sync.Mutex // protects process, killed by Interrupt during a run
+}
+
+// isolatedProcess is a process running an isolated check
+type isolatedProcess struct {
+	cmd      *exec.Cmd
+	requests *json.Encoder
+	results  *json.Decoder
+	stdin    io.Closer
+	exited   chan struct{}
+}
+
+// NewIsolatedCheck returns an isolated check, running the Python check of the given name
+func NewIsolatedCheck(name string) *IsolatedCheck {
+	c := &IsolatedCheck{
+		name:         name,
+		interval:     defaults.DefaultCheckInterval,
+		lastWarnings: []error{},
+		telemetry:    telemetry.IsCheckEnabled(name),
+	}
+	runtime.SetFinalizer(c, isolatedCheckFinalizer)
+	return c
+}
+
+// Configure the instance and starts its process, the errors of the
+// configuration of the check in the process are returned
+func (c *IsolatedCheck) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	// the ID is the one of the instance running in the agent
+	c.id = check.Identify(c, data, initConfig)
+
+	commonOptions, err := configureCommonOptions(c.id, data, initConfig)
+	if err != nil {
+		return err
+	}
+	if commonOptions.MinCollectionInterval > 0 {
+		c.interval = time.Duration(commonOptions.MinCollectionInterval) * time.Second
+	}
+	c.runOptions = check.NewRunOptions(commonOptions)
+	c.instance = data
+	c.initConfig = initConfig
+	c.source = source
+
+	if s, err := aggregator.GetSender(c.id); err != nil {
+		log.Errorf("failed to retrieve a sender for check %s: %s", string(c.id), err)
+	} else {
+		s.FinalizeCheckServiceTag()
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.start()
+}
+
+// start starts the process of the instance, c.m must be held
+func (c *IsolatedCheck) start() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find the agent executable: %s", err)
+	}
+	args := []string{"isolated-check"}
+	if config.Datadog.ConfigFileUsed() != "" {
+		args = append(args, "--cfgpath", config.FileUsedDir())
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.Env = append(os.Environ(), "DD_LOG_LEVEL="+config.Datadog.GetString("log_level"))
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	resultsReader, resultsWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	// the results pipe is the first extra file of the process, its descriptor 3
+	cmd.ExtraFiles = []*os.File{resultsWriter}
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		resultsReader.Close()
+		resultsWriter.Close()
+		return fmt.Errorf("could not start the process of the check: %s", err)
+	}
+	resultsWriter.Close()
+	log.Infof("Started the process %d running the isolated check %s", cmd.Process.Pid, c.id)
+
+	p := &isolatedProcess{
+		cmd:      cmd,
+		requests: json.NewEncoder(stdin),
+		results:  json.NewDecoder(resultsReader),
+		stdin:    stdin,
+		exited:   make(chan struct{}),
+	}
+	go func(id check.ID) {
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			log.Infof("(%s) %s", id, scanner.Text())
+		}
+		if err := cmd.Wait(); err != nil {
+			log.Warnf("The process of the isolated check %s exited: %s", id, err)
+		}
+		resultsReader.Close()
+		close(p.exited)
+	}(c.id)
+
+	err = p.requests.Encode(isolatedCheckConfig{
+		Name:       c.name,
+		InitConfig: string(c.initConfig),
+		Instance:   string(c.instance),
+		Source:     c.source,
+	})
+	result := isolatedResult{}
+	if err == nil {
+		err = p.results.Decode(&result)
+	}
+	if err != nil {
+		p.kill()
+		return fmt.Errorf("could not configure the check in its process: %s", err)
+	}
+	if result.Error != "" {
+		p.kill()
+		return errors.New(result.Error)
+	}
+
+	c.process = p
+	return nil
+}
+
+// kill stops the process without waiting for its exit
+func (p *isolatedProcess) kill() {
+	p.stdin.Close()
+	if err := p.cmd.Process.Kill(); err != nil {
+		log.Debugf("Could not kill the process %d: %s", p.cmd.Process.Pid, err)
+	}
+}
+
+// Run the check in its process, and sends the data it collected
+func (c *IsolatedCheck) Run() error {
+	c.m.Lock()
+	if c.process == nil {
+		if err := c.start(); err != nil {
+			c.m.Unlock()
+			return err
+		}
+	}
+	p := c.process
+	c.m.Unlock()
+
+	result := isolatedResult{}
+	err := p.requests.Encode(isolatedRunRequest{})
+	if err == nil {
+		err = p.results.Decode(&result)
+	}
+	if err != nil {
+		c.stopProcess(p)
+		<-p.exited
+		return fmt.Errorf("the process of the check exited during the run, it's restarted at the next run: %s", err)
+	}
+
+	sender, err := aggregator.GetSender(c.id)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve a Sender instance: %v", err)
+	}
+	replay(sender, result.Calls)
+	sender.Commit()
+
+	c.lastWarnings = []error{}
+	for _, w := range result.Warnings {
+		c.lastWarnings = append(c.lastWarnings, errors.New(w))
+	}
+	c.checkMemory(p)
+
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// checkMemory records the memory used by the process, and stops it when it's
+// above `python_isolation_max_memory_mb`
+func (c *IsolatedCheck) checkMemory(p *isolatedProcess) {
+	proc, err := process.NewProcess(int32(p.cmd.Process.Pid))
+	if err != nil {
+		log.Debugf("Could not get the process of the check %s: %s", c.id, err)
+		return
+	}
+	memory, err := proc.MemoryInfo()
+	if err != nil {
+		log.Debugf("Could not get the memory of the process of the check %s: %s", c.id, err)
+		return
+	}
+	c.memoryBytes = int64(memory.RSS)
+
+	maxMemory := config.Datadog.GetInt64("python_isolation_max_memory_mb") * 1024 * 1024
+	if maxMemory > 0 && c.memoryBytes > maxMemory {
+		c.lastWarnings = append(c.lastWarnings, fmt.Errorf("The process of the check uses %d MB, above the %d MB of `python_isolation_max_memory_mb`: it's restarted at the next run", c.memoryBytes/1024/1024, maxMemory/1024/1024))
+		c.stopProcess(p)
+	}
+}
+
+// stopProcess kills the process if it's still the one of the instance
+func (c *IsolatedCheck) stopProcess(p *isolatedProcess) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.process == p {
+		c.process = nil
+	}
+	p.kill()
+}
+
+// Interrupt kills the process of the check, the stuck run returns an error
+func (c *IsolatedCheck) Interrupt() error {
+	c.m.Lock()
+	p := c.process
+	c.m.Unlock()
+	if p == nil {
+		return fmt.Errorf("the process of the check %s is not running", c.id)
+	}
+	c.stopProcess(p)
+	return nil
+}
+
+// Cancel does nothing, the process of the check only lives during a run
+func (c *IsolatedCheck) Cancel() {}
+
+// Stop kills the process of the check
+func (c *IsolatedCheck) Stop() {
+	c.m.Lock()
+	p := c.process
+	c.m.Unlock()
+	if p != nil {
+		c.stopProcess(p)
+	}
+}
+
+// RunOptions returns the jitter, timeout and concurrency class of the instance
+func (c *IsolatedCheck) RunOptions() check.RunOptions {
+	return c.runOptions
+}
+
+// String representation (for debug and logging)
+func (c *IsolatedCheck) String() string {
+	return c.name
+}
+
+// Version returns an empty string, the check isn't loaded by the agent
+func (c *IsolatedCheck) Version() string {
+	return ""
+}
+
+// IsTelemetryEnabled returns if the telemetry is enabled for this check
+func (c *IsolatedCheck) IsTelemetryEnabled() bool {
+	return c.telemetry
+}
+
+// ConfigSource returns the source of the configuration for this check
+func (c *IsolatedCheck) ConfigSource() string {
+	return c.source
+}
+
+// GetWarnings grabs the warnings of the last run
+func (c *IsolatedCheck) GetWarnings() []error {
+	warnings := c.lastWarnings
+	c.lastWarnings = []error{}
+	return warnings
+}
+
+// GetMetricStats returns the stats from the last run of the check, with the
+// memory used by its process
+func (c *IsolatedCheck) GetMetricStats() (map[string]int64, error) {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve a Sender instance: %v", err)
+	}
+	stats := sender.GetMetricStats()
+	if c.memoryBytes > 0 {
+		stats["MemoryBytes"] = c.memoryBytes
+	}
+	return stats, nil
+}
+
+// Interval returns the scheduling time for the check
+func (c *IsolatedCheck) Interval() time.Duration {
+	return c.interval
+}
+
+// ID returns the ID of the check
+func (c *IsolatedCheck) ID() check.ID {
+	return c.id
+}
+
+// isolatedCheckFinalizer kills the process of an unscheduled check
+func isolatedCheckFinalizer(c *IsolatedCheck) {
+	c.Stop()
+}
@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build python
+
+package python
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The instances configured with `isolation: process` run in a process of their
+// own, started with the hidden `isolated-check` command of the agent, so that a
+// leaking or crashing check can't take the agent down with it.
+//
+// The agent writes the configuration of the instance on the standard input of
+// the process, then a request per run. The process answers on its results
+// pipe, the file descriptor 3, with the result of the configuration then the
+// result of each run: the calls made by the check to its sender, replayed by
+// the agent on the sender of the instance. The standard output and error of the
+// process are left to the check and logged by the agent.
+
+const (
+	isolationProcess = "process"
+	// IsolatedResultsFd is the file descriptor of the results pipe in the isolated process
+	IsolatedResultsFd = 3
+)
+
+// isolationConfig is the isolation option of an instance
+type isolationConfig struct {
+	Isolation string `yaml:"isolation"`
+}
+
+// isolatedCheckConfig is the configuration of the instance run by the isolated process
+type isolatedCheckConfig struct {
+	Name       string `json:"name"`
+	InitConfig string `json:"init_config"`
+	Instance   string `json:"instance"`
+	Source     string `json:"source"`
+}
+
+// isolatedRunRequest asks the isolated process to run the check
+type isolatedRunRequest struct{}
+
+// isolatedResult is the result of the configuration, or of a run, of the check
+type isolatedResult struct {
+	Calls    []senderCall `json:"calls,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// senderCall is a call made by an isolated check to its sender
+type senderCall struct {
+	Method     string                     `json:"method"`
+	Name       string                     `json:"name,omitempty"`
+	Value      float64                    `json:"value,omitempty"`
+	Hostname   string                     `json:"hostname,omitempty"`
+	Tags       []string                   `json:"tags,omitempty"`
+	Status     metrics.ServiceCheckStatus `json:"status,omitempty"`
+	Message    string                     `json:"message,omitempty"`
+	Event      *metrics.Event             `json:"event,omitempty"`
+	Count      int64                      `json:"count,omitempty"`
+	LowerBound float64                    `json:"lower_bound,omitempty"`
+	UpperBound float64                    `json:"upper_bound,omitempty"`
+	Monotonic  bool                       `json:"monotonic,omitempty"`
+}
+
+// isIsolated returns whether an instance runs in a process of its own
+func isIsolated(instance integration.Data) bool {
+	options := isolationConfig{}
+	if err := yaml.Unmarshal(instance, &options); err != nil {
+		return false
+	}
+	switch options.Isolation {
+	case "":
+		return false
+	case isolationProcess:
+		return true
+	default:
+		log.Warnf("Unknown isolation '%s', it should be '%s': the instance runs in the agent", options.Isolation, isolationProcess)
+		return false
+	}
+}
+
+// recordingSender records the calls of an isolated check to its sender. The
+// options of the sender are set by the agent on the sender of the instance.
+type recordingSender struct {
+	calls []senderCall
+	m     sync.Mutex
+}
+
+// flush returns the calls recorded since the previous flush
+func (s *recordingSender) flush() []senderCall {
+	s.m.Lock()
+	defer s.m.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+// record is safe for the threads started by the check
+func (s *recordingSender) record(call senderCall) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *recordingSender) Commit() {}
+
+func (s *recordingSender) Gauge(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "gauge", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Rate(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "rate", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Count(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "count", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) MonotonicCount(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "monotonic_count", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Counter(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "counter", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Histogram(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "histogram", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Historate(metric string, value float64, hostname string, tags []string) {
+	s.record(senderCall{Method: "historate", Name: metric, Value: value, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) ServiceCheck(checkName string, status metrics.ServiceCheckStatus, hostname string, tags []string, message string) {
+	s.record(senderCall{Method: "service_check", Name: checkName, Status: status, Hostname: hostname, Tags: tags, Message: message})
+}
+
+func (s *recordingSender) HistogramBucket(metric string, value int64, lowerBound, upperBound float64, monotonic bool, hostname string, tags []string) {
+	s.record(senderCall{Method: "histogram_bucket", Name: metric, Count: value, LowerBound: lowerBound, UpperBound: upperBound, Monotonic: monotonic, Hostname: hostname, Tags: tags})
+}
+
+func (s *recordingSender) Event(e metrics.Event) {
+	s.record(senderCall{Method: "event", Event: &e})
+}
+
+func (s *recordingSender) GetMetricStats() map[string]int64    { return map[string]int64{} }
+func (s *recordingSender) DisableDefaultHostname(disable bool) {}
+func (s *recordingSender) SetCheckCustomTags(tags []string)    {}
+func (s *recordingSender) SetCheckService(service string)      {}
+func (s *recordingSender) FinalizeCheckServiceTag()            {}
+func (s *recordingSender) SetContextLimit(limit int)           {}
+
+// replay makes the calls recorded by an isolated check on the sender of the instance
+func replay(sender aggregator.Sender, calls []senderCall) {
+	for _, call := range calls {
+		switch call.Method {
+		case "gauge":
+			sender.Gauge(call.Name, call.Value, call.Hostname, call.Tags)
+		case "rate":
+			sender.Rate(call.Name, call.Value, call.Hostname, call.Tags)
+		case "count":
+			sender.Count(call.Name, call.Value, call.Hostname, call.Tags)
+		case "monotonic_count":
+			sender.MonotonicCount(call.Name, call.Value, call.Hostname, call.Tags)
+		case "counter":
+			sender.Counter(call.Name, call.Value, call.Hostname, call.Tags)
+		case "histogram":
+			sender.Histogram(call.Name, call.Value, call.Hostname, call.Tags)
+		case "historate":
+			sender.Historate(call.Name, call.Value, call.Hostname, call.Tags)
+		case "service_check":
+			sender.ServiceCheck(call.Name, call.Status, call.Hostname, call.Tags, call.Message)
+		case "histogram_bucket":
+			sender.HistogramBucket(call.Name, call.Count, call.LowerBound, call.UpperBound, call.Monotonic, call.Hostname, call.Tags)
+		case "event":
+			if call.Event != nil {
+				sender.Event(*call.Event)
+			}
+		default:
+			log.Debugf("Unknown call '%s' of an isolated check to its sender", call.Method)
+		}
+	}
+}
+
+// ServeIsolatedCheck runs the check instance of an isolated process: it reads
+// the configuration and the run requests of the agent from in and writes the
+// results to out, until in is closed. Python and the aggregator must be
+// initialized.
+func ServeIsolatedCheck(in io.Reader, out io.Writer) error {
+	requests := json.NewDecoder(in)
+	results := json.NewEncoder(out)
+
+	conf := isolatedCheckConfig{}
+	if err := requests.Decode(&conf); err != nil {
+		return fmt.Errorf("could not read the configuration of the check: %s", err)
+	}
+	instance := integration.Data(conf.Instance)
+	initConfig := integration.Data(conf.InitConfig)
+
+	// the sender must be set before the check is configured, the ID of the
+	// check is the one of the instance in the agent
+	sender := &recordingSender{}
+	if err := aggregator.SetSender(sender, check.BuildID(conf.Name, instance, initConfig)); err != nil {
+		return err
+	}
+
+	loader, _ := NewPythonCheckLoader()
+	c, err := loader.load(integration.Config{
+		Name:       conf.Name,
+		InitConfig: initConfig,
+		Instances:  []integration.Data{instance},
+		Source:     conf.Source,
+	}, instance)
+	if err != nil {
+		// the agent reports the configuration error, the process exits anyway
+		results.Encode(isolatedResult{Error: err.Error()})
+		return err
+	}
+	if err := results.Encode(isolatedResult{}); err != nil {
+		return err
+	}
+
+	for {
+		if err := requests.Decode(&isolatedRunRequest{}); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		result := isolatedResult{}
+		if err := c.Run(); err != nil {
+			result.Error = err.Error()
+		}
+		for _, w := range c.GetWarnings() {
+			result.Warnings = append(result.Warnings, w.Error())
+		}
+		result.Calls = sender.flush()
+		if err := results.Encode(result); err != nil {
+			return err
+		}
+	}
+}
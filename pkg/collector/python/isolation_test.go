@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build python,test
+
+package python
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestIsIsolated(t *testing.T) {
+	assert.False(t, isIsolated(integration.Data("host: localhost")))
+	assert.True(t, isIsolated(integration.Data("host: localhost\nisolation: process")))
+	assert.False(t, isIsolated(integration.Data("isolation: interpreter")))
+	assert.False(t, isIsolated(integration.Data("[")))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	recorder := &recordingSender{}
+	recorder.Gauge("isolated.gauge", 1, "", []string{"a:b"})
+	recorder.Rate("isolated.rate", 2, "host", nil)
+	recorder.MonotonicCount("isolated.count", 3, "", nil)
+	recorder.HistogramBucket("isolated.bucket", 4, 0, 10, true, "", nil)
+	recorder.ServiceCheck("isolated.can_connect", metrics.ServiceCheckCritical, "", nil, "timeout")
+	recorder.Event(metrics.Event{Title: "isolated", Text: "event"})
+
+	// the calls go through the results pipe
+	data, err := json.Marshal(isolatedResult{Calls: recorder.flush()})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.flush())
+	result := isolatedResult{}
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Len(t, result.Calls, 6)
+
+	sender := mocksender.NewMockSender(check.ID("isolatedID"))
+	sender.SetupAcceptAll()
+	replay(sender, result.Calls)
+
+	sender.AssertMetric(t, "Gauge", "isolated.gauge", 1, "", []string{"a:b"})
+	sender.AssertMetric(t, "Rate", "isolated.rate", 2, "host", nil)
+	sender.AssertMetric(t, "MonotonicCount", "isolated.count", 3, "", nil)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "isolated.bucket", 4, 0, 10, true, "", nil)
+	sender.AssertServiceCheck(t, "isolated.can_connect", metrics.ServiceCheckCritical, "", nil, "timeout")
+	sender.AssertEvent(t, metrics.Event{Title: "isolated", Text: "event"}, 0)
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"expvar"
 	"fmt"
+	"runtime"
 	"strings"
 	"sync"
 	"unsafe"
@@ -94,8 +95,26 @@ func getRtLoaderError() error {
 }
 
 // Load tries to import a Python module with the same name found in config.Name, searches for
-// subclasses of the AgentCheck class and returns the corresponding Check
+// subclasses of the AgentCheck class and returns the corresponding Check. The
+// instances configured with `isolation: process` run in a process of their own.
 func (cl *PythonCheckLoader) Load(config integration.Config, instance integration.Data) (check.Check, error) {
+	if !isIsolated(instance) {
+		return cl.load(config, instance)
+	}
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("the isolation of the instances isn't supported on Windows")
+	}
+
+	c := NewIsolatedCheck(config.Name)
+	if err := c.Configure(instance, config.InitConfig, config.Source); err != nil {
+		addExpvarConfigureError(config.Name, err.Error())
+		return c, fmt.Errorf("could not configure isolated check instance for python check %s: %s", config.Name, err.Error())
+	}
+	return c, nil
+}
+
+// load imports and configures the check in the agent
+func (cl *PythonCheckLoader) load(config integration.Config, instance integration.Data) (check.Check, error) {
 	if rtloader == nil {
 		return nil, fmt.Errorf("python is not initialized")
 	}
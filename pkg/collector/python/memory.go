@@ -14,6 +14,7 @@ import (
 	"sync"
 	"unsafe"
 
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -59,6 +60,13 @@ var (
 		nil, "Untracked frees count")
 )
 
+// the owners of the memory accounted by rtloader, one per check instance. The
+// owner of an instance is kept when it's rescheduled, so that its leaks add up.
+var (
+	memoryOwners   = map[check.ID]C.int{}
+	memoryOwnersMx sync.Mutex
+)
+
 func init() {
 	rtLoaderExpvars.Set("InuseBytes", &inuseBytes)
 	rtLoaderExpvars.Set("AllocatedBytes", &allocatedBytes)
@@ -116,3 +124,34 @@ func TrackedCString(str string) *C.char {
 
 	return cstr
 }
+
+// memoryOwner returns the owner of the memory allocated by a check instance, 0
+// when all the owners are taken
+func memoryOwner(id check.ID) C.int {
+	memoryOwnersMx.Lock()
+	defer memoryOwnersMx.Unlock()
+
+	if owner, found := memoryOwners[id]; found {
+		return owner
+	}
+	owner := C.int(len(memoryOwners) + 1)
+	if owner >= C.MEMORY_ACCOUNTING_OWNERS {
+		log.Debugf("Too many check instances to account the memory of %s", id)
+		return 0
+	}
+	memoryOwners[id] = owner
+	return owner
+}
+
+// memoryOwnerBytes returns the memory allocated by the Python code of an owner
+// and not freed yet, it returns false if the memory isn't accounted
+func memoryOwnerBytes(owner C.int) (int64, bool) {
+	if owner == 0 {
+		return 0, false
+	}
+	bytes := C.get_memory_owner_bytes(rtloader, owner)
+	if bytes < 0 {
+		return 0, false
+	}
+	return int64(bytes), true
+}
@@ -77,10 +77,38 @@ char *run_check_return = NULL;
 rtloader_pyobject_t *run_check_instance = NULL;
 char *run_check(rtloader_t *s, rtloader_pyobject_t *check) {
 	run_check_instance = check;
+	run_check_memory_owner = memory_owner;
 	run_check_calls++;
 	return run_check_return;
 }
 
+unsigned long get_current_thread_id(rtloader_t *s) {
+	return 1;
+}
+
+int interrupt_thread_calls = 0;
+unsigned long interrupt_thread_id = 0;
+int interrupt_thread(rtloader_t *s, unsigned long thread_id) {
+	interrupt_thread_calls++;
+	interrupt_thread_id = thread_id;
+	return 1;
+}
+
+int set_memory_owner_calls = 0;
+int memory_owner = 0;
+int run_check_memory_owner = 0;
+int set_memory_owner(rtloader_t *s, int owner) {
+	set_memory_owner_calls++;
+	int previous = memory_owner;
+	memory_owner = owner;
+	return previous;
+}
+
+long long get_memory_owner_bytes_return = -1;
+long long get_memory_owner_bytes(rtloader_t *s, int owner) {
+	return get_memory_owner_bytes_return;
+}
+
 //
 // get_check MOCK
 //
@@ -149,6 +177,12 @@ void reset_check_mock() {
 	get_error_return = "";
 	rtloader_free_calls = 0;
 	run_check_calls = 0;
+	interrupt_thread_calls = 0;
+	interrupt_thread_id = 0;
+	set_memory_owner_calls = 0;
+	memory_owner = 0;
+	run_check_memory_owner = 0;
+	get_memory_owner_bytes_return = -1;
 	get_check_return = 0;
 
 	get_check_return = 0;
@@ -195,6 +229,56 @@ func testRunCheck(t *testing.T) {
 	assert.Equal(t, check.lastWarnings, []error{fmt.Errorf("warn1"), fmt.Errorf("warn2")})
 }
 
+func testInterrupt(t *testing.T) {
+	check := NewPythonCheck("fake_check", nil)
+	check.instance = &C.rtloader_pyobject_t{}
+
+	C.reset_check_mock()
+	err := check.Interrupt()
+	assert.EqualError(t, err, "the check  is not running")
+	assert.Equal(t, C.int(0), C.interrupt_thread_calls)
+
+	// the thread is set while the check runs
+	check.runThreadID = 42
+	err = check.Interrupt()
+	assert.Nil(t, err)
+	assert.Equal(t, C.int(1), C.interrupt_thread_calls)
+	assert.Equal(t, C.ulong(42), C.interrupt_thread_id)
+	assert.Equal(t, C.int(2), C.gil_locked_calls)
+	assert.Equal(t, C.int(2), C.gil_unlocked_calls)
+}
+
+func testRunMemoryOwner(t *testing.T) {
+	sender := mocksender.NewMockSender(check.ID("testID"))
+	sender.SetupAcceptAll()
+
+	c := NewPythonCheck("fake_check", nil)
+	c.instance = &C.rtloader_pyobject_t{}
+	c.id = check.ID("testID")
+	c.memoryOwner = 3
+
+	C.reset_check_mock()
+	C.run_check_return = C.CString("")
+
+	err := c.Run()
+	assert.Nil(t, err)
+
+	// the owner is set during the run, and restored
+	assert.Equal(t, C.int(2), C.set_memory_owner_calls)
+	assert.Equal(t, C.int(3), C.run_check_memory_owner)
+	assert.Equal(t, C.int(0), C.memory_owner)
+
+	// the memory is only reported when it's accounted
+	stats, err := c.GetMetricStats()
+	assert.Nil(t, err)
+	assert.NotContains(t, stats, "MemoryBytes")
+
+	C.get_memory_owner_bytes_return = 2048
+	stats, err = c.GetMetricStats()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2048), stats["MemoryBytes"])
+}
+
 func testRunErrorNil(t *testing.T) {
 	check := NewPythonCheck("fake_check", nil)
 	check.instance = &C.rtloader_pyobject_t{}
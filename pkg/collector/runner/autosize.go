@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// Interval between two adjustments of the number of workers
+	autosizeInterval = 30 * time.Second
+	// Average delay of the checks beyond which they run late
+	lateDelay = time.Second
+	// Number of late intervals in a row, without being able to add workers, after which the runner is saturated
+	saturationIntervals = 3
+	// Number of intervals in a row without delay after which a worker is removed
+	idleIntervals = 10
+)
+
+var (
+	// cpuPercent returns the CPU usage of the host since its previous call
+	cpuPercent = func() (float64, error) {
+		percents, err := cpu.Percent(0, false)
+		if err != nil || len(percents) == 0 {
+			return 0, err
+		}
+		return percents[0], nil
+	}
+
+	tlmWorkers = telemetry.NewGauge("runner", "workers",
+		nil, "Number of check runners")
+	tlmSaturated = telemetry.NewGauge("runner", "saturated",
+		nil, "Whether the checks consistently run late, 1 or 0")
+)
+
+// AutosizeStatus is the state of the sizing of the worker pool, published in
+// the runner stats
+type AutosizeStatus struct {
+	Enabled        bool
+	Workers        int
+	AverageDelayMs float64
+	MaxDelayMs     float64
+	CPUPercent     float64
+	Saturated      bool
+	Warning        string
+}
+
+// autosizer adds workers while the checks run late and the host has CPU
+// headroom, and removes them once they're idle. With a static number of
+// workers, it only reports the saturation of the runner.
+type autosizer struct {
+	enabled      bool
+	cpuThreshold float64
+	late         int // late intervals in a row without workers added
+	idle         int // intervals in a row without delay
+	status       AutosizeStatus
+	m            sync.RWMutex
+}
+
+func newAutosizer(enabled bool) *autosizer {
+	return &autosizer{
+		enabled:      enabled,
+		cpuThreshold: config.Datadog.GetFloat64("check_runners_max_cpu_percent"),
+		status:       AutosizeStatus{Enabled: enabled},
+	}
+}
+
+// step returns the number of workers to add, or to remove when negative, given
+// the delay of the checks over the last interval. Workers running the
+// long-running checks don't count in the bounds of the pool.
+func (a *autosizer) step(latency scheduler.Latency, cpuUsage float64, workers, longRunning int) int {
+	minWorkers := config.DefaultNumWorkers + longRunning
+	maxWorkers := config.MaxNumWorkers + longRunning
+	late := latency.Count > 0 && latency.Average > lateDelay
+	headroom := cpuUsage < a.cpuThreshold
+
+	delta := 0
+	switch {
+	case late && a.enabled && workers < maxWorkers && headroom:
+		// grow by a quarter of the pool to catch up quickly
+		delta = workers / 4
+		if delta < 1 {
+			delta = 1
+		}
+		if workers+delta > maxWorkers {
+			delta = maxWorkers - workers
+		}
+		a.late = 0
+	case late:
+		a.late++
+	default:
+		a.late = 0
+	}
+
+	if latency.Max < lateDelay {
+		a.idle++
+	} else {
+		a.idle = 0
+	}
+	if a.enabled && a.idle >= idleIntervals && workers > minWorkers {
+		delta = -1
+		a.idle = 0
+	}
+
+	status := AutosizeStatus{
+		Enabled:        a.enabled,
+		Workers:        workers + delta,
+		AverageDelayMs: float64(latency.Average) / float64(time.Millisecond),
+		MaxDelayMs:     float64(latency.Max) / float64(time.Millisecond),
+		CPUPercent:     cpuUsage,
+		Saturated:      a.late >= saturationIntervals,
+	}
+	if status.Saturated {
+		var reason string
+		switch {
+		case !a.enabled:
+			reason = fmt.Sprintf("all the %d check runners are busy, raise `check_runners` or set it to 0 to size the runners automatically", workers)
+		case !headroom:
+			reason = fmt.Sprintf("the CPU usage of the host, %.0f%%, leaves no headroom for more check runners", cpuUsage)
+		default:
+			reason = fmt.Sprintf("the check runners are at their maximum of %d", workers)
+		}
+		status.Warning = fmt.Sprintf("The checks run late, %s on average after their schedule: %s", latency.Average.Round(time.Millisecond), reason)
+	}
+
+	a.m.Lock()
+	a.status = status
+	a.m.Unlock()
+
+	tlmWorkers.Set(float64(status.Workers))
+	if status.Saturated {
+		tlmSaturated.Set(1)
+	} else {
+		tlmSaturated.Set(0)
+	}
+	return delta
+}
+
+func (a *autosizer) getStatus() interface{} {
+	a.m.RLock()
+	defer a.m.RUnlock()
+	return a.status
+}
+
+// autosize adjusts the number of workers at each interval, until the runner stops
+func (r *Runner) autosize() {
+	ticker := time.NewTicker(autosizeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopAutosize:
+			return
+		case <-ticker.C:
+		}
+
+		r.m.Lock()
+		s := r.scheduler
+		longRunning := 0
+		for _, c := range r.runningChecks {
+			if c.Interval() == 0 {
+				longRunning++
+			}
+		}
+		r.m.Unlock()
+		if s == nil {
+			continue
+		}
+
+		cpuUsage, err := cpuPercent()
+		if err != nil {
+			log.Debugf("Could not get the CPU usage of the host: %s", err)
+		}
+		workers, _ := strconv.Atoi(runnerStats.Get("Workers").String())
+
+		delta := r.autosizer.step(s.PopLatency(), cpuUsage, workers, longRunning)
+		for i := 0; i < delta; i++ {
+			r.AddWorker()
+		}
+		if delta > 0 {
+			log.Infof("The checks run late, added %d workers to runner: now at %d workers.", delta, workers+delta)
+		}
+		if delta < 0 {
+			r.removeWorker()
+		}
+	}
+}
+
+// removeWorker makes an idle worker exit, it does nothing if they're all busy
+func (r *Runner) removeWorker() {
+	select {
+	case r.shrink <- struct{}{}:
+		log.Debugf("Removed an idle worker from the runner")
+	default:
+	}
+}
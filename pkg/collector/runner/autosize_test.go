@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
+)
+
+var (
+	onTime = scheduler.Latency{Count: 10, Average: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+	late   = scheduler.Latency{Count: 10, Average: 3 * time.Second, Max: 8 * time.Second}
+)
+
+func TestAutosizeGrows(t *testing.T) {
+	a := &autosizer{enabled: true, cpuThreshold: 80}
+
+	assert.Equal(t, 0, a.step(onTime, 20, 4, 0))
+	// at least one worker is added
+	assert.Equal(t, 1, a.step(late, 20, 4, 0))
+	// a quarter of the pool is added
+	assert.Equal(t, 3, a.step(late, 20, 12, 0))
+	// up to the maximum, the workers of the long-running checks aside
+	assert.Equal(t, 1, a.step(late, 20, 26, 2))
+	assert.False(t, a.getStatus().(AutosizeStatus).Saturated)
+}
+
+func TestAutosizeShrinks(t *testing.T) {
+	a := &autosizer{enabled: true, cpuThreshold: 80}
+
+	for i := 0; i < idleIntervals-1; i++ {
+		assert.Equal(t, 0, a.step(onTime, 20, 6, 0))
+	}
+	assert.Equal(t, -1, a.step(onTime, 20, 6, 0))
+	// a late run resets the idle intervals
+	for i := 0; i < idleIntervals-1; i++ {
+		assert.Equal(t, 0, a.step(onTime, 20, 5, 0))
+	}
+	assert.Equal(t, 1, a.step(late, 20, 5, 0))
+	assert.Equal(t, 0, a.step(onTime, 20, 6, 0))
+
+	// the pool doesn't shrink below the default number of workers
+	a = &autosizer{enabled: true, cpuThreshold: 80}
+	for i := 0; i < 2*idleIntervals; i++ {
+		assert.Equal(t, 0, a.step(scheduler.Latency{}, 20, 4, 0))
+	}
+}
+
+func TestAutosizeSaturation(t *testing.T) {
+	// no CPU headroom
+	a := &autosizer{enabled: true, cpuThreshold: 80}
+	for i := 0; i < saturationIntervals; i++ {
+		assert.Equal(t, 0, a.step(late, 95, 8, 0))
+	}
+	status := a.getStatus().(AutosizeStatus)
+	assert.True(t, status.Saturated)
+	assert.Equal(t, 3000.0, status.AverageDelayMs)
+	assert.Equal(t, "The checks run late, 3s on average after their schedule: the CPU usage of the host, 95%, leaves no headroom for more check runners", status.Warning)
+
+	// back on time
+	a.step(onTime, 95, 8, 0)
+	status = a.getStatus().(AutosizeStatus)
+	assert.False(t, status.Saturated)
+	assert.Empty(t, status.Warning)
+
+	// static number of workers
+	a = &autosizer{cpuThreshold: 80}
+	for i := 0; i < saturationIntervals; i++ {
+		assert.Equal(t, 0, a.step(late, 20, 4, 0))
+	}
+	status = a.getStatus().(AutosizeStatus)
+	assert.True(t, status.Saturated)
+	assert.Equal(t, "The checks run late, 3s on average after their schedule: all the 4 check runners are busy, raise `check_runners` or set it to 0 to size the runners automatically", status.Warning)
+}
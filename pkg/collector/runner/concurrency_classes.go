@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package runner
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// concurrencyClasses limits the number of runs of the checks of each class
+// running at the same time, so that the heavy checks can't take all the workers
+type concurrencyClasses struct {
+	slots map[string]chan struct{}
+}
+
+// newConcurrencyClasses returns the classes set in `check_concurrency_classes`
+func newConcurrencyClasses() *concurrencyClasses {
+	classes := &concurrencyClasses{slots: make(map[string]chan struct{})}
+	for name, value := range config.Datadog.GetStringMap("check_concurrency_classes") {
+		limit, ok := toInt(value)
+		if !ok || limit <= 0 {
+			log.Warnf("Invalid concurrency limit for the class '%s': %v, it should be a positive integer; the class won't be limited", name, value)
+			continue
+		}
+		classes.slots[name] = make(chan struct{}, limit)
+	}
+	return classes
+}
+
+// acquire takes a slot of the class without waiting, it returns false when all
+// the slots are taken. Checks without a class, or of an unknown class, aren't limited.
+func (cc *concurrencyClasses) acquire(class string) bool {
+	slots, found := cc.slots[class]
+	if !found {
+		if class != "" {
+			log.Debugf("Unknown concurrency class '%s', the check runs aren't limited", class)
+		}
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release frees the slot taken by a run
+func (cc *concurrencyClasses) release(class string) {
+	if slots, found := cc.slots[class]; found {
+		<-slots
+	}
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	default:
+		return 0, false
+	}
+}
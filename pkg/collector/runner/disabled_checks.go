@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package runner
+
+import (
+	"sync"
+)
+
+var (
+	disabledChecksLock sync.RWMutex
+	// names of the checks whose runs are skipped, e.g. disabled by the remote configuration
+	disabledChecks = make(map[string]bool)
+)
+
+// SetDisabledChecks skips the runs of the checks of these names, until they're enabled
+// again. The long-running checks already running are not stopped.
+func SetDisabledChecks(names []string) {
+	disabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		disabled[name] = true
+	}
+	disabledChecksLock.Lock()
+	defer disabledChecksLock.Unlock()
+	disabledChecks = disabled
+}
+
+func isCheckDisabled(name string) bool {
+	disabledChecksLock.RLock()
+	defer disabledChecksLock.RUnlock()
+	return disabledChecks[name]
+}
@@ -6,11 +6,10 @@
 package runner
 
 import (
+	"context"
 	"expvar"
 	"fmt"
 	"strings"
-
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -64,6 +63,13 @@ type Runner struct {
 	pending          chan check.Check         // The channel where checks come from
 	runningChecks    map[check.ID]check.Check // The list of checks running
 	scheduler        *scheduler.Scheduler     // Scheduler runner operates on
+	classes          *concurrencyClasses      // Limits of the concurrency classes of the checks
+	queued           map[string][]check.Check // The checks waiting for a slot of their class, in order
+	queuedChecks     map[check.ID]struct{}    // The IDs of the queued checks
+	dequeued         chan check.Check         // The queued checks that can run, taken by the workers
+	autosizer        *autosizer               // Sizes the worker pool to the delay of the checks
+	shrink           chan struct{}            // Makes an idle worker exit
+	stopAutosize     chan struct{}            // Stops the sizing of the worker pool
 	m                sync.Mutex               // To control races on runningChecks
 
 }
@@ -76,9 +82,17 @@ func NewRunner() *Runner {
 		// initialize the channel
 		pending:          make(chan check.Check),
 		runningChecks:    make(map[check.ID]check.Check),
+		classes:          newConcurrencyClasses(),
+		queued:           make(map[string][]check.Check),
+		queuedChecks:     make(map[check.ID]struct{}),
+		dequeued:         make(chan check.Check),
+		shrink:           make(chan struct{}),
+		stopAutosize:     make(chan struct{}),
 		running:          1,
 		staticNumWorkers: numWorkers != 0,
 	}
+	r.autosizer = newAutosizer(!r.staticNumWorkers)
+	runnerStats.Set("Autosize", expvar.Func(r.autosizer.getStatus))
 
 	if !r.staticNumWorkers {
 		numWorkers = config.DefaultNumWorkers
@@ -89,6 +103,9 @@ func NewRunner() *Runner {
 		r.AddWorker()
 	}
 
+	// the delay of the checks is also monitored with a static number of workers
+	go r.autosize()
+
 	log.Infof("Runner started with %d workers.", numWorkers)
 	return r
 }
@@ -100,44 +117,6 @@ func (r *Runner) AddWorker() {
 	go r.work()
 }
 
-// UpdateNumWorkers checks if the current number of workers is reasonable, and adds more if needed
-func (r *Runner) UpdateNumWorkers(numChecks int64) {
-	numWorkers, _ := strconv.Atoi(runnerStats.Get("Workers").String())
-
-	if r.staticNumWorkers {
-		return
-	}
-
-	// Find which range the number of checks we're running falls in
-	var desiredNumWorkers int
-	switch {
-	case numChecks <= 10:
-		desiredNumWorkers = 4
-	case numChecks <= 15:
-		desiredNumWorkers = 10
-	case numChecks <= 20:
-		desiredNumWorkers = 15
-	case numChecks <= 25:
-		desiredNumWorkers = 20
-	default:
-		desiredNumWorkers = config.MaxNumWorkers
-	}
-
-	// Add workers if we don't have enough for this range
-	added := 0
-	for {
-		if numWorkers >= desiredNumWorkers {
-			break
-		}
-		r.AddWorker()
-		numWorkers++
-		added++
-	}
-	if added > 0 {
-		log.Infof("Added %d workers to runner: now at "+runnerStats.Get("Workers").String()+" workers.", added)
-	}
-}
-
 // Stop closes the pending channel so all workers will exit their loop and terminate
 // All publishers to the pending channel need to have stopped before Stop is called
 func (r *Runner) Stop() {
@@ -149,6 +128,7 @@ func (r *Runner) Stop() {
 	log.Info("Runner is shutting down...")
 
 	close(r.pending)
+	close(r.stopAutosize)
 	atomic.StoreUint32(&r.running, 0)
 
 	// stop checks that are still running
@@ -242,18 +222,21 @@ func (r *Runner) work() {
 	defer TestWg.Done()
 	defer runnerStats.Add("Workers", -1)
 
-	for check := range r.pending {
-		// see if the check is already running
-		r.m.Lock()
-		if _, isRunning := r.runningChecks[check.ID()]; isRunning {
-			log.Debugf("Check %s is already running, skip execution...", check)
-			r.m.Unlock()
+	for {
+		check, ok := r.receive()
+		if !ok {
+			break
+		}
+
+		if isCheckDisabled(check.String()) {
+			log.Debugf("Check %s is disabled, skip execution...", check)
+			continue
+		}
+
+		options, started := r.startRun(check)
+		if !started {
 			continue
-		} else {
-			r.runningChecks[check.ID()] = check
-			runnerStats.Add("RunningChecks", 1)
 		}
-		r.m.Unlock()
 
 		doLog, lastLog := shouldLog(check.ID())
 
@@ -264,13 +247,24 @@ func (r *Runner) work() {
 		}
 
 		// run the check
-		var err error
 		t0 := time.Now()
-
-		err = check.Run()
 		longRunning := check.Interval() == 0
 
-		warnings := check.GetWarnings()
+		// the long-running checks don't time out, their run lasts as long as the agent
+		timeout := options.Timeout
+		if longRunning {
+			timeout = 0
+		}
+		done, err := runCheck(check, timeout)
+
+		// the warnings of a run that timed out are still being written by the check
+		var warnings []error
+		stuck := isStuck(done)
+		if stuck {
+			runnerStats.Add("Timeouts", 1)
+		} else {
+			warnings = check.GetWarnings()
+		}
 
 		// use the default sender for the service checks
 		sender, e := aggregator.GetDefaultSender()
@@ -299,13 +293,15 @@ func (r *Runner) work() {
 			sender.Commit()
 		}
 
-		// remove the check from the running list
-		r.m.Lock()
-		delete(r.runningChecks, check.ID())
-		r.m.Unlock()
+		// remove the check from the running list, a run that timed out is removed
+		// once it returns so that the check doesn't run twice at the same time
+		if stuck {
+			go r.endRunOnReturn(check, options.ConcurrencyClass, done)
+		} else {
+			r.endRun(check, options.ConcurrencyClass)
+		}
 
 		// publish statistics about this run
-		runnerStats.Add("RunningChecks", -1)
 		runnerStats.Add("Runs", 1)
 
 		r.m.Lock()
@@ -338,6 +334,135 @@ func (r *Runner) work() {
 	log.Debug("Finished processing checks.")
 }
 
+// receive waits for the next check to run, it returns false when the worker
+// has to exit: the runner stopped or the pool shrinks
+func (r *Runner) receive() (check.Check, bool) {
+	select {
+	case c, ok := <-r.pending:
+		return c, ok
+	case c := <-r.dequeued:
+		return c, true
+	case <-r.shrink:
+		return nil, false
+	}
+}
+
+// startRun adds the check to the running checks, unless it's already running. When
+// the runs of its concurrency class are at their limit, the check is queued until a
+// run of the class ends.
+func (r *Runner) startRun(c check.Check) (check.RunOptions, bool) {
+	options := check.GetRunOptions(c)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// see if the check is already running
+	if _, isRunning := r.runningChecks[c.ID()]; isRunning {
+		log.Debugf("Check %s is already running, skip execution...", c)
+		return options, false
+	}
+	if !r.classes.acquire(options.ConcurrencyClass) {
+		if _, isQueued := r.queuedChecks[c.ID()]; isQueued {
+			log.Debugf("Check %s is already waiting for its concurrency class %s, skip execution...", c, options.ConcurrencyClass)
+			return options, false
+		}
+		log.Debugf("The concurrency class %s of check %s is at its limit, queue execution...", options.ConcurrencyClass, c)
+		r.queued[options.ConcurrencyClass] = append(r.queued[options.ConcurrencyClass], c)
+		r.queuedChecks[c.ID()] = struct{}{}
+		runnerStats.Add("QueuedRuns", 1)
+		return options, false
+	}
+	r.runningChecks[c.ID()] = c
+	runnerStats.Add("RunningChecks", 1)
+	return options, true
+}
+
+// endRun removes the check from the running checks, and hands the next check
+// queued for its concurrency class to the workers
+func (r *Runner) endRun(c check.Check, class string) {
+	r.m.Lock()
+	delete(r.runningChecks, c.ID())
+	var next check.Check
+	if queue := r.queued[class]; len(queue) > 0 {
+		next = queue[0]
+		r.queued[class] = queue[1:]
+		delete(r.queuedChecks, next.ID())
+	}
+	r.m.Unlock()
+
+	r.classes.release(class)
+	runnerStats.Add("RunningChecks", -1)
+
+	if next != nil {
+		go func() {
+			select {
+			case r.dequeued <- next:
+			case <-r.stopAutosize:
+				// the runner stopped
+			}
+		}()
+	}
+}
+
+// endRunOnReturn removes a check whose run timed out from the running checks
+// once the run returns
+func (r *Runner) endRunOnReturn(c check.Check, class string, done <-chan struct{}) {
+	<-done
+	log.Infof("The run of check %s that timed out has returned", c)
+	r.endRun(c, class)
+}
+
+// runCheck runs the check, and stops waiting for it after the timeout of its
+// instance: the context of the run is cancelled and the check is interrupted if
+// it supports it. The returned channel is closed once the run has returned.
+func runCheck(c check.Check, timeout time.Duration) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	if timeout <= 0 {
+		err := c.Run()
+		close(done)
+		return done, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	if cc, ok := c.(check.ContextCheck); ok {
+		cc.SetRunContext(ctx)
+	}
+
+	var err error
+	go func() {
+		defer cancel()
+		defer close(done)
+		err = c.Run()
+	}()
+
+	select {
+	case <-done:
+		return done, err
+	case <-ctx.Done():
+	}
+	// the run may have returned right at the timeout
+	if !isStuck(done) {
+		return done, err
+	}
+
+	log.Warnf("Check %s is still running after its timeout of %s, interrupting it", c, timeout)
+	if i, ok := c.(check.Interruptible); ok {
+		if e := i.Interrupt(); e != nil {
+			log.Warnf("Could not interrupt check %s: %s", c, e)
+		}
+	}
+	return done, fmt.Errorf("the run timed out after %s", timeout)
+}
+
+func isStuck(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
 func shouldLog(id check.ID) (doLog bool, lastLog bool) {
 	checkStats.M.RLock()
 	defer checkStats.M.RUnlock()
@@ -6,6 +6,7 @@
 package runner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
@@ -42,6 +43,7 @@ func (c *TestCheck) String() string
 func (c *TestCheck) Version() string                                            { return "" }
 func (c *TestCheck) ConfigSource() string                                       { return "" }
 func (c *TestCheck) Stop()                                                      {}
+func (c *TestCheck) Cancel()                                                    {}
 func (c *TestCheck) Configure(integration.Data, integration.Data, string) error { return nil }
 func (c *TestCheck) Interval() time.Duration                                    { return 1 }
 func (c *TestCheck) IsTelemetryEnabled() bool                                   { return false }
@@ -117,6 +119,28 @@ func TestWork(t *testing.T) {
 	assert.False(t, c3.HasRun())
 }
 
+func TestWorkDisabledCheck(t *testing.T) {
+	SetDisabledChecks([]string{"TestCheck"})
+	defer SetDisabledChecks(nil)
+
+	r := NewRunner()
+	defer r.Stop()
+	c1 := newTestCheck(false, "1")
+	r.pending <- c1
+	// wait to be sure the worker tried to run the check
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, c1.HasRun())
+
+	SetDisabledChecks(nil)
+	r.pending <- c1
+	select {
+	case <-c1.done:
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "Check hasn't run 1 second after being enabled again")
+	}
+	assert.True(t, c1.HasRun())
+}
+
 func TestLogging(t *testing.T) {
 	defaultFrequency := config.Datadog.GetInt64("logging_frequency")
 	config.Datadog.SetDefault("logging_frequency", int64(20))
@@ -180,3 +204,165 @@ func TestStopCheck(t *testing.T) {
 	err = r.StopCheck(c2.ID())
 	assert.Equal(t, "timeout during stop operation on check id TestCheck:2", err.Error())
 }
+
+type OptionsCheck struct {
+	*TestCheck
+	options     check.RunOptions
+	release     chan struct{}
+	ctx         context.Context
+	interrupted bool
+}
+
+func newOptionsCheck(id string, options check.RunOptions) *OptionsCheck {
+	return &OptionsCheck{
+		TestCheck: newTestCheck(false, id),
+		options:   options,
+		release:   make(chan struct{}),
+	}
+}
+
+func (c *OptionsCheck) RunOptions() check.RunOptions      { return c.options }
+func (c *OptionsCheck) SetRunContext(ctx context.Context) { c.ctx = ctx }
+func (c *OptionsCheck) Run() error {
+	<-c.release
+	return nil
+}
+func (c *OptionsCheck) Interrupt() error {
+	c.Lock()
+	defer c.Unlock()
+	c.interrupted = true
+	return nil
+}
+
+func TestRunCheckTimeout(t *testing.T) {
+	c := newOptionsCheck("1", check.RunOptions{})
+	close(c.release)
+	done, err := runCheck(c, 0)
+	assert.NoError(t, err)
+	assert.False(t, isStuck(done))
+	assert.Nil(t, c.ctx)
+
+	c = newOptionsCheck("2", check.RunOptions{})
+	done, err = runCheck(c, 50*time.Millisecond)
+	assert.EqualError(t, err, "the run timed out after 50ms")
+	assert.True(t, isStuck(done))
+	assert.Equal(t, context.DeadlineExceeded, c.ctx.Err())
+	c.Lock()
+	assert.True(t, c.interrupted)
+	c.Unlock()
+
+	close(c.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "The run didn't return after being released")
+	}
+}
+
+func TestWorkTimeout(t *testing.T) {
+	r := NewRunner()
+	defer r.Stop()
+
+	c := newOptionsCheck("1", check.RunOptions{Timeout: 50 * time.Millisecond})
+	r.pending <- c
+	// the check is still running after its timeout, it isn't run again
+	time.Sleep(200 * time.Millisecond)
+	r.m.Lock()
+	assert.Contains(t, r.runningChecks, c.ID())
+	r.m.Unlock()
+
+	// the check is removed from the running checks once its run returns
+	close(c.release)
+	for i := 0; i < 100; i++ {
+		r.m.Lock()
+		_, running := r.runningChecks[c.ID()]
+		r.m.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "The check is still running after its run returned")
+}
+
+func TestConcurrencyClasses(t *testing.T) {
+	config.Datadog.Set("check_concurrency_classes", map[string]interface{}{"heavy": 1, "invalid": "two"})
+	defer config.Datadog.Set("check_concurrency_classes", map[string]interface{}{})
+
+	r := NewRunner()
+	defer r.Stop()
+	assert.Len(t, r.classes.slots, 1)
+
+	heavy1 := newOptionsCheck("1", check.RunOptions{ConcurrencyClass: "heavy"})
+	heavy2 := newOptionsCheck("2", check.RunOptions{ConcurrencyClass: "heavy"})
+	unknown := newOptionsCheck("3", check.RunOptions{ConcurrencyClass: "invalid"})
+
+	_, started := r.startRun(heavy1)
+	assert.True(t, started)
+	// the class is at its limit, the run is queued once
+	_, started = r.startRun(heavy2)
+	assert.False(t, started)
+	_, started = r.startRun(heavy2)
+	assert.False(t, started)
+	r.m.Lock()
+	assert.Len(t, r.queued["heavy"], 1)
+	r.m.Unlock()
+	_, started = r.startRun(unknown)
+	assert.True(t, started)
+
+	// the queued check is handed to the workers once the run of the class ends
+	defer close(heavy2.release)
+	r.endRun(heavy1, "heavy")
+	requireRunning(t, r, heavy2.ID())
+	r.m.Lock()
+	assert.Len(t, r.queued["heavy"], 0)
+	assert.Len(t, r.queuedChecks, 0)
+	r.m.Unlock()
+}
+
+func TestWorkConcurrencyClassFull(t *testing.T) {
+	config.Datadog.Set("check_concurrency_classes", map[string]interface{}{"heavy": 1})
+	defer config.Datadog.Set("check_concurrency_classes", map[string]interface{}{})
+
+	r := NewRunner()
+	defer r.Stop()
+
+	heavy1 := newOptionsCheck("1", check.RunOptions{ConcurrencyClass: "heavy"})
+	heavy2 := newOptionsCheck("2", check.RunOptions{ConcurrencyClass: "heavy"})
+	defer close(heavy2.release)
+
+	r.pending <- heavy1
+	requireRunning(t, r, heavy1.ID())
+	// the class is full, the run of heavy2 waits for a slot instead of being dropped
+	r.pending <- heavy2
+	for i := 0; i < 100; i++ {
+		r.m.Lock()
+		_, queued := r.queuedChecks[heavy2.ID()]
+		r.m.Unlock()
+		if queued {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.m.Lock()
+	assert.NotContains(t, r.runningChecks, heavy2.ID())
+	assert.Contains(t, r.queuedChecks, heavy2.ID())
+	r.m.Unlock()
+
+	close(heavy1.release)
+	requireRunning(t, r, heavy2.ID())
+}
+
+// requireRunning waits for the check to be in the running checks
+func requireRunning(t *testing.T, r *Runner, id check.ID) {
+	for i := 0; i < 100; i++ {
+		r.m.Lock()
+		_, running := r.runningChecks[id]
+		r.m.Unlock()
+		if running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "The check isn't running", "check %s", id)
+}
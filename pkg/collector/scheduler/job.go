@@ -185,10 +185,14 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 			if !s.IsCheckScheduled(check.ID()) {
 				continue
 			}
+			if s.enqueueWithJitter(check) {
+				continue
+			}
 
 			select {
 			// blocking, we'll be here as long as it takes
 			case s.checksPipe <- check:
+				s.latency.record(time.Since(t))
 			case <-jq.stop:
 				jq.health.Deregister()
 				return false
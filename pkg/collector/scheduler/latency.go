@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+var tlmScheduleDelay = telemetry.NewHistogram("scheduler", "schedule_delay_seconds",
+	nil, "Delay between the scheduled time of the checks and their handover to a runner",
+	[]float64{0.1, 0.5, 1, 5, 15, 60})
+
+// Latency sums up how late the checks were handed to the runner, compared to
+// their scheduled time, over a window of time
+type Latency struct {
+	Count   int64
+	Average time.Duration
+	Max     time.Duration
+}
+
+// latencyStats accumulates the delays of the current window
+type latencyStats struct {
+	mu    sync.Mutex
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+func (l *latencyStats) record(delay time.Duration) {
+	tlmScheduleDelay.Observe(delay.Seconds())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+	l.total += delay
+	if delay > l.max {
+		l.max = delay
+	}
+}
+
+// pop returns the latency of the window and starts a new one
+func (l *latencyStats) pop() Latency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	latency := Latency{Count: l.count, Max: l.max}
+	if l.count > 0 {
+		latency.Average = l.total / time.Duration(l.count)
+	}
+	l.count, l.total, l.max = 0, 0, 0
+	return latency
+}
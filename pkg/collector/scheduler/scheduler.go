@@ -8,6 +8,7 @@ package scheduler
 import (
 	"expvar"
 	"fmt"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -47,9 +48,10 @@ type Scheduler struct {
 	jobQueues    map[time.Duration]*jobQueue // We have one scheduling queue for every interval
 	checkToQueue map[check.ID]*jobQueue      // Keep track of what is the queue for any Check
 	mu           sync.Mutex                  // To protect critical sections in struct's fields
+	latency      latencyStats                // How late the checks are handed to the runner
 
-	cancelOneTime chan bool      // Used to internally communicate a cancel signal to one-time schedule goroutines
-	wgOneTime     sync.WaitGroup // WaitGroup to track the exit of one-time schedule goroutines
+	cancelOneTime chan bool      // Used to internally communicate a cancel signal to one-time and jittered schedule goroutines
+	wgOneTime     sync.WaitGroup // WaitGroup to track the exit of one-time and jittered schedule goroutines
 }
 
 // NewScheduler create a Scheduler and returns a pointer to it.
@@ -190,6 +192,13 @@ func (s *Scheduler) Stop() error {
 	}
 }
 
+// PopLatency returns how late the checks were handed to the runner since the
+// previous call, compared to their scheduled time. The delay grows when all
+// the workers of the runner are busy.
+func (s *Scheduler) PopLatency() Latency {
+	return s.latency.pop()
+}
+
 // IsCheckScheduled returns whether a check is in the schedule or not
 func (s *Scheduler) IsCheckScheduled(id check.ID) bool {
 	s.mu.Lock()
@@ -255,6 +264,44 @@ func (s *Scheduler) enqueueOnce(check check.Check) {
 	schedulerChecksEntered.Add(1)
 }
 
+// enqueueWithJitter enqueues the check after a random delay, up to the
+// `min_collection_interval_jitter` of its instance, and returns whether it did.
+// Do not block, the delayed queuing is cancelled like the one-time schedules.
+func (s *Scheduler) enqueueWithJitter(c check.Check) bool {
+	jitter := check.GetRunOptions(c).Jitter
+	if jitter <= 0 {
+		return false
+	}
+	// keep the delay below the interval, so that the runs aren't skipped
+	if jitter > c.Interval() {
+		jitter = c.Interval()
+	}
+	delay := time.Duration(rand.Int63n(int64(jitter)))
+	s.wgOneTime.Add(1)
+
+	go func(cancelOneTime <-chan bool) {
+		defer s.wgOneTime.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-cancelOneTime:
+			return
+		}
+		// the check may have been unscheduled in the meantime
+		if !s.IsCheckScheduled(c.ID()) {
+			return
+		}
+		select {
+		case s.checksPipe <- c:
+		case <-cancelOneTime:
+		}
+	}(s.cancelOneTime)
+
+	return true
+}
+
 // expQueues return a function to get the stats for the queues
 func expQueues(s *Scheduler) func() interface{} {
 	return func() interface{} {
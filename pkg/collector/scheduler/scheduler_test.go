@@ -24,6 +24,7 @@ func (c *TestCheck) Configure(integration.Data, integration.Data, string) error
 func (c *TestCheck) Interval() time.Duration                                    { return c.intl }
 func (c *TestCheck) Run() error                                                 { return nil }
 func (c *TestCheck) Stop()                                                      {}
+func (c *TestCheck) Cancel()                                                    {}
 func (c *TestCheck) ID() check.ID                                               { return check.ID(c.String()) }
 func (c *TestCheck) GetWarnings() []error                                       { return []error{} }
 func (c *TestCheck) GetMetricStats() (map[string]int64, error)                  { return make(map[string]int64), nil }
@@ -204,3 +205,47 @@ func TestStopOneTimeSchedule(t *testing.T) {
 	// sleep to make the runtime schedule the hanging goroutines, if there are any
 	time.Sleep(time.Millisecond)
 }
+
+type JitteredCheck struct {
+	TestCheck
+	jitter time.Duration
+}
+
+func (c *JitteredCheck) RunOptions() check.RunOptions { return check.RunOptions{Jitter: c.jitter} }
+
+func TestEnqueueWithJitter(t *testing.T) {
+	ch := make(chan check.Check)
+	s := NewScheduler(ch)
+
+	assert.False(t, s.enqueueWithJitter(&TestCheck{intl: time.Second}))
+
+	// the delay is capped by the interval
+	c := &JitteredCheck{TestCheck: TestCheck{intl: 50 * time.Millisecond}, jitter: time.Hour}
+	s.checkToQueue[c.ID()] = newJobQueue(c.intl)
+	assert.True(t, s.enqueueWithJitter(c))
+	select {
+	case enqueued := <-ch:
+		assert.Equal(t, c, enqueued)
+	case <-time.After(time.Second):
+		assert.Fail(t, "The jittered check wasn't enqueued after its interval")
+	}
+
+	// the delayed queuing is cancelled when the scheduler stops
+	c.intl = time.Hour
+	assert.True(t, s.enqueueWithJitter(c))
+	close(s.cancelOneTime)
+	s.wgOneTime.Wait()
+}
+
+func TestPopLatency(t *testing.T) {
+	s := NewScheduler(make(chan check.Check))
+	assert.Equal(t, Latency{}, s.PopLatency())
+
+	s.latency.record(100 * time.Millisecond)
+	s.latency.record(300 * time.Millisecond)
+	s.latency.record(2 * time.Second)
+	assert.Equal(t, Latency{Count: 3, Average: 800 * time.Millisecond, Max: 2 * time.Second}, s.PopLatency())
+
+	// a new window starts
+	assert.Equal(t, Latency{}, s.PopLatency())
+}
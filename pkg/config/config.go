@@ -35,6 +35,12 @@ const (
 	// MaxNumWorkers maximum number of workers for our check runner
 	MaxNumWorkers = 25
 
+	// The range of serializer_compression_level, the levels of zlib (1 to 9)
+	// and of zstd (1 to 20)
+	minSerializerCompressionLevel     = 1
+	maxZlibSerializerCompressionLevel = 9
+	maxSerializerCompressionLevel     = 20
+
 	// DefaultForwarderRecoveryInterval is the default recovery interval,
 	// also used if the user-provided value is invalid.
 	DefaultForwarderRecoveryInterval = 2
@@ -44,6 +50,21 @@ const (
 	// DefaultBatchWait is the default HTTP batch wait in second for logs
 	DefaultBatchWait = 5
 
+	// DefaultBatchMaxSize is the default maximum number of logs in an HTTP batch
+	DefaultBatchMaxSize = 200
+
+	// DefaultBatchMaxContentSize is the default maximum size in bytes of the logs of an HTTP batch
+	DefaultBatchMaxContentSize = 1000000
+
+	// DefaultBatchMaxConcurrentSend is the default number of HTTP batches of logs sent concurrently
+	DefaultBatchMaxConcurrentSend = 1
+
+	// DefaultDiskBufferMaxSize is the default maximum size in bytes of the logs buffered on disk
+	DefaultDiskBufferMaxSize = 100 * 1024 * 1024
+
+	// DefaultDiskBufferMaxAge is the default maximum age in seconds of the logs buffered on disk
+	DefaultDiskBufferMaxAge = 24 * 60 * 60
+
 	// ClusterIDCacheKey is the key name for the orchestrator cluster id in the agent in-mem cache
 	ClusterIDCacheKey = "orchestratorClusterID"
 )
@@ -87,11 +108,15 @@ type ConfigurationProviders struct {
 	KeyFile          string `mapstructure:"key_file"`
 	Token            string `mapstructure:"token"`
 	GraceTimeSeconds int    `mapstructure:"grace_time_seconds"`
+	Watch            bool   `mapstructure:"watch"`
+	APIVersion       int    `mapstructure:"api_version"`
 }
 
 // Listeners helps unmarshalling `listeners` config param
 type Listeners struct {
-	Name string `mapstructure:"name"`
+	Name                   string `mapstructure:"name"`
+	StabilityWindowSeconds int    `mapstructure:"stability_window_seconds"`
+	UnscheduleDelaySeconds int    `mapstructure:"unschedule_delay_seconds"`
 }
 
 // Proxy represents the configuration for proxies in the agent
@@ -99,8 +124,19 @@ type Proxy struct {
 	HTTP    string   `mapstructure:"http"`
 	HTTPS   string   `mapstructure:"https"`
 	NoProxy []string `mapstructure:"no_proxy"`
+	// Endpoints overrides the proxy used for some hosts, whatever their scheme,
+	// an empty proxy disabling it
+	Endpoints map[string]string `mapstructure:"endpoints"`
+	// Auth is the authentication scheme of the proxies: basic (default) or ntlm
+	Auth string `mapstructure:"auth"`
 }
 
+// The authentication schemes supported for the proxies
+const (
+	ProxyAuthBasic = "basic"
+	ProxyAuthNTLM  = "ntlm"
+)
+
 // MappingProfile represent a group of mappings
 type MappingProfile struct {
 	Name     string          `mapstructure:"name"`
@@ -159,14 +195,20 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cmd_host", "localhost")
 	config.BindEnvAndSetDefault("cmd_port", 5001)
 	config.BindEnvAndSetDefault("cluster_agent.cmd_port", 5005)
+	config.BindEnvAndSetDefault("remote_tagger.enabled", false)
+	config.BindEnvAndSetDefault("remote_tagger.port", 5004)
 	config.BindEnvAndSetDefault("default_integration_http_timeout", 9)
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
-	config.BindEnvAndSetDefault("check_runners", int64(4))
+	config.BindEnvAndSetDefault("check_runners", int64(0)) // 0 sizes the pool to the delay of the checks
+	config.BindEnvAndSetDefault("check_runners_max_cpu_percent", 80.0)
+	config.BindEnvAndSetDefault("check_max_metric_contexts", 0)                        // 0 doesn't limit the contexts, overridden by `max_metric_contexts` in the instances
+	config.BindEnvAndSetDefault("check_concurrency_classes", map[string]interface{}{}) // class name -> maximum number of concurrent runs
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	config.BindEnvAndSetDefault("bind_host", "localhost")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
 	config.BindEnvAndSetDefault("health_port", int64(0))
+	config.BindEnvAndSetDefault("health_failure_threshold", 1) // health pings a component can miss in a row before /health fails
 	config.BindEnvAndSetDefault("disable_py3_validation", false)
 	config.BindEnvAndSetDefault("python_version", DefaultPython)
 	// Debugging + C-land crash feature flags
@@ -178,6 +220,11 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("tracemalloc_blacklist", "")
 	config.BindEnvAndSetDefault("run_path", defaultRunPath)
 
+	// Memory of the Python checks: accounted per instance in the agent with Python 3,
+	// limited for the instances running in a process of their own (`isolation: process`)
+	config.BindEnvAndSetDefault("python_memory_accounting", false)
+	config.BindEnvAndSetDefault("python_isolation_max_memory_mb", 0)
+
 	// Python 3 linter timeout, in seconds
 	// NOTE: linter is notoriously slow, in the absence of a better solution we
 	//       can only increase this timeout value. Linting operation is async.
@@ -202,6 +249,16 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("secret_backend_arguments", []string{})
 	config.BindEnvAndSetDefault("secret_backend_output_max_size", secrets.SecretBackendOutputMaxSize)
 	config.BindEnvAndSetDefault("secret_backend_timeout", 5)
+	config.BindEnvAndSetDefault("secret_refresh_interval", 0) // in seconds, 0 disables it
+	// built-in secret providers, used instead of the secret_backend_command: vault, aws_secrets_manager or gcp_secret_manager
+	config.BindEnvAndSetDefault("secret_backend_type", "")
+	config.BindEnvAndSetDefault("secret_backend_cache_ttl", 300)
+	config.BindEnvAndSetDefault("secret_backend_vault.address", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.auth_mount", "kubernetes")
+	config.BindEnvAndSetDefault("secret_backend_vault.role", "")
+	config.BindEnvAndSetDefault("secret_backend_vault.token_path", "/var/run/secrets/kubernetes.io/serviceaccount/token")
+	config.BindEnvAndSetDefault("secret_backend_aws.region", "")
+	config.BindEnvAndSetDefault("secret_backend_gcp.project", "")
 
 	// Use to output logs in JSON format
 	config.BindEnvAndSetDefault("log_format_json", false)
@@ -218,8 +275,12 @@ func initConfig(config Config) {
 	// Yaml keys which values are stripped from flare
 	config.BindEnvAndSetDefault("flare_stripped_keys", []string{})
 
+	// Keys whose values are scrubbed from the flares, the status pages and the orchestrator payloads
+	config.BindEnvAndSetDefault("scrubber.sensitive_words", []string{})
+
 	// Agent GUI access port
 	config.BindEnvAndSetDefault("GUI_port", defaultGuiPort)
+	config.BindEnvAndSetDefault("GUI_audit_log_file", "") // empty means gui-audit.log next to the agent log file
 
 	if IsContainerized() {
 		// In serverless-containerized environments (e.g Fargate)
@@ -256,16 +317,33 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("proc_root", "/proc")
 	config.BindEnvAndSetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
+	config.SetKnown("histogram_namespaces")
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
+
+	// Events sent on the status changes of the service checks
+	config.BindEnvAndSetDefault("service_check_events.service_checks", []string{})
+	config.BindEnvAndSetDefault("service_check_events.flapping_transitions", 5)
+	config.BindEnvAndSetDefault("service_check_events.flapping_window", 600) // in seconds
+	// Number of pipelines aggregating the dogstatsd samples in parallel, the contexts are sharded
+	// between them. `dogstatsd_pipeline_autoadjust` sets it to half the number of cores available.
+	config.BindEnvAndSetDefault("dogstatsd_pipeline_count", 1)
+	config.BindEnvAndSetDefault("dogstatsd_pipeline_autoadjust", false)
 	// Serializer
 	config.BindEnvAndSetDefault("enable_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_service_checks_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_events_stream_payload_serialization", true)
+	// Compression of the payloads: an empty kind or a 0 level selects the defaults of the build
+	config.BindEnvAndSetDefault("serializer_compressor_kind", "")
+	config.BindEnvAndSetDefault("serializer_compression_level", 0)
+	config.BindEnvAndSetDefault("serializer_adaptive_compression.enabled", false)
+	config.BindEnvAndSetDefault("serializer_adaptive_compression.max_cpu_usage", 0.5)
 
 	// Warning: do not change the two following values. Your payloads will get dropped by Datadog's intake.
 	config.BindEnvAndSetDefault("serializer_max_payload_size", 2*megaByte+megaByte/2)
 	config.BindEnvAndSetDefault("serializer_max_uncompressed_payload_size", 4*megaByte)
+	// Maximum number of items (series, sketches, events...) per payload, 0 meaning no limit
+	config.BindEnvAndSetDefault("serializer_max_items_per_payload", 0)
 	config.BindEnvAndSetDefault("use_v2_api.series", false)
 	config.BindEnvAndSetDefault("use_v2_api.events", false)
 	config.BindEnvAndSetDefault("use_v2_api.service_checks", false)
@@ -278,16 +356,45 @@ func initConfig(config Config) {
 
 	// Forwarder
 	config.BindEnvAndSetDefault("additional_endpoints", map[string][]string{})
+	config.BindEnvAndSetDefault("additional_endpoints_payload_types", map[string][]string{})
+	config.BindEnvAndSetDefault("additional_endpoints_rotation_api_keys", map[string][]string{})
+	config.BindEnvAndSetDefault("rotation_api_keys", []string{})
+	config.BindEnvAndSetDefault("forwarder_apikey_validation_interval", 600)
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
 	config.BindEnvAndSetDefault("forwarder_retry_queue_max_size", 30)
 	config.BindEnvAndSetDefault("forwarder_num_workers", 1)
 	config.BindEnvAndSetDefault("forwarder_stop_timeout", 2)
+	config.BindEnvAndSetDefault("forwarder_storage_path", "") // defaults to <run_path>/transactions_to_retry
+	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0)
 	// Forwarder retry settings
 	config.BindEnvAndSetDefault("forwarder_backoff_factor", 2)
 	config.BindEnvAndSetDefault("forwarder_backoff_base", 2)
 	config.BindEnvAndSetDefault("forwarder_backoff_max", 64)
 	config.BindEnvAndSetDefault("forwarder_recovery_interval", DefaultForwarderRecoveryInterval)
 	config.BindEnvAndSetDefault("forwarder_recovery_reset", false)
+	// Forwarder failover settings: the domains are health checked when they have fallback URLs
+	config.BindEnvAndSetDefault("forwarder_failover_urls", map[string][]string{})
+	config.BindEnvAndSetDefault("forwarder_failover_check_interval", 30)
+	config.BindEnvAndSetDefault("forwarder_failover_failure_threshold", 3)
+	config.BindEnvAndSetDefault("forwarder_failover_recovery_threshold", 5)
+	config.BindEnvAndSetDefault("forwarder_max_egress_rate", 0) // in bytes per second, 0 disables the limit
+	config.BindEnvAndSetDefault("forwarder_egress_weights", map[string]interface{}{})
+	// Forwarder export settings: the payloads are written to files instead of being sent when the export path is set
+	config.BindEnvAndSetDefault("forwarder_export_path", "")
+	config.BindEnvAndSetDefault("forwarder_export_max_file_size", 10*1024*1024)
+	config.BindEnvAndSetDefault("forwarder_export_rotation_interval", 300)
+
+	// FIPS mode
+	config.BindEnvAndSetDefault("fips.enabled", false)
+	config.BindEnvAndSetDefault("fips.local_address", "") // the TLS connections are delegated to a local FIPS proxy when set
+	config.BindEnvAndSetDefault("fips.port_range_start", 9803)
+
+	// Remote configuration
+	config.BindEnvAndSetDefault("remote_configuration.enabled", false)
+	config.BindEnvAndSetDefault("remote_configuration.dd_url", "")
+	config.BindEnvAndSetDefault("remote_configuration.refresh_interval", 60) // in seconds
+	config.BindEnvAndSetDefault("remote_configuration.timeout", 10)          // in seconds
+	config.BindEnv("remote_configuration.public_keys")                       // key ID to base64 encoded ed25519 public key
 
 	// Dogstatsd
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
@@ -302,8 +409,14 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_size", 32)
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_flush_timeout", 100*time.Millisecond)
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
+	// On Linux, the UDP listener reads up to `dogstatsd_udp_batch_size` datagrams per syscall
+	// (recvmmsg). Set to 0 or 1 to read the datagrams one by one.
+	config.BindEnvAndSetDefault("dogstatsd_udp_batch_size", 32)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
+	// IPs or CIDRs allowed to send datagrams to the UDP listener, empty means every source is allowed
+	config.BindEnvAndSetDefault("dogstatsd_udp_allowed_sources", []string{})
+	config.BindEnvAndSetDefault("dogstatsd_udp_log_rejected_sources", false)
 	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
 	config.BindEnvAndSetDefault("dogstatsd_stats_enable", false)
@@ -337,6 +450,13 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("container_include_logs", []string{})
 	config.BindEnvAndSetDefault("container_exclude_logs", []string{})
 	config.BindEnvAndSetDefault("ad_config_poll_interval", int64(10)) // in seconds
+	config.BindEnvAndSetDefault("autoconf_config_files_watch", true)
+	config.SetKnown("prometheus_scrape.rules")
+	config.BindEnvAndSetDefault("snmp_listener.workers", 100)
+	config.BindEnvAndSetDefault("snmp_listener.discovery_interval", 3600) // in seconds
+	config.BindEnvAndSetDefault("snmp_listener.discovery_allowed_failures", 3)
+	config.SetKnown("snmp_listener.configs")
+	config.SetKnown("snmp_listener.profiles")
 	config.BindEnvAndSetDefault("extra_listeners", []string{})
 	config.BindEnvAndSetDefault("extra_config_providers", []string{})
 
@@ -346,6 +466,7 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("docker_env_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("kubernetes_pod_labels_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("kubernetes_pod_annotations_as_tags", map[string]string{})
+	config.BindEnvAndSetDefault("kubernetes_namespace_annotations_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("kubernetes_node_labels_as_tags", map[string]string{})
 	config.BindEnvAndSetDefault("container_cgroup_prefix", "")
 
@@ -357,6 +478,7 @@ func initConfig(config Config) {
 	// Containerd
 	// We only support containerd in Kubernetes. By default containerd cri uses `k8s.io` https://github.com/containerd/cri/blob/release/1.2/pkg/constants/constants.go#L22-L23
 	config.BindEnvAndSetDefault("containerd_namespace", "k8s.io")
+	config.BindEnvAndSetDefault("containerd_namespaces", []string{})
 
 	// Kubernetes
 	config.BindEnvAndSetDefault("kubernetes_kubelet_host", "")
@@ -395,6 +517,11 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_agent.auth_token", "")
 	config.BindEnvAndSetDefault("cluster_agent.url", "")
 	config.BindEnvAndSetDefault("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
+	// The certificate the cluster agents serve their API with, valid for the kubernetes_service_name,
+	// the followers verify the one of the leader against the tls_ca_file to forward the requests to it
+	config.BindEnvAndSetDefault("cluster_agent.tls_cert_file", "")
+	config.BindEnvAndSetDefault("cluster_agent.tls_key_file", "")
+	config.BindEnvAndSetDefault("cluster_agent.tls_ca_file", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt")
 	config.BindEnvAndSetDefault("cluster_agent.tagging_fallback", false)
 	config.BindEnvAndSetDefault("metrics_port", "5000")
 
@@ -413,10 +540,17 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("ecs_collect_resource_tags_ec2", false)
 	config.BindEnvAndSetDefault("collect_ec2_tags", false)
 
+	// Nomad
+	config.BindEnvAndSetDefault("nomad_agent_url", "http://127.0.0.1:4646")
+	config.BindEnvAndSetDefault("nomad_token", "")
+
 	// GCE
 	config.BindEnvAndSetDefault("collect_gce_tags", true)
 	config.BindEnvAndSetDefault("exclude_gce_tags", []string{"kube-env", "kubelet-config", "containerd-configure-sh", "startup-script", "shutdown-script", "configure-sh", "sshKeys", "ssh-keys", "user-data", "cli-cert", "ipsec-cert", "ssl-cert", "google-container-manifest", "bosh_settings", "windows-startup-script-ps1", "common-psm1", "k8s-node-setup-psm1", "serial-port-logging-enable", "enable-oslogin", "disable-address-manager", "disable-legacy-endpoints", "windows-keys"})
 
+	// Cloud instance metadata
+	config.BindEnvAndSetDefault("cloud_instance_metadata_tags", []string{})
+
 	// Cloud Foundry
 	config.BindEnvAndSetDefault("cloud_foundry", false)
 	config.BindEnvAndSetDefault("bosh_id", "")
@@ -429,6 +563,13 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cloud_foundry_bbs.cert_file", "")
 	config.BindEnvAndSetDefault("cloud_foundry_bbs.key_file", "")
 
+	// Cloud Foundry CC
+	config.BindEnvAndSetDefault("cloud_foundry_cc.url", "https://cloud-controller-ng.service.cf.internal:9024")
+	config.BindEnvAndSetDefault("cloud_foundry_cc.client_id", "")
+	config.BindEnvAndSetDefault("cloud_foundry_cc.client_secret", "")
+	config.BindEnvAndSetDefault("cloud_foundry_cc.poll_interval", 60)
+	config.BindEnvAndSetDefault("cloud_foundry_cc.skip_ssl_validation", false)
+
 	// JMXFetch
 	config.BindEnvAndSetDefault("jmx_custom_jars", []string{})
 	config.BindEnvAndSetDefault("jmx_use_cgroup_memory_limit", false)
@@ -467,6 +608,7 @@ func initConfig(config Config) {
 
 	config.BindEnv("process_config.process_dd_url", "")
 	config.BindEnv("process_config.orchestrator_dd_url", "")
+	config.BindEnvAndSetDefault("process_config.service_discovery.enabled", false)
 
 	// Logs Agent
 
@@ -491,8 +633,18 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.open_files_limit", 100)
 	// add global processing rules that are applied on all logs
 	config.BindEnv("logs_config.processing_rules")
+	// define the scrubbing rule sets, and the ones that are applied on all logs
+	config.BindEnv("logs_config.scrubbing_rules")
+	config.BindEnvAndSetDefault("logs_config.scrubbing_rule_sets", []string{})
+	config.BindEnv("logs_config.service_rate_limit")
+	config.BindEnv("logs_config.json_remapping")
 	// enforce the agent to use files to collect container logs on kubernetes environment
 	config.BindEnvAndSetDefault("logs_config.k8s_container_use_file", false)
+	config.BindEnvAndSetDefault("logs_config.k8s_container_use_kubelet_api", false)
+	// detect the multi-line pattern of the sources from their first lines
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_detection", false)
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_sample_size", 500)
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_match_threshold", 0.48)
 	// additional config to ensure initial logs are tagged with kubelet tags
 	// wait (seconds) for tagger before start fetching tags of new AD services
 	config.BindEnvAndSetDefault("logs_config.tagger_warmup_duration", 0) // Disabled by default (0 seconds)
@@ -503,8 +655,19 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.use_http", false)
 	config.BindEnvAndSetDefault("logs_config.use_tcp", false)
 	config.BindEnvAndSetDefault("logs_config.use_compression", true)
-	config.BindEnvAndSetDefault("logs_config.compression_level", 6) // Default level for the gzip/deflate algorithm
+	config.BindEnvAndSetDefault("logs_config.compression_level", 6)     // Default level for the gzip/deflate algorithm
+	config.BindEnvAndSetDefault("logs_config.content_encoding", "gzip") // gzip, zstd or auto
 	config.BindEnvAndSetDefault("logs_config.batch_wait", DefaultBatchWait)
+	config.BindEnvAndSetDefault("logs_config.batch_max_size", DefaultBatchMaxSize)
+	config.BindEnvAndSetDefault("logs_config.batch_max_content_size", DefaultBatchMaxContentSize)
+	config.BindEnvAndSetDefault("logs_config.batch_max_concurrent_send", DefaultBatchMaxConcurrentSend)
+	config.BindEnvAndSetDefault("logs_config.batch_max_inflight", 0) // 0 means twice batch_max_concurrent_send
+	config.BindEnvAndSetDefault("logs_config.adaptive_batching", false)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_enabled", false)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_path", "") // defaults to <run_path>/disk_buffer
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_max_size", DefaultDiskBufferMaxSize)
+	config.BindEnvAndSetDefault("logs_config.disk_buffer_max_age", DefaultDiskBufferMaxAge)
+	config.BindEnvAndSetDefault("logs_config.inject_trace_context", false)
 	config.BindEnvAndSetDefault("logs_config.dd_port", 10516)
 	config.BindEnvAndSetDefault("logs_config.dev_mode_use_proto", true)
 	config.BindEnvAndSetDefault("logs_config.dd_url_443", "agent-443-intake.logs.datadoghq.com")
@@ -518,6 +681,10 @@ func initConfig(config Config) {
 	// Changing this setting may impact your custom metrics billing.
 	config.BindEnvAndSetDefault("checks_tag_cardinality", "low")
 	config.BindEnvAndSetDefault("dogstatsd_tag_cardinality", "low")
+	config.BindEnvAndSetDefault("max_tag_cardinality", "high")
+	config.BindEnvAndSetDefault("tagger.deletion_grace_period", 60) // in seconds
+	config.BindEnvAndSetDefault("tagger.deletion_grace_period_by_prefix", map[string]string{})
+	config.BindEnvAndSetDefault("tagger.prune_interval", 0) // in seconds, 0 to use the shortest deletion grace period
 
 	config.BindEnvAndSetDefault("histogram_copy_to_distribution", false)
 	config.BindEnvAndSetDefault("histogram_copy_to_distribution_prefix", "")
@@ -547,12 +714,31 @@ func initConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
+	// Node check templates served to the node agents
+	config.BindEnvAndSetDefault("node_configs.enabled", false)
+	config.BindEnvAndSetDefault("node_configs.confd_path", "/etc/datadog-agent/node_conf.d")
+	// Admission controller
+	config.BindEnvAndSetDefault("admission_controller.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.port", 8000)
+	config.BindEnvAndSetDefault("admission_controller.service_name", "datadog-admission-controller")
+	config.BindEnvAndSetDefault("admission_controller.webhook_name", "datadog-webhook")
+	config.BindEnvAndSetDefault("admission_controller.certificate.secret_name", "webhook-certificate")
+	config.BindEnvAndSetDefault("admission_controller.certificate.validity_bound", 365*24)      // value in hours
+	config.BindEnvAndSetDefault("admission_controller.certificate.expiration_threshold", 30*24) // value in hours
+	config.BindEnvAndSetDefault("admission_controller.failure_policy", "Ignore")
+	config.BindEnvAndSetDefault("admission_controller.validation.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.inject_lib.container_registry", "gcr.io/datadoghq")
+	config.BindEnvAndSetDefault("admission_controller.inject_config.mode", "hostip") // hostip, service or socket
+	config.BindEnvAndSetDefault("admission_controller.inject_config.local_service_name", "datadog")
+	config.BindEnvAndSetDefault("admission_controller.inject_config.dogstatsd_socket", "/var/run/datadog/dsd.socket")
+	config.BindEnvAndSetDefault("admission_controller.inject_config.trace_agent_socket", "/var/run/datadog/apm.socket")
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
 	config.BindEnvAndSetDefault("clc_runner_host", "") // must be set using the Kubernetes downward API
 	config.BindEnvAndSetDefault("clc_runner_port", 5005)
 	config.BindEnvAndSetDefault("clc_runner_server_write_timeout", 15)
 	config.BindEnvAndSetDefault("clc_runner_server_readheader_timeout", 10)
+	config.BindEnvAndSetDefault("clc_runner_bosh_spec_file", "") // set on BOSH deployments, e.g. /var/vcap/bosh/spec.json
 
 	// Telemetry
 	// Enable telemetry metrics on the internals of the Agent.
@@ -569,9 +755,23 @@ func initConfig(config Config) {
 	config.SetKnown("proxy.http")
 	config.SetKnown("proxy.https")
 	config.SetKnown("proxy.no_proxy")
+	config.SetKnown("proxy.endpoints")
+	config.SetKnown("proxy.auth")
 
 	// Ochestrator explorer
 	config.BindEnvAndSetDefault("orchestrator_explorer.enabled", false)
+	config.SetKnown("orchestrator_explorer.custom_resources")
+	config.BindEnvAndSetDefault("orchestrator_explorer.custom_resources_max_count", 5000)
+	config.BindEnvAndSetDefault("orchestrator_explorer.custom_resources_max_size", 256*1024)
+
+	// Resource recommendations
+	config.BindEnvAndSetDefault("resource_recommendations.enabled", false)
+	config.BindEnvAndSetDefault("resource_recommendations.refresh_period", 3600) // value in seconds
+	config.BindEnvAndSetDefault("resource_recommendations.window", 24*3600)      // value in seconds
+	config.BindEnvAndSetDefault("resource_recommendations.percentile", 95.0)
+	config.BindEnvAndSetDefault("resource_recommendations.margin", 0.15)
+	config.BindEnvAndSetDefault("resource_recommendations.threshold", 0.3)
+	config.BindEnvAndSetDefault("resource_recommendations.send_events", true)
 
 	// Process agent
 	config.SetKnown("process_config.dd_agent_env")
@@ -587,6 +787,7 @@ func initConfig(config Config) {
 	config.SetKnown("process_config.custom_sensitive_words")
 	config.SetKnown("process_config.scrub_args")
 	config.SetKnown("process_config.strip_proc_arguments")
+	config.SetKnown("process_config.scrub_rules")
 	config.SetKnown("process_config.windows.args_refresh_interval")
 	config.SetKnown("process_config.windows.add_new_args")
 	config.SetKnown("process_config.additional_endpoints.*")
@@ -618,6 +819,11 @@ func initConfig(config Config) {
 	config.SetKnown("system_probe_config.source_excludes")
 	config.SetKnown("system_probe_config.dest_excludes")
 	config.SetKnown("system_probe_config.closed_channel_size")
+	config.SetKnown("system_probe_config.enable_tracer_fallback")
+	config.SetKnown("system_probe_config.tracer_fallback_poll_interval")
+	config.SetKnown("system_probe_config.enable_tcp_queue_length")
+	config.SetKnown("system_probe_config.enable_oom_kill")
+	config.SetKnown("system_probe_config.enable_tcp_stats")
 
 	// Network
 	config.BindEnv("network.id")
@@ -664,8 +870,9 @@ func initConfig(config Config) {
 
 	// inventories
 	config.BindEnvAndSetDefault("inventories_enabled", true)
-	config.BindEnvAndSetDefault("inventories_max_interval", 600) // 10min
-	config.BindEnvAndSetDefault("inventories_min_interval", 300) // 5min
+	config.BindEnvAndSetDefault("inventories_max_interval", 600)               // 10min
+	config.BindEnvAndSetDefault("inventories_min_interval", 300)               // 5min
+	config.BindEnvAndSetDefault("inventories_providers_refresh_interval", 300) // 5min
 
 	// command line options
 	config.SetKnown("cmd.check.fullsketches")
@@ -748,12 +955,23 @@ func loadProxyFromEnv(config Config) {
 		p.NoProxy = strings.Split(noProxy, ",") // comma-separated list, consistent with other tools that use the NO_PROXY env var
 	}
 
+	if auth, found := lookupEnv("DD_PROXY_AUTH"); found {
+		isSet = true
+		p.Auth = auth
+	}
+
 	// We have to set each value individually so both config.Get("proxy")
 	// and config.Get("proxy.http") work
 	if isSet {
 		config.Set("proxy.http", p.HTTP)
 		config.Set("proxy.https", p.HTTPS)
 		config.Set("proxy.no_proxy", p.NoProxy)
+		if len(p.Endpoints) > 0 {
+			config.Set("proxy.endpoints", p.Endpoints)
+		}
+		if p.Auth != "" {
+			config.Set("proxy.auth", p.Auth)
+		}
 		proxies = p
 	}
 }
@@ -827,6 +1045,7 @@ func load(config Config, origin string, loadSecret bool) error {
 	// setTracemallocEnabled *must* be called before setNumWorkers
 	setTracemallocEnabled(config)
 	setNumWorkers(config)
+	setSerializerCompressionLevel(config)
 	return nil
 }
 
@@ -842,25 +1061,46 @@ func ResolveSecrets(config Config, origin string) error {
 		config.GetInt("secret_backend_timeout"),
 		config.GetInt("secret_backend_output_max_size"),
 	)
+	err := secrets.InitProvider(secrets.ProviderConfig{
+		Type:           config.GetString("secret_backend_type"),
+		CacheTTL:       config.GetDuration("secret_backend_cache_ttl") * time.Second,
+		Timeout:        config.GetDuration("secret_backend_timeout") * time.Second,
+		VaultAddress:   config.GetString("secret_backend_vault.address"),
+		VaultAuthMount: config.GetString("secret_backend_vault.auth_mount"),
+		VaultRole:      config.GetString("secret_backend_vault.role"),
+		VaultTokenPath: config.GetString("secret_backend_vault.token_path"),
+		AWSRegion:      config.GetString("secret_backend_aws.region"),
+		GCPProject:     config.GetString("secret_backend_gcp.project"),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to initialize the secret provider: %v", err)
+	}
 
-	if config.GetString("secret_backend_command") != "" {
-		// Viper doesn't expose the final location of the file it
-		// loads. Since we are searching for 'datadog.yaml' in multiple
-		// locations we let viper determine the one to use before
-		// updating it.
-		yamlConf, err := yaml.Marshal(config.AllSettings())
-		if err != nil {
-			return fmt.Errorf("unable to marshal configuration to YAML to decrypt secrets: %v", err)
-		}
+	return decryptSecrets(config, origin)
+}
 
-		finalYamlConf, err := secrets.Decrypt(yamlConf, origin)
-		if err != nil {
-			return fmt.Errorf("unable to decrypt secret from datadog.yaml: %v", err)
-		}
-		r := bytes.NewReader(finalYamlConf)
-		if err = config.MergeConfigOverride(r); err != nil {
-			return fmt.Errorf("could not update main configuration after decrypting secrets: %v", err)
-		}
+// decryptSecrets decrypts the secret values of config with the secrets package, which
+// must be initialized
+func decryptSecrets(config Config, origin string) error {
+	if config.GetString("secret_backend_command") == "" && config.GetString("secret_backend_type") == "" {
+		return nil
+	}
+	// Viper doesn't expose the final location of the file it
+	// loads. Since we are searching for 'datadog.yaml' in multiple
+	// locations we let viper determine the one to use before
+	// updating it.
+	yamlConf, err := yaml.Marshal(config.AllSettings())
+	if err != nil {
+		return fmt.Errorf("unable to marshal configuration to YAML to decrypt secrets: %v", err)
+	}
+
+	finalYamlConf, err := secrets.Decrypt(yamlConf, origin)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt secret from datadog.yaml: %v", err)
+	}
+	r := bytes.NewReader(finalYamlConf)
+	if err = config.MergeConfigOverride(r); err != nil {
+		return fmt.Errorf("could not update main configuration after decrypting secrets: %v", err)
 	}
 	return nil
 }
@@ -1101,6 +1341,30 @@ func setNumWorkers(config Config) {
 	config.Set("check_runners", numWorkers)
 }
 
+// setSerializerCompressionLevel clamps the compression level of the payloads to the
+// levels of the configured compressor, 0 selecting its default level.
+func setSerializerCompressionLevel(config Config) {
+	level := config.GetInt("serializer_compression_level")
+	if level == 0 {
+		return
+	}
+	max := maxSerializerCompressionLevel
+	if config.GetString("serializer_compressor_kind") == "zlib" {
+		max = maxZlibSerializerCompressionLevel
+	}
+
+	clamped := level
+	if clamped < minSerializerCompressionLevel {
+		clamped = minSerializerCompressionLevel
+	} else if clamped > max {
+		clamped = max
+	}
+	if clamped != level {
+		log.Warnf("Configured serializer_compression_level (%v) is out of range [%v, %v]: %v will be used", level, minSerializerCompressionLevel, max, clamped)
+		config.Set("serializer_compression_level", clamped)
+	}
+}
+
 // GetDogstatsdMappingProfiles returns mapping profiles used in DogStatsD mapper
 func GetDogstatsdMappingProfiles() ([]MappingProfile, error) {
 	return getDogstatsdMappingProfilesConfig(Datadog)
@@ -621,6 +621,37 @@ func TestLoadProxyEmptyValuePrecedence(t *testing.T) {
 	os.Unsetenv("DD_PROXY_NO_PROXY")
 }
 
+func TestLoadProxyEndpointsAndAuth(t *testing.T) {
+	config := setupConf()
+
+	config.Set("proxy.https", "https_conf")
+	config.Set("proxy.endpoints", map[string]string{"datadoghq.eu": "eu_conf"})
+	config.Set("proxy.auth", "ntlm")
+
+	// circleCI set some proxy setting
+	ciValue := os.Getenv("NO_PROXY")
+	os.Unsetenv("NO_PROXY")
+	defer os.Setenv("NO_PROXY", ciValue)
+
+	loadProxyFromEnv(config)
+	proxies := GetProxies()
+	assert.Equal(t,
+		&Proxy{
+			HTTPS:     "https_conf",
+			Endpoints: map[string]string{"datadoghq.eu": "eu_conf"},
+			Auth:      "ntlm"},
+		proxies)
+
+	os.Setenv("DD_PROXY_AUTH", "basic")
+	defer os.Unsetenv("DD_PROXY_AUTH")
+
+	loadProxyFromEnv(config)
+	proxies = GetProxies()
+	assert.Equal(t, "basic", proxies.Auth)
+	assert.Equal(t, "basic", config.GetString("proxy.auth"))
+	assert.Equal(t, map[string]string{"datadoghq.eu": "eu_conf"}, proxies.Endpoints)
+}
+
 func TestSanitizeAPIKey(t *testing.T) {
 	config := setupConf()
 
@@ -687,6 +718,26 @@ func TestNumWorkers(t *testing.T) {
 	assert.Equal(t, workers, 1)
 }
 
+func TestSerializerCompressionLevel(t *testing.T) {
+	config := setupConf()
+
+	config.Set("serializer_compression_level", 0)
+	setSerializerCompressionLevel(config)
+	assert.Equal(t, 0, config.GetInt("serializer_compression_level"))
+
+	config.Set("serializer_compression_level", -5)
+	setSerializerCompressionLevel(config)
+	assert.Equal(t, 1, config.GetInt("serializer_compression_level"))
+
+	config.Set("serializer_compression_level", 25)
+	setSerializerCompressionLevel(config)
+	assert.Equal(t, 20, config.GetInt("serializer_compression_level"))
+
+	config.Set("serializer_compressor_kind", "zlib")
+	setSerializerCompressionLevel(config)
+	assert.Equal(t, 9, config.GetInt("serializer_compression_level"))
+}
+
 // TestOverrides validates that the config overrides system works well.
 func TestApplyOverrides(t *testing.T) {
 	assert := assert.New(t)
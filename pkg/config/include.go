@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// includeKey is the key listing the files a configuration file includes
+	includeKey = "include"
+	// maxIncludeDepth limits the nesting of the includes
+	maxIncludeDepth = 10
+)
+
+// envVarPattern matches `${env:VAR}`, `${env:VAR:-default}` and `${env:VAR-default}`, and
+// `$${env:` which escapes a literal `${env:`. The explicit `env:` prefix leaves the values
+// that happen to contain `$` or `${` unchanged.
+var envVarPattern = regexp.MustCompile(`\$\$\{env:|\$\{env:([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}`)
+
+// readConfigFile reads a configuration file and the files it includes, and expands the
+// environment variables of their values. It returns the merged configuration as YAML.
+func readConfigFile(fs afero.Fs, path string) ([]byte, error) {
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	return preprocessConfig(fs, content, path)
+}
+
+// preprocessConfig resolves the includes of a configuration, relative to the directory of
+// its path, and expands the environment variables of its values:
+//  - the included files are merged in the order they're listed, the values of a file
+//    override the ones of the files it includes,
+//  - the maps are merged key by key, the other values (including the lists) are replaced,
+//  - a directory includes its .yaml files, in the lexical order of their names.
+func preprocessConfig(fs afero.Fs, content []byte, path string) ([]byte, error) {
+	values, err := loadIncludes(fs, content, path, []string{})
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return content, nil
+	}
+	return yaml.Marshal(values)
+}
+
+// loadIncludes parses a configuration and merges it on top of the files it includes.
+// included lists the files being included, to detect the cycles.
+func loadIncludes(fs afero.Fs, content []byte, path string, included []string) (map[interface{}]interface{}, error) {
+	name := path
+	if name == "" {
+		name = "the configuration"
+	}
+	var document interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", name, err)
+	}
+	if document == nil {
+		return nil, nil
+	}
+	values, ok := document.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a YAML mapping", name)
+	}
+	values = expandEnvVars(values).(map[interface{}]interface{})
+
+	includes, err := includePaths(fs, values[includeKey], filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %v", includeKey, name, err)
+	}
+	delete(values, includeKey)
+	if len(includes) == 0 {
+		return values, nil
+	}
+	if len(included) >= maxIncludeDepth {
+		return nil, fmt.Errorf("too many nested includes in %s", name)
+	}
+
+	merged := map[interface{}]interface{}{}
+	for _, include := range includes {
+		for _, parent := range append(included, path) {
+			if filepath.Clean(parent) == include {
+				return nil, fmt.Errorf("%s includes itself through %s", include, name)
+			}
+		}
+		includedContent, err := afero.ReadFile(fs, include)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s included by %s: %v", include, name, err)
+		}
+		includedValues, err := loadIncludes(fs, includedContent, include, append(included, path))
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Including the configuration file %s in %s", include, name)
+		mergeValues(merged, includedValues)
+	}
+	mergeValues(merged, values)
+	return merged, nil
+}
+
+// includePaths returns the files the include value lists: a path or a list of paths, of
+// files, directories or glob patterns, relative to dir.
+func includePaths(fs afero.Fs, include interface{}, dir string) ([]string, error) {
+	var patterns []string
+	switch value := include.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a path, got %v", item)
+			}
+			patterns = append(patterns, pattern)
+		}
+	default:
+		return nil, fmt.Errorf("expected a path or a list of paths, got %v", include)
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		if info, err := fs.Stat(pattern); err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "*.yaml")
+		} else if err == nil || !strings.ContainsAny(pattern, "*?[") {
+			// a file, or a missing file which is reported when it's read
+			paths = append(paths, filepath.Clean(pattern))
+			continue
+		}
+		matches, err := afero.Glob(fs, pattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			paths = append(paths, filepath.Clean(match))
+		}
+	}
+	return paths, nil
+}
+
+// mergeValues merges the values of src into dst, the maps are merged key by key
+func mergeValues(dst, src map[interface{}]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[interface{}]interface{})
+		dstMap, dstIsMap := dst[key].(map[interface{}]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// expandEnvVars expands the environment variables of the string values
+func expandEnvVars(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return expandEnvVarsInString(v)
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			v[key] = expandEnvVars(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = expandEnvVars(item)
+		}
+	}
+	return value
+}
+
+func expandEnvVarsInString(s string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${env:" {
+			return "${env:"
+		}
+		groups := envVarPattern.FindStringSubmatch(match)
+		name, operator, defaultValue := groups[1], groups[2], groups[3]
+		value, found := os.LookupEnv(name)
+		switch {
+		case operator == ":-" && value == "":
+			return defaultValue
+		case operator == "-" && !found:
+			return defaultValue
+		case !found:
+			log.Warnf("The environment variable %s referenced in the configuration is not set", name)
+		}
+		return value
+	})
+}
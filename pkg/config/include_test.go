@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvVars(t *testing.T) {
+	os.Setenv("DD_TEST_SITE", "datadoghq.eu")
+	os.Setenv("DD_TEST_EMPTY", "")
+	defer os.Unsetenv("DD_TEST_SITE")
+	defer os.Unsetenv("DD_TEST_EMPTY")
+
+	assert.Equal(t, "datadoghq.eu", expandEnvVarsInString("${env:DD_TEST_SITE}"))
+	assert.Equal(t, "app.datadoghq.eu", expandEnvVarsInString("app.${env:DD_TEST_SITE:-datadoghq.com}"))
+	assert.Equal(t, "datadoghq.com", expandEnvVarsInString("${env:DD_TEST_UNSET:-datadoghq.com}"))
+	assert.Equal(t, "datadoghq.com", expandEnvVarsInString("${env:DD_TEST_EMPTY:-datadoghq.com}"))
+	assert.Equal(t, "", expandEnvVarsInString("${env:DD_TEST_EMPTY-datadoghq.com}"))
+	assert.Equal(t, "", expandEnvVarsInString("${env:DD_TEST_UNSET}"))
+	assert.Equal(t, "${env:DD_TEST_SITE}", expandEnvVarsInString("$${env:DD_TEST_SITE}"))
+	assert.Equal(t, "ENC[api_key]", expandEnvVarsInString("ENC[api_key]"))
+
+	// the values without the env: prefix are kept as is
+	assert.Equal(t, "pa$$word${DD_TEST_SITE}", expandEnvVarsInString("pa$$word${DD_TEST_SITE}"))
+	assert.Equal(t, "$${literal}", expandEnvVarsInString("$${literal}"))
+}
+
+func TestConfigIncludes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/etc/datadog-agent/datadog.yaml": `
+include:
+  - base.yaml
+  - datadog.d
+api_key: host_key
+logs_config:
+  run_path: /var/run/host
+`,
+		"/etc/datadog-agent/base.yaml": `
+api_key: base_key
+site: datadoghq.com
+tags: [env:base]
+logs_config:
+  run_path: /var/run/base
+  open_files_limit: 100
+`,
+		"/etc/datadog-agent/datadog.d/10-site.yaml": `
+site: datadoghq.eu
+tags: [env:site]
+`,
+		"/etc/datadog-agent/datadog.d/20-dogstatsd.yaml": `
+dogstatsd_port: ${env:DD_TEST_UNSET_PORT:-8126}
+`,
+		"/etc/datadog-agent/datadog.d/README": "not a config file",
+	}
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0600))
+	}
+
+	config := NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	initConfig(config)
+	config.SetFs(fs)
+	config.SetConfigFile("/etc/datadog-agent/datadog.yaml")
+	require.NoError(t, config.ReadInConfig())
+
+	// the values of the including file override the included ones
+	assert.Equal(t, "host_key", config.GetString("api_key"))
+	assert.Equal(t, "/var/run/host", config.GetString("logs_config.run_path"))
+	// the maps are merged
+	assert.Equal(t, 100, config.GetInt("logs_config.open_files_limit"))
+	// the files included later override the ones included before them
+	assert.Equal(t, "datadoghq.eu", config.GetString("site"))
+	assert.Equal(t, []string{"env:site"}, config.GetStringSlice("tags"))
+	assert.Equal(t, 8126, config.GetInt("dogstatsd_port"))
+	assert.False(t, config.IsSet("include"))
+	assert.Equal(t, SourceFile, config.GetSource("site"))
+}
+
+func TestConfigIncludeErrors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/conf/a.yaml", []byte("include: b.yaml\n"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "/conf/b.yaml", []byte("include: [a.yaml]\n"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "/conf/missing.yaml", []byte("include: c.yaml\n"), 0600))
+	require.NoError(t, afero.WriteFile(fs, "/conf/invalid.yaml", []byte("include: {a: b}\n"), 0600))
+
+	_, err := readConfigFile(fs, "/conf/a.yaml")
+	assert.Contains(t, err.Error(), "includes itself")
+
+	_, err = readConfigFile(fs, "/conf/missing.yaml")
+	assert.Contains(t, err.Error(), "could not read /conf/c.yaml")
+
+	_, err = readConfigFile(fs, "/conf/invalid.yaml")
+	assert.Contains(t, err.Error(), "invalid include")
+}
@@ -0,0 +1,274 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// reloadableKeys are the keys of datadog.yaml applied when the configuration is reloaded,
+// the changes of the other keys require a restart of the agent
+var reloadableKeys = []string{
+	"log_level",
+	"tags",
+	"api_key",
+	"additional_endpoints",
+	"statsd_metric_namespace_blacklist",
+	"process_config.scrub_rules",
+}
+
+// ReloadHandler applies the new value of a reloadable key to a running component. An
+// error means the change requires a restart of the agent.
+type ReloadHandler func() error
+
+// ReloadReport describes the changes of datadog.yaml applied by a reload
+type ReloadReport struct {
+	Time  time.Time `json:"time"`
+	File  string    `json:"file"`
+	Error string    `json:"error,omitempty"`
+	// Applied lists the keys whose new value is used by the agent
+	Applied []string `json:"applied"`
+	// RequireRestart maps the changed keys which weren't applied to the reason why
+	RequireRestart map[string]string `json:"require_restart"`
+}
+
+var (
+	reloadLock     sync.Mutex
+	reloadHandlers = map[string]ReloadHandler{
+		"log_level": func() error { return changeLogLevel(Datadog.GetString("log_level")) },
+	}
+	// values of the config file last read, the values of the keys which require a
+	// restart are the ones the agent uses
+	reloadedFileValues map[string]interface{}
+	lastReloadReport   *ReloadReport
+)
+
+// ReloadableKeys returns the keys of datadog.yaml applied when the configuration is reloaded
+func ReloadableKeys() []string {
+	return append([]string{}, reloadableKeys...)
+}
+
+// RegisterReloadHandler registers the handler applying the new value of a reloadable key,
+// it replaces the handler previously registered for the key
+func RegisterReloadHandler(key string, handler ReloadHandler) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	reloadHandlers[strings.ToLower(key)] = handler
+}
+
+// LastReloadReport returns the report of the last reload, nil if the configuration
+// wasn't reloaded
+func LastReloadReport() *ReloadReport {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	return lastReloadReport
+}
+
+// Reload reads datadog.yaml again, and applies the changes of the reloadable keys. The
+// changes of the other keys are reported as requiring a restart.
+func Reload() ReloadReport {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	report := reload()
+	if report.Error != "" {
+		log.Errorf("Could not reload the configuration: %s", report.Error)
+	} else {
+		log.Infof("Reloaded the configuration from %s, applied: %v", report.File, report.Applied)
+		for _, key := range sortedKeys(report.RequireRestart) {
+			log.Warnf("The change of %s requires a restart of the agent: %s", key, report.RequireRestart[key])
+		}
+	}
+	lastReloadReport = &report
+	return report
+}
+
+func reload() ReloadReport {
+	report := ReloadReport{
+		Time:           time.Now(),
+		File:           Datadog.ConfigFileUsed(),
+		Applied:        []string{},
+		RequireRestart: map[string]string{},
+	}
+
+	current, ok := Datadog.(*safeConfig)
+	if !ok {
+		report.Error = "the configuration can't be reloaded"
+		return report
+	}
+	if reloadedFileValues == nil {
+		reloadedFileValues = current.fileLayer()
+	}
+
+	reloaded := NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	initConfig(reloaded)
+	reloaded.SetConfigFile(report.File)
+	if err := load(reloaded, "datadog.yaml", false); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	// the secrets package was initialized when the agent started
+	if err := decryptSecrets(reloaded, "datadog.yaml"); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	fileValues := reloaded.(*safeConfig).fileLayer()
+
+	changed := make(map[string]bool)
+	for _, key := range changedKeys(reloadedFileValues, fileValues) {
+		if root := reloadableRoot(key); root != "" {
+			changed[root] = true
+			continue
+		}
+		report.RequireRestart[key] = "only read when the agent starts"
+		// the agent keeps using the previous value
+		if value, found := reloadedFileValues[key]; found {
+			fileValues[key] = value
+		} else {
+			delete(fileValues, key)
+		}
+	}
+
+	for _, key := range reloadableKeys {
+		if !changed[key] {
+			continue
+		}
+		previous, wasSet := fileLayerValue(Datadog, key)
+		if wasSet && Datadog.GetSource(key) == SourceFile {
+			// the value used by the agent, with its secrets decrypted
+			previous = Datadog.Get(key)
+		}
+		setFileValue(key, reloaded.Get(key), hasFileValue(fileValues, key))
+		handler := reloadHandlers[key]
+		if handler == nil {
+			report.Applied = append(report.Applied, key)
+			continue
+		}
+		if err := handler(); err != nil {
+			report.RequireRestart[key] = err.Error()
+			setFileValue(key, previous, wasSet)
+			copyFileValues(reloadedFileValues, fileValues, key)
+			continue
+		}
+		report.Applied = append(report.Applied, key)
+	}
+
+	reloadedFileValues = fileValues
+	return report
+}
+
+// changedKeys returns the keys whose value differs between two file layers, the maps are
+// compared key by key
+func changedKeys(previous, current map[string]interface{}) []string {
+	var keys []string
+	for key, value := range current {
+		if old, found := previous[key]; !found || !equalFileValues(old, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key, value := range previous {
+		if _, found := current[key]; !found && !isMapValue(value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalFileValues(a, b interface{}) bool {
+	if isMapValue(a) && isMapValue(b) {
+		// the nested keys are compared instead
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isMapValue(value interface{}) bool {
+	switch value.(type) {
+	case map[interface{}]interface{}, map[string]interface{}:
+		return true
+	}
+	return false
+}
+
+// reloadableRoot returns the reloadable key a key of the file is, or is nested in
+func reloadableRoot(key string) string {
+	for _, root := range reloadableKeys {
+		if key == root || strings.HasPrefix(key, root+".") {
+			return root
+		}
+	}
+	return ""
+}
+
+func hasFileValue(values map[string]interface{}, key string) bool {
+	_, found := values[key]
+	return found
+}
+
+// fileLayerValue returns the value of a key in the file layer of a config
+func fileLayerValue(config Config, key string) (interface{}, bool) {
+	for _, value := range config.GetSources(key) {
+		if value.Source == SourceFile {
+			return value.Value, true
+		}
+	}
+	return nil, false
+}
+
+func setFileValue(key string, value interface{}, set bool) {
+	if set {
+		Datadog.SetWithSource(key, value, SourceFile)
+	} else {
+		Datadog.UnsetForSource(key, SourceFile)
+	}
+}
+
+// copyFileValues replaces the values of a key, and of the keys nested in it, of dst by
+// the ones of src
+func copyFileValues(src, dst map[string]interface{}, key string) {
+	for k := range dst {
+		if k == key || strings.HasPrefix(k, key+".") {
+			delete(dst, k)
+		}
+	}
+	for k, value := range src {
+		if k == key || strings.HasPrefix(k, key+".") {
+			dst[k] = value
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns a summary of the report
+func (r ReloadReport) String() string {
+	if r.Error != "" {
+		return fmt.Sprintf("could not reload %s: %s", r.File, r.Error)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reloaded %s at %s\n", r.File, r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Applied: %s\n", strings.Join(r.Applied, ", "))
+	fmt.Fprintf(&b, "Requiring a restart:")
+	for _, key := range sortedKeys(r.RequireRestart) {
+		fmt.Fprintf(&b, "\n  %s: %s", key, r.RequireRestart[key])
+	}
+	return b.String()
+}
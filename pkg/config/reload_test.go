@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "datadog.yaml")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+api_key: abcdef
+site: datadoghq.com
+tags: [env:prod]
+additional_endpoints:
+  https://app.datadoghq.eu: [ghijkl]
+`), 0600))
+	mockConfig := Mock()
+	mockConfig.SetConfigFile(path)
+	require.NoError(t, mockConfig.ReadInConfig())
+
+	reloadedFileValues = nil
+	handlers := reloadHandlers
+	defer func() { reloadHandlers = handlers }()
+	apiKeys := ""
+	reloadHandlers = map[string]ReloadHandler{
+		"api_key": func() error {
+			apiKeys = mockConfig.GetString("api_key")
+			return nil
+		},
+		"additional_endpoints": func() error {
+			return errors.New("the endpoints changed")
+		},
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+api_key: mnopqr
+site: datadoghq.eu
+tags: [env:staging]
+additional_endpoints:
+  https://app.datadoghq.eu: [ghijkl]
+  https://app.datadoghq.com: [stuvwx]
+`), 0600))
+	report := Reload()
+	assert.Empty(t, report.Error)
+	assert.Equal(t, path, report.File)
+	assert.Equal(t, []string{"tags", "api_key"}, report.Applied)
+	assert.Equal(t, map[string]string{
+		"site":                 "only read when the agent starts",
+		"additional_endpoints": "the endpoints changed",
+	}, report.RequireRestart)
+	assert.Equal(t, &report, LastReloadReport())
+
+	assert.Equal(t, "mnopqr", apiKeys)
+	assert.Equal(t, []string{"env:staging"}, mockConfig.GetStringSlice("tags"))
+	assert.Equal(t, SourceFile, mockConfig.GetSource("tags"))
+	assert.Equal(t, "datadoghq.com", mockConfig.GetString("site"))
+	assert.Len(t, mockConfig.GetStringMapStringSlice("additional_endpoints"), 1)
+
+	// the runtime values keep precedence, the keys removed from the file are unset
+	mockConfig.SetWithSource("tags", []string{"env:runtime"}, SourceRuntime)
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+api_key: mnopqr
+site: datadoghq.eu
+additional_endpoints:
+  https://app.datadoghq.eu: [ghijkl]
+`), 0600))
+	report = Reload()
+	assert.Equal(t, []string{"tags"}, report.Applied)
+	assert.Equal(t, map[string]string{"site": "only read when the agent starts"}, report.RequireRestart)
+	assert.Equal(t, []string{"env:runtime"}, mockConfig.GetStringSlice("tags"))
+	mockConfig.UnsetForSource("tags", SourceRuntime)
+	assert.Empty(t, mockConfig.GetStringSlice("tags"))
+	assert.Equal(t, SourceDefault, mockConfig.GetSource("tags"))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("api_key: [invalid"), 0600))
+	report = Reload()
+	assert.NotEmpty(t, report.Error)
+	assert.Equal(t, "mnopqr", mockConfig.GetString("api_key"))
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	remoteSettingsLock sync.Mutex
+	// keys set by the last remote configuration applied
+	remoteSettings = make(map[string]bool)
+)
+
+// RemoteSamplingKeys are the sampling rates of the trace-agent the remote configuration
+// can tune, applied by the trace-agent when it polls the remote configuration
+var RemoteSamplingKeys = []string{
+	"apm_config.extra_sample_rate",
+	"apm_config.max_traces_per_second",
+}
+
+// RemoteConfigurableKeys returns the configuration keys the remote configuration can set:
+// the log level, the keys that can be overridden at runtime and the sampling rates
+func RemoteConfigurableKeys() []string {
+	keys := append([]string{"log_level"}, runtimeConfigurableKeys...)
+	return append(keys, RemoteSamplingKeys...)
+}
+
+// ApplyRemoteSettings sets the settings of the remote configuration in the remote-config
+// layer, and unsets the ones it no longer sets. The values set with `agent config set`
+// keep precedence. It returns the reason each rejected setting wasn't applied.
+func ApplyRemoteSettings(settings map[string]interface{}) map[string]string {
+	remoteSettingsLock.Lock()
+	defer remoteSettingsLock.Unlock()
+
+	allowed := make(map[string]bool)
+	for _, key := range RemoteConfigurableKeys() {
+		allowed[key] = true
+	}
+
+	rejected := make(map[string]string)
+	applied := make(map[string]bool)
+	for key, value := range settings {
+		key = strings.ToLower(key)
+		if !allowed[key] {
+			rejected[key] = "this setting can't be changed remotely"
+			continue
+		}
+		if err := validateRemoteSetting(key, value); err != nil {
+			rejected[key] = err.Error()
+			continue
+		}
+		Datadog.SetWithSource(key, value, SourceRemoteConfig)
+		applied[key] = true
+	}
+
+	for key := range remoteSettings {
+		if !applied[key] {
+			Datadog.UnsetForSource(key, SourceRemoteConfig)
+		}
+	}
+
+	if applied["log_level"] || remoteSettings["log_level"] {
+		if err := changeLogLevel(Datadog.GetString("log_level")); err != nil {
+			log.Errorf("Could not apply the log level of the remote configuration: %v", err)
+		}
+	}
+	remoteSettings = applied
+	return rejected
+}
+
+func validateRemoteSetting(key string, value interface{}) error {
+	switch key {
+	case "log_level":
+		_, err := validateLogLevel(fmt.Sprint(value))
+		return err
+	case "apm_config.extra_sample_rate":
+		rate, ok := value.(float64)
+		if !ok || rate < 0 || rate > 1 {
+			return fmt.Errorf("the sample rate should be a number between 0 and 1, not %v", value)
+		}
+	case "apm_config.max_traces_per_second":
+		tps, ok := value.(float64)
+		if !ok || tps < 0 {
+			return fmt.Errorf("the number of traces per second should be a positive number, not %v", value)
+		}
+	}
+	return nil
+}
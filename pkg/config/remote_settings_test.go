@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRemoteSettings(t *testing.T) {
+	mockConfig := Mock()
+	mockConfig.SetDefault("log_payloads", false)
+	mockConfig.SetDefault("use_v2_api.series", false)
+	remoteSettings = make(map[string]bool)
+
+	rejected := ApplyRemoteSettings(map[string]interface{}{
+		"log_payloads":      true,
+		"use_v2_api.series": true,
+		"api_key":           "123",
+	})
+	assert.Len(t, rejected, 1)
+	assert.Contains(t, rejected, "api_key")
+	assert.True(t, mockConfig.GetBool("log_payloads"))
+	assert.Equal(t, SourceRemoteConfig, mockConfig.GetSource("log_payloads"))
+	assert.True(t, mockConfig.GetBool("use_v2_api.series"))
+
+	// the runtime values keep precedence
+	mockConfig.SetWithSource("log_payloads", false, SourceRuntime)
+	assert.False(t, mockConfig.GetBool("log_payloads"))
+	mockConfig.UnsetForSource("log_payloads", SourceRuntime)
+
+	// the settings no longer set remotely are unset
+	rejected = ApplyRemoteSettings(map[string]interface{}{"log_payloads": true})
+	assert.Empty(t, rejected)
+	assert.True(t, mockConfig.GetBool("log_payloads"))
+	assert.False(t, mockConfig.GetBool("use_v2_api.series"))
+	assert.Equal(t, SourceDefault, mockConfig.GetSource("use_v2_api.series"))
+
+	rejected = ApplyRemoteSettings(map[string]interface{}{"log_level": "not_a_level"})
+	assert.Contains(t, rejected, "log_level")
+	assert.False(t, mockConfig.GetBool("log_payloads"))
+}
+
+func TestApplyRemoteSamplingRates(t *testing.T) {
+	mockConfig := Mock()
+	remoteSettings = make(map[string]bool)
+
+	rejected := ApplyRemoteSettings(map[string]interface{}{
+		"apm_config.extra_sample_rate":     0.5,
+		"apm_config.max_traces_per_second": float64(20),
+	})
+	assert.Empty(t, rejected)
+	assert.Equal(t, 0.5, mockConfig.GetFloat64("apm_config.extra_sample_rate"))
+	assert.Equal(t, SourceRemoteConfig, mockConfig.GetSource("apm_config.max_traces_per_second"))
+
+	rejected = ApplyRemoteSettings(map[string]interface{}{
+		"apm_config.extra_sample_rate":     1.5,
+		"apm_config.max_traces_per_second": "many",
+	})
+	assert.Contains(t, rejected, "apm_config.extra_sample_rate")
+	assert.Contains(t, rejected, "apm_config.max_traces_per_second")
+	assert.NotEqual(t, SourceRemoteConfig, mockConfig.GetSource("apm_config.extra_sample_rate"))
+}
@@ -29,13 +29,25 @@ type RuntimeSetting interface {
 	Description() string
 }
 
+// runtimeConfigurableKeys are the configuration keys read by the agent each time they're
+// used, that can be overridden at runtime without restarting the agent
+var runtimeConfigurableKeys = []string{
+	"log_payloads",
+	"use_v2_api.series",
+	"use_v2_api.events",
+	"use_v2_api.service_checks",
+}
+
 func initRuntimeSettings() {
 	// Runtime-editable settings must be registered here to dynamically populate command-line information
-	registerRuntimeSetting(logLevelRuntimeSetting("log_level"))
+	RegisterRuntimeSetting(logLevelRuntimeSetting("log_level"))
+	for _, key := range runtimeConfigurableKeys {
+		RegisterRuntimeSetting(configKeyRuntimeSetting(key))
+	}
 }
 
 // RegisterRuntimeSettings keeps track of configurable settings
-func registerRuntimeSetting(setting RuntimeSetting) error {
+func RegisterRuntimeSetting(setting RuntimeSetting) error {
 	if _, ok := runtimeSettings[setting.Name()]; ok {
 		return errors.New("duplicated settings detected")
 	}
@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import "fmt"
+
+// configKeyRuntimeSetting overrides a configuration key in the runtime layer
+type configKeyRuntimeSetting string
+
+func (k configKeyRuntimeSetting) Description() string {
+	return fmt.Sprintf("Set/get %s, overriding its value from the configuration files and the environment", string(k))
+}
+
+func (k configKeyRuntimeSetting) Name() string {
+	return string(k)
+}
+
+func (k configKeyRuntimeSetting) Get() (interface{}, error) {
+	return Datadog.Get(string(k)), nil
+}
+
+func (k configKeyRuntimeSetting) Set(v interface{}) error {
+	Datadog.SetWithSource(string(k), v, SourceRuntime)
+	return nil
+}
@@ -34,6 +34,6 @@ func (l logLevelRuntimeSetting) Set(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	Datadog.Set("log_level", logLevel)
+	Datadog.SetWithSource("log_level", logLevel, SourceRuntime)
 	return nil
 }
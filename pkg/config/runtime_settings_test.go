@@ -40,7 +40,7 @@ func TestRuntimeSettings(t *testing.T) {
 	cleanRuntimeSetting()
 	runtimeSetting := runtimeTestSetting{1}
 
-	err := registerRuntimeSetting(&runtimeSetting)
+	err := RegisterRuntimeSetting(&runtimeSetting)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(RuntimeSettings()))
 
@@ -55,7 +55,7 @@ func TestRuntimeSettings(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 123, v)
 
-	err = registerRuntimeSetting(&runtimeSetting)
+	err = RegisterRuntimeSetting(&runtimeSetting)
 	assert.NotNil(t, err)
 	assert.Equal(t, "duplicated settings detected", err.Error())
 }
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+// Source is the layer a configuration value comes from
+type Source string
+
+// The layers of the configuration, a value of a layer overrides the values of the layers
+// listed before it in Sources.
+const (
+	SourceDefault      Source = "default"
+	SourceFile         Source = "file"
+	SourceEnvVar       Source = "environment-variable"
+	SourceRemoteConfig Source = "remote-config"
+	SourceRuntime      Source = "runtime"
+	// SourceUnknown is the source of the keys no layer sets
+	SourceUnknown Source = "unknown"
+)
+
+// Sources lists the layers of the configuration, from the lowest priority to the highest
+var Sources = []Source{
+	SourceDefault,
+	SourceFile,
+	SourceEnvVar,
+	SourceRemoteConfig,
+	SourceRuntime,
+}
+
+// ValueWithSource is the value of a key in one of the layers of the configuration
+type ValueWithSource struct {
+	Source Source      `json:"source"`
+	Value  interface{} `json:"value"`
+}
+
+// settableSource returns true for the layers that can be set, and unset, while the agent runs:
+// the file layer when the configuration file is reloaded, the remote-config and runtime layers
+func settableSource(source Source) bool {
+	return source == SourceFile || source == SourceRemoteConfig || source == SourceRuntime
+}
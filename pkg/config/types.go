@@ -20,6 +20,7 @@ import (
 // - files
 // - environment variables
 // - flags
+// - the remote configuration and the runtime settings, set while the agent runs
 type Config interface {
 
 	// API implemented by viper.Viper
@@ -80,4 +81,16 @@ type Config interface {
 	BindEnvAndSetDefault(key string, val interface{})
 	// GetEnvVars returns a list of the non-sensitive env vars that the config supports
 	GetEnvVars() []string
+
+	// SetWithSource sets the value of a key in the file (when it's reloaded), remote-config
+	// or runtime layer, it overrides the value of the lower layers until it's unset
+	SetWithSource(key string, value interface{}, source Source)
+	// UnsetForSource removes the value of a key from the file, remote-config or runtime
+	// layer, the value of the layers below is used again
+	UnsetForSource(key string, source Source)
+	// GetSource returns the highest layer setting the key
+	GetSource(key string) Source
+	// GetSources returns the value of the key in each layer setting it, from the lowest
+	// priority to the highest
+	GetSources(key string) []ValueWithSource
 }
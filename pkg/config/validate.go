@@ -0,0 +1,249 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ValidationSeverity is the severity of a configuration issue, only the errors make the
+// configuration invalid
+type ValidationSeverity string
+
+// The severities of the configuration issues
+const (
+	ValidationError   ValidationSeverity = "error"
+	ValidationWarning ValidationSeverity = "warning"
+)
+
+// ValidationIssue is an issue found while validating the configuration
+type ValidationIssue struct {
+	Severity ValidationSeverity `json:"severity"`
+	File     string             `json:"file,omitempty"`
+	Key      string             `json:"key,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// The types of the schema of the configuration
+const (
+	schemaBoolean  = "boolean"
+	schemaInteger  = "integer"
+	schemaNumber   = "number"
+	schemaString   = "string"
+	schemaDuration = "duration"
+	schemaList     = "list"
+	schemaMap      = "map"
+	schemaAny      = "any"
+)
+
+// GenerateSchema returns the type of each known key of the configuration, generated from
+// the type of its default value. The keys without default can hold any value.
+func GenerateSchema(config Config) map[string]string {
+	schema := make(map[string]string)
+	for key := range config.GetKnownKeys() {
+		schema[key] = schemaAny
+		for _, layer := range config.GetSources(key) {
+			if layer.Source == SourceDefault {
+				schema[key] = schemaType(layer.Value)
+			}
+		}
+	}
+	return schema
+}
+
+// Validate checks the values of the configuration file against the schema generated from
+// the defaults, and the constraints between the keys.
+func Validate(config Config) []ValidationIssue {
+	file := config.ConfigFileUsed()
+	issues := []ValidationIssue{}
+
+	schema := GenerateSchema(config)
+	for _, key := range findUnknownKeys(config) {
+		if inKnownMap(schema, key) {
+			continue
+		}
+		issues = append(issues, ValidationIssue{
+			Severity: ValidationWarning,
+			File:     file,
+			Key:      key,
+			Message:  "unknown key, it is ignored",
+		})
+	}
+
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, layer := range config.GetSources(key) {
+			if layer.Source != SourceFile || layer.Value == nil || matchesSchemaType(schema[key], layer.Value) {
+				continue
+			}
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationError,
+				File:     file,
+				Key:      key,
+				Message:  fmt.Sprintf("expected type %s, got %v", schema[key], layer.Value),
+			})
+		}
+	}
+
+	for _, issue := range crossFieldIssues(config) {
+		issue.File = file
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// crossFieldIssues checks the constraints between the keys of the configuration
+func crossFieldIssues(config Config) []ValidationIssue {
+	var issues []ValidationIssue
+	addIssue := func(severity ValidationSeverity, key string, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Severity: severity, Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if config.GetString("api_key") == "" {
+		addIssue(ValidationError, "api_key", "no API key is configured, the Agent can't send any data")
+	}
+
+	additionalEndpoints := config.GetStringMapStringSlice("additional_endpoints")
+	for endpoint, apiKeys := range additionalEndpoints {
+		if u, err := url.Parse(endpoint); err != nil || u.Host == "" {
+			addIssue(ValidationError, "additional_endpoints", "%s isn't a valid URL", endpoint)
+		}
+		if !hasNonEmpty(apiKeys) {
+			addIssue(ValidationError, "additional_endpoints", "no API key is configured for %s, nothing is sent to it", endpoint)
+		}
+	}
+	if len(additionalEndpoints) > 0 && config.GetBool("fips.enabled") && config.GetString("fips.local_address") != "" {
+		addIssue(ValidationWarning, "additional_endpoints", "the additional endpoints are dropped when the data is sent through the local FIPS proxy")
+	}
+
+	logsEnabled := config.GetBool("logs_enabled") || config.GetBool("log_enabled")
+	if logsDDURL := config.GetString("logs_config.logs_dd_url"); logsEnabled && logsDDURL != "" {
+		if _, port, err := net.SplitHostPort(logsDDURL); err != nil || port == "" {
+			addIssue(ValidationError, "logs_config.logs_dd_url", "%s isn't a host:port address, the logs can't be sent", logsDDURL)
+		}
+	}
+	var logsAdditionalEndpoints []map[string]interface{}
+	if err := config.UnmarshalKey("logs_config.additional_endpoints", &logsAdditionalEndpoints); err != nil {
+		addIssue(ValidationError, "logs_config.additional_endpoints", "expected a list of endpoints: %v", err)
+	}
+	if len(logsAdditionalEndpoints) > 0 && !logsEnabled {
+		addIssue(ValidationWarning, "logs_config.additional_endpoints", "the logs additional endpoints are configured but logs_enabled is false")
+	}
+	for i, endpoint := range logsAdditionalEndpoints {
+		if host, _ := endpoint["host"].(string); host == "" {
+			addIssue(ValidationError, "logs_config.additional_endpoints", "the endpoint %d has no host", i)
+		}
+		if apiKey, _ := endpoint["api_key"].(string); apiKey == "" {
+			addIssue(ValidationError, "logs_config.additional_endpoints", "the endpoint %d has no API key, nothing is sent to it", i)
+		}
+	}
+
+	if config.GetBool("remote_configuration.enabled") && len(config.GetStringMapString("remote_configuration.public_keys")) == 0 {
+		addIssue(ValidationError, "remote_configuration.public_keys", "no public key is configured, the remote configurations can't be verified")
+	}
+	return issues
+}
+
+// inKnownMap returns true when the key is nested in a known key holding a map, e.g. the
+// URLs of additional_endpoints
+func inKnownMap(schema map[string]string, key string) bool {
+	for i := range key {
+		if key[i] != '.' {
+			continue
+		}
+		if parentType, found := schema[key[:i]]; found && (parentType == schemaMap || parentType == schemaAny) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNonEmpty(values []string) bool {
+	for _, value := range values {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaType returns the type of the schema matching the default value of a key
+func schemaType(value interface{}) string {
+	if value == nil {
+		return schemaAny
+	}
+	if _, ok := value.(time.Duration); ok {
+		return schemaDuration
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool:
+		return schemaBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schemaInteger
+	case reflect.Float32, reflect.Float64:
+		return schemaNumber
+	case reflect.String:
+		return schemaString
+	case reflect.Slice, reflect.Array:
+		return schemaList
+	case reflect.Map, reflect.Struct:
+		return schemaMap
+	}
+	return schemaAny
+}
+
+// matchesSchemaType returns true when the value can be read as the type of the schema,
+// the scalars written as strings are accepted as the agent converts them.
+func matchesSchemaType(expected string, value interface{}) bool {
+	actual := schemaType(value)
+	str, isString := value.(string)
+	switch expected {
+	case schemaBoolean:
+		if isString {
+			_, err := strconv.ParseBool(str)
+			return err == nil
+		}
+		return actual == schemaBoolean
+	case schemaInteger:
+		if isString {
+			_, err := strconv.Atoi(str)
+			return err == nil
+		}
+		return actual == schemaInteger
+	case schemaNumber:
+		if isString {
+			_, err := strconv.ParseFloat(str, 64)
+			return err == nil
+		}
+		return actual == schemaInteger || actual == schemaNumber
+	case schemaDuration:
+		if isString {
+			_, err := time.ParseDuration(str)
+			_, errInt := strconv.Atoi(str)
+			return err == nil || errInt == nil
+		}
+		return actual == schemaInteger || actual == schemaDuration
+	case schemaString:
+		return actual != schemaList && actual != schemaMap
+	case schemaList:
+		// the lists can be written as space separated strings
+		return actual == schemaList || isString
+	case schemaMap:
+		return actual == schemaMap
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func issuesByKey(issues []ValidationIssue) map[string][]ValidationIssue {
+	byKey := make(map[string][]ValidationIssue)
+	for _, issue := range issues {
+		byKey[issue.Key] = append(byKey[issue.Key], issue)
+	}
+	return byKey
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema := GenerateSchema(setupConf())
+	assert.Equal(t, schemaBoolean, schema["logs_enabled"])
+	assert.Equal(t, schemaInteger, schema["dogstatsd_port"])
+	assert.Equal(t, schemaString, schema["site"])
+	assert.Equal(t, schemaList, schema["tags"])
+}
+
+func TestValidateValidConfig(t *testing.T) {
+	config := setupConfFromYAML(`
+api_key: abcdef
+logs_enabled: true
+dogstatsd_port: "8126"
+additional_endpoints:
+  https://app.datadoghq.eu:
+    - 123456
+`)
+	assert.Empty(t, Validate(config))
+}
+
+func TestValidateSchema(t *testing.T) {
+	config := setupConfFromYAML(`
+api_key: abcdef
+logs_enabled: maybe
+dogstatsd_port: [8125]
+unknown_key: true
+`)
+	issues := issuesByKey(Validate(config))
+	assert.Len(t, issues, 3)
+	if assert.Len(t, issues["logs_enabled"], 1) {
+		assert.Equal(t, ValidationError, issues["logs_enabled"][0].Severity)
+	}
+	if assert.Len(t, issues["dogstatsd_port"], 1) {
+		assert.Equal(t, ValidationError, issues["dogstatsd_port"][0].Severity)
+	}
+	if assert.Len(t, issues["unknown_key"], 1) {
+		assert.Equal(t, ValidationWarning, issues["unknown_key"][0].Severity)
+	}
+}
+
+func TestValidateCrossFields(t *testing.T) {
+	config := setupConfFromYAML(`
+additional_endpoints:
+  https://app.datadoghq.eu: []
+logs_enabled: false
+logs_config:
+  additional_endpoints:
+    - host: intake.logs.datadoghq.eu
+remote_configuration:
+  enabled: true
+`)
+	issues := issuesByKey(Validate(config))
+	assert.Len(t, issues["api_key"], 1)
+	assert.Len(t, issues["additional_endpoints"], 1)
+	// orphan endpoint, and no API key
+	assert.Len(t, issues["logs_config.additional_endpoints"], 2)
+	assert.Len(t, issues["remote_configuration.public_keys"], 1)
+
+	config = setupConfFromYAML(`
+api_key: abcdef
+logs_enabled: true
+logs_config:
+  logs_dd_url: intake.logs.datadoghq.eu
+`)
+	issues = issuesByKey(Validate(config))
+	assert.Len(t, issues, 1)
+	assert.Len(t, issues["logs_config.logs_dd_url"], 1)
+}
@@ -6,7 +6,11 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -16,16 +20,26 @@ import (
 	"github.com/DataDog/viper"
 	"github.com/spf13/afero"
 	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // safeConfig implements Config:
 // - wraps viper with a safety lock
 // - implements the additional DDHelpers
+// - tracks the layer each value comes from
 type safeConfig struct {
 	*viper.Viper
 	sync.RWMutex
-	envPrefix     string
-	configEnvVars []string
+	envPrefix      string
+	envKeyReplacer *strings.Replacer
+	configEnvVars  []string
+	fs             afero.Fs
+
+	// the values of the layers, initialized when they're first set
+	defaults    map[string]interface{}
+	envBindings map[string][]string               // env vars bound to the keys
+	fileValues  map[string]interface{}            // values of the config files, by flattened key
+	overrides   map[Source]map[string]interface{} // values of the remote-config and runtime layers
 }
 
 // Set wraps Viper for concurrent access
@@ -40,6 +54,10 @@ func (c *safeConfig) SetDefault(key string, value interface{}) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.SetDefault(key, value)
+	if c.defaults == nil {
+		c.defaults = make(map[string]interface{})
+	}
+	c.defaults[strings.ToLower(key)] = value
 }
 
 // SetKnown adds a key to the set of known valid config keys
@@ -62,6 +80,16 @@ func (c *safeConfig) SetFs(fs afero.Fs) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.SetFs(fs)
+	c.fs = fs
+}
+
+// getFs returns the filesystem the config files are read from. It must be called with the
+// lock held.
+func (c *safeConfig) getFs() afero.Fs {
+	if c.fs == nil {
+		return afero.NewOsFs()
+	}
+	return c.fs
 }
 
 // IsSet wraps Viper for concurrent access
@@ -227,21 +255,28 @@ func (c *safeConfig) SetEnvPrefix(in string) {
 func (c *safeConfig) BindEnv(input ...string) error {
 	c.Lock()
 	defer c.Unlock()
+	if c.envBindings == nil {
+		c.envBindings = make(map[string][]string)
+	}
 	if len(input) == 1 {
 		// FIXME: for the purposes of GetEnvVars implementation, we only track env var keys
 		// that are interpolated by viper from the config option key name
 		key := input[0]
 		envVarName := strings.Join([]string{c.envPrefix, strings.ToUpper(key)}, "_")
 		c.configEnvVars = append(c.configEnvVars, envVarName)
+		c.envBindings[strings.ToLower(key)] = []string{envVarName}
+	} else if len(input) > 1 {
+		c.envBindings[strings.ToLower(input[0])] = input[1:]
 	}
 	return c.Viper.BindEnv(input...)
 }
 
 // SetEnvKeyReplacer wraps Viper for concurrent access
 func (c *safeConfig) SetEnvKeyReplacer(r *strings.Replacer) {
-	c.RLock()
-	defer c.RUnlock()
+	c.Lock()
+	defer c.Unlock()
 	c.Viper.SetEnvKeyReplacer(r)
+	c.envKeyReplacer = r
 }
 
 // UnmarshalKey wraps Viper for concurrent access
@@ -269,21 +304,56 @@ func (c *safeConfig) UnmarshalExact(rawVal interface{}) error {
 func (c *safeConfig) ReadInConfig() error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.ReadInConfig()
+	if err := c.Viper.ReadInConfig(); err != nil {
+		return err
+	}
+	// the file is read again to merge the files it includes, and expand the env vars
+	content, err := readConfigFile(c.getFs(), c.Viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	if err := c.Viper.ReadConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	c.fileValues = make(map[string]interface{})
+	c.mergeFileValues(content)
+	return nil
 }
 
 // ReadConfig wraps Viper for concurrent access
 func (c *safeConfig) ReadConfig(in io.Reader) error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.ReadConfig(in)
+	content, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	// the includes are relative to the working directory
+	content, err = preprocessConfig(c.getFs(), content, "")
+	if err != nil {
+		return err
+	}
+	if err := c.Viper.ReadConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	c.fileValues = make(map[string]interface{})
+	c.mergeFileValues(content)
+	return nil
 }
 
 // MergeConfig wraps Viper for concurrent access
 func (c *safeConfig) MergeConfig(in io.Reader) error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.MergeConfig(in)
+	content, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if err := c.Viper.MergeConfig(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	c.mergeFileValues(content)
+	return nil
 }
 
 // MergeConfigOverride wraps Viper for concurrent access
@@ -347,6 +417,190 @@ func (c *safeConfig) GetEnvVars() []string {
 	return c.configEnvVars
 }
 
+// SetWithSource implements the Config interface
+func (c *safeConfig) SetWithSource(key string, value interface{}, source Source) {
+	if !settableSource(source) {
+		log.Warnf("The %s layer of the configuration can't be set while the agent runs, %s is left unchanged", source, key)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	key = strings.ToLower(key)
+	if source == SourceFile {
+		// the configuration file is reloaded
+		c.unsetFileValue(key)
+		flattenValues(key, value, c.fileValues)
+		c.applyHighestLayer(key)
+		// the nested keys can be set by a higher layer
+		for nestedKey := range c.fileValues {
+			if strings.HasPrefix(nestedKey, key+".") && c.highestSource(nestedKey) != SourceFile {
+				c.applyHighestLayer(nestedKey)
+			}
+		}
+		return
+	}
+	if c.overrides == nil {
+		c.overrides = make(map[Source]map[string]interface{})
+	}
+	if c.overrides[source] == nil {
+		c.overrides[source] = make(map[string]interface{})
+	}
+	c.overrides[source][key] = value
+	c.applyHighestLayer(key)
+}
+
+// UnsetForSource implements the Config interface
+func (c *safeConfig) UnsetForSource(key string, source Source) {
+	if !settableSource(source) {
+		log.Warnf("The %s layer of the configuration can't be unset while the agent runs, %s is left unchanged", source, key)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	key = strings.ToLower(key)
+	if source == SourceFile {
+		c.unsetFileValue(key)
+		c.applyHighestLayer(key)
+		return
+	}
+	if _, found := c.overrides[source][key]; !found {
+		return
+	}
+	delete(c.overrides[source], key)
+	c.applyHighestLayer(key)
+}
+
+// GetSource implements the Config interface
+func (c *safeConfig) GetSource(key string) Source {
+	c.RLock()
+	defer c.RUnlock()
+	return c.highestSource(strings.ToLower(key))
+}
+
+// GetSources implements the Config interface
+func (c *safeConfig) GetSources(key string) []ValueWithSource {
+	c.RLock()
+	defer c.RUnlock()
+	key = strings.ToLower(key)
+	var values []ValueWithSource
+	for _, source := range Sources {
+		if value, found := c.layerValue(key, source); found {
+			values = append(values, ValueWithSource{Source: source, Value: value})
+		}
+	}
+	return values
+}
+
+// highestSource returns the highest layer setting the key. It must be called with the lock held.
+func (c *safeConfig) highestSource(key string) Source {
+	for i := len(Sources) - 1; i >= 0; i-- {
+		if _, found := c.layerValue(key, Sources[i]); found {
+			return Sources[i]
+		}
+	}
+	return SourceUnknown
+}
+
+// applyHighestLayer sets the value of the highest layer setting the key as the value
+// viper returns. It must be called with the lock held.
+func (c *safeConfig) applyHighestLayer(key string) {
+	for i := len(Sources) - 1; i >= 0; i-- {
+		if value, found := c.layerValue(key, Sources[i]); found {
+			c.Viper.Set(key, value)
+			return
+		}
+	}
+	c.Viper.Set(key, nil)
+}
+
+// layerValue returns the value of the key in a layer. It must be called with the lock held.
+func (c *safeConfig) layerValue(key string, source Source) (interface{}, bool) {
+	var value interface{}
+	var found bool
+	switch source {
+	case SourceDefault:
+		value, found = c.defaults[key]
+	case SourceFile:
+		value, found = c.fileValues[key]
+	case SourceEnvVar:
+		for _, envVar := range c.envBindings[key] {
+			if c.envKeyReplacer != nil {
+				envVar = c.envKeyReplacer.Replace(envVar)
+			}
+			// like viper, the empty env vars are ignored
+			if v, ok := os.LookupEnv(envVar); ok && v != "" {
+				return v, true
+			}
+		}
+	case SourceRemoteConfig, SourceRuntime:
+		value, found = c.overrides[source][key]
+	}
+	return value, found
+}
+
+// mergeFileValues tracks the values set by a config file. It must be called with the lock held.
+func (c *safeConfig) mergeFileValues(content []byte) {
+	if c.fileValues == nil {
+		c.fileValues = make(map[string]interface{})
+	}
+	var values interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		log.Debugf("Could not parse the configuration to track the values it sets: %v", err)
+		return
+	}
+	flattenValues("", values, c.fileValues)
+}
+
+// fileLayer returns a copy of the values set by the config file
+func (c *safeConfig) fileLayer() map[string]interface{} {
+	c.RLock()
+	defer c.RUnlock()
+	values := make(map[string]interface{}, len(c.fileValues))
+	for key, value := range c.fileValues {
+		values[key] = value
+	}
+	return values
+}
+
+// unsetFileValue removes a key, and the keys nested in it, from the file layer. It must be
+// called with the lock held.
+func (c *safeConfig) unsetFileValue(key string) {
+	if c.fileValues == nil {
+		c.fileValues = make(map[string]interface{})
+	}
+	delete(c.fileValues, key)
+	for k := range c.fileValues {
+		if strings.HasPrefix(k, key+".") {
+			delete(c.fileValues, k)
+		}
+	}
+}
+
+// flattenValues adds the values of a YAML document to values, the nested keys are joined by dots
+func flattenValues(prefix string, value interface{}, values map[string]interface{}) {
+	if prefix != "" {
+		values[prefix] = value
+	}
+	nested := make(map[string]interface{})
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			nested[fmt.Sprint(k)] = item
+		}
+	case map[string]interface{}:
+		nested = v
+	default:
+		return
+	}
+	for k, v := range nested {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		flattenValues(key, v, values)
+	}
+}
+
 // BindEnvAndSetDefault implements the Config interface
 func (c *safeConfig) BindEnvAndSetDefault(key string, val interface{}) {
 	c.SetDefault(key, val)
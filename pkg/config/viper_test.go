@@ -6,11 +6,14 @@
 package config
 
 import (
+	"os"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/DataDog/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConcurrencySetGet(t *testing.T) {
@@ -86,3 +89,54 @@ func TestGetConfigEnvVars(t *testing.T) {
 	config.BindEnv("config_option", "DD_CONFIG_OPTION")
 	assert.NotContains(t, config.GetEnvVars(), "DD_CONFIG_OPTION")
 }
+
+func TestConfigSources(t *testing.T) {
+	config := NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	config.BindEnvAndSetDefault("log_level", "info")
+	config.BindEnvAndSetDefault("logs_config.run_path", "/opt/datadog-agent/run")
+	config.BindEnvAndSetDefault("hostname", "")
+	config.BindEnvAndSetDefault("site", "datadoghq.com")
+	config.SetConfigType("yaml")
+	require.NoError(t, config.ReadConfig(strings.NewReader("log_level: debug\nlogs_config:\n  run_path: /tmp/run\n")))
+	os.Setenv("DD_LOGS_CONFIG_RUN_PATH", "/var/run")
+	defer os.Unsetenv("DD_LOGS_CONFIG_RUN_PATH")
+
+	assert.Equal(t, SourceDefault, config.GetSource("site"))
+	assert.Equal(t, SourceFile, config.GetSource("log_level"))
+	assert.Equal(t, SourceEnvVar, config.GetSource("logs_config.run_path"))
+	assert.Equal(t, SourceUnknown, config.GetSource("unknown_key"))
+	assert.Equal(t, []ValueWithSource{
+		{Source: SourceDefault, Value: "/opt/datadog-agent/run"},
+		{Source: SourceFile, Value: "/tmp/run"},
+		{Source: SourceEnvVar, Value: "/var/run"},
+	}, config.GetSources("logs_config.run_path"))
+
+	// the runtime layer overrides the remote one, which overrides the others
+	config.SetWithSource("log_level", "warn", SourceRemoteConfig)
+	config.SetWithSource("log_level", "error", SourceRuntime)
+	assert.Equal(t, "error", config.GetString("log_level"))
+	assert.Equal(t, SourceRuntime, config.GetSource("log_level"))
+	config.UnsetForSource("log_level", SourceRuntime)
+	assert.Equal(t, "warn", config.GetString("log_level"))
+	assert.Equal(t, SourceRemoteConfig, config.GetSource("log_level"))
+	config.UnsetForSource("log_level", SourceRemoteConfig)
+	assert.Equal(t, "debug", config.GetString("log_level"))
+	assert.Equal(t, SourceFile, config.GetSource("log_level"))
+
+	// the file layer is set when the file is reloaded
+	config.SetWithSource("logs_config", map[string]interface{}{"run_path": "/tmp/reloaded"}, SourceFile)
+	assert.Equal(t, []ValueWithSource{
+		{Source: SourceDefault, Value: "/opt/datadog-agent/run"},
+		{Source: SourceFile, Value: "/tmp/reloaded"},
+		{Source: SourceEnvVar, Value: "/var/run"},
+	}, config.GetSources("logs_config.run_path"))
+	assert.Equal(t, "/var/run", config.GetString("logs_config.run_path"))
+	config.UnsetForSource("log_level", SourceFile)
+	assert.Equal(t, "info", config.GetString("log_level"))
+	assert.Equal(t, SourceDefault, config.GetSource("log_level"))
+
+	// the other layers can't be set at runtime
+	config.SetWithSource("hostname", "myhost", SourceEnvVar)
+	assert.Equal(t, "", config.GetString("hostname"))
+	assert.Equal(t, SourceDefault, config.GetSource("hostname"))
+}
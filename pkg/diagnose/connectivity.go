@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// connectivityTimeout bounds each request of the connectivity suites
+const connectivityTimeout = 10 * time.Second
+
+const connectivityRemediation = "Check that the firewall allows the outgoing traffic to this endpoint, or configure a proxy with the proxy settings"
+
+func init() {
+	diagnosis.RegisterNetworkSuite("Endpoints connectivity", diagnoseConnectivity)
+	diagnosis.RegisterNetworkSuite("API key validity", diagnoseAPIKeys)
+}
+
+// diagnoseConnectivity checks that the endpoints of each product are reachable,
+// any HTTP response means that the endpoint is reachable
+func diagnoseConnectivity() []diagnosis.Result {
+	client := diagnosisHTTPClient()
+	var results []diagnosis.Result
+
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return []diagnosis.Result{{
+			Name:        "Metrics",
+			Status:      diagnosis.StatusFail,
+			Message:     fmt.Sprintf("Misconfiguration of the agent endpoints: %s", err),
+			Remediation: "Check the dd_url, site and additional_endpoints settings",
+		}}
+	}
+	for _, domain := range sortedDomains(keysPerDomain) {
+		results = append(results, checkHTTPEndpoint(client, "Metrics", versionedDomain(domain)))
+	}
+
+	if config.Datadog.GetBool("apm_config.enabled") {
+		results = append(results, checkHTTPEndpoint(client, "APM", config.GetMainEndpoint("https://trace.agent.", "apm_config.apm_dd_url")))
+	}
+
+	if processURL := config.GetMainEndpoint("https://process.", "process_config.process_dd_url"); processURL != "disabled" {
+		results = append(results, checkHTTPEndpoint(client, "Processes", processURL))
+	}
+
+	if config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled") {
+		results = append(results, checkLogsEndpoint())
+	}
+
+	return results
+}
+
+// checkHTTPEndpoint checks that an HTTP endpoint is reachable
+func checkHTTPEndpoint(client *http.Client, product string, endpoint string) diagnosis.Result {
+	name := fmt.Sprintf("%s: %s", product, endpoint)
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return diagnosis.Result{
+			Name:        name,
+			Status:      diagnosis.StatusFail,
+			Message:     err.Error(),
+			Remediation: connectivityRemediation,
+		}
+	}
+	resp.Body.Close()
+	return diagnosis.Result{
+		Name:    name,
+		Status:  diagnosis.StatusPass,
+		Message: fmt.Sprintf("Reachable, response: %s", resp.Status),
+	}
+}
+
+// checkLogsEndpoint checks that the main logs endpoint accepts connections
+func checkLogsEndpoint() diagnosis.Result {
+	endpoints, err := logsconfig.BuildHTTPEndpoints()
+	if err != nil {
+		return diagnosis.Result{
+			Name:        "Logs",
+			Status:      diagnosis.StatusFail,
+			Message:     fmt.Sprintf("Misconfiguration of the logs endpoints: %s", err),
+			Remediation: "Check the logs_config.logs_dd_url setting",
+		}
+	}
+	host, port := endpoints.Main.Host, endpoints.Main.Port
+	if port == 0 {
+		port = 443
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	name := fmt.Sprintf("Logs: %s", address)
+
+	conn, err := net.DialTimeout("tcp", address, connectivityTimeout)
+	if err != nil {
+		return diagnosis.Result{
+			Name:        name,
+			Status:      diagnosis.StatusFail,
+			Message:     err.Error(),
+			Remediation: connectivityRemediation,
+		}
+	}
+	conn.Close()
+	return diagnosis.Result{Name: name, Status: diagnosis.StatusPass, Message: "Reachable"}
+}
+
+// diagnoseAPIKeys checks that each API key is valid for its endpoint
+func diagnoseAPIKeys() []diagnosis.Result {
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return []diagnosis.Result{{
+			Name:        "API keys",
+			Status:      diagnosis.StatusFail,
+			Message:     fmt.Sprintf("Misconfiguration of the agent endpoints: %s", err),
+			Remediation: "Check the api_key and additional_endpoints settings",
+		}}
+	}
+	return validateAPIKeys(diagnosisHTTPClient(), keysPerDomain)
+}
+
+// validateAPIKeys requests the validation route of each endpoint with each of its keys
+func validateAPIKeys(client *http.Client, keysPerDomain map[string][]string) []diagnosis.Result {
+	var results []diagnosis.Result
+	for _, domain := range sortedDomains(keysPerDomain) {
+		for _, apiKey := range keysPerDomain[domain] {
+			results = append(results, validateAPIKey(client, versionedDomain(domain), apiKey))
+		}
+	}
+	return results
+}
+
+func validateAPIKey(client *http.Client, domain string, apiKey string) diagnosis.Result {
+	result := diagnosis.Result{
+		Name: fmt.Sprintf("API key ending with %s on %s", keySuffix(apiKey), domain),
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(domain, "/")+proxyDiagnosisRoute, nil)
+	if err != nil {
+		result.Status = diagnosis.StatusFail
+		result.Message = err.Error()
+		return result
+	}
+	req.Header.Set("DD-Api-Key", apiKey)
+	req.Header.Set("User-Agent", fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// the connectivity suite reports the unreachable endpoints
+		result.Status = diagnosis.StatusWarn
+		result.Message = fmt.Sprintf("Could not validate the API key: %s", err)
+		result.Remediation = connectivityRemediation
+		return result
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		result.Status = diagnosis.StatusPass
+		result.Message = "API key valid"
+	case http.StatusForbidden:
+		result.Status = diagnosis.StatusFail
+		result.Message = "API key invalid"
+		result.Remediation = "Check the api_key setting, or the keys of this endpoint in additional_endpoints, and the site of the organization"
+	default:
+		result.Status = diagnosis.StatusWarn
+		result.Message = fmt.Sprintf("Could not validate the API key, unexpected response %s", resp.Status)
+	}
+	return result
+}
+
+func diagnosisHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: httputils.CreateHTTPTransport(),
+		Timeout:   connectivityTimeout,
+	}
+}
+
+// versionedDomain returns the domain the forwarder sends its payloads to
+func versionedDomain(domain string) string {
+	versioned, err := config.AddAgentVersionToDomain(domain, "app")
+	if err != nil {
+		return domain
+	}
+	return versioned
+}
+
+func sortedDomains(keysPerDomain map[string][]string) []string {
+	domains := make([]string, 0, len(keysPerDomain))
+	for domain := range keysPerDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// keySuffix returns the last 5 characters of an API key, like the status page
+func keySuffix(apiKey string) string {
+	if len(apiKey) > 5 {
+		return apiKey[len(apiKey)-5:]
+	}
+	return apiKey
+}
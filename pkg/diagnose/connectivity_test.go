@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAPIKeys(t *testing.T) {
+	valid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/validate", r.URL.Path)
+		if r.Header.Get("DD-Api-Key") != "valid-key-12345" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer valid.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	results := validateAPIKeys(&http.Client{}, map[string][]string{
+		valid.URL:   {"valid-key-12345", "invalid-key-67890"},
+		failing.URL: {"valid-key-12345"},
+	})
+	require.Len(t, results, 3)
+
+	byName := make(map[string]diagnosis.Result)
+	for _, result := range results {
+		byName[result.Name] = result
+	}
+	assert.Equal(t, diagnosis.StatusPass, byName["API key ending with 12345 on "+valid.URL].Status)
+	invalid := byName["API key ending with 67890 on "+valid.URL]
+	assert.Equal(t, diagnosis.StatusFail, invalid.Status)
+	assert.NotEmpty(t, invalid.Remediation)
+	assert.Equal(t, diagnosis.StatusWarn, byName["API key ending with 12345 on "+failing.URL].Status)
+}
+
+func TestCheckHTTPEndpoint(t *testing.T) {
+	// any response means that the endpoint is reachable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	result := checkHTTPEndpoint(&http.Client{}, "APM", server.URL)
+	assert.Equal(t, diagnosis.StatusPass, result.Status)
+	assert.Equal(t, "APM: "+server.URL, result.Name)
+
+	server.Close()
+	result = checkHTTPEndpoint(&http.Client{}, "APM", server.URL)
+	assert.Equal(t, diagnosis.StatusFail, result.Status)
+	assert.NotEmpty(t, result.Remediation)
+}
@@ -23,3 +23,48 @@ func Register(name string, d Diagnosis) {
 
 // Diagnosis should return an error to report its health
 type Diagnosis func() error
+
+// Status is the outcome of a check of a diagnosis suite
+type Status string
+
+const (
+	// StatusPass means that no issue was found
+	StatusPass Status = "pass"
+	// StatusWarn means that the agent works, but not as configured or not reliably
+	StatusWarn Status = "warn"
+	// StatusFail means that the agent can't work until the issue is fixed
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a check of a diagnosis suite, the remediation is a
+// hint to fix the issue when it didn't pass
+type Result struct {
+	Name        string `json:"name"`
+	Status      Status `json:"status"`
+	Message     string `json:"message,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Suite is a diagnosis made of several checks, e.g. one per endpoint
+type Suite func() []Result
+
+// DefaultSuites holds every compiled-in diagnosis suite
+var DefaultSuites = make(map[string]Suite)
+
+// RegisterSuite registers a diagnosis suite that will be called on diagnose
+func RegisterSuite(name string, s Suite) {
+	if _, ok := DefaultSuites[name]; ok {
+		log.Warnf("Diagnosis suite %s already registered, overriding it", name)
+	}
+	DefaultSuites[name] = s
+}
+
+// NetworkSuites holds the names of the suites that reach remote hosts, they can
+// take long to time out on hosts with no egress
+var NetworkSuites = make(map[string]bool)
+
+// RegisterNetworkSuite registers a diagnosis suite that reaches remote hosts
+func RegisterNetworkSuite(name string, s Suite) {
+	RegisterSuite(name, s)
+	NetworkSuites[name] = true
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+
+	"github.com/beevik/ntp"
+)
+
+// maxClockOffset is the offset beyond which the intake rejects or misplaces
+// the points sent by the agent
+const maxClockOffset = 60 * time.Second
+
+var (
+	ntpHosts = []string{"0.datadog.pool.ntp.org", "1.datadog.pool.ntp.org", "2.datadog.pool.ntp.org", "3.datadog.pool.ntp.org"}
+	ntpQuery = ntp.QueryWithOptions
+)
+
+func init() {
+	diagnosis.RegisterNetworkSuite("Clock offset", diagnoseClockOffset)
+}
+
+// diagnoseClockOffset compares the clock of the host to the first NTP server that answers
+func diagnoseClockOffset() []diagnosis.Result {
+	var errs []string
+	for _, host := range ntpHosts {
+		response, err := ntpQuery(host, ntp.QueryOptions{Timeout: 5 * time.Second})
+		if err == nil {
+			err = response.Validate()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", host, err))
+			continue
+		}
+		return []diagnosis.Result{clockOffsetResult(host, response.ClockOffset)}
+	}
+	return []diagnosis.Result{{
+		Name:        "NTP offset",
+		Status:      diagnosis.StatusWarn,
+		Message:     fmt.Sprintf("Could not query any NTP server: %v", errs),
+		Remediation: "Allow the outgoing UDP traffic on port 123 to check the clock of the host",
+	}}
+}
+
+func clockOffsetResult(host string, offset time.Duration) diagnosis.Result {
+	result := diagnosis.Result{
+		Name:    fmt.Sprintf("NTP offset with %s", host),
+		Status:  diagnosis.StatusPass,
+		Message: fmt.Sprintf("The clock offset is %s", offset),
+	}
+	if offset > maxClockOffset || offset < -maxClockOffset {
+		result.Status = diagnosis.StatusFail
+		result.Remediation = fmt.Sprintf("Synchronize the clock of the host with NTP, the metrics are misplaced in time beyond an offset of %s", maxClockOffset)
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+
+	"github.com/beevik/ntp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockOffsetResult(t *testing.T) {
+	assert.Equal(t, diagnosis.StatusPass, clockOffsetResult("host", 2*maxClockOffset/3).Status)
+	assert.Equal(t, diagnosis.StatusFail, clockOffsetResult("host", 2*maxClockOffset).Status)
+	assert.Equal(t, diagnosis.StatusFail, clockOffsetResult("host", -2*maxClockOffset).Status)
+}
+
+func TestDiagnoseClockOffsetUnreachable(t *testing.T) {
+	defer func() { ntpQuery = ntp.QueryWithOptions }()
+	ntpQuery = func(host string, opt ntp.QueryOptions) (*ntp.Response, error) {
+		return nil, errors.New("i/o timeout")
+	}
+
+	results := diagnoseClockOffset()
+	require.Len(t, results, 1)
+	assert.Equal(t, diagnosis.StatusWarn, results[0].Status)
+	assert.Contains(t, results[0].Message, "0.datadog.pool.ntp.org: i/o timeout")
+	assert.NotEmpty(t, results[0].Remediation)
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+)
+
+// DefaultLogFile is the log file checked when log_file isn't set, it's set by
+// the command running the diagnosis
+var DefaultLogFile string
+
+// pathKind is what the agent expects to find at a path
+type pathKind int
+
+const (
+	directoryPath pathKind = iota
+	filePath
+	socketPath
+)
+
+// checkedPath is a path the agent has to write to, or create
+type checkedPath struct {
+	setting string
+	path    string
+	kind    pathKind
+}
+
+func init() {
+	diagnosis.RegisterSuite("Filesystem permissions", diagnosePermissions)
+}
+
+// diagnosePermissions checks that the agent can write its logs, its run files
+// and its sockets
+func diagnosePermissions() []diagnosis.Result {
+	paths := []checkedPath{}
+
+	logFile := config.Datadog.GetString("log_file")
+	if logFile == "" {
+		logFile = DefaultLogFile
+	}
+	if logFile != "" && !config.Datadog.GetBool("disable_file_logging") {
+		paths = append(paths, checkedPath{"log_file", logFile, filePath})
+	}
+	paths = append(paths, checkedPath{"run_path", config.Datadog.GetString("run_path"), directoryPath})
+	if config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled") {
+		paths = append(paths, checkedPath{"logs_config.run_path", config.Datadog.GetString("logs_config.run_path"), directoryPath})
+	}
+	for _, setting := range []string{"dogstatsd_socket", "apm_config.receiver_socket"} {
+		if socket := config.Datadog.GetString(setting); socket != "" {
+			paths = append(paths, checkedPath{setting, socket, socketPath})
+		}
+	}
+
+	return checkPaths(paths)
+}
+
+func checkPaths(paths []checkedPath) []diagnosis.Result {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	results := make([]diagnosis.Result, 0, len(paths))
+	for _, p := range paths {
+		result := diagnosis.Result{
+			Name:    fmt.Sprintf("%s: %s", p.setting, p.path),
+			Status:  diagnosis.StatusPass,
+			Message: fmt.Sprintf("Writable by user %s", username),
+		}
+		if err := checkPath(p); err != nil {
+			result.Status = diagnosis.StatusFail
+			result.Message = fmt.Sprintf("Not usable by user %s: %s", username, err)
+			result.Remediation = fmt.Sprintf("Give the user %s write access to %s, or change the %s setting", username, p.path, p.setting)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkPath returns an error if the agent can't use the path, the files and
+// sockets that don't exist yet must be creatable in their directory
+func checkPath(p checkedPath) error {
+	info, err := os.Stat(p.path)
+	if os.IsNotExist(err) {
+		return checkWritableDir(filepath.Dir(p.path))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch p.kind {
+	case directoryPath:
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory")
+		}
+		return checkWritableDir(p.path)
+	case socketPath:
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("not a socket")
+		}
+		// the socket is replaced when the agent starts
+		return checkWritableDir(filepath.Dir(p.path))
+	default:
+		if info.IsDir() {
+			return fmt.Errorf("is a directory")
+		}
+		f, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// checkWritableDir creates and removes a temporary file in the directory
+func checkWritableDir(dir string) error {
+	f, err := ioutil.TempFile(dir, ".datadog-diagnose")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diagnose")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "agent.log")
+	require.NoError(t, ioutil.WriteFile(logFile, []byte("log"), 0644))
+
+	results := checkPaths([]checkedPath{
+		{"log_file", logFile, filePath},
+		{"run_path", dir, directoryPath},
+		{"logs_config.run_path", filepath.Join(dir, "run"), directoryPath},
+		{"dogstatsd_socket", logFile, socketPath},
+		{"log_file", dir, filePath},
+		{"run_path", filepath.Join(dir, "missing", "run"), directoryPath},
+	})
+	require.Len(t, results, 6)
+
+	for _, result := range results[:3] {
+		assert.Equal(t, diagnosis.StatusPass, result.Status, result.Name)
+	}
+	for _, result := range results[3:] {
+		assert.Equal(t, diagnosis.StatusFail, result.Status, result.Name)
+		assert.NotEmpty(t, result.Remediation)
+	}
+	assert.Contains(t, results[3].Message, "not a socket")
+
+	// the temporary files are removed
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
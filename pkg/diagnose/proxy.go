@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// proxyDiagnosisRoute is the route requested on each endpoint, it answers 200 or 403
+// depending on the validity of the API key.
+const proxyDiagnosisRoute = "/api/v1/validate"
+
+// RunProxy checks that each endpoint of keysPerDomain is reachable through its proxy,
+// with the transport used by the forwarder, and outputs the results in w.
+func RunProxy(w io.Writer, keysPerDomain map[string][]string) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	proxies := config.GetProxies()
+	if proxies == nil {
+		fmt.Fprintln(w, "No proxy configured")
+	} else {
+		auth := proxies.Auth
+		if auth == "" {
+			auth = config.ProxyAuthBasic
+		}
+		fmt.Fprintln(w, fmt.Sprintf("Proxy authentication: %s", auth))
+	}
+
+	domains := make([]string, 0, len(keysPerDomain))
+	for domain := range keysPerDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	client := &http.Client{
+		Transport: httputils.CreateHTTPTransport(),
+		Timeout:   config.Datadog.GetDuration("forwarder_timeout") * time.Second,
+	}
+
+	failed := 0
+	for _, domain := range domains {
+		// the requests are sent to the same hosts as the forwarder
+		versionedDomain, err := config.AddAgentVersionToDomain(domain, "app")
+		if err != nil {
+			versionedDomain = domain
+		}
+		fmt.Fprintln(w, fmt.Sprintf("=== Checking %s ===", color.BlueString(versionedDomain)))
+		err = diagnoseEndpoint(w, client, proxies, versionedDomain, keysPerDomain[domain])
+		statusString := color.GreenString("PASS")
+		if err != nil {
+			failed++
+			fmt.Fprintln(w, fmt.Sprintf("Error: %v", err))
+			statusString = color.RedString("FAIL")
+		}
+		fmt.Fprintln(w, fmt.Sprintf("===> %s\n", statusString))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d endpoint(s) out of %d could not be reached", failed, len(domains))
+	}
+	return nil
+}
+
+// diagnoseEndpoint outputs the proxy used for the domain and the response of the domain.
+func diagnoseEndpoint(w io.Writer, client *http.Client, proxies *config.Proxy, domain string, apiKeys []string) error {
+	endpoint, err := url.Parse(strings.TrimSuffix(domain, "/") + proxyDiagnosisRoute)
+	if err != nil {
+		return err
+	}
+
+	proxy := "none"
+	if proxies != nil {
+		proxyURL, err := httputils.GetProxyForURL(proxies, endpoint)
+		if err != nil {
+			return err
+		}
+		if proxyURL != nil {
+			proxy = httputils.SanitizeProxyURL(proxyURL)
+		}
+	}
+	fmt.Fprintln(w, fmt.Sprintf("Proxy: %s", proxy))
+
+	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return err
+	}
+	if len(apiKeys) > 0 {
+		req.Header.Set("DD-Api-Key", apiKeys[0])
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	fmt.Fprintln(w, fmt.Sprintf("Response: %s in %s", resp.Status, time.Since(start).Round(time.Millisecond)))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		// the endpoint was reached through the proxy
+		fmt.Fprintln(w, "The API key is invalid for this endpoint")
+		return nil
+	case http.StatusProxyAuthRequired:
+		return fmt.Errorf("the proxy refused the credentials")
+	default:
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package diagnose
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunProxy(t *testing.T) {
+	valid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/validate", r.URL.Path)
+		if r.Header.Get("DD-Api-Key") != "valid" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer valid.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	w := &bytes.Buffer{}
+	err := RunProxy(w, map[string][]string{valid.URL: {"valid"}})
+	assert.NoError(t, err)
+	assert.Contains(t, w.String(), "=== Checking "+valid.URL+" ===\nProxy: none\nResponse: 200 OK")
+
+	w.Reset()
+	err = RunProxy(w, map[string][]string{valid.URL: {"invalid"}, failing.URL: {"valid"}})
+	assert.EqualError(t, err, "1 endpoint(s) out of 2 could not be reached")
+	assert.Contains(t, w.String(), "The API key is invalid for this endpoint\n===> PASS")
+	assert.Contains(t, w.String(), "Error: unexpected response 502 Bad Gateway\n===> FAIL")
+}
@@ -6,9 +6,11 @@
 package diagnose
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -17,8 +19,36 @@ import (
 	"github.com/fatih/color"
 )
 
+// Report is the outcome of a diagnosis, the results are only set for the suites
+type Report struct {
+	Name    string             `json:"name"`
+	Status  diagnosis.Status   `json:"status"`
+	Error   string             `json:"error,omitempty"`
+	Results []diagnosis.Result `json:"results,omitempty"`
+}
+
 // RunAll runs all registered connectivity checks, output it in writer
 func RunAll(w io.Writer) error {
+	return runAll(w, diagnosisNames())
+}
+
+// RunLocal runs the registered diagnosis but the network suites, so that it
+// returns quickly on hosts with no egress, e.g. when building a flare
+func RunLocal(w io.Writer) error {
+	var names []string
+	for _, name := range diagnosisNames() {
+		if !diagnosis.NetworkSuites[name] {
+			names = append(names, name)
+		}
+	}
+	if err := runAll(w, names); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "The network diagnosis were skipped, run `agent diagnose` to run them")
+	return nil
+}
+
+func runAll(w io.Writer, names []string) error {
 	if w != color.Output {
 		color.NoColor = true
 	}
@@ -31,21 +61,84 @@ func RunAll(w io.Writer) error {
 	log.RegisterAdditionalLogger("diagnose", customLogger)
 	defer log.UnregisterAdditionalLogger("diagnose")
 
+	for _, name := range names {
+		fmt.Fprintln(w, fmt.Sprintf("=== Running %s diagnosis ===", color.BlueString(name)))
+		report := run(name)
+		for _, result := range report.Results {
+			fmt.Fprintln(w, fmt.Sprintf("[%s] %s: %s", colorStatus(result.Status), result.Name, result.Message))
+			if result.Remediation != "" {
+				fmt.Fprintln(w, fmt.Sprintf("    Remediation: %s", result.Remediation))
+			}
+		}
+		fmt.Fprintln(w, fmt.Sprintf("===> %s\n", colorStatus(report.Status)))
+	}
+
+	return nil
+}
+
+// RunAllJSON runs all registered diagnosis and writes their reports in JSON
+func RunAllJSON(w io.Writer) error {
+	reports := []Report{}
+	for _, name := range diagnosisNames() {
+		reports = append(reports, run(name))
+	}
+	b, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// diagnosisNames returns the sorted names of the diagnosis and of the suites
+func diagnosisNames() []string {
 	var sortedDiagnosis []string
 	for name := range diagnosis.DefaultCatalog {
 		sortedDiagnosis = append(sortedDiagnosis, name)
 	}
+	for name := range diagnosis.DefaultSuites {
+		if _, found := diagnosis.DefaultCatalog[name]; !found {
+			sortedDiagnosis = append(sortedDiagnosis, name)
+		}
+	}
 	sort.Strings(sortedDiagnosis)
+	return sortedDiagnosis
+}
 
-	for _, name := range sortedDiagnosis {
-		fmt.Fprintln(w, fmt.Sprintf("=== Running %s diagnosis ===", color.BlueString(name)))
-		err := diagnosis.DefaultCatalog[name]()
-		statusString := color.GreenString("PASS")
-		if err != nil {
-			statusString = color.RedString("FAIL")
+// run runs a diagnosis, a suite fails if any of its checks fails
+func run(name string) Report {
+	report := Report{Name: name, Status: diagnosis.StatusPass}
+
+	if d, found := diagnosis.DefaultCatalog[name]; found {
+		if err := d(); err != nil {
+			report.Status = diagnosis.StatusFail
+			report.Error = err.Error()
 		}
-		fmt.Fprintln(w, fmt.Sprintf("===> %s\n", statusString))
+		return report
 	}
 
-	return nil
+	report.Results = diagnosis.DefaultSuites[name]()
+	for _, result := range report.Results {
+		switch result.Status {
+		case diagnosis.StatusFail:
+			report.Status = diagnosis.StatusFail
+		case diagnosis.StatusWarn:
+			if report.Status == diagnosis.StatusPass {
+				report.Status = diagnosis.StatusWarn
+			}
+		}
+	}
+	return report
+}
+
+func colorStatus(status diagnosis.Status) string {
+	s := strings.ToUpper(string(status))
+	switch status {
+	case diagnosis.StatusPass:
+		return color.GreenString(s)
+	case diagnosis.StatusWarn:
+		return color.YellowString(s)
+	default:
+		return color.RedString(s)
+	}
 }
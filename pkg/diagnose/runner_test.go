@@ -7,15 +7,25 @@ package diagnose
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// withSuites replaces the registered suites, so that the tests don't reach the network
+func withSuites(suites map[string]diagnosis.Suite) func() {
+	registered := diagnosis.DefaultSuites
+	diagnosis.DefaultSuites = suites
+	return func() { diagnosis.DefaultSuites = registered }
+}
+
 func TestRunAll(t *testing.T) {
+	defer withSuites(map[string]diagnosis.Suite{})()
 
 	diagnosis.Register("failing", func() error { return errors.New("fail") })
 	diagnosis.Register("succeeding", func() error { return nil })
@@ -27,3 +37,75 @@ func TestRunAll(t *testing.T) {
 	assert.Contains(t, result, "=== Running failing diagnosis ===\n===> FAIL")
 	assert.Contains(t, result, "=== Running succeeding diagnosis ===\n===> PASS")
 }
+
+func TestRunAllSuites(t *testing.T) {
+	defer withSuites(map[string]diagnosis.Suite{
+		"warning suite": func() []diagnosis.Result {
+			return []diagnosis.Result{
+				{Name: "first", Status: diagnosis.StatusPass, Message: "ok"},
+				{Name: "second", Status: diagnosis.StatusWarn, Message: "degraded", Remediation: "fix it"},
+			}
+		},
+		"failing suite": func() []diagnosis.Result {
+			return []diagnosis.Result{
+				{Name: "first", Status: diagnosis.StatusWarn, Message: "degraded"},
+				{Name: "second", Status: diagnosis.StatusFail, Message: "broken"},
+			}
+		},
+	})()
+
+	w := &bytes.Buffer{}
+	RunAll(w)
+
+	result := w.String()
+	assert.Contains(t, result, "=== Running warning suite diagnosis ===\n[PASS] first: ok\n[WARN] second: degraded\n    Remediation: fix it\n===> WARN")
+	assert.Contains(t, result, "=== Running failing suite diagnosis ===\n[WARN] first: degraded\n[FAIL] second: broken\n===> FAIL")
+}
+
+func TestRunLocal(t *testing.T) {
+	defer withSuites(map[string]diagnosis.Suite{})()
+	registered := diagnosis.NetworkSuites
+	diagnosis.NetworkSuites = map[string]bool{}
+	defer func() { diagnosis.NetworkSuites = registered }()
+
+	diagnosis.RegisterSuite("local suite", func() []diagnosis.Result {
+		return []diagnosis.Result{{Name: "check", Status: diagnosis.StatusPass, Message: "ok"}}
+	})
+	diagnosis.RegisterNetworkSuite("network suite", func() []diagnosis.Result {
+		t.Error("the network suite shouldn't run")
+		return nil
+	})
+
+	w := &bytes.Buffer{}
+	require.NoError(t, RunLocal(w))
+
+	result := w.String()
+	assert.Contains(t, result, "=== Running local suite diagnosis ===\n[PASS] check: ok\n===> PASS")
+	assert.NotContains(t, result, "network suite")
+	assert.Contains(t, result, "The network diagnosis were skipped")
+}
+
+func TestRunAllJSON(t *testing.T) {
+	defer withSuites(map[string]diagnosis.Suite{
+		"passing suite": func() []diagnosis.Result {
+			return []diagnosis.Result{{Name: "check", Status: diagnosis.StatusPass}}
+		},
+	})()
+	diagnosis.Register("json failing", func() error { return errors.New("fail") })
+
+	w := &bytes.Buffer{}
+	require.NoError(t, RunAllJSON(w))
+
+	var reports []Report
+	require.NoError(t, json.Unmarshal(w.Bytes(), &reports))
+	byName := make(map[string]Report)
+	for _, report := range reports {
+		byName[report.Name] = report
+	}
+	assert.Equal(t, Report{Name: "json failing", Status: diagnosis.StatusFail, Error: "fail"}, byName["json failing"])
+	assert.Equal(t, Report{
+		Name:    "passing suite",
+		Status:  diagnosis.StatusPass,
+		Results: []diagnosis.Result{{Name: "check", Status: diagnosis.StatusPass}},
+	}, byName["passing suite"])
+}
@@ -2,20 +2,24 @@ package dogstatsd
 
 import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
 // batcher batches multiple metrics before submission
 // this struct is not safe for concurrent use
 type batcher struct {
-	samples      []metrics.MetricSample
-	samplesCount int
+	// samples holds one batch per aggregator pipeline, the samples
+	// are sharded by context, see aggregator.PipelineIndex
+	samples      [][]metrics.MetricSample
+	samplesCount []int
+	keyGenerator *ckey.KeyGenerator
 
 	events        []*metrics.Event
 	serviceChecks []*metrics.ServiceCheck
 
 	// output channels
-	choutSamples       chan<- []metrics.MetricSample
+	choutSamples       []chan []metrics.MetricSample
 	choutEvents        chan<- []*metrics.Event
 	choutServiceChecks chan<- []*metrics.ServiceCheck
 
@@ -23,22 +27,32 @@ type batcher struct {
 }
 
 func newBatcher(agg *aggregator.BufferedAggregator) *batcher {
-	s, e, sc := agg.GetBufferedChannels()
+	_, e, sc := agg.GetBufferedChannels()
+	pipelines := agg.GetBufferedMetricsPipelines()
+
+	samples := make([][]metrics.MetricSample, len(pipelines))
+	for i := range samples {
+		samples[i] = agg.MetricSamplePool.GetBatch()
+	}
+
 	return &batcher{
-		samples:            agg.MetricSamplePool.GetBatch(),
+		samples:            samples,
+		samplesCount:       make([]int, len(pipelines)),
+		keyGenerator:       ckey.NewKeyGenerator(),
 		metricSamplePool:   agg.MetricSamplePool,
-		choutSamples:       s,
+		choutSamples:       pipelines,
 		choutEvents:        e,
 		choutServiceChecks: sc,
 	}
 }
 
 func (b *batcher) appendSample(sample metrics.MetricSample) {
-	if b.samplesCount == len(b.samples) {
-		b.flushSamples()
+	idx := aggregator.PipelineIndex(b.keyGenerator, &sample, len(b.samples))
+	if b.samplesCount[idx] == len(b.samples[idx]) {
+		b.flushSamples(idx)
 	}
-	b.samples[b.samplesCount] = sample
-	b.samplesCount++
+	b.samples[idx][b.samplesCount[idx]] = sample
+	b.samplesCount[idx]++
 }
 
 func (b *batcher) appendEvent(event *metrics.Event) {
@@ -49,16 +63,18 @@ func (b *batcher) appendServiceCheck(serviceCheck *metrics.ServiceCheck) {
 	b.serviceChecks = append(b.serviceChecks, serviceCheck)
 }
 
-func (b *batcher) flushSamples() {
-	if b.samplesCount > 0 {
-		b.choutSamples <- b.samples[:b.samplesCount]
-		b.samplesCount = 0
-		b.samples = b.metricSamplePool.GetBatch()
+func (b *batcher) flushSamples(idx int) {
+	if b.samplesCount[idx] > 0 {
+		b.choutSamples[idx] <- b.samples[idx][:b.samplesCount[idx]]
+		b.samplesCount[idx] = 0
+		b.samples[idx] = b.metricSamplePool.GetBatch()
 	}
 }
 
 func (b *batcher) flush() {
-	b.flushSamples()
+	for idx := range b.samples {
+		b.flushSamples(idx)
+	}
 	if len(b.events) > 0 {
 		b.choutEvents <- b.events
 		b.events = []*metrics.Event{}
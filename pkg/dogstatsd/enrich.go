@@ -6,6 +6,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -16,12 +18,39 @@ var (
 	hostTagPrefix       = "host:"
 	entityIDTagPrefix   = "dd.internal.entity_id:"
 	entityIDIgnoreValue = "none"
+	// cardinalityTagPrefix allows the clients to override dogstatsd_tag_cardinality
+	cardinalityTagPrefix = "dd.internal.card:"
 
-	getTags tagRetriever = tagger.Tag
+	getTags       tagRetriever = tagger.Tag
+	isKnownEntity              = tagger.IsKnownEntity
+
+	tlmUnknownContainerID = telemetry.NewCounter("dogstatsd", "unknown_container_id",
+		nil, "Count of messages received with a container ID unknown to the tagger")
 )
 
-func enrichTags(tags []string, defaultHostname string, originTagsFunc func() []string, entityIDPrecedenceEnabled bool) ([]string, string) {
+// containerIDOriginTags returns a function retrieving the tags of the container
+// ID sent by the client in the `c:` field. If the container is unknown to the
+// tagger, it falls back on the origin detected by the listener (UDS), if any.
+// A known container without tags does not fall back.
+func containerIDOriginTags(containerID string, fallback func(collectors.TagCardinality) []string) func(collectors.TagCardinality) []string {
+	if containerID == "" {
+		return fallback
+	}
+	return func(cardinality collectors.TagCardinality) []string {
+		entity := containers.BuildTaggerEntityName(containerID)
+		tags, err := getTags(entity, cardinality)
+		if err != nil || (len(tags) == 0 && !isKnownEntity(entity)) {
+			log.Tracef("Cannot get tags for container %s sent by the client, falling back to the socket origin: %v", containerID, err)
+			tlmUnknownContainerID.Inc()
+			return fallback(cardinality)
+		}
+		return tags
+	}
+}
+
+func enrichTags(tags []string, defaultHostname string, originTagsFunc func(collectors.TagCardinality) []string, entityIDPrecedenceEnabled bool) ([]string, string) {
 	host := defaultHostname
+	cardinality := tagger.DogstatsdCardinality
 
 	n := 0
 	entityIDValue := ""
@@ -30,6 +59,12 @@ func enrichTags(tags []string, defaultHostname string, originTagsFunc func() []s
 			host = tag[len(hostTagPrefix):]
 		} else if strings.HasPrefix(tag, entityIDTagPrefix) {
 			entityIDValue = tag[len(entityIDTagPrefix):]
+		} else if strings.HasPrefix(tag, cardinalityTagPrefix) {
+			if card, err := tagger.ParseCardinality(tag[len(cardinalityTagPrefix):]); err == nil {
+				cardinality = card
+			} else {
+				log.Tracef("Ignoring the cardinality requested by the client: %s", err)
+			}
 		} else {
 			tags[n] = tag
 			n++
@@ -38,7 +73,7 @@ func enrichTags(tags []string, defaultHostname string, originTagsFunc func() []s
 	tags = tags[:n]
 	if entityIDValue == "" || !entityIDPrecedenceEnabled {
 		// Add origin tags only if the entity id tags is not provided
-		tags = append(tags, originTagsFunc()...)
+		tags = append(tags, originTagsFunc(cardinality)...)
 	}
 	if entityIDValue != "" && entityIDValue != entityIDIgnoreValue {
 		// Check if the value is not "none" in order to avoid calling
@@ -46,7 +81,7 @@ func enrichTags(tags []string, defaultHostname string, originTagsFunc func() []s
 
 		// currently only supported for pods
 		entity := kubelet.KubePodTaggerEntityPrefix + entityIDValue
-		entityTags, err := getTags(entity, tagger.DogstatsdCardinality)
+		entityTags, err := getTags(entity, cardinality)
 		if err != nil {
 			log.Tracef("Cannot get tags for entity %s: %s", entity, err)
 		} else {
@@ -84,7 +119,7 @@ func isBlacklisted(metricName, namespace string, namespaceBlacklist []string) bo
 	return false
 }
 
-func enrichMetricSample(metricSample dogstatsdMetricSample, namespace string, namespaceBlacklist []string, defaultHostname string, originTagsFunc func() []string, entityIDPrecedenceEnabled bool) metrics.MetricSample {
+func enrichMetricSample(metricSample dogstatsdMetricSample, namespace string, namespaceBlacklist []string, defaultHostname string, originTagsFunc func(collectors.TagCardinality) []string, entityIDPrecedenceEnabled bool) metrics.MetricSample {
 	metricName := metricSample.name
 	tags, hostname := enrichTags(metricSample.tags, defaultHostname, originTagsFunc, entityIDPrecedenceEnabled)
 
@@ -127,7 +162,7 @@ func enrichEventAlertType(dogstatsdAlertType alertType) metrics.EventAlertType {
 	return metrics.EventAlertTypeSuccess
 }
 
-func enrichEvent(event dogstatsdEvent, defaultHostname string, originTagsFunc func() []string, entityIDPrecedenceEnabled bool) *metrics.Event {
+func enrichEvent(event dogstatsdEvent, defaultHostname string, originTagsFunc func(collectors.TagCardinality) []string, entityIDPrecedenceEnabled bool) *metrics.Event {
 	tags, hostFromTags := enrichTags(event.tags, defaultHostname, originTagsFunc, entityIDPrecedenceEnabled)
 
 	enrichedEvent := &metrics.Event{
@@ -163,7 +198,7 @@ func enrichServiceCheckStatus(status serviceCheckStatus) metrics.ServiceCheckSta
 	return metrics.ServiceCheckUnknown
 }
 
-func enrichServiceCheck(serviceCheck dogstatsdServiceCheck, defaultHostname string, originTagsFunc func() []string, entityIDPrecedenceEnabled bool) *metrics.ServiceCheck {
+func enrichServiceCheck(serviceCheck dogstatsdServiceCheck, defaultHostname string, originTagsFunc func(collectors.TagCardinality) []string, entityIDPrecedenceEnabled bool) *metrics.ServiceCheck {
 	tags, hostFromTags := enrichTags(serviceCheck.tags, defaultHostname, originTagsFunc, entityIDPrecedenceEnabled)
 
 	enrichedServiceCheck := &metrics.ServiceCheck{
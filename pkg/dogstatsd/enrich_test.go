@@ -7,12 +7,13 @@ import (
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func returnEmptyTags() []string {
+func returnEmptyTags(collectors.TagCardinality) []string {
 	return []string{}
 }
 
@@ -780,13 +781,58 @@ func TestConvertEntityOriginDetectionTagsError(t *testing.T) {
 	assert.InEpsilon(t, 1.0, parsed.SampleRate, epsilon)
 }
 
+func TestConvertContainerIDOriginTags(t *testing.T) {
+	getTags = func(entity string, cardinality collectors.TagCardinality) ([]string, error) {
+		switch entity {
+		case "container_id://1234abcd":
+			return []string{"image_name:foo"}, nil
+		case "container_id://notags", "container_id://unknown":
+			return nil, nil
+		}
+		return nil, errors.New("unknown entity")
+	}
+	isKnownEntity = func(entity string) bool { return entity == "container_id://notags" }
+	defer func() { isKnownEntity = tagger.IsKnownEntity }()
+	socketOriginTags := func(collectors.TagCardinality) []string { return []string{"from:socket"} }
+
+	parser := newParser()
+	parsed, err := parser.parseMetricSample([]byte("daemon:666|g|#sometag:somevalue|c:1234abcd"))
+	require.NoError(t, err)
+	sample := enrichMetricSample(parsed, "", nil, "default-hostname", containerIDOriginTags(parsed.containerID, socketOriginTags), true)
+	assert.ElementsMatch(t, []string{"sometag:somevalue", "image_name:foo"}, sample.Tags)
+
+	// unknown container ID: fallback on the socket origin
+	parsed, err = parser.parseMetricSample([]byte("daemon:666|g|#sometag:somevalue|c:unknown"))
+	require.NoError(t, err)
+	sample = enrichMetricSample(parsed, "", nil, "default-hostname", containerIDOriginTags(parsed.containerID, socketOriginTags), true)
+	assert.ElementsMatch(t, []string{"sometag:somevalue", "from:socket"}, sample.Tags)
+
+	// known container ID without tags: no fallback
+	parsed, err = parser.parseMetricSample([]byte("daemon:666|g|#sometag:somevalue|c:notags"))
+	require.NoError(t, err)
+	sample = enrichMetricSample(parsed, "", nil, "default-hostname", containerIDOriginTags(parsed.containerID, socketOriginTags), true)
+	assert.ElementsMatch(t, []string{"sometag:somevalue"}, sample.Tags)
+
+	// tagger error: fallback on the socket origin
+	parsed, err = parser.parseMetricSample([]byte("daemon:666|g|#sometag:somevalue|c:error"))
+	require.NoError(t, err)
+	sample = enrichMetricSample(parsed, "", nil, "default-hostname", containerIDOriginTags(parsed.containerID, socketOriginTags), true)
+	assert.ElementsMatch(t, []string{"sometag:somevalue", "from:socket"}, sample.Tags)
+
+	// no container ID field
+	parsed, err = parser.parseMetricSample([]byte("daemon:666|g|#sometag:somevalue"))
+	require.NoError(t, err)
+	sample = enrichMetricSample(parsed, "", nil, "default-hostname", containerIDOriginTags(parsed.containerID, socketOriginTags), true)
+	assert.ElementsMatch(t, []string{"sometag:somevalue", "from:socket"}, sample.Tags)
+}
+
 func Test_enrichTags(t *testing.T) {
-	emptyTagsList := func() []string { return []string{} }
+	emptyTagsList := func(collectors.TagCardinality) []string { return []string{} }
 
 	type args struct {
 		tags                       []string
 		defaultHostname            string
-		originTagsFunc             func() []string
+		originTagsFunc             func(collectors.TagCardinality) []string
 		entityIDPrecendenceEnabled bool
 	}
 	tests := []struct {
@@ -810,7 +856,7 @@ func Test_enrichTags(t *testing.T) {
 			args: args{
 				tags:                       []string{"env:prod"},
 				defaultHostname:            "foo",
-				originTagsFunc:             func() []string { return []string{"mytag:bar"} },
+				originTagsFunc:             func(collectors.TagCardinality) []string { return []string{"mytag:bar"} },
 				entityIDPrecendenceEnabled: true,
 			},
 			want:  []string{"env:prod", "mytag:bar"},
@@ -821,7 +867,7 @@ func Test_enrichTags(t *testing.T) {
 			args: args{
 				tags:                       nil,
 				defaultHostname:            "foo",
-				originTagsFunc:             func() []string { return []string{"mytag:bar"} },
+				originTagsFunc:             func(collectors.TagCardinality) []string { return []string{"mytag:bar"} },
 				entityIDPrecendenceEnabled: true,
 			},
 			want:  []string{"mytag:bar"},
@@ -832,7 +878,7 @@ func Test_enrichTags(t *testing.T) {
 			args: args{
 				tags:                       []string{"env:prod", fmt.Sprintf("%s%s", entityIDTagPrefix, "my-id")},
 				defaultHostname:            "foo",
-				originTagsFunc:             func() []string { return []string{"mytag:bar"} },
+				originTagsFunc:             func(collectors.TagCardinality) []string { return []string{"mytag:bar"} },
 				entityIDPrecendenceEnabled: true,
 			},
 			want:  []string{"env:prod"},
@@ -843,7 +889,7 @@ func Test_enrichTags(t *testing.T) {
 			args: args{
 				tags:                       []string{"env:prod", fmt.Sprintf("%s%s", entityIDTagPrefix, "none")},
 				defaultHostname:            "foo",
-				originTagsFunc:             func() []string { panic("oups") },
+				originTagsFunc:             func(collectors.TagCardinality) []string { panic("oups") },
 				entityIDPrecendenceEnabled: true,
 			},
 			want:  []string{"env:prod"},
@@ -854,12 +900,36 @@ func Test_enrichTags(t *testing.T) {
 			args: args{
 				tags:                       []string{"env:prod", fmt.Sprintf("%s%s", entityIDTagPrefix, "42")},
 				defaultHostname:            "foo",
-				originTagsFunc:             func() []string { return []string{"cluster:bar"} },
+				originTagsFunc:             func(collectors.TagCardinality) []string { return []string{"cluster:bar"} },
 				entityIDPrecendenceEnabled: false,
 			},
 			want:  []string{"env:prod", "cluster:bar"},
 			want1: "foo",
 		},
+		{
+			name: "cardinality requested by the client, should call the originTagsFunc() with it",
+			args: args{
+				tags:            []string{"env:prod", cardinalityTagPrefix + "high"},
+				defaultHostname: "foo",
+				originTagsFunc: func(cardinality collectors.TagCardinality) []string {
+					return []string{"cardinality:" + cardinality.String()}
+				},
+			},
+			want:  []string{"env:prod", "cardinality:high"},
+			want1: "foo",
+		},
+		{
+			name: "invalid cardinality requested by the client, should use the default",
+			args: args{
+				tags:            []string{"env:prod", cardinalityTagPrefix + "unknown"},
+				defaultHostname: "foo",
+				originTagsFunc: func(cardinality collectors.TagCardinality) []string {
+					return []string{"cardinality:" + cardinality.String()}
+				},
+			},
+			want:  []string{"env:prod", "cardinality:low"},
+			want1: "foo",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -22,17 +23,28 @@ var (
 	udpPacketReadingErrors = expvar.Int{}
 	udpPackets             = expvar.Int{}
 	udpBytes               = expvar.Int{}
+	udpKernelDrops         = expvar.Int{}
+	udpRejectedPackets     = expvar.Int{}
 
 	tlmUDPPackets = telemetry.NewCounter("dogstatsd", "udp_packets",
 		[]string{"state"}, "Dogstatsd UDP packets count")
 	tlmUDPPacketsBytes = telemetry.NewCounter("dogstatsd", "udp_packets_bytes",
 		nil, "Dogstatsd UDP packets bytes count")
+	tlmUDPRejectedPackets = telemetry.NewCounter("dogstatsd", "udp_rejected_packets",
+		nil, "Dogstatsd UDP packets rejected because their source is not allowed")
+	tlmUDPKernelDrops = telemetry.NewGauge("dogstatsd", "udp_kernel_drops",
+		nil, "Dogstatsd UDP packets dropped by the kernel, as reported by the socket stats")
 )
 
+// kernelDropsRefreshInterval is the interval between two reads of the kernel socket stats.
+const kernelDropsRefreshInterval = 10 * time.Second
+
 func init() {
 	udpExpvars.Set("PacketReadingErrors", &udpPacketReadingErrors)
 	udpExpvars.Set("Packets", &udpPackets)
 	udpExpvars.Set("Bytes", &udpBytes)
+	udpExpvars.Set("KernelDrops", &udpKernelDrops)
+	udpExpvars.Set("RejectedPackets", &udpRejectedPackets)
 }
 
 // UDPListener implements the StatsdListener interface for UDP protocol.
@@ -44,6 +56,18 @@ type UDPListener struct {
 	packetsBuffer   *packetsBuffer
 	packetAssembler *packetAssembler
 	buffer          []byte
+	// batchSize is the maximum number of datagrams read with a single syscall
+	// when batch reading is supported by the platform (recvmmsg on Linux).
+	batchSize int
+	// packetOut and sharedPacketPool are directly used in batch mode: each
+	// datagram is read into a pooled packet and batches are handed off to the
+	// workers without going through the packet assembler.
+	packetOut        chan Packets
+	sharedPacketPool *PacketPool
+	stopChan         chan struct{}
+	// sourceFilter rejects the datagrams not sent by an allowed source, nil when
+	// every source is allowed.
+	sourceFilter *sourceFilter
 }
 
 // NewUDPListener returns an idle UDP Statsd listener
@@ -58,6 +82,14 @@ func NewUDPListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDPL
 		url = net.JoinHostPort(config.Datadog.GetString("bind_host"), config.Datadog.GetString("dogstatsd_port"))
 	}
 
+	sourceFilter, err := newSourceFilter(
+		config.Datadog.GetStringSlice("dogstatsd_udp_allowed_sources"),
+		config.Datadog.GetBool("dogstatsd_udp_log_rejected_sources"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dogstatsd_udp_allowed_sources: %s", err)
+	}
+
 	addr, err := net.ResolveUDPAddr("udp", url)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve udp addr: %s", err)
@@ -83,10 +115,15 @@ func NewUDPListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDPL
 	packetAssembler := newPacketAssembler(flushTimeout, packetsBuffer, sharedPacketPool)
 
 	listener := &UDPListener{
-		conn:            conn,
-		packetsBuffer:   packetsBuffer,
-		packetAssembler: packetAssembler,
-		buffer:          buffer,
+		conn:             conn,
+		packetsBuffer:    packetsBuffer,
+		packetAssembler:  packetAssembler,
+		buffer:           buffer,
+		batchSize:        config.Datadog.GetInt("dogstatsd_udp_batch_size"),
+		packetOut:        packetOut,
+		sharedPacketPool: sharedPacketPool,
+		stopChan:         make(chan struct{}),
+		sourceFilter:     sourceFilter,
 	}
 	log.Debugf("dogstatsd-udp: %s successfully initialized", conn.LocalAddr())
 	return listener, nil
@@ -95,9 +132,16 @@ func NewUDPListener(packetOut chan Packets, sharedPacketPool *PacketPool) (*UDPL
 // Listen runs the intake loop. Should be called in its own goroutine
 func (l *UDPListener) Listen() {
 	log.Infof("dogstatsd-udp: starting to listen on %s", l.conn.LocalAddr())
+	go l.monitorKernelDrops()
+
+	if l.batchSize > 1 && batchReadSupported {
+		l.listenBatch()
+		return
+	}
+
 	for {
 		udpPackets.Add(1)
-		n, _, err := l.conn.ReadFrom(l.buffer)
+		n, addr, err := l.conn.ReadFrom(l.buffer)
 		if err != nil {
 			// connection has been closed
 			if strings.HasSuffix(err.Error(), " use of closed network connection") {
@@ -109,6 +153,11 @@ func (l *UDPListener) Listen() {
 			tlmUDPPackets.Inc("error")
 			continue
 		}
+		if !l.sourceFilter.allows(addr) {
+			udpRejectedPackets.Add(1)
+			tlmUDPRejectedPackets.Inc()
+			continue
+		}
 		tlmUDPPackets.Inc("ok")
 
 		udpBytes.Add(int64(n))
@@ -119,8 +168,30 @@ func (l *UDPListener) Listen() {
 	}
 }
 
+// monitorKernelDrops periodically reports the number of datagrams dropped
+// by the kernel for this socket, e.g. because the receive buffer was full.
+func (l *UDPListener) monitorKernelDrops() {
+	ticker := time.NewTicker(kernelDropsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			drops, err := getUDPKernelDrops(l.conn)
+			if err != nil {
+				log.Debugf("dogstatsd-udp: can't read the kernel socket stats: %v", err)
+				return
+			}
+			udpKernelDrops.Set(int64(drops))
+			tlmUDPKernelDrops.Set(float64(drops))
+		case <-l.stopChan:
+			return
+		}
+	}
+}
+
 // Stop closes the UDP connection and stops listening
 func (l *UDPListener) Stop() {
+	close(l.stopChan)
 	l.packetAssembler.close()
 	l.packetsBuffer.close()
 	l.conn.Close()
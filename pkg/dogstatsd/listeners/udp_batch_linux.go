@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// batchReadSupported is true on Linux where recvmmsg is available
+const batchReadSupported = true
+
+// listenBatch reads up to batchSize datagrams per recvmmsg syscall, each one
+// into its own pooled packet, and hands off the batch to the workers.
+func (l *UDPListener) listenBatch() {
+	conn := ipv4.NewPacketConn(l.conn)
+	messages := make([]ipv4.Message, l.batchSize)
+	packets := make(Packets, l.batchSize)
+	for i := range messages {
+		packets[i] = l.sharedPacketPool.Get()
+		messages[i].Buffers = [][]byte{packets[i].buffer}
+	}
+
+	for {
+		n, err := conn.ReadBatch(messages, 0)
+		if err != nil {
+			// connection has been closed
+			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				for _, packet := range packets {
+					l.sharedPacketPool.Put(packet)
+				}
+				return
+			}
+
+			log.Errorf("dogstatsd-udp: error reading packets: %v", err)
+			udpPacketReadingErrors.Add(1)
+			tlmUDPPackets.Inc("error")
+			continue
+		}
+
+		batch := make(Packets, 0, n)
+		for i := 0; i < n; i++ {
+			if !l.sourceFilter.allows(messages[i].Addr) {
+				udpPackets.Add(1)
+				udpRejectedPackets.Add(1)
+				tlmUDPRejectedPackets.Inc()
+				// the packet is not handed off, its buffer is reused for the next read
+				continue
+			}
+			if messages[i].Flags&unix.MSG_TRUNC != 0 {
+				// the datagram did not fit in the buffer, its metrics can't be parsed
+				log.Debugf("dogstatsd-udp: dropping a datagram larger than dogstatsd_buffer_size")
+				udpPackets.Add(1)
+				udpPacketReadingErrors.Add(1)
+				tlmUDPPackets.Inc("error")
+				continue
+			}
+
+			packet := packets[i]
+			packet.Contents = packet.buffer[:messages[i].N]
+			batch = append(batch, packet)
+
+			udpPackets.Add(1)
+			tlmUDPPackets.Inc("ok")
+			udpBytes.Add(int64(messages[i].N))
+			tlmUDPPacketsBytes.Add(float64(messages[i].N))
+
+			// the packet now belongs to the workers which will put it
+			// back in the pool once processed, replace it with a fresh one.
+			packets[i] = l.sharedPacketPool.Get()
+			messages[i].Buffers[0] = packets[i].buffer
+		}
+		if len(batch) > 0 {
+			l.packetOut <- batch
+		}
+	}
+}
+
+// getUDPKernelDrops returns the number of datagrams dropped by the kernel for
+// the socket of the given connection, as reported in /proc/net/udp{,6}. The
+// socket is matched by its inode and local address, as other sockets may be
+// bound to the same port, e.g. on other addresses or with SO_REUSEPORT.
+func getUDPKernelDrops(conn *net.UDPConn) (uint64, error) {
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return 0, fmt.Errorf("unexpected local address type %T", conn.LocalAddr())
+	}
+	inode, err := getSocketInode(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, path := range []string{"/proc/net/udp", "/proc/net/udp6"} {
+		drops, found, err := readUDPKernelDrops(path, addr, inode)
+		if err != nil {
+			continue
+		}
+		if found {
+			return drops, nil
+		}
+	}
+	return 0, fmt.Errorf("no socket found for local address %s and inode %d", addr, inode)
+}
+
+// getSocketInode returns the inode of the socket of the given connection
+func getSocketInode(conn *net.UDPConn) (uint64, error) {
+	rawconn, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var stat unix.Stat_t
+	var statErr error
+	err = rawconn.Control(func(fd uintptr) {
+		statErr = unix.Fstat(int(fd), &stat)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if statErr != nil {
+		return 0, statErr
+	}
+	return stat.Ino, nil
+}
+
+// readUDPKernelDrops returns the drops column of the socket with the given
+// local address and inode in a /proc/net/udp formatted file.
+func readUDPKernelDrops(path string, addr *net.UDPAddr, inode uint64) (uint64, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+		if ino, err := strconv.ParseUint(fields[9], 10, 64); err != nil || ino != inode {
+			continue
+		}
+		ip, port, err := parseProcNetUDPAddress(fields[1])
+		if err != nil || port != addr.Port || !sameIP(ip, addr.IP) {
+			continue
+		}
+		drops, err := strconv.ParseUint(fields[len(fields)-1], 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return drops, true, nil
+	}
+	return 0, false, scanner.Err()
+}
+
+// parseProcNetUDPAddress parses an IP:port address of /proc/net/udp{,6}, where
+// the IP is written as hexadecimal 32-bit words in host byte order.
+func parseProcNetUDPAddress(raw string) (net.IP, int, error) {
+	idx := strings.IndexByte(raw, ':')
+	if idx == -1 {
+		return nil, 0, fmt.Errorf("missing port in %q", raw)
+	}
+	port, err := strconv.ParseUint(raw[idx+1:], 16, 16)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	words, err := hex.DecodeString(raw[:idx])
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(words) != net.IPv4len && len(words) != net.IPv6len {
+		return nil, 0, fmt.Errorf("invalid address length %d in %q", len(words), raw)
+	}
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		// the agent only runs on little-endian hosts
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(words[i:]))
+	}
+	return ip, int(port), nil
+}
+
+// sameIP returns true if both IPs are equal, the unspecified addresses of
+// both families being equal as the wildcard sockets are dual-stack.
+func sameIP(a, b net.IP) bool {
+	if a.IsUnspecified() && (b == nil || b.IsUnspecified()) {
+		return true
+	}
+	return a.Equal(b)
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package listeners
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestUDPBatchReceive(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.SetDefault("dogstatsd_udp_batch_size", 8)
+	defer config.Datadog.SetDefault("dogstatsd_udp_batch_size", 32)
+
+	packetChannel := make(chan Packets, 16)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP)
+	require.NotNil(t, s)
+	require.Nil(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	messages := []string{"first:1|c", "second:2|c", "third:3|c"}
+	for _, m := range messages {
+		conn.Write([]byte(m))
+	}
+
+	var received []string
+	timeout := time.After(2 * time.Second)
+	for len(received) < len(messages) {
+		select {
+		case packets := <-packetChannel:
+			for _, packet := range packets {
+				received = append(received, string(packet.Contents))
+			}
+		case <-timeout:
+			require.FailNow(t, "Timeout on receive channel")
+		}
+	}
+	assert.Equal(t, messages, received)
+}
+
+func TestReadUDPKernelDrops(t *testing.T) {
+	dir, err := ioutil.TempDir("", "udp-drops")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+ 1234: 0100007F:1FBD 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 31337 2 0000000000000000 42
+ 1235: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 31338 2 0000000000000000 7
+ 1236: 0200007F:1FBD 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 31339 2 0000000000000000 3
+`
+	path := filepath.Join(dir, "udp")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	drops, found, err := readUDPKernelDrops(path, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8125}, 31337)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint64(42), drops)
+
+	// another socket bound to the same port
+	drops, found, err = readUDPKernelDrops(path, &net.UDPAddr{IP: net.ParseIP("127.0.0.2"), Port: 8125}, 31339)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint64(3), drops)
+
+	// the wildcard address of the other family
+	drops, found, err = readUDPKernelDrops(path, &net.UDPAddr{IP: net.IPv6unspecified, Port: 53}, 31338)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint64(7), drops)
+
+	// the address does not match the inode
+	_, found, err = readUDPKernelDrops(path, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8125}, 31339)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, found, err = readUDPKernelDrops(path, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8126}, 31337)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestParseProcNetUDPAddress(t *testing.T) {
+	ip, port, err := parseProcNetUDPAddress("0100007F:1FBD")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", ip.String())
+	assert.Equal(t, 8125, port)
+
+	ip, port, err = parseProcNetUDPAddress("00000000000000000000000001000000:1FBD")
+	require.NoError(t, err)
+	assert.Equal(t, "::1", ip.String())
+	assert.Equal(t, 8125, port)
+
+	_, _, err = parseProcNetUDPAddress("0100007F")
+	assert.Error(t, err)
+}
+
+func TestGetUDPKernelDrops(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	drops, err := getUDPKernelDrops(conn)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), drops)
+}
+
+func TestUDPBatchDropsTruncatedDatagrams(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.Nil(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.SetDefault("dogstatsd_udp_batch_size", 8)
+	defer config.Datadog.SetDefault("dogstatsd_udp_batch_size", 32)
+
+	packetChannel := make(chan Packets, 16)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP)
+	require.NotNil(t, s)
+	require.Nil(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	conn.Write(make([]byte, len(s.buffer)+1))
+	conn.Write([]byte("last:1|c"))
+
+	select {
+	case packets := <-packetChannel:
+		require.Len(t, packets, 1)
+		assert.Equal(t, "last:1|c", string(packets[0].Contents))
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "Timeout on receive channel")
+	}
+}
@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package listeners
+
+import (
+	"net"
+)
+
+// batchReadSupported is false on non-linux hosts, datagrams are read one by one
+const batchReadSupported = false
+
+// listenBatch is never called on non-linux hosts
+func (l *UDPListener) listenBatch() {}
+
+// getUDPKernelDrops returns a "not implemented" error on non-linux hosts
+func getUDPKernelDrops(conn *net.UDPConn) (uint64, error) {
+	return 0, ErrLinuxOnly
+}
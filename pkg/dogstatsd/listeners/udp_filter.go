@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxLoggedRejectedSources bounds the number of rejected sources remembered
+// to only log them the first time they are seen.
+const maxLoggedRejectedSources = 1024
+
+// sourceFilter restricts the source addresses allowed to send datagrams
+// to the UDP listener. A nil sourceFilter allows every source.
+type sourceFilter struct {
+	networks []*net.IPNet
+	// logRejected enables logging the rejected sources the first time they are seen
+	logRejected bool
+	seen        map[string]struct{}
+	m           sync.Mutex
+}
+
+// newSourceFilter parses the allowed sources, IPs or CIDRs. It returns nil
+// when no source is configured, meaning every source is allowed.
+func newSourceFilter(allowedSources []string, logRejected bool) (*sourceFilter, error) {
+	if len(allowedSources) == 0 {
+		return nil, nil
+	}
+
+	f := &sourceFilter{
+		logRejected: logRejected,
+		seen:        make(map[string]struct{}),
+	}
+	for _, source := range allowedSources {
+		source = strings.TrimSpace(source)
+		if !strings.Contains(source, "/") {
+			ip := net.ParseIP(source)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid allowed source %q: not an IP or a CIDR", source)
+			}
+			if ip.To4() != nil {
+				source += "/32"
+			} else {
+				source += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed source %q: %s", source, err)
+		}
+		f.networks = append(f.networks, network)
+	}
+	return f, nil
+}
+
+// allows returns whether the datagrams sent by the given address are accepted.
+func (f *sourceFilter) allows(addr net.Addr) bool {
+	if f == nil {
+		return true
+	}
+
+	var ip net.IP
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		ip = udpAddr.IP
+	}
+	if ip != nil {
+		for _, network := range f.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	if f.logRejected {
+		f.logFirstRejection(ip)
+	}
+	return false
+}
+
+func (f *sourceFilter) logFirstRejection(ip net.IP) {
+	key := ip.String()
+	f.m.Lock()
+	defer f.m.Unlock()
+	if _, found := f.seen[key]; found || len(f.seen) >= maxLoggedRejectedSources {
+		return
+	}
+	f.seen[key] = struct{}{}
+	log.Warnf("dogstatsd-udp: rejecting datagrams from %s, not in dogstatsd_udp_allowed_sources", key)
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package listeners
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceFilterAllowAll(t *testing.T) {
+	f, err := newSourceFilter(nil, false)
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.allows(&net.UDPAddr{IP: net.ParseIP("192.168.1.1")}))
+}
+
+func TestSourceFilter(t *testing.T) {
+	f, err := newSourceFilter([]string{"127.0.0.1", "10.0.0.0/8", "fd00::/8"}, true)
+	require.NoError(t, err)
+
+	assert.True(t, f.allows(&net.UDPAddr{IP: net.ParseIP("127.0.0.1")}))
+	assert.True(t, f.allows(&net.UDPAddr{IP: net.ParseIP("10.1.2.3")}))
+	assert.True(t, f.allows(&net.UDPAddr{IP: net.ParseIP("fd00::1")}))
+	assert.False(t, f.allows(&net.UDPAddr{IP: net.ParseIP("127.0.0.2")}))
+	assert.False(t, f.allows(&net.UDPAddr{IP: net.ParseIP("192.168.1.1")}))
+	assert.False(t, f.allows(nil))
+
+	// rejected sources are only logged once
+	assert.Len(t, f.seen, 3)
+	f.allows(&net.UDPAddr{IP: net.ParseIP("192.168.1.1")})
+	assert.Len(t, f.seen, 3)
+}
+
+func TestSourceFilterInvalid(t *testing.T) {
+	_, err := newSourceFilter([]string{"not-an-ip"}, false)
+	assert.Error(t, err)
+
+	_, err = newSourceFilter([]string{"10.0.0.0/33"}, false)
+	assert.Error(t, err)
+}
@@ -6,27 +6,14 @@
 package listeners
 
 import (
-	"errors"
 	"fmt"
 	"net"
-	"strconv"
-	"time"
 
 	"golang.org/x/sys/unix"
 
-	"github.com/DataDog/datadog-agent/pkg/util/cache"
-	"github.com/DataDog/datadog-agent/pkg/util/containers"
-	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
 )
 
-const (
-	pidToEntityCacheKeyPrefix = "pid_to_entity"
-	pidToEntityCacheDuration  = time.Minute
-)
-
-// ErrNoContainerMatch is returned when no container ID can be matched
-var errNoContainerMatch = errors.New("cannot match a container ID")
-
 // getUDSAncillarySize gets the needed buffer size to retrieve the ancillary data
 // from the out of band channel. We only get the header + 1 credentials struct
 // and discard any information added by the sender.
@@ -69,48 +56,11 @@ func processUDSOrigin(ancillary []byte) (string, error) {
 			"probably to another namespace. Is the agent in host PID mode?")
 	}
 
-	entity, err := getEntityForPID(cred.Pid)
+	// the entity is cached by the tagger until the process exits, it is
+	// cheap enough to be resolved from the intake goroutine
+	entity, err := tagger.EntityForPID(cred.Pid)
 	if err != nil {
 		return NoOrigin, err
 	}
 	return entity, nil
 }
-
-// getEntityForPID returns the container entity name and caches the value for future lookups
-// As the result is cached and the lookup is really fast (parsing local files), it can be
-// called from the intake goroutine.
-func getEntityForPID(pid int32) (string, error) {
-	key := cache.BuildAgentKey(pidToEntityCacheKeyPrefix, strconv.Itoa(int(pid)))
-	if x, found := cache.Cache.Get(key); found {
-		return x.(string), nil
-	}
-
-	entity, err := entityForPID(pid)
-	switch err {
-	case nil:
-		// No error, yay!
-		cache.Cache.Set(key, entity, pidToEntityCacheDuration)
-		return entity, nil
-	case errNoContainerMatch:
-		// No runtime detected, cache the `NoOrigin` result
-		cache.Cache.Set(key, NoOrigin, pidToEntityCacheDuration)
-		return NoOrigin, nil
-	default:
-		// Other lookup error, retry next time
-		return NoOrigin, err
-	}
-}
-
-// entityForPID returns the entity ID for a given PID. It can return
-// errNoContainerMatch if no match is found for the PID.
-func entityForPID(pid int32) (string, error) {
-	cID, err := providers.ContainerImpl().ContainerIDForPID(int(pid))
-	if err != nil {
-		return "", err
-	}
-	if cID == "" {
-		return "", errNoContainerMatch
-	}
-
-	return containers.BuildTaggerEntityName(cID), nil
-}
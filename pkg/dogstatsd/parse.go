@@ -20,6 +20,10 @@ var (
 	eventPrefix        = []byte("_e{")
 	serviceCheckPrefix = []byte("_sc")
 
+	// containerIDFieldPrefix is the prefix of the optional container ID field,
+	// allowing origin detection for clients that can't use UDS.
+	containerIDFieldPrefix = []byte("c:")
+
 	fieldSeparator = []byte("|")
 	colonSeparator = []byte(":")
 	commaSeparator = []byte(",")
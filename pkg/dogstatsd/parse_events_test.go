@@ -217,6 +217,15 @@ func TestEventMetadataTags(t *testing.T) {
 	assert.Equal(t, "", e.sourceType)
 }
 
+func TestEventMetadataContainerID(t *testing.T) {
+	e, err := parseEvent([]byte("_e{10,9}:test title|test text|#tag1|c:1234abcd"))
+
+	require.Nil(t, err)
+	assert.Equal(t, string("test title"), e.title)
+	assert.Equal(t, []string{string("tag1")}, e.tags)
+	assert.Equal(t, "1234abcd", e.containerID)
+}
+
 func TestEventMetadataMultiple(t *testing.T) {
 	e, err := parseEvent([]byte("_e{10,9}:test title|test text|t:warning|d:12345|p:low|h:some.host|k:aggKey|s:source test|#tag1,tag2:test"))
 
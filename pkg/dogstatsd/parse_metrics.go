@@ -29,12 +29,13 @@ var (
 )
 
 type dogstatsdMetricSample struct {
-	name       string
-	value      float64
-	setValue   string
-	metricType metricType
-	sampleRate float64
-	tags       []string
+	name        string
+	value       float64
+	setValue    string
+	metricType  metricType
+	sampleRate  float64
+	tags        []string
+	containerID string
 }
 
 // sanity checks a given message against the metric sample format
@@ -43,7 +44,7 @@ func hasMetricSampleFormat(message []byte) bool {
 		return false
 	}
 	separatorCount := bytes.Count(message, fieldSeparator)
-	if separatorCount < 1 || separatorCount > 3 {
+	if separatorCount < 1 || separatorCount > 4 {
 		return false
 	}
 	return true
@@ -117,6 +118,7 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 
 	sampleRate := 1.0
 	var tags []string
+	var containerID string
 	var optionalField []byte
 	for message != nil {
 		optionalField, message = nextField(message)
@@ -127,15 +129,18 @@ func (p *parser) parseMetricSample(message []byte) (dogstatsdMetricSample, error
 			if err != nil {
 				return dogstatsdMetricSample{}, fmt.Errorf("could not parse dogstatsd sample rate %q", optionalField)
 			}
+		} else if bytes.HasPrefix(optionalField, containerIDFieldPrefix) {
+			containerID = p.interner.LoadOrStore(optionalField[len(containerIDFieldPrefix):])
 		}
 	}
 
 	return dogstatsdMetricSample{
-		name:       p.interner.LoadOrStore(name),
-		value:      value,
-		setValue:   string(setValue),
-		metricType: metricType,
-		sampleRate: sampleRate,
-		tags:       tags,
+		name:        p.interner.LoadOrStore(name),
+		value:       value,
+		setValue:    string(setValue),
+		metricType:  metricType,
+		sampleRate:  sampleRate,
+		tags:        tags,
+		containerID: containerID,
 	}, nil
 }
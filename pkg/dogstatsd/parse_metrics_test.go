@@ -148,6 +148,18 @@ func TestParseGaugeWithSampleRate(t *testing.T) {
 	assert.InEpsilon(t, 0.21, sample.sampleRate, epsilon)
 }
 
+func TestParseGaugeWithContainerID(t *testing.T) {
+	sample, err := parseMetricSample([]byte("daemon:666|g|@0.21|#sometag:somevalue|c:1234abcd"))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "daemon", sample.name)
+	assert.InEpsilon(t, 666.0, sample.value, epsilon)
+	assert.Equal(t, []string{"sometag:somevalue"}, sample.tags)
+	assert.InEpsilon(t, 0.21, sample.sampleRate, epsilon)
+	assert.Equal(t, "1234abcd", sample.containerID)
+}
+
 func TestParseGaugeWithPoundOnly(t *testing.T) {
 	sample, err := parseMetricSample([]byte("daemon:666|g|#"))
 
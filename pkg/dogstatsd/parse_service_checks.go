@@ -18,12 +18,13 @@ const (
 )
 
 type dogstatsdServiceCheck struct {
-	name      string
-	status    serviceCheckStatus
-	timestamp int64
-	hostname  string
-	message   string
-	tags      []string
+	name        string
+	status      serviceCheckStatus
+	timestamp   int64
+	hostname    string
+	message     string
+	tags        []string
+	containerID string
 }
 
 var (
@@ -88,6 +89,8 @@ func (p *parser) applyServiceCheckOptionalField(serviceCheck dogstatsdServiceChe
 		newServiceCheck.hostname = string(optionalField[len(serviceCheckHostnamePrefix):])
 	case bytes.HasPrefix(optionalField, serviceCheckTagsPrefix):
 		newServiceCheck.tags = p.parseTags(optionalField[len(serviceCheckTagsPrefix):])
+	case bytes.HasPrefix(optionalField, containerIDFieldPrefix):
+		newServiceCheck.containerID = p.interner.LoadOrStore(optionalField[len(containerIDFieldPrefix):])
 	case bytes.HasPrefix(optionalField, serviceCheckMessagePrefix):
 		newServiceCheck.message = string(optionalField[len(serviceCheckMessagePrefix):])
 	}
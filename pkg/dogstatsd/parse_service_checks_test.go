@@ -87,6 +87,15 @@ func TestServiceCheckMetadataTags(t *testing.T) {
 	assert.Equal(t, []string{"tag1", "tag2:test", "tag3"}, sc.tags)
 }
 
+func TestServiceCheckMetadataContainerID(t *testing.T) {
+	sc, err := parseServiceCheck([]byte("_sc|agent.up|0|#tag1|c:1234abcd"))
+
+	require.Nil(t, err)
+	assert.Equal(t, "agent.up", sc.name)
+	assert.Equal(t, []string{"tag1"}, sc.tags)
+	assert.Equal(t, "1234abcd", sc.containerID)
+}
+
 func TestServiceCheckMetadataMessage(t *testing.T) {
 	sc, err := parseServiceCheck([]byte("_sc|agent.up|0|m:this is fine"))
 
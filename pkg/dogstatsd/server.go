@@ -16,9 +16,11 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
@@ -64,21 +66,25 @@ type Server struct {
 	// will send the metrics samples, events and service checks to.
 	aggregator *aggregator.BufferedAggregator
 
-	packetsIn                 chan listeners.Packets
-	sharedPacketPool          *listeners.PacketPool
-	Statistics                *util.Stats
-	Started                   bool
-	stopChan                  chan bool
-	health                    *health.Handle
-	metricPrefix              string
-	metricPrefixBlacklist     []string
-	defaultHostname           string
-	histToDist                bool
-	histToDistPrefix          string
-	extraTags                 []string
-	debugMetricsStats         bool
+	packetsIn             chan listeners.Packets
+	sharedPacketPool      *listeners.PacketPool
+	Statistics            *util.Stats
+	Started               bool
+	stopChan              chan bool
+	health                *health.Handle
+	metricPrefix          string
+	metricPrefixBlacklist atomic.Value // []string, replaced when the configuration is reloaded
+	defaultHostname       string
+	histToDist            bool
+	histToDistPrefix      string
+	extraTags             []string
+	// debugMetricsStats is accessed atomically (0 or 1) since it can
+	// be toggled at runtime while the workers are processing packets.
+	debugMetricsStats         uint64
 	metricsStats              map[string]metricStat
 	statsLock                 sync.Mutex
+	statsKeyGenerator         *ckey.KeyGenerator // guarded by statsLock
+	statsTags                 []string           // guarded by statsLock
 	mapper                    *mapper.MetricMapper
 	telemetryEnabled          bool
 	entityIDPrecedenceEnabled bool
@@ -89,11 +95,21 @@ type Server struct {
 	disableVerboseLogs bool
 }
 
+// maxDebugContextsPerMetric bounds the number of distinct tag sets tracked
+// per metric name by the debug stats, to keep the memory usage under control
+// while investigating a context explosion.
+const maxDebugContextsPerMetric = 10000
+
 // metricStat holds how many times a metric has been
-// processed and when was the last time.
+// processed, when was the last time, how many distinct
+// tag sets have been seen and the last sample received.
 type metricStat struct {
-	Count    uint64    `json:"count"`
-	LastSeen time.Time `json:"last_seen"`
+	Count      uint64    `json:"count"`
+	LastSeen   time.Time `json:"last_seen"`
+	Contexts   int       `json:"contexts"`
+	LastValue  float64   `json:"last_value"`
+	LastTags   []string  `json:"last_tags"`
+	contextSet map[ckey.ContextKey]struct{}
 }
 
 // NewServer returns a running Dogstatsd server
@@ -109,10 +125,10 @@ func NewServer(aggregator *aggregator.BufferedAggregator) (*Server, error) {
 		dogstatsdExpvars.Set("PacketsLastSecond", &dogstatsdPacketsLastSec)
 	}
 
-	var metricsStats bool
+	var metricsStats uint64
 	if config.Datadog.GetBool("dogstatsd_metrics_stats_enable") == true {
 		log.Info("Dogstatsd: metrics statistics will be stored.")
-		metricsStats = true
+		metricsStats = 1
 	}
 
 	packetsChannel := make(chan listeners.Packets, config.Datadog.GetInt("dogstatsd_queue_size"))
@@ -173,17 +189,18 @@ func NewServer(aggregator *aggregator.BufferedAggregator) (*Server, error) {
 		stopChan:                  make(chan bool),
 		health:                    health.Register("dogstatsd-main"),
 		metricPrefix:              metricPrefix,
-		metricPrefixBlacklist:     metricPrefixBlacklist,
 		defaultHostname:           defaultHostname,
 		histToDist:                histToDist,
 		histToDistPrefix:          histToDistPrefix,
 		extraTags:                 extraTags,
 		debugMetricsStats:         metricsStats,
 		metricsStats:              make(map[string]metricStat),
+		statsKeyGenerator:         ckey.NewKeyGenerator(),
 		telemetryEnabled:          telemetry.IsEnabled(),
 		entityIDPrecedenceEnabled: entityIDPrecedenceEnabled,
 		disableVerboseLogs:        config.Datadog.GetBool("dogstatsd_disable_verbose_logs"),
 	}
+	s.SetMetricPrefixBlacklist(metricPrefixBlacklist)
 
 	// packets forwarding
 	// ----------------------
@@ -298,6 +315,9 @@ func nextMessage(packet *[]byte) (message []byte) {
 }
 
 func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*listeners.Packet) {
+	// the samples are stamped with their reception time so that the aggregator
+	// pipelines can tell the ones that arrive after their bucket was flushed
+	timestamp := float64(time.Now().UnixNano()) / float64(time.Second)
 	for _, packet := range packets {
 		originTagger := originTags{origin: packet.Origin}
 		log.Tracef("Dogstatsd receive: %q", packet.Contents)
@@ -318,7 +338,7 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 			case serviceCheckType:
 				serviceCheck, err := s.parseServiceCheckMessage(parser, message, originTagger.getTags)
 				if err != nil {
-					originTags := originTagger.getTags()
+					originTags := originTagger.getTags(tagger.DogstatsdCardinality)
 					if len(originTags) > 0 {
 						s.errLog("Dogstatsd: error parsing service check '%q' origin tags %v: %s", message, originTags, err)
 					} else {
@@ -330,7 +350,7 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 			case eventType:
 				event, err := s.parseEventMessage(parser, message, originTagger.getTags)
 				if err != nil {
-					originTags := originTagger.getTags()
+					originTags := originTagger.getTags(tagger.DogstatsdCardinality)
 					if len(originTags) > 0 {
 						s.errLog("Dogstatsd: error parsing event '%q' origin tags %v: %s", message, originTags, err)
 					} else {
@@ -342,7 +362,7 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 			case metricSampleType:
 				sample, err := s.parseMetricMessage(parser, message, originTagger.getTags)
 				if err != nil {
-					originTags := originTagger.getTags()
+					originTags := originTagger.getTags(tagger.DogstatsdCardinality)
 					if len(originTags) > 0 {
 						s.errLog("Dogstatsd: error parsing metric message '%q' origin tags %v: %s", message, originTags, err)
 					} else {
@@ -350,8 +370,9 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 					}
 					continue
 				}
-				if s.debugMetricsStats {
-					s.storeMetricStats(sample.Name)
+				sample.Timestamp = timestamp
+				if atomic.LoadUint64(&s.debugMetricsStats) == 1 {
+					s.storeMetricStats(sample)
 				}
 				batcher.appendSample(sample)
 				if s.histToDist && sample.Mtype == metrics.HistogramType {
@@ -375,7 +396,7 @@ func (s *Server) errLog(format string, params ...interface{}) {
 	}
 }
 
-func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFunc func() []string) (metrics.MetricSample, error) {
+func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFunc func(collectors.TagCardinality) []string) (metrics.MetricSample, error) {
 	sample, err := parser.parseMetricSample(message)
 	if err != nil {
 		dogstatsdMetricParseErrors.Add(1)
@@ -389,20 +410,22 @@ func (s *Server) parseMetricMessage(parser *parser, message []byte, originTagsFu
 			sample.tags = append(sample.tags, mapResult.Tags...)
 		}
 	}
-	metricSample := enrichMetricSample(sample, s.metricPrefix, s.metricPrefixBlacklist, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
+	originTagsFunc = containerIDOriginTags(sample.containerID, originTagsFunc)
+	metricSample := enrichMetricSample(sample, s.metricPrefix, s.getMetricPrefixBlacklist(), s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	metricSample.Tags = append(metricSample.Tags, s.extraTags...)
 	dogstatsdMetricPackets.Add(1)
 	tlmProcessed.IncWithTags(tlmProcessedOkTags)
 	return metricSample, nil
 }
 
-func (s *Server) parseEventMessage(parser *parser, message []byte, originTagsFunc func() []string) (*metrics.Event, error) {
+func (s *Server) parseEventMessage(parser *parser, message []byte, originTagsFunc func(collectors.TagCardinality) []string) (*metrics.Event, error) {
 	sample, err := parser.parseEvent(message)
 	if err != nil {
 		dogstatsdEventParseErrors.Add(1)
 		tlmProcessed.Inc("events", "error")
 		return nil, err
 	}
+	originTagsFunc = containerIDOriginTags(sample.containerID, originTagsFunc)
 	event := enrichEvent(sample, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	event.Tags = append(event.Tags, s.extraTags...)
 	tlmProcessed.Inc("events", "ok")
@@ -410,13 +433,14 @@ func (s *Server) parseEventMessage(parser *parser, message []byte, originTagsFun
 	return event, nil
 }
 
-func (s *Server) parseServiceCheckMessage(parser *parser, message []byte, originTagsFunc func() []string) (*metrics.ServiceCheck, error) {
+func (s *Server) parseServiceCheckMessage(parser *parser, message []byte, originTagsFunc func(collectors.TagCardinality) []string) (*metrics.ServiceCheck, error) {
 	sample, err := parser.parseServiceCheck(message)
 	if err != nil {
 		dogstatsdServiceCheckParseErrors.Add(1)
 		tlmProcessed.Inc("service_checks", "error")
 		return nil, err
 	}
+	originTagsFunc = containerIDOriginTags(sample.containerID, originTagsFunc)
 	serviceCheck := enrichServiceCheck(sample, s.defaultHostname, originTagsFunc, s.entityIDPrecedenceEnabled)
 	serviceCheck.Tags = append(serviceCheck.Tags, s.extraTags...)
 	dogstatsdServiceCheckPackets.Add(1)
@@ -437,14 +461,62 @@ func (s *Server) Stop() {
 	s.Started = false
 }
 
-func (s *Server) storeMetricStats(name string) {
+// SetMetricPrefixBlacklist replaces the prefixes of the metrics the namespace isn't
+// added to.
+func (s *Server) SetMetricPrefixBlacklist(blacklist []string) {
+	s.metricPrefixBlacklist.Store(append([]string{}, blacklist...))
+}
+
+func (s *Server) getMetricPrefixBlacklist() []string {
+	blacklist, _ := s.metricPrefixBlacklist.Load().([]string)
+	return blacklist
+}
+
+// EnableMetricsStats starts collecting statistics about the metrics processed
+// by the server. Previously collected statistics are discarded.
+func (s *Server) EnableMetricsStats() {
+	s.statsLock.Lock()
+	s.metricsStats = make(map[string]metricStat)
+	s.statsLock.Unlock()
+	atomic.StoreUint64(&s.debugMetricsStats, 1)
+	log.Info("Dogstatsd: metrics statistics will be stored.")
+}
+
+// DisableMetricsStats stops collecting statistics about the metrics processed
+// by the server. Statistics already collected are kept until the next call
+// to EnableMetricsStats.
+func (s *Server) DisableMetricsStats() {
+	atomic.StoreUint64(&s.debugMetricsStats, 0)
+	log.Info("Dogstatsd: metrics statistics are no longer stored.")
+}
+
+// IsMetricsStatsEnabled returns whether the server is collecting statistics
+// about the metrics it processes.
+func (s *Server) IsMetricsStatsEnabled() bool {
+	return atomic.LoadUint64(&s.debugMetricsStats) == 1
+}
+
+func (s *Server) storeMetricStats(sample metrics.MetricSample) {
 	now := time.Now()
 	s.statsLock.Lock()
 	defer s.statsLock.Unlock()
-	ms := s.metricsStats[name]
+	// the context is computed as in the aggregator, on a copy of the tags
+	// as they are sorted and deduplicated in place
+	s.statsTags = util.SortUniqInPlace(append(s.statsTags[:0], sample.Tags...))
+	key := s.statsKeyGenerator.Generate(sample.Name, sample.Host, s.statsTags)
+	ms := s.metricsStats[sample.Name]
+	if ms.contextSet == nil {
+		ms.contextSet = make(map[ckey.ContextKey]struct{})
+	}
+	if _, found := ms.contextSet[key]; !found && len(ms.contextSet) < maxDebugContextsPerMetric {
+		ms.contextSet[key] = struct{}{}
+	}
 	ms.Count++
 	ms.LastSeen = now
-	s.metricsStats[name] = ms
+	ms.Contexts = len(ms.contextSet)
+	ms.LastValue = sample.Value
+	ms.LastTags = append(ms.LastTags[:0], sample.Tags...)
+	s.metricsStats[sample.Name] = ms
 }
 
 // GetJSONDebugStats returns jsonified debug statistics.
@@ -476,13 +548,13 @@ func FormatDebugStats(stats []byte) (string, error) {
 	// write the response
 	buf := bytes.NewBuffer(nil)
 
-	header := fmt.Sprintf("%-40s | %-10s | %-20s\n", "Metric", "Count", "Last Seen")
+	header := fmt.Sprintf("%-40s | %-10s | %-10s | %-20s | %s\n", "Metric", "Count", "Contexts", "Last Seen", "Last Sample")
 	buf.Write([]byte(header))
 	buf.Write([]byte(strings.Repeat("-", len(header)) + "\n"))
 
 	for _, metric := range order {
 		stats := dogStats[metric]
-		buf.Write([]byte(fmt.Sprintf("%-40s | %-10d | %-20v\n", metric, stats.Count, stats.LastSeen)))
+		buf.Write([]byte(fmt.Sprintf("%-40s | %-10d | %-10d | %-20v | %v %v\n", metric, stats.Count, stats.Contexts, stats.LastSeen, stats.LastValue, stats.LastTags)))
 	}
 
 	if len(dogStats) == 0 {
@@ -492,10 +564,10 @@ func FormatDebugStats(stats []byte) (string, error) {
 	return buf.String(), nil
 }
 
-func findOriginTags(origin string) []string {
+func findOriginTags(origin string, cardinality collectors.TagCardinality) []string {
 	var tags []string
 	if origin != listeners.NoOrigin {
-		originTags, err := tagger.Tag(origin, tagger.DogstatsdCardinality)
+		originTags, err := tagger.Tag(origin, cardinality)
 		if err != nil {
 			log.Errorf(err.Error())
 		} else {
@@ -504,7 +576,7 @@ func findOriginTags(origin string) []string {
 	}
 
 	// Include orchestrator scope tags if the cardinality is set to orchestrator
-	if tagger.DogstatsdCardinality == collectors.OrchestratorCardinality {
+	if cardinality == collectors.OrchestratorCardinality {
 		orchestratorScopeTags, err := tagger.OrchestratorScopeTag()
 		if err != nil {
 			log.Error(err.Error())
@@ -517,15 +589,19 @@ func findOriginTags(origin string) []string {
 
 type originTags struct {
 	origin string
-	tags   []string
+	// tags by cardinality, the clients can request a cardinality for each message.
 	// we don't use "sync.Once" here because we know only on one goroutine can call the function `getTags()`
-	alreadyRun bool
+	tags map[collectors.TagCardinality][]string
 }
 
-func (o *originTags) getTags() []string {
-	if !o.alreadyRun {
-		o.tags = findOriginTags(o.origin)
-		o.alreadyRun = true
+func (o *originTags) getTags(cardinality collectors.TagCardinality) []string {
+	if tags, alreadyRun := o.tags[cardinality]; alreadyRun {
+		return tags
 	}
-	return o.tags
+	if o.tags == nil {
+		o.tags = make(map[collectors.TagCardinality][]string, 1)
+	}
+	tags := findOriginTags(o.origin, cardinality)
+	o.tags[cardinality] = tags
+	return tags
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 )
 
 // getAvailableUDPPort requests a random port number and makes sure it is available
@@ -84,7 +85,8 @@ func TestUDPReceive(t *testing.T) {
 	config.Datadog.SetDefault("dogstatsd_port", port)
 
 	agg := mockAggregator()
-	metricOut, eventOut, serviceOut := agg.GetBufferedChannels()
+	_, eventOut, serviceOut := agg.GetBufferedChannels()
+	metricOut := agg.GetBufferedMetricsPipelines()[0]
 	s, err := NewServer(agg)
 	require.NoError(t, err, "cannot start DSD")
 	defer s.Stop()
@@ -315,7 +317,7 @@ func TestHistToDist(t *testing.T) {
 	defer config.Datadog.SetDefault("histogram_copy_to_distribution_prefix", "")
 
 	agg := mockAggregator()
-	metricOut, _, _ := agg.GetBufferedChannels()
+	metricOut := agg.GetBufferedMetricsPipelines()[0]
 	s, err := NewServer(agg)
 	require.NoError(t, err, "cannot start DSD")
 	defer s.Stop()
@@ -354,7 +356,7 @@ func TestExtraTags(t *testing.T) {
 	defer config.Datadog.SetDefault("dogstatsd_tags", []string{})
 
 	agg := mockAggregator()
-	metricOut, _, _ := agg.GetBufferedChannels()
+	metricOut := agg.GetBufferedMetricsPipelines()[0]
 	s, err := NewServer(agg)
 	require.NoError(t, err, "cannot start DSD")
 	defer s.Stop()
@@ -386,10 +388,10 @@ func TestDebugStats(t *testing.T) {
 	require.NoError(t, err, "cannot start DSD")
 	defer s.Stop()
 
-	s.storeMetricStats("some.metric1")
-	s.storeMetricStats("some.metric2")
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric1"})
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric2"})
 	time.Sleep(10 * time.Millisecond)
-	s.storeMetricStats("some.metric1")
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric1"})
 
 	data, err := s.GetJSONDebugStats()
 	require.NoError(t, err, "cannot get debug stats")
@@ -403,9 +405,9 @@ func TestDebugStats(t *testing.T) {
 
 	require.True(t, stats["some.metric1"].LastSeen.After(stats["some.metric2"].LastSeen), "some.metric1 should have appeared again after sometag2")
 
-	s.storeMetricStats("some.metric3")
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric3"})
 	time.Sleep(10 * time.Millisecond)
-	s.storeMetricStats("some.metric1")
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric1"})
 
 	data, _ = s.GetJSONDebugStats()
 	err = json.Unmarshal(data, &stats)
@@ -424,9 +426,61 @@ func TestDebugStats(t *testing.T) {
 	require.Equal(t, metric3.Count, uint64(1))
 }
 
+func TestDebugStatsContexts(t *testing.T) {
+	agg := mockAggregator()
+	s, err := NewServer(agg)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 1, Tags: []string{"a:1", "b:1"}})
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 2, Tags: []string{"b:1", "a:1"}})
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 3, Tags: []string{"a:2", "b:1"}})
+	// duplicated tags are the same context, they must not cancel each other
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 4, Tags: []string{"b:1", "a:1", "a:1"}})
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 5, Tags: []string{"b:1"}})
+	tags := []string{"b:1", "a:2", "a:2"}
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric", Value: 6, Tags: tags})
+	// the tags of the sample are left untouched
+	assert.Equal(t, []string{"b:1", "a:2", "a:2"}, tags)
+
+	data, err := s.GetJSONDebugStats()
+	require.NoError(t, err, "cannot get debug stats")
+
+	var stats map[string]metricStat
+	err = json.Unmarshal(data, &stats)
+	require.NoError(t, err, "data is not valid")
+
+	metric := stats["some.metric"]
+	assert.Equal(t, uint64(6), metric.Count)
+	assert.Equal(t, 3, metric.Contexts)
+	assert.Equal(t, 6.0, metric.LastValue)
+	assert.Equal(t, []string{"b:1", "a:2", "a:2"}, metric.LastTags)
+}
+
+func TestDebugStatsToggle(t *testing.T) {
+	agg := mockAggregator()
+	s, err := NewServer(agg)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	assert.False(t, s.IsMetricsStatsEnabled())
+
+	s.storeMetricStats(metrics.MetricSample{Name: "some.metric"})
+	s.EnableMetricsStats()
+	assert.True(t, s.IsMetricsStatsEnabled())
+
+	// enabling the stats discards what has been previously collected
+	data, err := s.GetJSONDebugStats()
+	require.NoError(t, err, "cannot get debug stats")
+	assert.Equal(t, "{}", string(data))
+
+	s.DisableMetricsStats()
+	assert.False(t, s.IsMetricsStatsEnabled())
+}
+
 func TestNoMappingsConfig(t *testing.T) {
 	datadogYaml := ``
-	getOriginTags := func() []string { return []string{} }
+	getOriginTags := func(collectors.TagCardinality) []string { return []string{} }
 
 	port, err := getAvailableUDPPort()
 	require.NoError(t, err)
@@ -446,6 +500,35 @@ func TestNoMappingsConfig(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSetMetricPrefixBlacklist(t *testing.T) {
+	getOriginTags := func(collectors.TagCardinality) []string { return []string{} }
+
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.SetDefault("statsd_metric_namespace", "foo")
+	defer config.Datadog.SetDefault("statsd_metric_namespace", "")
+	config.Datadog.SetDefault("statsd_metric_namespace_blacklist", []string{"bar."})
+	defer config.Datadog.SetDefault("statsd_metric_namespace_blacklist", config.StandardStatsdPrefixes)
+
+	s, err := NewServer(mockAggregator())
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	parser := newParser()
+	sample, err := s.parseMetricMessage(parser, []byte("bar.metric:666|g"), getOriginTags)
+	require.NoError(t, err)
+	assert.Equal(t, "bar.metric", sample.Name)
+
+	s.SetMetricPrefixBlacklist([]string{"baz."})
+	sample, err = s.parseMetricMessage(parser, []byte("bar.metric:666|g"), getOriginTags)
+	require.NoError(t, err)
+	assert.Equal(t, "foo.bar.metric", sample.Name)
+	sample, err = s.parseMetricMessage(parser, []byte("baz.metric:666|g"), getOriginTags)
+	require.NoError(t, err)
+	assert.Equal(t, "baz.metric", sample.Name)
+}
+
 type MetricSample struct {
 	Name  string
 	Value float64
@@ -454,7 +537,7 @@ type MetricSample struct {
 }
 
 func TestMappingCases(t *testing.T) {
-	getOriginTags := func() []string { return []string{} }
+	getOriginTags := func(collectors.TagCardinality) []string { return []string{} }
 	scenarios := []struct {
 		name              string
 		config            string
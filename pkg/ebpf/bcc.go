@@ -0,0 +1,78 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+var includeRegexp = regexp.MustCompile(`^\s*#\s*include\s+"(.*)"$`)
+
+// compileBCCModule compiles the C source of an eBPF program embedded in the assets
+func compileBCCModule(assetName string) (*bpflib.Module, error) {
+	sourceRaw, err := Asset(assetName)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn’t find asset “%s”: %v", assetName, err)
+	}
+
+	// Process the `#include` of embedded headers.
+	// Note that embedded headers including other embedded headers is not managed because
+	// this would also require to properly handle inclusion guards.
+	var source bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewBuffer(sourceRaw))
+	for scanner.Scan() {
+		match := includeRegexp.FindSubmatch(scanner.Bytes())
+		if len(match) == 2 {
+			header, err := Asset(string(match[1]))
+			if err == nil {
+				source.Write(header)
+				continue
+			}
+		}
+		source.Write(scanner.Bytes())
+		source.WriteByte('\n')
+	}
+
+	m := bpflib.NewModule(source.String(), []string{})
+	if m == nil {
+		return nil, fmt.Errorf("Failed to compile “%s”", assetName)
+	}
+	return m, nil
+}
+
+// attachBCCKprobes loads and attaches the `kprobe__<function>` programs of a module to their kernel functions
+func attachBCCKprobes(m *bpflib.Module, functions ...string) error {
+	for _, function := range functions {
+		kprobe, err := m.LoadKprobe("kprobe__" + function)
+		if err != nil {
+			return fmt.Errorf("Failed to load kprobe__%s: %s", function, err)
+		}
+		if err := m.AttachKprobe(function, kprobe, -1); err != nil {
+			return fmt.Errorf("Failed to attach %s: %s", function, err)
+		}
+	}
+	return nil
+}
+
+// drainBCCTable returns the leaves of a table and deletes their keys one by one,
+// the entries added while draining the table are kept for the next call
+func drainBCCTable(table *bpflib.Table) (keys [][]byte, leaves [][]byte) {
+	// the table is only modified once iterated as deleting a key restarts the iteration
+	for it := table.Iter(); it.Next(); {
+		keys = append(keys, it.Key())
+		leaves = append(leaves, it.Leaf())
+	}
+	for _, key := range keys {
+		if err := table.Delete(key); err != nil {
+			log.Debugf("Failed to delete table entry: %s", err)
+		}
+	}
+	return keys, leaves
+}
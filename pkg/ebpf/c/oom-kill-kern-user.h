@@ -0,0 +1,24 @@
+#ifndef OOM_KILL_KERN_USER_H
+#define OOM_KILL_KERN_USER_H
+
+#include <linux/types.h>
+
+#define OOM_COMM_LEN 16
+
+struct oom_stats {
+  char cgroup_name[64];
+  // Pid of the process killed by the OOM killer
+  __u32 pid;
+  // Pid of the process whose allocation triggered the OOM killer
+  __u32 tpid;
+  // Name of the killed process
+  char comm[OOM_COMM_LEN];
+  // Name of the triggering process
+  char tcomm[OOM_COMM_LEN];
+  // Number of pages of the memory available to the killed process
+  __u64 pages;
+  // 1 if the OOM killer was triggered by the memory limit of a cgroup, 0 if the whole system was out of memory
+  __u32 memcg_oom;
+};
+
+#endif /* defined(OOM_KILL_KERN_USER_H) */
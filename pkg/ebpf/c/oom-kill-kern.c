@@ -0,0 +1,62 @@
+#include <linux/kconfig.h>
+#define KBUILD_MODNAME "foo"
+#include <linux/ptrace.h>
+#include <linux/bpf.h>
+#include <linux/sched.h>
+#include <linux/oom.h>
+#include <linux/cgroup.h>
+
+#include "oom-kill-kern-user.h"
+
+/*
+ * The `oom_stats` map is used to share with the userland program system-probe
+ * the processes killed by the OOM killer, indexed by their pid
+ */
+BPF_HASH(oom_stats, u32, struct oom_stats);
+
+// TODO: replace all `bpf_probe_read` by `bpf_probe_read_kernel` once we can assume that we have at least kernel 5.5
+int kprobe__oom_kill_process(struct pt_regs *ctx) {
+  struct oom_control *oc = (struct oom_control *)PT_REGS_PARM1(ctx);
+
+  // the victim is only part of struct oom_control since kernel 4.13
+  struct task_struct *victim;
+  if (bpf_probe_read(&victim, sizeof(victim), &oc->chosen) || victim == NULL)
+    return 0;
+
+  u32 pid;
+  bpf_probe_read(&pid, sizeof(pid), &victim->tgid);
+
+  struct oom_stats zero = {};
+  struct oom_stats *s = oom_stats.lookup_or_init(&pid, &zero);
+  if (s == NULL) return 0;
+
+  s->pid = pid;
+  s->tpid = bpf_get_current_pid_tgid() >> 32;
+  bpf_get_current_comm(&s->tcomm, sizeof(s->tcomm));
+  bpf_probe_read(&s->comm, sizeof(s->comm), &victim->comm);
+  bpf_probe_read(&s->pages, sizeof(s->pages), &oc->totalpages);
+
+  struct mem_cgroup *memcg = NULL;
+  bpf_probe_read(&memcg, sizeof(memcg), &oc->memcg);
+  s->memcg_oom = memcg != NULL ? 1 : 0;
+
+  // the name of the memory cgroup of the victim, which is its container id for the containers
+  struct css_set *css_set;
+  if (!bpf_probe_read(&css_set, sizeof(css_set), &victim->cgroups)) {
+    struct cgroup_subsys_state *css;
+    if (!bpf_probe_read(&css, sizeof(css), &css_set->subsys[memory_cgrp_id])) {
+      struct cgroup *cgrp;
+      if (!bpf_probe_read(&cgrp, sizeof(cgrp), &css->cgroup)) {
+        struct kernfs_node *kn;
+        if (!bpf_probe_read(&kn, sizeof(kn), &cgrp->kn)) {
+          const char *name;
+          if (!bpf_probe_read(&name, sizeof(name), &kn->name)) {
+            bpf_probe_read_str(&s->cgroup_name, sizeof(s->cgroup_name), name);
+          }
+        }
+      }
+    }
+  }
+
+  return 0;
+}
@@ -0,0 +1,19 @@
+#ifndef TCP_STATS_KERN_USER_H
+#define TCP_STATS_KERN_USER_H
+
+#include <linux/types.h>
+
+struct cgroup_key {
+  char cgroup_name[64];
+};
+
+struct tcp_counters {
+  // Number of retransmitted segments
+  __u64 retransmits;
+  // Number of resets sent to abort a connection
+  __u64 sent_resets;
+  // Number of resets received from the peers
+  __u64 received_resets;
+};
+
+#endif /* defined(TCP_STATS_KERN_USER_H) */
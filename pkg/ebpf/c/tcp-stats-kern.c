@@ -0,0 +1,104 @@
+#include <linux/kconfig.h>
+#define KBUILD_MODNAME "foo"
+#include <linux/ptrace.h>
+#include <linux/bpf.h>
+#include <net/sock.h>
+
+#include "tcp-stats-kern-user.h"
+
+/*
+ * The `tcp_stats` map is used to share with the userland program system-probe
+ * the retransmits and the resets of the TCP sockets, indexed by the cgroup of their process
+ */
+BPF_HASH(tcp_stats, struct cgroup_key, struct tcp_counters);
+
+/*
+ * The retransmits and the resets are mostly handled in softirq context, where the
+ * current task isn't the owner of the socket. The `sock_cgroup` map is used to remind
+ * the cgroup of the process which connected the socket or sent data over it.
+ */
+BPF_HASH(sock_cgroup, struct sock *, struct cgroup_key, 65536);
+
+// TODO: replace all `bpf_probe_read` by `bpf_probe_read_kernel` once we can assume that we have at least kernel 5.5
+static inline void remember_sock_cgroup(struct sock *sk) {
+  if (sock_cgroup.lookup(&sk) != NULL)
+    return;
+
+  struct cgroup_key key = {};
+
+  struct task_struct *cur_tsk = (struct task_struct *)bpf_get_current_task();
+  struct css_set *css_set;
+  if (!bpf_probe_read(&css_set, sizeof(css_set), &cur_tsk->cgroups)) {
+    struct cgroup_subsys_state *css;
+    // TODO: Do not arbitrarily pick the first subsystem
+    if (!bpf_probe_read(&css, sizeof(css), &css_set->subsys[0])) {
+      struct cgroup *cgrp;
+      if (!bpf_probe_read(&cgrp, sizeof(cgrp), &css->cgroup)) {
+        struct kernfs_node *kn;
+        if (!bpf_probe_read(&kn, sizeof(kn), &cgrp->kn)) {
+          const char *name;
+          if (!bpf_probe_read(&name, sizeof(name), &kn->name)) {
+            bpf_probe_read_str(&key.cgroup_name, sizeof(key.cgroup_name), name);
+          }
+        }
+      }
+    }
+  }
+
+  sock_cgroup.update(&sk, &key);
+}
+
+// counters_for_sock returns the counters of the cgroup of the socket, the sockets of an unknown cgroup are counted
+// with an empty cgroup name
+static inline struct tcp_counters *counters_for_sock(struct sock *sk) {
+  struct cgroup_key key = {};
+  struct cgroup_key *sk_key = sock_cgroup.lookup(&sk);
+  if (sk_key != NULL)
+    __builtin_memcpy(&key, sk_key, sizeof(key));
+
+  struct tcp_counters zero = {};
+  return tcp_stats.lookup_or_init(&key, &zero);
+}
+
+int kprobe__tcp_connect(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  remember_sock_cgroup(sk);
+  return 0;
+}
+
+int kprobe__tcp_sendmsg(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  remember_sock_cgroup(sk);
+  return 0;
+}
+
+// tcp_v6_destroy_sock calls tcp_v4_destroy_sock too
+int kprobe__tcp_v4_destroy_sock(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  sock_cgroup.delete(&sk);
+  return 0;
+}
+
+int kprobe__tcp_retransmit_skb(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  struct tcp_counters *c = counters_for_sock(sk);
+  if (c != NULL)
+    __sync_fetch_and_add(&c->retransmits, 1);
+  return 0;
+}
+
+int kprobe__tcp_send_active_reset(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  struct tcp_counters *c = counters_for_sock(sk);
+  if (c != NULL)
+    __sync_fetch_and_add(&c->sent_resets, 1);
+  return 0;
+}
+
+int kprobe__tcp_reset(struct pt_regs *ctx) {
+  struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+  struct tcp_counters *c = counters_for_sock(sk);
+  if (c != NULL)
+    __sync_fetch_and_add(&c->received_resets, 1);
+  return 0;
+}
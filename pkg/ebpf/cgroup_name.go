@@ -0,0 +1,11 @@
+package ebpf
+
+import "regexp"
+
+var containerIDRegexp = regexp.MustCompile("[0-9a-f]{64}")
+
+// containerIDFromCgroupName returns the container id in the name of a cgroup, empty if it's not the cgroup of a
+// container. The cgroup of a container is named after its id, e.g. `docker-<id>.scope` with the systemd cgroup driver.
+func containerIDFromCgroupName(name string) string {
+	return containerIDRegexp.FindString(name)
+}
@@ -0,0 +1,17 @@
+package ebpf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerIDFromCgroupName(t *testing.T) {
+	id := "3e8f1a6a9e10f2cbf0c3b15e4a39ae6c1ee9d7c3f3a4cb4f6a36f1b0b5a4e0d2"
+	assert.Equal(t, id, containerIDFromCgroupName(id))
+	assert.Equal(t, id, containerIDFromCgroupName("docker-"+id+".scope"))
+	assert.Equal(t, id, containerIDFromCgroupName("cri-containerd-"+id+".scope"))
+	assert.Equal(t, "", containerIDFromCgroupName("nginx.service"))
+	assert.Equal(t, "", containerIDFromCgroupName("/"))
+	assert.Equal(t, "", containerIDFromCgroupName(""))
+}
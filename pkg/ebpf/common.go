@@ -13,6 +13,26 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// TracerModeEBPF is the mode of the eBPF tracer
+	TracerModeEBPF = "ebpf"
+	// TracerModeSockDiag is the mode of the fallback tracer reading the sockets with netlink sock_diag,
+	// the traffic of the TCP connections is read from their tcp_info
+	TracerModeSockDiag = "sock_diag"
+	// TracerModeProcfs is the mode of the fallback tracer reading the sockets from /proc/net,
+	// without any traffic counter
+	TracerModeProcfs = "procfs"
+)
+
+// ConnectionTracer is implemented by the Tracer and by the FallbackTracer
+type ConnectionTracer interface {
+	GetActiveConnections(clientID string) (*Connections, error)
+	GetStats() (map[string]interface{}, error)
+	DebugNetworkState(clientID string) (map[string]interface{}, error)
+	DebugNetworkMaps() (*Connections, error)
+	Stop()
+}
+
 // Feature versions sourced from: https://github.com/iovisor/bcc/blob/master/docs/kernel-versions.md
 var requiredKernelFuncs = []string{
 	// Maps (3.18)
@@ -83,6 +83,14 @@ type Config struct {
 
 	// ExcludedDestinationConnections is a map of destination connections to blacklist
 	ExcludedDestinationConnections map[string][]string
+
+	// EnableFallback enables the tracer reading the sockets from netlink sock_diag or /proc/net
+	// when the eBPF tracer can't be loaded
+	EnableFallback bool
+
+	// FallbackPollInterval is how often the fallback tracer reads the sockets, connections that are
+	// shorter than this interval can be missed
+	FallbackPollInterval time.Duration
 }
 
 // NewDefaultConfig enables traffic collection for all connection types
@@ -107,6 +115,7 @@ func NewDefaultConfig() *Config {
 		MaxDNSStatsBufferred:         75000,
 		ClientStateExpiry:            2 * time.Minute,
 		ClosedChannelSize:            500,
+		FallbackPollInterval:         5 * time.Second,
 	}
 }
 
@@ -0,0 +1,523 @@
+// +build linux
+
+package ebpf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/netlink"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// fallbackFullProcScanInterval is how often the file descriptors of all the processes are scanned
+// for the owners of the new sockets, the other scans only read the processes started since the last one
+const fallbackFullProcScanInterval = time.Minute
+
+// FallbackTracer tracks the connections of the host when eBPF isn't available. It polls the sockets
+// with netlink sock_diag, or from /proc/net when sock_diag can't be used, so:
+//  • the connections shorter than the poll interval can be missed
+//  • the traffic is only known for the TCP connections in the sock_diag mode
+//  • only the connections of the network namespace of the system-probe are seen
+//  • the peers of the unconnected UDP sockets are only known from conntrack, when it's enabled
+type FallbackTracer struct {
+	config *Config
+	mode   string
+
+	state       NetworkState
+	conntracker netlink.Conntracker
+	sockDiag    *sockDiag
+	udpFlows    netlink.FlowDumper
+
+	// connsLock protects the connections of the last poll and the cache of the socket owners
+	connsLock sync.Mutex
+	conns     map[string]ConnectionStats
+	// inodePids are the owners of the sockets, 0 until they're found
+	inodePids map[uint64]uint32
+	// scannedPids are the processes whose file descriptors have been read since the last full scan
+	scannedPids  map[uint32]struct{}
+	lastFullScan time.Time
+
+	// Internal buffer used to compute bytekeys
+	buf *bytes.Buffer
+
+	// Connections for the tracer to blacklist
+	sourceExcludes []*ConnectionFilter
+	destExcludes   []*ConnectionFilter
+
+	exit chan struct{}
+
+	// Telemetry
+	polls        int64
+	pollErrors   int64
+	closedConns  int64
+	skippedConns int64
+	procScans    int64
+}
+
+// NewFallbackTracer creates a FallbackTracer and starts polling the sockets
+func NewFallbackTracer(config *Config) (*FallbackTracer, error) {
+	conntracker := netlink.NewNoOpConntracker()
+	if config.EnableConntrack {
+		if c, err := netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.EnableENOBUFS); err != nil {
+			log.Warnf("could not initialize conntrack, tracer will continue without NAT tracking: %s", err)
+		} else {
+			conntracker = c
+		}
+	}
+
+	t := newFallbackTracer(config, conntracker)
+
+	// sock_diag is used if it can dump the TCP sockets, it can be denied by the security policy
+	if sd, err := newSockDiag(); err != nil {
+		log.Warnf("could not use sock_diag, the traffic of the connections won't be collected: %s", err)
+	} else if _, err := sd.dump(syscall.AF_INET, syscall.IPPROTO_TCP); err != nil {
+		log.Warnf("could not dump the sockets with sock_diag, the traffic of the connections won't be collected: %s", err)
+		sd.Close()
+	} else {
+		t.sockDiag = sd
+		t.mode = TracerModeSockDiag
+	}
+
+	if config.CollectUDPConns && config.EnableConntrack {
+		if d, err := netlink.NewFlowDumper(); err != nil {
+			log.Warnf("could not dump the conntrack flows, the unconnected UDP sockets won't be tracked: %s", err)
+		} else {
+			t.udpFlows = d
+		}
+	}
+
+	if _, err := t.poll(); err != nil {
+		t.Stop()
+		return nil, fmt.Errorf("could not read the sockets: %s", err)
+	}
+	log.Infof("eBPF is not available, tracking the connections in %s mode every %s", t.mode, t.config.FallbackPollInterval)
+
+	go t.run()
+
+	return t, nil
+}
+
+// newFallbackTracer returns a FallbackTracer reading the sockets from /proc/net
+func newFallbackTracer(config *Config, conntracker netlink.Conntracker) *FallbackTracer {
+	return &FallbackTracer{
+		config:      config,
+		mode:        TracerModeProcfs,
+		conntracker: conntracker,
+		state: NewNetworkState(
+			config.ClientStateExpiry,
+			config.MaxClosedConnectionsBuffered,
+			config.MaxConnectionsStateBuffered,
+			config.MaxDNSStatsBufferred,
+		),
+		conns:          make(map[string]ConnectionStats),
+		inodePids:      make(map[uint64]uint32),
+		scannedPids:    make(map[uint32]struct{}),
+		buf:            &bytes.Buffer{},
+		sourceExcludes: ParseConnectionFilters(config.ExcludedSourceConnections),
+		destExcludes:   ParseConnectionFilters(config.ExcludedDestinationConnections),
+		exit:           make(chan struct{}),
+	}
+}
+
+// run polls the sockets in the background to catch the connections closed between two requests
+func (t *FallbackTracer) run() {
+	ticker := time.NewTicker(t.config.FallbackPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := t.poll(); err != nil {
+				log.Warnf("error reading the sockets: %s", err)
+			}
+			t.state.RemoveExpiredClients(time.Now())
+		case <-t.exit:
+			return
+		}
+	}
+}
+
+// Stop stops the polling of the sockets
+func (t *FallbackTracer) Stop() {
+	select {
+	case <-t.exit:
+		return
+	default:
+		close(t.exit)
+	}
+
+	t.connsLock.Lock()
+	defer t.connsLock.Unlock()
+	if t.sockDiag != nil {
+		t.sockDiag.Close()
+		t.sockDiag = nil
+	}
+	if t.udpFlows != nil {
+		t.udpFlows.Close()
+		t.udpFlows = nil
+	}
+	t.conntracker.Close()
+}
+
+// GetActiveConnections returns the connections of the last poll and the connections closed since the last call
+func (t *FallbackTracer) GetActiveConnections(clientID string) (*Connections, error) {
+	active, err := t.poll()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving connections: %s", err)
+	}
+
+	conns := t.state.Connections(clientID, uint64(time.Now().UnixNano()), active, nil)
+	return &Connections{Conns: conns}, nil
+}
+
+// poll reads the sockets of the host, stores the connections which disappeared since the last poll as
+// closed and returns the active connections
+func (t *FallbackTracer) poll() ([]ConnectionStats, error) {
+	t.connsLock.Lock()
+	defer t.connsLock.Unlock()
+
+	sockets, err := t.readSockets()
+	if err != nil {
+		atomic.AddInt64(&t.pollErrors, 1)
+		return nil, err
+	}
+	atomic.AddInt64(&t.polls, 1)
+
+	tcpListening := make(map[uint16]struct{})
+	udpListening := make(map[uint16]struct{})
+	for i := range sockets {
+		if !sockets[i].isListening() {
+			continue
+		}
+		if sockets[i].conn.Type == UDP {
+			udpListening[sockets[i].conn.SPort] = struct{}{}
+		} else {
+			tcpListening[sockets[i].conn.SPort] = struct{}{}
+		}
+	}
+
+	t.resolvePids(sockets)
+
+	now := uint64(time.Now().UnixNano())
+	active := make([]ConnectionStats, 0, len(t.conns))
+	conns := make(map[string]ConnectionStats, len(t.conns))
+	addConn := func(conn ConnectionStats, inode uint64) {
+		conn.Pid = t.inodePids[inode]
+		conn.LastUpdateEpoch = now
+
+		if t.shouldSkipConnection(&conn) {
+			atomic.AddInt64(&t.skippedConns, 1)
+			return
+		}
+
+		conn.IPTranslation = t.conntracker.GetTranslationForConn(
+			conn.Source,
+			conn.SPort,
+			conn.Dest,
+			conn.DPort,
+			process.ConnectionType(conn.Type),
+		)
+
+		key, err := conn.ByteKey(t.buf)
+		if err != nil {
+			log.Warnf("failed to create byte key: %s", err)
+			return
+		}
+		if _, ok := conns[string(key)]; ok {
+			return
+		}
+		conns[string(key)] = conn
+		active = append(active, conn)
+	}
+
+	for i := range sockets {
+		if !sockets[i].isConnection() {
+			continue
+		}
+
+		conn := sockets[i].conn
+		listening := tcpListening
+		if conn.Type == UDP {
+			listening = udpListening
+		}
+		conn.Direction = OUTGOING
+		if _, ok := listening[conn.SPort]; ok {
+			conn.Direction = INCOMING
+		}
+		addConn(conn, sockets[i].inode)
+	}
+
+	for _, s := range t.readUDPFlows(sockets) {
+		addConn(s.conn, s.inode)
+	}
+
+	for key, conn := range t.conns {
+		if _, ok := conns[key]; !ok {
+			t.state.StoreClosedConnection(conn)
+			atomic.AddInt64(&t.closedConns, 1)
+		}
+	}
+	t.conns = conns
+
+	return active, nil
+}
+
+// readUDPFlows returns the connections of the unconnected UDP sockets, their peers are read from the
+// UDP flows of conntrack
+func (t *FallbackTracer) readUDPFlows(sockets []socketEntry) []socketEntry {
+	if t.udpFlows == nil {
+		return nil
+	}
+
+	unconnected := make(map[uint16][]*socketEntry)
+	for i := range sockets {
+		if sockets[i].conn.Type == UDP && sockets[i].conn.DPort == 0 {
+			unconnected[sockets[i].conn.SPort] = append(unconnected[sockets[i].conn.SPort], &sockets[i])
+		}
+	}
+	if len(unconnected) == 0 {
+		return nil
+	}
+
+	flows, err := t.udpFlows.DumpUDPFlows()
+	if err != nil {
+		log.Debugf("could not dump the UDP flows: %s", err)
+		return nil
+	}
+
+	var entries []socketEntry
+	for _, f := range flows {
+		// the flows originated from the host are outgoing, the other ones are incoming
+		if s := findUDPSocket(unconnected[f.SPort], f.Source); s != nil {
+			entry := *s
+			entry.conn.Source, entry.conn.Dest, entry.conn.DPort = f.Source, f.Dest, f.DPort
+			entry.conn.Direction = OUTGOING
+			entries = append(entries, entry)
+		}
+		if s := findUDPSocket(unconnected[f.DPort], f.Dest); s != nil {
+			entry := *s
+			entry.conn.Source, entry.conn.Dest, entry.conn.DPort = f.Dest, f.Source, f.SPort
+			entry.conn.Direction = INCOMING
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// findUDPSocket returns the socket bound to the given address, or to all the addresses
+func findUDPSocket(sockets []*socketEntry, addr util.Address) *socketEntry {
+	for _, s := range sockets {
+		if net.IP(s.conn.Source.Bytes()).IsUnspecified() || bytes.Equal(s.conn.Source.Bytes(), addr.Bytes()) {
+			return s
+		}
+	}
+	return nil
+}
+
+// readSockets returns the sockets of the enabled connection types
+func (t *FallbackTracer) readSockets() ([]socketEntry, error) {
+	type table struct {
+		family   uint8
+		protocol uint8
+		file     string
+		connType ConnectionType
+	}
+
+	var tables []table
+	if t.config.CollectTCPConns {
+		tables = append(tables, table{syscall.AF_INET, syscall.IPPROTO_TCP, "net/tcp", TCP})
+		if t.config.CollectIPv6Conns {
+			tables = append(tables, table{syscall.AF_INET6, syscall.IPPROTO_TCP, "net/tcp6", TCP})
+		}
+	}
+	if t.config.CollectUDPConns {
+		tables = append(tables, table{syscall.AF_INET, syscall.IPPROTO_UDP, "net/udp", UDP})
+		if t.config.CollectIPv6Conns {
+			tables = append(tables, table{syscall.AF_INET6, syscall.IPPROTO_UDP, "net/udp6", UDP})
+		}
+	}
+
+	var sockets []socketEntry
+	for _, tb := range tables {
+		if t.sockDiag != nil {
+			// the udp_diag module can be missing while the TCP sockets can be dumped, so the
+			// sockets are still read from /proc/net on errors
+			entries, err := t.sockDiag.dump(tb.family, tb.protocol)
+			if err == nil {
+				sockets = append(sockets, entries...)
+				continue
+			}
+			log.Debugf("could not dump the sockets of %s with sock_diag: %s", tb.file, err)
+		}
+
+		entries, err := readProcNetSockets(path.Join(t.config.ProcRoot, tb.file), tb.connType)
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, entries...)
+	}
+	return sockets, nil
+}
+
+// resolvePids finds the processes owning the sockets. The file descriptors are only read when there are
+// new sockets, and only for the processes started since the last scan: the sockets opened by the
+// processes already scanned are found by the full scans, every fallbackFullProcScanInterval at most.
+func (t *FallbackTracer) resolvePids(sockets []socketEntry) {
+	missing := make(map[uint64]struct{})
+	unresolved := make(map[uint64]struct{})
+	current := make(map[uint64]struct{}, len(sockets))
+	for i := range sockets {
+		inode := sockets[i].inode
+		if inode == 0 {
+			continue
+		}
+		current[inode] = struct{}{}
+		// the unconnected UDP sockets can have connections read from conntrack
+		if !sockets[i].isConnection() && (sockets[i].conn.Type != UDP || t.udpFlows == nil) {
+			continue
+		}
+		if pid, ok := t.inodePids[inode]; !ok {
+			missing[inode] = struct{}{}
+		} else if pid == 0 {
+			unresolved[inode] = struct{}{}
+		}
+	}
+
+	for inode := range t.inodePids {
+		if _, ok := current[inode]; !ok {
+			delete(t.inodePids, inode)
+		}
+	}
+
+	fullScan := len(unresolved) > 0 && time.Since(t.lastFullScan) >= fallbackFullProcScanInterval
+	if len(missing) == 0 && !fullScan {
+		return
+	}
+
+	if fullScan {
+		for inode := range unresolved {
+			missing[inode] = struct{}{}
+		}
+		t.scannedPids = make(map[uint32]struct{})
+	}
+	if len(t.scannedPids) == 0 {
+		// all the processes are scanned
+		t.lastFullScan = time.Now()
+	}
+
+	atomic.AddInt64(&t.procScans, 1)
+	var owners map[uint64]uint32
+	owners, t.scannedPids = readSocketOwners(t.config.ProcRoot, missing, t.scannedPids)
+	// the sockets without owner are cached with the pid 0 so that they don't trigger a scan on every poll
+	for inode := range missing {
+		t.inodePids[inode] = owners[inode]
+	}
+}
+
+// readSocketOwners returns the pids of the processes having a file descriptor on the given socket inodes.
+// The processes already scanned are skipped, the returned scanned processes are the ones which still
+// exist, including the processes scanned by this call.
+func readSocketOwners(procRoot string, inodes map[uint64]struct{}, scanned map[uint32]struct{}) (map[uint64]uint32, map[uint32]struct{}) {
+	owners := make(map[uint64]uint32, len(inodes))
+	stillScanned := make(map[uint32]struct{}, len(scanned))
+
+	procs, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		log.Debugf("could not list the processes: %s", err)
+		return owners, scanned
+	}
+
+	for _, p := range procs {
+		pid, err := strconv.ParseUint(p.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := scanned[uint32(pid)]; ok {
+			stillScanned[uint32(pid)] = struct{}{}
+			continue
+		}
+		// the processes left once all the sockets are found are scanned by the next call
+		if len(owners) == len(inodes) {
+			continue
+		}
+
+		fdDir := path.Join(procRoot, p.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// the process is gone or not readable
+			continue
+		}
+		stillScanned[uint32(pid)] = struct{}{}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(path.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := inodes[inode]; ok {
+				owners[inode] = uint32(pid)
+			}
+		}
+	}
+
+	return owners, stillScanned
+}
+
+// shouldSkipConnection returns whether or not the tracer should ignore a given connection:
+//  • Local DNS (*:53) requests if configured (default: true)
+func (t *FallbackTracer) shouldSkipConnection(conn *ConnectionStats) bool {
+	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
+	if !t.config.CollectLocalDNS && isDNSConnection && conn.Dest.IsLoopback() {
+		return true
+	}
+	return IsBlacklistedConnection(t.sourceExcludes, t.destExcludes, conn)
+}
+
+// GetStats returns a map of statistics about the current tracer's internal state
+func (t *FallbackTracer) GetStats() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"mode":      t.mode,
+		"conntrack": t.conntracker.GetStats(),
+		"state":     t.state.GetStats(),
+		"tracer": map[string]int64{
+			"polls":              atomic.LoadInt64(&t.polls),
+			"poll_errors":        atomic.LoadInt64(&t.pollErrors),
+			"closed_conns":       atomic.LoadInt64(&t.closedConns),
+			"conn_valid_skipped": atomic.LoadInt64(&t.skippedConns),
+			"proc_scans":         atomic.LoadInt64(&t.procScans),
+		},
+	}, nil
+}
+
+// DebugNetworkState returns a map with the current tracer's internal state, for debugging
+func (t *FallbackTracer) DebugNetworkState(clientID string) (map[string]interface{}, error) {
+	return t.state.DumpState(clientID), nil
+}
+
+// DebugNetworkMaps returns the connections of the last poll without modifications from network state
+func (t *FallbackTracer) DebugNetworkMaps() (*Connections, error) {
+	t.connsLock.Lock()
+	defer t.connsLock.Unlock()
+
+	conns := make([]ConnectionStats, 0, len(t.conns))
+	for _, conn := range t.conns {
+		conns = append(conns, conn)
+	}
+	return &Connections{Conns: conns}, nil
+}
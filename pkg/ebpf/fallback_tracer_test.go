@@ -0,0 +1,164 @@
+// +build linux
+
+package ebpf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/netlink"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procNetTCPHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func TestFallbackTracerPoll(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "test-fallback-tracer")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "net"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "42", "fd"), 0755))
+	require.NoError(t, os.Symlink("socket:[20179]", filepath.Join(procRoot, "42", "fd", "3")))
+
+	writeTCP := func(lines string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "net", "tcp"), []byte(procNetTCPHeader+lines), 0644))
+	}
+	writeTCP(`   0: 0F02000A:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 61632 1 ffff88003cc20780 100 0 0 10 0
+   1: 0F02000A:0016 0202000A:C121 01 00000000:00000000 02:00091FA3 00000000     0        0 20179 3 ffff88003cc20000 20 4 1 10 -1
+   2: 0F02000A:A160 0302000A:01BB 01 00000000:00000000 02:00091FA3 00000000     0        0 20180 3 ffff88003cc20000 20 4 1 10 -1
+   3: 0F02000A:A162 0302000A:01BB 06 00000000:00000000 03:00000AA4 00000000     0        0 0 3 ffff880035387000
+`)
+
+	config := NewDefaultConfig()
+	config.ProcRoot = procRoot
+	config.CollectUDPConns = false
+	config.CollectIPv6Conns = false
+	tr := newFallbackTracer(config, netlink.NewNoOpConntracker())
+
+	conns, err := tr.GetActiveConnections(DEBUGCLIENT)
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	for _, conn := range conns.Conns {
+		switch conn.DPort {
+		case 49441:
+			// the server side of the ssh connection
+			assert.Equal(t, INCOMING, conn.Direction)
+			assert.Equal(t, uint32(42), conn.Pid)
+			assert.Equal(t, util.AddressFromString("10.0.2.2"), conn.Dest)
+		case 443:
+			assert.Equal(t, OUTGOING, conn.Direction)
+			assert.Equal(t, uint32(0), conn.Pid)
+		default:
+			assert.Failf(t, "unexpected connection", "%s", conn)
+		}
+	}
+
+	// the ssh connection is closed between two requests
+	writeTCP(`   0: 0F02000A:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 61632 1 ffff88003cc20780 100 0 0 10 0
+   2: 0F02000A:A160 0302000A:01BB 01 00000000:00000000 02:00091FA3 00000000     0        0 20180 3 ffff88003cc20000 20 4 1 10 -1
+`)
+	_, err = tr.poll()
+	require.NoError(t, err)
+
+	conns, err = tr.GetActiveConnections(DEBUGCLIENT)
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	maps, err := tr.DebugNetworkMaps()
+	require.NoError(t, err)
+	require.Len(t, maps.Conns, 1)
+	assert.Equal(t, uint16(443), maps.Conns[0].DPort)
+
+	stats, err := tr.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, TracerModeProcfs, stats["mode"])
+	assert.Equal(t, int64(3), stats["tracer"].(map[string]int64)["polls"])
+	assert.Equal(t, int64(1), stats["tracer"].(map[string]int64)["closed_conns"])
+	assert.Equal(t, int64(1), stats["tracer"].(map[string]int64)["proc_scans"])
+
+	tr.Stop()
+}
+
+type fakeFlowDumper struct {
+	flows []netlink.Flow
+}
+
+func (d *fakeFlowDumper) DumpUDPFlows() ([]netlink.Flow, error) { return d.flows, nil }
+func (d *fakeFlowDumper) Close()                                {}
+
+func TestFallbackTracerUDPFlows(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "test-fallback-tracer")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "net"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "42", "fd"), 0755))
+	require.NoError(t, os.Symlink("socket:[30001]", filepath.Join(procRoot, "42", "fd", "3")))
+	require.NoError(t, os.Symlink("socket:[30002]", filepath.Join(procRoot, "42", "fd", "4")))
+
+	// a server socket bound to all the addresses and a client socket, both unconnected
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "net", "udp"), []byte(procNetTCPHeader+
+		`   0: 00000000:1FBD 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 30001 2 ffff88003cc20780 0
+   1: 0F02000A:9C40 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 30002 2 ffff88003cc20000 0
+`), 0644))
+
+	config := NewDefaultConfig()
+	config.ProcRoot = procRoot
+	config.CollectTCPConns = false
+	config.CollectIPv6Conns = false
+	tr := newFallbackTracer(config, netlink.NewNoOpConntracker())
+	tr.udpFlows = &fakeFlowDumper{flows: []netlink.Flow{
+		{Source: util.AddressFromString("10.0.2.15"), SPort: 40000, Dest: util.AddressFromString("8.8.8.8"), DPort: 53},
+		{Source: util.AddressFromString("10.0.2.2"), SPort: 5000, Dest: util.AddressFromString("10.0.2.15"), DPort: 8125},
+		// a flow of another host
+		{Source: util.AddressFromString("10.0.3.2"), SPort: 5000, Dest: util.AddressFromString("10.0.3.3"), DPort: 6000},
+	}}
+	defer tr.Stop()
+
+	conns, err := tr.GetActiveConnections(DEBUGCLIENT)
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	for _, conn := range conns.Conns {
+		assert.Equal(t, UDP, conn.Type)
+		assert.Equal(t, uint32(42), conn.Pid)
+		switch conn.DPort {
+		case 53:
+			assert.Equal(t, OUTGOING, conn.Direction)
+			assert.Equal(t, uint16(40000), conn.SPort)
+			assert.Equal(t, util.AddressFromString("8.8.8.8"), conn.Dest)
+		case 5000:
+			assert.Equal(t, INCOMING, conn.Direction)
+			assert.Equal(t, uint16(8125), conn.SPort)
+			assert.Equal(t, util.AddressFromString("10.0.2.15"), conn.Source)
+			assert.Equal(t, util.AddressFromString("10.0.2.2"), conn.Dest)
+		default:
+			assert.Failf(t, "unexpected connection", "%s", conn)
+		}
+	}
+}
+
+func TestReadSocketOwnersSkipsScannedPids(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "test-fallback-tracer")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	for pid, inode := range map[string]string{"42": "1", "43": "2"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(procRoot, pid, "fd"), 0755))
+		require.NoError(t, os.Symlink("socket:["+inode+"]", filepath.Join(procRoot, pid, "fd", "3")))
+	}
+
+	// 42 was already scanned, 44 is gone
+	owners, scanned := readSocketOwners(procRoot,
+		map[uint64]struct{}{1: {}, 2: {}},
+		map[uint32]struct{}{42: {}, 44: {}})
+
+	assert.Equal(t, map[uint64]uint32{2: 43}, owners)
+	assert.Equal(t, map[uint32]struct{}{42: {}, 43: {}}, scanned)
+}
@@ -0,0 +1,34 @@
+// +build !linux
+
+package ebpf
+
+// FallbackTracer is not implemented
+type FallbackTracer struct{}
+
+// NewFallbackTracer is not implemented on this OS for FallbackTracer
+func NewFallbackTracer(_ *Config) (*FallbackTracer, error) {
+	return nil, ErrNotImplemented
+}
+
+// Stop is not implemented on this OS for FallbackTracer
+func (t *FallbackTracer) Stop() {}
+
+// GetActiveConnections is not implemented on this OS for FallbackTracer
+func (t *FallbackTracer) GetActiveConnections(_ string) (*Connections, error) {
+	return nil, ErrNotImplemented
+}
+
+// GetStats is not implemented on this OS for FallbackTracer
+func (t *FallbackTracer) GetStats() (map[string]interface{}, error) {
+	return nil, ErrNotImplemented
+}
+
+// DebugNetworkState is not implemented on this OS for FallbackTracer
+func (t *FallbackTracer) DebugNetworkState(clientID string) (map[string]interface{}, error) {
+	return nil, ErrNotImplemented
+}
+
+// DebugNetworkMaps is not implemented on this OS for FallbackTracer
+func (t *FallbackTracer) DebugNetworkMaps() (*Connections, error) {
+	return nil, ErrNotImplemented
+}
@@ -0,0 +1,75 @@
+// +build linux
+
+package netlink
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	ct "github.com/florianl/go-conntrack"
+)
+
+// Flow is a UDP flow tracked by conntrack, as seen by the host which originated it
+type Flow struct {
+	Source util.Address
+	SPort  uint16
+	Dest   util.Address
+	DPort  uint16
+}
+
+// FlowDumper lists the UDP flows of conntrack, the peers of the unconnected UDP sockets can only
+// be known from them without eBPF
+type FlowDumper interface {
+	DumpUDPFlows() ([]Flow, error)
+	Close()
+}
+
+type realFlowDumper struct {
+	nfct *ct.Nfct
+}
+
+// NewFlowDumper creates a FlowDumper for the network namespace of the current process
+func NewFlowDumper() (FlowDumper, error) {
+	nfct, err := createNetlinkSocket("dump", 0, getLogger(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &realFlowDumper{nfct: nfct}, nil
+}
+
+// DumpUDPFlows returns the IPv4 and IPv6 UDP flows of conntrack
+func (d *realFlowDumper) DumpUDPFlows() ([]Flow, error) {
+	sessions, err := d.nfct.Dump(ct.Conntrack, ct.IPv4)
+	if err != nil {
+		return nil, err
+	}
+	sessions6, err := d.nfct.Dump(ct.Conntrack, ct.IPv6)
+	if err != nil {
+		return nil, err
+	}
+
+	var flows []Flow
+	for _, c := range append(sessions, sessions6...) {
+		if f, ok := formatUDPFlow(c.Origin); ok {
+			flows = append(flows, f)
+		}
+	}
+	return flows, nil
+}
+
+// Close closes the netlink socket of the dumper
+func (d *realFlowDumper) Close() {
+	d.nfct.Close()
+}
+
+func formatUDPFlow(tuple *ct.IPTuple) (Flow, bool) {
+	if tuple == nil || tuple.Src == nil || tuple.Dst == nil || tuple.Proto == nil ||
+		tuple.Proto.Number == nil || tuple.Proto.SrcPort == nil || tuple.Proto.DstPort == nil ||
+		*tuple.Proto.Number != 17 {
+		return Flow{}, false
+	}
+	return Flow{
+		Source: util.AddressFromNetIP(*tuple.Src),
+		SPort:  *tuple.Proto.SrcPort,
+		Dest:   util.AddressFromNetIP(*tuple.Dst),
+		DPort:  *tuple.Proto.DstPort,
+	}, true
+}
@@ -0,0 +1,93 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+/*
+#include <string.h>
+#include "c/oom-kill-kern-user.h"
+*/
+import "C"
+
+// OOMKillTracer reports the processes killed by the OOM killer
+type OOMKillTracer struct {
+	m      *bpflib.Module
+	oomMap *bpflib.Table
+}
+
+// NewOOMKillTracer compiles and attaches the eBPF program tracing the OOM killer, it requires kernel 4.13+
+func NewOOMKillTracer() (*OOMKillTracer, error) {
+	m, err := compileBCCModule("oom-kill-kern.c")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := attachBCCKprobes(m, "oom_kill_process"); err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	table := bpflib.NewTable(m.TableId("oom_stats"), m)
+
+	return &OOMKillTracer{
+		m:      m,
+		oomMap: table,
+	}, nil
+}
+
+// Close detaches the eBPF program
+func (t *OOMKillTracer) Close() {
+	t.m.Close()
+}
+
+// Get returns the processes killed by the OOM killer
+func (t *OOMKillTracer) Get() []oomkill.Stats {
+	if t == nil {
+		return nil
+	}
+
+	var result []oomkill.Stats
+	for it := t.oomMap.Iter(); it.Next(); {
+		result = append(result, convertOOMStatsLeaf(it.Leaf()))
+	}
+	return result
+}
+
+// GetAndFlush returns the processes killed by the OOM killer since the previous call,
+// only the entries returned are deleted so that the kills happening meanwhile are not lost
+func (t *OOMKillTracer) GetAndFlush() []oomkill.Stats {
+	if t == nil {
+		return nil
+	}
+
+	_, leaves := drainBCCTable(t.oomMap)
+	result := make([]oomkill.Stats, 0, len(leaves))
+	for _, leaf := range leaves {
+		result = append(result, convertOOMStatsLeaf(leaf))
+	}
+	return result
+}
+
+func convertOOMStatsLeaf(data []byte) oomkill.Stats {
+	var stat C.struct_oom_stats
+	C.memcpy(unsafe.Pointer(&stat), unsafe.Pointer(&data[0]), C.sizeof_struct_oom_stats)
+	return convertOOMStats(stat)
+}
+
+func convertOOMStats(in C.struct_oom_stats) (out oomkill.Stats) {
+	out.ContainerID = containerIDFromCgroupName(C.GoString(&in.cgroup_name[0]))
+	out.Pid = uint32(in.pid)
+	out.ProcessName = C.GoString(&in.comm[0])
+	out.TriggerPid = uint32(in.tpid)
+	out.TriggerProcessName = C.GoString(&in.tcomm[0])
+	out.Pages = uint64(in.pages)
+	out.MemCgOOM = in.memcg_oom == 1
+	return
+}
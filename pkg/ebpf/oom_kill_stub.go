@@ -0,0 +1,26 @@
+// +build !linux_bpf linux_bpf,!bcc
+
+package ebpf
+
+import "github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
+
+// OOMKillTracer is not implemented on non-linux systems
+type OOMKillTracer struct{}
+
+// NewOOMKillTracer is not implemented on non-linux systems
+func NewOOMKillTracer() (*OOMKillTracer, error) {
+	return nil, ErrNotImplemented
+}
+
+// Close is not implemented on non-linux systems
+func (t *OOMKillTracer) Close() {}
+
+// Get is not implemented on non-linux systems
+func (t *OOMKillTracer) Get() []oomkill.Stats {
+	return nil
+}
+
+// GetAndFlush is not implemented on non-linux systems
+func (t *OOMKillTracer) GetAndFlush() []oomkill.Stats {
+	return nil
+}
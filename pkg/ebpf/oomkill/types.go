@@ -0,0 +1,16 @@
+package oomkill
+
+// Stats contains the information of a process killed by the OOM killer
+type Stats struct {
+	ContainerID string `json:"containerid"`
+	// Pid and ProcessName are the ones of the killed process
+	Pid         uint32 `json:"pid"`
+	ProcessName string `json:"process_name"`
+	// TriggerPid and TriggerProcessName are the ones of the process whose allocation triggered the OOM killer
+	TriggerPid         uint32 `json:"trigger_pid"`
+	TriggerProcessName string `json:"trigger_process_name"`
+	// Pages is the number of pages of the memory available to the killed process
+	Pages uint64 `json:"pages"`
+	// MemCgOOM is true if the OOM killer was triggered by the memory limit of a cgroup rather than the whole system
+	MemCgOOM bool `json:"memcg_oom"`
+}
@@ -3,15 +3,21 @@ package ebpf
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"net"
 	"os"
 	"strconv"
 
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
-	tcpListen int64 = 10
+	tcpListen   int64 = 10
+	tcpTimeWait int64 = 6
 
 	// tcpClose is also used to indicate a UDP connection where the other end hasn't been established
 	tcpClose int64 = 7
@@ -110,3 +116,125 @@ func (iter *fieldIterator) nextField() []byte {
 
 	return result
 }
+
+// socketEntry is a socket of the host, as read from /proc/net or from sock_diag.
+// Only the sock_diag entries of TCP sockets have traffic counters.
+type socketEntry struct {
+	conn  ConnectionStats
+	state int64
+	inode uint64
+}
+
+// isListening returns true for the TCP sockets in the listen state and the unconnected UDP sockets
+func (s *socketEntry) isListening() bool {
+	if s.conn.Type == UDP {
+		return s.state == tcpClose && s.conn.DPort == 0
+	}
+	return s.state == tcpListen
+}
+
+// isConnection returns true for the sockets with a peer which are still owned by a process
+func (s *socketEntry) isConnection() bool {
+	if s.conn.Type == UDP {
+		return s.conn.DPort != 0
+	}
+	return s.state != tcpListen && s.state != tcpTimeWait && s.state != tcpClose
+}
+
+// readProcNetSockets reads all the sockets of a /proc/net/{tcp,tcp6,udp,udp6} file
+func readProcNetSockets(path string, connType ConnectionType) ([]socketEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := bufio.NewReader(f)
+
+	var sockets []socketEntry
+
+	// Skip header line
+	_, _ = reader.ReadBytes('\n')
+
+	for {
+		b, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		iter := &fieldIterator{data: b}
+		iter.nextField() // entry number
+
+		rawLocal := iter.nextField()  // local_address
+		rawRemote := iter.nextField() // remote_address
+		rawState := iter.nextField()  // st
+
+		iter.nextField() // tx_queue:rx_queue
+		iter.nextField() // tr:tm->when
+		iter.nextField() // retrnsmt
+		iter.nextField() // uid
+		iter.nextField() // timeout
+
+		rawInode := iter.nextField() // inode
+
+		state, err := strconv.ParseInt(string(rawState), 16, 0)
+		if err != nil {
+			log.Errorf("error parsing tcp state [%s] as hex: %s", rawState, err)
+			continue
+		}
+
+		entry := socketEntry{state: state}
+		entry.conn.Type = connType
+		if entry.conn.Source, entry.conn.SPort, err = parseProcNetAddress(rawLocal); err != nil {
+			log.Errorf("error parsing local address [%s]: %s", rawLocal, err)
+			continue
+		}
+		if entry.conn.Dest, entry.conn.DPort, err = parseProcNetAddress(rawRemote); err != nil {
+			log.Errorf("error parsing remote address [%s]: %s", rawRemote, err)
+			continue
+		}
+		if len(entry.conn.Source.Bytes()) == net.IPv6len {
+			entry.conn.Family = AFINET6
+		}
+		if entry.inode, err = strconv.ParseUint(string(rawInode), 10, 64); err != nil {
+			log.Errorf("error parsing inode [%s]: %s", rawInode, err)
+			continue
+		}
+
+		sockets = append(sockets, entry)
+	}
+
+	return sockets, nil
+}
+
+// parseProcNetAddress parses an address of /proc/net like 0100007F:0016. The
+// address is printed as 32-bit words in host byte order, IPv4-mapped IPv6
+// addresses are returned as IPv4 addresses.
+func parseProcNetAddress(raw []byte) (util.Address, uint16, error) {
+	idx := bytes.IndexByte(raw, ':')
+	if idx == -1 {
+		return nil, 0, fmt.Errorf("missing port")
+	}
+
+	port, err := strconv.ParseUint(string(raw[idx+1:]), 16, 16)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	words := make([]byte, hex.DecodedLen(idx))
+	if _, err := hex.Decode(words, raw[:idx]); err != nil {
+		return nil, 0, err
+	}
+	if len(words) != net.IPv4len && len(words) != net.IPv6len {
+		return nil, 0, fmt.Errorf("invalid address length %d", len(words))
+	}
+
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		nativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(words[i:]))
+	}
+
+	return util.AddressFromNetIP(ip), uint16(port), nil
+}
@@ -1,10 +1,13 @@
 package ebpf
 
 import (
-	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestReadProcNet(t *testing.T) {
@@ -44,6 +47,56 @@ func TestReadProcNet(t *testing.T) {
 	}
 }
 
+func TestReadProcNetSockets(t *testing.T) {
+	file, err := writeTestFile(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0200007F:B600 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 61632 1 ffff88003cc20780 100 0 0 10 0
+   8: 0F02000A:0016 0202000A:C121 01 00000000:00000000 02:00091FA3 00000000     0        0 20179 3 ffff88003cc20000 20 4 1 10 -1
+`)
+	require.NoError(t, err)
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	sockets, err := readProcNetSockets(file.Name(), TCP)
+	require.NoError(t, err)
+	require.Len(t, sockets, 2)
+
+	assert.True(t, sockets[0].isListening())
+	assert.False(t, sockets[0].isConnection())
+	assert.Equal(t, util.AddressFromString("127.0.0.2"), sockets[0].conn.Source)
+	assert.Equal(t, uint16(46592), sockets[0].conn.SPort)
+
+	assert.False(t, sockets[1].isListening())
+	assert.True(t, sockets[1].isConnection())
+	assert.Equal(t, util.AddressFromString("10.0.2.15"), sockets[1].conn.Source)
+	assert.Equal(t, uint16(22), sockets[1].conn.SPort)
+	assert.Equal(t, util.AddressFromString("10.0.2.2"), sockets[1].conn.Dest)
+	assert.Equal(t, uint16(49441), sockets[1].conn.DPort)
+	assert.Equal(t, AFINET, sockets[1].conn.Family)
+	assert.Equal(t, TCP, sockets[1].conn.Type)
+	assert.Equal(t, uint64(20179), sockets[1].inode)
+}
+
+func TestParseProcNetAddress(t *testing.T) {
+	addr, port, err := parseProcNetAddress([]byte("0100007F:0035"))
+	require.NoError(t, err)
+	assert.Equal(t, util.AddressFromString("127.0.0.1"), addr)
+	assert.Equal(t, uint16(53), port)
+
+	addr, port, err = parseProcNetAddress([]byte("00000000000000000000000001000000:1F90"))
+	require.NoError(t, err)
+	assert.Equal(t, util.AddressFromString("::1"), addr)
+	assert.Equal(t, uint16(8080), port)
+
+	// IPv4-mapped IPv6 address
+	addr, _, err = parseProcNetAddress([]byte("0000000000000000FFFF00000F02000A:0016"))
+	require.NoError(t, err)
+	assert.Equal(t, util.AddressFromString("10.0.2.15"), addr)
+
+	_, _, err = parseProcNetAddress([]byte("0100007F"))
+	assert.Error(t, err)
+	_, _, err = parseProcNetAddress([]byte("01007F:0035"))
+	assert.Error(t, err)
+}
+
 func writeTestFile(content string) (f *os.File, err error) {
 	tmpfile, err := ioutil.TempFile("", "test-proc-net")
 
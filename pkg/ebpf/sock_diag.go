@@ -0,0 +1,181 @@
+// +build linux
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// sockDiagByFamily is the SOCK_DIAG_BY_FAMILY netlink message type
+	sockDiagByFamily = 20
+
+	// inetDiagInfo is the INET_DIAG_INFO attribute, it holds a struct tcp_info
+	inetDiagInfo = 2
+
+	// sizes of struct inet_diag_req_v2 and struct inet_diag_msg
+	inetDiagReqV2Len = 56
+	inetDiagMsgLen   = 72
+
+	// offsets of the fields of struct tcp_info, bytes_acked and bytes_received are only there since Linux 4.1
+	tcpInfoRTTOffset           = 68
+	tcpInfoRTTVarOffset        = 72
+	tcpInfoTotalRetransOffset  = 100
+	tcpInfoBytesAckedOffset    = 120
+	tcpInfoBytesReceivedOffset = 128
+	tcpInfoBytesLen            = 136
+
+	sockDiagBufferSize = 64 * 1024
+)
+
+// sockDiag dumps the sockets of the host with the NETLINK_SOCK_DIAG netlink
+// protocol. Unlike /proc/net, it reports the tcp_info of the TCP sockets, which
+// has their traffic counters.
+type sockDiag struct {
+	fd  int
+	seq uint32
+	buf []byte
+}
+
+func newSockDiag() (*sockDiag, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the sock_diag socket: %s", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("could not bind the sock_diag socket: %s", err)
+	}
+	return &sockDiag{
+		fd:  fd,
+		buf: make([]byte, sockDiagBufferSize),
+	}, nil
+}
+
+// dump returns the sockets of the given family (AF_INET or AF_INET6) and protocol (IPPROTO_TCP or IPPROTO_UDP)
+func (s *sockDiag) dump(family, protocol uint8) ([]socketEntry, error) {
+	s.seq++
+
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqV2Len)
+	nativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	nativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	nativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	nativeEndian.PutUint32(req[8:12], s.seq)
+	req[16] = family
+	req[17] = protocol
+	if protocol == syscall.IPPROTO_TCP {
+		req[18] = 1 << (inetDiagInfo - 1)
+	}
+	// all the states
+	nativeEndian.PutUint32(req[20:24], 0xffffffff)
+
+	if err := syscall.Sendto(s.fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	connType := TCP
+	if protocol == syscall.IPPROTO_UDP {
+		connType = UDP
+	}
+
+	var sockets []socketEntry
+	for {
+		n, _, err := syscall.Recvfrom(s.fd, s.buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(s.buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != s.seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return sockets, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) < 4 {
+					return nil, fmt.Errorf("truncated netlink error")
+				}
+				return nil, syscall.Errno(-int32(nativeEndian.Uint32(m.Data[0:4])))
+			}
+			if entry, ok := parseInetDiagMsg(m.Data, connType); ok {
+				sockets = append(sockets, entry)
+			}
+		}
+	}
+}
+
+// Close closes the netlink socket
+func (s *sockDiag) Close() {
+	_ = syscall.Close(s.fd)
+}
+
+// parseInetDiagMsg parses a struct inet_diag_msg and its attributes
+func parseInetDiagMsg(data []byte, connType ConnectionType) (socketEntry, bool) {
+	var entry socketEntry
+	if len(data) < inetDiagMsgLen {
+		return entry, false
+	}
+
+	entry.state = int64(data[1])
+	entry.conn.Type = connType
+
+	// the ports and the addresses of struct inet_diag_sockid are in network byte order
+	entry.conn.SPort = binary.BigEndian.Uint16(data[4:6])
+	entry.conn.DPort = binary.BigEndian.Uint16(data[6:8])
+	if data[0] == syscall.AF_INET {
+		entry.conn.Source = util.V4AddressFromBytes(data[8:12])
+		entry.conn.Dest = util.V4AddressFromBytes(data[24:28])
+	} else {
+		entry.conn.Source = util.AddressFromNetIP(net.IP(data[8:24]))
+		entry.conn.Dest = util.AddressFromNetIP(net.IP(data[24:40]))
+		if len(entry.conn.Source.Bytes()) == net.IPv6len {
+			entry.conn.Family = AFINET6
+		}
+	}
+	entry.inode = uint64(nativeEndian.Uint32(data[68:72]))
+
+	// struct rtattr are aligned on 4 bytes
+	attrs := data[inetDiagMsgLen:]
+	for len(attrs) >= 4 {
+		attrLen := int(nativeEndian.Uint16(attrs[0:2]))
+		attrType := nativeEndian.Uint16(attrs[2:4])
+		if attrLen < 4 || attrLen > len(attrs) {
+			break
+		}
+		if attrType == inetDiagInfo {
+			parseTCPInfo(attrs[4:attrLen], &entry.conn)
+		}
+		attrLen = (attrLen + 3) &^ 3
+		if attrLen > len(attrs) {
+			break
+		}
+		attrs = attrs[attrLen:]
+	}
+
+	return entry, true
+}
+
+// parseTCPInfo fills the counters of the connection from a struct tcp_info
+func parseTCPInfo(info []byte, conn *ConnectionStats) {
+	if len(info) < tcpInfoTotalRetransOffset+4 {
+		return
+	}
+	conn.RTT = nativeEndian.Uint32(info[tcpInfoRTTOffset:])
+	conn.RTTVar = nativeEndian.Uint32(info[tcpInfoRTTVarOffset:])
+	conn.MonotonicRetransmits = nativeEndian.Uint32(info[tcpInfoTotalRetransOffset:])
+
+	if len(info) < tcpInfoBytesLen {
+		return
+	}
+	conn.MonotonicSentBytes = nativeEndian.Uint64(info[tcpInfoBytesAckedOffset:])
+	conn.MonotonicRecvBytes = nativeEndian.Uint64(info[tcpInfoBytesReceivedOffset:])
+}
@@ -0,0 +1,60 @@
+// +build linux
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInetDiagMsg(t *testing.T) {
+	msg := make([]byte, inetDiagMsgLen)
+	msg[0] = syscall.AF_INET
+	msg[1] = 1 // established
+	binary.BigEndian.PutUint16(msg[4:], 22)
+	binary.BigEndian.PutUint16(msg[6:], 49441)
+	copy(msg[8:], []byte{10, 0, 2, 15})
+	copy(msg[24:], []byte{10, 0, 2, 2})
+	nativeEndian.PutUint32(msg[68:], 20179)
+
+	// INET_DIAG_INFO attribute with a tcp_info of Linux >= 4.1
+	info := make([]byte, tcpInfoBytesLen)
+	nativeEndian.PutUint32(info[tcpInfoRTTOffset:], 250)
+	nativeEndian.PutUint32(info[tcpInfoRTTVarOffset:], 50)
+	nativeEndian.PutUint32(info[tcpInfoTotalRetransOffset:], 3)
+	nativeEndian.PutUint64(info[tcpInfoBytesAckedOffset:], 1000)
+	nativeEndian.PutUint64(info[tcpInfoBytesReceivedOffset:], 2000)
+	attr := make([]byte, 4)
+	nativeEndian.PutUint16(attr[0:], uint16(4+len(info)))
+	nativeEndian.PutUint16(attr[2:], inetDiagInfo)
+
+	entry, ok := parseInetDiagMsg(append(append(msg, attr...), info...), TCP)
+	require.True(t, ok)
+	assert.True(t, entry.isConnection())
+	assert.Equal(t, util.AddressFromString("10.0.2.15"), entry.conn.Source)
+	assert.Equal(t, uint16(22), entry.conn.SPort)
+	assert.Equal(t, util.AddressFromString("10.0.2.2"), entry.conn.Dest)
+	assert.Equal(t, uint16(49441), entry.conn.DPort)
+	assert.Equal(t, AFINET, entry.conn.Family)
+	assert.Equal(t, uint64(20179), entry.inode)
+	assert.Equal(t, uint32(250), entry.conn.RTT)
+	assert.Equal(t, uint32(50), entry.conn.RTTVar)
+	assert.Equal(t, uint32(3), entry.conn.MonotonicRetransmits)
+	assert.Equal(t, uint64(1000), entry.conn.MonotonicSentBytes)
+	assert.Equal(t, uint64(2000), entry.conn.MonotonicRecvBytes)
+
+	// the tcp_info of older kernels doesn't have the traffic counters
+	nativeEndian.PutUint16(attr[0:], uint16(4+tcpInfoBytesAckedOffset))
+	entry, ok = parseInetDiagMsg(append(append(msg, attr...), info[:tcpInfoBytesAckedOffset]...), TCP)
+	require.True(t, ok)
+	assert.Equal(t, uint32(3), entry.conn.MonotonicRetransmits)
+	assert.Equal(t, uint64(0), entry.conn.MonotonicSentBytes)
+
+	_, ok = parseInetDiagMsg(msg[:inetDiagMsgLen-1], TCP)
+	assert.False(t, ok)
+}
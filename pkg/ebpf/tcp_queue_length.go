@@ -3,12 +3,9 @@
 package ebpf
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"net"
-	"regexp"
 	"unsafe"
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
@@ -28,33 +25,9 @@ type TCPQueueLengthTracer struct {
 }
 
 func NewTCPQueueLengthTracer() (*TCPQueueLengthTracer, error) {
-	source_raw, err := Asset("tcp-queue-length-kern.c")
+	m, err := compileBCCModule("tcp-queue-length-kern.c")
 	if err != nil {
-		return nil, fmt.Errorf("Couldn’t find asset “tcp-queue-length-kern.c”: %v", err)
-	}
-
-	// Process the `#include` of embedded headers.
-	// Note that embedded headers including other embedded headers is not managed because
-	// this would also require to properly handle inclusion guards.
-	includeRegexp := regexp.MustCompile(`^\s*#\s*include\s+"(.*)"$`)
-	var source bytes.Buffer
-	scanner := bufio.NewScanner(bytes.NewBuffer(source_raw))
-	for scanner.Scan() {
-		match := includeRegexp.FindSubmatch(scanner.Bytes())
-		if len(match) == 2 {
-			header, err := Asset(string(match[1]))
-			if err == nil {
-				source.Write(header)
-				continue
-			}
-		}
-		source.Write(scanner.Bytes())
-		source.WriteByte('\n')
-	}
-
-	m := bpflib.NewModule(source.String(), []string{})
-	if m == nil {
-		return nil, fmt.Errorf("Failed to compile “tcp-queue-length-kern.c”")
+		return nil, err
 	}
 
 	kprobe_recvmsg, err := m.LoadKprobe("kprobe__tcp_recvmsg")
@@ -0,0 +1,110 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpstats"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+/*
+#include <string.h>
+#include "c/tcp-stats-kern-user.h"
+*/
+import "C"
+
+// TCPStatsTracer counts the TCP retransmits and resets per container
+type TCPStatsTracer struct {
+	m        *bpflib.Module
+	statsMap *bpflib.Table
+}
+
+// NewTCPStatsTracer compiles and attaches the eBPF program counting the TCP retransmits and resets
+func NewTCPStatsTracer() (*TCPStatsTracer, error) {
+	m, err := compileBCCModule("tcp-stats-kern.c")
+	if err != nil {
+		return nil, err
+	}
+
+	// the sockets are associated to their cgroup before the counters are attached
+	err = attachBCCKprobes(m,
+		"tcp_connect",
+		"tcp_sendmsg",
+		"tcp_v4_destroy_sock",
+		"tcp_retransmit_skb",
+		"tcp_send_active_reset",
+		"tcp_reset",
+	)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	table := bpflib.NewTable(m.TableId("tcp_stats"), m)
+
+	return &TCPStatsTracer{
+		m:        m,
+		statsMap: table,
+	}, nil
+}
+
+// Close detaches the eBPF program
+func (t *TCPStatsTracer) Close() {
+	t.m.Close()
+}
+
+// Get returns the TCP counters per container
+func (t *TCPStatsTracer) Get() []tcpstats.Stats {
+	if t == nil {
+		return nil
+	}
+
+	var keys, leaves [][]byte
+	for it := t.statsMap.Iter(); it.Next(); {
+		keys = append(keys, it.Key())
+		leaves = append(leaves, it.Leaf())
+	}
+	return aggregateTCPStats(keys, leaves)
+}
+
+// GetAndFlush returns the TCP counters per container since the previous call,
+// only the entries returned are deleted so that the cgroups seen meanwhile are not lost
+func (t *TCPStatsTracer) GetAndFlush() []tcpstats.Stats {
+	if t == nil {
+		return nil
+	}
+
+	return aggregateTCPStats(drainBCCTable(t.statsMap))
+}
+
+// aggregateTCPStats sums the counters of the entries of the stats map per container,
+// the cgroups which aren't the ones of a container are counted together
+func aggregateTCPStats(keys [][]byte, leaves [][]byte) []tcpstats.Stats {
+	byContainer := make(map[string]*tcpstats.Stats)
+	for i := range keys {
+		var key C.struct_cgroup_key
+		var counters C.struct_tcp_counters
+
+		C.memcpy(unsafe.Pointer(&key), unsafe.Pointer(&keys[i][0]), C.sizeof_struct_cgroup_key)
+		C.memcpy(unsafe.Pointer(&counters), unsafe.Pointer(&leaves[i][0]), C.sizeof_struct_tcp_counters)
+
+		containerID := containerIDFromCgroupName(C.GoString(&key.cgroup_name[0]))
+		stats, ok := byContainer[containerID]
+		if !ok {
+			stats = &tcpstats.Stats{ContainerID: containerID}
+			byContainer[containerID] = stats
+		}
+		stats.Retransmits += uint64(counters.retransmits)
+		stats.SentResets += uint64(counters.sent_resets)
+		stats.ReceivedResets += uint64(counters.received_resets)
+	}
+
+	result := make([]tcpstats.Stats, 0, len(byContainer))
+	for _, stats := range byContainer {
+		result = append(result, *stats)
+	}
+	return result
+}
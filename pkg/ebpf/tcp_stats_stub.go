@@ -0,0 +1,26 @@
+// +build !linux_bpf linux_bpf,!bcc
+
+package ebpf
+
+import "github.com/DataDog/datadog-agent/pkg/ebpf/tcpstats"
+
+// TCPStatsTracer is not implemented on non-linux systems
+type TCPStatsTracer struct{}
+
+// NewTCPStatsTracer is not implemented on non-linux systems
+func NewTCPStatsTracer() (*TCPStatsTracer, error) {
+	return nil, ErrNotImplemented
+}
+
+// Close is not implemented on non-linux systems
+func (t *TCPStatsTracer) Close() {}
+
+// Get is not implemented on non-linux systems
+func (t *TCPStatsTracer) Get() []tcpstats.Stats {
+	return nil
+}
+
+// GetAndFlush is not implemented on non-linux systems
+func (t *TCPStatsTracer) GetAndFlush() []tcpstats.Stats {
+	return nil
+}
@@ -0,0 +1,10 @@
+package tcpstats
+
+// Stats contains the TCP counters of the processes of a container, the ones of the
+// processes running outside of a container have an empty ContainerID
+type Stats struct {
+	ContainerID    string `json:"containerid"`
+	Retransmits    uint64 `json:"retransmits"`
+	SentResets     uint64 `json:"sent_resets"`
+	ReceivedResets uint64 `json:"received_resets"`
+}
@@ -4,6 +4,10 @@
 // pkg/ebpf/c/tracer-ebpf-debug.o
 // pkg/ebpf/c/tcp-queue-length-kern.c
 // pkg/ebpf/c/tcp-queue-length-kern-user.h
+// pkg/ebpf/c/oom-kill-kern.c
+// pkg/ebpf/c/oom-kill-kern-user.h
+// pkg/ebpf/c/tcp-stats-kern.c
+// pkg/ebpf/c/tcp-stats-kern-user.h
 // DO NOT EDIT!
 
 package ebpf
@@ -151,6 +155,86 @@ func tcpQueueLengthKernUserH() (*asset, error) {
 	return a, nil
 }
 
+var _oomKillKernC = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x55\x5b\x6f\xdb\x36\x14\x7e\xd7\xaf\x38\x4d\x80\xc0\x32\x22\x67\x69\xd6\x97\x7a\xf1\x90\x22\xdd\x5a\x2c\xa9\x83\x36\x7d\x1a\x06\x9a\xa1\x69\x8b\x90\x44\x0a\x24\xd5\x24\x6d\xf3\xdf\x77\x0e\x29\xcb\xb2\xa0\xbe\xd8\x3c\xb7\xef\x3b\x37\x52\xc7\x4a\x8b\xb2\x59\x4b\xf8\xa3\x54\xba\x79\x3a\x2b\x84\xd1\x1b\xb5\x9d\xe5\x8b\xe4\x78\x2d\x37\x4a\x4b\xf8\xe7\xdd\xd7\x8f\x37\xd7\xec\x76\x79\xfd\xe9\xea\xf6\x3d\x1c\x6d\x8c\x39\x4a\x8e\x07\x71\xb5\xb7\x5c\xc8\x10\x36\xb0\x3c\xd4\x9b\x31\xb5\x13\xb9\x5c\x8f\x19\x8c\xa9\xc6\xd4\x62\x6b\x4d\x53\x93\x65\x6f\x3a\x42\xdf\xac\x50\x65\x99\x15\xd2\xea\xac\x71\xd2\xce\xf2\xa3\x24\x39\x9b\x26\x30\x85\xfb\x5c\xc2\x0a\x3d\x98\xf3\xdc\xbb\x15\x54\xbc\x06\xe5\x00\xbd\xd6\xe0\x0d\xb8\x9c\x5b\x09\x8f\xca\xe7\xe0\xd1\x93\x82\x4b\xae\xd7\x50\x5b\xb3\xb5\xbc\x02\xf7\xec\xbc\xac\x32\x14\x1f\x24\xc1\x91\x13\x0a\x42\x3a\x27\x1d\x10\x2b\xe2\x3c\x3c\x07\xfd\x72\x79\x1b\x35\xf6\x14\x94\x5e\xcb\xa7\xce\xa4\x2c\xd4\x6a\x8d\xf1\x67\xc9\xbb\xbb\xbf\xd8\x87\xab\x2f\x1f\x26\x5d\x4e\xa7\xd0\x5c\xbc\x3e\x05\xe7\x6d\x23\x3c\x74\xea\x74\x8e\x25\x9c\xc1\xfd\xf2\x7a\xf9\x16\xac\xac\x4b\xec\x2c\xf0\xb2\x84\x15\xf6\x92\x85\x84\x98\x95\x7c\xbd\x22\x8e\x81\x8e\x51\x27\x64\xb9\x02\xa3\x31\xe8\x51\x82\xe0\x1a\xb8\x73\x4d\x25\x31\x1d\xee\x49\x95\xf3\x6f\x88\xe7\xa1\x94\xdc\x79\x88\x01\xf0\x66\xf6\x26\x51\x1a\xc5\x88\xc5\x28\x1b\x2a\x89\xb5\x35\x4f\xda\x2c\x6b\x8f\x3c\x5b\x07\x53\xe1\x9f\x52\xf8\x91\x40\x3f\x7d\x5c\x1e\x6f\x4d\x09\x53\x23\xe0\x12\x26\x63\x96\xf4\xee\x9e\x7d\x7e\xff\xf7\x17\x76\x77\xf5\xf9\xf6\x7c\x42\x28\x58\x2e\x00\x16\x4c\x9d\xfc\xa6\x84\x57\x15\xcd\xc9\xe8\xf2\x19\x6a\x6e\x31\x7e\x33\xc6\xe1\x14\x55\xd8\x66\xff\xfb\xec\xfc\x62\x9f\x8a\xe7\xae\x60\xed\x79\x1a\x11\xe7\x68\x55\x1b\x98\x1c\x76\x6b\x72\x12\xad\x38\x03\xf5\x5d\x9a\xcd\x24\x8a\xe9\x29\x9c\x18\x91\x2d\x44\x6e\x9c\xd4\x29\xfc\xfc\xb9\xcb\xeb\xf2\x12\x3e\x7d\xbd\xb9\x49\x11\x0d\x70\x34\xbe\xb1\x1a\x7e\x0b\xf9\xe3\x24\x69\xd2\xc4\x33\xe4\x40\x75\x47\x80\x67\x42\x8f\x70\xd9\xc2\x6f\x51\x0e\xf1\xc3\x25\x80\xef\xd2\x1a\x6c\xe2\x8f\x97\xf9\x98\x75\xea\xd0\xd6\x89\xb3\xd2\x98\xa2\xa9\x99\xb1\x4c\x69\xe5\x5b\xca\x13\x82\x48\x77\x95\xbb\x2e\xf9\xc3\xc4\x5d\xb6\x40\x6f\x44\x6b\xb3\x47\xd9\x47\x05\xd5\xb1\x95\x9e\x89\xc6\x5a\xa9\x3d\x43\x2d\xa3\x84\x27\x29\x2c\x16\x70\xf1\x7a\x57\x6b\xdf\x47\x98\xaa\x9a\x9c\x10\x06\x9d\xba\xb2\x77\x8a\x34\x1d\x6b\x10\x5a\x87\xde\xc1\xb9\xd7\xa8\x20\xff\x22\xb6\xe6\x5b\xe9\xfa\xc1\x41\xb1\x1b\xa2\x37\x9e\x97\x51\xd3\x6f\x74\x25\x71\x95\xc2\x9b\x02\x53\x3c\x8b\x2d\xc4\xee\x8c\x71\x04\x7b\x47\x10\xa4\x1d\x7a\x14\xda\xbe\x05\x81\x6e\x0e\x62\x45\xcc\x57\x11\x14\xfe\x84\x73\x78\xdb\x36\xbc\xdd\x74\xcd\xf1\x4a\xe2\x6a\xd3\x19\x9d\x8d\x7d\x86\x36\x9f\x56\xb9\x5b\xcd\xc7\x5c\x89\x9c\x6e\x84\xc2\xb9\xd3\xf6\x73\x7c\x95\x2d\xe0\x88\x36\xc6\x06\xcf\x4e\xe9\xf6\xf5\x09\xe7\x98\x93\xb8\xff\xed\x61\xb7\x06\xaf\x86\xc5\xb5\xf6\xae\xbc\x56\x3e\x68\x7e\xc8\xcb\xa5\xf1\xbe\xef\x29\x82\x9a\xb9\xe6\x01\xdf\xca\xb0\x88\x32\xd0\xcd\x83\xd3\xaf\xc8\xfa\x44\x44\xd2\xf2\x65\x8b\x88\xf3\x6f\xec\x05\xcd\xa6\x66\x6a\xfd\x5f\x47\x3a\xa0\x45\x26\xf4\x98\xb7\xa6\x71\x32\x74\xd8\xb3\xa1\xd0\xd2\xed\x0a\xea\x41\x77\xe0\xf4\x9e\x6c\x1c\xd3\x06\x3f\x2f\xd3\x42\xcf\x3b\xfb\x28\x43\xa1\x3b\xfc\x42\x07\x74\x64\xc9\x16\x78\xee\x43\x03\x0d\x08\x5f\x5a\x81\x5f\x1c\x98\xd2\xdc\xe7\x3d\xdb\x28\x30\x39\x75\xd0\x24\x10\x78\xa1\xb3\x45\x38\x1f\x82\x0f\xf7\x95\x5e\xbe\x78\xa7\xe2\x7c\x0e\xb0\x0e\xd5\x88\x1a\xfe\xfa\xf9\xbc\x24\xc3\x53\xfc\xa7\xdf\x17\xda\xdf\xfd\xeb\xf1\x92\xfc\x0f\x32\x35\xa9\x23\x3f\x08\x00\x00")
+
+func oomKillKernCBytes() ([]byte, error) {
+	return bindataRead(
+		_oomKillKernC,
+		"oom-kill-kern.c",
+	)
+}
+
+func oomKillKernC() (*asset, error) {
+	bytes, err := oomKillKernCBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "oom-kill-kern.c", size: 2111, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _oomKillKernUserH = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x92\xc9\x4e\xc3\x30\x10\x86\xef\x7e\x8a\x91\x7a\x81\x0a\x11\x0a\x55\x2f\x45\x5c\x50\x25\x50\x37\x54\xc4\xa9\xaa\x2c\x37\x9e\x24\x16\x5e\x22\x2f\x94\x08\xf1\xee\xb8\x4d\xba\x50\xa5\x47\xcf\xf8\xff\xfe\xd9\x3a\x22\xd3\x1c\x33\x98\xcf\xa7\x74\xfc\x3a\x99\xd0\xf1\x68\x31\xa3\x1f\xef\xa3\x05\x7d\x21\x9d\x98\x11\x1a\xdb\x93\xa4\x23\x74\x2a\x03\x47\x78\x94\x42\x87\xef\xc4\x57\x25\xba\xdb\xe2\x89\xfc\xd3\x3d\xcf\xa7\x53\x3a\x19\xcd\xa0\x37\x20\xc4\x79\x1b\x52\x0f\xc6\x28\xea\x3c\xf3\x0e\x7e\x08\x40\x5a\x30\x0b\x69\x6e\x4d\x28\xa9\x66\x0a\x97\x83\xfe\x6a\x18\xe3\x49\x02\x6f\x82\x83\xc9\xc0\x17\x08\xa5\x35\x29\x3a\x07\x9f\x42\x4a\xe4\xb0\xae\x76\xd1\xe8\x50\x47\x6c\x14\x50\x1a\x1e\xee\xa1\x14\xfc\xb2\x7a\x53\x18\x87\xc0\xa4\x34\x29\xf3\xc2\x68\xf0\x56\xe4\x39\xda\x48\xbc\x80\xf3\x47\xde\x2c\x16\xb7\x07\x36\x65\x34\xdc\x43\x17\x46\xa9\xe5\x69\xd7\xab\x16\x69\x63\x29\x74\x7e\x2e\xf7\x97\xf5\x41\xad\xd1\x6e\x09\x25\xcb\xd1\xed\x51\x0a\x95\xb1\x15\xb0\x2f\x26\x24\x5b\xcb\xc8\x36\xed\xd5\xc5\x5e\x06\xfd\x5a\xdb\x10\x7b\x20\xb2\xb3\x9e\x61\xc3\xdc\xc9\x40\x9a\x11\x37\x1e\x52\x28\xe1\xb7\xbe\xac\xd9\xd5\x0d\xdc\xed\x11\x71\xaa\xd1\xdb\x55\xce\xa3\xda\x41\x4c\xd8\x7d\xad\xa5\x87\x51\xc6\x67\x9a\xd3\xb8\xfc\x21\xf9\x1d\xc6\x2b\x41\xcd\x23\x20\xe9\x42\x7d\x2e\xfc\xaa\xed\xce\xae\xa1\x9b\x90\x3f\xa4\x80\xf7\x07\xa6\x02\x00\x00")
+
+func oomKillKernUserHBytes() ([]byte, error) {
+	return bindataRead(
+		_oomKillKernUserH,
+		"oom-kill-kern-user.h",
+	)
+}
+
+func oomKillKernUserH() (*asset, error) {
+	bytes, err := oomKillKernUserHBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "oom-kill-kern-user.h", size: 678, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _tcpStatsKernC = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd5\x56\xdf\x6f\xdb\x36\x10\x7e\xf7\x5f\x71\x4d\x80\xcc\x36\x6c\xa7\xc5\x96\x3c\xcc\x8b\x81\x74\xe9\xd6\x61\x49\x13\xb4\xe9\xd3\x30\x30\xb2\x74\xb2\x09\x49\xa4\x46\x52\x49\xdc\x22\xff\xfb\xee\x44\x49\x91\x14\x35\xeb\x43\x81\xa1\x0f\x89\x49\xde\xf1\xee\xbb\xef\x7e\x50\xfb\x52\x85\x69\x11\x21\xfc\x92\x4a\x55\xdc\x1f\x26\xa1\x56\xb1\xdc\x2c\xb6\xab\xd1\x7e\x84\xb1\x54\x08\x7f\xbe\xfe\xf8\xc7\xf9\x99\xb8\xb8\x3c\x7b\x77\x7a\xf1\x06\xf6\x62\xad\xf7\x46\xfb\xbd\x7b\xb9\x33\x41\x88\xe5\xb5\x9e\x64\x9d\xc7\xdd\x63\x85\xee\xd0\xea\x30\xe1\xd3\xc7\xe3\x3d\x17\xe6\x73\xeb\x02\x67\xe7\x09\x1a\x35\x2f\x2c\x9a\xc5\x76\x6f\x34\x3a\x9c\x8e\x60\x0a\xd7\x5b\x84\x1b\x52\x11\xa5\xca\x0d\x64\x41\x0e\xd2\x02\x69\x45\xe0\x34\xd8\x6d\x60\x10\xee\xa4\xdb\x82\x23\x4d\xbe\x9c\x06\x2a\x82\xdc\xe8\x8d\x09\x32\xb0\x3b\xeb\x30\x9b\xd3\x76\x8d\x6c\x8e\x95\x0c\x12\x66\x65\x33\xe9\x2c\xb0\xae\x3f\xb3\x48\x5b\x1d\x97\xbb\xeb\x5f\xaf\x80\x91\xd2\xd1\x0c\xa4\x8a\xf0\x9e\xbc\xad\x77\xa5\x2c\xdc\x18\x5d\xe4\x95\xa6\x34\xec\x29\x44\x6b\xc9\xf8\xe1\xe8\xf5\xd5\x6f\xe2\xed\xe9\x87\xb7\xe3\x06\xf0\x0c\xac\x33\x45\xe8\xaa\x6b\x22\xc1\x5d\x73\xc4\x4a\xa1\x2e\x94\x43\x63\x27\xcb\x76\xc0\xcf\x20\xe4\x70\x33\x6d\x5d\xba\x83\x2d\x89\x52\x02\x26\x15\x81\x8d\x9d\x34\xff\x00\x25\xd1\xe1\xbd\x9b\xc1\xdd\x16\x49\x91\xee\xb1\xc9\xb0\x30\x06\x15\x39\x0c\x6c\x42\xe4\xa9\x1f\x5c\x69\x51\xdf\x29\x34\x75\xc8\x3e\xdc\x85\xe7\x9b\x37\xc2\x23\x7e\xc2\xb8\xc1\x8c\x18\xa9\xb9\xec\xb0\x51\x73\x41\xde\x65\xb8\x65\x30\x0a\x43\x87\x51\xcb\x01\x68\x03\x96\xb1\x44\x81\x0b\x40\xdf\x12\x00\xe9\x16\x5d\xf2\x5a\xde\x1b\xae\xf8\x0c\xa6\x83\x6c\x1e\x1f\x1d\xfd\x78\x5c\xf2\x77\x08\xd7\x97\x67\x97\x3f\x13\xc4\x3c\xa5\xa2\x84\x20\x4d\xe1\x86\xca\x50\x94\xe9\x17\x06\x83\xe8\x86\xd3\xd8\x3b\x13\x5c\x77\x98\xde\x80\x56\x74\xe9\x8e\x82\x0a\x14\x04\xd6\x16\x19\x33\x18\x38\x3e\xda\x06\xb7\x64\xcf\x41\x8a\x81\x75\xe0\x2f\xc0\xd1\xe2\x68\xc4\x59\x96\x21\xe5\x20\xe5\x96\xb9\xd5\x32\x62\x86\x30\x5b\xa3\x11\xad\x40\xc6\x9d\x38\x6c\x32\x81\xcf\x23\x00\x19\x43\x3b\xda\x45\xaa\x75\x42\xba\x07\x2c\x7f\x71\x02\xef\x3e\x9e\x9f\x4f\x48\x0d\xb8\x20\x0a\xa3\x28\x46\x78\xca\x00\xf0\xdf\x09\x7c\x7e\x68\x8b\x39\xd5\xa2\x5a\x4f\x29\xff\xc2\x51\xea\x4f\x60\x3c\x24\x9e\x30\x1d\x1b\x74\xa2\xaa\x13\xc1\xd2\x31\x31\xfa\xe8\xcc\x5a\x41\xd5\x47\x96\xfc\x62\x59\x61\x7f\xd1\x25\x72\x7c\x50\xc9\x29\x4f\xf2\x13\xea\x78\x5c\xed\x27\x33\x38\xa8\x40\xcc\x57\x1e\xb9\x9d\x78\x0a\xfa\x01\xd9\x62\x4d\x3d\x5b\x36\x0f\x96\xfe\x96\xa5\x52\x93\xdb\x33\x0d\x4a\x3b\x6a\x83\xb5\xa4\x1e\x31\x92\xfa\x20\x97\xc4\x29\x97\x58\x2c\x0d\x25\xc7\x5b\xa0\xae\x2f\x2f\x7e\x09\x66\x1b\x62\x09\xcf\x23\x9d\xaf\xfc\xf5\xbf\x5e\xfe\xdd\x00\xec\x41\x24\x54\x1b\x93\x2f\x2b\xd1\xb0\x7d\x52\x78\x74\x40\x9b\xca\x43\x1d\x7c\xcb\x74\x63\x9c\x6b\x2a\xb6\x42\x69\x9a\x89\xd3\x44\x2d\x1b\xf9\xa0\x87\x44\x35\xf6\x13\x55\x5a\x27\x2f\xf3\x15\xad\xdb\xa6\x81\x7b\x90\x28\x09\x69\x4a\xc2\x54\x05\x19\x2e\x5b\xb2\x41\xc3\xac\xd4\x98\xe6\x0d\x1b\x4f\xd4\x7c\x55\xae\xbb\xc6\x01\x7a\x8d\x44\xa1\x10\x34\xdc\x2d\xaa\x64\x76\x8c\xf5\xce\xc9\x6e\xf9\xd3\x46\xf4\x30\xea\xaf\xfc\x2f\xff\x7f\x28\xab\xbb\xd5\x2c\x45\x4e\x33\x04\xb9\x59\x18\x21\xee\xc8\xd2\x43\x39\x04\xea\x99\x2a\x62\xed\x7b\xb0\x6a\x1f\xeb\x27\x56\x25\xad\x67\x56\x77\x82\xf9\x29\x35\x6b\xad\x4b\x45\x1a\x08\x85\x4a\x14\x4d\xcc\x5a\x9f\xe7\xb0\x37\x15\xb1\xcf\xf2\x05\x22\x2d\xcc\x72\xb7\xab\x75\x38\xbe\xde\x80\x18\x18\xfd\x54\x4f\x7d\xc0\xc3\xd3\xe2\xb9\xce\x1f\x92\xd2\x45\xe1\x35\xbe\x30\x63\xea\x36\xae\xf4\x3a\x13\x47\x88\x75\x21\x53\x27\x95\xa0\x61\x16\xe6\xbb\x32\xaf\x94\xcb\xa4\x7a\xc2\x9a\x9c\x4e\x26\x9d\xb9\xd3\x0e\xec\x13\x1a\xdd\xe0\xf3\x39\x80\xe6\x65\xac\x80\x08\x0a\x59\x2a\xe9\x2a\xfb\x07\x7c\xc7\x67\x52\xd2\x2b\x91\xf8\xe2\x12\xde\x6c\xf9\x9c\xd4\xdc\xe4\x8e\x6a\x6e\xc3\xec\xb9\xfb\x0e\x3f\x35\x69\xad\x71\xe7\x8f\x26\x57\xd7\xe2\xfd\x9b\xdf\x3f\x88\xab\xd3\xf7\x17\xaf\xc6\x7c\xcf\x03\x1b\x1a\xd7\xc9\xa4\x05\xfa\xe5\x20\x20\x7a\xc7\xa2\xcc\x6e\xfe\x3f\x40\x54\x77\x8c\xe3\xf6\x58\x44\x48\x86\xf5\xce\x57\x7b\x48\xef\x9e\xf5\x92\x9f\xba\x12\xa7\xf5\x93\x28\x7a\x3a\xdf\x3c\x9a\x76\xed\x45\x98\xa2\x6f\xd9\xff\x66\xf7\xf1\x1b\x48\xd8\x64\xfd\xed\x61\x0d\x36\x22\xdd\x1e\x68\xc6\xc7\x4e\x09\xfb\x4d\x62\x77\x2a\x14\x31\xba\x70\x2b\xe8\x6b\x4c\x04\x11\x4f\xff\xf9\xaa\xf5\x01\x37\x83\x57\x5f\x57\x4a\x22\x08\x9d\xbc\xe5\x49\x4a\xef\xd0\x77\x16\x2f\x7f\xd2\x79\xe0\x5f\x17\xef\xf7\x18\xa3\xc1\x10\x29\x3f\xd1\x33\x71\xfe\x0b\x96\xee\xd5\xc4\x55\x0d\x00\x00")
+
+func tcpStatsKernCBytes() ([]byte, error) {
+	return bindataRead(
+		_tcpStatsKernC,
+		"tcp-stats-kern.c",
+	)
+}
+
+func tcpStatsKernC() (*asset, error) {
+	bytes, err := tcpStatsKernCBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "tcp-stats-kern.c", size: 3413, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _tcpStatsKernUserH = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x90\x41\x4b\x03\x31\x10\x85\xef\xf9\x15\x03\x7b\xa9\x3d\x98\x4b\xe9\x65\x45\x10\x29\x14\x84\x52\xba\xeb\x49\x24\xa4\xd9\x49\x37\xd8\x4c\x96\x64\x22\x16\xf1\xbf\x9b\xba\xb8\x14\xb4\xd7\x79\xdf\x7b\xf3\x66\x2a\x67\xa9\x43\x0b\xed\xe3\x56\x35\xed\x43\xdb\xa8\xa7\xd5\x6e\xa3\x9e\x9b\xd5\x4e\xad\x45\x55\x24\x47\x78\x45\x15\x95\x23\x73\xcc\x1d\xc2\xdd\xd1\x51\xfe\x90\x7c\x1a\x30\xdd\xf6\xf7\x42\x24\x8e\xd9\x30\x98\x43\x0c\x79\x50\x6f\x78\x82\x4f\x01\x60\x7a\x1d\x7f\x67\xa4\x3d\xbe\x2c\x17\xaf\xb5\xf8\xaa\x27\x9e\xcd\xa0\x4c\xc8\xc4\x18\xd3\x8f\x43\x4a\xd8\x64\xbf\xc7\x08\xc1\x42\x44\x8e\x9a\x92\x77\xcc\xd8\x41\xc2\x83\x47\xe2\x54\x28\xa5\xf2\x72\x71\x21\xa7\xfa\xaf\x35\x21\xa7\xe2\xa1\xb2\x24\x80\xde\x87\xc8\xa0\xc1\x04\x22\x34\xec\x02\x4d\x29\x67\x44\x8d\xf8\xb5\x94\x88\x06\xdd\x7b\xa9\x60\x63\xf0\xc0\x3d\xc2\x80\xa5\xf0\x45\x91\x51\x9f\x62\xce\x17\x56\x48\x9d\xb3\x20\xe7\x30\xfe\xb4\x9b\xfd\xfb\xd4\x1b\x98\x4b\xf1\x0d\xd8\x61\x46\x96\x95\x01\x00\x00")
+
+func tcpStatsKernUserHBytes() ([]byte, error) {
+	return bindataRead(
+		_tcpStatsKernUserH,
+		"tcp-stats-kern-user.h",
+	)
+}
+
+func tcpStatsKernUserH() (*asset, error) {
+	bytes, err := tcpStatsKernUserHBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "tcp-stats-kern-user.h", size: 405, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -207,6 +291,10 @@ var _bindata = map[string]func() (*asset, error){
 	"tracer-ebpf-debug.o":          tracerEbpfDebugO,
 	"tcp-queue-length-kern.c":      tcpQueueLengthKernC,
 	"tcp-queue-length-kern-user.h": tcpQueueLengthKernUserH,
+	"oom-kill-kern.c":              oomKillKernC,
+	"oom-kill-kern-user.h":         oomKillKernUserH,
+	"tcp-stats-kern.c":             tcpStatsKernC,
+	"tcp-stats-kern-user.h":        tcpStatsKernUserH,
 }
 
 // AssetDir returns the file names below a certain
@@ -250,8 +338,12 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
+	"oom-kill-kern-user.h":         {oomKillKernUserH, map[string]*bintree{}},
+	"oom-kill-kern.c":              {oomKillKernC, map[string]*bintree{}},
 	"tcp-queue-length-kern-user.h": {tcpQueueLengthKernUserH, map[string]*bintree{}},
 	"tcp-queue-length-kern.c":      {tcpQueueLengthKernC, map[string]*bintree{}},
+	"tcp-stats-kern-user.h":        {tcpStatsKernUserH, map[string]*bintree{}},
+	"tcp-stats-kern.c":             {tcpStatsKernC, map[string]*bintree{}},
 	"tracer-ebpf-debug.o":          {tracerEbpfDebugO, map[string]*bintree{}},
 	"tracer-ebpf.o":                {tracerEbpfO, map[string]*bintree{}},
 }}
@@ -240,7 +240,12 @@ func (t *Tracer) expvarStats() {
 		}
 
 		for name, stat := range stats {
-			for metric, val := range stat.(map[string]int64) {
+			// skip the values which aren't counters, like the mode
+			counters, ok := stat.(map[string]int64)
+			if !ok {
+				continue
+			}
+			for metric, val := range counters {
 				currVal := &expvar.Int{}
 				currVal.Set(val)
 				expvarEndpoints[name].Set(snakeToCapInitialCamel(metric), currVal)
@@ -631,6 +636,7 @@ func (t *Tracer) GetStats() (map[string]interface{}, error) {
 	conntrackStats := t.conntracker.GetStats()
 
 	return map[string]interface{}{
+		"mode":      TracerModeEBPF,
 		"conntrack": conntrackStats,
 		"state":     stateStats,
 		"tracer": map[string]int64{
@@ -30,6 +30,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -39,6 +40,8 @@ import (
 
 const (
 	routineDumpFilename = "go-routine-dump.log"
+	heapProfileFilename = "go-heap-profile.log"
+	taggerStateFilename = "tagger-state.json"
 
 	// Maximum size for the root directory name
 	directoryNameMaxSize = 32
@@ -47,6 +50,8 @@ const (
 var (
 	pprofURL = fmt.Sprintf("http://127.0.0.1:%s/debug/pprof/goroutine?debug=2",
 		config.Datadog.GetString("expvar_port"))
+	heapProfileURL = fmt.Sprintf("http://127.0.0.1:%s/debug/pprof/heap?debug=1",
+		config.Datadog.GetString("expvar_port"))
 	telemetryURL = fmt.Sprintf("http://127.0.0.1:%s/telemetry",
 		config.Datadog.GetString("expvar_port"))
 
@@ -83,18 +88,24 @@ type filePermsInfo struct {
 	group string
 }
 
-// CreateArchive packages up the files
-func CreateArchive(local bool, distPath, pyChecksPath, logFilePath string) (string, error) {
+// CreateArchive packages up the files included in the profile
+func CreateArchive(local bool, profile Profile, distPath, pyChecksPath, logFilePath string) (string, error) {
+	return CreateArchiveWithPerformanceProfiles(local, profile, distPath, pyChecksPath, logFilePath, nil)
+}
+
+// CreateArchiveWithPerformanceProfiles creates a flare like CreateArchive, with
+// the pprof profiles of the agent processes in its profiles directory
+func CreateArchiveWithPerformanceProfiles(local bool, profile Profile, distPath, pyChecksPath, logFilePath string, perfProfiles PerformanceProfiles) (string, error) {
 	zipFilePath := getArchivePath()
 	confSearchPaths := SearchPaths{
 		"":        config.Datadog.GetString("confd_path"),
 		"dist":    filepath.Join(distPath, "conf.d"),
 		"checksd": pyChecksPath,
 	}
-	return createArchive(zipFilePath, local, confSearchPaths, logFilePath)
+	return createArchive(zipFilePath, local, profile, confSearchPaths, logFilePath, perfProfiles)
 }
 
-func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string) (string, error) {
+func createArchive(zipFilePath string, local bool, profile Profile, confSearchPaths SearchPaths, logFilePath string, perfProfiles PerformanceProfiles) (string, error) {
 	b := make([]byte, 10)
 	_, err := rand.Read(b)
 	if err != nil {
@@ -108,6 +119,7 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 	}
 
 	defer os.RemoveAll(tempDir)
+	defer forgetScrubbedCounts(tempDir)
 
 	// Get hostname, if there's an error in getting the hostname,
 	// set the hostname to unknown
@@ -144,9 +156,11 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 		if err != nil {
 			return "", err
 		}
-		err = writeConfigCheck(tempDir, hostname, []byte("unable to get loaded checks config, is the agent running?"))
-		if err != nil {
-			return "", err
+		if profile.includesCheckConfigs() {
+			err = writeConfigCheck(tempDir, hostname, []byte("unable to get loaded checks config, is the agent running?"))
+			if err != nil {
+				return "", err
+			}
 		}
 	} else {
 		// Status informations are available, zip them up as the agent is running.
@@ -155,9 +169,18 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 			log.Errorf("Could not zip status: %s", err)
 		}
 
-		err = zipConfigCheck(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip config check: %s", err)
+		if profile.includesCheckConfigs() {
+			err = zipConfigCheck(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip config check: %s", err)
+			}
+		}
+
+		if profile.includesRuntimeState() {
+			err = zipTaggerState(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip tagger state: %s", err)
+			}
 		}
 	}
 
@@ -166,19 +189,24 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 		permsInfos.add(security.GetAuthTokenFilepath())
 	}
 
+	if !profile.includesCheckConfigs() {
+		confSearchPaths = nil
+	}
 	err = zipConfigFiles(tempDir, hostname, confSearchPaths, permsInfos)
 	if err != nil {
 		log.Errorf("Could not zip config: %s", err)
 	}
 
-	err = zipExpVar(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip exp var: %s", err)
-	}
+	if profile.includesRuntimeState() {
+		err = zipExpVar(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip exp var: %s", err)
+		}
 
-	err = zipDiagnose(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip diagnose: %s", err)
+		err = zipDiagnose(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip diagnose: %s", err)
+		}
 	}
 
 	err = zipSecrets(tempDir, hostname)
@@ -196,42 +224,60 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 		log.Errorf("Could not zip health check: %s", err)
 	}
 
-	if config.Datadog.GetBool("telemetry.enabled") {
+	if profile.includesRuntimeState() && config.Datadog.GetBool("telemetry.enabled") {
 		err = zipTelemetry(tempDir, hostname)
 		if err != nil {
 			log.Errorf("Could not collect telemetry metrics: %s", err)
 		}
 	}
 
-	err = zipStackTraces(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not collect go routine stack traces: %s", err)
+	if profile.includesStackTraces() {
+		err = zipStackTraces(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not collect go routine stack traces: %s", err)
+		}
 	}
 
-	if config.IsContainerized() {
-		err = zipDockerSelfInspect(tempDir, hostname)
+	if profile.includesHeapProfile() {
+		err = zipHeapProfile(tempDir, hostname)
 		if err != nil {
-			log.Errorf("Could not zip docker inspect: %s", err)
+			log.Errorf("Could not collect the heap profile: %s", err)
 		}
 	}
 
-	err = zipDockerPs(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip docker ps: %s", err)
-	}
+	if profile.includesRuntimeState() {
+		if config.IsContainerized() {
+			err = zipDockerSelfInspect(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip docker inspect: %s", err)
+			}
+		}
 
-	err = zipTypeperfData(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write typeperf data: %s", err)
+		err = zipDockerPs(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip docker ps: %s", err)
+		}
+
+		err = zipTypeperfData(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not write typeperf data: %s", err)
+		}
+		err = zipCounterStrings(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not write counter strings: %s", err)
+		}
 	}
-	err = zipCounterStrings(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write counter strings: %s", err)
+
+	if len(perfProfiles) > 0 {
+		err = writePerformanceProfiles(filepath.Join(tempDir, hostname), perfProfiles)
+		if err != nil {
+			log.Errorf("Could not write the performance profiles: %s", err)
+		}
 	}
 
 	// force a log flush before zipping them
 	log.Flush()
-	err = zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
+	err = zipLogFiles(tempDir, hostname, logFilePath, profile.includesRotatedLogs(), permsInfos)
 	if err != nil {
 		log.Errorf("Could not zip logs: %s", err)
 	}
@@ -241,6 +287,11 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 		log.Errorf("Could not write permissions.log file: %s", err)
 	}
 
+	// the manifest lists all the other files, it's written last
+	if err := writeManifest(tempDir, hostname, profile); err != nil {
+		log.Errorf("Could not write the flare manifest: %s", err)
+	}
+
 	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
 	if err != nil {
 		return "", err
@@ -275,7 +326,8 @@ func writeStatusFile(tempDir, hostname string, data []byte) error {
 	return err
 }
 
-func zipLogFiles(tempDir, hostname, logFilePath string, permsInfos permissionsInfos) error {
+// zipLogFiles copies the current log files, and the rotated ones if rotated is set
+func zipLogFiles(tempDir, hostname, logFilePath string, rotated bool, permsInfos permissionsInfos) error {
 	logFileDir := filepath.Dir(logFilePath)
 	err := filepath.Walk(logFileDir, func(src string, f os.FileInfo, err error) error {
 		if f == nil {
@@ -285,7 +337,7 @@ func zipLogFiles(tempDir, hostname, logFilePath string, permsInfos permissionsIn
 			return nil
 		}
 
-		if filepath.Ext(f.Name()) == ".log" || getFirstSuffix(f.Name()) == ".log" {
+		if filepath.Ext(f.Name()) == ".log" || (rotated && getFirstSuffix(f.Name()) == ".log") {
 			dst := filepath.Join(tempDir, hostname, "logs", f.Name())
 
 			if permsInfos != nil {
@@ -448,7 +500,7 @@ func zipDiagnose(tempDir, hostname string) error {
 	var b bytes.Buffer
 
 	writer := bufio.NewWriter(&b)
-	diagnose.RunAll(writer)
+	diagnose.RunLocal(writer)
 	writer.Flush()
 
 	f := filepath.Join(tempDir, hostname, "diagnose.log")
@@ -477,6 +529,34 @@ func zipConfigCheck(tempDir, hostname string) error {
 	return writeConfigCheck(tempDir, hostname, b.Bytes())
 }
 
+// zipTaggerState writes the tagger state, which can be loaded by the
+// tagger-replay command to reproduce the tag resolution offline
+func zipTaggerState(tempDir, hostname string) error {
+	state, err := tagger.ExportState()
+	if err != nil {
+		return err
+	}
+	jsonState, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, taggerStateFilename)
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	w, err := newRedactingWriter(f, os.ModePerm, true)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(jsonState)
+	return err
+}
+
 func writeConfigCheck(tempDir, hostname string, data []byte) error {
 	f := filepath.Join(tempDir, hostname, "config-check.log")
 	err := ensureParentDirsExist(f)
@@ -528,6 +608,10 @@ func zipStackTraces(tempDir, hostname string) error {
 	return zipHTTPCallContent(tempDir, hostname, routineDumpFilename, pprofURL)
 }
 
+func zipHeapProfile(tempDir, hostname string) error {
+	return zipHTTPCallContent(tempDir, hostname, heapProfileFilename, heapProfileURL)
+}
+
 // zipHTTPCallContent does a GET HTTP call to the given url and
 // writes the content of the HTTP response in the given file, ready
 // to be shipped in a flare.
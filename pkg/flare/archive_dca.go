@@ -25,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 // CreateDCAArchive packages up the files
@@ -51,6 +52,7 @@ func createDCAArchive(zipFilePath string, local bool, confSearchPaths SearchPath
 	}
 
 	defer os.RemoveAll(tempDir)
+	defer forgetScrubbedCounts(tempDir)
 
 	// Get hostname, if there's an error in getting the hostname,
 	// set the hostname to unknown
@@ -83,7 +85,7 @@ func createDCAArchive(zipFilePath string, local bool, confSearchPaths SearchPath
 
 	permsInfos := make(permissionsInfos)
 
-	err = zipLogFiles(tempDir, hostname, logFilePath, permsInfos)
+	err = zipLogFiles(tempDir, hostname, logFilePath, true, permsInfos)
 	if err != nil {
 		return "", err
 	}
@@ -153,7 +155,7 @@ func zipDCAStatusFile(tempDir, hostname string) error {
 	}
 
 	// Clean it up
-	cleaned, err := log.CredentialsCleanerBytes(s)
+	cleaned, err := scrubber.ScrubBytes("flare", s)
 	if err != nil {
 		log.Infof("Error redacting the log files: %q", err)
 		return err
@@ -32,7 +32,7 @@ func TestCreateArchive(t *testing.T) {
 	mockConfig.Set("confd_path", "./test/confd")
 	mockConfig.Set("log_file", "./test/logs/agent.log")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(zipFilePath, true, SearchPaths{}, "")
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{}, "", nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -56,7 +56,7 @@ func TestCreateArchiveAndGoRoutines(t *testing.T) {
 	pprofURL = ts.URL
 
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(zipFilePath, true, SearchPaths{}, "")
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{}, "", nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -100,7 +100,7 @@ func TestCreateArchiveAndGoRoutines(t *testing.T) {
 func TestCreateArchiveBadConfig(t *testing.T) {
 	common.SetupConfig("")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(zipFilePath, true, SearchPaths{}, "")
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{}, "", nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -154,7 +154,7 @@ func TestIncludeSystemProbeConfig(t *testing.T) {
 	defer os.Remove("./test/system-probe.yaml")
 
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(zipFilePath, true, SearchPaths{"": "./test/confd"}, "")
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{"": "./test/confd"}, "", nil)
 	assert.NoError(err)
 	assert.Equal(zipFilePath, filePath)
 
@@ -182,7 +182,7 @@ func TestIncludeConfigFiles(t *testing.T) {
 
 	common.SetupConfig("./test")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(zipFilePath, true, SearchPaths{"": "./test/confd"}, "")
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{"": "./test/confd"}, "", nil)
 
 	assert.NoError(err)
 	assert.Equal(zipFilePath, filePath)
@@ -246,3 +246,61 @@ func TestCleanDirectoryName(t *testing.T) {
 	assert.Len(t, cleanedHostname, directoryNameMaxSize)
 	assert.True(t, !directoryNameFilter.MatchString(cleanedHostname))
 }
+
+func TestCreateArchiveMinimalProfile(t *testing.T) {
+	common.SetupConfig("./test")
+
+	routinesServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "goroutines")
+	}))
+	defer routinesServer.Close()
+	pprofURL = routinesServer.URL
+
+	zipFilePath := getArchivePath()
+	filePath, err := createArchive(zipFilePath, true, ProfileMinimal, SearchPaths{"": "./test/confd"}, "", nil)
+	assert.NoError(t, err)
+	defer os.Remove(filePath)
+
+	manifest, err := ReadManifest(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileMinimal, manifest.Profile)
+
+	z, err := zip.OpenReader(filePath)
+	assert.NoError(t, err)
+	defer z.Close()
+	// every file of the archive but the manifest is listed in it
+	assert.Len(t, manifest.Files, len(z.File)-1)
+	for _, f := range z.File {
+		assert.NotEqual(t, routineDumpFilename, path.Base(f.Name))
+		assert.NotEqual(t, "config-check.log", path.Base(f.Name))
+		assert.NotContains(t, f.Name, "confd")
+	}
+}
+
+func TestManifestScrubbedCount(t *testing.T) {
+	common.SetupConfig("./test")
+	confd, err := ioutil.TempDir("", "confd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(confd)
+	err = ioutil.WriteFile(filepath.Join(confd, "conf.yaml"), []byte("instances:\n  - host: localhost\n    password: MySecurePass\n"), 0644)
+	assert.NoError(t, err)
+
+	zipFilePath := getArchivePath()
+	filePath, err := createArchive(zipFilePath, true, ProfileStandard, SearchPaths{"": confd}, "", nil)
+	assert.NoError(t, err)
+	defer os.Remove(filePath)
+
+	manifest, err := ReadManifest(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileStandard, manifest.Profile)
+	scrubbed := map[string]int{}
+	for _, e := range manifest.Files {
+		scrubbed[e.Path] = e.Scrubbed
+	}
+	assert.Equal(t, 1, scrubbed["etc/confd/conf.yaml"])
+
+	var b strings.Builder
+	manifest.Print(&b)
+	assert.Contains(t, b.String(), "etc/confd/conf.yaml")
+	assert.Contains(t, b.String(), "with the standard profile")
+}
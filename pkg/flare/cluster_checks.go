@@ -88,9 +88,13 @@ func GetClusterChecks(w io.Writer) error {
 	fmt.Fprintln(w, fmt.Sprintf("=== %d node-agents reporting ===", len(cr.Nodes)))
 	sort.Slice(cr.Nodes, func(i, j int) bool { return cr.Nodes[i].Name < cr.Nodes[j].Name })
 	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(table, "\nName\tRunning checks")
+	fmt.Fprintln(table, "\nName\tZone\tRunning checks")
 	for _, n := range cr.Nodes {
-		fmt.Fprintf(table, "%s\t%d\n", n.Name, len(n.Configs))
+		zone := ""
+		if n.Identity != nil {
+			zone = n.Identity.Zone
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\n", n.Name, zone, len(n.Configs))
 	}
 	table.Flush()
 
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/fatih/color"
 
@@ -92,6 +93,99 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 	return nil
 }
 
+// GetConfigResolution prints how the templates resolved against the services
+// known by the running agent
+func GetConfigResolution(w io.Writer) error {
+	if w != color.Output {
+		color.NoColor = true
+	}
+
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%v:%v/agent/config-check/resolution", ipcAddress, config.Datadog.GetInt("cmd_port"))
+	r, err := util.DoGet(c, url)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			return fmt.Errorf("the agent ran into an error while explaining the config resolution: %s", string(r))
+		}
+		return fmt.Errorf("failed to query the agent (running?): %s", err)
+	}
+
+	cr := response.ConfigResolutionResponse{}
+	err = json.Unmarshal(r, &cr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, fmt.Sprintf("=== %s ===", color.GreenString("Template resolution")))
+	if len(cr.Templates) == 0 {
+		fmt.Fprintln(w, "\nNo template loaded")
+	}
+	for _, tpl := range cr.Templates {
+		PrintTemplateResolution(w, tpl)
+	}
+	return nil
+}
+
+// PrintTemplateResolution prints a human-readable representation of the
+// resolution of a template
+func PrintTemplateResolution(w io.Writer, tpl integration.TemplateResolution) {
+	fmt.Fprintln(w, fmt.Sprintf("\n=== %s template ===", color.GreenString(tpl.Name)))
+	fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration provider"), color.CyanString(tpl.Provider)))
+	fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Configuration source"), color.CyanString(tpl.Source)))
+	fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Auto-discovery IDs"), color.CyanString(strings.Join(tpl.ADIdentifiers, ", "))))
+
+	for _, id := range tpl.UnmatchedADIdentifiers {
+		fmt.Fprintln(w, fmt.Sprintf("%s: no service found with this AD identifier", color.YellowString(id)))
+	}
+	for _, c := range tpl.FilteredContainers {
+		container := c.Name
+		if c.Namespace != "" {
+			container = c.Namespace + "/" + c.Name
+		}
+		fmt.Fprintln(w, fmt.Sprintf("* container %s (image %s) is excluded by the container filters", color.YellowString(container), c.Image))
+	}
+
+	for _, svc := range tpl.Services {
+		status := color.GreenString("resolved")
+		if !svc.Resolved {
+			status = color.RedString("rejected")
+		}
+		listener := svc.Listener
+		if listener == "" {
+			listener = "unknown"
+		}
+		fmt.Fprintln(w, fmt.Sprintf("\n%s: %s", color.BlueString("Service"), svc.Entity))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s, matched on %s", color.BlueString("Listener"), listener, svc.ADIdentifier))
+		fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Status"), status))
+		if svc.Error != "" {
+			fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Reason"), color.RedString(svc.Error)))
+		}
+		if svc.MetricsExcluded {
+			fmt.Fprintln(w, "Metrics collection is excluded by the container filters")
+		}
+		if svc.LogsExcluded {
+			fmt.Fprintln(w, "Logs collection is excluded by the container filters")
+		}
+		for _, v := range svc.Variables {
+			if v.Error != "" {
+				fmt.Fprintln(w, fmt.Sprintf("* instance %d: %s -> %s", v.Instance, v.Variable, color.RedString(v.Error)))
+			} else {
+				fmt.Fprintln(w, fmt.Sprintf("* instance %d: %s -> %s", v.Instance, v.Variable, color.CyanString(v.Value)))
+			}
+		}
+	}
+}
+
 // GetClusterAgentConfigCheck proxies GetConfigCheck overidding the URL
 func GetClusterAgentConfigCheck(w io.Writer, withDebug bool) error {
 	configCheckURL = fmt.Sprintf("https://localhost:%v/config-check", config.Datadog.GetInt("cluster_agent.cmd_port"))
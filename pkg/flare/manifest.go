@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const manifestFilename = "flare_manifest.yaml"
+
+// ManifestEntry describes a file of a flare
+type ManifestEntry struct {
+	Path     string `yaml:"path"`
+	Size     int64  `yaml:"size"`
+	Scrubbed int    `yaml:"scrubbed"`
+}
+
+// Manifest lists the files of a flare and the number of values redacted in
+// each of them, so that the content can be reviewed before it is sent
+type Manifest struct {
+	Profile Profile         `yaml:"profile"`
+	Files   []ManifestEntry `yaml:"files"`
+}
+
+var (
+	// scrubbedCounts holds the number of values redacted in the files written by
+	// the redacting writers, until they're listed in the manifest
+	scrubbedCounts      = make(map[string]int)
+	scrubbedCountsMutex sync.Mutex
+)
+
+func recordScrubbedCount(filePath string, count int) {
+	scrubbedCountsMutex.Lock()
+	defer scrubbedCountsMutex.Unlock()
+	scrubbedCounts[filePath] += count
+}
+
+func popScrubbedCount(filePath string) int {
+	scrubbedCountsMutex.Lock()
+	defer scrubbedCountsMutex.Unlock()
+	count := scrubbedCounts[filePath]
+	delete(scrubbedCounts, filePath)
+	return count
+}
+
+// forgetScrubbedCounts drops the counts of the files of a directory
+func forgetScrubbedCounts(dir string) {
+	scrubbedCountsMutex.Lock()
+	defer scrubbedCountsMutex.Unlock()
+	for filePath := range scrubbedCounts {
+		if strings.HasPrefix(filePath, dir) {
+			delete(scrubbedCounts, filePath)
+		}
+	}
+}
+
+// writeManifest lists the files of the flare and writes the manifest next to them,
+// the redacting writers must be closed beforehand
+func writeManifest(tempDir, hostname string, profile Profile) error {
+	root := filepath.Join(tempDir, hostname)
+	manifest := Manifest{Profile: profile}
+	err := filepath.Walk(root, func(src string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, src)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, ManifestEntry{
+			Path:     filepath.ToSlash(rel),
+			Size:     f.Size(),
+			Scrubbed: popScrubbedCount(src),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(root, manifestFilename), content, os.ModePerm)
+}
+
+// ReadManifest returns the manifest of a flare archive
+func ReadManifest(archivePath string) (Manifest, error) {
+	var manifest Manifest
+	z, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return manifest, err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if path.Base(f.Name) != manifestFilename {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return manifest, err
+		}
+		defer r.Close()
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return manifest, err
+		}
+		err = yaml.Unmarshal(content, &manifest)
+		return manifest, err
+	}
+	return manifest, fmt.Errorf("no manifest found in the flare %s", archivePath)
+}
+
+// Print writes the files of the manifest with their size and number of
+// scrubbed values, followed by the totals
+func (m Manifest) Print(w io.Writer) {
+	table := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(table, "File\tSize\tScrubbed values")
+	scrubbed := 0
+	for _, e := range m.Files {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", e.Path, e.Size, e.Scrubbed)
+		scrubbed += e.Scrubbed
+	}
+	table.Flush()
+	fmt.Fprintf(w, "\n%d files with the %s profile, %d values scrubbed\n", len(m.Files), m.Profile, scrubbed)
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mholt/archiver"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// pprofDirectory holds the performance profiles in the archives
+	pprofDirectory = "profiles"
+	// pprofErrorsFilename lists the profiles that couldn't be collected
+	pprofErrorsFilename = "errors.log"
+	// defaultProcessExpVarPort is the default expvar port of the process agent
+	defaultProcessExpVarPort = 6062
+)
+
+// PerformanceProfiles maps the file names of the pprof profiles of the agent
+// processes to their content
+type PerformanceProfiles map[string][]byte
+
+// pprofTarget is an agent process serving the pprof endpoints
+type pprofTarget struct {
+	name    string
+	baseURL string
+}
+
+// pprofProfile is a profile collected from each process
+type pprofProfile struct {
+	name string
+	path string
+}
+
+// pprofTargets returns the agent processes to profile: the core agent, and the
+// trace and process agents when they're enabled
+func pprofTargets() []pprofTarget {
+	targets := []pprofTarget{
+		{"core", fmt.Sprintf("http://127.0.0.1:%s/debug/pprof", config.Datadog.GetString("expvar_port"))},
+	}
+
+	if config.Datadog.GetBool("apm_config.enabled") {
+		apmPort := "8126"
+		if config.Datadog.IsSet("apm_config.receiver_port") {
+			apmPort = config.Datadog.GetString("apm_config.receiver_port")
+		}
+		targets = append(targets, pprofTarget{"trace", fmt.Sprintf("http://127.0.0.1:%s/debug/pprof", apmPort)})
+	}
+
+	if config.Datadog.GetString("process_config.enabled") != "disabled" {
+		processPort := defaultProcessExpVarPort
+		if config.Datadog.IsSet("process_config.expvar_port") {
+			processPort = config.Datadog.GetInt("process_config.expvar_port")
+		}
+		targets = append(targets, pprofTarget{"process", fmt.Sprintf("http://127.0.0.1:%d/debug/pprof", processPort)})
+	}
+
+	return targets
+}
+
+// CollectPerformanceProfiles collects the CPU profile over duration, then the
+// heap, goroutine and mutex profiles of the agent processes. The profiles that
+// couldn't be collected, e.g. of a process that isn't running, are listed in
+// the errors file of the profiles.
+func CollectPerformanceProfiles(duration time.Duration) PerformanceProfiles {
+	return collectPerformanceProfiles(pprofTargets(), duration)
+}
+
+func collectPerformanceProfiles(targets []pprofTarget, duration time.Duration) PerformanceProfiles {
+	seconds := int(duration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	profiles := []pprofProfile{
+		{"cpu", fmt.Sprintf("profile?seconds=%d", seconds)},
+		{"heap", "heap"},
+		{"goroutine", "goroutine"},
+		{"mutex", "mutex"},
+	}
+	client := &http.Client{Timeout: time.Duration(seconds)*time.Second + 30*time.Second}
+
+	var (
+		m      sync.Mutex
+		wg     sync.WaitGroup
+		errs   []string
+		result = make(PerformanceProfiles)
+	)
+	// the CPU profiles of the processes are collected at the same time
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target pprofTarget) {
+			defer wg.Done()
+			for _, profile := range profiles {
+				data, err := getPprof(client, target.baseURL+"/"+profile.path)
+
+				m.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s %s profile: %s", target.name, profile.name, err))
+				} else {
+					result[fmt.Sprintf("%s-%s.pprof", target.name, profile.name)] = data
+				}
+				m.Unlock()
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		result[pprofErrorsFilename] = []byte(strings.Join(errs, "\n") + "\n")
+	}
+	return result
+}
+
+func getPprof(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// CreatePerformanceProfileArchive zips the profiles in a temporary archive and
+// returns its path
+func CreatePerformanceProfileArchive(profiles PerformanceProfiles) (string, error) {
+	tempDir, err := ioutil.TempDir("", "datadog-agent-profile")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := writePerformanceProfiles(tempDir, profiles); err != nil {
+		return "", err
+	}
+
+	zipFilePath := filepath.Join(os.TempDir(), fmt.Sprintf("datadog-agent-profile-%s.zip", time.Now().Format("2006-01-02-15-04-05")))
+	if err := archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, pprofDirectory)}); err != nil {
+		return "", err
+	}
+	return zipFilePath, nil
+}
+
+// writePerformanceProfiles writes the profiles in the profiles directory of dir,
+// they're binary and aren't scrubbed
+func writePerformanceProfiles(dir string, profiles PerformanceProfiles) error {
+	profilesDir := filepath.Join(dir, pprofDirectory)
+	if err := os.MkdirAll(profilesDir, os.ModePerm); err != nil {
+		return err
+	}
+	for name, data := range profiles {
+		if err := ioutil.WriteFile(filepath.Join(profilesDir, filepath.Base(name)), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectPerformanceProfiles(t *testing.T) {
+	var cpuQuery string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		cpuQuery = r.URL.RawQuery
+		w.Write([]byte("cpu"))
+	})
+	mux.HandleFunc("/debug/pprof/heap", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("heap"))
+	})
+	mux.HandleFunc("/debug/pprof/goroutine", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("goroutine"))
+	})
+	mux.HandleFunc("/debug/pprof/mutex", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "mutex profiling is disabled", 500)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	targets := []pprofTarget{
+		{"core", ts.URL + "/debug/pprof"},
+		// nothing listens on the port of the closed server
+		{"trace", "http://127.0.0.1:0/debug/pprof"},
+	}
+	profiles := collectPerformanceProfiles(targets, 2*time.Second)
+
+	assert.Equal(t, "seconds=2", cpuQuery)
+	assert.Equal(t, []byte("cpu"), profiles["core-cpu.pprof"])
+	assert.Equal(t, []byte("heap"), profiles["core-heap.pprof"])
+	assert.Equal(t, []byte("goroutine"), profiles["core-goroutine.pprof"])
+	assert.NotContains(t, profiles, "core-mutex.pprof")
+	assert.NotContains(t, profiles, "trace-cpu.pprof")
+
+	errs := string(profiles[pprofErrorsFilename])
+	assert.Contains(t, errs, "core mutex profile: unexpected response 500 Internal Server Error: mutex profiling is disabled")
+	assert.Contains(t, errs, "trace cpu profile:")
+	assert.Contains(t, errs, "trace mutex profile:")
+}
+
+func TestCreatePerformanceProfileArchive(t *testing.T) {
+	profiles := PerformanceProfiles{
+		"core-cpu.pprof":     []byte("cpu"),
+		"process-heap.pprof": []byte("heap"),
+	}
+
+	filePath, err := CreatePerformanceProfileArchive(profiles)
+	require.NoError(t, err)
+	defer os.Remove(filePath)
+
+	r, err := zip.OpenReader(filePath)
+	require.NoError(t, err)
+	defer r.Close()
+
+	contents := make(map[string]string)
+	var names []string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		names = append(names, f.Name)
+		contents[filepath.Base(f.Name)] = string(data)
+	}
+	sort.Strings(names)
+
+	assert.Equal(t, []string{"profiles/core-cpu.pprof", "profiles/process-heap.pprof"}, names)
+	assert.Equal(t, "cpu", contents["core-cpu.pprof"])
+	assert.Equal(t, "heap", contents["process-heap.pprof"])
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+)
+
+// Profile controls the content of a flare
+type Profile string
+
+// Flare profiles
+const (
+	// ProfileMinimal only includes the status, the agent configuration and the
+	// current log files, without the check configurations and the runtime state
+	ProfileMinimal Profile = "minimal"
+	// ProfileStandard includes everything support usually needs, it's the default
+	ProfileStandard Profile = "standard"
+	// ProfileFull adds the heap profile of the agent to the standard content
+	ProfileFull Profile = "full"
+)
+
+// ParseProfile returns the profile with the given name, the standard one if it's empty
+func ParseProfile(name string) (Profile, error) {
+	switch p := Profile(name); p {
+	case "":
+		return ProfileStandard, nil
+	case ProfileMinimal, ProfileStandard, ProfileFull:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown flare profile %q, expected %s, %s or %s", name, ProfileMinimal, ProfileStandard, ProfileFull)
+}
+
+// includesCheckConfigs returns whether the configurations of the checks,
+// from conf.d and as loaded by the agent, are included
+func (p Profile) includesCheckConfigs() bool {
+	return p != ProfileMinimal
+}
+
+// includesRuntimeState returns whether the expvars, the telemetry, the tagger
+// state, the diagnose, the docker and the performance counters are included
+func (p Profile) includesRuntimeState() bool {
+	return p != ProfileMinimal
+}
+
+// includesStackTraces returns whether the goroutine dump is included
+func (p Profile) includesStackTraces() bool {
+	return p != ProfileMinimal
+}
+
+// includesRotatedLogs returns whether the rotated log files are included on
+// top of the current ones
+func (p Profile) includesRotatedLogs() bool {
+	return p != ProfileMinimal
+}
+
+// includesHeapProfile returns whether the heap profile is included
+func (p Profile) includesHeapProfile() bool {
+	return p == ProfileFull
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 //RedactingWriter is a writer that will redact content before writing to target
@@ -21,6 +22,7 @@ type RedactingWriter struct {
 	targetBuf *bufio.Writer
 	perm      os.FileMode
 	r         []log.Replacer
+	scrubbed  int
 }
 
 //NewRedactingWriter instantiates a RedactingWriter to target with given permissions
@@ -78,16 +80,22 @@ func (f *RedactingWriter) Write(p []byte) (int, error) {
 		return 0, errors.New("No viable target defined")
 	}
 
-	cleaned, err := log.CredentialsCleanerBytes(p)
+	cleaned, count, err := scrubber.Default().ScrubBytes(p)
 	if err != nil {
 		return 0, err
 	}
 
 	for _, r := range f.r {
 		if r.Regex != nil && r.ReplFunc != nil {
-			cleaned = r.Regex.ReplaceAllFunc(cleaned, r.ReplFunc)
+			replFunc := r.ReplFunc
+			cleaned = r.Regex.ReplaceAllFunc(cleaned, func(b []byte) []byte {
+				count++
+				return replFunc(b)
+			})
 		}
 	}
+	scrubber.AddScrubbedFields("flare", count)
+	f.scrubbed += count
 
 	var n int
 	if buffered {
@@ -103,6 +111,11 @@ func (f *RedactingWriter) Write(p []byte) (int, error) {
 	return len(p), err
 }
 
+//ScrubbedCount returns the number of values redacted by the writer
+func (f *RedactingWriter) ScrubbedCount() int {
+	return f.scrubbed
+}
+
 //Truncate truncates the file of the target file to the specified size
 func (f *RedactingWriter) Truncate(size int64) error {
 	return f.target.Truncate(size)
@@ -130,6 +143,7 @@ func (f *RedactingWriter) Close() error {
 	}
 
 	if f.target != nil {
+		recordScrubbedCount(f.target.Name(), f.scrubbed)
 		err = f.target.Close()
 	}
 
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var tlmAPIKeyRotations = telemetry.NewCounter("forwarder", "api_key_rotations",
+	[]string{"domain", "reason"}, "Count of the API keys replaced by the forwarder")
+
+// apiKeyRotation validates the API keys of the forwarder periodically, replacing the
+// revoked ones by the next rotation keys of their domain. It also fetches the secrets
+// again, so that the API keys rotated in the secrets backend are used without
+// restarting the agent.
+type apiKeyRotation struct {
+	forwarder *DefaultForwarder
+	interval  time.Duration
+
+	m         sync.Mutex
+	spareKeys map[string][]string // rotation keys not used yet, by domain
+
+	validate       func(domain, apiKey string) (bool, error)
+	refreshSecrets func() (map[string]string, error)
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newAPIKeyRotation(f *DefaultForwarder, spareKeys map[string][]string) *apiKeyRotation {
+	interval := config.Datadog.GetDuration("forwarder_apikey_validation_interval") * time.Second
+	if interval <= 0 {
+		log.Warnf("Configured forwarder_apikey_validation_interval (%v) is not positive; 600 seconds will be used", interval)
+		interval = 600 * time.Second
+	}
+
+	// the validation updates the API key statuses shown in the status page
+	fh := &forwarderHealth{timeout: validateAPIKeyTimeout}
+	return &apiKeyRotation{
+		forwarder: f,
+		interval:  interval,
+		spareKeys: spareKeys,
+		validate: func(domain, apiKey string) (bool, error) {
+			return fh.validateAPIKey(apiKey, apiDomainOf(domain))
+		},
+		refreshSecrets: secrets.Refresh,
+	}
+}
+
+func (r *apiKeyRotation) start() {
+	secretsReloader.register(r)
+	r.stop = make(chan struct{})
+	r.stopped = make(chan struct{})
+	go r.rotationLoop()
+}
+
+func (r *apiKeyRotation) stopRotation() {
+	close(r.stop)
+	<-r.stopped
+	secretsReloader.deregister(r)
+}
+
+func (r *apiKeyRotation) rotationLoop() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	defer close(r.stopped)
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.reloadSecrets()
+			r.rotate()
+		}
+	}
+}
+
+// reloadSecrets fetches the secrets again, the API keys that changed are replaced by
+// the refresh handler. The forwarders share the refreshes, so the secrets are not
+// fetched again if another forwarder did it less than half an interval ago.
+func (r *apiKeyRotation) reloadSecrets() {
+	secretsReloader.reload(r.refreshSecrets, r.interval/2)
+}
+
+// replaceSecrets replaces the API keys changed in the secrets backend.
+func (r *apiKeyRotation) replaceSecrets(handles []string, changed map[string]string) {
+	for oldKey, newKey := range changed {
+		for domain, apiKeys := range r.forwarder.apiKeys() {
+			for _, apiKey := range apiKeys {
+				if apiKey == oldKey {
+					log.Infof("API key ending with %s for domain %s reloaded from the secrets backend", obfuscateAPIKey(newKey), domain)
+					r.forwarder.replaceAPIKey(domain, oldKey, newKey)
+					tlmAPIKeyRotations.Inc(domain, "secrets_reload")
+				}
+			}
+		}
+
+		r.m.Lock()
+		for _, apiKeys := range r.spareKeys {
+			for i, apiKey := range apiKeys {
+				if apiKey == oldKey {
+					apiKeys[i] = newKey
+				}
+			}
+		}
+		r.m.Unlock()
+		// the rotation keys are validated by the health check too
+		if r.forwarder.healthChecker != nil {
+			r.forwarder.healthChecker.replaceAPIKey(oldKey, newKey)
+		}
+	}
+}
+
+// rotate replaces the revoked API keys by the next valid rotation key of their domain.
+// The keys that can't be validated, e.g. during a network outage, are kept.
+func (r *apiKeyRotation) rotate() {
+	for domain, apiKeys := range r.forwarder.apiKeys() {
+		for _, apiKey := range apiKeys {
+			valid, err := r.validate(domain, apiKey)
+			if err != nil {
+				log.Debugf("Could not validate the API key ending with %s for domain %s: %v", obfuscateAPIKey(apiKey), domain, err)
+				continue
+			}
+			if valid {
+				continue
+			}
+
+			nextKey, found := r.nextKey(domain)
+			if !found {
+				log.Errorf("The API key ending with %s for domain %s is invalid, and there's no rotation key left to replace it", obfuscateAPIKey(apiKey), domain)
+				continue
+			}
+			log.Warnf("The API key ending with %s for domain %s is invalid, replacing it by the API key ending with %s", obfuscateAPIKey(apiKey), domain, obfuscateAPIKey(nextKey))
+			r.forwarder.replaceAPIKey(domain, apiKey, nextKey)
+			tlmAPIKeyRotations.Inc(domain, "revoked")
+		}
+	}
+}
+
+// nextKey pops the next rotation key of the domain, skipping the invalid ones.
+func (r *apiKeyRotation) nextKey(domain string) (string, bool) {
+	for {
+		r.m.Lock()
+		if len(r.spareKeys[domain]) == 0 {
+			r.m.Unlock()
+			return "", false
+		}
+		apiKey := r.spareKeys[domain][0]
+		r.spareKeys[domain] = r.spareKeys[domain][1:]
+		r.m.Unlock()
+
+		if valid, err := r.validate(domain, apiKey); err == nil && !valid {
+			log.Warnf("The rotation API key ending with %s for domain %s is invalid, skipping it", obfuscateAPIKey(apiKey), domain)
+			continue
+		}
+		return apiKey, true
+	}
+}
+
+// apiKeyReloader refreshes the secrets on behalf of all the forwarders. A refresh notifies
+// the refresh handlers once, whoever triggered it, so a single handler fans the API keys
+// that changed out to the rotations of every running forwarder.
+type apiKeyReloader struct {
+	m            sync.Mutex
+	rotations    map[*apiKeyRotation]struct{}
+	lastRefresh  time.Time
+	registerOnce sync.Once
+}
+
+var secretsReloader = &apiKeyReloader{rotations: make(map[*apiKeyRotation]struct{})}
+
+func (l *apiKeyReloader) register(r *apiKeyRotation) {
+	// the secrets can also be refreshed by the other components, e.g. to reschedule the checks
+	l.registerOnce.Do(func() { secrets.RegisterRefreshHandler(l.replaceSecrets) })
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.rotations[r] = struct{}{}
+}
+
+func (l *apiKeyReloader) deregister(r *apiKeyRotation) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	delete(l.rotations, r)
+}
+
+// reload refreshes the secrets, unless they were refreshed less than minInterval ago.
+func (l *apiKeyReloader) reload(refresh func() (map[string]string, error), minInterval time.Duration) {
+	l.m.Lock()
+	if time.Since(l.lastRefresh) < minInterval {
+		l.m.Unlock()
+		return
+	}
+	l.lastRefresh = time.Now()
+	l.m.Unlock()
+
+	if _, err := refresh(); err != nil {
+		log.Warnf("Could not reload the API keys from the secrets backend: %v", err)
+	}
+}
+
+// replaceSecrets replaces the API keys changed in the secrets backend in the
+// configuration and in all the forwarders.
+func (l *apiKeyReloader) replaceSecrets(handles []string, changed map[string]string) {
+	if newKey, found := changed[config.Datadog.GetString("api_key")]; found {
+		config.Datadog.Set("api_key", newKey)
+	}
+
+	l.m.Lock()
+	rotations := make([]*apiKeyRotation, 0, len(l.rotations))
+	for r := range l.rotations {
+		rotations = append(rotations, r)
+	}
+	l.m.Unlock()
+	for _, r := range rotations {
+		r.replaceSecrets(handles, changed)
+	}
+}
+
+// obfuscateAPIKey returns the last 5 characters of an API key, to be logged
+func obfuscateAPIKey(apiKey string) string {
+	if len(apiKey) > 5 {
+		return apiKey[len(apiKey)-5:]
+	}
+	return apiKey
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func newTestAPIKeyRotation(valid map[string]bool) *DefaultForwarder {
+	options := NewOptions(map[string][]string{testDomain: {"api-key-1", "api-key-2"}})
+	options.RotationKeysPerDomain = map[string][]string{testDomain: {"api-key-3", "api-key-4"}}
+	f := NewDefaultForwarder(options)
+	f.apiKeyRotation.validate = func(domain, apiKey string) (bool, error) {
+		if _, found := valid[apiKey]; !found {
+			return false, fmt.Errorf("unknown API key")
+		}
+		return valid[apiKey], nil
+	}
+	return f
+}
+
+func TestAPIKeyRotation(t *testing.T) {
+	f := newTestAPIKeyRotation(map[string]bool{"api-key-1": false, "api-key-2": true, "api-key-3": false, "api-key-4": true})
+	require.NotNil(t, f.apiKeyRotation)
+	assert.Equal(t, []string{"api-key-3", "api-key-4"}, f.apiKeyRotation.spareKeys[testVersionDomain])
+	assert.ElementsMatch(t, []string{"api-key-1", "api-key-2", "api-key-3", "api-key-4"}, f.healthChecker.keysPerDomains[testDomain])
+
+	// the revoked key is replaced by the next valid rotation key
+	f.apiKeyRotation.rotate()
+	assert.Equal(t, []string{"api-key-4", "api-key-2"}, f.apiKeys()[testVersionDomain])
+	assert.Empty(t, f.apiKeyRotation.spareKeys[testVersionDomain])
+
+	p := []byte("A payload")
+	transactions := f.createHTTPTransactions(seriesEndpoint, Payloads{&p}, false, nil)
+	require.Len(t, transactions, 2)
+	assert.Equal(t, "api-key-4", transactions[0].Headers.Get(apiHTTPHeaderKey))
+	assert.Equal(t, "api-key-2", transactions[1].Headers.Get(apiHTTPHeaderKey))
+}
+
+func TestAPIKeyRotationNoKeyLeft(t *testing.T) {
+	f := newTestAPIKeyRotation(map[string]bool{"api-key-1": false, "api-key-3": false, "api-key-4": false})
+
+	// the keys that can't be validated are kept
+	f.apiKeyRotation.rotate()
+	assert.Equal(t, []string{"api-key-1", "api-key-2"}, f.apiKeys()[testVersionDomain])
+	assert.Empty(t, f.apiKeyRotation.spareKeys[testVersionDomain])
+}
+
+func TestAPIKeyRotationReloadSecrets(t *testing.T) {
+	f := newTestAPIKeyRotation(nil)
+	f.healthChecker.init()
+	f.apiKeyRotation.replaceSecrets([]string{"key2", "key3"}, map[string]string{"api-key-2": "api-key-5", "api-key-3": "api-key-6"})
+	assert.Equal(t, []string{"api-key-1", "api-key-5"}, f.apiKeys()[testVersionDomain])
+	assert.Equal(t, []string{"api-key-6", "api-key-4"}, f.apiKeyRotation.spareKeys[testVersionDomain])
+	assert.ElementsMatch(t, []string{"api-key-1", "api-key-5", "api-key-6", "api-key-4"}, f.healthChecker.keysPerAPIEndpoint["https://api.datadoghq.com"])
+}
+
+func TestAPIKeyReloaderFansOut(t *testing.T) {
+	config.Datadog.Set("api_key", "api-key-2")
+	defer config.Datadog.Set("api_key", nil)
+
+	reloader := &apiKeyReloader{rotations: make(map[*apiKeyRotation]struct{})}
+	f1 := newTestAPIKeyRotation(nil)
+	f2 := newTestAPIKeyRotation(nil)
+	reloader.rotations[f1.apiKeyRotation] = struct{}{}
+	reloader.rotations[f2.apiKeyRotation] = struct{}{}
+
+	refreshes := 0
+	refresh := func() (map[string]string, error) {
+		refreshes++
+		changed := map[string]string{"api-key-2": "api-key-5"}
+		reloader.replaceSecrets([]string{"key2"}, changed)
+		return changed, nil
+	}
+	reloader.reload(refresh, time.Minute)
+	// the second forwarder doesn't refresh the secrets again
+	reloader.reload(refresh, time.Minute)
+
+	assert.Equal(t, 1, refreshes)
+	assert.Equal(t, []string{"api-key-1", "api-key-5"}, f1.apiKeys()[testVersionDomain])
+	assert.Equal(t, []string{"api-key-1", "api-key-5"}, f2.apiKeys()[testVersionDomain])
+	assert.Equal(t, "api-key-5", config.Datadog.GetString("api_key"))
+}
+
+func TestNoAPIKeyRotation(t *testing.T) {
+	f := NewDefaultForwarder(NewOptions(keysPerDomains))
+	assert.Nil(t, f.apiKeyRotation)
+}
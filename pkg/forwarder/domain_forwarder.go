@@ -53,6 +53,8 @@ type domainForwarder struct {
 	workers             []*Worker
 	retryQueue          []Transaction
 	retryQueueLimit     int
+	storage             *transactionStorage // stores the transactions that don't fit in the retry queue, nil when disabled
+	failover            *endpointFailover   // fails over to the fallback URLs of the domain, nil when it has none
 	internalState       uint32
 	m                   sync.Mutex // To control Start/Stop races
 
@@ -69,11 +71,16 @@ func newDomainForwarder(domain string, numberOfWorkers int, retryQueueLimit int)
 	}
 }
 
-type byCreatedTime []Transaction
+type byPriorityAndCreatedTime []Transaction
 
-func (v byCreatedTime) Len() int           { return len(v) }
-func (v byCreatedTime) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
-func (v byCreatedTime) Less(i, j int) bool { return v[i].GetCreatedAt().After(v[j].GetCreatedAt()) }
+func (v byPriorityAndCreatedTime) Len() int      { return len(v) }
+func (v byPriorityAndCreatedTime) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
+func (v byPriorityAndCreatedTime) Less(i, j int) bool {
+	if pi, pj := v[i].GetPriority(), v[j].GetPriority(); pi != pj {
+		return pi > pj
+	}
+	return v[i].GetCreatedAt().After(v[j].GetCreatedAt())
+}
 
 func (f *domainForwarder) retryTransactions(retryBefore time.Time) {
 	// In case it takes more that flushInterval to sort and retry
@@ -85,10 +92,11 @@ func (f *domainForwarder) retryTransactions(retryBefore time.Time) {
 	defer atomic.StoreInt32(&f.isRetrying, 0)
 
 	newQueue := []Transaction{}
+	toStore := []Transaction{}
 	droppedRetryQueueFull := 0
 	droppedWorkerBusy := 0
 
-	sort.Sort(byCreatedTime(f.retryQueue))
+	sort.Sort(byPriorityAndCreatedTime(f.retryQueue))
 
 	for _, t := range f.retryQueue {
 		if !f.blockedList.isBlock(t.GetTarget()) {
@@ -105,6 +113,8 @@ func (f *domainForwarder) retryTransactions(retryBefore time.Time) {
 			newQueue = append(newQueue, t)
 			transactionsRequeued.Add(1)
 			tlmTxRequeud.Inc(f.domain)
+		} else if f.storage != nil {
+			toStore = append(toStore, t)
 		} else {
 			droppedRetryQueueFull++
 			transactionsDropped.Add(1)
@@ -112,6 +122,19 @@ func (f *domainForwarder) retryTransactions(retryBefore time.Time) {
 		}
 	}
 
+	// the transactions that don't fit in the retry queue are stored on disk,
+	// and loaded back once the queue has room for them
+	if len(toStore) > 0 {
+		f.storage.store(toStore)
+	} else if len(newQueue) < f.retryQueueLimit {
+		for _, t := range f.storage.load(f.retryQueueLimit - len(newQueue)) {
+			if httpTransaction, ok := t.(*HTTPTransaction); ok {
+				httpTransaction.failover = f.failover
+			}
+			newQueue = append(newQueue, t)
+		}
+	}
+
 	f.retryQueue = newQueue
 	transactionsRetryQueueSize.Set(int64(len(f.retryQueue)))
 	tlmTxRetryQueueSize.Set(float64(len(f.retryQueue)), f.domain)
@@ -172,12 +195,35 @@ func (f *domainForwarder) Start() error {
 		f.workers = append(f.workers, w)
 	}
 	go f.handleFailedTransactions()
+	if f.failover != nil {
+		f.failover.start()
+	}
 
 	f.internalState = Started
 	return nil
 }
 
-// Stop stops a domainForwarder, all transactions not yet flushed will be lost.
+// storeRetryQueue stores on disk the transactions still to retry,
+// so that they are retried after a restart.
+func (f *domainForwarder) storeRetryQueue() {
+	if f.storage == nil {
+		return
+	}
+	// the workers are stopped, no transaction is requeued or sent anymore:
+	// the transactions still queued are stored along with the retry queue
+	for _, queue := range []chan Transaction{f.requeuedTransaction, f.highPrio, f.lowPrio} {
+		for len(queue) > 0 {
+			f.retryQueue = append(f.retryQueue, <-queue)
+		}
+	}
+	if len(f.retryQueue) > 0 {
+		log.Infof("Storing %d transactions to retry for %s on disk", len(f.retryQueue), f.domain)
+		f.storage.store(f.retryQueue)
+	}
+}
+
+// Stop stops a domainForwarder, all transactions not yet flushed will be lost
+// unless they are stored on disk.
 func (f *domainForwarder) Stop(purgeHighPrio bool) {
 	// Lock so we can't start a Forwarder while is stopping
 	f.m.Lock()
@@ -189,9 +235,13 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 	}
 
 	f.stopRetry <- true
+	if f.failover != nil {
+		f.failover.stopChecks()
+	}
 	for _, w := range f.workers {
 		w.Stop(purgeHighPrio)
 	}
+	f.storeRetryQueue()
 	f.workers = []*Worker{}
 	f.retryQueue = []Transaction{}
 	close(f.highPrio)
@@ -6,6 +6,8 @@
 package forwarder
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
@@ -220,3 +222,60 @@ func TestForwarderRetryLimitQueue(t *testing.T) {
 	// assert that the oldest transaction was dropped
 	assert.Equal(t, transaction2, forwarder.retryQueue[0])
 }
+
+func TestForwarderRetryStoresTheTransactionsOnDisk(t *testing.T) {
+	path, err := ioutil.TempDir("", "transactions")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	forwarder := newDomainForwarder("test", 1, 10)
+	forwarder.init()
+	forwarder.retryQueueLimit = 1
+	forwarder.storage, err = newTransactionStorage(path, "test", []string{"api-key-1"}, 1024*1024, 1)
+	require.NoError(t, err)
+
+	series := newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)
+	metadata := newStoredTestTransaction("/api/v2/metadata", TransactionPriorityHigh)
+	forwarder.blockedList.close(series.GetTarget())
+	forwarder.blockedList.errorPerEndpoint[series.GetTarget()].until = time.Now().Add(1 * time.Minute)
+	forwarder.blockedList.close(metadata.GetTarget())
+	forwarder.blockedList.errorPerEndpoint[metadata.GetTarget()].until = time.Now().Add(1 * time.Minute)
+
+	forwarder.requeueTransaction(series)
+	forwarder.requeueTransaction(metadata)
+	forwarder.retryTransactions(time.Now())
+
+	// the metadata are kept in memory and the series are stored on disk
+	require.Len(t, forwarder.retryQueue, 1)
+	assert.Equal(t, metadata, forwarder.retryQueue[0])
+	assert.False(t, forwarder.storage.isEmpty())
+
+	// the series are loaded back once the retry queue has room
+	forwarder.blockedList.errorPerEndpoint[metadata.GetTarget()].until = time.Now().Add(-1 * time.Minute)
+	forwarder.retryTransactions(time.Now())
+	assert.Len(t, forwarder.lowPrio, 1)
+	require.Len(t, forwarder.retryQueue, 1)
+	assert.Equal(t, series.Endpoint, forwarder.retryQueue[0].(*HTTPTransaction).Endpoint)
+	assert.True(t, forwarder.storage.isEmpty())
+}
+
+func TestForwarderStopStoresTheQueuedTransactions(t *testing.T) {
+	path, err := ioutil.TempDir("", "transactions")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	forwarder := newDomainForwarder("test", 1, 10)
+	forwarder.init()
+	forwarder.storage, err = newTransactionStorage(path, "test", []string{"api-key-1"}, 1024*1024, 10)
+	require.NoError(t, err)
+
+	forwarder.highPrio <- newStoredTestTransaction("/api/v2/metadata", TransactionPriorityHigh)
+	forwarder.lowPrio <- newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)
+	forwarder.requeuedTransaction <- newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)
+	forwarder.storeRetryQueue()
+
+	assert.Len(t, forwarder.highPrio, 0)
+	assert.Len(t, forwarder.lowPrio, 0)
+	assert.Len(t, forwarder.requeuedTransaction, 0)
+	assert.Len(t, forwarder.storage.load(10), 3)
+}
@@ -0,0 +1,295 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// The health statuses of the endpoints
+const (
+	endpointHealthUnknown   = "unknown"
+	endpointHealthHealthy   = "healthy"
+	endpointHealthUnhealthy = "unhealthy"
+)
+
+// datadogDomains are the domains the API key is sent to by the health checks,
+// the other URLs are checked without it.
+var datadogDomains = []string{"datadoghq.com", "datadoghq.eu", "datad0g.com", "datad0g.eu"}
+
+var (
+	endpointsHealth = expvar.Map{}
+
+	tlmEndpointHealthy = telemetry.NewGauge("forwarder", "endpoint_healthy",
+		[]string{"domain", "url"}, "1 if the endpoint answered its last health check, 0 otherwise")
+	tlmFailovers = telemetry.NewCounter("forwarder", "failovers",
+		[]string{"domain", "url"}, "Count of the switches of the domains to another of their endpoints")
+)
+
+func initEndpointFailoverExpvars() {
+	endpointsHealth.Init()
+	forwarderExpvars.Set("EndpointsHealth", &endpointsHealth)
+}
+
+// endpointFailover checks the health of a domain and of its fallback URLs by validating
+// an API key against them periodically. The transactions of the domain are sent to the
+// first healthy fallback URL once the domain failed `forwarder_failover_failure_threshold`
+// consecutive checks, and back to the domain once it passed
+// `forwarder_failover_recovery_threshold` consecutive checks.
+type endpointFailover struct {
+	domain            string
+	urls              []string // the domain followed by its fallback URLs, in order
+	apiKey            string
+	interval          time.Duration
+	failureThreshold  int
+	recoveryThreshold int
+	isHealthy         func(url string) bool
+	client            *http.Client
+
+	m          sync.RWMutex
+	active     int      // index of the URL the transactions are sent to
+	health     []string // health status of each URL
+	failures   int      // consecutive failed checks of the active URL
+	recoveries int      // consecutive successful checks of the domain while it's failed over
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func newEndpointFailover(domain string, fallbackURLs []string, apiKey string) *endpointFailover {
+	interval := config.Datadog.GetDuration("forwarder_failover_check_interval") * time.Second
+	if interval <= 0 {
+		log.Warnf("Configured forwarder_failover_check_interval (%v) is not positive; 30 seconds will be used", interval)
+		interval = 30 * time.Second
+	}
+	failureThreshold := config.Datadog.GetInt("forwarder_failover_failure_threshold")
+	if failureThreshold <= 0 {
+		log.Warnf("Configured forwarder_failover_failure_threshold (%v) is not positive; 3 will be used", failureThreshold)
+		failureThreshold = 3
+	}
+	recoveryThreshold := config.Datadog.GetInt("forwarder_failover_recovery_threshold")
+	if recoveryThreshold <= 0 {
+		log.Warnf("Configured forwarder_failover_recovery_threshold (%v) is not positive; 5 will be used", recoveryThreshold)
+		recoveryThreshold = 5
+	}
+
+	urls := []string{domain}
+	for _, url := range fallbackURLs {
+		url = strings.TrimSuffix(strings.TrimSpace(url), "/")
+		if url == "" {
+			continue
+		}
+		// the fallback URLs of Datadog are versioned like the domains
+		versionedURL, err := config.AddAgentVersionToDomain(url, "app")
+		if err != nil {
+			log.Warnf("Invalid fallback URL '%s' for domain '%s': %v", url, domain, err)
+			continue
+		}
+		urls = append(urls, versionedURL)
+	}
+
+	e := &endpointFailover{
+		domain:            domain,
+		urls:              urls,
+		apiKey:            apiKey,
+		interval:          interval,
+		failureThreshold:  failureThreshold,
+		recoveryThreshold: recoveryThreshold,
+		health:            make([]string, len(urls)),
+		client: &http.Client{
+			Transport: httputils.CreateHTTPTransport(),
+			Timeout:   validateAPIKeyTimeout,
+		},
+	}
+	for i := range e.health {
+		e.health[i] = endpointHealthUnknown
+	}
+	e.isHealthy = e.validate
+	endpointsHealth.Set(domain, expvar.Func(func() interface{} { return e.healthStatus() }))
+	return e
+}
+
+// activeURL returns the URL the transactions of the domain are sent to.
+func (e *endpointFailover) activeURL() string {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	return e.urls[e.active]
+}
+
+// endpointsHealthStatus is the health of the URLs of a domain, shown in the status page
+type endpointsHealthStatus struct {
+	Active    string
+	Endpoints map[string]string
+}
+
+func (e *endpointFailover) healthStatus() endpointsHealthStatus {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	status := endpointsHealthStatus{
+		Active:    e.urls[e.active],
+		Endpoints: make(map[string]string, len(e.urls)),
+	}
+	for i, url := range e.urls {
+		status.Endpoints[url] = e.health[i]
+	}
+	return status
+}
+
+func (e *endpointFailover) start() {
+	e.stop = make(chan struct{})
+	e.stopped = make(chan struct{})
+	go e.checkLoop()
+}
+
+func (e *endpointFailover) stopChecks() {
+	close(e.stop)
+	<-e.stopped
+}
+
+func (e *endpointFailover) checkLoop() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	defer close(e.stopped)
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.checkEndpoints()
+		}
+	}
+}
+
+// checkEndpoints checks the active URL, and the domain while it's failed over, and
+// switches to another URL when the thresholds are reached.
+func (e *endpointFailover) checkEndpoints() {
+	e.m.RLock()
+	active := e.active
+	e.m.RUnlock()
+
+	healthy := e.check(active)
+	if active != 0 {
+		// the domain is checked until it recovers
+		domainHealthy := e.check(0)
+		e.m.Lock()
+		if domainHealthy {
+			e.recoveries++
+		} else {
+			e.recoveries = 0
+		}
+		recovered := e.recoveries >= e.recoveryThreshold
+		e.m.Unlock()
+		if recovered {
+			e.switchTo(0)
+			return
+		}
+	}
+
+	e.m.Lock()
+	if healthy {
+		e.failures = 0
+	} else {
+		e.failures++
+	}
+	failedOver := e.failures >= e.failureThreshold
+	e.m.Unlock()
+	if !failedOver {
+		return
+	}
+
+	// the other URLs are tried in order, the domain has just been checked if it's failed over
+	for i := range e.urls {
+		if i == active || (i == 0 && active != 0) {
+			continue
+		}
+		if e.check(i) {
+			e.switchTo(i)
+			return
+		}
+	}
+	log.Warnf("The endpoint %s of the domain %s is unhealthy, but none of its fallback URLs is healthy", e.urls[active], e.domain)
+}
+
+// check checks the health of the i-th URL and records it.
+func (e *endpointFailover) check(i int) bool {
+	url := e.urls[i]
+	healthy := e.isHealthy(url)
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	if healthy {
+		e.health[i] = endpointHealthHealthy
+		tlmEndpointHealthy.Set(1, e.domain, url)
+	} else {
+		e.health[i] = endpointHealthUnhealthy
+		tlmEndpointHealthy.Set(0, e.domain, url)
+	}
+	return healthy
+}
+
+func (e *endpointFailover) switchTo(i int) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	if i == 0 {
+		log.Infof("The domain %s is healthy again, sending its transactions to it instead of %s", e.domain, e.urls[e.active])
+	} else {
+		log.Warnf("The endpoint %s is unhealthy, sending the transactions of the domain %s to %s", e.urls[e.active], e.domain, e.urls[i])
+	}
+	e.active = i
+	e.failures = 0
+	e.recoveries = 0
+	tlmFailovers.Inc(e.domain, e.urls[i])
+}
+
+// validate returns true if the URL answers the API key validation, whether the key is valid or not.
+func (e *endpointFailover) validate(endpointURL string) bool {
+	req, err := http.NewRequest("GET", endpointURL+v1ValidateEndpoint.route, nil)
+	if err != nil {
+		log.Debugf("Could not check the health of %s: %v", endpointURL, err)
+		return false
+	}
+	if isDatadogURL(endpointURL) {
+		req.Header.Set(apiHTTPHeaderKey, e.apiKey)
+	}
+	req.Header.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		log.Debugf("Could not check the health of %s: %v", endpointURL, err)
+		return false
+	}
+	resp.Body.Close()
+	// the server responds 200 if the key is valid or 403 if invalid
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusForbidden
+}
+
+// isDatadogURL returns true if the host of the URL is a Datadog domain.
+func isDatadogURL(endpointURL string) bool {
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	for _, domain := range datadogDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEndpointFailover(healthy map[string]bool) *endpointFailover {
+	e := newEndpointFailover("https://domain.com", []string{"https://fallback1.com", " https://fallback2.com/ "}, "api_key")
+	e.failureThreshold = 2
+	e.recoveryThreshold = 2
+	e.isHealthy = func(url string) bool { return healthy[url] }
+	return e
+}
+
+func TestEndpointFailoverURLs(t *testing.T) {
+	e := newTestEndpointFailover(nil)
+	assert.Equal(t, []string{"https://domain.com", "https://fallback1.com", "https://fallback2.com"}, e.urls)
+	assert.Equal(t, "https://domain.com", e.activeURL())
+
+	status := e.healthStatus()
+	assert.Equal(t, "https://domain.com", status.Active)
+	assert.Equal(t, endpointHealthUnknown, status.Endpoints["https://fallback2.com"])
+}
+
+func TestEndpointFailoverAndFailBack(t *testing.T) {
+	healthy := map[string]bool{"https://domain.com": true, "https://fallback1.com": false, "https://fallback2.com": true}
+	e := newTestEndpointFailover(healthy)
+
+	e.checkEndpoints()
+	assert.Equal(t, "https://domain.com", e.activeURL())
+
+	// the domain fails over once the failure threshold is reached, to the first healthy fallback URL
+	healthy["https://domain.com"] = false
+	e.checkEndpoints()
+	assert.Equal(t, "https://domain.com", e.activeURL())
+	e.checkEndpoints()
+	assert.Equal(t, "https://fallback2.com", e.activeURL())
+
+	status := e.healthStatus()
+	assert.Equal(t, endpointHealthUnhealthy, status.Endpoints["https://domain.com"])
+	assert.Equal(t, endpointHealthUnhealthy, status.Endpoints["https://fallback1.com"])
+	assert.Equal(t, endpointHealthHealthy, status.Endpoints["https://fallback2.com"])
+
+	// the domain fails back once the recovery threshold is reached, a failed check resetting it
+	healthy["https://domain.com"] = true
+	e.checkEndpoints()
+	healthy["https://domain.com"] = false
+	e.checkEndpoints()
+	healthy["https://domain.com"] = true
+	e.checkEndpoints()
+	assert.Equal(t, "https://fallback2.com", e.activeURL())
+	e.checkEndpoints()
+	assert.Equal(t, "https://domain.com", e.activeURL())
+}
+
+func TestEndpointFailoverNoHealthyFallback(t *testing.T) {
+	e := newTestEndpointFailover(map[string]bool{})
+	for i := 0; i < 5; i++ {
+		e.checkEndpoints()
+	}
+	assert.Equal(t, "https://domain.com", e.activeURL())
+}
+
+func TestEndpointFailoverValidate(t *testing.T) {
+	status := http.StatusForbidden
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/validate", r.URL.Path)
+		// the API key is not sent to the URLs out of the Datadog domains
+		assert.Empty(t, r.Header.Get(apiHTTPHeaderKey))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	e := newEndpointFailover(ts.URL, []string{"https://fallback1.com"}, "api_key")
+	client := e.client
+	assert.True(t, e.validate(ts.URL))
+	status = http.StatusServiceUnavailable
+	assert.False(t, e.validate(ts.URL))
+	assert.Equal(t, client, e.client)
+}
+
+func TestIsDatadogURL(t *testing.T) {
+	assert.True(t, isDatadogURL("https://7-21-0-app.agent.datadoghq.com"))
+	assert.True(t, isDatadogURL("https://app.datadoghq.eu:443"))
+	assert.True(t, isDatadogURL("https://datad0g.com"))
+	assert.False(t, isDatadogURL("https://datadoghq.com.example.com"))
+	assert.False(t, isDatadogURL("https://notdatadoghq.com"))
+	assert.False(t, isDatadogURL("http://127.0.0.1:8080"))
+}
+
+func TestHTTPTransactionFailover(t *testing.T) {
+	e := newTestEndpointFailover(map[string]bool{"https://fallback1.com": true})
+	transaction := NewHTTPTransaction()
+	transaction.Domain = "https://domain.com"
+	transaction.Endpoint = "/api/v1/series"
+	transaction.failover = e
+	assert.Equal(t, "https://domain.com/api/v1/series", transaction.GetTarget())
+
+	e.checkEndpoints()
+	e.checkEndpoints()
+	require.Equal(t, "https://fallback1.com", e.activeURL())
+	assert.Equal(t, "https://fallback1.com/api/v1/series", transaction.GetTarget())
+}
@@ -0,0 +1,557 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// exportFileExtension is the extension of the complete export files, their name is
+	// made of the time they were created so that they are replayed in order
+	exportFileExtension = ".ddexport"
+	// exportTempExtension is the extension of the export file being written
+	exportTempExtension = ".tmp"
+	// exportProgressExtension is the extension of the file recording the payloads of an
+	// export file already accepted by each domain, when it was partially replayed
+	exportProgressExtension = ".progress"
+	// exportFormatVersion is the version of the format of the export files
+	exportFormatVersion = 1
+	// exportRecordHeaderSize is the size of the header of a record: the length and the checksum of its body
+	exportRecordHeaderSize = 4 + 4
+)
+
+var (
+	transactionsExported = expvar.Int{}
+
+	tlmTxExported = telemetry.NewCounter("transactions", "exported",
+		[]string{"endpoint"}, "Count of payloads written to the export files")
+
+	// exportMagic identifies the export files.
+	exportMagic = []byte("DDEX")
+
+	errNotExported = errors.New("these payloads can't be exported, they are dropped")
+
+	// exportedEndpoints are the endpoints of the payloads written to the export files, by name.
+	// The payloads of the processes and containers are not exported: their responses
+	// drive the collection intervals of the process-agent.
+	exportedEndpoints = map[string]exportedEndpoint{
+		seriesEndpoint.name:        {seriesEndpoint, false},
+		eventsEndpoint.name:        {eventsEndpoint, false},
+		serviceChecksEndpoint.name: {serviceChecksEndpoint, false},
+		sketchSeriesEndpoint.name:  {sketchSeriesEndpoint, true},
+		hostMetadataEndpoint.name:  {hostMetadataEndpoint, false},
+		metadataEndpoint.name:      {metadataEndpoint, false},
+		v1SeriesEndpoint.name:      {v1SeriesEndpoint, true},
+		v1CheckRunsEndpoint.name:   {v1CheckRunsEndpoint, true},
+		v1IntakeEndpoint.name:      {v1IntakeEndpoint, true},
+	}
+)
+
+func initExportExpvars() {
+	transactionsExpvars.Set("Exported", &transactionsExported)
+}
+
+type exportedEndpoint struct {
+	endpoint            endpoint
+	apiKeyInQueryString bool
+}
+
+// ExportForwarder is a Forwarder writing the payloads to files of a local directory
+// instead of sending them, for the hosts that are not connected to Datadog. The files
+// are sent later by ReplayExport, from a connected host.
+//
+// An export file starts with the magic number "DDEX" and the version of the format
+// (1 byte), followed by a record per payload:
+//   - the length of the body of the record (4 bytes, big endian)
+//   - the CRC32 (IEEE) checksum of the body (4 bytes, big endian)
+//   - the body: the length of the name of the endpoint (1 byte), the name of the
+//     endpoint, the length of the extra HTTP headers (4 bytes, big endian), the extra
+//     HTTP headers encoded in JSON, and the payload, as it would have been sent
+//
+// The API keys are not written to the export files.
+type ExportForwarder struct {
+	path             string
+	maxFileSize      int64
+	rotationInterval time.Duration
+
+	m         sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	filename  string
+	size      int64
+	createdAt time.Time
+	timeNow   func() time.Time
+}
+
+// Compile-time check to ensure that ExportForwarder implements the Forwarder interface
+var _ Forwarder = &ExportForwarder{}
+
+// NewExportForwarder returns a new ExportForwarder writing the payloads to path. The
+// export files are complete once they reach `forwarder_export_max_file_size` bytes, or
+// once they are `forwarder_export_rotation_interval` seconds old.
+func NewExportForwarder(path string) *ExportForwarder {
+	return &ExportForwarder{
+		path:             path,
+		maxFileSize:      config.Datadog.GetInt64("forwarder_export_max_file_size"),
+		rotationInterval: config.Datadog.GetDuration("forwarder_export_rotation_interval") * time.Second,
+		timeNow:          time.Now,
+	}
+}
+
+// Start creates the export directory, and completes the export file left by a previous run.
+func (f *ExportForwarder) Start() error {
+	if err := os.MkdirAll(f.path, 0700); err != nil {
+		return fmt.Errorf("could not create the export directory: %v", err)
+	}
+	files, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if name := file.Name(); strings.HasSuffix(name, exportTempExtension) {
+			// its last record may be truncated, it's skipped when replayed
+			filename := filepath.Join(f.path, name)
+			if err := os.Rename(filename, strings.TrimSuffix(filename, exportTempExtension)); err != nil {
+				log.Warnf("Could not complete the export file %s: %v", filename, err)
+			}
+		}
+	}
+	log.Infof("Forwarder started, exporting the payloads to %s", f.path)
+	return nil
+}
+
+// Stop completes the export file being written.
+func (f *ExportForwarder) Stop() {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if err := f.complete(); err != nil {
+		log.Errorf("Could not complete the export file %s: %v", f.filename, err)
+	}
+}
+
+func (f *ExportForwarder) export(ep endpoint, payloads Payloads, extra http.Header) error {
+	headers, err := json.Marshal(extra)
+	if err != nil {
+		return err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	for _, payload := range payloads {
+		if err := f.rotate(); err != nil {
+			return fmt.Errorf("could not create an export file: %v", err)
+		}
+		n, err := writeExportRecord(f.writer, ep.name, headers, *payload)
+		f.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("could not export a payload to %s: %v", f.filename, err)
+		}
+		transactionsExported.Add(1)
+		tlmTxExported.Inc(ep.name)
+	}
+	// the payloads must not be lost if the agent crashes
+	return f.writer.Flush()
+}
+
+// rotate completes the export file when it's full or too old, and creates a new one.
+func (f *ExportForwarder) rotate() error {
+	now := f.timeNow()
+	if f.file != nil && f.size < f.maxFileSize && now.Sub(f.createdAt) < f.rotationInterval {
+		return nil
+	}
+	if err := f.complete(); err != nil {
+		log.Errorf("Could not complete the export file %s: %v", f.filename, err)
+	}
+
+	f.filename = filepath.Join(f.path, fmt.Sprintf("payloads_%d%s", now.UnixNano(), exportFileExtension))
+	file, err := os.OpenFile(f.filename+exportTempExtension, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	f.createdAt = now
+	f.writer.Write(exportMagic)
+	f.writer.WriteByte(exportFormatVersion)
+	f.size = int64(len(exportMagic) + 1)
+	return nil
+}
+
+// complete closes the export file being written, and renames it so that it's replayed.
+func (f *ExportForwarder) complete() error {
+	if f.file == nil {
+		return nil
+	}
+	file := f.file
+	f.file = nil
+	err := f.writer.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if renameErr := os.Rename(f.filename+exportTempExtension, f.filename); err == nil {
+		err = renameErr
+	}
+	return err
+}
+
+func writeExportRecord(w io.Writer, name string, headers []byte, payload []byte) (int, error) {
+	body := bytes.NewBuffer(make([]byte, 0, 1+len(name)+4+len(headers)+len(payload)))
+	body.WriteByte(byte(len(name)))
+	body.WriteString(name)
+	binary.Write(body, binary.BigEndian, uint32(len(headers)))
+	body.Write(headers)
+	body.Write(payload)
+
+	header := make([]byte, exportRecordHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], uint32(body.Len()))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(body.Bytes()))
+	n, err := w.Write(header)
+	if err != nil {
+		return n, err
+	}
+	m, err := w.Write(body.Bytes())
+	return n + m, err
+}
+
+// SubmitSeries exports a series type payload.
+func (f *ExportForwarder) SubmitSeries(payload Payloads, extra http.Header) error {
+	return f.export(seriesEndpoint, payload, extra)
+}
+
+// SubmitEvents exports an event type payload.
+func (f *ExportForwarder) SubmitEvents(payload Payloads, extra http.Header) error {
+	return f.export(eventsEndpoint, payload, extra)
+}
+
+// SubmitServiceChecks exports a service check type payload.
+func (f *ExportForwarder) SubmitServiceChecks(payload Payloads, extra http.Header) error {
+	return f.export(serviceChecksEndpoint, payload, extra)
+}
+
+// SubmitSketchSeries exports a sketches type payload.
+func (f *ExportForwarder) SubmitSketchSeries(payload Payloads, extra http.Header) error {
+	return f.export(sketchSeriesEndpoint, payload, extra)
+}
+
+// SubmitHostMetadata exports a host_metadata type payload.
+func (f *ExportForwarder) SubmitHostMetadata(payload Payloads, extra http.Header) error {
+	return f.export(hostMetadataEndpoint, payload, extra)
+}
+
+// SubmitMetadata exports a metadata type payload.
+func (f *ExportForwarder) SubmitMetadata(payload Payloads, extra http.Header) error {
+	return f.export(metadataEndpoint, payload, extra)
+}
+
+// SubmitV1Series exports a timeserie of the v1 endpoint.
+func (f *ExportForwarder) SubmitV1Series(payload Payloads, extra http.Header) error {
+	return f.export(v1SeriesEndpoint, payload, extra)
+}
+
+// SubmitV1CheckRuns exports service checks of the v1 endpoint.
+func (f *ExportForwarder) SubmitV1CheckRuns(payload Payloads, extra http.Header) error {
+	return f.export(v1CheckRunsEndpoint, payload, extra)
+}
+
+// SubmitV1Intake exports payloads of the universal `/intake/` endpoint.
+func (f *ExportForwarder) SubmitV1Intake(payload Payloads, extra http.Header) error {
+	// the intake endpoint requires the Content-Type header to be set
+	headers := make(http.Header, len(extra)+1)
+	for key := range extra {
+		headers.Set(key, extra.Get(key))
+	}
+	headers.Set("Content-Type", "application/json")
+	return f.export(v1IntakeEndpoint, payload, headers)
+}
+
+// SubmitProcessChecks drops the process checks, they can't be exported.
+func (f *ExportForwarder) SubmitProcessChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// SubmitRTProcessChecks drops the real time process checks, they can't be exported.
+func (f *ExportForwarder) SubmitRTProcessChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// SubmitContainerChecks drops the container checks, they can't be exported.
+func (f *ExportForwarder) SubmitContainerChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// SubmitRTContainerChecks drops the real time container checks, they can't be exported.
+func (f *ExportForwarder) SubmitRTContainerChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// SubmitConnectionChecks drops the connection checks, they can't be exported.
+func (f *ExportForwarder) SubmitConnectionChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// SubmitPodChecks drops the pod checks, they can't be exported.
+func (f *ExportForwarder) SubmitPodChecks(payload Payloads, extra http.Header) (chan Response, error) {
+	return nil, errNotExported
+}
+
+// exportRecord is a payload read from an export file.
+type exportRecord struct {
+	endpoint exportedEndpoint
+	headers  http.Header
+	payload  []byte
+}
+
+// readExportFile returns the payloads of an export file. A truncated last record, written
+// when the agent crashed, and the records following a corrupted one are skipped.
+func readExportFile(filename string) ([]exportRecord, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < len(exportMagic)+1 || !bytes.Equal(content[:len(exportMagic)], exportMagic) {
+		return nil, fmt.Errorf("not an export file")
+	}
+	if version := content[len(exportMagic)]; version != exportFormatVersion {
+		return nil, fmt.Errorf("unsupported version of the export format: %d", version)
+	}
+	content = content[len(exportMagic)+1:]
+
+	var records []exportRecord
+	for len(content) > 0 {
+		if len(content) < exportRecordHeaderSize {
+			log.Warnf("Skipping the truncated last payload of %s", filename)
+			break
+		}
+		length := binary.BigEndian.Uint32(content[0:4])
+		checksum := binary.BigEndian.Uint32(content[4:8])
+		content = content[exportRecordHeaderSize:]
+		if uint32(len(content)) < length {
+			log.Warnf("Skipping the truncated last payload of %s", filename)
+			break
+		}
+		body := content[:length]
+		content = content[length:]
+		if crc32.ChecksumIEEE(body) != checksum {
+			// the length of the next records can't be trusted anymore
+			log.Warnf("Skipping the corrupted payloads at the end of %s", filename)
+			break
+		}
+
+		record, err := parseExportRecord(body)
+		if err != nil {
+			log.Warnf("Skipping a payload of %s: %v", filename, err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func parseExportRecord(body []byte) (exportRecord, error) {
+	var record exportRecord
+	if len(body) == 0 {
+		return record, errCorruptedTransactions
+	}
+	nameLength := int(body[0])
+	if len(body) < 1+nameLength+4 {
+		return record, errCorruptedTransactions
+	}
+	name := string(body[1 : 1+nameLength])
+	body = body[1+nameLength:]
+	headersLength := binary.BigEndian.Uint32(body[0:4])
+	body = body[4:]
+	if uint32(len(body)) < headersLength {
+		return record, errCorruptedTransactions
+	}
+	if err := json.Unmarshal(body[:headersLength], &record.headers); err != nil {
+		return record, err
+	}
+
+	var found bool
+	if record.endpoint, found = exportedEndpoints[name]; !found {
+		return record, fmt.Errorf("unknown endpoint %q", name)
+	}
+	record.payload = body[headersLength:]
+	return record, nil
+}
+
+// ReplayExport sends the payloads of the export files of path to the domains of
+// keysPerDomain, in the order they were exported, and removes each file once all its
+// payloads are accepted by every domain. It stops at the end of the first file a domain
+// doesn't accept a payload of: the number of payloads of the file each domain accepted
+// is recorded, so that the next replay doesn't send them to that domain again.
+// It returns the number of payloads accepted by every domain.
+func ReplayExport(path string, keysPerDomain map[string][]string) (int, error) {
+	options := NewOptions(keysPerDomain)
+	options.EnableHealthChecking = false
+	options.StorageMaxSize = 0
+	// the transactions are created by the forwarder but sent synchronously
+	f := NewDefaultForwarder(options)
+	if f.fipsError != nil {
+		return 0, f.fipsError
+	}
+	client := &http.Client{
+		Timeout:   config.Datadog.GetDuration("forwarder_timeout") * time.Second,
+		Transport: httputils.CreateHTTPTransport(),
+	}
+	// the progress is recorded by configured domain, the versioned ones change with upgrades
+	configuredDomains := make(map[string]string, len(keysPerDomain))
+	for domain := range keysPerDomain {
+		versionedDomain, _ := config.AddAgentVersionToDomain(domain, "app")
+		configuredDomains[versionedDomain] = domain
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*"+exportFileExtension))
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(files)
+
+	sent := 0
+	for _, filename := range files {
+		records, err := readExportFile(filename)
+		if err != nil {
+			return sent, fmt.Errorf("could not read %s: %v", filename, err)
+		}
+		progress, err := readExportProgress(filename)
+		if err != nil {
+			return sent, fmt.Errorf("could not read the replay progress of %s: %v", filename, err)
+		}
+
+		accepted, replayErr := replayExportRecords(f, client, records, progress, configuredDomains)
+		sent += accepted
+		if replayErr != nil {
+			if err := writeExportProgress(filename, progress); err != nil {
+				log.Warnf("Could not record the replay progress of %s: %v", filename, err)
+			}
+			return sent, fmt.Errorf("could not send a payload of %s: %v", filename, replayErr)
+		}
+		if err := os.Remove(filename); err != nil {
+			return sent, err
+		}
+		if err := os.Remove(filename + exportProgressExtension); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Could not remove the replay progress of %s: %v", filename, err)
+		}
+		log.Infof("Sent the %d payloads of %s", len(records), filename)
+	}
+	return sent, nil
+}
+
+// replayExportRecords sends the records to each domain from the first one it didn't accept
+// yet, and stops sending to a domain once it doesn't accept one. The progress of the domains
+// is updated, and the number of records accepted by every domain is returned along with the
+// first error.
+func replayExportRecords(f *DefaultForwarder, client *http.Client, records []exportRecord, progress exportProgress, configuredDomains map[string]string) (int, error) {
+	configuredDomain := func(t *HTTPTransaction) string {
+		if domain, found := configuredDomains[t.Domain]; found {
+			return domain
+		}
+		return t.Domain
+	}
+
+	var firstErr error
+	failed := make(map[string]bool) // domains which didn't accept a previous record
+	accepted := 0
+	for i, record := range records {
+		payload := record.payload
+		transactions := f.createHTTPTransactions(record.endpoint.endpoint, Payloads{&payload}, record.endpoint.apiKeyInQueryString, record.headers)
+
+		rejected := make(map[string]bool) // domains which didn't accept this record
+		for _, t := range transactions {
+			domain := configuredDomain(t)
+			if progress[domain] > i {
+				// accepted during a previous replay
+				continue
+			}
+			if failed[domain] || rejected[domain] {
+				rejected[domain] = true
+				continue
+			}
+			if err := sendExportTransaction(client, t); err != nil {
+				rejected[domain] = true
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		for _, t := range transactions {
+			if domain := configuredDomain(t); rejected[domain] {
+				failed[domain] = true
+			} else if progress[domain] <= i {
+				progress[domain] = i + 1
+			}
+		}
+		if len(rejected) == 0 {
+			accepted++
+		}
+	}
+	return accepted, firstErr
+}
+
+// sendExportTransaction sends the transaction, and returns an error unless its domain accepted it:
+// the payloads rejected by the domain are not retried by the transaction, but must not be lost.
+func sendExportTransaction(client *http.Client, t *HTTPTransaction) error {
+	statusCode, _, err := t.internalProcess(context.Background(), client)
+	if err != nil {
+		return err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return fmt.Errorf("the payload was rejected by %s with the status code %d", httputils.SanitizeURL(t.Domain), statusCode)
+	}
+	return nil
+}
+
+// exportProgress is the number of payloads of an export file each configured domain accepted.
+type exportProgress map[string]int
+
+func readExportProgress(filename string) (exportProgress, error) {
+	progress := make(exportProgress)
+	content, err := ioutil.ReadFile(filename + exportProgressExtension)
+	if os.IsNotExist(err) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func writeExportProgress(filename string, progress exportProgress) error {
+	if len(progress) == 0 {
+		return nil
+	}
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename+exportProgressExtension, content, 0600)
+}
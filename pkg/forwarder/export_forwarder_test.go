@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExportForwarder(t *testing.T) (*ExportForwarder, string) {
+	path, err := ioutil.TempDir("", "export")
+	require.NoError(t, err)
+	f := NewExportForwarder(path)
+	require.NoError(t, f.Start())
+	return f, path
+}
+
+func exportFiles(t *testing.T, path string) []string {
+	files, err := filepath.Glob(filepath.Join(path, "*"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestExportForwarderRotation(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+	now := time.Now()
+	f.timeNow = func() time.Time { return now }
+
+	p := []byte("A payload")
+	require.NoError(t, f.SubmitSeries(Payloads{&p}, nil))
+	// the file being written isn't replayed
+	files := exportFiles(t, path)
+	require.Len(t, files, 1)
+	assert.Equal(t, exportTempExtension, filepath.Ext(files[0]))
+
+	now = now.Add(f.rotationInterval)
+	require.NoError(t, f.SubmitEvents(Payloads{&p}, nil))
+	f.Stop()
+	files = exportFiles(t, path)
+	require.Len(t, files, 2)
+	assert.Equal(t, exportFileExtension, filepath.Ext(files[0]))
+	assert.Equal(t, exportFileExtension, filepath.Ext(files[1]))
+}
+
+func TestExportForwarderProcessChecks(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	p := []byte("A payload")
+	_, err := f.SubmitProcessChecks(Payloads{&p}, nil)
+	assert.Equal(t, errNotExported, err)
+}
+
+func TestReplayExport(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	series := []byte("series")
+	events := []byte("events")
+	intake := []byte("intake")
+	extra := http.Header{}
+	extra.Set("Content-Encoding", "deflate")
+	require.NoError(t, f.SubmitSeries(Payloads{&series}, extra))
+	require.NoError(t, f.SubmitEvents(Payloads{&events}, nil))
+	require.NoError(t, f.SubmitV1Intake(Payloads{&intake}, nil))
+	f.Stop()
+
+	var m sync.Mutex
+	var requests []*http.Request
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		m.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	sent, err := ReplayExport(path, map[string][]string{ts.URL: {"api-key-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Empty(t, exportFiles(t, path))
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, []string{"series", "events", "intake"}, bodies)
+	assert.Equal(t, seriesEndpoint.route, requests[0].URL.Path)
+	assert.Equal(t, "deflate", requests[0].Header.Get("Content-Encoding"))
+	assert.Equal(t, "api-key-1", requests[0].Header.Get(apiHTTPHeaderKey))
+	assert.Equal(t, eventsEndpoint.route, requests[1].URL.Path)
+	assert.Equal(t, v1IntakeEndpoint.route, requests[2].URL.Path)
+	assert.Equal(t, "api-key-1", requests[2].URL.Query().Get("api_key"))
+	assert.Equal(t, "application/json", requests[2].Header.Get("Content-Type"))
+}
+
+func TestReplayExportTruncated(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	p := []byte("A payload")
+	require.NoError(t, f.SubmitSeries(Payloads{&p, &p}, nil))
+	f.Stop()
+	files := exportFiles(t, path)
+	require.Len(t, files, 1)
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(files[0], info.Size()-2))
+
+	records, err := readExportFile(files[0])
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, p, records[0].payload)
+	assert.Equal(t, seriesEndpoint, records[0].endpoint.endpoint)
+}
+
+func TestReplayExportFailure(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	p := []byte("A payload")
+	require.NoError(t, f.SubmitSeries(Payloads{&p}, nil))
+	f.Stop()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	// the file is kept to be replayed again
+	sent, err := ReplayExport(path, map[string][]string{ts.URL: {"api-key-1"}})
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, exportFiles(t, path), 1)
+}
+
+func TestReplayExportRejected(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	p := []byte("A payload")
+	require.NoError(t, f.SubmitSeries(Payloads{&p}, nil))
+	f.Stop()
+
+	// the payloads rejected by the domain are not lost
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	sent, err := ReplayExport(path, map[string][]string{ts.URL: {"api-key-1"}})
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, exportFiles(t, path), 1)
+}
+
+func TestReplayExportPartialFailure(t *testing.T) {
+	f, path := newTestExportForwarder(t)
+	defer os.RemoveAll(path)
+
+	p1 := []byte("payload 1")
+	p2 := []byte("payload 2")
+	require.NoError(t, f.SubmitSeries(Payloads{&p1, &p2}, nil))
+	f.Stop()
+
+	var m sync.Mutex
+	var healthyBodies, flakyBodies []string
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m.Lock()
+		healthyBodies = append(healthyBodies, string(body))
+		m.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer healthy.Close()
+	flakyStatus := http.StatusServiceUnavailable
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		m.Lock()
+		defer m.Unlock()
+		if flakyStatus == http.StatusAccepted || string(body) == "payload 1" {
+			flakyBodies = append(flakyBodies, string(body))
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(flakyStatus)
+	}))
+	defer flaky.Close()
+
+	keysPerDomain := map[string][]string{healthy.URL: {"api-key-1"}, flaky.URL: {"api-key-2"}}
+	sent, err := ReplayExport(path, keysPerDomain)
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	files := exportFiles(t, path)
+	require.Len(t, files, 2)
+	assert.Equal(t, exportFileExtension, filepath.Ext(files[0]))
+	assert.Equal(t, exportProgressExtension, filepath.Ext(files[1]))
+
+	// the domains are only sent the payloads they didn't accept yet
+	flakyStatus = http.StatusAccepted
+	sent, err = ReplayExport(path, keysPerDomain)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Empty(t, exportFiles(t, path))
+	assert.Equal(t, []string{"payload 1", "payload 2"}, healthyBodies)
+	assert.Equal(t, []string{"payload 1", "payload 2"}, flakyBodies)
+}
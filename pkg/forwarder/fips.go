@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// applyFIPS returns the options of a forwarder complying with the FIPS mode: when the
+// TLS connections are delegated to the local FIPS proxy, only one endpoint of the forwarder
+// is kept, the main one when the forwarder has it, and its transactions are sent to the proxy.
+func applyFIPS(options *Options) (*Options, error) {
+	if err := fips.Check(fips.Forwarder); err != nil {
+		return nil, err
+	}
+
+	proxyURL, proxied := fips.ProxyURL(fips.Forwarder)
+	if !proxied {
+		return options, nil
+	}
+
+	mainDomain := config.GetMainInfraEndpoint()
+	if _, found := options.KeysPerDomain[mainDomain]; !found {
+		// the forwarders of the other products, e.g. the process-agent, have their own endpoints
+		domains := make([]string, 0, len(options.KeysPerDomain))
+		for domain := range options.KeysPerDomain {
+			domains = append(domains, domain)
+		}
+		if len(domains) == 0 {
+			return nil, fips.NotCompliant(fips.Forwarder, fmt.Errorf("no endpoint to forward to"))
+		}
+		sort.Strings(domains)
+		mainDomain = domains[0]
+	}
+	keys := options.KeysPerDomain[mainDomain]
+	if len(keys) == 0 {
+		return nil, fips.NotCompliant(fips.Forwarder, fmt.Errorf("no API key for the endpoint %s", mainDomain))
+	}
+	for domain := range options.KeysPerDomain {
+		if domain != mainDomain {
+			log.Errorf("The FIPS proxy only forwards to a single endpoint, dropping domain '%s'", domain)
+		}
+	}
+
+	fipsOptions := *options
+	fipsOptions.KeysPerDomain = map[string][]string{proxyURL: keys}
+	fipsOptions.RotationKeysPerDomain = map[string][]string{}
+	if rotationKeys := options.RotationKeysPerDomain[mainDomain]; len(rotationKeys) > 0 {
+		fipsOptions.RotationKeysPerDomain[proxyURL] = rotationKeys
+	}
+	fipsOptions.PayloadTypesPerDomain = map[string][]string{}
+	if types, found := options.PayloadTypesPerDomain[mainDomain]; found {
+		fipsOptions.PayloadTypesPerDomain[proxyURL] = types
+	}
+	// the proxy is the only way out, there's nothing to fail over to
+	fipsOptions.FailoverURLsPerDomain = nil
+	return &fipsOptions, nil
+}
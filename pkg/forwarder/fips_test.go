@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func setFIPSProxy(localAddress string) func() {
+	config.Datadog.Set("dd_url", testDomain)
+	config.Datadog.Set("fips.enabled", true)
+	config.Datadog.Set("fips.local_address", localAddress)
+	config.Datadog.Set("fips.port_range_start", 9803)
+	return func() {
+		config.Datadog.Set("dd_url", "")
+		config.Datadog.Set("fips.enabled", false)
+		config.Datadog.Set("fips.local_address", "")
+	}
+}
+
+func TestFIPSProxy(t *testing.T) {
+	defer setFIPSProxy("localhost")()
+
+	options := NewOptions(map[string][]string{
+		testDomain:                {"api-key-1", "api-key-2"},
+		"https://app.datadog.foo": {"api-key-3"},
+	})
+	options.FailoverURLsPerDomain = map[string][]string{testDomain: {"https://failover.datadog.foo"}}
+	f := NewDefaultForwarder(options)
+
+	assert.NoError(t, f.fipsError)
+	assert.Equal(t, map[string][]string{"http://localhost:9804": {"api-key-1", "api-key-2"}}, f.keysPerDomains)
+	assert.Len(t, f.domainForwarders, 1)
+	assert.Nil(t, f.domainForwarders["http://localhost:9804"].failover)
+}
+
+func TestFIPSProxyWithoutMainEndpoint(t *testing.T) {
+	defer setFIPSProxy("localhost")()
+
+	// e.g. the forwarders of the process-agent
+	f := NewDefaultForwarder(NewOptions(map[string][]string{"https://process.datadog.foo": {"api-key-1"}}))
+	assert.NoError(t, f.fipsError)
+	assert.Equal(t, map[string][]string{"http://localhost:9804": {"api-key-1"}}, f.keysPerDomains)
+}
+
+func TestFIPSNotCompliant(t *testing.T) {
+	defer setFIPSProxy("")()
+	// the TLS connections can't comply without validating the certificates
+	config.Datadog.Set("skip_ssl_validation", true)
+	defer config.Datadog.Set("skip_ssl_validation", false)
+
+	f := NewDefaultForwarder(NewOptions(map[string][]string{testDomain: {"api-key-1"}}))
+	assert.Error(t, f.fipsError)
+	assert.Empty(t, f.domainForwarders)
+	assert.Equal(t, f.fipsError, f.Start())
+}
@@ -9,6 +9,8 @@ import (
 	"expvar"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,6 +18,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -85,7 +88,10 @@ func init() {
 	transactionsExpvars.Set("Pods", &transactionsIntakePod)
 	initDomainForwarderExpvars()
 	initTransactionExpvars()
+	initTransactionStorageExpvars()
 	initForwarderHealthExpvars()
+	initEndpointFailoverExpvars()
+	initExportExpvars()
 }
 
 const (
@@ -116,6 +122,65 @@ func (e endpoint) String() string {
 	return e.route
 }
 
+// priority returns the priority of the transactions of the endpoint when they are retried:
+// the metadata are retried before the series, and the series before the sketches.
+func (e endpoint) priority() TransactionPriority {
+	switch e {
+	case hostMetadataEndpoint, metadataEndpoint:
+		return TransactionPriorityHigh
+	case sketchSeriesEndpoint, v1SketchSeriesEndpoint:
+		return TransactionPriorityLow
+	default:
+		return TransactionPriorityNormal
+	}
+}
+
+// The payload types the endpoints can be restricted to with `additional_endpoints_payload_types`
+const (
+	payloadTypeSeries        = "series"
+	payloadTypeSketches      = "sketches"
+	payloadTypeServiceChecks = "service_checks"
+	payloadTypeEvents        = "events"
+	payloadTypeMetadata      = "metadata"
+	payloadTypeIntake        = "intake"
+	payloadTypeProcess       = "process"
+	payloadTypeOrchestrator  = "orchestrator"
+)
+
+var payloadTypes = map[string]bool{
+	payloadTypeSeries:        true,
+	payloadTypeSketches:      true,
+	payloadTypeServiceChecks: true,
+	payloadTypeEvents:        true,
+	payloadTypeMetadata:      true,
+	payloadTypeIntake:        true,
+	payloadTypeProcess:       true,
+	payloadTypeOrchestrator:  true,
+}
+
+// payloadType returns the type of the payloads sent to the endpoint, so that the
+// additional endpoints can receive a subset of the payloads only.
+func (e endpoint) payloadType() string {
+	switch e {
+	case seriesEndpoint, v1SeriesEndpoint:
+		return payloadTypeSeries
+	case sketchSeriesEndpoint, v1SketchSeriesEndpoint:
+		return payloadTypeSketches
+	case serviceChecksEndpoint, v1CheckRunsEndpoint:
+		return payloadTypeServiceChecks
+	case eventsEndpoint:
+		return payloadTypeEvents
+	case hostMetadataEndpoint, metadataEndpoint:
+		return payloadTypeMetadata
+	case processesEndpoint, rtProcessesEndpoint, containerEndpoint, rtContainerEndpoint, connectionsEndpoint:
+		return payloadTypeProcess
+	case podEndpoint:
+		return payloadTypeOrchestrator
+	default:
+		return payloadTypeIntake
+	}
+}
+
 // Payloads is a slice of pointers to byte arrays, an alias for the slices of
 // payloads we pass into the forwarder
 type Payloads []*[]byte
@@ -158,18 +223,50 @@ type Options struct {
 	RetryQueueSize       int
 	EnableHealthChecking bool
 	KeysPerDomain        map[string][]string
+	// StoragePath is the directory where the transactions that don't fit in the retry queue are stored
+	StoragePath string
+	// StorageMaxSize is the maximum size in bytes of the transactions stored on disk per domain,
+	// the transactions are not stored on disk when it is 0
+	StorageMaxSize int64
+	// PayloadTypesPerDomain restricts the payloads sent to a domain to the given types,
+	// the domains missing from it receive all the payloads
+	PayloadTypesPerDomain map[string][]string
+	// FailoverURLsPerDomain contains the URLs the transactions of a domain are sent to, in order,
+	// when the domain is unhealthy
+	FailoverURLsPerDomain map[string][]string
+	// RotationKeysPerDomain contains the API keys replacing, in order, the revoked API keys of a domain
+	RotationKeysPerDomain map[string][]string
 }
 
 // NewOptions creates new Options with default values
 func NewOptions(keysPerDomain map[string][]string) *Options {
+	storagePath := config.Datadog.GetString("forwarder_storage_path")
+	if storagePath == "" {
+		storagePath = filepath.Join(config.Datadog.GetString("run_path"), "transactions_to_retry")
+	}
 	return &Options{
-		NumberOfWorkers:      config.Datadog.GetInt("forwarder_num_workers"),
-		RetryQueueSize:       config.Datadog.GetInt("forwarder_retry_queue_max_size"),
-		EnableHealthChecking: true,
-		KeysPerDomain:        keysPerDomain,
+		NumberOfWorkers:       config.Datadog.GetInt("forwarder_num_workers"),
+		RetryQueueSize:        config.Datadog.GetInt("forwarder_retry_queue_max_size"),
+		EnableHealthChecking:  true,
+		KeysPerDomain:         keysPerDomain,
+		StoragePath:           storagePath,
+		StorageMaxSize:        config.Datadog.GetInt64("forwarder_storage_max_size_in_bytes"),
+		PayloadTypesPerDomain: config.Datadog.GetStringMapStringSlice("additional_endpoints_payload_types"),
+		FailoverURLsPerDomain: config.Datadog.GetStringMapStringSlice("forwarder_failover_urls"),
+		RotationKeysPerDomain: rotationKeysPerDomain(),
 	}
 }
 
+// rotationKeysPerDomain returns the rotation API keys of the main endpoint and of the additional endpoints
+func rotationKeysPerDomain() map[string][]string {
+	keysPerDomain := config.Datadog.GetStringMapStringSlice("additional_endpoints_rotation_api_keys")
+	if keys := config.Datadog.GetStringSlice("rotation_api_keys"); len(keys) > 0 {
+		mainEndpoint := config.GetMainInfraEndpoint()
+		keysPerDomain[mainEndpoint] = append(keysPerDomain[mainEndpoint], keys...)
+	}
+	return keysPerDomain
+}
+
 // DefaultForwarder is the default implementation of the Forwarder.
 type DefaultForwarder struct {
 	// NumberOfWorkers Number of concurrent HTTP request made by the DefaultForwarder (default 4).
@@ -177,37 +274,177 @@ type DefaultForwarder struct {
 
 	domainForwarders map[string]*domainForwarder
 	keysPerDomains   map[string][]string
-	healthChecker    *forwarderHealth
-	internalState    uint32
-	m                sync.Mutex // To control Start/Stop races
+	// payloadTypesPerDomain contains the payload types accepted by the domains receiving a subset of the payloads
+	payloadTypesPerDomain map[string]map[string]bool
+	healthChecker         *forwarderHealth
+	apiKeyRotation        *apiKeyRotation // nil when there's no rotation key nor secrets backend
+	keysMutex             sync.RWMutex    // guards keysPerDomains, the API keys can be rotated
+	fipsError             error           // set when the forwarder can't comply with the FIPS mode
+	internalState         uint32
+	m                     sync.Mutex // To control Start/Stop races
 }
 
 // NewDefaultForwarder returns a new DefaultForwarder.
 func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	f := &DefaultForwarder{
-		NumberOfWorkers:  options.NumberOfWorkers,
-		domainForwarders: map[string]*domainForwarder{},
-		keysPerDomains:   map[string][]string{},
-		internalState:    Stopped,
+		NumberOfWorkers:       options.NumberOfWorkers,
+		domainForwarders:      map[string]*domainForwarder{},
+		keysPerDomains:        map[string][]string{},
+		payloadTypesPerDomain: map[string]map[string]bool{},
+		internalState:         Stopped,
+	}
+
+	if fips.Enabled() {
+		fipsOptions, err := applyFIPS(options)
+		if err != nil {
+			// nothing is sent, Start fails
+			f.fipsError = err
+			return f
+		}
+		options = fipsOptions
 	}
 
 	if options.EnableHealthChecking {
-		f.healthChecker = &forwarderHealth{keysPerDomains: options.KeysPerDomain}
+		// the forwarder is healthy as long as one of the API keys, or of the rotation keys, is valid
+		keysPerDomain := make(map[string][]string, len(options.KeysPerDomain))
+		for domain, keys := range options.KeysPerDomain {
+			if len(keys) > 0 {
+				keysPerDomain[domain] = append(append([]string(nil), keys...), options.RotationKeysPerDomain[domain]...)
+			}
+		}
+		f.healthChecker = &forwarderHealth{keysPerDomains: keysPerDomain}
 	}
+	spareKeysPerDomain := map[string][]string{}
 
-	for domain, keys := range options.KeysPerDomain {
-		domain, _ := config.AddAgentVersionToDomain(domain, "app")
+	for configuredDomain, keys := range options.KeysPerDomain {
+		domain, _ := config.AddAgentVersionToDomain(configuredDomain, "app")
 		if keys == nil || len(keys) == 0 {
 			log.Errorf("No API keys for domain '%s', dropping domain ", domain)
 		} else {
 			f.keysPerDomains[domain] = keys
+			if types, found := options.PayloadTypesPerDomain[configuredDomain]; found {
+				f.payloadTypesPerDomain[domain] = acceptedPayloadTypes(domain, types)
+			}
 			f.domainForwarders[domain] = newDomainForwarder(domain, options.NumberOfWorkers, options.RetryQueueSize)
+			if options.StorageMaxSize > 0 {
+				// the transactions are stored by configured domain so that they are retried after an upgrade
+				storage, err := newTransactionStorage(options.StoragePath, configuredDomain, keys, options.StorageMaxSize, options.RetryQueueSize)
+				if err != nil {
+					log.Errorf("Could not store the transactions to retry for domain '%s' on disk: %v", domain, err)
+				} else {
+					f.domainForwarders[domain].storage = storage
+				}
+			}
+			if urls := options.FailoverURLsPerDomain[configuredDomain]; len(urls) > 0 {
+				f.domainForwarders[domain].failover = newEndpointFailover(domain, urls, keys[0])
+			}
+			if spareKeys := options.RotationKeysPerDomain[configuredDomain]; len(spareKeys) > 0 {
+				spareKeysPerDomain[domain] = append([]string(nil), spareKeys...)
+			}
 		}
 	}
+	if len(spareKeysPerDomain) > 0 || config.Datadog.GetString("secret_backend_command") != "" || config.Datadog.GetString("secret_backend_type") != "" {
+		f.apiKeyRotation = newAPIKeyRotation(f, spareKeysPerDomain)
+	}
 
 	return f
 }
 
+// apiKeys returns a copy of the API keys of the domains.
+func (f *DefaultForwarder) apiKeys() map[string][]string {
+	f.keysMutex.RLock()
+	defer f.keysMutex.RUnlock()
+	keysPerDomains := make(map[string][]string, len(f.keysPerDomains))
+	for domain, keys := range f.keysPerDomains {
+		keysPerDomains[domain] = append([]string(nil), keys...)
+	}
+	return keysPerDomains
+}
+
+// replaceAPIKey replaces an API key of a domain by another for the next transactions.
+func (f *DefaultForwarder) replaceAPIKey(domain, oldKey, newKey string) {
+	f.keysMutex.Lock()
+	keys := make([]string, 0, len(f.keysPerDomains[domain]))
+	for _, key := range f.keysPerDomains[domain] {
+		if key == oldKey {
+			key = newKey
+		}
+		keys = append(keys, key)
+	}
+	f.keysPerDomains[domain] = keys
+	f.keysMutex.Unlock()
+
+	if f.healthChecker != nil {
+		f.healthChecker.replaceAPIKey(oldKey, newKey)
+	}
+}
+
+// UpdateAPIKeys replaces the API keys of the domains by the ones of keysPerDomain, when
+// the configuration is reloaded. The domains, and their number of keys, can't change
+// while the forwarder runs: an error lists these changes, which require a restart, and
+// no key is replaced.
+func (f *DefaultForwarder) UpdateAPIKeys(keysPerDomain map[string][]string) error {
+	if fips.Enabled() {
+		return fmt.Errorf("the endpoints can't be changed in FIPS mode")
+	}
+
+	newKeysPerDomain := make(map[string][]string, len(keysPerDomain))
+	for configuredDomain, keys := range keysPerDomain {
+		if len(keys) > 0 {
+			domain, _ := config.AddAgentVersionToDomain(configuredDomain, "app")
+			newKeysPerDomain[domain] = keys
+		}
+	}
+
+	keysPerDomains := f.apiKeys()
+	var changes []string
+	for domain, keys := range keysPerDomains {
+		if newKeys, found := newKeysPerDomain[domain]; !found {
+			changes = append(changes, fmt.Sprintf("domain %s removed", domain))
+		} else if len(newKeys) != len(keys) {
+			changes = append(changes, fmt.Sprintf("number of API keys of %s changed", domain))
+		}
+	}
+	for domain := range newKeysPerDomain {
+		if _, found := keysPerDomains[domain]; !found {
+			changes = append(changes, fmt.Sprintf("domain %s added", domain))
+		}
+	}
+	if len(changes) > 0 {
+		sort.Strings(changes)
+		return fmt.Errorf("the endpoints changed: %s", strings.Join(changes, ", "))
+	}
+
+	for domain, keys := range keysPerDomains {
+		for i, key := range keys {
+			if newKey := newKeysPerDomain[domain][i]; newKey != key {
+				f.replaceAPIKey(domain, key, newKey)
+			}
+		}
+	}
+	return nil
+}
+
+// acceptedPayloadTypes returns the set of the valid payload types among types.
+func acceptedPayloadTypes(domain string, types []string) map[string]bool {
+	accepted := make(map[string]bool, len(types))
+	for _, payloadType := range types {
+		payloadType = strings.TrimSpace(payloadType)
+		if !payloadTypes[payloadType] {
+			log.Errorf("Unknown payload type '%s' for domain '%s', ignoring it", payloadType, domain)
+			continue
+		}
+		accepted[payloadType] = true
+	}
+	return accepted
+}
+
+// accepts returns true if the payloads sent to the endpoint must be sent to the domain.
+func (f *DefaultForwarder) accepts(domain string, endpoint endpoint) bool {
+	types, restricted := f.payloadTypesPerDomain[domain]
+	return !restricted || types[endpoint.payloadType()]
+}
+
 // Start initialize and runs the forwarder.
 func (f *DefaultForwarder) Start() error {
 	// Lock so we can't stop a Forwarder while is starting
@@ -217,6 +454,9 @@ func (f *DefaultForwarder) Start() error {
 	if f.internalState == Started {
 		return fmt.Errorf("the forwarder is already started")
 	}
+	if f.fipsError != nil {
+		return f.fipsError
+	}
 
 	for _, df := range f.domainForwarders {
 		_ = df.Start()
@@ -225,8 +465,19 @@ func (f *DefaultForwarder) Start() error {
 	// log endpoints configuration
 	endpointLogs := make([]string, 0, len(f.keysPerDomains))
 	for domain, apiKeys := range f.keysPerDomains {
-		endpointLogs = append(endpointLogs, fmt.Sprintf("\"%s\" (%v api key(s))",
-			domain, len(apiKeys)))
+		endpointLog := fmt.Sprintf("\"%s\" (%v api key(s))", domain, len(apiKeys))
+		if df, found := f.domainForwarders[domain]; found && df.failover != nil {
+			endpointLog += fmt.Sprintf(" failing over to %s", strings.Join(df.failover.urls[1:], ", "))
+		}
+		if types, restricted := f.payloadTypesPerDomain[domain]; restricted {
+			acceptedTypes := make([]string, 0, len(types))
+			for payloadType := range types {
+				acceptedTypes = append(acceptedTypes, payloadType)
+			}
+			sort.Strings(acceptedTypes)
+			endpointLog += fmt.Sprintf(" receiving %s only", strings.Join(acceptedTypes, ", "))
+		}
+		endpointLogs = append(endpointLogs, endpointLog)
 	}
 	log.Infof("Forwarder started, sending to %v endpoint(s) with %v worker(s) each: %s",
 		len(endpointLogs), f.NumberOfWorkers, strings.Join(endpointLogs, " ; "))
@@ -234,6 +485,9 @@ func (f *DefaultForwarder) Start() error {
 	if f.healthChecker != nil {
 		f.healthChecker.Start()
 	}
+	if f.apiKeyRotation != nil {
+		f.apiKeyRotation.start()
+	}
 	f.internalState = Started
 	return nil
 }
@@ -281,6 +535,10 @@ func (f *DefaultForwarder) Stop() {
 		}
 	}
 
+	if f.apiKeyRotation != nil {
+		f.apiKeyRotation.stopRotation()
+	}
+
 	if f.healthChecker != nil {
 		f.healthChecker.Stop()
 	}
@@ -300,9 +558,15 @@ func (f *DefaultForwarder) State() uint32 {
 }
 
 func (f *DefaultForwarder) createHTTPTransactions(endpoint endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*HTTPTransaction {
+	f.keysMutex.RLock()
+	defer f.keysMutex.RUnlock()
+
 	transactions := make([]*HTTPTransaction, 0, len(payloads)*len(f.keysPerDomains))
 	for _, payload := range payloads {
 		for domain, apiKeys := range f.keysPerDomains {
+			if !f.accepts(domain, endpoint) {
+				continue
+			}
 			for _, apiKey := range apiKeys {
 				transactionEndpoint := endpoint.route
 				if apiKeyInQueryString {
@@ -315,6 +579,10 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint endpoint, payloads Pa
 				t.Headers.Set(apiHTTPHeaderKey, apiKey)
 				t.Headers.Set(versionHTTPHeaderKey, version.AgentVersion)
 				t.Headers.Set(useragentHTTPHeaderKey, fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+				t.priority = endpoint.priority()
+				if df, found := f.domainForwarders[domain]; found {
+					t.failover = df.failover
+				}
 
 				tlm.Inc(domain, endpoint.name)
 
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -48,6 +49,7 @@ type forwarderHealth struct {
 	timeout            time.Duration
 	keysPerDomains     map[string][]string
 	keysPerAPIEndpoint map[string][]string
+	m                  sync.Mutex // guards keysPerAPIEndpoint, the API keys can be rotated
 }
 
 func (fh *forwarderHealth) init() {
@@ -114,22 +116,35 @@ func (fh *forwarderHealth) healthCheckLoop() {
 // computeDomainsURL populates a map containing API Endpoints per API keys that belongs to the forwarderHealth struct
 func (fh *forwarderHealth) computeDomainsURL() {
 	for domain, apiKeys := range fh.keysPerDomains {
-		apiDomain := ""
-		re := regexp.MustCompile("datadoghq.[a-z]*")
-		if re.MatchString(domain) {
-			apiDomain = "https://api." + re.FindString(domain)
-		} else {
-			apiDomain = domain
-		}
+		apiDomain := apiDomainOf(domain)
 		fh.keysPerAPIEndpoint[apiDomain] = append(fh.keysPerAPIEndpoint[apiDomain], apiKeys...)
 	}
 }
 
-func (fh *forwarderHealth) setAPIKeyStatus(apiKey string, domain string, status expvar.Var) {
-	if len(apiKey) > 5 {
-		apiKey = apiKey[len(apiKey)-5:]
+// apiDomainOf returns the API endpoint the API keys of the domain are validated against
+func apiDomainOf(domain string) string {
+	re := regexp.MustCompile("datadoghq.[a-z]*")
+	if re.MatchString(domain) {
+		return "https://api." + re.FindString(domain)
+	}
+	return domain
+}
+
+// replaceAPIKey replaces an API key by another in the keys validated by the health check
+func (fh *forwarderHealth) replaceAPIKey(oldKey, newKey string) {
+	fh.m.Lock()
+	defer fh.m.Unlock()
+	for _, apiKeys := range fh.keysPerAPIEndpoint {
+		for i, apiKey := range apiKeys {
+			if apiKey == oldKey {
+				apiKeys[i] = newKey
+			}
+		}
 	}
-	obfuscatedKey := fmt.Sprintf("API key ending with %s", apiKey)
+}
+
+func (fh *forwarderHealth) setAPIKeyStatus(apiKey string, domain string, status expvar.Var) {
+	obfuscatedKey := fmt.Sprintf("API key ending with %s", obfuscateAPIKey(apiKey))
 	apiKeyStatus.Set(obfuscatedKey, status)
 }
 
@@ -175,7 +190,14 @@ func (fh *forwarderHealth) hasValidAPIKey() bool {
 	validKey := false
 	apiError := false
 
+	fh.m.Lock()
+	keysPerAPIEndpoint := make(map[string][]string, len(fh.keysPerAPIEndpoint))
 	for domain, apiKeys := range fh.keysPerAPIEndpoint {
+		keysPerAPIEndpoint[domain] = append([]string(nil), apiKeys...)
+	}
+	fh.m.Unlock()
+
+	for domain, apiKeys := range keysPerAPIEndpoint {
 		for _, apiKey := range apiKeys {
 			v, err := fh.validateAPIKey(apiKey, domain)
 			if err != nil {
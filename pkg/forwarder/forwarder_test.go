@@ -48,6 +48,26 @@ func TestNewDefaultForwarder(t *testing.T) {
 	assert.Equal(t, forwarder.State(), forwarder.internalState)
 }
 
+func TestUpdateAPIKeys(t *testing.T) {
+	forwarder := NewDefaultForwarder(NewOptions(keysPerDomains))
+
+	err := forwarder.UpdateAPIKeys(map[string][]string{
+		testDomain:    {"api-key-1", "api-key-3"},
+		"datadog.bar": nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api-key-1", "api-key-3"}, forwarder.apiKeys()[testVersionDomain])
+
+	err = forwarder.UpdateAPIKeys(map[string][]string{
+		testDomain:           {"api-key-4"},
+		"http://datadog.bar": {"api-key-5"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "number of API keys of "+testVersionDomain+" changed")
+	assert.Contains(t, err.Error(), "domain http://datadog.bar added")
+	assert.Equal(t, []string{"api-key-1", "api-key-3"}, forwarder.apiKeys()[testVersionDomain])
+}
+
 func TestStart(t *testing.T) {
 	forwarder := NewDefaultForwarder(NewOptions(monoKeysDomains))
 	err := forwarder.Start()
@@ -142,6 +162,58 @@ func TestCreateHTTPTransactions(t *testing.T) {
 	assert.Contains(t, transactions[1].Endpoint, "api_key=api-key-2")
 	assert.Contains(t, transactions[2].Endpoint, "api_key=api-key-1")
 	assert.Contains(t, transactions[3].Endpoint, "api_key=api-key-2")
+	assert.Equal(t, TransactionPriorityNormal, transactions[0].GetPriority())
+
+	transactions = forwarder.createHTTPTransactions(metadataEndpoint, payloads, false, headers)
+	assert.Equal(t, TransactionPriorityHigh, transactions[0].GetPriority())
+	transactions = forwarder.createHTTPTransactions(sketchSeriesEndpoint, payloads, true, headers)
+	assert.Equal(t, TransactionPriorityLow, transactions[0].GetPriority())
+}
+
+func TestCreateHTTPTransactionsWithPayloadTypes(t *testing.T) {
+	secondaryDomain := "http://app.datadoghq.eu"
+	secondaryVersionDomain, _ := config.AddAgentVersionToDomain(secondaryDomain, "app")
+	options := NewOptions(map[string][]string{
+		testDomain:      {"api-key-1"},
+		secondaryDomain: {"api-key-2"},
+	})
+	options.PayloadTypesPerDomain = map[string][]string{
+		secondaryDomain: {"series", " sketches", "unknown"},
+	}
+	forwarder := NewDefaultForwarder(options)
+	assert.Equal(t, map[string]bool{"series": true, "sketches": true}, forwarder.payloadTypesPerDomain[secondaryVersionDomain])
+
+	p := []byte("A payload")
+	domainsOf := func(transactions []*HTTPTransaction) []string {
+		domains := make([]string, 0, len(transactions))
+		for _, t := range transactions {
+			domains = append(domains, t.Domain)
+		}
+		return domains
+	}
+
+	// the main domain receives all the payloads
+	assert.ElementsMatch(t, []string{testVersionDomain, secondaryVersionDomain}, domainsOf(forwarder.createHTTPTransactions(seriesEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain, secondaryVersionDomain}, domainsOf(forwarder.createHTTPTransactions(v1SeriesEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain, secondaryVersionDomain}, domainsOf(forwarder.createHTTPTransactions(sketchSeriesEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain}, domainsOf(forwarder.createHTTPTransactions(eventsEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain}, domainsOf(forwarder.createHTTPTransactions(hostMetadataEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain}, domainsOf(forwarder.createHTTPTransactions(processesEndpoint, Payloads{&p}, false, nil)))
+	assert.ElementsMatch(t, []string{testVersionDomain}, domainsOf(forwarder.createHTTPTransactions(v1IntakeEndpoint, Payloads{&p}, false, nil)))
+}
+
+func TestCreateHTTPTransactionsWithFailover(t *testing.T) {
+	options := NewOptions(map[string][]string{testDomain: {"api-key-1"}})
+	options.FailoverURLsPerDomain = map[string][]string{testDomain: {"https://fallback.com"}}
+	forwarder := NewDefaultForwarder(options)
+	failover := forwarder.domainForwarders[testVersionDomain].failover
+	require.NotNil(t, failover)
+	assert.Equal(t, []string{testVersionDomain, "https://fallback.com"}, failover.urls)
+
+	p := []byte("A payload")
+	transactions := forwarder.createHTTPTransactions(seriesEndpoint, Payloads{&p}, false, nil)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, failover, transactions[0].failover)
 }
 
 func TestSendHTTPTransactions(t *testing.T) {
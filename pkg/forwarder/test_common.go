@@ -16,11 +16,13 @@ import (
 type testTransaction struct {
 	mock.Mock
 	processed chan bool
+	priority  TransactionPriority
 }
 
 func newTestTransaction() *testTransaction {
 	t := new(testTransaction)
 	t.processed = make(chan bool, 1)
+	t.priority = TransactionPriorityNormal
 	return t
 }
 
@@ -37,6 +39,14 @@ func (t *testTransaction) GetTarget() string {
 	return t.Called().Get(0).(string)
 }
 
+func (t *testTransaction) GetPriority() TransactionPriority {
+	return t.priority
+}
+
+func (t *testTransaction) GetPayloadSize() int {
+	return 0
+}
+
 // Compile-time checking to ensure that MockedForwarder implements Forwarder
 var _ Forwarder = &MockedForwarder{}
 
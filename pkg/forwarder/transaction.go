@@ -109,6 +109,20 @@ func initTransactionExpvars() {
 	transactionsErrorsByType.Set("SentRequestErrors", &transactionsSentRequestErrors)
 }
 
+// TransactionPriority defines the order in which the transactions are retried when
+// the retry queue is full: the transactions with the highest priority are retried
+// first and dropped last.
+type TransactionPriority int
+
+const (
+	// TransactionPriorityLow is the priority of the sketches
+	TransactionPriorityLow TransactionPriority = iota
+	// TransactionPriorityNormal is the priority of the series and of the other payloads
+	TransactionPriorityNormal
+	// TransactionPriorityHigh is the priority of the metadata
+	TransactionPriorityHigh
+)
+
 // HTTPTransaction represents one Payload for one Endpoint on one Domain.
 type HTTPTransaction struct {
 	// Domain represents the domain target by the HTTPTransaction.
@@ -125,6 +139,10 @@ type HTTPTransaction struct {
 	createdAt time.Time
 	// retryable indicates whether this transaction can be retried
 	retryable bool
+	// priority is the priority of this transaction when it is retried
+	priority TransactionPriority
+	// failover selects the URL the transaction is sent to among the domain and its fallback URLs, nil when the domain has none
+	failover *endpointFailover
 
 	// attemptHandler will be called with a transaction before the attempting to send the request
 	attemptHandler HTTPAttemptHandler
@@ -137,6 +155,8 @@ type Transaction interface {
 	Process(ctx context.Context, client *http.Client) error
 	GetCreatedAt() time.Time
 	GetTarget() string
+	GetPriority() TransactionPriority
+	GetPayloadSize() int
 }
 
 // NewHTTPTransaction returns a new HTTPTransaction.
@@ -145,6 +165,7 @@ func NewHTTPTransaction() *HTTPTransaction {
 		createdAt:         time.Now(),
 		ErrorCount:        0,
 		retryable:         true,
+		priority:          TransactionPriorityNormal,
 		Headers:           make(http.Header),
 		attemptHandler:    defaultAttemptHandler,
 		completionHandler: defaultCompletionHandler,
@@ -158,10 +179,32 @@ func (t *HTTPTransaction) GetCreatedAt() time.Time {
 
 // GetTarget return the url used by the transaction
 func (t *HTTPTransaction) GetTarget() string {
-	url := t.Domain + t.Endpoint
+	url := t.targetDomain() + t.Endpoint
 	return httputils.SanitizeURL(url) // sanitized url that can be logged
 }
 
+// targetDomain returns the domain the transaction is sent to, one of the fallback URLs
+// of its domain while the domain is unhealthy.
+func (t *HTTPTransaction) targetDomain() string {
+	if t.failover != nil {
+		return t.failover.activeURL()
+	}
+	return t.Domain
+}
+
+// GetPriority returns the priority of the HTTPTransaction when it is retried.
+func (t *HTTPTransaction) GetPriority() TransactionPriority {
+	return t.priority
+}
+
+// GetPayloadSize returns the size of the payload of the HTTPTransaction, in bytes.
+func (t *HTTPTransaction) GetPayloadSize() int {
+	if t.Payload == nil {
+		return 0
+	}
+	return len(*t.Payload)
+}
+
 // Process sends the Payload of the transaction to the right Endpoint and Domain.
 func (t *HTTPTransaction) Process(ctx context.Context, client *http.Client) error {
 	t.attemptHandler(t)
@@ -185,7 +228,7 @@ func (t *HTTPTransaction) Process(ctx context.Context, client *http.Client) erro
 // This will return  (http status code, response body, error).
 func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Client) (int, []byte, error) {
 	reader := bytes.NewReader(*t.Payload)
-	url := t.Domain + t.Endpoint
+	url := t.targetDomain() + t.Endpoint
 	logURL := httputils.SanitizeURL(url) // sanitized url that can be logged
 
 	req, err := http.NewRequest("POST", url, reader)
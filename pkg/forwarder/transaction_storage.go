@@ -0,0 +1,432 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// storageFileExtension is the extension of the files holding the stored transactions,
+	// their name is made of the priority, the sequence number and the number of transactions
+	storageFileExtension = ".retry"
+	// storageTempExtension is the extension of the files being written
+	storageTempExtension = ".tmp"
+	// storageHeaderSize is the size of the header of a file of stored transactions:
+	// the magic number, the time it was stored, the checksum and the length of the content
+	storageHeaderSize = 4 + 8 + 4 + 4
+	// apiKeyPlaceholder replaces the API keys in the stored transactions,
+	// they are restored from the configuration when the transactions are loaded
+	apiKeyPlaceholder = "{api_key_%d}"
+)
+
+// Reasons a transaction is dropped from the storage.
+const (
+	droppedBySize         = "size"
+	droppedByCorrupted    = "corrupted"
+	droppedByUnserialized = "unserialized"
+	droppedByWriteError   = "write_error"
+)
+
+var (
+	transactionsStored             = expvar.Int{}
+	transactionsStorageSizeInBytes = expvar.Int{}
+	transactionsDroppedFromStorage = expvar.Int{}
+
+	tlmTxStored = telemetry.NewCounter("transactions", "stored",
+		[]string{"domain"}, "Count of transactions stored on disk")
+	tlmTxStorageSize = telemetry.NewGauge("transactions", "storage_size_bytes",
+		[]string{"domain"}, "Size in bytes of the transactions stored on disk")
+	tlmTxDroppedFromStorage = telemetry.NewCounter("transactions", "storage_dropped",
+		[]string{"domain", "reason"}, "Count of transactions dropped from the disk storage grouped by reason")
+
+	// storageMagic identifies the files written by the storage.
+	storageMagic = []byte("DDTX")
+
+	errCorruptedTransactions = errors.New("corrupted transactions")
+
+	unsafeDomainCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+func initTransactionStorageExpvars() {
+	transactionsExpvars.Set("Stored", &transactionsStored)
+	transactionsExpvars.Set("StorageSizeInBytes", &transactionsStorageSizeInBytes)
+	transactionsExpvars.Set("DroppedFromStorage", &transactionsDroppedFromStorage)
+}
+
+// storedTransaction is the serialized form of an HTTPTransaction,
+// the handlers of the transaction are not stored.
+type storedTransaction struct {
+	Domain     string              `json:"domain"`
+	Endpoint   string              `json:"endpoint"`
+	Headers    http.Header         `json:"headers"`
+	Payload    []byte              `json:"payload"`
+	ErrorCount int                 `json:"error_count"`
+	CreatedAt  time.Time           `json:"created_at"`
+	Priority   TransactionPriority `json:"priority"`
+}
+
+// storageEntry is a file of stored transactions of the same priority.
+type storageEntry struct {
+	priority TransactionPriority
+	sequence uint64
+	count    int
+	size     int64
+}
+
+// transactionStorage stores on disk the transactions of a domain that don't fit
+// in the retry queue, so that they are not lost during long outages nor when the
+// agent restarts. The transactions with the highest priority are loaded first
+// and dropped last when the storage is full.
+type transactionStorage struct {
+	path         string
+	domain       string
+	apiKeys      []string
+	maxSize      int64
+	maxPerFile   int
+	mu           sync.Mutex
+	entries      []storageEntry // sorted by priority then sequence
+	size         int64
+	nextSequence uint64
+	timeNow      func() time.Time
+}
+
+// newTransactionStorage returns a new transactionStorage storing at most maxSize bytes of
+// transactions of domain in a directory of path, by files of at most maxPerFile transactions.
+// The transactions left on disk by a previous run are kept to be retried.
+func newTransactionStorage(path string, domain string, apiKeys []string, maxSize int64, maxPerFile int) (*transactionStorage, error) {
+	path = filepath.Join(path, unsafeDomainCharacters.ReplaceAllString(domain, "_"))
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	if maxPerFile <= 0 {
+		maxPerFile = 1
+	}
+	s := &transactionStorage{
+		path:       path,
+		domain:     domain,
+		apiKeys:    apiKeys,
+		maxSize:    maxSize,
+		maxPerFile: maxPerFile,
+		timeNow:    time.Now,
+	}
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// isEmpty returns true if no transaction is stored, a nil storage is always empty.
+func (s *transactionStorage) isEmpty() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries) == 0
+}
+
+// store writes the transactions on disk grouped by priority, the transactions
+// with a lower priority are dropped to make room for them when the storage is full.
+func (s *transactionStorage) store(transactions []Transaction) {
+	byPriority := make(map[TransactionPriority][]*HTTPTransaction)
+	for _, t := range transactions {
+		httpTransaction, ok := t.(*HTTPTransaction)
+		if !ok {
+			s.drop(1, droppedByUnserialized)
+			continue
+		}
+		byPriority[httpTransaction.priority] = append(byPriority[httpTransaction.priority], httpTransaction)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the transactions with the highest priority are written first to keep room for them
+	for priority := TransactionPriorityHigh; priority >= TransactionPriorityLow; priority-- {
+		transactions := byPriority[priority]
+		for len(transactions) > 0 {
+			count := s.maxPerFile
+			if count > len(transactions) {
+				count = len(transactions)
+			}
+			if err := s.write(priority, transactions[:count]); err != nil {
+				log.Errorf("Could not store %d transactions for %s on disk: %v", count, s.domain, err)
+				s.drop(count, droppedByWriteError)
+			}
+			transactions = transactions[count:]
+		}
+	}
+}
+
+// load returns the stored transactions with the highest priority, the most recent first,
+// as long as they fit in max transactions. The transactions are removed from disk.
+func (s *transactionStorage) load(max int) []Transaction {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transactions []Transaction
+	for len(s.entries) > 0 {
+		entry := s.entries[len(s.entries)-1]
+		if entry.count > max-len(transactions) {
+			break
+		}
+		loaded, err := s.read(entry)
+		if err != nil {
+			log.Warnf("Could not load the transactions stored in %s: %v", s.filename(entry), err)
+			s.remove(len(s.entries)-1, droppedByCorrupted)
+			continue
+		}
+		s.remove(len(s.entries)-1, "")
+		transactions = append(transactions, loaded...)
+	}
+	return transactions
+}
+
+// write stores the transactions in a new file, dropping the transactions with the same or a lower
+// priority to make room for them. The file is renamed once written so that partially written
+// transactions are never loaded.
+func (s *transactionStorage) write(priority TransactionPriority, transactions []*HTTPTransaction) error {
+	stored := make([]storedTransaction, 0, len(transactions))
+	for _, t := range transactions {
+		stored = append(stored, s.serialize(t))
+	}
+	content, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	size := int64(storageHeaderSize + len(content))
+	if size > s.maxSize {
+		return fmt.Errorf("transactions of %d bytes exceed the size of the storage", size)
+	}
+	for s.size+size > s.maxSize {
+		if s.entries[0].priority > priority {
+			s.drop(len(transactions), droppedBySize)
+			return nil
+		}
+		s.remove(0, droppedBySize)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	buf.Write(storageMagic)
+	binary.Write(buf, binary.BigEndian, s.timeNow().UnixNano())
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(content))
+	binary.Write(buf, binary.BigEndian, uint32(len(content)))
+	buf.Write(content)
+
+	entry := storageEntry{priority: priority, sequence: s.nextSequence, count: len(transactions), size: size}
+	filename := s.filename(entry)
+	tempFilename := filename + storageTempExtension
+	if err := ioutil.WriteFile(tempFilename, buf.Bytes(), 0600); err != nil {
+		os.Remove(tempFilename)
+		return err
+	}
+	if err := os.Rename(tempFilename, filename); err != nil {
+		os.Remove(tempFilename)
+		return err
+	}
+	s.nextSequence++
+	s.add(entry)
+	transactionsStored.Add(int64(entry.count))
+	tlmTxStored.Add(float64(entry.count), s.domain)
+	return nil
+}
+
+// read returns the transactions stored in a file,
+// it fails when the content of the file does not match its header.
+func (s *transactionStorage) read(entry storageEntry) ([]Transaction, error) {
+	content, err := ioutil.ReadFile(s.filename(entry))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < storageHeaderSize || !bytes.Equal(content[:4], storageMagic) {
+		return nil, errCorruptedTransactions
+	}
+	checksum := binary.BigEndian.Uint32(content[12:16])
+	length := binary.BigEndian.Uint32(content[16:20])
+	content = content[storageHeaderSize:]
+	if uint32(len(content)) != length || crc32.ChecksumIEEE(content) != checksum {
+		return nil, errCorruptedTransactions
+	}
+	var stored []storedTransaction
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, errCorruptedTransactions
+	}
+	transactions := make([]Transaction, 0, len(stored))
+	for _, st := range stored {
+		t, err := s.deserialize(st)
+		if err != nil {
+			log.Warnf("Could not load a stored transaction for %s: %v", s.domain, err)
+			s.drop(1, droppedByUnserialized)
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, nil
+}
+
+// serialize returns the stored form of a transaction, the API keys are replaced
+// by placeholders so that they are never written on disk.
+func (s *transactionStorage) serialize(t *HTTPTransaction) storedTransaction {
+	headers := make(http.Header, len(t.Headers))
+	for key, values := range t.Headers {
+		for _, value := range values {
+			headers.Add(key, s.replaceAPIKeys(value))
+		}
+	}
+	return storedTransaction{
+		Domain:     t.Domain,
+		Endpoint:   s.replaceAPIKeys(t.Endpoint),
+		Headers:    headers,
+		Payload:    *t.Payload,
+		ErrorCount: t.ErrorCount,
+		CreatedAt:  t.createdAt,
+		Priority:   t.priority,
+	}
+}
+
+// deserialize returns the transaction of its stored form, it fails when
+// the API keys it was sent with are not configured anymore.
+func (s *transactionStorage) deserialize(st storedTransaction) (*HTTPTransaction, error) {
+	t := NewHTTPTransaction()
+	t.Domain = st.Domain
+	t.ErrorCount = st.ErrorCount
+	t.createdAt = st.CreatedAt
+	t.priority = st.Priority
+	t.Payload = &st.Payload
+	var err error
+	if t.Endpoint, err = s.restoreAPIKeys(st.Endpoint); err != nil {
+		return nil, err
+	}
+	for key, values := range st.Headers {
+		for _, value := range values {
+			value, err = s.restoreAPIKeys(value)
+			if err != nil {
+				return nil, err
+			}
+			t.Headers.Add(key, value)
+		}
+	}
+	return t, nil
+}
+
+// replaceAPIKeys replaces the API keys of the domain by their placeholders.
+func (s *transactionStorage) replaceAPIKeys(value string) string {
+	for i, apiKey := range s.apiKeys {
+		if apiKey != "" {
+			value = strings.Replace(value, apiKey, fmt.Sprintf(apiKeyPlaceholder, i), -1)
+		}
+	}
+	return value
+}
+
+// restoreAPIKeys replaces the placeholders by the API keys of the domain.
+func (s *transactionStorage) restoreAPIKeys(value string) (string, error) {
+	for i, apiKey := range s.apiKeys {
+		value = strings.Replace(value, fmt.Sprintf(apiKeyPlaceholder, i), apiKey, -1)
+	}
+	if strings.Contains(value, "{api_key_") {
+		return "", fmt.Errorf("the API key of the transaction is not configured anymore")
+	}
+	return value, nil
+}
+
+// scan collects the transactions left on disk by a previous run.
+func (s *transactionStorage) scan() error {
+	files, err := ioutil.ReadDir(s.path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasSuffix(name, storageTempExtension) {
+			// the agent stopped while writing the transactions
+			os.Remove(filepath.Join(s.path, name))
+			continue
+		}
+		if !strings.HasSuffix(name, storageFileExtension) {
+			continue
+		}
+		var entry storageEntry
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, storageFileExtension), "%d_%d_%d", &entry.priority, &entry.sequence, &entry.count); err != nil {
+			continue
+		}
+		entry.size = file.Size()
+		s.add(entry)
+		if entry.sequence >= s.nextSequence {
+			s.nextSequence = entry.sequence + 1
+		}
+	}
+	for len(s.entries) > 0 && s.size > s.maxSize {
+		s.remove(0, droppedBySize)
+	}
+	return nil
+}
+
+// add registers a file of stored transactions.
+func (s *transactionStorage) add(entry storageEntry) {
+	i := sort.Search(len(s.entries), func(i int) bool {
+		e := s.entries[i]
+		return e.priority > entry.priority || (e.priority == entry.priority && e.sequence > entry.sequence)
+	})
+	s.entries = append(s.entries, storageEntry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = entry
+	s.addSize(entry.size)
+}
+
+// remove deletes the i-th file of stored transactions, reason is empty when the transactions were loaded.
+func (s *transactionStorage) remove(i int, reason string) {
+	entry := s.entries[i]
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	s.addSize(-entry.size)
+	if err := os.Remove(s.filename(entry)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove stored transactions: %v", err)
+	}
+	if reason != "" {
+		s.drop(entry.count, reason)
+	}
+}
+
+// drop counts count transactions dropped for reason.
+func (s *transactionStorage) drop(count int, reason string) {
+	transactionsDropped.Add(int64(count))
+	tlmTxDropped.Add(float64(count), s.domain)
+	transactionsDroppedFromStorage.Add(int64(count))
+	tlmTxDroppedFromStorage.Add(float64(count), s.domain, reason)
+}
+
+// addSize updates the number of bytes stored on disk.
+func (s *transactionStorage) addSize(size int64) {
+	s.size += size
+	transactionsStorageSizeInBytes.Add(size)
+	tlmTxStorageSize.Set(float64(s.size), s.domain)
+}
+
+// filename returns the path of a file of stored transactions.
+func (s *transactionStorage) filename(entry storageEntry) string {
+	return filepath.Join(s.path, fmt.Sprintf("%d_%020d_%d%s", entry.priority, entry.sequence, entry.count, storageFileExtension))
+}
@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStoredTestTransaction(endpoint string, priority TransactionPriority) *HTTPTransaction {
+	payload := []byte("payload of " + endpoint)
+	t := NewHTTPTransaction()
+	t.Domain = "https://app.datadoghq.com"
+	t.Endpoint = endpoint + "?api_key=api-key-1"
+	t.Headers.Set(apiHTTPHeaderKey, "api-key-1")
+	t.Payload = &payload
+	t.priority = priority
+	t.createdAt = time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	return t
+}
+
+func newTestTransactionStorage(t *testing.T, maxSize int64) (*transactionStorage, string) {
+	path, err := ioutil.TempDir("", "transactions")
+	require.NoError(t, err)
+	storage, err := newTransactionStorage(path, "https://app.datadoghq.com", []string{"api-key-1"}, maxSize, 2)
+	require.NoError(t, err)
+	return storage, path
+}
+
+func TestTransactionStorageStoresAndLoadsTheTransactions(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	storage.store([]Transaction{
+		newStoredTestTransaction("/api/beta/sketches", TransactionPriorityLow),
+		newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal),
+		newStoredTestTransaction("/api/v2/metadata", TransactionPriorityHigh),
+		newTestTransaction(), // can't be stored
+	})
+	assert.False(t, storage.isEmpty())
+
+	// the API keys are not written on disk
+	files, err := filepath.Glob(filepath.Join(storage.path, "*"+storageFileExtension))
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "api-key-1")
+	}
+
+	// the transactions with the highest priority are loaded first
+	loaded := storage.load(2)
+	require.Len(t, loaded, 2)
+	metadata := loaded[0].(*HTTPTransaction)
+	assert.Equal(t, "/api/v2/metadata?api_key=api-key-1", metadata.Endpoint)
+	assert.Equal(t, "api-key-1", metadata.Headers.Get(apiHTTPHeaderKey))
+	assert.Equal(t, "payload of /api/v2/metadata", string(*metadata.Payload))
+	assert.Equal(t, TransactionPriorityHigh, metadata.GetPriority())
+	assert.Equal(t, "/api/v2/series?api_key=api-key-1", loaded[1].(*HTTPTransaction).Endpoint)
+
+	loaded = storage.load(2)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, TransactionPriorityLow, loaded[0].GetPriority())
+	assert.True(t, storage.isEmpty())
+}
+
+func TestTransactionStorageResumesOnStartup(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	storage.store([]Transaction{
+		newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal),
+		newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal),
+		newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal),
+	})
+	// a file being written when the agent stopped
+	require.NoError(t, ioutil.WriteFile(filepath.Join(storage.path, "1_00000000000000000009_1.retry.tmp"), []byte("DDTX"), 0600))
+
+	storage, err := newTransactionStorage(path, "https://app.datadoghq.com", []string{"api-key-1"}, 1024*1024, 2)
+	require.NoError(t, err)
+	assert.Len(t, storage.entries, 2)
+	assert.Equal(t, uint64(2), storage.nextSequence)
+	assert.Len(t, storage.load(10), 3)
+	_, err = os.Stat(filepath.Join(storage.path, "1_00000000000000000009_1.retry.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTransactionStorageDropsTheLowestPriorityFirst(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	storage.store([]Transaction{newStoredTestTransaction("/api/beta/sketches", TransactionPriorityLow)})
+	storage.store([]Transaction{newStoredTestTransaction("/api/v2/metadata", TransactionPriorityHigh)})
+	storage.maxSize = storage.size + 1
+
+	dropped := transactionsDroppedFromStorage.Value()
+	storage.store([]Transaction{newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)})
+	assert.Equal(t, dropped+1, transactionsDroppedFromStorage.Value())
+	require.Len(t, storage.entries, 2)
+	assert.Equal(t, TransactionPriorityNormal, storage.entries[0].priority)
+	assert.Equal(t, TransactionPriorityHigh, storage.entries[1].priority)
+
+	// there is no room for a transaction with a lower priority
+	storage.store([]Transaction{newStoredTestTransaction("/api/beta/sketches", TransactionPriorityLow)})
+	assert.Equal(t, dropped+2, transactionsDroppedFromStorage.Value())
+	require.Len(t, storage.entries, 2)
+	assert.Equal(t, TransactionPriorityNormal, storage.entries[0].priority)
+}
+
+func TestTransactionStorageDropsTheCorruptedTransactions(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	storage.store([]Transaction{newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)})
+	storage.store([]Transaction{newStoredTestTransaction("/api/v2/metadata", TransactionPriorityHigh)})
+
+	filename := storage.filename(storage.entries[1])
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	content[len(content)-2] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(filename, content, 0600))
+
+	dropped := transactionsDroppedFromStorage.Value()
+	loaded := storage.load(10)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, TransactionPriorityNormal, loaded[0].GetPriority())
+	assert.Equal(t, dropped+1, transactionsDroppedFromStorage.Value())
+	assert.True(t, storage.isEmpty())
+}
+
+func TestTransactionStorageDropsTheTransactionsOfRemovedAPIKeys(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	storage.store([]Transaction{newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)})
+	storage.apiKeys = nil
+	assert.Len(t, storage.load(10), 0)
+}
+
+func TestStoredTransactionKeepsItsState(t *testing.T) {
+	storage, path := newTestTransactionStorage(t, 1024*1024)
+	defer os.RemoveAll(path)
+
+	transaction := newStoredTestTransaction("/api/v2/series", TransactionPriorityNormal)
+	transaction.ErrorCount = 3
+	storage.store([]Transaction{transaction})
+
+	loaded := storage.load(1)
+	require.Len(t, loaded, 1)
+	assert.True(t, transaction.createdAt.Equal(loaded[0].GetCreatedAt()))
+	assert.Equal(t, 3, loaded[0].(*HTTPTransaction).ErrorCount)
+}
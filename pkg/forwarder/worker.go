@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/egress"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 )
 
@@ -31,9 +32,10 @@ type Worker struct {
 	// RequeueChan is the channel used to send failed transaction back to the Forwarder.
 	RequeueChan chan<- Transaction
 
-	stopChan    chan struct{}
-	stopped     chan struct{}
-	blockedList *blockedEndpoints
+	stopChan      chan struct{}
+	stopped       chan struct{}
+	blockedList   *blockedEndpoints
+	egressLimiter *egress.Limiter
 }
 
 // NewWorker returns a new worker to consume Transaction from inputChan
@@ -47,13 +49,14 @@ func NewWorker(highPrioChan <-chan Transaction, lowPrioChan <-chan Transaction,
 	}
 
 	return &Worker{
-		HighPrio:    highPrioChan,
-		LowPrio:     lowPrioChan,
-		RequeueChan: requeueChan,
-		stopChan:    make(chan struct{}),
-		stopped:     make(chan struct{}),
-		Client:      httpClient,
-		blockedList: blocked,
+		HighPrio:      highPrioChan,
+		LowPrio:       lowPrioChan,
+		RequeueChan:   requeueChan,
+		stopChan:      make(chan struct{}),
+		stopped:       make(chan struct{}),
+		Client:        httpClient,
+		blockedList:   blocked,
+		egressLimiter: egress.NewLimiter(egress.Metrics),
 	}
 }
 
@@ -151,6 +154,10 @@ func (w *Worker) process(ctx context.Context, t Transaction) {
 	if w.blockedList.isBlock(target) {
 		requeue()
 		log.Errorf("Too many errors for endpoint '%s': retrying later", target)
+	} else if err := w.egressLimiter.Wait(ctx, t.GetPayloadSize()); err != nil {
+		// the worker is stopping while the transaction is throttled, it's retried later
+		requeue()
+		log.Debugf("Transaction to '%s' canceled while waiting for the egress bandwidth", target)
 	} else if err := t.Process(ctx, w.Client); err != nil {
 		w.blockedList.close(target)
 		requeue()
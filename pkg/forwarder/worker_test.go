@@ -6,13 +6,16 @@
 package forwarder
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/egress"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewWorker(t *testing.T) {
@@ -110,6 +113,26 @@ func TestWorkerRetryBlockedTransaction(t *testing.T) {
 	assert.True(t, w.blockedList.isBlock("error_url"))
 }
 
+func TestWorkerRetryThrottledTransaction(t *testing.T) {
+	highPrio := make(chan Transaction)
+	lowPrio := make(chan Transaction)
+	requeue := make(chan Transaction, 1)
+	w := NewWorker(highPrio, lowPrio, requeue, newBlockedEndpoints())
+	// the link is busy for a long time with a first payload
+	w.egressLimiter = egress.NewShaper(1, map[string]float64{}).Limiter(egress.Metrics)
+	require.NoError(t, w.egressLimiter.Wait(context.Background(), 1000))
+
+	mock := newTestTransaction()
+	mock.On("GetTarget").Return("throttled_url").Times(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.process(ctx, mock)
+	mock.AssertNumberOfCalls(t, "Process", 0)
+	require.Len(t, requeue, 1)
+	assert.Equal(t, mock, <-requeue)
+}
+
 func TestWorkerPurgeOnStop(t *testing.T) {
 	highPrio := make(chan Transaction, 1)
 	lowPrio := make(chan Transaction, 1)
@@ -16,13 +16,21 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/cloudfoundry"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/container"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/containerd"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/file"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/journald"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kafka"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kubeaudit"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/listener"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/windowsevent"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 )
 
@@ -42,7 +50,7 @@ type Agent struct {
 }
 
 // NewAgent returns a new Agent
-func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, endpoints *config.Endpoints) *Agent {
+func NewAgent(sources *config.LogSources, services *service.Services, processingRules []*config.ProcessingRule, scrubber *processor.Scrubber, rateLimiter *processor.RateLimiter, jsonRemapper *processor.JSONRemapper, traceContexts *processor.TraceContextInjector, endpoints *config.Endpoints) *Agent {
 	health := health.Register("logs-agent")
 
 	// setup the auditor
@@ -52,15 +60,25 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 	destinationsCtx := client.NewDestinationsContext()
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, processingRules, endpoints, destinationsCtx)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, processingRules, scrubber, rateLimiter, jsonRemapper, traceContexts, endpoints, destinationsCtx, newDiskBuffer())
 
 	// setup the inputs
 	inputs := []restart.Restartable{
 		file.NewScanner(sources, coreConfig.Datadog.GetInt("logs_config.open_files_limit"), pipelineProvider, auditor, file.DefaultSleepDuration),
 		container.NewLauncher(coreConfig.Datadog.GetBool("logs_config.container_collect_all"), coreConfig.Datadog.GetBool("logs_config.k8s_container_use_file"), sources, services, pipelineProvider, auditor),
+		kubelet.NewLauncher(sources, pipelineProvider, auditor),
 		listener.NewLauncher(sources, coreConfig.Datadog.GetInt("logs_config.frame_size"), pipelineProvider),
+		syslog.NewLauncher(sources, coreConfig.Datadog.GetInt("logs_config.frame_size"), pipelineProvider),
+		kubeaudit.NewLauncher(sources, pipelineProvider),
 		journald.NewLauncher(sources, pipelineProvider, auditor),
-		windowsevent.NewLauncher(sources, pipelineProvider),
+		kafka.NewLauncher(sources, pipelineProvider),
+		cloudfoundry.NewLauncher(sources, pipelineProvider),
+		windowsevent.NewLauncher(sources, pipelineProvider, auditor),
+	}
+	if launcher, err := containerd.NewLauncher(sources, coreConfig.Datadog.GetBool("logs_config.container_collect_all")); err == nil {
+		inputs = append(inputs, launcher)
+	} else {
+		log.Debugf("Could not setup the containerd launcher: %v", err)
 	}
 
 	return &Agent{
@@ -72,6 +90,20 @@ func NewAgent(sources *config.LogSources, services *service.Services, processing
 	}
 }
 
+// newDiskBuffer returns the disk buffer shared by all the pipelines,
+// or nil when the logs that can't be sent are not buffered on disk.
+func newDiskBuffer() *sender.DiskBuffer {
+	if !coreConfig.Datadog.GetBool("logs_config.disk_buffer_enabled") {
+		return nil
+	}
+	diskBuffer, err := sender.NewDiskBuffer(config.DiskBufferPath(), config.DiskBufferMaxSize(), config.DiskBufferMaxAge())
+	if err != nil {
+		log.Warnf("Could not setup the disk buffer, the logs that can't be sent won't be buffered on disk: %v", err)
+		return nil
+	}
+	return diskBuffer
+}
+
 // Start starts all the elements of the data pipeline
 // in the right order to prevent data loss
 func (a *Agent) Start() {
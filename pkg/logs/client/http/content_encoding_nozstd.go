@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !zstd
+
+package http
+
+// newZstdContentEncoding returns nil,
+// the agent is built without zstd support.
+func newZstdContentEncoding(level int) ContentEncoding {
+	return nil
+}
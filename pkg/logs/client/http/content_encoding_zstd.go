@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build zstd
+
+package http
+
+import (
+	zstd "github.com/DataDog/zstd.v1.3"
+)
+
+// ZstdContentEncoding encodes the payload using zstd algorithm
+type ZstdContentEncoding struct {
+	level int
+}
+
+// NewZstdContentEncoding creates a new Zstd content type
+func NewZstdContentEncoding(level int) *ZstdContentEncoding {
+	if level < zstd.BestSpeed {
+		level = zstd.BestSpeed
+	} else if level > zstd.BestCompression {
+		level = zstd.BestCompression
+	}
+
+	return &ZstdContentEncoding{
+		level,
+	}
+}
+
+func (c *ZstdContentEncoding) name() string {
+	return "zstd"
+}
+
+func (c *ZstdContentEncoding) encode(payload []byte) ([]byte, error) {
+	return zstd.CompressLevel(nil, payload, c.level)
+}
+
+// newZstdContentEncoding returns a zstd content encoding,
+// the agent is built with zstd support.
+func newZstdContentEncoding(level int) ContentEncoding {
+	return NewZstdContentEncoding(level)
+}
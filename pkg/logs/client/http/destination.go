@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/egress"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 )
 
@@ -26,8 +27,9 @@ const (
 
 // HTTP errors.
 var (
-	errClient = errors.New("client error")
-	errServer = errors.New("server error")
+	errClient              = errors.New("client error")
+	errServer              = errors.New("server error")
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
 )
 
 // emptyPayload is an empty payload used to check HTTP connectivity without sending logs.
@@ -38,10 +40,15 @@ type Destination struct {
 	url                 string
 	contentType         string
 	contentEncoding     ContentEncoding
+	negotiateEncoding   bool
+	compressionLevel    int
+	encodingMutex       sync.RWMutex
 	client              *http.Client
 	destinationsContext *client.DestinationsContext
 	once                sync.Once
 	payloadChan         chan []byte
+	throughput          *throughputMeter
+	egressLimiter       *egress.Limiter
 }
 
 // NewDestination returns a new Destination.
@@ -52,30 +59,54 @@ func NewDestination(endpoint config.Endpoint, contentType string, destinationsCo
 
 func newDestination(endpoint config.Endpoint, contentType string, destinationsContext *client.DestinationsContext, timeout time.Duration) *Destination {
 	return &Destination{
-		url:             buildURL(endpoint),
-		contentType:     contentType,
-		contentEncoding: buildContentEncoding(endpoint),
+		url:               buildURL(endpoint),
+		contentType:       contentType,
+		contentEncoding:   buildContentEncoding(endpoint),
+		negotiateEncoding: endpoint.UseCompression && endpoint.ContentEncoding == config.AutoContentEncoding,
+		compressionLevel:  endpoint.CompressionLevel,
 		client: &http.Client{
 			Timeout: timeout,
 			// reusing core agent HTTP transport to benefit from proxy settings.
 			Transport: httputils.CreateHTTPTransport(),
 		},
 		destinationsContext: destinationsContext,
+		throughput:          newThroughputMeter(endpointName(endpoint), throughputWindow),
+		egressLimiter:       egress.NewLimiter(egress.Logs),
 	}
 }
 
 // Send sends a payload over HTTP,
 // the error returned can be retryable and it is the responsibility of the callee to retry.
 func (d *Destination) Send(payload []byte) error {
+	contentEncoding := d.getContentEncoding()
+	err := d.send(payload, contentEncoding)
+	if err == errUnsupportedEncoding && d.negotiateEncoding && contentEncoding.name() != "gzip" {
+		// the intake does not support the encoding, fallback on gzip for all the next payloads
+		log.Warnf("The logs intake does not support the %s content encoding, fallback on gzip", contentEncoding.name())
+		d.setContentEncoding(NewGzipContentEncoding(d.compressionLevel))
+		return d.send(payload, d.getContentEncoding())
+	}
+	if err == errUnsupportedEncoding {
+		return errClient
+	}
+	return err
+}
+
+func (d *Destination) send(payload []byte, contentEncoding ContentEncoding) error {
 	ctx := d.destinationsContext.Context()
 
-	encodedPayload, err := d.contentEncoding.encode(payload)
+	encodedPayload, err := contentEncoding.encode(payload)
 	if err != nil {
 		return err
 	}
 	metrics.BytesSent.Add(int64(len(payload)))
 	metrics.EncodedBytesSent.Add(int64(len(encodedPayload)))
 
+	if err := d.egressLimiter.Wait(ctx, len(encodedPayload)); err != nil {
+		// the context is cancelled
+		return err
+	}
+
 	req, err := http.NewRequest("POST", d.url, bytes.NewReader(encodedPayload))
 	if err != nil {
 		// the request could not be built,
@@ -83,7 +114,7 @@ func (d *Destination) Send(payload []byte) error {
 		return err
 	}
 	req.Header.Set("Content-Type", d.contentType)
-	req.Header.Set("Content-Encoding", d.contentEncoding.name())
+	req.Header.Set("Content-Encoding", contentEncoding.name())
 	req = req.WithContext(ctx)
 
 	resp, err := d.client.Do(req)
@@ -107,15 +138,30 @@ func (d *Destination) Send(payload []byte) error {
 		// the server could not serve the request,
 		// most likely because of an internal error
 		return client.NewRetryableError(errServer)
+	} else if resp.StatusCode == http.StatusUnsupportedMediaType {
+		return errUnsupportedEncoding
 	} else if resp.StatusCode >= 400 {
 		// the logs-agent is likely to be misconfigured,
 		// the URL or the API key may be wrong.
 		return errClient
 	} else {
+		d.throughput.add(len(encodedPayload), time.Now())
 		return nil
 	}
 }
 
+func (d *Destination) getContentEncoding() ContentEncoding {
+	d.encodingMutex.RLock()
+	defer d.encodingMutex.RUnlock()
+	return d.contentEncoding
+}
+
+func (d *Destination) setContentEncoding(contentEncoding ContentEncoding) {
+	d.encodingMutex.Lock()
+	defer d.encodingMutex.Unlock()
+	d.contentEncoding = contentEncoding
+}
+
 // SendAsync sends a payload in background.
 func (d *Destination) SendAsync(payload []byte) {
 	d.once.Do(func() {
@@ -158,11 +204,28 @@ func buildURL(endpoint config.Endpoint) string {
 	return fmt.Sprintf("%v://%v/v1/input/%v", scheme, address, endpoint.APIKey)
 }
 
+// endpointName returns the address of an endpoint, without its api key.
+func endpointName(endpoint config.Endpoint) string {
+	if endpoint.Port != 0 {
+		return fmt.Sprintf("%v:%v", endpoint.Host, endpoint.Port)
+	}
+	return endpoint.Host
+}
+
 func buildContentEncoding(endpoint config.Endpoint) ContentEncoding {
-	if endpoint.UseCompression {
-		return NewGzipContentEncoding(endpoint.CompressionLevel)
+	if !endpoint.UseCompression {
+		return IdentityContentType
+	}
+	switch endpoint.ContentEncoding {
+	case config.ZstdContentEncoding, config.AutoContentEncoding:
+		if contentEncoding := newZstdContentEncoding(endpoint.CompressionLevel); contentEncoding != nil {
+			return contentEncoding
+		}
+		if endpoint.ContentEncoding == config.ZstdContentEncoding {
+			log.Warnf("The agent is built without zstd support, fallback on gzip for %s", endpointName(endpoint))
+		}
 	}
-	return IdentityContentType
+	return NewGzipContentEncoding(endpoint.CompressionLevel)
 }
 
 // CheckConnectivity check if sending logs through HTTP works
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/stretchr/testify/assert"
@@ -121,3 +122,72 @@ func TestConnectivityCheck(t *testing.T) {
 	assert.Equal(t, config.HTTPConnectivityFailure, connectivity)
 	server.stop()
 }
+
+type unsupportedContentEncoding struct{}
+
+func (c *unsupportedContentEncoding) name() string {
+	return "unsupported"
+}
+
+func (c *unsupportedContentEncoding) encode(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func TestDestinationFallbacksOnGzipWhenEncodingIsNotSupported(t *testing.T) {
+	var encodings []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+	url := strings.Split(ts.URL, ":")
+	port, _ := strconv.Atoi(url[2])
+	destCtx := client.NewDestinationsContext()
+	destCtx.Start()
+	defer destCtx.Stop()
+
+	endpoint := config.Endpoint{
+		APIKey:           "test",
+		Host:             strings.Replace(url[1], "/", "", -1),
+		Port:             port,
+		UseCompression:   true,
+		CompressionLevel: 6,
+		ContentEncoding:  config.AutoContentEncoding,
+	}
+	dest := NewDestination(endpoint, JSONContentType, destCtx)
+	// zstd may not be available in this build
+	dest.setContentEncoding(&unsupportedContentEncoding{})
+	assert.Nil(t, dest.Send([]byte("yo")))
+	assert.Nil(t, dest.Send([]byte("yo")))
+	assert.Equal(t, []string{"unsupported", "gzip", "gzip"}, encodings)
+	assert.Equal(t, "gzip", dest.getContentEncoding().name())
+
+	// the encoding is not negotiated unless auto
+	endpoint.ContentEncoding = config.GzipContentEncoding
+	endpoint.UseCompression = false
+	dest = NewDestination(endpoint, JSONContentType, destCtx)
+	err := dest.Send([]byte("yo"))
+	assert.Equal(t, "client error", err.Error())
+}
+
+func TestBuildContentEncoding(t *testing.T) {
+	assert.Equal(t, "identity", buildContentEncoding(config.Endpoint{}).name())
+	assert.Equal(t, "gzip", buildContentEncoding(config.Endpoint{UseCompression: true}).name())
+	assert.Equal(t, "gzip", buildContentEncoding(config.Endpoint{UseCompression: true, ContentEncoding: config.GzipContentEncoding}).name())
+}
+
+func TestThroughputMeter(t *testing.T) {
+	meter := newThroughputMeter("foo:1234", 10*time.Second)
+	start := meter.start
+
+	meter.add(1000, start.Add(time.Second))
+	assert.Equal(t, 0.0, meter.gauge.Value())
+
+	meter.add(1000, start.Add(10*time.Second))
+	assert.Equal(t, 200.0, meter.gauge.Value())
+	assert.Equal(t, int64(0), meter.bytes)
+}
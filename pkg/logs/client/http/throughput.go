@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package http
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+// throughputWindow is the period over which the throughput of an endpoint is measured
+const throughputWindow = 10 * time.Second
+
+// throughputMeter measures the number of bytes sent per second to an endpoint,
+// the gauges are updated once per window.
+type throughputMeter struct {
+	mu       sync.Mutex
+	endpoint string
+	window   time.Duration
+	start    time.Time
+	bytes    int64
+	gauge    *expvar.Float
+}
+
+func newThroughputMeter(endpoint string, window time.Duration) *throughputMeter {
+	gauge := &expvar.Float{}
+	metrics.DestinationThroughput.Set(endpoint, gauge)
+	return &throughputMeter{
+		endpoint: endpoint,
+		window:   window,
+		start:    time.Now(),
+		gauge:    gauge,
+	}
+}
+
+// add records the bytes sent at the given time
+func (m *throughputMeter) add(bytes int, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += int64(bytes)
+	elapsed := now.Sub(m.start)
+	if elapsed < m.window {
+		return
+	}
+	rate := float64(m.bytes) / elapsed.Seconds()
+	m.gauge.Set(rate)
+	metrics.TlmDestinationThroughput.Set(rate, m.endpoint)
+	m.start = now
+	m.bytes = 0
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/egress"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -29,6 +30,7 @@ type Destination struct {
 	conn                net.Conn
 	inputChan           chan []byte
 	once                sync.Once
+	egressLimiter       *egress.Limiter
 }
 
 // NewDestination returns a new destination.
@@ -39,6 +41,7 @@ func NewDestination(endpoint config.Endpoint, useProto bool, destinationsContext
 		delimiter:           NewDelimiter(useProto),
 		connManager:         NewConnectionManager(endpoint),
 		destinationsContext: destinationsContext,
+		egressLimiter:       egress.NewLimiter(egress.Logs),
 	}
 }
 
@@ -69,6 +72,11 @@ func (d *Destination) Send(payload []byte) error {
 		return err
 	}
 
+	if err := d.egressLimiter.Wait(d.destinationsContext.Context(), len(frame)); err != nil {
+		// the context is cancelled
+		return err
+	}
+
 	_, err = d.conn.Write(frame)
 	if err != nil {
 		d.connManager.CloseConnection(d.conn)
@@ -9,10 +9,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -89,6 +91,47 @@ func GlobalProcessingRules() ([]*ProcessingRule, error) {
 
 // BuildEndpoints returns the endpoints to send logs.
 func BuildEndpoints(httpConnectivity HTTPConnectivity) (*Endpoints, error) {
+	if fips.Enabled() {
+		return buildFIPSEndpoints(httpConnectivity)
+	}
+	return buildEndpoints(httpConnectivity)
+}
+
+// buildFIPSEndpoints returns the endpoints to send logs to in FIPS mode: the local FIPS proxy
+// over HTTP, or the endpoints encrypted by the FIPS-validated crypto provider.
+func buildFIPSEndpoints(httpConnectivity HTTPConnectivity) (*Endpoints, error) {
+	if err := fips.Check(fips.LogsAgent); err != nil {
+		return nil, err
+	}
+
+	if host, port, proxied := fips.ProxyAddress(fips.LogsAgent); proxied {
+		endpoints, err := BuildHTTPEndpoints()
+		if err != nil {
+			return nil, err
+		}
+		endpoints.Main.Host = host
+		endpoints.Main.Port = port
+		endpoints.Main.UseSSL = false
+		if len(endpoints.Additionals) > 0 {
+			log.Errorf("The additional endpoints of the logs can't be reached through the FIPS proxy, dropping them")
+			endpoints.Additionals = nil
+		}
+		return endpoints, nil
+	}
+
+	endpoints, err := buildEndpoints(httpConnectivity)
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range append([]Endpoint{endpoints.Main}, endpoints.Additionals...) {
+		if !endpoint.UseSSL {
+			return nil, fips.NotCompliant(fips.LogsAgent, fmt.Errorf("the logs are sent unencrypted to %s", endpoint.Host))
+		}
+	}
+	return endpoints, nil
+}
+
+func buildEndpoints(httpConnectivity HTTPConnectivity) (*Endpoints, error) {
 	if coreConfig.Datadog.GetBool("logs_config.dev_mode_no_ssl") {
 		log.Warnf("Use of illegal configuration parameter, if you need to send your logs to a proxy, please use 'logs_config.logs_dd_url' and 'logs_config.logs_no_ssl' instead")
 	}
@@ -166,6 +209,7 @@ func BuildHTTPEndpoints() (*Endpoints, error) {
 		APIKey:           getLogsAPIKey(coreConfig.Datadog),
 		UseCompression:   coreConfig.Datadog.GetBool("logs_config.use_compression"),
 		CompressionLevel: coreConfig.Datadog.GetInt("logs_config.compression_level"),
+		ContentEncoding:  contentEncoding(coreConfig.Datadog),
 	}
 
 	switch {
@@ -185,11 +229,21 @@ func BuildHTTPEndpoints() (*Endpoints, error) {
 	additionals := getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
 		additionals[i].UseSSL = main.UseSSL
+		if additionals[i].ContentEncoding == "" {
+			additionals[i].ContentEncoding = main.ContentEncoding
+		}
 	}
 
 	batchWait := batchWait(coreConfig.Datadog)
 
-	return NewEndpoints(main, additionals, false, true, batchWait), nil
+	endpoints := NewEndpoints(main, additionals, false, true, batchWait)
+	endpoints.BatchMaxSize = positiveIntOrDefault(coreConfig.Datadog, "logs_config.batch_max_size", coreConfig.DefaultBatchMaxSize)
+	endpoints.BatchMaxContentSize = positiveIntOrDefault(coreConfig.Datadog, "logs_config.batch_max_content_size", coreConfig.DefaultBatchMaxContentSize)
+	endpoints.BatchMaxConcurrentSend = positiveIntOrDefault(coreConfig.Datadog, "logs_config.batch_max_concurrent_send", coreConfig.DefaultBatchMaxConcurrentSend)
+	endpoints.BatchMaxInflight = batchMaxInflight(coreConfig.Datadog, endpoints.BatchMaxConcurrentSend)
+	endpoints.AdaptiveBatching = coreConfig.Datadog.GetBool("logs_config.adaptive_batching")
+
+	return endpoints, nil
 }
 
 func getAdditionalEndpoints() []Endpoint {
@@ -244,6 +298,81 @@ func batchWait(config coreConfig.Config) time.Duration {
 	return (time.Duration(batchWait) * time.Second)
 }
 
+// positiveIntOrDefault returns the value of the key, or the default value when it is not positive.
+func positiveIntOrDefault(config coreConfig.Config, key string, defaultValue int) int {
+	value := config.GetInt(key)
+	if value < 1 {
+		log.Warnf("Invalid %s: %v should be positive, fallback on %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// batchMaxInflight returns the maximum number of batches being sent or waiting to be sent,
+// it can't be lower than the number of batches sent concurrently.
+func batchMaxInflight(config coreConfig.Config, concurrentSend int) int {
+	inflight := config.GetInt("logs_config.batch_max_inflight")
+	if inflight == 0 {
+		return 2 * concurrentSend
+	}
+	if inflight < concurrentSend {
+		log.Warnf("Invalid batch_max_inflight: %v should be greater than batch_max_concurrent_send, fallback on %v", inflight, concurrentSend)
+		return concurrentSend
+	}
+	return inflight
+}
+
+// contentEncoding returns the algorithm compressing the logs sent over HTTP.
+func contentEncoding(config coreConfig.Config) string {
+	encoding := config.GetString("logs_config.content_encoding")
+	switch encoding {
+	case GzipContentEncoding, ZstdContentEncoding, AutoContentEncoding:
+		return encoding
+	default:
+		log.Warnf("Invalid content_encoding: %v should be one of %s, %s or %s, fallback on %s", encoding, GzipContentEncoding, ZstdContentEncoding, AutoContentEncoding, GzipContentEncoding)
+		return GzipContentEncoding
+	}
+}
+
+// DiskBufferPath returns the directory where the logs that can't be sent are buffered.
+func DiskBufferPath() string {
+	if isSetAndNotEmpty(coreConfig.Datadog, "logs_config.disk_buffer_path") {
+		return coreConfig.Datadog.GetString("logs_config.disk_buffer_path")
+	}
+	return filepath.Join(coreConfig.Datadog.GetString("logs_config.run_path"), "disk_buffer")
+}
+
+// ScrubbingHashKeyPath returns the path of the per-install key of the hash scrubbing rules.
+func ScrubbingHashKeyPath() string {
+	return filepath.Join(coreConfig.Datadog.GetString("logs_config.run_path"), "scrubbing_hash_key")
+}
+
+// DiskBufferMaxSize returns the maximum size in bytes of the logs buffered on disk.
+func DiskBufferMaxSize() int64 {
+	return int64(positiveIntOrDefault(coreConfig.Datadog, "logs_config.disk_buffer_max_size", coreConfig.DefaultDiskBufferMaxSize))
+}
+
+// DiskBufferMaxAge returns how long the logs are kept on disk, they are kept until the disk buffer is full when it is 0.
+func DiskBufferMaxAge() time.Duration {
+	maxAge := coreConfig.Datadog.GetInt("logs_config.disk_buffer_max_age")
+	if maxAge < 0 {
+		log.Warnf("Invalid disk_buffer_max_age: %v should be positive, fallback on %v", maxAge, coreConfig.DefaultDiskBufferMaxAge)
+		maxAge = coreConfig.DefaultDiskBufferMaxAge
+	}
+	return time.Duration(maxAge) * time.Second
+}
+
+// defaultTraceAgentPort is the port the trace agent listens to when apm_config.receiver_port is not set
+const defaultTraceAgentPort = 8126
+
+// TraceAgentPort returns the port of the trace agent maintaining the active traces of the containers.
+func TraceAgentPort() int {
+	if !coreConfig.Datadog.IsSet("apm_config.receiver_port") {
+		return defaultTraceAgentPort
+	}
+	return positiveIntOrDefault(coreConfig.Datadog, "apm_config.receiver_port", defaultTraceAgentPort)
+}
+
 // TaggerWarmupDuration is used to configure the tag providers
 func TaggerWarmupDuration() time.Duration {
 	return coreConfig.Datadog.GetDuration("logs_config.tagger_warmup_duration") * time.Second
@@ -138,6 +138,32 @@ func (suite *ConfigTestSuite) TestTaggerWarmupDuration() {
 	suite.Equal(5*time.Second, taggerWarmupDuration)
 }
 
+func (suite *ConfigTestSuite) TestDiskBufferSettings() {
+	suite.config.Set("logs_config.run_path", "/opt/datadog-agent/run")
+	suite.Equal("/opt/datadog-agent/run/disk_buffer", DiskBufferPath())
+	suite.Equal(int64(coreConfig.DefaultDiskBufferMaxSize), DiskBufferMaxSize())
+	suite.Equal(24*time.Hour, DiskBufferMaxAge())
+
+	suite.config.Set("logs_config.disk_buffer_path", "/var/lib/datadog/logs")
+	suite.config.Set("logs_config.disk_buffer_max_size", 1024)
+	suite.config.Set("logs_config.disk_buffer_max_age", 0)
+	suite.Equal("/var/lib/datadog/logs", DiskBufferPath())
+	suite.Equal(int64(1024), DiskBufferMaxSize())
+	suite.Equal(time.Duration(0), DiskBufferMaxAge())
+
+	suite.config.Set("logs_config.disk_buffer_max_size", -1)
+	suite.config.Set("logs_config.disk_buffer_max_age", -1)
+	suite.Equal(int64(coreConfig.DefaultDiskBufferMaxSize), DiskBufferMaxSize())
+	suite.Equal(24*time.Hour, DiskBufferMaxAge())
+}
+
+func (suite *ConfigTestSuite) TestTraceAgentPort() {
+	suite.Equal(8126, TraceAgentPort())
+
+	suite.config.Set("apm_config.receiver_port", 8127)
+	suite.Equal(8127, TraceAgentPort())
+}
+
 func TestConfigTestSuite(t *testing.T) {
 	suite.Run(t, new(ConfigTestSuite))
 }
@@ -161,23 +187,30 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsEnvVar() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 6}
+		CompressionLevel: 6,
+		ContentEncoding:  "gzip"}
 	expectedAdditionalEndpoint1 := Endpoint{
 		APIKey:           "456",
 		Host:             "additional.endpoint.1",
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 2}
+		CompressionLevel: 2,
+		ContentEncoding:  "gzip"}
 	expectedAdditionalEndpoint2 := Endpoint{
 		APIKey:           "789",
 		Host:             "additional.endpoint.2",
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 2}
+		CompressionLevel: 2,
+		ContentEncoding:  "gzip"}
 
 	expectedEndpoints := NewEndpoints(expectedMainEndpoint, []Endpoint{expectedAdditionalEndpoint1, expectedAdditionalEndpoint2}, false, true, time.Second)
+	expectedEndpoints.BatchMaxSize = 200
+	expectedEndpoints.BatchMaxContentSize = 1000000
+	expectedEndpoints.BatchMaxConcurrentSend = 1
+	expectedEndpoints.BatchMaxInflight = 2
 	endpoints, err := BuildHTTPEndpoints()
 
 	suite.Nil(err)
@@ -247,29 +280,63 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 6}
+		CompressionLevel: 6,
+		ContentEncoding:  "gzip"}
 	expectedAdditionalEndpoint1 := Endpoint{
 		APIKey:           "456",
 		Host:             "additional.endpoint.1",
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 2}
+		CompressionLevel: 2,
+		ContentEncoding:  "gzip"}
 	expectedAdditionalEndpoint2 := Endpoint{
 		APIKey:           "789",
 		Host:             "additional.endpoint.2",
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
-		CompressionLevel: 2}
+		CompressionLevel: 2,
+		ContentEncoding:  "gzip"}
 
 	expectedEndpoints := NewEndpoints(expectedMainEndpoint, []Endpoint{expectedAdditionalEndpoint1, expectedAdditionalEndpoint2}, false, true, time.Second)
+	expectedEndpoints.BatchMaxSize = 200
+	expectedEndpoints.BatchMaxContentSize = 1000000
+	expectedEndpoints.BatchMaxConcurrentSend = 1
+	expectedEndpoints.BatchMaxInflight = 2
 	endpoints, err := BuildHTTPEndpoints()
 
 	suite.Nil(err)
 	suite.Equal(expectedEndpoints, endpoints)
 }
 
+func (suite *ConfigTestSuite) TestHTTPEndpointsBatchingControls() {
+	suite.config.Set("api_key", "123")
+	suite.config.Set("logs_config.content_encoding", "zstd")
+	suite.config.Set("logs_config.batch_max_size", 500)
+	suite.config.Set("logs_config.batch_max_content_size", 0)
+	suite.config.Set("logs_config.batch_max_concurrent_send", 4)
+	suite.config.Set("logs_config.batch_max_inflight", 2)
+	suite.config.Set("logs_config.adaptive_batching", true)
+
+	endpoints, err := BuildHTTPEndpoints()
+
+	suite.Nil(err)
+	suite.Equal("zstd", endpoints.Main.ContentEncoding)
+	suite.Equal(500, endpoints.BatchMaxSize)
+	suite.Equal(1000000, endpoints.BatchMaxContentSize)
+	suite.Equal(4, endpoints.BatchMaxConcurrentSend)
+	// the inflight payloads can't be fewer than the concurrent ones
+	suite.Equal(4, endpoints.BatchMaxInflight)
+	suite.True(endpoints.AdaptiveBatching)
+
+	suite.config.Set("logs_config.content_encoding", "brotli")
+	endpoints, err = BuildHTTPEndpoints()
+
+	suite.Nil(err)
+	suite.Equal("gzip", endpoints.Main.ContentEncoding)
+}
+
 func (suite *ConfigTestSuite) TestMultipleTCPEndpointsInConf() {
 	suite.config.Set("api_key", "123")
 	suite.config.Set("logs_config.logs_dd_url", "agent-http-intake.logs.datadoghq.com:443")
@@ -9,14 +9,23 @@ import (
 	"time"
 )
 
+// Content encodings of the logs sent over HTTP.
+const (
+	GzipContentEncoding = "gzip"
+	ZstdContentEncoding = "zstd"
+	// AutoContentEncoding uses zstd when the intake supports it, gzip otherwise
+	AutoContentEncoding = "auto"
+)
+
 // Endpoint holds all the organization and network parameters to send logs to Datadog.
 type Endpoint struct {
 	APIKey           string `mapstructure:"api_key" json:"api_key"`
 	Host             string
 	Port             int
 	UseSSL           bool
-	UseCompression   bool `mapstructure:"use_compression" json:"use_compression"`
-	CompressionLevel int  `mapstructure:"compression_level" json:"compression_level"`
+	UseCompression   bool   `mapstructure:"use_compression" json:"use_compression"`
+	CompressionLevel int    `mapstructure:"compression_level" json:"compression_level"`
+	ContentEncoding  string `mapstructure:"content_encoding" json:"content_encoding"`
 	ProxyAddress     string
 }
 
@@ -27,6 +36,12 @@ type Endpoints struct {
 	UseProto    bool
 	UseHTTP     bool
 	BatchWait   time.Duration
+	// the batching controls are only used when sending logs over HTTP
+	BatchMaxSize           int
+	BatchMaxContentSize    int
+	BatchMaxConcurrentSend int
+	BatchMaxInflight       int
+	AdaptiveBatching       bool
 }
 
 // NewEndpoints returns a new endpoints composite.
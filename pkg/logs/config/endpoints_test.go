@@ -371,6 +371,33 @@ func (suite *EndpointsTestSuite) TestAdditionalEndpoints() {
 	suite.True(endpoint.UseSSL)
 }
 
+func (suite *EndpointsTestSuite) TestBuildEndpointsInFIPSProxyMode() {
+	suite.config.Set("fips.enabled", true)
+	suite.config.Set("fips.local_address", "localhost")
+	suite.config.Set("fips.port_range_start", 9803)
+	suite.config.Set("logs_config.additional_endpoints", []map[string]interface{}{
+		{
+			"host":    "foo",
+			"api_key": "1234",
+		},
+	})
+
+	endpoints, err := BuildEndpoints(HTTPConnectivityFailure)
+	suite.Nil(err)
+	suite.True(endpoints.UseHTTP)
+	suite.Equal("localhost", endpoints.Main.Host)
+	suite.Equal(9805, endpoints.Main.Port)
+	suite.False(endpoints.Main.UseSSL)
+	suite.Len(endpoints.Additionals, 0)
+}
+
+func (suite *EndpointsTestSuite) TestBuildEndpointsShouldFailInFIPSModeWithoutProviderNorProxy() {
+	suite.config.Set("fips.enabled", true)
+
+	_, err := BuildEndpoints(HTTPConnectivitySuccess)
+	suite.NotNil(err)
+}
+
 func TestEndpointsTestSuite(t *testing.T) {
 	suite.Run(t, new(EndpointsTestSuite))
 }
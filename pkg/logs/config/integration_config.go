@@ -8,6 +8,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 )
 
 // Logs source types
@@ -18,6 +20,11 @@ const (
 	DockerType       = "docker"
 	JournaldType     = "journald"
 	WindowsEventType = "windows_event"
+	KubeletType      = "kubelet"
+	KafkaType        = "kafka"
+	SyslogType       = "syslog"
+	KubeAuditType    = "kubernetes_audit"
+	CloudFoundryType = "cloud_foundry"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -25,29 +32,66 @@ const (
 type LogsConfig struct {
 	Type string
 
-	Port int    // Network
-	Path string // File, Journald
+	Port     int    // Network, Syslog, KubeAudit
+	Protocol string // Syslog
+	Path     string // File, Journald
 
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
-	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File, Kubelet, Windows Event
 
-	IncludeUnits  []string `mapstructure:"include_units" json:"include_units"`   // Journald
-	ExcludeUnits  []string `mapstructure:"exclude_units" json:"exclude_units"`   // Journald
-	ContainerMode bool     `mapstructure:"container_mode" json:"container_mode"` // Journald
+	IncludeUnits     []string `mapstructure:"include_units" json:"include_units"`   // Journald
+	ExcludeUnits     []string `mapstructure:"exclude_units" json:"exclude_units"`   // Journald
+	ContainerMode    bool     `mapstructure:"container_mode" json:"container_mode"` // Journald
+	JournalNamespace string   `mapstructure:"namespace" json:"namespace"`           // Journald
+	ConfigID         string   `mapstructure:"config_id" json:"config_id"`           // Journald
 
 	Image      string // Docker
 	Label      string // Docker
 	Name       string // Docker
-	Identifier string // Docker
+	Identifier string // Docker, Kubelet
+
+	PodNamespace  string // Kubelet
+	PodName       string // Kubelet
+	ContainerName string // Kubelet
+
+	Brokers       []string // Kafka
+	Topics        []string // Kafka
+	ConsumerGroup string   `mapstructure:"consumer_group" json:"consumer_group"`   // Kafka
+	KafkaVersion  string   `mapstructure:"kafka_version" json:"kafka_version"`     // Kafka
+	UseTLS        bool     `mapstructure:"use_tls" json:"use_tls"`                 // Kafka, Syslog
+	TLSCACert     string   `mapstructure:"tls_ca_cert" json:"tls_ca_cert"`         // Kafka, Syslog, KubeAudit
+	TLSCert       string   `mapstructure:"tls_cert" json:"tls_cert"`               // Kafka, Syslog, KubeAudit
+	TLSKey        string   `mapstructure:"tls_key" json:"tls_key"`                 // Kafka, Syslog, KubeAudit
+	TLSSkipVerify bool     `mapstructure:"tls_skip_verify" json:"tls_skip_verify"` // Kafka, Cloud Foundry
+	BearerToken   string   `mapstructure:"bearer_token" json:"bearer_token"`       // KubeAudit
+	SASLMechanism string   `mapstructure:"sasl_mechanism" json:"sasl_mechanism"`   // Kafka
+	SASLUsername  string   `mapstructure:"sasl_username" json:"sasl_username"`     // Kafka
+	SASLPassword  string   `mapstructure:"sasl_password" json:"sasl_password"`     // Kafka
+
+	URL            string // Cloud Foundry
+	UAAURL         string `mapstructure:"uaa_url" json:"uaa_url"`                 // Cloud Foundry
+	ClientID       string `mapstructure:"client_id" json:"client_id"`             // Cloud Foundry
+	ClientSecret   string `mapstructure:"client_secret" json:"client_secret"`     // Cloud Foundry
+	ShardID        string `mapstructure:"shard_id" json:"shard_id"`               // Cloud Foundry
+	IncludeMetrics bool   `mapstructure:"include_metrics" json:"include_metrics"` // Cloud Foundry
 
 	ChannelPath string `mapstructure:"channel_path" json:"channel_path"` // Windows Event
 	Query       string // Windows Event
+	Locale      string `mapstructure:"locale" json:"locale"`         // Windows Event
+	BatchSize   int    `mapstructure:"batch_size" json:"batch_size"` // Windows Event
 
 	Service         string
 	Source          string
 	SourceCategory  string
 	Tags            []string
 	ProcessingRules []*ProcessingRule `mapstructure:"log_processing_rules" json:"log_processing_rules"`
+	AutoMultiLine   *bool             `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
+	// ScrubbingRuleSets are the names of the sets of `logs_config.scrubbing_rules` applied to the source
+	ScrubbingRuleSets []string `mapstructure:"scrubbing_rule_sets" json:"scrubbing_rule_sets"`
+	// RateLimit caps the volume of logs of the source
+	RateLimit *RateLimit `mapstructure:"rate_limit" json:"rate_limit"`
+	// JSONRemapping promotes the attributes of the JSON logs of the source to the standard fields
+	JSONRemapping *JSONRemapping `mapstructure:"json_remapping" json:"json_remapping"`
 }
 
 // TailingMode type
@@ -107,11 +151,45 @@ func (c *LogsConfig) Validate() error {
 		if err != nil {
 			return err
 		}
+	case c.Type == JournaldType && c.Path != "" && c.JournalNamespace != "":
+		return fmt.Errorf("journald source can't have both a path and a namespace")
+	case c.Type == KubeletType && (c.PodNamespace == "" || c.PodName == "" || c.ContainerName == ""):
+		return fmt.Errorf("kubelet source must have a pod namespace, a pod name and a container name")
+	case c.Type == KafkaType && (len(c.Brokers) == 0 || len(c.Topics) == 0 || c.ConsumerGroup == ""):
+		return fmt.Errorf("kafka source must have brokers, topics and a consumer group")
+	case c.Type == KafkaType && c.SASLMechanism != "" && c.SASLMechanism != "PLAIN":
+		return fmt.Errorf("sasl mechanism %s is not supported for kafka source, only PLAIN is", c.SASLMechanism)
+	case c.Type == SyslogType && c.Port == 0:
+		return fmt.Errorf("syslog source must have a port")
+	case c.Type == SyslogType && c.Protocol != "" && c.Protocol != "tcp" && c.Protocol != "udp":
+		return fmt.Errorf("syslog source protocol must be tcp or udp")
+	case c.Type == SyslogType && c.UseTLS && (c.Protocol == "udp" || c.TLSCert == "" || c.TLSKey == ""):
+		return fmt.Errorf("syslog source must use tcp and have a certificate and a key to use tls")
+	case c.Type == KubeAuditType && (c.Port == 0 || c.TLSCert == "" || c.TLSKey == ""):
+		return fmt.Errorf("kubernetes_audit source must have a port, a certificate and a key")
+	case c.Type == KubeAuditType && c.TLSCACert == "" && c.BearerToken == "":
+		return fmt.Errorf("kubernetes_audit source must authenticate the API servers with a CA certificate or a bearer token")
+	case c.Type == CloudFoundryType && (c.URL == "" || c.UAAURL == "" || c.ClientID == "" || c.ClientSecret == ""):
+		return fmt.Errorf("cloud_foundry source must have a url, a uaa_url, a client_id and a client_secret")
+	case c.Type == WindowsEventType && c.ChannelPath == "" && !IsStructuredQuery(c.Query):
+		return fmt.Errorf("windows_event source must have a channel path or a structured query")
+	case c.Type == WindowsEventType && c.BatchSize < 0:
+		return fmt.Errorf("windows_event source batch size must be positive")
 	case c.Type == TCPType && c.Port == 0:
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
 	}
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("invalid rate_limit: %v", err)
+		}
+	}
+	if c.JSONRemapping != nil {
+		if err := c.JSONRemapping.Validate(); err != nil {
+			return fmt.Errorf("invalid json_remapping: %v", err)
+		}
+	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
@@ -119,6 +197,15 @@ func (c *LogsConfig) Validate() error {
 	return CompileProcessingRules(c.ProcessingRules)
 }
 
+// IsAutoMultiLineEnabled returns whether the multi-line pattern of the source
+// should be detected, the source setting overrides the global one
+func (c *LogsConfig) IsAutoMultiLineEnabled() bool {
+	if c.AutoMultiLine != nil {
+		return *c.AutoMultiLine
+	}
+	return coreConfig.Datadog.GetBool("logs_config.auto_multi_line_detection")
+}
+
 func (c *LogsConfig) validateTailingMode() error {
 	mode, found := TailingModeFromString(c.TailingMode)
 	if !found && c.TailingMode != "" {
@@ -134,3 +221,10 @@ func (c *LogsConfig) validateTailingMode() error {
 func ContainsWildcard(path string) bool {
 	return strings.ContainsAny(path, "*?[")
 }
+
+// IsStructuredQuery returns true if the windows event log query is a structured XML query,
+// which selects the events of several channels with a XPath filter per channel, ex:
+//  <QueryList><Query><Select Path="System">*[System[Level&lt;=3]]</Select></Query></QueryList>
+func IsStructuredQuery(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "<QueryList>")
+}
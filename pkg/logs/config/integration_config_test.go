@@ -18,6 +18,11 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: FileType, Path: "/var/log/foo.log"},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: KubeAuditType, Port: 8443, TLSCert: "/etc/ssl/cert.pem", TLSKey: "/etc/ssl/key.pem", TLSCACert: "/etc/ssl/ca.pem"},
+		{Type: KubeAuditType, Port: 8443, TLSCert: "/etc/ssl/cert.pem", TLSKey: "/etc/ssl/key.pem", BearerToken: "token"},
+		{Type: CloudFoundryType, URL: "https://log-stream.sys.example.com", UAAURL: "https://uaa.sys.example.com", ClientID: "datadog", ClientSecret: "secret"},
+		{Type: WindowsEventType, ChannelPath: "System", Query: "*[System[Level<=3]]", BatchSize: 50},
+		{Type: WindowsEventType, Query: `<QueryList><Query><Select Path="System">*</Select></Query></QueryList>`},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 	}
@@ -34,6 +39,11 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: FileType},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: KubeAuditType, Port: 8443},
+		{Type: KubeAuditType, Port: 8443, TLSCert: "/etc/ssl/cert.pem", TLSKey: "/etc/ssl/key.pem"},
+		{Type: CloudFoundryType, URL: "https://log-stream.sys.example.com", ClientID: "datadog", ClientSecret: "secret"},
+		{Type: WindowsEventType, Query: "*"},
+		{Type: WindowsEventType, ChannelPath: "System", BatchSize: -1},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -41,6 +51,9 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: DockerType, RateLimit: &RateLimit{}},
+		{Type: DockerType, RateLimit: &RateLimit{EventsPerSecond: 10, SampleRate: 2}},
+		{Type: DockerType, JSONRemapping: &JSONRemapping{Status: []string{"level", ""}}},
 	}
 
 	for _, config := range invalidConfigs {
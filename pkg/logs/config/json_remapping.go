@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// JSONRemapping defines the attributes of the JSON logs promoted to the standard fields,
+// each field takes the value of the first attribute found in the log.
+// The nested attributes are referenced with their path, e.g. `log.level`.
+type JSONRemapping struct {
+	Service   []string `mapstructure:"service" json:"service"`
+	Status    []string `mapstructure:"status" json:"status"`
+	Timestamp []string `mapstructure:"timestamp" json:"timestamp"`
+	TraceID   []string `mapstructure:"trace_id" json:"trace_id"`
+	// Flatten replaces the nested attributes with top-level attributes named after their path
+	Flatten bool `mapstructure:"flatten" json:"flatten"`
+}
+
+// Validate returns an error if the remapping is misconfigured.
+func (r *JSONRemapping) Validate() error {
+	for field, attributes := range map[string][]string{
+		"service":   r.Service,
+		"status":    r.Status,
+		"timestamp": r.Timestamp,
+		"trace_id":  r.TraceID,
+	} {
+		for _, attribute := range attributes {
+			if attribute == "" {
+				return fmt.Errorf("the attributes remapped to %s can't be empty", field)
+			}
+		}
+	}
+	return nil
+}
+
+// GlobalJSONRemapping returns the remapping applied to the JSON logs of all the sources,
+// defined in `logs_config.json_remapping`, or nil when it is not set.
+func GlobalJSONRemapping() (*JSONRemapping, error) {
+	var remapping *JSONRemapping
+	var err error
+	raw := coreConfig.Datadog.Get("logs_config.json_remapping")
+	if raw == nil {
+		return nil, nil
+	}
+	if s, ok := raw.(string); ok {
+		if s == "" {
+			return nil, nil
+		}
+		err = json.Unmarshal([]byte(s), &remapping)
+	} else {
+		err = coreConfig.Datadog.UnmarshalKey("logs_config.json_remapping", &remapping)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if remapping == nil {
+		return nil, nil
+	}
+	if err = remapping.Validate(); err != nil {
+		return nil, err
+	}
+	return remapping, nil
+}
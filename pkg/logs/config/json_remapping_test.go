@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestValidateJSONRemapping(t *testing.T) {
+	assert.NoError(t, (&JSONRemapping{}).Validate())
+	assert.NoError(t, (&JSONRemapping{Status: []string{"level", "log.level"}, Flatten: true}).Validate())
+
+	assert.Error(t, (&JSONRemapping{Service: []string{""}}).Validate())
+}
+
+func TestGlobalJSONRemapping(t *testing.T) {
+	mockConfig := coreConfig.Mock()
+
+	remapping, err := GlobalJSONRemapping()
+	require.NoError(t, err)
+	assert.Nil(t, remapping)
+
+	mockConfig.Set("logs_config.json_remapping", `{"status": ["level"], "flatten": true}`)
+	remapping, err = GlobalJSONRemapping()
+	require.NoError(t, err)
+	assert.Equal(t, &JSONRemapping{Status: []string{"level"}, Flatten: true}, remapping)
+
+	mockConfig.Set("logs_config.json_remapping", map[string]interface{}{"trace_id": []string{"dd.trace_id", "traceId"}})
+	remapping, err = GlobalJSONRemapping()
+	require.NoError(t, err)
+	assert.Equal(t, &JSONRemapping{TraceID: []string{"dd.trace_id", "traceId"}}, remapping)
+
+	mockConfig.Set("logs_config.json_remapping", `{"service": [""]}`)
+	_, err = GlobalJSONRemapping()
+	assert.Error(t, err)
+
+	mockConfig.Set("logs_config.json_remapping", nil)
+}
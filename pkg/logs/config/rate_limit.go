@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// DefaultRateLimitSampleRate is the ratio of the logs over the rate limit that are still sent
+// when the sample rate of the limit is not set
+const DefaultRateLimitSampleRate = 0.1
+
+// RateLimit caps the volume of logs in events or bytes per second,
+// the logs over the limit are sampled instead of being dropped.
+type RateLimit struct {
+	EventsPerSecond float64 `mapstructure:"events_per_second" json:"events_per_second"`
+	BytesPerSecond  float64 `mapstructure:"bytes_per_second" json:"bytes_per_second"`
+	// SampleRate is the ratio of the logs over the limit that are still sent
+	SampleRate float64 `mapstructure:"sample_rate" json:"sample_rate"`
+}
+
+// Validate returns an error if the rate limit is misconfigured.
+func (r *RateLimit) Validate() error {
+	switch {
+	case r.EventsPerSecond < 0 || r.BytesPerSecond < 0:
+		return fmt.Errorf("events_per_second and bytes_per_second can't be negative")
+	case r.EventsPerSecond == 0 && r.BytesPerSecond == 0:
+		return fmt.Errorf("events_per_second or bytes_per_second must be set")
+	case r.SampleRate < 0 || r.SampleRate > 1:
+		return fmt.Errorf("sample_rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// GetSampleRate returns the ratio of the logs over the limit that are still sent.
+func (r *RateLimit) GetSampleRate() float64 {
+	if r.SampleRate == 0 {
+		return DefaultRateLimitSampleRate
+	}
+	return r.SampleRate
+}
+
+// GlobalServiceRateLimit returns the rate limit applied to the logs of each service,
+// defined in `logs_config.service_rate_limit`, or nil when it is not set.
+func GlobalServiceRateLimit() (*RateLimit, error) {
+	var rateLimit *RateLimit
+	var err error
+	raw := coreConfig.Datadog.Get("logs_config.service_rate_limit")
+	if raw == nil {
+		return nil, nil
+	}
+	if s, ok := raw.(string); ok {
+		if s == "" {
+			return nil, nil
+		}
+		err = json.Unmarshal([]byte(s), &rateLimit)
+	} else {
+		err = coreConfig.Datadog.UnmarshalKey("logs_config.service_rate_limit", &rateLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rateLimit == nil {
+		return nil, nil
+	}
+	if err = rateLimit.Validate(); err != nil {
+		return nil, err
+	}
+	return rateLimit, nil
+}
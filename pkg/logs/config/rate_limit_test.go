@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestValidateRateLimit(t *testing.T) {
+	assert.NoError(t, (&RateLimit{EventsPerSecond: 100}).Validate())
+	assert.NoError(t, (&RateLimit{BytesPerSecond: 1000, SampleRate: 1}).Validate())
+
+	assert.Error(t, (&RateLimit{}).Validate())
+	assert.Error(t, (&RateLimit{EventsPerSecond: -1}).Validate())
+	assert.Error(t, (&RateLimit{EventsPerSecond: 100, SampleRate: 2}).Validate())
+
+	assert.Equal(t, DefaultRateLimitSampleRate, (&RateLimit{EventsPerSecond: 100}).GetSampleRate())
+	assert.Equal(t, 0.5, (&RateLimit{EventsPerSecond: 100, SampleRate: 0.5}).GetSampleRate())
+}
+
+func TestGlobalServiceRateLimit(t *testing.T) {
+	mockConfig := coreConfig.Mock()
+
+	rateLimit, err := GlobalServiceRateLimit()
+	require.NoError(t, err)
+	assert.Nil(t, rateLimit)
+
+	mockConfig.Set("logs_config.service_rate_limit", `{"events_per_second": 100, "sample_rate": 0.2}`)
+	rateLimit, err = GlobalServiceRateLimit()
+	require.NoError(t, err)
+	assert.Equal(t, &RateLimit{EventsPerSecond: 100, SampleRate: 0.2}, rateLimit)
+
+	mockConfig.Set("logs_config.service_rate_limit", map[string]interface{}{"bytes_per_second": 1000})
+	rateLimit, err = GlobalServiceRateLimit()
+	require.NoError(t, err)
+	assert.Equal(t, &RateLimit{BytesPerSecond: 1000}, rateLimit)
+
+	mockConfig.Set("logs_config.service_rate_limit", `{"sample_rate": 0.2}`)
+	_, err = GlobalServiceRateLimit()
+	assert.Error(t, err)
+
+	mockConfig.Set("logs_config.service_rate_limit", nil)
+}
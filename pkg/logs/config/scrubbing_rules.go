@@ -0,0 +1,245 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unicode/utf8"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Scrubbing rule types
+const (
+	ReplaceSequences     = "replace"
+	HashSequences        = "hash"
+	PartialMaskSequences = "partial_mask"
+)
+
+// defaultMaskCharacter replaces the masked characters of the partial_mask rules
+const defaultMaskCharacter = "*"
+
+// hashLength is the number of hexadecimal characters of the HMAC-SHA256 digest
+// replacing the sequences of the hash rules
+const hashLength = 16
+
+// hashKeyLength is the number of random bytes of the key of the hash rules
+const hashKeyLength = 32
+
+// ScrubbingRule defines how the sequences matching a pattern are scrubbed
+// from the logs before they leave the host
+type ScrubbingRule struct {
+	Type               string
+	Name               string
+	Pattern            string
+	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
+	KeepFirst          int    `mapstructure:"keep_first" json:"keep_first"`
+	KeepLast           int    `mapstructure:"keep_last" json:"keep_last"`
+	MaskCharacter      string `mapstructure:"mask_character" json:"mask_character"`
+	// TODO: should be moved out
+	Regex *regexp.Regexp
+
+	// hashKey keys the digests of the hash rules, so that the low-entropy
+	// sequences can't be recovered by hashing all their possible values
+	hashKey []byte
+}
+
+// ScrubbingRuleSets holds the scrubbing rules grouped by name
+type ScrubbingRuleSets map[string][]*ScrubbingRule
+
+// ValidateScrubbingRuleSets validates the rule sets and raises an error if one is misconfigured.
+// Each scrubbing rule must have:
+// - a valid name
+// - a valid type
+// - a valid pattern that compiles
+// - a single mask character for the partial_mask rules
+func ValidateScrubbingRuleSets(sets ScrubbingRuleSets) error {
+	for set, rules := range sets {
+		for _, rule := range rules {
+			if rule.Name == "" {
+				return fmt.Errorf("all scrubbing rules of the set `%s` must have a name", set)
+			}
+
+			switch rule.Type {
+			case ReplaceSequences, HashSequences:
+				break
+			case PartialMaskSequences:
+				if rule.KeepFirst < 0 || rule.KeepLast < 0 {
+					return fmt.Errorf("keep_first and keep_last can't be negative for scrubbing rule `%s` of the set `%s`", rule.Name, set)
+				}
+				if rule.MaskCharacter != "" && utf8.RuneCountInString(rule.MaskCharacter) != 1 {
+					return fmt.Errorf("mask_character must be a single character for scrubbing rule `%s` of the set `%s`", rule.Name, set)
+				}
+			case "":
+				return fmt.Errorf("type must be set for scrubbing rule `%s` of the set `%s`", rule.Name, set)
+			default:
+				return fmt.Errorf("type %s is not supported for scrubbing rule `%s` of the set `%s`", rule.Type, rule.Name, set)
+			}
+
+			if rule.Pattern == "" {
+				return fmt.Errorf("no pattern provided for scrubbing rule `%s` of the set `%s`", rule.Name, set)
+			}
+			_, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for scrubbing rule `%s` of the set `%s`", rule.Pattern, rule.Name, set)
+			}
+		}
+	}
+	return nil
+}
+
+// CompileScrubbingRuleSets compiles all scrubbing rule regular expressions.
+func CompileScrubbingRuleSets(sets ScrubbingRuleSets) error {
+	for _, rules := range sets {
+		for _, rule := range rules {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return err
+			}
+			rule.Regex = re
+			if rule.Type == PartialMaskSequences && rule.MaskCharacter == "" {
+				rule.MaskCharacter = defaultMaskCharacter
+			}
+		}
+	}
+	return nil
+}
+
+// GlobalScrubbingRuleSets returns the scrubbing rule sets defined in `logs_config.scrubbing_rules`,
+// they are applied to all logs when listed in `logs_config.scrubbing_rule_sets`,
+// and to the logs of a source when listed in its `scrubbing_rule_sets`.
+func GlobalScrubbingRuleSets() (ScrubbingRuleSets, error) {
+	sets := make(ScrubbingRuleSets)
+	var err error
+	raw := coreConfig.Datadog.Get("logs_config.scrubbing_rules")
+	if raw == nil {
+		return sets, nil
+	}
+	if s, ok := raw.(string); ok && s != "" {
+		err = json.Unmarshal([]byte(s), &sets)
+	} else {
+		err = coreConfig.Datadog.UnmarshalKey("logs_config.scrubbing_rules", &sets)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateScrubbingRuleSets(sets)
+	if err != nil {
+		return nil, err
+	}
+	err = CompileScrubbingRuleSets(sets)
+	if err != nil {
+		return nil, err
+	}
+	if sets.hasHashRules() {
+		key, err := createOrFetchScrubbingHashKey(ScrubbingHashKeyPath())
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the key of the hash scrubbing rules: %v", err)
+		}
+		sets.setHashKey(key)
+	}
+	return sets, nil
+}
+
+func (sets ScrubbingRuleSets) hasHashRules() bool {
+	for _, rules := range sets {
+		for _, rule := range rules {
+			if rule.Type == HashSequences {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (sets ScrubbingRuleSets) setHashKey(key []byte) {
+	for _, rules := range sets {
+		for _, rule := range rules {
+			rule.hashKey = key
+		}
+	}
+}
+
+// createOrFetchScrubbingHashKey returns the key of the hash rules stored at path,
+// a random key is generated and stored the first time so that the same sequences
+// keep the same digests across restarts of the agent.
+func createOrFetchScrubbingHashKey(path string) ([]byte, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(string(bytes.TrimSpace(raw)))
+		if err != nil || len(key) < hashKeyLength {
+			return nil, fmt.Errorf("invalid key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, hashKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Scrub scrubs the sequences matching the rule and returns the new content
+// with the number of scrubbed sequences.
+func (r *ScrubbingRule) Scrub(content []byte) ([]byte, int) {
+	count := 0
+	switch r.Type {
+	case ReplaceSequences:
+		count = len(r.Regex.FindAllIndex(content, -1))
+		if count > 0 {
+			// the placeholder can reference the groups of the pattern, e.g. ${1}
+			content = r.Regex.ReplaceAll(content, []byte(r.ReplacePlaceholder))
+		}
+	case HashSequences:
+		content = r.Regex.ReplaceAllFunc(content, func(sequence []byte) []byte {
+			count++
+			mac := hmac.New(sha256.New, r.hashKey)
+			mac.Write(sequence)
+			return []byte(hex.EncodeToString(mac.Sum(nil))[:hashLength])
+		})
+	case PartialMaskSequences:
+		content = r.Regex.ReplaceAllFunc(content, func(sequence []byte) []byte {
+			count++
+			return r.partialMask(sequence)
+		})
+	}
+	return content, count
+}
+
+// partialMask masks the characters of a sequence but the first and the last ones,
+// sequences too short to keep anything are masked entirely
+func (r *ScrubbingRule) partialMask(sequence []byte) []byte {
+	runes := bytes.Runes(sequence)
+	keepFirst, keepLast := r.KeepFirst, r.KeepLast
+	if keepFirst+keepLast >= len(runes) {
+		keepFirst, keepLast = 0, 0
+	}
+	mask := []rune(r.MaskCharacter)[0]
+	for i := keepFirst; i < len(runes)-keepLast; i++ {
+		runes[i] = mask
+	}
+	return []byte(string(runes))
+}
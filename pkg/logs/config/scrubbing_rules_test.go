@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScrubbingRuleSets(t *testing.T) {
+	valid := ScrubbingRuleSets{"pii": {
+		{Type: ReplaceSequences, Name: "emails", Pattern: `\w+@\w+`},
+		{Type: PartialMaskSequences, Name: "cards", Pattern: `\d{16}`, KeepLast: 4},
+	}}
+	assert.NoError(t, ValidateScrubbingRuleSets(valid))
+
+	for _, rule := range []*ScrubbingRule{
+		{Type: ReplaceSequences, Pattern: `\w+`},
+		{Name: "no_type", Pattern: `\w+`},
+		{Type: "mask", Name: "unknown_type", Pattern: `\w+`},
+		{Type: HashSequences, Name: "no_pattern"},
+		{Type: HashSequences, Name: "invalid_pattern", Pattern: `(\w+`},
+		{Type: PartialMaskSequences, Name: "negative", Pattern: `\w+`, KeepFirst: -1},
+		{Type: PartialMaskSequences, Name: "long_mask", Pattern: `\w+`, MaskCharacter: "xx"},
+	} {
+		assert.Error(t, ValidateScrubbingRuleSets(ScrubbingRuleSets{"invalid": {rule}}), rule.Name)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	sets := ScrubbingRuleSets{"pii": {
+		{Type: ReplaceSequences, Name: "emails", Pattern: `(\w+)@\w+\.com`, ReplacePlaceholder: "${1}@[domain]"},
+		{Type: HashSequences, Name: "users", Pattern: `user=\w+`},
+		{Type: PartialMaskSequences, Name: "cards", Pattern: `\d{16}`, KeepFirst: 2, KeepLast: 4},
+		{Type: PartialMaskSequences, Name: "pins", Pattern: `pin=\d+`, KeepFirst: 4, KeepLast: 4, MaskCharacter: "#"},
+	}}
+	require.NoError(t, CompileScrubbingRuleSets(sets))
+	sets.setHashKey([]byte("key"))
+	rules := sets["pii"]
+
+	content, count := rules[0].Scrub([]byte("from john@example.com to jane@example.com"))
+	assert.Equal(t, "from john@[domain] to jane@[domain]", string(content))
+	assert.Equal(t, 2, count)
+
+	content, count = rules[1].Scrub([]byte("login user=john"))
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("user=john"))
+	assert.Equal(t, "login "+hex.EncodeToString(mac.Sum(nil))[:16], string(content))
+	assert.Equal(t, 1, count)
+
+	content, count = rules[2].Scrub([]byte("card 4111111111111111 paid"))
+	assert.Equal(t, "card 41**********1111 paid", string(content))
+	assert.Equal(t, 1, count)
+
+	// the sequences too short to keep anything are masked entirely
+	content, count = rules[3].Scrub([]byte("pin=1234"))
+	assert.Equal(t, "########", string(content))
+	assert.Equal(t, 1, count)
+
+	content, count = rules[2].Scrub([]byte("nothing to scrub"))
+	assert.Equal(t, "nothing to scrub", string(content))
+	assert.Equal(t, 0, count)
+}
+
+func TestCreateOrFetchScrubbingHashKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scrubbing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run", "scrubbing_hash_key")
+
+	// a random key is created the first time, then reused
+	key, err := createOrFetchScrubbingHashKey(path)
+	require.NoError(t, err)
+	assert.Len(t, key, hashKeyLength)
+	fetched, err := createOrFetchScrubbingHashKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key, fetched)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("invalid"), 0600))
+	_, err = createOrFetchScrubbingHashKey(path)
+	assert.Error(t, err)
+}
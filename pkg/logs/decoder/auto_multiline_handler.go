@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package decoder
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/parser"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// autoMultiLineMessageKey is the key of the status message of the source
+// showing the result of the detection
+const autoMultiLineMessageKey = "auto_multi_line"
+
+// timestampPatterns are the formats of the timestamps starting the first line
+// of a multi-line message, the first one matching most of the sampled lines wins.
+var timestampPatterns = []string{
+	// 2006-01-02T15:04:05, 2006-01-02 15:04:05.000
+	`\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`,
+	// 2006/01/02 15:04:05
+	`\[?\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`,
+	// Mon Jan _2 15:04:05 2006
+	`\[?[A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`,
+	// Mon, 02 Jan 2006 15:04:05
+	`\[?[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2}`,
+	// Jan _2 15:04:05
+	`\[?[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`,
+	// 02/Jan/2006:15:04:05
+	`\[?\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2}`,
+	// 02-Jan-2006 15:04:05
+	`\[?\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}:\d{2}`,
+}
+
+var timestampRegexps = compileTimestampPatterns(timestampPatterns)
+
+func compileTimestampPatterns(patterns []string) []*regexp.Regexp {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		regexps = append(regexps, regexp.MustCompile("^"+pattern))
+	}
+	return regexps
+}
+
+// AutoMultiLineHandler samples the first lines of a source to detect whether
+// its messages span multiple lines. Lines are sent one by one while sampling,
+// then they are aggregated by a MultiLineHandler using the timestamp pattern
+// matching most of the sampled lines, if any.
+type AutoMultiLineHandler struct {
+	lineChan          chan []byte
+	outputChan        chan *Output
+	parser            parser.Parser
+	source            *config.LogSource
+	lineLimit         int
+	flushTimeout      time.Duration
+	sampleSize        int
+	matchThreshold    float64
+	sampled           int
+	matches           []int
+	singleLineHandler *SingleLineHandler
+	multiLineHandler  *MultiLineHandler
+}
+
+// NewAutoMultiLineHandler returns a new AutoMultiLineHandler.
+func NewAutoMultiLineHandler(outputChan chan *Output, source *config.LogSource, sampleSize int, matchThreshold float64, flushTimeout time.Duration, parser parser.Parser, lineLimit int) *AutoMultiLineHandler {
+	return &AutoMultiLineHandler{
+		lineChan:          make(chan []byte),
+		outputChan:        outputChan,
+		parser:            parser,
+		source:            source,
+		lineLimit:         lineLimit,
+		flushTimeout:      flushTimeout,
+		sampleSize:        sampleSize,
+		matchThreshold:    matchThreshold,
+		matches:           make([]int, len(timestampRegexps)),
+		singleLineHandler: NewSingleLineHandler(outputChan, parser, lineLimit),
+	}
+}
+
+// Handle puts all new lines into a channel for later processing.
+func (h *AutoMultiLineHandler) Handle(content []byte) {
+	h.lineChan <- content
+}
+
+// Stop stops the handler.
+func (h *AutoMultiLineHandler) Stop() {
+	close(h.lineChan)
+}
+
+// Start starts the handler.
+func (h *AutoMultiLineHandler) Start() {
+	h.source.Messages.AddMessage(autoMultiLineMessageKey, fmt.Sprintf("Auto multi-line detection: sampling the first %d lines", h.sampleSize))
+	go h.run()
+}
+
+// run samples the lines until the detection is done, then forwards them
+// to the multi-line handler if a pattern was detected.
+func (h *AutoMultiLineHandler) run() {
+	for line := range h.lineChan {
+		if h.multiLineHandler != nil {
+			h.multiLineHandler.Handle(line)
+			continue
+		}
+		if h.sampled < h.sampleSize {
+			h.sample(line)
+		}
+		if h.sampled == h.sampleSize {
+			h.detect()
+			// make sure the detection only runs once
+			h.sampled++
+		}
+		// the last sampled line is sent as is, the following ones are
+		// aggregated if a pattern was detected
+		h.singleLineHandler.process(line)
+	}
+	if h.multiLineHandler != nil {
+		// the multi-line handler flushes its buffer and closes the output channel
+		h.multiLineHandler.Stop()
+		return
+	}
+	close(h.outputChan)
+}
+
+// sample counts the lines starting with each timestamp pattern
+func (h *AutoMultiLineHandler) sample(line []byte) {
+	// the multi-line handler matches the parsed content, the parsing errors
+	// are reported when the line is processed
+	content, _, _, _ := h.parser.Parse(line)
+	for i, re := range timestampRegexps {
+		if re.Match(content) {
+			h.matches[i]++
+			break
+		}
+	}
+	h.sampled++
+}
+
+// detect switches to the multi-line aggregation when enough of the sampled
+// lines start with the same timestamp pattern
+func (h *AutoMultiLineHandler) detect() {
+	if h.sampled == 0 {
+		return
+	}
+	best := -1
+	for i, count := range h.matches {
+		if best == -1 || count > h.matches[best] {
+			best = i
+		}
+	}
+	ratio := float64(h.matches[best]) / float64(h.sampled)
+	if ratio < h.matchThreshold {
+		log.Debugf("No multi-line pattern detected for %s, %.2f of the lines start with a timestamp", h.source.Name, ratio)
+		h.source.Messages.AddMessage(autoMultiLineMessageKey, "Auto multi-line detection: no pattern detected, lines are sent one by one")
+		return
+	}
+
+	pattern := timestampPatterns[best]
+	log.Infof("Detected the multi-line pattern %s for %s, %.2f of the lines match", pattern, h.source.Name, ratio)
+	h.source.Messages.AddMessage(autoMultiLineMessageKey, fmt.Sprintf("Auto multi-line detection: detected the pattern `%s`, add a `multi_line` processing rule with this pattern to pin it", pattern))
+	h.multiLineHandler = NewMultiLineHandler(h.outputChan, timestampRegexps[best], h.flushTimeout, h.parser, h.lineLimit)
+	h.multiLineHandler.Start()
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package decoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/parser"
+)
+
+func TestAutoMultiLineHandlerDetectsPattern(t *testing.T) {
+	outputChan := make(chan *Output, 10)
+	source := config.NewLogSource("test", &config.LogsConfig{})
+	h := NewAutoMultiLineHandler(outputChan, source, 3, 0.5, 10*time.Millisecond, parser.NoopParser, 100)
+	h.Start()
+
+	// the sampled lines are sent one by one
+	h.Handle([]byte("2020-05-04 10:00:00 panic: boom"))
+	h.Handle([]byte("  at main.go:12"))
+	h.Handle([]byte("2020-05-04 10:00:01 started"))
+	for _, expected := range []string{"2020-05-04 10:00:00 panic: boom", "at main.go:12", "2020-05-04 10:00:01 started"} {
+		assert.Equal(t, expected, string((<-outputChan).Content))
+	}
+	assert.Equal(t, []string{"Auto multi-line detection: detected the pattern `\\[?\\d{4}-\\d{2}-\\d{2}[T ]\\d{2}:\\d{2}:\\d{2}`, add a `multi_line` processing rule with this pattern to pin it"}, source.Messages.GetMessages())
+
+	// the following lines are aggregated
+	h.Handle([]byte("[2020-05-04 10:00:02] panic: boom"))
+	h.Handle([]byte("  at main.go:12"))
+	h.Handle([]byte("2020-05-04 10:00:03 started"))
+	assert.Equal(t, `[2020-05-04 10:00:02] panic: boom\n  at main.go:12`, string((<-outputChan).Content))
+
+	h.Stop()
+	assert.Equal(t, "2020-05-04 10:00:03 started", string((<-outputChan).Content))
+	_, isOpen := <-outputChan
+	assert.False(t, isOpen)
+}
+
+func TestAutoMultiLineHandlerNoPattern(t *testing.T) {
+	outputChan := make(chan *Output, 10)
+	source := config.NewLogSource("test", &config.LogsConfig{})
+	h := NewAutoMultiLineHandler(outputChan, source, 2, 0.5, 10*time.Millisecond, parser.NoopParser, 100)
+	h.Start()
+
+	h.Handle([]byte("GET /"))
+	h.Handle([]byte("GET /index.html"))
+	h.Handle([]byte("2020-05-04 10:00:00 started"))
+	h.Handle([]byte("GET /favicon.ico"))
+	for _, expected := range []string{"GET /", "GET /index.html", "2020-05-04 10:00:00 started", "GET /favicon.ico"} {
+		assert.Equal(t, expected, string((<-outputChan).Content))
+	}
+	assert.Equal(t, []string{"Auto multi-line detection: no pattern detected, lines are sent one by one"}, source.Messages.GetMessages())
+
+	h.Stop()
+	_, isOpen := <-outputChan
+	assert.False(t, isOpen)
+}
+
+func TestIsAutoMultiLineEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	assert.True(t, (&config.LogsConfig{AutoMultiLine: &enabled}).IsAutoMultiLineEnabled())
+	assert.False(t, (&config.LogsConfig{AutoMultiLine: &disabled}).IsAutoMultiLineEnabled())
+	assert.False(t, (&config.LogsConfig{}).IsAutoMultiLineEnabled())
+}
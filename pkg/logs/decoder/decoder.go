@@ -8,6 +8,7 @@ package decoder
 import (
 	"bytes"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/parser"
 )
@@ -72,6 +73,11 @@ func NewDecoderWithEndLineMatcher(source *config.LogSource, parser parser.Parser
 			lineHandler = NewMultiLineHandler(outputChan, rule.Regex, defaultFlushTimeout, parser, lineLimit)
 		}
 	}
+	if lineHandler == nil && source.Config.IsAutoMultiLineEnabled() {
+		sampleSize := coreConfig.Datadog.GetInt("logs_config.auto_multi_line_sample_size")
+		matchThreshold := coreConfig.Datadog.GetFloat64("logs_config.auto_multi_line_match_threshold")
+		lineHandler = NewAutoMultiLineHandler(outputChan, source, sampleSize, matchThreshold, defaultFlushTimeout, parser, lineLimit)
+	}
 	if lineHandler == nil {
 		lineHandler = NewSingleLineHandler(outputChan, parser, lineLimit)
 	}
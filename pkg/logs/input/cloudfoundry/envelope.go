@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The default source of the envelopes,
+// it is still overridden by the integration config when defined
+const defaultSource = "cloudfoundry"
+
+// batch is a batch of envelopes sent by the RLP gateway,
+// see https://github.com/cloudfoundry/loggregator-api#v2-envelope
+type batch struct {
+	Batch []json.RawMessage `json:"batch"`
+}
+
+// envelope holds the attributes of a loggregator v2 envelope used to build a message,
+// the 64 bits integers are encoded as strings by the gateway.
+type envelope struct {
+	Timestamp  int64             `json:"timestamp,string"`
+	SourceID   string            `json:"source_id"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Log        *struct {
+		Payload []byte `json:"payload"`
+		Type    string `json:"type"`
+	} `json:"log"`
+	Counter *struct {
+		Name  string `json:"name"`
+		Delta uint64 `json:"delta,string"`
+		Total uint64 `json:"total,string"`
+	} `json:"counter"`
+	Gauge *struct {
+		Metrics map[string]struct {
+			Unit  string  `json:"unit"`
+			Value float64 `json:"value"`
+		} `json:"metrics"`
+	} `json:"gauge"`
+	Timer *struct {
+		Name  string `json:"name"`
+		Start int64  `json:"start,string"`
+		Stop  int64  `json:"stop,string"`
+	} `json:"timer"`
+	Event *struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	} `json:"event"`
+}
+
+// toMessages transforms a batch of envelopes into messages, the envelopes
+// which are neither logs nor included metrics are dropped, and so are the
+// malformed ones without dropping the rest of the batch.
+func toMessages(data []byte, source *config.LogSource, tagger appTagger) ([]*message.Message, error) {
+	var b batch
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	messages := make([]*message.Message, 0, len(b.Batch))
+	for _, item := range b.Batch {
+		msg, err := toMessage(item, source, tagger)
+		if err != nil {
+			log.Warnf("Could not decode an envelope from %s, dropping it: %v", source.Config.URL, err)
+			continue
+		}
+		if msg != nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// toMessage transforms an envelope into a message: the payload of a log is
+// sent as is, the attributes of a metric are kept along with a summary in a
+// "message" attribute, ex:
+// * envelope:
+//  {"source_id":"<app_guid>","gauge":{"metrics":{"cpu":{"unit":"percentage","value":1.5}}},...}
+// * message-content:
+//  {
+//    "message": "gauge cpu=1.5percentage",
+//    "source_id": "<app_guid>",
+//    "gauge": {"metrics": {"cpu": {"unit": "percentage", "value": 1.5}}},
+//    ...
+//  }
+func toMessage(item json.RawMessage, source *config.LogSource, tagger appTagger) (*message.Message, error) {
+	var e envelope
+	if err := json.Unmarshal(item, &e); err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	status := message.StatusInfo
+	switch {
+	case e.Log != nil:
+		if len(e.Log.Payload) == 0 {
+			return nil, nil
+		}
+		content = e.Log.Payload
+		if e.Log.Type == "ERR" {
+			status = message.StatusError
+		}
+	case source.Config.IncludeMetrics && e.summary() != "":
+		var attributes map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.UseNumber()
+		if err := decoder.Decode(&attributes); err != nil {
+			return nil, err
+		}
+		attributes["message"] = e.summary()
+		var err error
+		if content, err = json.Marshal(attributes); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	origin := message.NewOrigin(source)
+	origin.SetSource(defaultSource)
+	origin.SetService(e.service())
+	origin.SetTags(e.tags(tagger))
+	msg := message.NewMessage(content, origin, status)
+	if e.Timestamp > 0 {
+		msg.SetTimestamp(time.Unix(0, e.Timestamp))
+	}
+	return msg, nil
+}
+
+// summary returns a one-line description of a metric envelope, ex:
+//  counter requests delta=1 total=42
+func (e *envelope) summary() string {
+	switch {
+	case e.Counter != nil:
+		return fmt.Sprintf("counter %s delta=%d total=%d", e.Counter.Name, e.Counter.Delta, e.Counter.Total)
+	case e.Gauge != nil:
+		names := make([]string, 0, len(e.Gauge.Metrics))
+		for name := range e.Gauge.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, 0, len(names))
+		for _, name := range names {
+			values = append(values, fmt.Sprintf("%s=%g%s", name, e.Gauge.Metrics[name].Value, e.Gauge.Metrics[name].Unit))
+		}
+		return "gauge " + strings.Join(values, " ")
+	case e.Timer != nil:
+		return fmt.Sprintf("timer %s %s", e.Timer.Name, time.Duration(e.Timer.Stop-e.Timer.Start))
+	case e.Event != nil:
+		return fmt.Sprintf("event %s: %s", e.Event.Title, e.Event.Body)
+	}
+	return ""
+}
+
+// service returns the name of the application of the envelope if any, its source ID otherwise
+func (e *envelope) service() string {
+	if name := e.Tags["app_name"]; name != "" {
+		return name
+	}
+	return e.SourceID
+}
+
+// tags returns the tags of the envelope, along with the tags of its application
+// when its source ID is known to the caches of the cluster agent
+func (e *envelope) tags(tagger appTagger) []string {
+	tags := make([]string, 0, len(e.Tags)+2)
+	if e.SourceID != "" {
+		tags = append(tags, "source_id:"+e.SourceID)
+	}
+	if e.InstanceID != "" {
+		tags = append(tags, "instance_id:"+e.InstanceID)
+	}
+	for k, v := range e.Tags {
+		tags = append(tags, k+":"+v)
+	}
+	if tagger != nil && e.SourceID != "" {
+		if appTags, found := tagger(e.SourceID); found {
+			tags = append(tags, appTags...)
+		}
+	}
+	// the envelopes and the caches can both have the name of the application, the space or the org
+	sort.Strings(tags)
+	deduped := tags[:0]
+	for i, tag := range tags {
+		if i == 0 || tag != tags[i-1] {
+			deduped = append(deduped, tag)
+		}
+	}
+	return deduped
+}
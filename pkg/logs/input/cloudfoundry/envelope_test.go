@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const testBatch = `{"batch":[
+	{"timestamp":"1580000000000000000","source_id":"app-guid","instance_id":"1","tags":{"app_name":"my-app","source_type":"APP/PROC/WEB/0"},"log":{"payload":"aGVsbG8=","type":"OUT"}},
+	{"timestamp":"1580000001000000000","source_id":"app-guid","instance_id":"1","tags":{"app_name":"my-app"},"log":{"payload":"b29wcw==","type":"ERR"}},
+	{"timestamp":"1580000002000000000","source_id":"doppler","tags":{"deployment":"cf"},"gauge":{"metrics":{"memory":{"unit":"bytes","value":1024},"cpu":{"unit":"percentage","value":1.5}}}},
+	{"timestamp":"1580000003000000000","source_id":"gorouter","counter":{"name":"requests","delta":"1","total":"42"}}
+]}`
+
+func testTagger(appGUID string) ([]string, bool) {
+	if appGUID != "app-guid" {
+		return nil, false
+	}
+	return []string{"app_guid:app-guid", "app_name:my-app", "space_name:my-space"}, true
+}
+
+func TestToMessagesLogs(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.CloudFoundryType})
+	messages, err := toMessages([]byte(testBatch), source, testTagger)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, "hello", string(messages[0].Content))
+	assert.Equal(t, message.StatusInfo, messages[0].GetStatus())
+	assert.Equal(t, time.Unix(1580000000, 0), messages[0].GetTimestamp())
+	assert.Equal(t, "cloudfoundry", messages[0].Origin.Source())
+	assert.Equal(t, "my-app", messages[0].Origin.Service())
+	assert.Equal(t, []string{
+		"app_guid:app-guid",
+		"app_name:my-app",
+		"instance_id:1",
+		"source_id:app-guid",
+		"source_type:APP/PROC/WEB/0",
+		"space_name:my-space",
+	}, messages[0].Origin.Tags())
+
+	assert.Equal(t, "oops", string(messages[1].Content))
+	assert.Equal(t, message.StatusError, messages[1].GetStatus())
+}
+
+func TestToMessagesMetrics(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.CloudFoundryType, IncludeMetrics: true})
+	messages, err := toMessages([]byte(testBatch), source, nil)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(messages[2].Content, &attributes))
+	assert.Equal(t, "gauge cpu=1.5percentage memory=1024bytes", attributes["message"])
+	assert.Equal(t, "doppler", attributes["source_id"])
+	assert.Equal(t, "doppler", messages[2].Origin.Service())
+	assert.Equal(t, []string{"deployment:cf", "source_id:doppler"}, messages[2].Origin.Tags())
+
+	require.NoError(t, json.Unmarshal(messages[3].Content, &attributes))
+	assert.Equal(t, "counter requests delta=1 total=42", attributes["message"])
+
+	// the malformed envelopes are dropped, not the whole batch
+	messages, err = toMessages([]byte(`{"batch":[{"timestamp":"invalid"},{"source_id":"app-guid","log":{"payload":"aGVsbG8="}}]}`), source, nil)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "hello", string(messages[0].Content))
+
+	_, err = toMessages([]byte(`{"batch":`), source, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// Launcher starts and stops a stream for each cloud_foundry source
+type Launcher struct {
+	addedSources     chan *config.LogSource
+	removedSources   chan *config.LogSource
+	pipelineProvider pipeline.Provider
+	streams          map[*config.LogSource]*Stream
+	stop             chan struct{}
+}
+
+// NewLauncher returns a new Launcher
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		addedSources:     sources.GetAddedForType(config.CloudFoundryType),
+		removedSources:   sources.GetRemovedForType(config.CloudFoundryType),
+		pipelineProvider: pipelineProvider,
+		streams:          make(map[*config.LogSource]*Stream),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// Stop stops the launcher and all its streams
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for source, stream := range l.streams {
+		stopper.Add(stream)
+		delete(l.streams, source)
+	}
+	stopper.Stop()
+}
+
+// run starts and stops the streams of the sources
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.addedSources:
+			if _, exists := l.streams[source]; exists {
+				continue
+			}
+			stream := NewStream(source, l.pipelineProvider.NextPipelineChan())
+			stream.Start()
+			l.streams[source] = stream
+		case source := <-l.removedSources:
+			if stream, exists := l.streams[source]; exists {
+				delete(l.streams, source)
+				go stream.Stop()
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
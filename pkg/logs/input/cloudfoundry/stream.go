@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/cloudfoundry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	backoffInitialDuration = 1 * time.Second
+	backoffMaxDuration     = 60 * time.Second
+	// defaultShardID is shared by all the agents without a shard_id, the
+	// gateway spreads the envelopes of a shard among its consumers
+	defaultShardID = "datadog-agent"
+	// requestTimeout bounds the requests to UAA and the wait for the response
+	// headers of the gateway, the stream itself is long-lived
+	requestTimeout = 30 * time.Second
+)
+
+// idleTimeout is the delay after which a stream that didn't receive any event,
+// not even a heartbeat, is closed and opened again
+var idleTimeout = 2 * time.Minute
+
+// appTagger returns the tags of an application and whether it's known
+type appTagger func(appGUID string) ([]string, bool)
+
+// Stream reads the envelopes of the Reverse Log Proxy (RLP) gateway of
+// loggregator as server-sent events, and forwards them to the pipeline.
+// The gateway doesn't keep track of the consumers, the envelopes emitted
+// while the stream is disconnected are lost.
+type Stream struct {
+	source      *config.LogSource
+	outputChan  chan *message.Message
+	httpClient  *http.Client
+	tokenSource *cloudfoundry.UAATokenSource
+	tagger      appTagger
+	ctx         context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewStream returns a new Stream
+func NewStream(source *config.LogSource, outputChan chan *message.Message) *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   requestTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: source.Config.TLSSkipVerify},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: requestTimeout,
+	}
+	return &Stream{
+		source:     source,
+		outputChan: outputChan,
+		// the stream is bounded by the idle timeout instead of a client timeout
+		httpClient:  &http.Client{Transport: transport},
+		tokenSource: cloudfoundry.NewUAATokenSource(source.Config.UAAURL, source.Config.ClientID, source.Config.ClientSecret, &http.Client{Transport: transport, Timeout: requestTimeout}),
+		tagger:      defaultAppTagger,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start starts reading the envelopes
+func (s *Stream) Start() {
+	log.Infof("Start reading the envelopes of the loggregator gateway %s", s.source.Config.URL)
+	go s.run()
+}
+
+// Stop closes the connection to the gateway
+func (s *Stream) Stop() {
+	log.Infof("Stop reading the envelopes of the loggregator gateway %s", s.source.Config.URL)
+	s.cancel()
+	<-s.done
+}
+
+// run reads the envelopes, the gateway closes the connections periodically
+// and a new one must be opened.
+func (s *Stream) run() {
+	defer close(s.done)
+	backoff := backoffInitialDuration
+	for {
+		forwarded, err := s.read()
+		if s.ctx.Err() != nil {
+			return
+		}
+		if forwarded {
+			backoff = backoffInitialDuration
+		}
+		if err == nil {
+			continue
+		}
+
+		log.Warnf("Could not read the envelopes of the loggregator gateway %s, retrying in %s: %v", s.source.Config.URL, backoff, err)
+		s.source.Status.Error(err)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < backoffMaxDuration {
+			backoff *= 2
+		}
+	}
+}
+
+// read opens a connection to the gateway and forwards the envelopes until it
+// is closed, it returns whether any envelope was read.
+func (s *Stream) read() (bool, error) {
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		return false, fmt.Errorf("could not get a UAA token: %v", err)
+	}
+	req, err := http.NewRequest("GET", s.readURL(), nil)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// the token was revoked, get a new one on the next connection
+		s.tokenSource.Invalidate()
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	s.source.Status.Success()
+	s.source.AddInput(s.source.Config.URL)
+	defer s.source.RemoveInput(s.source.Config.URL)
+
+	idle := &idleReader{reader: resp.Body, timer: time.AfterFunc(idleTimeout, cancel)}
+	defer idle.timer.Stop()
+
+	forwarded := false
+	err = readEvents(idle, func(data []byte) error {
+		messages, err := toMessages(data, s.source, s.tagger)
+		if err != nil {
+			// a malformed batch doesn't break the stream
+			log.Warnf("Could not decode a batch of envelopes from %s: %v", s.source.Config.URL, err)
+			return nil
+		}
+		forwarded = true
+		for _, msg := range messages {
+			select {
+			case s.outputChan <- msg:
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			}
+		}
+		return nil
+	})
+	if err != nil && s.ctx.Err() == nil && ctx.Err() != nil {
+		err = fmt.Errorf("no event received in %s", idleTimeout)
+	}
+	return forwarded, err
+}
+
+// idleReader resets its timer at every read, the timer closes the stream when
+// it fires
+type idleReader struct {
+	reader io.Reader
+	timer  *time.Timer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(idleTimeout)
+	}
+	return n, err
+}
+
+// readURL returns the URL of the stream of the envelopes of the source
+func (s *Stream) readURL() string {
+	query := url.Values{}
+	query.Set("log", "")
+	if s.source.Config.IncludeMetrics {
+		for _, envelopeType := range []string{"counter", "gauge", "timer", "event"} {
+			query.Set(envelopeType, "")
+		}
+	}
+	shardID := s.source.Config.ShardID
+	if shardID == "" {
+		shardID = defaultShardID
+	}
+	query.Set("shard_id", shardID)
+	return strings.TrimSuffix(s.source.Config.URL, "/") + "/v2/read?" + query.Encode()
+}
+
+// readEvents calls handle with the data of each server-sent event of r until it is
+// closed, the heartbeats and the other named events are skipped, ex:
+//  data: {"batch":[...]}
+//
+//  event: heartbeat
+//  data: 1580000000
+func readEvents(r io.Reader, handle func([]byte) error) error {
+	reader := bufio.NewReader(r)
+	var eventType string
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(line) == 0:
+			// a blank line dispatches the event
+			if eventType == "" && data.Len() > 0 {
+				if err := handle(data.Bytes()); err != nil {
+					return err
+				}
+			}
+			eventType = ""
+			data.Reset()
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		case bytes.HasPrefix(line, []byte("event:")):
+			eventType = string(bytes.TrimSpace(bytes.TrimPrefix(line, []byte("event:"))))
+		}
+	}
+}
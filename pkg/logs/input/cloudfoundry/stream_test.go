@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := "data: {\"batch\":[]}\n\nevent: heartbeat\ndata: 1580000000\n\ndata: first\r\ndata: second\r\n\r\n"
+	var events []string
+	err := readEvents(strings.NewReader(stream), func(data []byte) error {
+		events = append(events, string(data))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"batch":[]}`, "first\nsecond"}, events)
+}
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "datadog", user)
+			assert.Equal(t, "secret", password)
+			fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+		case "/v2/read":
+			assert.Equal(t, "bearer token", r.Header.Get("Authorization"))
+			assert.Equal(t, "my-shard", r.URL.Query().Get("shard_id"))
+			_, hasGauges := r.URL.Query()["gauge"]
+			assert.False(t, hasGauges)
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\n\n", strings.Replace(testBatch, "\n", "", -1))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:         config.CloudFoundryType,
+		URL:          server.URL,
+		UAAURL:       server.URL,
+		ClientID:     "datadog",
+		ClientSecret: "secret",
+		ShardID:      "my-shard",
+	})
+	outputChan := make(chan *message.Message, 10)
+	stream := NewStream(source, outputChan)
+	stream.Start()
+	defer stream.Stop()
+
+	for _, expected := range []string{"hello", "oops"} {
+		select {
+		case msg := <-outputChan:
+			assert.Equal(t, expected, string(msg.Content))
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for the envelopes")
+		}
+	}
+	assert.True(t, source.Status.IsSuccess())
+}
+
+func TestStreamIdle(t *testing.T) {
+	defer func(timeout time.Duration) { idleTimeout = timeout }(idleTimeout)
+	idleTimeout = 100 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token" {
+			fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	source := config.NewLogSource("", &config.LogsConfig{
+		Type:         config.CloudFoundryType,
+		URL:          server.URL,
+		UAAURL:       server.URL,
+		ClientID:     "datadog",
+		ClientSecret: "secret",
+	})
+	stream := NewStream(source, make(chan *message.Message, 10))
+
+	// the stream is closed when the gateway stops sending events
+	forwarded, err := stream.read()
+	assert.False(t, forwarded)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no event received")
+}
@@ -0,0 +1,12 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !clusterchecks
+
+package cloudfoundry
+
+// defaultAppTagger is not set without the BBS and CC caches,
+// the envelopes are only tagged with their own tags
+var defaultAppTagger appTagger
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package containerd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	ctrUtil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// configPath is the label holding the logs configuration of a container,
+// like for the docker containers.
+const configPath = "com.datadoghq.ad.logs"
+
+// containerdIntegration is the source and the service of the containers without image.
+const containerdIntegration = "containerd"
+
+var errCollectAllDisabled = fmt.Errorf("%s disabled", config.ContainerCollectAll)
+
+// Launcher creates one logs-source per container of the standalone containerd workloads,
+// it tails the json log files written by nerdctl. The containers managed by Kubernetes
+// or docker are handled by their own launchers.
+type Launcher struct {
+	sources            *config.LogSources
+	sourcesByContainer map[workloadmeta.EntityID]*config.LogSource
+	store              *workloadmeta.Store
+	events             chan []workloadmeta.Event
+	stopped            chan struct{}
+	collectAll         bool
+}
+
+// NewLauncher returns a new launcher, or an error if containerd is not available.
+func NewLauncher(sources *config.LogSources, collectAll bool) (*Launcher, error) {
+	if _, err := ctrUtil.GetContainerdUtil(); err != nil {
+		return nil, err
+	}
+	return &Launcher{
+		sources:            sources,
+		sourcesByContainer: make(map[workloadmeta.EntityID]*config.LogSource),
+		store:              workloadmeta.GetGlobalStore(),
+		stopped:            make(chan struct{}),
+		collectAll:         collectAll,
+	}, nil
+}
+
+// Start starts the launcher
+func (l *Launcher) Start() {
+	log.Info("Starting containerd launcher")
+	l.events = l.store.Subscribe("logs-containerd", &workloadmeta.Filter{
+		Kinds:   []workloadmeta.Kind{workloadmeta.KindContainer},
+		Sources: []workloadmeta.Source{workloadmeta.SourceContainerd},
+	})
+	go l.run()
+}
+
+// Stop stops the launcher
+func (l *Launcher) Stop() {
+	log.Info("Stopping containerd launcher")
+	l.stopped <- struct{}{}
+	l.store.Unsubscribe(l.events)
+}
+
+// run adds and removes the sources of the containers
+func (l *Launcher) run() {
+	for {
+		select {
+		case events := <-l.events:
+			for _, event := range events {
+				switch event.Type {
+				case workloadmeta.EventTypeSet:
+					if container, ok := event.Entity.(workloadmeta.Container); ok {
+						l.addSource(container)
+					}
+				case workloadmeta.EventTypeUnset:
+					l.removeSource(event.Entity.GetID())
+				}
+			}
+		case <-l.stopped:
+			log.Info("containerd launcher stopped")
+			return
+		}
+	}
+}
+
+// addSource creates a new log-source for a container
+func (l *Launcher) addSource(container workloadmeta.Container) {
+	if ctrUtil.IsOrchestratedNamespace(container.Namespace) {
+		return
+	}
+	if _, exists := l.sourcesByContainer[container.EntityID]; exists {
+		return
+	}
+	source, err := l.getSource(container)
+	if err != nil {
+		if err != errCollectAllDisabled {
+			log.Warnf("Invalid configuration for container %v: %v", container.ID, err)
+		}
+		return
+	}
+	source.SetSourceType(config.DockerSourceType)
+	l.sourcesByContainer[container.EntityID] = source
+	l.sources.AddSource(source)
+}
+
+// removeSource removes the log-source of a container
+func (l *Launcher) removeSource(id workloadmeta.EntityID) {
+	if source, exists := l.sourcesByContainer[id]; exists {
+		delete(l.sourcesByContainer, id)
+		l.sources.RemoveSource(source)
+	}
+}
+
+// getSource returns a new source tailing the log file of the container.
+func (l *Launcher) getSource(container workloadmeta.Container) (*config.LogSource, error) {
+	stateDir, exists := container.Labels[ctrUtil.NerdctlStateDirLabel]
+	if !exists {
+		return nil, fmt.Errorf("the container was not created by nerdctl, its logs can't be found")
+	}
+
+	var cfg *config.LogsConfig
+	if label, exists := container.Labels[configPath]; exists {
+		configs, err := config.ParseJSON([]byte(label))
+		if err != nil || len(configs) == 0 {
+			return nil, fmt.Errorf("could not parse containerd label %v", label)
+		}
+		cfg = configs[0]
+	} else {
+		if !l.collectAll {
+			return nil, errCollectAllDisabled
+		}
+		name := containerdIntegration
+		if _, shortName, _, err := containers.SplitImageName(container.Image); err == nil && shortName != "" {
+			name = shortName
+		}
+		cfg = &config.LogsConfig{
+			Source:  name,
+			Service: name,
+		}
+	}
+	cfg.Type = config.FileType
+	cfg.Path = filepath.Join(stateDir, container.ID+"-json.log")
+	cfg.Identifier = containers.BuildTaggerEntityName(container.ID)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid containerd label: %v", err)
+	}
+
+	return config.NewLogSource(fmt.Sprintf("containerd/%s/%s", container.Namespace, container.ID), cfg), nil
+}
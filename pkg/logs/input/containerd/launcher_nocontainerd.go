@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !containerd
+
+package containerd
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// Launcher is not supported on non containerd environment
+type Launcher struct{}
+
+// NewLauncher returns a new launcher
+func NewLauncher(sources *config.LogSources, collectAll bool) (*Launcher, error) {
+	return &Launcher{}, nil
+}
+
+// Start does nothing
+func (l *Launcher) Start() {}
+
+// Stop does nothing
+func (l *Launcher) Stop() {}
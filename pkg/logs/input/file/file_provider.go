@@ -127,7 +127,7 @@ func (p *Provider) CollectFiles(source *config.LogSource) ([]*File, error) {
 
 // searchFiles returns all the files matching the source path pattern.
 func (p *Provider) searchFiles(pattern string, source *config.LogSource) ([]*File, error) {
-	paths, err := filepath.Glob(pattern)
+	paths, err := glob(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("malformed pattern, could not find any file: %s", pattern)
 	}
@@ -155,7 +155,7 @@ func (p *Provider) searchFiles(pattern string, source *config.LogSource) ([]*Fil
 	// Resolve excluded path(s)
 	excludedPaths := make(map[string]int)
 	for _, excludePattern := range source.Config.ExcludePaths {
-		excludedGlob, err := filepath.Glob(excludePattern)
+		excludedGlob, err := glob(excludePattern)
 		if err != nil {
 			return nil, fmt.Errorf("malformed exclusion pattern: %s, %s", excludePattern, err)
 		}
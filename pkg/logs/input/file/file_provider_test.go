@@ -272,6 +272,31 @@ func (suite *ProviderTestSuite) TestExcludePath() {
 	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[2].Path)
 }
 
+func (suite *ProviderTestSuite) TestRecursivePath() {
+	filesLimit := 6
+	err := os.MkdirAll(fmt.Sprintf("%s/2/3", suite.testDir), os.ModePerm)
+	suite.Nil(err)
+	_, err = os.Create(fmt.Sprintf("%s/2/3/4.log", suite.testDir))
+	suite.Nil(err)
+	defer os.RemoveAll(fmt.Sprintf("%s/2/3", suite.testDir))
+
+	path := fmt.Sprintf("%s/**/*.log", suite.testDir)
+	excludePaths := []string{fmt.Sprintf("%s/1/**", suite.testDir)}
+	fileProvider := NewProvider(filesLimit)
+	logSources := []*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, ExcludePaths: excludePaths}),
+	}
+
+	files := fileProvider.FilesToTail(logSources)
+	suite.Equal(3, len(files))
+	for i := 0; i < len(files); i++ {
+		suite.Assert().True(files[i].IsWildcardPath)
+	}
+	suite.Equal(fmt.Sprintf("%s/2/3/4.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/2.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/2/1.log", suite.testDir), files[2].Path)
+}
+
 func TestProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(ProviderTestSuite))
 }
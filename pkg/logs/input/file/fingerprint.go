@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package file
+
+import (
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// fingerprintMaxSize is the maximum number of bytes at the beginning of a file identifying its content
+const fingerprintMaxSize = 1024
+
+// fingerprint identifies the content of a file with the checksum of its first bytes,
+// it detects that a file was truncated and written again even when it is as large as before.
+type fingerprint struct {
+	size     int
+	checksum uint32
+}
+
+// computeFingerprint returns the fingerprint of the first bytes of the file.
+func computeFingerprint(file *os.File) (*fingerprint, error) {
+	buf := make([]byte, fingerprintMaxSize)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &fingerprint{
+		size:     n,
+		checksum: crc32.ChecksumIEEE(buf[:n]),
+	}, nil
+}
+
+// isComplete returns true if the fingerprint won't change as long as the file is not rotated.
+func (f *fingerprint) isComplete() bool {
+	return f != nil && f.size == fingerprintMaxSize
+}
+
+// matches returns true if the file still starts with the bytes of the fingerprint,
+// a nil or empty fingerprint matches any file.
+func (f *fingerprint) matches(file *os.File) (bool, error) {
+	if f == nil || f.size == 0 {
+		return true, nil
+	}
+	buf := make([]byte, f.size)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return n == f.size && crc32.ChecksumIEEE(buf) == f.checksum, nil
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// recursiveWildcard matches zero or more directories in a path pattern, e.g. /var/log/**/*.log
+const recursiveWildcard = "**"
+
+// isRecursivePattern returns true if the pattern matches files in any subdirectory.
+func isRecursivePattern(pattern string) bool {
+	return strings.Contains(pattern, recursiveWildcard)
+}
+
+// glob returns the paths of the files matching the pattern,
+// it behaves like filepath.Glob unless the pattern contains `**`.
+func glob(pattern string) ([]string, error) {
+	if !isRecursivePattern(pattern) {
+		return filepath.Glob(pattern)
+	}
+	// check that the pattern is well formed before walking the directories
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	segments := splitPath(pattern)
+	var paths []string
+	err := filepath.Walk(patternRoot(pattern), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the directory can't be read, keep walking the other ones
+			return nil
+		}
+		if !info.IsDir() && matchSegments(segments, splitPath(path)) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// patternRoot returns the deepest directory of the pattern without any wildcard.
+func patternRoot(pattern string) string {
+	segments := splitPath(pattern)
+	var root []string
+	for _, segment := range segments[:len(segments)-1] {
+		if config.ContainsWildcard(segment) {
+			break
+		}
+		root = append(root, segment)
+	}
+	switch {
+	case len(root) == 0:
+		return "."
+	case len(root) == 1 && root[0] == "":
+		return string(filepath.Separator)
+	default:
+		return strings.Join(root, string(filepath.Separator))
+	}
+}
+
+// matchSegments returns true if the segments of a path match the segments of a pattern,
+// `**` matches any number of segments.
+func matchSegments(patterns []string, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == recursiveWildcard {
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if matched, _ := filepath.Match(patterns[0], names[0]); !matched {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// splitPath returns the segments of a path.
+func splitPath(path string) []string {
+	return strings.Split(filepath.Clean(path), string(filepath.Separator))
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !windows
+
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchSegments(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"/var/log/**/*.log", "/var/log/app.log", true},
+		{"/var/log/**/*.log", "/var/log/app/app.log", true},
+		{"/var/log/**/*.log", "/var/log/app/1/2/app.log", true},
+		{"/var/log/**/*.log", "/var/log/app/app.txt", false},
+		{"/var/log/**/*.log", "/var/lib/app.log", false},
+		{"/var/log/**/app/*.log", "/var/log/1/app/app.log", true},
+		{"/var/log/**/app/*.log", "/var/log/1/other/app.log", false},
+		{"/var/log/**", "/var/log/1/app.log", true},
+	} {
+		assert.Equal(t, test.matches, matchSegments(splitPath(test.pattern), splitPath(test.path)), test.pattern+" "+test.path)
+	}
+}
+
+func TestPatternRoot(t *testing.T) {
+	assert.Equal(t, "/var/log", patternRoot("/var/log/**/*.log"))
+	assert.Equal(t, "/var/log", patternRoot("/var/log/*/app.log"))
+	assert.Equal(t, "/var/log", patternRoot("/var/log/*.log"))
+	assert.Equal(t, "/", patternRoot("/*/app.log"))
+	assert.Equal(t, "logs", patternRoot("logs/**/*.log"))
+	assert.Equal(t, ".", patternRoot("**/*.log"))
+}
+
+func TestDirsToWatch(t *testing.T) {
+	assert.Equal(t, []string{"/var/log"}, dirsToWatch("/var/log/app.log"))
+	assert.Equal(t, []string{"/var/log/does-not-exist"}, dirsToWatch("/var/log/does-not-exist/*.log"))
+}
@@ -9,30 +9,62 @@ import (
 	"os"
 )
 
+// rotation describes how a file has been log-rotated.
+type rotation int
+
+const (
+	// notRotated means that the file is still the one being tailed
+	notRotated rotation = iota
+	// recreated means that the file has been renamed or removed, and recreated,
+	// the content not read yet is still available in the file being tailed
+	recreated
+	// truncated means that the file has been truncated, e.g. with copytruncate,
+	// the content of the file being tailed has been replaced
+	truncated
+)
+
 // DidRotate returns true if the file has been log-rotated.
 // When a log rotation occurs, the file can be either:
 // - renamed and recreated
 // - removed and recreated
 // - truncated
 func DidRotate(file *os.File, lastReadOffset int64) (bool, error) {
+	rotation, err := detectRotation(file, lastReadOffset, nil)
+	return rotation != notRotated, err
+}
+
+// detectRotation returns how the file has been log-rotated, the inode of the file detects
+// that it has been recreated and the fingerprint of its content that it has been truncated,
+// even when it has been written again past the last read offset.
+func detectRotation(file *os.File, lastReadOffset int64, fingerprint *fingerprint) (rotation, error) {
 	f, err := openFile(file.Name())
-	defer f.Close()
 	if err != nil {
-		return false, err
+		return notRotated, err
 	}
+	defer f.Close()
 
 	fi1, err := f.Stat()
 	if err != nil {
-		return false, err
+		return notRotated, err
 	}
 
 	fi2, err := file.Stat()
 	if err != nil {
-		return true, nil
+		return recreated, nil
 	}
 
-	recreated := !os.SameFile(fi1, fi2)
-	truncated := fi1.Size() < lastReadOffset
-
-	return recreated || truncated, nil
+	if !os.SameFile(fi1, fi2) {
+		return recreated, nil
+	}
+	if fi1.Size() < lastReadOffset {
+		return truncated, nil
+	}
+	matches, err := fingerprint.matches(f)
+	if err != nil {
+		return notRotated, err
+	}
+	if !matches {
+		return truncated, nil
+	}
+	return notRotated, nil
 }
@@ -30,6 +30,7 @@ type Scanner struct {
 	activeSources       []*config.LogSource
 	tailingLimit        int
 	fileProvider        *Provider
+	watcher             *dirWatcher
 	tailers             map[string]*Tailer
 	registry            auditor.Registry
 	tailerSleepDuration time.Duration
@@ -53,6 +54,7 @@ func NewScanner(sources *config.LogSources, tailingLimit int, pipelineProvider p
 
 // Start starts the Scanner
 func (s *Scanner) Start() {
+	s.watcher = newDirWatcher()
 	go s.run()
 }
 
@@ -60,6 +62,7 @@ func (s *Scanner) Start() {
 // this call returns only when all the tailers are stopped
 func (s *Scanner) Stop() {
 	s.stop <- struct{}{}
+	s.watcher.close()
 	s.cleanup()
 }
 
@@ -76,6 +79,9 @@ func (s *Scanner) run() {
 		case <-scanTicker.C:
 			// check if there are new files to tail, tailers to stop and tailer to restart because of file rotation
 			s.scan()
+		case <-s.watcher.Changes():
+			// files have been created, renamed or removed, tail the new files and handle the rotations now
+			s.scan()
 		case <-s.stop:
 			// no more file should be tailed
 			return
@@ -101,6 +107,7 @@ func (s *Scanner) cleanup() {
 // The Scanner needs to stop that previous tailer,
 // and start a new one for the new file.
 func (s *Scanner) scan() {
+	s.watcher.update(s.activeSources)
 	files := s.fileProvider.FilesToTail(s.activeSources)
 	filesTailed := make(map[string]bool)
 	tailersLen := len(s.tailers)
@@ -128,17 +135,22 @@ func (s *Scanner) scan() {
 			continue
 		}
 
-		didRotate, err := DidRotate(tailer.file, tailer.GetReadOffset())
+		rotation, err := detectRotation(tailer.file, tailer.GetReadOffset(), tailer.fingerprint)
 		if err != nil {
 			continue
 		}
-		if didRotate {
+		if rotation != notRotated {
 			// restart tailer because of file-rotation on file
-			succeeded := s.restartTailerAfterFileRotation(tailer, file)
+			succeeded := s.restartTailerAfterFileRotation(tailer, file, rotation)
 			if !succeeded {
 				// the setup failed, let's try to tail this file in the next scan
 				continue
 			}
+		} else if !tailer.fingerprint.isComplete() {
+			// the file was too small to be fully fingerprinted
+			if fingerprint, err := computeFingerprint(tailer.file); err == nil {
+				tailer.fingerprint = fingerprint
+			}
 		}
 
 		filesTailed[file.Path] = true
@@ -156,6 +168,7 @@ func (s *Scanner) scan() {
 // addSource keeps track of the new source and launch new tailers for this source.
 func (s *Scanner) addSource(source *config.LogSource) {
 	s.activeSources = append(s.activeSources, source)
+	s.watcher.update(s.activeSources)
 	s.launchTailers(source)
 }
 
@@ -252,9 +265,14 @@ func (s *Scanner) stopTailer(tailer *Tailer) {
 
 // restartTailer safely stops tailer and starts a new one
 // returns true if the new tailer is up and running, false if an error occurred
-func (s *Scanner) restartTailerAfterFileRotation(tailer *Tailer, file *File) bool {
+func (s *Scanner) restartTailerAfterFileRotation(tailer *Tailer, file *File, rotation rotation) bool {
 	log.Info("Log rotation happened to ", tailer.path)
-	tailer.StopAfterFileRotation()
+	if rotation == truncated {
+		tailer.StopAfterFileTruncation()
+	} else {
+		// keep reading the rotated file for a while, it may not have been read entirely yet
+		tailer.StopAfterFileRotation()
+	}
 	tailer = s.createTailer(file, tailer.outputChan)
 	// force reading file from beginning since it has been log-rotated
 	err := tailer.StartFromBeginning()
@@ -165,6 +165,38 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncate() {
 	suite.Equal("third", string(msg.Content))
 }
 
+func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncateAndLargerContent() {
+	s := suite.s
+	source := suite.source
+
+	var err error
+	var msg *message.Message
+
+	tailer := s.tailers[source.Config.Path]
+	_, err = suite.testFile.WriteString("hello world\n")
+	suite.Nil(err)
+	msg = <-suite.outputChan
+	suite.Equal("hello world", string(msg.Content))
+
+	// fingerprint the content of the file
+	s.scan()
+	suite.True(tailer == s.tailers[source.Config.Path])
+
+	// the file is truncated and written again past the last read offset
+	suite.testFile.Truncate(0)
+	suite.testFile.Seek(0, 0)
+	suite.testFile.Sync()
+	_, err = suite.testFile.WriteString("a longer line after the rotation\n")
+	suite.Nil(err)
+
+	s.scan()
+	newTailer := s.tailers[source.Config.Path]
+	suite.True(tailer != newTailer)
+
+	msg = <-suite.outputChan
+	suite.Equal("a longer line after the rotation", string(msg.Content))
+}
+
 func (suite *ScannerTestSuite) TestScannerScanWithFileRemovedAndCreated() {
 	s := suite.s
 	tailerLen := len(s.tailers)
@@ -38,6 +38,7 @@ type Tailer struct {
 
 	path           string
 	file           *os.File
+	fingerprint    *fingerprint
 	isWildcardPath bool
 	tags           []string
 
@@ -135,6 +136,10 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	}
 
 	t.file = f
+	t.fingerprint, err = computeFingerprint(f)
+	if err != nil {
+		log.Debugf("Could not compute the fingerprint of %s: %v", t.path, err)
+	}
 	ret, _ := f.Seek(offset, whence)
 	t.readOffset = ret
 	t.decodedOffset = ret
@@ -204,6 +209,14 @@ func (t *Tailer) StopAfterFileRotation() {
 	t.source.RemoveInput(t.path)
 }
 
+// StopAfterFileTruncation stops the tailer without tracking its offset anymore,
+// the content of its file has been replaced so there is nothing left to read
+func (t *Tailer) StopAfterFileTruncation() {
+	atomic.StoreInt32(&t.didFileRotate, 1)
+	t.stop <- struct{}{}
+	t.source.RemoveInput(t.path)
+}
+
 // startStopTimer initialises and starts a timer to stop the tailor after the timeout
 func (t *Tailer) startStopTimer() {
 	stopTimer := time.NewTimer(t.closeTimeout)
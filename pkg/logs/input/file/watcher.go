@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// watchDebounce is the delay without any event after which the changes are notified,
+// a log rotation usually renames, creates and removes several files at once.
+const watchDebounce = 100 * time.Millisecond
+
+// dirWatcher watches the directories of the files to tail and notifies when files are created,
+// renamed or removed, so that the new files are tailed and the rotations are handled without
+// waiting for the next scan. A nil dirWatcher never notifies any change.
+type dirWatcher struct {
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+	changes chan struct{}
+}
+
+// newDirWatcher returns a new dirWatcher, or nil when the directories can't be watched.
+func newDirWatcher() *dirWatcher {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Warnf("Could not watch the directories of the files to tail, new files will be discovered every %v: %v", scanPeriod, err)
+		return nil
+	}
+	w := &dirWatcher{
+		watcher: watcher,
+		watched: make(map[string]bool),
+		changes: make(chan struct{}, 1),
+	}
+	go w.run()
+	return w
+}
+
+// Changes returns the channel notified when files are created, renamed or removed.
+func (w *dirWatcher) Changes() <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+	return w.changes
+}
+
+// update watches the directories where the files of the sources can be found,
+// and stops watching the other ones.
+func (w *dirWatcher) update(sources []*config.LogSource) {
+	if w == nil {
+		return
+	}
+	dirs := make(map[string]bool)
+	for _, source := range sources {
+		for _, dir := range dirsToWatch(source.Config.Path) {
+			dirs[dir] = true
+		}
+	}
+	for dir := range dirs {
+		if w.watched[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			log.Debugf("Could not watch the directory %s: %v", dir, err)
+			continue
+		}
+		w.watched[dir] = true
+	}
+	for dir := range w.watched {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+			delete(w.watched, dir)
+		}
+	}
+}
+
+// close stops watching the directories.
+func (w *dirWatcher) close() {
+	if w == nil {
+		return
+	}
+	w.watcher.Close()
+}
+
+// run notifies the changes until the watcher is closed.
+func (w *dirWatcher) run() {
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				// the writes are read by the tailers
+				continue
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Debugf("Error watching the directories of the files to tail: %v", err)
+			// events may have been lost, scan the files again
+			debounce.Reset(watchDebounce)
+		case <-debounce.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// dirsToWatch returns the directories where the files matching the path can be created,
+// all the subdirectories are watched for recursive patterns.
+func dirsToWatch(path string) []string {
+	if !config.ContainsWildcard(path) {
+		return []string{filepath.Dir(path)}
+	}
+	root := patternRoot(path)
+	if isRecursivePattern(path) {
+		var dirs []string
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+		return dirs
+	}
+	// the directories created in the root are watched once the next scan updates the watcher
+	dirs, _ := filepath.Glob(filepath.Dir(path))
+	return append(dirs, root)
+}
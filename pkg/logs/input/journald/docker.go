@@ -8,6 +8,8 @@
 package journald
 
 import (
+	"strconv"
+
 	"github.com/coreos/go-systemd/sdjournal"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
@@ -40,6 +42,21 @@ func (t *Tailer) getContainerTags(containerID string) []string {
 	return tags
 }
 
+// getProcessTags returns the tags of the container running the process which wrote the entry,
+// for the containers which do not log through the docker journald driver.
+func (t *Tailer) getProcessTags(entry *sdjournal.JournalEntry) []string {
+	pid, err := strconv.ParseInt(entry.Fields[sdjournal.SD_JOURNAL_FIELD_PID], 10, 32)
+	if err != nil {
+		return nil
+	}
+	tags, err := tagger.TagForPID(int32(pid), collectors.HighCardinality)
+	if err != nil {
+		// the process may have exited since it wrote the entry
+		log.Debugf("Could not get the tags of process %d: %v", pid, err)
+	}
+	return tags
+}
+
 // initializeTagger initializes the tag collector.
 func (t *Tailer) initializeTagger() {
 	tagger.Init()
@@ -18,6 +18,7 @@ import (
 // Launcher is in charge of starting and stopping new journald tailers
 type Launcher struct {
 	sources          chan *config.LogSource
+	removedSources   chan *config.LogSource
 	pipelineProvider pipeline.Provider
 	registry         auditor.Registry
 	tailers          map[string]*Tailer
@@ -28,6 +29,7 @@ type Launcher struct {
 func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider, registry auditor.Registry) *Launcher {
 	return &Launcher{
 		sources:          sources.GetAddedForType(config.JournaldType),
+		removedSources:   sources.GetRemovedForType(config.JournaldType),
 		pipelineProvider: pipelineProvider,
 		registry:         registry,
 		tailers:          make(map[string]*Tailer),
@@ -40,22 +42,31 @@ func (l *Launcher) Start() {
 	go l.run()
 }
 
-// run starts new tailers.
+// run starts and stops the tailers.
 func (l *Launcher) run() {
 	for {
 		select {
 		case source := <-l.sources:
-			identifier := source.Config.Path
+			identifier := sourceIdentifier(source)
 			if _, exists := l.tailers[identifier]; exists {
-				// set up only one tailer per journal
+				// set up only one tailer per journal and config id
+				log.Warnf("A journald source already tails %s, set a different config_id to tail it with different filters", identifier)
 				continue
 			}
-			tailer, err := l.setupTailer(source)
-			if err != nil {
+			tailer := NewTailer(source, l.pipelineProvider.NextPipelineChan())
+			if err := l.startTailer(tailer); err != nil {
 				log.Warn("Could not set up journald tailer: ", err)
 			} else {
 				l.tailers[identifier] = tailer
 			}
+		case source := <-l.removedSources:
+			for identifier, tailer := range l.tailers {
+				if tailer.source == source {
+					delete(l.tailers, identifier)
+					go tailer.Stop()
+					break
+				}
+			}
 		case <-l.stop:
 			return
 		}
@@ -73,14 +84,9 @@ func (l *Launcher) Stop() {
 	stopper.Stop()
 }
 
-// setupTailer configures and starts a new tailer,
-// returns the tailer or an error.
-func (l *Launcher) setupTailer(source *config.LogSource) (*Tailer, error) {
-	tailer := NewTailer(source, l.pipelineProvider.NextPipelineChan())
+// startTailer starts a tailer from the cursor persisted for its identifier,
+// returns an error if the journal can't be tailed.
+func (l *Launcher) startTailer(tailer *Tailer) error {
 	cursor := l.registry.GetOffset(tailer.Identifier())
-	err := tailer.Start(cursor)
-	if err != nil {
-		return nil, err
-	}
-	return tailer, nil
+	return tailer.Start(cursor)
 }
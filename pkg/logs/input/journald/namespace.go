@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build systemd
+
+package journald
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	machineIDPath = "/etc/machine-id"
+	// journalDirs are the directories of the persistent and of the volatile journals
+	journalDirs = []string{"/var/log/journal", "/run/log/journal"}
+)
+
+// namespaceJournalDir returns the directory of the journal of a namespace,
+// journald stores it in <journal dir>/<machine id>.<namespace> since systemd 245.
+func namespaceJournalDir(namespace string) (string, error) {
+	machineID, err := ioutil.ReadFile(machineIDPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read the machine id: %s", err)
+	}
+	name := strings.TrimSpace(string(machineID)) + "." + namespace
+	for _, dir := range journalDirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("could not find the journal of the namespace %s in %s", namespace, strings.Join(journalDirs, ", "))
+}
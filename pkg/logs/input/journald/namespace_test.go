@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build systemd
+
+package journald
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceJournalDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "journald")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	oldMachineIDPath, oldJournalDirs := machineIDPath, journalDirs
+	defer func() { machineIDPath, journalDirs = oldMachineIDPath, oldJournalDirs }()
+	machineIDPath = filepath.Join(root, "machine-id")
+	journalDirs = []string{filepath.Join(root, "persistent"), filepath.Join(root, "volatile")}
+
+	_, err = namespaceJournalDir("foo")
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(machineIDPath, []byte("0123456789abcdef\n"), 0644))
+	_, err = namespaceJournalDir("foo")
+	assert.Error(t, err)
+
+	volatile := filepath.Join(root, "volatile", "0123456789abcdef.foo")
+	require.NoError(t, os.MkdirAll(volatile, 0755))
+	dir, err := namespaceJournalDir("foo")
+	require.NoError(t, err)
+	assert.Equal(t, volatile, dir)
+
+	persistent := filepath.Join(root, "persistent", "0123456789abcdef.foo")
+	require.NoError(t, os.MkdirAll(persistent, 0755))
+	dir, err = namespaceJournalDir("foo")
+	require.NoError(t, err)
+	assert.Equal(t, persistent, dir)
+}
@@ -78,11 +78,18 @@ func (t *Tailer) setup() error {
 
 	t.initializeTagger()
 
-	if config.Path == "" {
+	switch {
+	case config.JournalNamespace != "":
+		var dir string
+		dir, err = namespaceJournalDir(config.JournalNamespace)
+		if err == nil {
+			t.journal, err = sdjournal.NewJournalFromDir(dir)
+		}
+	case config.Path != "":
+		t.journal, err = sdjournal.NewJournalFromDir(config.Path)
+	default:
 		// open the default journal
 		t.journal, err = sdjournal.NewJournal()
-	} else {
-		t.journal, err = sdjournal.NewJournalFromDir(config.Path)
 	}
 	if err != nil {
 		return err
@@ -115,8 +122,16 @@ func (t *Tailer) seek(cursor string) error {
 		if err != nil {
 			return err
 		}
-		// must skip one entry since the cursor points to the last committed one.
-		_, err = t.journal.NextSkip(1)
+		// the cursor points to the last committed entry, which must be skipped,
+		// but the journal seeks to the closest entry when it does not exist anymore
+		// (e.g. it was vacuumed), this entry has not been sent yet and must not be skipped.
+		n, err := t.journal.Next()
+		if err != nil || n < 1 {
+			return err
+		}
+		if t.journal.TestCursor(cursor) != nil {
+			_, err = t.journal.Previous()
+		}
 		return err
 	}
 	return t.journal.SeekTail()
@@ -263,6 +278,8 @@ func (t *Tailer) getTags(entry *sdjournal.JournalEntry) []string {
 	var tags []string
 	if t.isContainerEntry(entry) {
 		tags = t.getContainerTags(t.getContainerID(entry))
+	} else {
+		tags = t.getProcessTags(entry)
 	}
 	return tags
 }
@@ -297,15 +314,34 @@ func (t *Tailer) getStatus(entry *sdjournal.JournalEntry) string {
 // it's used to override the source of the message and as a fingerprint to store the journal cursor.
 const journaldIntegration = "journald"
 
-// Identifier returns the unique identifier of the current journal being tailed.
+// Identifier returns the unique identifier of the current journal being tailed,
+// the config id allows to tail the same journal with different sources
+// and to persist their cursors independently.
 func (t *Tailer) Identifier() string {
-	return journaldIntegration + ":" + t.journalPath()
+	return sourceIdentifier(t.source)
 }
 
 // journalPath returns the path of the journal
 func (t *Tailer) journalPath() string {
-	if t.source.Config.Path != "" {
-		return t.source.Config.Path
+	return journalPath(t.source)
+}
+
+// sourceIdentifier returns the identifier of the tailer of a source
+func sourceIdentifier(source *config.LogSource) string {
+	identifier := journaldIntegration + ":" + journalPath(source)
+	if source.Config.ConfigID != "" {
+		identifier += ":" + source.Config.ConfigID
+	}
+	return identifier
+}
+
+// journalPath returns the path of the journal tailed by a source
+func journalPath(source *config.LogSource) string {
+	if source.Config.JournalNamespace != "" {
+		return "namespace/" + source.Config.JournalNamespace
+	}
+	if source.Config.Path != "" {
+		return source.Config.Path
 	}
 	return "default"
 }
@@ -32,6 +32,15 @@ func TestIdentifier(t *testing.T) {
 	source = config.NewLogSource("", &config.LogsConfig{Path: "any_path"})
 	tailer = NewTailer(source, nil)
 	assert.Equal(t, "journald:any_path", tailer.Identifier())
+
+	// expect the config id to tell apart the sources of the same journal
+	source = config.NewLogSource("", &config.LogsConfig{Path: "any_path", ConfigID: "web"})
+	tailer = NewTailer(source, nil)
+	assert.Equal(t, "journald:any_path:web", tailer.Identifier())
+
+	source = config.NewLogSource("", &config.LogsConfig{JournalNamespace: "foo"})
+	tailer = NewTailer(source, nil)
+	assert.Equal(t, "journald:namespace/foo", tailer.Identifier())
 }
 
 func TestShouldDropEntry(t *testing.T) {
@@ -218,3 +227,19 @@ func TestWrongTypeFromCache(t *testing.T) {
 	_, hit := cache.Cache.Get(getImageCacheKey(containerID))
 	assert.True(t, hit)
 }
+
+func TestNoProcessTagsWithoutPID(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	tailer := NewTailer(source, nil)
+
+	assert.Nil(t, tailer.getTags(&sdjournal.JournalEntry{
+		Fields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_COMM: "foo.sh",
+		},
+	}))
+	assert.Nil(t, tailer.getTags(&sdjournal.JournalEntry{
+		Fields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_PID: "foo",
+		},
+	}))
+}
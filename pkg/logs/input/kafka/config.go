@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+const clientID = "datadog-agent"
+
+// defaultKafkaVersion is the oldest version supporting the consumer groups
+var defaultKafkaVersion = sarama.V0_10_2_0
+
+// newSaramaConfig returns the configuration of the consumer group of a source
+func newSaramaConfig(c *config.LogsConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = clientID
+	saramaConfig.Version = defaultKafkaVersion
+	if c.KafkaVersion != "" {
+		version, err := sarama.ParseKafkaVersion(c.KafkaVersion)
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Version = version
+	}
+
+	// the offsets are committed in the consumer group, the start position only
+	// applies when the group has not committed any offset for a partition yet
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if mode, _ := config.TailingModeFromString(c.TailingMode); mode == config.Beginning || mode == config.ForceBeginning {
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+	saramaConfig.Consumer.Return.Errors = true
+
+	if c.UseTLS {
+		tlsConfig, err := newTLSConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	if c.SASLMechanism != "" {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(c.SASLMechanism)
+		saramaConfig.Net.SASL.User = c.SASLUsername
+		saramaConfig.Net.SASL.Password = c.SASLPassword
+	}
+
+	return saramaConfig, saramaConfig.Validate()
+}
+
+// newTLSConfig returns the TLS configuration used to connect to the brokers
+func newTLSConfig(c *config.LogsConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+	}
+	if c.TLSCACert != "" {
+		caCert, err := ioutil.ReadFile(c.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in %s", c.TLSCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCert != "" || c.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
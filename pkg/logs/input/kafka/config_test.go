@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestNewSaramaConfig(t *testing.T) {
+	saramaConfig, err := newSaramaConfig(&config.LogsConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultKafkaVersion, saramaConfig.Version)
+	assert.Equal(t, sarama.OffsetNewest, saramaConfig.Consumer.Offsets.Initial)
+	assert.False(t, saramaConfig.Net.TLS.Enable)
+	assert.False(t, saramaConfig.Net.SASL.Enable)
+
+	saramaConfig, err = newSaramaConfig(&config.LogsConfig{
+		KafkaVersion:  "2.4.0",
+		TailingMode:   "beginning",
+		UseTLS:        true,
+		TLSSkipVerify: true,
+		SASLMechanism: "PLAIN",
+		SASLUsername:  "agent",
+		SASLPassword:  "secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sarama.V2_4_0_0, saramaConfig.Version)
+	assert.Equal(t, sarama.OffsetOldest, saramaConfig.Consumer.Offsets.Initial)
+	assert.True(t, saramaConfig.Net.TLS.Enable)
+	assert.True(t, saramaConfig.Net.TLS.Config.InsecureSkipVerify)
+	assert.True(t, saramaConfig.Net.SASL.Enable)
+	assert.Equal(t, "agent", saramaConfig.Net.SASL.User)
+
+	_, err = newSaramaConfig(&config.LogsConfig{KafkaVersion: "invalid"})
+	assert.Error(t, err)
+	_, err = newSaramaConfig(&config.LogsConfig{UseTLS: true, TLSCACert: "/does/not/exist"})
+	assert.Error(t, err)
+}
+
+func TestToMessage(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.KafkaType})
+	consumer := NewConsumer(source, nil)
+	msg := consumer.toMessage(&sarama.ConsumerMessage{Topic: "app", Partition: 3, Offset: 42, Value: []byte("hello")})
+	assert.Equal(t, "hello", string(msg.Content))
+	assert.Equal(t, "42", msg.Origin.Offset)
+	assert.Equal(t, "", msg.Origin.Identifier)
+	assert.Equal(t, []string{"kafka_topic:app", "kafka_partition:3"}, msg.Origin.Tags())
+}
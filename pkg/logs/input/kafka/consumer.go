@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	backoffInitialDuration = 1 * time.Second
+	backoffMaxDuration     = 60 * time.Second
+)
+
+// Consumer consumes the records of the topics of a source as a member of its
+// consumer group, and forwards them to the pipeline. The offset of a record is
+// committed in the consumer group once the record is forwarded.
+type Consumer struct {
+	source     *config.LogSource
+	outputChan chan *message.Message
+	group      sarama.ConsumerGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// NewConsumer returns a new Consumer
+func NewConsumer(source *config.LogSource, outputChan chan *message.Message) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Consumer{
+		source:     source,
+		outputChan: outputChan,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start joins the consumer group and starts consuming the topics
+func (c *Consumer) Start() error {
+	saramaConfig, err := newSaramaConfig(c.source.Config)
+	if err != nil {
+		c.source.Status.Error(err)
+		return err
+	}
+	c.group, err = sarama.NewConsumerGroup(c.source.Config.Brokers, c.source.Config.ConsumerGroup, saramaConfig)
+	if err != nil {
+		c.source.Status.Error(err)
+		return err
+	}
+	log.Infof("Start consuming the kafka topics %v with the consumer group %s", c.source.Config.Topics, c.source.Config.ConsumerGroup)
+	go c.logErrors()
+	go c.run()
+	return nil
+}
+
+// Stop leaves the consumer group, the offsets of the forwarded records are committed
+func (c *Consumer) Stop() {
+	log.Infof("Stop consuming the kafka topics %v", c.source.Config.Topics)
+	c.cancel()
+	<-c.done
+	if err := c.group.Close(); err != nil {
+		log.Warnf("Could not leave the kafka consumer group %s: %v", c.source.Config.ConsumerGroup, err)
+	}
+}
+
+// run consumes the topics, the session ends on every rebalance of the group
+// and a new one must be started.
+func (c *Consumer) run() {
+	defer close(c.done)
+	backoff := backoffInitialDuration
+	for {
+		err := c.group.Consume(c.ctx, c.source.Config.Topics, c)
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			backoff = backoffInitialDuration
+			continue
+		}
+
+		log.Warnf("Could not consume the kafka topics %v, retrying in %s: %v", c.source.Config.Topics, backoff, err)
+		c.source.Status.Error(err)
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < backoffMaxDuration {
+			backoff *= 2
+		}
+	}
+}
+
+// logErrors reports the errors of the consumer group until it is closed
+func (c *Consumer) logErrors() {
+	for err := range c.group.Errors() {
+		log.Warnf("Error while consuming the kafka topics %v: %v", c.source.Config.Topics, err)
+	}
+}
+
+// Setup is called at the beginning of a session, once the partitions are claimed
+func (c *Consumer) Setup(session sarama.ConsumerGroupSession) error {
+	c.source.Status.Success()
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			c.source.AddInput(inputName(topic, partition))
+		}
+	}
+	return nil
+}
+
+// Cleanup is called at the end of a session, once the claims are consumed
+func (c *Consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			c.source.RemoveInput(inputName(topic, partition))
+		}
+	}
+	return nil
+}
+
+// ConsumeClaim forwards the records of a partition until the session ends
+func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for record := range claim.Messages() {
+		if len(record.Value) == 0 {
+			session.MarkMessage(record, "")
+			continue
+		}
+		select {
+		case c.outputChan <- c.toMessage(record):
+			session.MarkMessage(record, "")
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// toMessage transforms a record into a message tagged with its topic and partition
+func (c *Consumer) toMessage(record *sarama.ConsumerMessage) *message.Message {
+	origin := message.NewOrigin(c.source)
+	// the offsets are committed in the consumer group,
+	// they don't need to be tracked by the auditor
+	origin.Offset = strconv.FormatInt(record.Offset, 10)
+	origin.SetTags([]string{
+		"kafka_topic:" + record.Topic,
+		"kafka_partition:" + strconv.FormatInt(int64(record.Partition), 10),
+	})
+	return message.NewMessage(record.Value, origin, message.StatusInfo)
+}
+
+func inputName(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
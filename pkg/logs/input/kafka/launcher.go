@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kafka
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Launcher starts and stops a consumer for each kafka source
+type Launcher struct {
+	addedSources     chan *config.LogSource
+	removedSources   chan *config.LogSource
+	pipelineProvider pipeline.Provider
+	consumers        map[*config.LogSource]*Consumer
+	stop             chan struct{}
+}
+
+// NewLauncher returns a new Launcher
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		addedSources:     sources.GetAddedForType(config.KafkaType),
+		removedSources:   sources.GetRemovedForType(config.KafkaType),
+		pipelineProvider: pipelineProvider,
+		consumers:        make(map[*config.LogSource]*Consumer),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// Stop stops the launcher and all its consumers
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for source, consumer := range l.consumers {
+		stopper.Add(consumer)
+		delete(l.consumers, source)
+	}
+	stopper.Stop()
+}
+
+// run starts and stops the consumers of the sources
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.addedSources:
+			if _, exists := l.consumers[source]; exists {
+				continue
+			}
+			consumer := NewConsumer(source, l.pipelineProvider.NextPipelineChan())
+			if err := consumer.Start(); err != nil {
+				log.Warnf("Could not consume the kafka topics %v: %v", source.Config.Topics, err)
+				continue
+			}
+			l.consumers[source] = consumer
+		case source := <-l.removedSources:
+			if consumer, exists := l.consumers[source]; exists {
+				delete(l.consumers, source)
+				go consumer.Stop()
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The default source and service of the audit events,
+// they are still overridden by the integration config when defined
+const (
+	defaultSource  = "kubernetes.audit"
+	defaultService = "kube-apiserver-audit"
+)
+
+// eventList is a batch of audit events posted by the API server,
+// see https://kubernetes.io/docs/tasks/debug-application-cluster/audit/#webhook-backend
+type eventList struct {
+	Kind  string            `json:"kind"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// event holds the attributes of an audit event used to summarize it.
+type event struct {
+	Stage          string    `json:"stage"`
+	RequestURI     string    `json:"requestURI"`
+	Verb           string    `json:"verb"`
+	StageTimestamp time.Time `json:"stageTimestamp"`
+	User           struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Subresource string `json:"subresource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+}
+
+// toMessages transforms a batch of audit events into messages, the attributes of
+// the events are kept as is along with a summary in a "message" attribute, ex:
+// * event:
+//  {"verb":"delete","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default","name":"web"},"responseStatus":{"code":403},...}
+// * message-content:
+//  {
+//    "message": "alice delete pods default/web: 403",
+//    "verb": "delete",
+//    "user": {"username": "alice"},
+//    ...
+//  }
+// The events that can't be decoded are skipped and counted, so that they don't
+// hold back the rest of the batch.
+func toMessages(body []byte, source *config.LogSource) ([]*message.Message, error) {
+	var list eventList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	if list.Kind != "EventList" {
+		return nil, fmt.Errorf("unexpected kind %q, expected EventList", list.Kind)
+	}
+	messages := make([]*message.Message, 0, len(list.Items))
+	for _, item := range list.Items {
+		msg, err := toMessage(item, source)
+		if err != nil {
+			log.Debugf("Skipping invalid Kubernetes audit event: %v", err)
+			metrics.InvalidKubeAuditEvents.Add(1)
+			metrics.TlmInvalidKubeAuditEvents.Inc()
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// toMessage transforms an audit event into a message.
+func toMessage(item json.RawMessage, source *config.LogSource) (*message.Message, error) {
+	var e event
+	if err := json.Unmarshal(item, &e); err != nil {
+		return nil, err
+	}
+	var attributes map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(item))
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
+		return nil, err
+	}
+	attributes["message"] = e.summary()
+	content, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := message.NewOrigin(source)
+	origin.SetSource(defaultSource)
+	origin.SetService(defaultService)
+	msg := message.NewMessage(content, origin, e.status())
+	if !e.StageTimestamp.IsZero() {
+		msg.SetTimestamp(e.StageTimestamp)
+	}
+	return msg, nil
+}
+
+// summary returns who did what on which object and the outcome, ex:
+//  system:serviceaccount:kube-system:deployment-controller update deployments/status default/web: 200
+func (e *event) summary() string {
+	parts := []string{e.User.Username, e.Verb}
+	if e.ObjectRef != nil && e.ObjectRef.Resource != "" {
+		resource := e.ObjectRef.Resource
+		if e.ObjectRef.Subresource != "" {
+			resource += "/" + e.ObjectRef.Subresource
+		}
+		parts = append(parts, resource)
+		if object := strings.Trim(e.ObjectRef.Namespace+"/"+e.ObjectRef.Name, "/"); object != "" {
+			parts = append(parts, object)
+		}
+	} else if e.RequestURI != "" {
+		parts = append(parts, e.RequestURI)
+	}
+	summary := strings.Join(parts, " ")
+	if e.ResponseStatus != nil {
+		summary = fmt.Sprintf("%s: %d", summary, e.ResponseStatus.Code)
+	}
+	return summary
+}
+
+// status maps the response code of the request to a status, the requests rejected
+// by the API server are warnings and its failures are errors.
+func (e *event) status() string {
+	if e.ResponseStatus == nil {
+		return message.StatusInfo
+	}
+	switch {
+	case e.ResponseStatus.Code >= 500:
+		return message.StatusError
+	case e.ResponseStatus.Code >= 400:
+		return message.StatusWarning
+	default:
+		return message.StatusInfo
+	}
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const testEventList = `{
+  "kind": "EventList",
+  "apiVersion": "audit.k8s.io/v1",
+  "items": [
+    {
+      "level": "Metadata",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/default/pods/web",
+      "verb": "delete",
+      "user": {"username": "alice", "groups": ["system:authenticated"]},
+      "objectRef": {"resource": "pods", "namespace": "default", "name": "web", "apiVersion": "v1"},
+      "responseStatus": {"metadata": {}, "code": 403},
+      "stageTimestamp": "2020-06-01T10:00:00.123456Z"
+    },
+    {
+      "stage": "ResponseComplete",
+      "requestURI": "/healthz",
+      "verb": "get",
+      "user": {"username": "system:anonymous"},
+      "responseStatus": {"metadata": {}, "code": 500}
+    },
+    {
+      "stage": "RequestReceived",
+      "verb": "list",
+      "user": {"username": "bob"},
+      "objectRef": {"resource": "nodes"}
+    }
+  ]
+}`
+
+func TestToMessages(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	messages, err := toMessages([]byte(testEventList), source)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 3)
+
+	assert.Equal(t, `{"level":"Metadata","message":"alice delete pods default/web: 403","objectRef":{"apiVersion":"v1","name":"web","namespace":"default","resource":"pods"},"requestURI":"/api/v1/namespaces/default/pods/web","responseStatus":{"code":403,"metadata":{}},"stage":"ResponseComplete","stageTimestamp":"2020-06-01T10:00:00.123456Z","user":{"groups":["system:authenticated"],"username":"alice"},"verb":"delete"}`, string(messages[0].Content))
+	assert.Equal(t, message.StatusWarning, messages[0].GetStatus())
+	assert.Equal(t, time.Date(2020, 6, 1, 10, 0, 0, 123456000, time.UTC), messages[0].GetTimestamp())
+	assert.Equal(t, defaultSource, messages[0].Origin.Source())
+	assert.Equal(t, defaultService, messages[0].Origin.Service())
+
+	assert.Contains(t, string(messages[1].Content), `"message":"system:anonymous get /healthz: 500"`)
+	assert.Equal(t, message.StatusError, messages[1].GetStatus())
+
+	assert.Contains(t, string(messages[2].Content), `"message":"bob list nodes"`)
+	assert.Equal(t, message.StatusInfo, messages[2].GetStatus())
+}
+
+func TestToMessagesWithIntegrationConfig(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Source: "audit", Service: "apiserver"})
+	messages, err := toMessages([]byte(testEventList), source)
+	assert.NoError(t, err)
+	assert.Equal(t, "audit", messages[0].Origin.Source())
+	assert.Equal(t, "apiserver", messages[0].Origin.Service())
+}
+
+func TestToMessagesWithInvalidBatch(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	for _, body := range []string{"", "{", `{"kind":"Event"}`} {
+		_, err := toMessages([]byte(body), source)
+		assert.Error(t, err, body)
+	}
+
+	// the invalid events are skipped
+	messages, err := toMessages([]byte(`{"kind":"EventList","items":[1,{"verb":"get"}]}`), source)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Launcher starts a webhook server for each Kubernetes audit source
+type Launcher struct {
+	pipelineProvider pipeline.Provider
+	sources          chan *config.LogSource
+	servers          []*Server
+	stop             chan struct{}
+}
+
+// NewLauncher returns an initialized Launcher
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		pipelineProvider: pipelineProvider,
+		sources:          sources.GetAddedForType(config.KubeAuditType),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// run starts new webhook servers.
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.sources:
+			s := NewServer(source, l.pipelineProvider.NextPipelineChan())
+			if err := s.Start(); err != nil {
+				log.Errorf("Can't start Kubernetes audit webhook on port %d: %v", source.Config.Port, err)
+				source.Status.Error(err)
+				continue
+			}
+			source.Status.Success()
+			l.servers = append(l.servers, s)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop stops all servers
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for _, s := range l.servers {
+		stopper.Add(s)
+	}
+	stopper.Stop()
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxRequestBodySize is the maximum size of a batch of audit events,
+// the API server splits the larger batches
+const maxRequestBodySize = 10 * 1024 * 1024
+
+// shutdownTimeout is the time left to the pending requests to complete when the server stops
+const shutdownTimeout = 5 * time.Second
+
+// Server implements the Kubernetes audit webhook API: it receives the batches of audit events
+// posted by the API server over HTTPS, and forwards each event to the pipeline.
+type Server struct {
+	source     *config.LogSource
+	outputChan chan *message.Message
+	listener   net.Listener
+	server     *http.Server
+	done       chan struct{}
+}
+
+// NewServer returns a new Server
+func NewServer(source *config.LogSource, outputChan chan *message.Message) *Server {
+	return &Server{
+		source:     source,
+		outputChan: outputChan,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts accepting the audit events
+func (s *Server) Start() error {
+	tlsConfig, err := newTLSConfig(s.source.Config)
+	if err != nil {
+		return err
+	}
+	s.listener, err = tls.Listen("tcp", fmt.Sprintf(":%d", s.source.Config.Port), tlsConfig)
+	if err != nil {
+		return err
+	}
+	log.Infof("Starting Kubernetes audit webhook on port %d", s.source.Config.Port)
+	s.server = &http.Server{
+		Handler:      s,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+			log.Warnf("Kubernetes audit webhook on port %d stopped: %v", s.source.Config.Port, err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops the server once the pending requests are complete
+func (s *Server) Stop() {
+	log.Infof("Stopping Kubernetes audit webhook on port %d", s.source.Config.Port)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+	}
+	<-s.done
+}
+
+// ServeHTTP forwards the events of a batch posted by the API server,
+// the API server retries the batches that are not acknowledged.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(req) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Datadog Agent"`)
+		http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxRequestBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	messages, err := toMessages(body, s.source)
+	if err != nil {
+		log.Warnf("Could not decode the Kubernetes audit events received on port %d: %v", s.source.Config.Port, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.source.AddInput(req.RemoteAddr)
+	defer s.source.RemoveInput(req.RemoteAddr)
+	for _, msg := range messages {
+		s.outputChan <- msg
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorized returns true if the request holds the bearer token of the source,
+// the requests are authenticated by their certificate when it is not set.
+func (s *Server) authorized(req *http.Request) bool {
+	if s.source.Config.BearerToken == "" {
+		return true
+	}
+	expected := []byte("Bearer " + s.source.Config.BearerToken)
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), expected) == 1
+}
+
+// newTLSConfig returns the TLS configuration of the server, the certificates
+// of the API servers are verified with the CA certificate when it is set.
+func newTLSConfig(c *config.LogsConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.TLSCACert != "" {
+		caCert, err := ioutil.ReadFile(c.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in %s", c.TLSCACert)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+func TestServerForwardsTheEvents(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	server := NewServer(config.NewLogSource("", &config.LogsConfig{Type: config.KubeAuditType}), outputChan)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(testEventList)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, outputChan, 3)
+}
+
+func TestServerRejectsTheInvalidRequests(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	server := NewServer(config.NewLogSource("", &config.LogsConfig{Type: config.KubeAuditType}), outputChan)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(`{"kind":"List","items":[]}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Len(t, outputChan, 0)
+}
+
+func TestServerSkipsTheInvalidEvents(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	server := NewServer(config.NewLogSource("", &config.LogsConfig{Type: config.KubeAuditType}), outputChan)
+	invalid := metrics.InvalidKubeAuditEvents.Value()
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(`{"kind":"EventList","items":["foo",{"verb":"get"}]}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, outputChan, 1)
+	assert.Equal(t, invalid+1, metrics.InvalidKubeAuditEvents.Value())
+}
+
+func TestServerRequiresTheBearerToken(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	server := NewServer(config.NewLogSource("", &config.LogsConfig{Type: config.KubeAuditType, BearerToken: "token"}), outputChan)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(testEventList)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(testEventList))
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, outputChan, 3)
+}
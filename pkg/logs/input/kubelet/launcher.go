@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Launcher starts and stops a tailer for each source streaming the logs of a
+// container from the kubelet API. The sources are created by the kubernetes
+// launcher when the log files of the pods are not available.
+type Launcher struct {
+	addedSources     chan *config.LogSource
+	removedSources   chan *config.LogSource
+	pipelineProvider pipeline.Provider
+	registry         auditor.Registry
+	tailers          map[*config.LogSource]*Tailer
+	stop             chan struct{}
+}
+
+// NewLauncher returns a new Launcher
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider, registry auditor.Registry) *Launcher {
+	return &Launcher{
+		addedSources:     sources.GetAddedForType(config.KubeletType),
+		removedSources:   sources.GetRemovedForType(config.KubeletType),
+		pipelineProvider: pipelineProvider,
+		registry:         registry,
+		tailers:          make(map[*config.LogSource]*Tailer),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// Stop stops the launcher and all its tailers
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for source, tailer := range l.tailers {
+		stopper.Add(tailer)
+		delete(l.tailers, source)
+	}
+	stopper.Stop()
+}
+
+// run starts and stops the tailers of the sources
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.addedSources:
+			l.startTailer(source)
+		case source := <-l.removedSources:
+			if tailer, exists := l.tailers[source]; exists {
+				delete(l.tailers, source)
+				go tailer.Stop()
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *Launcher) startTailer(source *config.LogSource) {
+	if _, exists := l.tailers[source]; exists {
+		return
+	}
+	kubeutil, err := kubelet.GetKubeUtil()
+	if err != nil {
+		log.Warnf("Could not use the kubelet client, logs of %s won't be collected: %v", source.Name, err)
+		source.Status.Error(err)
+		return
+	}
+
+	tailer := NewTailer(kubeutil, source, l.pipelineProvider.NextPipelineChan())
+	tailer.Start(since(l.registry.GetOffset(tailer.Identifier()), source.Config.TailingMode))
+	l.tailers[source] = tailer
+}
+
+// since returns the time from which the logs of a container are collected:
+// after the last collected line, or according to the tailing mode of the
+// source for the containers seen for the first time
+func since(offset string, tailingMode string) time.Time {
+	if offset != "" {
+		if last, err := time.Parse(time.RFC3339Nano, offset); err == nil {
+			return last
+		}
+	}
+	if mode, _ := config.TailingModeFromString(tailingMode); mode == config.Beginning || mode == config.ForceBeginning {
+		return time.Time{}
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubelet
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	lineParser "github.com/DataDog/datadog-agent/pkg/logs/parser"
+)
+
+var (
+	// log line timestamp/content delimiter
+	delimiter = []byte{' '}
+)
+
+// Parser parses the log lines streamed by the kubelet
+var Parser *parser
+
+type parser struct {
+	lineParser.Parser
+}
+
+// Parse parses a log line streamed by the kubelet containerLogs endpoint
+// with timestamps, which follows the pattern '<timestamp> <content>'.
+// The stream doesn't tell stdout from stderr apart, all the lines have the
+// status INFO.
+// Example:
+// 2018-09-20T11:54:11.753589172Z This is my message
+func (p *parser) Parse(msg []byte) ([]byte, string, string, error) {
+	components := bytes.SplitN(msg, delimiter, 2)
+	if len(components) < 2 {
+		if len(components[0]) > 0 && components[0][len(components[0])-1] == 'Z' {
+			// an empty line
+			return []byte{}, message.StatusInfo, string(components[0]), nil
+		}
+		return msg, message.StatusInfo, "", errors.New("cannot parse the log line")
+	}
+	return components[1], message.StatusInfo, string(components[0]), nil
+}
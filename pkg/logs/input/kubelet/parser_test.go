@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package kubelet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestParserParse(t *testing.T) {
+	content, status, timestamp, err := Parser.Parse([]byte("2018-09-20T11:54:11.753589172Z foo bar"))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusInfo, status)
+	assert.Equal(t, "2018-09-20T11:54:11.753589172Z", timestamp)
+	assert.Equal(t, []byte("foo bar"), content)
+
+	content, _, timestamp, err = Parser.Parse([]byte("2018-09-20T11:54:11.753589172Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2018-09-20T11:54:11.753589172Z", timestamp)
+	assert.Equal(t, []byte{}, content)
+
+	content, _, _, err = Parser.Parse([]byte("foo"))
+	assert.NotNil(t, err)
+	assert.Equal(t, []byte("foo"), content)
+}
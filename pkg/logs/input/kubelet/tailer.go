@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/tag"
+)
+
+const (
+	backoffInitialDuration = 1 * time.Second
+	backoffMaxDuration     = 60 * time.Second
+)
+
+type logStreamer interface {
+	StreamContainerLogs(ctx context.Context, namespace, podName, containerName string, since time.Time) (io.ReadCloser, error)
+}
+
+// Tailer streams the logs of a container from the kubelet API.
+// The stream is opened again from the last collected line when it ends,
+// which happens when the container restarts or the kubelet closes it.
+type Tailer struct {
+	source      *config.LogSource
+	streamer    logStreamer
+	outputChan  chan *message.Message
+	decoder     *decoder.Decoder
+	tagProvider tag.Provider
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex sync.Mutex
+	// last is the timestamp of the last collected line, the lines of the
+	// second it belongs to are streamed again when the stream is reopened
+	last time.Time
+}
+
+// NewTailer returns a new Tailer
+func NewTailer(streamer logStreamer, source *config.LogSource, outputChan chan *message.Message) *Tailer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tailer{
+		source:      source,
+		streamer:    streamer,
+		outputChan:  outputChan,
+		decoder:     decoder.InitializeDecoder(source, Parser),
+		tagProvider: tag.NewProvider(source.Config.Identifier),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+// Identifier returns a string that uniquely identifies a source
+func (t *Tailer) Identifier() string {
+	return fmt.Sprintf("kubelet:%s", t.source.Config.Identifier)
+}
+
+// Start starts streaming the logs from since
+func (t *Tailer) Start(since time.Time) {
+	log.Infof("Start streaming the logs of %s from the kubelet", t.source.Name)
+	t.setLast(since)
+	t.decoder.Start()
+	go t.forwardMessages()
+	go t.readForever()
+}
+
+// Stop stops the tailer, this call blocks until the decoder is completely flushed
+func (t *Tailer) Stop() {
+	log.Infof("Stop streaming the logs of %s from the kubelet", t.source.Name)
+	t.cancel()
+	<-t.done
+}
+
+// readForever opens the log stream and feeds the decoder until the tailer
+// is stopped, reopening the stream when it ends
+func (t *Tailer) readForever() {
+	defer t.decoder.Stop()
+	backoff := backoffInitialDuration
+	for {
+		err := t.readStream()
+		if t.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Debugf("Could not stream the logs of %s, retrying in %s: %v", t.source.Name, backoff, err)
+			t.source.Status.Error(err)
+		} else {
+			// the stream ended normally, the container may have restarted
+			backoff = backoffInitialDuration
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if err != nil && backoff < backoffMaxDuration {
+			backoff *= 2
+		}
+	}
+}
+
+// readStream reads the log stream until it ends
+func (t *Tailer) readStream() error {
+	stream, err := t.streamer.StreamContainerLogs(t.ctx, t.source.Config.PodNamespace, t.source.Config.PodName, t.source.Config.ContainerName, t.getLast())
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	t.source.Status.Success()
+	t.source.AddInput(t.source.Config.Identifier)
+	defer t.source.RemoveInput(t.source.Config.Identifier)
+
+	for {
+		inBuf := make([]byte, 4096)
+		n, err := stream.Read(inBuf)
+		if n > 0 {
+			t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// forwardMessages forwards decoded messages to the next pipeline, skipping
+// the lines streamed again after a reconnection
+func (t *Tailer) forwardMessages() {
+	defer close(t.done)
+	for output := range t.decoder.OutputChan {
+		if len(output.Content) == 0 {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, output.Timestamp)
+		if err == nil {
+			if !timestamp.After(t.getLast()) {
+				continue
+			}
+			t.setLast(timestamp)
+		}
+		origin := message.NewOrigin(t.source)
+		origin.Offset = output.Timestamp
+		origin.Identifier = t.Identifier()
+		origin.SetTags(t.tagProvider.GetTags())
+		t.outputChan <- message.NewMessage(output.Content, origin, output.Status)
+	}
+}
+
+func (t *Tailer) getLast() time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.last
+}
+
+func (t *Tailer) setLast(last time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.last = last
+}
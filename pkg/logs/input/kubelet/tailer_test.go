@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package kubelet
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// fakeStreamer returns its streams in order, then blocks until the context
+// is cancelled
+type fakeStreamer struct {
+	sync.Mutex
+	streams []string
+	since   []time.Time
+}
+
+func (f *fakeStreamer) StreamContainerLogs(ctx context.Context, namespace, podName, containerName string, since time.Time) (io.ReadCloser, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.since = append(f.since, since)
+	if len(f.streams) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	stream := f.streams[0]
+	f.streams = f.streams[1:]
+	return ioutil.NopCloser(strings.NewReader(stream)), nil
+}
+
+func TestTailerReconnects(t *testing.T) {
+	streamer := &fakeStreamer{streams: []string{
+		"2020-05-04T10:00:00.1Z first\n2020-05-04T10:00:00.2Z second\n",
+		// the second stream starts at the beginning of the second of the last line
+		"2020-05-04T10:00:00.1Z first\n2020-05-04T10:00:00.2Z second\n2020-05-04T10:00:01Z third\n",
+	}}
+	source := config.NewLogSource("default/nginx/nginx", &config.LogsConfig{
+		Type:          config.KubeletType,
+		PodNamespace:  "default",
+		PodName:       "nginx",
+		ContainerName: "nginx",
+		Identifier:    "container_id://abc",
+	})
+	outputChan := make(chan *message.Message, 10)
+	tailer := NewTailer(streamer, source, outputChan)
+	tailer.Start(time.Time{})
+
+	var messages []string
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-outputChan:
+			messages = append(messages, string(msg.Content))
+			assert.Equal(t, "kubelet:container_id://abc", msg.Origin.Identifier)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for the logs", "got %v", messages)
+		}
+	}
+	tailer.Stop()
+
+	assert.Equal(t, []string{"first", "second", "third"}, messages)
+	assert.True(t, streamer.since[0].IsZero())
+	assert.Equal(t, "2020-05-04T10:00:00.2Z", streamer.since[1].Format(time.RFC3339Nano))
+	assert.Len(t, outputChan, 0)
+}
+
+func TestSince(t *testing.T) {
+	assert.Equal(t, "2020-05-04T10:00:00.2Z", since("2020-05-04T10:00:00.2Z", "end").Format(time.RFC3339Nano))
+	assert.True(t, since("", "beginning").IsZero())
+	assert.WithinDuration(t, time.Now(), since("", "end"), time.Minute)
+	assert.WithinDuration(t, time.Now(), since("", ""), time.Minute)
+}
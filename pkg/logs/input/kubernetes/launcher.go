@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -29,6 +30,8 @@ const (
 var errCollectAllDisabled = fmt.Errorf("%s disabled", config.ContainerCollectAll)
 
 // Launcher looks for new and deleted pods to create or delete one logs-source per container.
+// The sources tail the log files of the containers in /var/log/pods, or stream
+// their logs from the kubelet API when the files are not available.
 type Launcher struct {
 	sources            *config.LogSources
 	sourcesByContainer map[string]*config.LogSource
@@ -37,12 +40,18 @@ type Launcher struct {
 	addedServices      chan *service.Service
 	removedServices    chan *service.Service
 	collectAll         bool
+	useKubeletAPI      bool
 }
 
 // NewLauncher returns a new launcher.
 func NewLauncher(sources *config.LogSources, services *service.Services, collectAll bool) (*Launcher, error) {
+	useKubeletAPI := false
 	if !isIntegrationAvailable() {
-		return nil, fmt.Errorf("%s not found", basePath)
+		if !coreConfig.Datadog.GetBool("logs_config.k8s_container_use_kubelet_api") {
+			return nil, fmt.Errorf("%s not found", basePath)
+		}
+		log.Infof("%s not found, the container logs will be streamed from the kubelet API", basePath)
+		useKubeletAPI = true
 	}
 	kubeutil, err := kubelet.GetKubeUtil()
 	if err != nil {
@@ -54,6 +63,7 @@ func NewLauncher(sources *config.LogSources, services *service.Services, collect
 		stopped:            make(chan struct{}),
 		kubeutil:           kubeutil,
 		collectAll:         collectAll,
+		useKubeletAPI:      useKubeletAPI,
 	}
 	launcher.addedServices = services.GetAllAddedServices()
 	launcher.removedServices = services.GetAllRemovedServices()
@@ -116,7 +126,7 @@ func (l *Launcher) addSource(svc *service.Service) {
 		log.Warn(err)
 		return
 	}
-	source, err := l.getSource(pod, container)
+	source, err := l.getSource(pod, container, svc.CreationTime)
 	if err != nil {
 		if err != errCollectAllDisabled {
 			log.Warnf("Invalid configuration for pod %v, container %v: %v", pod.Metadata.Name, container.Name, err)
@@ -148,7 +158,7 @@ func (l *Launcher) removeSource(service *service.Service) {
 const kubernetesIntegration = "kubernetes"
 
 // getSource returns a new source for the container in pod.
-func (l *Launcher) getSource(pod *kubelet.Pod, container kubelet.ContainerStatus) (*config.LogSource, error) {
+func (l *Launcher) getSource(pod *kubelet.Pod, container kubelet.ContainerStatus, creationTime service.CreationTime) (*config.LogSource, error) {
 	var cfg *config.LogsConfig
 	if annotation := l.getAnnotation(pod, container); annotation != "" {
 		configs, err := config.ParseJSON([]byte(annotation))
@@ -173,8 +183,23 @@ func (l *Launcher) getSource(pod *kubelet.Pod, container kubelet.ContainerStatus
 			}
 		}
 	}
-	cfg.Type = config.FileType
-	cfg.Path = l.getPath(basePath, pod, container)
+	if l.useKubeletAPI {
+		cfg.Type = config.KubeletType
+		cfg.PodNamespace = pod.Metadata.Namespace
+		cfg.PodName = pod.Metadata.Name
+		cfg.ContainerName = container.Name
+		// like the files, unless start_position is set, the logs of the
+		// containers started after the agent are collected from the beginning
+		if cfg.TailingMode == "" {
+			cfg.TailingMode = config.End.String()
+			if creationTime == service.After {
+				cfg.TailingMode = config.Beginning.String()
+			}
+		}
+	} else {
+		cfg.Type = config.FileType
+		cfg.Path = l.getPath(basePath, pod, container)
+	}
 	cfg.Identifier = getTaggerEntityID(container.ID)
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid kubernetes annotation: %v", err)
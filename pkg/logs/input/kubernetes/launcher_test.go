@@ -17,6 +17,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/service"
 )
 
 func TestGetSource(t *testing.T) {
@@ -37,7 +38,7 @@ func TestGetSource(t *testing.T) {
 		},
 	}
 
-	source, err := launcher.getSource(pod, container)
+	source, err := launcher.getSource(pod, container, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, config.FileType, source.Config.Type)
 	assert.Equal(t, "buu/fuz/foo", source.Name)
@@ -47,6 +48,48 @@ func TestGetSource(t *testing.T) {
 	assert.Equal(t, "bar", source.Config.Service)
 }
 
+func TestGetSourceWithKubeletAPI(t *testing.T) {
+	launcher := &Launcher{collectAll: true, useKubeletAPI: true}
+	container := kubelet.ContainerStatus{
+		Name:  "foo",
+		Image: "bar",
+		ID:    "docker://boo",
+	}
+	pod := &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:      "fuz",
+			Namespace: "buu",
+			UID:       "baz",
+		},
+		Status: kubelet.Status{
+			Containers: []kubelet.ContainerStatus{container},
+		},
+	}
+
+	source, err := launcher.getSource(pod, container, service.Before)
+	assert.Nil(t, err)
+	assert.Equal(t, config.KubeletType, source.Config.Type)
+	assert.Equal(t, "buu/fuz/foo", source.Name)
+	assert.Equal(t, "", source.Config.Path)
+	assert.Equal(t, "buu", source.Config.PodNamespace)
+	assert.Equal(t, "fuz", source.Config.PodName)
+	assert.Equal(t, "foo", source.Config.ContainerName)
+	assert.Equal(t, "container_id://boo", source.Config.Identifier)
+	assert.Equal(t, "end", source.Config.TailingMode)
+
+	source, err = launcher.getSource(pod, container, service.After)
+	assert.Nil(t, err)
+	assert.Equal(t, "beginning", source.Config.TailingMode)
+
+	// the start_position of the annotation is respected
+	pod.Metadata.Annotations = map[string]string{
+		"ad.datadoghq.com/foo.logs": `[{"source":"any_source","service":"any_service","start_position":"end"}]`,
+	}
+	source, err = launcher.getSource(pod, container, service.After)
+	assert.Nil(t, err)
+	assert.Equal(t, "end", source.Config.TailingMode)
+}
+
 func TestGetSourceShouldBeOverridenByAutoDiscoveryAnnotation(t *testing.T) {
 	launcher := &Launcher{collectAll: true}
 	container := kubelet.ContainerStatus{
@@ -68,7 +111,7 @@ func TestGetSourceShouldBeOverridenByAutoDiscoveryAnnotation(t *testing.T) {
 		},
 	}
 
-	source, err := launcher.getSource(pod, container)
+	source, err := launcher.getSource(pod, container, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, config.FileType, source.Config.Type)
 	assert.Equal(t, "buu/fuz/foo", source.Name)
@@ -101,7 +144,7 @@ func TestGetSourceShouldFailWithInvalidAutoDiscoveryAnnotation(t *testing.T) {
 		},
 	}
 
-	source, err := launcher.getSource(pod, container)
+	source, err := launcher.getSource(pod, container, service.Before)
 	assert.NotNil(t, err)
 	assert.Nil(t, source)
 }
@@ -124,7 +167,7 @@ func TestGetSourceAddContainerdParser(t *testing.T) {
 		},
 	}
 
-	source, err := launcher.getSource(pod, container)
+	source, err := launcher.getSource(pod, container, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, config.FileType, source.Config.Type)
 }
@@ -181,21 +224,21 @@ func TestContainerCollectAll(t *testing.T) {
 		},
 	}
 
-	source, err := launcherCollectAll.getSource(podFoo, containerFoo)
+	source, err := launcherCollectAll.getSource(podFoo, containerFoo, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, "container_id://fooID", source.Config.Identifier)
-	source, err = launcherCollectAll.getSource(podBar, containerBar)
+	source, err = launcherCollectAll.getSource(podBar, containerBar, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, "container_id://barID", source.Config.Identifier)
 
-	source, err = launcherCollectAllDisabled.getSource(podFoo, containerFoo)
+	source, err = launcherCollectAllDisabled.getSource(podFoo, containerFoo, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, "container_id://fooID", source.Config.Identifier)
-	source, err = launcherCollectAllDisabled.getSource(podBar, containerBar)
+	source, err = launcherCollectAllDisabled.getSource(podBar, containerBar, service.Before)
 	assert.Equal(t, errCollectAllDisabled, err)
 	assert.Nil(t, source)
 
-	source, err = launcherCollectAll.getSource(podBaz, containerBaz)
+	source, err = launcherCollectAll.getSource(podBaz, containerBaz, service.Before)
 	assert.Nil(t, err)
 	assert.Equal(t, "container_id://bazID", source.Config.Identifier)
 }
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// maxOctetCountDigits is the number of digits of the largest supported frame length
+const maxOctetCountDigits = 9
+
+// frameReader splits the syslog messages of a TCP stream, see RFC6587:
+// - octet-counted frames start with the length of the message: `<length> <message>`
+// - non-transparent frames start with the priority of the message and end with a line feed
+// The framing is detected for every message, frames longer than frameSize are truncated.
+type frameReader struct {
+	reader    *bufio.Reader
+	frameSize int
+}
+
+func newFrameReader(r io.Reader, frameSize int) *frameReader {
+	return &frameReader{
+		reader:    bufio.NewReaderSize(r, frameSize),
+		frameSize: frameSize,
+	}
+}
+
+// next returns the next non-empty frame of the stream
+func (r *frameReader) next() ([]byte, error) {
+	for {
+		first, err := r.reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		var frame []byte
+		if first[0] >= '0' && first[0] <= '9' {
+			frame, err = r.nextOctetCounted()
+		} else {
+			frame, err = r.nextNonTransparent()
+		}
+		if len(frame) > 0 || err != nil {
+			return frame, err
+		}
+	}
+}
+
+// nextOctetCounted reads a frame prefixed by its length
+func (r *frameReader) nextOctetCounted() ([]byte, error) {
+	digits, err := r.reader.ReadSlice(' ')
+	if err != nil {
+		return nil, err
+	}
+	if len(digits) > maxOctetCountDigits+1 {
+		return nil, fmt.Errorf("invalid octet count %q", digits)
+	}
+	length, err := strconv.Atoi(string(digits[:len(digits)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid octet count %q", digits)
+	}
+
+	size := length
+	if size > r.frameSize {
+		size = r.frameSize
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, frame); err != nil {
+		return nil, err
+	}
+	if length > size {
+		// drop the trailing part of the frame
+		if _, err := io.CopyN(ioutil.Discard, r.reader, int64(length-size)); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.TrimRight(frame, "\r\n"), nil
+}
+
+// nextNonTransparent reads a frame ending with a line feed
+func (r *frameReader) nextNonTransparent() ([]byte, error) {
+	var frame []byte
+	for {
+		line, err := r.reader.ReadSlice('\n')
+		if len(frame)+len(line) <= r.frameSize {
+			frame = append(frame, line...)
+		} else if len(frame) < r.frameSize {
+			frame = append(frame, line[:r.frameSize-len(frame)]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(frame) > 0 {
+			// the last frame of the stream may not end with a line feed
+			return bytes.TrimRight(frame, "\r\n"), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(frame, "\r\n"), nil
+	}
+}
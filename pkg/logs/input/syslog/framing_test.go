@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameReader(t *testing.T) {
+	stream := "<13>first\n" +
+		"27 <13>second\nwith a new line" +
+		"\r\n" +
+		"<13>third\r\n" +
+		"44 <13>this frame is longer than the frame size" +
+		"<13>last"
+	reader := newFrameReader(strings.NewReader(stream), 32)
+
+	var frames []string
+	for {
+		frame, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		frames = append(frames, string(frame))
+	}
+	assert.Equal(t, []string{
+		"<13>first",
+		"<13>second\nwith a new line",
+		"<13>third",
+		"<13>this frame is longer than th",
+		"<13>last",
+	}, frames)
+}
+
+func TestFrameReaderInvalidOctetCount(t *testing.T) {
+	reader := newFrameReader(strings.NewReader("12345678901 <13>hello"), 32)
+	_, err := reader.next()
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// server receives syslog messages
+type server interface {
+	Start() error
+	Stop()
+}
+
+// Launcher starts a syslog server for each syslog source
+type Launcher struct {
+	pipelineProvider pipeline.Provider
+	frameSize        int
+	sources          chan *config.LogSource
+	servers          []server
+	stop             chan struct{}
+}
+
+// NewLauncher returns an initialized Launcher
+func NewLauncher(sources *config.LogSources, frameSize int, pipelineProvider pipeline.Provider) *Launcher {
+	return &Launcher{
+		pipelineProvider: pipelineProvider,
+		frameSize:        frameSize,
+		sources:          sources.GetAddedForType(config.SyslogType),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the launcher.
+func (l *Launcher) Start() {
+	go l.run()
+}
+
+// run starts new syslog servers.
+func (l *Launcher) run() {
+	for {
+		select {
+		case source := <-l.sources:
+			var s server
+			if source.Config.Protocol == "udp" {
+				s = NewUDPServer(source, l.pipelineProvider.NextPipelineChan(), l.frameSize)
+			} else {
+				s = NewTCPServer(source, l.pipelineProvider.NextPipelineChan(), l.frameSize)
+			}
+			if err := s.Start(); err != nil {
+				log.Errorf("Can't start syslog server on port %d: %v", source.Config.Port, err)
+				source.Status.Error(err)
+				continue
+			}
+			source.Status.Success()
+			l.servers = append(l.servers, s)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop stops all servers
+func (l *Launcher) Stop() {
+	l.stop <- struct{}{}
+	stopper := restart.NewParallelStopper()
+	for _, s := range l.servers {
+		stopper.Add(s)
+	}
+	stopper.Stop()
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// severityStatusMapping represents the 1:1 mapping between syslog severities and statuses.
+var severityStatusMapping = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// toMessage transforms a syslog frame into a message, the header fields are
+// bundled in a "syslog" attribute, ex:
+// * frame:
+//  <165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+// * message-content:
+//  {
+//    "message": "An application event",
+//    "syslog": {
+//      "facility": 20,
+//      "severity": 5,
+//      "hostname": "mymachine",
+//      "appname": "evntslog",
+//      ...
+//    }
+//  }
+// The frames which are not valid syslog messages are sent as is.
+func toMessage(frame []byte, source *config.LogSource) *message.Message {
+	msg, err := Parse(frame)
+	if err != nil {
+		log.Debugf("Could not parse syslog message %q: %v", frame, err)
+		return message.NewMessageWithSource(frame, message.StatusInfo, source)
+	}
+
+	attributes := map[string]interface{}{
+		"facility": msg.Facility,
+		"severity": msg.Severity,
+	}
+	if msg.Version != 0 {
+		attributes["version"] = msg.Version
+	}
+	for name, value := range map[string]string{
+		"timestamp": msg.Timestamp,
+		"hostname":  msg.Hostname,
+		"appname":   msg.AppName,
+		"procid":    msg.ProcID,
+		"msgid":     msg.MsgID,
+	} {
+		if value != "" {
+			attributes[name] = value
+		}
+	}
+	if len(msg.StructuredData) > 0 {
+		attributes["structured_data"] = msg.StructuredData
+	}
+	content, err := json.Marshal(map[string]interface{}{
+		"message": string(msg.Msg),
+		"syslog":  attributes,
+	})
+	if err != nil {
+		// ensure the message has some content if the json encoding failed
+		content = msg.Msg
+	}
+
+	origin := message.NewOrigin(source)
+	// set the service and the source attributes of the message,
+	// those values are still overridden by the integration config when defined
+	if msg.AppName != "" {
+		origin.SetSource(msg.AppName)
+		origin.SetService(msg.AppName)
+	}
+	return message.NewMessage(content, origin, severityStatusMapping[msg.Severity])
+}
@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// nilValue represents a missing field of a RFC5424 header
+const nilValue = "-"
+
+// maxPriority is the priority of the debug messages of the local7 facility
+const maxPriority = 191
+
+var errInvalidPriority = errors.New("invalid syslog priority")
+
+// Message is a syslog message, the header fields which are not defined
+// by the format of the message, or missing, are left empty.
+type Message struct {
+	Facility       int
+	Severity       int
+	Version        int
+	Timestamp      string
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Msg            []byte
+}
+
+// Parse parses a RFC5424 or a RFC3164 syslog message, the message is
+// recognized as a RFC5424 one when its priority is followed by a version.
+// Returns an error when the message does not start with a valid priority.
+func Parse(frame []byte) (*Message, error) {
+	priority, rest, err := parsePriority(frame)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{
+		Facility: priority / 8,
+		Severity: priority % 8,
+	}
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && (rest[1] == ' ' || (rest[1] >= '0' && rest[1] <= '9')) {
+		if version, afterVersion, ok := nextField(rest); ok {
+			if v, err := strconv.Atoi(version); err == nil {
+				msg.Version = v
+				parseRFC5424(msg, afterVersion)
+				return msg, nil
+			}
+		}
+	}
+	parseRFC3164(msg, rest)
+	return msg, nil
+}
+
+// parsePriority parses the `<PRI>` prefix of the message
+func parsePriority(frame []byte) (int, []byte, error) {
+	if len(frame) < 3 || frame[0] != '<' {
+		return 0, nil, errInvalidPriority
+	}
+	end := bytes.IndexByte(frame, '>')
+	if end < 2 || end > 4 {
+		return 0, nil, errInvalidPriority
+	}
+	priority, err := strconv.Atoi(string(frame[1:end]))
+	if err != nil || priority < 0 || priority > maxPriority {
+		return 0, nil, errInvalidPriority
+	}
+	return priority, frame[end+1:], nil
+}
+
+// nextField returns the field ending at the next space and the remaining data
+func nextField(data []byte) (string, []byte, bool) {
+	end := bytes.IndexByte(data, ' ')
+	if end == -1 {
+		return string(data), nil, len(data) > 0
+	}
+	return string(data[:end]), data[end+1:], true
+}
+
+// parseRFC5424 parses the header of a RFC5424 message following the version:
+// TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(msg *Message, data []byte) {
+	fields := []*string{&msg.Timestamp, &msg.Hostname, &msg.AppName, &msg.ProcID, &msg.MsgID}
+	for _, field := range fields {
+		value, rest, ok := nextField(data)
+		if !ok {
+			return
+		}
+		if value != nilValue {
+			*field = value
+		}
+		data = rest
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		msg.StructuredData, data = parseStructuredData(data)
+	} else if bytes.HasPrefix(data, []byte(nilValue)) {
+		data = data[len(nilValue):]
+	}
+	msg.Msg = bytes.TrimPrefix(bytes.TrimPrefix(data, []byte(" ")), []byte("\xef\xbb\xbf"))
+}
+
+// parseStructuredData parses the structured data elements of a RFC5424 message:
+// [SD-ID PARAM-NAME="PARAM-VALUE" ...][SD-ID ...]
+func parseStructuredData(data []byte) (map[string]map[string]string, []byte) {
+	elements := make(map[string]map[string]string)
+	for len(data) > 0 && data[0] == '[' {
+		i := 1
+		start := i
+		for i < len(data) && data[i] != ' ' && data[i] != ']' {
+			i++
+		}
+		params := make(map[string]string)
+		elements[string(data[start:i])] = params
+		for i < len(data) && data[i] == ' ' {
+			i++
+			start = i
+			for i < len(data) && data[i] != '=' {
+				i++
+			}
+			name := string(data[start:i])
+			// skip `="`
+			i += 2
+			var value []byte
+			for i < len(data) && data[i] != '"' {
+				if data[i] == '\\' && i+1 < len(data) && (data[i+1] == '"' || data[i+1] == '\\' || data[i+1] == ']') {
+					i++
+				}
+				value = append(value, data[i])
+				i++
+			}
+			params[name] = string(value)
+			// skip the closing quote
+			i++
+		}
+		if i >= len(data) {
+			return elements, nil
+		}
+		// skip the closing bracket
+		data = data[i+1:]
+	}
+	return elements, data
+}
+
+// rfc3164TimestampLayout is the format of the timestamps of the RFC3164 messages,
+// e.g. "Oct 11 22:14:15"
+const rfc3164TimestampLayout = time.Stamp
+
+// parseRFC3164 parses a RFC3164 message following the priority:
+// TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG
+// The fields which can't be parsed are considered as being part of the message.
+func parseRFC3164(msg *Message, data []byte) {
+	msg.Msg = data
+	if len(data) < len(rfc3164TimestampLayout)+1 {
+		return
+	}
+	if _, err := time.Parse(rfc3164TimestampLayout, string(data[:len(rfc3164TimestampLayout)])); err != nil {
+		return
+	}
+	msg.Timestamp = string(data[:len(rfc3164TimestampLayout)])
+	data = data[len(rfc3164TimestampLayout)+1:]
+	msg.Msg = data
+
+	hostname, rest, ok := nextField(data)
+	if !ok || bytes.HasSuffix([]byte(hostname), []byte(":")) {
+		// the hostname is missing, the tag directly follows the timestamp
+		rest = data
+	} else {
+		msg.Hostname = hostname
+	}
+	msg.Msg = rest
+
+	// the tag is made of alphanumeric characters and ends the first non-alphanumeric one,
+	// usually `[` followed by the pid, or `:`
+	end := 0
+	for end < len(rest) && end < 32 && isTagCharacter(rest[end]) {
+		end++
+	}
+	if end == 0 || end == len(rest) || (rest[end] != '[' && rest[end] != ':') {
+		return
+	}
+	msg.AppName = string(rest[:end])
+	rest = rest[end:]
+	if rest[0] == '[' {
+		closing := bytes.IndexByte(rest, ']')
+		if closing == -1 {
+			return
+		}
+		msg.ProcID = string(rest[1:closing])
+		rest = rest[closing+1:]
+	}
+	rest = bytes.TrimPrefix(rest, []byte(":"))
+	msg.Msg = bytes.TrimPrefix(rest, []byte(" "))
+}
+
+func isTagCharacter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '/'
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	msg, err := Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Appli\"cation"][examplePriority@32473 class="high"] An application event`))
+	require.NoError(t, err)
+	assert.Equal(t, &Message{
+		Facility:  20,
+		Severity:  5,
+		Version:   1,
+		Timestamp: "2003-10-11T22:14:15.003Z",
+		Hostname:  "mymachine.example.com",
+		AppName:   "evntslog",
+		MsgID:     "ID47",
+		StructuredData: map[string]map[string]string{
+			"exampleSDID@32473":     {"iut": "3", "eventSource": `Appli"cation`},
+			"examplePriority@32473": {"class": "high"},
+		},
+		Msg: []byte("An application event"),
+	}, msg)
+
+	msg, err = Parse([]byte("<34>1 2003-10-11T22:14:15.003Z mymachine su 1234 - - \xef\xbb\xbf'su root' failed"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, msg.Facility)
+	assert.Equal(t, 2, msg.Severity)
+	assert.Equal(t, "su", msg.AppName)
+	assert.Equal(t, "1234", msg.ProcID)
+	assert.Equal(t, "", msg.MsgID)
+	assert.Nil(t, msg.StructuredData)
+	assert.Equal(t, "'su root' failed", string(msg.Msg))
+
+	// the message is optional
+	msg, err = Parse([]byte("<14>1 - - - - - -"))
+	require.NoError(t, err)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Empty(t, msg.Msg)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	msg, err := Parse([]byte("<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed for lonvick"))
+	require.NoError(t, err)
+	assert.Equal(t, &Message{
+		Facility:  4,
+		Severity:  2,
+		Timestamp: "Oct 11 22:14:15",
+		Hostname:  "mymachine",
+		AppName:   "su",
+		ProcID:    "1234",
+		Msg:       []byte("'su root' failed for lonvick"),
+	}, msg)
+
+	// the hostname is missing
+	msg, err = Parse([]byte("<13>Feb  5 17:32:18 sshd: Accepted publickey"))
+	require.NoError(t, err)
+	assert.Equal(t, "Feb  5 17:32:18", msg.Timestamp)
+	assert.Equal(t, "", msg.Hostname)
+	assert.Equal(t, "sshd", msg.AppName)
+	assert.Equal(t, "Accepted publickey", string(msg.Msg))
+
+	// the header fields which can't be parsed are part of the message
+	msg, err = Parse([]byte("<13>Use the BFG!"))
+	require.NoError(t, err)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, "Use the BFG!", string(msg.Msg))
+}
+
+func TestParseInvalidPriority(t *testing.T) {
+	for _, frame := range []string{"", "hello", "<>1 - - - - - -", "<192>hello", "<abc>hello", "<12"} {
+		_, err := Parse([]byte(frame))
+		assert.Error(t, err, frame)
+	}
+}
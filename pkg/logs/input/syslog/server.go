@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// TCPServer accepts the syslog connections, optionally over TLS,
+// and forwards the messages of each connection to the pipeline.
+type TCPServer struct {
+	source     *config.LogSource
+	outputChan chan *message.Message
+	frameSize  int
+	listener   net.Listener
+	mu         sync.Mutex
+	conns      map[net.Conn]struct{}
+	wg         sync.WaitGroup
+}
+
+// NewTCPServer returns a new TCPServer
+func NewTCPServer(source *config.LogSource, outputChan chan *message.Message, frameSize int) *TCPServer {
+	return &TCPServer{
+		source:     source,
+		outputChan: outputChan,
+		frameSize:  frameSize,
+		conns:      make(map[net.Conn]struct{}),
+	}
+}
+
+// Start starts accepting connections
+func (s *TCPServer) Start() error {
+	address := fmt.Sprintf(":%d", s.source.Config.Port)
+	var err error
+	if s.source.Config.UseTLS {
+		var tlsConfig *tls.Config
+		tlsConfig, err = newTLSConfig(s.source.Config)
+		if err != nil {
+			return err
+		}
+		s.listener, err = tls.Listen("tcp", address, tlsConfig)
+	} else {
+		s.listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Starting syslog server on tcp port %d", s.source.Config.Port)
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Stop closes the listener and all the connections
+func (s *TCPServer) Stop() {
+	log.Infof("Stopping syslog server on tcp port %d", s.source.Config.Port)
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// run accepts the connections until the listener is closed
+func (s *TCPServer) run() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if isClosedConnError(err) {
+				return
+			}
+			log.Warnf("Could not accept a syslog connection on port %d: %v", s.source.Config.Port, err)
+			continue
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle forwards the messages of a connection until it is closed
+func (s *TCPServer) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		s.source.RemoveInput(conn.RemoteAddr().String())
+		s.wg.Done()
+	}()
+	s.source.AddInput(conn.RemoteAddr().String())
+	reader := newFrameReader(conn, s.frameSize)
+	for {
+		frame, err := reader.next()
+		if err != nil {
+			if err != io.EOF && !isClosedConnError(err) {
+				log.Warnf("Could not read syslog messages from %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		s.outputChan <- toMessage(frame, s.source)
+	}
+}
+
+// UDPServer forwards the syslog messages of the received datagrams,
+// each datagram holds one message.
+type UDPServer struct {
+	source     *config.LogSource
+	outputChan chan *message.Message
+	frameSize  int
+	conn       net.PacketConn
+	done       chan struct{}
+}
+
+// NewUDPServer returns a new UDPServer
+func NewUDPServer(source *config.LogSource, outputChan chan *message.Message, frameSize int) *UDPServer {
+	return &UDPServer{
+		source:     source,
+		outputChan: outputChan,
+		frameSize:  frameSize,
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts receiving datagrams
+func (s *UDPServer) Start() error {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", s.source.Config.Port))
+	if err != nil {
+		return err
+	}
+	log.Infof("Starting syslog server on udp port %d", s.source.Config.Port)
+	s.conn = conn
+	go s.run()
+	return nil
+}
+
+// Stop closes the connection
+func (s *UDPServer) Stop() {
+	log.Infof("Stopping syslog server on udp port %d", s.source.Config.Port)
+	s.conn.Close()
+	<-s.done
+}
+
+// run forwards the messages until the connection is closed
+func (s *UDPServer) run() {
+	defer close(s.done)
+	buffer := make([]byte, s.frameSize)
+	for {
+		n, _, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			if isClosedConnError(err) {
+				return
+			}
+			log.Warnf("Could not read syslog messages on port %d: %v", s.source.Config.Port, err)
+			continue
+		}
+		frame := bytes.TrimRight(buffer[:n], "\r\n")
+		if len(frame) == 0 {
+			continue
+		}
+		content := make([]byte, len(frame))
+		copy(content, frame)
+		s.outputChan <- toMessage(content, s.source)
+	}
+}
+
+// newTLSConfig returns the TLS configuration of the server, the certificates
+// of the clients are verified with the CA certificate when it is set.
+func newTLSConfig(c *config.LogsConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.TLSCACert != "" {
+		caCert, err := ioutil.ReadFile(c.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in %s", c.TLSCACert)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// isClosedConnError returns true if the error is related to a closed connection,
+// for more details, see: https://golang.org/src/internal/poll/fd.go#L18.
+func isClosedConnError(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestTCPServer(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType})
+	outputChan := make(chan *message.Message, 10)
+	server := NewTCPServer(source, outputChan, 1024)
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.listener.Addr().String())
+	require.NoError(t, err)
+	frame := "<165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 [exampleSDID@32473 iut=\"3\"] An application event"
+	fmt.Fprintf(conn, "%d %s<34>Oct 11 22:14:15 mymachine su: 'su root' failed\n", len(frame), frame)
+	conn.Close()
+
+	msg := <-outputChan
+	assert.Equal(t, message.StatusNotice, msg.GetStatus())
+	assert.Equal(t, "evntslog", msg.Origin.Source())
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal(msg.Content, &content))
+	assert.Equal(t, "An application event", content["message"])
+	attributes := content["syslog"].(map[string]interface{})
+	assert.Equal(t, float64(20), attributes["facility"])
+	assert.Equal(t, "mymachine", attributes["hostname"])
+	assert.Equal(t, "ID47", attributes["msgid"])
+	assert.Equal(t, map[string]interface{}{"exampleSDID@32473": map[string]interface{}{"iut": "3"}}, attributes["structured_data"])
+
+	msg = <-outputChan
+	assert.Equal(t, message.StatusCritical, msg.GetStatus())
+	assert.Equal(t, "su", msg.Origin.Service())
+}
+
+func TestUDPServer(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Protocol: "udp"})
+	outputChan := make(chan *message.Message, 10)
+	server := NewUDPServer(source, outputChan, 1024)
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("udp", server.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprint(conn, "not a syslog message\n")
+
+	msg := <-outputChan
+	assert.Equal(t, "not a syslog message", string(msg.Content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	_, err := newTLSConfig(&config.LogsConfig{TLSCert: "/does/not/exist", TLSKey: "/does/not/exist"})
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "syslog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	_, certPEM, key, err := security.GenerateRootCert([]string{"127.0.0.1"}, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(certPath, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+
+	tlsConfig, err := newTLSConfig(&config.LogsConfig{TLSCert: certPath, TLSKey: keyPath})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+}
@@ -20,8 +20,7 @@ typedef struct RichEvent_t {
     LPWSTR level;
 } RichEvent ;
 
-ULONGLONG startEventSubscribe(char *channel, char* query, ULONGLONG  ullBookmark, int flags, PVOID ctx);
-RichEvent* EnrichEvent(ULONGLONG ullEvent);
+RichEvent* EnrichEvent(ULONGLONG ullEvent, LCID locale);
 
 /// our version of winerror.h doesn't have these... when we get an up-to-date compiler,
 // should be able to remove.
@@ -8,24 +8,30 @@ package windowsevent
 import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 )
 
+// defaultBatchSize is the number of events read at once from a channel when not configured
+const defaultBatchSize = 10
+
 // Launcher is in charge of starting and stopping windows event logs tailers
 type Launcher struct {
 	sources          chan *config.LogSource
 	pipelineProvider pipeline.Provider
+	registry         auditor.Registry
 	tailers          map[string]*Tailer
 	stop             chan struct{}
 }
 
 // NewLauncher returns a new Launcher.
-func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider) *Launcher {
+func NewLauncher(sources *config.LogSources, pipelineProvider pipeline.Provider, registry auditor.Registry) *Launcher {
 	return &Launcher{
 		sources:          sources.GetAddedForType(config.WindowsEventType),
 		pipelineProvider: pipelineProvider,
+		registry:         registry,
 		tailers:          make(map[string]*Tailer),
 		stop:             make(chan struct{}),
 	}
@@ -77,18 +83,27 @@ func (l *Launcher) Stop() {
 
 // sanitizedConfig sets default values for the config
 func (l *Launcher) sanitizedConfig(sourceConfig *config.LogsConfig) *Config {
-	config := &Config{sourceConfig.ChannelPath, sourceConfig.Query}
-	if config.Query == "" {
-		config.Query = "*"
+	mode, _ := config.TailingModeFromString(sourceConfig.TailingMode)
+	sanitizedConfig := &Config{
+		ChannelPath:   sourceConfig.ChannelPath,
+		Query:         sourceConfig.Query,
+		Locale:        sourceConfig.Locale,
+		BatchSize:     sourceConfig.BatchSize,
+		FromBeginning: mode == config.Beginning || mode == config.ForceBeginning,
+	}
+	if sanitizedConfig.Query == "" {
+		sanitizedConfig.Query = "*"
+	}
+	if sanitizedConfig.BatchSize == 0 {
+		sanitizedConfig.BatchSize = defaultBatchSize
 	}
-	return config
+	return sanitizedConfig
 }
 
-// setupTailer configures and starts a new tailer
+// setupTailer configures and starts a new tailer from the bookmark persisted for its identifier,
+// or from the beginning of the channel when configured to.
 func (l *Launcher) setupTailer(source *config.LogSource) (*Tailer, error) {
-	sanitizedConfig := l.sanitizedConfig(source.Config)
-	config := &Config{sanitizedConfig.ChannelPath, sanitizedConfig.Query}
-	tailer := NewTailer(source, config, l.pipelineProvider.NextPipelineChan())
-	tailer.Start()
+	tailer := NewTailer(source, l.sanitizedConfig(source.Config), l.pipelineProvider.NextPipelineChan())
+	tailer.Start(l.registry.GetOffset(tailer.Identifier()))
 	return tailer, nil
 }
@@ -14,6 +14,13 @@ import (
 )
 
 func TestShouldSanitizeConfig(t *testing.T) {
-	launcher := NewLauncher(config.NewLogSources(), nil)
-	assert.Equal(t, "*", launcher.sanitizedConfig(&config.LogsConfig{ChannelPath: "System", Query: ""}).Query)
+	launcher := NewLauncher(config.NewLogSources(), nil, nil)
+	sanitizedConfig := launcher.sanitizedConfig(&config.LogsConfig{ChannelPath: "System", Query: ""})
+	assert.Equal(t, "*", sanitizedConfig.Query)
+	assert.Equal(t, defaultBatchSize, sanitizedConfig.BatchSize)
+	assert.False(t, sanitizedConfig.FromBeginning)
+
+	sanitizedConfig = launcher.sanitizedConfig(&config.LogsConfig{ChannelPath: "System", BatchSize: 100, TailingMode: "beginning"})
+	assert.Equal(t, 100, sanitizedConfig.BatchSize)
+	assert.True(t, sanitizedConfig.FromBeginning)
 }
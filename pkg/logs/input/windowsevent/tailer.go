@@ -29,12 +29,15 @@ const (
 // Config is a event log tailer configuration
 type Config struct {
 	ChannelPath string
-	Query       string
-}
-
-// eventContext links go and c
-type eventContext struct {
-	id int
+	// Query is a XPath filter of the events of the channel, or a structured XML
+	// query selecting the events of several channels
+	Query string
+	// Locale is the name of the locale the messages are rendered in, the locale of the system by default
+	Locale string
+	// BatchSize is the maximum number of events read at once
+	BatchSize int
+	// FromBeginning makes the tailer read the existing events of the channel when no bookmark was persisted
+	FromBeginning bool
 }
 
 // richEvent carries rendered information to create a richer log
@@ -44,6 +47,7 @@ type richEvent struct {
 	task     string
 	opcode   string
 	level    string
+	bookmark string
 }
 
 // Tailer collects logs from event log.
@@ -53,8 +57,6 @@ type Tailer struct {
 	outputChan chan *message.Message
 	stop       chan struct{}
 	done       chan struct{}
-
-	context *eventContext
 }
 
 // NewTailer returns a new tailer.
@@ -78,7 +80,8 @@ func (t *Tailer) Identifier() string {
 	return Identifier(t.config.ChannelPath, t.config.Query)
 }
 
-// toMessage converts an XML message into json
+// toMessage converts an XML message into json, the bookmark of the event is
+// committed by the auditor so that the tailer resumes after it on restart.
 func (t *Tailer) toMessage(re *richEvent) (*message.Message, error) {
 	event := re.xmlEvent
 	log.Debug("Rendered XML:", event)
@@ -131,7 +134,12 @@ func (t *Tailer) toMessage(re *richEvent) (*message.Message, error) {
 	}
 	jsonEvent = replaceTextKeyToValue(jsonEvent)
 	log.Debug("Sending JSON:", string(jsonEvent))
-	return message.NewMessageWithSource(jsonEvent, message.StatusInfo, t.source), nil
+	msg := message.NewMessageWithSource(jsonEvent, message.StatusInfo, t.source)
+	if re.bookmark != "" {
+		msg.Origin.Identifier = t.Identifier()
+		msg.Origin.Offset = re.bookmark
+	}
+	return msg, nil
 }
 
 // extractDataField transforms the fields parsed from <Data Name='NAME1'>VALUE1</Data><Data Name='NAME2'>VALUE2</Data> to
@@ -12,7 +12,7 @@ import (
 )
 
 // Start does not do much
-func (t *Tailer) Start() {
+func (t *Tailer) Start(bookmark string) {
 	log.Warn("windows event log not supported on this system")
 	go t.tail()
 }
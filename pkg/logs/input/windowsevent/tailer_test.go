@@ -55,6 +55,22 @@ func TestToMessage(t *testing.T) {
 	assert.Equal(t, expected6, string(actual.Content))
 }
 
+func TestToMessageWithBookmark(t *testing.T) {
+	tailer := NewTailer(nil, &Config{ChannelPath: "System", Query: "*"}, nil)
+	evt := `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><EventRecordID>2</EventRecordID><Channel>System</Channel></System></Event>`
+	bookmark := `<BookmarkList><Bookmark Channel='System' RecordId='2' IsCurrent='true'/></BookmarkList>`
+
+	msg, err := tailer.toMessage(&richEvent{xmlEvent: evt, bookmark: bookmark})
+	assert.NoError(t, err)
+	assert.Equal(t, "eventlog:System;*", msg.Origin.Identifier)
+	assert.Equal(t, bookmark, msg.Origin.Offset)
+
+	// the offset of the events without bookmark is not committed
+	msg, err = tailer.toMessage(richEventFromXML(evt))
+	assert.NoError(t, err)
+	assert.Equal(t, "", msg.Origin.Identifier)
+}
+
 func richEventFromXML(xml string) *richEvent {
 	return &richEvent{xmlEvent: xml}
 }
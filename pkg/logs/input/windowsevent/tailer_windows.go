@@ -14,18 +14,35 @@ package windowsevent
 import "C"
 
 import (
+	"fmt"
 	"syscall"
 	"unicode/utf16"
 	"unsafe"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"golang.org/x/sys/windows"
 )
 
-// Start starts tailing the event log.
-func (t *Tailer) Start() {
+// waitTimeout is the time in milliseconds waited for new events before checking whether the tailer was stopped
+const waitTimeout = 1000
+
+type evtHandle uintptr
+
+// subscription is a pull subscription to the events of a channel, its bookmark
+// is updated with each event read.
+type subscription struct {
+	handle   evtHandle
+	bookmark evtHandle
+	signal   windows.Handle
+	locale   uint32
+}
+
+// Start starts tailing the event log after the bookmark, or from the configured position
+// when the bookmark is empty.
+func (t *Tailer) Start(bookmark string) {
 	log.Infof("Starting windows event log tailing for channel %s query %s", t.config.ChannelPath, t.config.Query)
-	go t.tail()
+	go t.tail(bookmark)
 }
 
 // Stop stops the tailer
@@ -35,69 +52,186 @@ func (t *Tailer) Stop() {
 	<-t.done
 }
 
-// tail subscribes to the channel for the windows events
-func (t *Tailer) tail() {
-	t.context = &eventContext{
-		id: indexForTailer(t),
+// tail reads the events of the channel by batches as soon as they are available
+func (t *Tailer) tail(bookmark string) {
+	defer func() {
+		t.done <- struct{}{}
+	}()
+	sub, err := t.subscribe(bookmark)
+	if err != nil {
+		log.Warnf("Could not subscribe to windows event log channel %s query %s: %v", t.config.ChannelPath, t.config.Query, err)
+		t.source.Status.Error(err)
+		return
 	}
-	C.startEventSubscribe(
-		C.CString(t.config.ChannelPath),
-		C.CString(t.config.Query),
-		C.ULONGLONG(0),
-		C.int(EvtSubscribeToFutureEvents),
-		C.PVOID(uintptr(unsafe.Pointer(t.context))),
-	)
+	defer sub.close()
 	t.source.Status.Success()
 
-	// wait for stop signal
-	<-t.stop
-	t.done <- struct{}{}
-	return
+	for {
+		stopped, err := t.readEvents(sub)
+		if stopped {
+			return
+		}
+		if err != nil {
+			log.Warnf("Could not read windows event log channel %s query %s: %v", t.config.ChannelPath, t.config.Query, err)
+		}
+		windows.ResetEvent(sub.signal)
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+		windows.WaitForSingleObject(sub.signal, waitTimeout)
+	}
 }
 
-/*
-	Windows related methods
-*/
+// subscribe opens a pull subscription to the channel starting after the bookmark,
+// a structured query selects the events of several channels on its own.
+func (t *Tailer) subscribe(bookmark string) (*subscription, error) {
+	locale, err := localeToLCID(t.config.Locale)
+	if err != nil {
+		return nil, err
+	}
+	sub := &subscription{locale: locale}
+	sub.signal, err = windows.CreateEvent(nil, 1, 1, nil)
+	if err != nil {
+		return nil, err
+	}
 
-/* These are entry points for the callback to hand the pointer to Go-land.
-   Note: handles are only valid within the callback. Don't pass them out. */
+	flags := EvtSubscribeToFutureEvents
+	if t.config.FromBeginning {
+		flags = EvtSubscribeStartAtOldestRecord
+	}
+	if bookmark != "" {
+		sub.bookmark, err = evtCreateBookmark(bookmark)
+		if err != nil {
+			log.Warnf("Could not restore the windows event log bookmark %s: %v", bookmark, err)
+		} else {
+			flags = EvtSubscribeStartAfterBookmark
+		}
+	}
+	if sub.bookmark == 0 {
+		if sub.bookmark, err = evtCreateBookmark(""); err != nil {
+			sub.close()
+			return nil, err
+		}
+	}
 
-//export goStaleCallback
-func goStaleCallback(errCode C.ULONGLONG, ctx C.PVOID) {
-	log.Warn("EventLog tailer got Stale callback")
+	var channelPath *uint16
+	if !config.IsStructuredQuery(t.config.Query) {
+		channelPath, err = windows.UTF16PtrFromString(t.config.ChannelPath)
+		if err != nil {
+			sub.close()
+			return nil, err
+		}
+	}
+	query, err := windows.UTF16PtrFromString(t.config.Query)
+	if err != nil {
+		sub.close()
+		return nil, err
+	}
+	var bookmarkHandle evtHandle
+	if flags == EvtSubscribeStartAfterBookmark {
+		bookmarkHandle = sub.bookmark
+	}
+	ret, _, err := procEvtSubscribe.Call(uintptr(0), // local computer
+		uintptr(sub.signal),
+		uintptr(unsafe.Pointer(channelPath)),
+		uintptr(unsafe.Pointer(query)),
+		uintptr(bookmarkHandle),
+		uintptr(0), // no context, the events are pulled
+		uintptr(0), // no callback, the events are pulled
+		uintptr(flags))
+	if ret == 0 {
+		sub.close()
+		return nil, err
+	}
+	sub.handle = evtHandle(ret)
+	return sub, nil
 }
 
-//export goErrorCallback
-func goErrorCallback(errCode C.ULONGLONG, ctx C.PVOID) {
-	log.Warn("EventLog tailer got Error callback with code ", errCode)
+// readEvents forwards the events available by batches, the bookmark is
+// updated with each event so that its offset can be committed.
+// It returns true when the tailer was stopped while reading.
+func (t *Tailer) readEvents(sub *subscription) (bool, error) {
+	events := make([]evtHandle, t.config.BatchSize)
+	for {
+		select {
+		case <-t.stop:
+			return true, nil
+		default:
+		}
+		var returned uint32
+		ret, _, err := procEvtNext.Call(uintptr(sub.handle),
+			uintptr(len(events)),
+			uintptr(unsafe.Pointer(&events[0])),
+			uintptr(waitTimeout),
+			uintptr(0), // must be zero
+			uintptr(unsafe.Pointer(&returned)))
+		if ret == 0 {
+			if err == error(ERROR_NO_MORE_ITEMS) || err == error(ERROR_TIMEOUT) {
+				return false, nil
+			}
+			return false, err
+		}
+		for i, event := range events[:returned] {
+			sent := t.forward(sub, event)
+			evtClose(event)
+			if !sent {
+				for _, remaining := range events[i+1 : returned] {
+					evtClose(remaining)
+				}
+				return true, nil
+			}
+		}
+	}
 }
 
-//export goNotificationCallback
-func goNotificationCallback(handle C.ULONGLONG, ctx C.PVOID) {
-	goctx := *(*eventContext)(unsafe.Pointer(uintptr(ctx)))
-	log.Debug("Callback from ", goctx.id)
-
-	richEvt, err := EvtRender(handle)
+// forward renders the event and sends it to the pipeline, it returns false
+// when the tailer was stopped before the message could be sent.
+func (t *Tailer) forward(sub *subscription, event evtHandle) bool {
+	richEvt, err := EvtRender(C.ULONGLONG(event), sub.locale)
 	if err != nil {
 		log.Warnf("Error rendering xml: %v", err)
-		return
+		return true
 	}
-	t, exists := tailerForIndex(goctx.id)
-	if !exists {
-		log.Warnf("Got invalid eventContext id %d when map is %v", goctx.id, eventContextToTailerMap)
-		return
+	if ret, _, err := procEvtUpdateBookmark.Call(uintptr(sub.bookmark), uintptr(event)); ret == 0 {
+		log.Debugf("Could not update the windows event log bookmark: %v", err)
+	} else if richEvt.bookmark, err = evtRenderXML(sub.bookmark, EvtRenderBookmark); err != nil {
+		log.Debugf("Could not render the windows event log bookmark: %v", err)
 	}
 	msg, err := t.toMessage(richEvt)
 	if err != nil {
 		log.Warnf("Couldn't convert xml to json: %s for event %s", err, richEvt.xmlEvent)
-		return
+		return true
+	}
+	select {
+	case t.outputChan <- msg:
+		return true
+	case <-t.stop:
+		return false
 	}
+}
 
-	t.outputChan <- msg
+// close releases the handles of the subscription
+func (s *subscription) close() {
+	if s.handle != 0 {
+		evtClose(s.handle)
+	}
+	if s.bookmark != 0 {
+		evtClose(s.bookmark)
+	}
+	if s.signal != 0 {
+		windows.CloseHandle(s.signal)
+	}
 }
 
+/*
+	Windows related methods
+*/
+
 var (
 	modWinEvtAPI = windows.NewLazyDLL("wevtapi.dll")
+	modKernel32  = windows.NewLazySystemDLL("kernel32.dll")
 
 	procEvtSubscribe       = modWinEvtAPI.NewProc("EvtSubscribe")
 	procEvtClose           = modWinEvtAPI.NewProc("EvtClose")
@@ -105,45 +239,86 @@ var (
 	procEvtOpenChannelEnum = modWinEvtAPI.NewProc("EvtOpenChannelEnum")
 	procEvtNextChannelPath = modWinEvtAPI.NewProc("EvtNextChannelPath")
 	procEvtNext            = modWinEvtAPI.NewProc("EvtNext")
+	procEvtCreateBookmark  = modWinEvtAPI.NewProc("EvtCreateBookmark")
+	procEvtUpdateBookmark  = modWinEvtAPI.NewProc("EvtUpdateBookmark")
+	procLocaleNameToLCID   = modKernel32.NewProc("LocaleNameToLCID")
 )
 
-// EvtRender takes an event handle and renders it to XML
-func EvtRender(h C.ULONGLONG) (richEvt *richEvent, err error) {
+// evtClose closes an event log handle
+func evtClose(h evtHandle) {
+	procEvtClose.Call(uintptr(h))
+}
+
+// evtCreateBookmark creates a bookmark from its XML representation, or a new bookmark when empty
+func evtCreateBookmark(bookmarkXML string) (evtHandle, error) {
+	var xml *uint16
+	if bookmarkXML != "" {
+		var err error
+		if xml, err = windows.UTF16PtrFromString(bookmarkXML); err != nil {
+			return 0, err
+		}
+	}
+	ret, _, err := procEvtCreateBookmark.Call(uintptr(unsafe.Pointer(xml)))
+	if ret == 0 {
+		return 0, err
+	}
+	return evtHandle(ret), nil
+}
+
+// localeToLCID returns the identifier of a locale name such as fr-FR, the default
+// locale of the system is used when the name is empty.
+func localeToLCID(locale string) (uint32, error) {
+	if locale == "" {
+		return 0, nil
+	}
+	name, err := windows.UTF16PtrFromString(locale)
+	if err != nil {
+		return 0, err
+	}
+	ret, _, _ := procLocaleNameToLCID.Call(uintptr(unsafe.Pointer(name)), uintptr(0))
+	if ret == 0 {
+		return 0, fmt.Errorf("unknown locale %s", locale)
+	}
+	return uint32(ret), nil
+}
+
+// evtRenderXML renders an event or a bookmark to XML
+func evtRenderXML(h evtHandle, flags int) (string, error) {
 	var bufSize uint32
 	var bufUsed uint32
 
-	_, _, err = procEvtRender.Call(uintptr(0), // this handle is always null for XML renders
-		uintptr(h),                 // handle of event we're rendering
-		uintptr(EvtRenderEventXml), // for now, always render in xml
+	_, _, err := procEvtRender.Call(uintptr(0), // this handle is always null for XML renders
+		uintptr(h),     // handle of event or bookmark we're rendering
+		uintptr(flags), // EvtRenderEventXml or EvtRenderBookmark
 		uintptr(bufSize),
 		uintptr(0),                        // no buffer for now, just getting necessary size
 		uintptr(unsafe.Pointer(&bufUsed)), // filled in with necessary buffer size
 		uintptr(0))                        // not used but must be provided
 	if err != error(windows.ERROR_INSUFFICIENT_BUFFER) {
-		log.Warnf("Couldn't render xml event: %s", err)
-		return
+		return "", err
 	}
 	bufSize = bufUsed
 	buf := make([]uint8, bufSize)
 	ret, _, err := procEvtRender.Call(uintptr(0), // this handle is always null for XML renders
-		uintptr(h),                 // handle of event we're rendering
-		uintptr(EvtRenderEventXml), // for now, always render in xml
+		uintptr(h),     // handle of event or bookmark we're rendering
+		uintptr(flags), // EvtRenderEventXml or EvtRenderBookmark
 		uintptr(bufSize),
 		uintptr(unsafe.Pointer(&buf[0])),  // actual buffer used
 		uintptr(unsafe.Pointer(&bufUsed)), // filled in with necessary buffer size
 		uintptr(0))                        // not used but must be provided
 	if ret == 0 {
-		return
+		return "", err
 	}
-	// Call will set error anyway.  Clear it so we don't return an error
-	err = nil
-
-	xml := ConvertWindowsString(buf)
-
-	richEvt = enrichEvent(h, xml)
-
-	return
+	return ConvertWindowsString(buf), nil
+}
 
+// EvtRender takes an event handle and renders it to XML, its fields are rendered in the locale
+func EvtRender(h C.ULONGLONG, locale uint32) (*richEvent, error) {
+	xml, err := evtRenderXML(evtHandle(h), EvtRenderEventXml)
+	if err != nil {
+		return nil, err
+	}
+	return enrichEvent(h, locale, xml), nil
 }
 
 // enrichEvent renders data, and set the rendered fields to the richEvent.
@@ -151,10 +326,10 @@ func EvtRender(h C.ULONGLONG) (richEvt *richEvent, err error) {
 // value. We then call a function in the Windows API that match the code to
 // a human readable value.
 // enrichEvent also takes care of freeing the memory allocated in the C code
-func enrichEvent(h C.ULONGLONG, xml string) *richEvent {
+func enrichEvent(h C.ULONGLONG, locale uint32, xml string) *richEvent {
 	var message, task, opcode, level string
 	// Enrich event with rendered
-	richEvtCStruct := C.EnrichEvent(h)
+	richEvtCStruct := C.EnrichEvent(h, C.LCID(locale))
 	if richEvtCStruct != nil {
 		if richEvtCStruct.message != nil {
 			message = LPWSTRToString(richEvtCStruct.message)
@@ -205,6 +380,7 @@ const (
 	EvtRenderBookmark    = 2 // Bookmark
 
 	ERROR_NO_MORE_ITEMS syscall.Errno = 259
+	ERROR_TIMEOUT       syscall.Errno = 1460
 
 	maxRunes      = 1<<17 - 1 // 128 kB
 	truncatedFlag = "...TRUNCATED..."
@@ -14,17 +14,23 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 )
 
 const (
 	// key used to display a warning message on the agent status
-	invalidProcessingRules = "invalid_global_processing_rules"
-	invalidEndpoints       = "invalid_endpoints"
+	invalidProcessingRules  = "invalid_global_processing_rules"
+	invalidScrubbingRules   = "invalid_scrubbing_rules"
+	invalidServiceRateLimit = "invalid_service_rate_limit"
+	invalidJSONRemapping    = "invalid_json_remapping"
+	invalidEndpoints        = "invalid_endpoints"
 )
 
 // Transport is the transport used by logs-agent, i.e TCP or HTTP
@@ -64,7 +70,10 @@ func Start() error {
 
 	// setup the server config
 	httpConnectivity := config.HTTPConnectivityFailure
-	if endpoints, err := config.BuildHTTPEndpoints(); err == nil {
+	if _, _, proxied := fips.ProxyAddress(fips.LogsAgent); proxied {
+		// the logs can't be sent to the intake directly in FIPS mode, the proxy is used over HTTP
+		httpConnectivity = config.HTTPConnectivitySuccess
+	} else if endpoints, err := config.BuildHTTPEndpoints(); err == nil {
 		httpConnectivity = http.CheckConnectivity(endpoints.Main)
 	}
 	endpoints, err := config.BuildEndpoints(httpConnectivity)
@@ -89,8 +98,46 @@ func Start() error {
 		return errors.New(message)
 	}
 
+	// setup the scrubbing rules
+	scrubbingRuleSets, err := config.GlobalScrubbingRuleSets()
+	if err != nil {
+		message := fmt.Sprintf("Invalid scrubbing rules: %v", err)
+		status.AddGlobalError(invalidScrubbingRules, message)
+		return errors.New(message)
+	}
+	scrubber, err := processor.NewScrubber(scrubbingRuleSets, coreConfig.Datadog.GetStringSlice("logs_config.scrubbing_rule_sets"))
+	if err != nil {
+		message := fmt.Sprintf("Invalid scrubbing rules: %v", err)
+		status.AddGlobalError(invalidScrubbingRules, message)
+		return errors.New(message)
+	}
+
+	// setup the rate limits of the services
+	serviceRateLimit, err := config.GlobalServiceRateLimit()
+	if err != nil {
+		message := fmt.Sprintf("Invalid service rate limit: %v", err)
+		status.AddGlobalError(invalidServiceRateLimit, message)
+		return errors.New(message)
+	}
+	rateLimiter := processor.NewRateLimiter(serviceRateLimit)
+
+	// setup the remapping of the attributes of the JSON logs
+	jsonRemapping, err := config.GlobalJSONRemapping()
+	if err != nil {
+		message := fmt.Sprintf("Invalid JSON remapping: %v", err)
+		status.AddGlobalError(invalidJSONRemapping, message)
+		return errors.New(message)
+	}
+	jsonRemapper := processor.NewJSONRemapper(jsonRemapping)
+
+	// setup the injection of the context of the active traces of the containers
+	var traceContexts *processor.TraceContextInjector
+	if coreConfig.Datadog.GetBool("logs_config.inject_trace_context") {
+		traceContexts = processor.NewTraceContextInjector(config.TraceAgentPort())
+	}
+
 	// setup and start the agent
-	agent = NewAgent(sources, services, processingRules, endpoints)
+	agent = NewAgent(sources, services, processingRules, scrubber, rateLimiter, jsonRemapper, traceContexts, endpoints)
 	log.Info("Starting logs-agent...")
 	agent.Start()
 	atomic.StoreInt32(&isRunning, 1)
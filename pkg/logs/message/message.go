@@ -5,13 +5,18 @@
 
 package message
 
-import "github.com/DataDog/datadog-agent/pkg/logs/config"
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
 
 // Message represents a log line sent to datadog, with its metadata
 type Message struct {
-	Content []byte
-	Origin  *Origin
-	status  string
+	Content   []byte
+	Origin    *Origin
+	status    string
+	timestamp time.Time
 }
 
 // NewMessageWithSource constructs message with content, status and log source.
@@ -36,3 +41,22 @@ func (m *Message) GetStatus() string {
 	}
 	return m.status
 }
+
+// SetStatus sets the status of the message.
+func (m *Message) SetStatus(status string) {
+	m.status = status
+}
+
+// GetTimestamp gets the timestamp of the message.
+// if timestamp is not set, the current time will be returned.
+func (m *Message) GetTimestamp() time.Time {
+	if m.timestamp.IsZero() {
+		return time.Now()
+	}
+	return m.timestamp
+}
+
+// SetTimestamp sets the timestamp of the message.
+func (m *Message) SetTimestamp(timestamp time.Time) {
+	m.timestamp = timestamp
+}
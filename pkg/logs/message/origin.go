@@ -88,6 +88,14 @@ func (o *Origin) SetTags(tags []string) {
 	o.tags = tags
 }
 
+// AddTag adds a tag to the origin.
+func (o *Origin) AddTag(tag string) {
+	// the tags may be shared with other origins, copy them before adding the new one
+	tags := make([]string, len(o.tags), len(o.tags)+1)
+	copy(tags, o.tags)
+	o.tags = append(tags, tag)
+}
+
 // SetSource sets the source of the origin.
 func (o *Origin) SetSource(source string) {
 	o.source = source
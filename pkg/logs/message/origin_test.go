@@ -105,3 +105,21 @@ func TestDefaultServiceValueIsServiceFromConfig(t *testing.T) {
 	origin.SetService("bar")
 	assert.Equal(t, "bar", origin.Service())
 }
+
+func TestAddTag(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	tags := make([]string, 1, 2)
+	tags[0] = "foo:bar"
+
+	origin := NewOrigin(source)
+	origin.SetTags(tags)
+	origin.AddTag("sample_rate:0.1")
+	assert.Equal(t, []string{"foo:bar", "sample_rate:0.1"}, origin.Tags())
+
+	// the tags shared with other origins are left unchanged
+	other := NewOrigin(source)
+	other.SetTags(tags)
+	other.AddTag("baz:qux")
+	assert.Equal(t, []string{"foo:bar", "sample_rate:0.1"}, origin.Tags())
+	assert.Equal(t, []string{"foo:bar", "baz:qux"}, other.Tags())
+}
@@ -51,6 +51,36 @@ var (
 	// TlmEncodedBytesSent is the total number of sent bytes after encoding if any
 	TlmEncodedBytesSent = telemetry.NewCounter("logs", "encoded_bytes_sent",
 		nil, "Total number of sent bytes after encoding if any")
+	// LogsScrubbed is the total number of sequences scrubbed per scrubbing rule
+	LogsScrubbed = expvar.Map{}
+	// TlmLogsScrubbed is the total number of sequences scrubbed per scrubbing rule
+	TlmLogsScrubbed = telemetry.NewCounter("logs", "scrubbed",
+		[]string{"rule_set", "rule"}, "Total number of sequences scrubbed per scrubbing rule")
+	// DestinationThroughput is the number of bytes sent per second per HTTP endpoint after encoding if any
+	DestinationThroughput = expvar.Map{}
+	// TlmDestinationThroughput is the number of bytes sent per second per HTTP endpoint after encoding if any
+	TlmDestinationThroughput = telemetry.NewGauge("logs", "destination_throughput",
+		[]string{"endpoint"}, "Number of bytes sent per second per HTTP endpoint after encoding if any")
+	// LogsRateLimited is the number of logs dropped by the rate limit of a source or a service
+	LogsRateLimited = expvar.Map{}
+	// TlmLogsRateLimited is the number of logs dropped by the rate limit of a source or a service
+	TlmLogsRateLimited = telemetry.NewCounter("logs", "rate_limited",
+		[]string{"limit"}, "Number of logs dropped by the rate limit of a source or a service")
+	// DiskBufferBytes is the number of bytes of the payloads stored in the disk buffer
+	DiskBufferBytes = expvar.Int{}
+	// TlmDiskBufferBytes is the number of bytes of the payloads stored in the disk buffer
+	TlmDiskBufferBytes = telemetry.NewGauge("logs", "disk_buffer_bytes",
+		nil, "Number of bytes of the payloads stored in the disk buffer")
+	// DiskBufferDropped is the number of payloads dropped from the disk buffer per reason
+	DiskBufferDropped = expvar.Map{}
+	// TlmDiskBufferDropped is the number of payloads dropped from the disk buffer per reason
+	TlmDiskBufferDropped = telemetry.NewCounter("logs", "disk_buffer_dropped",
+		[]string{"reason"}, "Number of payloads dropped from the disk buffer per reason")
+	// InvalidKubeAuditEvents is the number of Kubernetes audit events that could not be decoded
+	InvalidKubeAuditEvents = expvar.Int{}
+	// TlmInvalidKubeAuditEvents is the number of Kubernetes audit events that could not be decoded
+	TlmInvalidKubeAuditEvents = telemetry.NewCounter("logs", "invalid_kubernetes_audit_events",
+		nil, "Number of Kubernetes audit events that could not be decoded")
 	// TODO: Add LogsCollected for the total number of collected logs.
 
 )
@@ -64,4 +94,10 @@ func init() {
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
 	LogsExpvars.Set("BytesSent", &BytesSent)
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
+	LogsExpvars.Set("LogsScrubbed", &LogsScrubbed)
+	LogsExpvars.Set("DestinationThroughput", &DestinationThroughput)
+	LogsExpvars.Set("LogsRateLimited", &LogsRateLimited)
+	LogsExpvars.Set("DiskBufferBytes", &DiskBufferBytes)
+	LogsExpvars.Set("DiskBufferDropped", &DiskBufferDropped)
+	LogsExpvars.Set("InvalidKubeAuditEvents", &InvalidKubeAuditEvents)
 }
@@ -23,33 +23,30 @@ type Pipeline struct {
 }
 
 // NewPipeline returns a new Pipeline
-func NewPipeline(outputChan chan *message.Message, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) *Pipeline {
-	var destinations *client.Destinations
-	if endpoints.UseHTTP {
-		main := http.NewDestination(endpoints.Main, http.JSONContentType, destinationsContext)
-		additionals := []client.Destination{}
-		for _, endpoint := range endpoints.Additionals {
-			additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext))
-		}
-		destinations = client.NewDestinations(main, additionals)
-	} else {
-		main := tcp.NewDestination(endpoints.Main, endpoints.UseProto, destinationsContext)
-		additionals := []client.Destination{}
-		for _, endpoint := range endpoints.Additionals {
-			additionals = append(additionals, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext))
-		}
-		destinations = client.NewDestinations(main, additionals)
+func NewPipeline(outputChan chan *message.Message, processingRules []*config.ProcessingRule, scrubber *processor.Scrubber, rateLimiter *processor.RateLimiter, jsonRemapper *processor.JSONRemapper, traceContexts *processor.TraceContextInjector, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diskBuffer *sender.DiskBuffer) *Pipeline {
+	main := newDestination(endpoints.Main, endpoints, destinationsContext)
+	additionals := []client.Destination{}
+	for _, endpoint := range endpoints.Additionals {
+		additionals = append(additionals, newDestination(endpoint, endpoints, destinationsContext))
 	}
+	destinations := client.NewDestinations(main, additionals)
 
 	senderChan := make(chan *message.Message, config.ChanSize)
 
 	var strategy sender.Strategy
 	if endpoints.UseHTTP {
-		strategy = sender.NewBatchStrategy(sender.ArraySerializer, endpoints.BatchWait)
+		strategy = sender.NewBatchStrategyWithConfig(sender.ArraySerializer, sender.BatchConfig{
+			MaxBatchSize:      endpoints.BatchMaxSize,
+			MaxContentSize:    endpoints.BatchMaxContentSize,
+			BatchWait:         endpoints.BatchWait,
+			MaxConcurrentSend: endpoints.BatchMaxConcurrentSend,
+			MaxInflight:       endpoints.BatchMaxInflight,
+			Adaptive:          endpoints.AdaptiveBatching,
+		})
 	} else {
 		strategy = sender.StreamStrategy
 	}
-	sender := sender.NewSender(senderChan, outputChan, destinations, strategy)
+	sender := sender.NewSender(senderChan, outputChan, destinations, strategy, diskBuffer)
 
 	var encoder processor.Encoder
 	if endpoints.UseHTTP {
@@ -61,7 +58,7 @@ func NewPipeline(outputChan chan *message.Message, processingRules []*config.Pro
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, senderChan, processingRules, encoder)
+	processor := processor.New(inputChan, senderChan, processingRules, scrubber, rateLimiter, jsonRemapper, traceContexts, encoder)
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -81,3 +78,11 @@ func (p *Pipeline) Stop() {
 	p.processor.Stop()
 	p.sender.Stop()
 }
+
+// newDestination returns a destination sending logs to the endpoint over HTTP or TCP.
+func newDestination(endpoint config.Endpoint, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) client.Destination {
+	if endpoints.UseHTTP {
+		return http.NewDestination(endpoint, http.JSONContentType, destinationsContext)
+	}
+	return tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext)
+}
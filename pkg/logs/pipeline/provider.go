@@ -12,7 +12,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 )
 
 // Provider provides message channels
@@ -28,22 +30,32 @@ type provider struct {
 	auditor           *auditor.Auditor
 	outputChan        chan *message.Message
 	processingRules   []*config.ProcessingRule
+	scrubber          *processor.Scrubber
+	rateLimiter       *processor.RateLimiter
+	jsonRemapper      *processor.JSONRemapper
+	traceContexts     *processor.TraceContextInjector
 	endpoints         *config.Endpoints
 
 	pipelines            []*Pipeline
 	currentPipelineIndex int32
 	destinationsContext  *client.DestinationsContext
+	diskBuffer           *sender.DiskBuffer
 }
 
-// NewProvider returns a new Provider
-func NewProvider(numberOfPipelines int, auditor *auditor.Auditor, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
+// NewProvider returns a new Provider, all the pipelines share the disk buffer when it is not nil.
+func NewProvider(numberOfPipelines int, auditor *auditor.Auditor, processingRules []*config.ProcessingRule, scrubber *processor.Scrubber, rateLimiter *processor.RateLimiter, jsonRemapper *processor.JSONRemapper, traceContexts *processor.TraceContextInjector, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diskBuffer *sender.DiskBuffer) Provider {
 	return &provider{
 		numberOfPipelines:   numberOfPipelines,
 		auditor:             auditor,
 		processingRules:     processingRules,
+		scrubber:            scrubber,
+		rateLimiter:         rateLimiter,
+		jsonRemapper:        jsonRemapper,
+		traceContexts:       traceContexts,
 		endpoints:           endpoints,
 		pipelines:           []*Pipeline{},
 		destinationsContext: destinationsContext,
+		diskBuffer:          diskBuffer,
 	}
 }
 
@@ -52,11 +64,16 @@ func (p *provider) Start() {
 	// This requires the auditor to be started before.
 	p.outputChan = p.auditor.Channel()
 
+	p.traceContexts.Start()
 	for i := 0; i < p.numberOfPipelines; i++ {
-		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext)
+		pipeline := NewPipeline(p.outputChan, p.processingRules, p.scrubber, p.rateLimiter, p.jsonRemapper, p.traceContexts, p.endpoints, p.destinationsContext, p.diskBuffer)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
+
+	if p.diskBuffer != nil {
+		p.diskBuffer.Start(newDestination(p.endpoints.Main, p.endpoints, p.destinationsContext))
+	}
 }
 
 // Stop stops all pipelines in parallel,
@@ -67,6 +84,10 @@ func (p *provider) Stop() {
 		stopper.Add(pipeline)
 	}
 	stopper.Stop()
+	if p.diskBuffer != nil {
+		p.diskBuffer.Stop()
+	}
+	p.traceContexts.Stop()
 	p.pipelines = p.pipelines[:0]
 	p.outputChan = nil
 }
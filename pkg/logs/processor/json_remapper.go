@@ -0,0 +1,268 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// The trace id is remapped to the standard `dd.trace_id` attribute
+const (
+	traceIDParentAttribute = "dd"
+	traceIDAttribute       = "trace_id"
+)
+
+// statusAliases maps the usual log levels to the statuses of the messages
+var statusAliases = map[string]string{
+	"emerg":         message.StatusEmergency,
+	"fatal":         message.StatusCritical,
+	"crit":          message.StatusCritical,
+	"err":           message.StatusError,
+	"warning":       message.StatusWarning,
+	"information":   message.StatusInfo,
+	"informational": message.StatusInfo,
+	"trace":         message.StatusDebug,
+	// syslog severities
+	"0": message.StatusEmergency,
+	"1": message.StatusAlert,
+	"2": message.StatusCritical,
+	"3": message.StatusError,
+	"4": message.StatusWarning,
+	"5": message.StatusNotice,
+	"6": message.StatusInfo,
+	"7": message.StatusDebug,
+}
+
+// JSONRemapper promotes the attributes of the JSON logs to the standard fields of the messages,
+// so that all the logs follow the same conventions before they reach the intake.
+// It is shared by all the pipelines.
+type JSONRemapper struct {
+	globalRemapping *config.JSONRemapping
+}
+
+// NewJSONRemapper returns a new JSONRemapper applying globalRemapping to the JSON logs of the sources
+// without their own remapping.
+func NewJSONRemapper(globalRemapping *config.JSONRemapping) *JSONRemapper {
+	return &JSONRemapper{
+		globalRemapping: globalRemapping,
+	}
+}
+
+// Remap updates the service, the status and the timestamp of a JSON log from its attributes,
+// and returns its content without the remapped attributes. The content of the logs that are not
+// JSON objects is returned as is. A nil JSONRemapper remaps nothing.
+func (r *JSONRemapper) Remap(msg *message.Message, content []byte) []byte {
+	if r == nil {
+		return content
+	}
+	remapping := r.globalRemapping
+	if source := msg.Origin.LogSource; source != nil && source.Config.JSONRemapping != nil {
+		remapping = source.Config.JSONRemapping
+	}
+	if remapping == nil {
+		return content
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return content
+	}
+	var attributes map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	// keep the precision of the large numbers like the trace ids
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil || decoder.More() {
+		return content
+	}
+
+	if path, value, found := findAttribute(attributes, remapping.Service); found {
+		if service, ok := toString(value); ok {
+			msg.Origin.SetService(service)
+			removeAttribute(attributes, path)
+		}
+	}
+	if path, value, found := findAttribute(attributes, remapping.Status); found {
+		if status, ok := toStatus(value); ok {
+			msg.SetStatus(status)
+			removeAttribute(attributes, path)
+		}
+	}
+	if path, value, found := findAttribute(attributes, remapping.Timestamp); found {
+		if timestamp, ok := toTimestamp(value); ok {
+			msg.SetTimestamp(timestamp)
+			removeAttribute(attributes, path)
+		}
+	}
+	if path, value, found := findAttribute(attributes, remapping.TraceID); found {
+		if traceID, ok := toString(value); ok {
+			removeAttribute(attributes, path)
+			setTraceID(attributes, traceID)
+		}
+	}
+	if remapping.Flatten {
+		attributes = flatten(attributes)
+	}
+
+	remapped, err := json.Marshal(attributes)
+	if err != nil {
+		return content
+	}
+	return remapped
+}
+
+// findAttribute returns the path and the value of the first attribute found,
+// the nested attributes are looked up with their path, e.g. `log.level`.
+func findAttribute(attributes map[string]interface{}, paths []string) (string, interface{}, bool) {
+	for _, path := range paths {
+		if value, found := lookup(attributes, path); found {
+			return path, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// lookup returns the value of the attribute at path, a key containing dots takes precedence
+// over the nested attributes.
+func lookup(attributes map[string]interface{}, path string) (interface{}, bool) {
+	if value, found := attributes[path]; found {
+		return value, true
+	}
+	for i := strings.Index(path, "."); i >= 0; i = nextDot(path, i) {
+		if nested, ok := attributes[path[:i]].(map[string]interface{}); ok {
+			if value, found := lookup(nested, path[i+1:]); found {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// removeAttribute removes the attribute at path, the parents left empty are kept.
+func removeAttribute(attributes map[string]interface{}, path string) bool {
+	if _, found := attributes[path]; found {
+		delete(attributes, path)
+		return true
+	}
+	for i := strings.Index(path, "."); i >= 0; i = nextDot(path, i) {
+		if nested, ok := attributes[path[:i]].(map[string]interface{}); ok {
+			if removeAttribute(nested, path[i+1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextDot returns the index of the next dot of path after i, or -1.
+func nextDot(path string, i int) int {
+	next := strings.Index(path[i+1:], ".")
+	if next < 0 {
+		return -1
+	}
+	return i + 1 + next
+}
+
+// setTraceID sets the standard trace id attribute.
+func setTraceID(attributes map[string]interface{}, traceID string) {
+	parent, ok := attributes[traceIDParentAttribute].(map[string]interface{})
+	if !ok {
+		parent = make(map[string]interface{})
+		attributes[traceIDParentAttribute] = parent
+	}
+	parent[traceIDAttribute] = traceID
+}
+
+// flatten replaces the nested attributes with top-level attributes named after their path.
+func flatten(attributes map[string]interface{}) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(attributes))
+	for key, value := range attributes {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			for nestedKey, nestedValue := range flatten(nested) {
+				flattened[key+"."+nestedKey] = nestedValue
+			}
+			continue
+		}
+		flattened[key] = value
+	}
+	return flattened
+}
+
+// toString returns the string value of a string or a number attribute.
+func toString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// toStatus returns the status of a message from a log level.
+func toStatus(value interface{}) (string, bool) {
+	level, ok := toString(value)
+	if !ok {
+		return "", false
+	}
+	level = strings.ToLower(level)
+	if status, found := statusAliases[level]; found {
+		return status, true
+	}
+	switch level {
+	case message.StatusEmergency, message.StatusAlert, message.StatusCritical, message.StatusError,
+		message.StatusWarning, message.StatusNotice, message.StatusInfo, message.StatusDebug:
+		return level, true
+	}
+	return "", false
+}
+
+// toTimestamp returns the time of a RFC3339 date or of an epoch number,
+// the unit of the epoch, from seconds to nanoseconds, is deduced from its magnitude.
+func toTimestamp(value interface{}) (time.Time, bool) {
+	var epoch float64
+	switch v := value.(type) {
+	case string:
+		if timestamp, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return timestamp, true
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		epoch = f
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		epoch = f
+	default:
+		return time.Time{}, false
+	}
+	if epoch <= 0 || math.IsInf(epoch, 0) {
+		return time.Time{}, false
+	}
+
+	switch {
+	case epoch >= 1e17:
+		return time.Unix(0, int64(epoch)), true
+	case epoch >= 1e14:
+		return time.Unix(0, int64(epoch*1e3)), true
+	case epoch >= 1e11:
+		return time.Unix(0, int64(epoch*1e6)), true
+	default:
+		seconds, fraction := math.Modf(epoch)
+		return time.Unix(int64(seconds), int64(fraction*1e9)), true
+	}
+}
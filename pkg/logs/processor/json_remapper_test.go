@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestJSONRemapperPromotesTheAttributes(t *testing.T) {
+	remapper := NewJSONRemapper(&config.JSONRemapping{
+		Service:   []string{"app"},
+		Status:    []string{"severity", "log.level"},
+		Timestamp: []string{"time"},
+		TraceID:   []string{"traceId"},
+	})
+	source := config.NewLogSource("", &config.LogsConfig{Service: "default"})
+
+	msg := newMessage(nil, source, "")
+	content := remapper.Remap(msg, []byte(`{"app":"billing","log":{"level":"WARNING","logger":"main"},"time":"2020-06-01T10:00:00.5Z","traceId":1234567890123456789,"msg":"hello"}`))
+	assert.Equal(t, `{"dd":{"trace_id":"1234567890123456789"},"log":{"logger":"main"},"msg":"hello"}`, string(content))
+	assert.Equal(t, "billing", msg.Origin.Service())
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, time.Date(2020, 6, 1, 10, 0, 0, 500000000, time.UTC), msg.GetTimestamp())
+}
+
+func TestJSONRemapperKeepsTheInvalidAttributes(t *testing.T) {
+	remapper := NewJSONRemapper(&config.JSONRemapping{
+		Status:    []string{"level"},
+		Timestamp: []string{"time"},
+	})
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	msg := newMessage(nil, source, "")
+	content := remapper.Remap(msg, []byte(`{"level":"verbose","time":"yesterday"}`))
+	assert.Equal(t, `{"level":"verbose","time":"yesterday"}`, string(content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+}
+
+func TestJSONRemapperFlattensTheNestedAttributes(t *testing.T) {
+	remapper := NewJSONRemapper(nil)
+	source := config.NewLogSource("", &config.LogsConfig{JSONRemapping: &config.JSONRemapping{Status: []string{"level"}, Flatten: true}})
+
+	msg := newMessage(nil, source, "")
+	content := remapper.Remap(msg, []byte(`{"level":3,"http":{"method":"GET","url":{"path":"/"}},"empty":{},"list":[1,2]}`))
+	assert.Equal(t, `{"empty":{},"http.method":"GET","http.url.path":"/","list":[1,2]}`, string(content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+}
+
+func TestJSONRemapperIgnoresTheOtherLogs(t *testing.T) {
+	remapper := NewJSONRemapper(&config.JSONRemapping{Status: []string{"level"}, Flatten: true})
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	for _, content := range []string{"", "level=error", `["level"]`, `{"level":"error"`, `{"level":"error"} {}`} {
+		msg := newMessage(nil, source, "")
+		assert.Equal(t, content, string(remapper.Remap(msg, []byte(content))))
+		assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	}
+
+	var nilRemapper *JSONRemapper
+	msg := newMessage(nil, source, "")
+	assert.Equal(t, `{"level":"error"}`, string(nilRemapper.Remap(msg, []byte(`{"level":"error"}`))))
+}
+
+func TestToTimestamp(t *testing.T) {
+	expected := time.Unix(1591005600, 0)
+	for _, value := range []interface{}{"1591005600", "1591005600000", json.Number("1591005600000000"), json.Number("1591005600000000000")} {
+		timestamp, ok := toTimestamp(value)
+		assert.True(t, ok)
+		assert.True(t, expected.Equal(timestamp), "%v", value)
+	}
+	_, ok := toTimestamp(json.Number("-1"))
+	assert.False(t, ok)
+}
@@ -19,16 +19,24 @@ type Processor struct {
 	inputChan       chan *message.Message
 	outputChan      chan *message.Message
 	processingRules []*config.ProcessingRule
+	scrubber        *Scrubber
+	rateLimiter     *RateLimiter
+	jsonRemapper    *JSONRemapper
+	traceContexts   *TraceContextInjector
 	encoder         Encoder
 	done            chan struct{}
 }
 
 // New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder) *Processor {
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, scrubber *Scrubber, rateLimiter *RateLimiter, jsonRemapper *JSONRemapper, traceContexts *TraceContextInjector, encoder Encoder) *Processor {
 	return &Processor{
 		inputChan:       inputChan,
 		outputChan:      outputChan,
 		processingRules: processingRules,
+		scrubber:        scrubber,
+		rateLimiter:     rateLimiter,
+		jsonRemapper:    jsonRemapper,
+		traceContexts:   traceContexts,
 		encoder:         encoder,
 		done:            make(chan struct{}),
 	}
@@ -55,9 +63,23 @@ func (p *Processor) run() {
 		metrics.LogsDecoded.Add(1)
 		metrics.TlmLogsDecoded.Inc()
 		if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
+			// Promote the attributes of the JSON logs to the standard fields
+			redactedMsg = p.jsonRemapper.Remap(msg, redactedMsg)
+
+			// Sample the messages over the rate limits of their source and service
+			if !p.rateLimiter.Sample(msg, redactedMsg) {
+				continue
+			}
+
 			metrics.LogsProcessed.Add(1)
 			metrics.TlmLogsProcessed.Inc()
 
+			// Correlate the message with the active trace of its container
+			redactedMsg = p.traceContexts.Inject(msg, redactedMsg)
+
+			// Scrub the sensitive data before the message leaves the host
+			redactedMsg = p.scrubber.Scrub(redactedMsg, msg.Origin.LogSource)
+
 			// Encode the message to its final format
 			content, err := p.encoder.Encode(msg, redactedMsg)
 			if err != nil {
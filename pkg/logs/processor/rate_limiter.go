@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+// sampleRateTag tags the logs sent over a rate limit with the ratio of the logs
+// sent over the limit, so that their volume can be estimated
+const sampleRateTag = "sample_rate"
+
+// overflowEpsilon absorbs the rounding errors when the sample rates of the logs over the limit are accumulated
+const overflowEpsilon = 1e-9
+
+// bucketIdleTimeout is the time after which the bucket of a source or a service that
+// did not send any log is evicted, it is full again by then so it is simply recreated
+// on the next log.
+const bucketIdleTimeout = time.Minute
+
+// rateLimitBucket holds the events and the bytes a source or a service can still send,
+// they are refilled continuously up to one second of the limit.
+type rateLimitBucket struct {
+	events     float64
+	bytes      float64
+	lastRefill time.Time
+	// overflow accumulates the sample rate of the logs over the limit,
+	// a log is sent over the limit each time it reaches 1
+	overflow float64
+}
+
+// RateLimiter caps the volume of logs per source and per service,
+// the logs over the limit are sampled instead of being dropped.
+// It is shared by all the pipelines.
+type RateLimiter struct {
+	serviceLimit *config.RateLimit
+	mu           sync.Mutex
+	sources      map[string]*rateLimitBucket
+	services     map[string]*rateLimitBucket
+	lastEviction time.Time
+	now          func() time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter applying serviceLimit to the logs of each service,
+// the logs of a source are also limited by the rate limit of the source.
+func NewRateLimiter(serviceLimit *config.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		serviceLimit: serviceLimit,
+		sources:      make(map[string]*rateLimitBucket),
+		services:     make(map[string]*rateLimitBucket),
+		now:          time.Now,
+	}
+}
+
+// Sample returns false when the message is over a rate limit and is not sampled,
+// the sampled messages are tagged with their sample rate. A nil RateLimiter keeps all messages.
+func (r *RateLimiter) Sample(msg *message.Message, content []byte) bool {
+	if r == nil {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.Sub(r.lastEviction) >= bucketIdleTimeout {
+		r.evictIdleBuckets(now)
+	}
+
+	sampleRate := 1.0
+	source := msg.Origin.LogSource
+	if source != nil && source.Config.RateLimit != nil {
+		sampleRate *= r.bucket(r.sources, source.Name, source.Config.RateLimit, now).sample(source.Config.RateLimit, len(content), now)
+		if sampleRate == 0 {
+			r.drop("source", source.Name)
+			return false
+		}
+	}
+	if service := msg.Origin.Service(); r.serviceLimit != nil && service != "" {
+		sampleRate *= r.bucket(r.services, service, r.serviceLimit, now).sample(r.serviceLimit, len(content), now)
+		if sampleRate == 0 {
+			r.drop("service", service)
+			return false
+		}
+	}
+
+	if sampleRate < 1 {
+		msg.Origin.AddTag(sampleRateTag + ":" + strconv.FormatFloat(sampleRate, 'g', -1, 64))
+	}
+	return true
+}
+
+// bucket returns the bucket of a source or a service, a new bucket is full.
+func (r *RateLimiter) bucket(buckets map[string]*rateLimitBucket, name string, limit *config.RateLimit, now time.Time) *rateLimitBucket {
+	bucket, exists := buckets[name]
+	if !exists {
+		bucket = &rateLimitBucket{
+			events:     limit.EventsPerSecond,
+			bytes:      limit.BytesPerSecond,
+			lastRefill: now,
+		}
+		buckets[name] = bucket
+	}
+	return bucket
+}
+
+// evictIdleBuckets removes the buckets of the sources and the services
+// that did not send any log for bucketIdleTimeout.
+func (r *RateLimiter) evictIdleBuckets(now time.Time) {
+	for _, buckets := range []map[string]*rateLimitBucket{r.sources, r.services} {
+		for name, bucket := range buckets {
+			if now.Sub(bucket.lastRefill) >= bucketIdleTimeout {
+				delete(buckets, name)
+			}
+		}
+	}
+	r.lastEviction = now
+}
+
+// drop counts a message dropped by the rate limit of a source or a service.
+func (r *RateLimiter) drop(limitType string, name string) {
+	metrics.LogsRateLimited.Add(limitType+":"+name, 1)
+	metrics.TlmLogsRateLimited.Inc(limitType)
+}
+
+// sample returns 1 when the log is within the limit, its sample rate when it is over
+// the limit and sampled, 0 when it is over the limit and dropped.
+func (b *rateLimitBucket) sample(limit *config.RateLimit, size int, now time.Time) float64 {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+	b.events = math.Min(limit.EventsPerSecond, b.events+elapsed*limit.EventsPerSecond)
+	b.bytes = math.Min(limit.BytesPerSecond, b.bytes+elapsed*limit.BytesPerSecond)
+
+	withinEvents := limit.EventsPerSecond == 0 || b.events >= 1
+	withinBytes := limit.BytesPerSecond == 0 || b.bytes >= float64(size)
+	if withinEvents && withinBytes {
+		if limit.EventsPerSecond > 0 {
+			b.events--
+		}
+		if limit.BytesPerSecond > 0 {
+			b.bytes -= float64(size)
+		}
+		return 1
+	}
+
+	sampleRate := limit.GetSampleRate()
+	b.overflow += sampleRate
+	if b.overflow >= 1-overflowEpsilon {
+		b.overflow--
+		return sampleRate
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+func newTestRateLimiter(serviceLimit *config.RateLimit) (*RateLimiter, *time.Time) {
+	now := time.Now()
+	rateLimiter := NewRateLimiter(serviceLimit)
+	rateLimiter.now = func() time.Time { return now }
+	return rateLimiter, &now
+}
+
+func TestRateLimiterSamplesTheLogsOverTheSourceLimit(t *testing.T) {
+	rateLimiter, now := newTestRateLimiter(nil)
+	source := config.NewLogSource("limited", &config.LogsConfig{RateLimit: &config.RateLimit{EventsPerSecond: 2, SampleRate: 0.5}})
+
+	var kept []bool
+	var tags [][]string
+	for i := 0; i < 6; i++ {
+		msg := newMessage([]byte("hello"), source, "")
+		kept = append(kept, rateLimiter.Sample(msg, msg.Content))
+		tags = append(tags, msg.Origin.Tags())
+	}
+	assert.Equal(t, []bool{true, true, false, true, false, true}, kept)
+	assert.Empty(t, tags[0])
+	assert.Empty(t, tags[1])
+	assert.Equal(t, []string{"sample_rate:0.5"}, tags[3])
+	assert.Equal(t, "2", metrics.LogsRateLimited.Get("source:limited").String())
+
+	// the limit is refilled over time
+	*now = now.Add(time.Second)
+	msg := newMessage([]byte("hello"), source, "")
+	assert.True(t, rateLimiter.Sample(msg, msg.Content))
+	assert.Empty(t, msg.Origin.Tags())
+}
+
+func TestRateLimiterLimitsTheBytesOfEachService(t *testing.T) {
+	rateLimiter, _ := newTestRateLimiter(&config.RateLimit{BytesPerSecond: 10})
+	foo := config.NewLogSource("foo", &config.LogsConfig{Service: "foo"})
+	bar := config.NewLogSource("bar", &config.LogsConfig{Service: "bar"})
+
+	msg := newMessage([]byte("0123456789"), foo, "")
+	assert.True(t, rateLimiter.Sample(msg, msg.Content))
+	assert.Empty(t, msg.Origin.Tags())
+
+	// the default sample rate keeps one log out of ten over the limit
+	var kept int
+	for i := 0; i < 20; i++ {
+		msg = newMessage([]byte("0123456789"), foo, "")
+		if rateLimiter.Sample(msg, msg.Content) {
+			kept++
+			assert.Equal(t, []string{"sample_rate:0.1"}, msg.Origin.Tags())
+		}
+	}
+	assert.Equal(t, 2, kept)
+
+	// each service has its own limit
+	msg = newMessage([]byte("0123456789"), bar, "")
+	assert.True(t, rateLimiter.Sample(msg, msg.Content))
+	assert.Empty(t, msg.Origin.Tags())
+}
+
+func TestRateLimiterCombinesTheSampleRates(t *testing.T) {
+	rateLimiter, _ := newTestRateLimiter(&config.RateLimit{EventsPerSecond: 1, SampleRate: 0.5})
+	source := config.NewLogSource("combined", &config.LogsConfig{Service: "combined", RateLimit: &config.RateLimit{EventsPerSecond: 1, SampleRate: 0.5}})
+
+	msg := newMessage([]byte("hello"), source, "")
+	assert.True(t, rateLimiter.Sample(msg, msg.Content))
+
+	var tags [][]string
+	for i := 0; i < 8; i++ {
+		msg = newMessage([]byte("hello"), source, "")
+		if rateLimiter.Sample(msg, msg.Content) {
+			tags = append(tags, msg.Origin.Tags())
+		}
+	}
+	assert.Equal(t, [][]string{{"sample_rate:0.25"}, {"sample_rate:0.25"}}, tags)
+}
+
+func TestNilRateLimiterKeepsAllLogs(t *testing.T) {
+	var rateLimiter *RateLimiter
+	msg := newMessage([]byte("hello"), config.NewLogSource("", &config.LogsConfig{}), "")
+	assert.True(t, rateLimiter.Sample(msg, msg.Content))
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	rateLimiter, now := newTestRateLimiter(&config.RateLimit{EventsPerSecond: 1})
+	foo := config.NewLogSource("foo", &config.LogsConfig{Service: "foo", RateLimit: &config.RateLimit{EventsPerSecond: 1}})
+	bar := config.NewLogSource("bar", &config.LogsConfig{Service: "bar"})
+
+	msg := newMessage([]byte("hello"), foo, "")
+	rateLimiter.Sample(msg, msg.Content)
+	assert.Len(t, rateLimiter.sources, 1)
+	assert.Len(t, rateLimiter.services, 1)
+
+	// foo is idle since a minute, its buckets are evicted when bar sends a log
+	*now = now.Add(bucketIdleTimeout)
+	msg = newMessage([]byte("hello"), bar, "")
+	rateLimiter.Sample(msg, msg.Content)
+	assert.Len(t, rateLimiter.sources, 0)
+	assert.Len(t, rateLimiter.services, 1)
+	assert.Contains(t, rateLimiter.services, "bar")
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+// unknownRuleSetMessageKey is the key of the status message of the sources
+// referencing a scrubbing rule set that does not exist
+const unknownRuleSetMessageKey = "unknown_scrubbing_rule_set"
+
+// Scrubber scrubs the sensitive data of the logs with the scrubbing rule sets
+// applied to all logs, then with the ones applied to the source of the log.
+type Scrubber struct {
+	ruleSets   config.ScrubbingRuleSets
+	globalSets []string
+}
+
+// NewScrubber returns a new Scrubber applying globalSets to all logs.
+func NewScrubber(ruleSets config.ScrubbingRuleSets, globalSets []string) (*Scrubber, error) {
+	for _, name := range globalSets {
+		if _, exists := ruleSets[name]; !exists {
+			return nil, fmt.Errorf("unknown scrubbing rule set %s", name)
+		}
+	}
+	return &Scrubber{
+		ruleSets:   ruleSets,
+		globalSets: globalSets,
+	}, nil
+}
+
+// Scrub returns the content with the sensitive data scrubbed,
+// a nil Scrubber returns the content unchanged.
+func (s *Scrubber) Scrub(content []byte, source *config.LogSource) []byte {
+	if s == nil {
+		return content
+	}
+	content = s.scrubWith(content, s.globalSets)
+	if source != nil && len(source.Config.ScrubbingRuleSets) > 0 {
+		for _, name := range source.Config.ScrubbingRuleSets {
+			if _, exists := s.ruleSets[name]; !exists {
+				source.Messages.AddMessage(unknownRuleSetMessageKey, fmt.Sprintf("Unknown scrubbing rule set %s, define it in logs_config.scrubbing_rules", name))
+			}
+		}
+		content = s.scrubWith(content, source.Config.ScrubbingRuleSets)
+	}
+	return content
+}
+
+func (s *Scrubber) scrubWith(content []byte, sets []string) []byte {
+	for _, set := range sets {
+		for _, rule := range s.ruleSets[set] {
+			var count int
+			content, count = rule.Scrub(content)
+			if count > 0 {
+				metrics.LogsScrubbed.Add(set+"/"+rule.Name, int64(count))
+				metrics.TlmLogsScrubbed.Add(float64(count), set, rule.Name)
+			}
+		}
+	}
+	return content
+}
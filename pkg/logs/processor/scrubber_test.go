@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+func newScrubbingRuleSets(t *testing.T) config.ScrubbingRuleSets {
+	sets := config.ScrubbingRuleSets{
+		"emails": {{Type: config.ReplaceSequences, Name: "email", Pattern: `\w+@\w+\.com`, ReplacePlaceholder: "[email]"}},
+		"tokens": {{Type: config.PartialMaskSequences, Name: "token", Pattern: `tok_\w+`, KeepFirst: 4}},
+	}
+	require.NoError(t, config.CompileScrubbingRuleSets(sets))
+	return sets
+}
+
+func TestScrubber(t *testing.T) {
+	_, err := NewScrubber(newScrubbingRuleSets(t), []string{"unknown"})
+	assert.Error(t, err)
+
+	scrubber, err := NewScrubber(newScrubbingRuleSets(t), []string{"emails"})
+	require.NoError(t, err)
+
+	source := config.NewLogSource("", &config.LogsConfig{})
+	assert.Equal(t, "[email] tok_abc", string(scrubber.Scrub([]byte("john@example.com tok_abc"), source)))
+
+	source = config.NewLogSource("", &config.LogsConfig{ScrubbingRuleSets: []string{"tokens", "unknown"}})
+	assert.Equal(t, "[email] tok_***", string(scrubber.Scrub([]byte("john@example.com tok_abc"), source)))
+	assert.Len(t, source.Messages.GetMessages(), 1)
+
+	assert.Equal(t, "2", metrics.LogsScrubbed.Get("emails/email").String())
+	assert.Equal(t, "1", metrics.LogsScrubbed.Get("tokens/token").String())
+
+	// a nil scrubber leaves the content unchanged
+	var noop *Scrubber
+	assert.Equal(t, "john@example.com", string(noop.Scrub([]byte("john@example.com"), source)))
+}
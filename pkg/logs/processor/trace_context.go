@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// traceContextRefreshPeriod is the period at which the active traces are fetched from the trace agent
+const traceContextRefreshPeriod = time.Second
+
+// The correlation attributes set in the logs, like the tracers do when they inject the trace context
+const (
+	spanIDAttribute  = "span_id"
+	traceIDInjection = "dd.trace_id"
+	spanIDInjection  = "dd.span_id"
+)
+
+// dockerIdentifierPrefix prefixes the identifiers of the logs collected from the docker socket
+const dockerIdentifierPrefix = "docker:"
+
+// traceContext identifies the active trace of a container, as returned by the trace agent.
+type traceContext struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+}
+
+// TraceContextInjector injects the context of the active trace of a container in its logs,
+// so that the logs are correlated with the traces even when the loggers of the applications
+// can't be modified. The active traces of the containers are maintained by the trace agent.
+// It is shared by all the pipelines.
+type TraceContextInjector struct {
+	url       string
+	client    *http.Client
+	authToken func() string
+	mu        sync.RWMutex
+	contexts  map[string]traceContext
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewTraceContextInjector returns a new TraceContextInjector fetching the active traces from
+// the trace agent listening on port.
+func NewTraceContextInjector(port int) *TraceContextInjector {
+	return &TraceContextInjector{
+		url:       fmt.Sprintf("http://localhost:%d/v0.1/active_traces", port),
+		client:    &http.Client{Timeout: traceContextRefreshPeriod},
+		authToken: apiutil.GetAuthToken,
+		contexts:  make(map[string]traceContext),
+	}
+}
+
+// Start starts fetching the active traces.
+func (i *TraceContextInjector) Start() {
+	if i == nil {
+		return
+	}
+	i.stop = make(chan struct{})
+	i.done = make(chan struct{})
+	go i.run()
+}
+
+// Stop stops fetching the active traces.
+func (i *TraceContextInjector) Stop() {
+	if i == nil {
+		return
+	}
+	close(i.stop)
+	<-i.done
+}
+
+// run fetches the active traces until the injector is stopped.
+func (i *TraceContextInjector) run() {
+	defer close(i.done)
+	ticker := time.NewTicker(traceContextRefreshPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			contexts, err := i.fetch()
+			if err != nil {
+				log.Debugf("Could not fetch the active traces from the trace agent: %v", err)
+			}
+			i.mu.Lock()
+			i.contexts = contexts
+			i.mu.Unlock()
+		case <-i.stop:
+			return
+		}
+	}
+}
+
+// fetch returns the active trace of each container, the traces are not injected
+// anymore when the trace agent is not available.
+func (i *TraceContextInjector) fetch() (map[string]traceContext, error) {
+	req, err := http.NewRequest("GET", i.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+i.authToken())
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s, inject_trace_context must be enabled for the trace agent too", resp.Status)
+	}
+	var contexts map[string]traceContext
+	if err := json.NewDecoder(resp.Body).Decode(&contexts); err != nil {
+		return nil, err
+	}
+	return contexts, nil
+}
+
+// Inject returns the content of the message with the context of the active trace of its container,
+// in the `dd` attribute of the JSON logs or appended to the other logs. The logs already correlated
+// and the logs of the containers without active trace are returned as is.
+// A nil TraceContextInjector injects nothing.
+func (i *TraceContextInjector) Inject(msg *message.Message, content []byte) []byte {
+	if i == nil {
+		return content
+	}
+	containerID := containerIDOf(msg)
+	if containerID == "" {
+		return content
+	}
+	i.mu.RLock()
+	context, found := i.contexts[containerID]
+	i.mu.RUnlock()
+	if !found || bytes.Contains(content, []byte(traceIDInjection)) || bytes.Contains(content, []byte(`"`+traceIDAttribute+`"`)) {
+		return content
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var attributes map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		if err := decoder.Decode(&attributes); err == nil && !decoder.More() {
+			setTraceID(attributes, context.TraceID)
+			attributes[traceIDParentAttribute].(map[string]interface{})[spanIDAttribute] = context.SpanID
+			if injected, err := json.Marshal(attributes); err == nil {
+				return injected
+			}
+		}
+	}
+	return []byte(fmt.Sprintf("%s %s=%s %s=%s", content, traceIDInjection, context.TraceID, spanIDInjection, context.SpanID))
+}
+
+// containerIDOf returns the ID of the container the message was collected from.
+func containerIDOf(msg *message.Message) string {
+	if strings.HasPrefix(msg.Origin.Identifier, dockerIdentifierPrefix) {
+		return strings.TrimPrefix(msg.Origin.Identifier, dockerIdentifierPrefix)
+	}
+	if source := msg.Origin.LogSource; source != nil && strings.HasPrefix(source.Config.Identifier, containers.ContainerEntityPrefix) {
+		return strings.TrimPrefix(source.Config.Identifier, containers.ContainerEntityPrefix)
+	}
+	return ""
+}
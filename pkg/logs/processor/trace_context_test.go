@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestTraceContextInjector() *TraceContextInjector {
+	injector := NewTraceContextInjector(8126)
+	injector.contexts = map[string]traceContext{
+		"abc": {TraceID: "1234", SpanID: "5678"},
+	}
+	return injector
+}
+
+func TestTraceContextInjectorInjectsTheActiveTrace(t *testing.T) {
+	injector := newTestTraceContextInjector()
+	source := config.NewLogSource("", &config.LogsConfig{Identifier: "container_id://abc"})
+
+	msg := newMessage(nil, source, "")
+	assert.Equal(t, "hello dd.trace_id=1234 dd.span_id=5678", string(injector.Inject(msg, []byte("hello"))))
+	assert.Equal(t, `{"dd":{"env":"prod","span_id":"5678","trace_id":"1234"},"msg":"hello"}`, string(injector.Inject(msg, []byte(`{"msg":"hello","dd":{"env":"prod"}}`))))
+
+	// the logs collected from the docker socket
+	msg = message.NewMessage(nil, &message.Origin{Identifier: "docker:abc", LogSource: config.NewLogSource("", &config.LogsConfig{})}, "")
+	assert.Equal(t, "hello dd.trace_id=1234 dd.span_id=5678", string(injector.Inject(msg, []byte("hello"))))
+}
+
+func TestTraceContextInjectorKeepsTheOtherLogs(t *testing.T) {
+	injector := newTestTraceContextInjector()
+
+	msg := newMessage(nil, config.NewLogSource("", &config.LogsConfig{Identifier: "container_id://abc"}), "")
+	assert.Equal(t, "hello dd.trace_id=1", string(injector.Inject(msg, []byte("hello dd.trace_id=1"))))
+	assert.Equal(t, `{"trace_id":1}`, string(injector.Inject(msg, []byte(`{"trace_id":1}`))))
+
+	msg = newMessage(nil, config.NewLogSource("", &config.LogsConfig{Identifier: "container_id://def"}), "")
+	assert.Equal(t, "hello", string(injector.Inject(msg, []byte("hello"))))
+
+	msg = newMessage(nil, config.NewLogSource("", &config.LogsConfig{Path: "/var/log/app.log"}), "")
+	assert.Equal(t, "hello", string(injector.Inject(msg, []byte("hello"))))
+
+	var nilInjector *TraceContextInjector
+	assert.Equal(t, "hello", string(nilInjector.Inject(msg, []byte("hello"))))
+}
+
+func TestTraceContextInjectorFetchesTheActiveTraces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v0.1/active_traces", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"abc":{"trace_id":"1234","span_id":"5678"}}`))
+	}))
+	defer server.Close()
+
+	injector := NewTraceContextInjector(0)
+	injector.url = server.URL + "/v0.1/active_traces"
+	injector.authToken = func() string { return "token" }
+	contexts, err := injector.fetch()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]traceContext{"abc": {TraceID: "1234", SpanID: "5678"}}, contexts)
+
+	server.Config.Handler = http.NotFoundHandler()
+	_, err = injector.fetch()
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@
 package sender
 
 import (
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -17,29 +18,93 @@ import (
 const (
 	maxBatchSize   = 200
 	maxContentSize = 1000000
+	// maxBatchWait is the maximum time a batch waits before being sent
+	// when the batch wait is adapted to the latency of the destination
+	maxBatchWait = 10 * time.Second
+	// latencyWeight is the weight of the latency of the last payload
+	// in the moving average of the latency of the destination
+	latencyWeight = 0.2
 )
 
+// BatchConfig holds the limits of the batches and how they are sent.
+type BatchConfig struct {
+	MaxBatchSize      int
+	MaxContentSize    int
+	BatchWait         time.Duration
+	MaxConcurrentSend int
+	MaxInflight       int
+	Adaptive          bool
+}
+
+// inflightPayload holds the messages of a payload until it is sent
+type inflightPayload struct {
+	messages []*message.Message
+	sent     bool
+	done     chan struct{}
+}
+
 // batchStrategy contains all the logic to send logs in batch.
 type batchStrategy struct {
 	buffer     *MessageBuffer
 	serializer Serializer
 	batchWait  time.Duration
+	adaptive   bool
+	// inflight holds the payloads in the order they were built, so that their messages
+	// are forwarded in order whatever the order their sends complete
+	inflight  chan *inflightPayload
+	semaphore chan struct{}
+	// latency is the moving average of the time taken to send a payload
+	latency      time.Duration
+	latencyMutex sync.Mutex
 }
 
 // NewBatchStrategy returns a new batchStrategy.
 func NewBatchStrategy(serializer Serializer, batchWait time.Duration) Strategy {
+	return NewBatchStrategyWithConfig(serializer, BatchConfig{
+		MaxBatchSize:      maxBatchSize,
+		MaxContentSize:    maxContentSize,
+		BatchWait:         batchWait,
+		MaxConcurrentSend: 1,
+		MaxInflight:       1,
+	})
+}
+
+// NewBatchStrategyWithConfig returns a new batchStrategy sending up to MaxConcurrentSend
+// payloads concurrently, and building new payloads as long as less than MaxInflight are not sent yet.
+func NewBatchStrategyWithConfig(serializer Serializer, batchConfig BatchConfig) Strategy {
+	if batchConfig.MaxBatchSize < 1 {
+		batchConfig.MaxBatchSize = maxBatchSize
+	}
+	if batchConfig.MaxContentSize < 1 {
+		batchConfig.MaxContentSize = maxContentSize
+	}
+	if batchConfig.MaxConcurrentSend < 1 {
+		batchConfig.MaxConcurrentSend = 1
+	}
+	if batchConfig.MaxInflight < batchConfig.MaxConcurrentSend {
+		batchConfig.MaxInflight = batchConfig.MaxConcurrentSend
+	}
 	return &batchStrategy{
-		buffer:     NewMessageBuffer(maxBatchSize, maxContentSize),
+		buffer:     NewMessageBuffer(batchConfig.MaxBatchSize, batchConfig.MaxContentSize),
 		serializer: serializer,
-		batchWait:  batchWait,
+		batchWait:  batchConfig.BatchWait,
+		adaptive:   batchConfig.Adaptive,
+		inflight:   make(chan *inflightPayload, batchConfig.MaxInflight-1),
+		semaphore:  make(chan struct{}, batchConfig.MaxConcurrentSend),
 	}
 }
 
 // Send accumulates messages to a buffer and sends them when the buffer is full or outdated.
 func (s *batchStrategy) Send(inputChan chan *message.Message, outputChan chan *message.Message, send func([]byte) error) {
-	flushTimer := time.NewTimer(s.batchWait)
+	forwarderDone := make(chan struct{})
+	go s.forward(outputChan, forwarderDone)
+
+	flushTimer := time.NewTimer(s.flushWait())
 	defer func() {
 		flushTimer.Stop()
+		// wait for the messages of the inflight payloads to be forwarded
+		close(s.inflight)
+		<-forwarderDone
 	}()
 
 	for {
@@ -47,7 +112,7 @@ func (s *batchStrategy) Send(inputChan chan *message.Message, outputChan chan *m
 		case message, isOpen := <-inputChan:
 			if !isOpen {
 				// inputChan has been closed, no more payload are expected
-				s.sendBuffer(outputChan, send)
+				s.sendBuffer(send)
 				return
 			}
 			added := s.buffer.AddMessage(message)
@@ -61,8 +126,8 @@ func (s *batchStrategy) Send(inputChan chan *message.Message, outputChan chan *m
 					default:
 					}
 				}
-				s.sendBuffer(outputChan, send)
-				flushTimer.Reset(s.batchWait)
+				s.sendBuffer(send)
+				flushTimer.Reset(s.flushWait())
 			}
 			if !added {
 				// it's possible that the message could not be added because the buffer was full
@@ -72,34 +137,92 @@ func (s *batchStrategy) Send(inputChan chan *message.Message, outputChan chan *m
 		case <-flushTimer.C:
 			// the first message that was added to the buffer has been here for too long,
 			// send the payload now
-			s.sendBuffer(outputChan, send)
-			flushTimer.Reset(s.batchWait)
+			s.sendBuffer(send)
+			flushTimer.Reset(s.flushWait())
 		}
 	}
 }
 
-// sendBuffer sends all the messages that are stored in the buffer and forwards them
-// to the next stage of the pipeline.
-func (s *batchStrategy) sendBuffer(outputChan chan *message.Message, send func([]byte) error) {
+// sendBuffer sends all the messages that are stored in the buffer in background,
+// this call blocks when too many payloads are inflight.
+func (s *batchStrategy) sendBuffer(send func([]byte) error) {
 	if s.buffer.IsEmpty() {
 		return
 	}
 
-	messages := s.buffer.GetMessages()
-	defer s.buffer.Clear()
+	// the buffer is reused for the next payload while this one is sent
+	messages := make([]*message.Message, len(s.buffer.GetMessages()))
+	copy(messages, s.buffer.GetMessages())
+	s.buffer.Clear()
 
-	err := send(s.serializer.Serialize(messages))
-	if err != nil {
-		if shouldStopSending(err) {
-			return
+	payload := &inflightPayload{
+		messages: messages,
+		done:     make(chan struct{}),
+	}
+	s.inflight <- payload
+
+	s.semaphore <- struct{}{}
+	go func() {
+		defer func() {
+			<-s.semaphore
+			close(payload.done)
+		}()
+		start := time.Now()
+		err := send(s.serializer.Serialize(messages))
+		if err != nil {
+			if shouldStopSending(err) {
+				return
+			}
+			log.Warnf("Could not send payload: %v", err)
+		}
+		s.observeLatency(time.Since(start))
+		payload.sent = true
+
+		metrics.LogsSent.Add(int64(len(messages)))
+		metrics.TlmLogsSent.Add(float64(len(messages)))
+	}()
+}
+
+// forward forwards the messages of the payloads to the next stage of the pipeline
+// once they are sent, in the order the payloads were built.
+func (s *batchStrategy) forward(outputChan chan *message.Message, done chan struct{}) {
+	defer close(done)
+	for payload := range s.inflight {
+		<-payload.done
+		if !payload.sent {
+			continue
+		}
+		for _, message := range payload.messages {
+			outputChan <- message
 		}
-		log.Warnf("Could not send payload: %v", err)
 	}
+}
 
-	metrics.LogsSent.Add(int64(len(messages)))
-	metrics.TlmLogsSent.Add(float64(len(messages)))
+// observeLatency updates the moving average of the latency of the destination.
+func (s *batchStrategy) observeLatency(latency time.Duration) {
+	s.latencyMutex.Lock()
+	defer s.latencyMutex.Unlock()
+	if s.latency == 0 {
+		s.latency = latency
+		return
+	}
+	s.latency = time.Duration((1-latencyWeight)*float64(s.latency) + latencyWeight*float64(latency))
+}
 
-	for _, message := range messages {
-		outputChan <- message
+// flushWait returns how long a batch waits before being sent. With adaptive batching,
+// batches wait longer when the destination is slow so that fewer and larger payloads are sent.
+func (s *batchStrategy) flushWait() time.Duration {
+	if !s.adaptive {
+		return s.batchWait
+	}
+	s.latencyMutex.Lock()
+	defer s.latencyMutex.Unlock()
+	wait := s.batchWait
+	if s.latency > wait {
+		wait = s.latency
+	}
+	if wait > maxBatchWait {
+		wait = maxBatchWait
 	}
+	return wait
 }
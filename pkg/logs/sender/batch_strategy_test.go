@@ -17,11 +17,11 @@ import (
 
 // newBatchStrategyWithLimits returns a new batchStrategy.
 func newBatchStrategyWithLimits(serializer Serializer, batchSize int, contentSize int, batchWait time.Duration) Strategy {
-	return &batchStrategy{
-		buffer:     NewMessageBuffer(batchSize, contentSize),
-		serializer: serializer,
-		batchWait:  batchWait,
-	}
+	return NewBatchStrategyWithConfig(serializer, BatchConfig{
+		MaxBatchSize:   batchSize,
+		MaxContentSize: contentSize,
+		BatchWait:      batchWait,
+	})
 }
 
 func TestBatchStrategySendsPayloadWhenBufferIsFull(t *testing.T) {
@@ -149,3 +149,65 @@ func TestBatchStrategyShouldNotBlockWhenStoppingGracefully(t *testing.T) {
 
 	newBatchStrategyWithLimits(LineSerializer, 2, 2, 100*time.Millisecond).Send(input, output, success)
 }
+
+func TestBatchStrategySendsPayloadsConcurrentlyAndForwardsThemInOrder(t *testing.T) {
+	input := make(chan *message.Message)
+	output := make(chan *message.Message)
+
+	sending := make(chan []byte, 2)
+	release := make(chan struct{})
+	send := func(payload []byte) error {
+		sending <- payload
+		<-release
+		return nil
+	}
+
+	strategy := NewBatchStrategyWithConfig(LineSerializer, BatchConfig{
+		MaxBatchSize:      1,
+		MaxContentSize:    10,
+		BatchWait:         time.Hour,
+		MaxConcurrentSend: 2,
+		MaxInflight:       2,
+	})
+	go strategy.Send(input, output, send)
+
+	message1 := message.NewMessage([]byte("a"), nil, "")
+	input <- message1
+	message2 := message.NewMessage([]byte("b"), nil, "")
+	input <- message2
+
+	// both payloads are sent concurrently
+	payloads := [][]byte{<-sending, <-sending}
+	assert.ElementsMatch(t, [][]byte{[]byte("a"), []byte("b")}, payloads)
+
+	release <- struct{}{}
+	release <- struct{}{}
+
+	// the messages are forwarded in order whatever the order the sends complete
+	assert.Equal(t, message1, <-output)
+	assert.Equal(t, message2, <-output)
+	close(input)
+}
+
+func TestBatchStrategyAdaptsBatchWaitToLatency(t *testing.T) {
+	strategy := NewBatchStrategyWithConfig(LineSerializer, BatchConfig{
+		BatchWait: time.Second,
+		Adaptive:  true,
+	}).(*batchStrategy)
+
+	assert.Equal(t, time.Second, strategy.flushWait())
+
+	strategy.observeLatency(100 * time.Millisecond)
+	assert.Equal(t, time.Second, strategy.flushWait())
+
+	strategy.observeLatency(20*time.Second + 100*time.Millisecond)
+	assert.Equal(t, 4100*time.Millisecond, strategy.flushWait())
+
+	for i := 0; i < 20; i++ {
+		strategy.observeLatency(30 * time.Second)
+	}
+	assert.Equal(t, maxBatchWait, strategy.flushWait())
+
+	strategy.adaptive = false
+	assert.Equal(t, time.Second, strategy.flushWait())
+}
@@ -0,0 +1,344 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+const (
+	// diskBufferFileExtension is the extension of the files holding the buffered payloads,
+	// their name is the sequence number of the payload so that they are replayed in order
+	diskBufferFileExtension = ".buf"
+	// diskBufferTempExtension is the extension of the files being written
+	diskBufferTempExtension = ".tmp"
+	// diskBufferHeaderSize is the size of the header of a buffered payload:
+	// the magic number, the time it was stored, the checksum and the length of the payload
+	diskBufferHeaderSize = 4 + 8 + 4 + 4
+	// diskBufferRetryWait is the time waited before replaying a payload again
+	// when the destination is not available
+	diskBufferRetryWait = 5 * time.Second
+)
+
+// Reasons a payload is dropped from the disk buffer.
+const (
+	droppedBySize      = "size"
+	droppedByAge       = "age"
+	droppedByCorrupted = "corrupted"
+	droppedByRejected  = "rejected"
+)
+
+// diskBufferMagic identifies the files written by the disk buffer.
+var diskBufferMagic = []byte("DDLB")
+
+var errCorruptedPayload = errors.New("corrupted payload")
+
+// diskBufferEntry is a payload stored on disk.
+type diskBufferEntry struct {
+	sequence uint64
+	size     int64
+}
+
+// DiskBuffer stores on disk the payloads that could not be sent to the main destination
+// so that they are not lost when the agent restarts, and replays them in order
+// once the destination is available again. It is shared by all the pipelines:
+// as soon as a replayed payload is accepted, the pipelines send their new payloads
+// directly again instead of queueing them behind the backlog, so the replay does not
+// cap their throughput but the buffered payloads may arrive after newer ones.
+type DiskBuffer struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+
+	mu           sync.Mutex
+	entries      []diskBufferEntry
+	size         int64
+	nextSequence uint64
+	// healthy is true when the last payload sent to the destination was accepted
+	healthy bool
+
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewDiskBuffer returns a new DiskBuffer storing at most maxSize bytes of payloads in path
+// and dropping the payloads older than maxAge, the payloads already in path are kept to be replayed.
+func NewDiskBuffer(path string, maxSize int64, maxAge time.Duration) (*DiskBuffer, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	b := &DiskBuffer{
+		path:    path,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		notify:  make(chan struct{}, 1),
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Start replays the buffered payloads to the destination in background.
+func (b *DiskBuffer) Start(destination client.Destination) {
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.replay(destination)
+}
+
+// Stop stops replaying the buffered payloads, the payloads not replayed yet are kept on disk.
+func (b *DiskBuffer) Stop() {
+	close(b.stop)
+	<-b.done
+}
+
+// IsEmpty returns true if no payload is buffered.
+func (b *DiskBuffer) IsEmpty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries) == 0
+}
+
+// ShouldBuffer returns true if the new payloads should be buffered without trying
+// the destination, that is while the buffered payloads are still not accepted.
+func (b *DiskBuffer) ShouldBuffer() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries) > 0 && !b.healthy
+}
+
+// setHealthy records whether the destination accepted the last payload sent.
+func (b *DiskBuffer) setHealthy(healthy bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthy = healthy
+}
+
+// Store writes the payload on disk, dropping the oldest payloads when the buffer is full.
+func (b *DiskBuffer) Store(payload []byte) error {
+	size := int64(diskBufferHeaderSize + len(payload))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// payloads are only stored when the destination is not available
+	b.healthy = false
+
+	if size > b.maxSize {
+		return fmt.Errorf("payload of %d bytes exceeds the size of the disk buffer", len(payload))
+	}
+	for len(b.entries) > 0 && b.size+size > b.maxSize {
+		b.remove(droppedBySize)
+	}
+
+	sequence := b.nextSequence
+	if err := b.write(sequence, payload, time.Now()); err != nil {
+		return err
+	}
+	b.nextSequence++
+	b.entries = append(b.entries, diskBufferEntry{sequence: sequence, size: size})
+	b.addSize(size)
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// replay sends the buffered payloads to the destination in the order they were stored,
+// a payload is only removed from disk once it is sent.
+func (b *DiskBuffer) replay(destination client.Destination) {
+	defer close(b.done)
+	for {
+		sequence, payload, ok := b.peek()
+		if !ok {
+			select {
+			case <-b.notify:
+				continue
+			case <-b.stop:
+				return
+			}
+		}
+		err := destination.Send(payload)
+		if err != nil {
+			if shouldStopSending(err) {
+				return
+			}
+			metrics.DestinationErrors.Add(1)
+			metrics.TlmDestinationErrors.Inc()
+			if _, ok := err.(*client.RetryableError); ok {
+				// the destination is still not available, keep the payload for later
+				b.setHealthy(false)
+				select {
+				case <-time.After(diskBufferRetryWait):
+					continue
+				case <-b.stop:
+					return
+				}
+			}
+			log.Warnf("Could not replay buffered payload: %v", err)
+			b.pop(sequence, droppedByRejected)
+			continue
+		}
+		b.setHealthy(true)
+		b.pop(sequence, "")
+	}
+}
+
+// peek returns the oldest buffered payload,
+// the payloads that are too old or corrupted are dropped on the way.
+func (b *DiskBuffer) peek() (uint64, []byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.entries) > 0 {
+		payload, storedAt, err := b.read(b.entries[0].sequence)
+		if err != nil {
+			log.Warnf("Could not read buffered payload %s: %v", b.filename(b.entries[0].sequence), err)
+			b.remove(droppedByCorrupted)
+			continue
+		}
+		if b.maxAge > 0 && time.Since(storedAt) > b.maxAge {
+			b.remove(droppedByAge)
+			continue
+		}
+		return b.entries[0].sequence, payload, true
+	}
+	return 0, nil, false
+}
+
+// pop removes the payload returned by peek unless it was already dropped to make room
+// for new payloads, reason is empty when the payload was sent.
+func (b *DiskBuffer) pop(sequence uint64, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) > 0 && b.entries[0].sequence == sequence {
+		b.remove(reason)
+	}
+}
+
+// remove deletes the oldest buffered payload from disk.
+func (b *DiskBuffer) remove(reason string) {
+	entry := b.entries[0]
+	b.entries = b.entries[1:]
+	b.addSize(-entry.size)
+	if err := os.Remove(b.filename(entry.sequence)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove buffered payload: %v", err)
+	}
+	if reason != "" {
+		b.drop(reason)
+	}
+}
+
+// drop counts a payload dropped for reason.
+func (b *DiskBuffer) drop(reason string) {
+	metrics.DiskBufferDropped.Add(reason, 1)
+	metrics.TlmDiskBufferDropped.Inc(reason)
+}
+
+// addSize updates the number of bytes stored on disk.
+func (b *DiskBuffer) addSize(size int64) {
+	b.size += size
+	metrics.DiskBufferBytes.Add(size)
+	metrics.TlmDiskBufferBytes.Set(float64(metrics.DiskBufferBytes.Value()))
+}
+
+// load collects the payloads left on disk by a previous run.
+func (b *DiskBuffer) load() error {
+	files, err := ioutil.ReadDir(b.path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasSuffix(name, diskBufferTempExtension) {
+			// the agent stopped while writing the payload
+			os.Remove(filepath.Join(b.path, name))
+			continue
+		}
+		if !strings.HasSuffix(name, diskBufferFileExtension) {
+			continue
+		}
+		sequence, err := strconv.ParseUint(strings.TrimSuffix(name, diskBufferFileExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+		b.entries = append(b.entries, diskBufferEntry{sequence: sequence, size: file.Size()})
+		b.addSize(file.Size())
+		if sequence >= b.nextSequence {
+			b.nextSequence = sequence + 1
+		}
+	}
+	sort.Slice(b.entries, func(i, j int) bool {
+		return b.entries[i].sequence < b.entries[j].sequence
+	})
+	for len(b.entries) > 0 && b.size > b.maxSize {
+		b.remove(droppedBySize)
+	}
+	return nil
+}
+
+// write stores the payload in a new file, the file is renamed once written
+// so that a partially written payload is never replayed.
+func (b *DiskBuffer) write(sequence uint64, payload []byte, storedAt time.Time) error {
+	buf := bytes.NewBuffer(make([]byte, 0, diskBufferHeaderSize+len(payload)))
+	buf.Write(diskBufferMagic)
+	binary.Write(buf, binary.BigEndian, storedAt.UnixNano())
+	binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(payload))
+	binary.Write(buf, binary.BigEndian, uint32(len(payload)))
+	buf.Write(payload)
+
+	filename := b.filename(sequence)
+	tempFilename := filename + diskBufferTempExtension
+	if err := ioutil.WriteFile(tempFilename, buf.Bytes(), 0600); err != nil {
+		os.Remove(tempFilename)
+		return err
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+// read returns the payload stored in a file and the time it was stored,
+// it fails when the content of the file does not match its header.
+func (b *DiskBuffer) read(sequence uint64) ([]byte, time.Time, error) {
+	content, err := ioutil.ReadFile(b.filename(sequence))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(content) < diskBufferHeaderSize || !bytes.Equal(content[:4], diskBufferMagic) {
+		return nil, time.Time{}, errCorruptedPayload
+	}
+	storedAt := time.Unix(0, int64(binary.BigEndian.Uint64(content[4:12])))
+	checksum := binary.BigEndian.Uint32(content[12:16])
+	length := binary.BigEndian.Uint32(content[16:20])
+	payload := content[diskBufferHeaderSize:]
+	if uint32(len(payload)) != length || crc32.ChecksumIEEE(payload) != checksum {
+		return nil, time.Time{}, errCorruptedPayload
+	}
+	return payload, storedAt, nil
+}
+
+// filename returns the path of the file holding a payload.
+func (b *DiskBuffer) filename(sequence uint64) string {
+	return filepath.Join(b.path, fmt.Sprintf("%020d%s", sequence, diskBufferFileExtension))
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package sender
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+)
+
+// fakeDestination records the payloads it sends, it fails with a retryable error when unavailable.
+type fakeDestination struct {
+	available bool
+	payloads  chan []byte
+}
+
+func newFakeDestination(available bool) *fakeDestination {
+	return &fakeDestination{
+		available: available,
+		payloads:  make(chan []byte, 10),
+	}
+}
+
+func (d *fakeDestination) Send(payload []byte) error {
+	if !d.available {
+		return client.NewRetryableError(errors.New("unavailable"))
+	}
+	d.payloads <- payload
+	return nil
+}
+
+func (d *fakeDestination) SendAsync(payload []byte) {}
+
+func newTestDiskBuffer(t *testing.T, maxSize int64, maxAge time.Duration) (*DiskBuffer, string) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	assert.Nil(t, err)
+	buffer, err := NewDiskBuffer(path, maxSize, maxAge)
+	assert.Nil(t, err)
+	return buffer, path
+}
+
+func TestDiskBufferReplaysPayloadsInOrder(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	assert.True(t, buffer.IsEmpty())
+	assert.Nil(t, buffer.Store([]byte("a")))
+	assert.Nil(t, buffer.Store([]byte("b")))
+	assert.Nil(t, buffer.Store([]byte("c")))
+	assert.False(t, buffer.IsEmpty())
+
+	destination := newFakeDestination(true)
+	buffer.Start(destination)
+	defer buffer.Stop()
+
+	assert.Equal(t, []byte("a"), <-destination.payloads)
+	assert.Equal(t, []byte("b"), <-destination.payloads)
+	assert.Equal(t, []byte("c"), <-destination.payloads)
+
+	assert.Nil(t, buffer.Store([]byte("d")))
+	assert.Equal(t, []byte("d"), <-destination.payloads)
+}
+
+func TestDiskBufferKeepsPayloadsAcrossRestarts(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	buffer.Start(newFakeDestination(false))
+	assert.Nil(t, buffer.Store([]byte("a")))
+	assert.Nil(t, buffer.Store([]byte("b")))
+	buffer.Stop()
+
+	buffer, err := NewDiskBuffer(path, 1000, time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, buffer.IsEmpty())
+
+	assert.Nil(t, buffer.Store([]byte("c")))
+	sequence, payload, ok := buffer.peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), payload)
+	buffer.pop(sequence, "")
+	_, payload, _ = buffer.peek()
+	assert.Equal(t, []byte("b"), payload)
+	buffer.pop(sequence+1, "")
+	_, payload, _ = buffer.peek()
+	assert.Equal(t, []byte("c"), payload)
+}
+
+func TestDiskBufferDropsOldestPayloadsWhenFull(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 2*(diskBufferHeaderSize+1), time.Hour)
+	defer os.RemoveAll(path)
+
+	assert.Nil(t, buffer.Store([]byte("a")))
+	assert.Nil(t, buffer.Store([]byte("b")))
+	assert.Nil(t, buffer.Store([]byte("c")))
+	assert.NotNil(t, buffer.Store(make([]byte, 2*(diskBufferHeaderSize+1))))
+
+	_, payload, ok := buffer.peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), payload)
+	assert.Equal(t, int64(2*(diskBufferHeaderSize+1)), buffer.size)
+}
+
+func TestDiskBufferDropsExpiredPayloads(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	assert.Nil(t, buffer.write(0, []byte("a"), time.Now().Add(-2*time.Hour)))
+	buffer.entries = append(buffer.entries, diskBufferEntry{sequence: 0, size: diskBufferHeaderSize + 1})
+	buffer.nextSequence = 1
+	assert.Nil(t, buffer.Store([]byte("b")))
+
+	_, payload, ok := buffer.peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), payload)
+}
+
+func TestDiskBufferDropsCorruptedPayloads(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	assert.Nil(t, buffer.Store([]byte("a")))
+	assert.Nil(t, buffer.Store([]byte("b")))
+	content, err := ioutil.ReadFile(buffer.filename(0))
+	assert.Nil(t, err)
+	content[len(content)-1] = 'z'
+	assert.Nil(t, ioutil.WriteFile(buffer.filename(0), content, 0600))
+
+	_, payload, ok := buffer.peek()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), payload)
+	_, err = os.Stat(buffer.filename(0))
+	assert.True(t, os.IsNotExist(err))
+}
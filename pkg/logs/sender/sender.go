@@ -8,6 +8,8 @@ package sender
 import (
 	"context"
 
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
@@ -25,16 +27,19 @@ type Sender struct {
 	outputChan   chan *message.Message
 	destinations *client.Destinations
 	strategy     Strategy
+	diskBuffer   *DiskBuffer
 	done         chan struct{}
 }
 
-// NewSender returns a new sender.
-func NewSender(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy) *Sender {
+// NewSender returns a new sender,
+// the payloads that can't be sent to the main destination are stored in diskBuffer when it is not nil.
+func NewSender(inputChan chan *message.Message, outputChan chan *message.Message, destinations *client.Destinations, strategy Strategy, diskBuffer *DiskBuffer) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
 		strategy:     strategy,
+		diskBuffer:   diskBuffer,
 		done:         make(chan struct{}),
 	}
 }
@@ -59,14 +64,26 @@ func (s *Sender) run() {
 }
 
 // send sends a payload to multiple destinations,
-// it will forever retry for the main destination unless the error is not retryable
+// the payloads that can't be sent to the main destination are stored in the disk buffer when it is enabled,
+// otherwise it will forever retry for the main destination unless the error is not retryable,
 // and only try once for additionnal destinations.
 func (s *Sender) send(payload []byte) error {
+	if s.diskBuffer != nil && s.diskBuffer.ShouldBuffer() && s.storeInDiskBuffer(payload) {
+		// the destination did not accept the buffered payloads yet,
+		// this one is buffered too instead of failing again
+		s.sendToAdditionals(payload)
+		return nil
+	}
 	for {
 		err := s.destinations.Main.Send(payload)
 		if err != nil {
-			metrics.DestinationErrors.Add(1)
-			metrics.TlmDestinationErrors.Inc()
+			if !shouldStopSending(err) {
+				metrics.DestinationErrors.Add(1)
+				metrics.TlmDestinationErrors.Inc()
+			}
+			if s.storeInDiskBuffer(payload) {
+				break
+			}
 			if _, ok := err.(*client.RetryableError); ok {
 				// could not send the payload because of a client issue,
 				// let's retry
@@ -77,13 +94,29 @@ func (s *Sender) send(payload []byte) error {
 		break
 	}
 
+	s.sendToAdditionals(payload)
+	return nil
+}
+
+// storeInDiskBuffer returns true if the payload is stored in the disk buffer to be replayed later.
+func (s *Sender) storeInDiskBuffer(payload []byte) bool {
+	if s.diskBuffer == nil {
+		return false
+	}
+	if err := s.diskBuffer.Store(payload); err != nil {
+		log.Warnf("Could not store payload in the disk buffer: %v", err)
+		return false
+	}
+	return true
+}
+
+// sendToAdditionals sends a payload to the additional destinations.
+func (s *Sender) sendToAdditionals(payload []byte) {
 	for _, destination := range s.destinations.Additionals {
 		// send in the background so that the agent does not fall behind
 		// for the main destination
 		destination.SendAsync(payload)
 	}
-
-	return nil
 }
 
 // shouldStopSending returns true if a component should stop sending logs.
@@ -6,7 +6,10 @@
 package sender
 
 import (
+	"errors"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -36,7 +39,7 @@ func TestSender(t *testing.T) {
 	destination := tcp.AddrToDestination(l.Addr(), destinationsCtx)
 	destinations := client.NewDestinations(destination, nil)
 
-	sender := NewSender(input, output, destinations, StreamStrategy)
+	sender := NewSender(input, output, destinations, StreamStrategy, nil)
 	sender.Start()
 
 	expectedMessage := newMessage([]byte("fake line"), source, "")
@@ -69,7 +72,7 @@ func TestSenderNotBlockedByAdditional(t *testing.T) {
 	additionalDestination := tcp.NewDestination(config.Endpoint{Host: "dont.exist.local", Port: 0}, true, destinationsCtx)
 	destinations := client.NewDestinations(mainDestination, []client.Destination{additionalDestination})
 
-	sender := NewSender(input, output, destinations, StreamStrategy)
+	sender := NewSender(input, output, destinations, StreamStrategy, nil)
 	sender.Start()
 
 	expectedMessage1 := newMessage([]byte("fake line"), source, "")
@@ -87,3 +90,53 @@ func TestSenderNotBlockedByAdditional(t *testing.T) {
 	sender.Stop()
 	destinationsCtx.Stop()
 }
+
+// rejectingDestination fails to send every payload with an error that is not retryable
+type rejectingDestination struct{}
+
+func (d *rejectingDestination) Send(payload []byte) error { return errors.New("rejected") }
+func (d *rejectingDestination) SendAsync(payload []byte)  {}
+
+func TestSenderBuffersOnAllErrors(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	destinations := client.NewDestinations(&rejectingDestination{}, nil)
+	sender := NewSender(nil, nil, destinations, StreamStrategy, buffer)
+	assert.Nil(t, sender.send([]byte("a")))
+	assert.False(t, buffer.IsEmpty())
+
+	// without disk buffer the error is returned
+	sender = NewSender(nil, nil, destinations, StreamStrategy, nil)
+	assert.NotNil(t, sender.send([]byte("a")))
+}
+
+func TestSenderBypassesDiskBufferOnceDestinationIsHealthy(t *testing.T) {
+	buffer, path := newTestDiskBuffer(t, 1000, time.Hour)
+	defer os.RemoveAll(path)
+
+	assert.Nil(t, buffer.Store([]byte("a")))
+	assert.Nil(t, buffer.Store([]byte("b")))
+	assert.True(t, buffer.ShouldBuffer())
+
+	destination := newFakeDestination(true)
+	buffer.Start(destination)
+	defer buffer.Stop()
+	assert.Equal(t, []byte("a"), <-destination.payloads)
+
+	// the destination accepted a buffered payload, the new ones are not queued behind the backlog
+	for buffer.ShouldBuffer() {
+		time.Sleep(time.Millisecond)
+	}
+	sender := NewSender(nil, nil, client.NewDestinations(destination, nil), StreamStrategy, buffer)
+	assert.Nil(t, sender.send([]byte("c")))
+
+	payloads := [][]byte{<-destination.payloads, <-destination.payloads}
+	assert.ElementsMatch(t, [][]byte{[]byte("b"), []byte("c")}, payloads)
+
+	// the destination fails again, the new payloads are buffered until it accepts one
+	destination.available = false
+	sender = NewSender(nil, nil, client.NewDestinations(destination, nil), StreamStrategy, buffer)
+	assert.Nil(t, sender.send([]byte("d")))
+	assert.True(t, buffer.ShouldBuffer())
+}
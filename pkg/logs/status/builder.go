@@ -43,6 +43,7 @@ func (b *Builder) BuildStatus() Status {
 		Endpoints:     b.getEndpoints(),
 		Integrations:  b.getIntegrations(),
 		StatusMetrics: b.getMetricsStatus(),
+		ScrubbedLogs:  b.getScrubbedLogs(),
 		Warnings:      b.getWarnings(),
 		Errors:        b.getErrors(),
 		UseHTTP:       b.getUseHTTP(),
@@ -170,6 +171,11 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 	switch c.Type {
 	case config.TCPType, config.UDPType:
 		dictionary["Port"] = c.Port
+	case config.SyslogType:
+		dictionary["Port"] = c.Port
+		dictionary["Protocol"] = c.Protocol
+	case config.KubeAuditType:
+		dictionary["Port"] = c.Port
 	case config.FileType:
 		dictionary["Path"] = c.Path
 		dictionary["TailingMode"] = c.TailingMode
@@ -180,6 +186,15 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 	case config.JournaldType:
 		dictionary["IncludeUnits"] = strings.Join(c.IncludeUnits, ", ")
 		dictionary["ExcludeUnits"] = strings.Join(c.ExcludeUnits, ", ")
+		dictionary["Namespace"] = c.JournalNamespace
+		dictionary["ConfigID"] = c.ConfigID
+	case config.KafkaType:
+		dictionary["Brokers"] = strings.Join(c.Brokers, ", ")
+		dictionary["Topics"] = strings.Join(c.Topics, ", ")
+		dictionary["ConsumerGroup"] = c.ConsumerGroup
+	case config.CloudFoundryType:
+		dictionary["URL"] = c.URL
+		dictionary["ShardID"] = c.ShardID
 	case config.WindowsEventType:
 		dictionary["ChannelPath"] = c.ChannelPath
 		dictionary["Query"] = c.Query
@@ -201,3 +216,16 @@ func (b *Builder) getMetricsStatus() map[string]int64 {
 	metrics["EncodedBytesSent"] = b.logsExpVars.Get("EncodedBytesSent").(*expvar.Int).Value()
 	return metrics
 }
+
+// getScrubbedLogs exposes the number of sequences scrubbed per scrubbing rule on the agent status
+func (b *Builder) getScrubbedLogs() map[string]int64 {
+	scrubbed := make(map[string]int64)
+	if counters, ok := b.logsExpVars.Get("LogsScrubbed").(*expvar.Map); ok {
+		counters.Do(func(kv expvar.KeyValue) {
+			if count, ok := kv.Value.(*expvar.Int); ok {
+				scrubbed[kv.Key] = count.Value()
+			}
+		})
+	}
+	return scrubbed
+}
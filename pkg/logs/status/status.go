@@ -39,6 +39,7 @@ type Status struct {
 	IsRunning     bool             `json:"is_running"`
 	Endpoints     []string         `json:"endpoints"`
 	StatusMetrics map[string]int64 `json:"metrics"`
+	ScrubbedLogs  map[string]int64 `json:"scrubbed"`
 	Integrations  []Integration    `json:"integrations"`
 	Errors        []string         `json:"errors"`
 	Warnings      []string         `json:"warnings"`
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/azure"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/gce"
@@ -43,6 +44,50 @@ func appendAndSplitTags(target []string, tags []string, splits map[string]string
 	return target
 }
 
+type instanceTagsProvider struct {
+	name    string
+	getTags func() ([]string, error)
+}
+
+// instanceTagsProviders derive host tags from the cloud provider instance metadata
+var instanceTagsProviders = []instanceTagsProvider{
+	{ec2.CloudProviderName, ec2.GetInstanceMetadataTags},
+	{gce.CloudProviderName, gce.GetInstanceMetadataTags},
+	{azure.CloudProviderName, azure.GetInstanceMetadataTags},
+}
+
+// getInstanceMetadataTags returns the instance metadata tags whose name is in
+// the allowlist, from the first cloud provider whose metadata endpoint answers.
+func getInstanceMetadataTags(allowlist []string) []string {
+	if len(allowlist) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = struct{}{}
+	}
+
+	for _, provider := range instanceTagsProviders {
+		rawTags, err := provider.getTags()
+		if err != nil {
+			log.Debugf("No %s instance metadata tags %v", provider.name, err)
+			continue
+		}
+
+		tags := make([]string, 0, len(rawTags))
+		for _, tag := range rawTags {
+			name := strings.SplitN(tag, ":", 2)[0]
+			if _, ok := allowed[name]; ok {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+
+	return nil
+}
+
 func getHostTags() *tags {
 	splits := config.Datadog.GetStringMapString("tag_value_split_separator")
 	appendToHostTags := func(old, new []string) []string {
@@ -67,6 +112,10 @@ func getHostTags() *tags {
 		}
 	}
 
+	if instanceTags := getInstanceMetadataTags(config.Datadog.GetStringSlice("cloud_instance_metadata_tags")); len(instanceTags) > 0 {
+		hostTags = appendToHostTags(hostTags, instanceTags)
+	}
+
 	clusterName := clustername.GetClusterName()
 	if len(clusterName) != 0 {
 		hostTags = appendToHostTags(hostTags, []string{"cluster_name:" + clusterName})
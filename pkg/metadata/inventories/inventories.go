@@ -8,6 +8,7 @@ package inventories
 import (
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -45,6 +46,9 @@ var (
 	lastGetPayloadMutex = &sync.Mutex{}
 
 	metadataUpdatedC = make(chan interface{}, 1)
+
+	// payloadRevision is incremented every time the metadata changes
+	payloadRevision uint64
 )
 
 var (
@@ -59,6 +63,17 @@ const (
 	CloudProviderMetatadaName = "cloud_provider"
 )
 
+// notifyMetadataUpdated bumps the payload revision and signals the
+// metadataUpdatedC channel to send the updated payload.
+func notifyMetadataUpdated() {
+	atomic.AddUint64(&payloadRevision, 1)
+
+	select {
+	case metadataUpdatedC <- nil:
+	default: // To make sure this call is not blocking
+	}
+}
+
 // SetAgentMetadata updates the agent metadata value in the cache
 func SetAgentMetadata(name string, value interface{}) {
 	agentCacheMutex.Lock()
@@ -67,10 +82,7 @@ func SetAgentMetadata(name string, value interface{}) {
 	if agentMetadataCache[name] != value {
 		agentMetadataCache[name] = value
 
-		select {
-		case metadataUpdatedC <- nil:
-		default: // To make sure this call is not blocking
-		}
+		notifyMetadataUpdated()
 	}
 }
 
@@ -91,10 +103,7 @@ func SetCheckMetadata(checkID, key string, value interface{}) {
 		entry.LastUpdated = timeNow()
 		entry.CheckInstanceMetadata[key] = value
 
-		select {
-		case metadataUpdatedC <- nil:
-		default: // To make sure this call is not blocking
-		}
+		notifyMetadataUpdated()
 	}
 }
 
@@ -157,11 +166,16 @@ func CreatePayload(hostname string, ac AutoConfigInterface, coll CollectorInterf
 		agentMetadata[k] = v
 	}
 
+	providersMetadata := createProvidersMetadata()
+
 	return &Payload{
-		Hostname:      hostname,
-		Timestamp:     timeNow().UnixNano(),
-		CheckMetadata: &checkMetadata,
-		AgentMetadata: &agentMetadata,
+		Hostname:          hostname,
+		Timestamp:         timeNow().UnixNano(),
+		SchemaVersion:     PayloadSchemaVersion,
+		Revision:          atomic.LoadUint64(&payloadRevision),
+		CheckMetadata:     &checkMetadata,
+		AgentMetadata:     &agentMetadata,
+		ProvidersMetadata: &providersMetadata,
 	}
 }
 
@@ -9,6 +9,7 @@ package inventories
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +25,10 @@ func clearMetadata() {
 	agentCacheMutex.Lock()
 	defer agentCacheMutex.Unlock()
 	agentMetadataCache = make(AgentMetadata)
+	providersCacheMutex.Lock()
+	defer providersCacheMutex.Unlock()
+	providersCache = make(map[string]*providerCacheEntry)
+	atomic.StoreUint64(&payloadRevision, 0)
 
 	// purge metadataUpdatedC
 L:
@@ -135,6 +140,7 @@ func TestGetPayload(t *testing.T) {
 	p = GetPayload("testHostname", &mockAutoConfig{}, &mockCollector{})
 
 	assert.Equal(t, startNow.UnixNano(), p.Timestamp) //updated startNow is returned
+	assert.Equal(t, uint64(6), p.Revision)            // bumped by every metadata change
 
 	agentMetadata = *p.AgentMetadata
 	assert.Len(t, agentMetadata, 1)
@@ -167,6 +173,8 @@ func TestGetPayload(t *testing.T) {
 	{
 		"hostname": "testHostname",
 		"timestamp": %v,
+		"schema_version": 2,
+		"revision": 6,
 		"check_metadata":
 		{
 			"check1":
@@ -206,7 +214,8 @@ func TestGetPayload(t *testing.T) {
 		"agent_metadata":
 		{
 			"test": true
-		}
+		},
+		"providers_metadata": {}
 	}`
 	jsonString = fmt.Sprintf(jsonString, startNow.UnixNano(), startNow.UnixNano(), agentStartupTime.UnixNano(), originalStartNow.UnixNano(), originalStartNow.UnixNano())
 	jsonString = strings.Join(strings.Fields(jsonString), "") // Removes whitespaces and new lines
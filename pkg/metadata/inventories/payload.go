@@ -23,12 +23,26 @@ type CheckMetadata map[string][]*CheckInstanceMetadata
 // CheckInstanceMetadata contains metadata provided by an instance of an integration.
 type CheckInstanceMetadata map[string]interface{}
 
+// ProviderMetadata contains the metadata fragment reported by one provider.
+type ProviderMetadata map[string]interface{}
+
+// ProvidersMetadata contains the metadata fragments of all the registered
+// providers, indexed by provider name.
+type ProvidersMetadata map[string]ProviderMetadata
+
+// PayloadSchemaVersion is the version of the structure of the payload, it has
+// to be bumped on every incompatible change of the payload.
+const PayloadSchemaVersion = 2
+
 // Payload handles the JSON unmarshalling of the metadata payload
 type Payload struct {
-	Hostname      string         `json:"hostname"`
-	Timestamp     int64          `json:"timestamp"`
-	CheckMetadata *CheckMetadata `json:"check_metadata"`
-	AgentMetadata *AgentMetadata `json:"agent_metadata"`
+	Hostname          string             `json:"hostname"`
+	Timestamp         int64              `json:"timestamp"`
+	SchemaVersion     int                `json:"schema_version"`
+	Revision          uint64             `json:"revision"`
+	CheckMetadata     *CheckMetadata     `json:"check_metadata"`
+	AgentMetadata     *AgentMetadata     `json:"agent_metadata"`
+	ProvidersMetadata *ProvidersMetadata `json:"providers_metadata"`
 }
 
 // MarshalJSON serialization a Payload to JSON
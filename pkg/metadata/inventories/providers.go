@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package inventories
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Provider returns the metadata fragment of one part of the agent or of the
+// host. A nil fragment means that the provider has nothing to report.
+type Provider func() (ProviderMetadata, error)
+
+type providerCacheEntry struct {
+	provider    Provider
+	lastUpdated time.Time
+	fragment    ProviderMetadata
+}
+
+var (
+	providersCache      = make(map[string]*providerCacheEntry) // by provider name
+	providersCacheMutex = &sync.Mutex{}
+)
+
+// RegisterProvider registers a metadata provider, its fragment is added to the
+// payload under the given name once the providers are refreshed. Registering
+// a provider again under the same name replaces it.
+func RegisterProvider(name string, provider Provider) {
+	providersCacheMutex.Lock()
+	defer providersCacheMutex.Unlock()
+
+	if entry, found := providersCache[name]; found {
+		entry.provider = provider
+		return
+	}
+	providersCache[name] = &providerCacheEntry{provider: provider}
+}
+
+// RefreshProviders queries all the registered providers and, if any of their
+// fragments changed, triggers the sending of the updated payload. The providers
+// are called without holding the lock, as some of them query remote endpoints.
+func RefreshProviders() {
+	providersCacheMutex.Lock()
+	providers := make(map[string]Provider, len(providersCache))
+	for name, entry := range providersCache {
+		providers[name] = entry.provider
+	}
+	providersCacheMutex.Unlock()
+
+	fragments := make(map[string]ProviderMetadata, len(providers))
+	for name, provider := range providers {
+		fragment, err := provider()
+		if err != nil {
+			// Keep the last known fragment, the provider may only be temporarily unavailable
+			log.Debugf("Unable to refresh the %s inventory metadata: %s", name, err)
+			continue
+		}
+		fragments[name] = fragment
+	}
+
+	providersCacheMutex.Lock()
+	updated := false
+	for name, fragment := range fragments {
+		entry, found := providersCache[name]
+		if !found || reflect.DeepEqual(entry.fragment, fragment) {
+			continue
+		}
+		entry.fragment = fragment
+		entry.lastUpdated = timeNow()
+		updated = true
+	}
+	providersCacheMutex.Unlock()
+
+	if updated {
+		notifyMetadataUpdated()
+	}
+}
+
+func createProvidersMetadata() ProvidersMetadata {
+	providersCacheMutex.Lock()
+	defer providersCacheMutex.Unlock()
+
+	providersMetadata := make(ProvidersMetadata)
+	for name, entry := range providersCache {
+		if entry.fragment == nil {
+			continue
+		}
+
+		// Creating a copy of the fragment to add the last_updated field
+		providerMetadata := make(ProviderMetadata, len(entry.fragment)+1)
+		for k, v := range entry.fragment {
+			providerMetadata[k] = v
+		}
+		providerMetadata["last_updated"] = entry.lastUpdated.UnixNano()
+		providersMetadata[name] = providerMetadata
+	}
+
+	return providersMetadata
+}
+
+// StartProvidersRefreshGoroutine starts a routine that refreshes the registered
+// providers at the given interval, the first refresh happens right away.
+func StartProvidersRefreshGoroutine(interval time.Duration) error {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			RefreshProviders()
+			<-ticker.C
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+// +build !windows
+
+package inventories
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviders(t *testing.T) {
+	defer func() { clearMetadata() }()
+
+	startNow := time.Now()
+	timeNow = func() time.Time { return startNow }
+	defer func() { timeNow = time.Now }()
+
+	RegisterProvider("kernel", func() (ProviderMetadata, error) {
+		return ProviderMetadata{"kernel_release": "5.4.0"}, nil
+	})
+	RegisterProvider("empty", func() (ProviderMetadata, error) {
+		return nil, nil
+	})
+
+	// The fragments are only collected once the providers are refreshed
+	p := GetPayload("testHostname", nil, nil)
+	assert.Len(t, *p.ProvidersMetadata, 0)
+	assert.Equal(t, uint64(0), p.Revision)
+
+	RefreshProviders()
+	p = GetPayload("testHostname", nil, nil)
+	assert.Equal(t, ProvidersMetadata{
+		"kernel": {"kernel_release": "5.4.0", "last_updated": startNow.UnixNano()},
+	}, *p.ProvidersMetadata)
+	assert.Equal(t, uint64(1), p.Revision)
+	assert.Equal(t, PayloadSchemaVersion, p.SchemaVersion)
+
+	// The same fragment doesn't bump the revision
+	RefreshProviders()
+	p = GetPayload("testHostname", nil, nil)
+	assert.Equal(t, uint64(1), p.Revision)
+
+	// A failing provider keeps its last fragment
+	RegisterProvider("kernel", func() (ProviderMetadata, error) {
+		return nil, fmt.Errorf("unavailable")
+	})
+	RefreshProviders()
+	p = GetPayload("testHostname", nil, nil)
+	assert.Equal(t, "5.4.0", (*p.ProvidersMetadata)["kernel"]["kernel_release"])
+	assert.Equal(t, uint64(1), p.Revision)
+
+	// A new fragment bumps it and updates last_updated
+	startNow = startNow.Add(1000 * time.Second)
+	RegisterProvider("kernel", func() (ProviderMetadata, error) {
+		return ProviderMetadata{"kernel_release": "5.8.0"}, nil
+	})
+	RefreshProviders()
+	p = GetPayload("testHostname", nil, nil)
+	assert.Equal(t, ProvidersMetadata{
+		"kernel": {"kernel_release": "5.8.0", "last_updated": startNow.UnixNano()},
+	}, *p.ProvidersMetadata)
+	assert.Equal(t, uint64(2), p.Revision)
+}
+
+func TestRefreshProvidersDoesntBlockPayload(t *testing.T) {
+	defer func() { clearMetadata() }()
+
+	called := make(chan struct{})
+	release := make(chan struct{})
+	RegisterProvider("cloud", func() (ProviderMetadata, error) {
+		close(called)
+		<-release
+		return ProviderMetadata{"cloud_provider": "GCP"}, nil
+	})
+
+	refreshed := make(chan struct{})
+	go func() {
+		RefreshProviders()
+		close(refreshed)
+	}()
+	<-called
+
+	// The payload is created while the provider queries its endpoint
+	payloadCreated := make(chan struct{})
+	go func() {
+		GetPayload("testHostname", nil, nil)
+		close(payloadCreated)
+	}()
+	select {
+	case <-payloadCreated:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the payload creation is blocked by the provider")
+	}
+
+	close(release)
+	<-refreshed
+	p := GetPayload("testHostname", nil, nil)
+	assert.Equal(t, "GCP", (*p.ProvidersMetadata)["cloud"]["cloud_provider"])
+}
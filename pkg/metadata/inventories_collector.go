@@ -47,6 +47,9 @@ func (c inventoriesCollector) Send(s *serializer.Serializer) error {
 
 // Init initializes the inventory metadata collection
 func (c inventoriesCollector) Init() error {
+	if err := inventories.StartProvidersRefreshGoroutine(config.Datadog.GetDuration("inventories_providers_refresh_interval") * time.Second); err != nil {
+		return err
+	}
 	return inventories.StartMetadataUpdatedGoroutine(c.sc, config.Datadog.GetDuration("inventories_min_interval")*time.Second)
 }
 
@@ -71,6 +74,7 @@ func SetupInventories(sc *Scheduler, ac inventories.AutoConfigInterface, coll in
 		sc:   sc,
 	}
 	RegisterCollector("inventories", ic)
+	registerInventoriesProviders(coll)
 
 	if err := sc.AddCollector("inventories", config.Datadog.GetDuration("inventories_max_interval")*time.Second); err != nil {
 		return err
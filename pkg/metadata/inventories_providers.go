@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metadata
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/DataDog/datadog-agent/pkg/util/azure"
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+	"github.com/DataDog/datadog-agent/pkg/util/gce"
+)
+
+// checksVersionsGetter is implemented by the collector
+type checksVersionsGetter interface {
+	GetChecksVersions() map[string]string
+}
+
+type cloudInstanceMetadataGetter struct {
+	name    string
+	getTags func() ([]string, error)
+}
+
+var cloudInstanceMetadataGetters = []cloudInstanceMetadataGetter{
+	{ec2.CloudProviderName, ec2.GetInstanceMetadataTags},
+	{gce.CloudProviderName, gce.GetInstanceMetadataTags},
+	{azure.CloudProviderName, azure.GetInstanceMetadataTags},
+}
+
+// registerInventoriesProviders registers the metadata providers of the agent
+// and of the host in the inventories payload.
+func registerInventoriesProviders(coll inventories.CollectorInterface) {
+	inventories.RegisterProvider("agent_features", agentFeaturesProvider)
+	inventories.RegisterProvider("kernel", kernelProvider)
+	inventories.RegisterProvider("cloud_instance", newCloudInstanceProvider(cloudInstanceMetadataGetters))
+
+	if getter, ok := coll.(checksVersionsGetter); ok {
+		inventories.RegisterProvider("integrations", newIntegrationsProvider(getter))
+	}
+}
+
+// agentFeaturesProvider reports which features of the agent are enabled
+func agentFeaturesProvider() (inventories.ProviderMetadata, error) {
+	return inventories.ProviderMetadata{
+		"apm_enabled":                   config.Datadog.GetBool("apm_config.enabled"),
+		"logs_enabled":                  config.Datadog.GetBool("logs_enabled"),
+		"process_enabled":               config.Datadog.GetString("process_config.enabled"),
+		"dogstatsd_enabled":             config.Datadog.GetBool("use_dogstatsd"),
+		"cluster_agent_enabled":         config.Datadog.GetBool("cluster_agent.enabled"),
+		"orchestrator_explorer_enabled": config.Datadog.GetBool("orchestrator_explorer.enabled"),
+	}, nil
+}
+
+// newIntegrationsProvider returns a provider reporting the version of the
+// integrations of the running checks
+func newIntegrationsProvider(getter checksVersionsGetter) inventories.Provider {
+	return func() (inventories.ProviderMetadata, error) {
+		versions := getter.GetChecksVersions()
+		if len(versions) == 0 {
+			return nil, nil
+		}
+
+		fragment := make(inventories.ProviderMetadata, len(versions))
+		for name, version := range versions {
+			fragment[name] = version
+		}
+		return fragment, nil
+	}
+}
+
+// newCloudInstanceProvider returns a provider reporting the instance metadata
+// of the first cloud provider whose metadata endpoint answers. Once found, only
+// the metadata endpoint of that cloud provider is queried, and if none answers
+// the first time the endpoints aren't queried anymore.
+func newCloudInstanceProvider(getters []cloudInstanceMetadataGetter) inventories.Provider {
+	return func() (inventories.ProviderMetadata, error) {
+		for i, getter := range getters {
+			rawTags, err := getter.getTags()
+			if err != nil {
+				continue
+			}
+			getters = getters[i : i+1]
+
+			fragment := inventories.ProviderMetadata{
+				inventories.CloudProviderMetatadaName: getter.name,
+			}
+			for _, tag := range rawTags {
+				parts := strings.SplitN(tag, ":", 2)
+				if len(parts) == 2 {
+					fragment[parts[0]] = parts[1]
+				}
+			}
+			return fragment, nil
+		}
+
+		if len(getters) == 1 {
+			return nil, fmt.Errorf("unable to query the %s instance metadata", getters[0].name)
+		}
+		// not running on a cloud provider
+		getters = nil
+		return nil, nil
+	}
+}
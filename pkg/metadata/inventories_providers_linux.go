@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package metadata
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+)
+
+// kernelProvider reports the kernel release and the eBPF capabilities of the host
+func kernelProvider() (inventories.ProviderMetadata, error) {
+	procRoot := config.Datadog.GetString("container_proc_root")
+	// sysfs is mounted next to procfs, e.g. /host/sys next to /host/proc
+	return kernelMetadata(procRoot, filepath.Join(filepath.Dir(procRoot), "sys"))
+}
+
+func kernelMetadata(procRoot, sysRoot string) (inventories.ProviderMetadata, error) {
+	release, err := readSysctl(procRoot, "kernel/osrelease")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the kernel release: %s", err)
+	}
+
+	fragment := inventories.ProviderMetadata{
+		"kernel_release": release,
+	}
+
+	// These sysctls only exist if the kernel is built with eBPF support
+	if jit, err := readSysctl(procRoot, "net/core/bpf_jit_enable"); err == nil {
+		fragment["bpf_jit_enabled"] = jit != "0"
+	}
+	if unprivileged, err := readSysctl(procRoot, "kernel/unprivileged_bpf_disabled"); err == nil {
+		fragment["unprivileged_bpf_disabled"] = unprivileged != "0"
+	}
+
+	_, err = os.Stat(filepath.Join(sysRoot, "kernel", "btf", "vmlinux"))
+	fragment["btf_available"] = err == nil
+
+	return fragment, nil
+}
+
+func readSysctl(procRoot, name string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(procRoot, "sys", name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package metadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestKernelMetadata(t *testing.T) {
+	root, err := ioutil.TempDir("", "inventories")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	procRoot, sysRoot := filepath.Join(root, "proc"), filepath.Join(root, "sys")
+
+	_, err = kernelMetadata(procRoot, sysRoot)
+	assert.Error(t, err)
+
+	writeTestFile(t, filepath.Join(procRoot, "sys", "kernel", "osrelease"), "4.14.0-1-amd64\n")
+	fragment, err := kernelMetadata(procRoot, sysRoot)
+	require.NoError(t, err)
+	assert.Equal(t, inventories.ProviderMetadata{
+		"kernel_release": "4.14.0-1-amd64",
+		"btf_available":  false,
+	}, fragment)
+
+	writeTestFile(t, filepath.Join(procRoot, "sys", "net", "core", "bpf_jit_enable"), "1\n")
+	writeTestFile(t, filepath.Join(procRoot, "sys", "kernel", "unprivileged_bpf_disabled"), "0\n")
+	writeTestFile(t, filepath.Join(sysRoot, "kernel", "btf", "vmlinux"), "")
+	fragment, err = kernelMetadata(procRoot, sysRoot)
+	require.NoError(t, err)
+	assert.Equal(t, inventories.ProviderMetadata{
+		"kernel_release":            "4.14.0-1-amd64",
+		"bpf_jit_enabled":           true,
+		"unprivileged_bpf_disabled": false,
+		"btf_available":             true,
+	}, fragment)
+}
@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package metadata
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+)
+
+// kernelProvider has nothing to report outside of Linux
+func kernelProvider() (inventories.ProviderMetadata, error) {
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metadata
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChecksVersionsGetter map[string]string
+
+func (m mockChecksVersionsGetter) GetChecksVersions() map[string]string {
+	return m
+}
+
+func TestIntegrationsProvider(t *testing.T) {
+	fragment, err := newIntegrationsProvider(mockChecksVersionsGetter{})()
+	assert.NoError(t, err)
+	assert.Nil(t, fragment)
+
+	fragment, err = newIntegrationsProvider(mockChecksVersionsGetter{"redisdb": "3.1.0", "nginx": "3.8.0"})()
+	assert.NoError(t, err)
+	assert.Equal(t, inventories.ProviderMetadata{"redisdb": "3.1.0", "nginx": "3.8.0"}, fragment)
+}
+
+func TestCloudInstanceProvider(t *testing.T) {
+	gceCalls := 0
+	provider := newCloudInstanceProvider([]cloudInstanceMetadataGetter{
+		{"AWS", func() ([]string, error) { return nil, fmt.Errorf("not on AWS") }},
+		{"GCP", func() ([]string, error) {
+			gceCalls++
+			return []string{"preemptible:true", "instance_group:my-group"}, nil
+		}},
+	})
+
+	fragment, err := provider()
+	assert.NoError(t, err)
+	assert.Equal(t, inventories.ProviderMetadata{
+		"cloud_provider": "GCP",
+		"preemptible":    "true",
+		"instance_group": "my-group",
+	}, fragment)
+
+	// Only the detected cloud provider is queried afterwards
+	_, err = provider()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, gceCalls)
+
+	// Outside of any cloud provider there's nothing to report
+	awsCalls := 0
+	provider = newCloudInstanceProvider([]cloudInstanceMetadataGetter{
+		{"AWS", func() ([]string, error) {
+			awsCalls++
+			return nil, fmt.Errorf("not on AWS")
+		}},
+		{"GCP", func() ([]string, error) { return nil, fmt.Errorf("not on GCP") }},
+	})
+	fragment, err = provider()
+	assert.NoError(t, err)
+	assert.Nil(t, fragment)
+
+	// and the metadata endpoints aren't queried again
+	fragment, err = provider()
+	assert.NoError(t, err)
+	assert.Nil(t, fragment)
+	assert.Equal(t, 1, awsCalls)
+}
@@ -22,7 +22,8 @@ func MakeContextMetrics() ContextMetrics {
 }
 
 // AddSample add a sample to the current ContextMetrics and initialize a new metrics if needed.
-func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64) error {
+// The new histograms are configured by the histogram namespaces matching the metric name.
+func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSample, timestamp float64, interval int64, histogramNamespaces HistogramNamespaces) error {
 	if math.IsInf(sample.Value, 0) || math.IsNaN(sample.Value) {
 		return fmt.Errorf("sample with value '%v'", sample.Value)
 	}
@@ -37,9 +38,9 @@ func (m ContextMetrics) AddSample(contextKey ckey.ContextKey, sample *MetricSamp
 		case MonotonicCountType:
 			m[contextKey] = &MonotonicCount{}
 		case HistogramType:
-			m[contextKey] = NewHistogram(interval) // default histogram configuration (no call to `configure`) for now
+			m[contextKey] = newHistogramForName(interval, sample.Name, histogramNamespaces)
 		case HistorateType:
-			m[contextKey] = NewHistorate(interval) // internal histogram has the configuration for now
+			m[contextKey] = newHistorateForName(interval, sample.Name, histogramNamespaces)
 		case SetType:
 			m[contextKey] = NewSet()
 		case CounterType:
@@ -162,6 +162,11 @@ func (events Events) MarshalJSON() ([]byte, error) {
 	return reqBody.Bytes(), err
 }
 
+// Len returns the number of events, used to split the payloads
+func (events Events) Len() int {
+	return len(events)
+}
+
 // SplitPayload breaks the payload into times number of pieces
 func (events Events) SplitPayload(times int) ([]marshaler.Marshaler, error) {
 	eventExpvar.Add("TimesSplit", 1)
@@ -6,9 +6,10 @@
 package metrics
 
 import (
-	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -28,9 +29,9 @@ func (w weightSamples) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
 
 // Histogram tracks the distribution of samples added over one flush period
 type Histogram struct {
-	aggregates  []string // aggregates configured on this histogram
-	percentiles []int    // percentiles configured on this histogram, each in the 1-100 range
-	interval    int64    // interval over which the `count` value is normalized (bucket interval for Dogstatsd, 1 otherwise)
+	aggregates  []string  // aggregates configured on this histogram
+	percentiles []float64 // percentiles configured on this histogram, each in the 0-100 range
+	interval    int64     // interval over which the `count` value is normalized (bucket interval for Dogstatsd, 1 otherwise)
 	samples     weightSamples
 	sum         float64
 	count       int64
@@ -47,16 +48,22 @@ const (
 
 var (
 	defaultAggregates  = []string(nil)
-	defaultPercentiles = []int(nil)
+	defaultPercentiles = []float64(nil)
 )
 
 type histogramPercentilesConfig struct {
 	Percentiles []string `mapstructure:"histogram_percentiles"`
 }
 
-func (h *histogramPercentilesConfig) percentiles() []int {
-	res := []int{}
-	for _, p := range h.Percentiles {
+func (h *histogramPercentilesConfig) percentiles() []float64 {
+	return parsePercentiles(h.Percentiles)
+}
+
+// parsePercentiles converts percentiles expressed as strings of floats between
+// 0 and 1 to the 0-100 range, with a precision of one decimal (ex: "0.999" is 99.9).
+func parsePercentiles(percentiles []string) []float64 {
+	res := []float64{}
+	for _, p := range percentiles {
 		i, err := strconv.ParseFloat(p, 64)
 		if err != nil {
 			log.Errorf("Could not parse '%s' from 'histogram_percentiles' (skipping): %s", p, err)
@@ -67,9 +74,9 @@ func (h *histogramPercentilesConfig) percentiles() []int {
 			continue
 		}
 		// in some cases the '*100' will lower the number resulting in
-		// an int lower by 1 from what is expected (ex: 0.29 would
-		// become 28). As a workaround we add 0.5 before casting.
-		res = append(res, int(i*100+0.5))
+		// a value slightly lower than expected (ex: 0.29 would become
+		// 28.999999999999996). As a workaround we round to one decimal.
+		res = append(res, math.Round(i*1000)/10)
 	}
 	return res
 }
@@ -87,7 +94,7 @@ func NewHistogram(interval int64) *Histogram {
 			log.Errorf("Could not Unmarshal histogram configuration: %s", err)
 		} else {
 			defaultPercentiles = c.percentiles()
-			sort.Ints(defaultPercentiles)
+			sort.Float64s(defaultPercentiles)
 		}
 	}
 
@@ -98,9 +105,17 @@ func NewHistogram(interval int64) *Histogram {
 	}
 }
 
-func (h *Histogram) configure(aggregates []string, percentiles []int) {
+// newHistogramForName returns a histogram configured for the metric name by
+// the histogram namespaces, or with the default configuration
+func newHistogramForName(interval int64, name string, namespaces HistogramNamespaces) *Histogram {
+	h := NewHistogram(interval)
+	namespaces.configure(h, name)
+	return h
+}
+
+func (h *Histogram) configure(aggregates []string, percentiles []float64) {
 	h.aggregates = aggregates
-	sort.Ints(percentiles)
+	sort.Float64s(percentiles)
 	h.percentiles = percentiles
 }
 
@@ -165,7 +180,7 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 	// Compute percentiles
 	var target []int64
 	for _, percentile := range h.percentiles {
-		target = append(target, (int64(percentile)*h.count-1)/100)
+		target = append(target, int64((percentile*float64(h.count)-1)/100))
 	}
 
 	if len(target) > 0 {
@@ -177,7 +192,7 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 				series = append(series, &Serie{
 					Points:     []Point{{Ts: timestamp, Value: s.value}},
 					MType:      APIGaugeType,
-					NameSuffix: fmt.Sprintf(".%dpercentile", h.percentiles[idx]),
+					NameSuffix: percentileSuffix(h.percentiles[idx]),
 				})
 				idx++
 			}
@@ -194,3 +209,10 @@ func (h *Histogram) flush(timestamp float64) ([]*Serie, error) {
 
 	return series, nil
 }
+
+// percentileSuffix returns the metric name suffix of a percentile, the decimal
+// separator of the decimal percentiles is replaced by an underscore so that the
+// suffix stays a single metric name segment, e.g. ".99_9percentile".
+func percentileSuffix(percentile float64) string {
+	return "." + strings.Replace(strconv.FormatFloat(percentile, 'f', -1, 64), ".", "_", -1) + "percentile"
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// histogramNamespaceConfig overrides the histogram aggregates and/or
+// percentiles of the metrics whose name starts with Prefix
+type histogramNamespaceConfig struct {
+	Prefix      string   `mapstructure:"prefix"`
+	Aggregates  []string `mapstructure:"aggregates"`
+	Percentiles []string `mapstructure:"percentiles"`
+}
+
+// histogramNamespace is the parsed version of a histogramNamespaceConfig
+type histogramNamespace struct {
+	prefix      string
+	aggregates  []string
+	percentiles []float64
+}
+
+// HistogramNamespaces holds the histogram configurations of the metric
+// namespaces, sorted by decreasing prefix length so that the first match is the
+// most specific one. The samplers load it once and pass it to the histograms
+// they create.
+type HistogramNamespaces []histogramNamespace
+
+// LoadHistogramNamespaces parses the `histogram_namespaces` configuration.
+func LoadHistogramNamespaces() HistogramNamespaces {
+	var configs []histogramNamespaceConfig
+	if !config.Datadog.IsSet("histogram_namespaces") {
+		return nil
+	}
+	if err := config.Datadog.UnmarshalKey("histogram_namespaces", &configs); err != nil {
+		log.Errorf("Could not parse histogram_namespaces: %v", err)
+		return nil
+	}
+
+	namespaces := make(HistogramNamespaces, 0, len(configs))
+	for _, c := range configs {
+		if c.Prefix == "" {
+			log.Errorf("histogram_namespaces: skipping an entry without prefix")
+			continue
+		}
+		ns := histogramNamespace{
+			prefix:     c.Prefix,
+			aggregates: c.Aggregates,
+		}
+		if c.Percentiles != nil {
+			ns.percentiles = parsePercentiles(c.Percentiles)
+			sort.Float64s(ns.percentiles)
+		}
+		namespaces = append(namespaces, ns)
+	}
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return len(namespaces[i].prefix) > len(namespaces[j].prefix)
+	})
+	return namespaces
+}
+
+// configure applies the configuration of the most specific namespace matching
+// the metric name, if any, on top of the default one.
+func (n HistogramNamespaces) configure(h *Histogram, name string) {
+	for _, ns := range n {
+		if !strings.HasPrefix(name, ns.prefix) {
+			continue
+		}
+		if ns.aggregates != nil {
+			h.aggregates = ns.aggregates
+		}
+		if ns.percentiles != nil {
+			h.percentiles = ns.percentiles
+		}
+		return
+	}
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestHistogramNamespaces(t *testing.T) {
+	mockConfig := config.Mock()
+	defer mockConfig.Set("histogram_namespaces", nil)
+
+	mockConfig.Set("histogram_namespaces", []map[string]interface{}{
+		{
+			"prefix":      "app.",
+			"aggregates":  []string{"max"},
+			"percentiles": []string{"0.999", "0.99"},
+		},
+		{
+			"prefix":      "app.latency.",
+			"percentiles": []string{"0.5"},
+		},
+	})
+	namespaces := LoadHistogramNamespaces()
+
+	// default configuration
+	h := newHistogramForName(10, "other.metric", namespaces)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+	assert.Equal(t, []float64{95}, h.percentiles)
+
+	h = newHistogramForName(10, "app.requests", namespaces)
+	assert.Equal(t, []string{"max"}, h.aggregates)
+	assert.Equal(t, []float64{99, 99.9}, h.percentiles)
+
+	// the longest prefix wins, omitted fields use the global configuration
+	h = newHistogramForName(10, "app.latency.db", namespaces)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+	assert.Equal(t, []float64{50}, h.percentiles)
+
+	// the histograms created by the context metrics use the namespaces they are given
+	contextMetrics := MakeContextMetrics()
+	contextKey := ckey.ContextKey{0xffffffffffffffff, 0xffffffffffffffff}
+	require.NoError(t, contextMetrics.AddSample(contextKey, &MetricSample{Name: "app.requests", Mtype: HistorateType, Value: 1}, 12340, 10, namespaces))
+	require.IsType(t, &Historate{}, contextMetrics[contextKey])
+	assert.Equal(t, []string{"max"}, contextMetrics[contextKey].(*Historate).histogram.aggregates)
+
+	// without namespaces, the default configuration is used
+	h = newHistogramForName(10, "app.requests", nil)
+	assert.Equal(t, []string{"max", "median", "avg", "count"}, h.aggregates)
+	assert.Equal(t, []float64{95}, h.percentiles)
+}
+
+func TestHistogramDecimalPercentile(t *testing.T) {
+	h := NewHistogram(1)
+	h.configure([]string{}, []float64{99.9})
+	for i := 1; i <= 1000; i++ {
+		h.addSample(&MetricSample{Value: float64(i), SampleRate: 1}, 10)
+	}
+
+	series, err := h.flush(10)
+	require.Nil(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, ".99_9percentile", series[0].NameSuffix)
+	assert.InEpsilon(t, 999, series[0].Points[0].Value, 1e-9)
+}
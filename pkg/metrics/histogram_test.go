@@ -20,12 +20,12 @@ import (
 
 func TestHistogramConf(t *testing.T) {
 	h := histogramPercentilesConfig{Percentiles: []string{"0.95", "0.96", "0.28", "0.57", "0.58"}}
-	assert.Equal(t, []int{95, 96, 28, 57, 58}, h.percentiles())
+	assert.Equal(t, []float64{95, 96, 28, 57, 58}, h.percentiles())
 }
 
 func TestHistogramConfError(t *testing.T) {
 	h := histogramPercentilesConfig{Percentiles: []string{"0.95", "test", "0.12test", "0.22", "200", "-50"}}
-	assert.Equal(t, []int{95, 22}, h.percentiles())
+	assert.Equal(t, []float64{95, 22}, h.percentiles())
 }
 
 func TestConfigureDefault(t *testing.T) {
@@ -36,7 +36,7 @@ func TestConfigureDefault(t *testing.T) {
 	_, err := hist.flush(60)
 	require.Nil(t, err)
 	assert.Equal(t, []string{"max", "median", "avg", "count"}, hist.aggregates)
-	assert.Equal(t, []int{95}, hist.percentiles)
+	assert.Equal(t, []float64{95}, hist.percentiles)
 }
 
 func TestConfigure(t *testing.T) {
@@ -59,7 +59,7 @@ func TestConfigure(t *testing.T) {
 
 	hist := NewHistogram(10)
 	assert.Equal(t, aggregates, hist.aggregates)
-	assert.Equal(t, []int{30, 50, 98}, hist.percentiles)
+	assert.Equal(t, []float64{30, 50, 98}, hist.percentiles)
 }
 
 func TestDefaultHistogramSampling(t *testing.T) {
@@ -104,7 +104,7 @@ func TestDefaultHistogramSampling(t *testing.T) {
 func TestCustomHistogramSampling(t *testing.T) {
 	// Initialize custom histogram, with an invalid aggregate
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"min", "sum", "invalid"}, []int{})
+	mHistogram.configure([]string{"min", "sum", "invalid"}, []float64{})
 
 	// Empty flush
 	_, err := mHistogram.flush(50)
@@ -149,7 +149,7 @@ func shuffle(slice []float64) {
 func TestHistogramPercentiles(t *testing.T) {
 	// Initialize custom histogram
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "median", "avg", "count", "min"}, []int{95, 80})
+	mHistogram.configure([]string{"max", "median", "avg", "count", "min"}, []float64{95, 80})
 
 	// Empty flush
 	_, err := mHistogram.flush(50)
@@ -197,7 +197,7 @@ func TestHistogramPercentiles(t *testing.T) {
 
 func TestHistogramSampleRate(t *testing.T) {
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 
 	mHistogram.addSample(&MetricSample{Value: 1}, 50)
 	mHistogram.addSample(&MetricSample{Value: 2, SampleRate: 0.5}, 50)
@@ -237,7 +237,7 @@ func TestHistogramSampleRate(t *testing.T) {
 
 func TestHistogramReset(t *testing.T) {
 	mHistogram := NewHistogram(10)
-	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+	mHistogram.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 
 	mHistogram.addSample(&MetricSample{Value: 1}, 50)
 	mHistogram.addSample(&MetricSample{Value: 2, SampleRate: 0.5}, 50)
@@ -283,7 +283,7 @@ func TestHistogramReset(t *testing.T) {
 func benchHistogram(b *testing.B, number int, sampleRate float64) {
 	for n := 0; n < b.N; n++ {
 		h := NewHistogram(1)
-		h.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []int{20, 95, 80})
+		h.configure([]string{"max", "min", "median", "avg", "sum", "count"}, []float64{20, 95, 80})
 		m := MetricSample{Value: 21, SampleRate: sampleRate}
 
 		for i := 0; i < number; i++ {
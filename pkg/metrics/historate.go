@@ -22,6 +22,14 @@ func NewHistorate(interval int64) *Historate {
 	}
 }
 
+// newHistorateForName returns a historate whose histogram is configured for
+// the metric name by the histogram namespaces, or with the default configuration
+func newHistorateForName(interval int64, name string, namespaces HistogramNamespaces) *Historate {
+	return &Historate{
+		histogram: *newHistogramForName(interval, name, namespaces),
+	}
+}
+
 func (h *Historate) addSample(sample *MetricSample, timestamp float64) {
 	if h.previousTimestamp != 0 {
 		v := (sample.Value - h.previousSample) / (timestamp - h.previousTimestamp)
@@ -113,6 +113,11 @@ func (sl SketchSeriesList) Marshal() ([]byte, error) {
 	return pb.Marshal()
 }
 
+// Len returns the number of sketch series, used to split the payloads
+func (sl SketchSeriesList) Len() int {
+	return len(sl)
+}
+
 // SplitPayload breaks the payload into times number of pieces
 func (sl SketchSeriesList) SplitPayload(times int) ([]marshaler.Marshaler, error) {
 	// Only break it down as much as possible
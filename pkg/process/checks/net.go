@@ -138,6 +138,7 @@ func batchConnections(
 
 		// Get the container and process relationship from either the process or container checks
 		ctrIDForPID := getCtrIDsByPIDs(connectionPIDs(batchConns))
+		batchTags := addConnectionsServiceContext(batchConns)
 
 		batches = append(batches, &model.CollectorConnections{
 			HostName:          cfg.HostName,
@@ -148,12 +149,32 @@ func batchConnections(
 			ContainerForPid:   ctrIDForPID,
 			EncodedDNS:        dnsEncoder.Encode(batchDNS),
 			ContainerHostType: cfg.ContainerHostType,
+			Tags:              batchTags,
 		})
 		cxs = cxs[batchSize:]
 	}
 	return batches
 }
 
+// addConnectionsServiceContext tags the connections with the service of their process, found by the
+// process check, and returns the tags of the batch the connections refer to by index
+func addConnectionsServiceContext(conns []*model.Connection) []string {
+	var tags []string
+	tagIdx := make(map[string]uint32)
+	for _, c := range conns {
+		for _, tag := range Process.serviceContext(c.Pid) {
+			idx, ok := tagIdx[tag]
+			if !ok {
+				idx = uint32(len(tags))
+				tagIdx[tag] = idx
+				tags = append(tags, tag)
+			}
+			c.Tags = append(c.Tags, idx)
+		}
+	}
+	return tags
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
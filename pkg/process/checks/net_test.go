@@ -7,7 +7,10 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/servicediscovery"
+	"github.com/DataDog/gopsutil/process"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func makeConnection(pid int32) *model.Connection {
@@ -143,3 +146,28 @@ func TestNetworkConnectionBatchingWithDNS(t *testing.T) {
 	}
 	assert.Equal(t, 4, total)
 }
+
+func TestNetworkConnectionServiceContext(t *testing.T) {
+	extractor := servicediscovery.NewExtractor("/nonexistent")
+	servicediscovery.AddServiceContext(extractor,
+		map[string][]*model.Process{"": {{Pid: 1}, {Pid: 2}, {Pid: 3}}},
+		procsToHash([]*process.FilledProcess{
+			makeProcess(1, "java -jar billing-1.0.jar"),
+			makeProcess(2, "python -m billing"),
+			makeProcess(3, "python -m worker"),
+		}),
+		nil)
+	Process.serviceExtractor = extractor
+	defer func() { Process.serviceExtractor = nil }()
+
+	cfg := config.NewDefaultAgentConfig(false)
+	chunks := batchConnections(cfg, 0, []*model.Connection{makeConnection(1), makeConnection(2), makeConnection(3), makeConnection(4)}, nil, "nid")
+	require.Len(t, chunks, 1)
+
+	cc := chunks[0].(*model.CollectorConnections)
+	assert.Equal(t, []string{"service:billing", "service:worker"}, cc.Tags)
+	assert.Equal(t, []uint32{0}, cc.Connections[0].Tags)
+	assert.Equal(t, []uint32{0}, cc.Connections[1].Tags)
+	assert.Equal(t, []uint32{1}, cc.Connections[2].Tags)
+	assert.Empty(t, cc.Connections[3].Tags)
+}
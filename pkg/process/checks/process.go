@@ -13,6 +13,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/servicediscovery"
 	"github.com/DataDog/datadog-agent/pkg/process/statsd"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -37,11 +38,20 @@ type ProcessCheck struct {
 	lastCtrIDForPID map[int32]string
 	lastRun         time.Time
 	networkID       string
+
+	// the number of arguments scrubbed by the rules of the data scrubber at the last run
+	lastScrubbedArgs int64
+
+	// derives the service of the processes, nil if the service discovery is disabled
+	serviceExtractor *servicediscovery.Extractor
 }
 
 // Init initializes the singleton ProcessCheck.
-func (p *ProcessCheck) Init(_ *config.AgentConfig, info *model.SystemInfo) {
+func (p *ProcessCheck) Init(cfg *config.AgentConfig, info *model.SystemInfo) {
 	p.sysInfo = info
+	if cfg.EnableServiceDiscovery {
+		p.serviceExtractor = servicediscovery.NewExtractor(util.HostProc())
+	}
 
 	networkID, err := agentutil.GetNetworkID()
 	if err != nil {
@@ -96,6 +106,9 @@ func (p *ProcessCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.Mess
 
 	procsByCtr := fmtProcesses(cfg, procs, p.lastProcs, ctrList, cpuTimes[0], p.lastCPUTime, p.lastRun)
 	ctrs := fmtContainers(ctrList, p.lastCtrRates, p.lastRun)
+	if p.serviceExtractor != nil {
+		servicediscovery.AddServiceContext(p.serviceExtractor, procsByCtr, procs, ctrList)
+	}
 
 	messages, totalProcs, totalContainers := createProcCtrMessages(procsByCtr, ctrs, cfg, p.sysInfo, groupID, p.networkID)
 
@@ -109,6 +122,10 @@ func (p *ProcessCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.Mess
 
 	statsd.Client.Gauge("datadog.process.containers.host_count", float64(totalContainers), []string{}, 1)
 	statsd.Client.Gauge("datadog.process.processes.host_count", float64(totalProcs), []string{}, 1)
+
+	scrubbedArgs := cfg.Scrubber.ScrubbedArgsCount()
+	statsd.Client.Count("datadog.process.scrubber.scrubbed_args", scrubbedArgs-p.lastScrubbedArgs, []string{}, 1)
+	p.lastScrubbedArgs = scrubbedArgs
 	log.Debugf("collected processes in %s", time.Now().Sub(start))
 	return messages, nil
 }
@@ -190,6 +207,15 @@ func chunkProcesses(procs []*model.Process, size int) [][]*model.Process {
 	return chunks
 }
 
+// serviceContext returns the service tags of a process found by the last run, none if the service
+// discovery is disabled
+func (p *ProcessCheck) serviceContext(pid int32) []string {
+	if p.serviceExtractor == nil {
+		return nil
+	}
+	return p.serviceExtractor.ServiceContext(pid)
+}
+
 func ctrIDForPID(ctrList []*containers.Container) map[int32]string {
 	ctrIDForPID := make(map[int32]string, len(ctrList))
 	for _, c := range ctrList {
@@ -91,6 +91,11 @@ type AgentConfig struct {
 	ClosedChannelSize              int
 	MaxClosedConnectionsBuffered   int
 	MaxConnectionsStateBuffered    int
+	EnableTracerFallback           bool
+	TracerFallbackPollInterval     time.Duration
+
+	// Derive the service of the processes from their metadata
+	EnableServiceDiscovery bool
 
 	// Orchestrator collection configuration
 	OrchestrationCollectionEnabled bool
@@ -240,6 +240,7 @@ func TestAgentConfigYamlAndSystemProbeConfig(t *testing.T) {
 	assert.Equal(false, agentConfig.Scrubber.Enabled)
 	assert.Equal(5065, agentConfig.ProcessExpVarPort)
 	assert.False(agentConfig.DisableDNSInspection)
+	assert.False(agentConfig.EnableTracerFallback)
 
 	agentConfig, err = NewAgentConfig(
 		"test",
@@ -288,6 +289,8 @@ func TestAgentConfigYamlAndSystemProbeConfig(t *testing.T) {
 	assert.Equal(false, agentConfig.Scrubber.Enabled)
 	assert.False(agentConfig.SysProbeBPFDebug)
 	assert.Equal(1000, agentConfig.ClosedChannelSize)
+	assert.True(agentConfig.EnableTracerFallback)
+	assert.Equal(10*time.Second, agentConfig.TracerFallbackPollInterval)
 	assert.Equal(agentConfig.ExcludedBPFLinuxVersions, []string{"5.5.0", "4.2.1"})
 	assert.Equal("/var/my-location/system-probe.log", agentConfig.SystemProbeSocketPath)
 	assert.Equal(append(processChecks, "connections"), agentConfig.EnabledChecks)
@@ -3,9 +3,12 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/gopsutil/process"
@@ -33,6 +36,14 @@ type DataScrubber struct {
 	scrubbedCmdlines  map[string][]string
 	cacheCycles       uint32 // used to control the cache age
 	cacheMaxCycles    uint32 // number of cycles before resetting the cache content
+
+	// the rule sets can be replaced while the agent runs, the cache is reset when they change
+	rulesLock         sync.RWMutex
+	scrubRules        []*ProcessScrubRuleSet
+	rulesVersion      uint64
+	cacheRulesVersion uint64
+
+	scrubbedArgs int64 // number of arguments scrubbed since the start, read atomically
 }
 
 // NewDefaultDataScrubber creates a DataScrubber with the default behavior: enabled
@@ -127,10 +138,30 @@ func (ds *DataScrubber) ScrubProcessCommand(p *process.FilledProcess) []string {
 		return p.Cmdline
 	}
 
+	ds.rulesLock.RLock()
+	rules, version := ds.scrubRules, ds.rulesVersion
+	ds.rulesLock.RUnlock()
+	if version != ds.cacheRulesVersion {
+		ds.seenProcess = make(map[string]struct{})
+		ds.scrubbedCmdlines = make(map[string][]string)
+		ds.cacheRulesVersion = version
+	}
+
 	pKey := createProcessKey(p)
 	if _, ok := ds.seenProcess[pKey]; !ok {
 		ds.seenProcess[pKey] = struct{}{}
-		if scrubbed, changed := ds.ScrubCommand(p.Cmdline); changed {
+		scrubbed, count := ds.scrubCommand(p.Cmdline)
+		name := processName(p)
+		for _, set := range rules {
+			if !set.matches(name) {
+				continue
+			}
+			var setCount int
+			scrubbed, setCount = set.scrub(scrubbed)
+			count += setCount
+		}
+		if count > 0 {
+			atomic.AddInt64(&ds.scrubbedArgs, int64(count))
 			ds.scrubbedCmdlines[pKey] = scrubbed
 		}
 	}
@@ -155,20 +186,26 @@ func (ds *DataScrubber) IncrementCacheAge() {
 // ScrubCommand hides the argument value for any key which matches a "sensitive word" pattern.
 // It returns the updated cmdline, as well as a boolean representing whether it was scrubbed
 func (ds *DataScrubber) ScrubCommand(cmdline []string) ([]string, bool) {
+	newCmdline, count := ds.scrubCommand(cmdline)
+	return newCmdline, count > 0
+}
+
+// scrubCommand hides the values of the sensitive words and returns the number of hidden values
+func (ds *DataScrubber) scrubCommand(cmdline []string) ([]string, int) {
 	newCmdline := cmdline
 	rawCmdline := strings.Join(cmdline, " ")
-	changed := false
+	count := 0
 	for _, pattern := range ds.SensitivePatterns {
-		if pattern.MatchString(rawCmdline) {
-			changed = true
+		if matches := len(pattern.FindAllStringIndex(rawCmdline, -1)); matches > 0 {
+			count += matches
 			rawCmdline = pattern.ReplaceAllString(rawCmdline, "${key}${delimiter}********")
 		}
 	}
 
-	if changed {
+	if count > 0 {
 		newCmdline = strings.Split(rawCmdline, " ")
 	}
-	return newCmdline, changed
+	return newCmdline, count
 }
 
 // Strip away all arguments from the command line
@@ -186,3 +223,28 @@ func (ds *DataScrubber) AddCustomSensitiveWords(words []string) {
 	newPatterns := compileStringsToRegex(words)
 	ds.SensitivePatterns = append(ds.SensitivePatterns, newPatterns...)
 }
+
+// SetScrubRules replaces the rule sets applied to the command lines on top of the sensitive
+// words, the command lines already scrubbed are scrubbed again with the new rules
+func (ds *DataScrubber) SetScrubRules(rules []*ProcessScrubRuleSet) {
+	ds.rulesLock.Lock()
+	defer ds.rulesLock.Unlock()
+	ds.scrubRules = rules
+	ds.rulesVersion++
+}
+
+// ScrubbedArgsCount returns the number of arguments scrubbed since the agent started
+func (ds *DataScrubber) ScrubbedArgsCount() int64 {
+	return atomic.LoadInt64(&ds.scrubbedArgs)
+}
+
+// processName returns the name of the executable of a process, which the scrub rule sets are matched against
+func processName(p *process.FilledProcess) string {
+	if p.Exe != "" {
+		return filepath.Base(p.Exe)
+	}
+	if len(p.Cmdline) > 0 {
+		return filepath.Base(strings.Split(p.Cmdline[0], " ")[0])
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const maskedValue = "********"
+
+// ProcessScrubRuleSet is a set of scrubbing rules applied to the command line of the processes
+// whose executable name matches Process, `*` being a wildcard. The rules are applied in order:
+// the values of the flags are masked, the positional arguments are dropped, and the replacements
+// are applied to the whole command line.
+type ProcessScrubRuleSet struct {
+	Process string
+	// MaskFlags are the names of the flags whose value is masked, e.g. `--vault-token` masks the
+	// value of `--vault-token=<value>`, `--vault-token:<value>` and `--vault-token <value>`
+	MaskFlags []string `mapstructure:"mask_flags" json:"mask_flags"`
+	// DropPositionalArgsAfter is the number of arguments not starting with a dash that are kept,
+	// the following ones are removed from the command line
+	DropPositionalArgsAfter *int `mapstructure:"drop_positional_args_after" json:"drop_positional_args_after"`
+	// Replacements are regular expressions replaced in the command line
+	Replacements []*ProcessScrubReplacement `mapstructure:"replacements" json:"replacements"`
+
+	flags map[string]struct{}
+}
+
+// ProcessScrubReplacement replaces the sequences matching Pattern with Replace, which can
+// reference the groups of the pattern, e.g. "${1}"
+type ProcessScrubReplacement struct {
+	Pattern string
+	Replace string
+
+	regex *regexp.Regexp
+}
+
+// LoadProcessScrubRules returns the rule sets defined in `process_config.scrub_rules`
+func LoadProcessScrubRules() ([]*ProcessScrubRuleSet, error) {
+	var sets []*ProcessScrubRuleSet
+	if !config.Datadog.IsSet(key(ns, "scrub_rules")) {
+		return sets, nil
+	}
+	if err := config.Datadog.UnmarshalKey(key(ns, "scrub_rules"), &sets); err != nil {
+		return nil, err
+	}
+	if err := compileProcessScrubRules(sets); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// compileProcessScrubRules validates the rule sets and compiles their patterns
+func compileProcessScrubRules(sets []*ProcessScrubRuleSet) error {
+	for i, set := range sets {
+		if set.Process == "" {
+			return fmt.Errorf("process must be set for the scrub rule set #%d", i)
+		}
+		if _, err := filepath.Match(set.Process, ""); err != nil {
+			return fmt.Errorf("invalid process %s for the scrub rule set #%d: %s", set.Process, i, err)
+		}
+		if set.DropPositionalArgsAfter != nil && *set.DropPositionalArgsAfter < 0 {
+			return fmt.Errorf("drop_positional_args_after can't be negative for the scrub rule set of %s", set.Process)
+		}
+
+		set.flags = make(map[string]struct{}, len(set.MaskFlags))
+		for _, flag := range set.MaskFlags {
+			set.flags[strings.TrimLeft(flag, "-")] = struct{}{}
+		}
+
+		for _, replacement := range set.Replacements {
+			if replacement.Pattern == "" {
+				return fmt.Errorf("no pattern provided for a replacement of the scrub rule set of %s", set.Process)
+			}
+			re, err := regexp.Compile(replacement.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for the scrub rule set of %s: %s", replacement.Pattern, set.Process, err)
+			}
+			replacement.regex = re
+		}
+	}
+	return nil
+}
+
+// matches returns true if the rule set applies to the process
+func (s *ProcessScrubRuleSet) matches(processName string) bool {
+	matched, _ := filepath.Match(s.Process, processName)
+	return matched
+}
+
+// scrub applies the rules to the arguments of a command line, the first one being the
+// executable. It returns the new arguments with the number of scrubbed ones.
+func (s *ProcessScrubRuleSet) scrub(args []string) ([]string, int) {
+	// The entire command line sometimes comes in via the first element
+	if len(args) == 1 {
+		args = strings.Split(args[0], " ")
+	}
+
+	count := 0
+	scrubbed := make([]string, 0, len(args))
+	positional := 0
+	maskNext := false
+	for i, arg := range args {
+		if i == 0 {
+			scrubbed = append(scrubbed, arg)
+			continue
+		}
+
+		if maskNext && !strings.HasPrefix(arg, "-") {
+			maskNext = false
+			scrubbed = append(scrubbed, maskedValue)
+			count++
+			continue
+		}
+		maskNext = false
+
+		if strings.HasPrefix(arg, "-") {
+			name := strings.TrimLeft(arg, "-")
+			if idx := strings.IndexAny(name, "=:"); idx != -1 {
+				if _, ok := s.flags[name[:idx]]; ok {
+					arg = arg[:len(arg)-len(name)+idx+1] + maskedValue
+					count++
+				}
+			} else if _, ok := s.flags[name]; ok {
+				maskNext = true
+			}
+			scrubbed = append(scrubbed, arg)
+			continue
+		}
+
+		positional++
+		if s.DropPositionalArgsAfter != nil && positional > *s.DropPositionalArgsAfter {
+			count++
+			continue
+		}
+		scrubbed = append(scrubbed, arg)
+	}
+
+	if len(s.Replacements) == 0 {
+		return scrubbed, count
+	}
+
+	rawCmdline := strings.Join(scrubbed, " ")
+	replaced := false
+	for _, replacement := range s.Replacements {
+		if matches := len(replacement.regex.FindAllStringIndex(rawCmdline, -1)); matches > 0 {
+			count += matches
+			replaced = true
+			rawCmdline = replacement.regex.ReplaceAllString(rawCmdline, replacement.Replace)
+		}
+	}
+	if replaced {
+		scrubbed = strings.Split(rawCmdline, " ")
+	}
+	return scrubbed, count
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/DataDog/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestCompileProcessScrubRules(t *testing.T) {
+	for name, sets := range map[string][]*ProcessScrubRuleSet{
+		"no process":       {{MaskFlags: []string{"--token"}}},
+		"invalid process":  {{Process: "java["}},
+		"negative drop":    {{Process: "java", DropPositionalArgsAfter: intPtr(-1)}},
+		"no pattern":       {{Process: "java", Replacements: []*ProcessScrubReplacement{{Replace: "x"}}}},
+		"invalid pattern":  {{Process: "java", Replacements: []*ProcessScrubReplacement{{Pattern: "(["}}}},
+		"second set error": {{Process: "java"}, {Process: ""}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, compileProcessScrubRules(sets))
+		})
+	}
+
+	sets := []*ProcessScrubRuleSet{{
+		Process:      "java",
+		MaskFlags:    []string{"--token", "-Dsecret"},
+		Replacements: []*ProcessScrubReplacement{{Pattern: "a+", Replace: "b"}},
+	}}
+	require.NoError(t, compileProcessScrubRules(sets))
+	assert.Len(t, sets[0].flags, 2)
+	assert.NotNil(t, sets[0].Replacements[0].regex)
+}
+
+func TestProcessScrubRuleSetMatches(t *testing.T) {
+	set := &ProcessScrubRuleSet{Process: "java*"}
+	assert.True(t, set.matches("java"))
+	assert.True(t, set.matches("java8"))
+	assert.False(t, set.matches("python"))
+}
+
+func TestProcessScrubRuleSetScrub(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		set      *ProcessScrubRuleSet
+		cmdline  []string
+		expected []string
+		count    int
+	}{
+		{
+			name:     "mask flags",
+			set:      &ProcessScrubRuleSet{MaskFlags: []string{"--token", "-Dsecret", "key"}},
+			cmdline:  []string{"java", "--token=abc", "-Dsecret:def", "--key", "ghi", "--other", "jkl"},
+			expected: []string{"java", "--token=********", "-Dsecret:********", "--key", "********", "--other", "jkl"},
+			count:    3,
+		},
+		{
+			name:     "mask flag without value",
+			set:      &ProcessScrubRuleSet{MaskFlags: []string{"--token"}},
+			cmdline:  []string{"java", "--token", "--verbose"},
+			expected: []string{"java", "--token", "--verbose"},
+		},
+		{
+			name:     "drop positional args",
+			set:      &ProcessScrubRuleSet{DropPositionalArgsAfter: intPtr(1)},
+			cmdline:  []string{"cp", "-r", "src", "dst", "--force", "other"},
+			expected: []string{"cp", "-r", "src", "--force"},
+			count:    2,
+		},
+		{
+			name:     "drop all positional args",
+			set:      &ProcessScrubRuleSet{DropPositionalArgsAfter: intPtr(0)},
+			cmdline:  []string{"cp src dst"},
+			expected: []string{"cp"},
+			count:    2,
+		},
+		{
+			name: "replacements",
+			set: &ProcessScrubRuleSet{Replacements: []*ProcessScrubReplacement{
+				{Pattern: `jdbc:(\w+)://[^ ]+`, Replace: "jdbc:${1}://********"},
+			}},
+			cmdline:  []string{"java", "-url", "jdbc:mysql://user:pass@db:3306/app"},
+			expected: []string{"java", "-url", "jdbc:mysql://********"},
+			count:    1,
+		},
+		{
+			name: "no match",
+			set: &ProcessScrubRuleSet{
+				MaskFlags:    []string{"--token"},
+				Replacements: []*ProcessScrubReplacement{{Pattern: "secret"}},
+			},
+			cmdline:  []string{"java", "-jar", "app.jar"},
+			expected: []string{"java", "-jar", "app.jar"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.set.Process = "*"
+			require.NoError(t, compileProcessScrubRules([]*ProcessScrubRuleSet{tc.set}))
+			scrubbed, count := tc.set.scrub(tc.cmdline)
+			assert.Equal(t, tc.expected, scrubbed)
+			assert.Equal(t, tc.count, count)
+		})
+	}
+}
+
+func TestScrubProcessCommandWithRules(t *testing.T) {
+	scrubber := NewDefaultDataScrubber()
+	javaProc := &process.FilledProcess{Pid: 1, Exe: "/usr/bin/java", Cmdline: []string{"java", "--vault-token", "abc", "--password=def"}}
+	pythonProc := &process.FilledProcess{Pid: 2, Cmdline: []string{"/usr/bin/python --vault-token abc"}}
+
+	// without rules only the sensitive words are scrubbed
+	assert.Equal(t, []string{"java", "--vault-token", "abc", "--password=********"}, scrubber.ScrubProcessCommand(javaProc))
+	assert.Equal(t, pythonProc.Cmdline, scrubber.ScrubProcessCommand(pythonProc))
+	assert.EqualValues(t, 1, scrubber.ScrubbedArgsCount())
+
+	rules := []*ProcessScrubRuleSet{{Process: "java", MaskFlags: []string{"--vault-token"}}}
+	require.NoError(t, compileProcessScrubRules(rules))
+	scrubber.SetScrubRules(rules)
+
+	// the cache is reset when the rules change
+	assert.Equal(t, []string{"java", "--vault-token", "********", "--password=********"}, scrubber.ScrubProcessCommand(javaProc))
+	assert.Equal(t, pythonProc.Cmdline, scrubber.ScrubProcessCommand(pythonProc))
+	assert.EqualValues(t, 3, scrubber.ScrubbedArgsCount())
+
+	// the cached command lines aren't counted again
+	scrubber.ScrubProcessCommand(javaProc)
+	assert.EqualValues(t, 3, scrubber.ScrubbedArgsCount())
+
+	scrubber.SetScrubRules(nil)
+	assert.Equal(t, []string{"java", "--vault-token", "abc", "--password=********"}, scrubber.ScrubProcessCommand(javaProc))
+}
+
+func TestProcessName(t *testing.T) {
+	assert.Equal(t, "java", processName(&process.FilledProcess{Exe: "/usr/bin/java", Cmdline: []string{"other"}}))
+	assert.Equal(t, "python", processName(&process.FilledProcess{Cmdline: []string{"/usr/bin/python script.py"}}))
+	assert.Equal(t, "", processName(&process.FilledProcess{}))
+}
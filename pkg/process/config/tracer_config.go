@@ -68,6 +68,11 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.ClosedChannelSize = ccs
 	}
 
+	tracerConfig.EnableFallback = cfg.EnableTracerFallback
+	if fpi := cfg.TracerFallbackPollInterval; fpi > 0 {
+		tracerConfig.FallbackPollInterval = fpi
+	}
+
 	return tracerConfig
 }
 
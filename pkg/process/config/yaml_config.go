@@ -114,6 +114,12 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.ClosedChannelSize = ccs
 	}
 
+	// The connections are tracked from the sockets of the host when eBPF can't be used
+	a.EnableTracerFallback = config.Datadog.GetBool(key(spNS, "enable_tracer_fallback"))
+	if interval := config.Datadog.GetInt(key(spNS, "tracer_fallback_poll_interval")); interval > 0 {
+		a.TracerFallbackPollInterval = time.Duration(interval) * time.Second
+	}
+
 	// Pull additional parameters from the global config file.
 	a.LogLevel = config.Datadog.GetString("log_level")
 	a.StatsdPort = config.Datadog.GetInt("dogstatsd_port")
@@ -135,6 +141,14 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.EnabledChecks = append(a.EnabledChecks, "TCP queue length")
 	}
 
+	if config.Datadog.GetBool(key(spNS, "enable_oom_kill")) {
+		a.EnabledChecks = append(a.EnabledChecks, "OOM Kill")
+	}
+
+	if config.Datadog.GetBool(key(spNS, "enable_tcp_stats")) {
+		a.EnabledChecks = append(a.EnabledChecks, "TCP stats")
+	}
+
 	return nil
 }
 
@@ -247,6 +261,25 @@ func (a *AgentConfig) LoadProcessYamlConfig(path string) error {
 		a.Scrubber.StripAllArguments = true
 	}
 
+	// The scrub rules per process name, they're applied again when the configuration is reloaded
+	rules, err := LoadProcessScrubRules()
+	if err != nil {
+		return errors.Errorf("invalid %s: %s", key(ns, "scrub_rules"), err)
+	}
+	a.Scrubber.SetScrubRules(rules)
+	scrubber := a.Scrubber
+	config.RegisterReloadHandler(key(ns, "scrub_rules"), func() error {
+		rules, err := LoadProcessScrubRules()
+		if err != nil {
+			return err
+		}
+		scrubber.SetScrubRules(rules)
+		return nil
+	})
+
+	// Tags the processes with the service derived from their metadata
+	a.EnableServiceDiscovery = config.Datadog.GetBool(key(ns, "service_discovery.enabled"))
+
 	// How many check results to buffer in memory when POST fails. The default is usually fine.
 	if k := key(ns, "queue_size"); config.Datadog.IsSet(k) {
 		if queueSize := config.Datadog.GetInt(k); queueSize > 0 {
@@ -8,37 +8,54 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpstats"
 )
 
 const (
 	checksURL = "http://unix/check"
 )
 
-// GetCheck returns the output of the specified check
-func (r *RemoteSysProbeUtil) GetCheck(check string) ([]tcpqueuelength.Stats, error) {
+// GetCheck returns the output of the specified check: []tcpqueuelength.Stats for `tcp_queue_length`,
+// []oomkill.Stats for `oom_kill` and []tcpstats.Stats for `tcp_stats`
+func (r *RemoteSysProbeUtil) GetCheck(check string) (interface{}, error) {
+	switch check {
+	case "tcp_queue_length":
+		var stats []tcpqueuelength.Stats
+		err := r.getCheck(check, &stats)
+		return stats, err
+	case "oom_kill":
+		var stats []oomkill.Stats
+		err := r.getCheck(check, &stats)
+		return stats, err
+	case "tcp_stats":
+		var stats []tcpstats.Stats
+		err := r.getCheck(check, &stats)
+		return stats, err
+	}
+	return nil, fmt.Errorf("invalid check name: %s", check)
+}
+
+func (r *RemoteSysProbeUtil) getCheck(check string, stats interface{}) error {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", checksURL, check), nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return nil, err
-	} else if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("conn request failed: socket %s, url %s, status code: %d", r.path, fmt.Sprintf("%s/%s", checksURL, check), resp.StatusCode)
+		return err
 	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("conn request failed: socket %s, url %s, status code: %d", r.path, fmt.Sprintf("%s/%s", checksURL, check), resp.StatusCode)
 	}
 
-	var stats []tcpqueuelength.Stats
-	err = json.Unmarshal(body, &stats)
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return stats, nil
+	return json.Unmarshal(body, stats)
 }
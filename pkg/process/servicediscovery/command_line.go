@@ -0,0 +1,187 @@
+package servicediscovery
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// versionSuffix matches the version of the jars and the binaries, e.g. `-1.2.3-SNAPSHOT` or `3.8`
+var versionSuffix = regexp.MustCompile(`[-_.]?v?\d+(\.\d+)*([-.][\w.]+)?$`)
+
+// genericScripts are the scripts named after their role rather than their application,
+// the name of their directory is used instead
+var genericScripts = map[string]struct{}{
+	"app":      {},
+	"index":    {},
+	"main":     {},
+	"manage":   {},
+	"run":      {},
+	"server":   {},
+	"start":    {},
+	"__main__": {},
+}
+
+// javaOptionsWithValue are the options of java followed by a separate value
+var javaOptionsWithValue = map[string]struct{}{
+	"-cp":           {},
+	"-classpath":    {},
+	"--class-path":  {},
+	"-p":            {},
+	"--module-path": {},
+	"--add-opens":   {},
+	"--add-exports": {},
+	"--add-modules": {},
+}
+
+// serviceFromCommandLine returns the name of the application run by an interpreter
+func serviceFromCommandLine(cmdline []string) string {
+	// The entire command line sometimes comes in via the first element
+	if len(cmdline) == 1 {
+		cmdline = strings.Fields(cmdline[0])
+	}
+	if len(cmdline) == 0 {
+		return ""
+	}
+
+	exe := versionSuffix.ReplaceAllString(filepath.Base(cmdline[0]), "")
+	args := cmdline[1:]
+	switch exe {
+	case "java":
+		return serviceFromJava(args)
+	case "python", "pypy":
+		return serviceFromPython(args)
+	case "gunicorn", "uwsgi", "celery", "uvicorn":
+		return serviceFromPythonApp(args)
+	case "node", "nodejs", "ruby", "perl", "php":
+		return serviceFromScript(args, nil)
+	case "dotnet":
+		return serviceFromScript(args, map[string]struct{}{"run": {}, "exec": {}})
+	}
+	return ""
+}
+
+// serviceFromJava returns the name of the jar or the main class, e.g. `billing` for
+// `java -jar billing-1.2.jar` and `elasticsearch` for `java org.elasticsearch.bootstrap.Elasticsearch`.
+// The service set for the APM java tracer with `-Ddd.service` takes precedence.
+func serviceFromJava(args []string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-Ddd.service=") {
+			return strings.TrimPrefix(arg, "-Ddd.service=")
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-jar" {
+			if i+1 < len(args) {
+				return trimExtension(filepath.Base(args[i+1]), ".jar")
+			}
+			return ""
+		}
+		if _, ok := javaOptionsWithValue[arg]; ok {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if strings.HasSuffix(arg, ".jar") {
+			return trimExtension(filepath.Base(arg), ".jar")
+		}
+		// main class
+		if dot := strings.LastIndexByte(arg, '.'); dot != -1 {
+			return arg[dot+1:]
+		}
+		return arg
+	}
+	return ""
+}
+
+// serviceFromPython returns the module run with `-m` or the script
+func serviceFromPython(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-m" {
+			if i+1 < len(args) {
+				module := args[i+1]
+				if module == "gunicorn" || module == "uvicorn" || module == "celery" {
+					return serviceFromPythonApp(args[i+2:])
+				}
+				return strings.Split(module, ".")[0]
+			}
+			return ""
+		}
+		if arg == "-c" {
+			return ""
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return serviceFromScriptPath(arg)
+	}
+	return ""
+}
+
+// serviceFromPythonApp returns the package of the application of a python server,
+// e.g. `myapp` for `gunicorn myapp.wsgi:application`
+func serviceFromPythonApp(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-A" || arg == "--app" {
+			if i+1 < len(args) {
+				return strings.Split(strings.Split(args[i+1], ":")[0], ".")[0]
+			}
+			return ""
+		}
+		if strings.HasPrefix(arg, "-") {
+			// the options of these servers mostly have a value
+			if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+			}
+			continue
+		}
+		if strings.Contains(arg, ":") {
+			return strings.Split(strings.Split(arg, ":")[0], ".")[0]
+		}
+	}
+	return ""
+}
+
+// serviceFromScript returns the name of the first argument which isn't an option or a subcommand
+func serviceFromScript(args []string, subcommands map[string]struct{}) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if _, ok := subcommands[arg]; ok {
+			continue
+		}
+		return serviceFromScriptPath(arg)
+	}
+	return ""
+}
+
+// serviceFromScriptPath returns the name of a script without its extension, or the
+// name of its directory when the script is generic, e.g. `shop` for `/srv/shop/server.js`
+func serviceFromScriptPath(path string) string {
+	name := filepath.Base(path)
+	if ext := filepath.Ext(name); ext != "" {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if _, ok := genericScripts[strings.ToLower(name)]; ok {
+		dir := filepath.Base(filepath.Dir(path))
+		if dir == "." || dir == "/" || dir == "src" || dir == "bin" || dir == "dist" {
+			dir = filepath.Base(filepath.Dir(filepath.Dir(path)))
+		}
+		if dir != "." && dir != "/" {
+			return dir
+		}
+	}
+	return name
+}
+
+// trimExtension removes the extension and the version of a file name
+func trimExtension(name, ext string) string {
+	return versionSuffix.ReplaceAllString(strings.TrimSuffix(name, ext), "")
+}
@@ -0,0 +1,51 @@
+package servicediscovery
+
+import (
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/gopsutil/process"
+)
+
+// AddServiceContext tags the processes with the service derived from their metadata, so they
+// can be matched with the services of APM
+func AddServiceContext(
+	extractor *Extractor,
+	procsByCtr map[string][]*model.Process,
+	procs map[int32]*process.FilledProcess,
+	ctrList []*containers.Container,
+) {
+	imageForCtr := make(map[string]string, len(ctrList))
+	for _, c := range ctrList {
+		imageForCtr[c.ID] = c.Image
+	}
+
+	for ctrID, ctrProcs := range procsByCtr {
+		for _, proc := range ctrProcs {
+			fp, ok := procs[proc.Pid]
+			if !ok {
+				continue
+			}
+			service := extractor.Extract(ProcessInfo{
+				Pid:            fp.Pid,
+				CreateTime:     fp.CreateTime,
+				Exe:            fp.Exe,
+				Cmdline:        fp.Cmdline,
+				ContainerImage: imageForCtr[ctrID],
+			})
+			proc.ProcessContext = service.Tags()
+		}
+	}
+	extractor.Expire()
+}
+
+// ServiceContext returns the tags of the service of a process found by the last AddServiceContext,
+// so the connections of a process are tagged with the same service as the process
+func (e *Extractor) ServiceContext(pid int32) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cached, ok := e.cache[pid]; ok {
+		return cached.service.Tags()
+	}
+	return nil
+}
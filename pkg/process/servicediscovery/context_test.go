@@ -0,0 +1,40 @@
+package servicediscovery
+
+import (
+	"strings"
+	"testing"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddServiceContext(t *testing.T) {
+	procs := make(map[int32]*process.FilledProcess)
+	for pid, cmdline := range map[int32]string{
+		1: "java -jar billing-1.0.jar",
+		2: "python -m worker",
+		3: "sleep 10",
+	} {
+		procs[pid] = &process.FilledProcess{Pid: pid, Cmdline: strings.Split(cmdline, " ")}
+	}
+	ctr := &containers.Container{ID: "ctr", Image: "acme/worker:2.1", Pids: []int32{2}}
+
+	procsByCtr := map[string][]*model.Process{
+		"":    {{Pid: 1}, {Pid: 3}},
+		"ctr": {{Pid: 2}},
+	}
+	extractor := NewExtractor("/nonexistent")
+	AddServiceContext(extractor, procsByCtr, procs, []*containers.Container{ctr})
+
+	assert.Equal(t, []string{"service:billing"}, procsByCtr[""][0].ProcessContext)
+	assert.Empty(t, procsByCtr[""][1].ProcessContext)
+	assert.Equal(t, []string{"service:worker"}, procsByCtr["ctr"][0].ProcessContext)
+
+	// the connections of the processes get the same services
+	assert.Equal(t, []string{"service:billing"}, extractor.ServiceContext(1))
+	assert.Equal(t, []string{"service:worker"}, extractor.ServiceContext(2))
+	assert.Empty(t, extractor.ServiceContext(3))
+	assert.Empty(t, extractor.ServiceContext(4))
+}
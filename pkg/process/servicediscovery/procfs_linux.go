@@ -0,0 +1,136 @@
+// +build linux
+
+package servicediscovery
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dpkgInfoDir holds the lists of the files installed by the Debian packages
+var dpkgInfoDir = "/var/lib/dpkg/info"
+
+// readProcessEnv returns the value of an environment variable of a process. Reading the
+// environment of the processes of the other users requires the CAP_SYS_PTRACE capability.
+func readProcessEnv(procRoot string, pid int32, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(int(pid)), "environ"))
+	if err != nil {
+		return ""
+	}
+	prefix := []byte(name + "=")
+	for _, env := range bytes.Split(data, []byte{0}) {
+		if bytes.HasPrefix(env, prefix) {
+			return string(env[len(prefix):])
+		}
+	}
+	return ""
+}
+
+// readSystemdUnit returns the name of the systemd service running a process from its
+// cgroups, e.g. `nginx` for `/system.slice/nginx.service`
+func readSystemdUnit(procRoot string, pid int32) string {
+	f, err := os.Open(filepath.Join(procRoot, strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path, the name=systemd hierarchy on cgroups v1, the
+		// unified one on cgroups v2
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 || (fields[1] != "name=systemd" && fields[0] != "0") {
+			continue
+		}
+		if unit := systemdServiceFromCgroup(fields[2]); unit != "" {
+			return unit
+		}
+	}
+	return ""
+}
+
+// systemdServiceFromCgroup returns the innermost service of a cgroup path, the
+// instance of the template units is ignored
+func systemdServiceFromCgroup(path string) string {
+	parts := strings.Split(path, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(parts[i], ".service") {
+			continue
+		}
+		unit := strings.TrimSuffix(parts[i], ".service")
+		if at := strings.IndexByte(unit, '@'); at != -1 {
+			unit = unit[:at]
+		}
+		// the user managers run the user services, not a service of their own
+		if unit == "user" {
+			return ""
+		}
+		return unit
+	}
+	return ""
+}
+
+// packageIndex maps the executables of the host to the package which installed them.
+// It's loaded the first time it's used since reading the package database is expensive.
+type packageIndex struct {
+	root     string
+	once     sync.Once
+	packages map[string]string
+}
+
+// newPackageIndex returns the index of the packages of the host. When the agent runs in
+// a container, the filesystem of the host is reached through the root of its init process.
+func newPackageIndex(procRoot string) *packageIndex {
+	i := &packageIndex{}
+	if procRoot != "/proc" {
+		i.root = filepath.Join(procRoot, "1", "root")
+	}
+	return i
+}
+
+// lookup returns the package of an executable
+func (i *packageIndex) lookup(exe string) string {
+	i.once.Do(i.load)
+	return i.packages[exe]
+}
+
+func (i *packageIndex) load() {
+	i.packages = make(map[string]string)
+
+	lists, err := filepath.Glob(filepath.Join(i.root, dpkgInfoDir, "*.list"))
+	if err != nil {
+		return
+	}
+	for _, list := range lists {
+		pkg := strings.TrimSuffix(filepath.Base(list), ".list")
+		// multi-arch packages are suffixed with their architecture, e.g. `libc6:amd64`
+		if colon := strings.IndexByte(pkg, ':'); colon != -1 {
+			pkg = pkg[:colon]
+		}
+		i.loadList(list, pkg)
+	}
+}
+
+func (i *packageIndex) loadList(list, pkg string) {
+	f, err := os.Open(list)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// only the executables are kept to limit the size of the index
+		path := scanner.Text()
+		if dir := filepath.Base(filepath.Dir(path)); dir == "bin" || dir == "sbin" {
+			i.packages[path] = pkg
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// +build linux
+
+package servicediscovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestSystemdServiceFromCgroup(t *testing.T) {
+	assert.Equal(t, "nginx", systemdServiceFromCgroup("/system.slice/nginx.service"))
+	assert.Equal(t, "getty", systemdServiceFromCgroup("/system.slice/system-getty.slice/getty@tty1.service"))
+	assert.Equal(t, "syncthing", systemdServiceFromCgroup("/user.slice/user-1000.slice/user@1000.service/app.slice/syncthing.service"))
+	assert.Equal(t, "", systemdServiceFromCgroup("/user.slice/user-1000.slice/user@1000.service/init.scope"))
+	assert.Equal(t, "", systemdServiceFromCgroup("/user.slice/user-1000.slice/session-2.scope"))
+	assert.Equal(t, "", systemdServiceFromCgroup("/docker/3a4c5e"))
+}
+
+func TestExtractorProcfs(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "servicediscovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	// DD_SERVICE takes precedence
+	writeFile(t, filepath.Join(procRoot, "10", "environ"), "PATH=/usr/bin\x00DD_SERVICE=Checkout\x00DD_ENV=prod\x00")
+	writeFile(t, filepath.Join(procRoot, "10", "cgroup"), "0::/system.slice/shop.service\n")
+	// cgroups v1
+	writeFile(t, filepath.Join(procRoot, "11", "cgroup"), "4:memory:/system.slice/redis-server.service\n1:name=systemd:/system.slice/redis-server.service\n")
+	// the package of the executable
+	writeFile(t, filepath.Join(procRoot, "1", "root", dpkgInfoDir, "openssh-server.list"), "/.\n/usr\n/usr/sbin\n/usr/sbin/sshd\n/usr/share/doc/openssh-server\n")
+
+	e := NewExtractor(procRoot)
+	assert.Equal(t, Service{Name: "checkout", Source: SourceEnv}, e.Extract(ProcessInfo{Pid: 10, Cmdline: []string{"node", "app.js"}}))
+	assert.Equal(t, Service{Name: "redis-server", Source: SourceSystemd}, e.Extract(ProcessInfo{Pid: 11, Cmdline: []string{"redis-server"}}))
+	assert.Equal(t, Service{Name: "openssh-server", Source: SourcePackage}, e.Extract(ProcessInfo{Pid: 12, Exe: "/usr/sbin/sshd"}))
+	assert.Equal(t, Service{}, e.Extract(ProcessInfo{Pid: 13, Exe: "/usr/share/doc/openssh-server"}))
+
+	// the systemd units and the packages of the host are ignored for the processes of the containers
+	writeFile(t, filepath.Join(procRoot, "14", "cgroup"), "0::/system.slice/containerd.service\n")
+	assert.Equal(t, Service{Name: "sshd", Source: SourceContainerImage}, e.Extract(ProcessInfo{Pid: 14, Exe: "/usr/sbin/sshd", ContainerImage: "sshd"}))
+}
@@ -0,0 +1,21 @@
+// +build !linux
+
+package servicediscovery
+
+func readProcessEnv(procRoot string, pid int32, name string) string {
+	return ""
+}
+
+func readSystemdUnit(procRoot string, pid int32) string {
+	return ""
+}
+
+type packageIndex struct{}
+
+func newPackageIndex(procRoot string) *packageIndex {
+	return &packageIndex{}
+}
+
+func (i *packageIndex) lookup(exe string) string {
+	return ""
+}
@@ -0,0 +1,182 @@
+// Package servicediscovery derives the name of the service a process belongs to
+// from its metadata, so the processes and the connections reported by the agents
+// can be tagged with the same `service` as their APM traces.
+package servicediscovery
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxServiceLen is the maximum length of a service name, same as the trace-agent
+const maxServiceLen = 100
+
+// Source is the process metadata a service name was derived from
+type Source string
+
+const (
+	// SourceNone means that no service was found
+	SourceNone Source = ""
+	// SourceEnv is the DD_SERVICE environment variable of the process, set for APM
+	SourceEnv Source = "env"
+	// SourceContainerImage is the name of the image of the container of the process
+	SourceContainerImage Source = "container_image"
+	// SourceSystemd is the systemd unit running the process
+	SourceSystemd Source = "systemd"
+	// SourceCommandLine is the name of the jar, the module or the script run by an interpreter
+	SourceCommandLine Source = "command_line"
+	// SourcePackage is the name of the OS package which installed the executable
+	SourcePackage Source = "package"
+)
+
+// ProcessInfo is the metadata of a process used to derive its service
+type ProcessInfo struct {
+	Pid        int32
+	CreateTime int64
+	Exe        string
+	Cmdline    []string
+	// ContainerImage is the image of the container running the process, if any
+	ContainerImage string
+}
+
+// Service is the service of a process
+type Service struct {
+	Name   string
+	Source Source
+}
+
+// Tags returns the tags of the service, none if it's unknown
+func (s Service) Tags() []string {
+	if s.Name == "" {
+		return nil
+	}
+	return []string{"service:" + s.Name}
+}
+
+type cachedService struct {
+	createTime int64
+	service    Service
+	seen       bool
+}
+
+// Extractor derives the service of the processes and caches it for their lifetime.
+// It's safe for concurrent use, so it can be shared by the checks of an agent.
+type Extractor struct {
+	procRoot string
+
+	mu       sync.Mutex
+	cache    map[int32]*cachedService
+	packages *packageIndex
+}
+
+// NewExtractor returns an Extractor reading the process metadata from procRoot,
+// usually `/proc`
+func NewExtractor(procRoot string) *Extractor {
+	return &Extractor{
+		procRoot: procRoot,
+		cache:    make(map[int32]*cachedService),
+		packages: newPackageIndex(procRoot),
+	}
+}
+
+// Extract returns the service of a process. The heuristics are applied in the
+// following order, the first one finding a name wins:
+//  * the DD_SERVICE environment variable, which is the service of the traces of the process
+//  * the image of its container
+//  * its systemd service unit
+//  * the jar, module or script run by an interpreter
+//  * the OS package which installed its executable
+func (e *Extractor) Extract(p ProcessInfo) Service {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if cached, ok := e.cache[p.Pid]; ok && cached.createTime == p.CreateTime {
+		cached.seen = true
+		return cached.service
+	}
+
+	service := e.extract(p)
+	e.cache[p.Pid] = &cachedService{createTime: p.CreateTime, service: service, seen: true}
+	return service
+}
+
+func (e *Extractor) extract(p ProcessInfo) Service {
+	if name := normalizeServiceName(readProcessEnv(e.procRoot, p.Pid, "DD_SERVICE")); name != "" {
+		return Service{Name: name, Source: SourceEnv}
+	}
+	if name := normalizeServiceName(serviceFromImage(p.ContainerImage)); name != "" {
+		return Service{Name: name, Source: SourceContainerImage}
+	}
+	// the systemd units and the OS packages of the host don't apply to the processes of the containers
+	if p.ContainerImage == "" {
+		if name := normalizeServiceName(readSystemdUnit(e.procRoot, p.Pid)); name != "" {
+			return Service{Name: name, Source: SourceSystemd}
+		}
+	}
+	if name := normalizeServiceName(serviceFromCommandLine(p.Cmdline)); name != "" {
+		return Service{Name: name, Source: SourceCommandLine}
+	}
+	if p.ContainerImage == "" && p.Exe != "" {
+		if name := normalizeServiceName(e.packages.lookup(p.Exe)); name != "" {
+			return Service{Name: name, Source: SourcePackage}
+		}
+	}
+	return Service{}
+}
+
+// Expire removes the processes which weren't extracted since the previous call,
+// it's meant to be called after each collection of the processes
+func (e *Extractor) Expire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for pid, cached := range e.cache {
+		if !cached.seen {
+			delete(e.cache, pid)
+			continue
+		}
+		cached.seen = false
+	}
+}
+
+// serviceFromImage returns the short name of a container image, e.g. `redis`
+// for `docker.io/library/redis:6.0@sha256:...`
+func serviceFromImage(image string) string {
+	if i := strings.IndexByte(image, '@'); i != -1 {
+		image = image[:i]
+	}
+	if i := strings.LastIndexByte(image, '/'); i != -1 {
+		image = image[i+1:]
+	}
+	if i := strings.IndexByte(image, ':'); i != -1 {
+		image = image[:i]
+	}
+	return image
+}
+
+// normalizeServiceName lowercases a name and replaces the characters the backend
+// doesn't accept in a service with underscores
+func normalizeServiceName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if b.Len() == 0 && !unicode.IsLetter(r) {
+			continue
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' || r == '/':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+		if b.Len() >= maxServiceLen {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
@@ -0,0 +1,79 @@
+package servicediscovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFromCommandLine(t *testing.T) {
+	for _, tc := range []struct {
+		cmdline  []string
+		expected string
+	}{
+		{[]string{"java", "-Xmx1g", "-jar", "/opt/billing/billing-1.2.3-SNAPSHOT.jar", "--port", "8080"}, "billing"},
+		{[]string{"/usr/lib/jvm/java-11/bin/java", "-cp", "/opt/es/lib/*", "org.elasticsearch.bootstrap.Elasticsearch"}, "Elasticsearch"},
+		{[]string{"java", "-Ddd.service=payments", "-jar", "app.jar"}, "payments"},
+		{[]string{"java", "-version"}, ""},
+		{[]string{"python3.8", "-u", "-m", "worker.main"}, "worker"},
+		{[]string{"/usr/bin/python3 /srv/shop/manage.py runserver"}, "shop"},
+		{[]string{"python", "scraper.py"}, "scraper"},
+		{[]string{"python", "-c", "print(1)"}, ""},
+		{[]string{"python", "-m", "gunicorn", "-w", "4", "inventory.wsgi:application"}, "inventory"},
+		{[]string{"gunicorn", "--bind", "0.0.0.0:8000", "orders.app:create_app()"}, "orders"},
+		{[]string{"celery", "-A", "tasks", "worker"}, "tasks"},
+		{[]string{"node", "--inspect", "/srv/api/dist/index.js"}, "api"},
+		{[]string{"node", "/srv/gateway.js"}, "gateway"},
+		{[]string{"dotnet", "exec", "Catalog.Api.dll"}, "Catalog.Api"},
+		{[]string{"/usr/sbin/nginx", "-g", "daemon off;"}, ""},
+		{nil, ""},
+	} {
+		assert.Equal(t, tc.expected, serviceFromCommandLine(tc.cmdline), "%v", tc.cmdline)
+	}
+}
+
+func TestServiceFromImage(t *testing.T) {
+	assert.Equal(t, "redis", serviceFromImage("redis"))
+	assert.Equal(t, "redis", serviceFromImage("docker.io/library/redis:6.0"))
+	assert.Equal(t, "agent", serviceFromImage("gcr.io/datadoghq/agent:7@sha256:0123456789abcdef"))
+	assert.Equal(t, "app", serviceFromImage("localhost:5000/team/app"))
+	assert.Equal(t, "", serviceFromImage(""))
+}
+
+func TestNormalizeServiceName(t *testing.T) {
+	assert.Equal(t, "billing-api", normalizeServiceName("Billing-API"))
+	assert.Equal(t, "my_app", normalizeServiceName(" my app "))
+	assert.Equal(t, "web", normalizeServiceName("__web__"))
+	assert.Equal(t, "svc", normalizeServiceName("42svc"))
+	assert.Equal(t, "", normalizeServiceName("1234"))
+	assert.Len(t, normalizeServiceName(string(make([]byte, 300))+"a"), 1)
+}
+
+func TestExtractorCache(t *testing.T) {
+	e := NewExtractor("/nonexistent")
+	p := ProcessInfo{Pid: 42, CreateTime: 1, Cmdline: []string{"java", "-jar", "billing.jar"}}
+
+	assert.Equal(t, Service{Name: "billing", Source: SourceCommandLine}, e.Extract(p))
+	assert.Len(t, e.cache, 1)
+
+	// the cache is used while the process lives
+	p.Cmdline = []string{"java", "-jar", "other.jar"}
+	assert.Equal(t, "billing", e.Extract(p).Name)
+
+	// the pid was reused
+	p.CreateTime = 2
+	assert.Equal(t, "other", e.Extract(p).Name)
+
+	e.Expire()
+	assert.Len(t, e.cache, 1)
+	e.Expire()
+	assert.Len(t, e.cache, 0)
+}
+
+func TestExtractorContainerImage(t *testing.T) {
+	e := NewExtractor("/nonexistent")
+	service := e.Extract(ProcessInfo{Pid: 1, Cmdline: []string{"java", "-jar", "app.jar"}, ContainerImage: "acme/checkout:1.0"})
+	assert.Equal(t, Service{Name: "checkout", Source: SourceContainerImage}, service)
+	assert.Equal(t, []string{"service:checkout"}, service.Tags())
+	assert.Nil(t, Service{}.Tags())
+}
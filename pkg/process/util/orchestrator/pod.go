@@ -18,14 +18,15 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 
 	jsoniter "github.com/json-iterator/go"
 	yaml "gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	redactedValue = "********"
 	// from https://github.com/kubernetes/kubernetes/blob/abe6321296123aaba8e83978f7d17951ab1b64fd/pkg/util/node/node.go#L43
 	nodeUnreachablePodReason = "NodeLost"
 )
@@ -34,8 +35,12 @@ const (
 func ProcessPodlist(podList []*v1.Pod, groupID int32, cfg *config.AgentConfig, hostName string, clusterName string, clusterID string) ([]model.MessageBody, error) {
 	start := time.Now()
 	podMsgs := make([]*model.Pod, 0, len(podList))
+	s := scrubber.Default()
+	scrubbed := 0
 
 	for p := 0; p < len(podList); p++ {
+		scrubbed += scrubMetadata(&podList[p].ObjectMeta, s)
+
 		// extract pod info
 		podModel := extractPodMessage(podList[p])
 
@@ -48,12 +53,7 @@ func ProcessPodlist(podList []*v1.Pod, groupID int32, cfg *config.AgentConfig, h
 		podModel.Tags = tags
 
 		// scrub & generate YAML
-		for c := 0; c < len(podList[p].Spec.Containers); c++ {
-			scrubContainer(&podList[p].Spec.Containers[c], cfg)
-		}
-		for c := 0; c < len(podList[p].Spec.InitContainers); c++ {
-			scrubContainer(&podList[p].Spec.InitContainers[c], cfg)
-		}
+		scrubbed += scrubPodSpec(&podList[p].Spec, cfg.Scrubber, s)
 		// k8s objects only have json "omitempty" annotations
 		// we're doing json<>yaml to get rid of the null properties
 		jsonPod, err := jsoniter.Marshal(podList[p])
@@ -86,24 +86,40 @@ func ProcessPodlist(podList []*v1.Pod, groupID int32, cfg *config.AgentConfig, h
 		})
 	}
 
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
 	log.Debugf("Collected & enriched %d pods in %s", len(podMsgs), time.Now().Sub(start))
 	return messages, nil
 }
 
-// scrubContainer scrubs sensitive information in the command line & env vars
-func scrubContainer(c *v1.Container, cfg *config.AgentConfig) {
-	// scrub command line
-	scrubbedCmd, _ := cfg.Scrubber.ScrubCommand(c.Command)
-	c.Command = scrubbedCmd
-	// scrub env vars
+// scrubContainer scrubs sensitive information in the command line, the arguments
+// & env vars, it returns the number of values scrubbed. The command line and the
+// arguments are scrubbed with the data scrubber of the process config as well,
+// which holds the custom sensitive words, unless the scrubbing of the arguments is disabled.
+func scrubContainer(c *v1.Container, ds *config.DataScrubber, s *scrubber.Scrubber) int {
+	scrubbed := 0
+	if ds.Enabled {
+		var scrubbedCmd, scrubbedArgs bool
+		c.Command, scrubbedCmd = ds.ScrubCommand(c.Command)
+		c.Args, scrubbedArgs = ds.ScrubCommand(c.Args)
+		var cmdCount, argsCount int
+		c.Command, cmdCount = s.ScrubArgs(c.Command)
+		c.Args, argsCount = s.ScrubArgs(c.Args)
+		scrubbed += cmdCount + argsCount
+		// the values redacted by the data scrubber aren't counted again
+		if scrubbedCmd && cmdCount == 0 {
+			scrubbed++
+		}
+		if scrubbedArgs && argsCount == 0 {
+			scrubbed++
+		}
+	}
 	for e := 0; e < len(c.Env); e++ {
-		// use the "key: value" format to work with the regular credential cleaner
-		combination := c.Env[e].Name + ": " + c.Env[e].Value
-		scrubbedVal, err := log.CredentialsCleanerBytes([]byte(combination))
-		if err == nil && combination != string(scrubbedVal) {
-			c.Env[e].Value = redactedValue
+		if value, ok := s.ScrubValue(c.Env[e].Name, c.Env[e].Value); ok {
+			c.Env[e].Value = value
+			scrubbed++
 		}
 	}
+	return scrubbed
 }
 
 // chunkPods formats and chunks the pods into a slice of chunks using a specific number of chunks.
@@ -126,44 +142,8 @@ func chunkPods(pods []*model.Pod, chunks, perChunk int) [][]*model.Pod {
 
 // extractPodMessage extracts pod info into the proto model
 func extractPodMessage(p *v1.Pod) *model.Pod {
-	// pod medatadata
-	podMetadata := model.Metadata{
-		Name:      p.Name,
-		Namespace: p.Namespace,
-		Uid:       string(p.UID),
-	}
-	if !p.ObjectMeta.CreationTimestamp.IsZero() {
-		podMetadata.CreationTimestamp = p.ObjectMeta.CreationTimestamp.Unix()
-	}
-	if !p.ObjectMeta.DeletionTimestamp.IsZero() {
-		podMetadata.DeletionTimestamp = p.ObjectMeta.DeletionTimestamp.Unix()
-	}
-	if len(p.Annotations) > 0 {
-		podMetadata.Annotations = make([]string, len(p.Annotations))
-		i := 0
-		for k, v := range p.Annotations {
-			podMetadata.Annotations[i] = k + ":" + v
-			i++
-		}
-	}
-	if len(p.Labels) > 0 {
-		podMetadata.Labels = make([]string, len(p.Labels))
-		i := 0
-		for k, v := range p.Labels {
-			podMetadata.Labels[i] = k + ":" + v
-			i++
-		}
-	}
-	for _, o := range p.OwnerReferences {
-		owner := model.OwnerReference{
-			Name: o.Name,
-			Uid:  string(o.UID),
-			Kind: o.Kind,
-		}
-		podMetadata.OwnerReferences = append(podMetadata.OwnerReferences, &owner)
-	}
 	podModel := model.Pod{
-		Metadata: &podMetadata,
+		Metadata: extractMetadata(&p.ObjectMeta),
 	}
 	// pod spec
 	podModel.NodeName = p.Spec.NodeName
@@ -200,6 +180,46 @@ func extractPodMessage(p *v1.Pod) *model.Pod {
 	return &podModel
 }
 
+// extractMetadata extracts the metadata of a Kubernetes object into the proto model
+func extractMetadata(m *metav1.ObjectMeta) *model.Metadata {
+	metadata := model.Metadata{
+		Name:      m.Name,
+		Namespace: m.Namespace,
+		Uid:       string(m.UID),
+	}
+	if !m.CreationTimestamp.IsZero() {
+		metadata.CreationTimestamp = m.CreationTimestamp.Unix()
+	}
+	if !m.DeletionTimestamp.IsZero() {
+		metadata.DeletionTimestamp = m.DeletionTimestamp.Unix()
+	}
+	if len(m.Annotations) > 0 {
+		metadata.Annotations = make([]string, len(m.Annotations))
+		i := 0
+		for k, v := range m.Annotations {
+			metadata.Annotations[i] = k + ":" + v
+			i++
+		}
+	}
+	if len(m.Labels) > 0 {
+		metadata.Labels = make([]string, len(m.Labels))
+		i := 0
+		for k, v := range m.Labels {
+			metadata.Labels[i] = k + ":" + v
+			i++
+		}
+	}
+	for _, o := range m.OwnerReferences {
+		owner := model.OwnerReference{
+			Name: o.Name,
+			Uid:  string(o.UID),
+			Kind: o.Kind,
+		}
+		metadata.OwnerReferences = append(metadata.OwnerReferences, &owner)
+	}
+	return &metadata
+}
+
 // ComputeStatus is mostly copied from kubernetes to match what users see in kubectl
 // in case of issues, check for changes upstream: https://github.com/kubernetes/kubernetes/blob/1e12d92a5179dbfeb455c79dbf9120c8536e5f9c/pkg/printers/internalversion/printers.go#L685
 func ComputeStatus(p *v1.Pod) string {
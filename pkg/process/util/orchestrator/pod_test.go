@@ -14,6 +14,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -228,7 +229,6 @@ func TestExtractPodMessage(t *testing.T) {
 }
 
 func TestScrubContainer(t *testing.T) {
-	cfg := config.NewDefaultAgentConfig(true)
 	tests := map[string]struct {
 		input    v1.Container
 		expected v1.Container
@@ -241,6 +241,16 @@ func TestScrubContainer(t *testing.T) {
 				Command: []string{"mysql", "--password", "********"},
 			},
 		},
+		"sensitive args": {
+			input: v1.Container{
+				Command: []string{"redis-server"},
+				Args:    []string{"--port", "6379", "--password=afztyerbzio1234", "--api_key", "yolo123"},
+			},
+			expected: v1.Container{
+				Command: []string{"redis-server"},
+				Args:    []string{"--port", "6379", "--password=********", "--api_key", "********"},
+			},
+		},
 		"sensitive env var": {
 			input: v1.Container{
 				Env: []v1.EnvVar{{Name: "password", Value: "kqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJ"}},
@@ -272,12 +282,37 @@ func TestScrubContainer(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			scrubContainer(&tc.input, cfg)
+			scrubContainer(&tc.input, config.NewDefaultDataScrubber(), scrubber.Default())
 			assert.Equal(t, tc.expected, tc.input)
 		})
 	}
 }
 
+func TestScrubContainerDataScrubber(t *testing.T) {
+	ds := config.NewDefaultDataScrubber()
+	ds.AddCustomSensitiveWords([]string{"license"})
+	c := v1.Container{
+		Command: []string{"server", "--license", "afztyerbzio1234"},
+		Args:    []string{"--password=afztyerbzio1234"},
+		Env:     []v1.EnvVar{{Name: "password", Value: "afztyerbzio1234"}},
+	}
+
+	// the custom sensitive words of the process config are scrubbed
+	scrubbed := c.DeepCopy()
+	assert.Equal(t, 3, scrubContainer(scrubbed, ds, scrubber.Default()))
+	assert.Equal(t, []string{"server", "--license", "********"}, scrubbed.Command)
+	assert.Equal(t, []string{"--password=********"}, scrubbed.Args)
+	assert.Equal(t, "********", scrubbed.Env[0].Value)
+
+	// the command lines aren't scrubbed when scrub_args is disabled
+	ds.Enabled = false
+	scrubbed = c.DeepCopy()
+	assert.Equal(t, 1, scrubContainer(scrubbed, ds, scrubber.Default()))
+	assert.Equal(t, c.Command, scrubbed.Command)
+	assert.Equal(t, c.Args, scrubbed.Args)
+	assert.Equal(t, "********", scrubbed.Env[0].Value)
+}
+
 func TestComputeStatus(t *testing.T) {
 	for nb, tc := range []struct {
 		pod    *v1.Pod
@@ -0,0 +1,344 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build orchestrator
+
+package orchestrator
+
+import (
+	"time"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+
+	jsoniter "github.com/json-iterator/go"
+	yaml "gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// lastAppliedConfigAnnotation holds a copy of the whole manifest applied by kubectl,
+// including the env vars that would otherwise be scrubbed
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// ProcessDeploymentList processes a deployment list into process messages
+func ProcessDeploymentList(deploymentList []*appsv1.Deployment, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	deployMsgs := make([]*model.Deployment, 0, len(deploymentList))
+	s := scrubber.Default()
+	scrubbed := 0
+
+	for _, d := range deploymentList {
+		// the objects are shared with the informer cache, they must not be scrubbed in place
+		d = d.DeepCopy()
+		scrubbed += scrubMetadata(&d.ObjectMeta, s)
+		scrubbed += scrubPodSpec(&d.Spec.Template.Spec, cfg.Scrubber, s)
+		yamlDeploy, err := marshalYaml(d)
+		if err != nil {
+			log.Debugf("Could not marshal deployment %s/%s: %s", d.Namespace, d.Name, err)
+			continue
+		}
+		deployMsgs = append(deployMsgs, &model.Deployment{
+			Metadata: extractMetadata(&d.ObjectMeta),
+			Yaml:     yamlDeploy,
+		})
+	}
+
+	groupSize := chunkCount(len(deployMsgs), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(deployMsgs), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorDeployment{
+			ClusterName: clusterName,
+			Deployments: deployMsgs[low:high],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d deployments in %s", len(deployMsgs), time.Now().Sub(start))
+	return messages, nil
+}
+
+// ProcessReplicaSetList processes a replica set list into process messages
+func ProcessReplicaSetList(rsList []*appsv1.ReplicaSet, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	rsMsgs := make([]*model.ReplicaSet, 0, len(rsList))
+	s := scrubber.Default()
+	scrubbed := 0
+
+	for _, rs := range rsList {
+		rs = rs.DeepCopy()
+		scrubbed += scrubMetadata(&rs.ObjectMeta, s)
+		scrubbed += scrubPodSpec(&rs.Spec.Template.Spec, cfg.Scrubber, s)
+		yamlRs, err := marshalYaml(rs)
+		if err != nil {
+			log.Debugf("Could not marshal replica set %s/%s: %s", rs.Namespace, rs.Name, err)
+			continue
+		}
+		rsMsgs = append(rsMsgs, &model.ReplicaSet{
+			Metadata: extractMetadata(&rs.ObjectMeta),
+			Yaml:     yamlRs,
+		})
+	}
+
+	groupSize := chunkCount(len(rsMsgs), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(rsMsgs), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorReplicaSet{
+			ClusterName: clusterName,
+			ReplicaSets: rsMsgs[low:high],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d replica sets in %s", len(rsMsgs), time.Now().Sub(start))
+	return messages, nil
+}
+
+// ProcessStatefulSetList processes a stateful set list into process messages
+func ProcessStatefulSetList(stsList []*appsv1.StatefulSet, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	stsMsgs := make([]*model.StatefulSet, 0, len(stsList))
+	s := scrubber.Default()
+	scrubbed := 0
+
+	for _, sts := range stsList {
+		sts = sts.DeepCopy()
+		scrubbed += scrubMetadata(&sts.ObjectMeta, s)
+		scrubbed += scrubPodSpec(&sts.Spec.Template.Spec, cfg.Scrubber, s)
+		yamlSts, err := marshalYaml(sts)
+		if err != nil {
+			log.Debugf("Could not marshal stateful set %s/%s: %s", sts.Namespace, sts.Name, err)
+			continue
+		}
+		stsMsgs = append(stsMsgs, &model.StatefulSet{
+			Metadata: extractMetadata(&sts.ObjectMeta),
+			Yaml:     yamlSts,
+		})
+	}
+
+	groupSize := chunkCount(len(stsMsgs), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(stsMsgs), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorStatefulSet{
+			ClusterName:  clusterName,
+			StatefulSets: stsMsgs[low:high],
+			GroupId:      groupID,
+			GroupSize:    int32(groupSize),
+			ClusterId:    clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d stateful sets in %s", len(stsMsgs), time.Now().Sub(start))
+	return messages, nil
+}
+
+// ProcessDaemonSetList processes a daemon set list into process messages
+func ProcessDaemonSetList(dsList []*appsv1.DaemonSet, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	dsMsgs := make([]*model.DaemonSet, 0, len(dsList))
+	s := scrubber.Default()
+	scrubbed := 0
+
+	for _, ds := range dsList {
+		ds = ds.DeepCopy()
+		scrubbed += scrubMetadata(&ds.ObjectMeta, s)
+		scrubbed += scrubPodSpec(&ds.Spec.Template.Spec, cfg.Scrubber, s)
+		yamlDs, err := marshalYaml(ds)
+		if err != nil {
+			log.Debugf("Could not marshal daemon set %s/%s: %s", ds.Namespace, ds.Name, err)
+			continue
+		}
+		dsMsgs = append(dsMsgs, &model.DaemonSet{
+			Metadata: extractMetadata(&ds.ObjectMeta),
+			Yaml:     yamlDs,
+		})
+	}
+
+	groupSize := chunkCount(len(dsMsgs), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(dsMsgs), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorDaemonSet{
+			ClusterName: clusterName,
+			DaemonSets:  dsMsgs[low:high],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d daemon sets in %s", len(dsMsgs), time.Now().Sub(start))
+	return messages, nil
+}
+
+// ProcessNodeList processes a node list into process messages
+func ProcessNodeList(nodeList []*v1.Node, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	nodeMsgs := make([]*model.Node, 0, len(nodeList))
+	s := scrubber.Default()
+	scrubbed := 0
+
+	for _, n := range nodeList {
+		n = n.DeepCopy()
+		scrubbed += scrubMetadata(&n.ObjectMeta, s)
+		// the images of a node can be listed by the hundreds, and are reported by the pods already
+		n.Status.Images = nil
+		yamlNode, err := marshalYaml(n)
+		if err != nil {
+			log.Debugf("Could not marshal node %s: %s", n.Name, err)
+			continue
+		}
+		nodeMsgs = append(nodeMsgs, &model.Node{
+			Metadata: extractMetadata(&n.ObjectMeta),
+			Yaml:     yamlNode,
+		})
+	}
+
+	groupSize := chunkCount(len(nodeMsgs), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(nodeMsgs), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorNode{
+			ClusterName: clusterName,
+			Nodes:       nodeMsgs[low:high],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d nodes in %s", len(nodeMsgs), time.Now().Sub(start))
+	return messages, nil
+}
+
+// ProcessCustomResourceList processes a list of custom resources into manifest
+// messages. The custom resources have no known schema, so their whole manifest
+// goes through the scrubber. The manifests larger than maxSize bytes
+// are dropped, their number is returned.
+func ProcessCustomResourceList(crList []*unstructured.Unstructured, groupID int32, cfg *config.AgentConfig, clusterName string, clusterID string, maxSize int) ([]model.MessageBody, int, error) {
+	start := time.Now()
+	manifests := make([]*model.Manifest, 0, len(crList))
+	s := scrubber.Default()
+	tooLarge, scrubbed := 0, 0
+
+	for _, cr := range crList {
+		cr = cr.DeepCopy()
+		annotations := cr.GetAnnotations()
+		if _, found := annotations[lastAppliedConfigAnnotation]; found {
+			delete(annotations, lastAppliedConfigAnnotation)
+			cr.SetAnnotations(annotations)
+		}
+		yamlCr, err := marshalYaml(cr.Object)
+		if err != nil {
+			log.Debugf("Could not marshal %s %s/%s: %s", cr.GetKind(), cr.GetNamespace(), cr.GetName(), err)
+			continue
+		}
+		if maxSize > 0 && len(yamlCr) > maxSize {
+			log.Debugf("Skipping %s %s/%s: its manifest is %d bytes, more than %d", cr.GetKind(), cr.GetNamespace(), cr.GetName(), len(yamlCr), maxSize)
+			tooLarge++
+			continue
+		}
+		scrubbedCr, n, err := s.ScrubBytes(yamlCr)
+		if err != nil {
+			log.Debugf("Could not scrub %s %s/%s: %s", cr.GetKind(), cr.GetNamespace(), cr.GetName(), err)
+			continue
+		}
+		scrubbed += n
+		manifests = append(manifests, &model.Manifest{
+			Uid:             string(cr.GetUID()),
+			ResourceVersion: cr.GetResourceVersion(),
+			Content:         scrubbedCr,
+			ContentType:     "yaml",
+			Version:         "v1",
+		})
+	}
+
+	groupSize := chunkCount(len(manifests), cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+	for i := 0; i < groupSize; i++ {
+		low, high := chunkBounds(i, len(manifests), cfg.MaxPerMessage)
+		messages = append(messages, &model.CollectorManifest{
+			ClusterName: clusterName,
+			Manifests:   manifests[low:high],
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			ClusterId:   clusterID,
+		})
+	}
+
+	scrubber.AddScrubbedFields("orchestrator", scrubbed)
+	log.Debugf("Collected & enriched %d custom resources in %s", len(manifests), time.Now().Sub(start))
+	return messages, tooLarge, nil
+}
+
+// scrubPodSpec scrubs sensitive information in the containers of a pod spec,
+// it returns the number of values scrubbed
+func scrubPodSpec(spec *v1.PodSpec, ds *config.DataScrubber, s *scrubber.Scrubber) int {
+	scrubbed := 0
+	for c := 0; c < len(spec.Containers); c++ {
+		scrubbed += scrubContainer(&spec.Containers[c], ds, s)
+	}
+	for c := 0; c < len(spec.InitContainers); c++ {
+		scrubbed += scrubContainer(&spec.InitContainers[c], ds, s)
+	}
+	return scrubbed
+}
+
+// scrubMetadata removes the last applied configuration annotation, it would leak
+// the values scrubbed from the manifest, and scrubs the other annotations. It
+// returns the number of values scrubbed.
+func scrubMetadata(m *metav1.ObjectMeta, s *scrubber.Scrubber) int {
+	delete(m.Annotations, lastAppliedConfigAnnotation)
+	return s.ScrubMap(m.Annotations)
+}
+
+// marshalYaml marshals a Kubernetes object in YAML, k8s objects only have json
+// "omitempty" annotations so we're doing json<>yaml to get rid of the null properties
+func marshalYaml(obj interface{}) ([]byte, error) {
+	jsonObj, err := jsoniter.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var yamlObj interface{}
+	if err := yaml.Unmarshal(jsonObj, &yamlObj); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(yamlObj)
+}
+
+// chunkCount returns the number of chunks needed to send the given number of items
+func chunkCount(items, perChunk int) int {
+	chunks := items / perChunk
+	if items%perChunk != 0 {
+		chunks++
+	}
+	return chunks
+}
+
+// chunkBounds returns the bounds of the i-th chunk of the given number of items
+func chunkBounds(i, items, perChunk int) (int, int) {
+	low := i * perChunk
+	high := low + perChunk
+	if high > items {
+		high = items
+	}
+	return low, high
+}
@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build orchestrator
+
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	model "github.com/DataDog/agent-payload/process"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProcessDeploymentList(t *testing.T) {
+	cfg := config.NewDefaultAgentConfig(true)
+	cfg.MaxPerMessage = 2
+
+	var deploys []*appsv1.Deployment
+	for i := 0; i < 3; i++ {
+		deploys = append(deploys, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("deploy-%d", i),
+				Namespace: "default",
+				Annotations: map[string]string{
+					lastAppliedConfigAnnotation: `{"env":[{"name":"password","value":"secret"}]}`,
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{
+							Name: "app",
+							Env:  []v1.EnvVar{{Name: "password", Value: "kqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJ"}},
+						}},
+					},
+				},
+			},
+		})
+	}
+
+	messages, err := ProcessDeploymentList(deploys, 12, cfg, "cluster", "cluster-id")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	first := messages[0].(*model.CollectorDeployment)
+	assert.Equal(t, "cluster", first.ClusterName)
+	assert.Equal(t, "cluster-id", first.ClusterId)
+	assert.Equal(t, int32(12), first.GroupId)
+	assert.Equal(t, int32(2), first.GroupSize)
+	require.Len(t, first.Deployments, 2)
+	assert.Len(t, messages[1].(*model.CollectorDeployment).Deployments, 1)
+
+	deploy := first.Deployments[0]
+	assert.Equal(t, "deploy-0", deploy.Metadata.Name)
+	assert.Empty(t, deploy.Metadata.Annotations)
+	assert.Contains(t, string(deploy.Yaml), "********")
+	assert.NotContains(t, string(deploy.Yaml), "kqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJ")
+	assert.NotContains(t, string(deploy.Yaml), "secret")
+
+	// the objects of the informer cache are left untouched
+	assert.Equal(t, "kqhkiG9w0BAQEFAASCAl8wggJbAgEAAoGBAOLJ", deploys[0].Spec.Template.Spec.Containers[0].Env[0].Value)
+	assert.Contains(t, deploys[0].Annotations, lastAppliedConfigAnnotation)
+}
+
+func TestProcessNodeList(t *testing.T) {
+	cfg := config.NewDefaultAgentConfig(true)
+	nodes := []*v1.Node{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"zone": "a"}},
+		Status: v1.NodeStatus{
+			Images: []v1.ContainerImage{{Names: []string{"nginx:latest"}}},
+		},
+	}}
+
+	messages, err := ProcessNodeList(nodes, 1, cfg, "cluster", "cluster-id")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	node := messages[0].(*model.CollectorNode).Nodes[0]
+	assert.Equal(t, []string{"zone:a"}, node.Metadata.Labels)
+	assert.NotContains(t, string(node.Yaml), "nginx")
+	assert.Len(t, nodes[0].Status.Images, 1)
+
+	messages, err = ProcessNodeList(nil, 1, cfg, "cluster", "cluster-id")
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestProcessCustomResourceList(t *testing.T) {
+	cfg := config.NewDefaultAgentConfig(true)
+	newWidget := func(name, spec string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":            name,
+				"namespace":       "default",
+				"uid":             "uid-" + name,
+				"resourceVersion": "12",
+				"annotations": map[string]interface{}{
+					lastAppliedConfigAnnotation: "{}",
+				},
+			},
+			"spec": map[string]interface{}{"password": spec},
+		}}
+	}
+	widgets := []*unstructured.Unstructured{
+		newWidget("small", "afztyerbzio1234"),
+		newWidget("large", strings.Repeat("a", 1024)),
+	}
+
+	messages, tooLarge, err := ProcessCustomResourceList(widgets, 1, cfg, "cluster", "cluster-id", 512)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tooLarge)
+	require.Len(t, messages, 1)
+	manifests := messages[0].(*model.CollectorManifest).Manifests
+	require.Len(t, manifests, 1)
+	assert.Equal(t, "uid-small", manifests[0].Uid)
+	assert.Equal(t, "12", manifests[0].ResourceVersion)
+	assert.Contains(t, string(manifests[0].Content), "kind: Widget")
+	assert.NotContains(t, string(manifests[0].Content), "afztyerbzio1234")
+	assert.NotContains(t, string(manifests[0].Content), lastAppliedConfigAnnotation)
+
+	// the objects of the informer cache are left untouched
+	assert.Contains(t, widgets[0].GetAnnotations(), lastAppliedConfigAnnotation)
+}
+
+func TestChunkBounds(t *testing.T) {
+	assert.Equal(t, 0, chunkCount(0, 100))
+	assert.Equal(t, 1, chunkCount(100, 100))
+	assert.Equal(t, 2, chunkCount(101, 100))
+
+	low, high := chunkBounds(1, 101, 100)
+	assert.Equal(t, 100, low)
+	assert.Equal(t, 101, high)
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -20,6 +21,10 @@ func HandleSignals(exit chan bool) {
 		case syscall.SIGINT, syscall.SIGTERM:
 			log.Criticalf("Caught signal '%s'; terminating.", sig)
 			close(exit)
+		case syscall.SIGHUP:
+			// Reloads the settings that can change while the agent runs, e.g. the scrub rules
+			log.Infof("Caught signal '%s'; reloading the configuration.", sig)
+			config.Reload()
 		case syscall.SIGCHLD:
 			// Running docker.GetDockerStat() spins up / kills a new process
 			continue
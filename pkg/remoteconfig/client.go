@@ -0,0 +1,349 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package remoteconfig polls the remote configuration service for the agent settings
+// and the checks that can be tuned fleet-wide, verifies their signature, and applies
+// them live.
+package remoteconfig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/persistentcache"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	configurationPath = "/api/v1/agent/configuration"
+	// versionCacheKey is the key of the last version applied in the persistent cache, shared
+	// by the agent processes polling the remote configuration
+	versionCacheKey = "remote_configuration_version"
+)
+
+var (
+	remoteConfigExpvars = expvar.NewMap("remoteconfig")
+	tlmPolls            = telemetry.NewCounter("remoteconfig", "polls",
+		[]string{"status"}, "Count of the polls of the remote configuration service, by status")
+)
+
+// Configuration is the configuration served by the remote configuration service
+type Configuration struct {
+	// Version increases with each change of the configuration, the older versions are rejected
+	Version int64 `json:"version"`
+	// Settings override the agent settings that can be changed remotely
+	Settings map[string]interface{} `json:"settings"`
+	// DisabledChecks are the names of the checks not to run
+	DisabledChecks []string `json:"disabled_checks"`
+}
+
+// signedConfiguration is the configuration, signed with the private key of one of
+// the trusted public keys
+type signedConfiguration struct {
+	KeyID     string `json:"key_id"`
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// State is the state of the remote configuration applied, reported to the service and
+// shown in the status page
+type State struct {
+	Enabled          bool              `json:"enabled"`
+	URL              string            `json:"url"`
+	Version          int64             `json:"version"`
+	AppliedAt        time.Time         `json:"applied_at,omitempty"`
+	LastPoll         time.Time         `json:"last_poll,omitempty"`
+	LastError        string            `json:"last_error,omitempty"`
+	AppliedSettings  []string          `json:"applied_settings"`
+	RejectedSettings map[string]string `json:"rejected_settings"`
+	DisabledChecks   []string          `json:"disabled_checks"`
+}
+
+// Client polls the remote configuration service, and applies the configurations signed
+// with a trusted key
+type Client struct {
+	url        string
+	apiKey     string
+	hostname   string
+	interval   time.Duration
+	keys       map[string]ed25519.PublicKey
+	httpClient *http.Client
+
+	// applySettings applies the settings of the configuration, replaced in the tests
+	applySettings func(settings map[string]interface{}) map[string]string
+	// onApply applies what the process does with the configuration, e.g. disabling the checks
+	onApply func(conf *Configuration)
+	// minVersion is the last version applied before the agent restarted, the older
+	// versions are rejected
+	minVersion int64
+
+	m     sync.RWMutex
+	state State
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewClient creates a client of the remote configuration service from the agent config.
+// The settings of the configurations are applied to the agent config, then onApply is called
+// with each configuration applied.
+func NewClient(hostname string, onApply func(conf *Configuration)) (*Client, error) {
+	keys := make(map[string]ed25519.PublicKey)
+	for keyID, encoded := range config.Datadog.GetStringMapString("remote_configuration.public_keys") {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("the public key %s isn't a base64 encoded ed25519 public key", keyID)
+		}
+		keys[keyID] = ed25519.PublicKey(key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("remote_configuration.public_keys is empty, the configurations can't be verified")
+	}
+
+	interval := config.Datadog.GetDuration("remote_configuration.refresh_interval") * time.Second
+	if interval <= 0 {
+		log.Warnf("Configured remote_configuration.refresh_interval (%v) is not positive; 60 seconds will be used", interval)
+		interval = 60 * time.Second
+	}
+
+	url := strings.TrimSuffix(config.GetMainEndpoint("https://config.", "remote_configuration.dd_url"), "/") + configurationPath
+	c := &Client{
+		url:      url,
+		apiKey:   strings.TrimSpace(strings.Split(config.Datadog.GetString("api_key"), ",")[0]),
+		hostname: hostname,
+		interval: interval,
+		keys:     keys,
+		httpClient: &http.Client{
+			Timeout:   config.Datadog.GetDuration("remote_configuration.timeout") * time.Second,
+			Transport: httputils.CreateHTTPTransport(),
+		},
+		applySettings: config.ApplyRemoteSettings,
+		onApply:       onApply,
+		minVersion:    readAppliedVersion(),
+		state: State{
+			Enabled:          true,
+			URL:              url,
+			RejectedSettings: map[string]string{},
+		},
+	}
+	remoteConfigExpvars.Set("State", expvar.Func(func() interface{} {
+		return c.GetState()
+	}))
+	return c, nil
+}
+
+// Start polls the remote configuration service until the client is stopped
+func (c *Client) Start() {
+	c.stop = make(chan struct{})
+	c.stopped = make(chan struct{})
+	go c.pollLoop()
+}
+
+// Stop stops polling the remote configuration service
+func (c *Client) Stop() {
+	close(c.stop)
+	<-c.stopped
+}
+
+// GetState returns the state of the remote configuration applied
+func (c *Client) GetState() State {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.state
+}
+
+func (c *Client) pollLoop() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.poll()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.poll()
+		}
+	}
+}
+
+// poll fetches the configuration, and applies it when it's newer than the one applied
+func (c *Client) poll() {
+	err := c.update()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.state.LastPoll = time.Now()
+	c.state.LastError = ""
+	if err != nil {
+		log.Warnf("Could not update the remote configuration: %v", err)
+		c.state.LastError = err.Error()
+		tlmPolls.Inc("error")
+		return
+	}
+	tlmPolls.Inc("success")
+}
+
+func (c *Client) update() error {
+	signed, err := c.fetch()
+	if err != nil || signed == nil {
+		return err
+	}
+	conf, err := c.verify(signed)
+	if err != nil {
+		return err
+	}
+
+	c.m.RLock()
+	appliedVersion := c.state.Version
+	c.m.RUnlock()
+	// the version applied before a restart is applied again, but not the older ones
+	minVersion := c.minVersion
+	if appliedVersion > minVersion {
+		minVersion = appliedVersion
+	}
+	if conf.Version < minVersion {
+		return fmt.Errorf("the version %d of the configuration is older than the version %d applied", conf.Version, minVersion)
+	}
+	if conf.Version == appliedVersion {
+		return nil
+	}
+
+	c.apply(conf)
+	return nil
+}
+
+// fetch reports the state of the configuration applied, and returns the configuration
+// served, nil when it didn't change
+func (c *Client) fetch() (*signedConfiguration, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"hostname":      c.hostname,
+		"agent_version": version.AgentVersion,
+		"state":         c.GetState(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach the remote configuration service: %s", httputils.SanitizeURL(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the remote configuration service answered %s", resp.Status)
+	}
+
+	signed := &signedConfiguration{}
+	if err := json.Unmarshal(content, signed); err != nil {
+		return nil, fmt.Errorf("could not parse the signed configuration: %v", err)
+	}
+	return signed, nil
+}
+
+// verify checks the signature of the configuration, and decodes it
+func (c *Client) verify(signed *signedConfiguration) (*Configuration, error) {
+	key, found := c.keys[signed.KeyID]
+	if !found {
+		return nil, fmt.Errorf("the configuration is signed with the unknown key %q", signed.KeyID)
+	}
+	if !ed25519.Verify(key, signed.Payload, signed.Signature) {
+		return nil, fmt.Errorf("the signature of the configuration is invalid")
+	}
+
+	conf := &Configuration{}
+	if err := json.Unmarshal(signed.Payload, conf); err != nil {
+		return nil, fmt.Errorf("could not parse the configuration: %v", err)
+	}
+	return conf, nil
+}
+
+// apply applies the settings and the checks of the configuration
+func (c *Client) apply(conf *Configuration) {
+	rejected := c.applySettings(conf.Settings)
+	c.onApply(conf)
+	writeAppliedVersion(conf.Version)
+
+	applied := []string{}
+	for key := range conf.Settings {
+		key = strings.ToLower(key)
+		if reason, found := rejected[key]; found {
+			log.Warnf("The setting %s of the remote configuration version %d is rejected: %s", key, conf.Version, reason)
+			continue
+		}
+		applied = append(applied, key)
+	}
+	sort.Strings(applied)
+	disabledChecks := append([]string{}, conf.DisabledChecks...)
+	sort.Strings(disabledChecks)
+	log.Infof("Applied the remote configuration version %d: settings %v, disabled checks %v", conf.Version, applied, disabledChecks)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.state.Version = conf.Version
+	c.state.AppliedAt = time.Now()
+	c.state.AppliedSettings = applied
+	c.state.RejectedSettings = rejected
+	c.state.DisabledChecks = disabledChecks
+}
+
+// readAppliedVersion returns the last version applied, persisted so that the older versions
+// aren't applied again once the agent restarted
+func readAppliedVersion() int64 {
+	content, err := persistentcache.Read(versionCacheKey)
+	if err != nil || content == "" {
+		if err != nil {
+			log.Warnf("Could not read the last version of the remote configuration applied: %v", err)
+		}
+		return 0
+	}
+	version, err := strconv.ParseInt(content, 10, 64)
+	if err != nil {
+		log.Warnf("Could not parse the last version of the remote configuration applied: %v", err)
+		return 0
+	}
+	return version
+}
+
+// writeAppliedVersion persists the version applied, unless another agent process already
+// applied a newer one
+func writeAppliedVersion(version int64) {
+	if version <= readAppliedVersion() {
+		return
+	}
+	if err := persistentcache.Write(versionCacheKey, strconv.FormatInt(version, 10)); err != nil {
+		log.Warnf("Could not persist the version %d of the remote configuration applied: %v", version, err)
+	}
+}
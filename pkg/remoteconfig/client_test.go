@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remoteconfig
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type fakeService struct {
+	privateKey ed25519.PrivateKey
+	keyID      string
+	served     *signedConfiguration
+	apiKeys    []string
+}
+
+func (s *fakeService) serve(conf Configuration) {
+	payload, _ := json.Marshal(conf)
+	s.served = &signedConfiguration{
+		KeyID:     s.keyID,
+		Payload:   payload,
+		Signature: ed25519.Sign(s.privateKey, payload),
+	}
+}
+
+func (s *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.apiKeys = append(s.apiKeys, r.Header.Get("DD-API-KEY"))
+	if s.served == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(s.served)
+}
+
+type appliedConfig struct {
+	settings       map[string]interface{}
+	disabledChecks []string
+}
+
+func newTestClient(t *testing.T, runPath string) (*Client, *fakeService, *appliedConfig, *httptest.Server) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	service := &fakeService{privateKey: privateKey, keyID: "key1"}
+	ts := httptest.NewServer(service)
+
+	mockConfig := config.Mock()
+	mockConfig.Set("run_path", runPath)
+	mockConfig.Set("api_key", "abcdef")
+	mockConfig.Set("remote_configuration.dd_url", ts.URL)
+	mockConfig.Set("remote_configuration.public_keys", map[string]interface{}{
+		"key1": base64.StdEncoding.EncodeToString(publicKey),
+	})
+
+	applied := &appliedConfig{}
+	c, err := NewClient("myhost", func(conf *Configuration) {
+		applied.disabledChecks = conf.DisabledChecks
+	})
+	require.NoError(t, err)
+
+	c.applySettings = func(settings map[string]interface{}) map[string]string {
+		applied.settings = settings
+		rejected := map[string]string{}
+		if _, found := settings["api_key"]; found {
+			rejected["api_key"] = "this setting can't be changed remotely"
+		}
+		return rejected
+	}
+	return c, service, applied, ts
+}
+
+func newRunPath(t *testing.T) string {
+	runPath, err := ioutil.TempDir("", "run")
+	require.NoError(t, err)
+	return runPath
+}
+
+func TestNewClientWithoutKeys(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("remote_configuration.public_keys", map[string]interface{}{})
+	_, err := NewClient("myhost", nil)
+	assert.Error(t, err)
+
+	mockConfig.Set("remote_configuration.public_keys", map[string]interface{}{"key1": "not a key"})
+	_, err = NewClient("myhost", nil)
+	assert.Error(t, err)
+}
+
+func TestPollAppliesSignedConfiguration(t *testing.T) {
+	runPath := newRunPath(t)
+	defer os.RemoveAll(runPath)
+	c, service, applied, ts := newTestClient(t, runPath)
+	defer ts.Close()
+
+	// nothing is served yet
+	c.poll()
+	state := c.GetState()
+	assert.Empty(t, state.LastError)
+	assert.Equal(t, int64(0), state.Version)
+	assert.Equal(t, []string{"abcdef"}, service.apiKeys)
+
+	service.serve(Configuration{
+		Version:        2,
+		Settings:       map[string]interface{}{"log_level": "debug", "api_key": "123"},
+		DisabledChecks: []string{"disk", "cpu"},
+	})
+	c.poll()
+	state = c.GetState()
+	assert.Empty(t, state.LastError)
+	assert.Equal(t, int64(2), state.Version)
+	assert.Equal(t, []string{"log_level"}, state.AppliedSettings)
+	assert.Contains(t, state.RejectedSettings, "api_key")
+	assert.Equal(t, []string{"cpu", "disk"}, state.DisabledChecks)
+	assert.Equal(t, "debug", applied.settings["log_level"])
+	assert.Equal(t, []string{"disk", "cpu"}, applied.disabledChecks)
+}
+
+func TestPollRejectsInvalidConfiguration(t *testing.T) {
+	runPath := newRunPath(t)
+	defer os.RemoveAll(runPath)
+	c, service, applied, ts := newTestClient(t, runPath)
+	defer ts.Close()
+
+	// unknown key
+	service.serve(Configuration{Version: 1, Settings: map[string]interface{}{"log_level": "debug"}})
+	service.served.KeyID = "key2"
+	c.poll()
+	assert.Contains(t, c.GetState().LastError, "unknown key")
+	assert.Nil(t, applied.settings)
+
+	// tampered payload
+	service.serve(Configuration{Version: 1, Settings: map[string]interface{}{"log_level": "debug"}})
+	service.served.Payload = []byte(`{"version":1,"settings":{"log_level":"trace"}}`)
+	c.poll()
+	assert.Contains(t, c.GetState().LastError, "signature")
+	assert.Nil(t, applied.settings)
+	assert.Equal(t, int64(0), c.GetState().Version)
+}
+
+func TestPollRejectsRollback(t *testing.T) {
+	runPath := newRunPath(t)
+	defer os.RemoveAll(runPath)
+	c, service, applied, ts := newTestClient(t, runPath)
+	defer ts.Close()
+
+	service.serve(Configuration{Version: 3, Settings: map[string]interface{}{"log_level": "debug"}})
+	c.poll()
+	require.Equal(t, int64(3), c.GetState().Version)
+
+	service.serve(Configuration{Version: 2, Settings: map[string]interface{}{"log_level": "trace"}})
+	c.poll()
+	assert.Contains(t, c.GetState().LastError, "older")
+	assert.Equal(t, int64(3), c.GetState().Version)
+	assert.Equal(t, "debug", applied.settings["log_level"])
+
+	// the version applied isn't applied again
+	applied.settings = nil
+	service.serve(Configuration{Version: 3, Settings: map[string]interface{}{"log_level": "debug"}})
+	c.poll()
+	assert.Empty(t, c.GetState().LastError)
+	assert.Nil(t, applied.settings)
+}
+
+func TestPollRejectsRollbackAfterRestart(t *testing.T) {
+	runPath := newRunPath(t)
+	defer os.RemoveAll(runPath)
+	c, service, _, ts := newTestClient(t, runPath)
+	defer ts.Close()
+
+	service.serve(Configuration{Version: 3, Settings: map[string]interface{}{"log_level": "debug"}})
+	c.poll()
+	require.Equal(t, int64(3), c.GetState().Version)
+
+	// the version applied before the restart is applied again, but not the older ones
+	c, restartedService, applied, restartedTS := newTestClient(t, runPath)
+	defer restartedTS.Close()
+	restartedService.serve(Configuration{Version: 2, Settings: map[string]interface{}{"log_level": "trace"}})
+	c.poll()
+	assert.Contains(t, c.GetState().LastError, "older")
+	assert.Nil(t, applied.settings)
+
+	restartedService.serve(Configuration{Version: 3, Settings: map[string]interface{}{"log_level": "debug"}})
+	c.poll()
+	assert.Empty(t, c.GetState().LastError)
+	assert.Equal(t, int64(3), c.GetState().Version)
+	assert.Equal(t, "debug", applied.settings["log_level"])
+}
@@ -80,9 +80,14 @@ type Secret struct {
 var runCommand = execCommand
 
 // fetchSecret receives a list of secrets name to fetch, exec a custom
-// executable to fetch the actual secrets and returns them. Origin should be
-// the name of the configuration where the secret was referenced.
+// executable, or calls the built-in provider, to fetch the actual secrets and
+// returns them. Origin should be the name of the configuration where the
+// secret was referenced.
 func fetchSecret(secretsHandle []string, origin string) (map[string]string, error) {
+	if provider != nil {
+		return fetchFromProvider(secretsHandle, origin)
+	}
+
 	payload := map[string]interface{}{
 		"version": PayloadVersion,
 		"secrets": secretsHandle,
@@ -10,6 +10,7 @@ import (
 	"io"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // SecretInfo export troubleshooting information about the decrypted secrets
@@ -20,10 +21,50 @@ type SecretInfo struct {
 	UnixOwner      string
 	UnixGroup      string
 	SecretsHandles map[string][]string
+	// Provider is the built-in secret provider used instead of the executable, if any
+	Provider       string
+	ProviderHealth *ProviderHealth
 }
 
 // Print output a SecretInfo to a io.Writer
 func (si *SecretInfo) Print(w io.Writer) {
+	if si.Provider != "" {
+		si.printProvider(w)
+	} else {
+		si.printRights(w)
+	}
+
+	fmt.Fprintf(w, "\n=== Secrets stats ===\n")
+	fmt.Fprintf(w, "Number of secrets decrypted: %d\n", len(si.SecretsHandles))
+	fmt.Fprintf(w, "Secrets handle decrypted:\n")
+	for handle, origins := range si.SecretsHandles {
+		fmt.Fprintf(w, "- %s: from %s\n", handle, strings.Join(origins, ", "))
+	}
+}
+
+func (si *SecretInfo) printProvider(w io.Writer) {
+	fmt.Fprintf(w, "=== Checking secret provider ===\n")
+	fmt.Fprintf(w, "Provider: %s\n", si.Provider)
+	if si.ProviderHealth == nil {
+		return
+	}
+	health := si.ProviderHealth
+	if health.Healthy {
+		fmt.Fprintf(w, "Health: OK\n")
+	} else {
+		fmt.Fprintf(w, "Health: Error\n")
+	}
+	fmt.Fprintf(w, "Fetches: %d (%d errors)\n", health.Fetches, health.Errors)
+	fmt.Fprintf(w, "Cached secrets: %d\n", health.CachedSecrets)
+	if !health.LastSuccess.IsZero() {
+		fmt.Fprintf(w, "Last success: %s\n", health.LastSuccess.Format(time.RFC3339))
+	}
+	if health.LastError != "" {
+		fmt.Fprintf(w, "Last error: %s, at %s\n", health.LastError, health.LastErrorTime.Format(time.RFC3339))
+	}
+}
+
+func (si *SecretInfo) printRights(w io.Writer) {
 	fmt.Fprintf(w, "=== Checking executable rights ===\n")
 	fmt.Fprintf(w, "Executable path: %s\n", si.ExecutablePath)
 
@@ -36,11 +77,4 @@ func (si *SecretInfo) Print(w io.Writer) {
 		fmt.Fprintf(w, "Owner username: %s\n", si.UnixOwner)
 		fmt.Fprintf(w, "Group name: %s\n", si.UnixGroup)
 	}
-
-	fmt.Fprintf(w, "\n=== Secrets stats ===\n")
-	fmt.Fprintf(w, "Number of secrets decrypted: %d\n", len(si.SecretsHandles))
-	fmt.Fprintf(w, "Secrets handle decrypted:\n")
-	for handle, origins := range si.SecretsHandles {
-		fmt.Fprintf(w, "- %s: from %s\n", handle, strings.Join(origins, ", "))
-	}
 }
@@ -19,11 +19,31 @@ var SecretBackendOutputMaxSize = 1024 * 1024
 // Init placeholder when compiled without the 'secrets' build tag
 func Init(command string, arguments []string, timeout int, maxSize int) {}
 
+// InitProvider placeholder when compiled without the 'secrets' build tag
+func InitProvider(cfg ProviderConfig) error {
+	if cfg.Type != "" {
+		return fmt.Errorf("Secret feature is not available in this version of the agent")
+	}
+	return nil
+}
+
 // Decrypt encrypted secrets are not available on windows
 func Decrypt(data []byte, origin string) ([]byte, error) {
 	return data, nil
 }
 
+// Refresh placeholder when compiled without the 'secrets' build tag
+func Refresh() (map[string]string, error) {
+	return nil, nil
+}
+
+// RefreshHandler is called after a refresh changed some secrets, with the handles of the
+// secrets that changed, and their new values by their previous value.
+type RefreshHandler func(handles []string, changed map[string]string)
+
+// RegisterRefreshHandler placeholder when compiled without the 'secrets' build tag
+func RegisterRefreshHandler(handler RefreshHandler) {}
+
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
 	return nil, fmt.Errorf("Secret feature is not available in this version of the agent")
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// secretProvider fetches the secrets from a secrets backend natively, instead of running
+// the secret_backend_command.
+type secretProvider interface {
+	// fetch returns the value of the secret of the handle, and how long it's valid,
+	// 0 if the provider doesn't tell.
+	fetch(ctx context.Context, handle string) (string, time.Duration, error)
+}
+
+// provider is the built-in secret provider, nil when the secret_backend_command is used
+var provider *cachingProvider
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// cachingProvider caches the secrets of a provider until two thirds of their lease, or the cache
+// TTL, elapsed, and tracks the health of the provider.
+type cachingProvider struct {
+	name     string
+	provider secretProvider
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	m       sync.Mutex
+	cache   map[string]cachedSecret
+	health  ProviderHealth
+	timeNow func() time.Time
+}
+
+// InitProvider initializes the built-in secret provider used instead of the secret_backend_command.
+func InitProvider(cfg ProviderConfig) error {
+	provider = nil
+	if cfg.Type == "" {
+		return nil
+	}
+
+	var p secretProvider
+	var err error
+	switch cfg.Type {
+	case VaultProvider:
+		p, err = newVaultProvider(cfg)
+	case AWSSecretsManagerProvider:
+		p, err = newAWSSecretsManagerProvider(cfg)
+	case GCPSecretManagerProvider:
+		p, err = newGCPSecretManagerProvider(cfg)
+	default:
+		err = fmt.Errorf("unknown secret_backend_type '%s', it should be one of %s, %s or %s",
+			cfg.Type, VaultProvider, AWSSecretsManagerProvider, GCPSecretManagerProvider)
+	}
+	if err != nil {
+		return err
+	}
+	provider = newCachingProvider(cfg.Type, p, cfg.CacheTTL, cfg.Timeout)
+	return nil
+}
+
+func newCachingProvider(name string, p secretProvider, cacheTTL time.Duration, timeout time.Duration) *cachingProvider {
+	return &cachingProvider{
+		name:     name,
+		provider: p,
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+		cache:    make(map[string]cachedSecret),
+		timeNow:  time.Now,
+	}
+}
+
+// get returns the secret of the handle from the cache, or from the provider once it expired.
+func (p *cachingProvider) get(handle string) (string, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	now := p.timeNow()
+	if secret, found := p.cache[handle]; found && now.Before(secret.expiresAt) {
+		return secret.value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	value, ttl, err := p.provider.fetch(ctx, handle)
+	p.health.Fetches++
+	if err != nil {
+		p.health.Healthy = false
+		p.health.Errors++
+		p.health.LastError = err.Error()
+		p.health.LastErrorTime = now
+		return "", err
+	}
+	p.health.Healthy = true
+	p.health.LastSuccess = now
+
+	// renew the secret at two thirds of its lease, so it's never used once it expired
+	if ttl = ttl * 2 / 3; ttl <= 0 || ttl > p.cacheTTL {
+		ttl = p.cacheTTL
+	}
+	p.cache[handle] = cachedSecret{value: value, expiresAt: now.Add(ttl)}
+	return value, nil
+}
+
+func (p *cachingProvider) getHealth() ProviderHealth {
+	p.m.Lock()
+	defer p.m.Unlock()
+	health := p.health
+	health.CachedSecrets = len(p.cache)
+	return health
+}
+
+// fetchFromProvider fetches the secrets of the handles from the built-in secret provider,
+// like fetchSecret does from the secret_backend_command.
+func fetchFromProvider(secretsHandle []string, origin string) (map[string]string, error) {
+	res := map[string]string{}
+	for _, handle := range secretsHandle {
+		value, err := provider.get(handle)
+		if err != nil {
+			return nil, fmt.Errorf("an error occurred while fetching '%s' from %s: %s", handle, provider.name, err)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("secret '%s' fetched from %s is empty", handle, provider.name)
+		}
+		log.Debugf("Secret '%s' was fetched from %s", handle, provider.name)
+
+		// add it to the cache
+		secretCache[handle] = value
+		// keep track of place where a handle was found
+		secretOrigin[handle] = common.NewStringSet(origin)
+		res[handle] = value
+	}
+	return res, nil
+}
+
+// splitHandle splits the handles of the form "<secret>#<key>", referencing a key of a
+// secret holding a JSON object. The key is empty when the whole secret is referenced.
+func splitHandle(handle string) (string, string) {
+	if i := strings.LastIndex(handle, "#"); i >= 0 {
+		return handle[:i], handle[i+1:]
+	}
+	return handle, ""
+}
+
+// secretKey returns the value of the key of a secret holding a JSON object
+func secretKey(data map[string]interface{}, key string) (string, error) {
+	value, found := data[key]
+	if !found {
+		return "", fmt.Errorf("no key '%s' in the secret", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	default:
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	}
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets,ec2
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// awsSecretsManagerProvider reads the secrets from AWS Secrets Manager, with the credentials
+// of the default AWS credentials chain. The handles are of the form "<secret id>", or
+// "<secret id>#<key>" for the secrets holding a JSON object.
+type awsSecretsManagerProvider struct {
+	endpoint string
+	region   string
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+func newAWSSecretsManagerProvider(cfg ProviderConfig) (*awsSecretsManagerProvider, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get an aws session: %s", err)
+	}
+
+	region := cfg.AWSRegion
+	if region == "" {
+		region = aws.StringValue(sess.Config.Region)
+	}
+	if region == "" {
+		if region, err = ec2metadata.New(sess).Region(); err != nil {
+			return nil, fmt.Errorf("secret_backend_aws.region is not set, and the region of the instance is unknown: %s", err)
+		}
+	}
+
+	return &awsSecretsManagerProvider{
+		endpoint: fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region),
+		region:   region,
+		signer:   v4.NewSigner(sess.Config.Credentials),
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (p *awsSecretsManagerProvider) fetch(ctx context.Context, handle string) (string, time.Duration, error) {
+	secretID, key := splitHandle(handle)
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequest("POST", p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if _, err := p.signer.Sign(req, bytes.NewReader(payload), "secretsmanager", p.region, time.Now()); err != nil {
+		return "", 0, fmt.Errorf("could not sign the request: %v", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode >= 300 {
+		return "", 0, fmt.Errorf("AWS Secrets Manager answered %s: %s", resp.Status, body)
+	}
+
+	var secret struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", 0, fmt.Errorf("could not parse the secret: %v", err)
+	}
+	if key == "" {
+		return secret.SecretString, 0, nil
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(secret.SecretString), &data); err != nil {
+		return "", 0, fmt.Errorf("the secret doesn't hold a JSON object: %v", err)
+	}
+	value, err := secretKey(data, key)
+	return value, 0, err
+}
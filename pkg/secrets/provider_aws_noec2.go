@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets,!ec2
+
+package secrets
+
+import "errors"
+
+func newAWSSecretsManagerProvider(cfg ProviderConfig) (secretProvider, error) {
+	return nil, errors.New("AWS Secrets Manager is not supported: the agent was built without the ec2 tag")
+}
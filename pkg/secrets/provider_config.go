@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package secrets
+
+import "time"
+
+// The built-in secret providers, used instead of the secret_backend_command
+const (
+	VaultProvider             = "vault"
+	AWSSecretsManagerProvider = "aws_secrets_manager"
+	GCPSecretManagerProvider  = "gcp_secret_manager"
+)
+
+// ProviderConfig configures the built-in secret provider
+type ProviderConfig struct {
+	// Type is one of the built-in secret providers, no provider is used when it's empty
+	Type string
+	// CacheTTL is how long a secret is used before being fetched again, unless its lease is shorter
+	CacheTTL time.Duration
+	// Timeout of the requests to the provider
+	Timeout time.Duration
+
+	// VaultAddress is the URL of the Vault server
+	VaultAddress string
+	// VaultAuthMount is the mount path of the Kubernetes auth method
+	VaultAuthMount string
+	// VaultRole is the role to log in with, the VAULT_TOKEN environment variable is used when it's empty
+	VaultRole string
+	// VaultTokenPath is the path of the Kubernetes service account token to log in with
+	VaultTokenPath string
+
+	// AWSRegion is the region of the secrets, the region of the instance is used when it's empty
+	AWSRegion string
+
+	// GCPProject is the project of the secrets, the project of the instance is used when it's empty
+	GCPProject string
+}
+
+// ProviderHealth reports the health of the built-in secret provider
+type ProviderHealth struct {
+	Healthy       bool
+	Fetches       int
+	Errors        int
+	CachedSecrets int
+	LastSuccess   time.Time
+	LastError     string
+	LastErrorTime time.Time
+}
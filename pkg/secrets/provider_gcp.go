@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcpMetadataURL      = "http://metadata.google.internal/computeMetadata/v1"
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1"
+)
+
+// gcpSecretManagerProvider reads the secrets from GCP Secret Manager, with the service
+// account of the instance. The handles are of the form "<secret>", "<secret>#<version>",
+// or the full resource name "projects/<project>/secrets/<secret>/versions/<version>";
+// the latest version is used when none is set.
+type gcpSecretManagerProvider struct {
+	project          string
+	client           *http.Client
+	metadataURL      string
+	secretManagerURL string
+
+	m              sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+	timeNow        func() time.Time
+}
+
+func newGCPSecretManagerProvider(cfg ProviderConfig) (*gcpSecretManagerProvider, error) {
+	return &gcpSecretManagerProvider{
+		project:          cfg.GCPProject,
+		client:           &http.Client{Timeout: cfg.Timeout},
+		metadataURL:      gcpMetadataURL,
+		secretManagerURL: gcpSecretManagerURL,
+		timeNow:          time.Now,
+	}, nil
+}
+
+func (p *gcpSecretManagerProvider) fetch(ctx context.Context, handle string) (string, time.Duration, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	name := handle
+	if !strings.HasPrefix(handle, "projects/") {
+		if p.project == "" {
+			project, err := p.metadata(ctx, "/project/project-id")
+			if err != nil {
+				return "", 0, fmt.Errorf("could not get the project of the instance: %v", err)
+			}
+			p.project = project
+		}
+		secret, version := splitHandle(handle)
+		if version == "" {
+			version = "latest"
+		}
+		name = fmt.Sprintf("projects/%s/secrets/%s/versions/%s", p.project, secret, version)
+	}
+
+	if err := p.refreshToken(ctx); err != nil {
+		return "", 0, fmt.Errorf("could not get an access token: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s:access", p.secretManagerURL, name), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	body, err := p.do(req)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return "", 0, fmt.Errorf("could not parse the secret version: %v", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not decode the secret: %v", err)
+	}
+	return string(value), 0, nil
+}
+
+// refreshToken gets a new access token of the service account of the instance when it
+// expires soon. It must be called with the lock held.
+func (p *gcpSecretManagerProvider) refreshToken(ctx context.Context) error {
+	now := p.timeNow()
+	if p.token != "" && now.Before(p.tokenExpiresAt) {
+		return nil
+	}
+	raw, err := p.metadata(ctx, "/instance/service-accounts/default/token")
+	if err != nil {
+		return err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(raw), &token); err != nil {
+		return err
+	}
+	p.token = token.AccessToken
+	// renewed a minute before it expires
+	p.tokenExpiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return nil
+}
+
+func (p *gcpSecretManagerProvider) metadata(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequest("GET", p.metadataURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := p.do(req)
+	return string(body), err
+}
+
+func (p *gcpSecretManagerProvider) do(req *http.Request) ([]byte, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s answered %s: %s", req.URL.Host, resp.Status, body)
+	}
+	return body, nil
+}
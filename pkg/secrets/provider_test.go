@@ -0,0 +1,200 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	secrets map[string]string
+	ttl     time.Duration
+	fetches int
+}
+
+func (p *fakeProvider) fetch(ctx context.Context, handle string) (string, time.Duration, error) {
+	p.fetches++
+	value, found := p.secrets[handle]
+	if !found {
+		return "", 0, fmt.Errorf("unknown secret")
+	}
+	return value, p.ttl, nil
+}
+
+func TestCachingProvider(t *testing.T) {
+	fake := &fakeProvider{secrets: map[string]string{"pass1": "password1"}}
+	p := newCachingProvider("fake", fake, time.Minute, time.Second)
+	now := time.Now()
+	p.timeNow = func() time.Time { return now }
+
+	value, err := p.get("pass1")
+	require.NoError(t, err)
+	assert.Equal(t, "password1", value)
+
+	// cached until the cache TTL expires
+	fake.secrets["pass1"] = "password2"
+	value, _ = p.get("pass1")
+	assert.Equal(t, "password1", value)
+	assert.Equal(t, 1, fake.fetches)
+	now = now.Add(time.Minute)
+	value, _ = p.get("pass1")
+	assert.Equal(t, "password2", value)
+
+	// the lease is shorter than the cache TTL, the secret is renewed before it expires
+	fake.ttl = 3 * time.Second
+	now = now.Add(time.Minute)
+	p.get("pass1")
+	now = now.Add(time.Second)
+	p.get("pass1")
+	assert.Equal(t, 3, fake.fetches)
+	now = now.Add(time.Second)
+	p.get("pass1")
+	assert.Equal(t, 4, fake.fetches)
+
+	_, err = p.get("pass2")
+	assert.Error(t, err)
+	health := p.getHealth()
+	assert.False(t, health.Healthy)
+	assert.Equal(t, 5, health.Fetches)
+	assert.Equal(t, 1, health.Errors)
+	assert.Equal(t, 1, health.CachedSecrets)
+	assert.Equal(t, "unknown secret", health.LastError)
+}
+
+func TestDecryptWithProvider(t *testing.T) {
+	defer func() {
+		provider = nil
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+	}()
+	provider = newCachingProvider("fake", &fakeProvider{secrets: map[string]string{"pass1": "password1", "pass2": "password2"}}, time.Minute, time.Second)
+
+	newConf, err := Decrypt(testConf, "test")
+	require.NoError(t, err)
+	assert.Contains(t, string(newConf), "password: password1")
+	assert.Contains(t, string(newConf), "password: password2")
+
+	info, err := GetDebugInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "fake", info.Provider)
+	assert.True(t, info.ProviderHealth.Healthy)
+	assert.Equal(t, []string{"test"}, info.SecretsHandles["pass1"])
+}
+
+func TestInitProvider(t *testing.T) {
+	defer func() { provider = nil }()
+
+	assert.NoError(t, InitProvider(ProviderConfig{}))
+	assert.Nil(t, provider)
+	assert.Error(t, InitProvider(ProviderConfig{Type: "unknown"}))
+	assert.Error(t, InitProvider(ProviderConfig{Type: VaultProvider}))
+	assert.NoError(t, InitProvider(ProviderConfig{Type: GCPSecretManagerProvider}))
+	assert.NotNil(t, provider)
+}
+
+func TestVaultProvider(t *testing.T) {
+	tokenFile, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("service-account-token\n")
+	tokenFile.Close()
+
+	var logins, renewals int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, map[string]string{"role": "datadog", "jwt": "service-account-token"}, body)
+			logins++
+			w.Write([]byte(`{"auth": {"client_token": "token-1", "lease_duration": 3600, "renewable": true}}`))
+		case "/v1/auth/token/renew-self":
+			assert.Equal(t, "token-1", r.Header.Get("X-Vault-Token"))
+			renewals++
+			w.Write([]byte(`{"auth": {"client_token": "token-1", "lease_duration": 3600, "renewable": true}}`))
+		case "/v1/secret/data/datadog":
+			assert.Equal(t, "token-1", r.Header.Get("X-Vault-Token"))
+			w.Write([]byte(`{"data": {"data": {"api_key": "secret-api-key"}, "metadata": {"version": 1}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer ts.Close()
+
+	p, err := newVaultProvider(ProviderConfig{
+		VaultAddress:   ts.URL,
+		VaultAuthMount: "kubernetes",
+		VaultRole:      "datadog",
+		VaultTokenPath: tokenFile.Name(),
+		Timeout:        time.Second,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	p.timeNow = func() time.Time { return now }
+
+	value, _, err := p.fetch(context.Background(), "secret/data/datadog#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-api-key", value)
+	_, _, err = p.fetch(context.Background(), "secret/data/datadog#app_key")
+	assert.Error(t, err)
+	_, _, err = p.fetch(context.Background(), "secret/data/other#api_key")
+	assert.Error(t, err)
+	assert.Equal(t, 1, logins)
+
+	// the token is renewed before it expires
+	now = now.Add(time.Hour)
+	_, _, err = p.fetch(context.Background(), "secret/data/datadog#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, 1, logins)
+	assert.Equal(t, 1, renewals)
+}
+
+func TestGCPSecretManagerProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata/project/project-id":
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			w.Write([]byte("my-project"))
+		case "/metadata/instance/service-accounts/default/token":
+			w.Write([]byte(`{"access_token": "access-token", "expires_in": 3600}`))
+		case "/secretmanager/projects/my-project/secrets/api_key/versions/latest:access",
+			"/secretmanager/projects/my-project/secrets/api_key/versions/2:access":
+			assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"payload": {"data": "c2VjcmV0LWFwaS1rZXk="}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	p, err := newGCPSecretManagerProvider(ProviderConfig{Timeout: time.Second})
+	require.NoError(t, err)
+	p.metadataURL = ts.URL + "/metadata"
+	p.secretManagerURL = ts.URL + "/secretmanager"
+
+	for _, handle := range []string{"api_key", "api_key#2", "projects/my-project/secrets/api_key/versions/latest"} {
+		value, _, err := p.fetch(context.Background(), handle)
+		require.NoError(t, err, handle)
+		assert.Equal(t, "secret-api-key", value)
+	}
+	_, _, err = p.fetch(context.Background(), "app_key")
+	assert.Error(t, err)
+}
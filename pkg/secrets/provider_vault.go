@@ -0,0 +1,213 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build secrets
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// vaultProvider reads the secrets from the HashiCorp Vault KV engines, logged in with the
+// Kubernetes auth method. The handles are of the form "<path>#<key>", e.g.
+// "secret/data/datadog#api_key". The token, and the leases of the secrets, are renewed
+// before they expire.
+type vaultProvider struct {
+	address   string
+	authMount string
+	role      string
+	tokenPath string
+	client    *http.Client
+
+	m              sync.Mutex
+	token          string
+	tokenExpiresAt time.Time // zero when the token doesn't expire
+	tokenRenewable bool
+	leases         map[string]vaultLease // leases of the secrets, by path
+	timeNow        func() time.Time
+}
+
+type vaultLease struct {
+	id        string
+	renewable bool
+	data      map[string]interface{}
+}
+
+type vaultResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func newVaultProvider(cfg ProviderConfig) (*vaultProvider, error) {
+	if cfg.VaultAddress == "" {
+		return nil, fmt.Errorf("secret_backend_vault.address is required by the %s secret provider", VaultProvider)
+	}
+	p := &vaultProvider{
+		address:   strings.TrimSuffix(cfg.VaultAddress, "/"),
+		authMount: cfg.VaultAuthMount,
+		role:      cfg.VaultRole,
+		tokenPath: cfg.VaultTokenPath,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		leases:    make(map[string]vaultLease),
+		timeNow:   time.Now,
+	}
+	if p.role == "" {
+		p.token = os.Getenv("VAULT_TOKEN")
+		if p.token == "" {
+			return nil, fmt.Errorf("secret_backend_vault.role, or the VAULT_TOKEN environment variable, is required by the %s secret provider", VaultProvider)
+		}
+	}
+	return p, nil
+}
+
+func (p *vaultProvider) fetch(ctx context.Context, handle string) (string, time.Duration, error) {
+	path, key := splitHandle(handle)
+	if key == "" {
+		return "", 0, fmt.Errorf("the handle should be of the form <path>#<key>")
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if err := p.authenticate(ctx); err != nil {
+		return "", 0, fmt.Errorf("could not log in to Vault: %v", err)
+	}
+
+	var data map[string]interface{}
+	var ttl time.Duration
+	if lease, found := p.leases[path]; found && lease.renewable {
+		resp, err := p.request(ctx, "PUT", "/v1/sys/leases/renew", map[string]string{"lease_id": lease.id})
+		if err == nil {
+			data, ttl = lease.data, time.Duration(resp.LeaseDuration)*time.Second
+		} else {
+			log.Debugf("Could not renew the lease of the secret %s, reading it again: %v", path, err)
+		}
+	}
+	if data == nil {
+		resp, err := p.request(ctx, "GET", "/v1/"+strings.TrimPrefix(path, "/"), nil)
+		if err != nil {
+			return "", 0, err
+		}
+		data = resp.Data
+		// the secrets of the KV version 2 engine are nested with their metadata
+		if nested, ok := data["data"].(map[string]interface{}); ok {
+			if _, versioned := data["metadata"]; versioned {
+				data = nested
+			}
+		}
+		ttl = time.Duration(resp.LeaseDuration) * time.Second
+		if resp.LeaseID != "" {
+			p.leases[path] = vaultLease{id: resp.LeaseID, renewable: resp.Renewable, data: data}
+		}
+	}
+
+	value, err := secretKey(data, key)
+	return value, ttl, err
+}
+
+// authenticate logs in with the Kubernetes auth method, or renews the token, when the token
+// expires soon. It must be called with the lock held.
+func (p *vaultProvider) authenticate(ctx context.Context) error {
+	if p.role == "" {
+		// the token of the environment is used as is
+		return nil
+	}
+	now := p.timeNow()
+	if p.token != "" && (p.tokenExpiresAt.IsZero() || now.Before(p.tokenExpiresAt)) {
+		return nil
+	}
+
+	if p.token != "" && p.tokenRenewable {
+		resp, err := p.request(ctx, "POST", "/v1/auth/token/renew-self", nil)
+		if err == nil && resp.Auth != nil {
+			p.setToken(resp, now)
+			return nil
+		}
+		log.Debugf("Could not renew the Vault token, logging in again: %v", err)
+	}
+
+	jwt, err := ioutil.ReadFile(p.tokenPath)
+	if err != nil {
+		return fmt.Errorf("could not read the service account token: %v", err)
+	}
+	p.token = ""
+	resp, err := p.request(ctx, "POST", "/v1/auth/"+strings.Trim(p.authMount, "/")+"/login", map[string]string{
+		"role": p.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("no token in the login response")
+	}
+	p.setToken(resp, now)
+	return nil
+}
+
+// setToken uses the token of the auth response until two thirds of its lease
+func (p *vaultProvider) setToken(resp *vaultResponse, now time.Time) {
+	p.token = resp.Auth.ClientToken
+	p.tokenRenewable = resp.Auth.Renewable
+	p.tokenExpiresAt = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		p.tokenExpiresAt = now.Add(time.Duration(resp.Auth.LeaseDuration) * time.Second * 2 / 3)
+	}
+}
+
+func (p *vaultProvider) request(ctx context.Context, method string, path string, body interface{}) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, p.address+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	vaultResp := &vaultResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(vaultResp); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("could not parse the response of Vault: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("Vault answered %s: %s", resp.Status, strings.Join(vaultResp.Errors, ", "))
+	}
+	return vaultResp, nil
+}
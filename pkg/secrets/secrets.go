@@ -10,6 +10,7 @@ package secrets
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -18,6 +19,8 @@ import (
 )
 
 var (
+	// guards secretCache and secretOrigin, the secrets can be refreshed while decrypting a configuration
+	secretLock  sync.Mutex
 	secretCache map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
@@ -28,8 +31,15 @@ var (
 
 	// SecretBackendOutputMaxSize defines max size of the JSON output from a secrets reader backend
 	SecretBackendOutputMaxSize = 1024 * 1024
+
+	refreshHandlersLock sync.Mutex
+	refreshHandlers     []RefreshHandler
 )
 
+// RefreshHandler is called after a refresh changed some secrets, with the handles of the
+// secrets that changed, and their new values by their previous value.
+type RefreshHandler func(handles []string, changed map[string]string)
+
 func init() {
 	secretCache = make(map[string]string)
 	secretOrigin = make(map[string]common.StringSet)
@@ -45,6 +55,11 @@ func Init(command string, arguments []string, timeout int, maxSize int) {
 	SecretBackendOutputMaxSize = maxSize
 }
 
+// enabled returns true if the secrets are fetched by the secret_backend_command or by a built-in provider
+func enabled() bool {
+	return secretBackendCommand != "" || provider != nil
+}
+
 type walkerCallback func(string) (string, error)
 
 func walkSlice(data []interface{}, callback walkerCallback) error {
@@ -124,10 +139,13 @@ var secretFetcher = fetchSecret
 // Decrypt replaces all encrypted secrets in data by executing
 // "secret_backend_command" once if all secrets aren't present in the cache.
 func Decrypt(data []byte, origin string) ([]byte, error) {
-	if data == nil || secretBackendCommand == "" {
+	if data == nil || !enabled() {
 		return data, nil
 	}
 
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
 	var config interface{}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
@@ -192,13 +210,96 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 	return finalConfig, nil
 }
 
+// RegisterRefreshHandler registers a handler notified of the secrets changed by the
+// refreshes, whoever triggered them.
+func RegisterRefreshHandler(handler RefreshHandler) {
+	refreshHandlersLock.Lock()
+	defer refreshHandlersLock.Unlock()
+	refreshHandlers = append(refreshHandlers, handler)
+}
+
+// Refresh fetches again all the secrets already decrypted, so that a secret rotated in
+// the backend can be used without restarting the agent. It returns the new values of
+// the secrets that changed, by their previous value, and notifies the refresh handlers.
+func Refresh() (map[string]string, error) {
+	if !enabled() {
+		return nil, nil
+	}
+
+	handles, changed, err := refresh()
+	if err != nil || len(changed) == 0 {
+		return changed, err
+	}
+
+	// the handlers are called without the lock held, they can decrypt configurations again
+	refreshHandlersLock.Lock()
+	handlers := append([]RefreshHandler(nil), refreshHandlers...)
+	refreshHandlersLock.Unlock()
+	for _, handler := range handlers {
+		handler(handles, changed)
+	}
+	return changed, nil
+}
+
+// refresh fetches again the secrets of the cache, and returns the handles of the secrets
+// that changed, and their new values by their previous value.
+func refresh() ([]string, map[string]string, error) {
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
+	if len(secretCache) == 0 {
+		return nil, nil, nil
+	}
+
+	handles := make([]string, 0, len(secretCache))
+	previous := make(map[string]string, len(secretCache))
+	origins := make(map[string]common.StringSet, len(secretOrigin))
+	for handle, secret := range secretCache {
+		handles = append(handles, handle)
+		previous[handle] = secret
+		origins[handle] = secretOrigin[handle]
+	}
+
+	secrets, err := secretFetcher(handles, "refresh")
+	// the fetcher overwrites the places where the handles were found
+	for handle, origin := range origins {
+		secretOrigin[handle] = origin
+	}
+	if err != nil {
+		// the cache is left as it was
+		for handle, secret := range previous {
+			secretCache[handle] = secret
+		}
+		return nil, nil, err
+	}
+
+	var changedHandles []string
+	changed := make(map[string]string)
+	for handle, secret := range secrets {
+		secretCache[handle] = secret
+		if previous[handle] != secret {
+			log.Infof("Secret '%s' changed in the secrets backend", handle)
+			changedHandles = append(changedHandles, handle)
+			changed[previous[handle]] = secret
+		}
+	}
+	return changedHandles, changed, nil
+}
+
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
-	if secretBackendCommand == "" {
-		return nil, fmt.Errorf("No secret_backend_command set: secrets feature is not enabled")
+	if !enabled() {
+		return nil, fmt.Errorf("No secret_backend_command nor secret_backend_type set: secrets feature is not enabled")
+	}
+	info := &SecretInfo{}
+	if provider != nil {
+		health := provider.getHealth()
+		info.Provider = provider.name
+		info.ProviderHealth = &health
+	} else {
+		info.ExecutablePath = secretBackendCommand
+		info.populateRights()
 	}
-	info := &SecretInfo{ExecutablePath: secretBackendCommand}
-	info.populateRights()
 
 	info.SecretsHandles = map[string][]string{}
 	for handle, originNames := range secretOrigin {
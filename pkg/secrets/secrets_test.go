@@ -300,3 +300,51 @@ func TestDebugInfo(t *testing.T) {
 		"pass3": {"test2"},
 	}, handles)
 }
+
+func TestRefresh(t *testing.T) {
+	secretBackendCommand = "some_command"
+	secretCache = map[string]string{"pass1": "password1", "pass2": "password2"}
+	secretOrigin = map[string]common.StringSet{"pass1": common.NewStringSet("test"), "pass2": common.NewStringSet("test")}
+	defer func() {
+		secretBackendCommand = ""
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		secretFetcher = fetchSecret
+		refreshHandlers = nil
+	}()
+
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		sort.Strings(secrets)
+		assert.Equal(t, []string{"pass1", "pass2"}, secrets)
+		return map[string]string{
+			"pass1": "password1",
+			"pass2": "rotated_password2",
+		}, nil
+	}
+	var notifiedHandles []string
+	var notifiedChanges map[string]string
+	RegisterRefreshHandler(func(handles []string, changed map[string]string) {
+		notifiedHandles, notifiedChanges = handles, changed
+	})
+
+	changed, err := Refresh()
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"password2": "rotated_password2"}, changed)
+	assert.Equal(t, "rotated_password2", secretCache["pass2"])
+	assert.Equal(t, common.NewStringSet("test"), secretOrigin["pass2"])
+	assert.Equal(t, []string{"pass2"}, notifiedHandles)
+	assert.Equal(t, changed, notifiedChanges)
+
+	// the handlers are only notified of the changes
+	notifiedHandles = nil
+	_, err = Refresh()
+	require.Nil(t, err)
+	assert.Nil(t, notifiedHandles)
+
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		return nil, fmt.Errorf("some error")
+	}
+	_, err = Refresh()
+	require.NotNil(t, err)
+	assert.Equal(t, "rotated_password2", secretCache["pass2"])
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package serializer
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The types of the payloads in the compression telemetry
+const (
+	seriesPayloadType        = "series"
+	eventsPayloadType        = "events"
+	serviceChecksPayloadType = "service_checks"
+	sketchesPayloadType      = "sketches"
+	metadataPayloadType      = "metadata"
+)
+
+var (
+	expvarsCompressionRatios = expvar.Map{}
+
+	tlmCompressionBytesIn = telemetry.NewCounter("serializer", "compression_bytes_in",
+		[]string{"payload_type"}, "Count of bytes of the payloads before their compression")
+	tlmCompressionBytesOut = telemetry.NewCounter("serializer", "compression_bytes_out",
+		[]string{"payload_type"}, "Count of bytes of the payloads after their compression")
+	tlmCompressionRatio = telemetry.NewGauge("serializer", "compression_ratio",
+		[]string{"payload_type"}, "Compression ratio of the last payload")
+	tlmCompressionLevel = telemetry.NewGauge("serializer", "compression_level",
+		nil, "Compression level of the payloads")
+)
+
+func init() {
+	expvarsCompressionRatios.Init()
+	expvars.Set("CompressionRatios", &expvarsCompressionRatios)
+}
+
+// payloadCompressor compresses the payloads with the configured content encoding, at a
+// fixed level or at a level adapting to the CPU usage of the Agent.
+type payloadCompressor struct {
+	contentEncoding string
+	level           int
+	adaptiveLevel   *compression.AdaptiveLevel
+
+	// the extra headers of the payloads compressed with the content encoding
+	jsonExtraHeaders     http.Header
+	protobufExtraHeaders http.Header
+}
+
+// newPayloadCompressor returns the payloadCompressor of the configuration, the payloads
+// are compressed with the content encoding the Agent is built with by default.
+func newPayloadCompressor() *payloadCompressor {
+	contentEncoding := compression.ContentEncoding
+	switch kind := config.Datadog.GetString("serializer_compressor_kind"); kind {
+	case "":
+	case "zlib":
+		contentEncoding = compression.ZlibContentEncoding
+	case "zstd":
+		if compression.ZstdAvailable {
+			contentEncoding = compression.ZstdContentEncoding
+		} else {
+			log.Warnf("The agent is built without zstd support, the payloads are compressed with '%s'", contentEncoding)
+		}
+	default:
+		log.Warnf("Unknown serializer_compressor_kind '%s', the payloads are compressed with '%s'", kind, contentEncoding)
+	}
+
+	min, level, max := compression.Levels(contentEncoding)
+	if configuredLevel := config.Datadog.GetInt("serializer_compression_level"); configuredLevel != 0 {
+		var valid bool
+		if level, valid = compression.ClampLevel(contentEncoding, configuredLevel); !valid {
+			log.Warnf("serializer_compression_level %d is out of the levels of '%s' (%d to %d), %d will be used", configuredLevel, contentEncoding, min, max, level)
+		}
+	}
+	c := &payloadCompressor{
+		contentEncoding:      contentEncoding,
+		level:                level,
+		jsonExtraHeaders:     withContentEncoding(jsonExtraHeaders, contentEncoding),
+		protobufExtraHeaders: withContentEncoding(protobufExtraHeaders, contentEncoding),
+	}
+	if contentEncoding != "" && config.Datadog.GetBool("serializer_adaptive_compression.enabled") {
+		c.adaptiveLevel = compression.NewAdaptiveLevel(min, max, level, config.Datadog.GetFloat64("serializer_adaptive_compression.max_cpu_usage"))
+	}
+	return c
+}
+
+// withContentEncoding returns a copy of the headers with the content encoding.
+func withContentEncoding(headers http.Header, contentEncoding string) http.Header {
+	withEncoding := make(http.Header, len(headers)+1)
+	for k, v := range headers {
+		withEncoding[k] = v
+	}
+	if contentEncoding != "" {
+		withEncoding.Set("Content-Encoding", contentEncoding)
+	}
+	return withEncoding
+}
+
+// currentLevel returns the level the payloads are compressed at.
+func (c *payloadCompressor) currentLevel() int {
+	level := c.level
+	if c.adaptiveLevel != nil {
+		level = c.adaptiveLevel.Level()
+	}
+	tlmCompressionLevel.Set(float64(level))
+	return level
+}
+
+// forPayload returns the compressor of the payloads of a type.
+func (c *payloadCompressor) forPayload(payloadType string) *typedCompressor {
+	return &typedCompressor{
+		payloadCompressor: c,
+		payloadType:       payloadType,
+	}
+}
+
+// typedCompressor compresses the payloads of a type and reports their compression ratio.
+type typedCompressor struct {
+	*payloadCompressor
+	payloadType string
+}
+
+// Compress compresses the payload at the current level.
+func (c *typedCompressor) Compress(payload []byte) ([]byte, error) {
+	compressed, err := compression.CompressLevel(c.contentEncoding, nil, payload, c.currentLevel())
+	if err != nil {
+		return nil, err
+	}
+	c.Observe(len(payload), len(compressed))
+	return compressed, nil
+}
+
+// Level returns the current compression level, for the payloads compressed while they are streamed.
+func (c *typedCompressor) Level() int {
+	return c.currentLevel()
+}
+
+// Observe reports the compression ratio of a payload.
+func (c *typedCompressor) Observe(uncompressedSize, compressedSize int) {
+	tlmCompressionBytesIn.Add(float64(uncompressedSize), c.payloadType)
+	tlmCompressionBytesOut.Add(float64(compressedSize), c.payloadType)
+	if compressedSize == 0 {
+		return
+	}
+	ratio := float64(uncompressedSize) / float64(compressedSize)
+	tlmCompressionRatio.Set(ratio, c.payloadType)
+	ratioVar := &expvar.Float{}
+	ratioVar.Set(ratio)
+	expvarsCompressionRatios.Set(c.payloadType, ratioVar)
+}
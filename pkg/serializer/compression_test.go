@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package serializer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"expvar"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+func TestPayloadCompressorZlib(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", "zlib")
+	config.Datadog.Set("serializer_compression_level", 9)
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+	defer config.Datadog.Set("serializer_compression_level", nil)
+
+	c := newPayloadCompressor()
+	assert.Equal(t, compression.ZlibContentEncoding, c.contentEncoding)
+	assert.Nil(t, c.adaptiveLevel)
+
+	typed := c.forPayload(seriesPayloadType)
+	assert.Equal(t, 9, typed.Level())
+
+	payload := bytes.Repeat([]byte("{\"metric\":\"test\"}"), 100)
+	compressed, err := typed.Compress(payload)
+	require.NoError(t, err)
+	r, err := zlib.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decompressed)
+
+	ratio := expvarsCompressionRatios.Get(seriesPayloadType).(*expvar.Float)
+	assert.Equal(t, float64(len(payload))/float64(len(compressed)), ratio.Value())
+}
+
+func TestPayloadCompressorDefaults(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", "unknown")
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+
+	c := newPayloadCompressor()
+	assert.Equal(t, compression.ContentEncoding, c.contentEncoding)
+	_, level, _ := compression.Levels(compression.ContentEncoding)
+	assert.Equal(t, level, c.currentLevel())
+}
+
+func TestPayloadCompressorAdaptive(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", "zlib")
+	config.Datadog.Set("serializer_adaptive_compression.enabled", true)
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+	defer config.Datadog.Set("serializer_adaptive_compression.enabled", nil)
+
+	c := newPayloadCompressor()
+	require.NotNil(t, c.adaptiveLevel)
+	// the level is not adapted before the CPU usage is measured
+	assert.Equal(t, 6, c.currentLevel())
+}
+
+func TestPayloadCompressorClampsTheLevel(t *testing.T) {
+	config.Datadog.Set("serializer_compressor_kind", "zlib")
+	config.Datadog.Set("serializer_compression_level", 12)
+	defer config.Datadog.Set("serializer_compressor_kind", nil)
+	defer config.Datadog.Set("serializer_compression_level", nil)
+
+	c := newPayloadCompressor()
+	assert.Equal(t, 9, c.currentLevel())
+	assert.Equal(t, compression.ZlibContentEncoding, c.jsonExtraHeaders.Get("Content-Encoding"))
+	assert.Equal(t, compression.ZlibContentEncoding, c.protobufExtraHeaders.Get("Content-Encoding"))
+	assert.Empty(t, jsonExtraHeaders.Get("Content-Encoding"))
+}
@@ -0,0 +1,13 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2019-2020 Datadog, Inc.
+
+package jsonstream
+
+// Compression provides the zlib level the payloads are compressed at, and is
+// notified of the size of each payload before and after its compression.
+type Compression interface {
+	Level() int
+	Observe(uncompressedSize, compressedSize int)
+}
@@ -97,7 +97,7 @@ type compressor struct {
 	maxUncompressedSize int
 }
 
-func newCompressor(input, output *bytes.Buffer, header, footer []byte) (*compressor, error) {
+func newCompressor(input, output *bytes.Buffer, header, footer []byte, level int) (*compressor, error) {
 	// the backend accepts payloads up to 3MB compressed / 50MB uncompressed but
 	// prefers small uncompressed payloads of ~4MB
 	maxPayloadSize := config.Datadog.GetInt("serializer_max_payload_size")
@@ -114,7 +114,11 @@ func newCompressor(input, output *bytes.Buffer, header, footer []byte) (*compres
 		maxZippedItemSize:   maxUncompressedSize - compression.CompressBound(len(footer)+len(header)),
 	}
 
-	c.zipper = zlib.NewWriter(c.compressed)
+	zipper, err := zlib.NewWriterLevel(c.compressed, level)
+	if err != nil {
+		return nil, err
+	}
+	c.zipper = zipper
 	n, err := c.zipper.Write(header)
 	c.uncompressedWritten += n
 
@@ -188,7 +192,8 @@ func (c *compressor) addItem(data []byte) error {
 	return nil
 }
 
-func (c *compressor) close() ([]byte, error) {
+// close ends the payload and reports its compression ratio to observer if not nil
+func (c *compressor) close(observer Compression) ([]byte, error) {
 	// Flush remaining uncompressed data
 	if c.input.Len() > 0 {
 		n, err := c.input.WriteTo(c.zipper)
@@ -218,6 +223,9 @@ func (c *compressor) close() ([]byte, error) {
 	tlmBytesIn.Add(float64(c.uncompressedWritten))
 	expvarsBytesOut.Add(int64(c.compressed.Len()))
 	tlmBytesOut.Add(float64(c.compressed.Len()))
+	if observer != nil {
+		observer.Observe(c.uncompressedWritten, c.compressed.Len())
+	}
 
 	return payload, nil
 }
@@ -100,14 +100,14 @@ func payloadToString(payload []byte) string {
 }
 
 func TestCompressorSimple(t *testing.T) {
-	c, err := newCompressor(&bytes.Buffer{}, &bytes.Buffer{}, []byte("{["), []byte("]}"))
+	c, err := newCompressor(&bytes.Buffer{}, &bytes.Buffer{}, []byte("{["), []byte("]}"), zlib.DefaultCompression)
 	require.NoError(t, err)
 
 	for i := 0; i < 5; i++ {
 		c.addItem([]byte("A"))
 	}
 
-	p, err := c.close()
+	p, err := c.close(nil)
 	require.NoError(t, err)
 	require.Equal(t, "{[A,A,A,A,A]}", payloadToString(p))
 }
@@ -9,6 +9,7 @@ package jsonstream
 
 import (
 	"bytes"
+	"compress/zlib"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -27,14 +28,30 @@ var jsonConfig = jsoniter.Config{
 // use multiple PayloadBuilders for different sources.
 type PayloadBuilder struct {
 	inputSizeHint, outputSizeHint int
+	compression                   Compression
 }
 
 // NewPayloadBuilder creates a new PayloadBuilder with default values.
 func NewPayloadBuilder() *PayloadBuilder {
+	return NewPayloadBuilderWithCompression(nil)
+}
+
+// NewPayloadBuilderWithCompression creates a new PayloadBuilder compressing the payloads
+// at the level of the given Compression, the default zlib level being used when it is nil.
+func NewPayloadBuilderWithCompression(compression Compression) *PayloadBuilder {
 	return &PayloadBuilder{
 		inputSizeHint:  4096,
 		outputSizeHint: 4096,
+		compression:    compression,
+	}
+}
+
+// compressionLevel returns the level the next payload is compressed at.
+func (b *PayloadBuilder) compressionLevel() int {
+	if b.compression == nil {
+		return zlib.DefaultCompression
 	}
+	return b.compression.Level()
 }
 
 // OnErrItemTooBigPolicy defines the behavior when OnErrItemTooBig occurs.
@@ -83,7 +100,7 @@ func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 		return nil, err
 	}
 
-	compressor, err := newCompressor(input, output, header.Bytes(), footer.Bytes())
+	compressor, err := newCompressor(input, output, header.Bytes(), footer.Bytes(), b.compressionLevel())
 	if err != nil {
 		return nil, err
 	}
@@ -106,14 +123,14 @@ func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 			expvarsPayloadFulls.Add(1)
 			tlmPayloadFull.Inc()
 			// payload is full, we need to create a new one
-			payload, err := compressor.close()
+			payload, err := compressor.close(b.compression)
 			if err != nil {
 				return payloads, err
 			}
 			payloads = append(payloads, &payload)
 			input.Reset()
 			output.Reset()
-			compressor, err = newCompressor(input, output, header.Bytes(), footer.Bytes())
+			compressor, err = newCompressor(input, output, header.Bytes(), footer.Bytes(), b.compressionLevel())
 			if err != nil {
 				return nil, err
 			}
@@ -139,7 +156,7 @@ func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 	}
 
 	// Close last payload
-	payload, err := compressor.close()
+	payload, err := compressor.close(b.compression)
 	if err != nil {
 		return payloads, err
 	}
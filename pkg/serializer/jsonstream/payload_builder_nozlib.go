@@ -34,6 +34,11 @@ func NewPayloadBuilder() *PayloadBuilder {
 	return nil
 }
 
+// NewPayloadBuilderWithCompression is not implemented when zlib is not available.
+func NewPayloadBuilderWithCompression(Compression) *PayloadBuilder {
+	return nil
+}
+
 // BuildWithOnErrItemTooBigPolicy is not implemented when zlib is not available.
 func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(marshaler.StreamJSONMarshaler, OnErrItemTooBigPolicy) (forwarder.Payloads, error) {
 	return nil, fmt.Errorf("not implemented")
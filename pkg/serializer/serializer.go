@@ -103,7 +103,10 @@ type MetricSerializer interface {
 type Serializer struct {
 	Forwarder forwarder.Forwarder
 
-	seriesPayloadBuilder *jsonstream.PayloadBuilder
+	compressor                  *payloadCompressor
+	seriesPayloadBuilder        *jsonstream.PayloadBuilder
+	eventsPayloadBuilder        *jsonstream.PayloadBuilder
+	serviceChecksPayloadBuilder *jsonstream.PayloadBuilder
 
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
@@ -123,17 +126,24 @@ type Serializer struct {
 
 // NewSerializer returns a new Serializer initialized
 func NewSerializer(forwarder forwarder.Forwarder) *Serializer {
+	compressor := newPayloadCompressor()
+	// the payloads can only be streamed when they are compressed with zlib
+	streamAvailable := jsonstream.Available && compressor.contentEncoding == compression.ZlibContentEncoding
+
 	s := &Serializer{
 		Forwarder:                     forwarder,
-		seriesPayloadBuilder:          jsonstream.NewPayloadBuilder(),
+		compressor:                    compressor,
+		seriesPayloadBuilder:          jsonstream.NewPayloadBuilderWithCompression(compressor.forPayload(seriesPayloadType)),
+		eventsPayloadBuilder:          jsonstream.NewPayloadBuilderWithCompression(compressor.forPayload(eventsPayloadType)),
+		serviceChecksPayloadBuilder:   jsonstream.NewPayloadBuilderWithCompression(compressor.forPayload(serviceChecksPayloadType)),
 		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
 		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
 		enableServiceChecks:           config.Datadog.GetBool("enable_payloads.service_checks"),
 		enableSketches:                config.Datadog.GetBool("enable_payloads.sketches"),
 		enableJSONToV1Intake:          config.Datadog.GetBool("enable_payloads.json_to_v1_intake"),
-		enableJSONStream:              jsonstream.Available && config.Datadog.GetBool("enable_stream_payload_serialization"),
-		enableServiceChecksJSONStream: jsonstream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
-		enableEventsJSONStream:        jsonstream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
+		enableJSONStream:              streamAvailable && config.Datadog.GetBool("enable_stream_payload_serialization"),
+		enableServiceChecksJSONStream: streamAvailable && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
+		enableEventsJSONStream:        streamAvailable && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
 	}
 
 	if !s.enableEvents {
@@ -155,27 +165,31 @@ func NewSerializer(forwarder forwarder.Forwarder) *Serializer {
 	return s
 }
 
-func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool, useV1API bool) (forwarder.Payloads, http.Header, error) {
+func (s Serializer) serializePayload(payload marshaler.Marshaler, payloadType string, compress bool, useV1API bool) (forwarder.Payloads, http.Header, error) {
 	var marshalType split.MarshalType
 	var extraHeaders http.Header
 
 	if useV1API {
 		marshalType = split.MarshalJSON
 		if compress {
-			extraHeaders = jsonExtraHeadersWithCompression
+			extraHeaders = s.compressor.jsonExtraHeaders
 		} else {
 			extraHeaders = jsonExtraHeaders
 		}
 	} else {
 		marshalType = split.Marshal
 		if compress {
-			extraHeaders = protobufExtraHeadersWithCompression
+			extraHeaders = s.compressor.protobufExtraHeaders
 		} else {
 			extraHeaders = protobufExtraHeaders
 		}
 	}
 
-	payloads, err := split.Payloads(payload, compress, marshalType)
+	var compressor split.Compressor
+	if compress {
+		compressor = s.compressor.forPayload(payloadType)
+	}
+	payloads, err := split.Payloads(payload, compressor, marshalType)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not split payload into small enough chunks: %s", err)
@@ -184,9 +198,9 @@ func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool,
 	return payloads, extraHeaders, nil
 }
 
-func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy jsonstream.OnErrItemTooBigPolicy) (forwarder.Payloads, http.Header, error) {
-	payloads, err := s.seriesPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+func (s Serializer) serializeStreamablePayload(builder *jsonstream.PayloadBuilder, payload marshaler.StreamJSONMarshaler, policy jsonstream.OnErrItemTooBigPolicy) (forwarder.Payloads, http.Header, error) {
+	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(payload, policy)
+	return payloads, s.compressor.jsonExtraHeaders, err
 }
 
 // As events are gathered by SourceType, the serialization logic is more complex than for the other serializations.
@@ -201,7 +215,7 @@ func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarsh
 func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 	eventsStreamJSONMarshaler EventsStreamJSONMarshaler, useV1API bool) (forwarder.Payloads, http.Header, error) {
 	marshaler := eventsStreamJSONMarshaler.CreateSingleMarshaler()
-	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, jsonstream.FailOnErrItemTooBig)
+	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(s.eventsPayloadBuilder, marshaler, jsonstream.FailOnErrItemTooBig)
 
 	if err == jsonstream.ErrItemTooBig {
 		expvarsSendEventsErrItemTooBigs.Add(1)
@@ -209,12 +223,12 @@ func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 		// Do not use CreateMarshalersBySourceType when there are too many source types (Performance issue).
 		if marshaler.Len() > maxItemCountForCreateMarshalersBySourceType {
 			expvarsSendEventsErrItemTooBigsFallback.Add(1)
-			eventPayloads, extraHeaders, err = s.serializePayload(eventsStreamJSONMarshaler, true, useV1API)
+			eventPayloads, extraHeaders, err = s.serializePayload(eventsStreamJSONMarshaler, eventsPayloadType, true, useV1API)
 		} else {
 			eventPayloads = nil
 			for _, v := range eventsStreamJSONMarshaler.CreateMarshalersBySourceType() {
 				var eventPayloadsForSourceType forwarder.Payloads
-				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, jsonstream.DropItemOnErrItemTooBig)
+				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(s.eventsPayloadBuilder, v, jsonstream.DropItemOnErrItemTooBig)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -240,7 +254,7 @@ func (s *Serializer) SendEvents(e EventsStreamJSONMarshaler) error {
 	if useV1API && s.enableEventsJSONStream {
 		eventPayloads, extraHeaders, err = s.serializeEventsStreamJSONMarshalerPayload(e, useV1API)
 	} else {
-		eventPayloads, extraHeaders, err = s.serializePayload(e, true, useV1API)
+		eventPayloads, extraHeaders, err = s.serializePayload(e, eventsPayloadType, true, useV1API)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping event payload: %s", err)
@@ -266,9 +280,9 @@ func (s *Serializer) SendServiceChecks(sc marshaler.StreamJSONMarshaler) error {
 	var err error
 
 	if useV1API && s.enableServiceChecksJSONStream {
-		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(sc, jsonstream.DropItemOnErrItemTooBig)
+		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(s.serviceChecksPayloadBuilder, sc, jsonstream.DropItemOnErrItemTooBig)
 	} else {
-		serviceCheckPayloads, extraHeaders, err = s.serializePayload(sc, true, useV1API)
+		serviceCheckPayloads, extraHeaders, err = s.serializePayload(sc, serviceChecksPayloadType, true, useV1API)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping service check payload: %s", err)
@@ -294,9 +308,9 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 	var err error
 
 	if useV1API && s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, jsonstream.DropItemOnErrItemTooBig)
+		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(s.seriesPayloadBuilder, series, jsonstream.DropItemOnErrItemTooBig)
 	} else {
-		seriesPayloads, extraHeaders, err = s.serializePayload(series, true, useV1API)
+		seriesPayloads, extraHeaders, err = s.serializePayload(series, seriesPayloadType, true, useV1API)
 	}
 
 	if err != nil {
@@ -318,7 +332,7 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 
 	compress := true
 	useV1API := false // Sketches only have a v2 endpoint
-	splitSketches, extraHeaders, err := s.serializePayload(sketches, compress, useV1API)
+	splitSketches, extraHeaders, err := s.serializePayload(sketches, sketchesPayloadType, compress, useV1API)
 	if err != nil {
 		return fmt.Errorf("dropping sketch payload: %s", err)
 	}
@@ -328,7 +342,7 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 
 // SendMetadata serializes a metadata payload and sends it to the forwarder
 func (s *Serializer) SendMetadata(m marshaler.Marshaler) error {
-	smallEnough, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, true, split.MarshalJSON)
+	smallEnough, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, s.compressor.forPayload(metadataPayloadType), split.MarshalJSON)
 	if err != nil {
 		return fmt.Errorf("could not determine size of metadata payload: %s", err)
 	}
@@ -339,7 +353,7 @@ func (s *Serializer) SendMetadata(m marshaler.Marshaler) error {
 		return fmt.Errorf("metadata payload was too big to send (%d bytes compressed), metadata payloads cannot be split", len(compressedPayload))
 	}
 
-	if err := s.Forwarder.SubmitV1Intake(forwarder.Payloads{&compressedPayload}, jsonExtraHeadersWithCompression); err != nil {
+	if err := s.Forwarder.SubmitV1Intake(forwarder.Payloads{&compressedPayload}, s.compressor.jsonExtraHeaders); err != nil {
 		return err
 	}
 
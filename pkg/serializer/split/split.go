@@ -7,7 +7,9 @@ package split
 
 import (
 	"expvar"
+	"fmt"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -16,8 +18,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// the backend accepts payloads up to 3MB, but being conservative is okay
-var maxPayloadSize = 2 * 1024 * 1024
+var (
+	// the backend accepts payloads up to 3MB, but being conservative is okay
+	maxPayloadSize = 2 * 1024 * 1024
+	// maxSplitDepth is the number of times a payload is split recursively before
+	// the chunks still too big are dropped
+	maxSplitDepth = 5
+)
 
 // MarshalType is the type of marshaler to use
 type MarshalType int
@@ -44,115 +51,148 @@ var (
 		nil, "Splitter total loops run")
 	tlmSplitterPayloadDrops = telemetry.NewCounter("splitter", "payload_drops",
 		nil, "Splitter payload drops")
+	tlmSplitterDepth = telemetry.NewHistogram("splitter", "split_depth",
+		nil, "Number of times the payloads sent were split", []float64{0, 1, 2, 3, 4, 5})
+	tlmSplitterItemsPerPayload = telemetry.NewHistogram("splitter", "items_per_payload",
+		nil, "Number of items of the payloads sent", []float64{10, 100, 1000, 10000, 100000})
 )
 
+// Compressor compresses the serialized payloads
+type Compressor interface {
+	Compress(payload []byte) ([]byte, error)
+}
+
+type defaultCompressor struct{}
+
+func (defaultCompressor) Compress(payload []byte) ([]byte, error) {
+	return compression.Compress(nil, payload)
+}
+
+// DefaultCompressor compresses the payloads with the default compression of the Agent
+var DefaultCompressor Compressor = defaultCompressor{}
+
 func init() {
 	splitterExpvars.Set("NotTooBig", &splitterNotTooBig)
 	splitterExpvars.Set("TooBig", &splitterTooBig)
 	splitterExpvars.Set("TotalLoops", &splitterTotalLoops)
 	splitterExpvars.Set("PayloadDrops", &splitterPayloadDrops)
-
 }
 
-// CheckSizeAndSerialize Check the size of a payload and marshall it (optionally compress it)
+// CheckSizeAndSerialize Check the size of a payload and marshall it (optionally compress it, when compressor is not nil)
 // The dual role makes sense as you will never serialize without checking the size of the payload
-func CheckSizeAndSerialize(m marshaler.Marshaler, compress bool, mType MarshalType) (bool, []byte, []byte, error) {
-	compressedPayload, payload, err := serializeMarshaller(m, compress, mType)
+func CheckSizeAndSerialize(m marshaler.Marshaler, compressor Compressor, mType MarshalType) (bool, []byte, []byte, error) {
+	compressedPayload, payload, err := serializeMarshaller(m, compressor, mType)
 	if err != nil {
 		return false, nil, nil, err
 	}
 	return checkSize(compressedPayload), compressedPayload, payload, nil
 }
 
-// Payloads serializes a metadata payload and sends it to the forwarder
-func Payloads(m marshaler.Marshaler, compress bool, mType MarshalType) (forwarder.Payloads, error) {
-	marshallers := []marshaler.Marshaler{m}
-	smallEnoughPayloads := forwarder.Payloads{}
-	nottoobig, payload, _, err := CheckSizeAndSerialize(m, compress, mType)
+// Payloads serializes a metadata payload and sends it to the forwarder.
+// The payloads whose compressed size exceeds maxPayloadSize, or which contain more than
+// `serializer_max_items_per_payload` items, are split recursively, up to maxSplitDepth
+// times. Only the chunks that still cannot be split are dropped.
+func Payloads(m marshaler.Marshaler, compressor Compressor, mType MarshalType) (forwarder.Payloads, error) {
+	s := &splitter{
+		compressor: compressor,
+		mType:      mType,
+		maxItems:   config.Datadog.GetInt("serializer_max_items_per_payload"),
+	}
+	if err := s.split(m, 0); err != nil {
+		return s.payloads, err
+	}
+	if s.drops > 0 && len(s.payloads) == 0 {
+		return nil, fmt.Errorf("the payload could not be split into small enough chunks")
+	}
+	return s.payloads, nil
+}
+
+// splitter accumulates the payloads small enough to be sent
+type splitter struct {
+	compressor Compressor
+	mType      MarshalType
+	maxItems   int
+	payloads   forwarder.Payloads
+	drops      int
+}
+
+// split serializes m, and splits it into chunks serialized in turn if it's too big.
+func (s *splitter) split(m marshaler.Marshaler, depth int) error {
+	compressedPayload, payload, err := serializeMarshaller(m, s.compressor, s.mType)
 	if err != nil {
-		return smallEnoughPayloads, err
-	}
-	// If the payload's size is fine, just return it
-	if nottoobig {
-		log.Debug("The payload was not too big, returning the full payload")
-		splitterNotTooBig.Add(1)
-		tlmSplitterNotTooBig.Inc()
-		smallEnoughPayloads = append(smallEnoughPayloads, &payload)
-		return smallEnoughPayloads, nil
-	}
-	splitterTooBig.Add(1)
-	tlmSplitterTooBig.Inc()
-	toobig := !nottoobig
-	loops := 0
-	// Do not attempt to split payloads forever, if a payload cannot be split then abandon the task
-	// the function will return all the payloads that were able to be split
-	for toobig && loops < 3 {
-		splitterTotalLoops.Add(1)
-		tlmSplitterTotalLoops.Inc()
-		// create a temporary slice, the other array will be reused to keep track of the payloads that have yet to be split
-		tempSlice := make([]marshaler.Marshaler, len(marshallers))
-		copy(tempSlice, marshallers)
-		marshallers = []marshaler.Marshaler{}
-		for _, toSplit := range tempSlice {
-			var e error
-			// we have to do this every time to get the proper payload
-			payload, compressedPayload, e := serializeMarshaller(toSplit, compress, mType)
-			if e != nil {
-				return smallEnoughPayloads, e
-			}
-			payloadSize := len(payload)
-			compressedSize := len(compressedPayload)
-			// Attempt to account for the compression when estimating the number of chunks that will be needed
-			// This is the same function used in dd-agent
-			compressionRatio := float64(payloadSize) / float64(compressedSize)
-			numChunks := compressedSize/maxPayloadSize + 1 + int(compressionRatio/2)
-			log.Debugf("split the payload into into %d chunks", numChunks)
-			chunks, err := toSplit.SplitPayload(numChunks)
-			log.Debugf("payload was split into %d chunks", len(chunks))
-			if err != nil {
-				log.Warnf("Some payloads could not be split, dropping them")
-				splitterPayloadDrops.Add(1)
-				tlmSplitterPayloadDrops.Inc()
-				return smallEnoughPayloads, err
-			}
-			// after the payload has been split, loop through the chunks
-			for _, chunk := range chunks {
-				// serialize the payload
-				smallEnough, payload, _, err := CheckSizeAndSerialize(chunk, compress, mType)
-				if err != nil {
-					log.Debugf("Error serializing a chunk: %s", err)
-					continue
-				}
-				if smallEnough {
-					// if the payload is small enough, return it straight away
-					smallEnoughPayloads = append(smallEnoughPayloads, &payload)
-					log.Debugf("chunk was small enough: %v, smallEnoughPayloads are of length: %v", len(payload), len(smallEnoughPayloads))
-				} else {
-					// if it is not, append it to the list of payloads
-					marshallers = append(marshallers, chunk)
-					log.Debugf("chunk was not small enough: %v, marshallers are of length: %v", len(payload), len(marshallers))
-				}
-			}
+		return err
+	}
+	items, hasItems := itemCount(m)
+	tooManyItems := hasItems && s.maxItems > 0 && items > s.maxItems
+
+	if checkSize(compressedPayload) && !tooManyItems {
+		if depth == 0 {
+			log.Debug("The payload was not too big, returning the full payload")
+			splitterNotTooBig.Add(1)
+			tlmSplitterNotTooBig.Inc()
 		}
-		if len(marshallers) == 0 {
-			log.Debug("marshallers was empty, breaking out of the loop")
-			toobig = false
-		} else {
-			log.Debug("marshallers was not empty, running around the loop again")
-			loops++
+		tlmSplitterDepth.Observe(float64(depth))
+		if hasItems {
+			tlmSplitterItemsPerPayload.Observe(float64(items))
 		}
+		s.payloads = append(s.payloads, &compressedPayload)
+		return nil
+	}
+	if depth == 0 {
+		splitterTooBig.Add(1)
+		tlmSplitterTooBig.Inc()
+	}
+
+	// Do not attempt to split payloads forever, if a payload cannot be split then abandon it
+	if depth >= maxSplitDepth {
+		s.drop(fmt.Sprintf("still too big after being split %d times", depth))
+		return nil
+	}
+	splitterTotalLoops.Add(1)
+	tlmSplitterTotalLoops.Inc()
+
+	numChunks := len(compressedPayload)/maxPayloadSize + 1
+	if tooManyItems && (items+s.maxItems-1)/s.maxItems > numChunks {
+		numChunks = (items + s.maxItems - 1) / s.maxItems
+	}
+	log.Debugf("split the payload of %d bytes (%d compressed) into %d chunks", len(payload), len(compressedPayload), numChunks)
+	chunks, err := m.SplitPayload(numChunks)
+	if err != nil {
+		s.drop(err.Error())
+		return nil
 	}
-	if len(marshallers) != 0 {
-		log.Warnf("Some payloads could not be split, dropping them")
-		splitterPayloadDrops.Add(1)
-		tlmSplitterPayloadDrops.Inc()
+	if len(chunks) < 2 {
+		s.drop("it cannot be split any further")
+		return nil
 	}
+	log.Debugf("payload was split into %d chunks", len(chunks))
 
-	return smallEnoughPayloads, nil
+	for _, chunk := range chunks {
+		if err := s.split(chunk, depth+1); err != nil {
+			log.Debugf("Error serializing a chunk: %s", err)
+		}
+	}
+	return nil
+}
+
+// drop abandons a payload that could not be split into small enough chunks.
+func (s *splitter) drop(reason string) {
+	log.Warnf("Some payloads could not be split, dropping them: %s", reason)
+	s.drops++
+	splitterPayloadDrops.Add(1)
+	tlmSplitterPayloadDrops.Inc()
+}
+
+// itemCount returns the number of items of the marshaler, when it is known.
+func itemCount(m marshaler.Marshaler) (int, bool) {
+	if l, ok := m.(interface{ Len() int }); ok {
+		return l.Len(), true
+	}
+	return 0, false
 }
 
 // serializeMarshaller serializes the marshaller and returns both the compressed and uncompressed payloads
-func serializeMarshaller(m marshaler.Marshaler, compress bool, mType MarshalType) ([]byte, []byte, error) {
+func serializeMarshaller(m marshaler.Marshaler, compressor Compressor, mType MarshalType) ([]byte, []byte, error) {
 	var payload []byte
 	var compressedPayload []byte
 	var err error
@@ -161,8 +201,8 @@ func serializeMarshaller(m marshaler.Marshaler, compress bool, mType MarshalType
 	if err != nil {
 		return nil, nil, err
 	}
-	if compress {
-		compressedPayload, err = compression.Compress(nil, payload)
+	if compressor != nil {
+		compressedPayload, err = compressor.Compress(payload)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -8,12 +8,16 @@ package split
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 )
 
 func TestSplitPayloadsSeries(t *testing.T) {
@@ -43,7 +47,7 @@ func TestSplitPayloadsSeries(t *testing.T) {
 	}
 
 	originalLength := len(testSeries)
-	payloads, err := Payloads(testSeries, false, MarshalJSON)
+	payloads, err := Payloads(testSeries, nil, MarshalJSON)
 	require.Nil(t, err)
 	var splitSeries = []metrics.Series{}
 	for _, payload := range payloads {
@@ -86,7 +90,7 @@ func BenchmarkSplitPayloadsSeries(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		// always record the result of Payloads to prevent
 		// the compiler eliminating the function call.
-		r, _ = Payloads(testSeries, true, MarshalJSON)
+		r, _ = Payloads(testSeries, DefaultCompressor, MarshalJSON)
 
 	}
 	// ensure we actually had to split
@@ -125,7 +129,7 @@ func TestSplitPayloadsEvents(t *testing.T) {
 	}
 
 	originalLength := len(testEvent)
-	payloads, err := Payloads(testEvent, false, MarshalJSON)
+	payloads, err := Payloads(testEvent, nil, MarshalJSON)
 	require.Nil(t, err)
 	unrolledEvents := []interface{}{}
 	for _, payload := range payloads {
@@ -158,7 +162,7 @@ func TestSplitPayloadsServiceChecks(t *testing.T) {
 	}
 
 	originalLength := len(testServiceChecks)
-	payloads, err := Payloads(testServiceChecks, false, MarshalJSON)
+	payloads, err := Payloads(testServiceChecks, nil, MarshalJSON)
 	require.Nil(t, err)
 	unrolledServiceChecks := []interface{}{}
 	for _, payload := range payloads {
@@ -173,3 +177,72 @@ func TestSplitPayloadsServiceChecks(t *testing.T) {
 	newLength := len(testServiceChecks)
 	require.Equal(t, originalLength, newLength)
 }
+
+// testMarshaler is a payload of items, split in halves
+type testMarshaler []string
+
+func (m testMarshaler) MarshalJSON() ([]byte, error) { return json.Marshal([]string(m)) }
+func (m testMarshaler) Marshal() ([]byte, error)     { return m.MarshalJSON() }
+func (m testMarshaler) Len() int                     { return len(m) }
+func (m testMarshaler) SplitPayload(int) ([]marshaler.Marshaler, error) {
+	if len(m) < 2 {
+		return nil, fmt.Errorf("cannot split a single item")
+	}
+	return []marshaler.Marshaler{m[:len(m)/2], m[len(m)/2:]}, nil
+}
+
+func unmarshalTestPayloads(t *testing.T, payloads forwarder.Payloads) []string {
+	items := []string{}
+	for _, payload := range payloads {
+		var p []string
+		require.NoError(t, json.Unmarshal(*payload, &p))
+		items = append(items, p...)
+	}
+	return items
+}
+
+func TestSplitPayloadsMaxItems(t *testing.T) {
+	config.Datadog.Set("serializer_max_items_per_payload", 3)
+	defer config.Datadog.Set("serializer_max_items_per_payload", nil)
+
+	m := testMarshaler{"a", "b", "c", "d", "e", "f", "g", "h"}
+	payloads, err := Payloads(m, nil, MarshalJSON)
+	require.NoError(t, err)
+	assert.Len(t, payloads, 4)
+	for _, payload := range payloads {
+		var p []string
+		require.NoError(t, json.Unmarshal(*payload, &p))
+		assert.True(t, len(p) <= 3)
+	}
+	assert.Equal(t, []string(m), unmarshalTestPayloads(t, payloads))
+}
+
+func TestSplitPayloadsRecursively(t *testing.T) {
+	defer func(size int) { maxPayloadSize = size }(maxPayloadSize)
+	maxPayloadSize = 30
+
+	drops := GetPayloadDrops()
+	big := strings.Repeat("x", 40)
+	m := testMarshaler{"a", "b", "c", "d", big, "e", "f", "g"}
+	payloads, err := Payloads(m, nil, MarshalJSON)
+	require.NoError(t, err)
+	// only the item too big is dropped
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g"}, unmarshalTestPayloads(t, payloads))
+	assert.Equal(t, drops+1, GetPayloadDrops())
+
+	_, err = Payloads(testMarshaler{big}, nil, MarshalJSON)
+	assert.Error(t, err)
+}
+
+func TestSplitPayloadsMaxDepth(t *testing.T) {
+	defer func(size, depth int) { maxPayloadSize, maxSplitDepth = size, depth }(maxPayloadSize, maxSplitDepth)
+	maxPayloadSize = 10
+	maxSplitDepth = 1
+
+	drops := GetPayloadDrops()
+	payloads, err := Payloads(testMarshaler{"a", "b", "c", "d", "e", "f", "g", "h"}, nil, MarshalJSON)
+	require.Error(t, err)
+	assert.Empty(t, payloads)
+	// both halves are still too big after a single split
+	assert.Equal(t, drops+2, GetPayloadDrops())
+}
@@ -7,6 +7,7 @@ package health
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
 )
@@ -28,6 +29,8 @@ type component struct {
 	name       string
 	healthChan chan struct{}
 	healthy    bool
+	// failures counts the pings missed in a row
+	failures int
 }
 
 type catalog struct {
@@ -95,8 +98,10 @@ func (c *catalog) pingComponents() bool {
 		select {
 		case component.healthChan <- struct{}{}:
 			component.healthy = true
+			component.failures = 0
 		default:
 			component.healthy = false
+			component.failures++
 		}
 	}
 	c.latestRun = time.Now()
@@ -118,8 +123,39 @@ func (c *catalog) deregister(handle *Handle) error {
 // Status represents the current status of registered components
 // it is built and returned by GetStatus()
 type Status struct {
-	Healthy   []string
-	Unhealthy []string
+	Healthy    []string
+	Unhealthy  []string
+	Components []ComponentStatus `json:",omitempty"`
+}
+
+// ComponentStatus is the status of a registered component, its failures are
+// the health pings it missed in a row
+type ComponentStatus struct {
+	Name                string
+	Healthy             bool
+	ConsecutiveFailures int
+}
+
+// IsAlive returns false if the healthcheck itself is unhealthy, or if a component
+// missed failureThreshold health pings in a row. The components that just started
+// and didn't read their first ping yet are given failureThreshold pings.
+func (s Status) IsAlive(failureThreshold int) bool {
+	for _, name := range s.Unhealthy {
+		if name == "healthcheck" {
+			return false
+		}
+	}
+	for _, component := range s.Components {
+		if component.ConsecutiveFailures >= failureThreshold {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReady returns true if all the components are healthy
+func (s Status) IsReady() bool {
+	return len(s.Unhealthy) == 0
 }
 
 // getStatus allows to query the health status of the agent
@@ -142,6 +178,14 @@ func (c *catalog) getStatus() Status {
 		} else {
 			status.Unhealthy = append(status.Unhealthy, component.name)
 		}
+		status.Components = append(status.Components, ComponentStatus{
+			Name:                component.name,
+			Healthy:             component.healthy,
+			ConsecutiveFailures: component.failures,
+		})
 	}
+	sort.Slice(status.Components, func(i, j int) bool {
+		return status.Components[i].Name < status.Components[j].Name
+	})
 	return status
 }
@@ -108,3 +108,35 @@ func TestGetHealthy(t *testing.T) {
 	assert.Len(t, status.Healthy, 2)
 	assert.Len(t, status.Unhealthy, 0)
 }
+
+func TestFailureThreshold(t *testing.T) {
+	cat := newCatalog()
+	token := cat.register("test1")
+
+	// A component that just started is alive, but not ready
+	status := cat.getStatus()
+	assert.True(t, status.IsAlive(1))
+	assert.False(t, status.IsReady())
+	assert.Equal(t, []ComponentStatus{{Name: "test1"}}, status.Components)
+
+	// Miss two pings in a row
+	cat.pingComponents()
+	cat.pingComponents()
+	status = cat.getStatus()
+	assert.Equal(t, []ComponentStatus{{Name: "test1", ConsecutiveFailures: 2}}, status.Components)
+	assert.False(t, status.IsAlive(1))
+	assert.False(t, status.IsAlive(2))
+	assert.True(t, status.IsAlive(3))
+
+	// Recover
+	<-token.C
+	cat.pingComponents()
+	status = cat.getStatus()
+	assert.Equal(t, []ComponentStatus{{Name: "test1", Healthy: true}}, status.Components)
+	assert.True(t, status.IsAlive(1))
+	assert.True(t, status.IsReady())
+
+	// The healthcheck itself is stuck
+	cat.latestRun = time.Now().Add(-1 * time.Hour)
+	assert.False(t, cat.getStatus().IsAlive(3))
+}
@@ -33,6 +33,7 @@ func Fmap() htemplate.FuncMap {
 		"formatUnixTime":     formatUnixTime,
 		"humanize":           mkHuman,
 		"humanizeDuration":   mkHumanDuration,
+		"humanizeBytes":      mkHumanBytes,
 		"toUnsortedList":     toUnsortedList,
 		"formatTitle":        formatTitle,
 		"add":                add,
@@ -54,6 +55,7 @@ func Textfmap() ttemplate.FuncMap {
 		"formatUnixTime":     formatUnixTime,
 		"humanize":           mkHuman,
 		"humanizeDuration":   mkHumanDuration,
+		"humanizeBytes":      mkHumanBytes,
 		"toUnsortedList":     toUnsortedList,
 		"formatTitle":        formatTitle,
 		"add":                add,
@@ -130,9 +132,26 @@ func toUnsortedList(s map[string]interface{}) string {
 	return fmt.Sprintf("%s", res)
 }
 
-// mkHuman adds commas to large numbers to assist readability in status outputs
-func mkHuman(f float64) string {
-	return humanize.Commaf(f)
+// mkHuman adds commas to large numbers to assist readability in status outputs,
+// it takes the numbers of the typed sections as well as the decoded JSON ones
+func mkHuman(n interface{}) string {
+	switch v := n.(type) {
+	case float64:
+		return humanize.Commaf(v)
+	case int:
+		return humanize.Comma(int64(v))
+	case int64:
+		return humanize.Comma(v)
+	case uint64:
+		return humanize.Commaf(float64(v))
+	default:
+		return fmt.Sprintf("%v", n)
+	}
+}
+
+// mkHumanBytes makes memory sizes more readable
+func mkHumanBytes(f float64) string {
+	return humanize.IBytes(uint64(f))
 }
 
 // mkHumanDuration makes time values more readable
@@ -25,3 +25,14 @@ func TestMkHuman(t *testing.T) {
 		t.Errorf("Large number formatting is incorrectly adding commas in agent statuses")
 	}
 }
+
+func TestMkHumanTypes(t *testing.T) {
+	require.Equal(t, "1,695,783", mkHuman(int64(1695783)))
+	require.Equal(t, "1,695,783", mkHuman(1695783))
+	require.Equal(t, "1,695,783", mkHuman(uint64(1695783)))
+}
+
+func TestMkHumanBytes(t *testing.T) {
+	require.Equal(t, "512 B", mkHumanBytes(512))
+	require.Equal(t, "1.5 MiB", mkHumanBytes(1572864))
+}
@@ -16,45 +16,62 @@ import (
 	"text/template"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 var fmap = Textfmap()
 
+// typedSections are the sections of the status payload emitted as typed structs by
+// their providers, they're rendered from these structs rather than from the untyped map
+type typedSections struct {
+	Aggregator AggregatorSection `json:"aggregatorSection"`
+	DogStatsD  DogStatsDSection  `json:"dogstatsdSection"`
+	Forwarder  ForwarderSection  `json:"forwarderSection"`
+	Logs       logsStatus.Status `json:"logsStats"`
+}
+
 // FormatStatus takes a json bytestring and prints out the formatted statuspage
 func FormatStatus(data []byte) (string, error) {
 	var b = new(bytes.Buffer)
 
 	stats := make(map[string]interface{})
 	json.Unmarshal(data, &stats)
-	forwarderStats := stats["forwarderStats"]
+	var sections typedSections
+	json.Unmarshal(data, &sections)
 	runnerStats := stats["runnerStats"]
 	pyLoaderStats := stats["pyLoaderStats"]
 	pythonInit := stats["pythonInit"]
 	autoConfigStats := stats["autoConfigStats"]
 	checkSchedulerStats := stats["checkSchedulerStats"]
-	aggregatorStats := stats["aggregatorStats"]
-	dogstatsdStats := stats["dogstatsdStats"]
-	logsStats := stats["logsStats"]
 	dcaStats := stats["clusterAgentStatus"]
 	endpointsInfos := stats["endpointsInfos"]
 	inventoriesStats := stats["inventories"]
 	systemProbeStats := stats["systemProbeStats"]
+	fipsStatus := stats["fipsStatus"]
+	remoteConfigStatus := stats["remoteConfigStatus"]
 	title := fmt.Sprintf("Agent (v%s)", stats["version"])
 	stats["title"] = title
 	renderStatusTemplate(b, "/header.tmpl", stats)
 	renderChecksStats(b, runnerStats, pyLoaderStats, pythonInit, autoConfigStats, checkSchedulerStats, inventoriesStats, "")
 	renderStatusTemplate(b, "/jmxfetch.tmpl", stats)
-	renderStatusTemplate(b, "/forwarder.tmpl", forwarderStats)
+	renderStatusTemplate(b, "/forwarder.tmpl", sections.Forwarder)
 	renderStatusTemplate(b, "/endpoints.tmpl", endpointsInfos)
-	renderStatusTemplate(b, "/logsagent.tmpl", logsStats)
+	if config.Datadog.GetBool("fips.enabled") {
+		renderStatusTemplate(b, "/fips.tmpl", fipsStatus)
+	}
+	if config.Datadog.GetBool("remote_configuration.enabled") {
+		renderStatusTemplate(b, "/remoteconfig.tmpl", remoteConfigStatus)
+	}
+	renderStatusTemplate(b, "/logsagent.tmpl", sections.Logs)
 	renderStatusTemplate(b, "/systemprobe.tmpl", systemProbeStats)
-	renderStatusTemplate(b, "/aggregator.tmpl", aggregatorStats)
-	renderStatusTemplate(b, "/dogstatsd.tmpl", dogstatsdStats)
+	renderStatusTemplate(b, "/aggregator.tmpl", sections.Aggregator)
+	renderStatusTemplate(b, "/dogstatsd.tmpl", sections.DogStatsD)
 	if config.Datadog.GetBool("cluster_agent.enabled") || config.Datadog.GetBool("cluster_checks.enabled") {
 		renderStatusTemplate(b, "/clusteragent.tmpl", dcaStats)
 	}
 
-	return b.String(), nil
+	return scrubStatus(b.Bytes())
 }
 
 // FormatDCAStatus takes a json bytestring and prints out the formatted statuspage
@@ -63,7 +80,8 @@ func FormatDCAStatus(data []byte) (string, error) {
 
 	stats := make(map[string]interface{})
 	json.Unmarshal(data, &stats)
-	forwarderStats := stats["forwarderStats"]
+	var sections typedSections
+	json.Unmarshal(data, &sections)
 	runnerStats := stats["runnerStats"]
 	autoConfigStats := stats["autoConfigStats"]
 	checkSchedulerStats := stats["checkSchedulerStats"]
@@ -72,10 +90,20 @@ func FormatDCAStatus(data []byte) (string, error) {
 	stats["title"] = title
 	renderStatusTemplate(b, "/header.tmpl", stats)
 	renderChecksStats(b, runnerStats, nil, nil, autoConfigStats, checkSchedulerStats, nil, "")
-	renderStatusTemplate(b, "/forwarder.tmpl", forwarderStats)
+	renderStatusTemplate(b, "/forwarder.tmpl", sections.Forwarder)
 	renderStatusTemplate(b, "/endpoints.tmpl", endpointsInfos)
 
-	return b.String(), nil
+	return scrubStatus(b.Bytes())
+}
+
+// scrubStatus redacts the credentials and the values of the sensitive keys of the
+// status page, e.g. in the configuration errors of the checks
+func scrubStatus(status []byte) (string, error) {
+	scrubbed, err := scrubber.ScrubBytes("status", status)
+	if err != nil {
+		return "", err
+	}
+	return string(scrubbed), nil
 }
 
 // FormatHPAStatus takes a json bytestring and prints out the formatted statuspage
@@ -271,6 +271,20 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	json.Unmarshal(checkSchedulerStatsJSON, &checkSchedulerStats)
 	stats["checkSchedulerStats"] = checkSchedulerStats
 
+	if fipsData := expvar.Get("fips"); fipsData != nil {
+		fipsStatusJSON := []byte(fipsData.String())
+		fipsStatus := make(map[string]interface{})
+		json.Unmarshal(fipsStatusJSON, &fipsStatus)
+		stats["fipsStatus"] = fipsStatus
+	}
+
+	if remoteConfigData := expvar.Get("remoteconfig"); remoteConfigData != nil {
+		remoteConfigStatusJSON := []byte(remoteConfigData.String())
+		remoteConfigStatus := make(map[string]interface{})
+		json.Unmarshal(remoteConfigStatusJSON, &remoteConfigStatus)
+		stats["remoteConfigStatus"] = remoteConfigStatus["State"]
+	}
+
 	aggregatorStatsJSON := []byte(expvar.Get("aggregator").String())
 	aggregatorStats := make(map[string]interface{})
 	json.Unmarshal(aggregatorStatsJSON, &aggregatorStats)
@@ -293,6 +307,12 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	}
 	stats["dogstatsdStats"] = dogstatsdStats
 
+	// the text status is rendered from the typed sections, the untyped stats
+	// above are kept for the GUI and for the raw status
+	stats["aggregatorSection"] = getAggregatorSection()
+	stats["dogstatsdSection"] = getDogStatsDSection()
+	stats["forwarderSection"] = getForwarderSection()
+
 	pyLoaderData := expvar.Get("pyLoader")
 	if pyLoaderData != nil {
 		pyLoaderStatsJSON := []byte(pyLoaderData.String())
@@ -29,6 +29,14 @@ func getLeaderElectionDetails() map[string]string {
 	leaderElectionStats["renewedTime"] = record.RenewTime.Format(time.RFC1123)
 	leaderElectionStats["transitions"] = fmt.Sprintf("%d transitions", record.LeaderTransitions)
 	leaderElectionStats["status"] = "Running"
+
+	// the view of the election of this cluster agent
+	if stats, running := leaderelection.GetElectionStats(); running {
+		leaderElectionStats["currentLeader"] = stats.Leader
+		leaderElectionStats["isLeader"] = fmt.Sprintf("%t", stats.IsLeader)
+		leaderElectionStats["observedTransitions"] = fmt.Sprintf("%d new leader(s), started leading %d time(s), stopped leading %d time(s)",
+			stats.NewLeader, stats.StartedLeading, stats.StoppedLeading)
+	}
 	return leaderElectionStats
 }
 
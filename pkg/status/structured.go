@@ -0,0 +1,564 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package status
+
+import (
+	"encoding/json"
+	"expvar"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
+	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// SchemaVersion is the version of the structured status. Fields can be added
+// to a version, it's incremented when fields are removed, renamed or change
+// of meaning.
+const SchemaVersion = 1
+
+// Log source states of the structured status
+const (
+	LogSourceOK      = "ok"
+	LogSourcePending = "pending"
+	LogSourceError   = "error"
+)
+
+// Report is the structured status of the agent, for the tools parsing it
+type Report struct {
+	SchemaVersion int               `json:"schema_version"`
+	Agent         AgentSection      `json:"agent"`
+	Checks        ChecksSection     `json:"checks"`
+	Aggregator    AggregatorSection `json:"aggregator"`
+	DogStatsD     DogStatsDSection  `json:"dogstatsd"`
+	Forwarder     ForwarderSection  `json:"forwarder"`
+	Logs          LogsSection       `json:"logs"`
+}
+
+// AgentSection describes the running agent
+type AgentSection struct {
+	Version       string `json:"version"`
+	Hostname      string `json:"hostname"`
+	PID           int    `json:"pid"`
+	GoVersion     string `json:"go_version"`
+	PythonVersion string `json:"python_version"`
+	StartTime     string `json:"start_time"`
+	Time          string `json:"time"`
+	ConfigFile    string `json:"config_file"`
+}
+
+// ChecksSection holds the state of the check instances and the errors of the
+// checks that couldn't be scheduled
+type ChecksSection struct {
+	Instances    []CheckInstance              `json:"instances"`
+	ConfigErrors map[string]string            `json:"config_errors"`
+	LoaderErrors map[string]map[string]string `json:"loader_errors"`
+	InitErrors   map[string][]string          `json:"init_errors"`
+}
+
+// CheckInstance is the state of a scheduled check instance
+type CheckInstance struct {
+	Name                   string   `json:"name"`
+	ID                     string   `json:"id"`
+	Version                string   `json:"version"`
+	ConfigSource           string   `json:"config_source"`
+	TotalRuns              uint64   `json:"total_runs"`
+	TotalErrors            uint64   `json:"total_errors"`
+	TotalWarnings          uint64   `json:"total_warnings"`
+	MetricSamples          int64    `json:"metric_samples"`
+	TotalMetricSamples     uint64   `json:"total_metric_samples"`
+	AverageExecutionTimeMs int64    `json:"average_execution_time_ms"`
+	LastExecutionDate      int64    `json:"last_execution_date"`
+	LastSuccessDate        int64    `json:"last_success_date"`
+	LastError              string   `json:"last_error,omitempty"`
+	LastWarnings           []string `json:"last_warnings,omitempty"`
+}
+
+// AggregatorSection holds the counts of the samples received and flushed by
+// the aggregator since the agent started
+type AggregatorSection struct {
+	ChecksMetricSample      int64 `json:"checks_metric_sample"`
+	DogstatsdMetricSample   int64 `json:"dogstatsd_metric_sample"`
+	ServiceCheck            int64 `json:"service_check"`
+	Event                   int64 `json:"event"`
+	NumberOfFlush           int64 `json:"number_of_flush"`
+	SeriesFlushed           int64 `json:"series_flushed"`
+	SeriesFlushErrors       int64 `json:"series_flush_errors"`
+	SketchesFlushed         int64 `json:"sketches_flushed"`
+	SketchesFlushErrors     int64 `json:"sketches_flush_errors"`
+	ServiceCheckFlushed     int64 `json:"service_check_flushed"`
+	ServiceCheckFlushErrors int64 `json:"service_check_flush_errors"`
+	EventsFlushed           int64 `json:"events_flushed"`
+	EventsFlushErrors       int64 `json:"events_flush_errors"`
+
+	ChecksHistogramBucketMetricSample int64                `json:"checks_histogram_bucket_metric_sample"`
+	HostnameUpdate                    int64                `json:"hostname_update"`
+	SlowFlushes                       int64                `json:"slow_flushes"`
+	FlushIntervalSeconds              int64                `json:"flush_interval_seconds"`
+	Pipelines                         []AggregatorPipeline `json:"pipelines"`
+}
+
+// AggregatorPipeline holds the state of a DogStatsD pipeline of the aggregator
+type AggregatorPipeline struct {
+	FlushDurationNs int64  `json:"flush_duration_ns"`
+	Contexts        int    `json:"contexts"`
+	SeriesFlushed   int    `json:"series_flushed"`
+	LateSamples     uint64 `json:"late_samples"`
+}
+
+// DogStatsDSection holds the counts of the packets received by DogStatsD since
+// the agent started
+type DogStatsDSection struct {
+	MetricPackets           int64 `json:"metric_packets"`
+	MetricParseErrors       int64 `json:"metric_parse_errors"`
+	EventPackets            int64 `json:"event_packets"`
+	EventParseErrors        int64 `json:"event_parse_errors"`
+	ServiceCheckPackets     int64 `json:"service_check_packets"`
+	ServiceCheckParseErrors int64 `json:"service_check_parse_errors"`
+	PacketsLastSecond       int64 `json:"packets_last_second"`
+
+	UDP DogStatsDListener `json:"udp"`
+	UDS DogStatsDListener `json:"uds"`
+}
+
+// DogStatsDListener holds the counts of a DogStatsD listener, the kernel drops
+// and rejected packets are only counted by UDP and the origin detection errors
+// by UDS
+type DogStatsDListener struct {
+	Packets               int64 `json:"packets"`
+	Bytes                 int64 `json:"bytes"`
+	PacketReadingErrors   int64 `json:"packet_reading_errors"`
+	KernelDrops           int64 `json:"kernel_drops"`
+	RejectedPackets       int64 `json:"rejected_packets"`
+	OriginDetectionErrors int64 `json:"origin_detection_errors"`
+}
+
+// ForwarderSection holds the counts of the forwarder transactions
+type ForwarderSection struct {
+	Transactions    ForwarderTransactions      `json:"transactions"`
+	APIKeyStatus    map[string]string          `json:"api_key_status"`
+	EndpointsHealth map[string]EndpointsHealth `json:"endpoints_health"`
+}
+
+// EndpointsHealth is the health of the URLs of a domain the forwarder fails
+// over, keyed by URL, and the URL the transactions are sent to
+type EndpointsHealth struct {
+	Active    string            `json:"active"`
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// ForwarderTransactions are the counts of the forwarder transactions since the
+// agent started
+type ForwarderTransactions struct {
+	Success          int64            `json:"success"`
+	Errors           int64            `json:"errors"`
+	Retried          int64            `json:"retried"`
+	Requeued         int64            `json:"requeued"`
+	Dropped          int64            `json:"dropped"`
+	DroppedOnInput   int64            `json:"dropped_on_input"`
+	RetryQueueSize   int64            `json:"retry_queue_size"`
+	ErrorsByType     map[string]int64 `json:"errors_by_type"`
+	HTTPErrors       int64            `json:"http_errors"`
+	HTTPErrorsByCode map[string]int64 `json:"http_errors_by_code"`
+	// Counters are the other counters of the transactions, by payload type
+	// (Series, Events...) and of the transaction storage
+	Counters map[string]int64 `json:"counters"`
+}
+
+// LogsSection is the state of the logs agent and of its sources
+type LogsSection struct {
+	IsRunning bool        `json:"is_running"`
+	Endpoints []string    `json:"endpoints"`
+	Errors    []string    `json:"errors"`
+	Warnings  []string    `json:"warnings"`
+	Sources   []LogSource `json:"sources"`
+}
+
+// LogSource is the state of a log source, its error is set when the state is error
+type LogSource struct {
+	Integration string   `json:"integration"`
+	Type        string   `json:"type"`
+	State       string   `json:"state"`
+	Error       string   `json:"error,omitempty"`
+	Inputs      []string `json:"inputs,omitempty"`
+	Messages    []string `json:"messages,omitempty"`
+}
+
+// GetReport builds the structured status of the agent from the state of each
+// of its components, the errors are scrubbed like on the status page
+func GetReport() Report {
+	return Report{
+		SchemaVersion: SchemaVersion,
+		Agent:         getAgentSection(),
+		Checks:        getChecksSection(),
+		Aggregator:    getAggregatorSection(),
+		DogStatsD:     getDogStatsDSection(),
+		Forwarder:     getForwarderSection(),
+		Logs:          newLogsSection(logs.GetStatus()),
+	}
+}
+
+func getAgentSection() AgentSection {
+	section := AgentSection{
+		Version:       version.AgentVersion,
+		PID:           os.Getpid(),
+		GoVersion:     runtime.Version(),
+		PythonVersion: strings.Split(host.GetPythonVersion(), " ")[0],
+		StartTime:     startTime.Format(timeFormat),
+		Time:          time.Now().Format(timeFormat),
+		ConfigFile:    config.Datadog.ConfigFileUsed(),
+	}
+	hostnameData, err := util.GetHostnameData()
+	if err != nil {
+		log.Errorf("Error grabbing hostname for status: %v", err)
+		section.Hostname = "unknown"
+	} else {
+		section.Hostname = hostnameData.Hostname
+	}
+	return section
+}
+
+func getChecksSection() ChecksSection {
+	var runner struct {
+		Checks map[string]map[check.ID]*check.Stats
+	}
+	var autoConfig struct {
+		ConfigErrors map[string]string
+	}
+	var scheduler struct {
+		LoaderErrors map[string]map[string]string
+	}
+	var pyLoader struct {
+		ConfigureErrors map[string][]string
+	}
+	readExpvar("runner", &runner)
+	readExpvar("autoconfig", &autoConfig)
+	readExpvar("CheckScheduler", &scheduler)
+	readExpvar("pyLoader", &pyLoader)
+	return newChecksSection(runner.Checks, autoConfig.ConfigErrors, scheduler.LoaderErrors, pyLoader.ConfigureErrors)
+}
+
+func getAggregatorSection() AggregatorSection {
+	var stats struct {
+		ChecksMetricSample                int64
+		DogstatsdMetricSample             int64
+		ServiceCheck                      int64
+		Event                             int64
+		NumberOfFlush                     int64
+		SeriesFlushed                     int64
+		SeriesFlushErrors                 int64
+		SketchesFlushed                   int64
+		SketchesFlushErrors               int64
+		ServiceCheckFlushed               int64
+		ServiceCheckFlushErrors           int64
+		EventsFlushed                     int64
+		EventsFlushErrors                 int64
+		ChecksHistogramBucketMetricSample int64
+		HostnameUpdate                    int64
+		SlowFlushes                       int64
+		FlushIntervalSeconds              int64
+		Pipelines                         []struct {
+			FlushDuration int64
+			Contexts      int
+			SeriesFlushed int
+			LateSamples   uint64
+		}
+	}
+	readExpvar("aggregator", &stats)
+	section := AggregatorSection{
+		ChecksMetricSample:                stats.ChecksMetricSample,
+		DogstatsdMetricSample:             stats.DogstatsdMetricSample,
+		ServiceCheck:                      stats.ServiceCheck,
+		Event:                             stats.Event,
+		NumberOfFlush:                     stats.NumberOfFlush,
+		SeriesFlushed:                     stats.SeriesFlushed,
+		SeriesFlushErrors:                 stats.SeriesFlushErrors,
+		SketchesFlushed:                   stats.SketchesFlushed,
+		SketchesFlushErrors:               stats.SketchesFlushErrors,
+		ServiceCheckFlushed:               stats.ServiceCheckFlushed,
+		ServiceCheckFlushErrors:           stats.ServiceCheckFlushErrors,
+		EventsFlushed:                     stats.EventsFlushed,
+		EventsFlushErrors:                 stats.EventsFlushErrors,
+		ChecksHistogramBucketMetricSample: stats.ChecksHistogramBucketMetricSample,
+		HostnameUpdate:                    stats.HostnameUpdate,
+		SlowFlushes:                       stats.SlowFlushes,
+		FlushIntervalSeconds:              stats.FlushIntervalSeconds,
+		Pipelines:                         make([]AggregatorPipeline, 0, len(stats.Pipelines)),
+	}
+	for _, pipeline := range stats.Pipelines {
+		section.Pipelines = append(section.Pipelines, AggregatorPipeline{
+			FlushDurationNs: pipeline.FlushDuration,
+			Contexts:        pipeline.Contexts,
+			SeriesFlushed:   pipeline.SeriesFlushed,
+			LateSamples:     pipeline.LateSamples,
+		})
+	}
+	return section
+}
+
+func getDogStatsDSection() DogStatsDSection {
+	var section DogStatsDSection
+	var stats struct {
+		MetricPackets           int64
+		MetricParseErrors       int64
+		EventPackets            int64
+		EventParseErrors        int64
+		ServiceCheckPackets     int64
+		ServiceCheckParseErrors int64
+		PacketsLastSecond       int64
+	}
+	if readExpvar("dogstatsd", &stats) {
+		section = DogStatsDSection{
+			MetricPackets:           stats.MetricPackets,
+			MetricParseErrors:       stats.MetricParseErrors,
+			EventPackets:            stats.EventPackets,
+			EventParseErrors:        stats.EventParseErrors,
+			ServiceCheckPackets:     stats.ServiceCheckPackets,
+			ServiceCheckParseErrors: stats.ServiceCheckParseErrors,
+			PacketsLastSecond:       stats.PacketsLastSecond,
+		}
+	}
+	section.UDP = getDogStatsDListener("dogstatsd-udp")
+	section.UDS = getDogStatsDListener("dogstatsd-uds")
+	return section
+}
+
+func getDogStatsDListener(name string) DogStatsDListener {
+	var listener DogStatsDListener
+	var stats struct {
+		Packets               int64
+		Bytes                 int64
+		PacketReadingErrors   int64
+		KernelDrops           int64
+		RejectedPackets       int64
+		OriginDetectionErrors int64
+	}
+	if readExpvar(name, &stats) {
+		listener = DogStatsDListener(stats)
+	}
+	return listener
+}
+
+func getForwarderSection() ForwarderSection {
+	var stats struct {
+		Transactions struct {
+			Success          int64
+			Errors           int64
+			Retried          int64
+			Requeued         int64
+			Dropped          int64
+			DroppedOnInput   int64
+			RetryQueueSize   int64
+			ErrorsByType     map[string]int64
+			HTTPErrors       int64
+			HTTPErrorsByCode map[string]int64
+		}
+		APIKeyStatus    map[string]string
+		EndpointsHealth map[string]struct {
+			Active    string
+			Endpoints map[string]string
+		}
+	}
+	// the counters by payload type are registered by the forwarder at runtime
+	var counters struct {
+		Transactions map[string]interface{}
+	}
+	readExpvar("forwarder", &stats)
+	readExpvar("forwarder", &counters)
+
+	transactions := ForwarderTransactions{
+		Success:          stats.Transactions.Success,
+		Errors:           stats.Transactions.Errors,
+		Retried:          stats.Transactions.Retried,
+		Requeued:         stats.Transactions.Requeued,
+		Dropped:          stats.Transactions.Dropped,
+		DroppedOnInput:   stats.Transactions.DroppedOnInput,
+		RetryQueueSize:   stats.Transactions.RetryQueueSize,
+		ErrorsByType:     stats.Transactions.ErrorsByType,
+		HTTPErrors:       stats.Transactions.HTTPErrors,
+		HTTPErrorsByCode: stats.Transactions.HTTPErrorsByCode,
+		Counters:         make(map[string]int64),
+	}
+	for name, value := range counters.Transactions {
+		if _, typed := forwarderTransactionsFields[name]; typed {
+			continue
+		}
+		if count, ok := value.(float64); ok {
+			transactions.Counters[name] = int64(count)
+		}
+	}
+	endpointsHealth := make(map[string]EndpointsHealth, len(stats.EndpointsHealth))
+	for domain, health := range stats.EndpointsHealth {
+		endpointsHealth[domain] = EndpointsHealth(health)
+	}
+	return newForwarderSection(transactions, stats.APIKeyStatus, endpointsHealth)
+}
+
+// forwarderTransactionsFields are the transactions expvars decoded into the
+// fields of ForwarderTransactions rather than into its counters
+var forwarderTransactionsFields = map[string]struct{}{
+	"Success":          {},
+	"Errors":           {},
+	"Retried":          {},
+	"Requeued":         {},
+	"Dropped":          {},
+	"DroppedOnInput":   {},
+	"RetryQueueSize":   {},
+	"ErrorsByType":     {},
+	"HTTPErrors":       {},
+	"HTTPErrorsByCode": {},
+}
+
+// readExpvar decodes the expvar published by a component, it returns false
+// when the component isn't running or its expvar can't be decoded
+func readExpvar(name string, v interface{}) bool {
+	data := expvar.Get(name)
+	if data == nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(data.String()), v); err != nil {
+		log.Debugf("Error decoding the %s expvar for the status: %v", name, err)
+		return false
+	}
+	return true
+}
+
+// newChecksSection builds the state of the check instances, sorted by ID, and
+// the errors of the checks that couldn't be scheduled
+func newChecksSection(checks map[string]map[check.ID]*check.Stats, configErrors map[string]string, loaderErrors map[string]map[string]string, initErrors map[string][]string) ChecksSection {
+	section := ChecksSection{
+		Instances:    []CheckInstance{},
+		ConfigErrors: make(map[string]string, len(configErrors)),
+		LoaderErrors: make(map[string]map[string]string, len(loaderErrors)),
+		InitErrors:   make(map[string][]string, len(initErrors)),
+	}
+	for name, instances := range checks {
+		for id, stats := range instances {
+			if stats == nil {
+				continue
+			}
+			instance := CheckInstance{
+				Name:                   name,
+				ID:                     string(id),
+				Version:                stats.CheckVersion,
+				ConfigSource:           stats.CheckConfigSource,
+				TotalRuns:              stats.TotalRuns,
+				TotalErrors:            stats.TotalErrors,
+				TotalWarnings:          stats.TotalWarnings,
+				MetricSamples:          stats.MetricSamples,
+				TotalMetricSamples:     stats.TotalMetricSamples,
+				AverageExecutionTimeMs: stats.AverageExecutionTime,
+				LastExecutionDate:      stats.UpdateTimestamp,
+				LastSuccessDate:        stats.LastSuccessDate,
+			}
+			if stats.LastError != "" {
+				instance.LastError = scrubString(lastErrorMessage(stats.LastError))
+			}
+			for _, warning := range stats.LastWarnings {
+				instance.LastWarnings = append(instance.LastWarnings, scrubString(warning))
+			}
+			section.Instances = append(section.Instances, instance)
+		}
+	}
+	sort.Slice(section.Instances, func(i, j int) bool {
+		return section.Instances[i].ID < section.Instances[j].ID
+	})
+	for name, err := range configErrors {
+		section.ConfigErrors[name] = scrubString(err)
+	}
+	for name, errs := range loaderErrors {
+		section.LoaderErrors[name] = make(map[string]string, len(errs))
+		for loader, err := range errs {
+			section.LoaderErrors[name][loader] = scrubString(err)
+		}
+	}
+	for name, errs := range initErrors {
+		for _, err := range errs {
+			section.InitErrors[name] = append(section.InitErrors[name], scrubString(err))
+		}
+	}
+	return section
+}
+
+func newForwarderSection(transactions ForwarderTransactions, apiKeyStatus map[string]string, endpointsHealth map[string]EndpointsHealth) ForwarderSection {
+	section := ForwarderSection{
+		Transactions:    transactions,
+		APIKeyStatus:    make(map[string]string, len(apiKeyStatus)),
+		EndpointsHealth: make(map[string]EndpointsHealth, len(endpointsHealth)),
+	}
+	if section.Transactions.ErrorsByType == nil {
+		section.Transactions.ErrorsByType = map[string]int64{}
+	}
+	if section.Transactions.HTTPErrorsByCode == nil {
+		section.Transactions.HTTPErrorsByCode = map[string]int64{}
+	}
+	if section.Transactions.Counters == nil {
+		section.Transactions.Counters = map[string]int64{}
+	}
+	for key, keyStatus := range apiKeyStatus {
+		section.APIKeyStatus[key] = keyStatus
+	}
+	for domain, health := range endpointsHealth {
+		section.EndpointsHealth[domain] = health
+	}
+	return section
+}
+
+func newLogsSection(logs logsStatus.Status) LogsSection {
+	section := LogsSection{
+		IsRunning: logs.IsRunning,
+		Endpoints: append([]string{}, logs.Endpoints...),
+		Errors:    append([]string{}, logs.Errors...),
+		Warnings:  append([]string{}, logs.Warnings...),
+		Sources:   []LogSource{},
+	}
+	for _, integration := range logs.Integrations {
+		for _, source := range integration.Sources {
+			section.Sources = append(section.Sources, toLogSource(integration.Name, source))
+		}
+	}
+	return section
+}
+
+// toLogSource converts the status of a log source, which is either OK, Pending
+// or its error
+func toLogSource(integration string, source logsStatus.Source) LogSource {
+	s := LogSource{
+		Integration: integration,
+		Type:        source.Type,
+		Inputs:      source.Inputs,
+		Messages:    source.Messages,
+	}
+	switch source.Status {
+	case "OK":
+		s.State = LogSourceOK
+	case "Pending":
+		s.State = LogSourcePending
+	default:
+		s.State = LogSourceError
+		s.Error = scrubString(strings.TrimPrefix(source.Status, "Error: "))
+	}
+	return s
+}
+
+// scrubString redacts the credentials from an error reported by the status
+func scrubString(s string) string {
+	scrubbed, err := scrubber.ScrubBytes("status", []byte(s))
+	if err != nil {
+		return s
+	}
+	return string(scrubbed)
+}
@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
+)
+
+func TestNewChecksSection(t *testing.T) {
+	checks := map[string]map[check.ID]*check.Stats{
+		"redisdb": {"redisdb:abcd": {
+			CheckName:         "redisdb",
+			CheckVersion:      "3.0.0",
+			CheckConfigSource: "file:/etc/datadog-agent/conf.d/redisdb.d/conf.yaml",
+			CheckID:           "redisdb:abcd",
+			TotalRuns:         10,
+			TotalErrors:       2,
+			LastError:         `[{"message": "Error 111 connecting, password: hunter2", "traceback": "Traceback"}]`,
+			UpdateTimestamp:   1591005900,
+		}},
+		"cpu": {
+			"cpu":  {CheckName: "cpu", CheckID: "cpu", TotalRuns: 20, MetricSamples: 6, AverageExecutionTime: 1, LastWarnings: []string{"high load"}},
+			"none": nil,
+		},
+	}
+	section := newChecksSection(checks,
+		map[string]string{"nginx": "yaml: line 3: did not find expected key"},
+		map[string]map[string]string{"foo": {"Core Check Loader": "Check foo not found in Catalog"}},
+		map[string][]string{"mysql": {"missing host"}},
+	)
+
+	// the instances are sorted by ID and their errors are scrubbed
+	require.Len(t, section.Instances, 2)
+	assert.Equal(t, "cpu", section.Instances[0].ID)
+	assert.Equal(t, []string{"high load"}, section.Instances[0].LastWarnings)
+	redis := section.Instances[1]
+	assert.Equal(t, "redisdb", redis.Name)
+	assert.Equal(t, "3.0.0", redis.Version)
+	assert.Equal(t, uint64(2), redis.TotalErrors)
+	assert.Equal(t, int64(1591005900), redis.LastExecutionDate)
+	assert.Contains(t, redis.LastError, "Error 111 connecting")
+	assert.NotContains(t, redis.LastError, "hunter2")
+	assert.Equal(t, map[string]string{"nginx": "yaml: line 3: did not find expected key"}, section.ConfigErrors)
+	assert.Equal(t, map[string]map[string]string{"foo": {"Core Check Loader": "Check foo not found in Catalog"}}, section.LoaderErrors)
+	assert.Equal(t, map[string][]string{"mysql": {"missing host"}}, section.InitErrors)
+}
+
+func TestNewLogsSection(t *testing.T) {
+	section := newLogsSection(logsStatus.Status{
+		IsRunning: true,
+		Endpoints: []string{"Reliable: Sending compressed logs in HTTPS to agent-http-intake.logs.datadoghq.com on port 443"},
+		Integrations: []logsStatus.Integration{
+			{Name: "nginx", Sources: []logsStatus.Source{
+				{Type: "file", Status: "OK", Inputs: []string{"/var/log/nginx/access.log"}},
+				{Type: "file", Status: "Error: could not open /var/log/nginx/error.log"},
+			}},
+			{Name: "syslog", Sources: []logsStatus.Source{{Type: "udp", Status: "Pending"}}},
+		},
+	})
+
+	assert.True(t, section.IsRunning)
+	assert.Len(t, section.Endpoints, 1)
+	assert.Equal(t, []LogSource{
+		{Integration: "nginx", Type: "file", State: LogSourceOK, Inputs: []string{"/var/log/nginx/access.log"}},
+		{Integration: "nginx", Type: "file", State: LogSourceError, Error: "could not open /var/log/nginx/error.log"},
+		{Integration: "syslog", Type: "udp", State: LogSourcePending},
+	}, section.Sources)
+}
+
+func TestReadExpvar(t *testing.T) {
+	m := expvar.NewMap("testReadExpvar")
+	success := expvar.Int{}
+	success.Set(100)
+	m.Set("Success", &success)
+
+	var stats struct {
+		Success int64
+	}
+	assert.True(t, readExpvar("testReadExpvar", &stats))
+	assert.Equal(t, int64(100), stats.Success)
+	assert.False(t, readExpvar("testReadExpvarMissing", &stats))
+}
+
+func TestEmptySections(t *testing.T) {
+	report := Report{
+		SchemaVersion: SchemaVersion,
+		Checks:        newChecksSection(nil, nil, nil, nil),
+		Aggregator:    AggregatorSection{Pipelines: []AggregatorPipeline{}},
+		Forwarder:     newForwarderSection(ForwarderTransactions{}, nil, nil),
+		Logs:          newLogsSection(logsStatus.Status{}),
+	}
+
+	// the sections are always set, so that the tools don't have to check for nulls
+	b, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"agent": {"version": "", "hostname": "", "pid": 0, "go_version": "", "python_version": "", "start_time": "", "time": "", "config_file": ""},
+		"checks": {"instances": [], "config_errors": {}, "loader_errors": {}, "init_errors": {}},
+		"aggregator": {"checks_metric_sample": 0, "dogstatsd_metric_sample": 0, "service_check": 0, "event": 0, "number_of_flush": 0, "series_flushed": 0, "series_flush_errors": 0, "sketches_flushed": 0, "sketches_flush_errors": 0, "service_check_flushed": 0, "service_check_flush_errors": 0, "events_flushed": 0, "events_flush_errors": 0, "checks_histogram_bucket_metric_sample": 0, "hostname_update": 0, "slow_flushes": 0, "flush_interval_seconds": 0, "pipelines": []},
+		"dogstatsd": {"metric_packets": 0, "metric_parse_errors": 0, "event_packets": 0, "event_parse_errors": 0, "service_check_packets": 0, "service_check_parse_errors": 0, "packets_last_second": 0,
+			"udp": {"packets": 0, "bytes": 0, "packet_reading_errors": 0, "kernel_drops": 0, "rejected_packets": 0, "origin_detection_errors": 0},
+			"uds": {"packets": 0, "bytes": 0, "packet_reading_errors": 0, "kernel_drops": 0, "rejected_packets": 0, "origin_detection_errors": 0}},
+		"forwarder": {"transactions": {"success": 0, "errors": 0, "retried": 0, "requeued": 0, "dropped": 0, "dropped_on_input": 0, "retry_queue_size": 0, "errors_by_type": {}, "http_errors": 0, "http_errors_by_code": {}, "counters": {}}, "api_key_status": {}, "endpoints_health": {}},
+		"logs": {"is_running": false, "endpoints": [], "errors": [], "warnings": [], "sources": []}
+	}`, string(b))
+}
+
+func TestGetForwarderSection(t *testing.T) {
+	forwarder := expvar.Get("forwarder")
+	if forwarder == nil {
+		forwarder = expvar.NewMap("forwarder")
+	}
+	forwarderMap := forwarder.(*expvar.Map)
+	transactions := expvar.Map{}
+	transactions.Init()
+	success := expvar.Int{}
+	success.Set(10)
+	series := expvar.Int{}
+	series.Set(4)
+	transactions.Set("Success", &success)
+	transactions.Set("Series", &series)
+	forwarderMap.Set("Transactions", &transactions)
+
+	section := getForwarderSection()
+	assert.Equal(t, int64(10), section.Transactions.Success)
+	assert.Equal(t, map[string]int64{"Series": 4}, section.Transactions.Counters)
+}
+
+func TestRenderTypedSections(t *testing.T) {
+	stats := map[string]interface{}{
+		"aggregatorSection": AggregatorSection{
+			ChecksMetricSample: 1234,
+			Pipelines:          []AggregatorPipeline{{Contexts: 10}, {Contexts: 20, LateSamples: 3}},
+		},
+		"dogstatsdSection": DogStatsDSection{MetricPackets: 42, UDP: DogStatsDListener{KernelDrops: 7}},
+		"forwarderSection": newForwarderSection(
+			ForwarderTransactions{Success: 5, Counters: map[string]int64{"Series": 3}},
+			map[string]string{"API key ending with abcde": "API Key valid"},
+			nil,
+		),
+		"logsStats": logsStatus.Status{
+			IsRunning: true,
+			UseHTTP:   true,
+			Integrations: []logsStatus.Integration{
+				{Name: "nginx", Sources: []logsStatus.Source{{Type: "file", Status: "OK"}}},
+			},
+		},
+	}
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	var sections typedSections
+	require.NoError(t, json.Unmarshal(data, &sections))
+
+	var b bytes.Buffer
+	renderStatusTemplate(&b, "/aggregator.tmpl", sections.Aggregator)
+	renderStatusTemplate(&b, "/dogstatsd.tmpl", sections.DogStatsD)
+	renderStatusTemplate(&b, "/forwarder.tmpl", sections.Forwarder)
+	renderStatusTemplate(&b, "/logsagent.tmpl", sections.Logs)
+	text := b.String()
+
+	assert.Contains(t, text, "Checks Metric Sample: 1,234")
+	assert.Contains(t, text, "Pipeline 1: 20 contexts, 0 series flushed, 3 late samples")
+	assert.Contains(t, text, "Metric Packets: 42")
+	assert.Contains(t, text, "Udp Kernel Drops: 7")
+	assert.Contains(t, text, "Success: 5")
+	assert.Contains(t, text, "Series: 3")
+	assert.Contains(t, text, "API key ending with abcde: API Key valid")
+	assert.Contains(t, text, "nginx")
+	assert.Contains(t, text, "Status: OK")
+}
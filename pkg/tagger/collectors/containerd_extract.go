@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// extractContainerdTags returns the tags of a containerd container, they
+// match the tags of the docker containers
+func extractContainerdTags(container workloadmeta.Container) *TagInfo {
+	tags := utils.NewTagList()
+
+	imageName, shortImage, imageTag, err := containers.SplitImageName(container.Image)
+	if err != nil {
+		log.Debugf("Cannot split %s: %s", container.Image, err)
+	} else {
+		tags.AddLow("image_name", imageName)
+		tags.AddLow("short_image", shortImage)
+		tags.AddLow("image_tag", imageTag)
+	}
+	tags.AddLow("containerd_namespace", container.Namespace)
+
+	name := container.Labels[containerd.NerdctlNameLabel]
+	if name == "" {
+		name = container.Name
+	}
+	tags.AddHigh("container_name", name)
+	tags.AddHigh("container_id", container.ID)
+
+	low, orchestrator, high := tags.Compute()
+	return &TagInfo{
+		Source:               containerdCollectorName,
+		Entity:               containers.BuildTaggerEntityName(container.ID),
+		LowCardTags:          low,
+		OrchestratorCardTags: orchestrator,
+		HighCardTags:         high,
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+func TestExtractContainerdTags(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		container workloadmeta.Container
+		expected  *TagInfo
+	}{
+		{
+			name: "nerdctl container",
+			container: workloadmeta.Container{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "3b8efe0c50e8"},
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "3b8efe0c50e8",
+					Namespace: "default",
+					Labels:    map[string]string{"nerdctl/name": "redis-1"},
+				},
+				Image: "docker.io/library/redis:6.0",
+			},
+			expected: &TagInfo{
+				Source: containerdCollectorName,
+				Entity: "container_id://3b8efe0c50e8",
+				LowCardTags: []string{
+					"image_name:docker.io/library/redis",
+					"short_image:redis",
+					"image_tag:6.0",
+					"containerd_namespace:default",
+				},
+				OrchestratorCardTags: []string{},
+				HighCardTags:         []string{"container_name:redis-1", "container_id:3b8efe0c50e8"},
+			},
+		},
+		{
+			name: "unnamed container with an invalid image",
+			container: workloadmeta.Container{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "9d2a2f8a1c07"},
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "9d2a2f8a1c07",
+					Namespace: "nerdctl",
+				},
+			},
+			expected: &TagInfo{
+				Source:               containerdCollectorName,
+				Entity:               "container_id://9d2a2f8a1c07",
+				LowCardTags:          []string{"containerd_namespace:nerdctl"},
+				OrchestratorCardTags: []string{},
+				HighCardTags:         []string{"container_name:9d2a2f8a1c07", "container_id:9d2a2f8a1c07"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assertTagInfoEqual(t, tc.expected, extractContainerdTags(tc.container))
+		})
+	}
+}
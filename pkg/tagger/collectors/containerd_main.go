@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+
+	// register the workloadmeta collectors feeding the store
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors"
+)
+
+const (
+	containerdCollectorName = "containerd"
+)
+
+var containerdFilter = &workloadmeta.Filter{
+	Kinds:   []workloadmeta.Kind{workloadmeta.KindContainer},
+	Sources: []workloadmeta.Source{workloadmeta.SourceContainerd},
+}
+
+// ContainerdCollector listens to the containerd containers events of the
+// workloadmeta store to tag the containers of standalone containerd workloads
+// like docker containers. The containers managed by Kubernetes or docker are tagged
+// by the kubelet and docker collectors.
+type ContainerdCollector struct {
+	store   *workloadmeta.Store
+	stop    chan bool
+	infoOut chan<- []*TagInfo
+}
+
+// Detect tries to connect to the containerd socket
+func (c *ContainerdCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
+	if _, err := containerd.GetContainerdUtil(); err != nil {
+		return NoCollection, err
+	}
+
+	c.store = workloadmeta.GetGlobalStore()
+	c.stop = make(chan bool)
+	c.infoOut = out
+
+	return StreamCollection, nil
+}
+
+// Stream sends the tags of the containers as they are added to the
+// workloadmeta store. To be called in a goroutine.
+func (c *ContainerdCollector) Stream() error {
+	healthHandle := health.Register("tagger-containerd")
+
+	events := c.store.Subscribe("tagger-containerd", containerdFilter)
+
+	for {
+		select {
+		case <-c.stop:
+			healthHandle.Deregister()
+			c.store.Unsubscribe(events)
+			return nil
+		case <-healthHandle.C:
+		case evs, ok := <-events:
+			if !ok {
+				healthHandle.Deregister()
+				return nil
+			}
+			c.processEvents(evs)
+		}
+	}
+}
+
+// Stop queues a shutdown of ContainerdCollector
+func (c *ContainerdCollector) Stop() error {
+	c.stop <- true
+	return nil
+}
+
+// Fetch returns the tags of a container on cache miss
+func (c *ContainerdCollector) Fetch(entity string) ([]string, []string, []string, error) {
+	entityType, cID := containers.SplitEntityName(entity)
+	if entityType != containers.ContainerEntityName || len(cID) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	container, err := c.store.GetContainer(cID)
+	if err != nil {
+		return []string{}, []string{}, []string{}, err
+	}
+	if containerd.IsOrchestratedNamespace(container.Namespace) {
+		return []string{}, []string{}, []string{}, errors.NewNotFound(entity)
+	}
+	info := extractContainerdTags(container)
+	return info.LowCardTags, info.OrchestratorCardTags, info.HighCardTags, nil
+}
+
+// Replay returns the tags of the containerd containers of the store
+func (c *ContainerdCollector) Replay(store *workloadmeta.Store) []*TagInfo {
+	return c.parseEvents(replayEvents(store, "tagger-containerd-replay", containerdFilter))
+}
+
+func (c *ContainerdCollector) processEvents(events []workloadmeta.Event) {
+	if infos := c.parseEvents(events); len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+func (c *ContainerdCollector) parseEvents(events []workloadmeta.Event) []*TagInfo {
+	var infos []*TagInfo
+	for _, e := range events {
+		id := e.Entity.GetID()
+		if id.Kind != workloadmeta.KindContainer {
+			continue
+		}
+
+		switch e.Type {
+		case workloadmeta.EventTypeUnset:
+			infos = append(infos, &TagInfo{
+				Source:       containerdCollectorName,
+				Entity:       containers.BuildTaggerEntityName(id.ID),
+				DeleteEntity: true,
+			})
+		case workloadmeta.EventTypeSet:
+			container, ok := e.Entity.(workloadmeta.Container)
+			if !ok || containerd.IsOrchestratedNamespace(container.Namespace) {
+				continue
+			}
+			infos = append(infos, extractContainerdTags(container))
+		}
+	}
+	return infos
+}
+
+func containerdFactory() Collector {
+	return &ContainerdCollector{}
+}
+
+func init() {
+	registerCollector(containerdCollectorName, containerdFactory, NodeRuntime)
+}
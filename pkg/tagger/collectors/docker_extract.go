@@ -154,6 +154,8 @@ func dockerExtractEnvironmentVariables(tags *utils.TagList, containerEnvVariable
 			tags.AddLow("nomad_job", envValue)
 		case "NOMAD_GROUP_NAME":
 			tags.AddLow("nomad_group", envValue)
+		case "NOMAD_NAMESPACE":
+			tags.AddLow("nomad_namespace", envValue)
 
 		// Standard tags
 		case envVarEnv:
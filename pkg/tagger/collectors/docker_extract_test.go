@@ -214,6 +214,7 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 						"NOMAD_TASK_NAME=test-task",
 						"NOMAD_JOB_NAME=test-job",
 						"NOMAD_GROUP_NAME=test-group",
+						"NOMAD_NAMESPACE=test-namespace",
 					},
 					Labels: map[string]string{},
 				},
@@ -224,6 +225,7 @@ func TestDockerRecordsFromInspect(t *testing.T) {
 				"nomad_task:test-task",
 				"nomad_job:test-job",
 				"nomad_group:test-group",
+				"nomad_namespace:test-namespace",
 			},
 			expectedOrch: []string{},
 			expectedHigh: []string{},
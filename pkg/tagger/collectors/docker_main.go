@@ -8,7 +8,7 @@
 package collectors
 
 import (
-	"io"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -16,14 +16,23 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+
+	// register the workloadmeta collectors feeding the store
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors"
 )
 
 const (
 	dockerCollectorName = "docker"
+	// the containers that could not be inspected are retried every
+	// dockerRetryInterval, up to dockerMaxRetries times
+	dockerRetryInterval = 5 * time.Second
+	dockerMaxRetries    = 5
 )
 
-// DockerCollector listens to events on the docker socket to get new/dead containers
-// and feed a stram of TagInfo. It requires access to the docker socket.
+// DockerCollector listens to the docker containers events of the workloadmeta
+// store to get new/dead containers and feed a stream of TagInfo. It requires
+// access to the docker socket.
 // It will also embed DockerExtractor collectors for container tagging.
 type DockerCollector struct {
 	dockerUtil   *docker.DockerUtil
@@ -31,6 +40,8 @@ type DockerCollector struct {
 	infoOut      chan<- []*TagInfo
 	labelsAsTags map[string]string
 	envAsTags    map[string]string
+	// retries holds the number of failed inspects of the containers to retry, by ID
+	retries map[string]int
 }
 
 // Detect tries to connect to the docker socket and returns success
@@ -43,13 +54,12 @@ func (c *DockerCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error)
 	c.dockerUtil = du
 	c.stop = make(chan bool)
 	c.infoOut = out
+	c.retries = make(map[string]int)
 
 	// We lower-case the values collected by viper as well as the ones from inspecting the labels of containers.
 	c.labelsAsTags = retrieveMappingFromConfig("docker_labels_as_tags")
 	c.envAsTags = retrieveMappingFromConfig("docker_env_as_tags")
 
-	// TODO: list and inspect existing containers once docker utils are merged
-
 	return StreamCollection, nil
 }
 
@@ -57,26 +67,31 @@ func (c *DockerCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error)
 // to the channel. But be called in a goroutine.
 func (c *DockerCollector) Stream() error {
 	healthHandle := health.Register("tagger-docker")
+	retryTicker := time.NewTicker(dockerRetryInterval)
+	defer retryTicker.Stop()
 
-	messages, errs, err := c.dockerUtil.SubscribeToContainerEvents("DockerCollector")
-	if err != nil {
-		return err
-	}
+	// the first events hold the containers already running
+	store := workloadmeta.GetGlobalStore()
+	events := store.Subscribe("tagger-docker", &workloadmeta.Filter{
+		Kinds:   []workloadmeta.Kind{workloadmeta.KindContainer},
+		Sources: []workloadmeta.Source{workloadmeta.SourceDocker},
+	})
 
 	for {
 		select {
 		case <-c.stop:
 			healthHandle.Deregister()
-			return c.dockerUtil.UnsubscribeFromContainerEvents("DockerCollector")
+			store.Unsubscribe(events)
+			return nil
 		case <-healthHandle.C:
-		case msg := <-messages:
-			c.processEvent(msg)
-		case err := <-errs:
-			if err != nil && err != io.EOF {
-				log.Errorf("stopping collection: %s", err)
-				return err
+		case <-retryTicker.C:
+			c.retryContainers()
+		case evs, ok := <-events:
+			if !ok {
+				healthHandle.Deregister()
+				return nil
 			}
-			return nil
+			c.processEvents(evs)
 		}
 	}
 }
@@ -96,25 +111,70 @@ func (c *DockerCollector) Fetch(entity string) ([]string, []string, []string, er
 	return c.fetchForDockerID(cID)
 }
 
-func (c *DockerCollector) processEvent(e *docker.ContainerEvent) {
-	var info *TagInfo
+func (c *DockerCollector) processEvents(events []workloadmeta.Event) {
+	infos := make([]*TagInfo, 0, len(events))
+	for _, e := range events {
+		id := e.Entity.GetID().ID
+		entityName := docker.ContainerIDToTaggerEntityName(id)
+
+		switch e.Type {
+		case workloadmeta.EventTypeUnset:
+			delete(c.retries, id)
+			infos = append(infos, &TagInfo{Entity: entityName, Source: dockerCollectorName, DeleteEntity: true})
+		case workloadmeta.EventTypeSet:
+			delete(c.retries, id)
+			if info := c.tagInfoForContainer(id); info != nil {
+				infos = append(infos, info)
+			}
+		}
+	}
+	if len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+// retryContainers inspects again the containers whose inspect failed
+func (c *DockerCollector) retryContainers() {
+	var infos []*TagInfo
+	for id := range c.retries {
+		if info := c.tagInfoForContainer(id); info != nil {
+			infos = append(infos, info)
+		}
+	}
+	if len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
 
-	switch e.Action {
-	case "die":
-		info = &TagInfo{Entity: e.ContainerEntityName(), Source: dockerCollectorName, DeleteEntity: true}
-	case "start":
-		low, orchestrator, high, _ := c.fetchForDockerID(e.ContainerID)
-		info = &TagInfo{
-			Entity:               e.ContainerEntityName(),
+// tagInfoForContainer returns the tags of the container, or nil if it cannot
+// be inspected. The container is then retried later unless it is gone or it
+// has already been retried dockerMaxRetries times.
+func (c *DockerCollector) tagInfoForContainer(id string) *TagInfo {
+	low, orchestrator, high, err := c.fetchForDockerID(id)
+	if err == nil {
+		delete(c.retries, id)
+		return &TagInfo{
+			Entity:               docker.ContainerIDToTaggerEntityName(id),
 			Source:               dockerCollectorName,
 			LowCardTags:          low,
 			OrchestratorCardTags: orchestrator,
 			HighCardTags:         high,
 		}
-	default:
-		return // Nothing to see here
 	}
-	c.infoOut <- []*TagInfo{info}
+
+	if errors.IsNotFound(err) {
+		log.Debugf("Container %s not found, not tagging it", id)
+		delete(c.retries, id)
+		return nil
+	}
+	c.retries[id]++
+	if c.retries[id] > dockerMaxRetries {
+		log.Warnf("Cannot get the tags of container %s after %d attempts: %s", id, dockerMaxRetries, err)
+		delete(c.retries, id)
+		return nil
+	}
+	log.Debugf("Cannot get the tags of container %s, will retry: %s", id, err)
+	return nil
 }
 
 func (c *DockerCollector) fetchForDockerID(cID string) ([]string, []string, []string, error) {
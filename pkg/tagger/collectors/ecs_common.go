@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 )
 
 func addResourceTags(t *utils.TagList, m map[string]string) {
@@ -20,3 +21,16 @@ func addResourceTags(t *utils.TagList, m map[string]string) {
 		t.AddLow(strings.ToLower(k), strings.ToLower(v))
 	}
 }
+
+// addTaskV4Tags adds the task tags only exposed by the metadata v4 endpoint
+func addTaskV4Tags(t *utils.TagList, task *v4.Task) {
+	if task.ServiceName != "" {
+		t.AddLow("ecs_service", task.ServiceName)
+	}
+	if task.AvailabilityZone != "" {
+		t.AddLow("availability_zone", task.AvailabilityZone)
+	}
+	if task.LaunchType != "" {
+		t.AddLow("ecs_launch_type", task.LaunchType)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +30,30 @@ func TestAddTaskResourceTags(t *testing.T) {
 
 	assert.Equal(t, expectedTags, tags)
 }
+
+func TestAddTaskV4Tags(t *testing.T) {
+	tags := utils.NewTagList()
+	task := &v4.Task{
+		Family:           "curltest",
+		ServiceName:      "curltest-service",
+		LaunchType:       "FARGATE",
+		AvailabilityZone: "us-west-2d",
+	}
+
+	expectedTags := utils.NewTagList()
+	expectedTags.AddLow("ecs_service", "curltest-service")
+	expectedTags.AddLow("availability_zone", "us-west-2d")
+	expectedTags.AddLow("ecs_launch_type", "FARGATE")
+
+	addTaskV4Tags(tags, task)
+
+	assert.Equal(t, expectedTags, tags)
+
+	// standalone tasks don't have a service
+	tags = utils.NewTagList()
+	addTaskV4Tags(tags, &v4.Task{LaunchType: "EC2"})
+
+	expectedTags = utils.NewTagList()
+	expectedTags.AddLow("ecs_launch_type", "EC2")
+	assert.Equal(t, expectedTags, tags)
+}
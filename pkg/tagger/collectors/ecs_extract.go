@@ -8,53 +8,58 @@
 package collectors
 
 import (
-	"time"
-
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
-	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
-func (c *ECSCollector) parseTasks(tasks []v1.Task, targetDockerID string, containerHandlers ...func(containerID string, tags *utils.TagList)) ([]*TagInfo, error) {
+func (c *ECSCollector) parseTasks(tasks []workloadmeta.ECSTask, targetDockerID string, containerHandlers ...func(containerID string, tags *utils.TagList)) ([]*TagInfo, error) {
 	var output []*TagInfo
-	now := time.Now()
 	for _, task := range tasks {
 		// We only want to collect tasks without a STOPPED status.
 		if task.KnownStatus == "STOPPED" {
 			continue
 		}
-		for _, container := range task.Containers {
+		seen, found := c.seen[task.ID]
+		if !found {
+			seen = make(map[string]struct{}, len(task.Containers))
+			c.seen[task.ID] = seen
+		}
+		for _, containerID := range task.Containers {
 			// Only collect new containers + the targeted container, to avoid empty tags on race conditions
-			if c.expire.Update(container.DockerID, now) || container.DockerID == targetDockerID {
-				tags := utils.NewTagList()
-				tags.AddLow("task_version", task.Version)
-				tags.AddLow("task_name", task.Family)
-				tags.AddLow("task_family", task.Family)
-				tags.AddLow("ecs_container_name", container.Name)
-
-				if c.clusterName != "" {
-					tags.AddLow("cluster_name", c.clusterName)
-				}
+			if _, found := seen[containerID]; found && containerID != targetDockerID {
+				continue
+			}
+			seen[containerID] = struct{}{}
+
+			tags := utils.NewTagList()
+			tags.AddLow("task_version", task.Version)
+			tags.AddLow("task_name", task.Family)
+			tags.AddLow("task_family", task.Family)
+			tags.AddLow("ecs_container_name", task.ContainerNames[containerID])
 
-				for _, fn := range containerHandlers {
-					if fn != nil {
-						fn(container.DockerID, tags)
-					}
+			if c.clusterName != "" {
+				tags.AddLow("cluster_name", c.clusterName)
+			}
+
+			for _, fn := range containerHandlers {
+				if fn != nil {
+					fn(containerID, tags)
 				}
+			}
 
-				tags.AddOrchestrator("task_arn", task.Arn)
+			tags.AddOrchestrator("task_arn", task.ID)
 
-				low, orch, high := tags.Compute()
+			low, orch, high := tags.Compute()
 
-				info := &TagInfo{
-					Source:               ecsCollectorName,
-					Entity:               containers.BuildTaggerEntityName(container.DockerID),
-					HighCardTags:         high,
-					OrchestratorCardTags: orch,
-					LowCardTags:          low,
-				}
-				output = append(output, info)
+			info := &TagInfo{
+				Source:               ecsCollectorName,
+				Entity:               containers.BuildTaggerEntityName(containerID),
+				HighCardTags:         high,
+				OrchestratorCardTags: orch,
+				LowCardTags:          low,
 			}
+			output = append(output, info)
 		}
 	}
 	return output, nil
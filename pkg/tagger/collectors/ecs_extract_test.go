@@ -9,55 +9,50 @@ package collectors
 
 import (
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
-	taggerutil "github.com/DataDog/datadog-agent/pkg/tagger/utils"
-	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
 	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 func TestECSParseTasks(t *testing.T) {
-	ecsExpireFreq := 5 * time.Minute
-	expiretest, _ := taggerutil.NewExpire(ecsExpireFreq)
 	ecsCollector := &ECSCollector{
-		expire:      expiretest,
 		clusterName: "test-cluster",
+		seen:        make(map[string]map[string]struct{}),
 	}
 
 	for nb, tc := range []struct {
-		input    []v1.Task
+		input    []workloadmeta.ECSTask
 		expected []*TagInfo
 		handler  func(containerID string, tags *utils.TagList)
 		err      error
 	}{
 		{
-			input:    []v1.Task{},
+			input:    []workloadmeta.ECSTask{},
 			expected: []*TagInfo{},
 			err:      nil,
 		},
 		{
-			input: []v1.Task{
+			input: []workloadmeta.ECSTask{
 				{
-					Arn:           "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+					EntityID: workloadmeta.EntityID{
+						Kind: workloadmeta.KindECSTask,
+						ID:   "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+					},
 					DesiredStatus: "RUNNING",
 					KnownStatus:   "RUNNING",
 					Family:        "hello_world",
 					Version:       "8",
-					Containers: []v1.Container{
-						{
-							DockerID:   "9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
-							DockerName: "ecs-hello_world-8-mysql-fcae8ac8f9f1d89d8301",
-							Name:       "mysql",
-						},
-						{
-							DockerID:   "bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
-							DockerName: "ecs-hello_world-8-wordpress-e8bfddf9b488dff36c00",
-							Name:       "wordpress",
-						},
+					Containers: []string{
+						"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
+						"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
+					},
+					ContainerNames: map[string]string{
+						"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1": "mysql",
+						"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15": "wordpress",
 					},
 				},
 			},
@@ -80,24 +75,23 @@ func TestECSParseTasks(t *testing.T) {
 			err: nil,
 		},
 		{
-			input: []v1.Task{
+			input: []workloadmeta.ECSTask{
 				{
-					Arn:           "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+					EntityID: workloadmeta.EntityID{
+						Kind: workloadmeta.KindECSTask,
+						ID:   "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+					},
 					DesiredStatus: "RUNNING",
 					KnownStatus:   "RUNNING",
 					Family:        "hello_world",
 					Version:       "8",
-					Containers: []v1.Container{
-						{
-							DockerID:   "9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
-							DockerName: "ecs-hello_world-8-mysql-fcae8ac8f9f1d89d8301",
-							Name:       "mysql",
-						},
-						{
-							DockerID:   "bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
-							DockerName: "ecs-hello_world-8-wordpress-e8bfddf9b488dff36c00",
-							Name:       "wordpress",
-						},
+					Containers: []string{
+						"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
+						"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
+					},
+					ContainerNames: map[string]string{
+						"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1": "mysql",
+						"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15": "wordpress",
 					},
 				},
 			},
@@ -151,30 +145,27 @@ func TestECSParseTasks(t *testing.T) {
 }
 
 func TestECSParseTasksTargetting(t *testing.T) {
-	ecsExpireFreq := 5 * time.Minute
-	expiretest, _ := taggerutil.NewExpire(ecsExpireFreq)
 	ecsCollector := &ECSCollector{
-		expire: expiretest,
+		seen: make(map[string]map[string]struct{}),
 	}
 
-	input := []v1.Task{
+	input := []workloadmeta.ECSTask{
 		{
-			Arn:           "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindECSTask,
+				ID:   "arn:aws:ecs:us-east-1:<aws_account_id>:task/example5-58ff-46c9-ae05-543f8example",
+			},
 			DesiredStatus: "RUNNING",
 			KnownStatus:   "RUNNING",
 			Family:        "hello_world",
 			Version:       "8",
-			Containers: []v1.Container{
-				{
-					DockerID:   "9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
-					DockerName: "ecs-hello_world-8-mysql-fcae8ac8f9f1d89d8301",
-					Name:       "mysql",
-				},
-				{
-					DockerID:   "bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
-					DockerName: "ecs-hello_world-8-wordpress-e8bfddf9b488dff36c00",
-					Name:       "wordpress",
-				},
+			Containers: []string{
+				"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1",
+				"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15",
+			},
+			ContainerNames: map[string]string{
+				"9581a69a761a557fbfce1d0f6745e4af5b9dbfb86b6b2c5c4df156f1a5932ff1": "mysql",
+				"bf25c5c5b2d4dba68846c7236e75b6915e1e778d31611e3c6a06831e39814a15": "wordpress",
 			},
 		},
 	}
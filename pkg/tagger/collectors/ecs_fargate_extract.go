@@ -16,12 +16,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // parseMetadata parses the task metadata and its container list, and returns a list of TagInfo for the new ones.
 // It also updates the lastSeen cache of the ECSFargateCollector and return the list of dead containers to be expired.
-func (c *ECSFargateCollector) parseMetadata(meta *v2.Task, parseAll bool) ([]*TagInfo, error) {
+// The task from the metadata v4 endpoint is optional, it adds the tags only exposed by v4.
+func (c *ECSFargateCollector) parseMetadata(meta *v2.Task, metaV4 *v4.Task, parseAll bool) ([]*TagInfo, error) {
 	var output []*TagInfo
 	now := time.Now()
 
@@ -69,6 +71,9 @@ func (c *ECSFargateCollector) parseMetadata(meta *v2.Task, parseAll bool) ([]*Ta
 			tags.AddLow("task_family", meta.Family)
 			tags.AddLow("task_version", meta.Version)
 			tags.AddOrchestrator("task_arn", meta.TaskARN)
+			if metaV4 != nil {
+				addTaskV4Tags(tags, metaV4)
+			}
 
 			// container
 			tags.AddLow("ecs_container_name", ctr.Name)
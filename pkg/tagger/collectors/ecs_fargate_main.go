@@ -17,6 +17,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	ecsutil "github.com/DataDog/datadog-agent/pkg/util/ecs"
 	ecsmeta "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
@@ -63,7 +65,7 @@ func (c *ECSFargateCollector) Pull() error {
 		return err
 	}
 	// Only parse new containers
-	updates, err := c.parseMetadata(taskMeta, false)
+	updates, err := c.parseMetadata(taskMeta, getTaskV4FromCurrentTask(), false)
 	if err != nil {
 		return err
 	}
@@ -95,7 +97,7 @@ func (c *ECSFargateCollector) Fetch(container string) ([]string, []string, []str
 		return []string{}, []string{}, []string{}, err
 	}
 	// Force a full parse to avoid missing the container in a race with Pull
-	updates, err := c.parseMetadata(taskMeta, true)
+	updates, err := c.parseMetadata(taskMeta, getTaskV4FromCurrentTask(), true)
 	if err != nil {
 		return []string{}, []string{}, []string{}, err
 	}
@@ -123,6 +125,21 @@ func (c *ECSFargateCollector) parseExpires(idList []string) ([]*TagInfo, error)
 	return output, nil
 }
 
+// getTaskV4FromCurrentTask returns the task from the metadata v4 endpoint,
+// available from the Fargate platform 1.4, or nil if it's not available
+func getTaskV4FromCurrentTask() *v4.Task {
+	metaV4, err := ecsmeta.V4FromCurrentTask()
+	if err != nil {
+		return nil
+	}
+	task, err := metaV4.GetTask()
+	if err != nil {
+		log.Debugf("Unable to get the task from the metadata v4 API: %s", err)
+		return nil
+	}
+	return task
+}
+
 func ecsFargateFactory() Collector {
 	return &ECSFargateCollector{}
 }
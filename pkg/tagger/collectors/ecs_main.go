@@ -9,35 +9,43 @@ package collectors
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
-	taggerutil "github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	ecsutil "github.com/DataDog/datadog-agent/pkg/util/ecs"
 	ecsmeta "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata"
-	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
 	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
+
+	// register the workloadmeta collectors feeding the store
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors"
 )
 
 const (
 	ecsCollectorName = "ecs"
-	ecsExpireFreq    = 5 * time.Minute
 )
 
-// ECSCollector listen to the ECS agent to get ECS metadata.
+var ecsFilter = &workloadmeta.Filter{
+	Kinds:   []workloadmeta.Kind{workloadmeta.KindECSTask},
+	Sources: []workloadmeta.Source{workloadmeta.SourceECS},
+}
+
+// ECSCollector listens to the ECS tasks of the workloadmeta store, collected
+// from the ECS agent, to get ECS metadata.
 // Relies on the DockerCollector to trigger deletions, it's not intended to run standalone
 type ECSCollector struct {
+	store       *workloadmeta.Store
+	stop        chan bool
 	infoOut     chan<- []*TagInfo
-	expire      *taggerutil.Expire
-	lastExpire  time.Time
-	expireFreq  time.Duration
-	metaV1      *v1.Client
 	clusterName string
+	// seen holds the containers already tagged, by task ARN
+	seen map[string]map[string]struct{}
 }
 
 // Detect tries to connect to the ECS agent
@@ -51,24 +59,49 @@ func (c *ECSCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
 		return NoCollection, err
 	}
 
-	c.metaV1 = metaV1
+	c.store = workloadmeta.GetGlobalStore()
+	c.stop = make(chan bool)
 	c.infoOut = out
-	c.lastExpire = time.Now()
-	c.expireFreq = ecsExpireFreq
-
-	c.expire, err = taggerutil.NewExpire(ecsExpireFreq)
-	if err != nil {
-		return NoCollection, err
-	}
+	c.seen = make(map[string]map[string]struct{})
 
-	instance, err := c.metaV1.GetInstance()
+	instance, err := metaV1.GetInstance()
 	if err != nil {
 		log.Warnf("Cannot determine ECS cluster name: %s", err)
 	}
 
 	c.clusterName = instance.Cluster
 
-	return FetchOnlyCollection, nil
+	return StreamCollection, nil
+}
+
+// Stream sends the tags of the containers of the tasks as they are added to
+// the workloadmeta store. To be called in a goroutine.
+func (c *ECSCollector) Stream() error {
+	healthHandle := health.Register("tagger-ecs")
+
+	events := c.store.Subscribe("tagger-ecs", ecsFilter)
+
+	for {
+		select {
+		case <-c.stop:
+			healthHandle.Deregister()
+			c.store.Unsubscribe(events)
+			return nil
+		case <-healthHandle.C:
+		case evs, ok := <-events:
+			if !ok {
+				healthHandle.Deregister()
+				return nil
+			}
+			c.processEvents(evs)
+		}
+	}
+}
+
+// Stop queues a shutdown of ECSCollector
+func (c *ECSCollector) Stop() error {
+	c.stop <- true
+	return nil
 }
 
 // Fetch fetches ECS tags
@@ -78,32 +111,18 @@ func (c *ECSCollector) Fetch(entity string) ([]string, []string, []string, error
 		return nil, nil, nil, nil
 	}
 
-	tasks, err := c.metaV1.GetTasks()
+	task, err := c.store.GetECSTaskForContainer(cID)
 	if err != nil {
 		return []string{}, []string{}, []string{}, err
 	}
 
-	var updates []*TagInfo
-
-	if config.Datadog.GetBool("ecs_collect_resource_tags_ec2") && ecsutil.HasEC2ResourceTags() {
-		updates, err = c.parseTasks(tasks, cID, addTagsForContainer)
-	} else {
-		updates, err = c.parseTasks(tasks, cID)
-	}
+	updates, err := c.parseTasks([]workloadmeta.ECSTask{task}, cID, c.containerHandlers()...)
 	if err != nil {
 		return []string{}, []string{}, []string{}, err
 	}
 
 	c.infoOut <- updates
 
-	// Only run the expire process with the most up to date tasks parsed.
-	// Using a go routine as the expire process can be done asynchronously.
-	// We do not use the output as the ECSCollector is not meant run in standalone.
-	if time.Now().Sub(c.lastExpire) >= c.expireFreq {
-		go c.expire.ComputeExpires()
-		c.lastExpire = time.Now()
-	}
-
 	for _, info := range updates {
 		if info.Entity == entity {
 			return info.LowCardTags, info.OrchestratorCardTags, info.HighCardTags, nil
@@ -113,6 +132,49 @@ func (c *ECSCollector) Fetch(entity string) ([]string, []string, []string, error
 	return []string{}, []string{}, []string{}, errors.NewNotFound(entity)
 }
 
+// Replay returns the tags of the containers of the ECS tasks of the store. The
+// cluster name and the tags read from the metadata endpoints are not known offline.
+func (c *ECSCollector) Replay(store *workloadmeta.Store) []*TagInfo {
+	c.seen = make(map[string]map[string]struct{})
+	return c.parseEvents(replayEvents(store, "tagger-ecs-replay", ecsFilter))
+}
+
+func (c *ECSCollector) processEvents(events []workloadmeta.Event) {
+	if infos := c.parseEvents(events, c.containerHandlers()...); len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+func (c *ECSCollector) parseEvents(events []workloadmeta.Event, containerHandlers ...func(containerID string, tags *utils.TagList)) []*TagInfo {
+	var tasks []workloadmeta.ECSTask
+	for _, e := range events {
+		switch e.Type {
+		case workloadmeta.EventTypeUnset:
+			// the containers are deleted by the DockerCollector
+			delete(c.seen, e.Entity.GetID().ID)
+		case workloadmeta.EventTypeSet:
+			if task, ok := e.Entity.(workloadmeta.ECSTask); ok {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	infos, err := c.parseTasks(tasks, "", containerHandlers...)
+	if err != nil {
+		log.Debugf("Cannot parse the ECS tasks: %s", err)
+		return nil
+	}
+	return infos
+}
+
+func (c *ECSCollector) containerHandlers() []func(containerID string, tags *utils.TagList) {
+	handlers := []func(containerID string, tags *utils.TagList){newTaskV4TagsHandler()}
+	if config.Datadog.GetBool("ecs_collect_resource_tags_ec2") && ecsutil.HasEC2ResourceTags() {
+		handlers = append(handlers, addTagsForContainer)
+	}
+	return handlers
+}
+
 func addTagsForContainer(containerID string, tags *utils.TagList) {
 	task, err := fetchContainerTaskWithTagsV3(containerID)
 	if err != nil {
@@ -123,6 +185,42 @@ func addTagsForContainer(containerID string, tags *utils.TagList) {
 	addResourceTags(tags, task.TaskTags)
 }
 
+// newTaskV4TagsHandler returns a handler adding the tags of the task of the
+// containers exposed by the metadata v4 endpoint, available from the ECS agent
+// 1.39. The task is fetched once for all its containers parsed with the handler.
+func newTaskV4TagsHandler() func(containerID string, tags *utils.TagList) {
+	tasks := make(map[string]*v4.Task)
+	return func(containerID string, tags *utils.TagList) {
+		task, found := tasks[containerID]
+		if !found {
+			task = fetchContainerTaskV4(containerID)
+			tasks[containerID] = task
+			if task != nil {
+				for _, ctr := range task.Containers {
+					tasks[ctr.DockerID] = task
+				}
+			}
+		}
+		if task != nil {
+			addTaskV4Tags(tags, task)
+		}
+	}
+}
+
+func fetchContainerTaskV4(containerID string) *v4.Task {
+	metaV4, err := ecsmeta.V4(containerID)
+	if err != nil {
+		log.Debugf("Metadata v4 endpoint not available for container %s: %s", containerID, err)
+		return nil
+	}
+	task, err := metaV4.GetTask()
+	if err != nil {
+		log.Debugf("Unable to get the task of container %s from the metadata v4 API: %s", containerID, err)
+		return nil
+	}
+	return task
+}
+
 func fetchContainerTaskWithTagsV3(containerID string) (*v3.Task, error) {
 	metaV3, err := ecsmeta.V3(containerID)
 	if err != nil {
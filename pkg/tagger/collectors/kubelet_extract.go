@@ -70,8 +70,10 @@ func (c *KubeletCollector) parsePods(pods []*kubelet.Pod) ([]*TagInfo, error) {
 
 		// Pod annotations
 		for name, value := range pod.Metadata.Annotations {
-			if tagName, found := c.annotationsAsTags[strings.ToLower(name)]; found {
-				tags.AddAuto(tagName, value)
+			if tmpl, found := c.annotationsAsTags[strings.ToLower(name)]; found {
+				if tagName, tagValue, ok := tmpl.Resolve(name, value); ok {
+					tags.AddAuto(tagName, tagValue)
+				}
 			}
 		}
 		if podTags, found := extractTagsFromMap(podTagsAnnotation, pod.Metadata.Annotations); found {
@@ -12,7 +12,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 )
 
@@ -943,9 +945,11 @@ func TestParsePods(t *testing.T) {
 		},
 	} {
 		t.Run(fmt.Sprintf("case %d: %s", nb, tc.desc), func(t *testing.T) {
+			annotationsAsTags, errs := utils.ParseTagTemplates(tc.annotationsAsTags)
+			require.Empty(t, errs)
 			collector := &KubeletCollector{
 				labelsAsTags:      tc.labelsAsTags,
-				annotationsAsTags: tc.annotationsAsTags,
+				annotationsAsTags: annotationsAsTags,
 			}
 			infos, err := collector.parsePods([]*kubelet.Pod{tc.pod})
 			assert.Nil(t, err)
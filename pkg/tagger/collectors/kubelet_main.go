@@ -9,40 +9,49 @@ package collectors
 
 import (
 	"strings"
-	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+
+	// register the workloadmeta collectors feeding the store
+	_ "github.com/DataDog/datadog-agent/pkg/workloadmeta/collectors"
 )
 
 const (
 	kubeletCollectorName = "kubelet"
-	kubeletExpireFreq    = 5 * time.Minute
 )
 
-// KubeletCollector connects to the local kubelet to get kubernetes container
-// tags. It is to be supplemented by the cluster agent collector for tags from
-// the apiserver.
+// KubeletCollector listens to the pods of the workloadmeta store, collected
+// from the local kubelet, to get kubernetes container tags. It is to be
+// supplemented by the cluster agent collector for tags from the apiserver.
 type KubeletCollector struct {
-	watcher           *kubelet.PodWatcher
-	infoOut           chan<- []*TagInfo
-	lastExpire        time.Time
-	expireFreq        time.Duration
+	kubeUtil kubelet.KubeUtilInterface
+	store    *workloadmeta.Store
+	stop     chan bool
+	infoOut  chan<- []*TagInfo
+	// podEntities holds the tagger entities of each pod, by pod UID, to
+	// delete its containers along with the pod
+	podEntities       map[string][]string
 	labelsAsTags      map[string]string
-	annotationsAsTags map[string]string
+	annotationsAsTags map[string]*utils.TagTemplate
 }
 
 // Detect tries to connect to the kubelet
 func (c *KubeletCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
-	watcher, err := kubelet.NewPodWatcher(5*time.Minute, true)
+	ku, err := kubelet.GetKubeUtil()
 	if err != nil {
 		return NoCollection, err
 	}
-	c.watcher = watcher
+	c.kubeUtil = ku
+	c.store = workloadmeta.GetGlobalStore()
+	c.stop = make(chan bool)
 	c.infoOut = out
-	c.lastExpire = time.Now()
-	c.expireFreq = kubeletExpireFreq
+	c.podEntities = make(map[string][]string)
 
 	// We lower-case the values collected by viper as well as the ones from inspecting the labels of containers.
 	labelsList := config.Datadog.GetStringMapString("kubernetes_pod_labels_as_tags")
@@ -57,48 +66,51 @@ func (c *KubeletCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error)
 		delete(annotationsList, annotation)
 		annotationsList[strings.ToLower(annotation)] = value
 	}
-	c.annotationsAsTags = annotationsList
-	return PullCollection, nil
-}
-
-// Pull triggers a podlist refresh and sends new info. It also triggers
-// container deletion computation every 'expireFreq'
-func (c *KubeletCollector) Pull() error {
-	// Compute new/updated pods
-	updatedPods, err := c.watcher.PullChanges()
-	if err != nil {
-		return err
+	annotationsAsTags, errs := utils.ParseTagTemplates(annotationsList)
+	for _, err := range errs {
+		log.Errorf("Invalid kubernetes_pod_annotations_as_tags: %s", err)
 	}
+	c.annotationsAsTags = annotationsAsTags
+	return StreamCollection, nil
+}
 
-	updates, err := c.parsePods(updatedPods)
-	if err != nil {
-		return err
-	}
-	c.infoOut <- updates
-
-	// Throttle deletion computations
-	if time.Now().Sub(c.lastExpire) < c.expireFreq {
-		return nil
+// Stream sends the tags of the pods and their containers as they are added
+// to the workloadmeta store. To be called in a goroutine.
+func (c *KubeletCollector) Stream() error {
+	healthHandle := health.Register("tagger-kubelet")
+
+	events := c.store.Subscribe("tagger-kubelet", &workloadmeta.Filter{
+		Kinds:   []workloadmeta.Kind{workloadmeta.KindKubernetesPod},
+		Sources: []workloadmeta.Source{workloadmeta.SourceKubelet},
+	})
+
+	for {
+		select {
+		case <-c.stop:
+			healthHandle.Deregister()
+			c.store.Unsubscribe(events)
+			return nil
+		case <-healthHandle.C:
+		case evs, ok := <-events:
+			if !ok {
+				healthHandle.Deregister()
+				return nil
+			}
+			c.processEvents(evs)
+		}
 	}
+}
 
-	// Compute deleted pods
-	expireList, err := c.watcher.Expire()
-	if err != nil {
-		return err
-	}
-	expiries, err := c.parseExpires(expireList)
-	if err != nil {
-		return err
-	}
-	c.infoOut <- expiries
-	c.lastExpire = time.Now()
+// Stop queues a shutdown of KubeletCollector
+func (c *KubeletCollector) Stop() error {
+	c.stop <- true
 	return nil
 }
 
 // Fetch fetches tags for a given entity by iterating on the whole podlist
 // TODO: optimize if called too often on production
 func (c *KubeletCollector) Fetch(entity string) ([]string, []string, []string, error) {
-	pod, err := c.watcher.GetPodForEntityID(entity)
+	pod, err := c.kubeUtil.GetPodForEntityID(entity)
 	if err != nil {
 		return []string{}, []string{}, []string{}, err
 	}
@@ -119,8 +131,44 @@ func (c *KubeletCollector) Fetch(entity string) ([]string, []string, []string, e
 	return []string{}, []string{}, []string{}, errors.NewNotFound(entity)
 }
 
-// parseExpires transforms event from the PodWatcher to TagInfo objects
-func (c *KubeletCollector) parseExpires(idList []string) ([]*TagInfo, error) {
+// processEvents sends the tags of the updated pods, read from the cached pod
+// list of the kubelet, and deletes the entities of the removed pods
+func (c *KubeletCollector) processEvents(events []workloadmeta.Event) {
+	var infos []*TagInfo
+	for _, e := range events {
+		uid := e.Entity.GetID().ID
+
+		switch e.Type {
+		case workloadmeta.EventTypeUnset:
+			infos = append(infos, c.parseExpires(c.podEntities[uid])...)
+			delete(c.podEntities, uid)
+		case workloadmeta.EventTypeSet:
+			pod, err := c.kubeUtil.GetPodFromUID(uid)
+			if err != nil {
+				log.Debugf("Cannot get the pod %s from the kubelet: %s", uid, err)
+				continue
+			}
+			updates, err := c.parsePods([]*kubelet.Pod{pod})
+			if err != nil {
+				log.Debugf("Cannot parse the pod %s: %s", uid, err)
+				continue
+			}
+			entities := make([]string, 0, len(updates))
+			for _, info := range updates {
+				entities = append(entities, info.Entity)
+			}
+			c.podEntities[uid] = entities
+			infos = append(infos, updates...)
+		}
+	}
+
+	if len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+// parseExpires transforms a list of removed entities to TagInfo objects
+func (c *KubeletCollector) parseExpires(idList []string) []*TagInfo {
 	var output []*TagInfo
 	for _, id := range idList {
 		info := &TagInfo{
@@ -130,7 +178,7 @@ func (c *KubeletCollector) parseExpires(idList []string) ([]*TagInfo, error) {
 		}
 		output = append(output, info)
 	}
-	return output, nil
+	return output
 }
 
 func kubeletFactory() Collector {
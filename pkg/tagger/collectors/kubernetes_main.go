@@ -15,6 +15,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
@@ -35,6 +36,8 @@ type KubeMetadataCollector struct {
 	updateFreq time.Duration
 
 	clusterAgentEnabled bool
+
+	namespaceAnnotationsAsTags map[string]*utils.TagTemplate
 }
 
 // Detect tries to connect to the kubelet and the API Server if the DCA is not used or the DCA.
@@ -74,6 +77,11 @@ func (c *KubeMetadataCollector) Detect(out chan<- []*TagInfo) (CollectionMode, e
 		if err != nil {
 			return NoCollection, err
 		}
+		var errs []error
+		c.namespaceAnnotationsAsTags, errs = utils.ParseTagTemplates(config.Datadog.GetStringMapString("kubernetes_namespace_annotations_as_tags"))
+		for _, err := range errs {
+			log.Errorf("Invalid kubernetes_namespace_annotations_as_tags: %s", err)
+		}
 	}
 	c.infoOut = out
 	c.updateFreq = time.Duration(config.Datadog.GetInt("kubernetes_metadata_tag_update_freq")) * time.Second
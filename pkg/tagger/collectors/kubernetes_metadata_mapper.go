@@ -36,6 +36,7 @@ func (c *KubeMetadataCollector) getTagInfos(pods []*kubelet.Pod) []*TagInfo {
 	}
 	var tagInfo []*TagInfo
 	var metadataNames []string
+	namespaceTags := make(map[string][]string)
 	var tag []string
 	for _, po := range pods {
 		if kubelet.IsPodReady(po) == false {
@@ -83,6 +84,20 @@ func (c *KubeMetadataCollector) getTagInfos(pods []*kubelet.Pod) []*TagInfo {
 			}
 		}
 
+		nsTags, found := namespaceTags[po.Metadata.Namespace]
+		if !found {
+			nsTags, err = c.getNamespaceTags(po.Metadata.Namespace)
+			if err != nil {
+				log.Debugf("Could not fetch the tags of namespace %s: %v", po.Metadata.Namespace, err)
+			}
+			namespaceTags[po.Metadata.Namespace] = nsTags
+		}
+		for _, nsTag := range nsTags {
+			if tag := strings.SplitN(nsTag, ":", 2); len(tag) == 2 {
+				tagList.AddAuto(tag[0], tag[1])
+			}
+		}
+
 		low, orchestrator, high := tagList.Compute()
 		// Register the tags for the pod itself
 		if po.Metadata.UID != "" {
@@ -138,6 +153,25 @@ func (c *KubeMetadataCollector) getMetadaNames(getPodMetaDataFromAPIServerFunc f
 	return metadataNames, err
 }
 
+// getNamespaceTags returns the tags extracted from the annotations of the
+// namespace, by the DCA when it is enabled.
+func (c *KubeMetadataCollector) getNamespaceTags(ns string) ([]string, error) {
+	if ns == "" {
+		return nil, nil
+	}
+	if c.isClusterAgentEnabled() {
+		return c.dcaClient.GetNamespaceTags(ns)
+	}
+	if len(c.namespaceAnnotationsAsTags) == 0 {
+		return nil, nil
+	}
+	annotations, err := apiserver.GetNamespaceAnnotations(ns)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ExtractTags(c.namespaceAnnotationsAsTags, annotations), nil
+}
+
 // addToCacheMetadataMapping is acting like the DCA at the node level.
 func (c *KubeMetadataCollector) addToCacheMetadataMapping(kubeletPodList []*kubelet.Pod) error {
 	if len(kubeletPodList) == 0 {
@@ -15,6 +15,7 @@ import (
 
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	nodeconfigs "github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs/types"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
@@ -38,6 +39,9 @@ type FakeDCAClient struct {
 	KubernetesMetadataNames    []string
 	KubernetesMetadataNamesErr error
 
+	NamespaceTags    []string
+	NamespaceTagsErr error
+
 	ClusterCheckStatus    types.StatusResponse
 	ClusterCheckStatusErr error
 
@@ -46,6 +50,9 @@ type FakeDCAClient struct {
 
 	EndpointsCheckConfigs    types.ConfigResponse
 	EndpointsCheckConfigsErr error
+
+	NodeConfigs    nodeconfigs.ConfigResponse
+	NodeConfigsErr error
 }
 
 func (f *FakeDCAClient) Version() version.Version {
@@ -60,6 +67,9 @@ func (f *FakeDCAClient) GetVersion() (version.Version, error) {
 func (f *FakeDCAClient) GetNodeLabels(nodeName string) (map[string]string, error) {
 	return f.NodeLabel, f.NodeLabelErr
 }
+func (f *FakeDCAClient) GetNamespaceTags(ns string) ([]string, error) {
+	return f.NamespaceTags, f.NamespaceTagsErr
+}
 func (f *FakeDCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error) {
 	return f.PodMetadataForNode, f.PodMetadataForNodeErr
 }
@@ -79,6 +89,10 @@ func (f *FakeDCAClient) GetEndpointsCheckConfigs(nodeName string) (types.ConfigR
 	return f.EndpointsCheckConfigs, f.EndpointsCheckConfigsErr
 }
 
+func (f *FakeDCAClient) GetNodeConfigs(nodeName, version string) (nodeconfigs.ConfigResponse, bool, error) {
+	return f.NodeConfigs, f.NodeConfigs.Version == version, f.NodeConfigsErr
+}
+
 func TestKubeMetadataCollector_getMetadaNames(t *testing.T) {
 	type fields struct {
 		dcaClient           clusteragent.DCAClientInterface
@@ -320,6 +334,33 @@ func TestKubeMetadataCollector_getTagInfos(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "clusterAgentEnabled with namespace tags",
+			args: args{
+				pods: pods,
+			},
+			fields: fields{
+				kubeUtil:            kubeUtilFake,
+				clusterAgentEnabled: true,
+				dcaClient: &FakeDCAClient{
+					LocalVersion:            version.Version{Major: 1, Minor: 3},
+					KubernetesMetadataNames: []string{"svc1"},
+					NamespaceTags:           []string{"team:backend", "+owner:john"},
+				},
+			},
+			want: []*TagInfo{
+				{
+					Source:               kubeMetadataCollectorName,
+					Entity:               kubelet.PodUIDToTaggerEntityName("foouid"),
+					HighCardTags:         []string{"owner:john"},
+					OrchestratorCardTags: []string{},
+					LowCardTags: []string{
+						"kube_service:svc1",
+						"team:backend",
+					},
+				},
+			},
+		},
 		{
 			name: "clusterAgentEnabled enable but client init failed",
 			args: args{
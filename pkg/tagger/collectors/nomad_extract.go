@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/tagger/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	// environment variables set by Nomad in the task containers
+	nomadAllocIDEnvVar  = "NOMAD_ALLOC_ID"
+	nomadTaskNameEnvVar = "NOMAD_TASK_NAME"
+)
+
+// extractNomadTags returns the tags of a container of a Nomad allocation
+func extractNomadTags(container workloadmeta.Container, alloc workloadmeta.NomadAllocation) *TagInfo {
+	tags := utils.NewTagList()
+	tags.AddLow("nomad_job", alloc.Job)
+	tags.AddLow("nomad_group", alloc.Group)
+	tags.AddLow("nomad_namespace", alloc.Namespace)
+	tags.AddLow("nomad_task", container.EnvVars[nomadTaskNameEnvVar])
+	tags.AddOrchestrator("nomad_alloc_id", alloc.ID)
+
+	low, orchestrator, high := tags.Compute()
+	return &TagInfo{
+		Source:               nomadCollectorName,
+		Entity:               containers.BuildTaggerEntityName(container.ID),
+		LowCardTags:          low,
+		OrchestratorCardTags: orchestrator,
+		HighCardTags:         high,
+	}
+}
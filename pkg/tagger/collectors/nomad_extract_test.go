@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+func TestExtractNomadTags(t *testing.T) {
+	alloc := workloadmeta.NomadAllocation{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindNomadAlloc,
+			ID:   "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      "example.cache[0]",
+			Namespace: "default",
+		},
+		Job:   "example",
+		Group: "cache",
+		Tasks: []string{"redis"},
+	}
+	container := workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainer,
+			ID:   "3b8efe0c50e8",
+		},
+		EnvVars: map[string]string{
+			"NOMAD_ALLOC_ID":  "5456bd7a-9fc0-c0dd-6131-cbee77f57577",
+			"NOMAD_TASK_NAME": "redis",
+		},
+	}
+
+	expected := []*TagInfo{
+		{
+			Source: nomadCollectorName,
+			Entity: "container_id://3b8efe0c50e8",
+			LowCardTags: []string{
+				"nomad_job:example",
+				"nomad_group:cache",
+				"nomad_namespace:default",
+				"nomad_task:redis",
+			},
+			OrchestratorCardTags: []string{"nomad_alloc_id:5456bd7a-9fc0-c0dd-6131-cbee77f57577"},
+			HighCardTags:         []string{},
+		},
+	}
+	assertTagInfoListEqual(t, expected, []*TagInfo{extractNomadTags(container, alloc)})
+}
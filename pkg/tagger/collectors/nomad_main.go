@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/nomad"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	nomadCollectorName = "nomad"
+)
+
+var nomadFilter = &workloadmeta.Filter{
+	Kinds: []workloadmeta.Kind{workloadmeta.KindContainer, workloadmeta.KindNomadAlloc},
+}
+
+// NomadCollector tags the containers of the Nomad allocations of the node. It
+// listens to the allocations and containers of the workloadmeta store, the
+// allocations being collected from the local Nomad agent API, and matches
+// them with the NOMAD_ALLOC_ID variable set in the task environment.
+type NomadCollector struct {
+	store   *workloadmeta.Store
+	stop    chan bool
+	infoOut chan<- []*TagInfo
+}
+
+// Detect tries to connect to the local Nomad agent
+func (c *NomadCollector) Detect(out chan<- []*TagInfo) (CollectionMode, error) {
+	if _, err := nomad.NewClient(); err != nil {
+		return NoCollection, err
+	}
+
+	c.store = workloadmeta.GetGlobalStore()
+	c.stop = make(chan bool)
+	c.infoOut = out
+
+	return StreamCollection, nil
+}
+
+// Stream sends the tags of the containers as they and their allocations are
+// added to the workloadmeta store. To be called in a goroutine.
+func (c *NomadCollector) Stream() error {
+	healthHandle := health.Register("tagger-nomad")
+
+	events := c.store.Subscribe("tagger-nomad", nomadFilter)
+
+	for {
+		select {
+		case <-c.stop:
+			healthHandle.Deregister()
+			c.store.Unsubscribe(events)
+			return nil
+		case <-healthHandle.C:
+		case evs, ok := <-events:
+			if !ok {
+				healthHandle.Deregister()
+				return nil
+			}
+			c.processEvents(evs)
+		}
+	}
+}
+
+// Stop queues a shutdown of NomadCollector
+func (c *NomadCollector) Stop() error {
+	c.stop <- true
+	return nil
+}
+
+// Fetch returns the Nomad tags of a container on cache miss
+func (c *NomadCollector) Fetch(entity string) ([]string, []string, []string, error) {
+	entityType, cID := containers.SplitEntityName(entity)
+	if entityType != containers.ContainerEntityName || len(cID) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	container, err := c.store.GetContainer(cID)
+	if err != nil {
+		return []string{}, []string{}, []string{}, err
+	}
+	info := c.tagsForContainer(container)
+	if info == nil {
+		return []string{}, []string{}, []string{}, errors.NewNotFound(entity)
+	}
+	return info.LowCardTags, info.OrchestratorCardTags, info.HighCardTags, nil
+}
+
+// Replay returns the tags of the containers of the Nomad allocations of the store
+func (c *NomadCollector) Replay(store *workloadmeta.Store) []*TagInfo {
+	c.store = store
+	return c.parseEvents(replayEvents(store, "tagger-nomad-replay", nomadFilter))
+}
+
+func (c *NomadCollector) processEvents(events []workloadmeta.Event) {
+	if infos := c.parseEvents(events); len(infos) > 0 {
+		c.infoOut <- infos
+	}
+}
+
+func (c *NomadCollector) parseEvents(events []workloadmeta.Event) []*TagInfo {
+	var infos []*TagInfo
+	for _, e := range events {
+		id := e.Entity.GetID()
+
+		switch id.Kind {
+		case workloadmeta.KindContainer:
+			if e.Type == workloadmeta.EventTypeUnset {
+				infos = append(infos, &TagInfo{
+					Source:       nomadCollectorName,
+					Entity:       containers.BuildTaggerEntityName(id.ID),
+					DeleteEntity: true,
+				})
+				continue
+			}
+			if info := c.tagsForContainer(e.Entity.(workloadmeta.Container)); info != nil {
+				infos = append(infos, info)
+			}
+		case workloadmeta.KindNomadAlloc:
+			// containers are deleted on their own events
+			if e.Type == workloadmeta.EventTypeUnset {
+				continue
+			}
+			alloc := e.Entity.(workloadmeta.NomadAllocation)
+			for _, container := range c.store.ListContainers() {
+				if container.EnvVars[nomadAllocIDEnvVar] != alloc.ID {
+					continue
+				}
+				infos = append(infos, extractNomadTags(container, alloc))
+			}
+		}
+	}
+	return infos
+}
+
+// tagsForContainer returns the tags of a container, or nil if it does not
+// belong to a known allocation
+func (c *NomadCollector) tagsForContainer(container workloadmeta.Container) *TagInfo {
+	allocID, found := container.EnvVars[nomadAllocIDEnvVar]
+	if !found {
+		return nil
+	}
+	alloc, err := c.store.GetNomadAllocation(allocID)
+	if err != nil {
+		return nil
+	}
+	return extractNomadTags(container, alloc)
+}
+
+func nomadFactory() Collector {
+	return &NomadCollector{}
+}
+
+func init() {
+	registerCollector(nomadCollectorName, nomadFactory, NodeOrchestrator)
+}
@@ -5,6 +5,10 @@
 
 package collectors
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
 // TagInfo holds the tag information for a given entity and source. It's meant
 // to be created from collectors and read by the store.
 type TagInfo struct {
@@ -57,6 +61,18 @@ const (
 	HighCardinality
 )
 
+// String returns the name of the cardinality, as used in the configuration
+func (c TagCardinality) String() string {
+	switch c {
+	case HighCardinality:
+		return "high"
+	case OrchestratorCardinality:
+		return "orchestrator"
+	default:
+		return "low"
+	}
+}
+
 // Fetcher allows to fetch tags on-demand in case of cache miss
 type Fetcher interface {
 	Fetch(string) ([]string, []string, []string, error)
@@ -74,3 +90,18 @@ type Puller interface {
 	Fetcher
 	Pull() error
 }
+
+// Replayer computes the tags from the entities of a workloadmeta store alone,
+// without querying any runtime, to compute them again offline from a dump
+type Replayer interface {
+	Replay(store *workloadmeta.Store) []*TagInfo
+}
+
+// replayEvents returns the Set events of the entities of a store matching the
+// filter, as received by the subscribers of the store
+func replayEvents(store *workloadmeta.Store, name string, filter *workloadmeta.Filter) []workloadmeta.Event {
+	ch := store.Subscribe(name, filter)
+	events := <-ch
+	store.Unsubscribe(ch)
+	return events
+}
@@ -6,80 +6,194 @@
 package tagger
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 // defaultTagger is the shared tagger instance backing the global Tag and Init functions
 var defaultTagger *Tagger
 var initOnce sync.Once
 
+// pidEntities resolves the entities of the processes for EntityForPID
+var pidEntities = newPIDResolver()
+
+// source serves the global query functions, it is the defaultTagger unless
+// InitRemote is used
+var source EntitySource
+
+// EntitySource is the interface used by the global functions to query tags.
+// It is implemented by the Tagger, and by the remote tagger streaming its
+// entities from the core agent.
+type EntitySource interface {
+	Tag(entity string, cardinality collectors.TagCardinality) ([]string, error)
+	GetEntityHash(entity string) string
+	IsKnownEntity(entity string) bool
+	List(cardinality collectors.TagCardinality) response.TaggerListResponse
+	Stop() error
+}
+
 // ChecksCardinality defines the cardinality of tags we should send for check metrics
 // this can still be overridden when calling get_tags in python checks.
 var ChecksCardinality collectors.TagCardinality
 
 // DogstatsdCardinality defines the cardinality of tags we should send for metrics from
-// dogstatsd.
+// dogstatsd. It can be overridden by the clients with the dd.internal.card tag.
 var DogstatsdCardinality collectors.TagCardinality
 
+// MaxCardinality caps the cardinality of the tags returned by Tag, whatever
+// the cardinality requested by the checks or the dogstatsd clients.
+var MaxCardinality = collectors.HighCardinality
+
 // Init must be called once config is available, call it in your cmd
 func Init() {
 	initOnce.Do(func() {
-		var err error
-		checkCard := config.Datadog.GetString("checks_tag_cardinality")
-		dsdCard := config.Datadog.GetString("dogstatsd_tag_cardinality")
+		initCardinalities()
+		// the collectors watching the container runtimes consume the workloadmeta store
+		workloadmeta.StartGlobalStore(context.Background())
+		defaultTagger.tagStore.setDeletionTTLs(deletionTTLsFromConfig())
+		defaultTagger.Init(collectors.DefaultCatalog)
+	})
+}
 
-		ChecksCardinality, err = stringToTagCardinality(checkCard)
-		if err != nil {
-			log.Warnf("failed to parse check tag cardinality, defaulting to low. Error: %s", err)
-			ChecksCardinality = collectors.LowCardinality
-		}
-		DogstatsdCardinality, err = stringToTagCardinality(dsdCard)
+// InitRemote replaces the local tagger with the given source, typically a
+// remote tagger receiving the entity tags from the core agent, which must be
+// started by the caller. Call it instead of Init.
+func InitRemote(remote EntitySource) {
+	initOnce.Do(func() {
+		initCardinalities()
+		source = remote
+	})
+}
+
+func initCardinalities() {
+	var err error
+	MaxCardinality, err = ParseCardinality(config.Datadog.GetString("max_tag_cardinality"))
+	if err != nil {
+		log.Warnf("failed to parse max tag cardinality, defaulting to high. Error: %s", err)
+		MaxCardinality = collectors.HighCardinality
+	}
+
+	checkCard := config.Datadog.GetString("checks_tag_cardinality")
+	dsdCard := config.Datadog.GetString("dogstatsd_tag_cardinality")
+
+	ChecksCardinality, err = ParseCardinality(checkCard)
+	if err != nil {
+		log.Warnf("failed to parse check tag cardinality, defaulting to low. Error: %s", err)
+		ChecksCardinality = collectors.LowCardinality
+	}
+	DogstatsdCardinality, err = ParseCardinality(dsdCard)
+	if err != nil {
+		log.Warnf("failed to parse dogstatsd tag cardinality, defaulting to low. Error: %s", err)
+		DogstatsdCardinality = collectors.LowCardinality
+	}
+
+	if ChecksCardinality > MaxCardinality || DogstatsdCardinality > MaxCardinality {
+		log.Warnf("checks and dogstatsd tag cardinalities are capped to the max tag cardinality %s", MaxCardinality)
+	}
+	ChecksCardinality = CapCardinality(ChecksCardinality)
+	DogstatsdCardinality = CapCardinality(DogstatsdCardinality)
+}
+
+// CapCardinality returns the cardinality, lowered to MaxCardinality if needed
+func CapCardinality(cardinality collectors.TagCardinality) collectors.TagCardinality {
+	if cardinality > MaxCardinality {
+		return MaxCardinality
+	}
+	return cardinality
+}
+
+// deletionTTLsFromConfig returns the default deletion grace period and the
+// grace periods by entity prefix, configured in seconds
+func deletionTTLsFromConfig() (time.Duration, map[string]time.Duration) {
+	defaultTTL := time.Duration(config.Datadog.GetInt("tagger.deletion_grace_period")) * time.Second
+	ttls := make(map[string]time.Duration)
+	for prefix, value := range config.Datadog.GetStringMapString("tagger.deletion_grace_period_by_prefix") {
+		seconds, err := strconv.Atoi(value)
 		if err != nil {
-			log.Warnf("failed to parse dogstatsd tag cardinality, defaulting to low. Error: %s", err)
-			DogstatsdCardinality = collectors.LowCardinality
+			log.Warnf("Invalid deletion grace period %q for the %s entities, using the default: %s", value, prefix, err)
+			continue
 		}
-
-		defaultTagger.Init(collectors.DefaultCatalog)
-	})
+		ttls[prefix] = time.Duration(seconds) * time.Second
+	}
+	return defaultTTL, ttls
 }
 
 // Tag queries the defaultTagger to get entity tags from cache or sources.
 // It can return tags at high cardinality (with tags about individual containers),
 // or at orchestrator cardinality (pod/task level)
+// The cardinality is capped to MaxCardinality.
 func Tag(entity string, cardinality collectors.TagCardinality) ([]string, error) {
-	return defaultTagger.Tag(entity, cardinality)
+	return source.Tag(entity, CapCardinality(cardinality))
 }
 
 // OrchestratorScopeTag queries tags for orchestrator scope (e.g. task_arn in ECS Fargate)
 func OrchestratorScopeTag() ([]string, error) {
-	return defaultTagger.Tag(collectors.OrchestratorScopeEntityID, collectors.OrchestratorCardinality)
+	return source.Tag(collectors.OrchestratorScopeEntityID, CapCardinality(collectors.OrchestratorCardinality))
+}
+
+// EntityForPID returns the entity of the container running a process, or an
+// empty string if it runs on the host. It resolves the container through the
+// cgroups of the process, and caches the result until the process exits, so
+// that the tags can be queried when only a PID is known, as with the peer
+// credentials of unix sockets.
+func EntityForPID(pid int32) (string, error) {
+	return pidEntities.entityForPID(pid)
+}
+
+// TagForPID returns the tags of the container running a process, or no tags
+// if it runs on the host. The cardinality is capped to MaxCardinality.
+func TagForPID(pid int32, cardinality collectors.TagCardinality) ([]string, error) {
+	entity, err := EntityForPID(pid)
+	if err != nil || entity == "" {
+		return nil, err
+	}
+	return Tag(entity, cardinality)
+}
+
+// IsKnownEntity returns true if the entity was reported by a collector, an
+// unknown entity and a known entity without tags both having no tags
+func IsKnownEntity(entity string) bool {
+	return source.IsKnownEntity(entity)
 }
 
 // Stop queues a stop signal to the defaultTagger
 func Stop() error {
-	return defaultTagger.Stop()
+	return source.Stop()
 }
 
 // List the content of the defaulTagger
 func List(cardinality collectors.TagCardinality) response.TaggerListResponse {
-	return defaultTagger.List(cardinality)
+	return source.List(cardinality)
 }
 
 // GetEntityHash returns the hash for the tags associated with the given entity
 func GetEntityHash(entity string) string {
-	return defaultTagger.GetEntityHash(entity)
+	return source.GetEntityHash(entity)
+}
+
+// Subscribe returns a channel receiving the entity events of the defaultTagger
+func Subscribe() chan []EntityEvent {
+	return defaultTagger.Subscribe()
+}
+
+// Unsubscribe stops sending entity events to a channel returned by Subscribe
+func Unsubscribe(ch chan []EntityEvent) {
+	defaultTagger.Unsubscribe(ch)
 }
 
-// stringToTagCardinality extracts a TagCardinality from a string.
+// ParseCardinality extracts a TagCardinality from a string.
 // In case of failure to parse, returns an error and defaults to Low.
-func stringToTagCardinality(c string) (collectors.TagCardinality, error) {
+func ParseCardinality(c string) (collectors.TagCardinality, error) {
 	switch strings.ToLower(c) {
 	case "high":
 		return collectors.HighCardinality, nil
@@ -94,4 +208,5 @@ func stringToTagCardinality(c string) (collectors.TagCardinality, error) {
 
 func init() {
 	defaultTagger = newTagger()
+	source = defaultTagger
 }
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+)
+
+const (
+	// pidRevalidateInterval is the age after which a cached entity is checked
+	// against the process creation time, to detect PID reuse
+	pidRevalidateInterval = 10 * time.Second
+	// pidPurgeInterval is the interval between two purges of the exited processes
+	pidPurgeInterval = time.Minute
+)
+
+// pidResolver resolves the container entity of a process through its cgroups.
+// The result is cached until the process exits, which is detected when its
+// creation time changes or it cannot be found anymore.
+type pidResolver struct {
+	sync.Mutex
+	entries   map[int32]*pidEntry
+	lastPurge time.Time
+
+	// overridden in tests
+	containerIDForPID func(pid int) (string, error)
+	createTime        func(pid int32) (int64, error)
+}
+
+type pidEntry struct {
+	entity     string // empty for processes running outside of containers
+	createTime int64
+	validated  time.Time
+}
+
+func newPIDResolver() *pidResolver {
+	return &pidResolver{
+		entries:   make(map[int32]*pidEntry),
+		lastPurge: time.Now(),
+		containerIDForPID: func(pid int) (string, error) {
+			return providers.ContainerImpl().ContainerIDForPID(pid)
+		},
+		createTime: processCreateTime,
+	}
+}
+
+// entityForPID returns the tagger entity of the container running the process,
+// or an empty string if the process does not run in a container
+func (r *pidResolver) entityForPID(pid int32) (string, error) {
+	now := time.Now()
+
+	r.Lock()
+	defer r.Unlock()
+
+	if now.Sub(r.lastPurge) > pidPurgeInterval {
+		r.purge()
+		r.lastPurge = now
+	}
+
+	entry, found := r.entries[pid]
+	if found && now.Sub(entry.validated) < pidRevalidateInterval {
+		return entry.entity, nil
+	}
+
+	createTime, err := r.createTime(pid)
+	if err != nil {
+		delete(r.entries, pid)
+		return "", err
+	}
+	if found && entry.createTime == createTime {
+		entry.validated = now
+		return entry.entity, nil
+	}
+
+	cID, err := r.containerIDForPID(int(pid))
+	if err != nil {
+		delete(r.entries, pid)
+		return "", err
+	}
+
+	entity := ""
+	if cID != "" {
+		entity = containers.BuildTaggerEntityName(cID)
+	}
+	r.entries[pid] = &pidEntry{
+		entity:     entity,
+		createTime: createTime,
+		validated:  now,
+	}
+	return entity, nil
+}
+
+// purge removes the entries of the exited processes. The lock must be held.
+func (r *pidResolver) purge() {
+	for pid, entry := range r.entries {
+		createTime, err := r.createTime(pid)
+		if err != nil || createTime != entry.createTime {
+			delete(r.entries, pid)
+		}
+	}
+}
+
+func processCreateTime(pid int32) (int64, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcesses struct {
+	createTimes  map[int32]int64
+	containerIDs map[int]string
+	lookups      int
+}
+
+func (f *fakeProcesses) resolver() *pidResolver {
+	r := newPIDResolver()
+	r.createTime = func(pid int32) (int64, error) {
+		t, found := f.createTimes[pid]
+		if !found {
+			return 0, errors.New("process not found")
+		}
+		return t, nil
+	}
+	r.containerIDForPID = func(pid int) (string, error) {
+		f.lookups++
+		return f.containerIDs[pid], nil
+	}
+	return r
+}
+
+func TestEntityForPID(t *testing.T) {
+	procs := &fakeProcesses{
+		createTimes:  map[int32]int64{10: 1000, 20: 2000},
+		containerIDs: map[int]string{10: "abcdef"},
+	}
+	r := procs.resolver()
+
+	entity, err := r.entityForPID(10)
+	require.NoError(t, err)
+	assert.Equal(t, "container_id://abcdef", entity)
+
+	// processes running on the host have no entity
+	entity, err = r.entityForPID(20)
+	require.NoError(t, err)
+	assert.Equal(t, "", entity)
+
+	// cached
+	_, err = r.entityForPID(10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, procs.lookups)
+
+	_, err = r.entityForPID(30)
+	assert.Error(t, err)
+}
+
+func TestEntityForPIDReuse(t *testing.T) {
+	procs := &fakeProcesses{
+		createTimes:  map[int32]int64{10: 1000},
+		containerIDs: map[int]string{10: "abcdef"},
+	}
+	r := procs.resolver()
+
+	entity, err := r.entityForPID(10)
+	require.NoError(t, err)
+	assert.Equal(t, "container_id://abcdef", entity)
+
+	// the PID is reused by a process of another container
+	procs.createTimes[10] = 3000
+	procs.containerIDs[10] = "123456"
+	r.entries[10].validated = time.Now().Add(-2 * pidRevalidateInterval)
+
+	entity, err = r.entityForPID(10)
+	require.NoError(t, err)
+	assert.Equal(t, "container_id://123456", entity)
+}
+
+func TestPIDResolverPurge(t *testing.T) {
+	procs := &fakeProcesses{
+		createTimes:  map[int32]int64{10: 1000, 20: 2000},
+		containerIDs: map[int]string{10: "abcdef", 20: "123456"},
+	}
+	r := procs.resolver()
+
+	_, err := r.entityForPID(10)
+	require.NoError(t, err)
+	_, err = r.entityForPID(20)
+	require.NoError(t, err)
+
+	delete(procs.createTimes, 20)
+	r.lastPurge = time.Now().Add(-2 * pidPurgeInterval)
+
+	_, err = r.entityForPID(10)
+	require.NoError(t, err)
+	assert.Contains(t, r.entries, int32(10))
+	assert.NotContains(t, r.entries, int32(20))
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package remote implements the tagger gRPC streaming service, served by the
+core agent, and the remote tagger used by the other agent processes to
+receive the entity tags from the core agent instead of collecting them.
+*/
+package remote
+
+import (
+	"google.golang.org/grpc"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+)
+
+const (
+	serviceName              = "datadog.agent.Tagger"
+	streamEntitiesMethod     = "StreamEntities"
+	streamEntitiesFullName   = "/" + serviceName + "/" + streamEntitiesMethod
+	authorizationMetadataKey = "authorization"
+)
+
+// StreamEntitiesRequest is sent by a client to subscribe to the entity events
+type StreamEntitiesRequest struct{}
+
+// StreamEntitiesResponse is a batch of entity events. The first response of a
+// stream is a snapshot holding every entity known by the tagger.
+type StreamEntitiesResponse struct {
+	Snapshot bool                 `json:"snapshot"`
+	Events   []tagger.EntityEvent `json:"events"`
+}
+
+// taggerServer is implemented by the server of the tagger service
+type taggerServer interface {
+	StreamEntities(*StreamEntitiesRequest, grpc.ServerStream) error
+}
+
+var taggerServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*taggerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    streamEntitiesMethod,
+			Handler:       streamEntitiesHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func streamEntitiesHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := &StreamEntitiesRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(taggerServer).StreamEntities(req, stream)
+}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"encoding/json"
+)
+
+// codecName is the gRPC content-subtype of the tagger service messages
+const codecName = "json"
+
+// jsonCodec encodes the gRPC messages of the tagger service as JSON, the
+// messages being the Go structures shared with the tagger package. It is not
+// registered globally, not to change the codec of the other gRPC services of
+// the process: the server sets it as its codec and the client forces it on
+// its calls.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// String implements the grpc.Codec interface required by grpc.CustomCodec
+func (jsonCodec) String() string {
+	return codecName
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Server streams the entity events of the local tagger to the other agent
+// processes. It listens on the IPC address, uses the IPC certificate and
+// requires the IPC auth token.
+type Server struct {
+	grpcServer *grpc.Server
+	listener   net.Listener
+}
+
+// NewServer returns a tagger server listening on the `remote_tagger.port`
+// of the IPC address
+func NewServer(cert tls.Certificate) (*Server, error) {
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", ipcAddress, config.Datadog.GetInt("remote_tagger.port")))
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for the remote tagger: %v", err)
+	}
+
+	s := &Server{
+		grpcServer: grpc.NewServer(
+			grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
+			grpc.CustomCodec(jsonCodec{}),
+			grpc.StreamInterceptor(authStreamInterceptor),
+		),
+		listener: listener,
+	}
+	s.grpcServer.RegisterService(&taggerServiceDesc, s)
+	return s, nil
+}
+
+// Start serves the tagger service in the background
+func (s *Server) Start() {
+	go func() {
+		if err := s.grpcServer.Serve(s.listener); err != nil {
+			log.Errorf("Error serving the remote tagger: %v", err)
+		}
+	}()
+}
+
+// Stop closes the streams and the listener
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+// StreamEntities sends a snapshot of the tagger entities then every change,
+// until the client disconnects.
+func (s *Server) StreamEntities(req *StreamEntitiesRequest, stream grpc.ServerStream) error {
+	ch := tagger.Subscribe()
+	defer tagger.Unsubscribe(ch)
+
+	snapshot := true
+	for {
+		select {
+		case events, ok := <-ch:
+			if !ok {
+				// the stream was too slow, the client has to reconnect to get a fresh snapshot
+				return status.Error(codes.Aborted, "the client did not keep up with the entity events")
+			}
+			err := stream.SendMsg(&StreamEntitiesResponse{
+				Snapshot: snapshot,
+				Events:   events,
+			})
+			if err != nil {
+				log.Debugf("Error sending entity events to the remote tagger: %v", err)
+				return err
+			}
+			snapshot = false
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// authStreamInterceptor checks the IPC auth token sent by the clients
+func authStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, ok := metadata.FromIncomingContext(stream.Context())
+	if !ok || len(md.Get(authorizationMetadataKey)) == 0 {
+		return status.Error(codes.Unauthenticated, "no session token provided")
+	}
+	if md.Get(authorizationMetadataKey)[0] != "Bearer "+util.GetAuthToken() {
+		return status.Error(codes.PermissionDenied, "invalid session token")
+	}
+	return handler(srv, stream)
+}
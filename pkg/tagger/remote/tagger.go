@@ -0,0 +1,244 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 1 * time.Minute
+)
+
+// Tagger holds a copy of the entities of the core agent tagger, kept up to
+// date by the StreamEntities stream. It serves the last known tags while
+// reconnecting.
+type Tagger struct {
+	sync.RWMutex
+	store map[string]tagger.Entity
+
+	address string
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewTagger returns an unstarted remote tagger
+func NewTagger() *Tagger {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tagger{
+		store:  make(map[string]tagger.Entity),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start connects to the core agent and streams the entity events in the
+// background, reconnecting on errors.
+func (t *Tagger) Start() error {
+	if err := util.SetAuthToken(); err != nil {
+		return fmt.Errorf("unable to read the IPC auth token: %v", err)
+	}
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	t.address = fmt.Sprintf("%s:%d", ipcAddress, config.Datadog.GetInt("remote_tagger.port"))
+
+	go t.run()
+	return nil
+}
+
+// Stop closes the stream
+func (t *Tagger) Stop() error {
+	t.cancel()
+	return nil
+}
+
+// dial connects to the core agent, verifying it with the IPC certificate. The
+// certificate is read again on each connection as the Agent generates a new
+// one when it restarts.
+func (t *Tagger) dial() (*grpc.ClientConn, error) {
+	certPool, err := security.FetchIPCCertPool()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.DialContext(
+		t.ctx,
+		t.address,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: certPool})),
+		grpc.WithPerRPCCredentials(tokenCredentials(util.GetAuthToken())),
+	)
+}
+
+func (t *Tagger) run() {
+	backoff := minBackoff
+	for {
+		snapshotReceived, err := t.stream()
+		if t.ctx.Err() != nil {
+			return
+		}
+		if snapshotReceived {
+			backoff = minBackoff
+		}
+		log.Warnf("Lost the remote tagger stream, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// stream consumes a StreamEntities stream until it fails, it returns whether
+// a snapshot was received to reset the reconnection backoff.
+func (t *Tagger) stream() (bool, error) {
+	conn, err := t.dial()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	stream, err := conn.NewStream(t.ctx, &taggerServiceDesc.Streams[0], streamEntitiesFullName, grpc.ForceCodec(jsonCodec{}))
+	if err != nil {
+		return false, err
+	}
+	if err := stream.SendMsg(&StreamEntitiesRequest{}); err != nil {
+		return false, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return false, err
+	}
+
+	snapshotReceived := false
+	for {
+		resp := &StreamEntitiesResponse{}
+		if err := stream.RecvMsg(resp); err != nil {
+			return snapshotReceived, err
+		}
+		if resp.Snapshot {
+			snapshotReceived = true
+			log.Debugf("Received a snapshot of %d entities from the remote tagger", len(resp.Events))
+		}
+		t.processResponse(resp)
+	}
+}
+
+// processResponse applies a batch of events, a snapshot replaces the whole store
+func (t *Tagger) processResponse(resp *StreamEntitiesResponse) {
+	t.Lock()
+	defer t.Unlock()
+
+	if resp.Snapshot {
+		t.store = make(map[string]tagger.Entity, len(resp.Events))
+	}
+	for _, event := range resp.Events {
+		switch event.EventType {
+		case tagger.EventTypeAdded, tagger.EventTypeModified:
+			t.store[event.Entity.ID] = event.Entity
+		case tagger.EventTypeDeleted:
+			delete(t.store, event.Entity.ID)
+		default:
+			log.Debugf("Unknown entity event type %q, skipping", event.EventType)
+		}
+	}
+}
+
+// Tag returns the last known tags of the entity
+func (t *Tagger) Tag(entity string, cardinality collectors.TagCardinality) ([]string, error) {
+	if entity == "" {
+		return nil, fmt.Errorf("empty entity ID")
+	}
+
+	t.RLock()
+	defer t.RUnlock()
+
+	e, found := t.store[entity]
+	if !found {
+		return nil, nil
+	}
+	return copyArray(entityTags(e, cardinality)), nil
+}
+
+// GetEntityHash returns the tags hash of the entity
+func (t *Tagger) GetEntityHash(entity string) string {
+	t.RLock()
+	defer t.RUnlock()
+	return t.store[entity].Hash
+}
+
+// IsKnownEntity returns true if the entity was streamed by the remote tagger
+func (t *Tagger) IsKnownEntity(entity string) bool {
+	t.RLock()
+	defer t.RUnlock()
+	_, found := t.store[entity]
+	return found
+}
+
+// List the content of the remote tagger
+func (t *Tagger) List(cardinality collectors.TagCardinality) response.TaggerListResponse {
+	t.RLock()
+	defer t.RUnlock()
+
+	r := response.TaggerListResponse{
+		Entities: make(map[string]response.TaggerListEntity, len(t.store)),
+	}
+	for id, e := range t.store {
+		r.Entities[id] = response.TaggerListEntity{
+			Tags:    copyArray(entityTags(e, cardinality)),
+			Sources: []string{"remote"},
+		}
+	}
+	return r
+}
+
+func entityTags(e tagger.Entity, cardinality collectors.TagCardinality) []string {
+	switch cardinality {
+	case collectors.HighCardinality:
+		return e.HighCardinalityTags
+	case collectors.OrchestratorCardinality:
+		return e.OrchestratorCardinalityTags
+	default:
+		return e.LowCardinalityTags
+	}
+}
+
+func copyArray(source []string) []string {
+	copied := make([]string, len(source))
+	copy(copied, source)
+	return copied
+}
+
+// tokenCredentials sends the IPC auth token with every call
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationMetadataKey: "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+func TestProcessResponse(t *testing.T) {
+	remoteTagger := NewTagger()
+
+	remoteTagger.processResponse(&StreamEntitiesResponse{
+		Snapshot: true,
+		Events: []tagger.EntityEvent{
+			{
+				EventType: tagger.EventTypeAdded,
+				Entity: tagger.Entity{
+					ID:                          "container_id://foo",
+					HighCardinalityTags:         []string{"low:1", "orch:1", "high:1"},
+					OrchestratorCardinalityTags: []string{"low:1", "orch:1"},
+					LowCardinalityTags:          []string{"low:1"},
+					Hash:                        "abc",
+				},
+			},
+			{
+				EventType: tagger.EventTypeAdded,
+				Entity:    tagger.Entity{ID: "container_id://bar", LowCardinalityTags: []string{"low:2"}},
+			},
+		},
+	})
+
+	tags, err := remoteTagger.Tag("container_id://foo", collectors.OrchestratorCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"low:1", "orch:1"}, tags)
+	assert.Equal(t, "abc", remoteTagger.GetEntityHash("container_id://foo"))
+	assert.True(t, remoteTagger.IsKnownEntity("container_id://bar"))
+	assert.False(t, remoteTagger.IsKnownEntity("container_id://baz"))
+
+	// deltas
+	remoteTagger.processResponse(&StreamEntitiesResponse{
+		Events: []tagger.EntityEvent{
+			{
+				EventType: tagger.EventTypeModified,
+				Entity:    tagger.Entity{ID: "container_id://foo", LowCardinalityTags: []string{"low:3"}},
+			},
+			{
+				EventType: tagger.EventTypeDeleted,
+				Entity:    tagger.Entity{ID: "container_id://bar"},
+			},
+		},
+	})
+
+	tags, err = remoteTagger.Tag("container_id://foo", collectors.LowCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"low:3"}, tags)
+	tags, err = remoteTagger.Tag("container_id://bar", collectors.LowCardinality)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	assert.False(t, remoteTagger.IsKnownEntity("container_id://bar"))
+
+	// a new snapshot after a reconnection replaces the store
+	remoteTagger.processResponse(&StreamEntitiesResponse{
+		Snapshot: true,
+		Events: []tagger.EntityEvent{
+			{
+				EventType: tagger.EventTypeAdded,
+				Entity:    tagger.Entity{ID: "container_id://baz", LowCardinalityTags: []string{"low:4"}},
+			},
+		},
+	})
+	assert.Len(t, remoteTagger.List(collectors.LowCardinality).Entities, 1)
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// StateVersion is the version of the format of the tagger state. It must be
+// bumped on incompatible changes, as states are loaded by other agent versions.
+const StateVersion = 1
+
+// State is a dump of the tagger store, with the tags of each entity by
+// source, and of the workloadmeta store feeding the collectors. It holds
+// everything needed to resolve the tags of the entities offline.
+type State struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// priorities of the collectors, to merge the tags of the sources as the
+	// agent that created the state
+	Priorities   map[string]collectors.CollectorPriority `json:"priorities"`
+	Entities     map[string]EntityState                  `json:"entities"`
+	Workloadmeta *workloadmeta.Dump                      `json:"workloadmeta,omitempty"`
+}
+
+// EntityState holds the tags of an entity, by source
+type EntityState struct {
+	Sources map[string]SourceTags `json:"sources"`
+	// set for the deleted entities kept until their deletion grace period expires
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SourceTags holds the tags of an entity reported by a source
+type SourceTags struct {
+	Low          []string `json:"low"`
+	Orchestrator []string `json:"orchestrator"`
+	High         []string `json:"high"`
+}
+
+// ExportState returns the state of the tagger store
+func (t *Tagger) ExportState() State {
+	state := State{
+		Version:    StateVersion,
+		CreatedAt:  time.Now(),
+		Priorities: make(map[string]collectors.CollectorPriority, len(collectors.CollectorPriorities)),
+		Entities:   make(map[string]EntityState),
+	}
+	for name, priority := range collectors.CollectorPriorities {
+		state.Priorities[name] = priority
+	}
+
+	pending := t.tagStore.pendingDeletions()
+
+	t.tagStore.storeMutex.RLock()
+	defer t.tagStore.storeMutex.RUnlock()
+	for entityID, et := range t.tagStore.store {
+		entity := EntityState{Sources: make(map[string]SourceTags)}
+
+		et.RLock()
+		for source, low := range et.lowCardTags {
+			entity.Sources[source] = SourceTags{
+				Low:          copyArray(low),
+				Orchestrator: copyArray(et.orchestratorCardTags[source]),
+				High:         copyArray(et.highCardTags[source]),
+			}
+		}
+		et.RUnlock()
+
+		if p, found := pending[entityID]; found {
+			deletedAt, expiresAt := p.deletedAt, p.expiresAt
+			entity.DeletedAt = &deletedAt
+			entity.ExpiresAt = &expiresAt
+		}
+		state.Entities[entityID] = entity
+	}
+
+	return state
+}
+
+// NewReplayTagger returns a tagger serving the entities of a state, without
+// running any collector. The priorities of the collectors unknown to this
+// build are taken from the state. The tags of the collectors of the catalog
+// implementing collectors.Replayer are computed again from the workloadmeta
+// entities of the state, replacing the dumped ones, to reproduce the tags
+// this build would compute.
+func NewReplayTagger(state State, catalog collectors.Catalog) (*Tagger, error) {
+	if state.Version != StateVersion {
+		return nil, fmt.Errorf("unsupported tagger state version %d, expected %d", state.Version, StateVersion)
+	}
+
+	for name, priority := range state.Priorities {
+		if _, found := collectors.CollectorPriorities[name]; !found {
+			collectors.CollectorPriorities[name] = priority
+		}
+	}
+
+	var replayed []*collectors.TagInfo
+	replayedSources := make(map[string]struct{})
+	if state.Workloadmeta != nil {
+		// loaded in a store that is not started, no collector of the store runs
+		store := workloadmeta.NewStore(nil)
+		if err := store.Load(*state.Workloadmeta); err != nil {
+			return nil, fmt.Errorf("unable to load the workloadmeta state: %v", err)
+		}
+		for _, name := range ReplayedSources(catalog) {
+			replayed = append(replayed, catalog[name]().(collectors.Replayer).Replay(store)...)
+			replayedSources[name] = struct{}{}
+		}
+	}
+
+	t := newTagger()
+	for entityID, entity := range state.Entities {
+		for source, tags := range entity.Sources {
+			if _, found := replayedSources[source]; found {
+				continue
+			}
+			err := t.tagStore.processTagInfo(&collectors.TagInfo{
+				Source:               source,
+				Entity:               entityID,
+				LowCardTags:          tags.Low,
+				OrchestratorCardTags: tags.Orchestrator,
+				HighCardTags:         tags.High,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if entity.DeletedAt != nil && entity.ExpiresAt != nil {
+			t.tagStore.toDelete[entityID] = pendingDeletion{
+				deletedAt: *entity.DeletedAt,
+				expiresAt: *entity.ExpiresAt,
+			}
+		}
+	}
+	for _, info := range replayed {
+		if err := t.tagStore.processTagInfo(info); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// ReplayedSources returns the sorted names of the collectors of the catalog
+// computing their tags from the workloadmeta entities of the states
+func ReplayedSources(catalog collectors.Catalog) []string {
+	var sources []string
+	for name, factory := range catalog {
+		if _, ok := factory().(collectors.Replayer); ok {
+			sources = append(sources, name)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// ExportState returns the state of the defaultTagger and of the global
+// workloadmeta store
+func ExportState() (State, error) {
+	state := defaultTagger.ExportState()
+
+	dump, err := workloadmeta.GetGlobalStore().Dump()
+	if err != nil {
+		return state, err
+	}
+	state.Workloadmeta = &dump
+
+	return state, nil
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+func TestStateReplay(t *testing.T) {
+	collectors.CollectorPriorities["state-runtime"] = collectors.NodeRuntime
+	collectors.CollectorPriorities["state-cluster"] = collectors.ClusterOrchestrator
+	defer func() {
+		delete(collectors.CollectorPriorities, "state-runtime")
+		delete(collectors.CollectorPriorities, "state-cluster")
+	}()
+
+	tagger := newTagger()
+	tagger.tagStore.processTagInfo(&collectors.TagInfo{
+		Source:       "state-runtime",
+		Entity:       "container_id://abc",
+		LowCardTags:  []string{"service:runtime", "image_name:redis"},
+		HighCardTags: []string{"container_name:redis-1"},
+	})
+	tagger.tagStore.processTagInfo(&collectors.TagInfo{
+		Source:               "state-cluster",
+		Entity:               "container_id://abc",
+		LowCardTags:          []string{"service:cluster"},
+		OrchestratorCardTags: []string{"pod_name:redis-1"},
+	})
+	tagger.tagStore.processTagInfo(&collectors.TagInfo{
+		Source:       "state-runtime",
+		Entity:       "container_id://abc",
+		DeleteEntity: true,
+	})
+	expected, err := tagger.Tag("container_id://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(tagger.ExportState())
+	require.NoError(t, err)
+
+	// the state is loaded by an agent that does not know the sources
+	delete(collectors.CollectorPriorities, "state-runtime")
+	delete(collectors.CollectorPriorities, "state-cluster")
+
+	var state State
+	require.NoError(t, json.Unmarshal(raw, &state))
+	replay, err := NewReplayTagger(state, collectors.Catalog{})
+	require.NoError(t, err)
+
+	tags, err := replay.Tag("container_id://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, tags)
+	assert.Contains(t, tags, "service:cluster")
+	assert.NotContains(t, tags, "service:runtime")
+
+	entity := replay.List(collectors.HighCardinality).Entities["container_id://abc"]
+	assert.NotNil(t, entity.ExpiresAt)
+
+	state.Version = StateVersion + 1
+	_, err = NewReplayTagger(state, collectors.Catalog{})
+	assert.Error(t, err)
+}
+
+// replayCollector tags the containers of the workloadmeta store with their name
+type replayCollector struct{}
+
+func (c *replayCollector) Detect(out chan<- []*collectors.TagInfo) (collectors.CollectionMode, error) {
+	return collectors.NoCollection, nil
+}
+
+func (c *replayCollector) Replay(store *workloadmeta.Store) []*collectors.TagInfo {
+	var infos []*collectors.TagInfo
+	for _, container := range store.ListContainers() {
+		infos = append(infos, &collectors.TagInfo{
+			Source:      "state-replay",
+			Entity:      containers.BuildTaggerEntityName(container.ID),
+			LowCardTags: []string{"container_name:" + container.Name},
+		})
+	}
+	return infos
+}
+
+func TestStateReplayWorkloadmeta(t *testing.T) {
+	collectors.CollectorPriorities["state-replay"] = collectors.NodeRuntime
+	defer delete(collectors.CollectorPriorities, "state-replay")
+	catalog := collectors.Catalog{
+		"state-replay": func() collectors.Collector { return &replayCollector{} },
+	}
+
+	raw, err := json.Marshal(workloadmeta.Container{
+		EntityID:   workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "abc"},
+		EntityMeta: workloadmeta.EntityMeta{Name: "redis"},
+	})
+	require.NoError(t, err)
+
+	state := State{
+		Version: StateVersion,
+		Entities: map[string]EntityState{
+			// tags dumped by an older build, computed again from the workloadmeta entities
+			"container_id://abc": {Sources: map[string]SourceTags{"state-replay": {Low: []string{"container_name:old"}}}},
+			// no workloadmeta entity, the dumped tags of the source are dropped
+			"container_id://def": {Sources: map[string]SourceTags{"state-replay": {Low: []string{"container_name:gone"}}}},
+		},
+		Workloadmeta: &workloadmeta.Dump{Entities: []workloadmeta.DumpEntity{{
+			Kind:   workloadmeta.KindContainer,
+			Source: workloadmeta.SourceDocker,
+			Entity: raw,
+		}}},
+	}
+
+	assert.Equal(t, []string{"state-replay"}, ReplayedSources(catalog))
+
+	replay, err := NewReplayTagger(state, catalog)
+	require.NoError(t, err)
+	tags, err := replay.Tag("container_id://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"container_name:redis"}, tags)
+	_, found := replay.List(collectors.HighCardinality).Entities["container_id://def"]
+	assert.False(t, found)
+
+	// the dumped tags are kept when the state has no workloadmeta entities
+	state.Workloadmeta = nil
+	replay, err = NewReplayTagger(state, catalog)
+	require.NoError(t, err)
+	tags, err = replay.Tag("container_id://abc", collectors.HighCardinality)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"container_name:old"}, tags)
+}
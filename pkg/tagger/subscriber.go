@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// EventType is the type of an entity event
+type EventType string
+
+const (
+	// EventTypeAdded is sent when an entity is seen for the first time
+	EventTypeAdded EventType = "added"
+	// EventTypeModified is sent when the tags of a known entity change
+	EventTypeModified EventType = "modified"
+	// EventTypeDeleted is sent when an entity is removed from the tagger
+	EventTypeDeleted EventType = "deleted"
+)
+
+// subscriberBufferSize is the number of event batches buffered for each
+// subscriber. A subscriber too slow to keep up is closed, it can subscribe
+// again to get a fresh snapshot.
+const subscriberBufferSize = 100
+
+// Entity holds the tags of an entity, at every cardinality
+type Entity struct {
+	ID                          string   `json:"id"`
+	HighCardinalityTags         []string `json:"high_cardinality_tags,omitempty"`
+	OrchestratorCardinalityTags []string `json:"orchestrator_cardinality_tags,omitempty"`
+	LowCardinalityTags          []string `json:"low_cardinality_tags,omitempty"`
+	Hash                        string   `json:"hash,omitempty"`
+}
+
+// EntityEvent notifies a change of the tags of an entity
+type EntityEvent struct {
+	EventType EventType `json:"type"`
+	Entity    Entity    `json:"entity"`
+}
+
+// subscriber holds the channels of the subscribers of a tagStore
+type subscriber struct {
+	sync.Mutex
+	channels map[chan []EntityEvent]struct{}
+}
+
+func newSubscriber() *subscriber {
+	return &subscriber{
+		channels: make(map[chan []EntityEvent]struct{}),
+	}
+}
+
+// subscribe registers a new channel and sends it the initial events
+func (s *subscriber) subscribe(snapshot []EntityEvent) chan []EntityEvent {
+	ch := make(chan []EntityEvent, subscriberBufferSize)
+	ch <- snapshot
+
+	s.Lock()
+	s.channels[ch] = struct{}{}
+	s.Unlock()
+
+	return ch
+}
+
+// unsubscribe closes the channel, it is a noop if it was already closed
+func (s *subscriber) unsubscribe(ch chan []EntityEvent) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, found := s.channels[ch]; found {
+		delete(s.channels, ch)
+		close(ch)
+	}
+}
+
+// notify sends the events to every subscriber without blocking
+func (s *subscriber) notify(events []EntityEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for ch := range s.channels {
+		select {
+		case ch <- events:
+		default:
+			log.Warnf("Tagger subscriber is too slow to process the entity events, closing its stream")
+			delete(s.channels, ch)
+			close(ch)
+		}
+	}
+}
+
+// hasSubscribers returns whether events should be built at all
+func (s *subscriber) hasSubscribers() bool {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.channels) > 0
+}
+
+// toEntity returns the tags of the entity at every cardinality
+func (e *entityTags) toEntity(id string) Entity {
+	high, _, hash := e.get(collectors.HighCardinality)
+	orchestrator, _, _ := e.get(collectors.OrchestratorCardinality)
+	low, _, _ := e.get(collectors.LowCardinality)
+
+	return Entity{
+		ID:                          id,
+		HighCardinalityTags:         copyArray(high),
+		OrchestratorCardinalityTags: copyArray(orchestrator),
+		LowCardinalityTags:          copyArray(low),
+		Hash:                        hash,
+	}
+}
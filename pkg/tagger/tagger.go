@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -38,6 +39,10 @@ type Tagger struct {
 	health      *health.Handle
 }
 
+// minPruneInterval is the lower bound of the prune interval, the deletion
+// grace periods can be 0
+const minPruneInterval = time.Second
+
 type collectorReply struct {
 	name     string
 	mode     collectors.CollectionMode
@@ -72,6 +77,9 @@ func (t *Tagger) Init(catalog collectors.Catalog) {
 	// Only register the health check when the tagger is started
 	t.health = health.Register("tagger")
 
+	t.pruneTicker.Stop()
+	t.pruneTicker = time.NewTicker(t.pruneInterval())
+
 	// Populate collector candidate list from catalog
 	// as we'll remove entries we need to copy the map
 	for name, factory := range catalog {
@@ -84,6 +92,20 @@ func (t *Tagger) Init(catalog collectors.Catalog) {
 	go t.pull()
 }
 
+// pruneInterval returns the interval between two prunes of the deleted
+// entities. Unless configured, it is the shortest deletion grace period so
+// that the entities are removed soon after their grace period expires.
+func (t *Tagger) pruneInterval() time.Duration {
+	interval := time.Duration(config.Datadog.GetInt("tagger.prune_interval")) * time.Second
+	if interval <= 0 {
+		interval = t.tagStore.minDeletionTTL()
+	}
+	if interval < minPruneInterval {
+		interval = minPruneInterval
+	}
+	return interval
+}
+
 func (t *Tagger) run() error {
 	for {
 		select {
@@ -220,6 +242,12 @@ func (t *Tagger) GetEntityHash(entity string) string {
 	return tagsHash
 }
 
+// IsKnownEntity returns true if a collector reported the entity, to tell
+// apart the unknown entities from the known ones without tags
+func (t *Tagger) IsKnownEntity(entity string) bool {
+	return t.tagStore.isKnown(entity)
+}
+
 // Tag returns tags for a given entity
 func (t *Tagger) Tag(entity string, cardinality collectors.TagCardinality) ([]string, error) {
 	if entity == "" {
@@ -278,12 +306,26 @@ IterCollectors:
 	return copyArray(computedTags), nil
 }
 
+// Subscribe returns a channel receiving the entity events: a snapshot of every
+// known entity first, then the changes as they happen. The channel is closed
+// if the subscriber does not keep up, it must then subscribe again.
+func (t *Tagger) Subscribe() chan []EntityEvent {
+	return t.tagStore.subscribe()
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe
+func (t *Tagger) Unsubscribe(ch chan []EntityEvent) {
+	t.tagStore.unsubscribe(ch)
+}
+
 // List the content of the tagger
 func (t *Tagger) List(cardinality collectors.TagCardinality) response.TaggerListResponse {
 	r := response.TaggerListResponse{
 		Entities: make(map[string]response.TaggerListEntity),
 	}
 
+	pending := t.tagStore.pendingDeletions()
+
 	t.tagStore.storeMutex.RLock()
 	defer t.tagStore.storeMutex.RUnlock()
 	for entityID, et := range t.tagStore.store {
@@ -291,6 +333,11 @@ func (t *Tagger) List(cardinality collectors.TagCardinality) response.TaggerList
 		tags, sources, _ := et.get(cardinality)
 		entity.Tags = copyArray(tags)
 		entity.Sources = copyArray(sources)
+		if p, found := pending[entityID]; found {
+			deletedAt, expiresAt := p.deletedAt, p.expiresAt
+			entity.DeletedAt = &deletedAt
+			entity.ExpiresAt = &expiresAt
+		}
 		r.Entities[entityID] = entity
 	}
 
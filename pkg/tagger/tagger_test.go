@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -305,3 +306,44 @@ func TestSafeCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"low1", "low2", "low3"}, tags2)
 }
+
+func TestCapCardinality(t *testing.T) {
+	defer func(max collectors.TagCardinality) { MaxCardinality = max }(MaxCardinality)
+
+	MaxCardinality = collectors.OrchestratorCardinality
+	assert.Equal(t, collectors.LowCardinality, CapCardinality(collectors.LowCardinality))
+	assert.Equal(t, collectors.OrchestratorCardinality, CapCardinality(collectors.OrchestratorCardinality))
+	assert.Equal(t, collectors.OrchestratorCardinality, CapCardinality(collectors.HighCardinality))
+
+	card, err := ParseCardinality("High")
+	assert.NoError(t, err)
+	assert.Equal(t, collectors.HighCardinality, card)
+	_, err = ParseCardinality("unknown")
+	assert.Error(t, err)
+}
+
+func TestPruneAfterDeletionGracePeriod(t *testing.T) {
+	tagger := newTagger()
+	tagger.tagStore.setDeletionTTLs(time.Hour, map[string]time.Duration{"docker": time.Second})
+	tagger.Init(collectors.Catalog{})
+	defer tagger.Stop()
+
+	// the prune interval follows the shortest grace period, not the 5 minutes of the other entities
+	assert.Equal(t, time.Second, tagger.pruneInterval())
+
+	tagger.infoIn <- []*collectors.TagInfo{
+		{Source: "source", Entity: "docker://foo", LowCardTags: []string{"tag"}},
+		{Source: "source", Entity: "docker://foo", DeleteEntity: true},
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tags, _, _ := tagger.tagStore.lookup("docker://foo", collectors.LowCardinality)
+		if len(tags) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			assert.FailNow(t, "the deleted entity was not pruned after its grace period")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
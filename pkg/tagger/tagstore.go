@@ -7,12 +7,23 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 )
 
+var (
+	tlmStoredEntities = telemetry.NewGauge("tagger", "stored_entities",
+		[]string{"prefix"}, "Number of entities in the tagger store, by entity prefix")
+	tlmPendingDeletion = telemetry.NewGauge("tagger", "pending_deletion_entities",
+		nil, "Number of deleted entities kept in the tagger store until their deletion grace period expires")
+	tlmPrunedEntities = telemetry.NewCounter("tagger", "pruned_entities",
+		[]string{"prefix"}, "Number of deleted entities evicted from the tagger store, by entity prefix")
+)
+
 // entityTags holds the tag information for a given entity
 type entityTags struct {
 	sync.RWMutex
@@ -25,6 +36,14 @@ type entityTags struct {
 	cachedOrchestrator   []string // Low + orchestrator (subslice of cachedAll)
 	cachedLow            []string // Sub-slice of cachedAll
 	tagsHash             string
+	known                bool // true once a source reported the entity, not only a cache miss
+}
+
+// pendingDeletion holds the deletion time of an entity, its tags are kept
+// until expiresAt to tag the late metrics of the entity
+type pendingDeletion struct {
+	deletedAt time.Time
+	expiresAt time.Time
 }
 
 // tagStore stores entity tags in memory and handles search and collation.
@@ -33,14 +52,64 @@ type tagStore struct {
 	storeMutex    sync.RWMutex
 	store         map[string]*entityTags
 	toDeleteMutex sync.RWMutex
-	toDelete      map[string]struct{} // set emulation
+	toDelete      map[string]pendingDeletion
+	subscriber    *subscriber
+
+	// deletion grace periods, by entity prefix, and for the other entities
+	deletionTTLs       map[string]time.Duration
+	defaultDeletionTTL time.Duration
+
+	// prefixes reported by the stored_entities gauge, to reset them once empty
+	reportedPrefixes map[string]struct{}
 }
 
 func newTagStore() *tagStore {
 	return &tagStore{
-		store:    make(map[string]*entityTags),
-		toDelete: make(map[string]struct{}),
+		store:            make(map[string]*entityTags),
+		toDelete:         make(map[string]pendingDeletion),
+		subscriber:       newSubscriber(),
+		reportedPrefixes: make(map[string]struct{}),
+	}
+}
+
+// setDeletionTTLs configures how long the tags of a deleted entity are kept,
+// by entity prefix (docker, kubernetes_pod_uid...) with a default for the
+// other prefixes
+func (s *tagStore) setDeletionTTLs(defaultTTL time.Duration, ttls map[string]time.Duration) {
+	s.toDeleteMutex.Lock()
+	defer s.toDeleteMutex.Unlock()
+	s.defaultDeletionTTL = defaultTTL
+	s.deletionTTLs = ttls
+}
+
+// deletionTTL returns the deletion grace period of the entity, the
+// toDeleteMutex must be held
+func (s *tagStore) deletionTTL(entity string) time.Duration {
+	if ttl, found := s.deletionTTLs[entityPrefix(entity)]; found {
+		return ttl
 	}
+	return s.defaultDeletionTTL
+}
+
+// minDeletionTTL returns the shortest deletion grace period configured
+func (s *tagStore) minDeletionTTL() time.Duration {
+	s.toDeleteMutex.RLock()
+	defer s.toDeleteMutex.RUnlock()
+	min := s.defaultDeletionTTL
+	for _, ttl := range s.deletionTTLs {
+		if ttl < min {
+			min = ttl
+		}
+	}
+	return min
+}
+
+// entityPrefix returns the prefix of the entity ID, ex: docker for docker://<id>
+func entityPrefix(entity string) string {
+	if i := strings.Index(entity, "://"); i >= 0 {
+		return entity[:i]
+	}
+	return "unknown"
 }
 
 func (s *tagStore) processTagInfo(info *collectors.TagInfo) error {
@@ -55,7 +124,14 @@ func (s *tagStore) processTagInfo(info *collectors.TagInfo) error {
 	}
 	if info.DeleteEntity {
 		s.toDeleteMutex.Lock()
-		s.toDelete[info.Entity] = struct{}{}
+		// several sources can report the deletion, the first one starts the grace period
+		if _, found := s.toDelete[info.Entity]; !found {
+			now := time.Now()
+			s.toDelete[info.Entity] = pendingDeletion{
+				deletedAt: now,
+				expiresAt: now.Add(s.deletionTTL(info.Entity)),
+			}
+		}
 		s.toDeleteMutex.Unlock()
 		return nil
 	}
@@ -74,9 +150,9 @@ func (s *tagStore) processTagInfo(info *collectors.TagInfo) error {
 	}
 
 	storedTags.Lock()
-	defer storedTags.Unlock()
 	_, found := storedTags.lowCardTags[info.Source]
 	if found && info.CacheMiss {
+		storedTags.Unlock()
 		// check if the source tags is already present for this entry
 		// Only check once since we always write all cardinality tag levels.
 		err := fmt.Errorf("try to overwrite an existing entry with and empty cache-miss entry, info.Source: %s, info.Entity: %s", info.Source, info.Entity)
@@ -87,10 +163,49 @@ func (s *tagStore) processTagInfo(info *collectors.TagInfo) error {
 	storedTags.orchestratorCardTags[info.Source] = info.OrchestratorCardTags
 	storedTags.highCardTags[info.Source] = info.HighCardTags
 	storedTags.cacheValid = false
+	if !info.CacheMiss {
+		storedTags.known = true
+	}
+	storedTags.Unlock()
+
+	if s.subscriber.hasSubscribers() {
+		eventType := EventTypeModified
+		if !exist {
+			eventType = EventTypeAdded
+		}
+		s.subscriber.notify([]EntityEvent{{
+			EventType: eventType,
+			Entity:    storedTags.toEntity(info.Entity),
+		}})
+	}
 
 	return nil
 }
 
+// subscribe returns a channel receiving the entity events. The first batch
+// is a snapshot of the store, with every entity as added. The store lock is
+// held while registering so that no event is lost between the snapshot and
+// the deltas.
+func (s *tagStore) subscribe() chan []EntityEvent {
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+
+	snapshot := make([]EntityEvent, 0, len(s.store))
+	for entity, et := range s.store {
+		snapshot = append(snapshot, EntityEvent{
+			EventType: EventTypeAdded,
+			Entity:    et.toEntity(entity),
+		})
+	}
+
+	return s.subscriber.subscribe(snapshot)
+}
+
+// unsubscribe stops sending events to the channel and closes it
+func (s *tagStore) unsubscribe(ch chan []EntityEvent) {
+	s.subscriber.unsubscribe(ch)
+}
+
 func computeTagsHash(tags []string) string {
 	hash := ""
 	if len(tags) > 0 {
@@ -106,30 +221,74 @@ func computeTagsHash(tags []string) string {
 	return hash
 }
 
-// prune will lock the store and delete tags for the entity previously
-// passed as delete. This is to be called regularly from the user class.
+// prune will lock the store and delete tags for the entities previously
+// passed as delete, once their deletion grace period has expired.
+// This is to be called regularly from the user class.
 func (s *tagStore) prune() error {
 	s.toDeleteMutex.Lock()
 	defer s.toDeleteMutex.Unlock()
 
-	if len(s.toDelete) == 0 {
-		return nil
-	}
-
 	s.storeMutex.Lock()
 	defer s.storeMutex.Unlock()
-	for entity := range s.toDelete {
+
+	now := time.Now()
+	events := make([]EntityEvent, 0, len(s.toDelete))
+	for entity, pending := range s.toDelete {
+		if now.Before(pending.expiresAt) {
+			continue
+		}
+		delete(s.toDelete, entity)
+		if _, found := s.store[entity]; !found {
+			continue
+		}
 		delete(s.store, entity)
+		tlmPrunedEntities.Inc(entityPrefix(entity))
+		events = append(events, EntityEvent{
+			EventType: EventTypeDeleted,
+			Entity:    Entity{ID: entity},
+		})
 	}
+	s.subscriber.notify(events)
 
-	log.Debugf("pruned %d removed entities, %d remaining", len(s.toDelete), len(s.store))
-
-	// Start fresh
-	s.toDelete = make(map[string]struct{})
+	if len(events) > 0 {
+		log.Debugf("pruned %d removed entities, %d remaining, %d pending deletion", len(events), len(s.store), len(s.toDelete))
+	}
 
+	s.updateTelemetry()
 	return nil
 }
 
+// updateTelemetry reports the size of the store, both locks must be held
+func (s *tagStore) updateTelemetry() {
+	byPrefix := make(map[string]int)
+	for entity := range s.store {
+		byPrefix[entityPrefix(entity)]++
+	}
+	for prefix := range s.reportedPrefixes {
+		if _, found := byPrefix[prefix]; !found {
+			tlmStoredEntities.Set(0, prefix)
+		}
+	}
+	for prefix, count := range byPrefix {
+		tlmStoredEntities.Set(float64(count), prefix)
+		s.reportedPrefixes[prefix] = struct{}{}
+	}
+	tlmPendingDeletion.Set(float64(len(s.toDelete)))
+}
+
+// pendingDeletions returns a copy of the entities waiting for their deletion
+// grace period to expire
+func (s *tagStore) pendingDeletions() map[string]pendingDeletion {
+	s.toDeleteMutex.RLock()
+	defer s.toDeleteMutex.RUnlock()
+
+	pending := make(map[string]pendingDeletion, len(s.toDelete))
+	for entity, p := range s.toDelete {
+		pending[entity] = p
+	}
+	return pending
+}
+
 // lookup gets tags from the store and returns them concatenated in a string
 // slice. It returns the source names in the second slice to allow the
 // client to trigger manual lookups on missing sources, the last string
@@ -145,6 +304,20 @@ func (s *tagStore) lookup(entity string, cardinality collectors.TagCardinality)
 	return storedTags.get(cardinality)
 }
 
+// isKnown returns true if a source reported the entity, an entity only stored
+// after cache misses is unknown.
+func (s *tagStore) isKnown(entity string) bool {
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+	storedTags, present := s.store[entity]
+	if !present {
+		return false
+	}
+	storedTags.RLock()
+	defer storedTags.RUnlock()
+	return storedTags.known
+}
+
 type tagPriority struct {
 	tag         string                       // full tag
 	priority    collectors.CollectorPriority // collector priority
@@ -8,6 +8,7 @@ package tagger
 import (
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -99,6 +100,23 @@ func (s *StoreTestSuite) TestLookupNotPresent() {
 	assert.Nil(s.T(), sources)
 }
 
+func (s *StoreTestSuite) TestIsKnown() {
+	assert.False(s.T(), s.store.isKnown("test"))
+
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source:    "source1",
+		Entity:    "test",
+		CacheMiss: true,
+	})
+	assert.False(s.T(), s.store.isKnown("test"))
+
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source: "source2",
+		Entity: "test",
+	})
+	assert.True(s.T(), s.store.isKnown("test"))
+}
+
 func (s *StoreTestSuite) TestPrune() {
 	s.store.toDeleteMutex.RLock()
 	assert.Len(s.T(), s.store.toDelete, 0)
@@ -183,6 +201,106 @@ func (s *StoreTestSuite) TestPrune() {
 
 }
 
+func (s *StoreTestSuite) TestSubscribe() {
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source:      "source1",
+		Entity:      "test1",
+		LowCardTags: []string{"low1"},
+	})
+
+	ch := s.store.subscribe()
+	defer s.store.unsubscribe(ch)
+
+	// snapshot
+	events := <-ch
+	s.Require().Len(events, 1)
+	s.Equal(EventTypeAdded, events[0].EventType)
+	s.Equal("test1", events[0].Entity.ID)
+	s.Equal([]string{"low1"}, events[0].Entity.LowCardinalityTags)
+
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source:       "source1",
+		Entity:       "test1",
+		LowCardTags:  []string{"low2"},
+		HighCardTags: []string{"high2"},
+	})
+	events = <-ch
+	s.Require().Len(events, 1)
+	s.Equal(EventTypeModified, events[0].EventType)
+	s.Equal([]string{"low2"}, events[0].Entity.LowCardinalityTags)
+	s.Equal([]string{"low2", "high2"}, events[0].Entity.HighCardinalityTags)
+
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source:      "source1",
+		Entity:      "test2",
+		LowCardTags: []string{"low3"},
+	})
+	events = <-ch
+	s.Require().Len(events, 1)
+	s.Equal(EventTypeAdded, events[0].EventType)
+	s.Equal("test2", events[0].Entity.ID)
+
+	s.store.processTagInfo(&collectors.TagInfo{
+		Source:       "source1",
+		Entity:       "test1",
+		DeleteEntity: true,
+	})
+	s.store.prune()
+	events = <-ch
+	s.Require().Len(events, 1)
+	s.Equal(EventTypeDeleted, events[0].EventType)
+	s.Equal("test1", events[0].Entity.ID)
+}
+
+func (s *StoreTestSuite) TestSlowSubscriberIsClosed() {
+	ch := s.store.subscribe()
+	<-ch
+
+	for i := 0; i <= subscriberBufferSize; i++ {
+		s.store.processTagInfo(&collectors.TagInfo{
+			Source:      "source1",
+			Entity:      "test",
+			LowCardTags: []string{"low"},
+		})
+	}
+
+	for range ch {
+		// drain until the channel is closed
+	}
+	s.False(s.store.subscriber.hasSubscribers())
+	// unsubscribing a closed channel is a noop
+	s.store.unsubscribe(ch)
+}
+
+func (s *StoreTestSuite) TestPruneDeletionGracePeriod() {
+	s.store.setDeletionTTLs(0, map[string]time.Duration{"docker": time.Hour})
+
+	for _, entity := range []string{"docker://foo", "kubernetes_pod_uid://bar"} {
+		s.store.processTagInfo(&collectors.TagInfo{
+			Source:      "source",
+			Entity:      entity,
+			LowCardTags: []string{"tag"},
+		})
+		s.store.processTagInfo(&collectors.TagInfo{
+			Source:       "source",
+			Entity:       entity,
+			DeleteEntity: true,
+		})
+	}
+
+	s.store.prune()
+
+	// the docker entity is kept until its grace period expires
+	tags, _, _ := s.store.lookup("docker://foo", collectors.LowCardinality)
+	assert.Equal(s.T(), []string{"tag"}, tags)
+	tags, _, _ = s.store.lookup("kubernetes_pod_uid://bar", collectors.LowCardinality)
+	assert.Nil(s.T(), tags)
+
+	pending := s.store.pendingDeletions()
+	assert.Len(s.T(), pending, 1)
+	assert.Contains(s.T(), pending, "docker://foo")
+}
+
 func TestStoreSuite(t *testing.T) {
 	suite.Run(t, &StoreTestSuite{})
 }
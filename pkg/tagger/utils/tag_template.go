@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/tmplvar"
+)
+
+// TagTemplate maps a label or an annotation to a tag. It is configured as
+// `<TAG_KEY>` to use the raw value, or `<TAG_KEY>:<VALUE_TEMPLATE>` to
+// extract the tag value. The value template supports the variables:
+//   - %%value%%: the raw value
+//   - %%json_<path>%%: the field at the dotted path of a JSON value, ex: %%json_owner.team%%
+//     or %%json_containers.0.image%%
+//   - %%regex_<expression>%%: the first capture group, or the whole match, of
+//     a regular expression, ex: %%regex_sha=([0-9a-f]+)%%
+//
+// The tag key supports %%label%%, replaced by the name of the label or annotation.
+type TagTemplate struct {
+	key       string
+	valueTmpl string
+	vars      []tmplvar.TemplateVar
+	regexps   map[string]*regexp.Regexp
+}
+
+// NewTagTemplate parses a tag template
+func NewTagTemplate(tmpl string) (*TagTemplate, error) {
+	t := &TagTemplate{key: tmpl}
+
+	// the tag key cannot contain ':', the rest of the template is the value template
+	if i := strings.Index(tmpl, ":"); i >= 0 {
+		t.key = tmpl[:i]
+		t.valueTmpl = tmpl[i+1:]
+		t.vars = tmplvar.ParseString(t.valueTmpl)
+		t.regexps = make(map[string]*regexp.Regexp)
+		for _, v := range t.vars {
+			switch string(v.Name) {
+			case "value", "json":
+			case "regex":
+				re, err := regexp.Compile(string(v.Key))
+				if err != nil {
+					return nil, fmt.Errorf("invalid regular expression in tag template %q: %s", tmpl, err)
+				}
+				t.regexps[string(v.Key)] = re
+			default:
+				return nil, fmt.Errorf("unknown variable %s in tag template %q", v.Raw, tmpl)
+			}
+		}
+	}
+	if t.key == "" {
+		return nil, fmt.Errorf("empty tag key in tag template %q", tmpl)
+	}
+	return t, nil
+}
+
+// ParseTagTemplates parses a mapping of names to tag templates, indexed by
+// lowercased name. Invalid templates are returned as errors and skipped.
+func ParseTagTemplates(mapping map[string]string) (map[string]*TagTemplate, []error) {
+	templates := make(map[string]*TagTemplate, len(mapping))
+	var errs []error
+	for name, tmpl := range mapping {
+		t, err := NewTagTemplate(tmpl)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		templates[strings.ToLower(name)] = t
+	}
+	return templates, errs
+}
+
+// ExtractTags resolves the templates matching the values, indexed by
+// lowercased name, and returns the resulting `key:value` tags
+func ExtractTags(templates map[string]*TagTemplate, values map[string]string) []string {
+	var tags []string
+	for name, value := range values {
+		tmpl, found := templates[strings.ToLower(name)]
+		if !found {
+			continue
+		}
+		if key, v, ok := tmpl.Resolve(name, value); ok {
+			tags = append(tags, key+":"+v)
+		}
+	}
+	return tags
+}
+
+// Resolve returns the tag key and value for the given label or annotation.
+// It returns false when the value cannot be extracted.
+func (t *TagTemplate) Resolve(name, value string) (string, string, bool) {
+	key := strings.Replace(t.key, "%%label%%", name, -1)
+	if t.valueTmpl == "" {
+		return key, value, true
+	}
+
+	resolved := t.valueTmpl
+	for _, v := range t.vars {
+		var extracted string
+		var ok bool
+		switch string(v.Name) {
+		case "value":
+			extracted, ok = value, true
+		case "json":
+			extracted, ok = extractJSONPath(value, string(v.Key))
+		case "regex":
+			extracted, ok = extractRegex(t.regexps[string(v.Key)], value)
+		}
+		if !ok {
+			return "", "", false
+		}
+		resolved = strings.Replace(resolved, string(v.Raw), extracted, 1)
+	}
+	if resolved == "" {
+		return "", "", false
+	}
+	return key, resolved, true
+}
+
+func extractRegex(re *regexp.Regexp, value string) (string, bool) {
+	if re == nil {
+		return "", false
+	}
+	match := re.FindStringSubmatch(value)
+	switch {
+	case match == nil:
+		return "", false
+	case len(match) > 1:
+		return match[1], true
+	default:
+		return match[0], true
+	}
+}
+
+// extractJSONPath returns the scalar at the dotted path of a JSON document,
+// numeric path elements are array indexes
+func extractJSONPath(value, path string) (string, bool) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return "", false
+	}
+
+	path = strings.TrimPrefix(path, "$.")
+	if path != "" {
+		for _, elem := range strings.Split(path, ".") {
+			switch node := doc.(type) {
+			case map[string]interface{}:
+				var found bool
+				if doc, found = node[elem]; !found {
+					return "", false
+				}
+			case []interface{}:
+				i, err := strconv.Atoi(elem)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", false
+				}
+				doc = node[i]
+			default:
+				return "", false
+			}
+		}
+	}
+
+	switch v := doc.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagTemplateResolve(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl      string
+		value     string
+		wantKey   string
+		wantValue string
+		wantOK    bool
+	}{
+		{"team", "backend", "team", "backend", true},
+		{"+git_commit", "ea38b55f", "+git_commit", "ea38b55f", true},
+		{"annotation_%%label%%", "foo", "annotation_owner", "foo", true},
+		{"team:%%json_owner.team%%", `{"owner": {"team": "backend"}}`, "team", "backend", true},
+		{"image:%%json_$.containers.1.image%%", `{"containers": [{"image": "a"}, {"image": "b"}]}`, "image", "b", true},
+		{"replicas:%%json_replicas%%", `{"replicas": 3}`, "replicas", "3", true},
+		{"team:%%json_owner.team%%", `{"owner": "backend"}`, "", "", false},
+		{"team:%%json_owner.team%%", `not json`, "", "", false},
+		{"commit:%%regex_sha=([0-9a-f]+)%%", "branch=main,sha=ea38b55f", "commit", "ea38b55f", true},
+		{"commit:%%regex_[0-9a-f]{8}%%", "sha ea38b55f", "commit", "ea38b55f", true},
+		{"commit:%%regex_sha=([0-9a-f]+)%%", "branch=main", "", "", false},
+		{"env:prod-%%value%%", "eu", "env", "prod-eu", true},
+	} {
+		t.Run(tc.tmpl, func(t *testing.T) {
+			tmpl, err := NewTagTemplate(tc.tmpl)
+			require.NoError(t, err)
+			key, value, ok := tmpl.Resolve("owner", tc.value)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantKey, key)
+			assert.Equal(t, tc.wantValue, value)
+		})
+	}
+}
+
+func TestTagTemplateErrors(t *testing.T) {
+	_, err := NewTagTemplate("")
+	assert.Error(t, err)
+	_, err = NewTagTemplate("team:%%unknown%%")
+	assert.Error(t, err)
+	_, err = NewTagTemplate("commit:%%regex_([0-9%%")
+	assert.Error(t, err)
+
+	templates, errs := ParseTagTemplates(map[string]string{
+		"valid":   "team",
+		"invalid": "team:%%unknown%%",
+	})
+	assert.Len(t, errs, 1)
+	assert.Len(t, templates, 1)
+	assert.Contains(t, templates, "valid")
+}
+
+func TestExtractTags(t *testing.T) {
+	templates, errs := ParseTagTemplates(map[string]string{
+		"team":  "team",
+		"owner": "owner:%%json_name%%",
+	})
+	require.Empty(t, errs)
+
+	tags := ExtractTags(templates, map[string]string{
+		"Team":    "backend",
+		"owner":   `{"name": "john"}`,
+		"ignored": "foo",
+	})
+	assert.ElementsMatch(t, []string{"team:backend", "owner:john"}, tags)
+}
@@ -109,6 +109,14 @@ func (a *Agent) Run() {
 	a.loop()
 }
 
+// SetSamplingRates updates the extra sample rate and the max TPS of the trace samplers,
+// e.g. when they're tuned by the remote configuration
+func (a *Agent) SetSamplingRates(extraRate float64, maxTPS float64) {
+	for _, s := range []*Sampler{a.ScoreSampler, a.ErrorsScoreSampler, a.PrioritySampler} {
+		s.UpdateRates(extraRate, maxTPS)
+	}
+}
+
 func (a *Agent) work() {
 	for {
 		select {
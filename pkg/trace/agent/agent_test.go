@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/api"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/event"
@@ -342,6 +343,32 @@ func TestSampling(t *testing.T) {
 	}
 }
 
+func TestSetSamplingRates(t *testing.T) {
+	cfg := config.New()
+	cfg.ExtraSampleRate = 1
+	cfg.MaxTPS = 10
+	mockConfig := coreconfig.Mock()
+
+	// the configured rates are kept until they're set remotely
+	extraRate, maxTPS := remoteSamplingRates(cfg)
+	assert.Equal(t, 1.0, extraRate)
+	assert.Equal(t, 10.0, maxTPS)
+	mockConfig.SetWithSource("apm_config.max_traces_per_second", 2.0, coreconfig.SourceRemoteConfig)
+	extraRate, maxTPS = remoteSamplingRates(cfg)
+	assert.Equal(t, 1.0, extraRate)
+	assert.Equal(t, 2.0, maxTPS)
+
+	agnt := &Agent{
+		ScoreSampler:       NewScoreSampler(cfg),
+		ErrorsScoreSampler: NewErrorsSampler(cfg),
+		PrioritySampler:    NewPrioritySampler(cfg, sampler.NewDynamicConfig("none")),
+	}
+	agnt.SetSamplingRates(extraRate, maxTPS)
+	for _, s := range []*Sampler{agnt.ScoreSampler, agnt.ErrorsScoreSampler, agnt.PrioritySampler} {
+		assert.Equal(t, 2.0, s.engine.GetState().(sampler.InternalState).MaxTPS)
+	}
+}
+
 func TestEventProcessorFromConf(t *testing.T) {
 	if _, ok := os.LookupEnv("INTEGRATION"); !ok {
 		t.Skip("set INTEGRATION environment variable to run")
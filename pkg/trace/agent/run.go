@@ -16,7 +16,9 @@ import (
 
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/remote"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/flags"
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
@@ -111,10 +113,32 @@ func Run(ctx context.Context) {
 
 	rand.Seed(time.Now().UTC().UnixNano())
 
-	tagger.Init()
+	if coreconfig.Datadog.GetBool("remote_tagger.enabled") {
+		remoteTagger := remote.NewTagger()
+		if err := remoteTagger.Start(); err != nil {
+			osutil.Exitf("cannot start the remote tagger: %v", err)
+		}
+		tagger.InitRemote(remoteTagger)
+	} else {
+		tagger.Init()
+	}
 	defer tagger.Stop()
 
 	agnt := NewAgent(ctx, cfg)
+
+	// the sampling rates can be tuned by the remote configuration
+	if coreconfig.Datadog.GetBool("remote_configuration.enabled") {
+		client, err := remoteconfig.NewClient(cfg.Hostname, func(*remoteconfig.Configuration) {
+			agnt.SetSamplingRates(remoteSamplingRates(cfg))
+		})
+		if err != nil {
+			log.Errorf("Could not start the remote configuration client: %v", err)
+		} else {
+			client.Start()
+			defer client.Stop()
+		}
+	}
+
 	log.Infof("Trace agent running on host %s", cfg.Hostname)
 	agnt.Run()
 
@@ -135,3 +159,16 @@ func Run(ctx context.Context) {
 		f.Close()
 	}
 }
+
+// remoteSamplingRates returns the extra sample rate and the max TPS set by the remote
+// configuration, or the configured ones when they're not set remotely
+func remoteSamplingRates(cfg *config.AgentConfig) (extraRate float64, maxTPS float64) {
+	extraRate, maxTPS = cfg.ExtraSampleRate, cfg.MaxTPS
+	if coreconfig.Datadog.GetSource("apm_config.extra_sample_rate") == coreconfig.SourceRemoteConfig {
+		extraRate = coreconfig.Datadog.GetFloat64("apm_config.extra_sample_rate")
+	}
+	if coreconfig.Datadog.GetSource("apm_config.max_traces_per_second") == coreconfig.SourceRemoteConfig {
+		maxTPS = coreconfig.Datadog.GetFloat64("apm_config.max_traces_per_second")
+	}
+	return extraRate, maxTPS
+}
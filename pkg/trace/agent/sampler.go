@@ -81,6 +81,12 @@ func (s *Sampler) Add(t ProcessedTrace) (sampled bool, rate float64) {
 	return sampled, rate
 }
 
+// UpdateRates updates the extra sample rate and the max TPS of the sampler
+func (s *Sampler) UpdateRates(extraRate float64, maxTPS float64) {
+	s.engine.UpdateExtraRate(extraRate)
+	s.engine.UpdateMaxTPS(maxTPS)
+}
+
 // Stop stops the sampler
 func (s *Sampler) Stop() {
 	s.exit <- struct{}{}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// activeTraceTTL is the duration during which the last trace received from a
+// container is considered active.
+const activeTraceTTL = 10 * time.Second
+
+// TraceContext identifies the last trace received from a container, it is
+// injected in the logs of the container by the logs agent.
+type TraceContext struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id"`
+}
+
+// activeTrace is a TraceContext along with the time it was received.
+type activeTrace struct {
+	TraceContext
+	receivedAt time.Time
+}
+
+// activeTraces maintains the context of the last trace received from each container.
+type activeTraces struct {
+	mu        sync.Mutex
+	byID      map[string]activeTrace
+	ttl       time.Duration
+	timeNow   func() time.Time
+	lastPurge time.Time
+
+	// authToken is the IPC auth token of the agent, the active traces are
+	// only served to the requests holding it
+	authToken      string
+	fetchAuthToken func() (string, error)
+}
+
+func newActiveTraces() *activeTraces {
+	return &activeTraces{
+		byID:           make(map[string]activeTrace),
+		ttl:            activeTraceTTL,
+		timeNow:        time.Now,
+		fetchAuthToken: security.FetchAuthToken,
+	}
+}
+
+// record keeps the root span of the last trace of traces as the active trace of the container.
+func (a *activeTraces) record(containerID string, traces pb.Traces) {
+	if a == nil || containerID == "" || len(traces) == 0 {
+		return
+	}
+	root := traceutil.GetRoot(traces[len(traces)-1])
+	if root == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.timeNow()
+	a.byID[containerID] = activeTrace{
+		TraceContext: TraceContext{
+			TraceID: strconv.FormatUint(root.TraceID, 10),
+			SpanID:  strconv.FormatUint(root.SpanID, 10),
+		},
+		receivedAt: now,
+	}
+	// the containers that don't send traces anymore are purged regularly
+	if now.Sub(a.lastPurge) > a.ttl {
+		a.purge(now)
+		a.lastPurge = now
+	}
+}
+
+// contexts returns the context of the active trace of each container.
+func (a *activeTraces) contexts() map[string]TraceContext {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.purge(a.timeNow())
+	contexts := make(map[string]TraceContext, len(a.byID))
+	for containerID, trace := range a.byID {
+		contexts[containerID] = trace.TraceContext
+	}
+	return contexts
+}
+
+// purge removes the traces that are not active anymore.
+func (a *activeTraces) purge(now time.Time) {
+	for containerID, trace := range a.byID {
+		if now.Sub(trace.receivedAt) > a.ttl {
+			delete(a.byID, containerID)
+		}
+	}
+}
+
+// authorized returns true if the request holds the IPC auth token of the agent,
+// the token is read once it is created by the agent.
+func (a *activeTraces) authorized(req *http.Request) bool {
+	a.mu.Lock()
+	if a.authToken == "" {
+		token, err := a.fetchAuthToken()
+		if err != nil {
+			a.mu.Unlock()
+			log.Debugf("Could not read the agent auth token: %v", err)
+			return false
+		}
+		a.authToken = token
+	}
+	expected := []byte("Bearer " + a.authToken)
+	a.mu.Unlock()
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), expected) == 1
+}
+
+// handleActiveTraces returns the context of the active trace of each container to the
+// requests holding the IPC auth token of the agent, the endpoint is not found when the
+// active traces are not maintained.
+func (r *HTTPReceiver) handleActiveTraces(w http.ResponseWriter, req *http.Request) {
+	if r.activeTraces == nil {
+		http.NotFound(w, req)
+		return
+	}
+	if !r.activeTraces.authorized(req) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Datadog Agent"`)
+		http.Error(w, "invalid session token", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.activeTraces.contexts()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
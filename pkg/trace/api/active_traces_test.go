@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+func TestActiveTraces(t *testing.T) {
+	now := time.Now()
+	active := newActiveTraces()
+	active.timeNow = func() time.Time { return now }
+
+	active.record("abc", pb.Traces{
+		{{TraceID: 1, SpanID: 10}},
+		{{TraceID: 2, SpanID: 21, ParentID: 20}, {TraceID: 2, SpanID: 20}},
+	})
+	active.record("", pb.Traces{{{TraceID: 3, SpanID: 30}}})
+	assert.Equal(t, map[string]TraceContext{"abc": {TraceID: "2", SpanID: "20"}}, active.contexts())
+
+	now = now.Add(activeTraceTTL / 2)
+	active.record("def", pb.Traces{{{TraceID: 4, SpanID: 40}}})
+	assert.Len(t, active.contexts(), 2)
+
+	// the traces expire when the containers don't send traces anymore
+	now = now.Add(activeTraceTTL + time.Second)
+	assert.Empty(t, active.contexts())
+
+	var disabled *activeTraces
+	disabled.record("abc", pb.Traces{{{TraceID: 1, SpanID: 10}}})
+}
+
+func TestHandleActiveTraces(t *testing.T) {
+	conf := newTestReceiverConfig()
+	receiver := newTestReceiverFromConfig(conf)
+	rec := httptest.NewRecorder()
+	receiver.handleActiveTraces(rec, httptest.NewRequest("GET", "/v0.1/active_traces", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	conf.ActiveTraces = true
+	receiver = newTestReceiverFromConfig(conf)
+	receiver.activeTraces.fetchAuthToken = func() (string, error) { return "", errors.New("not created yet") }
+	receiver.activeTraces.record("abc", pb.Traces{{{TraceID: 1, SpanID: 10}}})
+
+	// the active traces are only served with the auth token of the agent
+	req := httptest.NewRequest("GET", "/v0.1/active_traces", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec = httptest.NewRecorder()
+	receiver.handleActiveTraces(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	receiver.activeTraces.fetchAuthToken = func() (string, error) { return "token", nil }
+	req.Header.Set("Authorization", "Bearer invalid")
+	rec = httptest.NewRecorder()
+	receiver.handleActiveTraces(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	receiver.handleActiveTraces(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var contexts map[string]TraceContext
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&contexts))
+	assert.Equal(t, map[string]TraceContext{"abc": {TraceID: "1", SpanID: "10"}}, contexts)
+}
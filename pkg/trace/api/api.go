@@ -40,6 +40,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -76,6 +77,9 @@ type HTTPReceiver struct {
 	Stats       *info.ReceiverStats
 	RateLimiter *rateLimiter
 
+	// activeTraces is nil when the context of the active traces is not maintained
+	activeTraces *activeTraces
+
 	out     chan *Trace
 	conf    *config.AgentConfig
 	dynConf *sampler.DynamicConfig
@@ -95,10 +99,15 @@ func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, o
 	if config.HasFeature("429") {
 		rateLimiterResponse = http.StatusTooManyRequests
 	}
+	var active *activeTraces
+	if conf.ActiveTraces {
+		active = newActiveTraces()
+	}
 	return &HTTPReceiver{
-		Stats:       info.NewReceiverStats(),
-		RateLimiter: newRateLimiter(),
-		out:         out,
+		Stats:        info.NewReceiverStats(),
+		RateLimiter:  newRateLimiter(),
+		activeTraces: active,
+		out:          out,
 
 		conf:    conf,
 		dynConf: dynConf,
@@ -127,6 +136,7 @@ func (r *HTTPReceiver) Start() {
 	mux.HandleFunc("/v0.3/services", r.handleWithVersion(v03, r.handleServices))
 	mux.HandleFunc("/v0.4/traces", r.handleWithVersion(v04, r.handleTraces))
 	mux.HandleFunc("/v0.4/services", r.handleWithVersion(v04, r.handleServices))
+	mux.HandleFunc("/v0.1/active_traces", r.handleActiveTraces)
 
 	timeout := 5 * time.Second
 	if r.conf.ReceiverTimeout > 0 {
@@ -138,6 +148,7 @@ func (r *HTTPReceiver) Start() {
 		WriteTimeout: timeout,
 		ErrorLog:     stdlog.New(httpLogger, "http.Server: ", 0),
 		Handler:      mux,
+		ConnContext:  connContext,
 	}
 
 	addr := fmt.Sprintf("%s:%d", r.conf.ReceiverHost, r.conf.ReceiverPort)
@@ -391,7 +402,8 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 			r.wg.Done()
 			watchdog.LogOnPanic()
 		}()
-		containerID := req.Header.Get(headerContainerID)
+		containerID := containerIDFromRequest(req)
+		r.activeTraces.record(containerID, traces)
 		r.processTraces(ts, containerID, traces)
 	}()
 }
@@ -591,6 +603,28 @@ func tracesFromSpans(spans []pb.Span) pb.Traces {
 	return traces
 }
 
+// ucredKey is the context key of the PID of the clients connected to the unix socket
+type ucredKey struct{}
+
+// containerIDFromRequest returns the container ID sent by the tracer or, on the
+// unix socket, the one of the client process resolved by the tagger
+func containerIDFromRequest(req *http.Request) string {
+	if containerID := req.Header.Get(headerContainerID); containerID != "" {
+		return containerID
+	}
+	pid, ok := req.Context().Value(ucredKey{}).(int32)
+	if !ok {
+		return ""
+	}
+	entity, err := tagger.EntityForPID(pid)
+	if err != nil {
+		log.Tracef("Getting container ID for PID %d: %v", pid, err)
+		return ""
+	}
+	_, containerID := containers.SplitEntityName(entity)
+	return containerID
+}
+
 // getContainerTag returns container and orchestrator tags belonging to containerID. If containerID
 // is empty or no tags are found, an empty string is returned.
 func getContainerTags(containerID string) string {
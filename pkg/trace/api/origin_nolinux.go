@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package api
+
+import (
+	"context"
+	"net"
+)
+
+// connContext is a noop, the peer credentials are only available on Linux
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	return ctx
+}
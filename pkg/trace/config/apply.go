@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -194,6 +195,10 @@ func (c *AgentConfig) applyDatadogConfig() error {
 	if config.Datadog.IsSet("apm_config.receiver_socket") {
 		c.ReceiverSocket = config.Datadog.GetString("apm_config.receiver_socket")
 	}
+	if config.Datadog.IsSet("logs_config.inject_trace_context") {
+		// the logs agent queries the active traces to inject their context in the logs
+		c.ActiveTraces = config.Datadog.GetBool("logs_config.inject_trace_context")
+	}
 	if config.Datadog.IsSet("apm_config.connection_limit") {
 		c.ConnectionLimit = config.Datadog.GetInt("apm_config.connection_limit")
 	}
@@ -380,6 +385,22 @@ func compileReplaceRules(rules []*ReplaceRule) error {
 	return nil
 }
 
+// applyFIPS sends the traces to the local FIPS proxy, or checks that they're encrypted
+// by the FIPS-validated crypto provider, when the FIPS mode is enabled.
+func (c *AgentConfig) applyFIPS() error {
+	if err := fips.Check(fips.TraceWriter); err != nil {
+		return err
+	}
+	if proxyURL, proxied := fips.ProxyURL(fips.TraceWriter); proxied {
+		if len(c.Endpoints) > 1 {
+			log.Errorf("The additional endpoints of APM can't be reached through the FIPS proxy, dropping them")
+		}
+		// the proxy is local, it's reached directly
+		c.Endpoints = []*Endpoint{{Host: proxyURL, APIKey: c.Endpoints[0].APIKey, NoProxy: true}}
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
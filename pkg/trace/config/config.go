@@ -20,6 +20,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -76,6 +77,9 @@ type AgentConfig struct {
 	ReceiverSocket  string // if not empty, UDS will be enabled on unix://<receiver_socket>
 	ConnectionLimit int    // for rate-limiting, how many unique connections to allow in a lease period (30s)
 	ReceiverTimeout int
+	// ActiveTraces enables the endpoint returning the context of the last trace received from
+	// each container, queried by the logs agent to correlate the logs of the containers.
+	ActiveTraces bool
 
 	// Writers
 	StatsWriter *WriterConfig
@@ -199,8 +203,10 @@ func (c *AgentConfig) acquireHostname() error {
 // HTTPClient returns a new http.Client to be used for outgoing connections to the
 // Datadog API.
 func (c *AgentConfig) HTTPClient() *http.Client {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.SkipSSLValidation}
+	fips.ApplyTLSConfig(tlsConfig)
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: c.SkipSSLValidation},
+		TLSClientConfig: tlsConfig,
 		// below field values are from http.DefaultTransport (go1.12)
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -240,6 +246,9 @@ func Load(path string) (*AgentConfig, error) {
 		return cfg, err
 	}
 	cfg.applyDatadogConfig()
+	if err := cfg.applyFIPS(); err != nil {
+		return cfg, err
+	}
 	return cfg, cfg.validate()
 }
 
@@ -132,6 +132,25 @@ func TestNoAPMConfig(t *testing.T) {
 	assert.Equal("DEBUG", c.LogLevel)
 }
 
+func TestFIPSProxy(t *testing.T) {
+	defer cleanConfig()()
+	assert := assert.New(t)
+
+	c, err := prepareConfig("./testdata/full.yaml")
+	assert.NoError(err)
+	assert.NoError(c.applyDatadogConfig())
+	apiKey := c.Endpoints[0].APIKey
+
+	config.Datadog.Set("fips.enabled", true)
+	config.Datadog.Set("fips.local_address", "localhost")
+	config.Datadog.Set("fips.port_range_start", 9803)
+	assert.NoError(c.applyFIPS())
+	assert.Len(c.Endpoints, 1)
+	assert.Equal("http://localhost:9806", c.Endpoints[0].Host)
+	assert.Equal(apiKey, c.Endpoints[0].APIKey)
+	assert.True(c.Endpoints[0].NoProxy)
+}
+
 func TestFullYamlConfig(t *testing.T) {
 	defer cleanConfig()()
 	origcfg := config.Datadog
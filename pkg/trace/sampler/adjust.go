@@ -16,7 +16,7 @@ func (s *Sampler) AdjustScoring() {
 	offset := s.signatureScoreOffset.Load()
 	cardinality := float64(s.Backend.GetCardinality())
 
-	newOffset, newSlope := adjustCoefficients(currentTPS, totalTPS, s.maxTPS, offset, cardinality)
+	newOffset, newSlope := adjustCoefficients(currentTPS, totalTPS, s.maxTPS.Load(), offset, cardinality)
 
 	s.SetSignatureCoefficients(newOffset, newSlope)
 }
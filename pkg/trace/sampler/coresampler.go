@@ -53,6 +53,10 @@ type Engine interface {
 	GetState() interface{}
 	// GetType returns the type of the sampler.
 	GetType() EngineType
+	// UpdateExtraRate updates the extra sample rate.
+	UpdateExtraRate(extraRate float64)
+	// UpdateMaxTPS updates the max TPS limit.
+	UpdateMaxTPS(maxTPS float64)
 }
 
 // Sampler is the main component of the sampling logic
@@ -61,9 +65,9 @@ type Sampler struct {
 	Backend Backend
 
 	// Extra sampling rate to combine to the existing sampling
-	extraRate float64
+	extraRate *atomic.Float64
 	// Maximum limit to the total number of traces per second to sample
-	maxTPS float64
+	maxTPS *atomic.Float64
 	// rateThresholdTo1 is the value above which all computed sampling rates will be set to 1
 	rateThresholdTo1 float64
 
@@ -82,8 +86,8 @@ type Sampler struct {
 func newSampler(extraRate float64, maxTPS float64) *Sampler {
 	s := &Sampler{
 		Backend:              NewMemoryBackend(defaultDecayPeriod, defaultDecayFactor),
-		extraRate:            extraRate,
-		maxTPS:               maxTPS,
+		extraRate:            atomic.NewFloat(extraRate),
+		maxTPS:               atomic.NewFloat(maxTPS),
 		rateThresholdTo1:     defaultSamplingRateThresholdTo1,
 		signatureScoreOffset: atomic.NewFloat(0),
 		signatureScoreSlope:  atomic.NewFloat(0),
@@ -106,12 +110,12 @@ func (s *Sampler) SetSignatureCoefficients(offset float64, slope float64) {
 
 // UpdateExtraRate updates the extra sample rate
 func (s *Sampler) UpdateExtraRate(extraRate float64) {
-	s.extraRate = extraRate
+	s.extraRate.Store(extraRate)
 }
 
 // UpdateMaxTPS updates the max TPS limit
 func (s *Sampler) UpdateMaxTPS(maxTPS float64) {
-	s.maxTPS = maxTPS
+	s.maxTPS.Store(maxTPS)
 }
 
 // Run runs and block on the Sampler main loop
@@ -146,17 +150,17 @@ func (s *Sampler) RunAdjustScoring() {
 
 // GetSampleRate returns the sample rate to apply to a trace.
 func (s *Sampler) GetSampleRate(trace pb.Trace, root *pb.Span, signature Signature) float64 {
-	return s.loadRate(s.GetSignatureSampleRate(signature) * s.extraRate)
+	return s.loadRate(s.GetSignatureSampleRate(signature) * s.extraRate.Load())
 }
 
 // GetMaxTPSSampleRate returns an extra sample rate to apply if we are above maxTPS.
 func (s *Sampler) GetMaxTPSSampleRate() float64 {
 	// When above maxTPS, apply an additional sample rate to statistically respect the limit
 	maxTPSrate := 1.0
-	if s.maxTPS > 0 {
+	if maxTPS := s.maxTPS.Load(); maxTPS > 0 {
 		currentTPS := s.Backend.GetUpperSampledScore()
-		if currentTPS > s.maxTPS {
-			maxTPSrate = s.maxTPS / currentTPS
+		if currentTPS > maxTPS {
+			maxTPSrate = maxTPS / currentTPS
 		}
 	}
 
@@ -143,6 +143,16 @@ func (s *PriorityEngine) GetState() interface{} {
 	return s.Sampler.GetState()
 }
 
+// UpdateExtraRate updates the extra sample rate of the underlying sampler.
+func (s *PriorityEngine) UpdateExtraRate(extraRate float64) {
+	s.Sampler.UpdateExtraRate(extraRate)
+}
+
+// UpdateMaxTPS updates the max TPS limit of the underlying sampler.
+func (s *PriorityEngine) UpdateMaxTPS(maxTPS float64) {
+	s.Sampler.UpdateMaxTPS(maxTPS)
+}
+
 // ratesByService returns all rates by service, this information is useful for
 // agents to pick the right service rate.
 func (s *PriorityEngine) ratesByService() map[ServiceSignature]float64 {
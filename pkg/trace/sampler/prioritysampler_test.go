@@ -188,7 +188,7 @@ func TestMaxTPSByService(t *testing.T) {
 	s.Sampler.rateThresholdTo1 = 1
 	for _, tc := range testCases {
 		t.Logf("testing maxTPS=%0.1f tps=%0.1f", tc.maxTPS, tc.tps)
-		s.Sampler.maxTPS = tc.maxTPS
+		s.Sampler.UpdateMaxTPS(tc.maxTPS)
 		periodSeconds := defaultDecayPeriod.Seconds()
 		tracesPerPeriod := tc.tps * periodSeconds
 		// Set signature score offset high enough not to kick in during the test.
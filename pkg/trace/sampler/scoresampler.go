@@ -98,6 +98,16 @@ func (s *ScoreEngine) GetState() interface{} {
 	return s.Sampler.GetState()
 }
 
+// UpdateExtraRate updates the extra sample rate of the underlying sampler.
+func (s *ScoreEngine) UpdateExtraRate(extraRate float64) {
+	s.Sampler.UpdateExtraRate(extraRate)
+}
+
+// UpdateMaxTPS updates the max TPS limit of the underlying sampler.
+func (s *ScoreEngine) UpdateMaxTPS(maxTPS float64) {
+	s.Sampler.UpdateMaxTPS(maxTPS)
+}
+
 // GetType returns the type of the sampler
 func (s *ScoreEngine) GetType() EngineType {
 	return s.engineType
@@ -52,9 +52,9 @@ func TestExtraSampleRate(t *testing.T) {
 	sRate := s.Sampler.GetSampleRate(trace, root, signature)
 
 	// Then turn on the extra sample rate, then ensure it affects both existing and new signatures
-	s.Sampler.extraRate = 0.33
+	s.Sampler.UpdateExtraRate(0.33)
 
-	assert.Equal(s.Sampler.GetSampleRate(trace, root, signature), s.Sampler.extraRate*sRate)
+	assert.Equal(s.Sampler.GetSampleRate(trace, root, signature), s.Sampler.extraRate.Load()*sRate)
 }
 
 func TestErrorSampleThresholdTo1(t *testing.T) {
@@ -87,7 +87,7 @@ func TestMaxTPS(t *testing.T) {
 	initPeriods := 20
 	periods := 50
 
-	s.Sampler.maxTPS = maxTPS
+	s.Sampler.UpdateMaxTPS(maxTPS)
 	periodSeconds := defaultDecayPeriod.Seconds()
 	tracesPerPeriod := tps * periodSeconds
 	// Set signature score offset high enough not to kick in during the test.
@@ -112,12 +112,12 @@ func TestMaxTPS(t *testing.T) {
 	assert.InEpsilon(tps, s.Sampler.Backend.GetSampledScore(), 0.01)
 
 	// We should have kept less traces per second than maxTPS
-	assert.True(s.Sampler.maxTPS >= float64(sampledCount)/(float64(periods)*periodSeconds))
+	assert.True(s.Sampler.maxTPS.Load() >= float64(sampledCount)/(float64(periods)*periodSeconds))
 
 	// We should have a throughput of sampled traces around maxTPS
 	// Check for 1% epsilon, but the precision also depends on the backend imprecision (error factor = decayFactor).
 	// Combine error rates with L1-norm instead of L2-norm by laziness, still good enough for tests.
-	assert.InEpsilon(s.Sampler.maxTPS, float64(sampledCount)/(float64(periods)*periodSeconds),
+	assert.InEpsilon(s.Sampler.maxTPS.Load(), float64(sampledCount)/(float64(periods)*periodSeconds),
 		0.01+defaultDecayFactor-1)
 }
 
@@ -23,6 +23,6 @@ func (s *Sampler) GetState() InternalState {
 		Cardinality: s.Backend.GetCardinality(),
 		InTPS:       s.Backend.GetTotalScore(),
 		OutTPS:      s.Backend.GetSampledScore(),
-		MaxTPS:      s.maxTPS,
+		MaxTPS:      s.maxTPS.Load(),
 	}
 }
@@ -45,3 +45,13 @@ func (e *MockEngine) GetState() interface{} {
 func (e *MockEngine) GetType() sampler.EngineType {
 	return sampler.NormalScoreEngineType
 }
+
+// UpdateExtraRate mocks Engine.UpdateExtraRate()
+func (e *MockEngine) UpdateExtraRate(extraRate float64) {
+	return
+}
+
+// UpdateMaxTPS mocks Engine.UpdateMaxTPS()
+func (e *MockEngine) UpdateMaxTPS(maxTPS float64) {
+	return
+}
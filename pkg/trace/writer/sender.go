@@ -7,6 +7,7 @@ package writer
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
+	"github.com/DataDog/datadog-agent/pkg/util/egress"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -36,18 +38,20 @@ func newSenders(cfg *config.AgentConfig, r eventRecorder, path string, climit, q
 	// spread out the the maximum connection limit (climit) between senders
 	maxConns := math.Max(1, float64(climit/len(cfg.Endpoints)))
 	senders := make([]*sender, len(cfg.Endpoints))
+	limiter := egress.NewLimiter(egress.APM)
 	for i, endpoint := range cfg.Endpoints {
 		url, err := url.Parse(endpoint.Host + path)
 		if err != nil {
 			osutil.Exitf("Invalid host endpoint: %q", endpoint.Host)
 		}
 		senders[i] = newSender(&senderConfig{
-			client:    client,
-			maxConns:  int(maxConns),
-			maxQueued: qsize,
-			url:       url,
-			apiKey:    endpoint.APIKey,
-			recorder:  r,
+			client:        client,
+			maxConns:      int(maxConns),
+			maxQueued:     qsize,
+			url:           url,
+			apiKey:        endpoint.APIKey,
+			recorder:      r,
+			egressLimiter: limiter,
 		})
 	}
 	return senders
@@ -125,6 +129,8 @@ type senderConfig struct {
 	// recorder specifies the eventRecorder to use when reporting events occurring
 	// in the sender.
 	recorder eventRecorder
+	// egressLimiter limits the bytes sent per second, it is nil when the egress rate is not limited.
+	egressLimiter *egress.Limiter
 }
 
 // sender is responsible for sending payloads to a given URL. It uses a size-limited
@@ -228,6 +234,8 @@ func (s *sender) sendPayload(p *payload) {
 		log.Errorf("http.Request: %s", err)
 		return
 	}
+	// the payload waits for its share of the egress bandwidth when the egress rate is limited
+	s.cfg.egressLimiter.Wait(context.Background(), p.body.Len())
 	start := time.Now()
 	err = s.do(req)
 	stats := &eventData{
@@ -6,6 +6,7 @@
 package azure
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -59,6 +60,33 @@ func GetClusterName() (string, error) {
 	return clusterName, nil
 }
 
+// GetInstanceMetadataTags returns host tags derived from the instance metadata:
+// the VM priority (Regular, Low or Spot) and the scale set the VM belongs to, if any.
+func GetInstanceMetadataTags() ([]string, error) {
+	res, err := getResponse(metadataURL + "/metadata/instance/compute?api-version=2020-06-01")
+	if err != nil {
+		return nil, fmt.Errorf("unable to query metadata endpoint: %s", err)
+	}
+
+	var compute struct {
+		Priority       string `json:"priority"`
+		VMScaleSetName string `json:"vmScaleSetName"`
+	}
+	if err := json.Unmarshal([]byte(res), &compute); err != nil {
+		return nil, fmt.Errorf("unable to parse compute metadata: %s", err)
+	}
+
+	tags := []string{}
+	if compute.Priority != "" {
+		tags = append(tags, "vm_priority:"+strings.ToLower(compute.Priority))
+	}
+	if compute.VMScaleSetName != "" {
+		tags = append(tags, "scale_set:"+compute.VMScaleSetName)
+	}
+
+	return tags, nil
+}
+
 func getResponseWithMaxLength(endpoint string, maxLength int) (string, error) {
 	result, err := getResponse(endpoint)
 	if err != nil {
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/events"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/lager"
 
@@ -42,9 +43,12 @@ type BBSCache struct {
 	pollAttempts       int
 	pollSuccesses      int
 	// maps Desired LRPs' AppGUID to list of ActualLRPs (IOW this is list of running containers per app)
-	actualLRPs  map[string][]ActualLRP
-	desiredLRPs []DesiredLRP
-	lastUpdated time.Time
+	actualLRPs map[string][]ActualLRP
+	// maps ActualLRPs' instance GUID, which is also the handle of their Garden container, to the ActualLRP;
+	// it's rebuilt on each poll and updated from the BBS instance events in between
+	actualLRPsByInstance map[string]ActualLRP
+	desiredLRPs          []DesiredLRP
+	lastUpdated          time.Time
 }
 
 var (
@@ -130,6 +134,14 @@ func (bc *BBSCache) GetActualLRPsFor(appGUID string) []ActualLRP {
 	return []ActualLRP{}
 }
 
+// GetActualLRPByInstanceGUID returns the ActualLRP with the given instance GUID, or Garden container handle
+func (bc *BBSCache) GetActualLRPByInstanceGUID(instanceGUID string) (ActualLRP, bool) {
+	bc.RLock()
+	defer bc.RUnlock()
+	lrp, ok := bc.actualLRPsByInstance[instanceGUID]
+	return lrp, ok
+}
+
 // GetDesiredLRPs returns slice of all DesiredLRP objects
 func (bc *BBSCache) GetDesiredLRPs() []DesiredLRP {
 	bc.RLock()
@@ -146,6 +158,7 @@ func (bc *BBSCache) GetAllLRPs() (map[string][]ActualLRP, []DesiredLRP) {
 
 func (bc *BBSCache) start() {
 	bc.readData()
+	go bc.watchInstanceEvents()
 	dataRefreshTicker := time.NewTicker(bc.pollInterval)
 	for {
 		select {
@@ -193,6 +206,12 @@ func (bc *BBSCache) readData() {
 	defer bc.Unlock()
 	log.Debug("Data from BBS API read successfully, refreshing the cache")
 	bc.actualLRPs = actualLRPs
+	bc.actualLRPsByInstance = map[string]ActualLRP{}
+	for _, lrpList := range actualLRPs {
+		for _, lrp := range lrpList {
+			bc.indexActualLRP(lrp)
+		}
+	}
 	bc.desiredLRPs = desiredLRPs
 	bc.lastUpdated = time.Now()
 	bc.pollSuccesses++
@@ -228,3 +247,85 @@ func (bc *BBSCache) readDesiredLRPs() ([]DesiredLRP, error) {
 	log.Debugf("Successfully read %d Desired LRPs", len(desiredLRPsBBS))
 	return desiredLRPs, nil
 }
+
+// watchInstanceEvents updates the index of the ActualLRPs by instance GUID from the
+// BBS instance events, until the context is cancelled
+func (bc *BBSCache) watchInstanceEvents() {
+	for {
+		eventSource, err := bc.bbsAPIClient.SubscribeToInstanceEvents(bc.bbsAPIClientLogger)
+		if err != nil {
+			log.Warnf("Failed subscribing to the BBS instance events, retrying in %s: %s", bc.pollInterval, err)
+		} else {
+			bc.consumeInstanceEvents(eventSource)
+		}
+
+		select {
+		case <-bc.cancelContext.Done():
+			return
+		case <-time.After(bc.pollInterval):
+		}
+	}
+}
+
+// consumeInstanceEvents handles the events of the source until it fails or the
+// context is cancelled
+func (bc *BBSCache) consumeInstanceEvents(eventSource events.EventSource) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-bc.cancelContext.Done():
+			eventSource.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		event, err := eventSource.Next()
+		if err == events.ErrUnrecognizedEventType {
+			continue
+		}
+		if err != nil {
+			log.Debugf("Stopped reading the BBS instance events: %s", err)
+			eventSource.Close()
+			return
+		}
+		bc.handleInstanceEvent(event)
+	}
+}
+
+// handleInstanceEvent updates the index of the ActualLRPs by instance GUID
+func (bc *BBSCache) handleInstanceEvent(event models.Event) {
+	bc.Lock()
+	defer bc.Unlock()
+	if bc.actualLRPsByInstance == nil {
+		bc.actualLRPsByInstance = map[string]ActualLRP{}
+	}
+	switch e := event.(type) {
+	case *models.ActualLRPInstanceCreatedEvent:
+		if e.ActualLrp != nil {
+			bc.indexActualLRP(ActualLRPFromBBSModel(e.ActualLrp))
+		}
+	case *models.ActualLRPInstanceChangedEvent:
+		bbsLRP := &models.ActualLRP{
+			ActualLRPKey:         e.ActualLRPKey,
+			ActualLRPInstanceKey: e.ActualLRPInstanceKey,
+		}
+		if e.After != nil {
+			bbsLRP.ActualLRPNetInfo = e.After.ActualLRPNetInfo
+			bbsLRP.State = e.After.State
+		}
+		bc.indexActualLRP(ActualLRPFromBBSModel(bbsLRP))
+	case *models.ActualLRPInstanceRemovedEvent:
+		if e.ActualLrp != nil {
+			delete(bc.actualLRPsByInstance, e.ActualLrp.InstanceGuid)
+		}
+	}
+}
+
+// indexActualLRP adds an ActualLRP to the index by instance GUID, the caller must hold the lock
+func (bc *BBSCache) indexActualLRP(lrp ActualLRP) {
+	if lrp.InstanceGUID != "" {
+		bc.actualLRPsByInstance[lrp.InstanceGUID] = lrp
+	}
+}
@@ -9,6 +9,7 @@ package cloudfoundry
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -64,6 +65,39 @@ func TestBBSCache_GetAllLRPs(t *testing.T) {
 	assert.EqualValues(t, map[string][]ActualLRP{"012345678901234567890123456789012345": {ExpectedA1, ExpectedA2}}, a)
 }
 
+func TestBBSCache_GetActualLRPByInstanceGUID(t *testing.T) {
+	lrp, found := c.GetActualLRPByInstanceGUID("instance-guid-1")
+	assert.True(t, found)
+	assert.EqualValues(t, ExpectedA1, lrp)
+	_, found = c.GetActualLRPByInstanceGUID("unknown")
+	assert.False(t, found)
+}
+
+func TestBBSCache_HandleInstanceEvent(t *testing.T) {
+	bc := &BBSCache{}
+	bc.handleInstanceEvent(&models.ActualLRPInstanceCreatedEvent{ActualLrp: &BBSModelA1})
+	lrp, found := bc.GetActualLRPByInstanceGUID("instance-guid-1")
+	assert.True(t, found)
+	assert.EqualValues(t, ExpectedA1, lrp)
+
+	bc.handleInstanceEvent(&models.ActualLRPInstanceChangedEvent{
+		ActualLRPKey:         BBSModelA1.ActualLRPKey,
+		ActualLRPInstanceKey: BBSModelA1.ActualLRPInstanceKey,
+		After: &models.ActualLRPInfo{
+			ActualLRPNetInfo: BBSModelA1.ActualLRPNetInfo,
+			State:            "RUNNING",
+		},
+	})
+	lrp, found = bc.GetActualLRPByInstanceGUID("instance-guid-1")
+	assert.True(t, found)
+	assert.Equal(t, "RUNNING", lrp.State)
+	assert.Equal(t, "1.2.3.4", lrp.ContainerIP)
+
+	bc.handleInstanceEvent(&models.ActualLRPInstanceRemovedEvent{ActualLrp: &BBSModelA1})
+	_, found = bc.GetActualLRPByInstanceGUID("instance-guid-1")
+	assert.False(t, found)
+}
+
 // These methods ensure we implement the bbs.Client API, but are in fact unused by our functionality
 func (t testBBSClient) DesireTask(logger lager.Logger, guid, domain string, def *models.TaskDefinition) error {
 	panic("implement me")
@@ -150,7 +184,7 @@ func (t testBBSClient) SubscribeToEvents(logger lager.Logger) (events.EventSourc
 }
 
 func (t testBBSClient) SubscribeToInstanceEvents(logger lager.Logger) (events.EventSource, error) {
-	panic("implement me")
+	return nil, fmt.Errorf("the instance events aren't supported by the test client")
 }
 
 func (t testBBSClient) SubscribeToTaskEvents(logger lager.Logger) (events.EventSource, error) {
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// BOSHInstance holds the metadata of the BOSH instance the agent is deployed on
+type BOSHInstance struct {
+	Deployment    string `json:"deployment"`
+	InstanceGroup string `json:"name"`
+	ID            string `json:"id"`
+	Index         int    `json:"index"`
+	AZ            string `json:"az"`
+}
+
+// GetBOSHInstance reads the metadata of the BOSH instance from the spec file
+// written by the BOSH agent, usually /var/vcap/bosh/spec.json
+func GetBOSHInstance(specPath string) (BOSHInstance, error) {
+	var instance BOSHInstance
+	content, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		return instance, err
+	}
+	if err := json.Unmarshal(content, &instance); err != nil {
+		return instance, fmt.Errorf("could not parse the BOSH spec %s: %v", specPath, err)
+	}
+	if instance.Deployment == "" || instance.InstanceGroup == "" || instance.ID == "" {
+		return instance, fmt.Errorf("the BOSH spec %s doesn't have a deployment, an instance group name and an instance ID", specPath)
+	}
+	return instance, nil
+}
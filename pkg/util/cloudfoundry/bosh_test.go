@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBOSHInstance(t *testing.T) {
+	spec, err := ioutil.TempFile("", "spec.json")
+	require.NoError(t, err)
+	defer os.Remove(spec.Name())
+	_, err = spec.WriteString(`{"deployment":"datadog","name":"clc-runner","id":"6d0b6e5c-1f2a","index":1,"az":"z2","job":{"name":"datadog-agent"}}`)
+	require.NoError(t, err)
+	spec.Close()
+
+	instance, err := GetBOSHInstance(spec.Name())
+	require.NoError(t, err)
+	assert.Equal(t, BOSHInstance{
+		Deployment:    "datadog",
+		InstanceGroup: "clc-runner",
+		ID:            "6d0b6e5c-1f2a",
+		Index:         1,
+		AZ:            "z2",
+	}, instance)
+
+	require.NoError(t, ioutil.WriteFile(spec.Name(), []byte(`{"deployment":"datadog"}`), 0644))
+	_, err = GetBOSHInstance(spec.Name())
+	assert.Error(t, err)
+
+	_, err = GetBOSHInstance("/does/not/exist")
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package cloudfoundry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// CCCacheI is an interface for a structure that caches and automatically refreshes data from Cloud Foundry CC API
+// it's useful mostly to be able to mock CCCache during unit tests
+type CCCacheI interface {
+	LastUpdated() time.Time
+	GetApp(appGUID string) (CFApp, bool)
+	GetSpace(spaceGUID string) (CFSpace, bool)
+	GetOrg(orgGUID string) (CFOrg, bool)
+}
+
+// CCCache is a simple structure that caches and automatically refreshes data from Cloud Foundry CC API
+type CCCache struct {
+	sync.RWMutex
+	cancelContext context.Context
+	configured    bool
+	ccAPIClient   CCClientI
+	pollInterval  time.Duration
+	pollAttempts  int
+	pollSuccesses int
+	apps          map[string]CFApp
+	spaces        map[string]CFSpace
+	orgs          map[string]CFOrg
+	lastUpdated   time.Time
+}
+
+var (
+	globalCCCache     *CCCache = &CCCache{}
+	globalCCCacheLock sync.Mutex
+)
+
+// ConfigureGlobalCCCache configures the global instance of CCCache from provided config
+func ConfigureGlobalCCCache(ctx context.Context, ccURL, clientID, clientSecret string, skipSSLValidation bool, pollInterval time.Duration, testing CCClientI) (*CCCache, error) {
+	globalCCCacheLock.Lock()
+	defer globalCCCacheLock.Unlock()
+
+	if globalCCCache.configured {
+		return globalCCCache, nil
+	}
+
+	if testing != nil {
+		globalCCCache.ccAPIClient = testing
+	} else {
+		if clientID == "" || clientSecret == "" {
+			return nil, fmt.Errorf("the client ID and the client secret of the CC API must be set")
+		}
+		globalCCCache.ccAPIClient = newCCAPIClient(ccURL, clientID, clientSecret, skipSSLValidation)
+	}
+
+	// the cache is only configured once the settings are validated
+	globalCCCache.configured = true
+	globalCCCache.pollInterval = pollInterval
+	globalCCCache.lastUpdated = time.Time{} // zero time
+	globalCCCache.cancelContext = ctx
+
+	go globalCCCache.start()
+
+	return globalCCCache, nil
+}
+
+// GetGlobalCCCache returns the global instance of CCCache (or error if the instance is not configured yet)
+func GetGlobalCCCache() (*CCCache, error) {
+	globalCCCacheLock.Lock()
+	defer globalCCCacheLock.Unlock()
+	if !globalCCCache.configured {
+		return nil, fmt.Errorf("global CC Cache not configured")
+	}
+	return globalCCCache, nil
+}
+
+// LastUpdated returns the last time the cache was refreshed
+func (ccc *CCCache) LastUpdated() time.Time {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	return ccc.lastUpdated
+}
+
+// GetPollAttempts returns the number of times the CC API was polled
+func (ccc *CCCache) GetPollAttempts() int {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	return ccc.pollAttempts
+}
+
+// GetPollSuccesses returns the number of times the cache was refreshed
+func (ccc *CCCache) GetPollSuccesses() int {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	return ccc.pollSuccesses
+}
+
+// GetApp returns the application with the given GUID
+func (ccc *CCCache) GetApp(appGUID string) (CFApp, bool) {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	app, ok := ccc.apps[appGUID]
+	return app, ok
+}
+
+// GetSpace returns the space with the given GUID
+func (ccc *CCCache) GetSpace(spaceGUID string) (CFSpace, bool) {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	space, ok := ccc.spaces[spaceGUID]
+	return space, ok
+}
+
+// GetOrg returns the organization with the given GUID
+func (ccc *CCCache) GetOrg(orgGUID string) (CFOrg, bool) {
+	ccc.RLock()
+	defer ccc.RUnlock()
+	org, ok := ccc.orgs[orgGUID]
+	return org, ok
+}
+
+func (ccc *CCCache) start() {
+	ccc.readData()
+	dataRefreshTicker := time.NewTicker(ccc.pollInterval)
+	for {
+		select {
+		case <-dataRefreshTicker.C:
+			ccc.readData()
+		case <-ccc.cancelContext.Done():
+			dataRefreshTicker.Stop()
+			return
+		}
+	}
+}
+
+// readData lists all the pages of the apps, spaces and orgs, the cache is only
+// refreshed when all of them were read
+func (ccc *CCCache) readData() {
+	log.Debug("Reading data from CC API")
+	ccc.Lock()
+	ccc.pollAttempts++
+	ccc.Unlock()
+	var wg sync.WaitGroup
+	var apps []CFApp
+	var spaces []CFSpace
+	var orgs []CFOrg
+	var errApps, errSpaces, errOrgs error
+
+	wg.Add(3)
+
+	go func() {
+		apps, errApps = ccc.ccAPIClient.ListApps()
+		wg.Done()
+	}()
+	go func() {
+		spaces, errSpaces = ccc.ccAPIClient.ListSpaces()
+		wg.Done()
+	}()
+	go func() {
+		orgs, errOrgs = ccc.ccAPIClient.ListOrgs()
+		wg.Done()
+	}()
+	wg.Wait()
+	if errApps != nil {
+		log.Errorf("Failed reading apps from CC API: %s", errApps.Error())
+		return
+	}
+	if errSpaces != nil {
+		log.Errorf("Failed reading spaces from CC API: %s", errSpaces.Error())
+		return
+	}
+	if errOrgs != nil {
+		log.Errorf("Failed reading orgs from CC API: %s", errOrgs.Error())
+		return
+	}
+
+	appsByGUID := make(map[string]CFApp, len(apps))
+	for _, app := range apps {
+		appsByGUID[app.GUID] = app
+	}
+	spacesByGUID := make(map[string]CFSpace, len(spaces))
+	for _, space := range spaces {
+		spacesByGUID[space.GUID] = space
+	}
+	orgsByGUID := make(map[string]CFOrg, len(orgs))
+	for _, org := range orgs {
+		orgsByGUID[org.GUID] = org
+	}
+
+	// put new values in cache
+	ccc.Lock()
+	defer ccc.Unlock()
+	log.Debugf("Read %d apps, %d spaces and %d orgs from CC API, refreshing the cache", len(apps), len(spaces), len(orgs))
+	ccc.apps = appsByGUID
+	ccc.spaces = spacesByGUID
+	ccc.orgs = orgsByGUID
+	ccc.lastUpdated = time.Now()
+	ccc.pollSuccesses++
+}
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package cloudfoundry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCCClient struct{}
+
+func (t testCCClient) ListApps() ([]CFApp, error) {
+	return []CFApp{{
+		GUID:        "012345678901234567890123456789012345",
+		Name:        "my-app",
+		SpaceGUID:   "space-guid",
+		Labels:      map[string]string{"team": "frontend"},
+		Annotations: map[string]string{"tags.datadoghq.com/env": "prod", "description": "not a tag"},
+	}}, nil
+}
+
+func (t testCCClient) ListSpaces() ([]CFSpace, error) {
+	return []CFSpace{{GUID: "space-guid", Name: "my-space", OrgGUID: "org-guid"}}, nil
+}
+
+func (t testCCClient) ListOrgs() ([]CFOrg, error) {
+	return []CFOrg{{GUID: "org-guid", Name: "my-org"}}, nil
+}
+
+func TestCCCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the cache isn't configured when the settings are invalid
+	_, err := ConfigureGlobalCCCache(ctx, "url", "", "", false, time.Second, nil)
+	assert.Error(t, err)
+	_, err = GetGlobalCCCache()
+	assert.Error(t, err)
+
+	cc, err := ConfigureGlobalCCCache(ctx, "url", "", "", false, time.Second, &testCCClient{})
+	require.NoError(t, err)
+	for i := 0; i < 10 && cc.GetPollSuccesses() == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NotZero(t, cc.GetPollSuccesses())
+	global, err := GetGlobalCCCache()
+	require.NoError(t, err)
+	assert.Equal(t, cc, global)
+
+	app, found := cc.GetApp("012345678901234567890123456789012345")
+	require.True(t, found)
+	assert.Equal(t, "my-app", app.Name)
+	space, found := cc.GetSpace(app.SpaceGUID)
+	require.True(t, found)
+	assert.Equal(t, "my-space", space.Name)
+	org, found := cc.GetOrg(space.OrgGUID)
+	require.True(t, found)
+	assert.Equal(t, "my-org", org.Name)
+	_, found = cc.GetApp("unknown")
+	assert.False(t, found)
+
+	assert.Equal(t, map[string][]string{
+		"012345678901234567890123456789012345": {
+			"app_guid:012345678901234567890123456789012345",
+			"app_name:my-app",
+			"env:prod",
+			"org_id:org-guid",
+			"org_name:my-org",
+			"space_id:space-guid",
+			"space_name:my-space",
+			"team:frontend",
+		},
+	}, GetAppTags(c, cc))
+
+	// without the CC API, only the tags from BBS are served
+	assert.Equal(t, map[string][]string{
+		"012345678901234567890123456789012345": {"app_guid:012345678901234567890123456789012345"},
+	}, GetAppTags(c, nil))
+
+	tags, found := GetInstanceTags(c, nil, "instance-guid-2")
+	require.True(t, found)
+	assert.Equal(t, []string{
+		"app_guid:012345678901234567890123456789012345",
+		"app_instance_guid:instance-guid-2",
+		"app_instance_index:3",
+	}, tags)
+	_, found = GetInstanceTags(c, cc, "unknown")
+	assert.False(t, found)
+}
+
+func TestCCAPIClientPaging(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `{"links":{"uaa":{"href":"%s/uaa"}}}`, server.URL)
+		case "/uaa/oauth/token":
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "client", user)
+			assert.Equal(t, "secret", password)
+			fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+		case "/v3/spaces":
+			assert.Equal(t, "bearer token", r.Header.Get("Authorization"))
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `{"pagination":{"next":null},"resources":[{"guid":"s2","name":"space-2","relationships":{"organization":{"data":{"guid":"o1"}}}}]}`)
+				return
+			}
+			assert.Equal(t, "5000", r.URL.Query().Get("per_page"))
+			fmt.Fprintf(w, `{"pagination":{"next":{"href":"%s/v3/spaces?page=2&per_page=5000"}},"resources":[{"guid":"s1","name":"space-1","relationships":{"organization":{"data":{"guid":"o1"}}}}]}`, server.URL)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newCCAPIClient(server.URL, "client", "secret", false)
+	spaces, err := client.ListSpaces()
+	require.NoError(t, err)
+	assert.Equal(t, []CFSpace{
+		{GUID: "s1", Name: "space-1", OrgGUID: "o1"},
+		{GUID: "s2", Name: "space-2", OrgGUID: "o1"},
+	}, spaces)
+
+	_, err = client.ListOrgs()
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package cloudfoundry
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ccPerPage is the maximum number of resources per page of the CC API v3
+const ccPerPage = 5000
+
+// CCClientI lists the resources of the Cloud Controller API
+// it's useful mostly to be able to mock the CC API during unit tests
+type CCClientI interface {
+	ListApps() ([]CFApp, error)
+	ListSpaces() ([]CFSpace, error)
+	ListOrgs() ([]CFOrg, error)
+}
+
+// ccAPIClient is a minimal client of the CC API v3, authenticated with the
+// client credentials grant of UAA
+type ccAPIClient struct {
+	sync.Mutex
+	url          string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	tokenSource  *UAATokenSource
+}
+
+// ccPage is a page of resources of the CC API v3
+type ccPage struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []json.RawMessage `json:"resources"`
+}
+
+// ccRelationship is a to-one relationship of a resource of the CC API v3
+type ccRelationship struct {
+	Data struct {
+		GUID string `json:"guid"`
+	} `json:"data"`
+}
+
+// ccMetadata holds the labels and the annotations of a resource of the CC API v3
+type ccMetadata struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// newCCAPIClient returns a client of the CC API at ccURL
+func newCCAPIClient(ccURL, clientID, clientSecret string, skipSSLValidation bool) *ccAPIClient {
+	return &ccAPIClient{
+		url:          strings.TrimSuffix(ccURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSLValidation},
+			},
+		},
+	}
+}
+
+// ListApps returns all the applications
+func (c *ccAPIClient) ListApps() ([]CFApp, error) {
+	var apps []CFApp
+	err := c.listResources("/v3/apps", func(raw json.RawMessage) error {
+		var app struct {
+			GUID          string     `json:"guid"`
+			Name          string     `json:"name"`
+			Metadata      ccMetadata `json:"metadata"`
+			Relationships struct {
+				Space ccRelationship `json:"space"`
+			} `json:"relationships"`
+		}
+		if err := json.Unmarshal(raw, &app); err != nil {
+			return err
+		}
+		apps = append(apps, CFApp{
+			GUID:        app.GUID,
+			Name:        app.Name,
+			SpaceGUID:   app.Relationships.Space.Data.GUID,
+			Labels:      app.Metadata.Labels,
+			Annotations: app.Metadata.Annotations,
+		})
+		return nil
+	})
+	return apps, err
+}
+
+// ListSpaces returns all the spaces
+func (c *ccAPIClient) ListSpaces() ([]CFSpace, error) {
+	var spaces []CFSpace
+	err := c.listResources("/v3/spaces", func(raw json.RawMessage) error {
+		var space struct {
+			GUID          string `json:"guid"`
+			Name          string `json:"name"`
+			Relationships struct {
+				Organization ccRelationship `json:"organization"`
+			} `json:"relationships"`
+		}
+		if err := json.Unmarshal(raw, &space); err != nil {
+			return err
+		}
+		spaces = append(spaces, CFSpace{
+			GUID:    space.GUID,
+			Name:    space.Name,
+			OrgGUID: space.Relationships.Organization.Data.GUID,
+		})
+		return nil
+	})
+	return spaces, err
+}
+
+// ListOrgs returns all the organizations
+func (c *ccAPIClient) ListOrgs() ([]CFOrg, error) {
+	var orgs []CFOrg
+	err := c.listResources("/v3/organizations", func(raw json.RawMessage) error {
+		var org struct {
+			GUID string `json:"guid"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &org); err != nil {
+			return err
+		}
+		orgs = append(orgs, CFOrg{GUID: org.GUID, Name: org.Name})
+		return nil
+	})
+	return orgs, err
+}
+
+// listResources calls handle on each resource of the path, following the
+// pages. A list is only complete if no error is returned.
+func (c *ccAPIClient) listResources(path string, handle func(json.RawMessage) error) error {
+	next := fmt.Sprintf("%s%s?per_page=%d", c.url, path, ccPerPage)
+	for next != "" {
+		var page ccPage
+		if err := c.get(next, &page); err != nil {
+			return err
+		}
+		for _, raw := range page.Resources {
+			if err := handle(raw); err != nil {
+				return fmt.Errorf("failed to decode a resource of %s: %s", path, err)
+			}
+		}
+		next = ""
+		if page.Pagination.Next != nil {
+			next = page.Pagination.Next.Href
+		}
+	}
+	return nil
+}
+
+// get decodes the JSON response of an authenticated GET request
+func (c *ccAPIClient) get(u string, v interface{}) error {
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		// the token was revoked, get a new one on the next request
+		c.tokenSource.Invalidate()
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, u, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getToken returns a valid UAA token, the UAA URL is discovered from the root
+// endpoint of the CC API on the first call
+func (c *ccAPIClient) getToken() (string, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.tokenSource != nil {
+		return c.tokenSource.Token()
+	}
+
+	var root struct {
+		Links struct {
+			UAA struct {
+				Href string `json:"href"`
+			} `json:"uaa"`
+		} `json:"links"`
+	}
+	resp, err := c.httpClient.Get(c.url + "/")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return "", fmt.Errorf("failed to decode the root endpoint of the CC API: %s", err)
+	}
+	if root.Links.UAA.Href == "" {
+		return "", fmt.Errorf("the CC API at %s doesn't advertise a UAA endpoint", c.url)
+	}
+	c.tokenSource = NewUAATokenSource(root.Links.UAA.Href, c.clientID, c.clientSecret, c.httpClient)
+	return c.tokenSource.Token()
+}
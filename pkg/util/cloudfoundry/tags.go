@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package cloudfoundry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// annotationTagsPrefix prefixes the annotations of the applications turned into tags,
+// the other annotations are free-form and not tagged
+const annotationTagsPrefix = "tags.datadoghq.com/"
+
+// GetAppTags returns the tags of the applications known to BBS, indexed by their GUID.
+// When the CC cache is set, they're enriched with the name, the space and the org of
+// the applications, their labels and their annotations prefixed with tags.datadoghq.com/.
+func GetAppTags(bc BBSCacheI, cc CCCacheI) map[string][]string {
+	appTags := map[string][]string{}
+	for _, dlrp := range bc.GetDesiredLRPs() {
+		if _, found := appTags[dlrp.AppGUID]; found {
+			continue
+		}
+		tags := getAppTags(dlrp.AppGUID, cc)
+		sort.Strings(tags)
+		appTags[dlrp.AppGUID] = tags
+	}
+	return appTags
+}
+
+// GetInstanceTags returns the tags of the ActualLRP with the given instance GUID, or
+// Garden container handle: the tags of its application and of the instance
+func GetInstanceTags(bc *BBSCache, cc CCCacheI, instanceGUID string) ([]string, bool) {
+	lrp, found := bc.GetActualLRPByInstanceGUID(instanceGUID)
+	if !found {
+		return nil, false
+	}
+	tags := append(getAppTags(lrp.AppGUID, cc),
+		fmt.Sprintf("app_instance_guid:%s", lrp.InstanceGUID),
+		fmt.Sprintf("app_instance_index:%d", lrp.Index),
+	)
+	sort.Strings(tags)
+	return tags, true
+}
+
+// getAppTags returns the tags of an application, resolved from the CC cache if it's set
+func getAppTags(appGUID string, cc CCCacheI) []string {
+	tags := []string{fmt.Sprintf("app_guid:%s", appGUID)}
+	if cc != nil {
+		tags = append(tags, ccAppTags(cc, appGUID)...)
+	}
+	return tags
+}
+
+// ccAppTags returns the tags of an application resolved from the CC cache
+func ccAppTags(cc CCCacheI, appGUID string) []string {
+	app, found := cc.GetApp(appGUID)
+	if !found {
+		return nil
+	}
+	tags := []string{fmt.Sprintf("app_name:%s", app.Name)}
+	for k, v := range app.Labels {
+		tags = append(tags, fmt.Sprintf("%s:%s", k, v))
+	}
+	for k, v := range app.Annotations {
+		if strings.HasPrefix(k, annotationTagsPrefix) && len(k) > len(annotationTagsPrefix) {
+			tags = append(tags, fmt.Sprintf("%s:%s", strings.TrimPrefix(k, annotationTagsPrefix), v))
+		}
+	}
+
+	space, found := cc.GetSpace(app.SpaceGUID)
+	if !found {
+		return tags
+	}
+	tags = append(tags, fmt.Sprintf("space_id:%s", space.GUID), fmt.Sprintf("space_name:%s", space.Name))
+	if org, found := cc.GetOrg(space.OrgGUID); found {
+		tags = append(tags, fmt.Sprintf("org_id:%s", org.GUID), fmt.Sprintf("org_name:%s", org.Name))
+	}
+	return tags
+}
+
+// GetGlobalAppTags returns the tags of an application from the global BBS and CC caches,
+// it returns false if the application is known to neither of them
+func GetGlobalAppTags(appGUID string) ([]string, bool) {
+	found := false
+	if bc, err := GetGlobalBBSCache(); err == nil {
+		found = len(bc.GetActualLRPsFor(appGUID)) > 0
+	}
+	var ccCache CCCacheI
+	if cc, err := GetGlobalCCCache(); err == nil {
+		ccCache = cc
+		if _, ok := cc.GetApp(appGUID); ok {
+			found = true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	tags := getAppTags(appGUID, ccCache)
+	sort.Strings(tags)
+	return tags, true
+}
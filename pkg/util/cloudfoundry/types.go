@@ -66,13 +66,14 @@ func (id ADIdentifier) String() string {
 
 // ActualLRP carries the necessary data about an Actual LRP obtained through BBS API
 type ActualLRP struct {
-	AppGUID     string
-	CellID      string
-	ContainerIP string
-	Index       int32
-	Ports       []uint32
-	ProcessGUID string
-	State       string
+	AppGUID      string
+	CellID       string
+	ContainerIP  string
+	Index        int32
+	InstanceGUID string
+	Ports        []uint32
+	ProcessGUID  string
+	State        string
 }
 
 // DesiredLRP carries the necessary data about a Desired LRP obtained through BBS API
@@ -90,13 +91,14 @@ func ActualLRPFromBBSModel(bbsLRP *models.ActualLRP) ActualLRP {
 		ports = append(ports, pm.ContainerPort)
 	}
 	a := ActualLRP{
-		AppGUID:     appGUIDFromProcessGUID(bbsLRP.ProcessGuid),
-		CellID:      bbsLRP.CellId,
-		ContainerIP: bbsLRP.InstanceAddress,
-		Index:       bbsLRP.Index,
-		Ports:       ports,
-		ProcessGUID: bbsLRP.ProcessGuid,
-		State:       bbsLRP.State,
+		AppGUID:      appGUIDFromProcessGUID(bbsLRP.ProcessGuid),
+		CellID:       bbsLRP.CellId,
+		ContainerIP:  bbsLRP.InstanceAddress,
+		Index:        bbsLRP.Index,
+		InstanceGUID: bbsLRP.InstanceGuid,
+		Ports:        ports,
+		ProcessGUID:  bbsLRP.ProcessGuid,
+		State:        bbsLRP.State,
 	}
 	return a
 }
@@ -202,3 +204,25 @@ func getVcapServicesMap(vcap, appGUID string) (map[string][]byte, error) {
 
 	return ret, nil
 }
+
+// CFApp carries the necessary data about an application obtained through the CC API
+type CFApp struct {
+	GUID        string
+	Name        string
+	SpaceGUID   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// CFSpace carries the necessary data about a space obtained through the CC API
+type CFSpace struct {
+	GUID    string
+	Name    string
+	OrgGUID string
+}
+
+// CFOrg carries the necessary data about an organization obtained through the CC API
+type CFOrg struct {
+	GUID string
+	Name string
+}
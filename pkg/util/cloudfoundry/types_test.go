@@ -28,19 +28,21 @@ var BBSModelA1 = models.ActualLRP{
 		ProcessGuid: "0123456789012345678901234567890123456789",
 	},
 	ActualLRPInstanceKey: models.ActualLRPInstanceKey{
-		CellId: "cell123",
+		CellId:       "cell123",
+		InstanceGuid: "instance-guid-1",
 	},
 	State: "STATE",
 }
 
 var ExpectedA1 = ActualLRP{
-	AppGUID:     "012345678901234567890123456789012345",
-	CellID:      "cell123",
-	ContainerIP: "1.2.3.4",
-	Index:       4,
-	Ports:       []uint32{1234, 5678},
-	ProcessGUID: "0123456789012345678901234567890123456789",
-	State:       "STATE",
+	AppGUID:      "012345678901234567890123456789012345",
+	CellID:       "cell123",
+	ContainerIP:  "1.2.3.4",
+	Index:        4,
+	InstanceGUID: "instance-guid-1",
+	Ports:        []uint32{1234, 5678},
+	ProcessGUID:  "0123456789012345678901234567890123456789",
+	State:        "STATE",
 }
 
 var BBSModelA2 = models.ActualLRP{
@@ -56,19 +58,21 @@ var BBSModelA2 = models.ActualLRP{
 		ProcessGuid: "0123456789012345678901234567890123456789",
 	},
 	ActualLRPInstanceKey: models.ActualLRPInstanceKey{
-		CellId: "cell123",
+		CellId:       "cell123",
+		InstanceGuid: "instance-guid-2",
 	},
 	State: "RUNNING",
 }
 
 var ExpectedA2 = ActualLRP{
-	AppGUID:     "012345678901234567890123456789012345",
-	CellID:      "cell123",
-	ContainerIP: "1.2.3.5",
-	Index:       3,
-	Ports:       []uint32{1234, 5678},
-	ProcessGUID: "0123456789012345678901234567890123456789",
-	State:       "RUNNING",
+	AppGUID:      "012345678901234567890123456789012345",
+	CellID:       "cell123",
+	ContainerIP:  "1.2.3.5",
+	Index:        3,
+	InstanceGUID: "instance-guid-2",
+	Ports:        []uint32{1234, 5678},
+	ProcessGUID:  "0123456789012345678901234567890123456789",
+	State:        "RUNNING",
 }
 
 var BBSModelD1 = models.DesiredLRP{
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package cloudfoundry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin renews the UAA tokens before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+// UAATokenSource gets and renews a UAA token with the client credentials grant
+type UAATokenSource struct {
+	m            sync.Mutex
+	uaaURL       string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	token        string
+	expiry       time.Time
+}
+
+// NewUAATokenSource returns a UAATokenSource for the UAA at uaaURL
+func NewUAATokenSource(uaaURL, clientID, clientSecret string, httpClient *http.Client) *UAATokenSource {
+	return &UAATokenSource{
+		uaaURL:       strings.TrimSuffix(uaaURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   httpClient,
+	}
+}
+
+// Token returns a valid token, a new one is requested when it's about to expire
+func (s *UAATokenSource) Token() (string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.token != "" && time.Now().Before(s.expiry) {
+		return s.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest("POST", s.uaaURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d from UAA", resp.StatusCode)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode the UAA token: %s", err)
+	}
+	s.token = token.AccessToken
+	s.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return s.token, nil
+}
+
+// Invalidate drops the current token, e.g. when it was revoked, a new one is
+// requested on the next call to Token
+func (s *UAATokenSource) Invalidate() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.token = ""
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/nodeconfigs/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const dcaNodeConfigsPath = "api/v1/configs/node"
+
+// GetNodeConfigs is called by the node_configs config provider, it returns
+// true without configs if the templates still have the given version
+func (c *DCAClient) GetNodeConfigs(nodeName, version string) (types.ConfigResponse, bool, error) {
+	// Retry on the main URL if the leader fails
+	willRetry := c.leaderClient.hasLeader()
+
+	result, upToDate, err := c.doGetNodeConfigs(nodeName, version)
+	if err != nil && willRetry {
+		log.Debugf("Got error on leader, retrying via the service: %s", err)
+		c.leaderClient.resetURL()
+		return c.doGetNodeConfigs(nodeName, version)
+	}
+	return result, upToDate, err
+}
+
+func (c *DCAClient) doGetNodeConfigs(nodeName, version string) (types.ConfigResponse, bool, error) {
+	var configs types.ConfigResponse
+
+	// https://host:port/api/v1/configs/node/{nodeName}?version={version}
+	rawURL := c.leaderClient.buildURL(dcaNodeConfigsPath, nodeName)
+	if version != "" {
+		rawURL += "?version=" + url.QueryEscape(version)
+	}
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return configs, false, err
+	}
+	req.Header = c.clusterAgentAPIRequestHeaders
+
+	resp, err := c.leaderClient.Do(req)
+	if err != nil {
+		return configs, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return configs, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return configs, false, fmt.Errorf("unexpected response: %d - %s", resp.StatusCode, resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return configs, false, err
+	}
+	err = json.Unmarshal(b, &configs)
+	return configs, false, err
+}
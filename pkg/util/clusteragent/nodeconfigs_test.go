@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dummyNodeConfigs = `{
+"version": "3f2a9c",
+"configs": [
+  {
+    "check_name": "ntp"
+  }
+]
+}`
+
+func (suite *clusterAgentSuite) TestNodeConfigsNominal() {
+	dca, err := newDummyClusterAgent()
+	require.NoError(suite.T(), err)
+
+	dca.rawResponses["/api/v1/configs/node/mynode"] = dummyNodeConfigs
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.NoError(suite.T(), err)
+	mockConfig.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.NoError(suite.T(), err)
+
+	configs, upToDate, err := ca.GetNodeConfigs("mynode", "")
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), upToDate)
+	assert.Equal(suite.T(), "3f2a9c", configs.Version)
+	require.Len(suite.T(), configs.Configs, 1)
+	assert.Equal(suite.T(), "ntp", configs.Configs[0].Name)
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compression
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
+)
+
+// adaptivePeriod is the minimum duration between two updates of an adaptive level,
+// the CPU usage of the Agent is not refreshed more often by the watchdog.
+const adaptivePeriod = 20 * time.Second
+
+// AdaptiveLevel is a compression level adapting to the CPU usage of the Agent, as
+// measured by the watchdog: it is raised when the Agent uses less than half of its
+// CPU budget, to save bandwidth, and lowered when the Agent exceeds it.
+type AdaptiveLevel struct {
+	min, max      int
+	maxCPUUsage   float64
+	cpuUsage      func(now time.Time) float64
+	mu            sync.Mutex
+	level         int
+	lastUpdatedAt time.Time
+}
+
+// NewAdaptiveLevel returns a new AdaptiveLevel between min and max, starting at level.
+// maxCPUUsage is the CPU budget of the Agent, 1 meaning a full core.
+func NewAdaptiveLevel(min, max, level int, maxCPUUsage float64) *AdaptiveLevel {
+	if level < min {
+		level = min
+	} else if level > max {
+		level = max
+	}
+	return &AdaptiveLevel{
+		min:         min,
+		max:         max,
+		maxCPUUsage: maxCPUUsage,
+		cpuUsage: func(now time.Time) float64 {
+			return watchdog.CPU(now).UserAvg
+		},
+		level:         level,
+		lastUpdatedAt: time.Now(),
+	}
+}
+
+// Level returns the current compression level.
+func (a *AdaptiveLevel) Level() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if now.Sub(a.lastUpdatedAt) < adaptivePeriod {
+		return a.level
+	}
+	a.lastUpdatedAt = now
+
+	usage := a.cpuUsage(now)
+	switch {
+	case usage > a.maxCPUUsage && a.level > a.min:
+		a.level--
+	case usage < a.maxCPUUsage/2 && a.level < a.max:
+		a.level++
+	}
+	return a.level
+}
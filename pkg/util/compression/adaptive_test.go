@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLevel(t *testing.T) {
+	usage := 0.1
+	level := NewAdaptiveLevel(1, 3, 2, 0.5)
+	level.cpuUsage = func(time.Time) float64 { return usage }
+
+	// the level is not updated more often than the CPU usage
+	assert.Equal(t, 2, level.Level())
+
+	// the CPU is idle
+	level.lastUpdatedAt = time.Time{}
+	assert.Equal(t, 3, level.Level())
+	level.lastUpdatedAt = time.Time{}
+	assert.Equal(t, 3, level.Level())
+
+	// the CPU usage is within the budget
+	usage = 0.4
+	level.lastUpdatedAt = time.Time{}
+	assert.Equal(t, 3, level.Level())
+
+	// the CPU usage exceeds the budget
+	usage = 0.8
+	for _, expected := range []int{2, 1, 1} {
+		level.lastUpdatedAt = time.Time{}
+		assert.Equal(t, expected, level.Level())
+	}
+
+	assert.Equal(t, 3, NewAdaptiveLevel(1, 3, 5, 0.5).Level())
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+)
+
+// The content encodings the payloads can be compressed with at a given level
+const (
+	ZlibContentEncoding = "deflate"
+	ZstdContentEncoding = "zstd"
+)
+
+// The levels of the zlib compression
+const (
+	zlibMinLevel     = zlib.BestSpeed
+	zlibDefaultLevel = 6
+	zlibMaxLevel     = zlib.BestCompression
+)
+
+// Levels returns the minimum, default and maximum compression levels of the content encoding.
+func Levels(contentEncoding string) (min, def, max int) {
+	switch contentEncoding {
+	case ZlibContentEncoding:
+		return zlibMinLevel, zlibDefaultLevel, zlibMaxLevel
+	case ZstdContentEncoding:
+		return zstdMinLevel, zstdDefaultLevel, zstdMaxLevel
+	default:
+		return 0, 0, 0
+	}
+}
+
+// ClampLevel returns the level clamped to the levels of the content encoding,
+// and whether it was in their range.
+func ClampLevel(contentEncoding string, level int) (int, bool) {
+	min, _, max := Levels(contentEncoding)
+	if level < min {
+		return min, false
+	}
+	if level > max {
+		return max, false
+	}
+	return level, true
+}
+
+// CompressLevel compresses src with the content encoding at the given level, which is
+// clamped to the levels of the content encoding. An empty content encoding doesn't compress.
+func CompressLevel(contentEncoding string, dst []byte, src []byte, level int) ([]byte, error) {
+	level, _ = ClampLevel(contentEncoding, level)
+
+	switch contentEncoding {
+	case "":
+		return src, nil
+	case ZlibContentEncoding:
+		var b bytes.Buffer
+		w, err := zlib.NewWriterLevel(&b, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(src); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	case ZstdContentEncoding:
+		return zstdCompressLevel(dst, src, level)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", contentEncoding)
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressLevel(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"metric":"system.cpu.user","points":[[1590000000,12.5]],"tags":["env:prod"]},`), 1000)
+
+	fastest, err := CompressLevel(ZlibContentEncoding, nil, payload, zlibMinLevel)
+	require.NoError(t, err)
+	best, err := CompressLevel(ZlibContentEncoding, nil, payload, 100)
+	require.NoError(t, err)
+	assert.True(t, len(best) <= len(fastest))
+
+	r, err := zlib.NewReader(bytes.NewReader(best))
+	require.NoError(t, err)
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decompressed)
+
+	uncompressed, err := CompressLevel("", nil, payload, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, uncompressed)
+
+	_, err = CompressLevel("br", nil, payload, 0)
+	assert.Error(t, err)
+}
+
+func TestClampLevel(t *testing.T) {
+	level, ok := ClampLevel(ZlibContentEncoding, 5)
+	assert.True(t, ok)
+	assert.Equal(t, 5, level)
+
+	level, ok = ClampLevel(ZlibContentEncoding, 12)
+	assert.False(t, ok)
+	assert.Equal(t, zlibMaxLevel, level)
+
+	level, ok = ClampLevel(ZlibContentEncoding, -3)
+	assert.False(t, ok)
+	assert.Equal(t, zlibMinLevel, level)
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build zstd
+
+package compression
+
+import (
+	zstd "github.com/DataDog/zstd.v1.3"
+)
+
+// ZstdAvailable is true if the zstd compression is compiled in
+const ZstdAvailable = true
+
+// The levels of the zstd compression
+const (
+	zstdMinLevel     = zstd.BestSpeed
+	zstdDefaultLevel = zstd.DefaultCompression
+	zstdMaxLevel     = zstd.BestCompression
+)
+
+func zstdCompressLevel(dst []byte, src []byte, level int) ([]byte, error) {
+	return zstd.CompressLevel(dst, src, level)
+}
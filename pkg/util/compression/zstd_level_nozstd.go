@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !zstd
+
+package compression
+
+import (
+	"errors"
+)
+
+// ZstdAvailable is true if the zstd compression is compiled in
+const ZstdAvailable = false
+
+// The levels of the zstd compression
+const (
+	zstdMinLevel     = 1
+	zstdDefaultLevel = 5
+	zstdMaxLevel     = 20
+)
+
+func zstdCompressLevel(dst []byte, src []byte, level int) ([]byte, error) {
+	return nil, errors.New("the agent is built without zstd support")
+}
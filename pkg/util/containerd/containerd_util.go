@@ -25,6 +25,16 @@ const (
 	// The check config is used if the containerd socket is detected.
 	// However we want to cover cases with custom config files.
 	containerdDefaultSocketPath = "/var/run/containerd/containerd.sock"
+
+	// KubernetesNamespace is the namespace of the containers created by the Kubernetes CRI plugin
+	KubernetesNamespace = "k8s.io"
+	// DockerNamespace is the namespace of the containers created by docker
+	DockerNamespace = "moby"
+
+	// NerdctlNameLabel is the label holding the name of the containers created by nerdctl
+	NerdctlNameLabel = "nerdctl/name"
+	// NerdctlStateDirLabel is the label holding the directory where nerdctl writes the logs of a container
+	NerdctlStateDirLabel = "nerdctl/state-dir"
 )
 
 var (
@@ -34,12 +44,15 @@ var (
 
 // ContainerdItf is the interface implementing a subset of methods that leverage the Containerd api.
 type ContainerdItf interface {
+	Container(id string) (containerd.Container, error)
 	Containers() ([]containerd.Container, error)
 	GetEvents() containerd.EventService
 	Info(ctn containerd.Container) (containers.Container, error)
 	ImageSize(ctn containerd.Container) (int64, error)
 	Metadata() (containerd.Version, error)
 	Namespace() string
+	Namespaces() []string
+	WithNamespace(namespace string) ContainerdItf
 	TaskMetrics(ctn containerd.Container) (*types.Metric, error)
 	TaskPids(ctn containerd.Container) ([]containerd.ProcessInfo, error)
 }
@@ -52,6 +65,7 @@ type ContainerdUtil struct {
 	queryTimeout      time.Duration
 	connectionTimeout time.Duration
 	namespace         string
+	namespaces        []string
 }
 
 // GetContainerdUtil creates the Containerd util containing the Containerd client and implementing the ContainerdItf
@@ -63,6 +77,10 @@ func GetContainerdUtil() (ContainerdItf, error) {
 			connectionTimeout: config.Datadog.GetDuration("cri_connection_timeout") * time.Second,
 			socketPath:        config.Datadog.GetString("cri_socket_path"),
 			namespace:         config.Datadog.GetString("containerd_namespace"),
+			namespaces:        config.Datadog.GetStringSlice("containerd_namespaces"),
+		}
+		if len(globalContainerdUtil.namespaces) == 0 {
+			globalContainerdUtil.namespaces = []string{globalContainerdUtil.namespace}
 		}
 		if globalContainerdUtil.socketPath == "" {
 			log.Info("No socket path was specified, defaulting to /var/run/containerd/containerd.sock")
@@ -84,10 +102,35 @@ func GetContainerdUtil() (ContainerdItf, error) {
 	return globalContainerdUtil, nil
 }
 
+// IsOrchestratedNamespace returns true for the namespaces whose containers are managed by Kubernetes or docker,
+// they are tagged and their logs collected through Kubernetes or docker rather than containerd
+func IsOrchestratedNamespace(namespace string) bool {
+	return namespace == KubernetesNamespace || namespace == DockerNamespace
+}
+
+// Namespace returns the namespace used to query the Containerd api
 func (c *ContainerdUtil) Namespace() string {
 	return c.namespace
 }
 
+// Namespaces returns the namespaces whose containers are monitored,
+// defined in `containerd_namespaces` or defaulting to `containerd_namespace`
+func (c *ContainerdUtil) Namespaces() []string {
+	return c.namespaces
+}
+
+// WithNamespace returns a util sharing the same client that queries the Containerd api in another namespace
+func (c *ContainerdUtil) WithNamespace(namespace string) ContainerdItf {
+	return &ContainerdUtil{
+		cl:                c.cl,
+		socketPath:        c.socketPath,
+		queryTimeout:      c.queryTimeout,
+		connectionTimeout: c.connectionTimeout,
+		namespace:         namespace,
+		namespaces:        c.namespaces,
+	}
+}
+
 // Metadata is used to collect the version and revision of the Containerd API
 func (c *ContainerdUtil) Metadata() (containerd.Version, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
@@ -140,6 +183,14 @@ func (c *ContainerdUtil) Containers() ([]containerd.Container, error) {
 	return c.cl.Containers(ctxNamespace)
 }
 
+// Container interfaces with the containerd api to get a Container by ID
+func (c *ContainerdUtil) Container(id string) (containerd.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	ctxNamespace := namespaces.WithNamespace(ctx, c.namespace)
+	return c.cl.LoadContainer(ctxNamespace, id)
+}
+
 // ImageSize interfaces with the containerd api to get the size of an image
 func (c *ContainerdUtil) ImageSize(ctn containerd.Container) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
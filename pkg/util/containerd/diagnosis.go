@@ -8,15 +8,31 @@
 package containerd
 
 import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 )
 
 func init() {
-	diagnosis.Register("Containerd availability", diagnose)
+	diagnosis.RegisterSuite("Containerd availability", diagnose)
 }
 
 // diagnose the Containerd socket connectivity
-func diagnose() error {
-	_, err := GetContainerdUtil()
-	return err
+func diagnose() []diagnosis.Result {
+	socketPath := config.Datadog.GetString("cri_socket_path")
+	if socketPath == "" {
+		socketPath = containerdDefaultSocketPath
+	}
+	result := diagnosis.Result{
+		Name:    fmt.Sprintf("Containerd socket %s", socketPath),
+		Status:  diagnosis.StatusPass,
+		Message: "Reachable",
+	}
+	if _, err := GetContainerdUtil(); err != nil {
+		result.Status = diagnosis.StatusFail
+		result.Message = err.Error()
+		result.Remediation = "Check that the containerd socket is mounted in the agent container and readable by the agent, or set cri_socket_path to its path"
+	}
+	return []diagnosis.Result{result}
 }
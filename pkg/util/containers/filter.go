@@ -33,46 +33,90 @@ const (
 	pauseContainerECR     = `image:ecr(.*)amazonaws.com/pause(.*)`
 )
 
+// PodLabelFilter matches the pods having a label whose value matches the
+// Value regex, or having the label at all if Value is nil
+type PodLabelFilter struct {
+	Key   string
+	Value *regexp.Regexp
+}
+
+// Match returns whether the pod labels match the filter
+func (f PodLabelFilter) Match(podLabels map[string]string) bool {
+	value, found := podLabels[f.Key]
+	if !found {
+		return false
+	}
+	return f.Value == nil || f.Value.MatchString(value)
+}
+
 // Filter holds the state for the container filtering logic
 type Filter struct {
 	Enabled            bool
 	ImageWhitelist     []*regexp.Regexp
 	NameWhitelist      []*regexp.Regexp
 	NamespaceWhitelist []*regexp.Regexp
+	PodLabelWhitelist  []PodLabelFilter
 	ImageBlacklist     []*regexp.Regexp
 	NameBlacklist      []*regexp.Regexp
 	NamespaceBlacklist []*regexp.Regexp
+	PodLabelBlacklist  []PodLabelFilter
 }
 
 var sharedFilter *Filter
 
-func parseFilters(filters []string) (imageFilters, nameFilters, namespaceFilters []*regexp.Regexp, err error) {
+// parsePodLabelFilter parses the `pod_label:<key>=<value regex>` and
+// `pod_label:<key>` patterns
+func parsePodLabelFilter(pat string) (PodLabelFilter, error) {
+	parts := strings.SplitN(pat, "=", 2)
+	key := strings.TrimSpace(parts[0])
+	if key == "" {
+		return PodLabelFilter{}, fmt.Errorf("invalid pod label filter '%s': missing label key", pat)
+	}
+	if len(parts) == 1 {
+		return PodLabelFilter{Key: key}, nil
+	}
+
+	// the value is anchored: pod_label:team=infra doesn't match team=infra-prod
+	r, err := regexp.Compile("^(?:" + parts[1] + ")$")
+	if err != nil {
+		return PodLabelFilter{}, fmt.Errorf("invalid regex '%s': %s", parts[1], err)
+	}
+	return PodLabelFilter{Key: key, Value: r}, nil
+}
+
+func parseFilters(filters []string) (imageFilters, nameFilters, namespaceFilters []*regexp.Regexp, podLabelFilters []PodLabelFilter, err error) {
 	for _, filter := range filters {
 		switch {
 		case strings.HasPrefix(filter, "image:"):
 			pat := strings.TrimPrefix(filter, "image:")
 			r, err := regexp.Compile(strings.TrimPrefix(pat, "image:"))
 			if err != nil {
-				return nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
+				return nil, nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
 			}
 			imageFilters = append(imageFilters, r)
 		case strings.HasPrefix(filter, "name:"):
 			pat := strings.TrimPrefix(filter, "name:")
 			r, err := regexp.Compile(pat)
 			if err != nil {
-				return nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
+				return nil, nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
 			}
 			nameFilters = append(nameFilters, r)
 		case strings.HasPrefix(filter, "kube_namespace:"):
 			pat := strings.TrimPrefix(filter, "kube_namespace:")
 			r, err := regexp.Compile(pat)
 			if err != nil {
-				return nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
+				return nil, nil, nil, nil, fmt.Errorf("invalid regex '%s': %s", pat, err)
 			}
 			namespaceFilters = append(namespaceFilters, r)
+		case strings.HasPrefix(filter, "pod_label:"):
+			f, err := parsePodLabelFilter(strings.TrimPrefix(filter, "pod_label:"))
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			podLabelFilters = append(podLabelFilters, f)
 		}
 	}
-	return imageFilters, nameFilters, namespaceFilters, nil
+	return imageFilters, nameFilters, namespaceFilters, podLabelFilters, nil
 }
 
 // GetSharedFilter allows to share the result of NewFilterFromConfig
@@ -97,14 +141,16 @@ func ResetSharedFilter() {
 
 // NewFilter creates a new container filter from a two slices of
 // regexp patterns for a whitelist and blacklist. Each pattern should have
-// the following format: "field:pattern" where field can be: [image, name].
+// the following format: "field:pattern" where field can be: [image, name,
+// kube_namespace, pod_label]. Pod label patterns are "pod_label:key=value",
+// value being a regex matching the whole label value, or "pod_label:key".
 // An error is returned if any of the expression don't compile.
 func NewFilter(whitelist, blacklist []string) (*Filter, error) {
-	iwl, nwl, nswl, err := parseFilters(whitelist)
+	iwl, nwl, nswl, plwl, err := parseFilters(whitelist)
 	if err != nil {
 		return nil, err
 	}
-	ibl, nbl, nsbl, err := parseFilters(blacklist)
+	ibl, nbl, nsbl, plbl, err := parseFilters(blacklist)
 	if err != nil {
 		return nil, err
 	}
@@ -114,9 +160,11 @@ func NewFilter(whitelist, blacklist []string) (*Filter, error) {
 		ImageWhitelist:     iwl,
 		NameWhitelist:      nwl,
 		NamespaceWhitelist: nswl,
+		PodLabelWhitelist:  plwl,
 		ImageBlacklist:     ibl,
 		NameBlacklist:      nbl,
 		NamespaceBlacklist: nsbl,
+		PodLabelBlacklist:  plbl,
 	}, nil
 }
 
@@ -182,6 +230,12 @@ func NewAutodiscoveryFilter(filter FilterType) (*Filter, error) {
 // IsExcluded returns a bool indicating if the container should be excluded
 // based on the filters in the containerFilter instance.
 func (cf Filter) IsExcluded(containerName, containerImage, podNamespace string) bool {
+	return cf.IsPodContainerExcluded(containerName, containerImage, podNamespace, nil)
+}
+
+// IsPodContainerExcluded is IsExcluded for the containers whose pod labels
+// are known, also applying the pod label filters.
+func (cf Filter) IsPodContainerExcluded(containerName, containerImage, podNamespace string, podLabels map[string]string) bool {
 	if !cf.Enabled {
 		return false
 	}
@@ -202,6 +256,11 @@ func (cf Filter) IsExcluded(containerName, containerImage, podNamespace string)
 			return false
 		}
 	}
+	for _, f := range cf.PodLabelWhitelist {
+		if f.Match(podLabels) {
+			return false
+		}
+	}
 
 	// Check if blacklisted
 	for _, r := range cf.ImageBlacklist {
@@ -219,6 +278,11 @@ func (cf Filter) IsExcluded(containerName, containerImage, podNamespace string)
 			return true
 		}
 	}
+	for _, f := range cf.PodLabelBlacklist {
+		if f.Match(podLabels) {
+			return true
+		}
+	}
 
 	return false
 }
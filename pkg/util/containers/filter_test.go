@@ -229,6 +229,25 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestFilterPodLabels(t *testing.T) {
+	f, err := NewFilter([]string{"pod_label:keep"}, []string{"pod_label:team=infra|ops", "kube_namespace:kube-system"})
+	require.NoError(t, err)
+
+	assert.True(t, f.IsPodContainerExcluded("app", "app:1.0", "default", map[string]string{"team": "infra"}))
+	assert.True(t, f.IsPodContainerExcluded("app", "app:1.0", "default", map[string]string{"team": "ops"}))
+	assert.False(t, f.IsPodContainerExcluded("app", "app:1.0", "default", map[string]string{"team": "infra-prod"}))
+	assert.False(t, f.IsPodContainerExcluded("app", "app:1.0", "default", nil))
+	assert.True(t, f.IsPodContainerExcluded("app", "app:1.0", "kube-system", nil))
+
+	// the include rules have precedence
+	assert.False(t, f.IsPodContainerExcluded("app", "app:1.0", "kube-system", map[string]string{"team": "infra", "keep": ""}))
+
+	_, err = NewFilter(nil, []string{"pod_label:=infra"})
+	assert.Error(t, err)
+	_, err = NewFilter(nil, []string{"pod_label:team=[infra"})
+	assert.Error(t, err)
+}
+
 func TestNewFilterFromConfig(t *testing.T) {
 	config.Datadog.SetDefault("exclude_pause_container", true)
 	config.Datadog.SetDefault("ac_include", []string{"image:apache.*"})
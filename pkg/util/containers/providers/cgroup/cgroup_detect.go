@@ -35,7 +35,7 @@ var (
 // ContainerStartTime gets the stat for cgroup directory and use the mtime for that dir to determine the start time for the container
 // this should work because the cgroup dir for the container would be created only when it's started
 func (c ContainerCgroup) ContainerStartTime() (int64, error) {
-	cgroupDir := c.cgroupFilePath("cpuacct", "")
+	cgroupDir := c.cgroupFilePath(c.controllerTarget("cpuacct"), "")
 	if !pathExists(cgroupDir) {
 		return 0, fmt.Errorf("could not get cgroup dir, directory doesn't exist")
 	}
@@ -85,7 +85,11 @@ func (c ContainerCgroup) cgroupFilePath(target, file string) string {
 //	 cgroup /sys/fs/cgroup/perf_event cgroup rw,relatime,perf_event 0 0
 //	 cgroup /sys/fs/cgroup/hugetlb cgroup rw,relatime,hugetlb 0 0
 //
-// Returns a map for every target (cpuset, cpu, cpuacct) => path
+// With cgroup v2, the unified hierarchy is a single mount point:
+//	 cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime 0 0
+//
+// Returns a map for every target (cpuset, cpu, cpuacct) => path, the unified
+// hierarchy is stored under the empty target
 func cgroupMountPoints() (map[string]string, error) {
 	mountsFile := "/proc/mounts"
 	if !pathExists(mountsFile) {
@@ -106,6 +110,15 @@ func parseCgroupMountPoints(r io.Reader) map[string]string {
 	for scanner.Scan() {
 		mount := scanner.Text()
 		tokens := strings.Split(mount, " ")
+		// The unified hierarchy of cgroup v2, the mount point can be the cgroup root itself
+		if len(tokens) >= 3 && tokens[2] == "cgroup2" {
+			cgroupPath := tokens[1]
+			if !strings.HasPrefix(cgroupPath+"/", cgroupRoot) {
+				continue
+			}
+			mountPoints[unifiedTarget] = cgroupPath
+			continue
+		}
 		// Check if the filesystem type is 'cgroup'
 		if len(tokens) >= 3 && tokens[2] == "cgroup" {
 			cgroupPath := tokens[1]
@@ -203,6 +216,10 @@ func readCgroupsForPath(pidCgroupPath, prefix string) (string, map[string]string
 // 8:memory:/kubepods/besteffort/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e
 // 7:blkio:/kubepods/besteffort/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e
 //
+// The unified hierarchy of cgroup v2 has an empty target:
+//
+// 0::/system.slice/docker-47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e.scope
+//
 // Returns the common containerID and a mapping of target => path
 // If the first line doesn't have a valid container ID we will return an empty string
 func parseCgroupPaths(r io.Reader, prefix string) (string, map[string]string, error) {
@@ -224,6 +241,15 @@ func parseCgroupPaths(r io.Reader, prefix string) (string, map[string]string, er
 		if len(sp) < 3 {
 			continue
 		}
+		if sp[1] == unifiedTarget {
+			// in a private cgroup namespace, the paths outside of it are relative to its root
+			cgPath := sp[2]
+			for strings.HasPrefix(cgPath, "/..") {
+				cgPath = cgPath[3:]
+			}
+			paths[unifiedTarget] = cgPath
+			continue
+		}
 		// Target can be comma-separate values like cpu,cpuacct
 		tsp := strings.Split(sp[1], ",")
 		for _, target := range tsp {
@@ -112,6 +112,29 @@ func TestParseCgroupMountPoints(t *testing.T) {
 				"systemd":    "/sys/fs/cgroup/systemd",
 			},
 		},
+		{
+			// cgroup v2 only
+			contents: []string{
+				"sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0",
+				"cgroup2 /sys/fs/cgroup cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0",
+			},
+			expected: map[string]string{
+				"": "/sys/fs/cgroup",
+			},
+		},
+		{
+			// hybrid, the controllers are in the v1 hierarchies
+			contents: []string{
+				"tmpfs /sys/fs/cgroup tmpfs ro,nosuid,nodev,noexec,mode=755 0 0",
+				"cgroup2 /sys/fs/cgroup/unified cgroup2 rw,nosuid,nodev,noexec,relatime,nsdelegate 0 0",
+				"cgroup /sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0",
+				"cgroup2 /var/lib/other cgroup2 rw,nosuid,nodev,noexec,relatime 0 0",
+			},
+			expected: map[string]string{
+				"":       "/sys/fs/cgroup/unified",
+				"memory": "/sys/fs/cgroup/memory",
+			},
+		},
 		{
 			contents: []string{
 				"",
@@ -255,6 +278,26 @@ func TestParseCgroupPaths(t *testing.T) {
 				"cpuset":       "/docker/af1c1c0b02c6e45e0b6cb6151cd68fd02c7a6d91ad70d9bd72ccec8e83607841",
 			},
 		},
+		{
+			// cgroup v2
+			contents: []string{
+				"0::/system.slice/docker-47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e.scope",
+			},
+			expectedContainer: "47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e",
+			expectedPaths: map[string]string{
+				"": "/system.slice/docker-47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e.scope",
+			},
+		},
+		{
+			// cgroup v2 seen from a private cgroup namespace
+			contents: []string{
+				"0::/../../kubepods/burstable/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e",
+			},
+			expectedContainer: "47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e",
+			expectedPaths: map[string]string{
+				"": "/kubepods/burstable/pod2baa3444-4d37-11e7-bd2f-080027d2bf10/47fc31db38b4fa0f4db44b99d0cad10e3cd4d5f142135a7721c1c95c1aadfb2e",
+			},
+		},
 	} {
 		contents := strings.NewReader(strings.Join(tc.contents, "\n"))
 		c, p, err := parseCgroupPaths(contents, "")
@@ -369,6 +369,13 @@ func (c ContainerCgroup) IO() (*metrics.ContainerIOStats, error) {
 		return ret, fmt.Errorf("error reading %s: %s", statfile, err)
 	}
 
+	ret.OpenFiles = c.openFiles()
+
+	return ret, nil
+}
+
+// openFiles returns the number of file descriptors opened by the processes of the cgroup
+func (c ContainerCgroup) openFiles() uint64 {
 	var fileDescCount uint64
 	for _, pid := range c.Pids {
 		fdCount, err := GetFileDescriptorLen(int(pid))
@@ -378,9 +385,7 @@ func (c ContainerCgroup) IO() (*metrics.ContainerIOStats, error) {
 		}
 		fileDescCount += uint64(fdCount)
 	}
-	ret.OpenFiles = fileDescCount
-
-	return ret, nil
+	return fileDescCount
 }
 
 // ThreadCount returns the number of threads in the pid cgroup
@@ -390,10 +395,10 @@ func (c ContainerCgroup) IO() (*metrics.ContainerIOStats, error) {
 // Although the metric is called `pid.current`, it also tracks
 // threads, and not only task-group-pids
 func (c ContainerCgroup) ThreadCount() (uint64, error) {
-	v, err := c.ParseSingleStat("pids", "pids.current")
+	v, err := c.ParseSingleStat(c.controllerTarget("pids"), "pids.current")
 	if os.IsNotExist(err) {
 		log.Debugf("Missing cgroup file: %s",
-			c.cgroupFilePath("pids", "pids.current"))
+			c.cgroupFilePath(c.controllerTarget("pids"), "pids.current"))
 		return 0, nil
 	} else if err != nil {
 		return 0, err
@@ -407,7 +412,7 @@ func (c ContainerCgroup) ThreadCount() (uint64, error) {
 //
 // If `max` is found, the method returns 0 as-in "no limit"
 func (c ContainerCgroup) ThreadLimit() (uint64, error) {
-	statFile := c.cgroupFilePath("pids", "pids.max")
+	statFile := c.cgroupFilePath(c.controllerTarget("pids"), "pids.max")
 	lines, err := readLines(statFile)
 	if os.IsNotExist(err) {
 		log.Debugf("Missing cgroup file: %s", statFile)
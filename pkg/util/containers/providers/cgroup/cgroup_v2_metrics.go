@@ -0,0 +1,298 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package cgroup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The files of the unified hierarchy are documented in
+// https://www.kernel.org/doc/Documentation/admin-guide/cgroup-v2.rst
+// The metrics are converted to the units of their cgroup v1 counterparts,
+// so that they can be reported the same way.
+
+// unifiedMetrics returns the CPU, IO and Memory metrics of a cgroup v2
+func (c ContainerCgroup) unifiedMetrics() (*metrics.ContainerMetrics, error) {
+	var ret metrics.ContainerMetrics
+	var err error
+	ret.Memory, err = c.unifiedMem()
+	if err != nil {
+		return nil, fmt.Errorf("memory: %s", err)
+	}
+	ret.CPU, err = c.unifiedCPU()
+	if err != nil {
+		return nil, fmt.Errorf("cpu: %s", err)
+	}
+	ret.CPU.ThreadCount, err = c.ThreadCount()
+	if err != nil {
+		return nil, fmt.Errorf("thread count: %s", err)
+	}
+	ret.IO, err = c.unifiedIO()
+	if err != nil {
+		return nil, fmt.Errorf("i/o: %s", err)
+	}
+	return &ret, nil
+}
+
+// unifiedLimits returns the CPU, Thread and Memory limits of a cgroup v2
+func (c ContainerCgroup) unifiedLimits() (*metrics.ContainerLimits, error) {
+	var ret metrics.ContainerLimits
+	var err error
+	ret.CPULimit, err = c.unifiedCPULimit()
+	if err != nil {
+		return nil, fmt.Errorf("cpu limit: %s", err)
+	}
+	ret.MemLimit, err = c.parseUnifiedValue("memory.max")
+	if err != nil {
+		return nil, fmt.Errorf("mem limit: %s", err)
+	}
+	ret.ThreadLimit, err = c.ThreadLimit()
+	if err != nil {
+		return nil, fmt.Errorf("thread limit: %s", err)
+	}
+	return &ret, nil
+}
+
+// unifiedMem returns the memory statistics of a cgroup v2, read from
+// memory.stat, memory.current, memory.swap.current, memory.max, memory.low
+// and memory.events
+func (c ContainerCgroup) unifiedMem() (*metrics.ContainerMemStats, error) {
+	ret := &metrics.ContainerMemStats{}
+	stats, err := c.parseUnifiedKeyValues("memory.stat")
+	if err != nil {
+		return nil, err
+	}
+	ret.RSS = stats["anon"]
+	ret.Cache = stats["file"]
+	ret.RSSHuge = stats["anon_thp"]
+	ret.MappedFile = stats["file_mapped"]
+	ret.Pgfault = stats["pgfault"]
+	ret.Pgmajfault = stats["pgmajfault"]
+	ret.InactiveAnon = stats["inactive_anon"]
+	ret.ActiveAnon = stats["active_anon"]
+	ret.InactiveFile = stats["inactive_file"]
+	ret.ActiveFile = stats["active_file"]
+	ret.Unevictable = stats["unevictable"]
+	ret.KernMemUsage = stats["kernel_stack"] + stats["slab"]
+
+	ret.MemUsageInBytes, err = c.parseUnifiedValue("memory.current")
+	if err != nil {
+		return nil, err
+	}
+	if swap, err := c.ParseSingleStat(unifiedTarget, "memory.swap.current"); err == nil {
+		ret.Swap = swap
+		ret.SwapPresent = true
+	}
+	ret.HierarchicalMemoryLimit, err = c.parseUnifiedValue("memory.max")
+	if err != nil {
+		return nil, err
+	}
+	// docker and the kubelet set the memory reservation in memory.low
+	ret.SoftMemLimit, err = c.parseUnifiedValue("memory.low")
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.parseUnifiedKeyValues("memory.events")
+	if err != nil {
+		return nil, err
+	}
+	ret.MemFailCnt = events["max"]
+	return ret, nil
+}
+
+// unifiedCPU returns the CPU status of a cgroup v2. cpu.stat is in
+// microseconds, the times are converted to USER_HZ like with cgroup v1.
+func (c ContainerCgroup) unifiedCPU() (*metrics.ContainerCPUStats, error) {
+	ret := &metrics.ContainerCPUStats{}
+	stats, err := c.parseUnifiedKeyValues("cpu.stat")
+	if err != nil {
+		return nil, err
+	}
+	ret.User = stats["user_usec"] * 1000 / uint64(NanoToUserHZDivisor)
+	ret.System = stats["system_usec"] * 1000 / uint64(NanoToUserHZDivisor)
+	ret.UsageTotal = float64(stats["usage_usec"]*1000) / NanoToUserHZDivisor
+	ret.NrThrottled = stats["nr_throttled"]
+
+	// cpu.weight is in [1, 10000], it's converted back to the [2, 262144]
+	// range of cpu.shares the same way as the container runtimes convert it
+	weight, err := c.ParseSingleStat(unifiedTarget, "cpu.weight")
+	if err == nil {
+		if weight > 0 {
+			ret.Shares = 2 + ((weight-1)*262142)/9999
+		}
+	} else {
+		log.Debugf("Missing cpu weight stat for %s: %s", c.ContainerID, err.Error())
+	}
+	return ret, nil
+}
+
+// unifiedCPULimit returns the CPU limit of a cgroup v2 in percent, cpu.max
+// holds the quota and the period, the quota is `max` without limit:
+//
+//	50000 100000
+func (c ContainerCgroup) unifiedCPULimit() (float64, error) {
+	statFile := c.cgroupFilePath(unifiedTarget, "cpu.max")
+	lines, err := readLines(statFile)
+	if os.IsNotExist(err) {
+		log.Debugf("Missing cgroup file: %s", statFile)
+		return 100, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(lines) != 1 {
+		return 0, fmt.Errorf("wrong file format: %s", statFile)
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("wrong file format: %s", statFile)
+	}
+	// default cpu limit is 100%
+	if fields[0] == "max" {
+		return 100, nil
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	limit := 100.0
+	if (period > 0) && (quota > 0) {
+		limit = (quota / period) * 100.0
+	}
+	return limit, nil
+}
+
+// unifiedIO returns the disk read and write bytes stats of a cgroup v2.
+// Format:
+//
+// 8:0 rbytes=49225728 wbytes=9850880 rios=1187 wios=207 dbytes=0 dios=0
+// 252:0 rbytes=49094656 wbytes=9850880 rios=1170 wios=207 dbytes=0 dios=0
+//
+func (c ContainerCgroup) unifiedIO() (*metrics.ContainerIOStats, error) {
+	ret := &metrics.ContainerIOStats{
+		DeviceReadBytes:  make(map[string]uint64),
+		DeviceWriteBytes: make(map[string]uint64),
+	}
+
+	statfile := c.cgroupFilePath(unifiedTarget, "io.stat")
+	f, err := os.Open(statfile)
+	if os.IsNotExist(err) {
+		log.Debugf("Missing cgroup file: %s", statfile)
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var devices map[string]string
+	mapping, err := getDiskDeviceMapping()
+	if err != nil {
+		log.Debugf("Cannot get per-device stats: %s", err)
+	} else {
+		devices = mapping.idToName
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		deviceName := devices[fields[0]]
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			value, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch kv[0] {
+			case "rbytes":
+				ret.ReadBytes += value
+				if deviceName != "" {
+					ret.DeviceReadBytes[deviceName] = value
+				}
+			case "wbytes":
+				ret.WriteBytes += value
+				if deviceName != "" {
+					ret.DeviceWriteBytes[deviceName] = value
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ret, fmt.Errorf("error reading %s: %s", statfile, err)
+	}
+
+	ret.OpenFiles = c.openFiles()
+	return ret, nil
+}
+
+// parseUnifiedKeyValues reads a flat keyed file of the unified hierarchy, like
+// memory.stat or cpu.stat. A missing file returns an empty map.
+func (c ContainerCgroup) parseUnifiedKeyValues(file string) (map[string]uint64, error) {
+	ret := make(map[string]uint64)
+	statfile := c.cgroupFilePath(unifiedTarget, file)
+	f, err := os.Open(statfile)
+	if os.IsNotExist(err) {
+		log.Debugf("Missing cgroup file: %s", statfile)
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		ret[fields[0]] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return ret, fmt.Errorf("error reading %s: %s", statfile, err)
+	}
+	return ret, nil
+}
+
+// parseUnifiedValue reads a single value file of the unified hierarchy, `max`
+// and a missing file both return 0 as-in "no limit"
+func (c ContainerCgroup) parseUnifiedValue(file string) (uint64, error) {
+	statFile := c.cgroupFilePath(unifiedTarget, file)
+	lines, err := readLines(statFile)
+	if os.IsNotExist(err) {
+		log.Debugf("Missing cgroup file: %s", statFile)
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(lines) != 1 {
+		return 0, fmt.Errorf("wrong file format: %s", statFile)
+	}
+	if lines[0] == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(lines[0], 10, 64)
+}
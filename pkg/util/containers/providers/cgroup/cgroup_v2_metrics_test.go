@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package cgroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUnified(t *testing.T) {
+	assert.True(t, newDummyContainerCgroup("/sys/fs/cgroup", unifiedTarget).isUnified())
+	assert.False(t, newDummyContainerCgroup("/sys/fs/cgroup", unifiedTarget, "memory").isUnified())
+	assert.False(t, newDummyContainerCgroup("/sys/fs/cgroup", "cpu", "memory").isUnified())
+}
+
+func TestUnifiedMetrics(t *testing.T) {
+	tempFolder, err := newTempFolder("unified-stats")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	memoryStats := dummyCgroupStat{
+		"anon":          1000,
+		"file":          2000,
+		"kernel_stack":  30,
+		"slab":          70,
+		"pgfault":       12,
+		"pgmajfault":    1,
+		"inactive_file": 500,
+	}
+	tempFolder.add("memory.stat", memoryStats.String())
+	tempFolder.add("memory.current", "3100")
+	tempFolder.add("memory.max", "max")
+	tempFolder.add("memory.low", "1024")
+	tempFolder.add("memory.events", "low 0\nhigh 0\nmax 4\noom 0\noom_kill 0")
+	cpuStats := dummyCgroupStat{
+		"usage_usec":   915266418,
+		"user_usec":    641400000,
+		"system_usec":  183270000,
+		"nr_periods":   10,
+		"nr_throttled": 3,
+	}
+	tempFolder.add("cpu.stat", cpuStats.String())
+	tempFolder.add("cpu.weight", "100")
+	tempFolder.add("pids.current", "8")
+	tempFolder.add("io.stat", "8:0 rbytes=100 wbytes=10 rios=1 wios=1 dbytes=0 dios=0\n252:0 rbytes=200 wbytes=20 rios=2 wios=2 dbytes=0 dios=0")
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, unifiedTarget)
+
+	m, err := cgroup.unifiedMetrics()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), m.Memory.RSS)
+	assert.Equal(t, uint64(2000), m.Memory.Cache)
+	assert.Equal(t, uint64(100), m.Memory.KernMemUsage)
+	assert.Equal(t, uint64(500), m.Memory.InactiveFile)
+	assert.Equal(t, uint64(3100), m.Memory.MemUsageInBytes)
+	assert.Equal(t, uint64(0), m.Memory.HierarchicalMemoryLimit)
+	assert.Equal(t, uint64(1024), m.Memory.SoftMemLimit)
+	assert.Equal(t, uint64(4), m.Memory.MemFailCnt)
+	assert.False(t, m.Memory.SwapPresent)
+
+	// same units as cpuacct.stat and cpuacct.usage
+	assert.Equal(t, uint64(64140), m.CPU.User)
+	assert.Equal(t, uint64(18327), m.CPU.System)
+	assert.InDelta(t, 91526.6418, m.CPU.UsageTotal, 0.0000001)
+	assert.Equal(t, uint64(3), m.CPU.NrThrottled)
+	// the default weight is the default shares
+	assert.Equal(t, uint64(2597), m.CPU.Shares)
+	assert.Equal(t, uint64(8), m.CPU.ThreadCount)
+
+	assert.Equal(t, uint64(300), m.IO.ReadBytes)
+	assert.Equal(t, uint64(30), m.IO.WriteBytes)
+}
+
+func TestUnifiedLimits(t *testing.T) {
+	tempFolder, err := newTempFolder("unified-limits")
+	require.NoError(t, err)
+	defer tempFolder.removeAll()
+
+	cgroup := newDummyContainerCgroup(tempFolder.RootPath, unifiedTarget)
+
+	// No file
+	limits, err := cgroup.unifiedLimits()
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, limits.CPULimit)
+	assert.Equal(t, uint64(0), limits.MemLimit)
+	assert.Equal(t, uint64(0), limits.ThreadLimit)
+
+	// No limit
+	tempFolder.add("cpu.max", "max 100000")
+	tempFolder.add("memory.max", "max")
+	tempFolder.add("pids.max", "max")
+	limits, err = cgroup.unifiedLimits()
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, limits.CPULimit)
+	assert.Equal(t, uint64(0), limits.MemLimit)
+	assert.Equal(t, uint64(0), limits.ThreadLimit)
+
+	// Limits
+	tempFolder.add("cpu.max", "50000 100000")
+	tempFolder.add("memory.max", "536870912")
+	tempFolder.add("pids.max", "100")
+	limits, err = cgroup.unifiedLimits()
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, limits.CPULimit)
+	assert.Equal(t, uint64(536870912), limits.MemLimit)
+	assert.Equal(t, uint64(100), limits.ThreadLimit)
+
+	// Invalid file
+	tempFolder.add("cpu.max", "50000")
+	_, err = cgroup.unifiedLimits()
+	assert.Error(t, err)
+}
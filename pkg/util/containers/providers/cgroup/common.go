@@ -25,6 +25,26 @@ type ContainerCgroup struct {
 	Mounts      map[string]string
 }
 
+// unifiedTarget is the target of the unified hierarchy of cgroup v2 in the
+// mounts and paths, it has no controller name
+const unifiedTarget = ""
+
+// isUnified returns true if the cgroup is in the unified hierarchy of cgroup v2.
+// On hybrid hosts, the controllers are still in the v1 hierarchies.
+func (c ContainerCgroup) isUnified() bool {
+	_, unified := c.Mounts[unifiedTarget]
+	_, memory := c.Mounts["memory"]
+	return unified && !memory
+}
+
+// controllerTarget returns the target holding the files of a controller
+func (c ContainerCgroup) controllerTarget(controller string) string {
+	if c.isUnified() {
+		return unifiedTarget
+	}
+	return controller
+}
+
 // readLines reads contents from a file and splits them by new lines.
 func readLines(filename string) ([]string, error) {
 	f, err := os.Open(filename)
@@ -68,6 +68,9 @@ func (mp *provider) GetContainerMetrics(containerID string) (*metrics.ContainerM
 	if err != nil {
 		return nil, err
 	}
+	if cg.isUnified() {
+		return cg.unifiedMetrics()
+	}
 
 	var metrics metrics.ContainerMetrics
 	metrics.Memory, err = cg.Mem()
@@ -112,6 +115,9 @@ func (mp *provider) GetContainerLimits(containerID string) (*metrics.ContainerLi
 	if err != nil {
 		return nil, err
 	}
+	if cg.isUnified() {
+		return cg.unifiedLimits()
+	}
 
 	var limits metrics.ContainerLimits
 	limits.CPULimit, err = cg.CPULimit()
@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 var healthRe = regexp.MustCompile(`\(health: (\w+)\)`)
@@ -226,7 +227,8 @@ func (d *DockerUtil) dockerContainers(cfg *ContainerListConfig) ([]*containers.C
 			log.Warnf("Can't resolve image name %s: %s", c.Image, err)
 		}
 
-		excluded := d.cfg.filter.IsExcluded(c.Names[0], image, "")
+		podNamespace, podLabels := workloadmeta.GetGlobalStore().GetPodMetaForContainer(c.ID, c.Labels)
+		excluded := d.cfg.filter.IsPodContainerExcluded(c.Names[0], image, podNamespace, podLabels)
 		if excluded && !cfg.FlagExcluded {
 			continue
 		}
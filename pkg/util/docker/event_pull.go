@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/events"
@@ -68,7 +69,9 @@ func (d *DockerUtil) processContainerEvent(msg events.Message) (*ContainerEvent,
 			log.Warnf("can't resolve image name %s: %s", imageName, err)
 		}
 	}
-	if d.cfg.filter.IsExcluded(containerName, imageName, "") {
+	// the attributes of the container events hold the labels of the container
+	podNamespace, podLabels := workloadmeta.GetGlobalStore().GetPodMetaForContainer(msg.Actor.ID, msg.Actor.Attributes)
+	if d.cfg.filter.IsPodContainerExcluded(containerName, imageName, podNamespace, podLabels) {
 		log.Tracef("events from %s are skipped as the image is excluded for the event collection", containerName)
 		return nil, nil
 	}
@@ -92,6 +92,26 @@ func GetNetworkID() (string, error) {
 	}
 }
 
+// GetInstanceMetadataTags returns host tags derived from the instance metadata
+// endpoint: the instance lifecycle (spot or on-demand) and, when instance tags
+// are exposed through the metadata endpoint, the autoscaling group name.
+func GetInstanceMetadataTags() ([]string, error) {
+	lifecycle, err := getMetadataItem("/instance-life-cycle")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the instance lifecycle: %s", err)
+	}
+
+	tags := []string{"instance_lifecycle:" + strings.TrimSpace(lifecycle)}
+
+	// Only available when instance metadata tags are enabled on the instance
+	asg, err := getMetadataItem("/tags/instance/aws:autoscaling:groupName")
+	if err == nil && asg != "" {
+		tags = append(tags, "autoscaling_group:"+strings.TrimSpace(asg))
+	}
+
+	return tags, nil
+}
+
 func getMetadataItemWithMaxLength(endpoint string, maxLength int) (string, error) {
 	result, err := getMetadataItem(endpoint)
 	if err != nil {
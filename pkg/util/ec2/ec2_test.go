@@ -331,3 +331,45 @@ func TestMetedataRequestWithToken(t *testing.T) {
 	assert.Equal(t, "/local-ipv4", requestWithToken.RequestURI)
 	assert.Equal(t, http.MethodGet, requestWithToken.Method)
 }
+
+func TestGetInstanceMetadataTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/instance-life-cycle":
+			io.WriteString(w, "spot")
+		case "/tags/instance/aws:autoscaling:groupName":
+			io.WriteString(w, "my-asg")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+	timeout = time.Second
+	defer resetPackageVars()
+
+	tags, err := GetInstanceMetadataTags()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"instance_lifecycle:spot", "autoscaling_group:my-asg"}, tags)
+}
+
+func TestGetInstanceMetadataTagsNoAutoscalingGroup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/instance-life-cycle":
+			io.WriteString(w, "on-demand")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+	timeout = time.Second
+	defer resetPackageVars()
+
+	tags, err := GetInstanceMetadataTags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"instance_lifecycle:on-demand"}, tags)
+}
@@ -9,6 +9,7 @@ package ecs
 
 import (
 	"net"
+	"sort"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
@@ -17,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 )
 
 // ListContainersInCurrentTask returns internal container representations (with
@@ -41,6 +43,10 @@ func ListContainersInCurrentTask() ([]*containers.Container, error) {
 // UpdateContainerMetrics updates performance metrics for a list of internal
 // container representations based on stats collected from the ECS metadata v2 API
 func UpdateContainerMetrics(cList []*containers.Container) error {
+	// the network stats of the containers are only exposed by the metadata v4
+	// endpoint, they're fetched once for all the containers of the task
+	taskStats := getTaskStatsV4()
+
 	for _, ctr := range cList {
 		stats, err := metadata.V2().GetContainerStats(ctr.ID)
 		if err != nil {
@@ -59,10 +65,52 @@ func UpdateContainerMetrics(cList []*containers.Container) error {
 		if ctr.MemLimit == 0 {
 			ctr.MemLimit = memLimit
 		}
+		if s, found := taskStats[ctr.ID]; found && s != nil {
+			ctr.Network = convertMetaV4NetworkStats(s.Networks)
+		}
 	}
 	return nil
 }
 
+// getTaskStatsV4 returns the stats of the containers of the current task from
+// the metadata v4 endpoint, available from the Fargate platform 1.4, or nil if
+// it's not available
+func getTaskStatsV4() map[string]*v4.ContainerStats {
+	metaV4, err := metadata.V4FromCurrentTask()
+	if err != nil {
+		return nil
+	}
+	stats, err := metaV4.GetTaskStats()
+	if err != nil {
+		log.Debugf("Unable to get the task stats from the metadata v4 API: %s", err)
+		return nil
+	}
+	return stats
+}
+
+// convertMetaV4NetworkStats returns the network stats of a container, by
+// interface sorted by name, from its ECS metadata v4 stats
+func convertMetaV4NetworkStats(networks map[string]v4.NetworkStats) metrics.ContainerNetStats {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make(metrics.ContainerNetStats, 0, len(networks))
+	for _, name := range names {
+		network := networks[name]
+		stats = append(stats, &metrics.InterfaceNetStats{
+			NetworkName: name,
+			BytesSent:   network.TxBytes,
+			BytesRcvd:   network.RxBytes,
+			PacketsSent: network.TxPackets,
+			PacketsRcvd: network.RxPackets,
+		})
+	}
+	return stats
+}
+
 // convertMetaV2Container returns an internal container representation from an
 // ECS metadata v2 container object.
 func convertMetaV2Container(c v2.Container) *containers.Container {
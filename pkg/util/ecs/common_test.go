@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -161,6 +162,20 @@ func TestConvertMetaV2ContainerStats(t *testing.T) {
 	assert.Equal(t, uint64(268435456), memLimit)
 }
 
+func TestConvertMetaV4NetworkStats(t *testing.T) {
+	networks := map[string]v4.NetworkStats{
+		"eth1": {RxBytes: 564655295, RxPackets: 384960, TxBytes: 3043269, TxPackets: 54355},
+		"eth0": {RxBytes: 10, RxPackets: 1, TxBytes: 20, TxPackets: 2},
+	}
+
+	expected := metrics.ContainerNetStats{
+		{NetworkName: "eth0", BytesRcvd: 10, PacketsRcvd: 1, BytesSent: 20, PacketsSent: 2},
+		{NetworkName: "eth1", BytesRcvd: 564655295, PacketsRcvd: 384960, BytesSent: 3043269, PacketsSent: 54355},
+	}
+	assert.Equal(t, expected, convertMetaV4NetworkStats(networks))
+	assert.Len(t, convertMetaV4NetworkStats(nil), 0)
+}
+
 func TestParseContainerNetworkAddresses(t *testing.T) {
 	ports := []v2.Port{
 		{
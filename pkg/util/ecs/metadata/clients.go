@@ -17,6 +17,7 @@ import (
 	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
 	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 )
 
 var globalUtil util
@@ -25,12 +26,15 @@ type util struct {
 	// used to setup the ECSUtil
 	initRetryV1 retry.Retrier
 	initRetryV3 retry.Retrier
+	initRetryV4 retry.Retrier
 	initV1      sync.Once
 	initV2      sync.Once
 	initV3      sync.Once
+	initV4      sync.Once
 	v1          *v1.Client
 	v2          *v2.Client
 	v3          *v3.Client
+	v4          *v4.Client
 }
 
 // V1 returns a client for the ECS metadata API v1, also called introspection
@@ -89,6 +93,37 @@ func V3FromCurrentTask() (*v3.Client, error) {
 	return globalUtil.v3, nil
 }
 
+// V4 returns a client for the ECS metadata API v4 by detecting the endpoint
+// address for the specified container. Returns an error if it was not possible
+// to detect the endpoint address.
+func V4(containerID string) (*v4.Client, error) {
+	agentURL, err := getAgentURLFromDocker(containerID, v4.DefaultMetadataURIv4EnvVariable)
+	if err != nil {
+		return nil, err
+	}
+	return v4.NewClient(agentURL), nil
+}
+
+// V4FromCurrentTask returns a client for the ECS metadata API v4 by detecting
+// the endpoint address from the task the executable is running in. Returns an
+// error if it was not possible to detect the endpoint address.
+func V4FromCurrentTask() (*v4.Client, error) {
+	globalUtil.initV4.Do(func() {
+		globalUtil.initRetryV4.SetupRetrier(&retry.Config{
+			Name:              "ecsutil-meta-v4",
+			AttemptMethod:     initV4,
+			Strategy:          retry.Backoff,
+			InitialRetryDelay: 1 * time.Second,
+			MaxRetryDelay:     5 * time.Minute,
+		})
+	})
+	if err := globalUtil.initRetryV4.TriggerRetry(); err != nil {
+		log.Debugf("ECS metadata v4 client init error: %s", err)
+		return nil, err
+	}
+	return globalUtil.v4, nil
+}
+
 // newAutodetectedClientV1 detects the metadata v1 API endpoint and creates a new
 // client for it. Returns an error if it was not possible to find the endpoint.
 func newAutodetectedClientV1() (*v1.Client, error) {
@@ -102,7 +137,7 @@ func newAutodetectedClientV1() (*v1.Client, error) {
 // newClientV3ForContainer detects the metadata API v3 endpoint for the specified
 // container and creates a new client for it.
 func newClientV3ForContainer(id string) (*v3.Client, error) {
-	agentURL, err := getAgentV3URLFromDocker(id)
+	agentURL, err := getAgentURLFromDocker(id, v3.DefaultMetadataURIEnvVariable)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +147,7 @@ func newClientV3ForContainer(id string) (*v3.Client, error) {
 // newClientV3ForCurrentTask detects the metadata API v3 endpoint from the current
 // task and creates a new client for it.
 func newClientV3ForCurrentTask() (*v3.Client, error) {
-	agentURL, err := getAgentV3URLFromEnv()
+	agentURL, err := getAgentURLFromEnv(v3.DefaultMetadataURIEnvVariable)
 	if err != nil {
 		return nil, err
 	}
@@ -136,3 +171,12 @@ func initV3() error {
 	globalUtil.v3 = client
 	return nil
 }
+
+func initV4() error {
+	agentURL, err := getAgentURLFromEnv(v4.DefaultMetadataURIv4EnvVariable)
+	if err != nil {
+		return err
+	}
+	globalUtil.v4 = v4.NewClient(agentURL)
+	return nil
+}
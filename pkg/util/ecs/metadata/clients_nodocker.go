@@ -13,6 +13,7 @@ import (
 	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
 	v2 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v2"
 	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
+	v4 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v4"
 )
 
 // V1 returns a client for the ECS metadata API v1, also called introspection
@@ -41,3 +42,17 @@ func V3(containerID string) (*v3.Client, error) {
 func V3FromCurrentTask() (*v3.Client, error) {
 	return nil, docker.ErrDockerNotCompiled
 }
+
+// V4 returns a client for the ECS metadata API v4 by detecting the endpoint
+// address for the specified container. Returns an error if it was not possible
+// to detect the endpoint address.
+func V4(containerID string) (*v4.Client, error) {
+	return nil, docker.ErrDockerNotCompiled
+}
+
+// V4FromCurrentTask returns a client for the ECS metadata API v4 by detecting
+// the endpoint address from the task the executable is running in. Returns an
+// error if it was not possible to detect the endpoint address.
+func V4FromCurrentTask() (*v4.Client, error) {
+	return nil, docker.ErrDockerNotCompiled
+}
@@ -22,7 +22,6 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
-	v3 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v3"
 )
 
 func detectAgentV1URL() (string, error) {
@@ -116,15 +115,20 @@ func testURLs(urls []string, timeout time.Duration) string {
 	return ""
 }
 
-func getAgentV3URLFromEnv() (string, error) {
-	agentURL, found := os.LookupEnv(v3.DefaultMetadataURIEnvVariable)
+// getAgentURLFromEnv returns the metadata endpoint URI from the environment
+// variable of the executable, ECS_CONTAINER_METADATA_URI for v3 or
+// ECS_CONTAINER_METADATA_URI_V4 for v4
+func getAgentURLFromEnv(envVar string) (string, error) {
+	agentURL, found := os.LookupEnv(envVar)
 	if !found {
-		return "", fmt.Errorf("Could not initialize client: missing metadata v3 URL")
+		return "", fmt.Errorf("Could not initialize client: missing %s environment variable", envVar)
 	}
 	return agentURL, nil
 }
 
-func getAgentV3URLFromDocker(containerID string) (string, error) {
+// getAgentURLFromDocker returns the metadata endpoint URI from the environment
+// of the container
+func getAgentURLFromDocker(containerID, envVar string) (string, error) {
 	du, err := docker.GetDockerUtil()
 	if err != nil {
 		return "", err
@@ -136,18 +140,16 @@ func getAgentV3URLFromDocker(containerID string) (string, error) {
 	}
 
 	for _, env := range container.Config.Env {
-		substrings := strings.Split(env, "=")
+		substrings := strings.SplitN(env, "=", 2)
 		if len(substrings) != 2 {
 			log.Tracef("invalid container env format: %s", env)
+			continue
 		}
 
-		k := substrings[0]
-		v := substrings[1]
-
-		if k == v3.DefaultMetadataURIEnvVariable {
-			return v, nil
+		if substrings[0] == envVar {
+			return substrings[1], nil
 		}
 	}
 
-	return "", fmt.Errorf("metadata v3 URL not found in container %s", containerID)
+	return "", fmt.Errorf("%s not found in container %s", envVar, containerID)
 }
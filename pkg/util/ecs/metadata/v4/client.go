@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2020 Datadog, Inc.
+
+// +build docker
+
+package v4
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"time"
+)
+
+const (
+	// DefaultMetadataURIv4EnvVariable is the environment variable holding the metadata v4 endpoint URI.
+	DefaultMetadataURIv4EnvVariable = "ECS_CONTAINER_METADATA_URI_V4"
+
+	// Metadata v4 API paths
+	taskMetadataPath         = "/task"
+	taskMetadataWithTagsPath = "/taskWithTags"
+	taskStatsPath            = "/task/stats"
+
+	// Default client configuration
+	endpointTimeout = 500 * time.Millisecond
+)
+
+// Client represents a client for a metadata v4 API endpoint.
+type Client struct {
+	agentURL string
+}
+
+// NewClient creates a new client for the specified metadata v4 API endpoint.
+func NewClient(agentURL string) *Client {
+	return &Client{
+		agentURL: agentURL,
+	}
+}
+
+// GetContainer returns metadata for the container of the endpoint.
+func (c *Client) GetContainer() (*Container, error) {
+	var ct Container
+	if err := c.get("", &ct); err != nil {
+		return nil, err
+	}
+	return &ct, nil
+}
+
+// GetTask returns the task of the endpoint.
+func (c *Client) GetTask() (*Task, error) {
+	return c.getTaskMetadataAtPath(taskMetadataPath)
+}
+
+// GetTaskWithTags returns the task of the endpoint, including propagated resource tags.
+func (c *Client) GetTaskWithTags() (*Task, error) {
+	return c.getTaskMetadataAtPath(taskMetadataWithTagsPath)
+}
+
+// GetTaskStats returns the statistics of the containers of the task, by Docker ID.
+func (c *Client) GetTaskStats() (map[string]*ContainerStats, error) {
+	var s map[string]*ContainerStats
+	if err := c.get(taskStatsPath, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *Client) get(path string, v interface{}) error {
+	client := http.Client{Timeout: endpointTimeout}
+	url, err := c.makeURL(path)
+	if err != nil {
+		return fmt.Errorf("Error constructing metadata request URL: %s", err)
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected HTTP status code in metadata v4 reply: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Failed to decode metadata v4 JSON payload to type %s: %s", reflect.TypeOf(v), err)
+	}
+
+	return nil
+}
+
+func (c *Client) getTaskMetadataAtPath(path string) (*Task, error) {
+	var t Task
+	if err := c.get(path, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (c *Client) makeURL(requestPath string) (string, error) {
+	u, err := url.Parse(c.agentURL)
+	if err != nil {
+		return "", err
+	}
+	// As in v3, the agent URL contains a subpath that looks like "/v4/<id>"
+	// so we must make sure not to dismiss the current URL path.
+	u.Path = path.Join(u.Path, requestPath)
+	return u.String(), nil
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2020 Datadog, Inc.
+
+// +build docker
+
+package v4
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	taskResponse = `{
+  "Cluster": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+  "TaskARN": "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c",
+  "Family": "curltest",
+  "ServiceName": "curltest-service",
+  "Revision": "26",
+  "DesiredStatus": "RUNNING",
+  "KnownStatus": "RUNNING",
+  "LaunchType": "FARGATE",
+  "AvailabilityZone": "us-west-2d",
+  "Containers": [
+    {
+      "DockerID": "ea32192c8553fbff06c9340478a2ff089b2bb5646fb718b4ee206641c9086d66",
+      "Name": "curl",
+      "DockerName": "ecs-curltest-26-curl-cca48e8dcadd97805600",
+      "Image": "111122223333.dkr.ecr.us-west-2.amazonaws.com/curltest:latest",
+      "KnownStatus": "RUNNING",
+      "DesiredStatus": "RUNNING",
+      "Type": "NORMAL",
+      "LogDriver": "awslogs",
+      "ContainerARN": "arn:aws:ecs:us-west-2:111122223333:container/0206b271-b33f-47ab-86c6-a0ba208a70a9",
+      "Networks": [
+        {
+          "NetworkMode": "awsvpc",
+          "IPv4Addresses": ["10.0.2.106"],
+          "IPv4SubnetCIDRBlock": "10.0.2.0/24",
+          "MACAddress": "12:22:80:c1:31:4e"
+        }
+      ]
+    }
+  ]
+}`
+	taskStatsResponse = `{
+  "ea32192c8553fbff06c9340478a2ff089b2bb5646fb718b4ee206641c9086d66": {
+    "networks": {
+      "eth1": {
+        "rx_bytes": 564655295,
+        "rx_packets": 384960,
+        "rx_errors": 0,
+        "rx_dropped": 0,
+        "tx_bytes": 3043269,
+        "tx_packets": 54355,
+        "tx_errors": 0,
+        "tx_dropped": 0
+      }
+    }
+  }
+}`
+)
+
+func TestGetTask(t *testing.T) {
+	ecsinterface, err := testutil.NewDummyECS(
+		testutil.RawHandlerOption("/v4/1234/task", taskResponse),
+	)
+	require.NoError(t, err)
+
+	ts, _, err := ecsinterface.Start()
+	require.NoError(t, err)
+	defer ts.Close()
+
+	task, err := NewClient(ts.URL + "/v4/1234").GetTask()
+	require.NoError(t, err)
+
+	assert.Equal(t, "curltest", task.Family)
+	assert.Equal(t, "26", task.Version)
+	assert.Equal(t, "curltest-service", task.ServiceName)
+	assert.Equal(t, "FARGATE", task.LaunchType)
+	assert.Equal(t, "us-west-2d", task.AvailabilityZone)
+	require.Len(t, task.Containers, 1)
+	assert.Equal(t, "ea32192c8553fbff06c9340478a2ff089b2bb5646fb718b4ee206641c9086d66", task.Containers[0].DockerID)
+	assert.Equal(t, "awslogs", task.Containers[0].LogDriver)
+	require.Len(t, task.Containers[0].Networks, 1)
+	assert.Equal(t, "10.0.2.0/24", task.Containers[0].Networks[0].IPv4SubnetCIDRBlock)
+}
+
+func TestGetTaskStats(t *testing.T) {
+	ecsinterface, err := testutil.NewDummyECS(
+		testutil.RawHandlerOption("/v4/1234/task/stats", taskStatsResponse),
+	)
+	require.NoError(t, err)
+
+	ts, _, err := ecsinterface.Start()
+	require.NoError(t, err)
+	defer ts.Close()
+
+	stats, err := NewClient(ts.URL + "/v4/1234").GetTaskStats()
+	require.NoError(t, err)
+
+	require.Contains(t, stats, "ea32192c8553fbff06c9340478a2ff089b2bb5646fb718b4ee206641c9086d66")
+	network := stats["ea32192c8553fbff06c9340478a2ff089b2bb5646fb718b4ee206641c9086d66"].Networks["eth1"]
+	assert.Equal(t, uint64(564655295), network.RxBytes)
+	assert.Equal(t, uint64(54355), network.TxPackets)
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2020 Datadog, Inc.
+
+package v4
+
+// Task represents a task as returned by the ECS metadata API v4.
+type Task struct {
+	ClusterName           string             `json:"Cluster"`
+	Containers            []Container        `json:"Containers"`
+	KnownStatus           string             `json:"KnownStatus"`
+	TaskARN               string             `json:"TaskARN"`
+	Family                string             `json:"Family"`
+	Version               string             `json:"Revision"`
+	Limits                map[string]float64 `json:"Limits,omitempty"`
+	DesiredStatus         string             `json:"DesiredStatus"`
+	LaunchType            string             `json:"LaunchType,omitempty"` // present only in v4
+	AvailabilityZone      string             `json:"AvailabilityZone,omitempty"`
+	ServiceName           string             `json:"ServiceName,omitempty"`
+	ContainerInstanceTags map[string]string  `json:"ContainerInstanceTags,omitempty"`
+	TaskTags              map[string]string  `json:"TaskTags,omitempty"`
+}
+
+// Container represents a container within a task.
+type Container struct {
+	Name          string            `json:"Name"`
+	Limits        map[string]uint64 `json:"Limits,omitempty"`
+	ImageID       string            `json:"ImageID,omitempty"`
+	StartedAt     string            `json:"StartedAt,omitempty"` // 2017-11-17T17:14:07.781711848Z
+	DockerName    string            `json:"DockerName"`
+	Type          string            `json:"Type"`
+	Image         string            `json:"Image"`
+	Labels        map[string]string `json:"Labels,omitempty"`
+	KnownStatus   string            `json:"KnownStatus"`
+	DesiredStatus string            `json:"DesiredStatus"`
+	DockerID      string            `json:"DockerID"`
+	CreatedAt     string            `json:"CreatedAt,omitempty"`
+	Networks      []Network         `json:"Networks,omitempty"`
+	Ports         []Port            `json:"Ports,omitempty"`
+	LogDriver     string            `json:"LogDriver,omitempty"`    // present only in v4
+	ContainerARN  string            `json:"ContainerARN,omitempty"` // present only in v4
+}
+
+// Network represents the network of a container
+type Network struct {
+	NetworkMode              string   `json:"NetworkMode"`   // supports awsvpc and bridge
+	IPv4Addresses            []string `json:"IPv4Addresses"` // one-element list
+	IPv4SubnetCIDRBlock      string   `json:"IPv4SubnetCIDRBlock,omitempty"`
+	MACAddress               string   `json:"MACAddress,omitempty"`
+	PrivateDNSName           string   `json:"PrivateDNSName,omitempty"`
+	SubnetGatewayIPv4Address string   `json:"SubnetGatewayIpv4Address,omitempty"`
+}
+
+// Port represents the ports of a container
+type Port struct {
+	ContainerPort uint16 `json:"ContainerPort,omitempty"`
+	Protocol      string `json:"Protocol,omitempty"`
+	HostPort      uint16 `json:"HostPort,omitempty"`
+}
+
+// ContainerStats represents the statistics of a container as returned by the
+// ECS metadata API v4, only the network statistics are decoded.
+type ContainerStats struct {
+	Networks map[string]NetworkStats `json:"networks,omitempty"` // by interface name
+}
+
+// NetworkStats represents the statistics of a network interface
+type NetworkStats struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	RxDropped uint64 `json:"rx_dropped"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors"`
+	TxDropped uint64 `json:"tx_dropped"`
+}
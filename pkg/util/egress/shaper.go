@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package egress shapes the bandwidth used by the agent to send its payloads.
+package egress
+
+import (
+	"container/heap"
+	"context"
+	"expvar"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The products sharing the egress bandwidth
+const (
+	Metrics = "metrics"
+	Logs    = "logs"
+	APM     = "apm"
+)
+
+var (
+	egressExpvars         = expvar.NewMap("egress")
+	expvarsThrottledTimes = expvar.Map{}
+
+	tlmThrottledTime = telemetry.NewCounter("egress", "throttled_seconds",
+		[]string{"product"}, "Time spent waiting for the egress bandwidth, in seconds")
+
+	defaultShaper     *Shaper
+	defaultShaperOnce sync.Once
+)
+
+func init() {
+	expvarsThrottledTimes.Init()
+	egressExpvars.Set("ThrottledSeconds", &expvarsThrottledTimes)
+}
+
+// Shaper limits the bytes sent per second by the products to a maximum egress rate,
+// sharing it between the products in proportion of their weights. A product only
+// gets its share when it's sending: the bandwidth it leaves unused goes to the others.
+//
+// The products running in other processes (e.g. APM in the trace-agent) can't borrow
+// the unused bandwidth of this process, their share is reserved to them.
+type Shaper struct {
+	m           sync.Mutex
+	rate        float64            // bytes per second shared by all the products
+	weights     map[string]float64 // weights of all the products
+	products    map[string]*product
+	capacity    float64 // bytes per second shared by the products of this process
+	virtualTime float64
+	nextFree    time.Time // time at which the last granted payload is sent at the capacity
+	queue       requestQueue
+	seq         uint64
+	timer       *time.Timer
+}
+
+type product struct {
+	name          string
+	weight        float64
+	finish        float64 // virtual finish time of the last payload of the product
+	throttledTime *expvar.Float
+}
+
+// NewShaper returns a new Shaper of the given egress rate, in bytes per second.
+func NewShaper(rate float64, weights map[string]float64) *Shaper {
+	return &Shaper{
+		rate:     rate,
+		weights:  weights,
+		products: make(map[string]*product),
+	}
+}
+
+// newShaperFromConfig returns a Shaper configured from `forwarder_max_egress_rate` and
+// `forwarder_egress_weights`, or nil if the egress rate is not limited.
+func newShaperFromConfig() *Shaper {
+	rate := config.Datadog.GetFloat64("forwarder_max_egress_rate")
+	if rate <= 0 {
+		return nil
+	}
+
+	weights := map[string]float64{Metrics: 1, Logs: 1, APM: 1}
+	for name, value := range config.Datadog.GetStringMap("forwarder_egress_weights") {
+		weight, ok := toFloat64(value)
+		if !ok || weight <= 0 {
+			log.Warnf("Invalid egress weight for '%s': %v, it should be a positive number; 1 will be used", name, value)
+			continue
+		}
+		weights[name] = weight
+	}
+	log.Infof("The egress rate is limited to %.0f bytes per second, shared with the weights %v", rate, weights)
+	return NewShaper(rate, weights)
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// Limiter limits the egress of a product.
+type Limiter struct {
+	shaper  *Shaper
+	product *product
+}
+
+// NewLimiter returns the Limiter of the given product, shaped by the egress rate set in
+// the configuration. It's nil, which doesn't limit anything, if the egress rate is not limited.
+func NewLimiter(name string) *Limiter {
+	defaultShaperOnce.Do(func() {
+		defaultShaper = newShaperFromConfig()
+	})
+	if defaultShaper == nil {
+		return nil
+	}
+	return defaultShaper.Limiter(name)
+}
+
+// Limiter returns the Limiter of the given product, registering it in the products of this process.
+func (s *Shaper) Limiter(name string) *Limiter {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	p, found := s.products[name]
+	if !found {
+		weight, found := s.weights[name]
+		if !found {
+			weight = 1
+			s.weights[name] = weight
+		}
+		p = &product{
+			name:          name,
+			weight:        weight,
+			throttledTime: &expvar.Float{},
+		}
+		expvarsThrottledTimes.Set(name, p.throttledTime)
+		s.products[name] = p
+		s.updateCapacity()
+	}
+	return &Limiter{shaper: s, product: p}
+}
+
+func (s *Shaper) updateCapacity() {
+	var localWeight, totalWeight float64
+	for name, weight := range s.weights {
+		totalWeight += weight
+		if _, found := s.products[name]; found {
+			localWeight += weight
+		}
+	}
+	s.capacity = s.rate * localWeight / totalWeight
+}
+
+// Wait blocks until size bytes can be sent by the product, or until the context is done.
+func (l *Limiter) Wait(ctx context.Context, size int) error {
+	if l == nil {
+		return nil
+	}
+	return l.shaper.wait(ctx, l.product, size)
+}
+
+// wait uses start-time fair queuing: each payload is tagged with the virtual time at
+// which it would start if each product had a link of its share of the capacity, and
+// the payloads are sent in the order of their tags, at the capacity.
+func (s *Shaper) wait(ctx context.Context, p *product, size int) error {
+	s.m.Lock()
+	r := &request{
+		size:    size,
+		start:   math.Max(s.virtualTime, p.finish),
+		seq:     s.seq,
+		granted: make(chan struct{}),
+	}
+	s.seq++
+	p.finish = r.start + float64(size)/p.weight
+	heap.Push(&s.queue, r)
+	s.dispatch()
+	s.m.Unlock()
+
+	select {
+	case <-r.granted:
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	defer func() {
+		throttled := time.Since(start).Seconds()
+		p.throttledTime.Add(throttled)
+		tlmThrottledTime.Add(throttled, p.name)
+	}()
+
+	select {
+	case <-r.granted:
+		return nil
+	case <-ctx.Done():
+		s.m.Lock()
+		r.cancelled = true
+		s.m.Unlock()
+		return ctx.Err()
+	}
+}
+
+// dispatch grants the queued payloads while the link is free, and schedules the next
+// dispatch when it's busy. It must be called with the lock held.
+func (s *Shaper) dispatch() {
+	now := time.Now()
+	for s.queue.Len() > 0 && !s.nextFree.After(now) {
+		r := heap.Pop(&s.queue).(*request)
+		if r.cancelled {
+			continue
+		}
+		s.virtualTime = r.start
+		s.nextFree = now.Add(time.Duration(float64(r.size) / s.capacity * float64(time.Second)))
+		close(r.granted)
+	}
+	if s.queue.Len() > 0 && s.timer == nil {
+		s.timer = time.AfterFunc(s.nextFree.Sub(now), func() {
+			s.m.Lock()
+			defer s.m.Unlock()
+			s.timer = nil
+			s.dispatch()
+		})
+	}
+}
+
+type request struct {
+	size      int
+	start     float64 // virtual start time
+	seq       uint64  // breaks the ties between the start times
+	granted   chan struct{}
+	cancelled bool
+}
+
+// requestQueue is a heap of the requests ordered by their virtual start time
+type requestQueue []*request
+
+func (q requestQueue) Len() int { return len(q) }
+
+func (q requestQueue) Less(i, j int) bool {
+	if q[i].start == q[j].start {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].start < q[j].start
+}
+
+func (q requestQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *requestQueue) Push(x interface{}) { *q = append(*q, x.(*request)) }
+
+func (q *requestQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	r := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return r
+}
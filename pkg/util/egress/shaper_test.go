@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package egress
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	assert.NoError(t, l.Wait(context.Background(), 1000))
+}
+
+func TestShaperCapacity(t *testing.T) {
+	s := NewShaper(1000, map[string]float64{Metrics: 1, Logs: 1, APM: 2})
+	s.Limiter(Metrics)
+	assert.Equal(t, 250.0, s.capacity)
+	s.Limiter(Logs)
+	assert.Equal(t, 500.0, s.capacity)
+	// the unknown products have a weight of 1
+	s.Limiter("other")
+	assert.Equal(t, 600.0, s.capacity)
+}
+
+func TestShaperThrottles(t *testing.T) {
+	s := NewShaper(10000, map[string]float64{Metrics: 1})
+	l := s.Limiter(Metrics)
+
+	start := time.Now()
+	require.NoError(t, l.Wait(context.Background(), 1000))
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+	// the link is busy sending the first 1000 bytes for 100ms
+	require.NoError(t, l.Wait(context.Background(), 1000))
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.True(t, l.product.throttledTime.Value() > 0)
+}
+
+func TestShaperCancel(t *testing.T) {
+	s := NewShaper(1000, map[string]float64{Metrics: 1})
+	l := s.Limiter(Metrics)
+	require.NoError(t, l.Wait(context.Background(), 1000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx, 1000))
+}
+
+func TestShaperWeightedFairSharing(t *testing.T) {
+	s := NewShaper(100000, map[string]float64{Metrics: 3, Logs: 1, APM: 1})
+	metrics := s.Limiter(Metrics)
+	logs := s.Limiter(Logs)
+	apm := s.Limiter(APM)
+
+	// the link is busy for 100ms, while the payloads of the other products are queued
+	require.NoError(t, apm.Wait(context.Background(), 10000))
+
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	var order []string
+	enqueue := func(l *Limiter) {
+		s.m.Lock()
+		queued := s.queue.Len()
+		s.m.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Wait(context.Background(), 100)
+			m.Lock()
+			order = append(order, l.product.name)
+			m.Unlock()
+		}()
+		for {
+			s.m.Lock()
+			done := s.queue.Len() > queued
+			s.m.Unlock()
+			if done {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		enqueue(metrics)
+	}
+	for i := 0; i < 4; i++ {
+		enqueue(logs)
+	}
+	wg.Wait()
+
+	// metrics gets three times the bandwidth of logs
+	assert.Equal(t, []string{Metrics, Logs, Metrics, Metrics, Metrics, Logs, Logs, Logs}, order)
+}
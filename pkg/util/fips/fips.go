@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package fips implements the FIPS mode of the agent: the outbound TLS connections
+// are either made by a FIPS-validated crypto provider the agent is built with (with
+// the `boringcrypto` build tag), or delegated to a local FIPS proxy. The components
+// that can't comply with it don't send anything.
+package fips
+
+import (
+	"crypto/tls"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// The components sending data out of the host
+const (
+	Forwarder   = "forwarder"
+	LogsAgent   = "logs_agent"
+	TraceWriter = "trace_writer"
+)
+
+// proxyPortOffsets are the offsets of the ports of the local FIPS proxy
+// receiving the data of each component, from `fips.port_range_start`
+var proxyPortOffsets = map[string]int{
+	Forwarder:   1,
+	LogsAgent:   2,
+	TraceWriter: 3,
+}
+
+// cipherSuites are the TLS 1.2 cipher suites approved by NIST SP 800-52
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+var (
+	fipsExpvars      = expvar.NewMap("fips")
+	componentsStatus = expvar.Map{}
+
+	// cryptoProvider is the FIPS-validated crypto provider the agent is built with, if any
+	cryptoProvider = provider
+)
+
+func init() {
+	componentsStatus.Init()
+	fipsExpvars.Set("Components", &componentsStatus)
+	fipsExpvars.Set("Mode", expvar.Func(func() interface{} { return mode() }))
+}
+
+// Enabled returns true if the FIPS mode is enabled.
+func Enabled() bool {
+	return config.Datadog.GetBool("fips.enabled")
+}
+
+// ProxyAddress returns the address of the local FIPS proxy receiving the data of the
+// component, if the FIPS mode delegates the TLS connections to a proxy.
+func ProxyAddress(component string) (string, int, bool) {
+	host := config.Datadog.GetString("fips.local_address")
+	if !Enabled() || host == "" {
+		return "", 0, false
+	}
+	return host, config.Datadog.GetInt("fips.port_range_start") + proxyPortOffsets[component], true
+}
+
+// ProxyURL returns the URL of the local FIPS proxy receiving the data of the component,
+// if the FIPS mode delegates the TLS connections to a proxy.
+func ProxyURL(component string) (string, bool) {
+	host, port, proxied := ProxyAddress(component)
+	if !proxied {
+		return "", false
+	}
+	// the proxy is local, the data is only encrypted by the proxy
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port)), true
+}
+
+// Check returns an error if the component can't comply with the FIPS mode, and
+// reports its compliance in the status page. It's nil when the FIPS mode is disabled.
+func Check(component string) error {
+	if !Enabled() {
+		return nil
+	}
+
+	if proxyURL, proxied := ProxyURL(component); proxied {
+		return compliant(component, fmt.Sprintf("Sending through the FIPS proxy %s", proxyURL))
+	}
+
+	if cryptoProvider == "" {
+		return NotCompliant(component, errors.New("the agent isn't built with a FIPS-validated crypto provider, and no FIPS proxy is set in `fips.local_address`"))
+	}
+	if config.Datadog.GetBool("skip_ssl_validation") {
+		return NotCompliant(component, errors.New("the TLS certificates aren't validated, `skip_ssl_validation` is set"))
+	}
+	return compliant(component, fmt.Sprintf("Sending with %s", cryptoProvider))
+}
+
+// NotCompliant reports that the component can't comply with the FIPS mode because of err,
+// and returns the error to fail with.
+func NotCompliant(component string, err error) error {
+	status := &expvar.String{}
+	status.Set(fmt.Sprintf("Not compliant, not sending anything: %v", err))
+	componentsStatus.Set(component, status)
+
+	err = fmt.Errorf("%s doesn't comply with the FIPS mode: %v", component, err)
+	log.Error(err)
+	return err
+}
+
+func compliant(component string, message string) error {
+	status := &expvar.String{}
+	status.Set(message)
+	componentsStatus.Set(component, status)
+	return nil
+}
+
+// mode describes how the TLS connections are made in FIPS mode, for the status page
+func mode() string {
+	switch {
+	case !Enabled():
+		return ""
+	case config.Datadog.GetString("fips.local_address") != "":
+		return fmt.Sprintf("TLS delegated to the local FIPS proxy at %s", config.Datadog.GetString("fips.local_address"))
+	case cryptoProvider != "":
+		return fmt.Sprintf("TLS made by the FIPS-validated crypto provider %s", cryptoProvider)
+	default:
+		return "No FIPS-validated crypto provider nor local FIPS proxy"
+	}
+}
+
+// ApplyTLSConfig restricts the TLS configuration to the approved cipher suites and
+// curves when the FIPS mode is enabled. The TLS 1.3 cipher suites can't be configured,
+// so the connections use TLS 1.2.
+func ApplyTLSConfig(tlsConfig *tls.Config) {
+	if !Enabled() {
+		return
+	}
+	tlsConfig.MinVersion = tls.VersionTLS12
+	tlsConfig.MaxVersion = tls.VersionTLS12
+	tlsConfig.CipherSuites = cipherSuites
+	tlsConfig.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fips
+
+import (
+	"crypto/tls"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestFIPSDisabled(t *testing.T) {
+	assert.NoError(t, Check(Forwarder))
+	_, proxied := ProxyURL(Forwarder)
+	assert.False(t, proxied)
+
+	tlsConfig := &tls.Config{}
+	ApplyTLSConfig(tlsConfig)
+	assert.Nil(t, tlsConfig.CipherSuites)
+}
+
+func TestFIPSProxy(t *testing.T) {
+	config.Datadog.Set("fips.enabled", true)
+	config.Datadog.Set("fips.local_address", "localhost")
+	config.Datadog.Set("fips.port_range_start", 9803)
+	defer config.Datadog.Set("fips.enabled", false)
+	defer config.Datadog.Set("fips.local_address", "")
+
+	proxyURL, proxied := ProxyURL(LogsAgent)
+	assert.True(t, proxied)
+	assert.Equal(t, "http://localhost:9805", proxyURL)
+	assert.NoError(t, Check(LogsAgent))
+	assert.Equal(t, "Sending through the FIPS proxy http://localhost:9805", componentsStatus.Get(LogsAgent).(*expvar.String).Value())
+}
+
+func TestFIPSProvider(t *testing.T) {
+	config.Datadog.Set("fips.enabled", true)
+	defer config.Datadog.Set("fips.enabled", false)
+	defer func(p string) { cryptoProvider = p }(cryptoProvider)
+
+	cryptoProvider = ""
+	assert.Error(t, Check(TraceWriter))
+	assert.Contains(t, componentsStatus.Get(TraceWriter).String(), "Not compliant")
+
+	cryptoProvider = "BoringCrypto"
+	assert.NoError(t, Check(TraceWriter))
+	config.Datadog.Set("skip_ssl_validation", true)
+	defer config.Datadog.Set("skip_ssl_validation", false)
+	assert.Error(t, Check(TraceWriter))
+
+	tlsConfig := &tls.Config{}
+	ApplyTLSConfig(tlsConfig)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MaxVersion)
+	assert.Equal(t, cipherSuites, tlsConfig.CipherSuites)
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build boringcrypto
+
+package fips
+
+import (
+	// restricts crypto/tls to the FIPS-approved settings
+	_ "crypto/tls/fipsonly"
+)
+
+// provider is the FIPS-validated crypto provider the agent is built with
+const provider = "BoringCrypto"
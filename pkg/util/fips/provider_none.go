@@ -0,0 +1,11 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !boringcrypto
+
+package fips
+
+// provider is empty, the agent isn't built with a FIPS-validated crypto provider
+const provider = ""
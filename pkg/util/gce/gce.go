@@ -6,6 +6,7 @@
 package gce
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -104,6 +105,52 @@ func GetNetworkID() (string, error) {
 
 }
 
+// GetInstanceMetadataTags returns host tags derived from the instance metadata:
+// whether the instance is preemptible and, for instances created by a managed
+// instance group, the name of that group.
+func GetInstanceMetadataTags() ([]string, error) {
+	preemptible, err := getResponse(metadataURL + "/instance/scheduling/preemptible")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the instance scheduling: %s", err)
+	}
+
+	tags := []string{"preemptible:" + strings.ToLower(strings.TrimSpace(preemptible))}
+
+	// created-by is only set for instances managed by an instance group manager,
+	// e.g. projects/123456789012/zones/us-central1-a/instanceGroupManagers/my-group
+	createdBy, err := getResponse(metadataURL + "/instance/attributes/created-by")
+	if err == nil {
+		parts := strings.Split(strings.TrimSpace(createdBy), "/")
+		if len(parts) >= 2 && parts[len(parts)-2] == "instanceGroupManagers" {
+			tags = append(tags, "instance_group:"+parts[len(parts)-1])
+		}
+	}
+
+	return tags, nil
+}
+
+// GetServiceAccountToken returns an OAuth2 access token of the default service
+// account of the instance, along with its expiration time.
+func GetServiceAccountToken() (string, time.Time, error) {
+	res, err := getResponse(metadataURL + "/instance/service-accounts/default/token")
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to get the service account token: %s", err)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(res), &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to parse the service account token: %s", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("empty service account token")
+	}
+
+	return token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
+
 func getResponseWithMaxLength(endpoint string, maxLength int) (string, error) {
 	result, err := getResponse(endpoint)
 	if err != nil {
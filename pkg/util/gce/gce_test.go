@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,3 +149,21 @@ func TestGetNetworkMultipleVPC(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "more than one network interface")
 }
+
+func TestGetServiceAccountToken(t *testing.T) {
+	var lastRequest *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`)
+		lastRequest = r
+	}))
+	defer ts.Close()
+	metadataURL = ts.URL
+
+	token, expiry, err := GetServiceAccountToken()
+	require.NoError(t, err)
+	assert.Equal(t, "ya29.token", token)
+	assert.True(t, expiry.After(time.Now()))
+	assert.Equal(t, "/instance/service-accounts/default/token", lastRequest.URL.Path)
+	assert.Equal(t, "Google", lastRequest.Header.Get("Metadata-Flavor"))
+}
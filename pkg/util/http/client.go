@@ -12,9 +12,11 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fips"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -40,19 +42,22 @@ func CreateHTTPTransport() *http.Transport {
 	if config.Datadog.GetBool("force_tls_12") {
 		tlsConfig.MinVersion = tls.VersionTLS12
 	}
+	fips.ApplyTLSConfig(tlsConfig)
+
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		// Enables TCP keepalives to detect broken connections
+		KeepAlive: 30 * time.Second,
+		// Disable RFC 6555 Fast Fallback ("Happy Eyeballs")
+		FallbackDelay: -1 * time.Nanosecond,
+	}
 
 	// Most of the following timeouts are a copy of Golang http.DefaultTransport
 	// They are mostly used to act as safeguards in case we forget to add a general
 	// timeout to our http clients.
 	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-		DialContext: (&net.Dialer{
-			Timeout: 30 * time.Second,
-			// Enables TCP keepalives to detect broken connections
-			KeepAlive: 30 * time.Second,
-			// Disable RFC 6555 Fast Fallback ("Happy Eyeballs")
-			FallbackDelay: -1 * time.Nanosecond,
-		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		DialContext:         dialer.DialContext,
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 5,
 		// This parameter is set to avoid connections sitting idle in the pool indefinitely
@@ -62,7 +67,18 @@ func CreateHTTPTransport() *http.Transport {
 	}
 
 	if proxies := config.GetProxies(); proxies != nil {
-		transport.Proxy = GetProxyTransportFunc(proxies)
+		switch strings.ToLower(proxies.Auth) {
+		case config.ProxyAuthNTLM:
+			// the NTLM handshake is made on the connections to the proxies,
+			// so the requests are tunneled through them when dialing
+			transport.DialContext = newNTLMProxyDialer(proxies, dialer.DialContext).DialContext
+		case "", config.ProxyAuthBasic:
+			// the credentials of the proxy URLs are sent by the transport
+			transport.Proxy = GetProxyTransportFunc(proxies)
+		default:
+			log.Warnf("Unknown proxy authentication '%s', using %s", proxies.Auth, config.ProxyAuthBasic)
+			transport.Proxy = GetProxyTransportFunc(proxies)
+		}
 	}
 
 	return transport
@@ -72,45 +88,101 @@ func CreateHTTPTransport() *http.Transport {
 // would return the right proxy depending on the configuration.
 func GetProxyTransportFunc(p *config.Proxy) func(*http.Request) (*url.URL, error) {
 	return func(r *http.Request) (*url.URL, error) {
+		return GetProxyForURL(p, r.URL)
+	}
+}
+
+// GetProxyForURL returns the proxy to use for u: the proxy of its endpoint when it is
+// overridden, none when it matches the no_proxy list, the proxy of its scheme otherwise.
+func GetProxyForURL(p *config.Proxy, u *url.URL) (*url.URL, error) {
+	confProxy := ""
+	if endpointProxy, found := lookupEndpointProxy(p.Endpoints, u); found {
+		if endpointProxy == "" {
+			log.Debugf("URL '%s' is configured to not use any proxy", SanitizeURL(u.String()))
+			return nil, nil
+		}
+		confProxy = endpointProxy
+	} else {
 		// check no_proxy list first
-		for _, host := range p.NoProxy {
-			if r.URL.Host == host {
-				log.Debugf("URL match no_proxy list item '%s': not using any proxy", host)
-				return nil, nil
-			}
+		if entry, found := matchNoProxy(p.NoProxy, u); found {
+			log.Debugf("URL match no_proxy list item '%s': not using any proxy", entry)
+			return nil, nil
 		}
 
 		// check proxy by scheme
-		confProxy := ""
-		if r.URL.Scheme == "http" {
+		if u.Scheme == "http" {
 			confProxy = p.HTTP
-		} else if r.URL.Scheme == "https" {
+		} else if u.Scheme == "https" {
 			confProxy = p.HTTPS
 		} else {
-			log.Warnf("Proxy configuration do not support scheme '%s'", r.URL.Scheme)
+			log.Warnf("Proxy configuration do not support scheme '%s'", u.Scheme)
 		}
+	}
 
-		if confProxy != "" {
-			proxyURL, err := url.Parse(confProxy)
-			if err != nil {
-				err := fmt.Errorf("Could not parse the proxy URL for scheme %s from configuration: %s", r.URL.Scheme, err)
-				log.Error(err.Error())
-				return nil, err
-			}
-			userInfo := ""
-			if proxyURL.User != nil {
-				if _, isSet := proxyURL.User.Password(); isSet {
-					userInfo = "*****:*****@"
-				} else {
-					userInfo = "*****@"
-				}
-			}
-
-			log.Debugf("Using proxy %s://%s%s for URL '%s'", proxyURL.Scheme, userInfo, proxyURL.Host, SanitizeURL(r.URL.String()))
-			return proxyURL, nil
+	if confProxy != "" {
+		proxyURL, err := url.Parse(confProxy)
+		if err != nil {
+			err := fmt.Errorf("Could not parse the proxy URL for scheme %s from configuration: %s", u.Scheme, err)
+			log.Error(err.Error())
+			return nil, err
 		}
 
-		// no proxy set for this request
-		return nil, nil
+		log.Debugf("Using proxy %s for URL '%s'", SanitizeProxyURL(proxyURL), SanitizeURL(u.String()))
+		return proxyURL, nil
+	}
+
+	// no proxy set for this request
+	return nil, nil
+}
+
+// SanitizeProxyURL returns the proxy URL without its credentials, so that it can be logged safely.
+func SanitizeProxyURL(proxyURL *url.URL) string {
+	userInfo := ""
+	if proxyURL.User != nil {
+		if _, isSet := proxyURL.User.Password(); isSet {
+			userInfo = "*****:*****@"
+		} else {
+			userInfo = "*****@"
+		}
+	}
+	return fmt.Sprintf("%s://%s%s", proxyURL.Scheme, userInfo, proxyURL.Host)
+}
+
+// lookupEndpointProxy returns the proxy configured for the host of u or for one of its parent
+// domains, the most specific one first. The endpoints are either hosts or URLs.
+func lookupEndpointProxy(endpoints map[string]string, u *url.URL) (string, bool) {
+	hostname := strings.ToLower(u.Hostname())
+	matched, matchedProxy := "", ""
+	for endpoint, proxy := range endpoints {
+		host := endpoint
+		if endpointURL, err := url.Parse(endpoint); err == nil && endpointURL.Host != "" {
+			host = endpointURL.Host
+		}
+		host = strings.ToLower(host)
+		if host != strings.ToLower(u.Host) && host != hostname && !strings.HasSuffix(hostname, "."+host) {
+			continue
+		}
+		if len(host) > len(matched) {
+			matched, matchedProxy = host, proxy
+		}
+	}
+	return matchedProxy, matched != ""
+}
+
+// matchNoProxy returns the entry of the no_proxy list matching u: either its host,
+// with or without port, or a CIDR containing its IP.
+func matchNoProxy(noProxy []string, u *url.URL) (string, bool) {
+	ip := net.ParseIP(u.Hostname())
+	for _, entry := range noProxy {
+		if entry == u.Host || entry == u.Hostname() {
+			return entry, true
+		}
+		if ip == nil || !strings.Contains(entry, "/") {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return entry, true
+		}
 	}
+	return "", false
 }
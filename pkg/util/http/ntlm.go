@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-ntlmssp"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// ntlmProxyDialer tunnels the connections through the proxies authenticating with NTLM.
+// The NTLM handshake authenticates a connection rather than a request, so it can't be
+// made by the proxy function of a transport.
+type ntlmProxyDialer struct {
+	proxies *config.Proxy
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+func newNTLMProxyDialer(proxies *config.Proxy, dial func(ctx context.Context, network, addr string) (net.Conn, error)) *ntlmProxyDialer {
+	return &ntlmProxyDialer{
+		proxies: proxies,
+		dial:    dial,
+	}
+}
+
+// DialContext connects to addr through its proxy if any.
+func (d *ntlmProxyDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	// the scheme of the requests is not known when dialing,
+	// the connections to the port 80 are considered plain HTTP ones
+	scheme := "https"
+	if _, port, err := net.SplitHostPort(addr); err == nil && port == "80" {
+		scheme = "http"
+	}
+	proxyURL, err := GetProxyForURL(d.proxies, &url.URL{Scheme: scheme, Host: addr})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	conn, err := d.dial(ctx, network, proxyAddr(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{
+			ServerName:         proxyURL.Hostname(),
+			InsecureSkipVerify: config.Datadog.GetBool("skip_ssl_validation"),
+		})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if err := connectWithNTLM(conn, proxyURL, addr); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not connect to %s through the proxy %s: %v", addr, SanitizeProxyURL(proxyURL), err)
+	}
+	return conn, nil
+}
+
+// proxyAddr returns the address of the proxy, with its default port if none is set.
+func proxyAddr(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	if proxyURL.Scheme == "https" {
+		return net.JoinHostPort(proxyURL.Hostname(), "443")
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), "80")
+}
+
+// connectWithNTLM opens a tunnel to addr through the proxy, authenticating with the
+// credentials of the proxy URL, the user being prefixed by its domain: DOMAIN\user.
+func connectWithNTLM(conn net.Conn, proxyURL *url.URL, addr string) error {
+	user, domain, password := ntlmCredentials(proxyURL.User)
+	negotiate, err := ntlmssp.NewNegotiateMessage(domain, "")
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := connect(conn, reader, addr, negotiate)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		// the proxy doesn't require any authentication
+		return nil
+	}
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	challenge, err := ntlmChallenge(resp.Header)
+	if err != nil {
+		return err
+	}
+	authenticate, err := ntlmssp.ProcessChallenge(challenge, user, password)
+	if err != nil {
+		return fmt.Errorf("invalid NTLM challenge: %v", err)
+	}
+	resp, err = connect(conn, reader, addr, authenticate)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the NTLM authentication failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// connect sends a CONNECT request to the proxy with the NTLM message and returns its response.
+func connect(conn net.Conn, reader *bufio.Reader, addr string, message []byte) (*http.Response, error) {
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{
+			"Proxy-Authorization": {"NTLM " + base64.StdEncoding.EncodeToString(message)},
+			"Proxy-Connection":    {"Keep-Alive"},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the body of the challenge must be read for the connection to be reused
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	return resp, nil
+}
+
+// ntlmCredentials splits the user of the proxy URL into its name and its domain.
+func ntlmCredentials(userInfo *url.Userinfo) (user, domain, password string) {
+	if userInfo == nil {
+		return "", "", ""
+	}
+	password, _ = userInfo.Password()
+	user, domain = ntlmssp.GetDomain(userInfo.Username())
+	return user, domain, password
+}
+
+// ntlmChallenge returns the NTLM challenge message of the Proxy-Authenticate headers.
+func ntlmChallenge(header http.Header) ([]byte, error) {
+	for _, value := range header["Proxy-Authenticate"] {
+		if !strings.HasPrefix(value, "NTLM ") {
+			continue
+		}
+		challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(value, "NTLM ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid NTLM challenge: %v", err)
+		}
+		return challenge, nil
+	}
+	return nil, errors.New("the proxy does not support the NTLM authentication")
+}
@@ -17,6 +17,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -47,6 +49,10 @@ const (
 	tokenKey                  = "tokenKey"
 	metadataMapExpire         = 2 * time.Minute
 	metadataMapperCachePrefix = "KubernetesMetadataMapping"
+	// namespaceAnnotationsExpire is how long the annotations of a namespace read
+	// from the API server are kept, when the namespace informer doesn't run
+	namespaceAnnotationsExpire      = 5 * time.Minute
+	namespaceAnnotationsCachePrefix = "KubernetesNamespaceAnnotations"
 )
 
 // APIClient provides authenticated access to the
@@ -154,6 +160,32 @@ func getInformerFactory() (informers.SharedInformerFactory, error) {
 	return informers.NewSharedInformerFactory(client, resyncPeriodSeconds*time.Second), nil
 }
 
+// GetDynamicInformerFactory returns an informer factory for the resources
+// without typed client, like the custom resources. It is to be started by
+// the caller once its informers are created.
+func GetDynamicInformerFactory() (dynamicinformer.DynamicSharedInformerFactory, error) {
+	return GetFilteredDynamicInformerFactory(metav1.NamespaceAll, nil)
+}
+
+// GetFilteredDynamicInformerFactory returns a dynamic informer factory whose
+// informers only watch the given namespace, and the objects matching the list
+// options set by tweakListOptions, filtered server-side.
+func GetFilteredDynamicInformerFactory(namespace string, tweakListOptions dynamicinformer.TweakListOptionsFunc) (dynamicinformer.DynamicSharedInformerFactory, error) {
+	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
+	clientConfig, err := getClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	// the dynamic client only supports JSON
+	clientConfig.ContentType = ""
+	client, err := dynamic.NewForConfig(clientConfig) // No timeout for the Informers, to allow long watch.
+	if err != nil {
+		log.Errorf("Could not get apiserver dynamic client: %v", err)
+		return nil, err
+	}
+	return dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, resyncPeriodSeconds*time.Second, namespace, tweakListOptions), nil
+}
+
 func getInformerFactoryWithOption(options informers.SharedInformerOption) (informers.SharedInformerFactory, error) {
 	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
 	client, err := getKubeClient(0) // No timeout for the Informers, to allow long watch.
@@ -54,3 +54,9 @@ func GetNodeLabels(nodeName string) (map[string]string, error) {
 	log.Errorf("GetNodeLabels not implemented %s", ErrNotCompiled.Error())
 	return nil, nil
 }
+
+// GetNamespaceAnnotations retrieves the annotations of the queried namespace from the API server.
+func GetNamespaceAnnotations(ns string) (map[string]string, error) {
+	log.Errorf("GetNamespaceAnnotations not implemented %s", ErrNotCompiled.Error())
+	return nil, nil
+}
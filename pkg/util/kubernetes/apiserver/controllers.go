@@ -8,6 +8,10 @@
 package apiserver
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -44,6 +48,12 @@ var controllerCatalog = map[string]controllerFuncs{
 	},
 }
 
+var (
+	readInformersMutex sync.RWMutex
+	// readInformers are the informers the tags served by the cluster agent are read from
+	readInformers map[string]cache.SharedInformer
+)
+
 type ControllerContext struct {
 	InformerFactory    informers.SharedInformerFactory
 	WPAClient          wpa_client.Interface
@@ -89,11 +99,48 @@ func startMetadataController(ctx ControllerContext) error {
 	)
 	go metaController.Run(ctx.StopCh)
 
-	// Wait for the cache to sync
-	return SyncInformers(map[string]cache.SharedInformer{
+	informers := map[string]cache.SharedInformer{
 		"nodes":     ctx.InformerFactory.Core().V1().Nodes().Informer(),
 		"endpoints": ctx.InformerFactory.Core().V1().Endpoints().Informer(),
-	})
+	}
+	if len(config.Datadog.GetStringMapString("kubernetes_namespace_annotations_as_tags")) > 0 {
+		// the namespace tags are served from the cache of the informer
+		informers["namespaces"] = ctx.InformerFactory.Core().V1().Namespaces().Informer()
+	}
+	readInformersMutex.Lock()
+	readInformers = informers
+	readInformersMutex.Unlock()
+
+	// Wait for the cache to sync
+	return SyncInformers(informers)
+}
+
+// ReadPathReady returns an error until the informers the tags are read from are started
+// and synced. Every replica of the cluster agent runs these informers and serves the tags,
+// whether it's the leader or not.
+func ReadPathReady() error {
+	readInformersMutex.RLock()
+	defer readInformersMutex.RUnlock()
+
+	if readInformers == nil {
+		if !controllerCatalog["metadata"].enabled() {
+			// the tags aren't collected, there's nothing to wait for
+			return nil
+		}
+		return fmt.Errorf("the informers are not started yet")
+	}
+
+	names := make([]string, 0, len(readInformers))
+	for name := range readInformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !readInformers[name].HasSynced() {
+			return fmt.Errorf("the %s informer is not synced yet", name)
+		}
+	}
+	return nil
 }
 
 // startAutoscalersController starts the informers needed for autoscaling.
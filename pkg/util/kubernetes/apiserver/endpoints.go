@@ -12,6 +12,8 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	dderrors "github.com/DataDog/datadog-agent/pkg/errors"
 )
@@ -40,3 +42,71 @@ func SearchTargetPerName(endpoints *v1.Endpoints, targetName string) (v1.Endpoin
 func EntityForEndpoints(namespace, name, ip string) string {
 	return fmt.Sprintf("%s%s/%s/%s", kubeEndpointIDPrefix, namespace, name, ip)
 }
+
+// ServiceEndpoints holds a service along with its endpoints
+type ServiceEndpoints struct {
+	Service   *v1.Service
+	Endpoints *v1.Endpoints
+}
+
+// GetServiceEndpointsForPods returns the services accepted by filter in the
+// namespaces of the pods, along with their endpoints restricted to the
+// addresses of the pods. podNamespaces holds the namespaces of the pods by IP.
+// The services are listed per namespace and only the endpoints of the accepted
+// services are requested, so that the node agents do not watch the services
+// and the endpoints of the whole cluster.
+func (c *APIClient) GetServiceEndpointsForPods(podNamespaces map[string]string, filter func(*v1.Service) bool) ([]ServiceEndpoints, error) {
+	namespaces := make(map[string]struct{})
+	for _, namespace := range podNamespaces {
+		namespaces[namespace] = struct{}{}
+	}
+
+	var result []ServiceEndpoints
+	for namespace := range namespaces {
+		services, err := c.Cl.CoreV1().Services(namespace).List(metav1.ListOptions{TimeoutSeconds: &c.timeoutSeconds})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the services of namespace %s: %s", namespace, err)
+		}
+		for i := range services.Items {
+			service := &services.Items[i]
+			if !filter(service) {
+				continue
+			}
+			kep, err := c.Cl.CoreV1().Endpoints(namespace).Get(service.Name, metav1.GetOptions{})
+			if err != nil {
+				if k8serrors.IsNotFound(err) {
+					// the service has no endpoints yet
+					continue
+				}
+				return nil, fmt.Errorf("cannot get the endpoints of service %s/%s: %s", namespace, service.Name, err)
+			}
+			result = append(result, ServiceEndpoints{
+				Service:   service,
+				Endpoints: filterEndpointsAddresses(kep, podNamespaces),
+			})
+		}
+	}
+	return result, nil
+}
+
+// filterEndpointsAddresses returns a copy of the endpoints only holding the
+// addresses of the pods of their namespace, indexed by IP in podNamespaces
+func filterEndpointsAddresses(kep *v1.Endpoints, podNamespaces map[string]string) *v1.Endpoints {
+	filtered := kep.DeepCopy()
+	filtered.Subsets = nil
+	for _, subset := range kep.Subsets {
+		var addresses []v1.EndpointAddress
+		for _, address := range subset.Addresses {
+			if namespace, found := podNamespaces[address.IP]; found && namespace == kep.Namespace {
+				addresses = append(addresses, address)
+			}
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+		subset = *subset.DeepCopy()
+		subset.Addresses = addresses
+		filtered.Subsets = append(filtered.Subsets, subset)
+	}
+	return filtered
+}
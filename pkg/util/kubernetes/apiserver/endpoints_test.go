@@ -15,6 +15,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestSearchTargetPerName(t *testing.T) {
@@ -90,3 +92,70 @@ func TestSearchTargetPerName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetServiceEndpointsForPods(t *testing.T) {
+	annotated := map[string]string{"ad.datadoghq.com/endpoints.instances": "[{}]"}
+	endpoints := func(namespace, name string, ips ...string) *v1.Endpoints {
+		var addresses []v1.EndpointAddress
+		for _, ip := range ips {
+			addresses = append(addresses, v1.EndpointAddress{IP: ip})
+		}
+		return &v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Subsets: []v1.EndpointSubset{{
+				Addresses: addresses,
+				Ports:     []v1.EndpointPort{{Name: "http", Port: 80}},
+			}},
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Annotations: annotated}},
+		endpoints("default", "web", "10.0.0.1", "10.0.1.1"),
+		// not annotated
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"}},
+		endpoints("default", "db", "10.0.0.2"),
+		// no endpoints yet
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", Annotations: annotated}},
+		// no local pod in the namespace
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "web", Annotations: annotated}},
+		endpoints("other", "web", "10.0.1.2"),
+	)
+	cl := &APIClient{Cl: client, timeoutSeconds: 5}
+
+	result, err := cl.GetServiceEndpointsForPods(
+		map[string]string{"10.0.0.1": "default", "10.0.0.2": "default"},
+		func(svc *v1.Service) bool { return len(svc.Annotations) > 0 },
+	)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	web := result[0]
+	assert.Equal(t, "default", web.Service.Namespace)
+	assert.Equal(t, "web", web.Service.Name)
+	require.Len(t, web.Endpoints.Subsets, 1)
+	assert.Equal(t, []v1.EndpointAddress{{IP: "10.0.0.1"}}, web.Endpoints.Subsets[0].Addresses)
+	assert.Equal(t, []v1.EndpointPort{{Name: "http", Port: 80}}, web.Endpoints.Subsets[0].Ports)
+}
+
+func TestFilterEndpointsAddresses(t *testing.T) {
+	kep := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}},
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.3"}}},
+		},
+	}
+
+	filtered := filterEndpointsAddresses(kep, map[string]string{
+		"10.0.0.2": "default",
+		// same IP reported by a pod of another namespace
+		"10.0.0.3": "other",
+	})
+	assert.Equal(t, []v1.EndpointSubset{
+		{Addresses: []v1.EndpointAddress{{IP: "10.0.0.2"}}},
+	}, filtered.Subsets)
+	// the input endpoints are left untouched
+	assert.Len(t, kep.Subsets, 2)
+	assert.Len(t, kep.Subsets[0].Addresses, 2)
+}
@@ -23,6 +23,7 @@ import (
 	"context"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -35,10 +36,33 @@ const (
 	getLeaderTimeout           = 10 * time.Second
 )
 
+// The transitions of the election observed by the cluster agent
+const (
+	transitionNewLeader      = "new_leader"
+	transitionStartedLeading = "started_leading"
+	transitionStoppedLeading = "stopped_leading"
+)
+
 var (
 	globalLeaderEngine *LeaderEngine
+
+	transitionsTelemetry = telemetry.NewCounterWithOpts("", "leader_election_transitions",
+		[]string{"transition"}, "Counter of the leader election transitions observed by the cluster agent.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+	leaderTelemetry = telemetry.NewGaugeWithOpts("", "leader_election_leader",
+		[]string{"leader"}, "Current leader of the election, 1 if it's this cluster agent, 0 otherwise.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
 )
 
+// ElectionStats are the leader election statistics of the cluster agent
+type ElectionStats struct {
+	Leader         string
+	IsLeader       bool
+	NewLeader      int
+	StartedLeading int
+	StoppedLeading int
+}
+
 // LeaderEngine is a structure for the LeaderEngine client to run leader election
 // on Kubernetes clusters
 type LeaderEngine struct {
@@ -59,6 +83,8 @@ type LeaderEngine struct {
 
 	// leaderIdentity is the HolderIdentity of the current leader.
 	leaderIdentity string
+	// transitions counts the transitions observed, guarded by leaderIdentityMutex
+	transitions map[string]int
 }
 
 func newLeaderEngine() *LeaderEngine {
@@ -205,6 +231,53 @@ func (le *LeaderEngine) GetLeader() string {
 	return le.leaderIdentity
 }
 
+// setLeaderIdentity stores the identity of the new leader, and counts the transition
+func (le *LeaderEngine) setLeaderIdentity(identity, transition string) {
+	le.leaderIdentityMutex.Lock()
+	previous := le.leaderIdentity
+	le.leaderIdentity = identity
+	if le.transitions == nil {
+		le.transitions = make(map[string]int)
+	}
+	le.transitions[transition]++
+	le.leaderIdentityMutex.Unlock()
+
+	transitionsTelemetry.Inc(transition)
+	if previous != "" && previous != identity {
+		leaderTelemetry.Delete(previous)
+	}
+	if identity != "" {
+		isLeader := 0.0
+		if identity == le.HolderIdentity {
+			isLeader = 1.0
+		}
+		leaderTelemetry.Set(isLeader, identity)
+	}
+}
+
+// GetStats returns the current leader and the transitions observed
+func (le *LeaderEngine) GetStats() ElectionStats {
+	le.leaderIdentityMutex.RLock()
+	defer le.leaderIdentityMutex.RUnlock()
+
+	return ElectionStats{
+		Leader:         le.leaderIdentity,
+		IsLeader:       le.leaderIdentity != "" && le.leaderIdentity == le.HolderIdentity,
+		NewLeader:      le.transitions[transitionNewLeader],
+		StartedLeading: le.transitions[transitionStartedLeading],
+		StoppedLeading: le.transitions[transitionStoppedLeading],
+	}
+}
+
+// GetElectionStats returns the statistics of the leader engine of the cluster agent,
+// false if it isn't running, without starting it
+func GetElectionStats() (ElectionStats, bool) {
+	if globalLeaderEngine == nil {
+		return ElectionStats{}, false
+	}
+	return globalLeaderEngine.GetStats(), true
+}
+
 // GetLeaderIP returns the IP the leader can be reached at, assuming its
 // identity is its pod name. Returns empty if we are the leader.
 // The result is not cached.
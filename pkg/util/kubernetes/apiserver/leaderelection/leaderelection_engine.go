@@ -72,26 +72,17 @@ func (le *LeaderEngine) newElection() (*ld.LeaderElector, error) {
 	log.Debugf("Current registered leader is %q, building leader elector %q as candidate", currentLeader, le.HolderIdentity)
 	callbacks := ld.LeaderCallbacks{
 		OnNewLeader: func(identity string) {
-			le.leaderIdentityMutex.Lock()
-			le.leaderIdentity = identity
-			le.leaderIdentityMutex.Unlock()
-
+			le.setLeaderIdentity(identity, transitionNewLeader)
 			log.Infof("New leader %q", identity)
 		},
 		OnStartedLeading: func(ctx context.Context) {
-			le.leaderIdentityMutex.Lock()
-			le.leaderIdentity = le.HolderIdentity
-			le.leaderIdentityMutex.Unlock()
-
+			le.setLeaderIdentity(le.HolderIdentity, transitionStartedLeading)
 			log.Infof("Started leading as %q...", le.HolderIdentity)
 		},
 		// OnStoppedLeading shouldn't be called unless the election is lost. This could happen if
 		// we lose connection to the apiserver for the duration of the lease.
 		OnStoppedLeading: func() {
-			le.leaderIdentityMutex.Lock()
-			le.leaderIdentity = ""
-			le.leaderIdentityMutex.Unlock()
-
+			le.setLeaderIdentity("", transitionStoppedLeading)
 			log.Infof("Stopped leading %q", le.HolderIdentity)
 		},
 	}
@@ -92,6 +92,11 @@ func TestNewLeaseAcquiring(t *testing.T) {
 	require.Contains(t, Cm.Annotations[rl.LeaderElectionRecordAnnotationKey], "\"leaderTransitions\":1")
 	require.True(t, le.IsLeader())
 
+	stats := le.GetStats()
+	assert.Equal(t, "foo", stats.Leader)
+	assert.True(t, stats.IsLeader)
+	assert.Equal(t, 0, stats.StoppedLeading)
+
 	// As a leader, GetLeaderIP should return an empty IP
 	ip, err := le.GetLeaderIP()
 	assert.Equal(t, "", ip)
@@ -159,6 +164,10 @@ func TestGetLeaderIPFollower(t *testing.T) {
 
 	// We should be follower, and GetLeaderIP should return bar's IP
 	require.False(t, le.IsLeader())
+	stats := le.GetStats()
+	assert.Equal(t, "bar", stats.Leader)
+	assert.False(t, stats.IsLeader)
+	assert.Equal(t, 1, stats.NewLeader)
 	ip, err := le.GetLeaderIP()
 	assert.Equal(t, "1.1.1.2", ip)
 	assert.NoError(t, err)
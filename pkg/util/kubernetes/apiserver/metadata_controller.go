@@ -17,6 +17,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -328,3 +329,45 @@ func GetNodeLabels(nodeName string) (map[string]string, error) {
 	}
 	return node.Labels, nil
 }
+
+// GetNamespaceAnnotations retrieves the annotations of the queried namespace from the cache of the
+// shared informer when it runs (cluster agent). Otherwise, they are retrieved from the API server and
+// kept in the cache for namespaceAnnotationsExpire.
+func GetNamespaceAnnotations(ns string) (map[string]string, error) {
+	as, err := GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	if !config.Datadog.GetBool("kubernetes_collect_metadata_tags") {
+		return nil, log.Errorf("Metadata collection is disabled on the Cluster Agent")
+	}
+	if namespaceInformerSynced() {
+		namespace, err := as.InformerFactory.Core().V1().Namespaces().Lister().Get(ns)
+		if err != nil {
+			return nil, err
+		}
+		return namespace.Annotations, nil
+	}
+
+	cacheKey := agentcache.BuildAgentKey(namespaceAnnotationsCachePrefix, ns)
+	if cached, found := agentcache.Cache.Get(cacheKey); found {
+		if annotations, ok := cached.(map[string]string); ok {
+			return annotations, nil
+		}
+	}
+	namespace, err := as.Cl.CoreV1().Namespaces().Get(ns, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	agentcache.Cache.Set(cacheKey, namespace.Annotations, namespaceAnnotationsExpire)
+	return namespace.Annotations, nil
+}
+
+// namespaceInformerSynced returns whether the namespace informer is started and synced.
+func namespaceInformerSynced() bool {
+	readInformersMutex.RLock()
+	defer readInformersMutex.RUnlock()
+
+	informer, found := readInformers["namespaces"]
+	return found && informer.HasSynced()
+}
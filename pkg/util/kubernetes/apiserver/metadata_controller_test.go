@@ -12,6 +12,7 @@ import (
 	"time"
 
 	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -487,3 +488,60 @@ func newFakeEndpointAddress(nodeName string, pod v1.Pod) v1.EndpointAddress {
 		},
 	}
 }
+
+func TestReadPathReady(t *testing.T) {
+	defer func() { readInformers = nil }()
+	mockConfig := config.Mock()
+
+	// the tags aren't collected
+	mockConfig.Set("kubernetes_collect_metadata_tags", false)
+	assert.NoError(t, ReadPathReady())
+
+	// the informers aren't started yet
+	mockConfig.Set("kubernetes_collect_metadata_tags", true)
+	assert.Error(t, ReadPathReady())
+
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	readInformers = map[string]cache.SharedInformer{
+		"nodes": informerFactory.Core().V1().Nodes().Informer(),
+	}
+	assert.Error(t, ReadPathReady())
+
+	stop := make(chan struct{})
+	defer close(stop)
+	informerFactory.Start(stop)
+	require.True(t, cache.WaitForCacheSync(stop, readInformers["nodes"].HasSynced))
+	assert.NoError(t, ReadPathReady())
+}
+
+func TestNamespaceInformerSynced(t *testing.T) {
+	defer func() { readInformers = nil }()
+
+	assert.False(t, namespaceInformerSynced())
+
+	client := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{"team": "backend"},
+		},
+	})
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	readInformers = map[string]cache.SharedInformer{
+		"nodes": informerFactory.Core().V1().Nodes().Informer(),
+	}
+	assert.False(t, namespaceInformerSynced())
+
+	readInformers["namespaces"] = informerFactory.Core().V1().Namespaces().Informer()
+	assert.False(t, namespaceInformerSynced())
+
+	stop := make(chan struct{})
+	defer close(stop)
+	informerFactory.Start(stop)
+	require.True(t, cache.WaitForCacheSync(stop, readInformers["namespaces"].HasSynced))
+	assert.True(t, namespaceInformerSynced())
+
+	namespace, err := informerFactory.Core().V1().Namespaces().Lister().Get("default")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "backend"}, namespace.Annotations)
+}
@@ -34,7 +34,7 @@ func (ku *KubeUtil) ListContainers() ([]*containers.Container, error) {
 
 	for _, pod := range pods {
 		for _, c := range pod.Status.GetAllContainers() {
-			if ku.filter.IsExcluded(c.Name, c.Image, pod.Metadata.Namespace) {
+			if ku.filter.IsPodContainerExcluded(c.Name, c.Image, pod.Metadata.Namespace, pod.Metadata.Labels) {
 				continue
 			}
 			container, err := parseContainerInPod(c, pod)
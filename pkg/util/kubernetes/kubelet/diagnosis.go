@@ -14,14 +14,21 @@ import (
 )
 
 func init() {
-	diagnosis.Register("Kubelet availability", diagnose)
+	diagnosis.RegisterSuite("Kubelet availability", diagnose)
 }
 
-// diagnose the API server availability
-func diagnose() error {
-	_, err := GetKubeUtil()
-	if err != nil {
+// diagnose the Kubelet API availability
+func diagnose() []diagnosis.Result {
+	result := diagnosis.Result{
+		Name:    "Kubelet API",
+		Status:  diagnosis.StatusPass,
+		Message: "Reachable",
+	}
+	if _, err := GetKubeUtil(); err != nil {
 		log.Error(err)
+		result.Status = diagnosis.StatusFail
+		result.Message = err.Error()
+		result.Remediation = "Check that kubernetes_kubelet_host is the IP of the node (e.g. from the status.hostIP field), that the kubelet port (kubernetes_https_kubelet_port) is reachable, and set kubelet_tls_verify to false if the kubelet certificate isn't signed for this IP"
 	}
-	return err
+	return []diagnosis.Result{result}
 }
@@ -12,6 +12,7 @@ import (
 	"crypto/tls"
 	"expvar"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -35,6 +36,7 @@ import (
 const (
 	kubeletPodPath         = "/pods"
 	kubeletMetricsPath     = "/metrics"
+	kubeletLogsPath        = "/containerLogs"
 	authorizationHeaderKey = "Authorization"
 	podListCacheKey        = "KubeletPodListCacheKey"
 	unreadyAnnotation      = "ad.datadoghq.com/tolerate-unready"
@@ -451,6 +453,40 @@ func (ku *KubeUtil) QueryKubelet(path string) ([]byte, int, error) {
 	return b, response.StatusCode, nil
 }
 
+// StreamContainerLogs follows the stdout and stderr of a container with the
+// kubelet containerLogs endpoint, starting at since. Each line is prefixed by
+// its RFC3339Nano timestamp. The stream is closed by cancelling the context.
+func (ku *KubeUtil) StreamContainerLogs(ctx context.Context, namespace, podName, containerName string, since time.Time) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("follow", "true")
+	query.Set("timestamps", "true")
+	if !since.IsZero() {
+		query.Set("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+	path := fmt.Sprintf("%s/%s/%s/%s?%s", kubeletLogsPath, url.PathEscape(namespace), url.PathEscape(podName), url.PathEscape(containerName), query.Encode())
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", ku.kubeletAPIEndpoint, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = *ku.kubeletAPIRequestHeaders
+	req = req.WithContext(ctx)
+
+	// the API client has a short timeout, unfit for a stream
+	client := &http.Client{Transport: ku.kubeletAPIClient.Transport}
+	response, err := client.Do(req)
+	kubeletExpVar.Add(1)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d on %s%s: %s", response.StatusCode, ku.kubeletAPIEndpoint, path, string(data))
+	}
+	return response.Body, nil
+}
+
 // GetKubeletAPIEndpoint returns the current endpoint used to perform QueryKubelet
 func (ku *KubeUtil) GetKubeletAPIEndpoint() string {
 	return ku.kubeletAPIEndpoint
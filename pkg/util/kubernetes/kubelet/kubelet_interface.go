@@ -8,6 +8,10 @@
 package kubelet
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 )
 
@@ -23,6 +27,7 @@ type KubeUtilInterface interface {
 	GetPodFromUID(podUID string) (*Pod, error)
 	GetPodForEntityID(entityID string) (*Pod, error)
 	QueryKubelet(path string) ([]byte, int, error)
+	StreamContainerLogs(ctx context.Context, namespace, podName, containerName string, since time.Time) (io.ReadCloser, error)
 	GetKubeletAPIEndpoint() string
 	GetRawConnectionInfo() map[string]string
 	GetRawMetrics() ([]byte, error)
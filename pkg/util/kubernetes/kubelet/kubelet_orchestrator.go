@@ -8,8 +8,11 @@
 package kubelet
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	v1 "k8s.io/api/core/v1"
@@ -29,6 +32,7 @@ type KubeUtilInterface interface {
 	GetPodFromUID(podUID string) (*Pod, error)
 	GetPodForEntityID(entityID string) (*Pod, error)
 	QueryKubelet(path string) ([]byte, int, error)
+	StreamContainerLogs(ctx context.Context, namespace, podName, containerName string, since time.Time) (io.ReadCloser, error)
 	GetKubeletAPIEndpoint() string
 	GetRawConnectionInfo() map[string]string
 	GetRawMetrics() ([]byte, error)
@@ -61,3 +61,16 @@ func (p *Pod) GetPersistentVolumeClaimNames() []string {
 	}
 	return pvcs
 }
+
+// PodNamespacesByIP returns the namespaces of the pods indexed by their IP. The
+// pods in the host network are skipped, as they share the IP of the node.
+func PodNamespacesByIP(pods []*Pod) map[string]string {
+	namespaces := make(map[string]string, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Status.PodIP == "" {
+			continue
+		}
+		namespaces[pod.Status.PodIP] = pod.Metadata.Namespace
+	}
+	return namespaces
+}
@@ -177,3 +177,29 @@ func TestPodGetPersistentVolumeClaimNames(t *testing.T) {
 		})
 	}
 }
+
+func TestPodNamespacesByIP(t *testing.T) {
+	pods := []*Pod{
+		{
+			Metadata: PodMetadata{Name: "web", Namespace: "default"},
+			Status:   Status{PodIP: "10.0.0.1"},
+		},
+		{
+			Metadata: PodMetadata{Name: "db", Namespace: "storage"},
+			Status:   Status{PodIP: "10.0.0.2"},
+		},
+		{
+			Metadata: PodMetadata{Name: "agent", Namespace: "datadog"},
+			Spec:     Spec{HostNetwork: true},
+			Status:   Status{PodIP: "192.168.0.1"},
+		},
+		{
+			Metadata: PodMetadata{Name: "pending", Namespace: "default"},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"10.0.0.1": "default",
+		"10.0.0.2": "storage",
+	}, PodNamespacesByIP(pods))
+}
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	// Nomad API paths
+	agentSelfPath       = "/v1/agent/self"
+	nodeAllocationsPath = "/v1/node/%s/allocations"
+	allocServicesPath   = "/v1/allocation/%s/services"
+
+	tokenHeader = "X-Nomad-Token"
+
+	endpointTimeout = 2 * time.Second
+)
+
+// Client queries the API of the local Nomad agent, which must run as a client
+// to know the allocations of the node.
+type Client struct {
+	agentURL string
+	token    string
+	nodeID   string
+}
+
+// NewClient returns a client for the Nomad agent configured by nomad_agent_url,
+// it checks the agent is reachable and runs as a client.
+func NewClient() (*Client, error) {
+	c := &Client{
+		agentURL: config.Datadog.GetString("nomad_agent_url"),
+		token:    config.Datadog.GetString("nomad_token"),
+	}
+
+	var self agentSelf
+	if err := c.get(agentSelfPath, nil, &self); err != nil {
+		return nil, fmt.Errorf("Nomad agent not reachable: %s", err)
+	}
+	if self.Stats.Client.NodeID == "" {
+		return nil, fmt.Errorf("the Nomad agent at %s does not run as a client", c.agentURL)
+	}
+	c.nodeID = self.Stats.Client.NodeID
+
+	return c, nil
+}
+
+// NodeID returns the ID of the Nomad node of the agent
+func (c *Client) NodeID() string {
+	return c.nodeID
+}
+
+// GetNodeAllocations returns the allocations placed on the node of the agent
+func (c *Client) GetNodeAllocations() ([]Allocation, error) {
+	var allocs []Allocation
+	if err := c.get(fmt.Sprintf(nodeAllocationsPath, c.nodeID), nil, &allocs); err != nil {
+		return nil, err
+	}
+	return allocs, nil
+}
+
+// GetNodeServices returns the services registered by the running allocations
+// of the node of the agent. Only the services of the local allocations are
+// queried, not the whole service catalog of the cluster.
+func (c *Client) GetNodeServices() ([]ServiceRegistration, error) {
+	allocs, err := c.GetNodeAllocations()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []ServiceRegistration
+	for _, alloc := range allocs {
+		if alloc.ClientStatus != "running" {
+			continue
+		}
+		var registrations []ServiceRegistration
+		query := url.Values{"namespace": {alloc.Namespace}}
+		if err := c.get(fmt.Sprintf(allocServicesPath, url.PathEscape(alloc.ID)), query, &registrations); err != nil {
+			return nil, err
+		}
+		services = append(services, registrations...)
+	}
+
+	return services, nil
+}
+
+func (c *Client) get(path string, query url.Values, v interface{}) error {
+	u, err := url.Parse(c.agentURL)
+	if err != nil {
+		return fmt.Errorf("Error constructing Nomad request URL: %s", err)
+	}
+	u.Path = path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set(tokenHeader, c.token)
+	}
+
+	client := http.Client{Timeout: endpointTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected HTTP status code in Nomad reply: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Failed to decode Nomad JSON payload to type %s: %s", reflect.TypeOf(v), err)
+	}
+
+	return nil
+}
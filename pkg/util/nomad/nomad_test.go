@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nomad
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get(tokenHeader))
+		switch r.URL.Path {
+		case "/v1/agent/self":
+			io.WriteString(w, `{"stats": {"client": {"node_id": "node-1"}}}`)
+		case "/v1/node/node-1/allocations":
+			io.WriteString(w, `[
+				{"ID": "alloc-1", "Namespace": "default", "JobID": "example", "TaskGroup": "cache", "ClientStatus": "running", "TaskStates": {"redis": {"State": "running"}}},
+				{"ID": "alloc-0", "Namespace": "default", "JobID": "example", "TaskGroup": "cache", "ClientStatus": "complete", "TaskStates": {"redis": {"State": "dead"}}}
+			]`)
+		case "/v1/allocation/alloc-1/services":
+			assert.Equal(t, "default", r.URL.Query().Get("namespace"))
+			io.WriteString(w, `[
+				{"ID": "reg-1", "ServiceName": "redis", "Namespace": "default", "NodeID": "node-1", "JobID": "example", "AllocID": "alloc-1", "Address": "10.0.0.1", "Port": 6379}
+			]`)
+		case "/v1/allocation/alloc-0/services":
+			assert.Fail(t, "the services of the terminated allocations are not queried")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("nomad_agent_url", ts.URL)
+	mockConfig.Set("nomad_token", "secret")
+
+	client, err := NewClient()
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", client.NodeID())
+
+	allocs, err := client.GetNodeAllocations()
+	require.NoError(t, err)
+	require.Len(t, allocs, 2)
+	assert.Equal(t, "example", allocs[0].JobID)
+	assert.Equal(t, "cache", allocs[0].TaskGroup)
+	assert.Contains(t, allocs[0].TaskStates, "redis")
+
+	services, err := client.GetNodeServices()
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, "reg-1", services[0].ID)
+	assert.Equal(t, 6379, services[0].Port)
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nomad
+
+// Allocation is the stub of an allocation, as listed by the Nomad API
+type Allocation struct {
+	ID            string               `json:"ID"`
+	Name          string               `json:"Name"`
+	Namespace     string               `json:"Namespace"`
+	NodeID        string               `json:"NodeID"`
+	JobID         string               `json:"JobID"`
+	TaskGroup     string               `json:"TaskGroup"`
+	DesiredStatus string               `json:"DesiredStatus"`
+	ClientStatus  string               `json:"ClientStatus"`
+	TaskStates    map[string]TaskState `json:"TaskStates"`
+}
+
+// TaskState is the state of a task of an allocation
+type TaskState struct {
+	State  string `json:"State"`
+	Failed bool   `json:"Failed"`
+}
+
+// ServiceRegistration is a service registered in the Nomad service catalog
+type ServiceRegistration struct {
+	ID          string   `json:"ID"`
+	ServiceName string   `json:"ServiceName"`
+	Namespace   string   `json:"Namespace"`
+	NodeID      string   `json:"NodeID"`
+	Datacenter  string   `json:"Datacenter"`
+	JobID       string   `json:"JobID"`
+	AllocID     string   `json:"AllocID"`
+	Tags        []string `json:"Tags"`
+	Address     string   `json:"Address"`
+	Port        int      `json:"Port"`
+}
+
+// agentSelf is the subset of the agent self endpoint used by the client
+type agentSelf struct {
+	Stats struct {
+		Client struct {
+			NodeID string `json:"node_id"`
+		} `json:"client"`
+	} `json:"stats"`
+}
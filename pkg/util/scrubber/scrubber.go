@@ -0,0 +1,283 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package scrubber redacts the sensitive data of the content collected by the
+// agents before it leaves them: the Kubernetes manifests sent to the orchestrator
+// explorer, the flares and the status pages.
+package scrubber
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RedactedValue replaces the scrubbed values
+const RedactedValue = "********"
+
+// defaultSensitiveWords are the keys whose values are always scrubbed, `*`
+// matches any sequence of characters
+var defaultSensitiveWords = []string{
+	"*password*", "*passwd*", "*pwd*",
+	"*access_token*", "*auth_token*",
+	"*api_key*", "*apikey*", "*app_key*", "*appkey*",
+	"*secret*", "*credentials*", "*token", "stripetoken",
+}
+
+var (
+	scrubbedFields = telemetry.NewCounterWithOpts("", "scrubbed_fields",
+		[]string{"source"}, "Counter of fields redacted by the scrubber.",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
+
+	// keyValueLine matches the `key: value` and `key=value` lines, optionally
+	// in a YAML list item, the quoted keys are handled as JSON
+	keyValueLine = regexp.MustCompile(`^(\s*(?:-\s+)?)([\w.\-/]+)(\s*[:=]\s*)(\S.*)$`)
+	// jsonKeyValue matches the string values of JSON objects
+	jsonKeyValue = regexp.MustCompile(`"([^"]+)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+
+	defaultScrubberMutex sync.Mutex
+	defaultScrubber      *Scrubber
+	defaultScrubberWords []string
+)
+
+// Scrubber redacts the values of the keys matching its sensitive words, on top
+// of the credentials the log package cleans up
+type Scrubber struct {
+	sensitiveKeys []*regexp.Regexp
+}
+
+// New returns a Scrubber matching the given sensitive words, case-insensitively.
+// A sensitive word matches whole keys, `*` being a wildcard.
+func New(words []string) *Scrubber {
+	s := &Scrubber{}
+	for _, word := range words {
+		if strings.Trim(word, "*") == "" {
+			log.Warnf("scrubber: ignoring the sensitive word %q, it would match every key", word)
+			continue
+		}
+		parts := strings.Split(word, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		s.sensitiveKeys = append(s.sensitiveKeys, regexp.MustCompile(`(?i)^`+strings.Join(parts, `.*`)+`$`))
+	}
+	return s
+}
+
+// Default returns the Scrubber matching the default sensitive words and the ones
+// of the `scrubber.sensitive_words` option. It's built again when the option
+// changes, e.g. once the configuration is loaded.
+func Default() *Scrubber {
+	words := config.Datadog.GetStringSlice("scrubber.sensitive_words")
+
+	defaultScrubberMutex.Lock()
+	defer defaultScrubberMutex.Unlock()
+	if defaultScrubber == nil || !equalWords(words, defaultScrubberWords) {
+		defaultScrubber = New(append(append([]string{}, defaultSensitiveWords...), words...))
+		defaultScrubberWords = words
+	}
+	return defaultScrubber
+}
+
+func equalWords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSensitiveKey returns whether the values of the key must be scrubbed
+func (s *Scrubber) IsSensitiveKey(key string) bool {
+	key = strings.TrimLeft(key, "-")
+	for _, r := range s.sensitiveKeys {
+		if r.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScrubValue returns the value of the key, redacted if the key is sensitive or
+// the value holds credentials, and whether it was redacted
+func (s *Scrubber) ScrubValue(key, value string) (string, bool) {
+	if isRedacted(value) {
+		return value, false
+	}
+	if s.IsSensitiveKey(key) {
+		return RedactedValue, true
+	}
+	// use the "key: value" format to work with the regular credential cleaner
+	combination := key + ": " + value
+	if cleaned, err := log.CredentialsCleanerBytes([]byte(combination)); err == nil && string(cleaned) != combination {
+		return RedactedValue, true
+	}
+	return value, false
+}
+
+// ScrubArgs returns a copy of the command line arguments with the values of the
+// sensitive flags redacted, e.g. `--password foo`, `--password=foo` or
+// `password:foo`, and the number of values redacted
+func (s *Scrubber) ScrubArgs(args []string) ([]string, int) {
+	if len(args) == 0 {
+		return args, 0
+	}
+	scrubbed := make([]string, len(args))
+	copy(scrubbed, args)
+	count := 0
+	for i := 0; i < len(scrubbed); i++ {
+		arg := scrubbed[i]
+		if idx := strings.IndexAny(arg, "=:"); idx > 0 {
+			if s.IsSensitiveKey(arg[:idx]) && !isRedacted(arg[idx+1:]) {
+				scrubbed[i] = arg[:idx+1] + RedactedValue
+				count++
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && s.IsSensitiveKey(arg) && i+1 < len(scrubbed) && !strings.HasPrefix(scrubbed[i+1], "-") {
+			i++
+			if !isRedacted(scrubbed[i]) {
+				scrubbed[i] = RedactedValue
+				count++
+			}
+		}
+	}
+	return scrubbed, count
+}
+
+// ScrubMap redacts in place the values of a map of strings, e.g. annotations,
+// whose keys are sensitive, and the credentials their values hold. It returns
+// the number of values redacted.
+func (s *Scrubber) ScrubMap(m map[string]string) int {
+	count := 0
+	for k, v := range m {
+		if s.IsSensitiveKey(k) {
+			if !isRedacted(v) {
+				m[k] = RedactedValue
+				count++
+			}
+			continue
+		}
+		scrubbed, n := s.scrubText([]byte(v))
+		if n > 0 {
+			m[k] = string(scrubbed)
+			count += n
+		}
+	}
+	return count
+}
+
+// ScrubBytes redacts the credentials and the values of the sensitive keys of a
+// text, e.g. a YAML or JSON document, a flare file or a status page. Like the
+// credentials cleaner, it drops the comment lines, but keeps the blank ones.
+func (s *Scrubber) ScrubBytes(b []byte) ([]byte, int, error) {
+	var blocks [][]byte
+	count := 0
+	lines := bytes.Split(b, []byte("\n"))
+	for start := 0; start < len(lines); {
+		if len(bytes.TrimSpace(lines[start])) == 0 {
+			blocks = append(blocks, lines[start])
+			start++
+			continue
+		}
+		// the credentials cleaner drops the blank lines, it's called on the
+		// blocks of lines between them
+		end := start
+		for end < len(lines) && len(bytes.TrimSpace(lines[end])) > 0 {
+			end++
+		}
+		block := bytes.Join(lines[start:end], []byte("\n"))
+		cleaned, err := log.CredentialsCleanerBytes(block)
+		if err != nil {
+			return nil, 0, err
+		}
+		count += changedLines(block, cleaned)
+		if len(cleaned) > 0 {
+			blocks = append(blocks, cleaned)
+		}
+		start = end
+	}
+	scrubbed, n := s.scrubText(bytes.Join(blocks, []byte("\n")))
+	return scrubbed, count + n, nil
+}
+
+// changedLines returns the number of lines of a block the credentials cleaner
+// changed, it drops the comment lines
+func changedLines(before, after []byte) int {
+	var beforeLines [][]byte
+	for _, line := range bytes.Split(before, []byte("\n")) {
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] != '#' {
+			beforeLines = append(beforeLines, line)
+		}
+	}
+	afterLines := bytes.Split(after, []byte("\n"))
+	if len(beforeLines) != len(afterLines) {
+		// the multi-line replacers, e.g. of the certificates, merged lines
+		return 1
+	}
+	count := 0
+	for i := range beforeLines {
+		if !bytes.Equal(beforeLines[i], afterLines[i]) {
+			count++
+		}
+	}
+	return count
+}
+
+// scrubText redacts the values of the sensitive keys of the `key: value` lines
+// and the JSON objects of a text
+func (s *Scrubber) scrubText(b []byte) ([]byte, int) {
+	count := 0
+	lines := bytes.Split(b, []byte("\n"))
+	for i, line := range lines {
+		if m := keyValueLine.FindSubmatch(line); m != nil && s.IsSensitiveKey(string(m[2])) && !isRedacted(string(m[4])) {
+			lines[i] = append(append(append(append([]byte{}, m[1]...), m[2]...), m[3]...), RedactedValue...)
+			count++
+			continue
+		}
+		lines[i] = jsonKeyValue.ReplaceAllFunc(line, func(match []byte) []byte {
+			sub := jsonKeyValue.FindSubmatch(match)
+			if !s.IsSensitiveKey(string(sub[1])) || isRedacted(string(sub[3])) {
+				return match
+			}
+			count++
+			return []byte(`"` + string(sub[1]) + `"` + string(sub[2]) + `"` + RedactedValue + `"`)
+		})
+	}
+	return bytes.Join(lines, []byte("\n")), count
+}
+
+// isRedacted returns whether a value is empty or was already redacted, e.g.
+// the API keys the log package only keeps the last characters of
+func isRedacted(value string) bool {
+	return value == "" || strings.Contains(value, RedactedValue)
+}
+
+// ScrubBytes redacts a text with the default Scrubber, and counts the values
+// redacted for the given source, e.g. flare or status
+func ScrubBytes(source string, b []byte) ([]byte, error) {
+	scrubbed, count, err := Default().ScrubBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	AddScrubbedFields(source, count)
+	return scrubbed, nil
+}
+
+// AddScrubbedFields counts the values redacted for the given source
+func AddScrubbedFields(source string, count int) {
+	if count > 0 {
+		scrubbedFields.Add(float64(count), source)
+	}
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// SysObjectIDOID is the OID of the sysObjectID of the SNMPv2-MIB
+const SysObjectIDOID = "1.3.6.1.2.1.1.2.0"
+
+// Versions of the protocol, as encoded in the messages
+const (
+	Version1  = int(gosnmp.Version1)
+	Version2c = int(gosnmp.Version2c)
+)
+
+// ErrNoSuchObject is returned when the device doesn't expose the OID
+var ErrNoSuchObject = errors.New("no such object")
+
+// Client sends SNMP v1 and v2c GET requests to a device
+type Client struct {
+	Host      string
+	Port      int
+	Community string
+	Version   int
+	Timeout   time.Duration
+	Retries   int
+}
+
+// ParseVersion returns the version of the protocol from its name
+func ParseVersion(version string) (int, error) {
+	switch version {
+	case "1":
+		return Version1, nil
+	case "", "2", "2c":
+		return Version2c, nil
+	}
+	return 0, fmt.Errorf("unsupported SNMP version %q, only 1 and 2c are supported", version)
+}
+
+// GetOID returns the value of an OID whose value is itself an OID, like the
+// sysObjectID, in its dotted representation.
+func (c *Client) GetOID(oid string) (string, error) {
+	session := &gosnmp.GoSNMP{
+		Target:    c.Host,
+		Port:      uint16(c.Port),
+		Community: c.Community,
+		Version:   gosnmp.SnmpVersion(c.Version),
+		Timeout:   c.Timeout,
+		Retries:   c.Retries,
+	}
+	if err := session.Connect(); err != nil {
+		return "", err
+	}
+	defer session.Conn.Close()
+
+	result, err := session.Get([]string{oid})
+	if err != nil {
+		return "", err
+	}
+	if result.Error == gosnmp.NoSuchName {
+		// SNMP v1 devices report missing objects with an error status
+		return "", ErrNoSuchObject
+	}
+	if result.Error != gosnmp.NoError {
+		return "", fmt.Errorf("the device answered with the error status %s", result.Error)
+	}
+	if len(result.Variables) != 1 {
+		return "", fmt.Errorf("the device answered with %d values instead of 1", len(result.Variables))
+	}
+
+	variable := result.Variables[0]
+	switch variable.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+		return "", ErrNoSuchObject
+	case gosnmp.ObjectIdentifier:
+		value, ok := variable.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("invalid value of %s", oid)
+		}
+		return strings.TrimPrefix(value, "."), nil
+	}
+	return "", fmt.Errorf("the value of %s is a %s, not an OID", oid, variable.Type)
+}
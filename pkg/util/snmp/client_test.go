@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package snmp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent answers the GET requests with the community `public`
+func fakeAgent(t *testing.T, sysObjectID string) (int, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			request, err := (&gosnmp.GoSNMP{}).SnmpDecodePacket(buffer[:n])
+			require.NoError(t, err)
+			if request.Community != "public" {
+				// wrong communities are silently ignored
+				continue
+			}
+
+			variable := gosnmp.SnmpPDU{Name: SysObjectIDOID, Type: gosnmp.NoSuchObject}
+			if sysObjectID != "" {
+				variable = gosnmp.SnmpPDU{Name: SysObjectIDOID, Type: gosnmp.ObjectIdentifier, Value: sysObjectID}
+			}
+			response := &gosnmp.SnmpPacket{
+				Version:   request.Version,
+				Community: request.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: request.RequestID,
+				Variables: []gosnmp.SnmpPDU{variable},
+			}
+			raw, err := response.MarshalMsg()
+			require.NoError(t, err)
+			conn.WriteTo(raw, addr)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port, func() { conn.Close() }
+}
+
+func TestGetOID(t *testing.T) {
+	port, stop := fakeAgent(t, "1.3.6.1.4.1.9.1.1208")
+	defer stop()
+
+	client := &Client{Host: "127.0.0.1", Port: port, Community: "public", Version: Version2c, Timeout: time.Second}
+	value, err := client.GetOID(SysObjectIDOID)
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.9.1.1208", value)
+
+	client.Version = Version1
+	value, err = client.GetOID(SysObjectIDOID)
+	require.NoError(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.9.1.1208", value)
+
+	client.Community = "private"
+	client.Timeout = 50 * time.Millisecond
+	client.Retries = 1
+	_, err = client.GetOID(SysObjectIDOID)
+	assert.Error(t, err)
+}
+
+func TestGetOIDNoSuchObject(t *testing.T) {
+	port, stop := fakeAgent(t, "")
+	defer stop()
+
+	client := &Client{Host: "127.0.0.1", Port: port, Community: "public", Version: Version2c, Timeout: time.Second}
+	_, err := client.GetOID(SysObjectIDOID)
+	assert.Equal(t, ErrNoSuchObject, err)
+}
+
+func TestParseVersion(t *testing.T) {
+	for name, expected := range map[string]int{"": Version2c, "1": Version1, "2": Version2c, "2c": Version2c} {
+		version, err := ParseVersion(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, version)
+	}
+
+	_, err := ParseVersion("3")
+	assert.Error(t, err)
+}
@@ -8,6 +8,10 @@ package pdhutil
 
 import (
 	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -19,11 +23,23 @@ var (
 	pfnPdhAddCounter                    = PdhAddCounter
 	pfnPdhCollectQueryData              = PdhCollectQueryData
 	pfnPdhEnumObjectItems               = pdhEnumObjectItems
+	pfnPdhIsMultiInstance               = pdhIsMultiInstance
 	pfnPdhRemoveCounter                 = PdhRemoveCounter
 	pfnPdhLookupPerfNameByIndex         = pdhLookupPerfNameByIndex
 	pfnPdhGetFormattedCounterValueFloat = pdhGetFormattedCounterValueFloat
 	pfnPdhCloseQuery                    = PdhCloseQuery
 	pfnPdhMakeCounterPath               = pdhMakeCounterPath
+	pfnPdhRefreshObjects                = pdhRefreshObjects
+)
+
+// instanceRefreshInterval is the minimum interval between two refreshes of
+// the instances, pdh caches them until they're refreshed and refreshing
+// them is expensive
+const instanceRefreshInterval = 15 * time.Second
+
+var (
+	lastInstanceRefresh time.Time
+	instanceRefreshLock sync.Mutex
 )
 
 // CounterInstanceVerify is a callback function called by GetCounterSet for each
@@ -35,10 +51,19 @@ type CounterInstanceVerify func(string) bool
 type PdhCounterSet struct {
 	className string
 	query     PDH_HQUERY
+	// sharedQuery is true when the query is a PdhQuery, its owner collects
+	// and closes it
+	sharedQuery bool
 
 	counterName string
 }
 
+// PdhQuery is a query collecting the counters of several counter sets at
+// once, e.g. all the counters of a check
+type PdhQuery struct {
+	handle PDH_HQUERY
+}
+
 // PdhSingleInstanceCounterSet is a specialization for single instance counters
 type PdhSingleInstanceCounterSet struct {
 	PdhCounterSet
@@ -50,40 +75,119 @@ type PdhMultiInstanceCounterSet struct {
 	PdhCounterSet
 	requestedCounterName string
 	requestedInstances   map[string]bool
+	requestedPatterns    []string                // wildcard instances, like `svchost*`
 	countermap           map[string]PDH_HCOUNTER // map instance name to counter handle
 	verifyfn             CounterInstanceVerify
 }
 
 // Initialize initializes a counter set object
 func (p *PdhCounterSet) Initialize(className string) error {
+	var err error
+	p.className, err = localizedClassName(className)
+	if err != nil {
+		return err
+	}
 
+	winerror := pfnPdhOpenQuery(uintptr(0), uintptr(0), &p.query)
+	if ERROR_SUCCESS != winerror {
+		err = fmt.Errorf("Failed to open PDH query handle %d", winerror)
+		return err
+	}
+	return nil
+}
+
+// initializeShared initializes a counter set object of a PdhQuery
+func (p *PdhCounterSet) initializeShared(className string, query PDH_HQUERY) error {
+	var err error
+	p.className, err = localizedClassName(className)
+	if err != nil {
+		return err
+	}
+	p.query = query
+	p.sharedQuery = true
+	return nil
+}
+
+// localizedClassName returns the name of the class in the language of the host
+func localizedClassName(className string) (string, error) {
 	// the counter index list may be > 1, but for class name, only take the first
 	// one.  If not present at all, try the english counter name
 	ndxlist, err := getCounterIndexList(className)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if ndxlist == nil || len(ndxlist) == 0 {
 		log.Warnf("Didn't find counter index for class %s, attempting english counter", className)
-		p.className = className
-	} else {
-		if len(ndxlist) > 1 {
-			log.Warnf("Class %s had multiple (%d) indices, using first", className, len(ndxlist))
-		}
-		ndx := ndxlist[0]
-		p.className, err = pfnPdhLookupPerfNameByIndex(ndx)
-		if err != nil {
-			return fmt.Errorf("Class name not found: %s", className)
-		}
-		log.Debugf("Found class name for %s %s", className, p.className)
+		return className, nil
 	}
+	if len(ndxlist) > 1 {
+		log.Warnf("Class %s had multiple (%d) indices, using first", className, len(ndxlist))
+	}
+	localized, err := pfnPdhLookupPerfNameByIndex(ndxlist[0])
+	if err != nil {
+		return "", fmt.Errorf("Class name not found: %s", className)
+	}
+	log.Debugf("Found class name for %s %s", className, localized)
+	return localized, nil
+}
 
-	winerror := pfnPdhOpenQuery(uintptr(0), uintptr(0), &p.query)
+// IsSingleInstance returns whether the given counter class has a single instance,
+// a multi-instance class without instances at the moment isn't
+func IsSingleInstance(className string) (bool, error) {
+	localized, err := localizedClassName(className)
+	if err != nil {
+		return false, err
+	}
+	multiInstance, err := pfnPdhIsMultiInstance(localized)
+	if err != nil {
+		return false, err
+	}
+	return !multiInstance, nil
+}
+
+// NewPdhQuery opens a query to add counter sets to, Close releases it and its counters
+func NewPdhQuery() (*PdhQuery, error) {
+	q := &PdhQuery{}
+	winerror := pfnPdhOpenQuery(uintptr(0), uintptr(0), &q.handle)
 	if ERROR_SUCCESS != winerror {
-		err = fmt.Errorf("Failed to open PDH query handle %d", winerror)
-		return err
+		return nil, fmt.Errorf("Failed to open PDH query handle %d", winerror)
 	}
-	return nil
+	return q, nil
+}
+
+// CollectData collects the values of all the counter sets of the query, they
+// don't collect them themselves
+func (q *PdhQuery) CollectData() {
+	pfnPdhCollectQueryData(q.handle)
+}
+
+// Close closes the query handle and the counters of its counter sets
+func (q *PdhQuery) Close() {
+	pfnPdhCloseQuery(q.handle)
+}
+
+// AddSingleInstanceCounter adds a single instance counter of the given counter class to the query
+func (q *PdhQuery) AddSingleInstanceCounter(className, counterName string) (*PdhSingleInstanceCounterSet, error) {
+	var p PdhSingleInstanceCounterSet
+	if err := p.initializeShared(className, q.handle); err != nil {
+		return nil, err
+	}
+	if err := p.addCounter(counterName); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AddMultiInstanceCounter adds a multi-instance counter of the given counter class to the query
+func (q *PdhQuery) AddMultiInstanceCounter(className, counterName string, requestedInstances *[]string, verifyfn CounterInstanceVerify) (*PdhMultiInstanceCounterSet, error) {
+	var p PdhMultiInstanceCounterSet
+	if err := p.initializeShared(className, q.handle); err != nil {
+		return nil, err
+	}
+	if err := p.addCounters(counterName, requestedInstances, verifyfn); err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
 // GetSingleInstanceCounter returns a single instance counter object for the given counter class
@@ -92,58 +196,87 @@ func GetSingleInstanceCounter(className, counterName string) (*PdhSingleInstance
 	if err := p.Initialize(className); err != nil {
 		return nil, err
 	}
+	if err := p.addCounter(counterName); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *PdhSingleInstanceCounterSet) addCounter(counterName string) error {
 	// check to make sure this is really a single instance counter
-	allcounters, instances, _ := pfnPdhEnumObjectItems(p.className)
-	if len(instances) > 0 {
-		return nil, fmt.Errorf("Requested counter is not single-instance: %s", p.className)
+	multiInstance, err := pfnPdhIsMultiInstance(p.className)
+	if err != nil {
+		return err
 	}
+	if multiInstance {
+		return fmt.Errorf("Requested counter is not single-instance: %s", p.className)
+	}
+	allcounters, _, _ := pfnPdhEnumObjectItems(p.className)
 	path, err := p.MakeCounterPath("", counterName, "", allcounters)
 	if err != nil {
 		log.Warnf("Failed pdhEnumObjectItems %v", err)
-		return nil, err
+		return err
 	}
 	winerror := pfnPdhAddCounter(p.query, path, uintptr(0), &p.singleCounter)
 	if ERROR_SUCCESS != winerror {
-		return nil, fmt.Errorf("Failed to add single counter %d", winerror)
+		return fmt.Errorf("Failed to add single counter %d", winerror)
 	}
 
 	// do the initial collect now
 	pfnPdhCollectQueryData(p.query)
-	return &p, nil
+	return nil
 }
 
-// GetMultiInstanceCounter returns a multi-instance counter object for the given counter class
+// GetMultiInstanceCounter returns a multi-instance counter object for the given counter class.
+// The requested instances may contain wildcards, see path.Match for their syntax.
 func GetMultiInstanceCounter(className, counterName string, requestedInstances *[]string, verifyfn CounterInstanceVerify) (*PdhMultiInstanceCounterSet, error) {
 	var p PdhMultiInstanceCounterSet
 	if err := p.Initialize(className); err != nil {
 		return nil, err
 	}
+	if err := p.addCounters(counterName, requestedInstances, verifyfn); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *PdhMultiInstanceCounterSet) addCounters(counterName string, requestedInstances *[]string, verifyfn CounterInstanceVerify) error {
 	p.countermap = make(map[string]PDH_HCOUNTER)
 	p.verifyfn = verifyfn
 	p.requestedCounterName = counterName
 
-	// check to make sure this is really a single instance counter
-	_, instances, _ := pfnPdhEnumObjectItems(p.className)
-	if len(instances) <= 0 {
-		return nil, fmt.Errorf("Requested counter is a single-instance: %s", p.className)
+	// check to make sure this is really a multi-instance counter, it may
+	// have no instances yet
+	multiInstance, err := pfnPdhIsMultiInstance(p.className)
+	if err != nil {
+		return err
+	}
+	if !multiInstance {
+		return fmt.Errorf("Requested counter is a single-instance: %s", p.className)
 	}
 	// save the requested instances
 	if requestedInstances != nil && len(*requestedInstances) > 0 {
 		p.requestedInstances = make(map[string]bool)
 		for _, inst := range *requestedInstances {
-			p.requestedInstances[inst] = true
+			if strings.ContainsAny(inst, "*?[") {
+				if _, err := path.Match(inst, ""); err != nil {
+					return fmt.Errorf("Invalid instance pattern %s: %v", inst, err)
+				}
+				p.requestedPatterns = append(p.requestedPatterns, inst)
+			} else {
+				p.requestedInstances[inst] = true
+			}
 		}
 	}
-	if err := p.MakeInstanceList(); err != nil {
-		return nil, err
-	}
-	return &p, nil
-
+	return p.MakeInstanceList()
 }
 
 // MakeInstanceList walks the list of available instances, and adds new
 // instances that have appeared since the last check run
 func (p *PdhMultiInstanceCounterSet) MakeInstanceList() error {
+	refreshInstances()
 	allcounters, instances, err := pfnPdhEnumObjectItems(p.className)
 	if err != nil {
 		return err
@@ -154,7 +287,7 @@ func (p *PdhMultiInstanceCounterSet) MakeInstanceList() error {
 		// they're here.  If not, add them to the list of instances to make
 		if p.requestedInstances != nil {
 			// if it's not in the requestedInstances, don't bother
-			if !p.requestedInstances[actualInstance] {
+			if !p.isRequested(actualInstance) {
 				continue
 			}
 			// ok.  it was requested.  If it's not in our map
@@ -201,6 +334,33 @@ func (p *PdhMultiInstanceCounterSet) MakeInstanceList() error {
 	return nil
 }
 
+// isRequested returns whether an instance is one of the requested instances
+func (p *PdhMultiInstanceCounterSet) isRequested(instance string) bool {
+	if p.requestedInstances[instance] {
+		return true
+	}
+	for _, pattern := range p.requestedPatterns {
+		if matched, _ := path.Match(pattern, instance); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshInstances refreshes the instances listed by pdh, so that the new
+// instances are found and the removed ones aren't added back
+func refreshInstances() {
+	instanceRefreshLock.Lock()
+	defer instanceRefreshLock.Unlock()
+	if time.Since(lastInstanceRefresh) < instanceRefreshInterval {
+		return
+	}
+	if err := pfnPdhRefreshObjects(); err != nil {
+		log.Debugf("Failed to refresh the pdh instances: %v", err)
+	}
+	lastInstanceRefresh = time.Now()
+}
+
 //RemoveInvalidInstance removes an instance from the counter that is no longer valid
 func (p *PdhMultiInstanceCounterSet) RemoveInvalidInstance(badInstance string) {
 	hc := p.countermap[badInstance]
@@ -263,7 +423,9 @@ func (p *PdhMultiInstanceCounterSet) GetAllValues() (values map[string]float64,
 	values = make(map[string]float64)
 	err = nil
 	var removeList []string
-	pfnPdhCollectQueryData(p.query)
+	if !p.sharedQuery {
+		pfnPdhCollectQueryData(p.query)
+	}
 	for inst, hcounter := range p.countermap {
 		var retval float64
 		retval, err = pfnPdhGetFormattedCounterValueFloat(hcounter)
@@ -294,14 +456,19 @@ func (p *PdhSingleInstanceCounterSet) GetValue() (val float64, err error) {
 	if p.singleCounter == PDH_HCOUNTER(0) {
 		return 0, fmt.Errorf("Not a single-value counter")
 	}
-	pfnPdhCollectQueryData(p.query)
+	if !p.sharedQuery {
+		pfnPdhCollectQueryData(p.query)
+	}
 	return pfnPdhGetFormattedCounterValueFloat(p.singleCounter)
 
 }
 
 // Close closes the query handle, freeing the underlying windows resources.
+// The counter sets of a PdhQuery are released when it's closed.
 func (p *PdhCounterSet) Close() {
-	PdhCloseQuery(p.query)
+	if !p.sharedQuery {
+		pfnPdhCloseQuery(p.query)
+	}
 }
 
 func getCounterIndexList(cname string) ([]int, error) {
@@ -21,6 +21,7 @@ var (
 
 	procPdhLookupPerfNameByIndex    = modPdhDll.NewProc("PdhLookupPerfNameByIndexW")
 	procPdhEnumObjectItems          = modPdhDll.NewProc("PdhEnumObjectItemsW")
+	procPdhEnumObjects              = modPdhDll.NewProc("PdhEnumObjectsW")
 	procPdhMakeCounterPath          = modPdhDll.NewProc("PdhMakeCounterPathW")
 	procPdhGetFormattedCounterValue = modPdhDll.NewProc("PdhGetFormattedCounterValue")
 	procPdhAddCounterW              = modPdhDll.NewProc("PdhAddCounterW")
@@ -121,6 +122,46 @@ func pdhEnumObjectItems(className string) (counters []string, instances []string
 
 }
 
+// pdhIsMultiInstance returns whether a class has instances, even if it has
+// none at the moment
+func pdhIsMultiInstance(className string) (bool, error) {
+	var counterlen uint32
+	var instancelen uint32
+	r, _, _ := procPdhEnumObjectItems.Call(
+		uintptr(0), // NULL data source, use computer in computername parameter
+		uintptr(0), // local computer
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(className))),
+		uintptr(0), // only the buffer sizes are needed
+		uintptr(unsafe.Pointer(&counterlen)),
+		uintptr(0),
+		uintptr(unsafe.Pointer(&instancelen)),
+		uintptr(PERF_DETAIL_WIZARD),
+		uintptr(0))
+	if r != PDH_MORE_DATA && r != ERROR_SUCCESS {
+		return false, fmt.Errorf("Failed to get buffer size %v", r)
+	}
+	// a single instance class has no instance list, a multi-instance class
+	// without instances has an empty list of 2 characters
+	return instancelen != 0, nil
+}
+
+// pdhRefreshObjects refreshes the objects and instances cached by pdh, the
+// objects themselves aren't returned
+func pdhRefreshObjects() error {
+	var bufLen uint32
+	r, _, _ := procPdhEnumObjects.Call(
+		uintptr(0), // NULL data source, use computer in computername parameter
+		uintptr(0), // local computer
+		uintptr(0), // no object list, only the refresh is needed
+		uintptr(unsafe.Pointer(&bufLen)),
+		uintptr(PERF_DETAIL_WIZARD),
+		uintptr(1)) // bRefresh
+	if r != ERROR_SUCCESS && r != PDH_MORE_DATA {
+		return fmt.Errorf("Failed to refresh the objects %v", r)
+	}
+	return nil
+}
+
 type pdh_counter_path_elements struct {
 	ptrmachineString  uintptr
 	ptrobjectString   uintptr
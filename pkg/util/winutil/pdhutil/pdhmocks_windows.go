@@ -129,6 +129,23 @@ func mockpdhMakeCounterPath(machine string, object string, instance string, coun
 	return
 }
 
+func mockpdhRefreshObjects() error {
+	return nil
+}
+
+// mockMultiInstanceClasses are the multi-instance classes with no instances
+var mockMultiInstanceClasses = make(map[string]bool)
+
+// AddMultiInstanceClassWithoutInstances makes a class of the test counters
+// multi-instance, even if it has no instances
+func AddMultiInstanceClassWithoutInstances(className string) {
+	mockMultiInstanceClasses[className] = true
+}
+
+func mockpdhIsMultiInstance(className string) (bool, error) {
+	return len(activeAvailableCounters.instancesByClass[className]) > 0 || mockMultiInstanceClasses[className], nil
+}
+
 func mockpdhEnumObjectItems(className string) (counters []string, instances []string, err error) {
 	counters = activeAvailableCounters.countersByClass[className]
 	instances = activeAvailableCounters.instancesByClass[className]
@@ -139,17 +156,20 @@ func mockpdhEnumObjectItems(className string) (counters []string, instances []st
 func SetupTesting(counterstringsfile, countersfile string) {
 	activeCounterStrings, _ = ReadCounterStrings(counterstringsfile)
 	activeAvailableCounters, _ = ReadCounters(countersfile)
+	mockMultiInstanceClasses = make(map[string]bool)
 	// For testing
 	pfnMakeCounterSetInstances = mockmakeCounterSetIndexes
 	pfnPdhOpenQuery = mockPdhOpenQuery
 	pfnPdhAddCounter = mockPdhAddCounter
 	pfnPdhCollectQueryData = mockPdhCollectQueryData
 	pfnPdhEnumObjectItems = mockpdhEnumObjectItems
+	pfnPdhIsMultiInstance = mockpdhIsMultiInstance
 	pfnPdhRemoveCounter = mockPdhRemoveCounter
 	pfnPdhLookupPerfNameByIndex = mockpdhLookupPerfNameByIndex
 	pfnPdhGetFormattedCounterValueFloat = mockpdhGetFormattedCounterValueFloat
 	pfnPdhCloseQuery = mockPdhCloseQuery
 	pfnPdhMakeCounterPath = mockpdhMakeCounterPath
+	pfnPdhRefreshObjects = mockpdhRefreshObjects
 
 }
 
@@ -0,0 +1,189 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build containerd
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/api/events"
+	containerdevents "github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/gogo/protobuf/proto"
+
+	ctrUtil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const containerdCollectorName = "containerd"
+
+// containerdEventTopics are the topics of the containerd events updating the containers
+var containerdEventTopics = []string{
+	"/containers/create",
+	"/containers/update",
+	"/containers/delete",
+}
+
+// containerdEventFilters selects the containerd events updating the containers of the given namespaces
+func containerdEventFilters(namespaces []string) []string {
+	var filters []string
+	for _, namespace := range namespaces {
+		for _, topic := range containerdEventTopics {
+			filters = append(filters, fmt.Sprintf(`topic==%q,namespace==%q`, topic, namespace))
+		}
+	}
+	return filters
+}
+
+// containerdCollector watches the containerd events to keep the containers up to date
+type containerdCollector struct {
+	containerdUtil ctrUtil.ContainerdItf
+	store          *workloadmeta.Store
+}
+
+func (c *containerdCollector) Start(ctx context.Context, store *workloadmeta.Store) error {
+	cu, err := ctrUtil.GetContainerdUtil()
+	if err != nil {
+		return err
+	}
+	c.containerdUtil = cu
+	c.store = store
+
+	nsCtx := namespaces.WithNamespace(ctx, cu.Namespace())
+	stream, errs := cu.GetEvents().Subscribe(nsCtx, containerdEventFilters(cu.Namespaces())...)
+
+	// the containers created before the subscription have no create event
+	for _, namespace := range cu.Namespaces() {
+		if err := c.listContainers(namespace); err != nil {
+			return err
+		}
+	}
+
+	go c.stream(ctx, stream, errs)
+	return nil
+}
+
+func (c *containerdCollector) stream(ctx context.Context, stream <-chan *containerdevents.Envelope, errs <-chan error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-stream:
+			c.handleEvent(message)
+		case err := <-errs:
+			if err != nil {
+				log.Errorf("stopping the workloadmeta containerd collection: %s", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *containerdCollector) handleEvent(message *containerdevents.Envelope) {
+	switch message.Topic {
+	case "/containers/create":
+		create := &events.ContainerCreate{}
+		if err := proto.Unmarshal(message.Event.Value, create); err != nil {
+			log.Errorf("Could not process create event from containerd: %v", err)
+			return
+		}
+		c.updateContainer(message.Namespace, create.ID)
+	case "/containers/update":
+		update := &events.ContainerUpdate{}
+		if err := proto.Unmarshal(message.Event.Value, update); err != nil {
+			log.Errorf("Could not process update event from containerd: %v", err)
+			return
+		}
+		c.updateContainer(message.Namespace, update.ID)
+	case "/containers/delete":
+		del := &events.ContainerDelete{}
+		if err := proto.Unmarshal(message.Event.Value, del); err != nil {
+			log.Errorf("Could not process delete event from containerd: %v", err)
+			return
+		}
+		c.store.Notify([]workloadmeta.CollectorEvent{{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceContainerd,
+			Entity: workloadmeta.Container{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: del.ID},
+			},
+		}})
+	}
+}
+
+// updateContainer sends a Set event for the container of the namespace with
+// the given ID
+func (c *containerdCollector) updateContainer(namespace string, id string) {
+	cu := c.containerdUtil.WithNamespace(namespace)
+	ctn, err := cu.Container(id)
+	if err != nil {
+		log.Debugf("Failed to get container %s - %s", id, err)
+		return
+	}
+	container, err := c.buildContainer(cu, ctn)
+	if err != nil {
+		log.Debugf("Failed to get the info of container %s - %s", id, err)
+		return
+	}
+	c.store.Notify([]workloadmeta.CollectorEvent{{
+		Type:   workloadmeta.EventTypeSet,
+		Source: workloadmeta.SourceContainerd,
+		Entity: container,
+	}})
+}
+
+// listContainers sends a Set event for every container of the namespace
+func (c *containerdCollector) listContainers(namespace string) error {
+	cu := c.containerdUtil.WithNamespace(namespace)
+	list, err := cu.Containers()
+	if err != nil {
+		return err
+	}
+
+	var events []workloadmeta.CollectorEvent
+	for _, ctn := range list {
+		container, err := c.buildContainer(cu, ctn)
+		if err != nil {
+			log.Debugf("Failed to get the info of container %s - %s", ctn.ID(), err)
+			continue
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceContainerd,
+			Entity: container,
+		})
+	}
+	c.store.Notify(events)
+	return nil
+}
+
+func (c *containerdCollector) buildContainer(cu ctrUtil.ContainerdItf, ctn containerd.Container) (workloadmeta.Container, error) {
+	info, err := cu.Info(ctn)
+	if err != nil {
+		return workloadmeta.Container{}, err
+	}
+
+	return workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: info.ID},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      info.ID,
+			Namespace: cu.Namespace(),
+			Labels:    info.Labels,
+		},
+		Image:   info.Image,
+		Runtime: workloadmeta.ContainerRuntimeContainerd,
+	}, nil
+}
+
+func init() {
+	workloadmeta.RegisterCollector(containerdCollectorName, func() workloadmeta.Collector {
+		return &containerdCollector{}
+	})
+}
@@ -0,0 +1,11 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package collectors holds the workloadmeta collectors. Each of them registers
+itself in the workloadmeta DefaultCatalog, depending on the build tags: the
+package is to be imported by the agent binaries running the global store.
+*/
+package collectors
@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const dockerCollectorName = "docker"
+
+// dockerCollector watches the docker events to keep the containers up to date
+type dockerCollector struct {
+	dockerUtil *docker.DockerUtil
+	store      *workloadmeta.Store
+}
+
+func (c *dockerCollector) Start(ctx context.Context, store *workloadmeta.Store) error {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return err
+	}
+	c.dockerUtil = du
+	c.store = store
+
+	messages, errs, err := c.dockerUtil.SubscribeToContainerEvents("workloadmeta")
+	if err != nil {
+		return err
+	}
+
+	// the containers started before the subscription have no start event
+	if err := c.listContainers(); err != nil {
+		c.dockerUtil.UnsubscribeFromContainerEvents("workloadmeta")
+		return err
+	}
+
+	go c.stream(ctx, messages, errs)
+	return nil
+}
+
+func (c *dockerCollector) stream(ctx context.Context, messages <-chan *docker.ContainerEvent, errs <-chan error) {
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.dockerUtil.UnsubscribeFromContainerEvents("workloadmeta"); err != nil {
+				log.Warnf("error unsubscribing from the docker events: %s", err)
+			}
+			return
+		case msg := <-messages:
+			c.handleEvent(msg)
+		case err := <-errs:
+			if err != nil && err != io.EOF {
+				log.Errorf("stopping the workloadmeta docker collection: %s", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *dockerCollector) listContainers() error {
+	list, err := c.dockerUtil.RawContainerList(types.ContainerListOptions{})
+	if err != nil {
+		return err
+	}
+
+	events := make([]workloadmeta.CollectorEvent, 0, len(list))
+	for _, co := range list {
+		container, err := c.buildContainer(co.ID)
+		if err != nil {
+			continue
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceDocker,
+			Entity: container,
+		})
+	}
+	c.store.Notify(events)
+	return nil
+}
+
+func (c *dockerCollector) handleEvent(ev *docker.ContainerEvent) {
+	var event workloadmeta.CollectorEvent
+
+	switch ev.Action {
+	case "start", "rename":
+		container, err := c.buildContainer(ev.ContainerID)
+		if err != nil {
+			return
+		}
+		event = workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceDocker,
+			Entity: container,
+		}
+	case "die":
+		event = workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceDocker,
+			Entity: workloadmeta.Container{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: ev.ContainerID},
+			},
+		}
+	default:
+		return
+	}
+
+	c.store.Notify([]workloadmeta.CollectorEvent{event})
+}
+
+func (c *dockerCollector) buildContainer(id string) (workloadmeta.Container, error) {
+	co, err := c.dockerUtil.Inspect(id, false)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Debugf("Failed to inspect container %s - %s", id, err)
+		}
+		return workloadmeta.Container{}, err
+	}
+
+	container := workloadmeta.Container{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: co.ID},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: strings.TrimPrefix(co.Name, "/"),
+		},
+		Runtime: workloadmeta.ContainerRuntimeDocker,
+	}
+	if co.Config != nil {
+		container.Labels = co.Config.Labels
+		container.EnvVars = parseEnvVars(co.Config.Env)
+		container.Image, err = c.dockerUtil.ResolveImageNameFromContainer(co)
+		if err != nil {
+			log.Debugf("Failed to resolve the image of container %s - %s", id, err)
+			container.Image = co.Config.Image
+		}
+	}
+	if co.State != nil {
+		container.State.Running = co.State.Running
+		container.State.StartedAt, _ = time.Parse(time.RFC3339Nano, co.State.StartedAt)
+	}
+	return container, nil
+}
+
+// parseEnvVars converts a list of KEY=VALUE variables to a map
+func parseEnvVars(env []string) map[string]string {
+	envVars := make(map[string]string, len(env))
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		envVars[parts[0]] = parts[1]
+	}
+	return envVars
+}
+
+func init() {
+	workloadmeta.RegisterCollector(dockerCollectorName, func() workloadmeta.Collector {
+		return &dockerCollector{}
+	})
+}
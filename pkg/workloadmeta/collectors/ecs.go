@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build docker
+
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/ecs"
+	ecsmeta "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata"
+	v1 "github.com/DataDog/datadog-agent/pkg/util/ecs/metadata/v1"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	ecsCollectorName = "ecs"
+	ecsPullInterval  = 10 * time.Second
+)
+
+// ecsCollector lists the tasks of the ECS agent. The introspection endpoint
+// has no events, the collector computes the changes between two task lists.
+type ecsCollector struct {
+	metaV1    *v1.Client
+	store     *workloadmeta.Store
+	seenTasks map[string]workloadmeta.ECSTask
+}
+
+func (c *ecsCollector) Start(ctx context.Context, store *workloadmeta.Store) error {
+	if !ecs.IsECSInstance() {
+		return fmt.Errorf("not running on an ECS instance")
+	}
+
+	var err error
+	c.metaV1, err = ecsmeta.V1()
+	if err != nil {
+		return err
+	}
+	c.store = store
+	c.seenTasks = make(map[string]workloadmeta.ECSTask)
+
+	go c.run(ctx)
+	return nil
+}
+
+func (c *ecsCollector) run(ctx context.Context) {
+	ticker := time.NewTicker(ecsPullInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pull(); err != nil {
+			log.Warnf("workloadmeta ecs collector: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *ecsCollector) pull() error {
+	tasks, err := c.metaV1.GetTasks()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]workloadmeta.ECSTask, len(tasks))
+	events := make([]workloadmeta.CollectorEvent, 0, len(tasks))
+	for _, t := range tasks {
+		task := buildTask(t)
+		seen[task.ID] = task
+		// only the new and updated tasks are sent to the store
+		if previous, found := c.seenTasks[task.ID]; found && reflect.DeepEqual(previous, task) {
+			continue
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceECS,
+			Entity: task,
+		})
+	}
+
+	for arn := range c.seenTasks {
+		if _, found := seen[arn]; found {
+			continue
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceECS,
+			Entity: workloadmeta.ECSTask{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindECSTask, ID: arn},
+			},
+		})
+	}
+	c.seenTasks = seen
+
+	c.store.Notify(events)
+	return nil
+}
+
+func buildTask(task v1.Task) workloadmeta.ECSTask {
+	containerIDs := make([]string, 0, len(task.Containers))
+	containerNames := make(map[string]string, len(task.Containers))
+	for _, container := range task.Containers {
+		containerIDs = append(containerIDs, container.DockerID)
+		containerNames[container.DockerID] = container.Name
+	}
+
+	return workloadmeta.ECSTask{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindECSTask,
+			ID:   task.Arn,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name: task.Family,
+		},
+		Family:         task.Family,
+		Version:        task.Version,
+		DesiredStatus:  task.DesiredStatus,
+		KnownStatus:    task.KnownStatus,
+		Containers:     containerIDs,
+		ContainerNames: containerNames,
+	}
+}
+
+func init() {
+	workloadmeta.RegisterCollector(ecsCollectorName, func() workloadmeta.Collector {
+		return &ecsCollector{}
+	})
+}
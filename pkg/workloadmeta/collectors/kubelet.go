@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package collectors
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	kubeletCollectorName = "kubelet"
+	kubeletPullInterval  = 5 * time.Second
+	kubeletExpireFreq    = 15 * time.Second
+)
+
+// kubeletCollector watches the pod list of the kubelet. The kubelet has no
+// watch API, the PodWatcher computes the changes between two pod lists.
+type kubeletCollector struct {
+	watcher    *kubelet.PodWatcher
+	store      *workloadmeta.Store
+	lastExpire time.Time
+}
+
+func (c *kubeletCollector) Start(ctx context.Context, store *workloadmeta.Store) error {
+	watcher, err := kubelet.NewPodWatcher(kubeletExpireFreq, true)
+	if err != nil {
+		return err
+	}
+	c.watcher = watcher
+	c.store = store
+	c.lastExpire = time.Now()
+
+	go c.run(ctx)
+	return nil
+}
+
+func (c *kubeletCollector) run(ctx context.Context) {
+	ticker := time.NewTicker(kubeletPullInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pull(); err != nil {
+			log.Warnf("workloadmeta kubelet collector: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *kubeletCollector) pull() error {
+	updatedPods, err := c.watcher.PullChanges()
+	if err != nil {
+		return err
+	}
+
+	events := make([]workloadmeta.CollectorEvent, 0, len(updatedPods))
+	for _, pod := range updatedPods {
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceKubelet,
+			Entity: buildPod(pod),
+		})
+		for _, container := range buildCRIOContainers(pod) {
+			events = append(events, workloadmeta.CollectorEvent{
+				Type:   workloadmeta.EventTypeSet,
+				Source: workloadmeta.SourceKubelet,
+				Entity: container,
+			})
+		}
+	}
+
+	if time.Since(c.lastExpire) >= kubeletExpireFreq {
+		expired, err := c.watcher.Expire()
+		if err != nil {
+			return err
+		}
+		for _, id := range expired {
+			if runtime, containerID := containers.SplitEntityName(id); runtime == string(workloadmeta.ContainerRuntimeCRIO) {
+				events = append(events, workloadmeta.CollectorEvent{
+					Type:   workloadmeta.EventTypeUnset,
+					Source: workloadmeta.SourceKubelet,
+					Entity: workloadmeta.Container{
+						EntityID: workloadmeta.EntityID{
+							Kind: workloadmeta.KindContainer,
+							ID:   containerID,
+						},
+					},
+				})
+				continue
+			}
+			if !strings.HasPrefix(id, kubelet.KubePodPrefix) {
+				// containers are removed by the container runtime collectors
+				continue
+			}
+			events = append(events, workloadmeta.CollectorEvent{
+				Type:   workloadmeta.EventTypeUnset,
+				Source: workloadmeta.SourceKubelet,
+				Entity: workloadmeta.KubernetesPod{
+					EntityID: workloadmeta.EntityID{
+						Kind: workloadmeta.KindKubernetesPod,
+						ID:   strings.TrimPrefix(id, kubelet.KubePodPrefix),
+					},
+				},
+			})
+		}
+		c.lastExpire = time.Now()
+	}
+
+	c.store.Notify(events)
+	return nil
+}
+
+func buildPod(pod *kubelet.Pod) workloadmeta.KubernetesPod {
+	var containerIDs []string
+	for _, status := range pod.Status.GetAllContainers() {
+		if status.IsPending() {
+			continue
+		}
+		_, id := containers.SplitEntityName(status.ID)
+		containerIDs = append(containerIDs, id)
+	}
+
+	return workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindKubernetesPod,
+			ID:   pod.Metadata.UID,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:        pod.Metadata.Name,
+			Namespace:   pod.Metadata.Namespace,
+			Labels:      pod.Metadata.Labels,
+			Annotations: pod.Metadata.Annotations,
+		},
+		Phase:      pod.Status.Phase,
+		Containers: containerIDs,
+	}
+}
+
+// buildCRIOContainers returns the CRI-O containers of a pod. There's no
+// collector for the CRI-O runtime, the kubelet is the source of its containers.
+func buildCRIOContainers(pod *kubelet.Pod) []workloadmeta.Container {
+	var ctrs []workloadmeta.Container
+	for _, status := range pod.Status.GetAllContainers() {
+		runtime, id := containers.SplitEntityName(status.ID)
+		if runtime != string(workloadmeta.ContainerRuntimeCRIO) {
+			continue
+		}
+
+		state := workloadmeta.ContainerState{}
+		if status.State.Running != nil {
+			state.Running = true
+			state.StartedAt = status.State.Running.StartedAt
+		}
+		ctrs = append(ctrs, workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainer,
+				ID:   id,
+			},
+			EntityMeta: workloadmeta.EntityMeta{
+				Name:      status.Name,
+				Namespace: pod.Metadata.Namespace,
+			},
+			Image:   status.Image,
+			Runtime: workloadmeta.ContainerRuntimeCRIO,
+			State:   state,
+		})
+	}
+	return ctrs
+}
+
+func init() {
+	workloadmeta.RegisterCollector(kubeletCollectorName, func() workloadmeta.Collector {
+		return &kubeletCollector{}
+	})
+}
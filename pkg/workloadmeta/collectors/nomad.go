@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package collectors
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/nomad"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	nomadCollectorName = "nomad"
+	nomadPullInterval  = 10 * time.Second
+)
+
+// nomadCollector lists the allocations of the node from the local Nomad
+// agent, and computes the changes between two allocation lists.
+type nomadCollector struct {
+	client     *nomad.Client
+	store      *workloadmeta.Store
+	seenAllocs map[string]struct{}
+}
+
+func (c *nomadCollector) Start(ctx context.Context, store *workloadmeta.Store) error {
+	var err error
+	c.client, err = nomad.NewClient()
+	if err != nil {
+		return err
+	}
+	c.store = store
+	c.seenAllocs = make(map[string]struct{})
+
+	go c.run(ctx)
+	return nil
+}
+
+func (c *nomadCollector) run(ctx context.Context) {
+	ticker := time.NewTicker(nomadPullInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.pull(); err != nil {
+			log.Warnf("workloadmeta nomad collector: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *nomadCollector) pull() error {
+	allocs, err := c.client.GetNodeAllocations()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(allocs))
+	events := make([]workloadmeta.CollectorEvent, 0, len(allocs))
+	for _, alloc := range allocs {
+		// terminal allocations are kept by Nomad until garbage collected
+		if alloc.ClientStatus != "pending" && alloc.ClientStatus != "running" {
+			continue
+		}
+		seen[alloc.ID] = struct{}{}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeSet,
+			Source: workloadmeta.SourceNomad,
+			Entity: buildNomadAllocation(alloc),
+		})
+	}
+
+	for id := range c.seenAllocs {
+		if _, found := seen[id]; found {
+			continue
+		}
+		events = append(events, workloadmeta.CollectorEvent{
+			Type:   workloadmeta.EventTypeUnset,
+			Source: workloadmeta.SourceNomad,
+			Entity: workloadmeta.NomadAllocation{
+				EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindNomadAlloc, ID: id},
+			},
+		})
+	}
+	c.seenAllocs = seen
+
+	c.store.Notify(events)
+	return nil
+}
+
+func buildNomadAllocation(alloc nomad.Allocation) workloadmeta.NomadAllocation {
+	tasks := make([]string, 0, len(alloc.TaskStates))
+	for task := range alloc.TaskStates {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	return workloadmeta.NomadAllocation{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindNomadAlloc,
+			ID:   alloc.ID,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      alloc.Name,
+			Namespace: alloc.Namespace,
+		},
+		Job:          alloc.JobID,
+		Group:        alloc.TaskGroup,
+		ClientStatus: alloc.ClientStatus,
+		Tasks:        tasks,
+	}
+}
+
+func init() {
+	workloadmeta.RegisterCollector(nomadCollectorName, func() workloadmeta.Collector {
+		return &nomadCollector{}
+	})
+}
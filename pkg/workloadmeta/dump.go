@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// scrubbedEnvValue replaces the values of the environment variables of the
+// containers in the dumps, as they often hold credentials
+const scrubbedEnvValue = "********"
+
+// dumpedEnvVars are the environment variables whose value is kept in the
+// dumps, as they are used to compute the unified service tags
+var dumpedEnvVars = map[string]struct{}{
+	"DD_ENV":     {},
+	"DD_SERVICE": {},
+	"DD_VERSION": {},
+}
+
+// Dump is a serializable copy of the entities of a store, by source
+type Dump struct {
+	Entities []DumpEntity `json:"entities"`
+}
+
+// DumpEntity is an entity of a Dump, its concrete type is given by its kind
+type DumpEntity struct {
+	Kind   Kind            `json:"kind"`
+	Source Source          `json:"source"`
+	Entity json.RawMessage `json:"entity"`
+}
+
+// Dump returns a copy of the entities of the store, the values of the
+// environment variables of the containers are scrubbed
+func (s *Store) Dump() (Dump, error) {
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	var dump Dump
+	for kind, entitiesByID := range s.store {
+		for _, entitiesBySource := range entitiesByID {
+			for source, entity := range entitiesBySource {
+				raw, err := json.Marshal(scrubEntity(entity))
+				if err != nil {
+					return Dump{}, fmt.Errorf("unable to serialize %s %s: %s", kind, entity.GetID().ID, err)
+				}
+				dump.Entities = append(dump.Entities, DumpEntity{
+					Kind:   kind,
+					Source: source,
+					Entity: raw,
+				})
+			}
+		}
+	}
+
+	// stable output, to ease the comparison of dumps
+	sort.Slice(dump.Entities, func(i, j int) bool {
+		a, b := dump.Entities[i], dump.Entities[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return string(a.Entity) < string(b.Entity)
+	})
+
+	return dump, nil
+}
+
+// scrubEntity returns a copy of the entity without the values of the
+// environment variables of the containers
+func scrubEntity(entity Entity) Entity {
+	container, ok := entity.(Container)
+	if !ok || len(container.EnvVars) == 0 {
+		return entity
+	}
+
+	envVars := make(map[string]string, len(container.EnvVars))
+	for name, value := range container.EnvVars {
+		if _, found := dumpedEnvVars[name]; !found {
+			value = scrubbedEnvValue
+		}
+		envVars[name] = value
+	}
+	container.EnvVars = envVars
+	return container
+}
+
+// Load adds the entities of a dump to the store, as if they were sent by
+// their collectors. It is meant for stores that are not started.
+func (s *Store) Load(dump Dump) error {
+	events := make([]CollectorEvent, 0, len(dump.Entities))
+	for _, e := range dump.Entities {
+		entity, err := decodeEntity(e.Kind, e.Entity)
+		if err != nil {
+			return err
+		}
+		events = append(events, CollectorEvent{
+			Type:   EventTypeSet,
+			Source: e.Source,
+			Entity: entity,
+		})
+	}
+	s.handleEvents(events)
+	return nil
+}
+
+func decodeEntity(kind Kind, raw json.RawMessage) (Entity, error) {
+	var err error
+	switch kind {
+	case KindContainer:
+		var e Container
+		err = json.Unmarshal(raw, &e)
+		return e, err
+	case KindKubernetesPod:
+		var e KubernetesPod
+		err = json.Unmarshal(raw, &e)
+		return e, err
+	case KindECSTask:
+		var e ECSTask
+		err = json.Unmarshal(raw, &e)
+		return e, err
+	case KindNomadAlloc:
+		var e NomadAllocation
+		err = json.Unmarshal(raw, &e)
+		return e, err
+	default:
+		return nil, fmt.Errorf("unknown entity kind %q", kind)
+	}
+}
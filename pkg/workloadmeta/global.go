@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	globalStore     *Store
+	globalStoreOnce sync.Once
+	startOnce       sync.Once
+)
+
+// GetGlobalStore returns the store shared by the agent components, running
+// the collectors of the DefaultCatalog
+func GetGlobalStore() *Store {
+	globalStoreOnce.Do(func() {
+		globalStore = NewStore(DefaultCatalog)
+	})
+	return globalStore
+}
+
+// StartGlobalStore starts the global store, it must be called once the
+// configuration is loaded. Subsequent calls are noops.
+func StartGlobalStore(ctx context.Context) {
+	startOnce.Do(func() {
+		GetGlobalStore().Start(ctx)
+	})
+}
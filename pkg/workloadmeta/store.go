@@ -0,0 +1,380 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/retry"
+)
+
+const (
+	retryCollectorInterval = 30 * time.Second
+	eventBufferSize        = 100
+
+	// kubernetesPodNamespaceLabel is set by the kubelet on the containers of the pods
+	kubernetesPodNamespaceLabel = "io.kubernetes.pod.namespace"
+)
+
+// Store is the central store of the workload metadata. It is fed by the
+// collectors, each of them watching a source (a container runtime, the
+// kubelet...), and sends the changes to its subscribers.
+type Store struct {
+	storeMut sync.RWMutex
+	store    map[Kind]map[string]map[Source]Entity // kind -> entity ID -> source -> entity
+
+	subscribersMut sync.RWMutex
+	subscribers    []subscriber
+
+	// notifyMut is held while the events are sent to the subscribers, so
+	// that their channels are not closed in the middle of a send
+	notifyMut sync.Mutex
+
+	candidates map[string]CollectorFactory
+	collectors map[string]Collector
+
+	eventCh chan []CollectorEvent
+}
+
+type subscriber struct {
+	name   string
+	filter *Filter
+	ch     chan []Event
+	// done is closed when the subscriber unsubscribes, to abort the pending sends
+	done chan struct{}
+}
+
+// NewStore returns a store running the collectors of the catalog once started
+func NewStore(catalog CollectorCatalog) *Store {
+	candidates := make(map[string]CollectorFactory, len(catalog))
+	for name, factory := range catalog {
+		candidates[name] = factory
+	}
+
+	return &Store{
+		store:      make(map[Kind]map[string]map[Source]Entity),
+		candidates: candidates,
+		collectors: make(map[string]Collector),
+		eventCh:    make(chan []CollectorEvent, eventBufferSize),
+	}
+}
+
+// Start starts the collectors and processes their events until the context
+// is cancelled. Collectors that cannot start yet are retried regularly.
+func (s *Store) Start(ctx context.Context) {
+	retryTicker := time.NewTicker(retryCollectorInterval)
+	s.startCandidates(ctx)
+
+	go func() {
+		defer retryTicker.Stop()
+		for {
+			select {
+			case events := <-s.eventCh:
+				s.handleEvents(events)
+			case <-retryTicker.C:
+				if len(s.candidates) > 0 {
+					s.startCandidates(ctx)
+				}
+			case <-ctx.Done():
+				s.subscribersMut.Lock()
+				subscribers := s.subscribers
+				s.subscribers = nil
+				s.subscribersMut.Unlock()
+
+				s.notifyMut.Lock()
+				for _, sub := range subscribers {
+					close(sub.done)
+					close(sub.ch)
+				}
+				s.notifyMut.Unlock()
+				return
+			}
+		}
+	}()
+
+	log.Info("workloadmeta store initialized successfully")
+}
+
+func (s *Store) startCandidates(ctx context.Context) {
+	for name, factory := range s.candidates {
+		collector := factory()
+		err := collector.Start(ctx, s)
+		if retry.IsErrWillRetry(err) {
+			log.Debugf("workloadmeta collector %q could not start, will retry later: %s", name, err)
+			continue
+		}
+
+		// Whatever the outcome, don't try this collector again
+		delete(s.candidates, name)
+		if err != nil {
+			log.Debugf("workloadmeta collector %q cannot start: %s", name, err)
+			continue
+		}
+		s.collectors[name] = collector
+		log.Infof("workloadmeta collector %q started successfully", name)
+	}
+}
+
+// Notify is called by the collectors to send their events to the store
+func (s *Store) Notify(events []CollectorEvent) {
+	if len(events) > 0 {
+		s.eventCh <- events
+	}
+}
+
+// Subscribe returns a channel receiving the events matching the filter. The
+// first batch holds a Set event for every entity already in the store, it is
+// always received before any other event. An entity updated while
+// subscribing can be sent again right after the snapshot: Set events are to be
+// handled as idempotent upserts.
+// The subscribers must consume their channel promptly, the store blocks
+// until each of them has received its events or has unsubscribed.
+func (s *Store) Subscribe(name string, filter *Filter) chan []Event {
+	sub := subscriber{
+		name:   name,
+		filter: filter,
+		ch:     make(chan []Event, 1),
+		done:   make(chan struct{}),
+	}
+
+	// The store is locked until the subscriber is registered so that no
+	// update can happen between the snapshot and the registration.
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	var snapshot []Event
+	for _, entitiesByID := range s.store {
+		for _, entitiesBySource := range entitiesByID {
+			for source, entity := range entitiesBySource {
+				ev := Event{Type: EventTypeSet, Source: source, Entity: entity}
+				if filter.Match(ev) {
+					snapshot = append(snapshot, ev)
+				}
+			}
+		}
+	}
+
+	// The channel is empty and buffered, this doesn't block
+	sub.ch <- snapshot
+
+	s.subscribersMut.Lock()
+	s.subscribers = append(s.subscribers, sub)
+	s.subscribersMut.Unlock()
+
+	return sub.ch
+}
+
+// Unsubscribe stops sending events to the channel and closes it
+func (s *Store) Unsubscribe(ch chan []Event) {
+	s.subscribersMut.Lock()
+	var sub subscriber
+	found := false
+	for i := range s.subscribers {
+		if s.subscribers[i].ch == ch {
+			sub, found = s.subscribers[i], true
+			s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+			break
+		}
+	}
+	s.subscribersMut.Unlock()
+
+	if !found {
+		return
+	}
+
+	// Abort a pending send to this subscriber, then wait for the end of the
+	// current notification before closing the channel
+	close(sub.done)
+	s.notifyMut.Lock()
+	close(sub.ch)
+	s.notifyMut.Unlock()
+}
+
+func (s *Store) handleEvents(collectorEvents []CollectorEvent) {
+	events := make([]Event, 0, len(collectorEvents))
+
+	s.storeMut.Lock()
+	for _, cev := range collectorEvents {
+		id := cev.Entity.GetID()
+		entitiesByID, ok := s.store[id.Kind]
+		if !ok {
+			entitiesByID = make(map[string]map[Source]Entity)
+			s.store[id.Kind] = entitiesByID
+		}
+
+		switch cev.Type {
+		case EventTypeSet:
+			entitiesBySource, ok := entitiesByID[id.ID]
+			if !ok {
+				entitiesBySource = make(map[Source]Entity)
+				entitiesByID[id.ID] = entitiesBySource
+			}
+			entitiesBySource[cev.Source] = cev.Entity
+		case EventTypeUnset:
+			entitiesBySource, ok := entitiesByID[id.ID]
+			if !ok {
+				continue
+			}
+			if _, ok := entitiesBySource[cev.Source]; !ok {
+				continue
+			}
+			delete(entitiesBySource, cev.Source)
+			if len(entitiesBySource) == 0 {
+				delete(entitiesByID, id.ID)
+			}
+		default:
+			log.Errorf("workloadmeta: unknown event type %d from %s", cev.Type, cev.Source)
+			continue
+		}
+
+		events = append(events, Event(cev))
+	}
+	s.storeMut.Unlock()
+
+	s.subscribersMut.RLock()
+	subscribers := make([]subscriber, len(s.subscribers))
+	copy(subscribers, s.subscribers)
+	s.subscribersMut.RUnlock()
+
+	s.notifyMut.Lock()
+	defer s.notifyMut.Unlock()
+	for _, sub := range subscribers {
+		var filtered []Event
+		for _, ev := range events {
+			if sub.filter.Match(ev) {
+				filtered = append(filtered, ev)
+			}
+		}
+		if len(filtered) == 0 {
+			continue
+		}
+		select {
+		case sub.ch <- filtered:
+		case <-sub.done:
+		}
+	}
+}
+
+// GetContainer returns the container with the given ID
+func (s *Store) GetContainer(id string) (Container, error) {
+	entity, err := s.getEntity(KindContainer, id)
+	if err != nil {
+		return Container{}, err
+	}
+	return entity.(Container), nil
+}
+
+// ListContainers returns every container of the store
+func (s *Store) ListContainers() []Container {
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	containers := make([]Container, 0, len(s.store[KindContainer]))
+	for id := range s.store[KindContainer] {
+		containers = append(containers, s.pickEntity(KindContainer, id).(Container))
+	}
+	return containers
+}
+
+// GetKubernetesPod returns the pod with the given UID
+func (s *Store) GetKubernetesPod(id string) (KubernetesPod, error) {
+	entity, err := s.getEntity(KindKubernetesPod, id)
+	if err != nil {
+		return KubernetesPod{}, err
+	}
+	return entity.(KubernetesPod), nil
+}
+
+// GetKubernetesPodForContainer returns the pod running the container with
+// the given ID
+func (s *Store) GetKubernetesPodForContainer(containerID string) (KubernetesPod, error) {
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	for id := range s.store[KindKubernetesPod] {
+		pod := s.pickEntity(KindKubernetesPod, id).(KubernetesPod)
+		for _, podContainerID := range pod.Containers {
+			if podContainerID == containerID {
+				return pod, nil
+			}
+		}
+	}
+	return KubernetesPod{}, errors.NewNotFound(string(KindKubernetesPod) + " for container " + containerID)
+}
+
+// GetPodMetaForContainer returns the namespace and labels of the pod running
+// the container with the given ID, to apply the container filters. When the
+// pod is not in the store, e.g. without kubelet collector, the namespace is
+// read from the labels set by the kubelet on the container, and there are no
+// pod labels. Both are empty for the containers that don't run in a pod.
+func (s *Store) GetPodMetaForContainer(containerID string, containerLabels map[string]string) (string, map[string]string) {
+	if pod, err := s.GetKubernetesPodForContainer(containerID); err == nil {
+		return pod.Namespace, pod.Labels
+	}
+	return containerLabels[kubernetesPodNamespaceLabel], nil
+}
+
+// GetECSTask returns the task with the given ARN
+func (s *Store) GetECSTask(id string) (ECSTask, error) {
+	entity, err := s.getEntity(KindECSTask, id)
+	if err != nil {
+		return ECSTask{}, err
+	}
+	return entity.(ECSTask), nil
+}
+
+// GetECSTaskForContainer returns the task running the container with the
+// given ID
+func (s *Store) GetECSTaskForContainer(containerID string) (ECSTask, error) {
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	for id := range s.store[KindECSTask] {
+		task := s.pickEntity(KindECSTask, id).(ECSTask)
+		for _, taskContainerID := range task.Containers {
+			if taskContainerID == containerID {
+				return task, nil
+			}
+		}
+	}
+	return ECSTask{}, errors.NewNotFound(string(KindECSTask) + " for container " + containerID)
+}
+
+// GetNomadAllocation returns the allocation with the given ID
+func (s *Store) GetNomadAllocation(id string) (NomadAllocation, error) {
+	entity, err := s.getEntity(KindNomadAlloc, id)
+	if err != nil {
+		return NomadAllocation{}, err
+	}
+	return entity.(NomadAllocation), nil
+}
+
+func (s *Store) getEntity(kind Kind, id string) (Entity, error) {
+	s.storeMut.RLock()
+	defer s.storeMut.RUnlock()
+
+	if _, ok := s.store[kind][id]; !ok {
+		return nil, errors.NewNotFound(string(kind) + " " + id)
+	}
+	return s.pickEntity(kind, id), nil
+}
+
+// pickEntity returns the entity of the first source, sorted by name, so that
+// the result does not depend on the map ordering. The store lock must be held.
+func (s *Store) pickEntity(kind Kind, id string) Entity {
+	entitiesBySource := s.store[kind][id]
+	sources := make([]string, 0, len(entitiesBySource))
+	for source := range entitiesBySource {
+		sources = append(sources, string(source))
+	}
+	sort.Strings(sources)
+	return entitiesBySource[Source(sources[0])]
+}
@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/errors"
+)
+
+type fakeCollector struct {
+	store *Store
+}
+
+func (c *fakeCollector) Start(ctx context.Context, store *Store) error {
+	c.store = store
+	return nil
+}
+
+func container(id string, source Source) CollectorEvent {
+	return CollectorEvent{
+		Type:   EventTypeSet,
+		Source: source,
+		Entity: Container{
+			EntityID:   EntityID{Kind: KindContainer, ID: id},
+			EntityMeta: EntityMeta{Name: id},
+		},
+	}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	collector := &fakeCollector{}
+	store := NewStore(CollectorCatalog{
+		"fake": func() Collector { return collector },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store.Start(ctx)
+	require.NotNil(t, collector.store)
+
+	// the snapshot of an empty store is empty
+	all := store.Subscribe("all", nil)
+	assert.Empty(t, <-all)
+
+	collector.store.Notify([]CollectorEvent{
+		container("foo", SourceDocker),
+		{
+			Type:   EventTypeSet,
+			Source: SourceKubelet,
+			Entity: KubernetesPod{EntityID: EntityID{Kind: KindKubernetesPod, ID: "pod"}},
+		},
+	})
+	events := <-all
+	require.Len(t, events, 2)
+	assert.Equal(t, EventTypeSet, events[0].Type)
+	assert.Equal(t, "foo", events[0].Entity.GetID().ID)
+
+	// the snapshot holds the existing entities matching the filter
+	containers := store.Subscribe("containers", &Filter{Kinds: []Kind{KindContainer}})
+	events = <-containers
+	require.Len(t, events, 1)
+	assert.Equal(t, "foo", events[0].Entity.GetID().ID)
+
+	c, err := store.GetContainer("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", c.Name)
+
+	// unsetting an entity of a source keeps the ones of the other sources
+	collector.store.Notify([]CollectorEvent{container("foo", SourceContainerd)})
+	<-all
+	<-containers
+	collector.store.Notify([]CollectorEvent{{
+		Type:   EventTypeUnset,
+		Source: SourceDocker,
+		Entity: Container{EntityID: EntityID{Kind: KindContainer, ID: "foo"}},
+	}})
+	<-all
+	events = <-containers
+	require.Len(t, events, 1)
+	assert.Equal(t, EventTypeUnset, events[0].Type)
+	assert.Equal(t, SourceDocker, events[0].Source)
+	_, err = store.GetContainer("foo")
+	assert.NoError(t, err)
+
+	collector.store.Notify([]CollectorEvent{{
+		Type:   EventTypeUnset,
+		Source: SourceContainerd,
+		Entity: Container{EntityID: EntityID{Kind: KindContainer, ID: "foo"}},
+	}})
+	<-all
+	<-containers
+	_, err = store.GetContainer("foo")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Empty(t, store.ListContainers())
+
+	store.Unsubscribe(containers)
+	_, ok := <-containers
+	assert.False(t, ok)
+}
+
+func TestStoreUnsubscribeSlowSubscriber(t *testing.T) {
+	collector := &fakeCollector{}
+	store := NewStore(CollectorCatalog{
+		"fake": func() Collector { return collector },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store.Start(ctx)
+
+	// a subscriber that stops reading blocks the store
+	slow := store.Subscribe("slow", nil)
+	collector.store.Notify([]CollectorEvent{container("foo", SourceDocker)})
+	collector.store.Notify([]CollectorEvent{container("bar", SourceDocker)})
+	// let the store block on the send
+	time.Sleep(100 * time.Millisecond)
+
+	// until it unsubscribes
+	done := make(chan struct{})
+	go func() {
+		store.Unsubscribe(slow)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "unsubscribing a slow subscriber blocked")
+	}
+
+	// the events are still processed once it is gone
+	all := store.Subscribe("all", nil)
+	<-all
+	collector.store.Notify([]CollectorEvent{container("baz", SourceDocker)})
+	for {
+		select {
+		case events := <-all:
+			if events[len(events)-1].Entity.GetID().ID == "baz" {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "the store is blocked")
+			return
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	ev := Event{Type: EventTypeSet, Source: SourceDocker, Entity: Container{EntityID: EntityID{Kind: KindContainer, ID: "foo"}}}
+
+	var nilFilter *Filter
+	assert.True(t, nilFilter.Match(ev))
+	assert.True(t, (&Filter{}).Match(ev))
+	assert.True(t, (&Filter{Kinds: []Kind{KindECSTask, KindContainer}}).Match(ev))
+	assert.False(t, (&Filter{Kinds: []Kind{KindECSTask}}).Match(ev))
+	assert.False(t, (&Filter{Kinds: []Kind{KindContainer}, Sources: []Source{SourceContainerd}}).Match(ev))
+}
+
+func TestStoreDumpLoad(t *testing.T) {
+	store := NewStore(nil)
+	store.handleEvents([]CollectorEvent{
+		{
+			Type:   EventTypeSet,
+			Source: SourceDocker,
+			Entity: Container{
+				EntityID:   EntityID{Kind: KindContainer, ID: "abc"},
+				EntityMeta: EntityMeta{Name: "abc"},
+				EnvVars:    map[string]string{"DD_SERVICE": "redis", "REDIS_PASSWORD": "secret"},
+			},
+		},
+		{
+			Type:   EventTypeSet,
+			Source: SourceKubelet,
+			Entity: KubernetesPod{
+				EntityID:   EntityID{Kind: KindKubernetesPod, ID: "pod-uid"},
+				EntityMeta: EntityMeta{Name: "redis", Namespace: "default"},
+				Phase:      "Running",
+				Containers: []string{"abc"},
+			},
+		},
+	})
+
+	dump, err := store.Dump()
+	require.NoError(t, err)
+	require.Len(t, dump.Entities, 2)
+
+	loaded := NewStore(nil)
+	require.NoError(t, loaded.Load(dump))
+
+	c, err := loaded.GetContainer("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", c.Name)
+	// only the values of the environment variables used for tagging are kept
+	assert.Equal(t, map[string]string{"DD_SERVICE": "redis", "REDIS_PASSWORD": "********"}, c.EnvVars)
+
+	// the store itself is not scrubbed
+	c, err = store.GetContainer("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", c.EnvVars["REDIS_PASSWORD"])
+
+	pod, err := loaded.GetKubernetesPod("pod-uid")
+	require.NoError(t, err)
+	assert.Equal(t, "Running", pod.Phase)
+	assert.Equal(t, []string{"abc"}, pod.Containers)
+
+	err = loaded.Load(Dump{Entities: []DumpEntity{{Kind: "unknown", Entity: []byte("{}")}}})
+	assert.Error(t, err)
+}
+
+func TestGetKubernetesPodForContainer(t *testing.T) {
+	store := NewStore(nil)
+	store.handleEvents([]CollectorEvent{
+		{
+			Type:   EventTypeSet,
+			Source: SourceKubelet,
+			Entity: KubernetesPod{
+				EntityID:   EntityID{Kind: KindKubernetesPod, ID: "pod-uid"},
+				EntityMeta: EntityMeta{Name: "redis", Namespace: "default"},
+				Containers: []string{"abc", "def"},
+			},
+		},
+	})
+
+	pod, err := store.GetKubernetesPodForContainer("def")
+	require.NoError(t, err)
+	assert.Equal(t, "pod-uid", pod.ID)
+
+	_, err = store.GetKubernetesPodForContainer("ghi")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestGetPodMetaForContainer(t *testing.T) {
+	store := NewStore(nil)
+	store.handleEvents([]CollectorEvent{
+		{
+			Type:   EventTypeSet,
+			Source: SourceKubelet,
+			Entity: KubernetesPod{
+				EntityID:   EntityID{Kind: KindKubernetesPod, ID: "pod-uid"},
+				EntityMeta: EntityMeta{Name: "redis", Namespace: "default", Labels: map[string]string{"team": "infra"}},
+				Containers: []string{"abc"},
+			},
+		},
+	})
+
+	namespace, labels := store.GetPodMetaForContainer("abc", map[string]string{kubernetesPodNamespaceLabel: "other"})
+	assert.Equal(t, "default", namespace)
+	assert.Equal(t, map[string]string{"team": "infra"}, labels)
+
+	// unknown pod: the namespace comes from the container labels
+	namespace, labels = store.GetPodMetaForContainer("def", map[string]string{kubernetesPodNamespaceLabel: "kube-system"})
+	assert.Equal(t, "kube-system", namespace)
+	assert.Nil(t, labels)
+
+	// not in a pod
+	namespace, labels = store.GetPodMetaForContainer("ghi", nil)
+	assert.Equal(t, "", namespace)
+	assert.Nil(t, labels)
+}
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package workloadmeta
+
+import (
+	"context"
+	"time"
+)
+
+// Kind is the kind of an entity
+type Kind string
+
+// List of the entity kinds
+const (
+	KindContainer     Kind = "container"
+	KindKubernetesPod Kind = "kubernetes_pod"
+	KindECSTask       Kind = "ecs_task"
+	KindNomadAlloc    Kind = "nomad_allocation"
+)
+
+// Source is the name of the collector an entity comes from
+type Source string
+
+// List of the sources
+const (
+	SourceDocker     Source = "docker"
+	SourceContainerd Source = "containerd"
+	SourceKubelet    Source = "kubelet"
+	SourceECS        Source = "ecs"
+	SourceNomad      Source = "nomad"
+)
+
+// ContainerRuntime is the runtime of a container
+type ContainerRuntime string
+
+// List of the container runtimes
+const (
+	ContainerRuntimeDocker     ContainerRuntime = "docker"
+	ContainerRuntimeContainerd ContainerRuntime = "containerd"
+	ContainerRuntimeCRIO       ContainerRuntime = "cri-o"
+)
+
+// EventType is the type of an event
+type EventType int
+
+const (
+	// EventTypeSet is sent when an entity is added or updated
+	EventTypeSet EventType = iota
+	// EventTypeUnset is sent when an entity is removed
+	EventTypeUnset
+)
+
+// EntityID identifies an entity in the store
+type EntityID struct {
+	Kind Kind
+	ID   string
+}
+
+// Entity is an entity of the store
+type Entity interface {
+	GetID() EntityID
+}
+
+// EntityMeta holds the metadata shared by the entities
+type EntityMeta struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// ContainerState is the state of a container
+type ContainerState struct {
+	Running   bool
+	StartedAt time.Time
+}
+
+// Container is a container, collected from its runtime
+type Container struct {
+	EntityID
+	EntityMeta
+	Image   string
+	EnvVars map[string]string
+	Runtime ContainerRuntime
+	State   ContainerState
+}
+
+// GetID implements Entity
+func (c Container) GetID() EntityID {
+	return c.EntityID
+}
+
+// KubernetesPod is a pod, collected from the kubelet
+type KubernetesPod struct {
+	EntityID
+	EntityMeta
+	Phase string
+	// Containers holds the IDs of the containers of the pod
+	Containers []string
+}
+
+// GetID implements Entity
+func (p KubernetesPod) GetID() EntityID {
+	return p.EntityID
+}
+
+// ECSTask is an ECS task, collected from the ECS agent
+type ECSTask struct {
+	EntityID
+	EntityMeta
+	Family        string
+	Version       string
+	DesiredStatus string
+	KnownStatus   string
+	// Containers holds the IDs of the containers of the task
+	Containers []string
+	// ContainerNames holds the names of the containers in the task
+	// definition, by container ID
+	ContainerNames map[string]string
+}
+
+// GetID implements Entity
+func (t ECSTask) GetID() EntityID {
+	return t.EntityID
+}
+
+// NomadAllocation is a Nomad allocation, collected from the local Nomad agent.
+// Its EntityMeta holds the namespace of the job.
+type NomadAllocation struct {
+	EntityID
+	EntityMeta
+	Job          string
+	Group        string
+	ClientStatus string
+	// Tasks holds the names of the tasks of the allocation
+	Tasks []string
+}
+
+// GetID implements Entity
+func (a NomadAllocation) GetID() EntityID {
+	return a.EntityID
+}
+
+// Event notifies the subscribers of a change of an entity. For Unset events
+// the Entity only holds its EntityID.
+type Event struct {
+	Type   EventType
+	Source Source
+	Entity Entity
+}
+
+// CollectorEvent is sent by the collectors to the store
+type CollectorEvent struct {
+	Type   EventType
+	Source Source
+	Entity Entity
+}
+
+// Filter selects the events sent to a subscriber. Empty fields match everything.
+type Filter struct {
+	Kinds   []Kind
+	Sources []Source
+}
+
+// Match returns whether the event passes the filter
+func (f *Filter) Match(ev Event) bool {
+	if f == nil {
+		return true
+	}
+	return f.matchKind(ev.Entity.GetID().Kind) && f.matchSource(ev.Source)
+}
+
+func (f *Filter) matchKind(kind Kind) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchSource(source Source) bool {
+	if len(f.Sources) == 0 {
+		return true
+	}
+	for _, s := range f.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Collector feeds the store with the entities of a source. Start must return
+// once the collector is running, it sends its events with Store.Notify until
+// the context is cancelled.
+type Collector interface {
+	Start(ctx context.Context, store *Store) error
+}
+
+// CollectorFactory instantiates a Collector
+type CollectorFactory func() Collector
+
+// CollectorCatalog holds the available collectors, by name
+type CollectorCatalog map[string]CollectorFactory
+
+// DefaultCatalog holds the collectors registered by the build
+var DefaultCatalog = make(CollectorCatalog)
+
+// RegisterCollector adds a collector to the DefaultCatalog, it is to be
+// called in the init function of the collectors
+func RegisterCollector(name string, factory CollectorFactory) {
+	DefaultCatalog[name] = factory
+}
@@ -200,6 +200,61 @@ DATADOG_AGENT_RTLOADER_API char *run_check(rtloader_t *, rtloader_pyobject_t *ch
 */
 DATADOG_AGENT_RTLOADER_API char **get_checks_warnings(rtloader_t *, rtloader_pyobject_t *check);
 
+/*! \fn unsigned long get_current_thread_id(rtloader_t *)
+    \brief Get the Python identifier of the current thread.
+    \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
+    \return The identifier of the thread, to pass to `interrupt_thread()`.
+    \sa rtloader_t
+*/
+DATADOG_AGENT_RTLOADER_API unsigned long get_current_thread_id(rtloader_t *);
+
+/*! \fn int interrupt_thread(rtloader_t *, unsigned long thread_id)
+    \brief Interrupts the Python code running in a thread, e.g. a stuck check run.
+    \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
+    \param thread_id The identifier of the thread returned by `get_current_thread_id()`.
+    \return An integer with the success of the operation, 0 if the thread wasn't found.
+    \sa rtloader_t
+
+    A KeyboardInterrupt exception is raised in the thread the next time it executes
+    Python code. The GIL must be held by the caller.
+*/
+DATADOG_AGENT_RTLOADER_API int interrupt_thread(rtloader_t *, unsigned long thread_id);
+
+/*! \fn int enable_memory_accounting(rtloader_t *)
+    \brief Accounts the memory allocated by Python to the checks running when it was allocated.
+    \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
+    \return An integer with the success of the operation, 0 if the runtime doesn't support
+    memory accounting (Python 2).
+    \sa rtloader_t
+
+    This function must be called before `init()`. Each allocation of the Python object and
+    memory allocators is prefixed with a header recording its size and its owner.
+*/
+DATADOG_AGENT_RTLOADER_API int enable_memory_accounting(rtloader_t *);
+
+/*! \fn int set_memory_owner(rtloader_t *, int owner)
+    \brief Sets the owner of the memory allocated by Python in the current thread.
+    \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
+    \param owner The owner, between 1 and `MEMORY_ACCOUNTING_OWNERS` - 1, 0 for none.
+    \return The previous owner of the current thread, to restore once done.
+    \sa rtloader_t
+
+    The owner applies to the calls made from the current thread, the caller must stay
+    on it until the previous owner is restored.
+*/
+DATADOG_AGENT_RTLOADER_API int set_memory_owner(rtloader_t *, int owner);
+
+/*! \fn long long get_memory_owner_bytes(rtloader_t *, int owner)
+    \brief Gets the bytes allocated by Python for an owner and not freed yet.
+    \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
+    \param owner The owner set with `set_memory_owner()`.
+    \return The bytes in use, -1 if memory accounting isn't enabled.
+    \sa rtloader_t
+
+    The GIL doesn't need to be held.
+*/
+DATADOG_AGENT_RTLOADER_API long long get_memory_owner_bytes(rtloader_t *, int owner);
+
 /*! \fn void rtloader_free(rtloader_t *, void *ptr)
     \brief Routine to free heap memory in RtLoader.
     \param rtloader_t A rtloader_t * pointer to the RtLoader instance.
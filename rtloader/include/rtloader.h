@@ -122,6 +122,45 @@ public:
     */
     virtual char **getCheckWarnings(RtLoaderPyObject *check) = 0;
 
+    //! Pure virtual getCurrentThreadId member.
+    /*!
+      \return The Python identifier of the current thread.
+    */
+    virtual unsigned long getCurrentThreadId() = 0;
+
+    //! Pure virtual interruptThread member.
+    /*!
+      \param thread_id The Python identifier of the thread to interrupt.
+      \return A boolean indicating if the thread was found.
+
+      Raises a KeyboardInterrupt exception in the thread, the GIL must be held.
+    */
+    virtual bool interruptThread(unsigned long thread_id) = 0;
+
+    //! Pure virtual enableMemoryAccounting member.
+    /*!
+      \return A boolean indicating if the runtime supports memory accounting.
+
+      Accounts the memory allocated by Python to the owner set on the thread, it
+      must be called before init().
+    */
+    virtual bool enableMemoryAccounting() = 0;
+
+    //! Pure virtual setMemoryOwner member.
+    /*!
+      \param owner The owner of the memory allocated by the current thread, 0 for none.
+      \return The previous owner of the current thread.
+    */
+    virtual int setMemoryOwner(int owner) = 0;
+
+    //! Pure virtual getMemoryOwnerBytes member.
+    /*!
+      \param owner The owner set with setMemoryOwner().
+      \return The bytes allocated for the owner and not freed yet, -1 if memory
+      accounting isn't enabled.
+    */
+    virtual long long getMemoryOwnerBytes(int owner) = 0;
+
     //! clearError member.
     /*!
       Clears any errors set on the RtLoader instance.
@@ -40,6 +40,9 @@ typedef enum {
     DATADOG_AGENT_RTLOADER_FREE,
 } rtloader_mem_ops_t;
 
+// the number of owners of the memory accounted with enable_memory_accounting
+#define MEMORY_ACCOUNTING_OWNERS 4096
+
 typedef void *(*rtloader_malloc_t)(size_t);
 typedef void (*rtloader_free_t)(void *);
 
@@ -303,6 +303,31 @@ char **get_checks_warnings(rtloader_t *rtloader, rtloader_pyobject_t *check)
     return AS_TYPE(RtLoader, rtloader)->getCheckWarnings(AS_TYPE(RtLoaderPyObject, check));
 }
 
+unsigned long get_current_thread_id(rtloader_t *rtloader)
+{
+    return AS_TYPE(RtLoader, rtloader)->getCurrentThreadId();
+}
+
+int interrupt_thread(rtloader_t *rtloader, unsigned long thread_id)
+{
+    return AS_TYPE(RtLoader, rtloader)->interruptThread(thread_id) ? 1 : 0;
+}
+
+int enable_memory_accounting(rtloader_t *rtloader)
+{
+    return AS_TYPE(RtLoader, rtloader)->enableMemoryAccounting() ? 1 : 0;
+}
+
+int set_memory_owner(rtloader_t *rtloader, int owner)
+{
+    return AS_TYPE(RtLoader, rtloader)->setMemoryOwner(owner);
+}
+
+long long get_memory_owner_bytes(rtloader_t *rtloader, int owner)
+{
+    return AS_TYPE(RtLoader, rtloader)->getMemoryOwnerBytes(owner);
+}
+
 /*
  * error API
  */
@@ -19,8 +19,114 @@
 #include "util.h"
 
 #include <algorithm>
+#include <atomic>
 #include <sstream>
 
+namespace
+{
+// Memory accounting: the allocations of the memory and object domains, the ones
+// made with the GIL held, are prefixed with a header recording their size and
+// their owner, the check running on the thread when they were made. The raw
+// domain isn't accounted, the large allocations of the object allocator go
+// through it and would be counted twice.
+struct mem_header_t {
+    size_t size;
+    int owner;
+};
+
+// the size of the header keeps the alignment of the allocations
+const size_t MEM_HEADER_SIZE = 16;
+static_assert(sizeof(mem_header_t) <= MEM_HEADER_SIZE, "the memory header doesn't fit");
+
+bool memoryAccounting = false;
+PyMemAllocatorEx baseMemAllocator;
+PyMemAllocatorEx baseObjAllocator;
+std::atomic<long long> ownerBytes[MEMORY_ACCOUNTING_OWNERS];
+thread_local int currentOwner = 0;
+
+void *toUser(mem_header_t *header)
+{
+    return reinterpret_cast<char *>(header) + MEM_HEADER_SIZE;
+}
+
+mem_header_t *toHeader(void *ptr)
+{
+    return reinterpret_cast<mem_header_t *>(static_cast<char *>(ptr) - MEM_HEADER_SIZE);
+}
+
+void *accountedMalloc(void *ctx, size_t size)
+{
+    PyMemAllocatorEx *base = static_cast<PyMemAllocatorEx *>(ctx);
+    if (size > PY_SSIZE_T_MAX - MEM_HEADER_SIZE) {
+        return NULL;
+    }
+    mem_header_t *header = static_cast<mem_header_t *>(base->malloc(base->ctx, size + MEM_HEADER_SIZE));
+    if (header == NULL) {
+        return NULL;
+    }
+    header->size = size;
+    header->owner = currentOwner;
+    ownerBytes[header->owner] += size;
+    return toUser(header);
+}
+
+void *accountedCalloc(void *ctx, size_t nelem, size_t elsize)
+{
+    PyMemAllocatorEx *base = static_cast<PyMemAllocatorEx *>(ctx);
+    if (elsize != 0 && nelem > (PY_SSIZE_T_MAX - MEM_HEADER_SIZE) / elsize) {
+        return NULL;
+    }
+    size_t size = nelem * elsize;
+    mem_header_t *header = static_cast<mem_header_t *>(base->calloc(base->ctx, 1, size + MEM_HEADER_SIZE));
+    if (header == NULL) {
+        return NULL;
+    }
+    header->size = size;
+    header->owner = currentOwner;
+    ownerBytes[header->owner] += size;
+    return toUser(header);
+}
+
+void *accountedRealloc(void *ctx, void *ptr, size_t size)
+{
+    if (ptr == NULL) {
+        return accountedMalloc(ctx, size);
+    }
+    PyMemAllocatorEx *base = static_cast<PyMemAllocatorEx *>(ctx);
+    if (size > PY_SSIZE_T_MAX - MEM_HEADER_SIZE) {
+        return NULL;
+    }
+    mem_header_t *header = toHeader(ptr);
+    size_t oldSize = header->size;
+    header = static_cast<mem_header_t *>(base->realloc(base->ctx, header, size + MEM_HEADER_SIZE));
+    if (header == NULL) {
+        return NULL;
+    }
+    // the memory stays accounted to the owner of the original allocation
+    header->size = size;
+    ownerBytes[header->owner] += static_cast<long long>(size) - static_cast<long long>(oldSize);
+    return toUser(header);
+}
+
+void accountedFree(void *ctx, void *ptr)
+{
+    if (ptr == NULL) {
+        return;
+    }
+    PyMemAllocatorEx *base = static_cast<PyMemAllocatorEx *>(ctx);
+    mem_header_t *header = toHeader(ptr);
+    ownerBytes[header->owner] -= header->size;
+    base->free(base->ctx, header);
+}
+
+void setAccountedAllocator(PyMemAllocatorDomain domain, PyMemAllocatorEx *base)
+{
+    PyMem_GetAllocator(domain, base);
+    PyMemAllocatorEx allocator = { base, accountedMalloc, accountedCalloc, accountedRealloc, accountedFree };
+    PyMem_SetAllocator(domain, &allocator);
+}
+} // namespace
+
 extern "C" DATADOG_AGENT_RTLOADER_API RtLoader *create(const char *pythonHome, cb_memory_tracker_t memtrack_cb)
 {
     return new Three(pythonHome, memtrack_cb);
@@ -489,6 +595,53 @@ done:
     return warnings;
 }
 
+bool Three::enableMemoryAccounting()
+{
+    // the allocators can only be replaced before any allocation is made with them
+    if (Py_IsInitialized()) {
+        return false;
+    }
+    if (!memoryAccounting) {
+        setAccountedAllocator(PYMEM_DOMAIN_MEM, &baseMemAllocator);
+        setAccountedAllocator(PYMEM_DOMAIN_OBJ, &baseObjAllocator);
+        memoryAccounting = true;
+    }
+    return true;
+}
+
+int Three::setMemoryOwner(int owner)
+{
+    int previous = currentOwner;
+    if (owner < 0 || owner >= MEMORY_ACCOUNTING_OWNERS) {
+        owner = 0;
+    }
+    currentOwner = owner;
+    return previous;
+}
+
+long long Three::getMemoryOwnerBytes(int owner)
+{
+    if (!memoryAccounting) {
+        return -1;
+    }
+    if (owner < 0 || owner >= MEMORY_ACCOUNTING_OWNERS) {
+        return 0;
+    }
+    return ownerBytes[owner].load();
+}
+
+unsigned long Three::getCurrentThreadId()
+{
+    return PyThread_get_thread_ident();
+}
+
+bool Three::interruptThread(unsigned long thread_id)
+{
+    // the exception is raised the next time the thread executes Python code,
+    // a call blocked in C code is not interrupted
+    return PyThreadState_SetAsyncExc(static_cast<unsigned long>(thread_id), PyExc_KeyboardInterrupt) == 1;
+}
+
 // return new reference
 PyObject *Three::_importFrom(const char *module, const char *name)
 {
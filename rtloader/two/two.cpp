@@ -486,6 +486,34 @@ done:
     return warnings;
 }
 
+unsigned long Two::getCurrentThreadId()
+{
+    return PyThread_get_thread_ident();
+}
+
+bool Two::interruptThread(unsigned long thread_id)
+{
+    // the exception is raised the next time the thread executes Python code,
+    // a call blocked in C code is not interrupted
+    return PyThreadState_SetAsyncExc(static_cast<long>(thread_id), PyExc_KeyboardInterrupt) == 1;
+}
+
+bool Two::enableMemoryAccounting()
+{
+    // Python 2 can't replace its allocators
+    return false;
+}
+
+int Two::setMemoryOwner(int owner)
+{
+    return 0;
+}
+
+long long Two::getMemoryOwnerBytes(int owner)
+{
+    return -1;
+}
+
 // return new reference
 PyObject *Two::_importFrom(const char *module, const char *name)
 {
@@ -67,6 +67,11 @@ public:
 
     char *runCheck(RtLoaderPyObject *check);
     char **getCheckWarnings(RtLoaderPyObject *check);
+    unsigned long getCurrentThreadId();
+    bool interruptThread(unsigned long thread_id);
+    bool enableMemoryAccounting();
+    int setMemoryOwner(int owner);
+    long long getMemoryOwnerBytes(int owner);
     void decref(RtLoaderPyObject *obj);
     void incref(RtLoaderPyObject *obj);
     void setModuleAttrString(char *module, char *attr, char *value);
@@ -61,8 +61,10 @@ AGENT_CORECHECKS = [
     "load",
     "memory",
     "ntp",
+    "oom_kill",
     "systemd",
     "tcp_queue_length",
+    "tcp_stats",
     "uptime",
     "winproc",
 ]
@@ -301,15 +301,26 @@ def build_object_files(ctx, install=True):
         # Now update the assets stored in the go code
         commands.append("go get -u github.com/jteeuwen/go-bindata/...")
 
-        assets_cmd = os.environ["GOPATH"]+"/bin/go-bindata -pkg ebpf -prefix '{c_dir}' -modtime 1 -o '{go_file}' '{obj_file}' '{debug_obj_file}' '{tcp_queue_length_kern_c_file}' '{tcp_queue_length_kern_user_h_file}'"
+        # The sources of the programs compiled at runtime with bcc are embedded as well
+        bcc_files = [
+            os.path.join(c_dir, f) for f in [
+                "tcp-queue-length-kern.c",
+                "tcp-queue-length-kern-user.h",
+                "oom-kill-kern.c",
+                "oom-kill-kern-user.h",
+                "tcp-stats-kern.c",
+                "tcp-stats-kern-user.h",
+            ]
+        ]
+
+        assets_cmd = os.environ["GOPATH"]+"/bin/go-bindata -pkg ebpf -prefix '{c_dir}' -modtime 1 -o '{go_file}' '{obj_file}' '{debug_obj_file}' {bcc_files}"
         go_file = os.path.join(bpf_dir, "tracer-ebpf.go")
         commands.append(assets_cmd.format(
             c_dir=c_dir,
             go_file=go_file,
             obj_file=obj_file,
             debug_obj_file=debug_obj_file,
-            tcp_queue_length_kern_c_file=os.path.join(c_dir, "tcp-queue-length-kern.c"),
-            tcp_queue_length_kern_user_h_file=os.path.join(c_dir, "tcp-queue-length-kern-user.h"),
+            bcc_files=" ".join("'{}'".format(f) for f in bcc_files),
         ))
 
         commands.append("gofmt -w -s {go_file}".format(go_file=go_file))
@@ -176,7 +176,7 @@ def integration_freeze
 end
 
 def json_info
-  info_output = `#{agent_command} status -j 2>&1`
+  info_output = `#{agent_command} status --raw-json 2>&1`
   info_output = info_output.gsub("Getting the status from the agent.", "")
 
   # removes any stray log lines